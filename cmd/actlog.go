@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"time"
@@ -12,15 +11,51 @@ import (
 )
 
 func actlogCmd() *cobra.Command {
+	var since, tool, status string
+	var types []string
+	var count int
+
 	cmd := &cobra.Command{
 		Use:     "log",
 		Aliases: []string{"activity", "logs"},
 		Short:   "Unified activity log — track AI tool actions across sessions",
+		Long: `Show the activity log: installs, tool runs, compose steps, graph changes,
+proxy starts and stops, and more. Entries are hash-chained; run
+` + "`palm log verify`" + ` to check that none were edited or removed.`,
+		Example: `  palm log --since 24h --type install
+  palm log --type graph --json
+  palm log --tool aider --status failed`,
 		Run: func(cmd *cobra.Command, args []string) {
-			ui.Banner("activity log")
+			filter := activity.Filter{Types: types, Tool: tool, Status: status}
+			if since != "" {
+				t, err := parseSince(since)
+				if err != nil {
+					ui.Bad.Printf("  %v\n", err)
+					os.Exit(1)
+				}
+				filter.Since = t
+			}
 
-			entries, err := activity.Read(20)
-			if err != nil || len(entries) == 0 {
+			entries, err := activity.Query(filter, count)
+			if err != nil {
+				ui.Bad.Printf("  Failed to read activity log: %v\n", err)
+				os.Exit(1)
+			}
+
+			if jsonOutput {
+				if entries == nil {
+					entries = []activity.Entry{}
+				}
+				printJSON(entries)
+				return
+			}
+
+			ui.Banner("activity log")
+			if len(entries) == 0 {
+				if since != "" || len(types) > 0 || tool != "" || status != "" {
+					fmt.Println("  No entries match the filters.")
+					return
+				}
 				fmt.Println("  No activity recorded yet.")
 				fmt.Println("  Activity is logged when using `palm run <tool>`")
 				return
@@ -37,23 +72,59 @@ func actlogCmd() *cobra.Command {
 				if e.Duration > 0 {
 					durStr = formatDuration(time.Duration(e.Duration * float64(time.Second)))
 				}
-				rows = append(rows, []string{timeStr, e.Action, e.Tool, durStr, costStr, truncateLog(e.Details, 30)})
+				statusStr := "-"
+				if e.Status != "" {
+					statusStr = ui.StatusIcon(e.Status == "ok")
+				}
+				rows = append(rows, []string{timeStr, e.Action, e.Tool, statusStr, durStr, costStr, truncateLog(e.Details, 30)})
 			}
-			ui.Table([]string{"Time", "Action", "Tool", "Duration", "Cost", "Details"}, rows)
+			ui.Table([]string{"Time", "Action", "Tool", "Status", "Duration", "Cost", "Details"}, rows)
 			fmt.Printf("\n  Showing %d most recent entries\n", len(entries))
 		},
 	}
 
+	cmd.Flags().StringVar(&since, "since", "", "Only entries in this window (e.g. 24h, 7d, 2w)")
+	cmd.Flags().StringSliceVarP(&types, "type", "t", nil, "Only these actions (install, run, compose, graph, proxy, ...)")
+	cmd.Flags().StringVar(&tool, "tool", "", "Only entries whose tool contains this")
+	cmd.Flags().StringVar(&status, "status", "", "Only entries with this status (ok, failed)")
+	cmd.Flags().IntVarP(&count, "count", "n", 20, "Number of entries to show (0 for all)")
+
 	cmd.AddCommand(
 		actlogSearchCmd(),
 		actlogClearCmd(),
 		actlogExportCmd(),
 		actlogStatsCmd(),
+		actlogVerifyCmd(),
 	)
 
 	return cmd
 }
 
+func actlogVerifyCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "verify",
+		Short: "Check the activity log's hash chain for tampering",
+		Run: func(cmd *cobra.Command, args []string) {
+			ui.Banner("activity log integrity")
+
+			res, err := activity.Verify()
+			if err != nil {
+				ui.Bad.Printf("  Failed to read activity log: %v\n", err)
+				os.Exit(1)
+			}
+			if !res.OK() {
+				ui.Bad.Printf("  %s Line %d: %s\n", ui.StatusIcon(false), res.Line, res.Problem)
+				fmt.Printf("  %d entries verified before the break\n", res.Entries)
+				os.Exit(1)
+			}
+			ui.Good.Printf("  %s %d entries verified, chain intact\n", ui.StatusIcon(true), res.Entries)
+			if res.Legacy > 0 {
+				fmt.Printf("  %s\n", ui.Subtle.Sprintf("%d older entries predate hashing and are not covered", res.Legacy))
+			}
+		},
+	}
+}
+
 func actlogSearchCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "search <query>",
@@ -106,8 +177,7 @@ func actlogExportCmd() *cobra.Command {
 				ui.Bad.Printf("  %v\n", err)
 				os.Exit(1)
 			}
-			data, _ := json.MarshalIndent(entries, "", "  ")
-			fmt.Println(string(data))
+			printJSON(entries)
 		},
 	}
 }
@@ -4,156 +4,218 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
+	"github.com/msalah0e/palm/internal/audit"
 	"github.com/msalah0e/palm/internal/ui"
 	"github.com/spf13/cobra"
 )
 
-type auditIssue struct {
-	File     string
-	Line     int
-	Severity string // "error", "warning", "info"
-	Message  string
+// auditCheckLabels titles each check in the report.
+var auditCheckLabels = map[string]string{
+	audit.CheckUnknownAPI:     "APIs that don't exist",
+	audit.CheckSwallowedError: "Swallowed errors",
+	audit.CheckDeadCode:       "Dead code",
+	audit.CheckDuplicate:      "Duplicated blocks",
+	audit.CheckNaming:         "Inconsistent naming",
+	audit.CheckSecret:         "Secrets",
+	audit.CheckPlaceholder:    "Placeholders",
+	audit.CheckDebug:          "Debug output",
+	audit.CheckStyle:          "Style",
 }
 
 func auditCmd() *cobra.Command {
-	var fix bool
+	var (
+		fix      bool
+		all      bool
+		since    string
+		minScore int
+	)
 
 	cmd := &cobra.Command{
 		Use:   "audit [file|dir]",
 		Short: "AI code quality gate — detect common AI-generated code issues",
-		Args:  cobra.MaximumNArgs(1),
+		Long: `Audit code for the smells AI assistants tend to leave behind: calls to
+functions a package doesn't have, swallowed errors, dead and unreachable
+code, duplicated blocks, naming out of step with the rest of the file,
+placeholders, debug output and hardcoded secrets.
+
+By default only the lines changed since the snapshot taken by
+` + "`palm shield pre`" + ` are audited, or since HEAD without one. --since picks
+another base; a file or directory argument, or --all, audits every line.
+
+The report scores the findings from 0 to 100 per 100 lines audited;
+--min-score makes a low score fail, for CI.`,
+		Args: cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			target := "."
-			if len(args) > 0 {
-				target = args[0]
-			}
-
-			ui.Banner("code audit")
-
-			info, err := os.Stat(target)
-			if err != nil {
-				ui.Bad.Printf("  %v\n", err)
-				os.Exit(1)
-			}
-
-			var issues []auditIssue
-			if info.IsDir() {
-				filepath.Walk(target, func(path string, fi os.FileInfo, err error) error {
-					if err != nil || fi.IsDir() {
-						return nil
-					}
-					if fi.Size() > 512*1024 { // Skip >512KB
-						return nil
-					}
-					ext := strings.ToLower(filepath.Ext(path))
-					if ext == ".go" || ext == ".py" || ext == ".js" || ext == ".ts" || ext == ".tsx" {
-						fileIssues := auditFile(path)
-						issues = append(issues, fileIssues...)
-					}
-					return nil
-				})
-			} else {
-				issues = auditFile(target)
-			}
-
-			if len(issues) == 0 {
-				ui.Good.Printf("  %s No issues found\n", ui.StatusIcon(true))
-				return
-			}
-
-			errors, warnings, infos := 0, 0, 0
-			for _, issue := range issues {
-				icon := ui.StatusIcon(false)
-				switch issue.Severity {
-				case "warning":
-					icon = ui.WarnIcon()
-					warnings++
-				case "info":
-					icon = ui.Info.Sprint("i")
-					infos++
-				default:
-					errors++
+			var (
+				sources []audit.Source
+				base    string
+				root    = "."
+			)
+			_, gitErr := gitOutput("rev-parse", "--show-toplevel")
+			switch {
+			case len(args) > 0 || all || gitErr != nil:
+				target := "."
+				if len(args) > 0 {
+					target = args[0]
+				}
+				var err error
+				if sources, err = auditTreeSources(target); err != nil {
+					ui.Bad.Printf("  %v\n", err)
+					os.Exit(1)
+				}
+			default:
+				var err error
+				if root, base, sources, err = auditDiffSources(since); err != nil {
+					ui.Bad.Printf("  %v\n", err)
+					os.Exit(1)
 				}
-				fmt.Printf("  %s %s:%d — %s\n", icon, issue.File, issue.Line, issue.Message)
 			}
 
-			fmt.Println()
-			fmt.Printf("  %d errors, %d warnings, %d info\n", errors, warnings, infos)
+			report := audit.Analyze(sources, audit.Options{Root: root})
+			report.Base = base
+			render(report, func() { printAuditReport(report) })
 
-			if fix {
+			if fix && !jsonOutput {
 				fmt.Println()
 				ui.Info.Println("  Auto-fix is not yet available. Review issues manually.")
 			}
+			if cmd.Flags().Changed("min-score") && report.Score < minScore {
+				ui.Bad.Fprintf(os.Stderr, "  Score %d is below --min-score %d\n", report.Score, minScore)
+				os.Exit(1)
+			}
 		},
 	}
 
 	cmd.Flags().BoolVar(&fix, "fix", false, "Attempt to auto-fix issues (coming soon)")
+	cmd.Flags().BoolVar(&all, "all", false, "Audit every line, not just the changed ones")
+	cmd.Flags().StringVar(&since, "since", "", "Audit the changes since this commit (default: the shield snapshot, or HEAD)")
+	cmd.Flags().IntVar(&minScore, "min-score", 0, "Exit with an error when the score is below this")
 	return cmd
 }
 
-func auditFile(path string) []auditIssue {
-	data, err := os.ReadFile(path)
+// auditTreeSources lists every auditable file under target, all lines.
+func auditTreeSources(target string) ([]audit.Source, error) {
+	info, err := os.Stat(target)
 	if err != nil {
-		return nil
+		return nil, err
 	}
-
-	content := string(data)
-	lines := strings.Split(content, "\n")
-	var issues []auditIssue
-
-	ext := strings.ToLower(filepath.Ext(path))
-	relPath, _ := filepath.Rel(".", path)
-
-	for i, line := range lines {
-		lineNum := i + 1
-		trimmed := strings.TrimSpace(line)
-
-		// Common AI code smells
-		if strings.Contains(trimmed, "TODO: implement") || strings.Contains(trimmed, "TODO: add") {
-			issues = append(issues, auditIssue{relPath, lineNum, "warning", "Placeholder TODO — likely unimplemented AI suggestion"})
-		}
-		if strings.Contains(trimmed, "// This function") && strings.Contains(trimmed, "...") {
-			issues = append(issues, auditIssue{relPath, lineNum, "warning", "Truncated AI comment"})
+	if !info.IsDir() {
+		return []audit.Source{{Path: target}}, nil
+	}
+	var sources []audit.Source
+	err = filepath.WalkDir(target, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
 		}
-		if strings.Contains(trimmed, "pass  #") || trimmed == "pass" {
-			if ext == ".py" {
-				issues = append(issues, auditIssue{relPath, lineNum, "info", "Empty pass statement — may be AI placeholder"})
+		if d.IsDir() {
+			if name := d.Name(); path != target && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor") {
+				return filepath.SkipDir
 			}
+			return nil
 		}
-		if strings.Contains(trimmed, "console.log(") && (ext == ".ts" || ext == ".tsx" || ext == ".js") {
-			issues = append(issues, auditIssue{relPath, lineNum, "info", "Debug console.log left in code"})
-		}
-		if strings.Contains(trimmed, "fmt.Println(\"debug") || strings.Contains(trimmed, "fmt.Println(\"DEBUG") {
-			issues = append(issues, auditIssue{relPath, lineNum, "info", "Debug print statement"})
+		if audit.Extensions[strings.ToLower(filepath.Ext(path))] {
+			sources = append(sources, audit.Source{Path: path})
 		}
+		return nil
+	})
+	return sources, err
+}
 
-		// Security checks
-		if strings.Contains(line, "password") && strings.Contains(line, "=") && strings.Contains(line, "\"") {
-			if !strings.Contains(trimmed, "//") && !strings.Contains(trimmed, "#") && !strings.HasPrefix(trimmed, "*") {
-				issues = append(issues, auditIssue{relPath, lineNum, "error", "Possible hardcoded password"})
-			}
-		}
-		if strings.Contains(line, "api_key") && strings.Contains(line, "\"sk-") {
-			issues = append(issues, auditIssue{relPath, lineNum, "error", "Possible hardcoded API key"})
+// auditDiffSources returns the files changed since base, with their
+// changed lines, relative to the repository root. The working tree is
+// snapshotted so uncommitted and untracked files count.
+func auditDiffSources(since string) (root, base string, sources []audit.Source, err error) {
+	root, err = gitOutput("rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", "", nil, fmt.Errorf("palm audit needs a git repository, or a file or directory to audit")
+	}
+
+	var from string
+	switch {
+	case since != "":
+		if from, err = gitOutput("rev-parse", "--verify", since+"^{tree}"); err != nil {
+			return "", "", nil, fmt.Errorf("unknown commit %q", since)
 		}
-		if strings.Contains(line, "secret") && strings.Contains(line, "=") && len(line) > 50 {
-			if !strings.Contains(trimmed, "//") && !strings.Contains(trimmed, "#") && !strings.HasPrefix(trimmed, "*") && !strings.HasPrefix(trimmed, "os.") && !strings.HasPrefix(trimmed, "env") {
-				issues = append(issues, auditIssue{relPath, lineNum, "warning", "Possible hardcoded secret"})
-			}
+		base = since
+	default:
+		if snap, serr := loadShieldSnapshot(); serr == nil {
+			from, base = snap.Tree, "the shield snapshot of "+snap.TakenAt.Format("2006-01-02 15:04")
+		} else if from, err = gitOutput("rev-parse", "HEAD^{tree}"); err == nil {
+			base = "HEAD"
+		} else {
+			return "", "", nil, fmt.Errorf("no commits to compare with — pass a file or directory, or --all")
 		}
+	}
 
-		// Unused imports / dead code patterns
-		if ext == ".go" && strings.HasPrefix(trimmed, "_ = ") {
-			issues = append(issues, auditIssue{relPath, lineNum, "info", "Blank identifier assignment — possibly suppressing unused error"})
-		}
+	current, err := snapshotWorkTree(root)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to snapshot the working tree: %v", err)
+	}
+	patch, err := gitOutput("-C", root, "diff", "--no-color", "--no-ext-diff", "-M", "-U0", from, current)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("git diff: %v", err)
+	}
+	changed := audit.ChangedLines(patch)
+	paths := make([]string, 0, len(changed))
+	for p := range changed {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		sources = append(sources, audit.Source{Path: p, Changed: changed[p]})
+	}
+	return root, base, sources, nil
+}
+
+func printAuditReport(r *audit.Report) {
+	ui.Banner("code audit")
+	scope := fmt.Sprintf("%d file(s) · %d line(s)", r.Files, r.Lines)
+	if r.Base != "" {
+		scope = fmt.Sprintf("Changes since %s · %d file(s) · %d changed line(s)", r.Base, r.Files, r.Lines)
+	}
+	ui.Subtle.Printf("  %s\n\n", scope)
+
+	if r.Files == 0 {
+		fmt.Println("  Nothing to audit.")
+		return
+	}
+	if len(r.Findings) == 0 {
+		ui.Good.Printf("  %s No issues found\n", ui.StatusIcon(true))
+		return
+	}
 
-		// Overly long lines (common in AI output)
-		if len(line) > 200 {
-			issues = append(issues, auditIssue{relPath, lineNum, "info", fmt.Sprintf("Very long line (%d chars) — consider breaking up", len(line))})
+	for _, check := range audit.Checks {
+		if r.Counts[check] == 0 {
+			continue
+		}
+		fmt.Printf("  %s %s\n", ui.Brand.Sprint(auditCheckLabels[check]), ui.Subtle.Sprintf("(%d)", r.Counts[check]))
+		for _, f := range r.Findings {
+			if f.Check != check {
+				continue
+			}
+			icon := ui.StatusIcon(false)
+			switch f.Severity {
+			case audit.SeverityWarning:
+				icon = ui.WarnIcon()
+			case audit.SeverityInfo:
+				icon = ui.Info.Sprint("i")
+			}
+			fmt.Printf("    %s %s:%d — %s\n", icon, f.File, f.Line, f.Message)
 		}
+		fmt.Println()
 	}
 
-	return issues
+	score := ui.Good
+	switch {
+	case r.Score < 70:
+		score = ui.Bad
+	case r.Score < 90:
+		score = ui.Warn
+	}
+	fmt.Printf("  Score %s · %d errors, %d warnings, %d info\n",
+		score.Sprintf("%d/100 (%s)", r.Score, r.Grade),
+		r.Count(audit.SeverityError), r.Count(audit.SeverityWarning), r.Count(audit.SeverityInfo))
 }
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/msalah0e/palm/internal/budget"
 	"github.com/msalah0e/palm/internal/ui"
@@ -20,6 +22,7 @@ func budgetCmd() *cobra.Command {
 		budgetStatusCmd(),
 		budgetSetCmd(),
 		budgetResetCmd(),
+		budgetAlertCmd(),
 	)
 
 	return cmd
@@ -29,18 +32,48 @@ func budgetStatusCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "status",
 		Short: "Show current budget status",
+		Long: `Show this month's spend against the budget limits, and a forecast: the
+daily burn rate over the last week and the month-end spend it leads to,
+per provider, from proxy cost estimates and billing imported with
+palm cost import (billed days replace the estimates).`,
 		Run: func(cmd *cobra.Command, args []string) {
-			ui.Banner("budget status")
-
 			status, err := budget.GetStatus()
 			if err != nil {
 				ui.Bad.Printf("  Failed to check budget: %v\n", err)
 				os.Exit(1)
 			}
+			forecast := spendForecast(status.MonthlyLimit)
+
+			if jsonOutput {
+				var alerts []string
+				events, _ := budget.CheckAlerts()
+				for _, ev := range events {
+					alerts = append(alerts, ev.Text)
+				}
+				printJSON(map[string]any{
+					"month":         status.CurrentMonth,
+					"monthly_limit": status.MonthlyLimit,
+					"monthly_spend": status.MonthlySpend,
+					"daily_limit":   status.DailyLimit,
+					"daily_spend":   status.DailySpend,
+					"percent_used":  status.PercentUsed,
+					"over_budget":   status.IsOverBudget,
+					"near_budget":   status.IsNearBudget,
+					"by_tool":       status.ByTool,
+					"by_provider":   status.ByProvider,
+					"total_tokens":  status.TotalTokens,
+					"alerts_fired":  alerts,
+					"forecast":      forecast,
+				})
+				return
+			}
+
+			ui.Banner("budget status")
 
 			if status.MonthlyLimit == 0 && status.DailyLimit == 0 {
 				fmt.Println("  No budget limits configured.")
 				fmt.Println("  Set one: palm budget set --monthly 50")
+				printForecast(forecast)
 				return
 			}
 
@@ -85,6 +118,17 @@ func budgetStatusCmd() *cobra.Command {
 			if status.TotalTokens > 0 {
 				fmt.Printf("\n  Total tokens: %d\n", status.TotalTokens)
 			}
+			printForecast(forecast)
+
+			if events, err := budget.CheckAlerts(); len(events) > 0 || err != nil {
+				fmt.Println()
+				for _, ev := range events {
+					ui.Warn.Printf("  %s Alert fired: %s\n", ui.WarnIcon(), ev.Text)
+				}
+				if err != nil {
+					ui.Bad.Printf("  Alert delivery failed: %v\n", err)
+				}
+			}
 		},
 	}
 }
@@ -146,6 +190,7 @@ func budgetResetCmd() *cobra.Command {
 			b := &budget.Budget{
 				AlertAt: 0.8,
 				PerTool: make(map[string]float64),
+				Alerts:  budget.Load().Alerts,
 			}
 			if err := budget.Save(b); err != nil {
 				ui.Bad.Printf("  Failed to reset budget: %v\n", err)
@@ -156,6 +201,176 @@ func budgetResetCmd() *cobra.Command {
 	}
 }
 
+func budgetAlertCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "alert",
+		Short: "Configure actions fired when spending crosses a threshold",
+		Long: `Configure actions fired when spending crosses a percentage of the
+monthly or daily limit. Each threshold fires once per budget period.
+
+Webhooks receive a JSON POST with scope, threshold, percent, spend, limit and
+text fields; the text field makes Slack incoming webhooks work as-is. Commands
+run via sh with PALM_BUDGET_* variables set.
+
+Examples:
+  palm budget alert add --at 80% --notify
+  palm budget alert add --at 100% --webhook https://hooks.slack.com/services/...
+  palm budget alert add --at 90% --command 'say "budget almost spent"'
+  palm budget alert list
+  palm budget alert test 1
+  palm budget alert remove 1`,
+	}
+
+	cmd.AddCommand(
+		budgetAlertAddCmd(),
+		budgetAlertListCmd(),
+		budgetAlertRemoveCmd(),
+		budgetAlertTestCmd(),
+	)
+	return cmd
+}
+
+func budgetAlertAddCmd() *cobra.Command {
+	var at, webhook, command string
+	var notify bool
+
+	cmd := &cobra.Command{
+		Use:   "add",
+		Short: "Add a threshold alert",
+		Run: func(cmd *cobra.Command, args []string) {
+			pct, err := budget.ParsePercent(at)
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+			if !notify && webhook == "" && command == "" {
+				ui.Bad.Println("  Specify at least one action: --notify, --webhook or --command")
+				os.Exit(1)
+			}
+			if webhook != "" && !strings.HasPrefix(webhook, "http://") && !strings.HasPrefix(webhook, "https://") {
+				ui.Bad.Printf("  Invalid webhook URL: %s\n", webhook)
+				os.Exit(1)
+			}
+
+			a := budget.Alert{At: pct, Notify: notify, Webhook: webhook, Command: command}
+			b := budget.Load()
+			b.Alerts = append(b.Alerts, a)
+			if err := budget.Save(b); err != nil {
+				ui.Bad.Printf("  Failed to save budget: %v\n", err)
+				os.Exit(1)
+			}
+			ui.Good.Printf("  %s Alert at %g%%: %s\n", ui.StatusIcon(true), pct, a.Describe())
+			if b.MonthlyLimit == 0 && b.DailyLimit == 0 {
+				ui.Subtle.Println("  No limits set yet — alerts fire relative to: palm budget set --monthly 50")
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&at, "at", "80%", "Threshold as a percentage of the limit")
+	cmd.Flags().BoolVar(&notify, "notify", false, "Show a native desktop notification")
+	cmd.Flags().StringVar(&webhook, "webhook", "", "POST a JSON payload to this URL")
+	cmd.Flags().StringVar(&command, "command", "", "Run a shell command")
+	return cmd
+}
+
+func budgetAlertListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List configured alerts",
+		Run: func(cmd *cobra.Command, args []string) {
+			ui.Banner("budget alerts")
+
+			b := budget.Load()
+			if len(b.Alerts) == 0 {
+				fmt.Println("  No alerts configured.")
+				fmt.Println("  Add one: palm budget alert add --at 80% --notify")
+				return
+			}
+
+			var rows [][]string
+			for i, a := range b.Alerts {
+				rows = append(rows, []string{strconv.Itoa(i + 1), fmt.Sprintf("%g%%", a.At), a.Describe()})
+			}
+			ui.Table([]string{"#", "At", "Actions"}, rows)
+		},
+	}
+}
+
+func budgetAlertRemoveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "remove <number>",
+		Aliases: []string{"rm"},
+		Short:   "Remove an alert by its number from 'palm budget alert list'",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			b := budget.Load()
+			idx, err := strconv.Atoi(args[0])
+			if err != nil || idx < 1 || idx > len(b.Alerts) {
+				ui.Bad.Printf("  No alert #%s\n", args[0])
+				os.Exit(1)
+			}
+			removed := b.Alerts[idx-1]
+			b.Alerts = append(b.Alerts[:idx-1], b.Alerts[idx:]...)
+			if err := budget.Save(b); err != nil {
+				ui.Bad.Printf("  Failed to save budget: %v\n", err)
+				os.Exit(1)
+			}
+			ui.Good.Printf("  %s Removed alert at %g%%\n", ui.StatusIcon(true), removed.At)
+		},
+	}
+}
+
+func budgetAlertTestCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "test [number]",
+		Short: "Fire alerts now with a sample event",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			b := budget.Load()
+			alerts := b.Alerts
+			if len(args) == 1 {
+				idx, err := strconv.Atoi(args[0])
+				if err != nil || idx < 1 || idx > len(b.Alerts) {
+					ui.Bad.Printf("  No alert #%s\n", args[0])
+					os.Exit(1)
+				}
+				alerts = b.Alerts[idx-1 : idx]
+			}
+			if len(alerts) == 0 {
+				fmt.Println("  No alerts configured.")
+				return
+			}
+
+			limit := b.MonthlyLimit
+			if limit == 0 {
+				limit = 100
+			}
+			failed := false
+			for _, a := range alerts {
+				ev := budget.AlertEvent{
+					Scope:     "monthly",
+					Threshold: a.At,
+					Percent:   a.At,
+					Spend:     limit * a.At / 100,
+					Limit:     limit,
+					Text:      fmt.Sprintf("palm: test alert — monthly AI budget at %g%%", a.At),
+					Timestamp: time.Now(),
+				}
+				if err := budget.Fire(a, ev); err != nil {
+					ui.Bad.Printf("  %s %g%%: %v\n", ui.StatusIcon(false), a.At, err)
+					failed = true
+					continue
+				}
+				ui.Good.Printf("  %s %g%%: %s\n", ui.StatusIcon(true), a.At, a.Describe())
+			}
+			if failed {
+				os.Exit(1)
+			}
+		},
+	}
+}
+
 func progressBar(percent float64, width int) string {
 	if percent > 100 {
 		percent = 100
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/msalah0e/palm/internal/billing"
+	"github.com/msalah0e/palm/internal/budget"
+	"github.com/msalah0e/palm/internal/proxy"
+	"github.com/msalah0e/palm/internal/ui"
+)
+
+// spendForecast projects this month's spend from the proxy log and
+// imported billing data.
+func spendForecast(limit float64) budget.Forecast {
+	now := time.Now().UTC()
+	// The burn rate looks back a week, which may reach into last month
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if week := now.Truncate(24*time.Hour).AddDate(0, 0, -7); week.Before(from) {
+		from = week
+	}
+	logs, _ := proxy.ReadLogs(0)
+	billed, _ := billing.Load()
+	return budget.Project(estimateByDay(logs, from), billed, limit, now)
+}
+
+func printForecast(f budget.Forecast) {
+	if f.Spend == 0 && f.BurnRate == 0 {
+		return
+	}
+
+	fmt.Printf("\n  %s %s\n", ui.Brand.Sprint("Forecast"), ui.Subtle.Sprintf("(%s, UTC days, proxy estimates and billing)", f.Month))
+	fmt.Printf("  Burn rate:  $%.2f/day over the last week\n", f.BurnRate)
+	line := fmt.Sprintf("  Month end:  $%.2f projected", f.Projected)
+	if f.Limit > 0 {
+		switch {
+		case f.OverageDate != "" && f.Spend >= f.Limit:
+			line += " · " + ui.Bad.Sprintf("limit crossed %s", forecastDay(f.OverageDate))
+		case f.OverageDate != "":
+			line += " · " + ui.Warn.Sprintf("%s over $%.2f around %s", ui.WarnIcon(), f.Limit, forecastDay(f.OverageDate))
+		default:
+			line += " · " + ui.Good.Sprintf("within $%.2f", f.Limit)
+		}
+	}
+	fmt.Println(line)
+	fmt.Printf("  Daily:      %s\n", ui.Info.Sprint(ui.Sparkline(f.Daily)))
+
+	if len(f.Providers) > 1 || (len(f.Providers) == 1 && f.Providers[0].Billed) {
+		fmt.Println()
+		for _, p := range f.Providers {
+			source := ""
+			if p.Billed {
+				source = ui.Subtle.Sprint(" billed")
+			}
+			fmt.Printf("    %-14s %9s → %-9s %s%s\n", p.Provider, fmt.Sprintf("$%.2f", p.Spend), fmt.Sprintf("$%.2f", p.Projected), ui.Info.Sprint(ui.Sparkline(p.Daily)), source)
+		}
+	}
+}
+
+// forecastDay formats a YYYY-MM-DD day like "Jun 22".
+func forecastDay(day string) string {
+	if t, err := time.Parse("2006-01-02", day); err == nil {
+		return t.Format("Jan 2")
+	}
+	return day
+}
@@ -0,0 +1,338 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/msalah0e/palm/internal/activity"
+	"github.com/msalah0e/palm/internal/chat"
+	"github.com/msalah0e/palm/internal/graph"
+	"github.com/msalah0e/palm/internal/models"
+	"github.com/msalah0e/palm/internal/proxy"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/msalah0e/palm/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+// chatRecallLimit caps how many graph facts are loaded into a new session.
+const chatRecallLimit = 12
+
+func chatCmd() *cobra.Command {
+	var provider, model, system string
+	var direct, noMemory bool
+	var port int
+	var topics []string
+
+	cmd := &cobra.Command{
+		Use:   "chat",
+		Short: "Chat with any configured model, with memory from the knowledge graph",
+		Long: `Start an interactive chat with any provider palm has a key for.
+
+Requests go through the palm proxy when it is running, so they show up in
+proxy stats and budgets; otherwise palm calls the provider directly.
+
+At the start of a session, facts about the current project (and any --topic)
+are recalled from the knowledge graph. Commands inside the chat:
+
+  /model [id]          show or switch the model
+  /provider [name]     show or switch the provider
+  /save [file]         write the transcript as markdown
+  /remember [fact]     store a fact ("entity: fact"), or extract facts from the chat
+  /clear               start over, keeping recalled memory
+  /exit                leave`,
+		Example: `  palm chat
+  palm chat -m gpt-4o
+  palm chat --provider ollama --topic billing`,
+		Run: func(cmd *cobra.Command, args []string) {
+			v := vault.New()
+			if provider == "" && model != "" {
+				provider = proxy.InferProvider(model)
+			}
+			if provider == "" {
+				provider = defaultChatProvider(v)
+			}
+			if _, ok := proxy.CompatBase(provider, 0); !ok {
+				ui.Bad.Printf("  Unknown provider %q (use %s)\n", provider, strings.Join(chat.Providers, ", "))
+				os.Exit(1)
+			}
+			if model == "" {
+				model = chat.DefaultModel(provider)
+			}
+
+			viaProxy := false
+			if !direct {
+				viaProxy, _ = proxy.IsRunning()
+			}
+			proxyPort := 0
+			if viaProxy {
+				proxyPort = port
+			}
+
+			project := projectName()
+			sess := &chat.Session{Provider: provider, Model: model, System: system, Started: time.Now()}
+			var g *graph.Graph
+			var recalled int
+			if !noMemory {
+				var err error
+				if g, err = graph.Load(); err != nil {
+					ui.Warn.Printf("  Knowledge graph unavailable: %v\n", err)
+				} else {
+					facts := chat.Recall(g, append([]string{project}, topics...), chatRecallLimit)
+					recalled = len(facts)
+					sess.System = joinPrompt(system, chat.MemoryPrompt(facts))
+				}
+			}
+
+			ui.Banner("chat")
+			route := "direct"
+			if viaProxy {
+				route = fmt.Sprintf("via proxy on :%d", port)
+			}
+			fmt.Printf("  %s  %s/%s %s\n", ui.Brand.Sprintf("%-8s", "Model"), provider, model, ui.Subtle.Sprint("("+route+")"))
+			if g != nil {
+				fmt.Printf("  %s  %d facts recalled\n", ui.Brand.Sprintf("%-8s", "Memory"), recalled)
+			}
+			if !proxy.KeyAvailable(v, provider) {
+				ui.Warn.Printf("  No API key for %s — add one with: palm keys add %s\n", provider, providerEnvKey(provider))
+			}
+			fmt.Printf("  %s\n\n", ui.Subtle.Sprint("/help for commands, /exit or Ctrl-D to leave"))
+
+			turns := 0
+			for {
+				fmt.Print(ui.Brand.Sprint("you ›") + " ")
+				line, err := stdinReader.ReadString('\n')
+				if err != nil && line == "" {
+					fmt.Println()
+					break
+				}
+				line = strings.TrimSpace(line)
+				if line == "" {
+					continue
+				}
+
+				if name, arg, ok := chat.ParseCommand(line); ok {
+					if name == "exit" || name == "quit" || name == "q" {
+						break
+					}
+					chatCommand(sess, name, arg, v, g, proxyPort, project)
+					fmt.Println()
+					continue
+				}
+
+				sess.Messages = append(sess.Messages, chat.Message{Role: "user", Content: line})
+				reply, err := chatReply(sess, v, proxyPort, sess.Conversation(), true)
+				if err != nil {
+					// Drop the unanswered turn so the next one starts clean
+					sess.Messages = sess.Messages[:len(sess.Messages)-1]
+					ui.Bad.Printf("  %v\n\n", err)
+					continue
+				}
+				sess.Messages = append(sess.Messages, chat.Message{Role: "assistant", Content: reply})
+				turns++
+			}
+
+			_ = activity.Append(activity.Entry{
+				Action:   "chat",
+				Tool:     sess.Provider + "/" + sess.Model,
+				Details:  fmt.Sprintf("%d turns", turns),
+				Duration: time.Since(sess.Started).Seconds(),
+				Status:   "ok",
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&provider, "provider", "p", "", "Provider to chat with (default: first with an API key)")
+	cmd.Flags().StringVarP(&model, "model", "m", "", "Model ID (default: the provider's default)")
+	cmd.Flags().StringVar(&system, "system", "", "System prompt")
+	cmd.Flags().StringSliceVar(&topics, "topic", nil, "Also recall graph facts about these topics")
+	cmd.Flags().BoolVar(&noMemory, "no-memory", false, "Don't recall or store knowledge graph facts")
+	cmd.Flags().BoolVar(&direct, "direct", false, "Call the provider directly even when the proxy is running")
+	cmd.Flags().IntVar(&port, "port", 4778, "Port of the running palm proxy")
+	return cmd
+}
+
+// chatCommand handles a /command inside the chat loop.
+func chatCommand(sess *chat.Session, name, arg string, v vault.Vault, g *graph.Graph, proxyPort int, project string) {
+	switch name {
+	case "model":
+		if arg == "" {
+			fmt.Printf("  %s/%s\n", sess.Provider, sess.Model)
+			for _, m := range models.AllModels() {
+				if m.Provider == sess.Provider && m.Type == "chat" {
+					fmt.Printf("    %s\n", ui.Subtle.Sprint(m.ID))
+				}
+			}
+			return
+		}
+		// A known model from another provider switches provider too
+		if p := proxy.InferProvider(arg); p != "" && p != sess.Provider {
+			sess.Provider = p
+		}
+		sess.Model = arg
+		ui.Good.Printf("  %s Now chatting with %s/%s\n", ui.StatusIcon(true), sess.Provider, sess.Model)
+
+	case "provider":
+		if arg == "" {
+			for _, p := range chat.Providers {
+				fmt.Printf("  %s %-10s %s\n", ui.StatusIcon(proxy.KeyAvailable(v, p)), p, ui.Subtle.Sprint(chat.DefaultModel(p)))
+			}
+			return
+		}
+		if _, ok := proxy.CompatBase(arg, 0); !ok {
+			ui.Bad.Printf("  Unknown provider %q (use %s)\n", arg, strings.Join(chat.Providers, ", "))
+			return
+		}
+		sess.Provider, sess.Model = arg, chat.DefaultModel(arg)
+		ui.Good.Printf("  %s Now chatting with %s/%s\n", ui.StatusIcon(true), sess.Provider, sess.Model)
+		if !proxy.KeyAvailable(v, arg) {
+			ui.Warn.Printf("  No API key for %s — add one with: palm keys add %s\n", arg, providerEnvKey(arg))
+		}
+
+	case "save":
+		path := arg
+		if path == "" {
+			path = "chat-" + sess.Started.Format("20060102-150405") + ".md"
+		}
+		if err := os.WriteFile(path, []byte(sess.Transcript()), 0o644); err != nil {
+			ui.Bad.Printf("  %v\n", err)
+			return
+		}
+		ui.Good.Printf("  %s Saved %d messages to %s\n", ui.StatusIcon(true), len(sess.Messages), path)
+
+	case "remember":
+		if g == nil {
+			ui.Warn.Println("  Memory is off for this session")
+			return
+		}
+		var facts []chat.Fact
+		if arg != "" {
+			facts = []chat.Fact{chat.ParseFact(arg, project)}
+		} else {
+			if len(sess.Messages) == 0 {
+				fmt.Println("  Nothing to remember yet")
+				return
+			}
+			fmt.Printf("  %s\n", ui.Subtle.Sprint("Extracting facts from the conversation..."))
+			prompt := []chat.Message{{Role: "user", Content: chat.ExtractPrompt(sess.Messages)}}
+			reply, err := chatReply(sess, v, proxyPort, prompt, false)
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				return
+			}
+			facts = chat.ParseFacts(reply, project)
+		}
+
+		stored := 0
+		for _, f := range facts {
+			added, err := chat.Remember(g, f, "chat")
+			if err != nil {
+				ui.Bad.Printf("  %s: %v\n", f.Entity, err)
+				continue
+			}
+			if added {
+				stored++
+				fmt.Printf("  %s %s: %s\n", ui.StatusIcon(true), ui.Brand.Sprint(f.Entity), f.Text)
+			}
+		}
+		if stored == 0 {
+			fmt.Println("  Nothing new to remember")
+			return
+		}
+		if err := graph.Save(g); err != nil {
+			ui.Bad.Printf("  Failed to save graph: %v\n", err)
+			return
+		}
+		logGraphChange("remember", fmt.Sprintf("%d fact(s) from chat", stored))
+		ui.Good.Printf("  Stored %d fact(s) in the knowledge graph\n", stored)
+
+	case "clear":
+		sess.Messages = nil
+		fmt.Println("  Conversation cleared")
+
+	case "help":
+		fmt.Println("  /model [id]        show or switch the model")
+		fmt.Println("  /provider [name]   show or switch the provider")
+		fmt.Println("  /save [file]       write the transcript as markdown")
+		fmt.Println("  /remember [fact]   store a fact, or extract facts from the chat")
+		fmt.Println("  /clear             start over")
+		fmt.Println("  /exit              leave")
+
+	default:
+		ui.Warn.Printf("  Unknown command /%s — try /help\n", name)
+	}
+}
+
+// chatReply sends msgs to the session's model. When show is set the reply is
+// streamed to the terminal. Ctrl-C cancels the request, not the chat.
+func chatReply(sess *chat.Session, v vault.Vault, proxyPort int, msgs []chat.Message, show bool) (string, error) {
+	base, _ := proxy.CompatBase(sess.Provider, proxyPort)
+	client := &chat.Client{BaseURL: base, Header: make(http.Header)}
+	if proxyPort == 0 {
+		proxy.Authorize(client.Header, v, sess.Provider, "chat")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var out io.Writer = io.Discard
+	if show {
+		out = os.Stdout
+		fmt.Print("  ")
+	}
+	reply, err := client.Stream(ctx, sess.Model, msgs, func(delta string) {
+		fmt.Fprint(out, strings.ReplaceAll(delta, "\n", "\n  "))
+	})
+	if show {
+		fmt.Print("\n\n")
+	}
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("cancelled")
+	}
+	return reply, err
+}
+
+// defaultChatProvider picks the first provider with a key, falling back to
+// local Ollama.
+func defaultChatProvider(v vault.Vault) string {
+	for _, p := range chat.Providers {
+		if p != "ollama" && proxy.KeyAvailable(v, p) {
+			return p
+		}
+	}
+	return "ollama"
+}
+
+func providerEnvKey(provider string) string {
+	for _, p := range models.BuiltinProviders() {
+		if strings.EqualFold(p.Name, provider) {
+			return p.EnvKey
+		}
+	}
+	return ""
+}
+
+// projectName is the name facts about the current directory are filed under.
+func projectName() string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "project"
+	}
+	return filepath.Base(wd)
+}
+
+func joinPrompt(parts ...string) string {
+	var kept []string
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			kept = append(kept, p)
+		}
+	}
+	return strings.Join(kept, "\n\n")
+}
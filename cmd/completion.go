@@ -1,7 +1,12 @@
 package cmd
 
 import (
+	"slices"
+	"sort"
+
+	"github.com/msalah0e/palm/internal/graph"
 	"github.com/msalah0e/palm/internal/models"
+	"github.com/msalah0e/palm/internal/state"
 	"github.com/msalah0e/palm/internal/vault"
 	"github.com/spf13/cobra"
 )
@@ -11,7 +16,9 @@ func completionCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "completion [bash|zsh|fish|powershell]",
 		Short: "Generate shell completion scripts",
-		Long: `Generate completion scripts for your shell.
+		Long: `Generate completion scripts for your shell. Besides commands and flags,
+they complete registry and installed tool names, knowledge graph entities,
+compose step names (from --file) and stored vault key names.
 
   # Bash (add to ~/.bashrc)
   eval "$(palm completion bash)"
@@ -43,12 +50,15 @@ func completionCmd() *cobra.Command {
 	return cmd
 }
 
-// toolCompletionFunc provides dynamic completion for tool names.
+// toolCompletionFunc provides dynamic completion for tool names. Tools
+// already on the command line aren't offered again.
 func toolCompletionFunc(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	reg := loadRegistry()
 	var completions []string
 	for _, t := range reg.All() {
-		completions = append(completions, t.Name+"\t"+t.Description)
+		if !slices.Contains(args, t.Name) {
+			completions = append(completions, t.Name+"\t"+t.Description)
+		}
 	}
 	return completions, cobra.ShellCompDirectiveNoFileComp
 }
@@ -57,9 +67,17 @@ func toolCompletionFunc(cmd *cobra.Command, args []string, toComplete string) ([
 func installedToolCompletionFunc(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	reg := loadRegistry()
 	var completions []string
-	for _, t := range reg.All() {
-		completions = append(completions, t.Name+"\t"+t.Description)
+	for name := range state.ListInstalled() {
+		if slices.Contains(args, name) {
+			continue
+		}
+		if t := reg.Get(name); t != nil {
+			completions = append(completions, name+"\t"+t.Description)
+		} else {
+			completions = append(completions, name)
+		}
 	}
+	sort.Strings(completions)
 	return completions, cobra.ShellCompDirectiveNoFileComp
 }
 
@@ -72,8 +90,16 @@ func modelCompletionFunc(cmd *cobra.Command, args []string, toComplete string) (
 	return completions, cobra.ShellCompDirectiveNoFileComp
 }
 
-// keyCompletionFunc provides dynamic completion for vault key names.
+// keyCompletionFunc provides dynamic completion for vault key names, from
+// the key profile the command line selects.
 func keyCompletionFunc(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	proj, _ := loadProject()
+	if err := vault.SetProfile(resolveKeyProfile(keyProfile, proj)); err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
 	v := vault.New()
 	keys, err := v.List()
 	if err != nil {
@@ -81,3 +107,44 @@ func keyCompletionFunc(cmd *cobra.Command, args []string, toComplete string) ([]
 	}
 	return keys, cobra.ShellCompDirectiveNoFileComp
 }
+
+// graphEntityCompletion completes knowledge graph entity names, with their
+// type as the description, for the argument positions that take one.
+func graphEntityCompletion(positions ...int) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if !slices.Contains(positions, len(args)) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		g, err := graph.Load()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		var completions []string
+		for _, e := range g.Entities {
+			if !slices.Contains(args, e.Name) {
+				completions = append(completions, e.Name+"\t"+e.Type)
+			}
+		}
+		sort.Strings(completions)
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// composeStepCompletionFunc completes the step names of the workflow the
+// --file flag points at, with each step's tool as the description.
+func composeStepCompletionFunc(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	f, _ := cmd.Flags().GetString("file")
+	wf, err := loadComposeFile(f)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	completions := make([]string, 0, len(wf.Steps))
+	for _, s := range wf.Steps {
+		desc := s.Tool
+		if desc == "" && s.Run != "" {
+			desc = "shell"
+		}
+		completions = append(completions, s.Name+"\t"+desc)
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/msalah0e/palm/internal/graph"
+	"github.com/msalah0e/palm/internal/state"
+)
+
+func TestDynamicCompletions(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	g := graph.New()
+	_ = g.AddEntity("Kubernetes", "technology")
+	_ = g.AddEntity("palm", "project")
+	if err := graph.Save(g); err != nil {
+		t.Fatal(err)
+	}
+	entities := graphEntityCompletion(0, 2)
+	if got, _ := entities(nil, nil, ""); strings.Join(got, ",") != "Kubernetes\ttechnology,palm\tproject" {
+		t.Errorf("entity completions = %q", got)
+	}
+	if got, _ := entities(nil, []string{"palm"}, ""); len(got) != 0 {
+		t.Errorf("the relation argument shouldn't complete entities, got %q", got)
+	}
+	if got, _ := entities(nil, []string{"palm", "uses"}, ""); strings.Join(got, ",") != "Kubernetes\ttechnology" {
+		t.Errorf("entities already given shouldn't be offered again, got %q", got)
+	}
+
+	if err := state.Record("aider", "0.1", "pip", "aider-chat", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := installedToolCompletionFunc(nil, nil, ""); len(got) != 1 || !strings.HasPrefix(got[0], "aider") {
+		t.Errorf("only installed tools should complete, got %q", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "flow.toml")
+	_ = os.WriteFile(path, []byte("name = \"flow\"\n\n[[steps]]\nname = \"lint\"\nrun = \"make lint\"\n\n[[steps]]\nname = \"review\"\ntool = \"aider\"\nprompt = \"review\"\n"), 0o644)
+	cmd := composeRunCmd()
+	_ = cmd.Flags().Set("file", path)
+	if got, _ := composeStepCompletionFunc(cmd, nil, ""); strings.Join(got, ",") != "lint\tshell,review\taider" {
+		t.Errorf("step completions = %q", got)
+	}
+}
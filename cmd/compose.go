@@ -2,15 +2,20 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/BurntSushi/toml"
+	"github.com/msalah0e/palm/internal/activity"
+	"github.com/msalah0e/palm/internal/contextpack"
+	"github.com/msalah0e/palm/internal/registry"
 	"github.com/msalah0e/palm/internal/ui"
 	"github.com/msalah0e/palm/internal/vault"
 	"github.com/spf13/cobra"
@@ -18,30 +23,77 @@ import (
 
 // ComposeFile represents a .palm-compose.toml workflow definition.
 type ComposeFile struct {
-	Name        string        `toml:"name"`
-	Description string        `toml:"description"`
-	Steps       []ComposeStep `toml:"steps"`
+	Name        string                   `toml:"name"`
+	Description string                   `toml:"description"`
+	Include     composeIncludes          `toml:"include"` // files whose steps and groups come first
+	Groups      map[string][]ComposeStep `toml:"groups"`
+	Steps       []ComposeStep            `toml:"steps"`
+
+	BaseDir string `toml:"-"` // directory of the workflow file
 }
 
 // ComposeStep is a single step in a compose workflow.
 type ComposeStep struct {
 	Name      string   `toml:"name"`
+	Group     string   `toml:"group"` // expands to the steps of a [groups] entry
 	Run       string   `toml:"run"`
 	Tool      string   `toml:"tool"`
+	Prompt    string   `toml:"prompt"` // sent through the tool's registry invoke template
 	Args      []string `toml:"args"`
 	Input     string   `toml:"input"`
 	DependsOn []string `toml:"depends_on"`
-	OnFail    string   `toml:"on_fail"` // continue, stop (default: stop)
-	Timeout   int      `toml:"timeout"` // seconds, 0 = no timeout
+	OnFail    string   `toml:"on_fail"`   // continue, stop (default: stop)
+	Timeout   int      `toml:"timeout"`   // seconds, 0 = no timeout
+	Approve   bool     `toml:"approve"`   // pause for confirmation before running
+	Artifacts []string `toml:"artifacts"` // files the step produces, as globs relative to its dir
+
+	Dir string            `toml:"dir"` // working directory, relative to the file defining the step
+	Env map[string]string `toml:"env"` // variables to set; $VAR expands from the step's environment
+
+	Origin  string `toml:"-"` // include file or group the step came from
+	BaseDir string `toml:"-"` // directory of the file defining the step
 }
 
 // ComposeResult holds the result of running a step.
 type ComposeResult struct {
-	Step     string
-	Output   string
-	Duration time.Duration
-	ExitCode int
-	Error    string
+	Step     string        `json:"step"`
+	Output   string        `json:"output"`
+	Duration time.Duration `json:"duration"`
+	ExitCode int           `json:"exit_code"`
+	Error    string        `json:"error,omitempty"`
+	// Artifacts lists the files matching the step's artifacts globs once it
+	// has run.
+	Artifacts []string `json:"artifacts,omitempty"`
+}
+
+// Exit codes of palm compose, so CI can tell a broken workflow file from a
+// failing step from one that ran out of time.
+const (
+	composeExitOK         = 0
+	composeExitStepFailed = 1 // a step failed, or an approval gate declined
+	composeExitInvalid    = 2 // the workflow file is missing or invalid
+	composeExitTimeout    = 3 // steps only failed by timing out
+)
+
+// composeRunOutput is the document `palm compose --output json` prints.
+type composeRunOutput struct {
+	Workflow string              `json:"workflow"`
+	Status   string              `json:"status"` // ok, failed, timeout, invalid or dry-run
+	ExitCode int                 `json:"exit_code"`
+	Duration float64             `json:"duration_seconds"`
+	Error    string              `json:"error,omitempty"`
+	Steps    []composeStepOutput `json:"steps"`
+	Report   string              `json:"report,omitempty"`
+}
+
+type composeStepOutput struct {
+	Name      string   `json:"name"`
+	Status    string   `json:"status"` // ok, failed, timeout, not-approved, skipped or planned
+	ExitCode  int      `json:"exit_code"`
+	Duration  float64  `json:"duration_seconds"`
+	Error     string   `json:"error,omitempty"`
+	Output    string   `json:"output,omitempty"`
+	Artifacts []string `json:"artifacts,omitempty"`
 }
 
 func composeCmd() *cobra.Command {
@@ -49,6 +101,11 @@ func composeCmd() *cobra.Command {
 		file    string
 		dryRun  bool
 		verbose bool
+		allKeys bool
+		yes     bool
+		noMask  bool
+		report  string
+		output  string
 	)
 
 	cmd := &cobra.Command{
@@ -65,6 +122,15 @@ Examples:
   palm compose --file review.toml           # Run a specific workflow
   palm compose init                         # Create a sample workflow
   palm compose --dry-run                    # Show what would run
+  palm compose run --step review -i         # Debug one step: edit its input
+                                            # and prompt, re-run it in a loop
+  palm compose catalog                      # List reusable workflows
+  palm compose install code-review          # Copy a catalog workflow locally
+  palm compose publish --catalog            # Share a sanitized workflow
+  palm compose export --format github-actions
+                                            # Turn the workflow into a CI pipeline
+  palm compose schedule add nightly --cron "0 2 * * *"
+                                            # Run a workflow on a schedule
 
 Workflow file (.palm-compose.toml):
   name = "code-review"
@@ -76,15 +142,68 @@ Workflow file (.palm-compose.toml):
 
   [[steps]]
   name = "review"
-  tool = "ollama"
-  args = ["run", "llama3.3"]
+  tool = "claude-code"
+  prompt = "Review this code for bugs"
   input = "step:analyze"
   depends_on = ["analyze"]
 
   [[steps]]
   name = "test"
   run = "go test ./..."
-  depends_on = ["review"]`,
+  depends_on = ["review"]
+
+Shared steps can live in other files and in named groups:
+  include = "../shared/common-steps.toml"   # or a list of files
+
+  [[groups.lint]]
+  name = "vet"
+  run = "go vet ./..."
+
+  [[steps]]
+  name = "checks"
+  group = "lint"                            # runs as checks/vet
+
+A tool step with a prompt runs the tool the way the registry says it takes
+one-shot prompts (e.g. aider --message, claude -p), with input on stdin.
+With args instead, the tool's binary runs with exactly those arguments.
+Each step only sees the vault keys its own tool declares.
+
+Steps run where palm was started unless they set dir, relative to the file
+that defines them, and can set their own environment variables:
+
+  [[steps]]
+  name = "api-tests"
+  dir = "services/api"
+  run = "go test ./..."
+
+  [steps.env]
+  OLLAMA_HOST = "http://gpu-box:11434"
+  PATH = "$HOME/bin:$PATH"
+
+A step using a group passes its dir and env on to the group's steps.
+
+A step with approve = true pauses before it runs and shows its command and
+pending input; it only runs once you answer y. Without a terminal the gate
+declines, so pass --yes in CI to approve every gate.
+
+A step can list the files it produces, and their paths are reported once
+it has run:
+
+  [[steps]]
+  name = "coverage"
+  run = "go test -coverprofile=cover.out ./..."
+  artifacts = ["cover.out"]
+
+For CI, --output json prints one JSON document with each step's status,
+exit code, duration, output and artifacts to stdout, and everything else
+to stderr. The exit code tells failures apart: 0 when every step passed,
+1 when a step failed, 2 when the workflow file is invalid, and 3 when
+steps failed only by timing out.
+
+Step output is masked before it is printed or saved: values of vault keys
+and other credential variables in the step's environment, and well-known
+API key formats, become <redacted> in the output, the report, --output json
+and text artifacts. --no-mask turns this off for debugging.`,
 		Aliases: []string{"workflow"},
 		Run: func(cmd *cobra.Command, args []string) {
 			// Handle "compose init" subcommand
@@ -93,11 +212,30 @@ Workflow file (.palm-compose.toml):
 				return
 			}
 
+			asJSON := jsonOutput
+			switch output {
+			case "", "text":
+			case "json":
+				asJSON = true
+			default:
+				ui.Bad.Printf("  Unknown --output %q — use text or json\n", output)
+				os.Exit(composeExitInvalid)
+			}
+			stdout := os.Stdout
+			if asJSON {
+				stdout = ui.ToStderr()
+			}
+
 			// Load workflow file
 			workflow, err := loadComposeFile(file)
 			if err != nil {
 				ui.Bad.Printf("  Failed to load workflow: %v\n", err)
-				os.Exit(1)
+				if asJSON {
+					writeComposeOutput(stdout, &composeRunOutput{
+						Status: "invalid", ExitCode: composeExitInvalid, Error: err.Error(), Steps: []composeStepOutput{},
+					})
+				}
+				os.Exit(composeExitInvalid)
 			}
 
 			ui.Banner("compose")
@@ -111,13 +249,30 @@ Workflow file (.palm-compose.toml):
 
 			if dryRun {
 				composeDryRun(workflow)
+				if asJSON {
+					writeComposeOutput(stdout, composeOutput(workflow, nil, 0, "dry-run"))
+				}
 				return
 			}
 
+			reg := loadRegistry()
 			v := vault.New()
-			env := buildVaultEnv(v)
+			envs := composeEnvs(v, reg, workflow, allKeys)
+			mask := newComposeMasker(v, noMask)
 
-			results := runCompose(workflow, env, verbose)
+			started := time.Now()
+			gate := confirmComposeStep
+			if yes {
+				gate = nil
+			}
+			results := runCompose(workflow, reg, envs, mask, verbose, gate)
+			elapsed := time.Since(started)
+			recordComposeRun(workflow, results, elapsed)
+			if report != "" {
+				if err := writeComposeReport(report, workflow, results); err != nil {
+					ui.Warn.Printf("  %s Couldn't write report: %v\n", ui.WarnIcon(), err)
+				}
+			}
 
 			// Print summary
 			fmt.Println()
@@ -140,17 +295,39 @@ Workflow file (.palm-compose.toml):
 
 			ui.Table(headers, rows)
 
+			code := composeExitCode(results)
+			if asJSON {
+				out := composeOutput(workflow, results, elapsed, "")
+				out.Report = report
+				writeComposeOutput(stdout, out)
+			}
 			if !allPassed {
 				fmt.Println()
 				ui.Bad.Println("  Some steps failed")
-				os.Exit(1)
+				os.Exit(code)
 			}
 		},
 	}
 
+	cmd.AddCommand(
+		composeRunCmd(),
+		composeCatalogCmd(),
+		composeInstallCmd(),
+		composePublishCmd(),
+		composeExportCmd(),
+		composeScheduleCmd(),
+		composeSchedulerCmd(),
+	)
+
 	cmd.Flags().StringVarP(&file, "file", "f", ".palm-compose.toml", "Workflow file path")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would run without executing")
 	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show step output")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Approve every approval gate without asking (for CI)")
+	cmd.Flags().BoolVar(&allKeys, "all-keys", false, "Inject every vault key, not just the ones the steps' tools need")
+	cmd.Flags().StringVar(&report, "report", "", "Also write each step's result and output to a JSON file")
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "Output format: text, or json for CI (UI goes to stderr)")
+	cmd.Flags().BoolVar(&noMask, "no-mask", false, "Show step output with secrets unmasked (for debugging)")
+	_ = cmd.MarkFlagFilename("file", "toml")
 	return cmd
 }
 
@@ -170,7 +347,7 @@ run = "cat main.go"
 [[steps]]
 name = "ai-review"
 tool = "ollama"
-args = ["run", "llama3.3", "Review this Go code for bugs and improvements:"]
+prompt = "Review this Go code for bugs and improvements:"
 input = "step:read-code"
 depends_on = ["read-code"]
 
@@ -184,7 +361,7 @@ timeout = 60
 [[steps]]
 name = "summary"
 tool = "ollama"
-args = ["run", "llama3.3", "Summarize the code review and test results:"]
+prompt = "Summarize the code review and test results:"
 input = "step:ai-review,step:run-tests"
 depends_on = ["ai-review", "run-tests"]
 `
@@ -204,30 +381,39 @@ depends_on = ["ai-review", "run-tests"]
 	fmt.Println("  Edit it, then run: palm compose")
 }
 
-func loadComposeFile(file string) (*ComposeFile, error) {
-	// Search up for the file
-	path := file
-	if !filepath.IsAbs(path) {
-		dir, err := os.Getwd()
-		if err != nil {
-			return nil, err
+// resolveComposePath searches up from the working directory for a workflow file.
+func resolveComposePath(file string) (string, error) {
+	if filepath.IsAbs(file) {
+		return file, nil
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		candidate := filepath.Join(dir, file)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
 		}
-		for {
-			candidate := filepath.Join(dir, file)
-			if _, err := os.Stat(candidate); err == nil {
-				path = candidate
-				break
-			}
-			parent := filepath.Dir(dir)
-			if parent == dir {
-				return nil, fmt.Errorf("%s not found (run 'palm compose init' to create one)", file)
-			}
-			dir = parent
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("%s not found (run 'palm compose init' to create one)", file)
 		}
+		dir = parent
+	}
+}
+
+func loadComposeFile(file string) (*ComposeFile, error) {
+	path, err := resolveComposePath(file)
+	if err != nil {
+		return nil, err
 	}
 
-	var cf ComposeFile
-	if _, err := toml.DecodeFile(path, &cf); err != nil {
+	cf, err := readComposeTree(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if cf.Steps, err = expandGroups(cf.Steps, cf.Groups, nil); err != nil {
 		return nil, err
 	}
 
@@ -240,6 +426,22 @@ func loadComposeFile(file string) (*ComposeFile, error) {
 		if s.Run == "" && s.Tool == "" {
 			return nil, fmt.Errorf("step '%s': must have 'run' or 'tool'", s.Name)
 		}
+		if s.Prompt != "" && s.Tool == "" {
+			return nil, fmt.Errorf("step '%s': 'prompt' needs a 'tool'", s.Name)
+		}
+		if s.Prompt != "" && len(s.Args) > 0 {
+			return nil, fmt.Errorf("step '%s': use 'prompt' or 'args', not both", s.Name)
+		}
+		if dir := composeStepDir(s); dir != "" {
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+				return nil, fmt.Errorf("step '%s': dir '%s' is not a directory", s.Name, s.Dir)
+			}
+		}
+		for k := range s.Env {
+			if k == "" || strings.ContainsAny(k, "= \t") {
+				return nil, fmt.Errorf("step '%s': invalid env variable name '%s'", s.Name, k)
+			}
+		}
 		if stepNames[s.Name] {
 			return nil, fmt.Errorf("duplicate step name: '%s'", s.Name)
 		}
@@ -255,10 +457,11 @@ func loadComposeFile(file string) (*ComposeFile, error) {
 		}
 	}
 
-	return &cf, nil
+	return cf, nil
 }
 
 func composeDryRun(wf *ComposeFile) {
+	reg := loadRegistry()
 	fmt.Printf("  %s Dry run — showing execution plan\n\n", ui.Info.Sprint("📋"))
 
 	// Build dependency graph
@@ -274,15 +477,27 @@ func composeDryRun(wf *ComposeFile) {
 		for _, step := range level {
 			cmd := step.Run
 			if step.Tool != "" {
-				cmd = step.Tool + " " + strings.Join(step.Args, " ")
+				cmd = strings.Join(composeStepArgs(reg, step), " ")
 			}
 			fmt.Printf("    %s  %s\n", ui.Brand.Sprint(step.Name), ui.Subtle.Sprint(cmd))
 			if step.Input != "" {
 				fmt.Printf("           input: %s\n", ui.Info.Sprint(step.Input))
 			}
+			if step.Dir != "" {
+				fmt.Printf("           dir:   %s\n", step.Dir)
+			}
+			if len(step.Env) > 0 {
+				fmt.Printf("           env:   %s\n", strings.Join(sortedEnv(step.Env), " "))
+			}
+			if step.Origin != "" {
+				fmt.Printf("           from:  %s\n", ui.Subtle.Sprint(step.Origin))
+			}
 			if len(step.DependsOn) > 0 {
 				fmt.Printf("           after: %s\n", strings.Join(step.DependsOn, ", "))
 			}
+			if step.Approve {
+				fmt.Printf("           %s\n", ui.Warn.Sprint("waits for approval"))
+			}
 		}
 		fmt.Println()
 	}
@@ -339,12 +554,19 @@ func resolveExecutionOrder(wf *ComposeFile) [][]ComposeStep {
 	return levels
 }
 
-func runCompose(wf *ComposeFile, env []string, verbose bool) []ComposeResult {
+// composeGate decides whether a step marked approve may run, given the
+// input it is about to receive. A nil gate approves every step.
+type composeGate func(step ComposeStep, cmdArgs []string, input string) bool
+
+// runCompose runs the workflow level by level. envs holds each step's
+// environment by step name; mask scrubs secrets from each step's results.
+func runCompose(wf *ComposeFile, reg *registry.Registry, envs map[string][]string, mask *composeMasker, verbose bool, gate composeGate) []ComposeResult {
 	levels := resolveExecutionOrder(wf)
 
 	// Store outputs by step name for input references
 	outputs := make(map[string]string)
 	var mu sync.Mutex
+	var gateMu sync.Mutex // one approval prompt at a time across parallel steps
 	var allResults []ComposeResult
 
 	for levelIdx, level := range levels {
@@ -369,7 +591,21 @@ func runCompose(wf *ComposeFile, env []string, verbose bool) []ComposeResult {
 					stdinData = resolveInput(s.Input, outputs, &mu)
 				}
 
-				result := executeComposeStep(s, env, stdinData, verbose)
+				var result ComposeResult
+				if s.Approve && gate != nil {
+					gateMu.Lock()
+					approved := gate(s, composeStepArgs(reg, s), stdinData)
+					gateMu.Unlock()
+					if !approved {
+						result = ComposeResult{Step: s.Name, ExitCode: 1, Error: "not approved"}
+					}
+				}
+				if result.Error == "" {
+					result = executeComposeStep(s, reg, envs[s.Name], stdinData, verbose)
+					result.Artifacts = composeArtifacts(s)
+					result = mask.result(result, envs[s.Name])
+				}
+				recordComposeStep(wf, s, result)
 
 				mu.Lock()
 				outputs[s.Name] = result.Output
@@ -395,16 +631,11 @@ func runCompose(wf *ComposeFile, env []string, verbose bool) []ComposeResult {
 
 		wg.Wait()
 
-		// Check for failures
-		for _, r := range levelResults {
-			allResults = append(allResults, r)
-
-			// Find original step to check on_fail
-			for _, s := range level {
-				if s.Name == r.Step && r.Error != "" && s.OnFail != "continue" {
-					// Stop execution
-					return allResults
-				}
+		// Keep every result of the level, then stop if a failed step asks to
+		allResults = append(allResults, levelResults...)
+		for i, r := range levelResults {
+			if r.Error != "" && level[i].OnFail != "continue" {
+				return allResults
 			}
 		}
 	}
@@ -412,6 +643,257 @@ func runCompose(wf *ComposeFile, env []string, verbose bool) []ComposeResult {
 	return allResults
 }
 
+// composeStepStatus names how a step ended.
+func composeStepStatus(r ComposeResult) string {
+	switch r.Error {
+	case "":
+		return "ok"
+	case "timeout":
+		return "timeout"
+	case "not approved":
+		return "not-approved"
+	}
+	return "failed"
+}
+
+// composeExitCode returns what palm compose exits with after results: a
+// step failure outranks timeouts, which CI may want to retry.
+func composeExitCode(results []ComposeResult) int {
+	code := composeExitOK
+	for _, r := range results {
+		switch composeStepStatus(r) {
+		case "ok":
+		case "timeout":
+			if code == composeExitOK {
+				code = composeExitTimeout
+			}
+		default:
+			return composeExitStepFailed
+		}
+	}
+	return code
+}
+
+// composeOutput builds the JSON document for a run. Steps without a result
+// didn't run: they are planned under status "dry-run" and skipped otherwise.
+func composeOutput(wf *ComposeFile, results []ComposeResult, elapsed time.Duration, status string) *composeRunOutput {
+	out := &composeRunOutput{Workflow: wf.Name, Status: status, Duration: elapsed.Seconds(), Steps: []composeStepOutput{}}
+	byStep := make(map[string]ComposeResult, len(results))
+	for _, r := range results {
+		byStep[r.Step] = r
+	}
+	for _, s := range wf.Steps {
+		r, ran := byStep[s.Name]
+		step := composeStepOutput{Name: s.Name, Status: "skipped"}
+		switch {
+		case ran:
+			step = composeStepOutput{
+				Name: s.Name, Status: composeStepStatus(r), ExitCode: r.ExitCode,
+				Duration: r.Duration.Seconds(), Error: r.Error, Output: r.Output, Artifacts: r.Artifacts,
+			}
+		case status == "dry-run":
+			step.Status = "planned"
+		}
+		out.Steps = append(out.Steps, step)
+	}
+	if status == "" {
+		out.ExitCode = composeExitCode(results)
+		switch out.ExitCode {
+		case composeExitOK:
+			out.Status = "ok"
+		case composeExitTimeout:
+			out.Status = "timeout"
+		default:
+			out.Status = "failed"
+		}
+	}
+	return out
+}
+
+func writeComposeOutput(w *os.File, out *composeRunOutput) {
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		fmt.Fprintf(w, "{\"error\": %q}\n", err.Error())
+		return
+	}
+	fmt.Fprintln(w, string(data))
+}
+
+// composeArtifacts lists the files matching a step's artifacts globs,
+// relative to the step's directory.
+func composeArtifacts(s ComposeStep) []string {
+	dir := composeStepDir(s)
+	var paths []string
+	for _, pattern := range s.Artifacts {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+		matches, _ := filepath.Glob(pattern)
+		for _, m := range matches {
+			paths = appendUnique(paths, m)
+		}
+	}
+	return paths
+}
+
+// confirmComposeStep shows what a gated step is about to do and asks to go
+// ahead. It declines when nobody can answer.
+func confirmComposeStep(step ComposeStep, cmdArgs []string, input string) bool {
+	fmt.Println()
+	fmt.Printf("  %s %s needs approval\n", ui.WarnIcon(), ui.Brand.Sprint(step.Name))
+	fmt.Printf("    command: %s\n", ui.Subtle.Sprint(strings.Join(cmdArgs, " ")))
+	if dir := composeStepDir(step); dir != "" {
+		fmt.Printf("    in:      %s\n", dir)
+	}
+	if input != "" {
+		fmt.Printf("    input (%d bytes):\n", len(input))
+		printTruncatedOutput(input, 500)
+	}
+	return confirmNo("  Run this step?")
+}
+
+// composeStepTool returns the tool a step invokes: the tool of tool steps
+// and the command of run steps.
+func composeStepTool(s ComposeStep) string {
+	if s.Tool != "" {
+		return s.Tool
+	}
+	if fields := strings.Fields(s.Run); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
+
+// composeStepDir returns the directory a step runs in, or "" for palm's
+// own working directory.
+func composeStepDir(s ComposeStep) string {
+	dir := s.Dir
+	switch {
+	case dir == "":
+		return ""
+	case strings.HasPrefix(dir, "~/"):
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, dir[2:])
+	case filepath.IsAbs(dir):
+		return dir
+	}
+	return filepath.Join(s.BaseDir, dir)
+}
+
+// composeStepEnv returns base with a step's env overrides applied. $VAR in
+// an override expands from base, so PATH = "$HOME/bin:$PATH" extends it.
+func composeStepEnv(base []string, overrides map[string]string) []string {
+	if len(overrides) == 0 {
+		return base
+	}
+	values := make(map[string]string, len(base))
+	env := make([]string, 0, len(base)+len(overrides))
+	for _, kv := range base {
+		k, v, _ := strings.Cut(kv, "=")
+		values[k] = v
+		if _, ok := overrides[k]; !ok {
+			env = append(env, kv)
+		}
+	}
+	for _, kv := range sortedEnv(overrides) {
+		k, v, _ := strings.Cut(kv, "=")
+		env = append(env, k+"="+os.Expand(v, func(name string) string { return values[name] }))
+	}
+	return env
+}
+
+// sortedEnv lists env as KEY=value pairs in key order.
+func sortedEnv(env map[string]string) []string {
+	pairs := make([]string, 0, len(env))
+	for k, v := range env {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+// composeEnvs builds each step's environment, holding only the vault keys
+// its own tool declares unless allKeys is set, plus the step's own env.
+// Steps sharing a tool share a base environment.
+func composeEnvs(v vault.Vault, reg *registry.Registry, wf *ComposeFile, allKeys bool) map[string][]string {
+	byTool := make(map[string][]string)
+	envs := make(map[string][]string, len(wf.Steps))
+	for _, s := range wf.Steps {
+		tool := composeStepTool(s)
+		env, ok := byTool[tool]
+		if !ok {
+			env = buildVaultEnv(v, "compose", reg, []string{tool}, allKeys)
+			byTool[tool] = env
+		}
+		envs[s.Name] = composeStepEnv(env, s.Env)
+	}
+	return envs
+}
+
+// composeStepArgs returns the command a step runs. Tool steps with a prompt
+// go through the registry invoke template; tool steps with args run the
+// tool's binary with them.
+func composeStepArgs(reg *registry.Registry, step ComposeStep) []string {
+	if step.Run != "" {
+		return []string{"sh", "-c", step.Run}
+	}
+	if step.Prompt != "" {
+		return promptArgs(reg, step.Tool, step.Prompt)
+	}
+	bin := step.Tool
+	if reg != nil {
+		if tool := reg.Get(step.Tool); tool != nil {
+			bin = tool.Binary()
+		}
+	}
+	return append([]string{bin}, step.Args...)
+}
+
+// recordComposeStep logs a single step so failures can be traced to the step
+// and tool that caused them.
+func recordComposeStep(wf *ComposeFile, s ComposeStep, r ComposeResult) {
+	tool := composeStepTool(s)
+	if tool == "" {
+		tool = "sh"
+	}
+	name := wf.Name
+	if name == "" {
+		name = "unnamed"
+	}
+	details := name + "/" + s.Name
+	if r.Error != "" {
+		details += ": " + r.Error
+	}
+	_ = activity.Append(activity.Entry{
+		Action:   "compose.step",
+		Tool:     tool,
+		Details:  details,
+		Duration: r.Duration.Seconds(),
+		Status:   activity.StatusOf(r.Error == ""),
+	})
+}
+
+// recordComposeRun appends a run summary to the activity log for `palm stats`.
+func recordComposeRun(wf *ComposeFile, results []ComposeResult, elapsed time.Duration) {
+	failed := 0
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+		}
+	}
+	name := wf.Name
+	if name == "" {
+		name = "unnamed"
+	}
+	_ = activity.Append(activity.Entry{
+		Action:   "compose",
+		Tool:     name,
+		Details:  fmt.Sprintf("%d/%d steps ok", len(results)-failed, len(wf.Steps)),
+		Duration: elapsed.Seconds(),
+		Status:   activity.StatusOf(failed == 0 && len(results) == len(wf.Steps)),
+	})
+}
+
 func resolveInput(input string, outputs map[string]string, mu *sync.Mutex) string {
 	parts := strings.Split(input, ",")
 	var resolved []string
@@ -442,6 +924,12 @@ func resolveInput(input string, outputs map[string]string, mu *sync.Mutex) strin
 					resolved = append(resolved, string(out))
 				}
 			}
+		} else if strings.HasPrefix(part, "context:") {
+			// Most relevant project files for a task, e.g. "context:auth token refresh"
+			query := strings.TrimPrefix(part, "context:")
+			if p, err := contextpack.Build(contextpack.Options{Query: query, Budget: defaultPackBudget}); err == nil {
+				resolved = append(resolved, p.Render())
+			}
 		} else {
 			// Literal text
 			resolved = append(resolved, part)
@@ -451,22 +939,15 @@ func resolveInput(input string, outputs map[string]string, mu *sync.Mutex) strin
 	return strings.Join(resolved, "\n\n")
 }
 
-func executeComposeStep(step ComposeStep, env []string, stdinData string, verbose bool) ComposeResult {
-	var cmdArgs []string
-
-	if step.Run != "" {
-		// Shell command
-		cmdArgs = []string{"sh", "-c", step.Run}
-	} else if step.Tool != "" {
-		// Tool with args
-		cmdArgs = append([]string{step.Tool}, step.Args...)
-	}
+func executeComposeStep(step ComposeStep, reg *registry.Registry, env []string, stdinData string, verbose bool) ComposeResult {
+	cmdArgs := composeStepArgs(reg, step)
 
 	var stdout, stderr bytes.Buffer
 	c := exec.Command(cmdArgs[0], cmdArgs[1:]...)
 	c.Stdout = &stdout
 	c.Stderr = &stderr
 	c.Env = env
+	c.Dir = composeStepDir(step)
 
 	if stdinData != "" {
 		c.Stdin = strings.NewReader(stdinData)
@@ -474,13 +955,22 @@ func executeComposeStep(step ComposeStep, env []string, stdinData string, verbos
 
 	start := time.Now()
 
+	// exitCode is the step's own exit status where it has one
+	exitCode := func(err error) int {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+			return exitErr.ExitCode()
+		}
+		return 1
+	}
+
 	if step.Timeout > 0 {
 		if err := c.Start(); err != nil {
 			return ComposeResult{
 				Step:     step.Name,
 				Duration: time.Since(start),
 				Output:   stderr.String(),
-				ExitCode: 1,
+				ExitCode: exitCode(err),
 				Error:    err.Error(),
 			}
 		}
@@ -496,7 +986,7 @@ func executeComposeStep(step ComposeStep, env []string, stdinData string, verbos
 					Step:     step.Name,
 					Duration: elapsed,
 					Output:   stderr.String(),
-					ExitCode: 1,
+					ExitCode: exitCode(err),
 					Error:    err.Error(),
 				}
 			}
@@ -525,7 +1015,7 @@ func executeComposeStep(step ComposeStep, env []string, stdinData string, verbos
 			Step:     step.Name,
 			Duration: elapsed,
 			Output:   stderr.String(),
-			ExitCode: 1,
+			ExitCode: exitCode(err),
 			Error:    err.Error(),
 		}
 	}
@@ -1,10 +1,17 @@
 package cmd
 
 import (
+	"bufio"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/msalah0e/palm/internal/registry"
 )
 
 func TestLoadComposeFile_Valid(t *testing.T) {
@@ -253,7 +260,7 @@ func TestExecuteComposeStep_ShellCommand(t *testing.T) {
 		Run:  "echo hello world",
 	}
 
-	result := executeComposeStep(step, os.Environ(), "", false)
+	result := executeComposeStep(step, nil, os.Environ(), "", false)
 
 	if result.Error != "" {
 		t.Errorf("expected no error, got %q", result.Error)
@@ -278,7 +285,7 @@ func TestExecuteComposeStep_FailingCommand(t *testing.T) {
 		Run:  "false",
 	}
 
-	result := executeComposeStep(step, os.Environ(), "", false)
+	result := executeComposeStep(step, nil, os.Environ(), "", false)
 
 	if result.Error == "" {
 		t.Error("expected error for failing command")
@@ -294,7 +301,7 @@ func TestExecuteComposeStep_WithStdin(t *testing.T) {
 		Run:  "cat",
 	}
 
-	result := executeComposeStep(step, os.Environ(), "piped input", false)
+	result := executeComposeStep(step, nil, os.Environ(), "piped input", false)
 
 	if result.Error != "" {
 		t.Errorf("unexpected error: %q", result.Error)
@@ -311,7 +318,7 @@ func TestExecuteComposeStep_Timeout(t *testing.T) {
 		Timeout: 1,
 	}
 
-	result := executeComposeStep(step, os.Environ(), "", false)
+	result := executeComposeStep(step, nil, os.Environ(), "", false)
 
 	if result.Error != "timeout" {
 		t.Errorf("expected timeout error, got %q", result.Error)
@@ -321,6 +328,141 @@ func TestExecuteComposeStep_Timeout(t *testing.T) {
 	}
 }
 
+func TestLoadComposeFile_PromptNeedsTool(t *testing.T) {
+	dir := t.TempDir()
+	for _, content := range []string{
+		"[[steps]]\nname = \"p\"\nrun = \"echo\"\nprompt = \"hi\"\n",
+		"[[steps]]\nname = \"p\"\ntool = \"aider\"\nprompt = \"hi\"\nargs = [\"--yes\"]\n",
+	} {
+		path := filepath.Join(dir, "workflow.toml")
+		os.WriteFile(path, []byte(content), 0644)
+		if _, err := loadComposeFile(path); err == nil {
+			t.Errorf("expected error for %q", content)
+		}
+	}
+}
+
+func TestComposeStepArgs(t *testing.T) {
+	reg := registry.New([]registry.Tool{{
+		Name:    "aider",
+		Install: registry.Install{Verify: registry.Verify{Command: "aider --version"}},
+		Invoke:  registry.Invoke{Prompt: "aider --message {prompt}"},
+	}})
+	cases := []struct {
+		step ComposeStep
+		want []string
+	}{
+		{ComposeStep{Run: "go test ./..."}, []string{"sh", "-c", "go test ./..."}},
+		{ComposeStep{Tool: "aider", Prompt: "review this"}, []string{"aider", "--message", "review this"}},
+		{ComposeStep{Tool: "aider", Args: []string{"--yes", "main.go"}}, []string{"aider", "--yes", "main.go"}},
+		{ComposeStep{Tool: "unknown", Prompt: "hi"}, []string{"unknown", "hi"}},
+	}
+	for _, c := range cases {
+		got := composeStepArgs(reg, c.step)
+		if strings.Join(got, "|") != strings.Join(c.want, "|") {
+			t.Errorf("composeStepArgs(%+v) = %q, want %q", c.step, got, c.want)
+		}
+	}
+}
+
+func TestLoadComposeFile_IncludesAndGroups(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "shared"), 0o755)
+	os.WriteFile(filepath.Join(dir, "shared", "common.toml"), []byte(`
+[[steps]]
+name = "fetch"
+run = "echo fetched"
+
+[[groups.lint]]
+name = "vet"
+run = "go vet ./..."
+
+[[groups.lint]]
+name = "report"
+run = "cat"
+input = "step:vet"
+depends_on = ["vet"]
+`), 0o644)
+	path := filepath.Join(dir, ".palm-compose.toml")
+	os.WriteFile(path, []byte(`include = "shared/common.toml"
+
+[[steps]]
+name = "checks"
+group = "lint"
+depends_on = ["fetch"]
+timeout = 30
+
+[[steps]]
+name = "done"
+run = "echo done"
+depends_on = ["checks"]
+`), 0o644)
+
+	cf, err := loadComposeFile(path)
+	if err != nil {
+		t.Fatalf("loadComposeFile failed: %v", err)
+	}
+	var names []string
+	byName := make(map[string]ComposeStep)
+	for _, s := range cf.Steps {
+		names = append(names, s.Name)
+		byName[s.Name] = s
+	}
+	if got := strings.Join(names, ","); got != "fetch,checks/vet,checks/report,done" {
+		t.Fatalf("flattened steps = %s", got)
+	}
+	if byName["fetch"].Origin != "shared/common.toml" {
+		t.Errorf("fetch origin = %q", byName["fetch"].Origin)
+	}
+	if deps := byName["checks/vet"].DependsOn; len(deps) != 1 || deps[0] != "fetch" {
+		t.Errorf("group entry step deps = %v", deps)
+	}
+	report := byName["checks/report"]
+	if len(report.DependsOn) != 1 || report.DependsOn[0] != "checks/vet" || report.Input != "step:checks/vet" {
+		t.Errorf("group member not rewritten: %+v", report)
+	}
+	if report.Timeout != 30 {
+		t.Errorf("expected group step timeout inherited, got %d", report.Timeout)
+	}
+	if deps := strings.Join(byName["done"].DependsOn, ","); deps != "checks/vet,checks/report" {
+		t.Errorf("depending on a group step = %s", deps)
+	}
+}
+
+func TestLoadComposeFile_IncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.toml"), []byte("include = \"b.toml\"\n[[steps]]\nname = \"a\"\nrun = \"true\"\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "b.toml"), []byte("include = [\"a.toml\"]\n"), 0o644)
+
+	_, err := loadComposeFile(filepath.Join(dir, "a.toml"))
+	if err == nil || !strings.Contains(err.Error(), "include cycle: a.toml -> b.toml -> a.toml") {
+		t.Errorf("expected include cycle error, got %v", err)
+	}
+}
+
+func TestLoadComposeFile_GroupCycle(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "workflow.toml")
+	os.WriteFile(path, []byte(`
+[[groups.a]]
+name = "inner"
+group = "b"
+
+[[groups.b]]
+name = "inner"
+group = "a"
+
+[[steps]]
+name = "start"
+group = "a"
+`), 0o644)
+
+	_, err := loadComposeFile(path)
+	if err == nil || !strings.Contains(err.Error(), "group cycle") {
+		t.Errorf("expected group cycle error, got %v", err)
+	}
+}
+
 func TestComposeInit(t *testing.T) {
 	dir := t.TempDir()
 	origDir, _ := os.Getwd()
@@ -354,3 +496,353 @@ func stepNames(steps []ComposeStep) []string {
 	}
 	return names
 }
+
+func TestLoadWorkflowCatalog(t *testing.T) {
+	builtin := fstest.MapFS{
+		"workflows/code-review.toml": {Data: []byte("name = \"code-review\"\ndescription = \"built-in review\"\n")},
+		"workflows/broken.toml":      {Data: []byte("name = ")},
+	}
+	userDir := t.TempDir()
+	os.WriteFile(filepath.Join(userDir, "code-review.toml"), []byte("description = \"team review\"\n"), 0644)
+	os.WriteFile(filepath.Join(userDir, "deploy.toml"), []byte("description = \"deploy\"\n"), 0644)
+	teamDir := t.TempDir()
+	os.WriteFile(filepath.Join(teamDir, "deploy.toml"), []byte("description = \"shared deploy\"\n"), 0644)
+	os.WriteFile(filepath.Join(teamDir, "triage.toml"), []byte("description = \"triage\"\n"), 0644)
+
+	workflows := loadWorkflowCatalog(builtin, teamDir, userDir)
+	if len(workflows) != 3 {
+		t.Fatalf("expected 3 workflows (broken skipped), got %d", len(workflows))
+	}
+	if workflows[2].Name != "triage" || workflows[2].Source != "team" {
+		t.Errorf("team workflow should be listed: %+v", workflows[2])
+	}
+	if workflows[0].Name != "code-review" || workflows[0].Source != "local" || workflows[0].Description != "team review" {
+		t.Errorf("local workflow should override built-in: %+v", workflows[0])
+	}
+	if workflows[1].Name != "deploy" {
+		t.Errorf("expected deploy second, got %q", workflows[1].Name)
+	}
+}
+
+func TestSanitizeWorkflow(t *testing.T) {
+	data := `run = "curl -H 'Authorization: Bearer my-secret-token-123' /home/alice/project"
+args = ["--key", "sk-abcdefghijklmnopqrstuvwxyz123456"]`
+
+	clean, n := sanitizeWorkflow(data, map[string]string{"API_TOKEN": "my-secret-token-123", "SHORT": "abc"}, "/home/alice")
+	if n != 2 {
+		t.Errorf("expected 2 redactions, got %d", n)
+	}
+	if strings.Contains(clean, "my-secret-token-123") || strings.Contains(clean, "sk-abcdef") {
+		t.Errorf("secrets leaked: %s", clean)
+	}
+	if !strings.Contains(clean, "$API_TOKEN") {
+		t.Errorf("expected vault value replaced with $API_TOKEN: %s", clean)
+	}
+	if !strings.Contains(clean, "~/project") {
+		t.Errorf("expected home dir replaced with ~: %s", clean)
+	}
+}
+
+func TestCatalogFileName(t *testing.T) {
+	for name, want := range map[string]string{
+		"code-review":     "code-review",
+		"My Review Flow":  "My-Review-Flow",
+		"../../.bashrc":   "bashrc",
+		"team/review":     "team-review",
+		`..\windows\evil`: "windows-evil",
+		"..":              "",
+	} {
+		if got := catalogFileName(name); got != want {
+			t.Errorf("catalogFileName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestRunCompose_ApprovalGate(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	wf := &ComposeFile{Steps: []ComposeStep{
+		{Name: "read", Run: "echo diff"},
+		{Name: "apply", Run: "echo applied", Input: "step:read", DependsOn: []string{"read"}, Approve: true},
+		{Name: "after", Run: "echo after", DependsOn: []string{"apply"}},
+	}}
+	envs := map[string][]string{"read": os.Environ(), "apply": os.Environ(), "after": os.Environ()}
+
+	var asked []string
+	var seenInput string
+	deny := func(s ComposeStep, args []string, input string) bool {
+		asked = append(asked, s.Name)
+		seenInput = input
+		return false
+	}
+	results := runCompose(wf, nil, envs, nil, false, deny)
+	if len(asked) != 1 || asked[0] != "apply" || seenInput != "diff\n" {
+		t.Fatalf("gate asked for %v with input %q", asked, seenInput)
+	}
+	if len(results) != 2 || results[1].Error != "not approved" {
+		t.Fatalf("a declined gate should stop the workflow, got %+v", results)
+	}
+
+	// A nil gate (--yes) approves everything
+	results = runCompose(wf, nil, envs, nil, false, nil)
+	if len(results) != 3 || results[1].Output != "applied\n" {
+		t.Errorf("expected all steps to run, got %+v", results)
+	}
+}
+
+func TestPlanCIJobs(t *testing.T) {
+	reg := registry.New([]registry.Tool{{
+		Name:   "aider",
+		Keys:   registry.Keys{Required: []string{"OPENAI_API_KEY"}},
+		Invoke: registry.Invoke{Prompt: "aider --message {prompt}"},
+	}})
+	wf := &ComposeFile{Name: "review", Steps: []ComposeStep{
+		{Name: "read code", Run: "cat main.go"},
+		{Name: "review", Tool: "aider", Prompt: "find bugs", Input: "step:read code,git:diff", Approve: true},
+		{Name: "stages", Run: "go test ./...", Timeout: 90, OnFail: "continue", DependsOn: []string{"review"}},
+	}}
+
+	jobs := planCIJobs(wf, reg)
+	if len(jobs) != 3 || jobs[0].ID != "read-code" || jobs[2].ID != "step-stages" {
+		t.Fatalf("job IDs: %+v", jobs)
+	}
+	read, review := jobs[0], jobs[1]
+	if !read.Export || read.Script[len(read.Script)-1] != "sh -c 'cat main.go' | tee .palm/outputs/read-code.txt" {
+		t.Errorf("read script = %q", read.Script)
+	}
+	// Reading a step's output makes it a dependency
+	if strings.Join(review.Needs, ",") != "read-code" || strings.Join(review.Inputs, ",") != "read-code" {
+		t.Errorf("review needs %v, inputs %v", review.Needs, review.Inputs)
+	}
+	want := []string{
+		"mkdir -p .palm/outputs",
+		"{ cat .palm/outputs/read-code.txt; printf '\\n\\n'; git diff; } > .palm/outputs/review.in",
+		"aider --message 'find bugs' < .palm/outputs/review.in",
+	}
+	if strings.Join(review.Script, "\n") != strings.Join(want, "\n") {
+		t.Errorf("review script = %q", review.Script)
+	}
+	if !review.NeedsPalm || strings.Join(review.Secrets, ",") != "OPENAI_API_KEY" {
+		t.Errorf("review = %+v", review)
+	}
+
+	gh := exportGitHubActions(wf, jobs)
+	for _, s := range []string{
+		"name: review\n",
+		"    name: read code\n",
+		"      OPENAI_API_KEY: ${{ secrets.OPENAI_API_KEY }}\n",
+		"    environment: compose-approval",
+		"          name: palm-read-code\n",
+		"    needs: [review]\n    timeout-minutes: 2\n    continue-on-error: true\n",
+	} {
+		if !strings.Contains(gh, s) {
+			t.Errorf("GitHub Actions export is missing %q:\n%s", s, gh)
+		}
+	}
+	gl := exportGitLabCI(wf, jobs)
+	for _, s := range []string{
+		"read-code:\n  needs: []\n",
+		"  when: manual\n  allow_failure: false",
+		"# needs CI/CD variables: OPENAI_API_KEY",
+		"    - palm install aider --yes\n",
+		"step-stages:\n  needs: [review]\n  timeout: 90 seconds\n  allow_failure: true\n",
+		"      - .palm/outputs/read-code.txt\n",
+	} {
+		if !strings.Contains(gl, s) {
+			t.Errorf("GitLab CI export is missing %q:\n%s", s, gl)
+		}
+	}
+}
+
+func TestComposeStepDirAndEnv(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "services", "api"), 0o755)
+	os.MkdirAll(filepath.Join(root, "shared"), 0o755)
+	os.WriteFile(filepath.Join(root, "shared", "steps.toml"), []byte(`
+[[groups.check]]
+name = "where"
+run = "pwd"
+`), 0o644)
+	path := filepath.Join(root, ".palm-compose.toml")
+	os.WriteFile(path, []byte(`include = "shared/steps.toml"
+
+[[steps]]
+name = "api"
+dir = "services/api"
+run = "pwd; echo $OLLAMA_HOST $GREETING"
+
+[steps.env]
+OLLAMA_HOST = "http://gpu-box:11434"
+GREETING = "hi $USER"
+
+[[steps]]
+name = "grouped"
+group = "check"
+dir = "services"
+env = { MODE = "ci" }
+`), 0o644)
+
+	cf, err := loadComposeFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api, grouped := cf.Steps[0], cf.Steps[1]
+	if got := composeStepDir(api); got != filepath.Join(root, "services", "api") {
+		t.Errorf("dir should be relative to the workflow file, got %s", got)
+	}
+	if grouped.Name != "grouped/where" || composeStepDir(grouped) != filepath.Join(root, "services") || grouped.Env["MODE"] != "ci" {
+		t.Errorf("group steps should inherit dir and env: %+v", grouped)
+	}
+
+	env := composeStepEnv([]string{"USER=ada", "OLLAMA_HOST=localhost"}, api.Env)
+	if strings.Join(env, ",") != "USER=ada,GREETING=hi ada,OLLAMA_HOST=http://gpu-box:11434" {
+		t.Errorf("env = %q", env)
+	}
+	result := executeComposeStep(api, nil, env, "", false)
+	want := filepath.Join(root, "services", "api") + "\nhttp://gpu-box:11434 hi ada\n"
+	if resolved, err := filepath.EvalSymlinks(root); err == nil && resolved != root {
+		want = strings.Replace(want, root, resolved, 1)
+	}
+	if result.Error != "" || result.Output != want {
+		t.Errorf("step ran with output %q (%s), want %q", result.Output, result.Error, want)
+	}
+
+	// The CI export moves into the dir, relative to the checkout
+	jobs := planCIJobs(cf, registry.New(nil))
+	if got := jobs[0].Script[len(jobs[0].Script)-1]; got != `(cd services/api && export GREETING="hi $USER" && export OLLAMA_HOST="http://gpu-box:11434" && pwd; echo $OLLAMA_HOST $GREETING)` {
+		t.Errorf("export script = %s", got)
+	}
+
+	os.WriteFile(path, []byte("[[steps]]\nname = \"x\"\ndir = \"missing\"\nrun = \"true\"\n"), 0o644)
+	if _, err := loadComposeFile(path); err == nil || !strings.Contains(err.Error(), "not a directory") {
+		t.Errorf("a missing dir should be rejected, got %v", err)
+	}
+	os.WriteFile(path, []byte("[[steps]]\nname = \"x\"\nrun = \"true\"\n[steps.env]\n\"A=B\" = \"c\"\n"), 0o644)
+	if _, err := loadComposeFile(path); err == nil {
+		t.Error("an env name with = should be rejected")
+	}
+}
+
+func TestComposeOutputAndExitCodes(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	wf := &ComposeFile{Name: "ci", Steps: []ComposeStep{
+		{Name: "build", Run: "echo built > app.bin", Dir: dir, Artifacts: []string{"*.bin", "missing.txt"}},
+		{Name: "lint", Run: "exit 7", DependsOn: []string{"build"}},
+		{Name: "deploy", Run: "true", DependsOn: []string{"lint"}},
+	}}
+	envs := map[string][]string{"build": os.Environ(), "lint": os.Environ(), "deploy": os.Environ()}
+
+	results := runCompose(wf, nil, envs, nil, false, nil)
+	out := composeOutput(wf, results, time.Second, "")
+	if out.Status != "failed" || out.ExitCode != composeExitStepFailed || len(out.Steps) != 3 {
+		t.Fatalf("unexpected run output %+v", out)
+	}
+	build, lint, deploy := out.Steps[0], out.Steps[1], out.Steps[2]
+	if build.Status != "ok" || len(build.Artifacts) != 1 || build.Artifacts[0] != filepath.Join(dir, "app.bin") {
+		t.Errorf("build = %+v", build)
+	}
+	if lint.Status != "failed" || lint.ExitCode != 7 {
+		t.Errorf("lint should keep the step's own exit code: %+v", lint)
+	}
+	if deploy.Status != "skipped" {
+		t.Errorf("deploy should be skipped after lint failed: %+v", deploy)
+	}
+
+	timedOut := []ComposeResult{{Step: "a"}, {Step: "b", Error: "timeout", ExitCode: -1}}
+	if code := composeExitCode(timedOut); code != composeExitTimeout {
+		t.Errorf("timeouts alone should exit %d, got %d", composeExitTimeout, code)
+	}
+	if code := composeExitCode(append(timedOut, ComposeResult{Step: "c", Error: "not approved"})); code != composeExitStepFailed {
+		t.Errorf("a failure should outrank timeouts, got %d", code)
+	}
+	if code := composeExitCode([]ComposeResult{{Step: "a"}}); code != composeExitOK {
+		t.Errorf("a passing run should exit 0, got %d", code)
+	}
+
+	if plan := composeOutput(wf, nil, 0, "dry-run"); plan.Status != "dry-run" || plan.Steps[0].Status != "planned" {
+		t.Errorf("dry-run output = %+v", plan)
+	}
+}
+
+func TestComposeUpstreamAndDebugLoop(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	wf := &ComposeFile{Steps: []ComposeStep{
+		{Name: "setup", Run: "true"},
+		{Name: "src", Run: "echo hello", DependsOn: []string{"setup"}},
+		{Name: "unrelated", Run: "echo nope"},
+		{Name: "notes", Run: "echo notes", Input: "step:src"},
+		{Name: "shout", Run: "tr a-z A-Z", Input: "step:notes, literal", DependsOn: []string{"unrelated"}},
+	}}
+	shout, _ := findComposeStep(wf, "shout")
+	if got := composeStepNames(composeUpstream(wf, shout)); strings.Join(got, ",") != "setup,src,notes" {
+		t.Fatalf("upstream = %v", got)
+	}
+
+	envs := map[string][]string{}
+	for _, s := range wf.Steps {
+		envs[s.Name] = os.Environ()
+	}
+	outputs, failed := runComposeUpstream(wf, shout, nil, envs, nil, nil)
+	if failed != "" || outputs["notes"] != "notes\n" || outputs["unrelated"] != "" {
+		t.Fatalf("outputs = %v, failed %q", outputs, failed)
+	}
+
+	// Run, swap the command through the editor, re-run, quit
+	editor := filepath.Join(t.TempDir(), "ed.sh")
+	if err := os.WriteFile(editor, []byte("#!/bin/sh\nprintf 'rev' > \"$1\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VISUAL", editor)
+	saved := stdinReader
+	defer func() { stdinReader = saved }()
+	stdinReader = bufio.NewReader(strings.NewReader("r\np\nr\n"))
+	debugComposeStep(shout, nil, os.Environ(), nil, "abc")
+
+	if got := tomlString("line one\nline two"); got != "'''\nline one\nline two'''" {
+		t.Errorf("tomlString multi-line = %q", got)
+	}
+}
+
+func TestComposeMasker(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	secret := "vault-value-1234567890"
+	env := append(os.Environ(), "SERVICE_CRED="+secret, "OPENAI_API_KEY=sk-abcdefghijklmnopqrstuvwx")
+	wf := &ComposeFile{Steps: []ComposeStep{
+		{Name: "leak", Run: "echo $SERVICE_CRED; echo ghp_" + strings.Repeat("a", 36) + " > out.log; env > env.txt", Dir: dir, Artifacts: []string{"*.log", "*.txt"}},
+		{Name: "fail", Run: "echo $OPENAI_API_KEY >&2; exit 1", DependsOn: []string{"leak"}, OnFail: "continue"},
+	}}
+	envs := map[string][]string{"leak": env, "fail": env}
+	mask := &composeMasker{keys: []string{"SERVICE_CRED"}}
+
+	results := runCompose(wf, nil, envs, mask, false, nil)
+	if len(results) != 2 || results[0].Output != "<redacted>\n" || results[1].Output != "<redacted>\n" {
+		t.Fatalf("step output should be masked: %+v", results)
+	}
+	data, _ := json.Marshal(composeOutput(wf, results, 0, ""))
+	if strings.Contains(string(data), secret) || strings.Contains(string(data), "sk-abc") {
+		t.Errorf("--output json leaks a secret: %s", data)
+	}
+	for _, name := range []string{"out.log", "env.txt"} {
+		b, _ := os.ReadFile(filepath.Join(dir, name))
+		if strings.Contains(string(b), secret) || strings.Contains(string(b), "ghp_") || !strings.Contains(string(b), "<redacted>") {
+			t.Errorf("%s should be masked:\n%s", name, b)
+		}
+	}
+
+	// Binary artifacts are left alone
+	bin := filepath.Join(dir, "blob.bin")
+	os.WriteFile(bin, []byte("\x00"+secret), 0o644)
+	if changed, err := mask.artifact(bin, env); changed || err != nil {
+		t.Errorf("a binary artifact was rewritten: %v %v", changed, err)
+	}
+
+	// --no-mask leaves output as the step printed it
+	var off *composeMasker
+	if got := off.text(secret, env); got != secret {
+		t.Errorf("a nil masker changed the text: %q", got)
+	}
+	if r := off.result(ComposeResult{Output: secret}, env); r.Output != secret {
+		t.Errorf("a nil masker changed the output: %q", r.Output)
+	}
+}
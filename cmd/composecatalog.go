@@ -0,0 +1,294 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/msalah0e/palm/internal/config"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/msalah0e/palm/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+// catalogWorkflow is a reusable workflow available to `palm compose install`.
+type catalogWorkflow struct {
+	Name        string
+	Description string
+	Source      string // "built-in", "team" or "local"
+	Data        []byte
+}
+
+// secretPatterns match well-known API key formats that must never be shared.
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`sk-(?:ant-|proj-)?[A-Za-z0-9_\-]{20,}`),
+	regexp.MustCompile(`gh[pousr]_[A-Za-z0-9]{36,}`),
+	regexp.MustCompile(`AKIA[0-9A-Z]{16}`),
+	regexp.MustCompile(`AIza[0-9A-Za-z_\-]{35}`),
+	regexp.MustCompile(`gsk_[A-Za-z0-9]{20,}`),
+	regexp.MustCompile(`xox[abposr]-[A-Za-z0-9\-]{10,}`),
+}
+
+var catalogNameChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// catalogFileName turns a workflow name into a file name for the local
+// catalog, so a name like "../x" or "a/b" can't write outside it.
+func catalogFileName(name string) string {
+	return strings.Trim(catalogNameChars.ReplaceAllString(name, "-"), "-")
+}
+
+// userWorkflowDir returns the directory holding locally published workflows.
+func userWorkflowDir() string {
+	return filepath.Join(config.ConfigDir(), "workflows")
+}
+
+// loadWorkflowCatalog merges built-in workflows with those shared by the
+// active team in teamDir and those in userDir. Local workflows override team
+// ones, which override built-in ones with the same name.
+func loadWorkflowCatalog(fsys fs.FS, teamDir, userDir string) []catalogWorkflow {
+	byName := make(map[string]catalogWorkflow)
+
+	add := func(name, source string, data []byte) {
+		var cf ComposeFile
+		if _, err := toml.Decode(string(data), &cf); err != nil {
+			return
+		}
+		byName[name] = catalogWorkflow{Name: name, Description: cf.Description, Source: source, Data: data}
+	}
+
+	if entries, err := fs.ReadDir(fsys, "workflows"); err == nil {
+		for _, e := range entries {
+			if e.IsDir() || !strings.HasSuffix(e.Name(), ".toml") {
+				continue
+			}
+			if data, err := fs.ReadFile(fsys, "workflows/"+e.Name()); err == nil {
+				add(strings.TrimSuffix(e.Name(), ".toml"), "built-in", data)
+			}
+		}
+	}
+
+	addDir := func(dir, source string) {
+		if dir == "" {
+			return
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, e := range entries {
+			if e.IsDir() || !strings.HasSuffix(e.Name(), ".toml") {
+				continue
+			}
+			if data, err := os.ReadFile(filepath.Join(dir, e.Name())); err == nil {
+				add(strings.TrimSuffix(e.Name(), ".toml"), source, data)
+			}
+		}
+	}
+	addDir(teamDir, "team")
+	addDir(userDir, "local")
+
+	result := make([]catalogWorkflow, 0, len(byName))
+	for _, w := range byName {
+		result = append(result, w)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// sanitizeWorkflow strips secrets and machine-specific paths from workflow TOML.
+// Known secret values are replaced with $NAME references so the workflow still
+// works when the recipient has the same key in their vault.
+func sanitizeWorkflow(data string, secrets map[string]string, home string) (string, int) {
+	replaced := 0
+
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	// Longest values first so overlapping secrets are replaced whole
+	sort.Slice(names, func(i, j int) bool { return len(secrets[names[i]]) > len(secrets[names[j]]) })
+	for _, name := range names {
+		val := secrets[name]
+		if len(val) < 8 {
+			continue
+		}
+		if n := strings.Count(data, val); n > 0 {
+			data = strings.ReplaceAll(data, val, "$"+name)
+			replaced += n
+		}
+	}
+
+	for _, re := range secretPatterns {
+		data = re.ReplaceAllStringFunc(data, func(string) string {
+			replaced++
+			return "<redacted>"
+		})
+	}
+
+	if home != "" && home != "/" {
+		data = strings.ReplaceAll(data, home, "~")
+	}
+	return data, replaced
+}
+
+func composeCatalogCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "catalog",
+		Aliases: []string{"library"},
+		Short:   "List reusable workflows available to install",
+		Run: func(cmd *cobra.Command, args []string) {
+			ui.Banner("compose catalog")
+
+			workflows := loadWorkflowCatalog(workflowFS, teamWorkflowDir(), userWorkflowDir())
+			if len(workflows) == 0 {
+				fmt.Println("  No workflows in catalog")
+				return
+			}
+
+			var rows [][]string
+			for _, w := range workflows {
+				rows = append(rows, []string{w.Name, w.Source, w.Description})
+			}
+			ui.Table([]string{"Name", "Source", "Description"}, rows)
+			fmt.Println()
+			ui.Subtle.Println("  Install with: palm compose install <name>")
+		},
+	}
+}
+
+func composeInstallCmd() *cobra.Command {
+	var output string
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "install <name>",
+		Short: "Copy a catalog workflow into the current directory",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			name := args[0]
+
+			var found *catalogWorkflow
+			for _, w := range loadWorkflowCatalog(workflowFS, teamWorkflowDir(), userWorkflowDir()) {
+				if w.Name == name {
+					found = &w
+					break
+				}
+			}
+			if found == nil {
+				ui.Bad.Printf("  Unknown workflow: %s\n", name)
+				fmt.Println("  Run 'palm compose catalog' to see available workflows")
+				os.Exit(1)
+			}
+
+			if _, err := os.Stat(output); err == nil && !force {
+				ui.Warn.Printf("  %s already exists — use --output or --force\n", output)
+				os.Exit(1)
+			}
+
+			if err := os.WriteFile(output, found.Data, 0o644); err != nil {
+				ui.Bad.Printf("  Failed to write %s: %v\n", output, err)
+				os.Exit(1)
+			}
+
+			ui.Good.Printf("  %s Installed %s → %s\n", ui.StatusIcon(true), ui.Brand.Sprint(name), output)
+			if output == ".palm-compose.toml" {
+				fmt.Println("  Run it with: palm compose")
+			} else {
+				fmt.Printf("  Run it with: palm compose --file %s\n", output)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", ".palm-compose.toml", "Destination file")
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite an existing file")
+	return cmd
+}
+
+func composePublishCmd() *cobra.Command {
+	var (
+		file      string
+		output    string
+		toCatalog bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "publish",
+		Short: "Export a sanitized copy of a workflow for sharing",
+		Long: `Export a workflow with secrets and machine-specific paths removed.
+
+Values of vault keys are replaced with $KEY references, common API key
+formats are redacted, and your home directory is replaced with ~.
+
+Examples:
+  palm compose publish > review.toml          # Print sanitized workflow
+  palm compose publish -o shared/review.toml  # Write to a file
+  palm compose publish --catalog              # Add to your local catalog`,
+		Run: func(cmd *cobra.Command, args []string) {
+			wf, err := loadComposeFile(file)
+			if err != nil {
+				ui.Bad.Printf("  Failed to load workflow: %v\n", err)
+				os.Exit(1)
+			}
+			path, _ := resolveComposePath(file)
+			raw, err := os.ReadFile(path)
+			if err != nil {
+				ui.Bad.Printf("  Failed to read workflow: %v\n", err)
+				os.Exit(1)
+			}
+
+			secrets := make(map[string]string)
+			v := vault.New()
+			if keys, err := v.List(); err == nil {
+				for _, k := range keys {
+					if val, err := v.Get(k); err == nil {
+						secrets[k] = val
+					}
+				}
+			}
+			home, _ := os.UserHomeDir()
+			clean, redacted := sanitizeWorkflow(string(raw), secrets, home)
+			if len(wf.Include) > 0 {
+				fmt.Fprintf(os.Stderr, "  %s Included files are not bundled: %s\n", ui.WarnIcon(), strings.Join(wf.Include, ", "))
+			}
+
+			if toCatalog {
+				name := catalogFileName(wf.Name)
+				if name == "" {
+					name = strings.TrimSuffix(filepath.Base(path), ".toml")
+				}
+				output = filepath.Join(userWorkflowDir(), name+".toml")
+				if err := os.MkdirAll(userWorkflowDir(), 0o755); err != nil {
+					ui.Bad.Printf("  Failed to create %s: %v\n", userWorkflowDir(), err)
+					os.Exit(1)
+				}
+			}
+
+			if output == "" {
+				fmt.Print(clean)
+				if redacted > 0 {
+					fmt.Fprintf(os.Stderr, "  %s Removed %d secret value(s)\n", ui.WarnIcon(), redacted)
+				}
+				return
+			}
+
+			if err := os.WriteFile(output, []byte(clean), 0o644); err != nil {
+				ui.Bad.Printf("  Failed to write %s: %v\n", output, err)
+				os.Exit(1)
+			}
+			ui.Good.Printf("  %s Published %s → %s\n", ui.StatusIcon(true), ui.Brand.Sprint(wf.Name), output)
+			if redacted > 0 {
+				ui.Warn.Printf("  %s Removed %d secret value(s)\n", ui.WarnIcon(), redacted)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&file, "file", "f", ".palm-compose.toml", "Workflow file to publish")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write to file instead of stdout")
+	cmd.Flags().BoolVar(&toCatalog, "catalog", false, "Save into ~/.config/palm/workflows for 'palm compose install'")
+	return cmd
+}
@@ -0,0 +1,362 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/msalah0e/palm/internal/registry"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/msalah0e/palm/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+func composeRunCmd() *cobra.Command {
+	var (
+		file        string
+		step        string
+		interactive bool
+		allKeys     bool
+		yes         bool
+		noMask      bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "run --step <name>",
+		Short: "Run one workflow step in isolation, optionally as a debugger",
+		Long: `Run a single step of a workflow on its own and show the input it resolves
+to. Steps its input reads from (input = "step:...") run first, once, so the
+input is the real one.
+
+With --interactive the step becomes a debugging loop: edit its input or its
+prompt (the command, for run steps) in $EDITOR and re-run it as often as you
+like without re-running the pipeline. Edits stay in the session; palm prints
+the changed prompt on exit so you can copy it back into the workflow file.
+
+Examples:
+  palm compose run --step review                 # Run one step and show its output
+  palm compose run --step review --interactive   # Iterate on its prompt and input
+  palm compose run -f review.toml --step checks/vet`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			wf, err := loadComposeFile(file)
+			if err != nil {
+				ui.Bad.Printf("  Failed to load workflow: %v\n", err)
+				os.Exit(composeExitInvalid)
+			}
+			s, ok := findComposeStep(wf, step)
+			if !ok {
+				ui.Bad.Printf("  No step %q in the workflow\n", step)
+				fmt.Printf("  Steps: %s\n", strings.Join(composeStepNames(wf.Steps), ", "))
+				os.Exit(composeExitInvalid)
+			}
+
+			ui.Banner("compose run")
+			fmt.Printf("  Step:     %s\n", ui.Brand.Sprint(s.Name))
+			if s.Origin != "" {
+				fmt.Printf("  From:     %s\n", ui.Subtle.Sprint(s.Origin))
+			}
+			fmt.Println()
+
+			reg := loadRegistry()
+			v := vault.New()
+			envs := composeEnvs(v, reg, wf, allKeys)
+			mask := newComposeMasker(v, noMask)
+			gate := confirmComposeStep
+			if yes {
+				gate = nil
+			}
+
+			outputs, failed := runComposeUpstream(wf, s, reg, envs, mask, gate)
+			if failed != "" && !interactive {
+				ui.Bad.Printf("  Upstream step %s failed; not running %s\n", failed, s.Name)
+				os.Exit(composeExitStepFailed)
+			}
+			var mu sync.Mutex
+			input := ""
+			if s.Input != "" {
+				input = resolveInput(s.Input, outputs, &mu)
+			}
+
+			if interactive {
+				debugComposeStep(s, reg, envs[s.Name], mask, input)
+				return
+			}
+
+			printComposeStepInput(s, composeStepArgs(reg, s), input)
+			r := executeComposeStep(s, reg, envs[s.Name], input, false)
+			r.Artifacts = composeArtifacts(s)
+			r = mask.result(r, envs[s.Name])
+			recordComposeStep(wf, s, r)
+			printComposeStepResult(r)
+			if code := composeExitCode([]ComposeResult{r}); code != composeExitOK {
+				os.Exit(code)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&file, "file", "f", ".palm-compose.toml", "Workflow file path")
+	cmd.Flags().StringVar(&step, "step", "", "Name of the step to run (required)")
+	cmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Edit the input and prompt in $EDITOR and re-run the step in a loop")
+	cmd.Flags().BoolVar(&allKeys, "all-keys", false, "Inject every vault key, not just the ones the steps' tools need")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Approve every approval gate without asking")
+	cmd.Flags().BoolVar(&noMask, "no-mask", false, "Show step output with secrets unmasked")
+	_ = cmd.MarkFlagRequired("step")
+	_ = cmd.RegisterFlagCompletionFunc("step", composeStepCompletionFunc)
+	_ = cmd.MarkFlagFilename("file", "toml")
+	return cmd
+}
+
+func findComposeStep(wf *ComposeFile, name string) (ComposeStep, bool) {
+	for _, s := range wf.Steps {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return ComposeStep{}, false
+}
+
+func composeStepNames(steps []ComposeStep) []string {
+	names := make([]string, len(steps))
+	for i, s := range steps {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// composeInputSteps returns the steps an input reads from ("step:name").
+func composeInputSteps(input string) []string {
+	var names []string
+	for _, part := range strings.Split(input, ",") {
+		if name, ok := strings.CutPrefix(strings.TrimSpace(part), "step:"); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// composeUpstream returns, in workflow order, the steps that must run before
+// step to resolve its input: the steps it reads from, and everything those
+// depend on or read from in turn.
+func composeUpstream(wf *ComposeFile, step ComposeStep) []ComposeStep {
+	byName := make(map[string]ComposeStep, len(wf.Steps))
+	for _, s := range wf.Steps {
+		byName[s.Name] = s
+	}
+	need := make(map[string]bool)
+	var visit func(name string)
+	visit = func(name string) {
+		s, ok := byName[name]
+		if !ok || need[name] || name == step.Name {
+			return
+		}
+		need[name] = true
+		for _, dep := range s.DependsOn {
+			visit(dep)
+		}
+		for _, ref := range composeInputSteps(s.Input) {
+			visit(ref)
+		}
+	}
+	for _, ref := range composeInputSteps(step.Input) {
+		visit(ref)
+	}
+
+	var upstream []ComposeStep
+	for _, s := range wf.Steps {
+		if need[s.Name] {
+			// Only the upstream steps run, so drop dependencies outside them
+			var deps []string
+			for _, d := range s.DependsOn {
+				if need[d] {
+					deps = append(deps, d)
+				}
+			}
+			s.DependsOn = deps
+			upstream = append(upstream, s)
+		}
+	}
+	return upstream
+}
+
+// runComposeUpstream runs the steps step's input reads from and returns
+// their outputs by step name, and the first upstream step that failed.
+func runComposeUpstream(wf *ComposeFile, step ComposeStep, reg *registry.Registry, envs map[string][]string, mask *composeMasker, gate composeGate) (map[string]string, string) {
+	outputs := make(map[string]string)
+	upstream := composeUpstream(wf, step)
+	if len(upstream) == 0 {
+		return outputs, ""
+	}
+
+	fmt.Printf("  %s Resolving input from %s\n", ui.Info.Sprint("⟳"), strings.Join(composeStepNames(upstream), ", "))
+	sub := &ComposeFile{Name: wf.Name, Steps: upstream, BaseDir: wf.BaseDir}
+	failed := ""
+	for _, r := range runCompose(sub, reg, envs, mask, false, gate) {
+		outputs[r.Step] = r.Output
+		if r.Error != "" && failed == "" {
+			failed = r.Step
+		}
+	}
+	fmt.Println()
+	return outputs, failed
+}
+
+// debugComposeStep runs step in a loop, letting the input and the prompt or
+// command be edited between runs.
+func debugComposeStep(step ComposeStep, reg *registry.Registry, env []string, mask *composeMasker, input string) {
+	original := composeStepSource(step)
+	ran := false
+	for {
+		printComposeStepInput(step, composeStepArgs(reg, step), input)
+
+		run := "[r]un"
+		if ran {
+			run = "[r]e-run"
+		}
+		choice := readChoice(fmt.Sprintf("  %s  [e]dit input  [p] edit %s  [q]uit > ", run, composeStepSourceLabel(step)))
+		switch choice {
+		case "r", "":
+			r := executeComposeStep(step, reg, env, input, false)
+			r.Artifacts = composeArtifacts(step)
+			r = mask.result(r, env)
+			printComposeStepResult(r)
+			ran = true
+		case "e":
+			edited, err := editText(input, ".txt")
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				continue
+			}
+			input = edited
+		case "p":
+			edited, err := editText(composeStepSource(step), ".txt")
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				continue
+			}
+			setComposeStepSource(&step, strings.TrimRight(edited, "\n"))
+		case "q":
+			if src := composeStepSource(step); src != original {
+				fmt.Printf("  %s changed — to keep it, update the step in the workflow file:\n\n", composeStepSourceLabel(step))
+				fmt.Printf("  %s = %s\n\n", composeStepSourceLabel(step), tomlString(src))
+			}
+			return
+		default:
+			ui.Warn.Printf("  Unknown choice %q\n", choice)
+		}
+	}
+}
+
+// composeStepSource is what the debugger lets you edit besides the input:
+// a tool step's prompt, or a run step's command.
+func composeStepSource(s ComposeStep) string {
+	if s.Run != "" {
+		return s.Run
+	}
+	return s.Prompt
+}
+
+func composeStepSourceLabel(s ComposeStep) string {
+	if s.Run != "" {
+		return "run"
+	}
+	return "prompt"
+}
+
+func setComposeStepSource(s *ComposeStep, src string) {
+	if s.Run != "" {
+		s.Run = src
+		return
+	}
+	s.Prompt = src
+}
+
+// tomlString quotes s as a TOML string, using a multi-line literal string
+// when it spans lines.
+func tomlString(s string) string {
+	if strings.Contains(s, "\n") && !strings.Contains(s, "'''") {
+		return "'''\n" + s + "'''"
+	}
+	return fmt.Sprintf("%q", s)
+}
+
+func printComposeStepInput(step ComposeStep, cmdArgs []string, input string) {
+	fmt.Printf("  command: %s\n", ui.Subtle.Sprint(strings.Join(cmdArgs, " ")))
+	if dir := composeStepDir(step); dir != "" {
+		fmt.Printf("  in:      %s\n", dir)
+	}
+	if input == "" {
+		fmt.Printf("  input:   %s\n", ui.Subtle.Sprint("(none)"))
+	} else {
+		fmt.Printf("  input (%d bytes):\n", len(input))
+		printTruncatedOutput(input, 2000)
+	}
+	fmt.Println()
+}
+
+func printComposeStepResult(r ComposeResult) {
+	if r.Error != "" {
+		ui.Bad.Printf("  %s %s failed in %.2fs: %s\n", ui.StatusIcon(false), r.Step, r.Duration.Seconds(), r.Error)
+	} else {
+		fmt.Printf("  %s %s completed in %.2fs\n", ui.StatusIcon(true), ui.Brand.Sprint(r.Step), r.Duration.Seconds())
+	}
+	if out := strings.TrimSpace(r.Output); out != "" {
+		fmt.Println()
+		for _, line := range strings.Split(out, "\n") {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+	for _, a := range r.Artifacts {
+		fmt.Printf("  artifact: %s\n", a)
+	}
+	fmt.Println()
+}
+
+// readChoice prints prompt and reads a one-word answer, lowercased. End of
+// input reads as "q".
+func readChoice(prompt string) string {
+	fmt.Print(prompt)
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Println()
+		return "q"
+	}
+	return strings.ToLower(strings.TrimSpace(line))
+}
+
+// editText opens text in $VISUAL or $EDITOR (vi by default) and returns
+// what was saved.
+func editText(text, suffix string) (string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	f, err := os.CreateTemp("", "palm-*"+suffix)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		return "", err
+	}
+	f.Close()
+
+	fields := strings.Fields(editor)
+	c := exec.Command(fields[0], append(fields[1:], f.Name())...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := c.Run(); err != nil {
+		return "", fmt.Errorf("editor %s failed: %v", fields[0], err)
+	}
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
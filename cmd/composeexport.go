@@ -0,0 +1,394 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/msalah0e/palm/internal/registry"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// palmInstallCommand installs palm on a CI runner.
+const palmInstallCommand = "curl -fsSL https://msalah0e.github.io/palm/install.sh | sh"
+
+// ciOutputDir holds step outputs that other jobs read as input.
+const ciOutputDir = ".palm/outputs"
+
+func composeExportCmd() *cobra.Command {
+	var file, format, output string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Translate a workflow into a GitHub Actions or GitLab CI pipeline",
+		Long: `Export a workflow as a CI pipeline skeleton, so a pipeline prototyped
+locally can be promoted to CI without rewriting it.
+
+Each step becomes a job. depends_on becomes needs, timeout becomes the job
+timeout, on_fail = "continue" lets the pipeline go on past a failure, dir
+and env are set in the job's script, and
+approve = true becomes a protected environment (GitHub) or a manual job
+(GitLab). Step outputs used as input travel between jobs as artifacts.
+Vault keys the steps' tools need are mapped to CI secrets of the same name;
+palm never exports their values.
+
+Formats: github-actions, gitlab-ci
+
+  palm compose export --format github-actions -o .github/workflows/review.yml
+  palm compose export --format gitlab-ci -o .gitlab-ci.yml`,
+		Run: func(cmd *cobra.Command, args []string) {
+			wf, err := loadComposeFile(file)
+			if err != nil {
+				ui.Bad.Printf("  Failed to load workflow: %v\n", err)
+				os.Exit(1)
+			}
+
+			jobs := planCIJobs(wf, loadRegistry())
+			var out string
+			switch format {
+			case "github-actions", "github":
+				out = exportGitHubActions(wf, jobs)
+			case "gitlab-ci", "gitlab":
+				out = exportGitLabCI(wf, jobs)
+			default:
+				ui.Bad.Printf("  Unknown format %q (use github-actions or gitlab-ci)\n", format)
+				os.Exit(1)
+			}
+
+			if output == "" {
+				fmt.Print(out)
+				return
+			}
+			if err := os.WriteFile(output, []byte(out), 0o644); err != nil {
+				ui.Bad.Printf("  Failed to write %s: %v\n", output, err)
+				os.Exit(1)
+			}
+			ui.Good.Printf("  %s Wrote %s (%d jobs)\n", ui.StatusIcon(true), output, len(jobs))
+			if secrets := ciSecrets(jobs); len(secrets) > 0 {
+				fmt.Printf("  Add these CI secrets: %s\n", ui.Info.Sprint(strings.Join(secrets, ", ")))
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&file, "file", "f", ".palm-compose.toml", "Workflow file path")
+	cmd.Flags().StringVar(&format, "format", "github-actions", "CI format: github-actions or gitlab-ci")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write the pipeline to this file instead of stdout")
+	return cmd
+}
+
+// ciJob is a compose step prepared for a CI job.
+type ciJob struct {
+	ID        string
+	Step      ComposeStep
+	Needs     []string // job IDs
+	Inputs    []string // job IDs whose output artifact this job reads
+	Script    []string // shell lines
+	Secrets   []string
+	NeedsPalm bool // the job runs a registry tool or packs context
+	Export    bool // another job reads this job's output
+}
+
+var ciJobIDChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// ciReservedIDs are top-level GitLab CI keywords that can't name a job.
+var ciReservedIDs = map[string]bool{
+	"image": true, "services": true, "stages": true, "types": true, "before_script": true,
+	"after_script": true, "variables": true, "cache": true, "include": true, "default": true,
+	"workflow": true, "pages": true,
+}
+
+// planCIJobs turns the workflow's steps into CI jobs, in file order.
+func planCIJobs(wf *ComposeFile, reg *registry.Registry) []*ciJob {
+	ids := make(map[string]string, len(wf.Steps))
+	used := make(map[string]bool)
+	for _, s := range wf.Steps {
+		id := strings.Trim(ciJobIDChars.ReplaceAllString(s.Name, "-"), "-")
+		if id == "" || (id[0] >= '0' && id[0] <= '9') || ciReservedIDs[id] {
+			id = "step-" + id
+		}
+		for base, n := id, 2; used[id]; n++ {
+			id = fmt.Sprintf("%s-%d", base, n)
+		}
+		used[id] = true
+		ids[s.Name] = id
+	}
+
+	// Steps whose output is another step's input save it as an artifact
+	exported := make(map[string]bool)
+	for _, s := range wf.Steps {
+		for _, part := range strings.Split(s.Input, ",") {
+			if name, ok := strings.CutPrefix(strings.TrimSpace(part), "step:"); ok {
+				exported[name] = true
+			}
+		}
+	}
+
+	jobs := make([]*ciJob, 0, len(wf.Steps))
+	for _, s := range wf.Steps {
+		j := &ciJob{ID: ids[s.Name], Step: s, NeedsPalm: s.Tool != "", Export: exported[s.Name]}
+		for _, dep := range s.DependsOn {
+			j.Needs = appendUnique(j.Needs, ids[dep])
+		}
+		j.Secrets = toolKeys(reg, []string{composeStepTool(s)})
+		sort.Strings(j.Secrets)
+
+		// Build the step's stdin the way resolveInput does
+		var parts []string
+		if s.Input != "" {
+			for _, part := range strings.Split(s.Input, ",") {
+				part = strings.TrimSpace(part)
+				switch {
+				case strings.HasPrefix(part, "step:"):
+					id, ok := ids[strings.TrimPrefix(part, "step:")]
+					if !ok {
+						continue
+					}
+					j.Inputs = appendUnique(j.Inputs, id)
+					j.Needs = appendUnique(j.Needs, id)
+					parts = append(parts, "cat "+ciOutputDir+"/"+id+".txt")
+				case strings.HasPrefix(part, "file:"):
+					parts = append(parts, "cat "+shellQuote(strings.TrimPrefix(part, "file:")))
+				case part == "git:diff":
+					parts = append(parts, "git diff")
+				case part == "git:log":
+					parts = append(parts, "git log --oneline -10")
+				case strings.HasPrefix(part, "git:"):
+				case strings.HasPrefix(part, "context:"):
+					j.NeedsPalm = true
+					parts = append(parts, "palm context pack "+shellQuote(strings.TrimPrefix(part, "context:")))
+				default:
+					parts = append(parts, "printf '%s' "+shellQuote(part))
+				}
+			}
+		}
+
+		command := s.Run
+		if s.Tool != "" {
+			args := composeStepArgs(reg, s)
+			for i, a := range args {
+				args[i] = shellQuote(a)
+			}
+			command = strings.Join(args, " ")
+		} else if len(parts) > 0 || j.Export {
+			// Redirections must apply to the whole command
+			command = "sh -c " + shellQuote(s.Run)
+		}
+		if setup := ciStepSetup(wf, s); setup != "" {
+			// A subshell, so input and output paths stay relative to the checkout
+			command = "(" + setup + command + ")"
+		}
+		if len(parts) > 0 || j.Export {
+			j.Script = append(j.Script, "mkdir -p "+ciOutputDir)
+		}
+		if len(parts) > 0 {
+			in := ciOutputDir + "/" + j.ID + ".in"
+			j.Script = append(j.Script, "{ "+strings.Join(parts, "; printf '\\n\\n'; ")+"; } > "+in)
+			command += " < " + in
+		}
+		if j.Export {
+			command += " | tee " + ciOutputDir + "/" + j.ID + ".txt"
+		}
+		j.Script = append(j.Script, command)
+		jobs = append(jobs, j)
+	}
+	return jobs
+}
+
+// ciStepSetup returns the shell that moves into a step's dir, relative to
+// the workflow file, and sets its env, each followed by "&& ".
+func ciStepSetup(wf *ComposeFile, s ComposeStep) string {
+	var b strings.Builder
+	if dir := composeStepDir(s); dir != "" {
+		if rel, err := filepath.Rel(wf.BaseDir, dir); err == nil {
+			dir = rel
+		}
+		b.WriteString("cd " + shellQuote(filepath.ToSlash(dir)) + " && ")
+	}
+	for _, kv := range sortedEnv(s.Env) {
+		k, v, _ := strings.Cut(kv, "=")
+		// Double quotes, so $VAR expands on the runner as it does locally
+		v = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`").Replace(v)
+		b.WriteString("export " + k + "=\"" + v + "\" && ")
+	}
+	return b.String()
+}
+
+// exportGitHubActions renders jobs as a GitHub Actions workflow.
+func exportGitHubActions(wf *ComposeFile, jobs []*ciJob) string {
+	var b strings.Builder
+	writeCIHeader(&b, wf, jobs, "Settings → Secrets and variables → Actions")
+	fmt.Fprintf(&b, "name: %s\n\n", yamlString(ciWorkflowName(wf)))
+	b.WriteString("on:\n  workflow_dispatch:\n  # push:\n  #   branches: [main]\n\n")
+	b.WriteString("defaults:\n  run:\n    shell: bash # -eo pipefail, so a failing step fails its job\n\n")
+	b.WriteString("jobs:\n")
+	for i, j := range jobs {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "  %s:\n", j.ID)
+		if j.ID != j.Step.Name {
+			fmt.Fprintf(&b, "    name: %s\n", yamlString(j.Step.Name))
+		}
+		b.WriteString("    runs-on: ubuntu-latest\n")
+		if len(j.Needs) > 0 {
+			fmt.Fprintf(&b, "    needs: [%s]\n", strings.Join(j.Needs, ", "))
+		}
+		if j.Step.Timeout > 0 {
+			fmt.Fprintf(&b, "    timeout-minutes: %d\n", (j.Step.Timeout+59)/60)
+		}
+		if j.Step.OnFail == "continue" {
+			b.WriteString("    continue-on-error: true\n")
+		}
+		if j.Step.Approve {
+			b.WriteString("    environment: compose-approval # add required reviewers to this environment\n")
+		}
+		if len(j.Secrets) > 0 {
+			b.WriteString("    env:\n")
+			for _, key := range j.Secrets {
+				fmt.Fprintf(&b, "      %s: ${{ secrets.%s }}\n", key, key)
+			}
+		}
+		b.WriteString("    steps:\n")
+		b.WriteString("      - uses: actions/checkout@v4\n")
+		if j.NeedsPalm {
+			fmt.Fprintf(&b, "      - name: Install palm\n        run: %s\n", palmInstallCommand)
+			if j.Step.Tool != "" {
+				fmt.Fprintf(&b, "      - name: Install %s\n        run: palm install %s --yes\n", j.Step.Tool, shellQuote(j.Step.Tool))
+			}
+		}
+		for _, id := range j.Inputs {
+			fmt.Fprintf(&b, "      - uses: actions/download-artifact@v4\n        with:\n          name: palm-%s\n          path: %s\n", id, ciOutputDir)
+		}
+		fmt.Fprintf(&b, "      - name: %s\n        run: |\n", yamlString(j.Step.Name))
+		writeCIScript(&b, j.Script, "          ")
+		if j.Export {
+			fmt.Fprintf(&b, "      - uses: actions/upload-artifact@v4\n        with:\n          name: palm-%s\n          path: %s/%s.txt\n", j.ID, ciOutputDir, j.ID)
+		}
+	}
+	return b.String()
+}
+
+// exportGitLabCI renders jobs as a GitLab CI pipeline.
+func exportGitLabCI(wf *ComposeFile, jobs []*ciJob) string {
+	var b strings.Builder
+	writeCIHeader(&b, wf, jobs, "Settings → CI/CD → Variables")
+	for i, j := range jobs {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		if j.ID != j.Step.Name {
+			fmt.Fprintf(&b, "# step %s\n", j.Step.Name)
+		}
+		fmt.Fprintf(&b, "%s:\n", j.ID)
+		if len(j.Needs) > 0 {
+			fmt.Fprintf(&b, "  needs: [%s]\n", strings.Join(j.Needs, ", "))
+		} else {
+			b.WriteString("  needs: []\n")
+		}
+		if j.Step.Timeout > 0 {
+			fmt.Fprintf(&b, "  timeout: %d seconds\n", j.Step.Timeout)
+		}
+		if j.Step.Approve {
+			b.WriteString("  when: manual\n")
+		}
+		switch {
+		case j.Step.OnFail == "continue":
+			b.WriteString("  allow_failure: true\n")
+		case j.Step.Approve:
+			b.WriteString("  allow_failure: false # block later jobs until approved\n")
+		}
+		if len(j.Secrets) > 0 {
+			fmt.Fprintf(&b, "  # needs CI/CD variables: %s\n", strings.Join(j.Secrets, ", "))
+		}
+		if j.NeedsPalm {
+			b.WriteString("  before_script:\n")
+			fmt.Fprintf(&b, "    - %s\n", palmInstallCommand)
+			if j.Step.Tool != "" {
+				fmt.Fprintf(&b, "    - palm install %s --yes\n", shellQuote(j.Step.Tool))
+			}
+		}
+		b.WriteString("  script:\n    - |\n")
+		script := j.Script
+		if j.Export {
+			script = append([]string{"set -o pipefail"}, script...)
+		}
+		writeCIScript(&b, script, "      ")
+		if j.Export {
+			fmt.Fprintf(&b, "  artifacts:\n    paths:\n      - %s/%s.txt\n", ciOutputDir, j.ID)
+		}
+	}
+	return b.String()
+}
+
+func writeCIHeader(b *strings.Builder, wf *ComposeFile, jobs []*ciJob, secretsHint string) {
+	fmt.Fprintf(b, "# Generated by palm compose export from %q.\n", ciWorkflowName(wf))
+	if wf.Description != "" {
+		fmt.Fprintf(b, "# %s\n", wf.Description)
+	}
+	b.WriteString("# Review before committing: tools may need runner setup beyond palm install.\n")
+	if secrets := ciSecrets(jobs); len(secrets) > 0 {
+		fmt.Fprintf(b, "# Secrets to add (%s): %s\n", secretsHint, strings.Join(secrets, ", "))
+	}
+	b.WriteString("\n")
+}
+
+func writeCIScript(b *strings.Builder, script []string, indent string) {
+	for _, line := range script {
+		for _, l := range strings.Split(line, "\n") {
+			if l == "" {
+				b.WriteString("\n")
+				continue
+			}
+			b.WriteString(indent + l + "\n")
+		}
+	}
+}
+
+func ciWorkflowName(wf *ComposeFile) string {
+	if wf.Name != "" {
+		return wf.Name
+	}
+	return "palm compose"
+}
+
+// ciSecrets lists every job's secrets, sorted and without duplicates.
+func ciSecrets(jobs []*ciJob) []string {
+	var all []string
+	for _, j := range jobs {
+		for _, key := range j.Secrets {
+			all = appendUnique(all, key)
+		}
+	}
+	sort.Strings(all)
+	return all
+}
+
+var yamlPlain = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_ ./()-]*$`)
+
+// yamlString returns s as a YAML scalar, quoting it unless it's plain.
+func yamlString(s string) string {
+	if yamlPlain.MatchString(s) && !strings.HasSuffix(s, " ") {
+		switch strings.ToLower(s) {
+		case "true", "false", "yes", "no", "on", "off", "null", "~":
+		default:
+			if _, err := strconv.ParseFloat(s, 64); err != nil {
+				return s
+			}
+		}
+	}
+	return strconv.Quote(s)
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
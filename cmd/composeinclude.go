@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// composeIncludes lists workflow files to include. A single path is accepted
+// as well as a list.
+type composeIncludes []string
+
+// UnmarshalTOML implements toml.Unmarshaler.
+func (c *composeIncludes) UnmarshalTOML(v any) error {
+	switch v := v.(type) {
+	case string:
+		*c = []string{v}
+	case []any:
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("include entries must be file paths")
+			}
+			*c = append(*c, s)
+		}
+	default:
+		return fmt.Errorf("include must be a file path or a list of them")
+	}
+	return nil
+}
+
+// readComposeTree decodes the workflow at path and folds in its includes:
+// included steps run first, and the file's own groups override included ones
+// of the same name. stack holds the files being read, to report cycles.
+func readComposeTree(path string, stack []string) (*ComposeFile, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if i := slices.Index(stack, abs); i >= 0 {
+		var chain []string
+		for _, p := range append(stack[i:], abs) {
+			chain = append(chain, filepath.Base(p))
+		}
+		return nil, fmt.Errorf("include cycle: %s", strings.Join(chain, " -> "))
+	}
+
+	var cf ComposeFile
+	if _, err := toml.DecodeFile(abs, &cf); err != nil {
+		if len(stack) > 0 {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(abs), err)
+		}
+		return nil, err
+	}
+
+	cf.BaseDir = filepath.Dir(abs)
+	for i := range cf.Steps {
+		cf.Steps[i].BaseDir = cf.BaseDir
+	}
+	for _, g := range cf.Groups {
+		for i := range g {
+			g[i].BaseDir = cf.BaseDir
+		}
+	}
+
+	stack = append(stack, abs)
+	var steps []ComposeStep
+	groups := make(map[string][]ComposeStep)
+	for _, inc := range cf.Include {
+		incPath := inc
+		if strings.HasPrefix(incPath, "~/") {
+			home, _ := os.UserHomeDir()
+			incPath = filepath.Join(home, incPath[2:])
+		} else if !filepath.IsAbs(incPath) {
+			incPath = filepath.Join(filepath.Dir(abs), incPath)
+		}
+		sub, err := readComposeTree(incPath, stack)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range sub.Steps {
+			if s.Origin == "" {
+				s.Origin = inc
+			}
+			steps = append(steps, s)
+		}
+		for name, g := range sub.Groups {
+			groups[name] = g
+		}
+	}
+	for name, g := range cf.Groups {
+		groups[name] = g
+	}
+
+	cf.Steps = append(steps, cf.Steps...)
+	cf.Groups = groups
+	return &cf, nil
+}
+
+// expandGroups replaces each step that uses a group with the group's steps,
+// named "<step>/<group step>". The group's first steps wait for the using
+// step's dependencies, and depending on the using step means waiting for
+// all of the group. stack holds the groups being expanded, to report cycles.
+func expandGroups(steps []ComposeStep, groups map[string][]ComposeStep, stack []string) ([]ComposeStep, error) {
+	var out []ComposeStep
+	expanded := make(map[string][]string)
+
+	for _, s := range steps {
+		if s.Group == "" {
+			out = append(out, s)
+			continue
+		}
+		if s.Run != "" || s.Tool != "" {
+			return nil, fmt.Errorf("step '%s': 'group' cannot be combined with 'run' or 'tool'", s.Name)
+		}
+		members, ok := groups[s.Group]
+		if !ok {
+			return nil, fmt.Errorf("step '%s' uses unknown group '%s'", s.Name, s.Group)
+		}
+		if slices.Contains(stack, s.Group) {
+			return nil, fmt.Errorf("group cycle: %s -> %s", strings.Join(stack, " -> "), s.Group)
+		}
+		inner, err := expandGroups(members, groups, append(stack, s.Group))
+		if err != nil {
+			return nil, err
+		}
+
+		local := make(map[string]bool, len(inner))
+		for _, m := range inner {
+			local[m.Name] = true
+		}
+		prefix := s.Name + "/"
+		for _, m := range inner {
+			entry := true
+			var deps []string
+			for _, d := range m.DependsOn {
+				if local[d] {
+					d, entry = prefix+d, false
+				}
+				deps = append(deps, d)
+			}
+			if entry {
+				deps = append(deps, s.DependsOn...)
+			}
+			m.DependsOn = deps
+			m.Input = prefixStepRefs(m.Input, prefix, local)
+			m.Name = prefix + m.Name
+			if m.Timeout == 0 {
+				m.Timeout = s.Timeout
+			}
+			if m.OnFail == "" {
+				m.OnFail = s.OnFail
+			}
+			if m.Dir == "" && s.Dir != "" {
+				m.Dir, m.BaseDir = s.Dir, s.BaseDir
+			}
+			if len(s.Env) > 0 {
+				env := make(map[string]string, len(s.Env)+len(m.Env))
+				for k, v := range s.Env {
+					env[k] = v
+				}
+				for k, v := range m.Env {
+					env[k] = v
+				}
+				m.Env = env
+			}
+			if m.Origin == "" {
+				m.Origin = "group " + s.Group
+				if s.Origin != "" {
+					m.Origin += " in " + s.Origin
+				}
+			}
+			expanded[s.Name] = append(expanded[s.Name], m.Name)
+			out = append(out, m)
+		}
+	}
+
+	for i := range out {
+		var deps []string
+		for _, d := range out[i].DependsOn {
+			if names, ok := expanded[d]; ok {
+				deps = append(deps, names...)
+			} else {
+				deps = append(deps, d)
+			}
+		}
+		out[i].DependsOn = deps
+	}
+	return out, nil
+}
+
+// prefixStepRefs rewrites "step:" references in an input spec that point at
+// steps in local.
+func prefixStepRefs(input, prefix string, local map[string]bool) string {
+	if input == "" {
+		return input
+	}
+	parts := strings.Split(input, ",")
+	for i, part := range parts {
+		name, ok := strings.CutPrefix(strings.TrimSpace(part), "step:")
+		if ok && local[name] {
+			parts[i] = "step:" + prefix + name
+		}
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+
+	"github.com/msalah0e/palm/internal/session"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/msalah0e/palm/internal/vault"
+)
+
+// maxMaskedArtifact is the largest artifact scanned for secrets; bigger
+// files are left alone.
+const maxMaskedArtifact = 8 << 20
+
+// composeMasker scrubs secrets from what a step produces before palm prints
+// or stores it: the values of vault keys and other credential variables in
+// the step's environment, and well-known API key formats. A nil masker
+// leaves everything as it is.
+type composeMasker struct {
+	keys []string // vault key names, masked whatever they are called
+}
+
+// newComposeMasker returns a masker for v's keys, or nil when off is set.
+func newComposeMasker(v vault.Vault, off bool) *composeMasker {
+	if off {
+		return nil
+	}
+	keys, _ := v.List()
+	return &composeMasker{keys: keys}
+}
+
+// text returns s with secrets from env and known key formats redacted.
+func (m *composeMasker) text(s string, env []string) string {
+	if m == nil || s == "" {
+		return s
+	}
+	s = session.ScrubSecrets(s, env, m.keys...)
+	for _, re := range secretPatterns {
+		s = re.ReplaceAllString(s, session.Redacted)
+	}
+	return s
+}
+
+// result masks a step's output and error, and rewrites its text artifacts
+// that contain secrets.
+func (m *composeMasker) result(r ComposeResult, env []string) ComposeResult {
+	if m == nil {
+		return r
+	}
+	r.Output = m.text(r.Output, env)
+	r.Error = m.text(r.Error, env)
+	for _, path := range r.Artifacts {
+		if masked, err := m.artifact(path, env); err != nil {
+			ui.Warn.Printf("  %s Couldn't mask secrets in %s: %v\n", ui.WarnIcon(), path, err)
+		} else if masked {
+			ui.Warn.Printf("  %s Masked secrets in %s\n", ui.WarnIcon(), path)
+		}
+	}
+	return r
+}
+
+// artifact redacts secrets in the file at path and reports whether it
+// changed. Binary and very large files are skipped.
+func (m *composeMasker) artifact(path string, env []string) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > maxMaskedArtifact {
+		return false, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || bytes.IndexByte(data, 0) >= 0 {
+		return false, err
+	}
+	masked := m.text(string(data), env)
+	if masked == string(data) {
+		return false, nil
+	}
+	return true, os.WriteFile(path, []byte(masked), info.Mode().Perm())
+}
@@ -0,0 +1,645 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/msalah0e/palm/internal/activity"
+	"github.com/msalah0e/palm/internal/schedule"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// composeReport is what `palm compose --report` writes.
+type composeReport struct {
+	Workflow string          `json:"workflow"`
+	Steps    []ComposeResult `json:"steps"`
+}
+
+func writeComposeReport(path string, wf *ComposeFile, results []ComposeResult) error {
+	data, err := json.MarshalIndent(composeReport{Workflow: wf.Name, Steps: results}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+func composeScheduleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Run workflows on a cron schedule",
+		Long: `Register workflows to run on a cron schedule. The scheduler daemon
+(palm compose scheduler start) runs them with the same per-step vault keys
+as palm compose, keeps each run's output under ~/.config/palm/compose-runs,
+and can alert you when a run fails.
+
+  palm compose schedule add nightly-review --cron "0 2 * * *" --file review.toml --notify
+  palm compose schedule list
+  palm compose schedule runs nightly-review`,
+	}
+	cmd.AddCommand(
+		composeScheduleAddCmd(),
+		composeScheduleListCmd(),
+		composeScheduleRmCmd(),
+		composeScheduleRunCmd(),
+		composeScheduleRunsCmd(),
+	)
+	return cmd
+}
+
+func composeScheduleAddCmd() *cobra.Command {
+	var (
+		cronExpr string
+		file     string
+		s        schedule.Schedule
+	)
+
+	cmd := &cobra.Command{
+		Use:   "add <name> --cron <expr>",
+		Short: "Schedule a workflow",
+		Long: `Schedule a workflow to run whenever a cron expression fires. The
+expression has five fields — minute, hour, day of month, month, weekday —
+or is one of @hourly, @daily, @weekly, @monthly, @yearly. Steps run in the
+current directory, as they would with palm compose. Adding a schedule under
+an existing name replaces it.
+
+Steps marked approve = true are declined in scheduled runs unless the
+schedule is added with --yes.
+
+  palm compose schedule add nightly-review --cron "0 2 * * *"
+  palm compose schedule add triage --cron "*/30 9-17 * * mon-fri" --file triage.toml
+  palm compose schedule add nightly-review --cron @daily --webhook https://hooks.slack.com/...`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cron, err := schedule.ParseCron(cronExpr)
+			if err != nil {
+				ui.Bad.Printf("  Invalid --cron: %v\n", err)
+				os.Exit(1)
+			}
+			path, err := resolveComposePath(file)
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+			wf, err := loadComposeFile(path)
+			if err != nil {
+				ui.Bad.Printf("  Failed to load workflow: %v\n", err)
+				os.Exit(1)
+			}
+
+			s.Name = args[0]
+			s.Cron = cron.String()
+			s.File = path
+			s.Dir, _ = os.Getwd()
+			s.Created = time.Now()
+			replaced, err := schedule.Add(s)
+			if err != nil {
+				ui.Bad.Printf("  Failed to save schedule: %v\n", err)
+				os.Exit(1)
+			}
+
+			verb := "Scheduled"
+			if replaced {
+				verb = "Updated"
+			}
+			ui.Good.Printf("  %s %s %s (%s)\n", ui.StatusIcon(true), verb, s.Name, s.Cron)
+			fmt.Printf("  Workflow: %s\n", path)
+			if next := cron.Next(time.Now()); !next.IsZero() {
+				fmt.Printf("  Next run: %s\n", next.Format("Mon Jan 02 15:04"))
+			} else {
+				ui.Warn.Printf("  %s %s never fires\n", ui.WarnIcon(), s.Cron)
+			}
+			if alerts := s.Alerts(); alerts != "" {
+				fmt.Printf("  On failure: %s\n", alerts)
+			}
+
+			gated := 0
+			for _, step := range wf.Steps {
+				if step.Approve {
+					gated++
+				}
+			}
+			if gated > 0 && !s.Yes {
+				ui.Warn.Printf("  %s %d step(s) need approval and will be declined — add with --yes to approve them\n", ui.WarnIcon(), gated)
+			}
+			if running, _ := schedule.IsRunning(); !running {
+				fmt.Println()
+				fmt.Println("  Start the scheduler: palm compose scheduler start --bg")
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&cronExpr, "cron", "", `Cron expression, e.g. "0 2 * * *" (required)`)
+	cmd.Flags().StringVarP(&file, "file", "f", ".palm-compose.toml", "Workflow file path")
+	cmd.Flags().BoolVarP(&s.Yes, "yes", "y", false, "Approve every approval gate in scheduled runs")
+	cmd.Flags().BoolVar(&s.Notify, "notify", false, "Show a desktop notification when a run fails")
+	cmd.Flags().StringVar(&s.Webhook, "webhook", "", "POST a JSON payload to this URL when a run fails")
+	cmd.Flags().StringVar(&s.Command, "command", "", "Run a shell command when a run fails (PALM_SCHEDULE_* env vars set)")
+	_ = cmd.MarkFlagRequired("cron")
+	return cmd
+}
+
+// scheduleView is a schedule as `schedule list` shows it.
+type scheduleView struct {
+	schedule.Schedule
+	Next    *time.Time    `json:"next,omitempty"`
+	LastRun *schedule.Run `json:"last_run,omitempty"`
+}
+
+func composeScheduleListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List scheduled workflows",
+		Run: func(cmd *cobra.Command, args []string) {
+			schedules, err := schedule.Load()
+			if err != nil {
+				ui.Bad.Printf("  Failed to load schedules: %v\n", err)
+				os.Exit(1)
+			}
+
+			views := make([]scheduleView, 0, len(schedules))
+			for _, s := range schedules {
+				v := scheduleView{Schedule: s}
+				if c, err := schedule.ParseCron(s.Cron); err == nil {
+					if next := c.Next(time.Now()); !next.IsZero() {
+						v.Next = &next
+					}
+				}
+				if last, ok := schedule.LastRun(s.Name); ok {
+					v.LastRun = &last
+				}
+				views = append(views, v)
+			}
+
+			render(views, func() {
+				ui.Banner("compose schedules")
+				if len(views) == 0 {
+					fmt.Println("  No scheduled workflows")
+					fmt.Println("  Add one: palm compose schedule add nightly --cron \"0 2 * * *\"")
+					return
+				}
+
+				var rows [][]string
+				for _, v := range views {
+					next := "never"
+					if v.Next != nil {
+						next = v.Next.Format("Jan 02 15:04")
+					}
+					last := "-"
+					if v.LastRun != nil {
+						last = ui.StatusIcon(v.LastRun.OK) + " " + v.LastRun.Started.Format("Jan 02 15:04")
+					}
+					rows = append(rows, []string{v.Name, v.Cron, filepath.Base(v.File), next, last, orDash(v.Alerts())})
+				}
+				ui.Table([]string{"Name", "Cron", "Workflow", "Next run", "Last run", "Alerts"}, rows)
+
+				fmt.Println()
+				if running, pid := schedule.IsRunning(); running {
+					fmt.Printf("  Scheduler running (PID %d)\n", pid)
+				} else {
+					ui.Warn.Printf("  %s Scheduler is not running — palm compose scheduler start --bg\n", ui.WarnIcon())
+				}
+			})
+		},
+	}
+}
+
+func composeScheduleRmCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "rm <name>",
+		Aliases: []string{"remove"},
+		Short:   "Remove a schedule (its run history is kept)",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := schedule.Remove(args[0]); err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+			ui.Good.Printf("  %s Removed schedule %s\n", ui.StatusIcon(true), args[0])
+		},
+	}
+}
+
+func composeScheduleRunCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "run <name>",
+		Short: "Run a scheduled workflow now, as the scheduler would",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			s, ok := schedule.Get(args[0])
+			if !ok {
+				ui.Bad.Printf("  No schedule named %q\n", args[0])
+				os.Exit(1)
+			}
+
+			if !jsonOutput {
+				fmt.Printf("  Running %s...\n", ui.Brand.Sprint(s.Name))
+			}
+			run, err := runSchedule(s)
+			render(run, func() {
+				printScheduledRun(run)
+				if err != nil {
+					ui.Warn.Printf("  %s Failure alert: %v\n", ui.WarnIcon(), err)
+				}
+			})
+			if !run.OK {
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+func composeScheduleRunsCmd() *cobra.Command {
+	var count int
+
+	cmd := &cobra.Command{
+		Use:   "runs [name]",
+		Short: "Show recent scheduled runs and where their artifacts are",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			name := ""
+			if len(args) == 1 {
+				name = args[0]
+			}
+			runs, err := schedule.Runs(name)
+			if err != nil {
+				ui.Bad.Printf("  Failed to read runs: %v\n", err)
+				os.Exit(1)
+			}
+			if count > 0 && len(runs) > count {
+				runs = runs[:count]
+			}
+
+			render(runs, func() {
+				ui.Banner("scheduled runs")
+				if len(runs) == 0 {
+					fmt.Println("  No runs yet")
+					return
+				}
+				var rows [][]string
+				for _, r := range runs {
+					status := ui.StatusIcon(r.OK) + " ok"
+					if !r.OK {
+						status = ui.StatusIcon(false) + " " + orDash(strings.Join(r.Failed(), ", "))
+					}
+					rows = append(rows, []string{
+						r.Started.Format("2006-01-02 15:04"),
+						r.Schedule,
+						formatDuration(time.Duration(r.Duration * float64(time.Second))),
+						status,
+						r.Dir,
+					})
+				}
+				ui.Table([]string{"Started", "Schedule", "Time", "Status", "Artifacts"}, rows)
+			})
+		},
+	}
+
+	cmd.Flags().IntVarP(&count, "count", "n", 10, "Number of runs to show (0 for all)")
+	return cmd
+}
+
+func printScheduledRun(r schedule.Run) {
+	if r.OK {
+		ui.Good.Printf("  %s %s finished in %s\n", ui.StatusIcon(true), r.Schedule, formatDuration(time.Duration(r.Duration*float64(time.Second))))
+	} else {
+		msg := r.Error
+		if failed := r.Failed(); len(failed) > 0 {
+			msg = "failed at " + strings.Join(failed, ", ")
+		}
+		ui.Bad.Printf("  %s %s %s\n", ui.StatusIcon(false), r.Schedule, msg)
+	}
+	if r.Dir != "" {
+		fmt.Printf("  Artifacts: %s\n", r.Dir)
+	}
+}
+
+// runSchedule runs a scheduled workflow through `palm compose` in the
+// schedule's directory, so steps get the same vault wiring as an
+// interactive run. Console output, the step report and each step's output
+// are kept in the run's directory. The returned error is from failure
+// alerts, which fire when the run fails.
+func runSchedule(s schedule.Schedule) (schedule.Run, error) {
+	started := time.Now()
+	run := schedule.Run{Schedule: s.Name, Workflow: filepath.Base(s.File), Started: started, ExitCode: -1}
+
+	dir, err := schedule.NewRunDir(s.Name, started)
+	if err != nil {
+		run.Error = err.Error()
+		return run, schedule.NotifyFailure(s, run)
+	}
+	run.Dir = dir
+
+	var output *os.File
+	exe, err := os.Executable()
+	if err == nil {
+		output, err = os.OpenFile(filepath.Join(dir, "output.log"), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	}
+	if err == nil {
+		reportPath := filepath.Join(dir, "report.json")
+		args := []string{"compose", "--file", s.File, "--report", reportPath}
+		if s.Yes {
+			args = append(args, "--yes")
+		}
+		c := exec.Command(exe, args...)
+		c.Dir = s.Dir
+		c.Stdout, c.Stderr = output, output
+		err = c.Run()
+		output.Close()
+		if c.ProcessState != nil {
+			run.ExitCode = c.ProcessState.ExitCode()
+		}
+		run.Workflow, run.Steps = saveStepOutputs(reportPath, dir, run.Workflow)
+	}
+	run.Duration = time.Since(started).Seconds()
+	run.OK = err == nil
+	if err != nil {
+		run.Error = err.Error()
+	}
+
+	if err := schedule.SaveRun(run); err != nil {
+		run.Error = strings.TrimPrefix(run.Error+"; ", "; ") + "saving run: " + err.Error()
+	}
+	_ = activity.Append(activity.Entry{
+		Action:   "compose.schedule",
+		Tool:     s.Name,
+		Details:  fmt.Sprintf("%d/%d steps ok", len(run.Steps)-len(run.Failed()), len(run.Steps)),
+		Duration: run.Duration,
+		Status:   activity.StatusOf(run.OK),
+	})
+
+	if !run.OK {
+		return run, schedule.NotifyFailure(s, run)
+	}
+	return run, nil
+}
+
+// saveStepOutputs turns the report `palm compose --report` wrote into one
+// output file per step under dir/steps, and returns the workflow name and
+// step outcomes. The report is removed once split.
+func saveStepOutputs(reportPath, dir, workflow string) (string, []schedule.Step) {
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		return workflow, nil
+	}
+	var report composeReport
+	if json.Unmarshal(data, &report) != nil {
+		return workflow, nil
+	}
+	if report.Workflow != "" {
+		workflow = report.Workflow
+	}
+
+	stepsDir := filepath.Join(dir, "steps")
+	_ = os.MkdirAll(stepsDir, 0o700)
+	var steps []schedule.Step
+	for _, r := range report.Steps {
+		steps = append(steps, schedule.Step{
+			Name:     r.Step,
+			Duration: r.Duration.Seconds(),
+			ExitCode: r.ExitCode,
+			Error:    r.Error,
+		})
+		// Grouped steps are named group/step
+		name := strings.ReplaceAll(r.Step, "/", "_") + ".txt"
+		_ = os.WriteFile(filepath.Join(stepsDir, name), []byte(r.Output), 0o600)
+	}
+	_ = os.Remove(reportPath)
+	return workflow, steps
+}
+
+func composeSchedulerCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "scheduler",
+		Short: "Run the daemon that starts scheduled workflows",
+	}
+	cmd.AddCommand(
+		composeSchedulerStartCmd(),
+		composeSchedulerStopCmd(),
+		composeSchedulerStatusCmd(),
+	)
+	return cmd
+}
+
+func composeSchedulerStartCmd() *cobra.Command {
+	var background bool
+
+	cmd := &cobra.Command{
+		Use:   "start",
+		Short: "Start the scheduler",
+		Long: `Start the scheduler. It checks the schedules every minute, so schedules
+added or removed while it runs take effect without a restart. A workflow
+still running when its next run is due is not started twice.
+
+With --bg the scheduler runs detached and logs to
+~/.config/palm/compose-scheduler.log.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			// A --bg child finds the PID file its parent wrote for it
+			if running, pid := schedule.IsRunning(); running && pid != os.Getpid() {
+				fmt.Printf("  Scheduler already running (PID %d)\n", pid)
+				return
+			}
+
+			if background {
+				logFile, err := os.OpenFile(schedule.LogPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+				if err != nil {
+					ui.Bad.Printf("  Failed to open log: %v\n", err)
+					os.Exit(1)
+				}
+				defer logFile.Close()
+
+				exe, _ := os.Executable()
+				child := exec.Command(exe, "compose", "scheduler", "start")
+				child.Stdout = logFile
+				child.Stderr = logFile
+				setDetached(child)
+
+				if err := child.Start(); err != nil {
+					ui.Bad.Printf("  Failed to start scheduler: %v\n", err)
+					os.Exit(1)
+				}
+				_ = os.WriteFile(schedule.PidFile(), []byte(strconv.Itoa(child.Process.Pid)), 0o644)
+
+				ui.Good.Printf("  %s Scheduler started (PID %d)\n", ui.StatusIcon(true), child.Process.Pid)
+				fmt.Printf("  Log: %s\n", schedule.LogPath())
+				return
+			}
+
+			_ = os.MkdirAll(filepath.Dir(schedule.PidFile()), 0o755)
+			_ = os.WriteFile(schedule.PidFile(), []byte(strconv.Itoa(os.Getpid())), 0o644)
+			defer os.Remove(schedule.PidFile())
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			runScheduler(ctx)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&background, "bg", "b", false, "Run in background")
+	return cmd
+}
+
+// runScheduler starts due workflows at the top of every minute until ctx
+// is done, then waits for the runs in progress.
+func runScheduler(ctx context.Context) {
+	logf := func(format string, args ...any) {
+		fmt.Printf("  %s %s\n", time.Now().Format("2006-01-02 15:04:05"), fmt.Sprintf(format, args...))
+	}
+	logf("scheduler started (PID %d)", os.Getpid())
+
+	var (
+		mu      sync.Mutex
+		running = make(map[string]bool)
+		wg      sync.WaitGroup
+		last    time.Time
+	)
+	for {
+		select {
+		case <-ctx.Done():
+			mu.Lock()
+			n := len(running)
+			mu.Unlock()
+			if n > 0 {
+				logf("stopping, waiting for %d running workflow(s)", n)
+			}
+			wg.Wait()
+			logf("scheduler stopped")
+			return
+		case <-time.After(time.Until(time.Now().Truncate(time.Minute).Add(time.Minute))):
+		}
+
+		// After a sleep or suspend, check the minute it is now, once
+		minute := time.Now().Truncate(time.Minute)
+		if !minute.After(last) {
+			continue
+		}
+		last = minute
+
+		schedules, err := schedule.Load()
+		if err != nil {
+			logf("can't load schedules: %v", err)
+			continue
+		}
+		for _, s := range schedules {
+			c, err := schedule.ParseCron(s.Cron)
+			if err != nil {
+				logf("%s: %v", s.Name, err)
+				continue
+			}
+			if !c.Matches(minute) {
+				continue
+			}
+			mu.Lock()
+			busy := running[s.Name]
+			running[s.Name] = true
+			mu.Unlock()
+			if busy {
+				logf("%s: previous run still in progress, skipping", s.Name)
+				continue
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				logf("%s: starting %s", s.Name, s.File)
+				run, err := runSchedule(s)
+				if run.OK {
+					logf("%s: ok in %.1fs — %s", s.Name, run.Duration, run.Dir)
+				} else {
+					logf("%s: failed (%s) — %s", s.Name, orDash(strings.Join(run.Failed(), ", ")), orDash(run.Dir))
+				}
+				if err != nil {
+					logf("%s: failure alert: %v", s.Name, err)
+				}
+				mu.Lock()
+				delete(running, s.Name)
+				mu.Unlock()
+			}()
+		}
+	}
+}
+
+func composeSchedulerStopCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "stop",
+		Short: "Stop the scheduler",
+		Run: func(cmd *cobra.Command, args []string) {
+			running, pid := schedule.IsRunning()
+			if !running {
+				fmt.Println("  Scheduler is not running")
+				return
+			}
+			proc, err := os.FindProcess(pid)
+			if err != nil {
+				ui.Bad.Printf("  Failed to find process %d: %v\n", pid, err)
+				os.Exit(1)
+			}
+			if err := stopProcess(proc); err != nil {
+				ui.Bad.Printf("  Failed to stop scheduler: %v\n", err)
+				os.Exit(1)
+			}
+			ui.Good.Printf("  %s Scheduler stopped (PID %d)\n", ui.StatusIcon(true), pid)
+			fmt.Println("  Workflows already running are allowed to finish")
+		},
+	}
+}
+
+func composeSchedulerStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Check the scheduler and the next scheduled run",
+		Run: func(cmd *cobra.Command, args []string) {
+			running, pid := schedule.IsRunning()
+			schedules, _ := schedule.Load()
+
+			var nextName string
+			var next time.Time
+			for _, s := range schedules {
+				c, err := schedule.ParseCron(s.Cron)
+				if err != nil {
+					continue
+				}
+				if t := c.Next(time.Now()); !t.IsZero() && (next.IsZero() || t.Before(next)) {
+					nextName, next = s.Name, t
+				}
+			}
+
+			status := struct {
+				Running   bool       `json:"running"`
+				PID       int        `json:"pid,omitempty"`
+				Schedules int        `json:"schedules"`
+				Next      string     `json:"next,omitempty"`
+				NextAt    *time.Time `json:"next_at,omitempty"`
+				Log       string     `json:"log"`
+			}{Running: running, PID: pid, Schedules: len(schedules), Log: schedule.LogPath()}
+			if nextName != "" {
+				status.Next, status.NextAt = nextName, &next
+			}
+
+			render(status, func() {
+				if running {
+					ui.Good.Printf("  %s Scheduler running (PID %d)\n", ui.StatusIcon(true), pid)
+				} else {
+					fmt.Println("  Scheduler is not running")
+					fmt.Println("  Start: palm compose scheduler start --bg")
+				}
+				fmt.Printf("  Schedules: %d\n", len(schedules))
+				if nextName != "" {
+					fmt.Printf("  Next run:  %s at %s\n", nextName, next.Format("Mon Jan 02 15:04"))
+				}
+				fmt.Printf("  Log:       %s\n", schedule.LogPath())
+			})
+		},
+	}
+}
@@ -3,10 +3,14 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 
+	"github.com/msalah0e/palm/internal/contextpack"
+	"github.com/msalah0e/palm/internal/tokens"
 	"github.com/msalah0e/palm/internal/ui"
+	"github.com/msalah0e/palm/internal/vault"
 	"github.com/spf13/cobra"
 )
 
@@ -31,6 +35,7 @@ func contextCmd() *cobra.Command {
 		contextInitCmd(),
 		contextShowCmd(),
 		contextSyncCmd(),
+		contextPackCmd(),
 	)
 
 	return cmd
@@ -38,10 +43,21 @@ func contextCmd() *cobra.Command {
 
 func contextInitCmd() *cobra.Command {
 	var tools []string
+	var analyze bool
 
 	cmd := &cobra.Command{
 		Use:   "init",
 		Short: "Generate context files for AI tools in this project",
+		Long: `Generate .palm-context.md and context files for AI tools in this project.
+
+With --analyze, the repository is walked to fill in a real structure summary
+instead of placeholders: top-level directories and their likely purpose,
+entry points, test layout, and build commands from Makefile, package.json,
+go.mod, Cargo.toml and pyproject.toml. Run it again later to refresh the
+summary in an existing .palm-context.md; the rest of the file is kept.
+
+  palm context init --analyze
+  palm context sync`,
 		Run: func(cmd *cobra.Command, args []string) {
 			ui.Banner("context init")
 
@@ -53,12 +69,33 @@ func contextInitCmd() *cobra.Command {
 			}
 			fmt.Println()
 
+			var analysis *contextpack.Analysis
+			if analyze {
+				var err error
+				analysis, err = contextpack.Analyze(".")
+				if err != nil {
+					ui.Bad.Printf("  Failed to analyze project: %v\n", err)
+					os.Exit(1)
+				}
+				fmt.Printf("  Analyzed: %d directories, %d entry points, %d build commands\n",
+					len(analysis.Dirs), len(analysis.EntryPoints), len(analysis.Commands))
+			}
+
 			// Create .palm-context.md as the single source of truth
 			contextPath := ".palm-context.md"
-			if _, err := os.Stat(contextPath); err == nil {
-				fmt.Printf("  %s already exists\n", contextPath)
+			if existing, err := os.ReadFile(contextPath); err == nil {
+				if analysis == nil {
+					fmt.Printf("  %s already exists\n", contextPath)
+				} else {
+					content := replaceAnalysisBlock(string(existing), analysisBlock(analysis))
+					if err := os.WriteFile(contextPath, []byte(content), 0o644); err != nil {
+						ui.Bad.Printf("  Failed to update %s: %v\n", contextPath, err)
+						os.Exit(1)
+					}
+					ui.Good.Printf("  %s Updated the project analysis in %s\n", ui.StatusIcon(true), contextPath)
+				}
 			} else {
-				content := generateContext(lang, framework)
+				content := generateContext(lang, framework, analysis)
 				if err := os.WriteFile(contextPath, []byte(content), 0o644); err != nil {
 					ui.Bad.Printf("  Failed to create %s: %v\n", contextPath, err)
 					os.Exit(1)
@@ -99,7 +136,7 @@ func contextInitCmd() *cobra.Command {
 					continue
 				}
 
-				content := generateToolContext(tool, lang, framework)
+				content := generateToolContext(tool, lang, framework, analysis)
 				if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
 					ui.Bad.Printf("  Failed to create %s: %v\n", file, err)
 					continue
@@ -114,6 +151,7 @@ func contextInitCmd() *cobra.Command {
 	}
 
 	cmd.Flags().StringSliceVar(&tools, "tools", nil, "Specific tools to generate context for")
+	cmd.Flags().BoolVar(&analyze, "analyze", false, "Summarize the project's structure, entry points, tests and build commands")
 	return cmd
 }
 
@@ -159,13 +197,16 @@ func contextSyncCmd() *cobra.Command {
 				os.Exit(1)
 			}
 
+			// Fit each file to the tool's token budget, as palm rules sync does
+			fitter := newRuleFitter(contextPath)
 			synced := 0
 			for tool, file := range contextFiles {
 				if _, err := os.Stat(file); err != nil {
 					continue // only sync existing files
 				}
 
-				content := wrapForTool(tool, string(baseContent))
+				fitted, fit := fitter.fit(tool, string(baseContent), func(c string) string { return wrapForTool(tool, c) })
+				content := wrapForTool(tool, fitted)
 
 				dir := filepath.Dir(file)
 				if dir != "." {
@@ -177,6 +218,7 @@ func contextSyncCmd() *cobra.Command {
 					continue
 				}
 				ui.Good.Printf("  %s synced → %s\n", ui.StatusIcon(true), file)
+				printRuleFit(fit)
 				synced++
 			}
 
@@ -190,6 +232,155 @@ func contextSyncCmd() *cobra.Command {
 	}
 }
 
+// defaultPackBudget is the bundle size when --budget is not given; it fits
+// every chat model palm knows about with room left for the answer.
+const defaultPackBudget = 32000
+
+func contextPackCmd() *cobra.Command {
+	var (
+		budget     string
+		to         string
+		out        string
+		list       bool
+		noChurn    bool
+		embed      bool
+		embedModel string
+		include    []string
+		allKeys    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   `pack "<task>"`,
+		Short: "Bundle the files most relevant to a task within a token budget",
+		Long: `Rank project files by relevance to a task and concatenate the best ones into
+a single bundle that fits a token budget.
+
+Files are ranked by path matches, content matches and recent git churn, plus
+semantic similarity from a local Ollama embedding model with --embed.
+
+The bundle is written to stdout so it can be piped anywhere; use --to to send
+it straight to a tool, or "context:<task>" as a compose step input.
+
+Examples:
+  palm context pack "fix the auth bug" --budget 32k | mods "fix the auth bug"
+  palm context pack "add rate limiting to the proxy" --to aider
+  palm context pack "billing rounding" --list
+  palm context pack "config loading" --include README.md --out context.md`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			query := args[0]
+			limit := defaultPackBudget
+			if budget != "" {
+				n, err := tokens.ParseCount(budget)
+				if err != nil {
+					ui.Bad.Printf("  %v\n", err)
+					os.Exit(1)
+				}
+				limit = n
+			}
+
+			opts := contextpack.Options{Query: query, Budget: limit, NoChurn: noChurn, Include: include}
+			if embed {
+				opts.Embedder = contextpack.OllamaEmbedder("http://localhost:11434", embedModel)
+			}
+			p, err := contextpack.Build(opts)
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+			if len(p.Files) == 0 {
+				ui.Warn.Println("  No files matched the task — try different words or --embed")
+				os.Exit(1)
+			}
+
+			if jsonOutput {
+				printJSON(p)
+				return
+			}
+
+			if list {
+				ui.Banner("context pack")
+				printPackTable(p)
+				return
+			}
+
+			bundle := p.Render()
+			switch {
+			case to != "":
+				printPackSummary(p)
+				runWithBundle(to, query, bundle, allKeys)
+			case out != "":
+				if err := os.WriteFile(out, []byte(bundle), 0o644); err != nil {
+					ui.Bad.Printf("  %v\n", err)
+					os.Exit(1)
+				}
+				printPackSummary(p)
+				fmt.Fprintf(os.Stderr, "  Wrote %s\n", out)
+			default:
+				fmt.Print(bundle)
+				printPackSummary(p)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&budget, "budget", "", "Token budget, e.g. 32k or 128000 (default 32k)")
+	cmd.Flags().StringVar(&to, "to", "", "Send the bundle to a tool on stdin, with the task as its prompt")
+	cmd.Flags().StringVarP(&out, "out", "o", "", "Write the bundle to a file")
+	cmd.Flags().BoolVar(&list, "list", false, "Show the ranking without printing the bundle")
+	cmd.Flags().BoolVar(&noChurn, "no-churn", false, "Ignore git history when ranking")
+	cmd.Flags().BoolVar(&embed, "embed", false, "Rank with semantic similarity from a local Ollama embedding model")
+	cmd.Flags().StringVar(&embedModel, "embed-model", "nomic-embed-text", "Ollama model used with --embed")
+	cmd.Flags().StringSliceVar(&include, "include", nil, "Files to always include first")
+	cmd.Flags().BoolVar(&allKeys, "all-keys", false, "With --to, inject every vault key, not just the ones the tool needs")
+	return cmd
+}
+
+func printPackTable(p *contextpack.Pack) {
+	var rows [][]string
+	for _, f := range p.Files {
+		rows = append(rows, []string{ui.StatusIcon(true), f.Path, tokens.FormatTokens(f.Tokens), fmt.Sprintf("%.2f", f.Score), strings.Join(f.Reasons, ", ")})
+	}
+	// The long tail of skipped files is noise; show the closest misses
+	for _, f := range p.Skipped[:min(5, len(p.Skipped))] {
+		rows = append(rows, []string{ui.Subtle.Sprint("–"), ui.Subtle.Sprint(f.Path), tokens.FormatTokens(f.Tokens), fmt.Sprintf("%.2f", f.Score), "over budget"})
+	}
+	ui.Table([]string{"", "File", "Tokens", "Score", "Why"}, rows)
+	fmt.Printf("\n  %d files, %s / %s tokens\n", len(p.Files), tokens.FormatTokens(p.Tokens), tokens.FormatTokens(p.Budget))
+}
+
+// printPackSummary reports the selection on stderr so stdout stays pipeable.
+func printPackSummary(p *contextpack.Pack) {
+	fmt.Fprintf(os.Stderr, "  %s %d files, %s / %s tokens",
+		ui.Brand.Sprint("context pack:"), len(p.Files), tokens.FormatTokens(p.Tokens), tokens.FormatTokens(p.Budget))
+	if len(p.Skipped) > 0 {
+		fmt.Fprintf(os.Stderr, " (%d relevant files over budget)", len(p.Skipped))
+	}
+	fmt.Fprintln(os.Stderr)
+}
+
+// runWithBundle runs tool with the task as its prompt and the bundle on stdin.
+func runWithBundle(tool, prompt, bundle string, allKeys bool) {
+	reg := loadRegistry()
+	args := promptArgs(reg, tool, prompt)
+	if _, err := exec.LookPath(args[0]); err != nil {
+		ui.Bad.Printf("  %s not found on PATH\n", args[0])
+		os.Exit(1)
+	}
+
+	c := exec.Command(args[0], args[1:]...)
+	c.Stdin = strings.NewReader(bundle)
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	c.Env = buildVaultEnv(vault.New(), tool, reg, []string{tool}, allKeys)
+	if err := c.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			os.Exit(exitErr.ExitCode())
+		}
+		ui.Bad.Printf("  %v\n", err)
+		os.Exit(1)
+	}
+}
+
 func detectProject() (lang, framework string) {
 	checks := []struct {
 		file      string
@@ -256,7 +447,45 @@ func detectProject() (lang, framework string) {
 	return
 }
 
-func generateContext(lang, framework string) string {
+// The generated analysis sits between these markers so --analyze can refresh
+// it without touching the rest of .palm-context.md.
+const (
+	analysisBegin = "<!-- palm:analysis -->"
+	analysisEnd   = "<!-- /palm:analysis -->"
+)
+
+// contextPlaceholders is the part of the template the analysis replaces.
+const contextPlaceholders = "## Project Structure\n\n" +
+	"<!-- Describe your project structure here -->\n\n" +
+	"## Key Files\n\n" +
+	"<!-- List important files and their purpose -->\n"
+
+func analysisBlock(a *contextpack.Analysis) string {
+	return analysisBegin + "\n" + a.Markdown() + analysisEnd + "\n"
+}
+
+// replaceAnalysisBlock puts block in place of an earlier analysis, or of the
+// template placeholders, or else at the end of content.
+func replaceAnalysisBlock(content, block string) string {
+	start := strings.Index(content, analysisBegin)
+	end := strings.Index(content, analysisEnd)
+	if start >= 0 && end > start {
+		end += len(analysisEnd)
+		if end < len(content) && content[end] == '\n' {
+			end++
+		}
+		return content[:start] + block + content[end:]
+	}
+	if strings.Contains(content, contextPlaceholders) {
+		return strings.Replace(content, contextPlaceholders, block, 1)
+	}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content + "\n" + block
+}
+
+func generateContext(lang, framework string, analysis *contextpack.Analysis) string {
 	var b strings.Builder
 	b.WriteString("# Project Context\n\n")
 	b.WriteString(fmt.Sprintf("Language: %s\n", lang))
@@ -284,16 +513,18 @@ func generateContext(lang, framework string) string {
 		b.WriteString("- Handle errors with Result, avoid unwrap in production code\n")
 	}
 
-	b.WriteString("\n## Project Structure\n\n")
-	b.WriteString("<!-- Describe your project structure here -->\n\n")
-	b.WriteString("## Key Files\n\n")
-	b.WriteString("<!-- List important files and their purpose -->\n")
+	b.WriteString("\n")
+	if analysis != nil {
+		b.WriteString(analysisBlock(analysis))
+	} else {
+		b.WriteString(contextPlaceholders)
+	}
 
 	return b.String()
 }
 
-func generateToolContext(tool, lang, framework string) string {
-	base := generateContext(lang, framework)
+func generateToolContext(tool, lang, framework string, analysis *contextpack.Analysis) string {
+	base := generateContext(lang, framework, analysis)
 
 	switch tool {
 	case "aider":
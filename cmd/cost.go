@@ -1,22 +1,46 @@
 package cmd
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/msalah0e/palm/internal/billing"
+	"github.com/msalah0e/palm/internal/proxy"
 	"github.com/msalah0e/palm/internal/session"
 	"github.com/msalah0e/palm/internal/ui"
+	"github.com/msalah0e/palm/internal/vault"
 	"github.com/spf13/cobra"
 )
 
 func costCmd() *cobra.Command {
+	var byTool bool
+	var since string
+
 	cmd := &cobra.Command{
 		Use:     "cost",
 		Short:   "Track AI tool spending across providers",
 		Aliases: []string{"costs", "spend"},
+		Long: `Track AI tool spending across providers.
+
+With --by-tool, proxy traffic is broken down by the tool that sent it. Tools
+started with palm run are tagged automatically when their base URL points
+at the proxy; other clients can send an X-Palm-Tool header.
+
+  palm cost --by-tool --since 7d`,
 		Run: func(cmd *cobra.Command, args []string) {
+			if byTool {
+				from, err := parseSince(since)
+				if err != nil {
+					ui.Bad.Printf("  %v\n", err)
+					os.Exit(1)
+				}
+				printCostByTool(from, since)
+				return
+			}
+
 			ui.Banner("cost tracking")
 
 			summary, err := session.Summarize()
@@ -57,11 +81,64 @@ func costCmd() *cobra.Command {
 		costTodayCmd(),
 		costWeekCmd(),
 		costExportCmd(),
+		costImportCmd(),
+		costReconcileCmd(),
 	)
 
+	cmd.Flags().BoolVar(&byTool, "by-tool", false, "Break proxy spending down by calling tool")
+	cmd.Flags().StringVar(&since, "since", "30d", "Period for --by-tool (e.g. 7d, 2w)")
 	return cmd
 }
 
+// printCostByTool shows logged proxy spending per calling tool.
+func printCostByTool(from time.Time, window string) {
+	logs, err := proxy.ReadLogs(0)
+	if err != nil {
+		ui.Bad.Printf("  Failed to read proxy logs: %v\n", err)
+		os.Exit(1)
+	}
+	tools := proxy.CostByTool(logs, from)
+
+	render(tools, func() {
+		ui.Banner("cost by tool")
+		if len(tools) == 0 {
+			fmt.Printf("  No proxy requests in the last %s\n", window)
+			fmt.Println("  Route tools through the proxy: palm proxy start --bg")
+			return
+		}
+
+		var total float64
+		for _, t := range tools {
+			total += t.Cost
+		}
+		var rows [][]string
+		untagged := false
+		for _, t := range tools {
+			name := t.Tool
+			if name == "" {
+				name = "(untagged)"
+				untagged = true
+			}
+			share := "-"
+			if total > 0 {
+				share = fmt.Sprintf("%.0f%%", t.Cost/total*100)
+			}
+			rows = append(rows, []string{
+				name,
+				fmt.Sprintf("%d", t.Requests),
+				fmt.Sprintf("%d", t.InputTokens+t.OutputTokens),
+				fmt.Sprintf("$%.4f", t.Cost),
+				share,
+			})
+		}
+		ui.Table([]string{"Tool", "Requests", "Tokens", "Cost", "Share"}, rows)
+		fmt.Printf("\n  Total: $%.4f over the last %s\n", total, window)
+		if untagged {
+			fmt.Printf("  %s\n", ui.Subtle.Sprint("Untagged requests came from clients that weren't started with palm run and sent no X-Palm-Tool header"))
+		}
+	})
+}
+
 func costTodayCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "today",
@@ -143,8 +220,217 @@ func costExportCmd() *cobra.Command {
 				ui.Bad.Printf("  %v\n", err)
 				os.Exit(1)
 			}
-			data, _ := json.MarshalIndent(summary, "", "  ")
-			fmt.Println(string(data))
+			printJSON(summary)
+		},
+	}
+}
+
+func costImportCmd() *cobra.Command {
+	var providers []string
+	var since string
+
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import actual spend from provider billing APIs",
+		Long: `Pull daily spend from the OpenAI and Anthropic cost APIs so palm cost
+reconcile can check palm's estimates against what was billed.
+
+Both APIs need an organization admin key, stored in the vault as
+OPENAI_ADMIN_KEY or ANTHROPIC_ADMIN_KEY. Providers without one are skipped.`,
+		Example: `  palm keys add ANTHROPIC_ADMIN_KEY
+  palm cost import --since 30d
+  palm cost import --provider openai`,
+		Run: func(cmd *cobra.Command, args []string) {
+			from, err := parseSince(since)
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+			if len(providers) == 0 {
+				providers = billing.Providers
+			}
+
+			ui.Banner("billing import")
+			imported, failed := importBilling(vault.New(), providers, from, time.Now())
+			if imported == 0 && failed == 0 {
+				fmt.Println()
+				fmt.Println("  No admin keys found. Add one with: palm keys add ANTHROPIC_ADMIN_KEY")
+				return
+			}
+			fmt.Println()
+			fmt.Println("  Compare with palm's estimates: palm cost reconcile")
+			if failed > 0 {
+				os.Exit(1)
+			}
 		},
 	}
+
+	cmd.Flags().StringSliceVarP(&providers, "provider", "p", nil, "Providers to import (default: all with an admin key)")
+	cmd.Flags().StringVar(&since, "since", "30d", "How far back to import (e.g. 7d, 2w)")
+	return cmd
+}
+
+// importBilling fetches and stores costs for each provider with an admin key.
+// It returns how many providers were imported and how many failed.
+func importBilling(v vault.Vault, providers []string, from, until time.Time) (imported, failed int) {
+	for _, p := range providers {
+		keyName, ok := billing.AdminKeys[p]
+		if !ok {
+			ui.Bad.Printf("  %s %s: no billing importer (use %s)\n", ui.StatusIcon(false), p, strings.Join(billing.Providers, ", "))
+			failed++
+			continue
+		}
+		key := os.Getenv(keyName)
+		if key == "" {
+			if val, err := v.Get(keyName); err == nil {
+				key = val
+				_ = vault.RecordUse("cost import", keyName)
+			}
+		}
+		if key == "" {
+			fmt.Printf("  %s %s %s\n", ui.Subtle.Sprint("-"), p, ui.Subtle.Sprintf("skipped, no %s", keyName))
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		costs, err := billing.Fetch(ctx, nil, p, key, from, until)
+		cancel()
+		if err == nil {
+			err = billing.Merge(costs)
+		}
+		if err != nil {
+			ui.Bad.Printf("  %s %s: %v\n", ui.StatusIcon(false), p, err)
+			failed++
+			continue
+		}
+		var total float64
+		for _, c := range costs {
+			total += c.Amount
+		}
+		ui.Good.Printf("  %s %s: %d days, $%.2f\n", ui.StatusIcon(true), p, len(costs), total)
+		imported++
+	}
+	return imported, failed
+}
+
+func costReconcileCmd() *cobra.Command {
+	var since string
+	var threshold float64
+	var fetch, flaggedOnly bool
+
+	cmd := &cobra.Command{
+		Use:   "reconcile",
+		Short: "Compare proxy cost estimates with imported billing data",
+		Long: `Compare the cost palm estimated for proxied requests with the actual spend
+imported by palm cost import, per provider and UTC day, and flag days that
+differ by more than --threshold percent.
+
+Billed spend also covers usage that didn't go through the palm proxy, so a
+day billed well above its estimate usually means traffic bypassed the proxy;
+one billed below it usually means stale pricing data.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			from, err := parseSince(since)
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+			// Billing buckets are whole UTC days
+			from = from.UTC().Truncate(24 * time.Hour)
+			if fetch {
+				if _, failed := importBilling(vault.New(), billing.Providers, from, time.Now()); failed > 0 {
+					os.Exit(1)
+				}
+			}
+
+			actual, err := billing.Load()
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+			fromDay := from.UTC().Format("2006-01-02")
+			var inWindow []billing.DailyCost
+			for _, c := range actual {
+				if c.Day >= fromDay {
+					inWindow = append(inWindow, c)
+				}
+			}
+
+			logs, _ := proxy.ReadLogs(0)
+			rows := billing.Reconcile(inWindow, estimateByDay(logs, from), threshold)
+			flagged := 0
+			for _, r := range rows {
+				if r.Flagged {
+					flagged++
+				}
+			}
+
+			if jsonOutput {
+				if rows == nil {
+					rows = []billing.Row{}
+				}
+				printJSON(rows)
+				if flagged > 0 {
+					os.Exit(1)
+				}
+				return
+			}
+
+			ui.Banner("cost reconciliation")
+			if len(rows) == 0 {
+				fmt.Println("  No billing data for this period.")
+				fmt.Println("  Import it with: palm cost import")
+				return
+			}
+
+			var tableRows [][]string
+			var totalEst, totalActual float64
+			for _, r := range rows {
+				totalEst += r.Estimated
+				totalActual += r.Actual
+				if flaggedOnly && !r.Flagged {
+					continue
+				}
+				mark := ui.StatusIcon(true)
+				if r.Flagged {
+					mark = ui.WarnIcon()
+				}
+				tableRows = append(tableRows, []string{
+					r.Day,
+					r.Provider,
+					fmt.Sprintf("$%.2f", r.Estimated),
+					fmt.Sprintf("$%.2f", r.Actual),
+					fmt.Sprintf("%+.0f%%", r.DiffPct),
+					mark,
+				})
+			}
+			if len(tableRows) > 0 {
+				ui.Table([]string{"Day", "Provider", "Estimated", "Billed", "Diff", ""}, tableRows)
+				fmt.Println()
+			}
+			fmt.Printf("  Estimated $%.2f · billed $%.2f\n", totalEst, totalActual)
+			if flagged > 0 {
+				ui.Warn.Printf("  %d day(s) differ by more than %.0f%%\n", flagged, threshold)
+				os.Exit(1)
+			}
+			ui.Good.Printf("  %s Estimates within %.0f%% of billing\n", ui.StatusIcon(true), threshold)
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "30d", "Period to compare (e.g. 7d, 2w)")
+	cmd.Flags().Float64Var(&threshold, "threshold", 10, "Flag days differing by more than this percent")
+	cmd.Flags().BoolVar(&fetch, "fetch", false, "Import fresh billing data first")
+	cmd.Flags().BoolVar(&flaggedOnly, "flagged", false, "Only list flagged days")
+	return cmd
+}
+
+// estimateByDay sums proxy cost estimates by "provider/day" (UTC).
+func estimateByDay(logs []proxy.RequestLog, since time.Time) map[string]float64 {
+	est := make(map[string]float64)
+	for _, l := range logs {
+		if l.Timestamp.Before(since) || l.Cost == 0 {
+			continue
+		}
+		est[l.Provider+"/"+l.Timestamp.UTC().Format("2006-01-02")] += l.Cost
+	}
+	return est
 }
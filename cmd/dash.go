@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"os"
+	"time"
+
+	"github.com/msalah0e/palm/internal/dash"
+	"github.com/msalah0e/palm/internal/gpu"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+func dashCmd() *cobra.Command {
+	var interval, port, rows int
+	var once bool
+
+	cmd := &cobra.Command{
+		Use:     "dash",
+		Aliases: []string{"dashboard"},
+		Short:   "Live dashboard — processes, proxy feed, budget and activity",
+		Long: ui.Brand.Sprint(ui.Palm+" palm dash") + ` — one screen for what palm top, palm proxy logs,
+palm budget status and palm logs show separately: running AI processes, the
+live proxy request feed, budget burn-down, and recent graph and compose
+activity, redrawn in place.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg := dash.Config{
+				RefreshInterval: time.Duration(interval) * time.Second,
+				KnownBinaries:   buildKnownBinaries(loadRegistry()),
+				ProxyPort:       port,
+				Rows:            rows,
+			}
+			if once {
+				dash.Render(os.Stdout, dash.Collect(cfg, gpu.Detect()), cfg, dash.Width())
+				return
+			}
+			if err := dash.Run(cfg); err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().IntVar(&interval, "interval", 2, "Refresh interval in seconds")
+	cmd.Flags().IntVar(&port, "port", 4778, "Port of the running palm proxy")
+	cmd.Flags().IntVarP(&rows, "rows", "n", 5, "Entries per panel")
+	cmd.Flags().BoolVar(&once, "once", false, "Print one frame and exit")
+	return cmd
+}
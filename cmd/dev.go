@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/msalah0e/palm/internal/mocktools"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+func devCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dev",
+		Short: "Helpers for testing palm workflows",
+	}
+	cmd.AddCommand(devMockToolsCmd())
+	return cmd
+}
+
+func devMockToolsCmd() *cobra.Command {
+	var dir string
+	var outputs, stderrs, latencies, exits map[string]string
+	var calls bool
+
+	cmd := &cobra.Command{
+		Use:   "mock-tools [tool...]",
+		Short: "Install fake AI tools for deterministic workflow tests",
+		Long: `Install fake AI tools — shell scripts that answer with canned output after
+a fixed latency and exit code — so squad, speedtest, eval and compose can be
+tested end to end without models, network or API keys. With no tools named,
+ollama and aider are mocked.
+
+Each mock answers "<tool>: <prompt>" unless --output says otherwise; {prompt}
+in an output is replaced with the prompt. Every call is logged, and --calls
+shows the log, so tests can check what each tool was asked.
+
+  palm dev mock-tools ollama aider --latency aider=2s --exit aider=1
+  palm dev mock-tools --output ollama=$'ACCURACY: 90\nVERDICT: good'
+  export PATH="$PWD/.palm/mock-tools:$PATH"
+  palm squad "fix the bug" --tools ollama,aider --mode race
+  palm dev mock-tools --calls`,
+		Run: func(cmd *cobra.Command, args []string) {
+			abs, err := filepath.Abs(dir)
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+
+			if calls {
+				log, err := mocktools.Calls(abs)
+				if err != nil {
+					ui.Bad.Printf("  No mock tools in %s: %v\n", abs, err)
+					os.Exit(1)
+				}
+				render(log, func() {
+					ui.Banner("mock tool calls")
+					if len(log) == 0 {
+						fmt.Println("  No calls yet")
+						return
+					}
+					var rows [][]string
+					for i, c := range log {
+						rows = append(rows, []string{strconv.Itoa(i + 1), c.Tool, truncate(c.Args, 50), truncate(orDash(c.Input), 30)})
+					}
+					ui.Table([]string{"#", "Tool", "Args", "Stdin"}, rows)
+				})
+				return
+			}
+
+			specs, err := mockSpecs(args, outputs, stderrs, latencies, exits)
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+			if err := mocktools.Install(abs, specs); err != nil {
+				ui.Bad.Printf("  Failed to install mock tools: %v\n", err)
+				os.Exit(1)
+			}
+
+			path := mocktools.PathWith(abs, "$PATH")
+			render(struct {
+				Dir   string           `json:"dir"`
+				Path  string           `json:"path"`
+				Tools []mocktools.Spec `json:"tools"`
+			}{abs, path, specs}, func() {
+				ui.Banner("mock tools")
+				var rows [][]string
+				for _, s := range specs {
+					rows = append(rows, []string{s.Name, s.Latency.String(), strconv.Itoa(s.ExitCode), truncate(strings.ReplaceAll(s.Output, "\n", " "), 50)})
+				}
+				ui.Table([]string{"Tool", "Latency", "Exit", "Output"}, rows)
+				fmt.Printf("\n  Installed in %s\n", abs)
+				fmt.Println("  Put them first on PATH:")
+				ui.Info.Printf("    export PATH=\"%s\"\n", path)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&dir, "dir", filepath.Join(".palm", "mock-tools"), "Directory to install the mocks in")
+	cmd.Flags().StringToStringVar(&outputs, "output", nil, "Canned answer per tool, e.g. ollama='ACCURACY: 90'")
+	cmd.Flags().StringToStringVar(&stderrs, "stderr", nil, "Text a tool writes to stderr, e.g. aider='rate limited'")
+	cmd.Flags().StringToStringVar(&latencies, "latency", nil, "Delay per tool before answering, e.g. aider=1.5s")
+	cmd.Flags().StringToStringVar(&exits, "exit", nil, "Exit code per tool, e.g. aider=1")
+	cmd.Flags().BoolVar(&calls, "calls", false, "Show the calls the mocks in --dir have received")
+	return cmd
+}
+
+// mockSpecs builds a spec for each named tool, or the default tools when
+// none are named, plus any only named in the per-tool flags.
+func mockSpecs(names []string, outputs, stderrs, latencies, exits map[string]string) ([]mocktools.Spec, error) {
+	seen := make(map[string]bool)
+	var all []string
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			all = append(all, name)
+		}
+	}
+	if len(names) == 0 {
+		names = mocktools.DefaultNames
+	}
+	for _, n := range names {
+		add(n)
+	}
+	var flagged []string
+	for _, m := range []map[string]string{outputs, stderrs, latencies, exits} {
+		for n := range m {
+			flagged = append(flagged, n)
+		}
+	}
+	sort.Strings(flagged)
+	for _, n := range flagged {
+		add(n)
+	}
+
+	specs := make([]mocktools.Spec, 0, len(all))
+	for _, name := range all {
+		s := mocktools.Default(name)
+		if out, ok := outputs[name]; ok {
+			s.Output = out
+		}
+		s.Stderr = stderrs[name]
+		if l, ok := latencies[name]; ok {
+			d, err := time.ParseDuration(l)
+			if err != nil || d < 0 {
+				return nil, fmt.Errorf("invalid latency %q for %s (use e.g. 500ms or 2s)", l, name)
+			}
+			s.Latency = d
+		}
+		if e, ok := exits[name]; ok {
+			code, err := strconv.Atoi(e)
+			if err != nil {
+				return nil, fmt.Errorf("invalid exit code %q for %s", e, name)
+			}
+			s.ExitCode = code
+		}
+		specs = append(specs, s)
+	}
+	return specs, nil
+}
@@ -8,18 +8,25 @@ import (
 	"runtime"
 	"strings"
 
+	"github.com/msalah0e/palm/internal/installer"
 	"github.com/msalah0e/palm/internal/registry"
 	"github.com/msalah0e/palm/internal/ui"
 	"github.com/spf13/cobra"
 )
 
 func doctorCmd() *cobra.Command {
-	var deep bool
+	var deep, network bool
 
 	cmd := &cobra.Command{
 		Use:     "doctor",
 		Aliases: []string{"dr"},
 		Short:   "Health check — verify tools, keys, and runtimes",
+		Long: `Health check — verify tools, keys, and runtimes.
+
+--network checks each provider's API step by step (DNS, TCP, TLS, then a
+round trip), spots corporate proxies and TLS interception, and confirms the
+stored keys authenticate with a free model-listing call. Run it when an AI
+tool hangs or fails to connect.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			reg := loadRegistry()
 			detected := registry.DetectInstalled(reg)
@@ -35,7 +42,23 @@ func doctorCmd() *cobra.Command {
 					ver = "?"
 				}
 
-				if len(dt.KeysMissing) > 0 {
+				var problems []string
+				if unmet, err := installer.CheckRequirements(dt.Tool, reg); err != nil {
+					problems = append(problems, err.Error())
+				} else {
+					for _, u := range unmet {
+						problems = append(problems, u.String())
+					}
+				}
+				if conflicts := installer.InstalledConflicts(dt.Tool, reg); len(conflicts) > 0 {
+					problems = append(problems, "conflicts with "+strings.Join(conflicts, ", "))
+				}
+
+				if len(problems) > 0 {
+					fmt.Printf("  %s %s %s — %s\n",
+						ui.WarnIcon(), dt.Tool.Name, ver, strings.Join(problems, "; "))
+					warnings++
+				} else if len(dt.KeysMissing) > 0 {
 					fmt.Printf("  %s %s %s — missing %s\n",
 						ui.WarnIcon(), dt.Tool.Name, ver, dt.KeysMissing)
 					warnings++
@@ -85,10 +108,18 @@ func doctorCmd() *cobra.Command {
 				fmt.Println()
 				runDeepChecks()
 			}
+
+			if network {
+				fmt.Println()
+				if problems := runNetworkChecks(); problems > 0 {
+					os.Exit(1)
+				}
+			}
 		},
 	}
 
 	cmd.Flags().BoolVar(&deep, "deep", false, "Run extended health checks (configs, disk, network)")
+	cmd.Flags().BoolVar(&network, "network", false, "Diagnose reaching each provider's API and verify stored keys")
 	return cmd
 }
 
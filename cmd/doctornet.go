@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/msalah0e/palm/internal/models"
+	"github.com/msalah0e/palm/internal/netcheck"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/msalah0e/palm/internal/vault"
+)
+
+// runNetworkChecks diagnoses reaching each provider's API and whether the
+// stored key works, then explains what failed. It returns the number of
+// providers with problems.
+func runNetworkChecks() int {
+	ui.Banner("network")
+
+	proxyVars := []string{}
+	for _, name := range []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy", "ALL_PROXY", "NO_PROXY", "no_proxy"} {
+		if val := os.Getenv(name); val != "" {
+			proxyVars = append(proxyVars, name+"="+redactProxy(val))
+		}
+	}
+	if len(proxyVars) > 0 {
+		fmt.Printf("  Proxy: %s\n\n", strings.Join(proxyVars, " "))
+	} else {
+		fmt.Printf("  Proxy: %s\n\n", ui.Subtle.Sprint("none (direct connections)"))
+	}
+
+	providers := models.BuiltinProviders()
+	results := make([]netcheck.Result, len(providers))
+	keys := make([]string, len(providers))
+	v := vault.New()
+	var wg sync.WaitGroup
+	for i, p := range providers {
+		wg.Add(1)
+		go func(i int, p models.Provider) {
+			defer wg.Done()
+			results[i] = netcheck.Check(netcheck.Target{Name: p.Name, URL: p.Endpoint}, netcheck.Options{})
+			keys[i] = "-"
+			if p.EnvKey == "" || !results[i].OK() {
+				return
+			}
+			key := os.Getenv(p.EnvKey)
+			if key == "" {
+				key, _ = v.Get(p.EnvKey)
+			}
+			switch {
+			case key == "":
+				keys[i] = ui.Subtle.Sprint("not set")
+			case models.VerifyKey(&p, key) != nil:
+				keys[i] = ui.Bad.Sprint("rejected")
+			default:
+				keys[i] = ui.Good.Sprint("valid")
+			}
+		}(i, p)
+	}
+	wg.Wait()
+
+	var rows [][]string
+	var notes []string
+	problems := 0
+	for i, r := range results {
+		local := providers[i].EnvKey == ""
+		ok := r.OK() && !strings.Contains(keys[i], "rejected")
+		icon := ui.StatusIcon(ok)
+		if !ok && local {
+			icon = ui.Subtle.Sprint("-")
+		} else if !ok || r.Intercepted {
+			problems++
+		}
+		if r.Intercepted && ok {
+			icon = ui.WarnIcon()
+		}
+		rows = append(rows, []string{
+			icon + " " + r.Name,
+			r.Host,
+			netStep(r.DNS),
+			netStep(r.TCP),
+			netStep(r.TLS),
+			netStep(r.HTTP),
+			keys[i],
+		})
+		if note := netAdvice(r, local, providers[i].EnvKey, keys[i]); note != "" {
+			notes = append(notes, note)
+		}
+	}
+	ui.Table([]string{"Provider", "Host", "DNS", "TCP", "TLS", "RTT", "Key"}, rows)
+
+	if len(notes) > 0 {
+		fmt.Println()
+		for _, n := range notes {
+			fmt.Printf("  %s\n", n)
+		}
+	}
+	return problems
+}
+
+// netStep renders a stage's timing, or what went wrong in a word.
+func netStep(s netcheck.Step) string {
+	switch {
+	case s.Skipped:
+		return ui.Subtle.Sprint("-")
+	case netcheck.IsTimeout(s.Err):
+		return ui.Bad.Sprint("timeout")
+	case netcheck.IsRefused(s.Err):
+		return ui.Bad.Sprint("refused")
+	case s.Err != nil:
+		return ui.Bad.Sprint("failed")
+	}
+	return formatLatency(s.Took)
+}
+
+func formatLatency(d time.Duration) string {
+	if d < time.Millisecond {
+		return "<1ms"
+	}
+	return fmt.Sprintf("%dms", d.Milliseconds())
+}
+
+// netAdvice explains a provider's failure and what to try.
+func netAdvice(r netcheck.Result, local bool, envKey, key string) string {
+	stage, err := r.Failure()
+	name := ui.Brand.Sprint(r.Name)
+	switch {
+	case stage == "" && strings.Contains(key, "rejected"):
+		return fmt.Sprintf("%s %s: %s was rejected — replace it with: palm keys add %s", ui.StatusIcon(false), name, envKey, envKey)
+	case stage == "" && r.Intercepted:
+		return fmt.Sprintf("%s %s: TLS is inspected by %s. This system trusts it, but tools that bundle their own CAs (Node, Python) may not:\n    set NODE_EXTRA_CA_CERTS and REQUESTS_CA_BUNDLE to its root certificate", ui.WarnIcon(), name, r.Issuer)
+	case stage == "":
+		return ""
+	case local && netcheck.IsRefused(err):
+		return ""
+	case stage == "dns":
+		return fmt.Sprintf("%s %s: can't resolve %s (%v) — check your DNS or VPN", ui.StatusIcon(false), name, r.Host, err)
+	case stage == "tcp" && r.Proxy != nil:
+		return fmt.Sprintf("%s %s: the proxy %s failed (%v) — check HTTPS_PROXY", ui.StatusIcon(false), name, r.Proxy.Host, err)
+	case stage == "tcp" && netcheck.IsTimeout(err):
+		return fmt.Sprintf("%s %s: connecting to %s timed out — a firewall may drop the traffic; behind a corporate proxy, set HTTPS_PROXY", ui.StatusIcon(false), name, r.Host)
+	case stage == "tls" && r.Intercepted:
+		return fmt.Sprintf("%s %s: %v — a proxy or security product intercepts TLS.\n    Point tools at your company's root certificate: SSL_CERT_FILE, NODE_EXTRA_CA_CERTS and REQUESTS_CA_BUNDLE", ui.StatusIcon(false), name, err)
+	}
+	return fmt.Sprintf("%s %s: %s failed: %v", ui.StatusIcon(false), name, stage, err)
+}
+
+// redactProxy hides credentials in a proxy URL.
+func redactProxy(val string) string {
+	at := strings.LastIndex(val, "@")
+	if at < 0 {
+		return val
+	}
+	start := 0
+	if i := strings.Index(val, "://"); i >= 0 && i < at {
+		start = i + 3
+	}
+	return val[:start] + "***" + val[at:]
+}
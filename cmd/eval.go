@@ -6,9 +6,12 @@ import (
 	"math"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/msalah0e/palm/internal/activity"
+	"github.com/msalah0e/palm/internal/grounding"
 	"github.com/msalah0e/palm/internal/ui"
 	"github.com/msalah0e/palm/internal/vault"
 	"github.com/spf13/cobra"
@@ -16,10 +19,14 @@ import (
 
 func evalCmd() *cobra.Command {
 	var (
-		tools   string
-		context string
-		judge   string
-		timeout int
+		tools     string
+		context   string
+		judge     string
+		timeout   int
+		groundDir string
+		passages  int
+		allKeys   bool
+		seed      int64
 	)
 
 	cmd := &cobra.Command{
@@ -34,7 +41,10 @@ This gives you a trustworthiness score for each tool on your specific use cases.
 Examples:
   palm eval "What is the capital of France?" --tools ollama,mods
   palm eval "Explain how TCP works" --tools ollama,aider --context "networking basics"
-  palm eval "What year was Python released?" --tools ollama,mods --judge ollama`,
+  palm eval "What year was Python released?" --tools ollama,mods --judge ollama
+  palm eval "Explain CRDTs" --tools aider,mods --judge ollama,mods,llm
+  palm eval "How do I rotate keys?" --tools ollama,mods --grounding docs/
+  palm eval ab "Explain CRDTs" --tools aider,mods --rounds 10   # blinded pairwise, Elo`,
 		Aliases: []string{"evaluate", "check"},
 		Args:    cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
@@ -53,6 +63,23 @@ Examples:
 			if judge == "" {
 				judge = toolNames[0] // Use first tool as judge if not specified
 			}
+			judges := judgeList(judge)
+
+			// Retrieve supporting passages once; every response is judged
+			// against the same sources.
+			var sources []grounding.Chunk
+			if groundDir != "" {
+				chunks, err := grounding.Load(groundDir)
+				if err != nil {
+					ui.Bad.Printf("  Cannot read grounding sources: %v\n", err)
+					os.Exit(1)
+				}
+				sources = grounding.Retrieve(chunks, question+" "+context, passages)
+				if len(sources) == 0 {
+					ui.Bad.Printf("  No passages in %s match the question\n", groundDir)
+					os.Exit(1)
+				}
+			}
 
 			ui.Banner("eval")
 			printEvalHeader()
@@ -62,57 +89,101 @@ Examples:
 				fmt.Printf("  Context:  %s\n", ui.Subtle.Sprint(context))
 			}
 			fmt.Printf("  Tools:    %s\n", strings.Join(toolNames, ", "))
-			fmt.Printf("  Judge:    %s\n", ui.Info.Sprint(judge))
+			if len(judges) > 1 {
+				fmt.Printf("  Judges:   %s\n", ui.Info.Sprint(strings.Join(judges, ", ")))
+			} else {
+				fmt.Printf("  Judge:    %s\n", ui.Info.Sprint(judge))
+			}
+			if len(sources) > 0 {
+				refs := make([]string, len(sources))
+				for i, c := range sources {
+					refs[i] = c.Ref()
+				}
+				fmt.Printf("  Sources:  %s\n", ui.Subtle.Sprint(strings.Join(refs, ", ")))
+			}
 			fmt.Println()
 
+			applySeed(&seed)
 			reg := loadRegistry()
 			v := vault.New()
-			env := buildVaultEnv(v)
+			env := buildVaultEnv(v, "eval", reg, append(toolNames, judges...), allKeys)
 
 			// Run all tools on the question
-			results := runSquad(toolNames, question, reg, env, timeout)
+			results := runSquad(toolNames, question, nil, reg, env, timeout)
 
 			// Now evaluate each result
 			fmt.Println()
 			fmt.Printf("  %s Evaluating responses...\n", ui.Info.Sprint("🔍"))
 			fmt.Println()
 
-			var scores []evalScore
-
-			for _, r := range results {
-				if r.Error != "" {
-					scores = append(scores, evalScore{
-						Tool:    r.Tool,
-						Verdict: "FAILED: " + r.Error,
-					})
-					continue
-				}
-
-				// Build evaluation prompt
-				evalPrompt := buildEvalPrompt(question, context, r.Output)
-				judgeOutput := runJudgeTool(judge, evalPrompt, env, timeout)
+			scores := judgeResults(results, question, context, sources, judges, env, timeout)
 
-				score := parseEvalScore(r.Tool, judgeOutput)
-				scores = append(scores, score)
+			for _, s := range scores {
+				_ = activity.Append(activity.Entry{
+					Action:  "eval",
+					Tool:    s.Tool,
+					Details: question,
+					Status:  activity.StatusOf(!strings.HasPrefix(s.Verdict, "FAILED")),
+					Value:   float64(s.Overall),
+				})
 			}
 
 			// Print scorecard
 			printEvalScorecard(scores)
+			if len(judges) > 1 {
+				printJudgeAgreement(scores, judges)
+			}
+			recordRun(cmd, "eval", seed, []string{question}, append(toolNames, judges...), evalRunResults(toolNames, results, scores))
 		},
 	}
 
 	cmd.Flags().StringVar(&tools, "tools", "", "Comma-separated list of tools to evaluate (required)")
 	cmd.Flags().StringVar(&context, "context", "", "Additional context for evaluation")
-	cmd.Flags().StringVar(&judge, "judge", "", "Tool to use as evaluator (default: first tool)")
+	cmd.Flags().StringVar(&judge, "judge", "", "Tool(s) to use as evaluator, comma-separated to average several judges (default: first tool)")
 	cmd.Flags().IntVar(&timeout, "timeout", 60, "Timeout per tool in seconds")
+	cmd.Flags().StringVar(&groundDir, "grounding", "", "File or directory of source documents to check claims against")
+	cmd.Flags().IntVar(&passages, "passages", 5, "Number of source passages given to the judge with --grounding")
+	cmd.Flags().BoolVar(&allKeys, "all-keys", false, "Inject every vault key, not just the ones the tools need")
+	addSeedFlag(cmd, &seed)
 	_ = cmd.MarkFlagRequired("tools")
+
+	cmd.AddCommand(evalSuiteCmd(), evalABCmd(), evalLeaderboardCmd())
 	return cmd
 }
 
+// judgeResults scores each tool's response with the judges, averaging them
+// when there are several. Failed runs score zero with a FAILED verdict.
+func judgeResults(results []SquadResult, question, context string, sources []grounding.Chunk, judges, env []string, timeout int) []evalScore {
+	var scores []evalScore
+	for _, r := range results {
+		if r.Error != "" {
+			scores = append(scores, evalScore{
+				Tool:    r.Tool,
+				Verdict: "FAILED: " + r.Error,
+			})
+			continue
+		}
+
+		// Build evaluation prompt; every judge scores independently
+		evalPrompt := buildEvalPrompt(question, context, r.Output)
+		if len(sources) > 0 {
+			evalPrompt = buildGroundedEvalPrompt(question, context, r.Output, sources)
+		}
+		if len(judges) == 1 {
+			scores = append(scores, parseEvalScore(r.Tool, runJudgeTool(judges[0], evalPrompt, env, timeout)))
+			continue
+		}
+		var perJudge []evalScore
+		for _, out := range runJudges(judges, evalPrompt, env, timeout) {
+			perJudge = append(perJudge, parseEvalScore(r.Tool, out))
+		}
+		scores = append(scores, averageScores(r.Tool, perJudge))
+	}
+	return scores
+}
+
 func printEvalHeader() {
-	fmt.Println(ui.Brand.Sprint("  ╔═══════════════════════════════════════════════════╗"))
-	fmt.Println(ui.Brand.Sprint("  ║") + "   🔬  " + ui.Brand.Sprint("palm eval") + " — AI Accuracy & Trust Scanner     " + ui.Brand.Sprint("║"))
-	fmt.Println(ui.Brand.Sprint("  ╚═══════════════════════════════════════════════════╝"))
+	ui.Box(ui.BoxDouble, "", []string{"🔬  " + ui.Brand.Sprint("palm eval") + " — AI Accuracy & Trust Scanner"})
 }
 
 func buildEvalPrompt(question, context, response string) string {
@@ -145,15 +216,53 @@ Scoring guide:
 - CLARITY: How clear and well-structured? 100 = crystal clear`, question, contextPart, response)
 }
 
-func runJudgeTool(judge, prompt string, env []string, timeout int) string {
-	var cmdArgs []string
-	switch judge {
-	case "ollama":
-		cmdArgs = []string{"ollama", "run", "llama3.3", prompt}
-	default:
-		cmdArgs = []string{judge, prompt}
+// buildGroundedEvalPrompt asks the judge to check the response against the
+// retrieved source passages rather than its own knowledge.
+func buildGroundedEvalPrompt(question, context, response string, sources []grounding.Chunk) string {
+	contextPart := ""
+	if context != "" {
+		contextPart = fmt.Sprintf("\nContext: %s\n", context)
+	}
+
+	var src strings.Builder
+	for i, c := range sources {
+		fmt.Fprintf(&src, "[%d] %s\n%s\n\n", i+1, c.Ref(), c.Text)
 	}
 
+	return fmt.Sprintf(`You are checking whether an AI response is faithful to the provided sources.
+
+Question: "%s"%s
+
+Sources:
+---
+%s---
+
+AI Response:
+---
+%s
+---
+
+Judge every claim ONLY against the sources above, not your own knowledge. Reply in EXACTLY this format:
+
+ACCURACY: [0-100]
+HALLUCINATION: [0-100]
+COMPLETENESS: [0-100]
+CLARITY: [0-100]
+GROUNDED: [0-100]
+UNSUPPORTED: [claims not backed by the sources, separated by ";" — or "none"]
+VERDICT: [one sentence summary]
+
+Scoring guide:
+- ACCURACY: How well do the claims match the sources? 100 = fully consistent
+- HALLUCINATION: How much is contradicted by or absent from the sources? 0 = none
+- COMPLETENESS: Does it answer the question using what the sources contain? 100 = thorough
+- CLARITY: How clear and well-structured? 100 = crystal clear
+- GROUNDED: Share of claims supported by a source. 100 = every claim is supported`, question, contextPart, src.String(), response)
+}
+
+func runJudgeTool(judge, prompt string, env []string, timeout int) string {
+	cmdArgs := promptArgs(loadRegistry(), judge, prompt)
+
 	var stdout bytes.Buffer
 	c := exec.Command(cmdArgs[0], cmdArgs[1:]...)
 	c.Stdout = &stdout
@@ -188,10 +297,13 @@ type evalScore struct {
 	Clarity       int
 	Overall       int
 	Verdict       string
+	PerJudge      []int // each judge's Overall when an ensemble scored this tool
+	Grounded      int   // share of claims supported by --grounding sources; -1 when not checked
+	Unsupported   []string
 }
 
 func parseEvalScore(tool, output string) evalScore {
-	score := evalScore{Tool: tool}
+	score := evalScore{Tool: tool, Grounded: -1}
 
 	// Parse scores from judge output
 	lines := strings.Split(output, "\n")
@@ -205,6 +317,10 @@ func parseEvalScore(tool, output string) evalScore {
 			fmt.Sscanf(strings.TrimPrefix(line, "COMPLETENESS:"), "%d", &score.Completeness)
 		} else if strings.HasPrefix(line, "CLARITY:") {
 			fmt.Sscanf(strings.TrimPrefix(line, "CLARITY:"), "%d", &score.Clarity)
+		} else if strings.HasPrefix(line, "GROUNDED:") {
+			fmt.Sscanf(strings.TrimPrefix(line, "GROUNDED:"), "%d", &score.Grounded)
+		} else if strings.HasPrefix(line, "UNSUPPORTED:") {
+			score.Unsupported = parseUnsupported(strings.TrimPrefix(line, "UNSUPPORTED:"))
 		} else if strings.HasPrefix(line, "VERDICT:") {
 			score.Verdict = strings.TrimSpace(strings.TrimPrefix(line, "VERDICT:"))
 		}
@@ -223,57 +339,68 @@ func parseEvalScore(tool, output string) evalScore {
 	if score.Accuracy > 0 || score.Completeness > 0 {
 		hallPenalty := float64(score.Hallucination) * 0.5
 		raw := (float64(score.Accuracy)*0.4 + float64(score.Completeness)*0.3 + float64(score.Clarity)*0.3) - hallPenalty
+		if score.Grounded >= 0 {
+			// Faithfulness to the sources outweighs style in grounded evals
+			raw = (float64(score.Accuracy)*0.3 + float64(score.Grounded)*0.3 + float64(score.Completeness)*0.2 + float64(score.Clarity)*0.2) - hallPenalty
+		}
 		score.Overall = int(math.Max(0, math.Min(100, raw)))
 	}
 
 	return score
 }
 
-func printEvalScorecard(scores []evalScore) {
-	fmt.Println(ui.Brand.Sprint("  ┌──────────────────────────────────────────────────────────────────┐"))
-	fmt.Println(ui.Brand.Sprint("  │") + "  " + ui.Brand.Sprint("EVALUATION SCORECARD") + "                                            " + ui.Brand.Sprint("│"))
-	fmt.Println(ui.Brand.Sprint("  ├──────────────────────────────────────────────────────────────────┤"))
+// parseUnsupported splits the judge's UNSUPPORTED list, treating "none" as empty.
+func parseUnsupported(s string) []string {
+	var claims []string
+	for _, c := range strings.Split(s, ";") {
+		c = strings.Trim(strings.TrimSpace(c), `"[]`)
+		if c == "" || strings.EqualFold(c, "none") || strings.EqualFold(c, "n/a") {
+			continue
+		}
+		claims = append(claims, c)
+	}
+	return claims
+}
 
+func printEvalScorecard(scores []evalScore) {
+	var lines []string
 	for _, s := range scores {
-		fmt.Println(ui.Brand.Sprint("  │") + "                                                                  " + ui.Brand.Sprint("│"))
+		lines = append(lines, "")
 
 		if s.Accuracy == 0 && s.Hallucination == 0 && s.Verdict != "" && strings.HasPrefix(s.Verdict, "FAILED") {
-			line := fmt.Sprintf("  %-14s  %s", s.Tool, ui.Bad.Sprint(s.Verdict))
-			pad := max(0, 64-len(s.Tool)-2-len(s.Verdict)-2)
-			fmt.Println(ui.Brand.Sprint("  │") + line + strings.Repeat(" ", pad) + ui.Brand.Sprint("│"))
+			lines = append(lines, fmt.Sprintf("%-14s  %s", s.Tool, ui.Bad.Sprint(s.Verdict)))
 			continue
 		}
 
-		// Tool name
-		toolLine := fmt.Sprintf("  %s", ui.Brand.Sprint(s.Tool))
-		overallGrade := gradeFromScore(s.Overall)
-		gradePad := max(0, 60-len(s.Tool)-len(overallGrade)-4)
-		fmt.Println(ui.Brand.Sprint("  │") + toolLine + strings.Repeat(" ", gradePad) + overallGrade + "  " + ui.Brand.Sprint("│"))
+		// Tool name, with the grade at the end of the score bars
+		lines = append(lines, ui.Brand.Sprint(s.Tool)+strings.Repeat(" ", max(2, 42-ui.Width(s.Tool)))+gradeFromScore(s.Overall))
 
 		// Score bars
-		printScoreBar("  Accuracy", s.Accuracy, true)
-		printScoreBar("  Hallucination", s.Hallucination, false) // Lower is better
-		printScoreBar("  Completeness", s.Completeness, true)
-		printScoreBar("  Clarity", s.Clarity, true)
+		lines = append(lines,
+			scoreBarLine("Accuracy", s.Accuracy, true),
+			scoreBarLine("Hallucination", s.Hallucination, false), // Lower is better
+			scoreBarLine("Completeness", s.Completeness, true),
+			scoreBarLine("Clarity", s.Clarity, true),
+		)
+		if s.Grounded >= 0 {
+			lines = append(lines, scoreBarLine("Grounded", s.Grounded, true))
+		}
 
 		// Overall
-		fmt.Printf(ui.Brand.Sprint("  │")+"  Overall: %s  %s\n",
-			formatScoreNum(s.Overall),
-			strings.Repeat(" ", max(0, 46))+ui.Brand.Sprint("│"))
+		lines = append(lines, "Overall: "+formatScoreNum(s.Overall))
 
 		// Verdict
 		if s.Verdict != "" {
-			verdictLine := fmt.Sprintf("  💬 %s", s.Verdict)
-			if len(verdictLine) > 62 {
-				verdictLine = verdictLine[:62] + "..."
-			}
-			pad := max(0, 66-len(verdictLine))
-			fmt.Println(ui.Brand.Sprint("  │") + verdictLine + strings.Repeat(" ", pad) + ui.Brand.Sprint("│"))
+			lines = append(lines, ui.Clip("💬 "+s.Verdict, 64))
 		}
-	}
 
-	fmt.Println(ui.Brand.Sprint("  │") + "                                                                  " + ui.Brand.Sprint("│"))
-	fmt.Println(ui.Brand.Sprint("  └──────────────────────────────────────────────────────────────────┘"))
+		// Claims the sources do not back
+		for _, claim := range s.Unsupported {
+			lines = append(lines, ui.Warn.Sprint(ui.Clip("✗ "+claim, 64)))
+		}
+	}
+	lines = append(lines, "")
+	ui.Box(ui.BoxSingle, ui.Brand.Sprint("EVALUATION SCORECARD"), lines)
 
 	// Trust recommendation
 	fmt.Println()
@@ -292,7 +419,36 @@ func printEvalScorecard(scores []evalScore) {
 	fmt.Println()
 }
 
-func printScoreBar(label string, score int, higherIsBetter bool) {
+// printJudgeAgreement shows each judge's scores and how many judges agree
+// with the highest-mean winner.
+func printJudgeAgreement(scores []evalScore, judges []string) {
+	var rows [][]string
+	for _, s := range scores {
+		if len(s.PerJudge) == 0 {
+			continue
+		}
+		rows = append(rows, []string{s.Tool, strconv.Itoa(s.Overall), formatPerJudge(judges, s.PerJudge), strconv.Itoa(scoreSpread(s.PerJudge))})
+	}
+	if len(rows) == 0 {
+		return
+	}
+	ui.Table([]string{"Tool", "Mean", "Per judge", "Spread"}, rows)
+
+	if best, agree := judgeAgreement(scores, len(judges)); best != "" {
+		fmt.Printf("\n  Judge agreement: %d/%d judges ranked %s first", agree, len(judges), ui.Brand.Sprint(best))
+		switch {
+		case agree == len(judges):
+			ui.Good.Println(" — high confidence")
+		case agree*2 > len(judges):
+			ui.Warn.Println(" — moderate confidence")
+		default:
+			ui.Bad.Println(" — low confidence")
+		}
+	}
+	fmt.Println()
+}
+
+func scoreBarLine(label string, score int, higherIsBetter bool) string {
 	barWidth := 20
 	filled := score * barWidth / 100
 
@@ -319,9 +475,7 @@ func printScoreBar(label string, score int, higherIsBetter bool) {
 	empty := ui.Subtle.Sprint(strings.Repeat("░", barWidth-filled))
 	scoreStr := formatScoreNum(score)
 
-	line := fmt.Sprintf("  %-16s %s%s %s", label, bar, empty, scoreStr)
-	// Approximate padding — the ANSI codes make exact calc tricky
-	fmt.Printf(ui.Brand.Sprint("  │")+"%s\n", line)
+	return fmt.Sprintf("%-16s %s%s %s", label, bar, empty, scoreStr)
 }
 
 func formatScoreNum(score int) string {
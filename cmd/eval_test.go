@@ -4,6 +4,8 @@ import (
 	"math"
 	"strings"
 	"testing"
+
+	"github.com/msalah0e/palm/internal/grounding"
 )
 
 func TestParseEvalScore_ValidOutput(t *testing.T) {
@@ -197,6 +199,43 @@ func TestBuildEvalPrompt_WithoutContext(t *testing.T) {
 	}
 }
 
+func TestBuildGroundedEvalPrompt(t *testing.T) {
+	sources := []grounding.Chunk{{Source: "docs/keys.md", Line: 12, Text: "Keys are stored in the system keychain."}}
+	prompt := buildGroundedEvalPrompt("Where are keys stored?", "", "In a plain text file", sources)
+
+	for _, want := range []string{"docs/keys.md:12", "system keychain", "GROUNDED", "UNSUPPORTED"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("grounded prompt should contain %q", want)
+		}
+	}
+}
+
+func TestParseEvalScore_Grounded(t *testing.T) {
+	output := `ACCURACY: 40
+HALLUCINATION: 60
+COMPLETENESS: 70
+CLARITY: 90
+GROUNDED: 25
+UNSUPPORTED: keys live in ~/.palmrc; rotation is automatic
+VERDICT: Mostly unsupported`
+
+	score := parseEvalScore("tool", output)
+	if score.Grounded != 25 {
+		t.Errorf("expected grounded 25, got %d", score.Grounded)
+	}
+	if len(score.Unsupported) != 2 || score.Unsupported[1] != "rotation is automatic" {
+		t.Errorf("unexpected unsupported claims %q", score.Unsupported)
+	}
+
+	plain := parseEvalScore("tool", "ACCURACY: 80\nVERDICT: ok")
+	if plain.Grounded != -1 || plain.Unsupported != nil {
+		t.Errorf("ungrounded eval should not report grounding, got %d %q", plain.Grounded, plain.Unsupported)
+	}
+	if got := parseUnsupported(" none "); got != nil {
+		t.Errorf("expected no claims for none, got %q", got)
+	}
+}
+
 func TestGradeFromScore(t *testing.T) {
 	tests := []struct {
 		score int
@@ -227,3 +266,60 @@ func TestFormatScoreNum(t *testing.T) {
 		}
 	}
 }
+
+func TestParseJudges(t *testing.T) {
+	got := parseJudges(" ollama, mods,,ollama ,llm")
+	want := []string{"ollama", "mods", "llm"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("parseJudges = %v, want %v", got, want)
+	}
+	if got := parseJudges(" , ,"); len(got) != 0 {
+		t.Errorf("a --judge of only commas should name no judges, got %v", got)
+	}
+}
+
+func TestParseVoteChoice(t *testing.T) {
+	valid := map[int]bool{1: true, 3: true}
+	pick, reason := parseVoteChoice("1. Candidate 3\n2. It handles edge cases correctly.\n3. ...", valid)
+	if pick != 3 {
+		t.Errorf("expected pick 3, got %d", pick)
+	}
+	if reason != "It handles edge cases correctly." {
+		t.Errorf("unexpected reason %q", reason)
+	}
+
+	if pick, _ := parseVoteChoice("Candidate 2 is best", valid); pick != 0 {
+		t.Errorf("candidate 2 is not valid, got pick %d", pick)
+	}
+}
+
+func TestTallyVotes(t *testing.T) {
+	winner, votes, tie := tallyVotes([]judgeBallot{{Pick: 2}, {Pick: 1}, {Pick: 2}, {Pick: 0}})
+	if winner != 2 || votes != 2 || tie {
+		t.Errorf("expected candidate 2 with 2 votes, got %d/%d tie=%v", winner, votes, tie)
+	}
+
+	if _, _, tie := tallyVotes([]judgeBallot{{Pick: 1}, {Pick: 2}}); !tie {
+		t.Error("expected a tie")
+	}
+}
+
+func TestAverageScoresAndAgreement(t *testing.T) {
+	a := averageScores("a", []evalScore{{Accuracy: 80, Overall: 70}, {Accuracy: 60, Overall: 50}, {Accuracy: 70, Overall: 60}})
+	b := averageScores("b", []evalScore{{Accuracy: 90, Overall: 80}, {Accuracy: 40, Overall: 40}, {Accuracy: 90, Overall: 75}})
+	if a.Accuracy != 70 || a.Overall != 60 || len(a.PerJudge) != 3 {
+		t.Errorf("unexpected average %+v", a)
+	}
+	if scoreSpread(b.PerJudge) != 40 {
+		t.Errorf("expected spread 40, got %d", scoreSpread(b.PerJudge))
+	}
+
+	if none := averageScores("c", nil); none.Overall != 0 || none.Grounded != -1 {
+		t.Errorf("no scores should average to an empty score, got %+v", none)
+	}
+
+	best, agree := judgeAgreement([]evalScore{a, b}, 3)
+	if best != "b" || agree != 2 {
+		t.Errorf("expected b ranked first by 2 judges, got %s by %d", best, agree)
+	}
+}
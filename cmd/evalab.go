@@ -0,0 +1,573 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/msalah0e/palm/internal/activity"
+	"github.com/msalah0e/palm/internal/runs"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/msalah0e/palm/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+// abComparison is one blinded judgement between two tools' answers.
+type abComparison struct {
+	Round  int    `json:"round"`
+	A      string `json:"a"`
+	B      string `json:"b"`
+	Winner string `json:"winner"` // a tool, or "tie"
+	Reason string `json:"reason,omitempty"`
+}
+
+// abRow is one tool's record over an A/B run and its Elo rating after it.
+type abRow struct {
+	Tool    string  `json:"tool"`
+	Wins    int     `json:"wins"`
+	Losses  int     `json:"losses"`
+	Ties    int     `json:"ties"`
+	Rating  float64 `json:"rating"`
+	Change  float64 `json:"change"`
+	Matches int     `json:"matches"` // all rated comparisons in the category
+}
+
+type abReport struct {
+	Question    string         `json:"question"`
+	Category    string         `json:"category"`
+	Rounds      []string       `json:"rounds"` // the prompt asked in each round
+	Comparisons []abComparison `json:"comparisons"`
+	Tools       []abRow        `json:"tools"` // by rating, best first
+}
+
+func evalABCmd() *cobra.Command {
+	var (
+		tools    string
+		context  string
+		judge    string
+		category string
+		rounds   int
+		timeout  int
+		allKeys  bool
+		seed     int64
+	)
+
+	cmd := &cobra.Command{
+		Use:   `ab "<question>" --tools tool1,tool2`,
+		Short: "Compare tools head to head with a blinded judge and Elo ratings",
+		Long: `Compare tools pairwise instead of scoring each answer on its own. The
+first round asks the question as given and later rounds ask paraphrases
+of it written by the judge. The judge sees each pair of answers as
+"Response A" and "Response B" in random order and picks the better one or
+a tie. Judges are more consistent choosing between two
+answers than putting a number on one.
+
+Every judgement updates a persistent Elo leaderboard for the category
+(general unless --category is given), so results accumulate across runs.
+
+Examples:
+  palm eval ab "Explain Go interfaces" --tools aider,mods --rounds 10
+  palm eval ab "Refactor this loop" --tools aider,goose,mods --category coding --judge ollama
+  palm eval leaderboard coding`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			question := args[0]
+			toolNames := parseJudges(tools)
+			if len(toolNames) < 2 {
+				ui.Warn.Println("  Provide at least 2 tools: --tools tool1,tool2")
+				os.Exit(1)
+			}
+			if rounds < 1 {
+				ui.Warn.Println("  --rounds must be at least 1")
+				os.Exit(1)
+			}
+			category = strings.ToLower(strings.TrimSpace(category))
+			if category == "" {
+				category = defaultCategory
+			}
+			if judge == "" {
+				judge = toolNames[0]
+			}
+			judges := judgeList(judge)
+
+			board, err := loadEloBoard()
+			if err != nil {
+				ui.Bad.Printf("  Can't read the leaderboard: %v\n", err)
+				os.Exit(1)
+			}
+
+			if !jsonOutput {
+				ui.Banner("eval ab")
+				fmt.Printf("  Question: %s\n", ui.Brand.Sprint(question))
+				fmt.Printf("  Tools:    %s\n", strings.Join(toolNames, ", "))
+				fmt.Printf("  Judge:    %s\n", ui.Info.Sprint(strings.Join(judges, ", ")))
+				fmt.Printf("  Category: %s · %d rounds\n\n", category, rounds)
+			}
+
+			applySeed(&seed)
+			rng := rand.New(rand.NewSource(seed))
+			reg := loadRegistry()
+			env := buildVaultEnv(vault.New(), "eval", reg, append(toolNames, judges...), allKeys)
+
+			prompts := []string{question}
+			if rounds > 1 {
+				out := runJudgeTool(judges[0], buildParaphrasePrompt(question, rounds-1), env, timeout)
+				prompts = append(prompts, parseParaphrases(out, question, rounds-1)...)
+			}
+
+			before := make(map[string]float64)
+			for _, t := range toolNames {
+				before[t] = board.rating(category, t).Rating
+			}
+			var comparisons []abComparison
+			for round, prompt := range prompts {
+				if !jsonOutput {
+					fmt.Printf("  [%d/%d] %s\n", round+1, len(prompts), truncate(prompt, 70))
+				}
+				results := runSquad(toolNames, prompt, nil, reg, env, timeout)
+				for i := 0; i < len(toolNames); i++ {
+					for j := i + 1; j < len(toolNames); j++ {
+						c, ok := compareAB(rng, round+1, prompt, context, toolNames[i], toolNames[j], results[i], results[j], judges, env, timeout)
+						if !ok {
+							continue
+						}
+						comparisons = append(comparisons, c)
+						board.record(category, c)
+						if !jsonOutput {
+							fmt.Printf("         %s vs %s → %s\n", c.A, c.B, abWinnerLabel(c))
+						}
+					}
+				}
+			}
+
+			report := abReport{Question: question, Category: category, Rounds: prompts, Comparisons: comparisons}
+			report.Tools = abRows(board, category, toolNames, comparisons, before)
+			if err := saveEloBoard(board); err != nil {
+				ui.Warn.Fprintf(os.Stderr, "  %s Leaderboard not saved: %v\n", ui.WarnIcon(), err)
+			}
+
+			var results []runs.Result
+			for _, r := range report.Tools {
+				_ = activity.Append(activity.Entry{
+					Action:  "eval",
+					Tool:    r.Tool,
+					Details: "ab " + question,
+					Status:  activity.StatusOf(r.Wins+r.Ties > 0),
+					Value:   r.Rating,
+				})
+				results = append(results, runs.Result{Tool: r.Tool, Metrics: map[string]float64{
+					"wins": float64(r.Wins), "losses": float64(r.Losses), "ties": float64(r.Ties), "elo": r.Rating,
+				}})
+			}
+
+			render(report, func() {
+				fmt.Println()
+				if len(comparisons) == 0 {
+					ui.Warn.Println("  No comparison could be judged")
+					return
+				}
+				var rows [][]string
+				for _, r := range report.Tools {
+					rows = append(rows, []string{
+						ui.Brand.Sprint(r.Tool),
+						fmt.Sprintf("%d", r.Wins), fmt.Sprintf("%d", r.Losses), fmt.Sprintf("%d", r.Ties),
+						fmt.Sprintf("%.0f", r.Rating), eloChange(r.Change),
+					})
+				}
+				ui.Table([]string{"TOOL", "WINS", "LOSSES", "TIES", "ELO", "CHANGE"}, rows)
+				fmt.Printf("\n  %d comparisons · leaderboard: palm eval leaderboard %s\n", len(comparisons), category)
+			})
+			recordRun(cmd, "eval-ab", seed, prompts, append(toolNames, judges...), results)
+		},
+	}
+
+	cmd.Flags().StringVar(&tools, "tools", "", "Comma-separated list of tools to compare (required)")
+	cmd.Flags().StringVar(&context, "context", "", "Additional context for the judge")
+	cmd.Flags().StringVar(&judge, "judge", "", "Tool(s) to judge with, comma-separated for a majority of several judges (default: first tool)")
+	cmd.Flags().StringVar(&category, "category", defaultCategory, "Leaderboard category the results count towards")
+	cmd.Flags().IntVar(&rounds, "rounds", 3, "Rounds to run, each with a paraphrase of the question")
+	cmd.Flags().IntVar(&timeout, "timeout", 60, "Timeout per tool in seconds")
+	cmd.Flags().BoolVar(&allKeys, "all-keys", false, "Inject every vault key, not just the ones the tools need")
+	addSeedFlag(cmd, &seed)
+	_ = cmd.MarkFlagRequired("tools")
+	return cmd
+}
+
+// compareAB judges a against b. The answers are shown in random order so
+// the judge can't favour a position or a name. A tool that failed loses
+// without a judgement; ok is false when nothing could be decided.
+func compareAB(rng *rand.Rand, round int, question, context, a, b string, ra, rb SquadResult, judges, env []string, timeout int) (abComparison, bool) {
+	c := abComparison{Round: round, A: a, B: b}
+	switch {
+	case ra.Error != "" && rb.Error != "":
+		return c, false
+	case ra.Error != "":
+		c.Winner, c.Reason = b, a+" failed: "+ra.Error
+		return c, true
+	case rb.Error != "":
+		c.Winner, c.Reason = a, b+" failed: "+rb.Error
+		return c, true
+	}
+
+	swapped := rng.Intn(2) == 1
+	first, second := ra.Output, rb.Output
+	if swapped {
+		first, second = second, first
+	}
+	prompt := buildPairwisePrompt(question, context, first, second)
+
+	votes := make(map[string]int)
+	for _, out := range runJudges(judges, prompt, env, timeout) {
+		verdict, reason := parsePairwiseVerdict(out)
+		if verdict == "" {
+			continue
+		}
+		if swapped && verdict != "tie" {
+			verdict = map[string]string{"A": "B", "B": "A"}[verdict]
+		}
+		votes[verdict]++
+		if c.Reason == "" {
+			c.Reason = reason
+		}
+	}
+	switch {
+	case votes["A"]+votes["B"]+votes["tie"] == 0:
+		return c, false
+	case votes["A"] > votes["B"]:
+		c.Winner = a
+	case votes["B"] > votes["A"]:
+		c.Winner = b
+	default:
+		c.Winner = "tie"
+	}
+	return c, true
+}
+
+func buildParaphrasePrompt(question string, n int) string {
+	return fmt.Sprintf(`Rewrite the following question %d different ways. Keep the meaning, the
+facts and any code exactly the same; change only the wording.
+
+Question: "%s"
+
+Reply with one rewrite per line, numbered 1 to %d, and nothing else.`, n, question, n)
+}
+
+// parseParaphrases reads up to n distinct rewrites from the judge's reply,
+// repeating the original question for any it didn't provide.
+func parseParaphrases(output, question string, n int) []string {
+	seen := map[string]bool{strings.ToLower(question): true}
+	var out []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "0123456789.)-*# "))
+		line = strings.Trim(line, `"`)
+		if line == "" || seen[strings.ToLower(line)] || len(out) == n {
+			continue
+		}
+		seen[strings.ToLower(line)] = true
+		out = append(out, line)
+	}
+	for len(out) < n {
+		out = append(out, question)
+	}
+	return out
+}
+
+func buildPairwisePrompt(question, context, first, second string) string {
+	contextPart := ""
+	if context != "" {
+		contextPart = fmt.Sprintf("\nContext: %s\n", context)
+	}
+
+	return fmt.Sprintf(`You are comparing two AI responses to the same question. Judge accuracy
+first, then completeness and clarity. Ignore length and the order the
+responses appear in.
+
+Question: "%s"%s
+
+Response A:
+---
+%s
+---
+
+Response B:
+---
+%s
+---
+
+Reply in EXACTLY this format:
+
+WINNER: [A, B or TIE]
+REASON: [one sentence]`, question, contextPart, first, second)
+}
+
+var (
+	winnerRe = regexp.MustCompile(`(?i)winner\W*(?:response\s+)?(A|B|TIE)\b`)
+	reasonRe = regexp.MustCompile(`(?i)reason\W*(.+)`)
+)
+
+// parsePairwiseVerdict returns "A", "B" or "tie", or "" when the judge gave
+// no usable answer.
+func parsePairwiseVerdict(output string) (string, string) {
+	m := winnerRe.FindStringSubmatch(output)
+	if m == nil {
+		return "", ""
+	}
+	verdict := strings.ToUpper(m[1])
+	if verdict == "TIE" {
+		verdict = "tie"
+	}
+	reason := ""
+	if r := reasonRe.FindStringSubmatch(output); r != nil {
+		reason = truncate(strings.TrimSpace(r[1]), 80)
+	}
+	return verdict, reason
+}
+
+func abWinnerLabel(c abComparison) string {
+	if c.Winner == "tie" {
+		return ui.Subtle.Sprint("tie")
+	}
+	return ui.Good.Sprint(c.Winner)
+}
+
+// abRows tallies each tool's results in this run, ordered by rating.
+func abRows(board eloBoard, category string, toolNames []string, comparisons []abComparison, before map[string]float64) []abRow {
+	var rows []abRow
+	for _, t := range toolNames {
+		r := board.rating(category, t)
+		row := abRow{Tool: t, Rating: r.Rating, Change: r.Rating - before[t], Matches: r.Games}
+		for _, c := range comparisons {
+			if c.A != t && c.B != t {
+				continue
+			}
+			switch c.Winner {
+			case "tie":
+				row.Ties++
+			case t:
+				row.Wins++
+			default:
+				row.Losses++
+			}
+		}
+		rows = append(rows, row)
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Rating > rows[j].Rating })
+	return rows
+}
+
+func eloChange(d float64) string {
+	switch {
+	case math.Round(d) > 0:
+		return ui.Good.Sprintf("+%.0f", d)
+	case math.Round(d) < 0:
+		return ui.Bad.Sprintf("%.0f", d)
+	}
+	return ui.Subtle.Sprint("±0")
+}
+
+const (
+	eloStart = 1500.0
+	eloK     = 32.0
+)
+
+// eloRating is a tool's standing in one category.
+type eloRating struct {
+	Rating float64 `json:"rating"`
+	Games  int     `json:"games"`
+	Wins   int     `json:"wins"`
+	Losses int     `json:"losses"`
+	Ties   int     `json:"ties"`
+}
+
+// eloBoard holds ratings by category, then tool.
+type eloBoard map[string]map[string]*eloRating
+
+func eloBoardPath() string {
+	return filepath.Join(palmConfigDir(), "eval-elo.json")
+}
+
+func loadEloBoard() (eloBoard, error) {
+	board := eloBoard{}
+	data, err := os.ReadFile(eloBoardPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return board, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &board); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(eloBoardPath()), err)
+	}
+	return board, nil
+}
+
+func saveEloBoard(board eloBoard) error {
+	path := eloBoardPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(board, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// rating returns tool's rating in category, starting it at eloStart.
+func (b eloBoard) rating(category, tool string) *eloRating {
+	if b[category] == nil {
+		b[category] = make(map[string]*eloRating)
+	}
+	r, ok := b[category][tool]
+	if !ok {
+		r = &eloRating{Rating: eloStart}
+		b[category][tool] = r
+	}
+	return r
+}
+
+// record updates both tools' ratings with the outcome of c.
+func (b eloBoard) record(category string, c abComparison) {
+	ra, rb := b.rating(category, c.A), b.rating(category, c.B)
+	score := 0.5
+	switch c.Winner {
+	case c.A:
+		score = 1
+		ra.Wins++
+		rb.Losses++
+	case c.B:
+		score = 0
+		ra.Losses++
+		rb.Wins++
+	default:
+		ra.Ties++
+		rb.Ties++
+	}
+	expected := 1 / (1 + math.Pow(10, (rb.Rating-ra.Rating)/400))
+	delta := eloK * (score - expected)
+	ra.Rating += delta
+	rb.Rating -= delta
+	ra.Games++
+	rb.Games++
+}
+
+// eloStanding is one row of palm eval leaderboard.
+type eloStanding struct {
+	Rank int    `json:"rank"`
+	Tool string `json:"tool"`
+	eloRating
+}
+
+// standings ranks the tools of category, best first.
+func (b eloBoard) standings(category string) []eloStanding {
+	var out []eloStanding
+	for tool, r := range b[category] {
+		out = append(out, eloStanding{Tool: tool, eloRating: *r})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Rating != out[j].Rating {
+			return out[i].Rating > out[j].Rating
+		}
+		return out[i].Tool < out[j].Tool
+	})
+	for i := range out {
+		out[i].Rank = i + 1
+	}
+	return out
+}
+
+func evalLeaderboardCmd() *cobra.Command {
+	var reset bool
+
+	cmd := &cobra.Command{
+		Use:   "leaderboard [category]",
+		Short: "Show the Elo ratings built up by palm eval ab",
+		Long: `Show the Elo leaderboard that palm eval ab updates after every pairwise
+judgement, for one category or all of them. Tools start at 1500.
+
+  palm eval leaderboard
+  palm eval leaderboard coding
+  palm eval leaderboard coding --reset`,
+		Args: cobra.MaximumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			board, _ := loadEloBoard()
+			var out []string
+			for c := range board {
+				out = append(out, c)
+			}
+			sort.Strings(out)
+			return out, cobra.ShellCompDirectiveNoFileComp
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			board, err := loadEloBoard()
+			if err != nil {
+				ui.Bad.Printf("  Can't read the leaderboard: %v\n", err)
+				os.Exit(1)
+			}
+			var categories []string
+			if len(args) == 1 {
+				categories = []string{strings.ToLower(args[0])}
+			} else {
+				for c := range board {
+					categories = append(categories, c)
+				}
+				sort.Strings(categories)
+			}
+
+			if reset {
+				for _, c := range categories {
+					delete(board, c)
+				}
+				if err := saveEloBoard(board); err != nil {
+					ui.Bad.Printf("  %v\n", err)
+					os.Exit(1)
+				}
+				ui.Good.Printf("  %s Reset %s\n", ui.StatusIcon(true), strings.Join(categories, ", "))
+				return
+			}
+
+			out := make(map[string][]eloStanding)
+			for _, c := range categories {
+				out[c] = board.standings(c)
+			}
+			render(out, func() {
+				ui.Banner("eval leaderboard")
+				shown := 0
+				for _, c := range categories {
+					if len(out[c]) == 0 {
+						continue
+					}
+					if shown > 0 {
+						fmt.Println()
+					}
+					shown++
+					fmt.Printf("  %s\n", ui.Info.Sprint(c))
+					var rows [][]string
+					for _, s := range out[c] {
+						rows = append(rows, []string{
+							fmt.Sprintf("%d", s.Rank), ui.Brand.Sprint(s.Tool), fmt.Sprintf("%.0f", s.Rating),
+							fmt.Sprintf("%d-%d-%d", s.Wins, s.Losses, s.Ties), fmt.Sprintf("%d", s.Games),
+						})
+					}
+					ui.Table([]string{"#", "TOOL", "ELO", "W-L-T", "GAMES"}, rows)
+				}
+				if shown == 0 {
+					fmt.Println("  No ratings yet")
+					fmt.Println(`  Compare tools: palm eval ab "<question>" --tools tool1,tool2`)
+				}
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&reset, "reset", false, "Clear the ratings of the category, or of every category")
+	return cmd
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestParsePairwiseVerdict(t *testing.T) {
+	tests := []struct{ out, verdict string }{
+		{"WINNER: A\nREASON: more accurate", "A"},
+		{"**Winner:** Response B\nReason: covers edge cases", "B"},
+		{"WINNER: tie\nREASON: equally good", "tie"},
+		{"Both are fine.", ""},
+	}
+	for _, tt := range tests {
+		if got, _ := parsePairwiseVerdict(tt.out); got != tt.verdict {
+			t.Errorf("parsePairwiseVerdict(%q) = %q, want %q", tt.out, got, tt.verdict)
+		}
+	}
+	if _, reason := parsePairwiseVerdict("WINNER: A\nREASON: more accurate"); reason != "more accurate" {
+		t.Errorf("reason = %q", reason)
+	}
+}
+
+func TestParseParaphrases(t *testing.T) {
+	got := parseParaphrases("1. How does TCP work?\n2. \"Explain TCP\"\n3. explain tcp\n", "Explain TCP", 3)
+	if strings.Join(got, "|") != "How does TCP work?|Explain TCP|Explain TCP" {
+		t.Errorf("paraphrases should skip repeats of the question and fall back to it, got %q", got)
+	}
+}
+
+func TestEloBoard(t *testing.T) {
+	board := eloBoard{}
+	board.record("coding", abComparison{A: "aider", B: "mods", Winner: "aider"})
+	a, m := board.rating("coding", "aider"), board.rating("coding", "mods")
+	if a.Rating != 1516 || m.Rating != 1484 || a.Wins != 1 || m.Losses != 1 {
+		t.Errorf("an even match should move 16 points, got %+v %+v", a, m)
+	}
+
+	// Once ahead, a tie costs the favourite
+	board.record("coding", abComparison{A: "aider", B: "mods", Winner: "tie"})
+	if a.Rating >= 1516 || a.Ties != 1 || a.Games != 2 {
+		t.Errorf("a tie against a weaker tool should lower the rating, got %+v", a)
+	}
+	if s := board.standings("coding"); len(s) != 2 || s[0].Tool != "aider" || s[0].Rank != 1 {
+		t.Errorf("unexpected standings %+v", s)
+	}
+	if len(board.standings("math")) != 0 {
+		t.Error("categories should be rated separately")
+	}
+}
+
+func TestCompareABFailedTool(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	c, ok := compareAB(rng, 1, "q", "", "aider", "mods", SquadResult{Output: "answer"}, SquadResult{Error: "timeout"}, nil, nil, 1)
+	if !ok || c.Winner != "aider" {
+		t.Errorf("a tool that fails should lose without a judgement, got %+v", c)
+	}
+	if _, ok := compareAB(rng, 1, "q", "", "aider", "mods", SquadResult{Error: "x"}, SquadResult{Error: "y"}, nil, nil, 1); ok {
+		t.Error("two failures can't be compared")
+	}
+}
@@ -0,0 +1,365 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/msalah0e/palm/internal/activity"
+	"github.com/msalah0e/palm/internal/runs"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/msalah0e/palm/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+// evalSuite is a question bank, loaded from TOML:
+//
+//	name = "daily-driver"
+//
+//	[[questions]]
+//	question = "What is 17 * 23?"
+//	category = "math"
+//	expected = "391"
+type evalSuite struct {
+	Name      string          `toml:"name"`
+	Questions []suiteQuestion `toml:"questions"`
+}
+
+type suiteQuestion struct {
+	Question string `toml:"question"`
+	Category string `toml:"category"` // coding, math, knowledge, extraction, ...
+	Context  string `toml:"context"`
+	Expected string `toml:"expected"` // reference answer shown to the judge
+}
+
+// defaultCategory groups questions that have no category.
+const defaultCategory = "general"
+
+func loadEvalSuite(path string) (*evalSuite, error) {
+	var s evalSuite
+	if _, err := toml.DecodeFile(path, &s); err != nil {
+		return nil, err
+	}
+	if len(s.Questions) == 0 {
+		return nil, fmt.Errorf("%s has no [[questions]]", path)
+	}
+	for i := range s.Questions {
+		q := &s.Questions[i]
+		if strings.TrimSpace(q.Question) == "" {
+			return nil, fmt.Errorf("question #%d is empty", i+1)
+		}
+		q.Category = strings.ToLower(strings.TrimSpace(q.Category))
+		if q.Category == "" {
+			q.Category = defaultCategory
+		}
+	}
+	if s.Name == "" {
+		s.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return &s, nil
+}
+
+// judgeContext is what the judge sees besides the question: the question's
+// context and, when given, the reference answer.
+func (q suiteQuestion) judgeContext() string {
+	parts := []string{}
+	if q.Context != "" {
+		parts = append(parts, q.Context)
+	}
+	if q.Expected != "" {
+		parts = append(parts, "Reference answer: "+q.Expected)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// suiteScore is one tool's score on one question.
+type suiteScore struct {
+	Tool     string
+	Category string
+	Overall  int
+	Failed   bool
+}
+
+// suiteReport is the per-tool, per-category scorecard of a suite run.
+type suiteReport struct {
+	Suite      string     `json:"suite"`
+	Questions  int        `json:"questions"`
+	Categories []string   `json:"categories"`
+	Tools      []suiteRow `json:"tools"` // best first
+}
+
+type suiteRow struct {
+	Rank       int                `json:"rank"`
+	Tool       string             `json:"tool"`
+	Overall    float64            `json:"overall"`    // mean of the category means
+	Categories map[string]float64 `json:"categories"` // mean score per category
+	Failed     int                `json:"failed"`     // questions the tool didn't answer
+}
+
+// buildSuiteReport averages scores per tool and category. The overall score
+// weighs categories equally, so a suite heavy on one category doesn't hide
+// weakness in another. Failed answers count as zero.
+func buildSuiteReport(suite *evalSuite, tools []string, scores []suiteScore) suiteReport {
+	r := suiteReport{Suite: suite.Name, Questions: len(suite.Questions)}
+	seen := make(map[string]bool)
+	for _, q := range suite.Questions {
+		if !seen[q.Category] {
+			seen[q.Category] = true
+			r.Categories = append(r.Categories, q.Category)
+		}
+	}
+
+	for _, tool := range tools {
+		row := suiteRow{Tool: tool, Categories: make(map[string]float64)}
+		sums, counts := make(map[string]int), make(map[string]int)
+		for _, s := range scores {
+			if s.Tool != tool {
+				continue
+			}
+			sums[s.Category] += s.Overall
+			counts[s.Category]++
+			if s.Failed {
+				row.Failed++
+			}
+		}
+		n := 0
+		for _, c := range r.Categories {
+			if counts[c] == 0 {
+				continue
+			}
+			mean := float64(sums[c]) / float64(counts[c])
+			row.Categories[c] = mean
+			row.Overall += mean
+			n++
+		}
+		if n > 0 {
+			row.Overall /= float64(n)
+		}
+		r.Tools = append(r.Tools, row)
+	}
+
+	sort.SliceStable(r.Tools, func(i, j int) bool { return r.Tools[i].Overall > r.Tools[j].Overall })
+	for i := range r.Tools {
+		r.Tools[i].Rank = i + 1
+	}
+	return r
+}
+
+// matrixRows renders the report as rows of strings: a header, then one row
+// per tool in rank order.
+func (r suiteReport) matrixRows() [][]string {
+	header := append([]string{"Rank", "Tool"}, r.Categories...)
+	header = append(header, "Overall", "Failed")
+	rows := [][]string{header}
+	for _, t := range r.Tools {
+		row := []string{strconv.Itoa(t.Rank), t.Tool}
+		for _, c := range r.Categories {
+			if v, ok := t.Categories[c]; ok {
+				row = append(row, fmt.Sprintf("%.0f", v))
+			} else {
+				row = append(row, "-")
+			}
+		}
+		row = append(row, fmt.Sprintf("%.0f", t.Overall), strconv.Itoa(t.Failed))
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func (r suiteReport) markdown() string {
+	rows := r.matrixRows()
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Eval: %s\n\n%d questions · scores are 0–100, higher is better\n\n", r.Suite, r.Questions)
+	b.WriteString("| " + strings.Join(rows[0], " | ") + " |\n")
+	b.WriteString("|" + strings.Repeat(" --- |", len(rows[0])) + "\n")
+	for _, row := range rows[1:] {
+		b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+	}
+	return b.String()
+}
+
+func (r suiteReport) csv() (string, error) {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	if err := w.WriteAll(r.matrixRows()); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func evalSuiteCmd() *cobra.Command {
+	var (
+		tools   string
+		judge   string
+		timeout int
+		export  string
+		allKeys bool
+		seed    int64
+	)
+
+	cmd := &cobra.Command{
+		Use:   "suite <questions.toml> --tools tool1,tool2",
+		Short: "Run a category-tagged question bank and score tools per category",
+		Long: `Run every question in a suite file through each tool, judge the answers,
+and print a tool × category matrix with an overall ranking. Categories
+count equally in the overall score.
+
+  name = "daily-driver"
+
+  [[questions]]
+  question = "What is 17 * 23?"
+  category = "math"
+  expected = "391"          # optional reference answer for the judge
+
+  [[questions]]
+  question = "Extract the dates from: 'Shipped 3 May, fixed 9 June'"
+  category = "extraction"
+
+Examples:
+  palm eval suite bank.toml --tools ollama,aider --judge mods
+  palm eval suite bank.toml --tools ollama,aider --export scores.md
+  palm eval suite bank.toml --tools ollama,aider --export scores.csv`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			suite, err := loadEvalSuite(args[0])
+			if err != nil {
+				ui.Bad.Printf("  Can't load suite: %v\n", err)
+				os.Exit(1)
+			}
+			if export != "" {
+				if ext := strings.ToLower(filepath.Ext(export)); ext != ".md" && ext != ".markdown" && ext != ".csv" {
+					ui.Bad.Printf("  Unknown export format %q (use .md or .csv)\n", ext)
+					os.Exit(1)
+				}
+			}
+			toolNames := strings.Split(tools, ",")
+			for i := range toolNames {
+				toolNames[i] = strings.TrimSpace(toolNames[i])
+			}
+			if judge == "" {
+				judge = toolNames[0]
+			}
+			judges := parseJudges(judge)
+
+			if !jsonOutput {
+				ui.Banner("eval suite")
+				fmt.Printf("  Suite:    %s (%d questions)\n", ui.Brand.Sprint(suite.Name), len(suite.Questions))
+				fmt.Printf("  Tools:    %s\n", strings.Join(toolNames, ", "))
+				fmt.Printf("  Judge:    %s\n\n", ui.Info.Sprint(strings.Join(judges, ", ")))
+			}
+
+			applySeed(&seed)
+			reg := loadRegistry()
+			env := buildVaultEnv(vault.New(), "eval", reg, append(toolNames, judges...), allKeys)
+
+			var scores []suiteScore
+			for i, q := range suite.Questions {
+				if !jsonOutput {
+					fmt.Printf("  [%d/%d] %s %s\n", i+1, len(suite.Questions), ui.Subtle.Sprint(q.Category), truncate(q.Question, 60))
+				}
+				results := runSquad(toolNames, q.Question, nil, reg, env, timeout)
+				for j, s := range judgeResults(results, q.Question, q.judgeContext(), nil, judges, env, timeout) {
+					// runSquad reports display names; keep the names asked for
+					scores = append(scores, suiteScore{
+						Tool:     toolNames[j],
+						Category: q.Category,
+						Overall:  s.Overall,
+						Failed:   strings.HasPrefix(s.Verdict, "FAILED"),
+					})
+				}
+			}
+
+			report := buildSuiteReport(suite, toolNames, scores)
+			for _, t := range report.Tools {
+				_ = activity.Append(activity.Entry{
+					Action:  "eval",
+					Tool:    t.Tool,
+					Details: "suite " + suite.Name,
+					Status:  activity.StatusOf(t.Failed < report.Questions),
+					Value:   t.Overall,
+				})
+			}
+
+			var prompts []string
+			for _, q := range suite.Questions {
+				prompts = append(prompts, q.Question)
+			}
+			var results []runs.Result
+			for _, t := range report.Tools {
+				metrics := map[string]float64{"overall": t.Overall, "failed": float64(t.Failed)}
+				for c, v := range t.Categories {
+					metrics["category."+c] = v
+				}
+				results = append(results, runs.Result{Tool: t.Tool, Metrics: metrics})
+			}
+
+			if export != "" {
+				if err := exportSuiteReport(report, export); err != nil {
+					ui.Bad.Printf("  Export failed: %v\n", err)
+					os.Exit(1)
+				}
+			}
+
+			render(report, func() {
+				fmt.Println()
+				rows := report.matrixRows()
+				ui.Table(rows[0], rows[1:])
+				fmt.Println()
+				if len(report.Tools) > 0 {
+					best := report.Tools[0]
+					fmt.Printf("  %s Best overall: %s (%.0f/100)\n", ui.Brand.Sprint("🏆"), ui.Brand.Sprint(best.Tool), best.Overall)
+				}
+				for _, c := range report.Categories {
+					if tool, score, ok := bestInCategory(report, c); ok {
+						fmt.Printf("  %s %s\n", ui.Subtle.Sprintf("%-12s", c), fmt.Sprintf("%s (%.0f)", tool, score))
+					}
+				}
+				if export != "" {
+					fmt.Println()
+					ui.Good.Printf("  %s Wrote %s\n", ui.StatusIcon(true), export)
+				}
+			})
+			recordRun(cmd, "eval-suite", seed, prompts, append(toolNames, judges...), results)
+		},
+	}
+
+	cmd.Flags().StringVar(&tools, "tools", "", "Comma-separated list of tools to evaluate (required)")
+	cmd.Flags().StringVar(&judge, "judge", "", "Tool(s) to use as evaluator, comma-separated to average several judges (default: first tool)")
+	cmd.Flags().IntVar(&timeout, "timeout", 60, "Timeout per tool in seconds")
+	cmd.Flags().StringVar(&export, "export", "", "Also write the matrix to a .md or .csv file")
+	cmd.Flags().BoolVar(&allKeys, "all-keys", false, "Inject every vault key, not just the ones the tools need")
+	addSeedFlag(cmd, &seed)
+	_ = cmd.MarkFlagRequired("tools")
+	return cmd
+}
+
+func bestInCategory(r suiteReport, category string) (string, float64, bool) {
+	best, score, found := "", -1.0, false
+	for _, t := range r.Tools {
+		if v, ok := t.Categories[category]; ok && v > score {
+			best, score, found = t.Tool, v, true
+		}
+	}
+	return best, score, found
+}
+
+func exportSuiteReport(r suiteReport, path string) error {
+	var data string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		data = r.markdown()
+	case ".csv":
+		var err error
+		if data, err = r.csv(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown export format %q (use .md or .csv)", filepath.Ext(path))
+	}
+	return os.WriteFile(path, []byte(data), 0o644)
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadEvalSuite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bank.toml")
+	os.WriteFile(path, []byte(`
+[[questions]]
+question = "What is 17 * 23?"
+category = "Math"
+expected = "391"
+
+[[questions]]
+question = "Who wrote Dune?"
+`), 0o644)
+
+	s, err := loadEvalSuite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Name != "bank" {
+		t.Errorf("name = %q, want it from the file name", s.Name)
+	}
+	if s.Questions[0].Category != "math" || s.Questions[1].Category != defaultCategory {
+		t.Errorf("categories = %q, %q", s.Questions[0].Category, s.Questions[1].Category)
+	}
+	if !strings.Contains(s.Questions[0].judgeContext(), "Reference answer: 391") {
+		t.Errorf("judge context = %q", s.Questions[0].judgeContext())
+	}
+
+	os.WriteFile(path, []byte(`name = "empty"`), 0o644)
+	if _, err := loadEvalSuite(path); err == nil {
+		t.Error("a suite without questions should be rejected")
+	}
+}
+
+func TestBuildSuiteReport(t *testing.T) {
+	suite := &evalSuite{Name: "bank", Questions: []suiteQuestion{
+		{Category: "math"}, {Category: "math"}, {Category: "math"}, {Category: "extraction"},
+	}}
+	scores := []suiteScore{
+		{Tool: "llama", Category: "math", Overall: 20},
+		{Tool: "llama", Category: "math", Overall: 30},
+		{Tool: "llama", Category: "math", Overall: 0, Failed: true},
+		{Tool: "llama", Category: "extraction", Overall: 90},
+		{Tool: "claude", Category: "math", Overall: 90},
+		{Tool: "claude", Category: "math", Overall: 90},
+		{Tool: "claude", Category: "math", Overall: 90},
+		{Tool: "claude", Category: "extraction", Overall: 80},
+	}
+
+	r := buildSuiteReport(suite, []string{"llama", "claude"}, scores)
+	if strings.Join(r.Categories, ",") != "math,extraction" {
+		t.Errorf("categories = %v, want suite order", r.Categories)
+	}
+	if r.Tools[0].Tool != "claude" || r.Tools[0].Rank != 1 {
+		t.Fatalf("ranking = %+v", r.Tools)
+	}
+	llama := r.Tools[1]
+	if llama.Categories["math"] != 50.0/3 || llama.Categories["extraction"] != 90 || llama.Failed != 1 {
+		t.Errorf("llama = %+v", llama)
+	}
+	// Categories weigh equally: (16.7 + 90) / 2, not the per-question mean
+	if want := (50.0/3 + 90) / 2; llama.Overall != want {
+		t.Errorf("llama overall = %v, want %v", llama.Overall, want)
+	}
+
+	md := r.markdown()
+	if !strings.Contains(md, "| Rank | Tool | math | extraction | Overall | Failed |") || !strings.Contains(md, "| 2 | llama | 17 | 90 | 53 | 1 |") {
+		t.Errorf("markdown:\n%s", md)
+	}
+	csv, err := r.csv()
+	if err != nil || !strings.HasPrefix(csv, "Rank,Tool,math,extraction,Overall,Failed\n1,claude,90,80,85,0\n") {
+		t.Errorf("csv = %q, %v", csv, err)
+	}
+}
@@ -1,26 +1,64 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
 	"strings"
+	"time"
 
 	"github.com/msalah0e/palm/internal/gpu"
+	"github.com/msalah0e/palm/internal/models"
 	"github.com/msalah0e/palm/internal/ui"
 	"github.com/spf13/cobra"
 )
 
 func gpuCmd() *cobra.Command {
-	return &cobra.Command{
+	var watch bool
+	var interval time.Duration
+
+	cmd := &cobra.Command{
 		Use:   "gpu",
 		Short: "Detect and display GPU information",
 		Long: `Detect available GPUs and their capabilities for local LLM inference.
 
-  palm gpu                 # Show GPU info and model recommendations`,
+  palm gpu                 # Show GPU info and model recommendations
+  palm gpu --watch         # Live memory and utilization (NVIDIA, AMD)`,
 		Run: func(cmd *cobra.Command, args []string) {
-			ui.Banner("GPU detection")
+			if watch {
+				watchGPUs(interval)
+				return
+			}
 
 			gpus := gpu.Detect()
 
+			if jsonOutput {
+				type gpuJSON struct {
+					Index          int    `json:"index"`
+					Vendor         string `json:"vendor"`
+					Model          string `json:"model"`
+					Driver         string `json:"driver,omitempty"`
+					Compute        string `json:"compute,omitempty"`
+					VRAMTotalMB    int    `json:"vram_total_mb"`
+					Unified        bool   `json:"unified,omitempty"`
+					VRAMUsedMB     *int   `json:"vram_used_mb,omitempty"`
+					UtilizationPct *int   `json:"utilization_pct,omitempty"`
+				}
+				out := make([]gpuJSON, 0, len(gpus))
+				for _, g := range gpus {
+					j := gpuJSON{g.Index, g.Vendor, g.Model, g.Driver, g.Compute, g.VRAMTotalMB, g.Unified, nil, nil}
+					if g.Sampled {
+						j.VRAMUsedMB, j.UtilizationPct = &g.VRAMUsedMB, &g.UtilizationPct
+					}
+					out = append(out, j)
+				}
+				printJSON(out)
+				return
+			}
+
+			ui.Banner("GPU detection")
+
 			if len(gpus) == 0 {
 				fmt.Println("  No GPU detected")
 				fmt.Println()
@@ -29,49 +67,87 @@ func gpuCmd() *cobra.Command {
 				return
 			}
 
-			headers := []string{"Vendor", "Model", "VRAM", "Compute"}
-			var rows [][]string
-
-			for _, g := range gpus {
-				vendor := g.Vendor
-				if vendor == "" {
-					vendor = "Unknown"
-				}
-				vram := g.VRAM
-				if vram == "" {
-					vram = "-"
-				}
-				compute := g.Compute
-				if compute == "" {
-					compute = "-"
-				}
-
-				rows = append(rows, []string{vendor, g.Model, vram, compute})
-			}
-
-			ui.Table(headers, rows)
+			printGPUTable(gpus)
 
 			// Show recommendation
 			fmt.Println()
 			fmt.Printf("  %s Recommended models for your hardware:\n", ui.Brand.Sprint("🌴"))
 
-			g := gpus[0]
-			if strings.Contains(strings.ToLower(g.Vendor), "apple") {
-				fmt.Println("    - llama3.3       (8B, runs great on Apple Silicon)")
-				fmt.Println("    - deepseek-coder (for coding tasks)")
-				fmt.Println("    - phi3:mini      (lightweight, fast)")
-			} else if strings.Contains(g.VRAM, "24") || strings.Contains(g.VRAM, "48") {
-				fmt.Println("    - llama3.3:70b   (70B, needs 48GB+ VRAM)")
-				fmt.Println("    - llama3.3       (8B, fast on your GPU)")
-				fmt.Println("    - mixtral        (47B MoE, needs 32GB+)")
-			} else {
-				fmt.Println("    - llama3.3       (8B, good general model)")
-				fmt.Println("    - phi3:mini      (3.8B, lightweight)")
-				fmt.Println("    - tinyllama      (1.1B, minimal resources)")
+			recs, _ := models.Recommend(models.Request{
+				Task:    "chat",
+				Budget:  "free",
+				LocalOK: true,
+				VRAMMB:  gpu.MaxVRAMMB(gpus),
+			}, 3)
+			for _, r := range recs {
+				fmt.Printf("    - %-15s (%s)\n", r.Model.ID, r.Model.Name)
 			}
-
+			if len(recs) == 0 {
+				fmt.Println("    - none of the built-in local models fit; try a cloud model")
+			}
+			fmt.Println("  More options: palm models recommend --local-ok")
 			fmt.Println()
 			fmt.Println("  Install: palm serve pull <model>")
 		},
 	}
+
+	cmd.Flags().BoolVarP(&watch, "watch", "w", false, "Refresh memory and utilization until Ctrl-C")
+	cmd.Flags().DurationVar(&interval, "interval", 2*time.Second, "Refresh interval for --watch")
+	return cmd
+}
+
+func printGPUTable(gpus []gpu.Info) {
+	var rows [][]string
+	for _, g := range gpus {
+		vendor := g.Vendor
+		if vendor == "" {
+			vendor = "Unknown"
+		}
+		used, util := "-", "-"
+		if g.Sampled {
+			used = gpu.FormatMB(g.VRAMUsedMB)
+			util = fmt.Sprintf("%s %3d%%", usageBar(g.UtilizationPct, 10), g.UtilizationPct)
+		}
+		rows = append(rows, []string{fmt.Sprint(g.Index), vendor, g.Model, orDash(g.VRAM()), used, util, orDash(g.Compute)})
+	}
+	ui.Table([]string{"#", "Vendor", "Model", "VRAM", "Used", "Utilization", "Compute"}, rows)
+}
+
+// usageBar draws pct as a bar of width cells.
+func usageBar(pct, width int) string {
+	filled := min(max(pct*width/100, 0), width)
+	return strings.Repeat("█", filled) + ui.Subtle.Sprint(strings.Repeat("░", width-filled))
+}
+
+// watchGPUs redraws the GPU table every interval until interrupted.
+func watchGPUs(interval time.Duration) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Print("\033[?25l")
+	defer fmt.Print("\033[?25h")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		gpus := gpu.Detect()
+		fmt.Print("\033[H\033[J")
+		ui.Banner("GPU monitor")
+		if len(gpus) == 0 {
+			fmt.Println("  No GPU detected")
+			return
+		}
+		printGPUTable(gpus)
+		fmt.Println()
+		if !gpus[0].Sampled {
+			fmt.Printf("  %s\n", ui.Subtle.Sprint("Live usage is only reported for NVIDIA (nvidia-smi) and AMD (rocm-smi) GPUs"))
+		}
+		fmt.Printf("  %s\n", ui.Subtle.Sprintf("Updated %s · every %s · Ctrl-C to quit", time.Now().Format("15:04:05"), interval))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
 }
@@ -1,14 +1,17 @@
 package cmd
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
 
+	"github.com/msalah0e/palm/internal/activity"
 	"github.com/msalah0e/palm/internal/graph"
 	"github.com/msalah0e/palm/internal/ui"
 	"github.com/spf13/cobra"
@@ -44,6 +47,9 @@ func graphCmd() *cobra.Command {
 			fmt.Printf("  %s  %d\n", ui.Brand.Sprintf("%-16s", "Types"), stats.Types)
 			fmt.Println()
 			fmt.Printf("  %s\n", ui.Subtle.Sprint("Stored encrypted at ~/.config/palm/graph.enc"))
+			if graph.ReadOnly() {
+				fmt.Printf("  %s\n", ui.Warn.Sprint("Read-only: readonly = true under [graph]"))
+			}
 		},
 	}
 
@@ -55,8 +61,18 @@ func graphCmd() *cobra.Command {
 		graphSearchCmd(),
 		graphListCmd(),
 		graphRemoveCmd(),
+		graphRenameCmd(),
+		graphMergeCmd(),
+		graphAliasCmd(),
+		graphGCCmd(),
+		graphDedupeCmd(),
+		graphTypesCmd(),
 		graphExportCmd(),
 		graphImportCmd(),
+		graphDiffCmd(),
+		graphPullCmd(),
+		graphBackupCmd(),
+		graphRestoreCmd(),
 		graphViewCmd(),
 	)
 
@@ -65,6 +81,8 @@ func graphCmd() *cobra.Command {
 
 func graphAddCmd() *cobra.Command {
 	var entityType string
+	var fields []string
+	var strict bool
 
 	cmd := &cobra.Command{
 		Use:   "add <name>",
@@ -82,30 +100,57 @@ func graphAddCmd() *cobra.Command {
 				os.Exit(1)
 			}
 
+			var keys, observations []string
+			for _, f := range fields {
+				key, value, ok := strings.Cut(f, "=")
+				if !ok || strings.TrimSpace(key) == "" {
+					ui.Bad.Printf("  Invalid --field %q (use key=value)\n", f)
+					os.Exit(1)
+				}
+				keys = append(keys, strings.TrimSpace(key))
+				observations = append(observations, strings.TrimSpace(key)+": "+strings.TrimSpace(value))
+			}
+			if strict {
+				if err := g.CheckEntity(entityType, keys); err != nil {
+					ui.Bad.Printf("  %v\n", err)
+					os.Exit(1)
+				}
+			}
+			if def := g.TypeDef(entityType); def != nil {
+				entityType = def.Name
+			}
+
 			if err := g.AddEntity(name, entityType); err != nil {
 				ui.Bad.Printf("  %v\n", err)
 				os.Exit(1)
 			}
+			for _, o := range observations {
+				_ = g.AddObservation(name, o)
+			}
 
 			if err := graph.Save(g); err != nil {
 				ui.Bad.Printf("  Failed to save graph: %v\n", err)
 				os.Exit(1)
 			}
 
+			logGraphChange("add", fmt.Sprintf("%s (%s)", name, entityType))
 			ui.Good.Printf("  %s Added %s (%s)\n", ui.StatusIcon(true), ui.Brand.Sprint(name), entityType)
 		},
 	}
 
 	cmd.Flags().StringVar(&entityType, "type", "", "Entity type (e.g., person, project, tool)")
+	cmd.Flags().StringArrayVar(&fields, "field", nil, "Field as key=value, stored as an observation (repeatable)")
+	cmd.Flags().BoolVar(&strict, "strict", false, "Reject types and fields not declared with 'palm graph types define'")
 	return cmd
 }
 
 func graphObserveCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:     "observe <name> <observation>",
-		Short:   "Add an observation to an entity",
-		Aliases: []string{"obs", "note"},
-		Args:    cobra.ExactArgs(2),
+		Use:               "observe <name> <observation>",
+		Short:             "Add an observation to an entity",
+		Aliases:           []string{"obs", "note"},
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: graphEntityCompletion(0),
 		Run: func(cmd *cobra.Command, args []string) {
 			name, observation := args[0], args[1]
 
@@ -116,6 +161,11 @@ func graphObserveCmd() *cobra.Command {
 			}
 
 			if err := g.AddObservation(name, observation); err != nil {
+				if errors.Is(err, graph.ErrDuplicateObservation) {
+					e, _ := g.GetEntity(name)
+					fmt.Printf("  %s already has that observation — nothing added\n", ui.Brand.Sprint(e.Name))
+					return
+				}
 				ui.Bad.Printf("  %v\n", err)
 				os.Exit(1)
 			}
@@ -126,16 +176,20 @@ func graphObserveCmd() *cobra.Command {
 			}
 
 			e, _ := g.GetEntity(name)
+			logGraphChange("observe", e.Name+": "+observation)
 			ui.Good.Printf("  %s Added observation to %s (%d total)\n", ui.StatusIcon(true), ui.Brand.Sprint(e.Name), len(e.Observations))
 		},
 	}
 }
 
 func graphRelateCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "relate <from> <relation> <to>",
-		Short: "Create a directed relation between entities",
-		Args:  cobra.ExactArgs(3),
+	var strict bool
+
+	cmd := &cobra.Command{
+		Use:               "relate <from> <relation> <to>",
+		Short:             "Create a directed relation between entities",
+		Args:              cobra.ExactArgs(3),
+		ValidArgsFunction: graphEntityCompletion(0, 2),
 		Run: func(cmd *cobra.Command, args []string) {
 			from, relType, to := args[0], args[1], args[2]
 
@@ -145,6 +199,13 @@ func graphRelateCmd() *cobra.Command {
 				os.Exit(1)
 			}
 
+			if strict {
+				if err := g.CheckRelation(from, relType, to); err != nil {
+					ui.Bad.Printf("  %v\n", err)
+					os.Exit(1)
+				}
+			}
+
 			if err := g.AddRelation(from, relType, to); err != nil {
 				ui.Bad.Printf("  %v\n", err)
 				os.Exit(1)
@@ -155,18 +216,28 @@ func graphRelateCmd() *cobra.Command {
 				os.Exit(1)
 			}
 
+			// Aliases are recorded against their entity
+			if e, err := g.GetEntity(from); err == nil {
+				from = e.Name
+			}
+			if e, err := g.GetEntity(to); err == nil {
+				to = e.Name
+			}
+			logGraphChange("relate", fmt.Sprintf("%s --%s--> %s", from, relType, to))
 			ui.Good.Printf("  %s %s --%s--> %s\n", ui.StatusIcon(true), ui.Brand.Sprint(from), relType, ui.Brand.Sprint(to))
 		},
 	}
+
+	cmd.Flags().BoolVar(&strict, "strict", false, "Reject relations not declared with 'palm graph types relation'")
+	return cmd
 }
 
 func graphShowCmd() *cobra.Command {
-	var jsonOutput bool
-
-	cmd := &cobra.Command{
-		Use:   "show <name>",
-		Short: "Show entity details and connections",
-		Args:  cobra.ExactArgs(1),
+	return &cobra.Command{
+		Use:               "show <name>",
+		Short:             "Show entity details and connections",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: graphEntityCompletion(0),
 		Run: func(cmd *cobra.Command, args []string) {
 			name := args[0]
 
@@ -182,8 +253,7 @@ func graphShowCmd() *cobra.Command {
 					ui.Bad.Printf("  %v\n", err)
 					os.Exit(1)
 				}
-				data, _ := json.MarshalIndent(result, "", "  ")
-				fmt.Println(string(data))
+				printJSON(result)
 				return
 			}
 
@@ -202,17 +272,18 @@ func graphShowCmd() *cobra.Command {
 			fmt.Println()
 		},
 	}
-
-	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON (for AI tools)")
-	return cmd
 }
 
 func graphSearchCmd() *cobra.Command {
-	var jsonOutput bool
+	var flags graphQueryFlags
 
 	cmd := &cobra.Command{
 		Use:   "search <query>",
 		Short: "Search entities by name, type, or observation",
+		Long: `Search entities by name, type, or observation, best matches first.
+
+The filter, sort and paging flags work as in 'palm graph list', e.g.
+  palm graph search api --type project --has-relation depends_on --limit 20`,
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			query := args[0]
@@ -223,18 +294,26 @@ func graphSearchCmd() *cobra.Command {
 				os.Exit(1)
 			}
 
-			results := g.Search(query)
+			page, err := g.Query(query, flags.query())
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+			results := page.Results
 
 			if jsonOutput {
-				data, _ := json.MarshalIndent(results, "", "  ")
-				fmt.Println(string(data))
+				printJSON(results)
 				return
 			}
 
-			if len(results) == 0 {
+			if page.Total == 0 {
 				fmt.Printf("  No entities found matching %q\n", query)
 				return
 			}
+			if len(results) == 0 {
+				printPageFooter(page, flags.offset, "results")
+				return
+			}
 
 			ui.Banner("search results")
 			var rows [][]string
@@ -249,21 +328,25 @@ func graphSearchCmd() *cobra.Command {
 				rows = append(rows, []string{r.Entity.Name, r.Entity.Type, obs, fmt.Sprintf("%d", r.Score)})
 			}
 			ui.Table([]string{"Name", "Type", "Observation", "Score"}, rows)
-			fmt.Printf("\n  %d results\n", len(results))
+			printPageFooter(page, flags.offset, "results")
 		},
 	}
 
-	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON (for AI tools)")
+	flags.register(cmd)
 	return cmd
 }
 
 func graphListCmd() *cobra.Command {
-	var filterType string
-	var jsonOutput bool
+	var flags graphQueryFlags
 
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List all entities",
+		Long: `List entities by name, or sorted with --sort updated|created|name|degree.
+
+Filters combine: --type project --has-relation depends_on --updated-since 7d
+lists projects that depend on something, or are depended on, and changed in
+the last week. Page through large graphs with --limit and --offset.`,
 		Aliases: []string{"ls"},
 		Run: func(cmd *cobra.Command, args []string) {
 			g, err := graph.Load()
@@ -272,30 +355,31 @@ func graphListCmd() *cobra.Command {
 				os.Exit(1)
 			}
 
-			// Collect entities, optionally filtered
-			var entities []*graph.Entity
-			for _, name := range g.EntityNames() {
-				e, _ := g.GetEntity(name)
-				if filterType != "" && !strings.EqualFold(e.Type, filterType) {
-					continue
-				}
-				entities = append(entities, e)
+			page, err := g.List(flags.query())
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
 			}
+			entities := page.Entities()
 
 			if jsonOutput {
-				data, _ := json.MarshalIndent(entities, "", "  ")
-				fmt.Println(string(data))
+				printJSON(entities)
 				return
 			}
 
-			if len(entities) == 0 {
-				if filterType != "" {
-					fmt.Printf("  No entities of type %q\n", filterType)
-				} else {
+			if page.Total == 0 {
+				switch {
+				case flags.filtered():
+					fmt.Println("  No entities match the filters")
+				default:
 					fmt.Println("  No entities in graph")
 				}
 				return
 			}
+			if len(entities) == 0 {
+				printPageFooter(page, flags.offset, "entities")
+				return
+			}
 
 			ui.Banner("entities")
 			var rows [][]string
@@ -306,21 +390,21 @@ func graphListCmd() *cobra.Command {
 				rows = append(rows, []string{e.Name, e.Type, obs, rels})
 			}
 			ui.Table([]string{"Name", "Type", "Observations", "Relations"}, rows)
-			fmt.Printf("\n  %d entities\n", len(entities))
+			printPageFooter(page, flags.offset, "entities")
 		},
 	}
 
-	cmd.Flags().StringVar(&filterType, "type", "", "Filter by entity type")
-	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON (for AI tools)")
+	flags.register(cmd)
 	return cmd
 }
 
 func graphRemoveCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:     "remove <name>",
-		Short:   "Remove an entity and its relations",
-		Aliases: []string{"rm", "delete"},
-		Args:    cobra.ExactArgs(1),
+		Use:               "remove <name>",
+		Short:             "Remove an entity and its relations",
+		Aliases:           []string{"rm", "delete"},
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: graphEntityCompletion(0),
 		Run: func(cmd *cobra.Command, args []string) {
 			name := args[0]
 
@@ -335,28 +419,142 @@ func graphRemoveCmd() *cobra.Command {
 				os.Exit(1)
 			}
 
+			backupGraphBefore("remove")
 			if err := graph.Save(g); err != nil {
 				ui.Bad.Printf("  Failed to save graph: %v\n", err)
 				os.Exit(1)
 			}
 
+			logGraphChange("remove", name)
 			ui.Good.Printf("  %s Removed %s and its relations\n", ui.StatusIcon(true), name)
 		},
 	}
 }
 
+func graphRenameCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:               "rename <old> <new>",
+		Short:             "Rename an entity, keeping its observations and relations",
+		Aliases:           []string{"mv"},
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: graphEntityCompletion(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			oldName, newName := args[0], args[1]
+
+			g, err := graph.Load()
+			if err != nil {
+				ui.Bad.Printf("  Failed to load graph: %v\n", err)
+				os.Exit(1)
+			}
+
+			e, err := g.GetEntity(oldName)
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+			oldName = e.Name
+
+			if err := g.RenameEntity(oldName, newName); err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+
+			if err := graph.Save(g); err != nil {
+				ui.Bad.Printf("  Failed to save graph: %v\n", err)
+				os.Exit(1)
+			}
+
+			out, in := g.RelationsOf(newName)
+			logGraphChange("rename", oldName+" -> "+newName)
+			ui.Good.Printf("  %s Renamed %s to %s (%d relations kept)\n",
+				ui.StatusIcon(true), oldName, ui.Brand.Sprint(newName), len(out)+len(in))
+		},
+	}
+}
+
+func graphMergeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "merge <source> <target>",
+		Short: "Merge a duplicate entity into another",
+		Long: `Merge folds a duplicate entity into another: the source's observations
+move to the target, every relation to or from the source is pointed at the
+target, and the source is removed.
+
+  palm graph merge K8s Kubernetes`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: graphEntityCompletion(0, 1),
+		Run: func(cmd *cobra.Command, args []string) {
+			source, target := args[0], args[1]
+
+			g, err := graph.Load()
+			if err != nil {
+				ui.Bad.Printf("  Failed to load graph: %v\n", err)
+				os.Exit(1)
+			}
+
+			if e, err := g.GetEntity(source); err == nil {
+				source = e.Name
+			}
+
+			obs, rels, err := g.MergeEntity(source, target)
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+
+			backupGraphBefore("merge")
+			if err := graph.Save(g); err != nil {
+				ui.Bad.Printf("  Failed to save graph: %v\n", err)
+				os.Exit(1)
+			}
+
+			e, _ := g.GetEntity(target)
+			logGraphChange("merge", fmt.Sprintf("%s -> %s (%d observations, %d relations)", source, e.Name, obs, rels))
+			ui.Good.Printf("  %s Merged %s into %s\n", ui.StatusIcon(true), source, ui.Brand.Sprint(e.Name))
+			fmt.Printf("    %d observations moved, %d relations rewritten\n", obs, rels)
+		},
+	}
+}
+
 func graphExportCmd() *cobra.Command {
 	var format string
+	var redact bool
+	var patterns []string
 
 	cmd := &cobra.Command{
 		Use:   "export",
 		Short: "Export the full graph (decrypted)",
+		Long: `Export the full graph, decrypted, in one of several formats.
+
+--redact makes an export fit to share with teammates or cloud models: it
+leaves out entities tagged private (a "tags: private" observation, as added
+by --field tags=private) with their relations, and observations that look
+like secrets — API keys, tokens, private keys and password assignments.
+--redact-pattern adds regular expressions of your own.
+
+  palm graph export --redact > team-graph.json
+  palm graph export --redact --redact-pattern 'INC-\d+' --format html`,
 		Run: func(cmd *cobra.Command, args []string) {
 			g, err := graph.Load()
 			if err != nil {
 				ui.Bad.Printf("  Failed to load graph: %v\n", err)
 				os.Exit(1)
 			}
+			if redact || len(patterns) > 0 {
+				secrets := append([]*regexp.Regexp{}, graphSecretPatterns...)
+				for _, p := range patterns {
+					re, err := regexp.Compile(p)
+					if err != nil {
+						ui.Bad.Printf("  Invalid --redact-pattern %q: %v\n", p, err)
+						os.Exit(1)
+					}
+					secrets = append(secrets, re)
+				}
+				var report graph.RedactReport
+				g, report = g.Redact(secrets)
+				ui.Subtle.Fprintf(os.Stderr, "  palm: redacted %d private entities, %d relations and %d observations\n",
+					len(report.Private), report.Relations, report.Observations)
+			}
 
 			switch format {
 			case "json":
@@ -370,29 +568,57 @@ func graphExportCmd() *cobra.Command {
 				fmt.Print(g.ExportDOT())
 			case "html":
 				fmt.Print(g.ExportHTML())
+			case "graphml":
+				fmt.Print(g.ExportGraphML())
+			case "csv", "csv-nodes", "csv-edges":
+				export := g.ExportNodesCSV
+				if format == "csv-edges" {
+					export = g.ExportEdgesCSV
+				}
+				data, err := export()
+				if err != nil {
+					ui.Bad.Printf("  Export failed: %v\n", err)
+					os.Exit(1)
+				}
+				fmt.Print(data)
 			default:
-				ui.Bad.Printf("  Unknown format: %s (use json, dot, or html)\n", format)
+				ui.Bad.Printf("  Unknown format: %s (use json, dot, html, graphml, csv, or csv-edges)\n", format)
 				os.Exit(1)
 			}
 		},
 	}
 
-	cmd.Flags().StringVar(&format, "format", "json", "Export format: json, dot, or html")
+	cmd.Flags().StringVar(&format, "format", "json", "Export format: json, dot, html, graphml, csv (nodes), or csv-edges")
+	cmd.Flags().BoolVar(&redact, "redact", false, "Leave out private entities and observations that look like secrets")
+	cmd.Flags().StringArrayVar(&patterns, "redact-pattern", nil, "Also leave out observations matching this regexp (repeatable; implies --redact)")
 	return cmd
 }
 
+// graphSecretPatterns are the secret formats export --redact drops: the
+// API key formats workflows are scrubbed of, plus private keys and
+// credential assignments that turn up in notes.
+var graphSecretPatterns = append(append([]*regexp.Regexp{}, secretPatterns...),
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`),
+	regexp.MustCompile(`(?i)\b(password|passwd|secret|token|api[_-]?key)\s*[:=]\s*\S+`),
+)
+
 func graphImportCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "import <file>",
-		Short: "Import/merge entities from a JSON file",
-		Args:  cobra.ExactArgs(1),
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "import <file|dir>",
+		Short: "Import/merge entities from a JSON file or an Obsidian vault",
+		Long: "Import/merge entities from a JSON export, or from an Obsidian vault directory\n" +
+			"where each note becomes an entity and [[wikilinks]] become relations.",
+		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			filePath := args[0]
+			path := args[0]
 
-			data, err := os.ReadFile(filePath)
-			if err != nil {
-				ui.Bad.Printf("  Failed to read file: %v\n", err)
-				os.Exit(1)
+			if format == "" {
+				format = "json"
+				if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+					format = "obsidian"
+				}
 			}
 
 			g, err := graph.Load()
@@ -401,28 +627,65 @@ func graphImportCmd() *cobra.Command {
 				os.Exit(1)
 			}
 
-			added, merged, relAdded, err := g.ImportJSON(data)
+			added, merged, relAdded, err := importGraph(g, format, path)
 			if err != nil {
 				ui.Bad.Printf("  Import failed: %v\n", err)
 				os.Exit(1)
 			}
 
+			backupGraphBefore("import")
 			if err := graph.Save(g); err != nil {
 				ui.Bad.Printf("  Failed to save graph: %v\n", err)
 				os.Exit(1)
 			}
 
+			logGraphChange("import", fmt.Sprintf("%s: %d added, %d merged, %d relations", path, added, merged, relAdded))
 			ui.Good.Printf("  %s Imported: %d added, %d merged, %d relations\n",
 				ui.StatusIcon(true), added, merged, relAdded)
 		},
 	}
+
+	cmd.Flags().StringVar(&format, "format", "", "Import format: json or obsidian (default: obsidian for directories, else json)")
+	return cmd
+}
+
+// importGraph merges the export or vault at path into g.
+func importGraph(g *graph.Graph, format, path string) (added, merged, relAdded int, err error) {
+	switch format {
+	case "json":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		return g.ImportJSON(data)
+	case "obsidian":
+		return g.ImportObsidian(path)
+	}
+	return 0, 0, 0, fmt.Errorf("unknown format %s (use json or obsidian)", format)
 }
 
 func graphViewCmd() *cobra.Command {
-	return &cobra.Command{
+	var serveAddr, focus string
+	var depth int
+
+	cmd := &cobra.Command{
 		Use:   "view",
 		Short: "Open interactive graph visualization in browser (Obsidian-like)",
+		Long: "Open interactive graph visualization in browser (Obsidian-like).\n\n" +
+			"With --serve, palm hosts the view on a local address instead of writing a\n" +
+			"static file: clicking a node shows its details, and observations or relations\n" +
+			"added in the browser are saved back into graph.enc. The server only answers\n" +
+			"requests from this machine, and a bare port such as :7777 binds to 127.0.0.1.\n\n" +
+			"With --focus, only the entities within --depth relations of one entity are\n" +
+			"drawn, which keeps large graphs readable. In the browser, click legend\n" +
+			"types to hide them, raise the connections slider to drop sparse nodes,\n" +
+			"color by detected cluster, drag nodes to pin them and save a PNG or SVG.",
 		Run: func(cmd *cobra.Command, args []string) {
+			if focus != "" && serveAddr != "" {
+				ui.Bad.Println("  --focus applies to the static view; it can't be combined with --serve")
+				os.Exit(1)
+			}
+
 			g, err := graph.Load()
 			if err != nil {
 				ui.Bad.Printf("  Failed to load graph: %v\n", err)
@@ -430,6 +693,28 @@ func graphViewCmd() *cobra.Command {
 			}
 
 			stats := g.GetStats()
+
+			if serveAddr != "" {
+				// The server hands out the decrypted graph, so a bare port
+				// stays on loopback
+				if strings.HasPrefix(serveAddr, ":") {
+					serveAddr = "127.0.0.1" + serveAddr
+				}
+				url := "http://" + serveAddr
+
+				ui.Banner("graph view")
+				ui.Good.Printf("  %s Serving graph at %s (%d entities, %d relations)\n",
+					ui.StatusIcon(true), ui.Brand.Sprint(url), stats.Entities, stats.Relations)
+				ui.Subtle.Println("  Edits in the browser are saved to ~/.config/palm/graph.enc — Ctrl+C to stop")
+				_ = openBrowser(url)
+
+				if err := http.ListenAndServe(serveAddr, graph.Handler()); err != nil {
+					ui.Bad.Printf("  Server error: %v\n", err)
+					os.Exit(1)
+				}
+				return
+			}
+
 			if stats.Entities == 0 {
 				fmt.Println("  Empty graph — add some entities first")
 				return
@@ -438,23 +723,22 @@ func graphViewCmd() *cobra.Command {
 			// Write HTML to temp file and open in browser
 			tmpDir := os.TempDir()
 			htmlPath := filepath.Join(tmpDir, "palm-graph.html")
-			if err := os.WriteFile(htmlPath, []byte(g.ExportHTML()), 0o644); err != nil {
+			html := g.ExportHTML()
+			if focus != "" {
+				sub, err := g.Neighborhood(focus, depth)
+				if err != nil {
+					ui.Bad.Printf("  %v\n", err)
+					os.Exit(1)
+				}
+				stats = sub.GetStats()
+				html, _ = g.ExportFocusHTML(focus, depth)
+			}
+			if err := os.WriteFile(htmlPath, []byte(html), 0o644); err != nil {
 				ui.Bad.Printf("  Failed to write HTML: %v\n", err)
 				os.Exit(1)
 			}
 
-			var openCmd *exec.Cmd
-			switch runtime.GOOS {
-			case "darwin":
-				openCmd = exec.Command("open", htmlPath)
-			case "linux":
-				openCmd = exec.Command("xdg-open", htmlPath)
-			default:
-				// Windows or other
-				openCmd = exec.Command("cmd", "/c", "start", htmlPath)
-			}
-
-			if err := openCmd.Start(); err != nil {
+			if err := openBrowser(htmlPath); err != nil {
 				// Fallback: just print the path
 				fmt.Printf("  HTML written to: %s\n", htmlPath)
 				fmt.Println("  Open it in your browser to see the graph")
@@ -466,4 +750,30 @@ func graphViewCmd() *cobra.Command {
 			ui.Subtle.Printf("  %s\n", htmlPath)
 		},
 	}
+
+	cmd.Flags().StringVar(&serveAddr, "serve", "", "Serve an editable live view on this address (e.g. :7777, bound to 127.0.0.1)")
+	cmd.Flags().StringVar(&focus, "focus", "", "Only draw the neighborhood of this entity")
+	cmd.Flags().IntVar(&depth, "depth", 2, "Relations to follow from the --focus entity")
+	_ = cmd.RegisterFlagCompletionFunc("focus", graphEntityCompletion(0))
+	return cmd
+}
+
+// openBrowser opens a file path or URL with the platform's default handler.
+func openBrowser(target string) error {
+	var openCmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		openCmd = exec.Command("open", target)
+	case "linux":
+		openCmd = exec.Command("xdg-open", target)
+	default:
+		// Windows or other
+		openCmd = exec.Command("cmd", "/c", "start", target)
+	}
+	return openCmd.Start()
+}
+
+// logGraphChange records a knowledge graph mutation in the activity log.
+func logGraphChange(op, details string) {
+	_ = activity.Append(activity.Entry{Action: "graph." + op, Tool: "graph", Details: details, Status: "ok"})
 }
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/msalah0e/palm/internal/graph"
+)
+
+func TestImportGraphReportsBadJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.json")
+	if err := os.WriteFile(path, []byte(`{"entities": [`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	g := graph.New()
+	if _, _, _, err := importGraph(g, "json", path); err == nil {
+		t.Fatal("importing malformed JSON should fail")
+	}
+	if _, _, _, err := importGraph(g, "json", filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("importing a missing file should fail")
+	}
+	if _, _, _, err := importGraph(g, "csv", path); err == nil {
+		t.Error("an unknown format should fail")
+	}
+
+	os.WriteFile(path, []byte(`{"entities": {"palm": {"name": "palm", "entityType": "project"}}}`), 0o644)
+	if added, _, _, err := importGraph(g, "json", path); err != nil || added != 1 {
+		t.Errorf("valid import: added %d, err %v", added, err)
+	}
+}
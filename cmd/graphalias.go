@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/msalah0e/palm/internal/graph"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+func graphAliasCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "alias",
+		Short: "Give entities alternate names",
+		Long: `Aliases are alternate names of an entity. show, observe, relate, merge and
+imports resolve them to the entity, and search matches them like its name.
+Names also resolve ignoring case, spaces and punctuation when only one
+entity matches, so "node js" finds Node.js.
+
+  palm graph alias                      # List entities with aliases
+  palm graph alias add Kubernetes k8s kube
+  palm graph relate palm deploys-to k8s # Recorded against Kubernetes
+  palm graph alias remove kube`,
+		Run: func(cmd *cobra.Command, args []string) {
+			g, err := graph.Load()
+			if err != nil {
+				ui.Bad.Printf("  Failed to load graph: %v\n", err)
+				os.Exit(1)
+			}
+
+			aliased := g.Aliased()
+			if jsonOutput {
+				out := make(map[string][]string, len(aliased))
+				for _, e := range aliased {
+					out[e.Name] = e.Aliases
+				}
+				printJSON(out)
+				return
+			}
+
+			ui.Banner("graph aliases")
+			if len(aliased) == 0 {
+				fmt.Println("  No aliases yet. Add one:")
+				fmt.Println()
+				ui.Info.Println("  palm graph alias add Kubernetes k8s")
+				return
+			}
+			var rows [][]string
+			for _, e := range aliased {
+				rows = append(rows, []string{e.Name, strings.Join(e.Aliases, ", ")})
+			}
+			ui.Table([]string{"Entity", "Aliases"}, rows)
+		},
+	}
+
+	cmd.AddCommand(graphAliasAddCmd(), graphAliasRemoveCmd())
+	return cmd
+}
+
+func graphAliasAddCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:               "add <entity> <alias...>",
+		Short:             "Add alternate names to an entity",
+		Args:              cobra.MinimumNArgs(2),
+		ValidArgsFunction: graphEntityCompletion(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			g, err := graph.Load()
+			if err != nil {
+				ui.Bad.Printf("  Failed to load graph: %v\n", err)
+				os.Exit(1)
+			}
+
+			var e *graph.Entity
+			for _, alias := range args[1:] {
+				if e, err = g.AddAlias(args[0], alias); err != nil {
+					ui.Bad.Printf("  %v\n", err)
+					os.Exit(1)
+				}
+			}
+
+			if err := graph.Save(g); err != nil {
+				ui.Bad.Printf("  Failed to save graph: %v\n", err)
+				os.Exit(1)
+			}
+
+			logGraphChange("alias.add", e.Name+": "+strings.Join(args[1:], ", "))
+			ui.Good.Printf("  %s %s now also goes by %s\n", ui.StatusIcon(true), ui.Brand.Sprint(e.Name), strings.Join(args[1:], ", "))
+		},
+	}
+}
+
+func graphAliasRemoveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "remove <alias>",
+		Short:   "Remove an alternate name",
+		Aliases: []string{"rm"},
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			g, err := graph.Load()
+			if err != nil {
+				ui.Bad.Printf("  Failed to load graph: %v\n", err)
+				os.Exit(1)
+			}
+
+			e, err := g.RemoveAlias(args[0])
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+
+			if err := graph.Save(g); err != nil {
+				ui.Bad.Printf("  Failed to save graph: %v\n", err)
+				os.Exit(1)
+			}
+
+			logGraphChange("alias.remove", e.Name+": "+args[0])
+			ui.Good.Printf("  %s Removed alias %s of %s\n", ui.StatusIcon(true), args[0], ui.Brand.Sprint(e.Name))
+		},
+	}
+}
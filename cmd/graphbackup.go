@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/msalah0e/palm/internal/config"
+	"github.com/msalah0e/palm/internal/graph"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+func graphBackupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Snapshot the graph (encrypted) for restoring later",
+		Long: `Snapshot the encrypted graph to ~/.config/palm/backups.
+
+palm also takes a snapshot once a day when the graph changes, and before
+every remove, merge, gc and import. Old snapshots are pruned per config:
+
+  [graph]
+  backup_keep = 20       # snapshots to keep
+  backup_max_days = 90   # drop snapshots older than this (0 keeps them)
+
+  palm graph backup
+  palm graph backup list
+  palm graph restore 1   # the newest snapshot`,
+		Run: func(cmd *cobra.Command, args []string) {
+			b, err := graph.CreateBackup("manual")
+			if err != nil {
+				ui.Bad.Printf("  Backup failed: %v\n", err)
+				os.Exit(1)
+			}
+			if b == nil {
+				fmt.Println("  Nothing to back up — the graph is empty")
+				return
+			}
+			logGraphChange("backup", b.Name)
+			ui.Good.Printf("  %s Backed up to %s\n", ui.StatusIcon(true), b.Name)
+		},
+	}
+
+	cmd.AddCommand(graphBackupListCmd(), graphBackupPruneCmd())
+	return cmd
+}
+
+func graphBackupListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Short:   "List graph snapshots, newest first",
+		Aliases: []string{"ls"},
+		Run: func(cmd *cobra.Command, args []string) {
+			backups, err := graph.ListBackups()
+			if err != nil {
+				ui.Bad.Printf("  Failed to list backups: %v\n", err)
+				os.Exit(1)
+			}
+			if jsonOutput {
+				if backups == nil {
+					backups = []graph.Backup{}
+				}
+				printJSON(backups)
+				return
+			}
+
+			ui.Banner("graph backups")
+			if len(backups) == 0 {
+				fmt.Println("  No backups yet. Take one with:")
+				fmt.Println()
+				ui.Info.Println("  palm graph backup")
+				return
+			}
+
+			var rows [][]string
+			for i, b := range backups {
+				rows = append(rows, []string{
+					fmt.Sprint(i + 1),
+					b.Time.Local().Format("2006-01-02 15:04:05"),
+					formatDuration(time.Since(b.Time)) + " ago",
+					b.Reason,
+					formatBytes(int(b.Size)),
+				})
+			}
+			ui.Table([]string{"#", "Taken", "Age", "Reason", "Size"}, rows)
+			fmt.Println()
+			ui.Subtle.Printf("  %s · restore with: palm graph restore <#>\n", graph.BackupDir())
+		},
+	}
+}
+
+func graphBackupPruneCmd() *cobra.Command {
+	var keep, maxDays int
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete snapshots beyond the retention policy",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg := config.Load().Graph
+			if !cmd.Flags().Changed("keep") {
+				keep = cfg.BackupKeep
+			}
+			if !cmd.Flags().Changed("max-days") {
+				maxDays = cfg.BackupMaxDays
+			}
+			removed, err := graph.PruneBackups(keep, time.Duration(maxDays)*24*time.Hour)
+			if err != nil {
+				ui.Bad.Printf("  Prune failed: %v\n", err)
+				os.Exit(1)
+			}
+			ui.Good.Printf("  %s Removed %d snapshot(s)\n", ui.StatusIcon(true), len(removed))
+		},
+	}
+
+	cmd.Flags().IntVar(&keep, "keep", 0, "Snapshots to keep (default: [graph] backup_keep)")
+	cmd.Flags().IntVar(&maxDays, "max-days", 0, "Delete snapshots older than this (default: [graph] backup_max_days)")
+	return cmd
+}
+
+func graphRestoreCmd() *cobra.Command {
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "restore <snapshot>",
+		Short: "Replace the graph with a backup snapshot",
+		Long: `Replace the graph with a snapshot from ` + "`palm graph backup list`" + `, given by
+its number (1 is the newest) or name. The current graph is backed up first,
+so a restore can itself be undone.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			b, err := graph.FindBackup(args[0])
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+			snap, err := graph.LoadBackup(b)
+			if err != nil {
+				ui.Bad.Printf("  Can't read %s: %v\n", b.Name, err)
+				os.Exit(1)
+			}
+
+			stats := snap.GetStats()
+			fmt.Printf("  %s from %s (%s)\n", ui.Brand.Sprint(b.Name), b.Time.Local().Format("2006-01-02 15:04"), b.Reason)
+			fmt.Printf("  %d entities, %d relations, %d observations\n\n", stats.Entities, stats.Relations, stats.Observations)
+			if cur, err := graph.Load(); err == nil {
+				now := cur.GetStats()
+				fmt.Printf("  Current graph: %d entities, %d relations, %d observations\n", now.Entities, now.Relations, now.Observations)
+			}
+			if !yes && !confirmNo("  Replace the current graph with this snapshot?") {
+				fmt.Println("  Cancelled")
+				return
+			}
+
+			if _, err := graph.RestoreBackup(b); err != nil {
+				ui.Bad.Printf("  Restore failed: %v\n", err)
+				os.Exit(1)
+			}
+			logGraphChange("restore", b.Name)
+			ui.Good.Printf("  %s Restored %s — the previous graph was backed up first\n", ui.StatusIcon(true), b.Name)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Don't ask for confirmation")
+	return cmd
+}
+
+// backupGraphBefore snapshots the graph ahead of a destructive operation,
+// and stops the operation when that fails or the graph is read-only.
+func backupGraphBefore(op string) {
+	if graph.ReadOnly() {
+		ui.Bad.Printf("  Can't %s: %v\n", op, graph.ErrReadOnly)
+		os.Exit(1)
+	}
+	if _, err := graph.CreateBackup(op); err != nil {
+		ui.Bad.Printf("  Couldn't back up the graph before %s: %v\n", op, err)
+		os.Exit(1)
+	}
+}
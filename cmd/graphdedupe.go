@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/msalah0e/palm/internal/graph"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+func graphDedupeCmd() *cobra.Command {
+	var dryRun, yes bool
+	var threshold float64
+
+	cmd := &cobra.Command{
+		Use:   "dedupe [entity]",
+		Short: "Find near-duplicate observations and merge them",
+		Long: `Cluster observations that say nearly the same thing, such as repeated agent
+writes that differ in a word or in punctuation, and merge each cluster into
+one observation. Similarity is the better of normalized edit distance and
+word overlap, from 0 to 1; --threshold sets the cut-off (default 0.8).
+
+For each cluster, pick the observation to keep (the longest is suggested),
+skip it, or quit. --yes keeps the suggestion for every cluster. The graph
+is backed up before anything is merged.
+
+  palm graph dedupe                   # Every entity
+  palm graph dedupe Kubernetes --dry-run
+  palm graph dedupe --threshold 0.9 --yes`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: graphEntityCompletion(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			if threshold <= 0 || threshold > 1 {
+				ui.Bad.Println("  --threshold must be above 0 and at most 1")
+				os.Exit(1)
+			}
+
+			g, err := graph.Load()
+			if err != nil {
+				ui.Bad.Printf("  Failed to load graph: %v\n", err)
+				os.Exit(1)
+			}
+
+			name := ""
+			if len(args) == 1 {
+				name = args[0]
+			}
+			clusters, err := g.NearDuplicates(name, threshold)
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+
+			if jsonOutput {
+				if clusters == nil {
+					clusters = []graph.ObservationCluster{}
+				}
+				printJSON(clusters)
+				return
+			}
+
+			ui.Banner("graph dedupe")
+			if len(clusters) == 0 {
+				ui.Good.Printf("  %s No near-duplicate observations\n", ui.StatusIcon(true))
+				return
+			}
+			fmt.Printf("  %d clusters of near-duplicate observations\n\n", len(clusters))
+
+			var chosen []graph.ObservationCluster
+		review:
+			for _, c := range clusters {
+				printObservationCluster(c)
+				switch {
+				case dryRun:
+					fmt.Println()
+					continue
+				case yes:
+					chosen = append(chosen, c)
+					fmt.Println()
+					continue
+				}
+
+				choice := readChoice(fmt.Sprintf("  Keep [1-%d, Enter = %d]  [s]kip  [q]uit > ", len(c.Observations), c.Keep+1))
+				switch choice {
+				case "q":
+					break review
+				case "s":
+					fmt.Println()
+					continue
+				case "":
+				default:
+					n, err := strconv.Atoi(choice)
+					if err != nil || n < 1 || n > len(c.Observations) {
+						ui.Warn.Printf("  Unknown choice %q — skipped\n\n", choice)
+						continue
+					}
+					c.Keep = n - 1
+				}
+				chosen = append(chosen, c)
+				fmt.Println()
+			}
+
+			if dryRun {
+				ui.Subtle.Println("  Dry run — nothing merged")
+				return
+			}
+			if len(chosen) == 0 {
+				fmt.Println("  Nothing merged")
+				return
+			}
+			removed, err := g.MergeClusters(chosen)
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+
+			backupGraphBefore("dedupe")
+			if err := graph.Save(g); err != nil {
+				ui.Bad.Printf("  Failed to save graph: %v\n", err)
+				os.Exit(1)
+			}
+			logGraphChange("dedupe", fmt.Sprintf("merged %d clusters, removed %d observations", len(chosen), removed))
+			ui.Good.Printf("  %s Merged %d clusters, removed %d observations\n", ui.StatusIcon(true), len(chosen), removed)
+		},
+	}
+
+	cmd.Flags().Float64Var(&threshold, "threshold", graph.DefaultSimilarity, "Similarity from 0 to 1 at which observations count as duplicates")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "List the clusters only; merge nothing")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Keep the suggested observation of every cluster without asking")
+	return cmd
+}
+
+func printObservationCluster(c graph.ObservationCluster) {
+	fmt.Printf("  %s\n", ui.Brand.Sprint(c.Entity))
+	for i, o := range c.Observations {
+		marker := " "
+		if i == c.Keep {
+			marker = ui.Good.Sprint("*")
+		}
+		fmt.Printf("   %s %d. %s\n", marker, i+1, o)
+	}
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/msalah0e/palm/internal/graph"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+func graphGCCmd() *cobra.Command {
+	var dryRun, yes bool
+	var days int
+
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Find orphan, stale and duplicate entities and clean them up",
+		Long: `Report entities worth cleaning up:
+
+  orphans      no relations and no observations
+  stale        not updated in --days days (default 90, 0 to skip)
+  duplicates   names that differ only in case or punctuation, e.g. Node.js
+               and nodejs, with the 'palm graph merge' command for each
+
+Orphans and stale entities are removed after confirmation, or right away
+with --yes; the graph is backed up first. Duplicates are only suggested,
+since which name to keep is a judgement call.
+
+  palm graph gc --dry-run
+  palm graph gc --days 30 --yes`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if days < 0 {
+				ui.Bad.Println("  --days must not be negative")
+				os.Exit(1)
+			}
+
+			g, err := graph.Load()
+			if err != nil {
+				ui.Bad.Printf("  Failed to load graph: %v\n", err)
+				os.Exit(1)
+			}
+
+			var cutoff time.Time
+			if days > 0 {
+				cutoff = time.Now().AddDate(0, 0, -days)
+			}
+			report := g.GC(cutoff)
+
+			if jsonOutput {
+				printJSON(report)
+				return
+			}
+
+			ui.Banner("graph gc")
+			if len(report.Removable()) == 0 && len(report.Duplicates) == 0 {
+				ui.Good.Printf("  %s Nothing to clean up in %d entities\n", ui.StatusIcon(true), len(g.Entities))
+				return
+			}
+
+			printGCEntities("Orphans", "no relations or observations", report.Orphans, g)
+			if days > 0 {
+				printGCEntities("Stale", fmt.Sprintf("not updated in %d days", days), report.Stale, g)
+			}
+			if len(report.Duplicates) > 0 {
+				fmt.Printf("  %s %s\n", ui.Brand.Sprint("Duplicates"), ui.Subtle.Sprintf("(%d, merge to tidy up)", len(report.Duplicates)))
+				for _, d := range report.Duplicates {
+					for _, s := range d.Sources {
+						ui.Info.Printf("    palm graph merge %s %s\n", shellQuote(s.Name), shellQuote(d.Target.Name))
+					}
+				}
+				fmt.Println()
+			}
+
+			if dryRun {
+				ui.Subtle.Println("  Dry run — nothing removed")
+				return
+			}
+
+			var remove []*graph.Entity
+			if len(report.Orphans) > 0 && (yes || confirmNo(fmt.Sprintf("  Remove %d orphan entities?", len(report.Orphans)))) {
+				remove = append(remove, report.Orphans...)
+			}
+			if len(report.Stale) > 0 && (yes || confirmNo(fmt.Sprintf("  Remove %d stale entities and their relations?", len(report.Stale)))) {
+				remove = append(remove, report.Stale...)
+			}
+			if len(remove) == 0 {
+				if len(report.Removable()) > 0 {
+					fmt.Println("  Nothing removed")
+				}
+				return
+			}
+
+			names := make([]string, 0, len(remove))
+			for _, e := range remove {
+				if err := g.RemoveEntity(e.Name); err != nil {
+					ui.Bad.Printf("  %v\n", err)
+					os.Exit(1)
+				}
+				names = append(names, e.Name)
+			}
+
+			backupGraphBefore("gc")
+			if err := graph.Save(g); err != nil {
+				ui.Bad.Printf("  Failed to save graph: %v\n", err)
+				os.Exit(1)
+			}
+
+			logGraphChange("gc", fmt.Sprintf("removed %d entities: %s", len(names), strings.Join(names, ", ")))
+			ui.Good.Printf("  %s Removed %d entities\n", ui.StatusIcon(true), len(names))
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Report only; remove nothing")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Remove orphan and stale entities without asking")
+	cmd.Flags().IntVar(&days, "days", 90, "Entities not updated in this many days are stale (0 to skip)")
+	return cmd
+}
+
+// printGCEntities prints one category of the gc report as a table.
+func printGCEntities(title, reason string, entities []*graph.Entity, g *graph.Graph) {
+	if len(entities) == 0 {
+		return
+	}
+	fmt.Printf("  %s %s\n\n", ui.Brand.Sprint(title), ui.Subtle.Sprintf("(%d, %s)", len(entities), reason))
+	var rows [][]string
+	for _, e := range entities {
+		outgoing, incoming := g.RelationsOf(e.Name)
+		rows = append(rows, []string{
+			e.Name,
+			orDash(e.Type),
+			fmt.Sprintf("%d", len(e.Observations)),
+			fmt.Sprintf("%d", len(outgoing)+len(incoming)),
+			e.UpdatedAt.Local().Format("2006-01-02"),
+		})
+	}
+	ui.Table([]string{"Name", "Type", "Observations", "Relations", "Updated"}, rows)
+	fmt.Println()
+}
+
+// shellQuote quotes a name for pasting into a shell command when it holds
+// spaces or shell metacharacters.
+func shellQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n'\"$`\\|&;<>()*?[]#~!{}") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
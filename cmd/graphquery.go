@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/msalah0e/palm/internal/graph"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// graphQueryFlags are the filter, sort and paging flags shared by
+// 'palm graph list' and 'palm graph search'.
+type graphQueryFlags struct {
+	entityType   string
+	hasRelation  string
+	updatedSince string
+	sort         string
+	limit        int
+	offset       int
+}
+
+func (f *graphQueryFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.entityType, "type", "", "Filter by entity type")
+	cmd.Flags().StringVar(&f.hasRelation, "has-relation", "", "Only entities with a relation of this type, in either direction")
+	cmd.Flags().StringVar(&f.updatedSince, "updated-since", "", "Only entities updated within this window (e.g. 24h, 7d, 2w)")
+	cmd.Flags().StringVar(&f.sort, "sort", "", "Sort by "+strings.Join(graph.SortOrders, ", "))
+	cmd.Flags().IntVar(&f.limit, "limit", 0, "Show at most this many entities")
+	cmd.Flags().IntVar(&f.offset, "offset", 0, "Skip this many entities")
+}
+
+// query builds the graph query, exiting on invalid flags.
+func (f *graphQueryFlags) query() graph.Query {
+	q := graph.Query{
+		Type:        f.entityType,
+		HasRelation: f.hasRelation,
+		Sort:        f.sort,
+		Offset:      f.offset,
+		Limit:       f.limit,
+	}
+	if f.updatedSince != "" {
+		t, err := parseSince(f.updatedSince)
+		if err != nil {
+			ui.Bad.Printf("  %v\n", err)
+			os.Exit(1)
+		}
+		q.UpdatedSince = t
+	}
+	return q
+}
+
+// filtered reports whether any flag narrows the results.
+func (f *graphQueryFlags) filtered() bool {
+	return f.entityType != "" || f.hasRelation != "" || f.updatedSince != ""
+}
+
+// printPageFooter prints the result count, and the range shown when the
+// page doesn't hold every match.
+func printPageFooter(page graph.Page, offset int, noun string) {
+	shown := len(page.Results)
+	if shown == page.Total {
+		fmt.Printf("\n  %d %s\n", page.Total, noun)
+		return
+	}
+	if shown == 0 {
+		fmt.Printf("\n  No %s past offset %d (%d total)\n", noun, offset, page.Total)
+		return
+	}
+	fmt.Printf("\n  %d–%d of %d %s", offset+1, offset+shown, page.Total, noun)
+	if next := offset + shown; next < page.Total {
+		fmt.Print(ui.Subtle.Sprintf(" · next page: --offset %d", next))
+	}
+	fmt.Println()
+}
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/msalah0e/palm/internal/graph"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+func graphDiffCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "diff <file|url>",
+		Short: "Compare another graph export with this graph",
+		Long: `Compare a JSON export (from ` + "`palm graph export`" + `) with this graph.
+Added and removed are relative to this graph: "added" entities are in the
+other graph only.
+
+  palm graph diff laptop.json
+  palm graph diff https://example.com/graph.json`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			g, theirs := loadGraphPair(args[0])
+			d := graph.Compare(g, theirs)
+
+			render(d, func() {
+				ui.Banner("graph diff")
+				if d.Empty() {
+					ui.Good.Printf("  %s Identical to %s\n", ui.StatusIcon(true), args[0])
+					return
+				}
+				printGraphDiff(d)
+			})
+		},
+	}
+}
+
+func printGraphDiff(d graph.Diff) {
+	for _, e := range d.Added {
+		ui.Good.Printf("  + %s", e.Name)
+		ui.Subtle.Printf(" (%s, %d observations)\n", e.Type, len(e.Observations))
+	}
+	for _, e := range d.Removed {
+		ui.Bad.Printf("  - %s", e.Name)
+		ui.Subtle.Printf(" (%s, %d observations)\n", e.Type, len(e.Observations))
+	}
+	for _, c := range d.Changed {
+		ui.Warn.Printf("  ~ %s\n", c.Name)
+		if c.TypeFrom != c.TypeTo {
+			fmt.Printf("      type: %s → %s\n", c.TypeFrom, c.TypeTo)
+		}
+		for _, o := range c.ObservationsAdded {
+			ui.Good.Printf("      + %s\n", truncate(o, 80))
+		}
+		for _, o := range c.ObservationsRemoved {
+			ui.Bad.Printf("      - %s\n", truncate(o, 80))
+		}
+	}
+	for _, r := range d.RelationsAdded {
+		ui.Good.Printf("  + %s -[%s]-> %s\n", r.From, r.Type, r.To)
+	}
+	for _, r := range d.RelationsRemoved {
+		ui.Bad.Printf("  - %s -[%s]-> %s\n", r.From, r.Type, r.To)
+	}
+	fmt.Println()
+	ui.Subtle.Printf("  %d added, %d removed, %d changed · relations: %d added, %d removed\n",
+		len(d.Added), len(d.Removed), len(d.Changed), len(d.RelationsAdded), len(d.RelationsRemoved))
+}
+
+func graphPullCmd() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "pull <file|url>",
+		Short: "Merge changes from another graph export",
+		Long: `Merge a JSON export from another machine into this graph.
+
+palm remembers what each source looked like at the last pull, so entities,
+observations and relations deleted there since are deleted here too. When
+both sides changed the same thing differently, the local side is kept and
+the conflict is listed. The first pull from a source only adds.
+
+  palm graph export > ~/Sync/desktop.json     # on the desktop
+  palm graph pull ~/Sync/desktop.json         # on the laptop`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			source := graphSource(args[0])
+			g, theirs := loadGraphPair(args[0])
+			base, err := graph.LoadSyncBase(source)
+			if err != nil {
+				ui.Bad.Printf("  Can't read the last pull from %s: %v\n", args[0], err)
+				os.Exit(1)
+			}
+
+			res := g.Pull(theirs, base)
+			if res.Conflicts == nil {
+				res.Conflicts = []graph.Conflict{}
+			}
+			if !dryRun {
+				backupGraphBefore("pull")
+				if err := graph.Save(g); err != nil {
+					ui.Bad.Printf("  Failed to save graph: %v\n", err)
+					os.Exit(1)
+				}
+				if err := graph.SaveSyncBase(source, theirs); err != nil {
+					ui.Warn.Printf("  %s Couldn't record this pull; the next one will only add: %v\n", ui.WarnIcon(), err)
+				}
+				logGraphChange("pull", fmt.Sprintf("%s: %d added, %d updated, %d removed, %d conflicts",
+					args[0], res.Added, res.Updated, res.Removed, len(res.Conflicts)))
+			}
+
+			render(res, func() {
+				verb := "Pulled"
+				if dryRun {
+					verb = "Would pull"
+				}
+				ui.Good.Printf("  %s %s: %d added, %d updated, %d removed · relations: %d added, %d removed\n",
+					ui.StatusIcon(true), verb, res.Added, res.Updated, res.Removed, res.RelationsAdded, res.RelationsRemoved)
+				if base == nil {
+					ui.Subtle.Println("  First pull from this source — nothing was deleted")
+				}
+				if len(res.Conflicts) == 0 {
+					return
+				}
+				fmt.Println()
+				ui.Warn.Printf("  %s %d conflict(s), local side kept:\n", ui.WarnIcon(), len(res.Conflicts))
+				for _, c := range res.Conflicts {
+					fmt.Printf("    %s: %s\n", ui.Brand.Sprint(c.Entity), c.Reason)
+				}
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would change without saving")
+	return cmd
+}
+
+// loadGraphPair loads this graph and the one at path, exiting on failure.
+func loadGraphPair(path string) (*graph.Graph, *graph.Graph) {
+	g, err := graph.Load()
+	if err != nil {
+		ui.Bad.Printf("  Failed to load graph: %v\n", err)
+		os.Exit(1)
+	}
+	data, err := readGraphSource(path)
+	if err != nil {
+		ui.Bad.Printf("  Failed to read %s: %v\n", path, err)
+		os.Exit(1)
+	}
+	theirs, err := graph.ParseJSON(data)
+	if err != nil {
+		ui.Bad.Printf("  %s is not a graph export: %v\n", path, err)
+		os.Exit(1)
+	}
+	return g, theirs
+}
+
+func isURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
+
+func readGraphSource(path string) ([]byte, error) {
+	if !isURL(path) {
+		return os.ReadFile(path)
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(path)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %d", resp.Request.URL.Host, resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// graphSource identifies a pull source across runs, so relative paths from
+// different directories still match.
+func graphSource(path string) string {
+	if isURL(path) {
+		return path
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/msalah0e/palm/internal/graph"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+func graphTypesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "types",
+		Short: "Declare and list entity and relation types",
+		Long: `Types declares the entity and relation types the graph expects. The
+schema is optional: add and relate only enforce it with --strict. Declared
+types keep a fixed color in 'palm graph view'.
+
+  palm graph types                                     # List declared types
+  palm graph types define person --fields email,company
+  palm graph types define project --color "#0171E3"
+  palm graph types relation works-on --from person --to project
+  palm graph add Alice --type person --field email=alice@example.com --strict
+  palm graph relate Alice works-on palm --strict`,
+		Aliases: []string{"schema"},
+		Run: func(cmd *cobra.Command, args []string) {
+			g, err := graph.Load()
+			if err != nil {
+				ui.Bad.Printf("  Failed to load graph: %v\n", err)
+				os.Exit(1)
+			}
+
+			types, relations := g.DeclaredTypes(), g.DeclaredRelations()
+			if jsonOutput {
+				printJSON(map[string]any{"types": types, "relations": relations})
+				return
+			}
+
+			ui.Banner("graph schema")
+			if len(types) == 0 && len(relations) == 0 {
+				fmt.Println("  No types declared. Add some:")
+				fmt.Println()
+				ui.Info.Println("  palm graph types define person --fields email,company")
+				ui.Info.Println("  palm graph types relation works-on --from person --to project")
+				return
+			}
+
+			counts := make(map[string]int)
+			for _, name := range g.EntityNames() {
+				e, _ := g.GetEntity(name)
+				counts[strings.ToLower(e.Type)]++
+			}
+
+			if len(types) > 0 {
+				var rows [][]string
+				for _, t := range types {
+					rows = append(rows, []string{swatch(t.Color) + " " + t.Name, orDash(strings.Join(t.Fields, ", ")), fmt.Sprint(counts[strings.ToLower(t.Name)])})
+				}
+				ui.Table([]string{"Type", "Fields", "Entities"}, rows)
+			}
+
+			if len(relations) > 0 {
+				if len(types) > 0 {
+					fmt.Println()
+				}
+				var rows [][]string
+				for _, r := range relations {
+					rows = append(rows, []string{r.Name, orAny(r.From), orAny(r.To)})
+				}
+				ui.Table([]string{"Relation", "From", "To"}, rows)
+			}
+		},
+	}
+
+	cmd.AddCommand(graphTypesDefineCmd(), graphTypesRelationCmd(), graphTypesRemoveCmd())
+	return cmd
+}
+
+func graphTypesDefineCmd() *cobra.Command {
+	var fields []string
+	var hex string
+
+	cmd := &cobra.Command{
+		Use:   "define <type>",
+		Short: "Declare an entity type and its fields",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			g, err := graph.Load()
+			if err != nil {
+				ui.Bad.Printf("  Failed to load graph: %v\n", err)
+				os.Exit(1)
+			}
+
+			def, err := g.DefineType(args[0], fields, hex)
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+
+			if err := graph.Save(g); err != nil {
+				ui.Bad.Printf("  Failed to save graph: %v\n", err)
+				os.Exit(1)
+			}
+
+			logGraphChange("types.define", def.Name)
+			ui.Good.Printf("  %s Declared type %s %s\n", ui.StatusIcon(true), ui.Brand.Sprint(def.Name), swatch(def.Color))
+			if len(def.Fields) > 0 {
+				fmt.Printf("    fields: %s\n", strings.Join(def.Fields, ", "))
+			}
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&fields, "fields", nil, "Allowed field names, comma-separated")
+	cmd.Flags().StringVar(&hex, "color", "", "Fixed color in the HTML view, e.g. #2DB682")
+	return cmd
+}
+
+func graphTypesRelationCmd() *cobra.Command {
+	var from, to []string
+
+	cmd := &cobra.Command{
+		Use:   "relation <name>",
+		Short: "Declare a relation type and the entity types it connects",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			g, err := graph.Load()
+			if err != nil {
+				ui.Bad.Printf("  Failed to load graph: %v\n", err)
+				os.Exit(1)
+			}
+
+			def, err := g.DefineRelation(args[0], from, to)
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+
+			if err := graph.Save(g); err != nil {
+				ui.Bad.Printf("  Failed to save graph: %v\n", err)
+				os.Exit(1)
+			}
+
+			logGraphChange("types.relation", def.Name)
+			ui.Good.Printf("  %s Declared %s --%s--> %s\n", ui.StatusIcon(true), orAny(def.From), ui.Brand.Sprint(def.Name), orAny(def.To))
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&from, "from", nil, "Allowed source types, comma-separated (default any)")
+	cmd.Flags().StringSliceVar(&to, "to", nil, "Allowed target types, comma-separated (default any)")
+	return cmd
+}
+
+func graphTypesRemoveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "remove <type-or-relation>",
+		Short:   "Remove a type or relation declaration",
+		Aliases: []string{"rm"},
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			g, err := graph.Load()
+			if err != nil {
+				ui.Bad.Printf("  Failed to load graph: %v\n", err)
+				os.Exit(1)
+			}
+
+			if err := g.Undefine(args[0]); err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+
+			if err := graph.Save(g); err != nil {
+				ui.Bad.Printf("  Failed to save graph: %v\n", err)
+				os.Exit(1)
+			}
+
+			logGraphChange("types.remove", args[0])
+			ui.Good.Printf("  %s Removed declaration %s (entities and relations are unchanged)\n", ui.StatusIcon(true), args[0])
+		},
+	}
+}
+
+// swatch renders a block in a #rrggbb color on truecolor terminals.
+func swatch(hex string) string {
+	var r, g, b int
+	if _, err := fmt.Sscanf(strings.TrimPrefix(hex, "#"), "%02x%02x%02x", &r, &g, &b); err != nil || color.NoColor {
+		return ui.Subtle.Sprint(hex)
+	}
+	return fmt.Sprintf("\033[38;2;%d;%d;%dm●\033[0m", r, g, b)
+}
+
+func orAny(types []string) string {
+	if len(types) == 0 {
+		return "any"
+	}
+	return strings.Join(types, "|")
+}
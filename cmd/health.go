@@ -19,50 +19,66 @@ func healthCmd() *cobra.Command {
 		Aliases: []string{"status"},
 		Short:   "System health overview — tools, runtimes, configs, and disk usage",
 		Run: func(cmd *cobra.Command, args []string) {
-			ui.Banner("system health")
-
-			// OS info
-			fmt.Printf("  %s  %s/%s\n", ui.Brand.Sprintf("%-12s", "Platform"), runtime.GOOS, runtime.GOARCH)
-			fmt.Printf("  %s  %s\n", ui.Brand.Sprintf("%-12s", "Go"), runtime.Version())
-
-			// palm config dir
 			configDir := palmConfigDir()
-			configSize := dirSize(configDir)
-			fmt.Printf("  %s  %s (%.1f KB)\n", ui.Brand.Sprintf("%-12s", "Config"), configDir, float64(configSize)/1024)
-
-			// Installed tools
 			reg := loadRegistry()
 			detected := registry.DetectInstalled(reg)
-			fmt.Printf("  %s  %d installed, %d in registry\n", ui.Brand.Sprintf("%-12s", "Tools"), len(detected), len(reg.All()))
 
-			// Data files
-			fmt.Println()
-			fmt.Println("  Data files:")
-			dataFiles := map[string]string{
-				"vault.enc":       "API key vault",
-				"graph.enc":       "Knowledge graph",
-				"sessions.jsonl":  "Session history",
-				"activity.jsonl":  "Activity log",
-				"budget.json":     "Budget config",
-				"state.json":      "State tracking",
+			report := healthReport{
+				Platform:   runtime.GOOS + "/" + runtime.GOARCH,
+				Go:         runtime.Version(),
+				ConfigDir:  configDir,
+				ConfigSize: dirSize(configDir),
+				Installed:  len(detected),
+				Registry:   len(reg.All()),
+				DataFiles:  []healthFile{},
 			}
-			for f, desc := range dataFiles {
-				path := filepath.Join(configDir, f)
-				if info, err := os.Stat(path); err == nil {
-					fmt.Printf("    %s %-20s %s (%.1f KB)\n", ui.StatusIcon(true), f, ui.Subtle.Sprint(desc), float64(info.Size())/1024)
+			for _, f := range []healthFile{
+				{Name: "vault.enc", Description: "API key vault"},
+				{Name: "graph.enc", Description: "Knowledge graph"},
+				{Name: "sessions.jsonl", Description: "Session history"},
+				{Name: "activity.jsonl", Description: "Activity log"},
+				{Name: "top-history.jsonl", Description: "Recorded top samples"},
+				{Name: "budget.json", Description: "Budget config"},
+				{Name: "state.json", Description: "State tracking"},
+			} {
+				if info, err := os.Stat(filepath.Join(configDir, f.Name)); err == nil {
+					f.Size = info.Size()
+					report.DataFiles = append(report.DataFiles, f)
 				}
 			}
-
-			// Prompts
-			promptDir := filepath.Join(configDir, "prompts")
-			if entries, err := os.ReadDir(promptDir); err == nil && len(entries) > 0 {
-				fmt.Printf("    %s %-20s %d prompts\n", ui.StatusIcon(true), "prompts/", len(entries))
+			if entries, err := os.ReadDir(filepath.Join(configDir, "prompts")); err == nil {
+				report.Prompts = len(entries)
+			}
+			if entries, err := os.ReadDir(filepath.Join(configDir, "runs")); err == nil {
+				report.Runs = len(entries)
 			}
+
+			render(report, func() {
+				ui.Banner("system health")
+
+				fmt.Printf("  %s  %s\n", ui.Brand.Sprintf("%-12s", "Platform"), report.Platform)
+				fmt.Printf("  %s  %s\n", ui.Brand.Sprintf("%-12s", "Go"), report.Go)
+				fmt.Printf("  %s  %s (%.1f KB)\n", ui.Brand.Sprintf("%-12s", "Config"), report.ConfigDir, float64(report.ConfigSize)/1024)
+				fmt.Printf("  %s  %d installed, %d in registry\n", ui.Brand.Sprintf("%-12s", "Tools"), report.Installed, report.Registry)
+
+				fmt.Println()
+				fmt.Println("  Data files:")
+				for _, f := range report.DataFiles {
+					fmt.Printf("    %s %-20s %s (%.1f KB)\n", ui.StatusIcon(true), f.Name, ui.Subtle.Sprint(f.Description), float64(f.Size)/1024)
+				}
+				if report.Prompts > 0 {
+					fmt.Printf("    %s %-20s %d prompts\n", ui.StatusIcon(true), "prompts/", report.Prompts)
+				}
+				if report.Runs > 0 {
+					fmt.Printf("    %s %-20s %d run manifests\n", ui.StatusIcon(true), "runs/", report.Runs)
+				}
+			})
 		},
 	}
 
 	cmd.AddCommand(
 		healthCheckCmd(),
+		healthWatchCmd(),
 	)
 
 	return cmd
@@ -73,8 +89,6 @@ func healthCheckCmd() *cobra.Command {
 		Use:   "check",
 		Short: "Run comprehensive health checks",
 		Run: func(cmd *cobra.Command, args []string) {
-			ui.Banner("health check")
-
 			checks := []struct {
 				name  string
 				check func() (bool, string)
@@ -117,20 +131,51 @@ func healthCheckCmd() *cobra.Command {
 				}},
 			}
 
+			type result struct {
+				Name   string `json:"name"`
+				OK     bool   `json:"ok"`
+				Detail string `json:"detail"`
+			}
+			var results []result
 			passed := 0
 			for _, c := range checks {
 				ok, detail := c.check()
 				if ok {
 					passed++
 				}
-				fmt.Printf("  %s %-25s %s\n", ui.StatusIcon(ok), c.name, ui.Subtle.Sprint(detail))
+				results = append(results, result{c.name, ok, detail})
 			}
 
-			fmt.Printf("\n  %d/%d checks passed\n", passed, len(checks))
+			render(map[string]any{"checks": results, "passed": passed, "total": len(checks)}, func() {
+				ui.Banner("health check")
+				for _, r := range results {
+					fmt.Printf("  %s %-25s %s\n", ui.StatusIcon(r.OK), r.Name, ui.Subtle.Sprint(r.Detail))
+				}
+				fmt.Printf("\n  %d/%d checks passed\n", passed, len(checks))
+			})
 		},
 	}
 }
 
+// healthReport is the system overview printed by palm health.
+type healthReport struct {
+	Platform   string       `json:"platform"`
+	Go         string       `json:"go"`
+	ConfigDir  string       `json:"config_dir"`
+	ConfigSize int64        `json:"config_bytes"`
+	Installed  int          `json:"tools_installed"`
+	Registry   int          `json:"tools_in_registry"`
+	DataFiles  []healthFile `json:"data_files"`
+	Prompts    int          `json:"prompts"`
+	Runs       int          `json:"runs"`
+}
+
+type healthFile struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Size        int64  `json:"bytes"`
+}
+
 func dirSize(path string) int64 {
 	var size int64
 	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
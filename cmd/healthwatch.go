@@ -0,0 +1,432 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/msalah0e/palm/internal/health"
+	"github.com/msalah0e/palm/internal/proxy"
+	"github.com/msalah0e/palm/internal/registry"
+	"github.com/msalah0e/palm/internal/serve"
+	"github.com/msalah0e/palm/internal/state"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/msalah0e/palm/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+// watchOptions configures a round of watchdog checks and its alerts.
+type watchOptions struct {
+	interval  time.Duration
+	notify    bool
+	webhook   string
+	minFreeGB float64
+	proxyPort int
+	skipKeys  bool
+}
+
+func healthWatchCmd() *cobra.Command {
+	var (
+		opts   watchOptions
+		daemon bool
+		once   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Watch tools, runtimes, the proxy, keys and disk space in the background",
+		Long: `Check every interval that what palm manages still works, and alert when
+something breaks instead of finding out mid-session:
+
+  tools     installed tools still run (palm install state)
+  runtimes  model servers started by palm serve, and ollama, answer
+  proxy     a palm proxy that was running still answers
+  keys      API keys in the environment or vault are still accepted
+  disk      model directories have at least --min-free GB free
+
+An alert fires when a check starts failing and again when it recovers,
+as a desktop notification and/or a JSON POST to --webhook. Key checks
+that can't reach the provider don't raise or clear alerts.
+
+With --daemon the watchdog runs detached and logs to
+~/.config/palm/health-watch.log.
+
+Examples:
+  palm health watch --once                      # Run the checks once
+  palm health watch --daemon                    # Watch every 5 minutes
+  palm health watch -d --interval 1m --webhook https://hooks.slack.com/...
+  palm health watch status                      # Latest results`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if opts.interval < 10*time.Second {
+				ui.Bad.Println("  --interval must be at least 10s")
+				os.Exit(1)
+			}
+
+			if once {
+				checks := runHealthChecks(opts)
+				_ = health.SaveReport(health.Report{At: time.Now(), Checks: checks})
+				failed := 0
+				for _, c := range checks {
+					if c.Failed() {
+						failed++
+					}
+				}
+				render(checks, func() {
+					ui.Banner("health watch")
+					printHealthChecks(checks)
+				})
+				if failed > 0 {
+					os.Exit(1)
+				}
+				return
+			}
+
+			// A --daemon child finds the PID file its parent wrote for it
+			if running, pid := health.IsRunning(); running && pid != os.Getpid() {
+				fmt.Printf("  Health watchdog already running (PID %d)\n", pid)
+				return
+			}
+
+			if daemon {
+				logFile, err := os.OpenFile(health.LogPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+				if err != nil {
+					ui.Bad.Printf("  Failed to open log: %v\n", err)
+					os.Exit(1)
+				}
+				defer logFile.Close()
+
+				exe, _ := os.Executable()
+				child := exec.Command(exe, watchArgs(opts)...)
+				child.Stdout = logFile
+				child.Stderr = logFile
+				setDetached(child)
+
+				if err := child.Start(); err != nil {
+					ui.Bad.Printf("  Failed to start health watchdog: %v\n", err)
+					os.Exit(1)
+				}
+				_ = os.WriteFile(health.PidFile(), []byte(strconv.Itoa(child.Process.Pid)), 0o644)
+
+				ui.Good.Printf("  %s Health watchdog started (PID %d), checking every %s\n", ui.StatusIcon(true), child.Process.Pid, opts.interval)
+				fmt.Printf("  Log: %s\n", health.LogPath())
+				return
+			}
+
+			_ = os.MkdirAll(filepath.Dir(health.PidFile()), 0o755)
+			_ = os.WriteFile(health.PidFile(), []byte(strconv.Itoa(os.Getpid())), 0o644)
+			defer os.Remove(health.PidFile())
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			runHealthWatch(ctx, opts)
+		},
+	}
+
+	cmd.Flags().DurationVar(&opts.interval, "interval", 5*time.Minute, "Time between rounds of checks")
+	cmd.Flags().BoolVar(&opts.notify, "notify", true, "Show a desktop notification when a check fails or recovers")
+	cmd.Flags().StringVar(&opts.webhook, "webhook", "", "POST a JSON alert to this URL when a check fails or recovers")
+	cmd.Flags().Float64Var(&opts.minFreeGB, "min-free", 10, "Alert when a model directory's disk has less than this many GB free")
+	cmd.Flags().IntVar(&opts.proxyPort, "proxy-port", 4778, "Port of the palm proxy")
+	cmd.Flags().BoolVar(&opts.skipKeys, "no-keys", false, "Skip API key checks, which call each provider")
+	cmd.Flags().BoolVarP(&daemon, "daemon", "d", false, "Run detached in the background")
+	cmd.Flags().BoolVar(&once, "once", false, "Run the checks once, print them and exit (non-zero if any fail)")
+
+	cmd.AddCommand(
+		healthWatchStopCmd(),
+		healthWatchStatusCmd(),
+	)
+	return cmd
+}
+
+// watchArgs rebuilds the command line for a detached watchdog.
+func watchArgs(opts watchOptions) []string {
+	args := []string{"health", "watch",
+		"--interval", opts.interval.String(),
+		"--notify=" + strconv.FormatBool(opts.notify),
+		"--min-free", strconv.FormatFloat(opts.minFreeGB, 'f', -1, 64),
+		"--proxy-port", strconv.Itoa(opts.proxyPort),
+	}
+	if opts.webhook != "" {
+		args = append(args, "--webhook", opts.webhook)
+	}
+	if opts.skipKeys {
+		args = append(args, "--no-keys")
+	}
+	return args
+}
+
+// runHealthWatch runs the checks every interval until ctx is done, logging
+// each round and alerting on changes.
+func runHealthWatch(ctx context.Context, opts watchOptions) {
+	logf := func(format string, args ...any) {
+		fmt.Printf("  %s %s\n", time.Now().Format("2006-01-02 15:04:05"), fmt.Sprintf(format, args...))
+	}
+	logf("health watchdog started (PID %d), checking every %s", os.Getpid(), opts.interval)
+
+	tracker := health.NewTracker()
+	for {
+		checks := runHealthChecks(opts)
+		if err := health.SaveReport(health.Report{At: time.Now(), Checks: checks}); err != nil {
+			logf("can't save report: %v", err)
+		}
+		failed := 0
+		for _, c := range checks {
+			if c.Failed() {
+				failed++
+			}
+		}
+		logf("%d checks, %d failing", len(checks), failed)
+
+		if changes := tracker.Update(checks); len(changes) > 0 {
+			for _, c := range changes {
+				if c.Recovered {
+					logf("recovered: %s", c.Name)
+				} else {
+					logf("broken: %s — %s", c.Name, c.Detail)
+				}
+			}
+			if err := health.Notify(changes, opts.notify, opts.webhook); err != nil {
+				logf("alert: %v", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			logf("health watchdog stopped")
+			return
+		case <-time.After(opts.interval):
+		}
+	}
+}
+
+// runHealthChecks runs one round of every watchdog check.
+func runHealthChecks(opts watchOptions) []health.Check {
+	var checks []health.Check
+	checks = append(checks, checkInstalledTools(loadRegistry())...)
+	checks = append(checks, checkRuntimes()...)
+	if c, ok := checkProxy(opts.proxyPort); ok {
+		checks = append(checks, c)
+	}
+	if !opts.skipKeys {
+		checks = append(checks, checkKeys(vault.New())...)
+	}
+	checks = append(checks, checkModelDisks(opts.minFreeGB)...)
+	return checks
+}
+
+// checkInstalledTools confirms every tool palm installed still runs.
+func checkInstalledTools(reg *registry.Registry) []health.Check {
+	installed := state.ListInstalled()
+	names := make([]string, 0, len(installed))
+	for name := range installed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Check the tools as they are now, not as last cached
+	_ = registry.ForgetDetection(names...)
+	detected := detectTools(reg, names)
+
+	var checks []health.Check
+	for _, name := range names {
+		dt, ok := detected[name]
+		if !ok {
+			continue
+		}
+		c := health.Check{Name: "tool:" + name, Kind: "tool", Status: health.StatusOK}
+		if dt.Installed {
+			c.Detail = dt.Version
+		} else {
+			c.Status, c.Detail = health.StatusFail, "no longer runs — palm install "+name
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+// checkRuntimes asks every server palm serve started, and ollama when it is
+// installed, for its models.
+func checkRuntimes() []health.Check {
+	roots := make(map[string]string) // check name → root URL
+	insts, _ := serve.Instances()
+	for _, inst := range insts {
+		roots["runtime:"+inst.Runtime] = inst.Root()
+	}
+	if _, ok := roots["runtime:ollama"]; !ok {
+		if _, err := exec.LookPath("ollama"); err == nil {
+			roots["runtime:ollama"] = ollamaRoot()
+		}
+	}
+	names := make([]string, 0, len(roots))
+	for name := range roots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var checks []health.Check
+	for _, name := range names {
+		c := health.Check{Name: name, Kind: "runtime", Status: health.StatusOK}
+		if ids, err := serve.Health(roots[name]); err != nil {
+			c.Status, c.Detail = health.StatusFail, roots[name]+" unreachable"
+		} else {
+			c.Detail = fmt.Sprintf("%s, %d models", roots[name], len(ids))
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+// ollamaRoot returns the ollama server URL, honouring OLLAMA_HOST.
+func ollamaRoot() string {
+	host := os.Getenv("OLLAMA_HOST")
+	if host == "" {
+		return "http://localhost:11434"
+	}
+	if !strings.Contains(host, "://") {
+		host = "http://" + host
+	}
+	return strings.TrimRight(host, "/")
+}
+
+// checkProxy reports on the palm proxy when its PID file says it runs. A
+// proxy that isn't running isn't a failure; one that stopped answering is.
+func checkProxy(port int) (health.Check, bool) {
+	running, pid := proxy.IsRunning()
+	if !running {
+		return health.Check{}, false
+	}
+	c := health.Check{Name: "proxy", Kind: "proxy", Status: health.StatusOK, Detail: fmt.Sprintf("PID %d, port %d", pid, port)}
+	base := fmt.Sprintf("://localhost:%d", port)
+	if !proxy.IsProxyURL("http"+base) && !proxy.IsProxyURL("https"+base) {
+		c.Status, c.Detail = health.StatusFail, fmt.Sprintf("PID %d not answering on port %d", pid, port)
+	}
+	return c, true
+}
+
+// checkKeys confirms each provider with a key configured still accepts it.
+func checkKeys(v vault.Vault) []health.Check {
+	var checks []health.Check
+	for _, provider := range proxy.KeyedProviders() {
+		if !proxy.KeyAvailable(v, provider) {
+			continue
+		}
+		c := health.Check{Name: "key:" + provider, Kind: "key", Status: health.StatusOK}
+		if err := proxy.CheckKey(v, provider); errors.Is(err, proxy.ErrKeyRejected) {
+			c.Status, c.Detail = health.StatusFail, err.Error()
+		} else if err != nil {
+			c.Status, c.Detail = health.StatusUnknown, err.Error()
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+// checkModelDisks checks free space where local runtimes keep models.
+func checkModelDisks(minFreeGB float64) []health.Check {
+	var checks []health.Check
+	for _, dir := range []string{serve.OllamaModelsDir(), serve.LlamaCacheDir(), serve.HFHubDir()} {
+		if _, err := os.Stat(dir); err != nil {
+			continue
+		}
+		c := health.Check{Name: "disk:" + dir, Kind: "disk", Status: health.StatusOK}
+		free, err := health.FreeBytes(dir)
+		switch {
+		case err != nil:
+			c.Status, c.Detail = health.StatusUnknown, err.Error()
+		case float64(free) < minFreeGB*(1<<30):
+			c.Status, c.Detail = health.StatusFail, fmt.Sprintf("%.1f GB free, below %.0f GB", float64(free)/(1<<30), minFreeGB)
+		default:
+			c.Detail = fmt.Sprintf("%.1f GB free", float64(free)/(1<<30))
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+func printHealthChecks(checks []health.Check) {
+	if len(checks) == 0 {
+		fmt.Println("  Nothing to check — no tools installed, runtimes served or keys configured")
+		return
+	}
+	failed := 0
+	for _, c := range checks {
+		icon := ui.StatusIcon(!c.Failed())
+		if c.Status == health.StatusUnknown {
+			icon = ui.WarnIcon()
+		}
+		if c.Failed() {
+			failed++
+		}
+		fmt.Printf("  %s %-30s %s\n", icon, c.Name, ui.Subtle.Sprint(c.Detail))
+	}
+	fmt.Printf("\n  %d/%d checks passing\n", len(checks)-failed, len(checks))
+}
+
+func healthWatchStopCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "stop",
+		Short: "Stop the health watchdog",
+		Run: func(cmd *cobra.Command, args []string) {
+			running, pid := health.IsRunning()
+			if !running {
+				fmt.Println("  Health watchdog is not running")
+				return
+			}
+			proc, err := os.FindProcess(pid)
+			if err != nil {
+				ui.Bad.Printf("  Failed to find process %d: %v\n", pid, err)
+				os.Exit(1)
+			}
+			if err := stopProcess(proc); err != nil {
+				ui.Bad.Printf("  Failed to stop health watchdog: %v\n", err)
+				os.Exit(1)
+			}
+			ui.Good.Printf("  %s Health watchdog stopped (PID %d)\n", ui.StatusIcon(true), pid)
+		},
+	}
+}
+
+func healthWatchStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show whether the watchdog runs and its latest results",
+		Run: func(cmd *cobra.Command, args []string) {
+			running, pid := health.IsRunning()
+			report, err := health.LoadReport()
+
+			status := struct {
+				Running bool           `json:"running"`
+				PID     int            `json:"pid,omitempty"`
+				Log     string         `json:"log"`
+				Last    *health.Report `json:"last,omitempty"`
+			}{Running: running, PID: pid, Log: health.LogPath()}
+			if err == nil {
+				status.Last = &report
+			}
+
+			render(status, func() {
+				if running {
+					ui.Good.Printf("  %s Health watchdog running (PID %d)\n", ui.StatusIcon(true), pid)
+				} else {
+					fmt.Println("  Health watchdog is not running")
+					fmt.Println("  Start: palm health watch --daemon")
+				}
+				fmt.Printf("  Log: %s\n", health.LogPath())
+				if status.Last != nil {
+					fmt.Printf("\n  Last checked %s:\n", report.At.Format("Mon Jan 02 15:04"))
+					printHealthChecks(report.Checks)
+				}
+			})
+		},
+	}
+}
@@ -26,17 +26,32 @@ func infoCmd() *cobra.Command {
 				os.Exit(1)
 			}
 
-			ui.Banner("tool info")
+			dt := registry.DetectOne(*tool)
 
-			detected := registry.Detect(reg)
-			var dt *registry.DetectedTool
-			for i := range detected {
-				if detected[i].Tool.Name == name {
-					dt = &detected[i]
-					break
+			if jsonOutput {
+				backend, pkg := tool.InstallMethod()
+				out := map[string]any{
+					"name":          tool.Name,
+					"display_name":  tool.DisplayName,
+					"category":      tool.Category,
+					"description":   tool.Description,
+					"homepage":      tool.Homepage,
+					"repo":          tool.Repo,
+					"tags":          tool.Tags,
+					"install":       map[string]string{"backend": backend, "package": pkg},
+					"required_keys": tool.Keys.Required,
+					"optional_keys": tool.Keys.Optional,
+					"installed":     dt.Installed,
+				}
+				if dt.Installed {
+					out["version"], out["path"] = dt.Version, dt.Path
 				}
+				printJSON(out)
+				return
 			}
 
+			ui.Banner("tool info")
+
 			fmt.Printf("  %s %s\n", ui.Brand.Sprint(tool.DisplayName), ui.Subtle.Sprintf("(%s)", tool.Category))
 			fmt.Printf("  %s\n\n", tool.Description)
 
@@ -54,7 +69,7 @@ func infoCmd() *cobra.Command {
 			fmt.Printf("  Install:   %s (%s)\n", pkg, backend)
 
 			fmt.Println()
-			if dt != nil && dt.Installed {
+			if dt.Installed {
 				ver := dt.Version
 				if ver == "" {
 					ver = "unknown"
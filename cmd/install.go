@@ -1,9 +1,14 @@
 package cmd
 
 import (
+	"bufio"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/msalah0e/palm/internal/activity"
 	"github.com/msalah0e/palm/internal/config"
 	"github.com/msalah0e/palm/internal/hooks"
 	"github.com/msalah0e/palm/internal/installer"
@@ -15,32 +20,72 @@ import (
 )
 
 func installCmd() *cobra.Command {
-	var sequential bool
+	var sequential, dryRun, assumeYes, team bool
+	var stacks []string
 
 	cmd := &cobra.Command{
-		Use:               "install <tool> [tool2...]",
-		Aliases:           []string{"i", "add"},
-		Short:             "Install AI tool(s)",
-		Args:              cobra.MinimumNArgs(1),
+		Use:     "install <tool> [tool2...]",
+		Aliases: []string{"i", "add"},
+		Short:   "Install AI tool(s)",
+		Long: `Install one or more AI tools from the registry.
+
+--stack installs a curated stack (see palm stacks): its tools that aren't
+installed yet, and the MCP servers it uses.
+
+  palm install --stack local-first`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if team || len(stacks) > 0 {
+				return nil
+			}
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
 		ValidArgsFunction: toolCompletionFunc,
 		Run: func(cmd *cobra.Command, args []string) {
 			reg := loadRegistry()
 
+			if team {
+				var err error
+				if args, err = teamMissingTools(reg, args); err != nil {
+					ui.Bad.Printf("  %v\n", err)
+					os.Exit(1)
+				}
+				if len(args) == 0 {
+					ui.Good.Printf("  %s All team tools are installed\n", ui.StatusIcon(true))
+					return
+				}
+			}
+
+			var servers []string
+			if len(stacks) > 0 {
+				args, servers = stackInstallList(reg, args, stacks)
+				defer installStackServers(servers, dryRun)
+				if len(args) == 0 {
+					ui.Good.Printf("  %s All stack tools are installed\n", ui.StatusIcon(true))
+					return
+				}
+			}
+
+			if dryRun {
+				printPlans(installer.ActionInstall, planTools(reg, args, installer.ActionInstall))
+				return
+			}
+
 			if len(args) == 1 {
-				installOne(reg, args[0])
+				installOne(reg, args[0], assumeYes)
 				return
 			}
 
 			// Multiple tools — use parallel by default
 			cfg := config.Load()
 			if !sequential && cfg.Parallel.Enabled && len(args) > 1 {
-				installParallel(reg, args, cfg.Parallel.Concurrency)
+				installParallel(reg, args, cfg.Parallel.Concurrency, assumeYes)
 				return
 			}
 
 			// Sequential fallback
 			ui.Banner("installing")
 			success, failed := 0, 0
+			var tools []*registry.Tool
 			for _, name := range args {
 				tool := reg.Get(name)
 				if tool == nil {
@@ -48,11 +93,26 @@ func installCmd() *cobra.Command {
 					failed++
 					continue
 				}
+				tools = append(tools, tool)
+			}
+			blocked, done := ensurePrerequisites(reg, tools, assumeYes)
+			var installed []*registry.Tool
+			for _, tool := range tools {
+				if done[tool.Name] {
+					success++ // installed as a dependency of another tool
+					continue
+				}
+				if reason, ok := blocked[tool.Name]; ok {
+					ui.Bad.Printf("  %s %s: %s\n", ui.StatusIcon(false), tool.DisplayName, reason)
+					failed++
+					continue
+				}
 				if err := doInstall(tool); err != nil {
 					ui.Bad.Printf("  %s %s: %v\n", ui.StatusIcon(false), tool.DisplayName, err)
 					failed++
 				} else {
 					ui.Good.Printf("  %s %s installed\n", ui.StatusIcon(true), tool.DisplayName)
+					installed = append(installed, tool)
 					success++
 				}
 			}
@@ -61,14 +121,42 @@ func installCmd() *cobra.Command {
 				fmt.Printf(" · %d failed", failed)
 			}
 			fmt.Println()
+			for _, tool := range installed {
+				runSetupSteps(tool, assumeYes)
+			}
 		},
 	}
 
 	cmd.Flags().BoolVar(&sequential, "seq", false, "Install sequentially (disable parallel)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show the commands that would run without executing them")
+	cmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Install missing prerequisites and run setup steps without asking")
+	cmd.Flags().BoolVar(&team, "team", false, "Also install the team config's tools that are missing")
+	cmd.Flags().StringSliceVar(&stacks, "stack", nil, "Install a curated stack of tools and MCP servers (see palm stacks)")
+	_ = cmd.RegisterFlagCompletionFunc("stack", stackCompletionFunc)
 	return cmd
 }
 
-func installOne(reg *registry.Registry, name string) {
+// teamMissingTools adds the team config's registry tools that aren't
+// installed yet to names.
+func teamMissingTools(reg *registry.Registry, names []string) ([]string, error) {
+	tc, err := loadTeamConfig()
+	if err != nil {
+		return nil, err
+	}
+	detected := detectTools(reg, tc.Tools)
+	for _, name := range tc.Tools {
+		if reg.Get(name) == nil {
+			ui.Warn.Printf("  %s %s — not in registry\n", ui.WarnIcon(), name)
+			continue
+		}
+		if !detected[name].Installed && !containsStr(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func installOne(reg *registry.Registry, name string, assumeYes bool) {
 	tool := reg.Get(name)
 	if tool == nil {
 		ui.Warn.Printf("palm: unknown tool %q\n", name)
@@ -82,6 +170,11 @@ func installOne(reg *registry.Registry, name string) {
 	fmt.Printf("  %s %s\n", ui.Brand.Sprint(tool.DisplayName), ui.Subtle.Sprintf("(%s via %s)", pkg, backend))
 	fmt.Println()
 
+	if blocked, _ := ensurePrerequisites(reg, []*registry.Tool{tool}, assumeYes); len(blocked) > 0 {
+		ui.Bad.Printf("\n  Install failed: %s\n", blocked[tool.Name])
+		os.Exit(1)
+	}
+
 	if err := doInstall(tool); err != nil {
 		ui.Bad.Printf("\n  Install failed: %v\n", err)
 		os.Exit(1)
@@ -89,6 +182,7 @@ func installOne(reg *registry.Registry, name string) {
 
 	fmt.Println()
 	ui.Good.Printf("  %s %s installed successfully\n", ui.StatusIcon(true), tool.DisplayName)
+	runSetupSteps(tool, assumeYes)
 
 	if tool.NeedsAPIKey() {
 		fmt.Printf("\n  %s Requires: %s\n", ui.WarnIcon(), tool.Keys.Required)
@@ -96,10 +190,10 @@ func installOne(reg *registry.Registry, name string) {
 	}
 }
 
-func installParallel(reg *registry.Registry, names []string, concurrency int) {
+func installParallel(reg *registry.Registry, names []string, concurrency int, assumeYes bool) {
 	ui.Banner("installing (parallel)")
 
-	var tasks []parallel.Task
+	var tools []*registry.Tool
 	var unknown int
 
 	for _, name := range names {
@@ -109,7 +203,30 @@ func installParallel(reg *registry.Registry, names []string, concurrency int) {
 			unknown++
 			continue
 		}
+		tools = append(tools, tool)
+	}
+
+	// Dependencies (node, python, required tools) are installed first, in the
+	// foreground, since package managers may prompt for sudo.
+	blocked, done := ensurePrerequisites(reg, tools, assumeYes)
+
+	var tasks []parallel.Task
+	var pending []*registry.Tool
+	for _, tool := range tools {
+		if done[tool.Name] {
+			continue // already installed as a dependency of another tool
+		}
+		pending = append(pending, tool)
 		t := *tool // copy
+		if reason, ok := blocked[t.Name]; ok {
+			tasks = append(tasks, parallel.Task{
+				Name: t.DisplayName,
+				Fn: func() (string, error) {
+					return "", fmt.Errorf("%s", reason)
+				},
+			})
+			continue
+		}
 		tasks = append(tasks, parallel.Task{
 			Name: t.DisplayName,
 			Fn: func() (string, error) {
@@ -125,29 +242,185 @@ func installParallel(reg *registry.Registry, names []string, concurrency int) {
 	fmt.Println()
 	results := parallel.Run(tasks, concurrency)
 
-	success, failed := 0, 0
-	for _, r := range results {
+	success, failed := len(done), 0
+	var rows [][]string
+	var installed []*registry.Tool
+	for i, r := range results {
 		if r.OK {
+			installed = append(installed, pending[i])
 			success++
-		} else {
-			failed++
+			continue
+		}
+		failed++
+		logPath := "-"
+		if strings.TrimSpace(r.Output) != "" {
+			if path, err := writeInstallLog(pending[i].Name, r.Output); err == nil {
+				logPath = path
+			}
 		}
+		rows = append(rows, []string{r.Name, firstErrLine(r.Err), logPath})
 	}
 	failed += unknown
 
+	if len(rows) > 0 {
+		fmt.Println()
+		ui.Table([]string{"Failed", "Error", "Log"}, rows)
+	}
+
 	fmt.Printf("\n  %d installed", success)
 	if failed > 0 {
 		fmt.Printf(" · %d failed", failed)
 	}
 	fmt.Println()
+
+	// Setup steps may prompt, so they run one tool at a time afterwards
+	for _, tool := range installed {
+		runSetupSteps(tool, assumeYes)
+	}
+}
+
+// ensurePrerequisites checks every tool's install backend, declared requires
+// and conflicts, offering to install missing toolchains and required registry
+// tools first. It returns the tools that still cannot be installed, mapped to
+// the reason, and the names of batch tools it already installed as dependencies.
+func ensurePrerequisites(reg *registry.Registry, tools []*registry.Tool, assumeYes bool) (blocked map[string]string, done map[string]bool) {
+	blocked = make(map[string]string)
+	done = make(map[string]bool)
+
+	type dependency struct {
+		label      string
+		homepage   string
+		tool       *registry.Tool // registry tool to install via doInstall
+		prereq     *installer.Prerequisite
+		dependents []string
+	}
+	var order []string
+	deps := make(map[string]*dependency)
+	need := func(key string, d dependency, dependent string) {
+		if _, ok := deps[key]; !ok {
+			order = append(order, key)
+			deps[key] = &d
+		}
+		deps[key].dependents = append(deps[key].dependents, dependent)
+	}
+
+	for _, t := range tools {
+		if conflicts := installer.InstalledConflicts(*t, reg); len(conflicts) > 0 {
+			blocked[t.Name] = "conflicts with installed " + strings.Join(conflicts, ", ")
+			continue
+		}
+		if p := installer.MissingPrerequisite(*t); p != nil {
+			need("toolchain:"+p.Name, dependency{label: p.Label, homepage: p.Tool.Homepage, prereq: p}, t.DisplayName)
+		}
+		unmet, err := installer.CheckRequirements(*t, reg)
+		if err != nil {
+			blocked[t.Name] = err.Error()
+			continue
+		}
+		for _, u := range unmet {
+			switch {
+			case !u.Missing:
+				blocked[t.Name] = u.String() + " — upgrade it and retry"
+			case u.Prereq != nil:
+				need("toolchain:"+u.Prereq.Name, dependency{label: u.Prereq.Label, homepage: u.Prereq.Tool.Homepage, prereq: u.Prereq}, t.DisplayName)
+			case u.Tool != nil:
+				need("tool:"+u.Tool.Name, dependency{label: u.Tool.DisplayName, homepage: u.Tool.Homepage, tool: u.Tool}, t.DisplayName)
+			default:
+				blocked[t.Name] = u.String()
+			}
+		}
+	}
+
+	for _, key := range order {
+		d := deps[key]
+		fmt.Printf("  %s %s is required by %s but was not found\n", ui.WarnIcon(), ui.Brand.Sprint(d.label), strings.Join(d.dependents, ", "))
+
+		canInstall := d.tool != nil || d.prereq.CanInstall()
+		switch {
+		case !canInstall:
+			fmt.Printf("    Install it from %s\n", d.homepage)
+		case assumeYes || confirm(fmt.Sprintf("    Install %s first?", d.label)):
+			var err error
+			if d.tool != nil {
+				err = doInstall(d.tool)
+			} else {
+				err = installer.Install(d.prereq.Tool)
+			}
+			if err != nil {
+				ui.Bad.Printf("    %s %s install failed: %v\n", ui.StatusIcon(false), d.label, err)
+				continue
+			}
+			ui.Good.Printf("    %s %s installed\n", ui.StatusIcon(true), d.label)
+			if d.tool != nil {
+				done[d.tool.Name] = true
+			}
+		}
+	}
+
+	// Re-check now that dependencies had their chance to install
+	for _, t := range tools {
+		if _, ok := blocked[t.Name]; ok || done[t.Name] {
+			continue
+		}
+		if p := installer.MissingPrerequisite(*t); p != nil {
+			blocked[t.Name] = p.Label + " is required"
+			if deps["toolchain:"+p.Name] != nil && p.CanInstall() {
+				blocked[t.Name] += " (if just installed, open a new shell so it is on PATH)"
+			}
+			continue
+		}
+		if unmet, _ := installer.CheckRequirements(*t, reg); len(unmet) > 0 {
+			blocked[t.Name] = unmet[0].String()
+		}
+	}
+	return blocked, done
+}
+
+// confirm asks a yes/no question, defaulting to yes. Non-interactive input
+// is treated as no so scripted installs never block.
+func confirm(question string) bool {
+	if fi, err := os.Stdin.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	fmt.Printf("%s [Y/n] ", question)
+	scanner := bufio.NewScanner(os.Stdin)
+	if !scanner.Scan() {
+		return false
+	}
+	answer := strings.ToLower(strings.TrimSpace(scanner.Text()))
+	return answer == "" || answer == "y" || answer == "yes"
+}
+
+// writeInstallLog saves the captured output of a failed install.
+func writeInstallLog(tool, output string) (string, error) {
+	dir := filepath.Join(config.ConfigDir(), "logs")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("install-%s-%s.log", tool, time.Now().Format("20060102-150405")))
+	return path, os.WriteFile(path, []byte(output), 0o644)
+}
+
+func firstErrLine(err error) string {
+	if err == nil {
+		return ""
+	}
+	msg := err.Error()
+	if i := strings.IndexByte(msg, '\n'); i >= 0 {
+		msg = msg[:i]
+	}
+	return msg
 }
 
 func doInstall(tool *registry.Tool) error {
 	_ = hooks.Run("pre_install", tool.Name, tool.Category)
 
+	start := time.Now()
 	if err := installer.Install(*tool); err != nil {
+		logToolChange("install", tool, start, err)
 		return err
 	}
+	logToolChange("install", tool, start, nil)
 
 	backend, pkg := tool.InstallMethod()
 	dt := registry.DetectOne(*tool)
@@ -161,7 +434,9 @@ func doInstall(tool *registry.Tool) error {
 func doInstallQuiet(tool *registry.Tool) (string, error) {
 	_ = hooks.Run("pre_install", tool.Name, tool.Category)
 
+	start := time.Now()
 	output, err := installer.InstallQuiet(*tool)
+	logToolChange("install", tool, start, err)
 	if err != nil {
 		return output, err
 	}
@@ -174,3 +449,101 @@ func doInstallQuiet(tool *registry.Tool) (string, error) {
 
 	return output, nil
 }
+
+// logToolChange records an install, update or removal in the activity log.
+func logToolChange(action string, tool *registry.Tool, start time.Time, err error) {
+	backend, pkg := tool.InstallMethod()
+	details := backend + " " + pkg
+	if err != nil {
+		details = firstErrLine(err)
+	}
+	_ = activity.Append(activity.Entry{
+		Action:   action,
+		Tool:     tool.Name,
+		Details:  details,
+		Duration: time.Since(start).Seconds(),
+		Status:   activity.StatusOf(err == nil),
+	})
+}
+
+// planTools resolves dry-run plans for the named tools. Unknown names get a
+// plan carrying only an error so they still show up in the output.
+func planTools(reg *registry.Registry, names []string, action string) []installer.Plan {
+	var plans []installer.Plan
+	for _, name := range names {
+		tool := reg.Get(name)
+		if tool == nil {
+			plans = append(plans, installer.Plan{Tool: name, Action: action, Error: "unknown tool"})
+			continue
+		}
+		p := installer.PlanFor(action, *tool)
+		if action == installer.ActionInstall {
+			unmet, _ := installer.CheckRequirements(*tool, reg)
+			for _, u := range unmet {
+				p.Notes = append(p.Notes, u.String())
+			}
+		}
+		plans = append(plans, p)
+	}
+	return plans
+}
+
+// printPlans prints what an install, update or uninstall would execute.
+func printPlans(action string, plans []installer.Plan) {
+	ui.Banner(action + " (dry run)")
+	fmt.Println("  Nothing will be executed.")
+	fmt.Println()
+
+	runnable, sudo := 0, 0
+	var download int64
+	unknownSize := 0
+	for _, p := range plans {
+		label := p.Tool
+		if p.Backend != "" {
+			label += ui.Subtle.Sprintf(" (%s via %s)", p.Package, p.Backend)
+		}
+		fmt.Printf("  %s\n", ui.Brand.Sprint(label))
+		if p.Error != "" {
+			ui.Bad.Printf("    %s %s\n", ui.StatusIcon(false), p.Error)
+			fmt.Println()
+			continue
+		}
+		runnable++
+
+		if len(p.Command) > 0 {
+			fmt.Printf("    $ %s\n", p.CommandLine())
+		}
+		if p.Sudo {
+			sudo++
+			ui.Warn.Printf("    %s requires sudo\n", ui.WarnIcon())
+		}
+		if action != installer.ActionUninstall {
+			if size := installer.EstimateDownload(p.Backend, p.Package); size > 0 {
+				download += size
+				fmt.Printf("    download: ~%s (package only, excluding dependencies)\n", formatBytes(int(size)))
+			} else {
+				unknownSize++
+			}
+		}
+		for _, n := range p.Notes {
+			ui.Subtle.Printf("    note: %s\n", n)
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("  %d command(s) would run", runnable)
+	if sudo > 0 {
+		fmt.Printf(" · %d with sudo", sudo)
+	}
+	if failed := len(plans) - runnable; failed > 0 {
+		fmt.Printf(" · %d cannot run", failed)
+	}
+	fmt.Println()
+	if download > 0 {
+		fmt.Printf("  Estimated download: ~%s", formatBytes(int(download)))
+		if unknownSize > 0 {
+			fmt.Printf(" (+%d package(s) of unknown size)", unknownSize)
+		}
+		fmt.Println()
+	}
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/msalah0e/palm/internal/ui"
+)
+
+// parseJudges splits a --judge value like "ollama,mods,llm" into judge tools.
+func parseJudges(s string) []string {
+	var judges []string
+	seen := make(map[string]bool)
+	for _, j := range strings.Split(s, ",") {
+		j = strings.TrimSpace(j)
+		if j != "" && !seen[j] {
+			seen[j] = true
+			judges = append(judges, j)
+		}
+	}
+	return judges
+}
+
+// judgeList parses a --judge value and exits when it names no tool, as
+// --judge "," does.
+func judgeList(s string) []string {
+	judges := parseJudges(s)
+	if len(judges) == 0 {
+		ui.Bad.Printf("  --judge %q names no judge tool\n", s)
+		os.Exit(1)
+	}
+	return judges
+}
+
+// runJudges sends the same prompt to every judge concurrently and returns
+// their outputs in judge order. Failed or timed-out judges yield "".
+func runJudges(judges []string, prompt string, env []string, timeout int) []string {
+	outputs := make([]string, len(judges))
+	var wg sync.WaitGroup
+	for i, j := range judges {
+		wg.Add(1)
+		go func(i int, j string) {
+			defer wg.Done()
+			outputs[i] = runJudgeTool(j, prompt, env, timeout)
+		}(i, j)
+	}
+	wg.Wait()
+	return outputs
+}
+
+// judgeBallot is one judge's pick in vote mode.
+type judgeBallot struct {
+	Judge  string
+	Pick   int // candidate number; 0 when the judge gave no usable answer
+	Reason string
+}
+
+var candidateRe = regexp.MustCompile(`(?i)candidate\s*#?\s*(\d+)`)
+
+// parseVoteChoice extracts the chosen candidate number and a one-line reason
+// from a judge's reply. Only numbers in valid are accepted.
+func parseVoteChoice(output string, valid map[int]bool) (int, string) {
+	pick := 0
+	if m := candidateRe.FindStringSubmatch(output); m != nil {
+		if n, err := strconv.Atoi(m[1]); err == nil && valid[n] {
+			pick = n
+		}
+	}
+
+	reason := ""
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "0123456789.)-*# "))
+		if line == "" || candidateRe.MatchString(line) && len(line) < 20 {
+			continue
+		}
+		reason = line
+		break
+	}
+	if len(reason) > 80 {
+		reason = reason[:77] + "..."
+	}
+	return pick, reason
+}
+
+// tallyVotes returns the candidate with the most votes, its vote count, and
+// whether the lead is shared. Ballots without a pick are ignored.
+func tallyVotes(ballots []judgeBallot) (winner, votes int, tie bool) {
+	counts := make(map[int]int)
+	for _, b := range ballots {
+		if b.Pick > 0 {
+			counts[b.Pick]++
+		}
+	}
+	picks := make([]int, 0, len(counts))
+	for p := range counts {
+		picks = append(picks, p)
+	}
+	sort.Ints(picks)
+	for _, p := range picks {
+		switch {
+		case counts[p] > votes:
+			winner, votes, tie = p, counts[p], false
+		case counts[p] == votes:
+			tie = true
+		}
+	}
+	return winner, votes, tie
+}
+
+// averageScores combines several judges' scores for one tool into their mean.
+func averageScores(tool string, scores []evalScore) evalScore {
+	avg := evalScore{Tool: tool, Grounded: -1}
+	if len(scores) == 0 {
+		return avg
+	}
+	var verdicts []string
+	grounded, groundedN := 0, 0
+	seen := make(map[string]bool)
+	for _, s := range scores {
+		if s.Grounded >= 0 {
+			grounded += s.Grounded
+			groundedN++
+		}
+		for _, c := range s.Unsupported {
+			if !seen[c] {
+				seen[c] = true
+				avg.Unsupported = append(avg.Unsupported, c)
+			}
+		}
+		avg.Accuracy += s.Accuracy
+		avg.Hallucination += s.Hallucination
+		avg.Completeness += s.Completeness
+		avg.Clarity += s.Clarity
+		avg.Overall += s.Overall
+		avg.PerJudge = append(avg.PerJudge, s.Overall)
+		if s.Verdict != "" {
+			verdicts = append(verdicts, s.Verdict)
+		}
+	}
+	n := len(scores)
+	avg.Accuracy /= n
+	avg.Hallucination /= n
+	avg.Completeness /= n
+	avg.Clarity /= n
+	avg.Overall /= n
+	if groundedN > 0 {
+		avg.Grounded = grounded / groundedN
+	}
+	if len(verdicts) > 0 {
+		avg.Verdict = verdicts[0]
+	}
+	return avg
+}
+
+// judgeAgreement reports how many judges ranked the overall winner first.
+// Each judge's ranking comes from its own per-tool Overall scores.
+func judgeAgreement(scores []evalScore, judges int) (best string, agree int) {
+	top := -1
+	for _, s := range scores {
+		if len(s.PerJudge) == judges && s.Overall > top {
+			top, best = s.Overall, s.Tool
+		}
+	}
+	if best == "" {
+		return "", 0
+	}
+	for j := 0; j < judges; j++ {
+		pick, pickScore := "", -1
+		for _, s := range scores {
+			if len(s.PerJudge) == judges && s.PerJudge[j] > pickScore {
+				pick, pickScore = s.Tool, s.PerJudge[j]
+			}
+		}
+		if pick == best {
+			agree++
+		}
+	}
+	return best, agree
+}
+
+// scoreSpread is the gap between the highest and lowest judge score.
+func scoreSpread(perJudge []int) int {
+	if len(perJudge) == 0 {
+		return 0
+	}
+	lo, hi := perJudge[0], perJudge[0]
+	for _, v := range perJudge {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+	return hi - lo
+}
+
+func formatPerJudge(judges []string, perJudge []int) string {
+	parts := make([]string, len(perJudge))
+	for i, v := range perJudge {
+		name := "?"
+		if i < len(judges) {
+			name = judges[i]
+		}
+		parts[i] = fmt.Sprintf("%s %d", name, v)
+	}
+	return strings.Join(parts, " · ")
+}
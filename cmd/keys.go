@@ -4,9 +4,17 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/msalah0e/palm/internal/config"
+	"github.com/msalah0e/palm/internal/models"
 	"github.com/msalah0e/palm/internal/registry"
 	"github.com/msalah0e/palm/internal/ui"
 	"github.com/msalah0e/palm/internal/vault"
@@ -21,10 +29,15 @@ func keysCmd() *cobra.Command {
 
 	keysCmd.AddCommand(
 		keysAddCmd(),
+		keysSetupCmd(),
+		keysAuditCmd(),
+		keysDoctorCmd(),
 		keysRmCmd(),
 		keysListCmd(),
 		keysExportCmd(),
 		keysEnvCmd(),
+		keysDirenvCmd(),
+		keysProfilesCmd(),
 	)
 
 	return keysCmd
@@ -34,7 +47,15 @@ func keysAddCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "add <KEY_NAME>",
 		Short: "Store an API key in the vault",
-		Args:  cobra.ExactArgs(1),
+		Long: `Store an API key in the vault.
+
+Instead of the key itself, you can store a reference to a secret manager.
+Only the reference is kept; it is resolved each time the key is injected:
+
+  op://vault/item/field   1Password (op read)
+  pass:openai/key         pass (first line of pass show)
+  env:NAME                an environment variable`,
+		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			keyName := args[0]
 			v := vault.New()
@@ -48,23 +69,385 @@ func keysAddCmd() *cobra.Command {
 				ui.Warn.Println("  Empty value — key not stored")
 				return
 			}
+			if vault.IsRef(value) {
+				if err := vault.CheckRef(value); err != nil {
+					ui.Bad.Printf("  %v\n", err)
+					os.Exit(1)
+				}
+			}
 
 			if err := v.Set(keyName, value); err != nil {
 				ui.Bad.Printf("  Failed to store key: %v\n", err)
 				os.Exit(1)
 			}
+			_ = vault.RecordSet(keyName)
 
+			if source := vault.RefSource(value); source != "" {
+				ui.Good.Printf("  %s %s stored as a reference to %s\n", ui.StatusIcon(true), keyName, source)
+				if _, err := vault.Resolve(value); err != nil {
+					ui.Warn.Printf("  %s It does not resolve yet: %v\n", ui.WarnIcon(), err)
+				}
+				return
+			}
+			if p := vault.Profile(); p != "" {
+				ui.Good.Printf("  %s %s stored in vault (profile %s)\n", ui.StatusIcon(true), keyName, p)
+				return
+			}
 			ui.Good.Printf("  %s %s stored in vault\n", ui.StatusIcon(true), keyName)
 		},
 	}
 }
 
+func keysSetupCmd() *cobra.Command {
+	var test, noTest bool
+
+	cmd := &cobra.Command{
+		Use:   "setup <tool>",
+		Short: "Interactively store every API key a tool needs",
+		Long: `Walk through a tool's required and optional API keys from the registry.
+
+Input is masked, values are checked against the provider's key format, and
+keys for known providers can be verified with a free live call before they
+are stored in the vault.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: toolCompletionFunc,
+		Run: func(cmd *cobra.Command, args []string) {
+			reg := loadRegistry()
+			tool := reg.Get(args[0])
+			if tool == nil {
+				ui.Warn.Printf("palm: unknown tool %q\n", args[0])
+				os.Exit(1)
+			}
+
+			ui.Banner("keys setup")
+			fmt.Printf("  %s\n\n", ui.Brand.Sprint(tool.DisplayName))
+
+			if len(tool.Keys.Required)+len(tool.Keys.Optional) == 0 {
+				fmt.Println("  This tool does not need any API keys.")
+				return
+			}
+
+			v := vault.New()
+			stored, missing := 0, 0
+			for _, k := range append(append([]string{}, tool.Keys.Required...), tool.Keys.Optional...) {
+				optional := !containsString(tool.Keys.Required, k)
+				label := k
+				if optional {
+					label += ui.Subtle.Sprint(" (optional)")
+				}
+				fmt.Printf("  %s\n", ui.Brand.Sprint(label))
+
+				if existing, err := vault.Stored(v, k); err == nil && existing != "" {
+					fmt.Printf("    Stored: %s\n", ui.Subtle.Sprint(maskStored(existing)))
+					if !confirmNo("    Replace it?") {
+						fmt.Println()
+						continue
+					}
+				}
+
+				value := promptKey(k, optional)
+				if value == "" {
+					if optional {
+						ui.Subtle.Println("    Skipped")
+					} else {
+						ui.Warn.Printf("    %s Skipped — %s will not work without it\n", ui.WarnIcon(), tool.DisplayName)
+						missing++
+					}
+					fmt.Println()
+					continue
+				}
+
+				if p := models.ProviderForKey(k); p != nil && !noTest && (test || confirm(fmt.Sprintf("    Verify with a live call to %s?", p.Name))) {
+					secret, err := vault.Resolve(value)
+					if err == nil {
+						err = models.VerifyKey(p, secret)
+					}
+					if err != nil {
+						ui.Bad.Printf("    %s %v\n", ui.StatusIcon(false), err)
+						if !confirmNo("    Store it anyway?") {
+							fmt.Println()
+							if !optional {
+								missing++
+							}
+							continue
+						}
+					} else {
+						ui.Good.Printf("    %s Key works\n", ui.StatusIcon(true))
+					}
+				}
+
+				if err := v.Set(k, value); err != nil {
+					ui.Bad.Printf("    Failed to store key: %v\n", err)
+					os.Exit(1)
+				}
+				_ = vault.RecordSet(k)
+				ui.Good.Printf("    %s Stored in vault\n\n", ui.StatusIcon(true))
+				stored++
+			}
+
+			fmt.Printf("  %d key(s) stored", stored)
+			if missing > 0 {
+				fmt.Printf(" · %d required key(s) missing", missing)
+			}
+			fmt.Println()
+			if missing == 0 {
+				fmt.Printf("  Run it with: palm run %s\n", tool.Name)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&test, "test", false, "Verify keys with a live call without asking")
+	cmd.Flags().BoolVar(&noTest, "no-test", false, "Never make live verification calls")
+	return cmd
+}
+
+// promptKey reads a key with masked input, re-prompting on format errors.
+// An empty result means the user skipped the key.
+func promptKey(name string, optional bool) string {
+	hint := "Enter value"
+	if optional {
+		hint = "Enter value (blank to skip)"
+	}
+	for attempt := 0; attempt < 3; attempt++ {
+		value := readSecret(fmt.Sprintf("    %s: ", hint))
+		if value == "" {
+			return ""
+		}
+		if vault.IsRef(value) {
+			if err := vault.CheckRef(value); err != nil {
+				ui.Warn.Printf("    %s %v\n", ui.WarnIcon(), err)
+				continue
+			}
+			return value
+		}
+		if err := vault.CheckFormat(name, value); err != nil {
+			ui.Warn.Printf("    %s %v\n", ui.WarnIcon(), err)
+			if confirmNo("    Use it anyway?") {
+				return value
+			}
+			continue
+		}
+		return value
+	}
+	return ""
+}
+
+// maskStored shows a stored value: references in full, since they are not
+// secret, and anything else masked.
+func maskStored(value string) string {
+	if source := vault.RefSource(value); source != "" {
+		return fmt.Sprintf("%s (%s)", value, source)
+	}
+	return vault.Mask(value)
+}
+
+// warnUnresolved explains why a key stored as a reference was left out;
+// keys that are simply missing stay quiet.
+func warnUnresolved(v vault.Vault, err error, key string) {
+	if stored, serr := vault.Stored(v, key); serr == nil && vault.IsRef(stored) {
+		ui.Warn.Fprintf(os.Stderr, "  palm: couldn't resolve %v\n", err)
+	}
+}
+
+// stdinReader is shared by the interactive prompts so buffered input is not
+// lost between them when stdin is a pipe.
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// readSecret prints prompt and reads a line without echoing it when stdin
+// is a terminal.
+func readSecret(prompt string) string {
+	fmt.Print(prompt)
+	interactive := false
+	if fi, err := os.Stdin.Stat(); err == nil && fi.Mode()&os.ModeCharDevice != 0 && runtime.GOOS != "windows" {
+		if restore, err := hideInput(); err == nil {
+			interactive = true
+			defer restore()
+		}
+	}
+	value, _ := stdinReader.ReadString('\n')
+	if interactive {
+		fmt.Println()
+	}
+	return strings.TrimSpace(value)
+}
+
+// hideInput turns terminal echo off until restore is called, or until the
+// user interrupts, in which case echo is restored before palm exits.
+func hideInput() (restore func(), err error) {
+	if err := sttyEcho(false); err != nil {
+		return nil, err
+	}
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-sigs:
+			_ = sttyEcho(true)
+			fmt.Println()
+			os.Exit(130)
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(sigs)
+		close(done)
+		_ = sttyEcho(true)
+	}, nil
+}
+
+func sttyEcho(on bool) error {
+	arg := "-echo"
+	if on {
+		arg = "echo"
+	}
+	c := exec.Command("stty", arg)
+	c.Stdin = os.Stdin
+	return c.Run()
+}
+
+// confirmNo asks a yes/no question that defaults to no.
+func confirmNo(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Println()
+		return false
+	}
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes"
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func keysAuditCmd() *cobra.Command {
+	var staleDays, rotationDays int
+
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Report stale keys, due rotations, and which tools used each key",
+		Long: `Audit vault keys using palm's key metadata: when each key was added, when it
+was last injected into a tool or proxy request, and by whom.
+
+Defaults come from [vault] stale_days (90) and rotation_days (off) in
+~/.config/palm/config.toml.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg := config.Load()
+			if !cmd.Flags().Changed("stale-days") {
+				staleDays = cfg.Vault.StaleDays
+			}
+			if !cmd.Flags().Changed("rotation-days") {
+				rotationDays = cfg.Vault.RotationDays
+			}
+
+			v := vault.New()
+			keys, err := v.List()
+			if err != nil {
+				ui.Bad.Printf("  Failed to list keys: %v\n", err)
+				os.Exit(1)
+			}
+			meta, err := vault.LoadMeta()
+			if err != nil {
+				ui.Bad.Printf("  Failed to read key metadata: %v\n", err)
+				os.Exit(1)
+			}
+
+			day := 24 * time.Hour
+			audit := vault.Audit(keys, meta, time.Now(), time.Duration(staleDays)*day, time.Duration(rotationDays)*day)
+
+			if jsonOutput {
+				printJSON(audit)
+				return
+			}
+
+			ui.Banner("keys audit")
+			if len(audit) == 0 {
+				fmt.Println("  No API keys stored.")
+				return
+			}
+
+			var rows [][]string
+			stale, due := 0, 0
+			for _, a := range audit {
+				added, lastUsed := "unknown", "never"
+				if a.AddedAt != nil {
+					added = fmt.Sprintf("%s (%dd)", a.AddedAt.Format("2006-01-02"), a.AgeDays)
+				}
+				if a.LastUsed != nil {
+					lastUsed = a.LastUsed.Format("2006-01-02")
+				}
+
+				status := ui.Good.Sprint("ok")
+				switch {
+				case a.RotationDue:
+					status = ui.Bad.Sprint("rotate now")
+					due++
+				case a.RotateSoon:
+					status = ui.Warn.Sprint("rotate soon")
+					due++
+				case a.Stale:
+					status = ui.Warn.Sprint("stale")
+				}
+				if a.Stale {
+					stale++
+					if a.RotationDue || a.RotateSoon {
+						status += ui.Warn.Sprint(", stale")
+					}
+				}
+
+				rows = append(rows, []string{a.Name, added, lastUsed, recentConsumers(a.UsedBy, 3), status})
+			}
+			ui.Table([]string{"Key", "Added", "Last used", "Used by", "Status"}, rows)
+
+			fmt.Printf("\n  %d key(s)", len(audit))
+			if stale > 0 {
+				fmt.Printf(" · %d unused for %d+ days", stale, staleDays)
+			}
+			if due > 0 {
+				fmt.Printf(" · %d due for rotation", due)
+			}
+			fmt.Println()
+			if rotationDays == 0 {
+				ui.Subtle.Println("  Rotation checks off — set [vault] rotation_days or pass --rotation-days")
+			}
+		},
+	}
+
+	cmd.Flags().IntVar(&staleDays, "stale-days", 90, "Flag keys not used for this many days")
+	cmd.Flags().IntVar(&rotationDays, "rotation-days", 0, "Flag keys older than this many days (0 disables)")
+	return cmd
+}
+
+// recentConsumers lists up to n consumers, most recent first.
+func recentConsumers(usedBy map[string]time.Time, n int) string {
+	if len(usedBy) == 0 {
+		return "-"
+	}
+	names := make([]string, 0, len(usedBy))
+	for name := range usedBy {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return usedBy[names[i]].After(usedBy[names[j]]) })
+	if len(names) > n {
+		return strings.Join(names[:n], ", ") + fmt.Sprintf(" +%d", len(names)-n)
+	}
+	return strings.Join(names, ", ")
+}
+
 func keysRmCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:     "rm <KEY_NAME>",
-		Aliases: []string{"remove", "delete"},
-		Short:   "Remove an API key from the vault",
-		Args:    cobra.ExactArgs(1),
+		Use:               "rm <KEY_NAME>",
+		Aliases:           []string{"remove", "delete"},
+		Short:             "Remove an API key from the vault",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: keyCompletionFunc,
 		Run: func(cmd *cobra.Command, args []string) {
 			keyName := args[0]
 			v := vault.New()
@@ -73,6 +456,7 @@ func keysRmCmd() *cobra.Command {
 				ui.Bad.Printf("  Failed to remove key: %v\n", err)
 				os.Exit(1)
 			}
+			_ = vault.RecordDelete(keyName)
 
 			ui.Good.Printf("  %s %s removed from vault\n", ui.StatusIcon(true), keyName)
 		},
@@ -87,6 +471,9 @@ func keysListCmd() *cobra.Command {
 			v := vault.New()
 
 			ui.Banner("stored API keys")
+			if p := vault.Profile(); p != "" {
+				fmt.Printf("  Profile: %s\n\n", ui.Brand.Sprint(p))
+			}
 
 			keys, err := v.List()
 			if err != nil {
@@ -101,10 +488,10 @@ func keysListCmd() *cobra.Command {
 			}
 
 			for _, key := range keys {
-				val, err := v.Get(key)
+				val, err := vault.Stored(v, key)
 				masked := "****"
 				if err == nil {
-					masked = vault.Mask(val)
+					masked = maskStored(val)
 				}
 				fmt.Printf("  %s  %s\n", ui.Brand.Sprintf("%-30s", key), ui.Subtle.Sprint(masked))
 			}
@@ -135,9 +522,11 @@ func keysExportCmd() *cobra.Command {
 			fmt.Println("# palm vault — eval $(palm keys export)")
 			for _, key := range keys {
 				val, err := v.Get(key)
-				if err == nil {
-					fmt.Printf("export %s=%q\n", key, val)
+				if err != nil {
+					warnUnresolved(v, err, key)
+					continue
 				}
+				fmt.Printf("export %s=%q\n", key, val)
 			}
 		},
 	}
@@ -145,14 +534,41 @@ func keysExportCmd() *cobra.Command {
 
 // keysEnvCmd prints shell exports for vault keys AND tool paths.
 func keysEnvCmd() *cobra.Command {
-	return &cobra.Command{
+	var (
+		tools     []string
+		workspace bool
+	)
+
+	cmd := &cobra.Command{
 		Use:   "env",
 		Short: "Print shell exports for vault keys and tool paths",
-		Long:  "Print export statements for eval — usage: eval $(palm keys env)",
+		Long: `Print export statements for eval — usage: eval $(palm keys env)
+
+With --tool or --workspace, print only the keys those tools need, for a
+project's .envrc:
+
+  palm keys env --tool aider > .envrc`,
 		Run: func(cmd *cobra.Command, args []string) {
 			v := vault.New()
 			reg := loadRegistry()
 
+			if len(tools) > 0 || workspace {
+				scoped, ok := scopedTools(tools)
+				if !ok {
+					ui.Bad.Fprintln(os.Stderr, "  No tools pinned in this workspace — pass --tool")
+					os.Exit(1)
+				}
+				lines, missing := scopedKeyExports(v, reg, scoped)
+				fmt.Println(scopedEnvHeader(scoped, "palm keys env"))
+				for _, line := range lines {
+					fmt.Println(line)
+				}
+				if len(missing) > 0 {
+					ui.Warn.Fprintf(os.Stderr, "  %s Not in the vault: %s\n", ui.WarnIcon(), strings.Join(missing, ", "))
+				}
+				return
+			}
+
 			fmt.Println("# palm env — eval $(palm keys env)")
 
 			// Export all vault keys
@@ -160,9 +576,11 @@ func keysEnvCmd() *cobra.Command {
 			if err == nil {
 				for _, key := range keys {
 					val, err := v.Get(key)
-					if err == nil {
-						fmt.Printf("export %s=%q\n", key, val)
+					if err != nil {
+						warnUnresolved(v, err, key)
+						continue
 					}
+					fmt.Printf("export %s=%q\n", key, val)
 				}
 			}
 
@@ -199,4 +617,8 @@ func keysEnvCmd() *cobra.Command {
 			fmt.Println("# end palm env")
 		},
 	}
+
+	cmd.Flags().StringSliceVar(&tools, "tool", nil, "Only the keys these tools use")
+	cmd.Flags().BoolVar(&workspace, "workspace", false, "Only the keys the workspace's pinned tools use")
+	return cmd
 }
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/msalah0e/palm/internal/registry"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/msalah0e/palm/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+const (
+	envrcBegin = "# >>> palm keys >>>"
+	envrcEnd   = "# <<< palm keys <<<"
+)
+
+// scopedKeyExports returns export lines for just the keys the named tools
+// use, as allowed by the workspace, and the required keys the vault lacks.
+func scopedKeyExports(v vault.Vault, reg *registry.Registry, tools []string) (lines, missing []string) {
+	proj, _ := loadProject()
+	var exported []string
+	for _, key := range toolKeys(reg, tools) {
+		if !proj.allowsKey(key) {
+			continue
+		}
+		val, err := v.Get(key)
+		if err != nil {
+			warnUnresolved(v, err, key)
+			if requiredByAny(reg, tools, key) {
+				missing = append(missing, key)
+			}
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("export %s=%q", key, val))
+		exported = append(exported, key)
+	}
+	if len(exported) > 0 {
+		_ = vault.RecordUse("env:"+strings.Join(tools, ","), exported...)
+	}
+	return lines, missing
+}
+
+func requiredByAny(reg *registry.Registry, tools []string, key string) bool {
+	for _, name := range tools {
+		if t := reg.Get(name); t != nil && containsString(t.Keys.Required, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// scopedEnvHeader warns that generated output holds secrets.
+func scopedEnvHeader(tools []string, regenerate string) string {
+	return fmt.Sprintf("# palm: API keys for %s — contains secrets, do not commit.\n"+
+		"# Regenerate with: %s --tool %s", strings.Join(tools, ", "), regenerate, strings.Join(tools, " --tool "))
+}
+
+// scopedTools returns the tools to scope keys to: those given, or the ones
+// pinned in the current workspace.
+func scopedTools(tools []string) ([]string, bool) {
+	if len(tools) > 0 {
+		return tools, true
+	}
+	if ws := loadWorkspace(); ws != nil && len(ws.Tools) > 0 {
+		return ws.Tools, true
+	}
+	return nil, false
+}
+
+func keysDirenvCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "direnv",
+		Short: "Load a project's keys with direnv",
+	}
+	cmd.AddCommand(keysDirenvInstallCmd())
+	return cmd
+}
+
+func keysDirenvInstallCmd() *cobra.Command {
+	var tools []string
+
+	cmd := &cobra.Command{
+		Use:   "install",
+		Short: "Write the workspace tools' keys to .envrc and git-ignore it",
+		Long: `Write export lines for only the keys the workspace's tools need (from
+.palm.toml, or --tool) into .envrc, so direnv loads them when you enter the
+project. palm manages a marked block in .envrc and leaves the rest of the
+file alone; running install again refreshes it. .envrc is added to
+.gitignore and made readable only by you.
+
+  palm keys direnv install
+  palm keys direnv install --tool aider --tool claude-code`,
+		Run: func(cmd *cobra.Command, args []string) {
+			tools, ok := scopedTools(tools)
+			if !ok {
+				ui.Bad.Println("  No tools to scope keys to — pass --tool, or pin tools with `palm workspace add`")
+				os.Exit(1)
+			}
+
+			dir, _ := os.Getwd()
+			if _, path := loadProject(); path != "" {
+				dir = filepath.Dir(path)
+			}
+
+			lines, missing := scopedKeyExports(vault.New(), loadRegistry(), tools)
+			block := envrcBegin + "\n" + scopedEnvHeader(tools, "palm keys direnv install") + "\n" + strings.Join(append(lines, envrcEnd), "\n") + "\n"
+
+			envrc := filepath.Join(dir, ".envrc")
+			existing, err := os.ReadFile(envrc)
+			if err != nil && !os.IsNotExist(err) {
+				ui.Bad.Printf("  Failed to read .envrc: %v\n", err)
+				os.Exit(1)
+			}
+			if err := os.WriteFile(envrc, []byte(replaceManagedBlock(string(existing), block)), 0o600); err != nil {
+				ui.Bad.Printf("  Failed to write .envrc: %v\n", err)
+				os.Exit(1)
+			}
+			_ = os.Chmod(envrc, 0o600)
+
+			ui.Good.Printf("  %s Wrote %d key(s) for %s to %s\n", ui.StatusIcon(true), len(lines), strings.Join(tools, ", "), envrc)
+			added, err := ensureGitignored(dir, ".envrc")
+			switch {
+			case err != nil:
+				ui.Warn.Printf("  %s Couldn't update .gitignore: %v — don't commit .envrc\n", ui.WarnIcon(), err)
+			case added:
+				ui.Good.Printf("  %s Added .envrc to .gitignore\n", ui.StatusIcon(true))
+			}
+			if len(missing) > 0 {
+				ui.Warn.Printf("  %s Not in the vault: %s — add with `palm keys add`\n", ui.WarnIcon(), strings.Join(missing, ", "))
+			}
+			fmt.Println()
+			fmt.Println("  Run `direnv allow` to load it")
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&tools, "tool", nil, "Tools whose keys to include (default: the workspace's tools)")
+	return cmd
+}
+
+// replaceManagedBlock swaps palm's marked block in content for block, or
+// appends block when there is none.
+func replaceManagedBlock(content, block string) string {
+	start := strings.Index(content, envrcBegin)
+	end := strings.Index(content, envrcEnd)
+	if start >= 0 && end > start {
+		end += len(envrcEnd)
+		if end < len(content) && content[end] == '\n' {
+			end++
+		}
+		return content[:start] + block + content[end:]
+	}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content + block
+}
+
+// ensureGitignored adds entry to dir/.gitignore unless a line already
+// matches it. It reports whether it added it.
+func ensureGitignored(dir, entry string) (bool, error) {
+	path := filepath.Join(dir, ".gitignore")
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == entry || line == "/"+entry {
+			return false, nil
+		}
+	}
+	content := string(data)
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return true, os.WriteFile(path, []byte(content+entry+"\n"), 0o644)
+}
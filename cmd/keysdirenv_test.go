@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReplaceManagedBlock(t *testing.T) {
+	block := envrcBegin + "\nexport A=\"1\"\n" + envrcEnd + "\n"
+
+	if got := replaceManagedBlock("", block); got != block {
+		t.Errorf("empty file: %q", got)
+	}
+
+	user := "use nix\nexport FOO=bar"
+	got := replaceManagedBlock(user, block)
+	if got != user+"\n"+block {
+		t.Errorf("append: %q", got)
+	}
+
+	updated := envrcBegin + "\nexport A=\"2\"\n" + envrcEnd + "\n"
+	again := replaceManagedBlock(got+"layout python\n", updated)
+	if strings.Count(again, envrcBegin) != 1 || !strings.Contains(again, `A="2"`) || strings.Contains(again, `A="1"`) {
+		t.Errorf("replace: %q", again)
+	}
+	if !strings.HasPrefix(again, "use nix\n") || !strings.HasSuffix(again, "layout python\n") {
+		t.Errorf("replace must keep the user's lines: %q", again)
+	}
+}
+
+func TestEnsureGitignored(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".gitignore")
+	os.WriteFile(path, []byte("node_modules"), 0o644)
+
+	added, err := ensureGitignored(dir, ".envrc")
+	if err != nil || !added {
+		t.Fatalf("ensureGitignored = %v, %v", added, err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "node_modules\n.envrc\n" {
+		t.Errorf(".gitignore = %q", data)
+	}
+
+	if added, _ := ensureGitignored(dir, ".envrc"); added {
+		t.Error("an existing entry should not be added twice")
+	}
+	os.WriteFile(path, []byte("/.envrc\n"), 0o644)
+	if added, _ := ensureGitignored(dir, ".envrc"); added {
+		t.Error("a rooted entry already covers it")
+	}
+}
@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/msalah0e/palm/internal/models"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/msalah0e/palm/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+// keyDiagnosis is what palm keys doctor found for one key.
+type keyDiagnosis struct {
+	Key      string   `json:"key"`
+	Source   string   `json:"source"`         // where the key is set: vault, env, or both
+	Format   string   `json:"format"`         // "ok", or what is wrong with the value
+	Live     string   `json:"live,omitempty"` // valid, rejected or unreachable; empty when not checked
+	Problems []string `json:"problems,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+
+	value      string // the value tools get
+	vaultValue string // the vault's value when it differs from value
+}
+
+// diagnoseKey checks what the vault and the environment hold for key,
+// without network access. stored is the vault entry as stored, which is a
+// reference for referenced keys, secret its resolved value and env the
+// environment's value; empty strings mean unset.
+func diagnoseKey(key, stored, secret string, resolveErr error, env string) keyDiagnosis {
+	d := keyDiagnosis{Key: key, Format: "ok"}
+
+	var sources []string
+	if stored != "" {
+		src := "vault"
+		if ref := vault.RefSource(stored); ref != "" {
+			src = ref + " ref"
+		}
+		sources = append(sources, src)
+	}
+	if env != "" {
+		sources = append(sources, "env")
+	}
+	d.Source = strings.Join(sources, " + ")
+
+	if resolveErr != nil {
+		d.Problems = append(d.Problems, fmt.Sprintf("can't read the vault value: %v", resolveErr))
+	}
+
+	// Tools get the environment's value when both are set
+	d.value = secret
+	if env != "" {
+		d.value = env
+		if secret != "" && secret != env {
+			d.vaultValue = secret
+			d.Problems = append(d.Problems, "set in the environment and the vault with different values — tools get the environment's")
+		}
+	}
+	if d.value == "" {
+		d.Format = "-"
+		return d
+	}
+
+	if err := vault.CheckFormat(key, d.value); err != nil {
+		d.Format = err.Error()
+		d.Problems = append(d.Problems, err.Error())
+	} else if d.vaultValue != "" {
+		if err := vault.CheckFormat(key, d.vaultValue); err != nil {
+			d.Warnings = append(d.Warnings, "the vault's value: "+err.Error())
+		}
+	}
+	return d
+}
+
+// verifyDiagnosis checks the key with its provider, when palm knows one.
+func verifyDiagnosis(d *keyDiagnosis) {
+	p := models.ProviderForKey(d.Key)
+	if p == nil || d.value == "" {
+		return
+	}
+	d.Live = liveStatus(models.VerifyKey(p, d.value))
+	switch d.Live {
+	case "rejected":
+		d.Problems = append(d.Problems, fmt.Sprintf("%s refused it — it was likely revoked or has expired; replace it with: palm keys add %s", p.Name, d.Key))
+	case "unreachable":
+		d.Warnings = append(d.Warnings, fmt.Sprintf("couldn't reach %s to verify it — try palm doctor --network", p.Name))
+	}
+	if d.vaultValue != "" && d.Live == "rejected" && liveStatus(models.VerifyKey(p, d.vaultValue)) == "valid" {
+		d.Problems = append(d.Problems, fmt.Sprintf("the vault's value works — unset %s in your shell", d.Key))
+	}
+}
+
+func liveStatus(err error) string {
+	var rejected *models.KeyRejectedError
+	switch {
+	case err == nil:
+		return "valid"
+	case errors.As(err, &rejected):
+		return "rejected"
+	}
+	return "unreachable"
+}
+
+func keysDoctorCmd() *cobra.Command {
+	var live bool
+
+	cmd := &cobra.Command{
+		Use:   "doctor [KEY_NAME...]",
+		Short: "Check stored keys for bad formats, env conflicts and revoked keys",
+		Long: `Check each vault key, and each provider key set in the environment, for
+the problems behind vague 401s from tools:
+
+  - values with stray whitespace, too short, or without the provider's prefix
+  - keys set in both the environment and the vault with different values
+    (tools get the environment's)
+  - vault references that can't be resolved
+
+With --live, keys of known providers are also sent to the provider's free
+model-listing endpoint, which reports revoked or expired keys.
+
+Exits with status 1 when a problem is found.`,
+		ValidArgsFunction: keyCompletionFunc,
+		Run: func(cmd *cobra.Command, args []string) {
+			v := vault.New()
+			names, err := v.List()
+			if err != nil {
+				ui.Bad.Printf("  Failed to list keys: %v\n", err)
+				os.Exit(1)
+			}
+			inVault := make(map[string]bool)
+			for _, n := range names {
+				inVault[n] = true
+			}
+			for _, p := range models.BuiltinProviders() {
+				if p.EnvKey != "" && !inVault[p.EnvKey] && os.Getenv(p.EnvKey) != "" {
+					names = append(names, p.EnvKey)
+				}
+			}
+			if len(args) > 0 {
+				names = args
+			}
+			sort.Strings(names)
+
+			var diags []keyDiagnosis
+			for _, name := range names {
+				stored, _ := vault.Stored(v, name)
+				var secret string
+				var resolveErr error
+				if stored != "" {
+					secret, resolveErr = v.Get(name)
+				}
+				d := diagnoseKey(name, stored, secret, resolveErr, os.Getenv(name))
+				if d.Source == "" {
+					d.Problems = append(d.Problems, "not set in the vault or the environment")
+				}
+				diags = append(diags, d)
+			}
+
+			if live && offlineMode {
+				ui.Warn.Fprintf(os.Stderr, "  %s --offline set, skipping live checks\n", ui.WarnIcon())
+				live = false
+			}
+			if live {
+				var wg sync.WaitGroup
+				for i := range diags {
+					wg.Add(1)
+					go func(d *keyDiagnosis) {
+						defer wg.Done()
+						verifyDiagnosis(d)
+					}(&diags[i])
+				}
+				wg.Wait()
+			}
+
+			problems := 0
+			for _, d := range diags {
+				if len(d.Problems) > 0 {
+					problems++
+				}
+			}
+
+			render(diags, func() {
+				ui.Banner("keys doctor")
+				if len(diags) == 0 {
+					fmt.Println("  No API keys stored.")
+					return
+				}
+
+				var rows [][]string
+				var notes []string
+				for _, d := range diags {
+					status := ui.StatusIcon(true) + " ok"
+					switch {
+					case len(d.Problems) > 0:
+						status = ui.StatusIcon(false) + " problem"
+					case len(d.Warnings) > 0:
+						status = ui.WarnIcon() + " warning"
+					}
+					format := d.Format
+					if format != "ok" && format != "-" {
+						format = ui.Bad.Sprint("bad")
+					}
+					rows = append(rows, []string{d.Key, orDash(d.Source), format, keyLiveCell(d.Live), status})
+					for _, p := range d.Problems {
+						notes = append(notes, fmt.Sprintf("%s %s: %s", ui.StatusIcon(false), ui.Brand.Sprint(d.Key), p))
+					}
+					for _, w := range d.Warnings {
+						notes = append(notes, fmt.Sprintf("%s %s: %s", ui.WarnIcon(), ui.Brand.Sprint(d.Key), w))
+					}
+				}
+				ui.Table([]string{"Key", "Source", "Format", "Live", "Status"}, rows)
+
+				if len(notes) > 0 {
+					fmt.Println()
+					for _, n := range notes {
+						fmt.Printf("  %s\n", n)
+					}
+				}
+				fmt.Printf("\n  %d key(s) checked", len(diags))
+				if problems > 0 {
+					fmt.Printf(" · %d with problems", problems)
+				}
+				fmt.Println()
+				if !live {
+					ui.Subtle.Println("  Add --live to verify keys with their providers (free, no tokens used)")
+				}
+			})
+			if problems > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&live, "live", false, "Verify keys with their providers' APIs")
+	return cmd
+}
+
+func keyLiveCell(status string) string {
+	switch status {
+	case "valid":
+		return ui.Good.Sprint("valid")
+	case "rejected":
+		return ui.Bad.Sprint("rejected")
+	case "unreachable":
+		return ui.Warn.Sprint("unreachable")
+	}
+	return ui.Subtle.Sprint("-")
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/msalah0e/palm/internal/models"
+)
+
+func TestDiagnoseKey(t *testing.T) {
+	good := "sk-ant-REDACTED"
+
+	d := diagnoseKey("ANTHROPIC_API_KEY", good, good, nil, "")
+	if d.Source != "vault" || d.Format != "ok" || len(d.Problems) != 0 {
+		t.Errorf("healthy key: %+v", d)
+	}
+
+	d = diagnoseKey("ANTHROPIC_API_KEY", good, good, nil, "sk-ant-REDACTED")
+	if d.Source != "vault + env" || len(d.Problems) != 1 || !strings.Contains(d.Problems[0], "different values") {
+		t.Errorf("env conflict: %+v", d)
+	}
+	if d.value != "sk-ant-REDACTED" || d.vaultValue != good {
+		t.Errorf("tools should get the env value: %+v", d)
+	}
+
+	d = diagnoseKey("ANTHROPIC_API_KEY", good, good, nil, good)
+	if len(d.Problems) != 0 {
+		t.Errorf("the same value in both places is fine: %+v", d)
+	}
+
+	d = diagnoseKey("OPENAI_API_KEY", "", "", nil, "gsk_abcdefghijklmnopqrstu")
+	if d.Source != "env" || d.Format == "ok" {
+		t.Errorf("wrong prefix not caught: %+v", d)
+	}
+
+	d = diagnoseKey("OPENAI_API_KEY", "op://vault/openai/key", "", fmt.Errorf("op not installed"), "")
+	if d.Source != "1Password ref" || d.Format != "-" || len(d.Problems) != 1 {
+		t.Errorf("unresolved reference: %+v", d)
+	}
+}
+
+func TestLiveStatus(t *testing.T) {
+	if got := liveStatus(nil); got != "valid" {
+		t.Errorf("nil error = %s", got)
+	}
+	rejected := fmt.Errorf("verify: %w", &models.KeyRejectedError{Provider: "OpenAI", Status: "401 Unauthorized"})
+	if got := liveStatus(rejected); got != "rejected" {
+		t.Errorf("rejected key = %s", got)
+	}
+	if got := liveStatus(errors.New("could not reach OpenAI")); got != "unreachable" {
+		t.Errorf("network error = %s", got)
+	}
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/msalah0e/palm/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+// keyProfile is the --profile flag: the named set of vault keys to use.
+var keyProfile string
+
+// resolveKeyProfile picks the active key profile: --profile, then
+// PALM_PROFILE, then the workspace's profile setting.
+func resolveKeyProfile(flag string, proj *palmProject) string {
+	if flag != "" {
+		return flag
+	}
+	if env := os.Getenv("PALM_PROFILE"); env != "" {
+		return env
+	}
+	if proj != nil {
+		return proj.Workspace.Profile
+	}
+	return ""
+}
+
+// applyKeyProfile makes every vault opened from here on use the active
+// profile, so run, squad, compose and the proxy all inject the same keys.
+func applyKeyProfile() {
+	proj, _ := loadProject()
+	if err := vault.SetProfile(resolveKeyProfile(keyProfile, proj)); err != nil {
+		ui.Bad.Printf("palm: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// profileLabel names a profile for display.
+func profileLabel(profile string) string {
+	if profile == "" {
+		return "default"
+	}
+	return profile
+}
+
+func keysProfilesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "profiles",
+		Short: "List key profiles and which one is active",
+		Long: `List the key profiles in the vault.
+
+A profile is a named set of keys, so work and personal credentials never mix:
+
+  palm keys add OPENAI_API_KEY --profile work
+  palm run aider --profile work
+
+The active profile comes from --profile, then PALM_PROFILE, then the
+workspace's .palm.toml:
+
+  [workspace]
+  profile = "work"
+
+Keys without a profile make up the default profile. A tool run with a
+profile only gets that profile's keys.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			v := vault.New()
+			profiles, err := vault.Profiles(v)
+			if err != nil {
+				ui.Bad.Printf("  Failed to list profiles: %v\n", err)
+				os.Exit(1)
+			}
+			profiles = append([]string{""}, profiles...)
+
+			type profileInfo struct {
+				Name   string `json:"name"`
+				Keys   int    `json:"keys"`
+				Active bool   `json:"active"`
+			}
+			var infos []profileInfo
+			for _, p := range profiles {
+				keys, err := vault.WithProfile(v, p).List()
+				if err != nil {
+					ui.Bad.Printf("  Failed to list keys: %v\n", err)
+					os.Exit(1)
+				}
+				infos = append(infos, profileInfo{Name: profileLabel(p), Keys: len(keys), Active: p == vault.Profile()})
+			}
+			if active := vault.Profile(); !containsStr(profiles, active) {
+				infos = append(infos, profileInfo{Name: active, Active: true})
+			}
+
+			render(infos, func() {
+				ui.Banner("key profiles")
+				var rows [][]string
+				for _, info := range infos {
+					active := ""
+					if info.Active {
+						active = ui.StatusIcon(true)
+					}
+					rows = append(rows, []string{info.Name, fmt.Sprintf("%d", info.Keys), active})
+				}
+				ui.Table([]string{"Profile", "Keys", "Active"}, rows)
+			})
+		},
+	}
+}
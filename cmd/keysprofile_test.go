@@ -0,0 +1,23 @@
+package cmd
+
+import "testing"
+
+func TestResolveKeyProfile(t *testing.T) {
+	proj := &palmProject{Workspace: WorkspaceConfig{Profile: "work"}}
+
+	t.Setenv("PALM_PROFILE", "")
+	if got := resolveKeyProfile("", nil); got != "" {
+		t.Errorf("no settings: %q", got)
+	}
+	if got := resolveKeyProfile("", proj); got != "work" {
+		t.Errorf("workspace: %q", got)
+	}
+
+	t.Setenv("PALM_PROFILE", "personal")
+	if got := resolveKeyProfile("", proj); got != "personal" {
+		t.Errorf("PALM_PROFILE should win over the workspace: %q", got)
+	}
+	if got := resolveKeyProfile("client", proj); got != "client" {
+		t.Errorf("--profile should win: %q", got)
+	}
+}
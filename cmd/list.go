@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/msalah0e/palm/internal/registry"
 	"github.com/msalah0e/palm/internal/ui"
@@ -9,14 +10,58 @@ import (
 )
 
 func listCmd() *cobra.Command {
-	return &cobra.Command{
+	var (
+		category string
+		tags     []string
+		stack    string
+		all      bool
+	)
+
+	cmd := &cobra.Command{
 		Use:     "list",
 		Aliases: []string{"ls"},
 		Short:   "List installed AI tools with status",
+		Long: `List installed AI tools with their version and key status.
+
+--category, --tag and --stack narrow the list down and include tools that
+aren't installed, so the registry can be browsed by facet; --all lists the
+whole registry.
+
+  palm list --tag local --category coding`,
 		Run: func(cmd *cobra.Command, args []string) {
 			reg := loadRegistry()
+			if all || category != "" || len(tags) > 0 || stack != "" {
+				listFaceted(reg, category, tags, stack)
+				return
+			}
 			detected := registry.DetectInstalled(reg)
 
+			if jsonOutput {
+				type listedTool struct {
+					Name        string   `json:"name"`
+					Version     string   `json:"version,omitempty"`
+					Category    string   `json:"category"`
+					Path        string   `json:"path,omitempty"`
+					KeysSet     []string `json:"keys_set,omitempty"`
+					KeysMissing []string `json:"keys_missing,omitempty"`
+					Ready       bool     `json:"ready"`
+				}
+				out := []listedTool{}
+				for _, dt := range detected {
+					out = append(out, listedTool{
+						Name:        dt.Tool.Name,
+						Version:     dt.Version,
+						Category:    dt.Tool.Category,
+						Path:        dt.Path,
+						KeysSet:     dt.KeysSet,
+						KeysMissing: dt.KeysMissing,
+						Ready:       !dt.Tool.NeedsAPIKey() || len(dt.KeysMissing) == 0,
+					})
+				}
+				printJSON(out)
+				return
+			}
+
 			ui.Banner("installed tools")
 
 			if len(detected) == 0 {
@@ -67,4 +112,122 @@ func listCmd() *cobra.Command {
 			fmt.Println(msg)
 		},
 	}
+
+	cmd.Flags().StringVar(&category, "category", "", "Only tools in this category")
+	cmd.Flags().StringSliceVar(&tags, "tag", nil, "Only tools with this tag (repeat to require several)")
+	cmd.Flags().StringVar(&stack, "stack", "", "Only the tools of this stack")
+	cmd.Flags().BoolVar(&all, "all", false, "List every registry tool, installed or not")
+	_ = cmd.RegisterFlagCompletionFunc("category", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return loadRegistry().Categories(), cobra.ShellCompDirectiveNoFileComp
+	})
+	_ = cmd.RegisterFlagCompletionFunc("tag", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		_, facets := registry.Facets(loadRegistry().All())
+		var names []string
+		for _, f := range facets {
+			names = append(names, f.Name)
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	})
+	_ = cmd.RegisterFlagCompletionFunc("stack", stackCompletionFunc)
+	return cmd
+}
+
+// listFaceted lists the registry tools matching the facets, installed or
+// not, and the categories and tags to narrow them further.
+func listFaceted(reg *registry.Registry, category string, tags []string, stack string) {
+	tools := reg.Filter(category, tags)
+	if stack != "" {
+		stacks := loadStacks()
+		s := findStack(stacks, stack)
+		if s == nil {
+			unknownStack(stacks, stack)
+		}
+		var inStack []registry.Tool
+		for _, t := range tools {
+			if containsStr(s.Tools, t.Name) {
+				inStack = append(inStack, t)
+			}
+		}
+		tools = inStack
+	}
+
+	type facetedTool struct {
+		Name      string   `json:"name"`
+		Category  string   `json:"category"`
+		Tags      []string `json:"tags"`
+		Installed bool     `json:"installed"`
+		Version   string   `json:"version,omitempty"`
+	}
+	out := struct {
+		Tools      []facetedTool    `json:"tools"`
+		Categories []registry.Facet `json:"categories"`
+		Tags       []registry.Facet `json:"tags"`
+	}{Tools: []facetedTool{}}
+	out.Categories, out.Tags = registry.Facets(tools)
+	for _, dt := range registry.DetectMany(tools) {
+		t := dt.Tool
+		out.Tools = append(out.Tools, facetedTool{t.Name, t.Category, t.Tags, dt.Installed, dt.Version})
+	}
+
+	render(out, func() {
+		ui.Banner("tools")
+		if len(out.Tools) == 0 {
+			fmt.Println("  No tools match.")
+			fmt.Println("  Run `palm list --all` to see every facet")
+			return
+		}
+
+		var rows [][]string
+		installed := 0
+		for _, t := range out.Tools {
+			status := ui.Subtle.Sprint("-")
+			if t.Installed {
+				status = ui.StatusIcon(true) + " " + orDash(t.Version)
+				installed++
+			}
+			rows = append(rows, []string{t.Name, t.Category, strings.Join(t.Tags, ", "), status})
+		}
+		ui.Table([]string{"Tool", "Category", "Tags", "Installed"}, rows)
+
+		fmt.Printf("\n  %d tools · %d installed\n", len(out.Tools), installed)
+		if len(out.Categories) > 1 {
+			fmt.Printf("  Categories: %s\n", ui.Subtle.Sprint(formatFacets(out.Categories, 8)))
+		}
+		if refine := unusedFacets(out.Tags, tags, len(out.Tools)); len(refine) > 0 {
+			fmt.Printf("  Tags:       %s\n", ui.Subtle.Sprint(formatFacets(refine, 10)))
+		}
+	})
+}
+
+// unusedFacets drops the tags already filtered on, and those every listed
+// tool has, which wouldn't narrow anything.
+func unusedFacets(facets []registry.Facet, used []string, total int) []registry.Facet {
+	var out []registry.Facet
+	for _, f := range facets {
+		if f.Count == total || containsStr(used, f.Name) {
+			continue
+		}
+		skip := false
+		for _, u := range used {
+			if registry.NormalizeTag(u) == f.Name {
+				skip = true
+			}
+		}
+		if !skip {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func formatFacets(facets []registry.Facet, max int) string {
+	var parts []string
+	for i, f := range facets {
+		if i == max {
+			parts = append(parts, fmt.Sprintf("+%d more", len(facets)-max))
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%s (%d)", f.Name, f.Count))
+	}
+	return strings.Join(parts, " · ")
 }
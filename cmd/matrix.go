@@ -19,9 +19,8 @@ import (
 
 func matrixCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:     "matrix",
-		Aliases: []string{"dashboard", "dash"},
-		Short:   "Terminal dashboard — tools, keys, sessions, budget at a glance",
+		Use:   "matrix",
+		Short: "Control plane overview — tools, keys, sessions, budget at a glance",
 		Run: func(cmd *cobra.Command, args []string) {
 			reg := loadRegistry()
 			v := vault.New()
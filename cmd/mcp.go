@@ -5,6 +5,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/msalah0e/palm/internal/config"
 	"github.com/msalah0e/palm/internal/mcp"
 	"github.com/msalah0e/palm/internal/ui"
 	"github.com/spf13/cobra"
@@ -32,7 +33,7 @@ func mcpCmd() *cobra.Command {
 				fmt.Println("  No MCP servers configured")
 			}
 
-			fmt.Printf("\n  %s  %d servers in registry\n", ui.Brand.Sprint("Registry"), len(mcp.Registry))
+			fmt.Printf("\n  %s  %d servers in registry\n", ui.Brand.Sprint("Registry"), len(mcp.Servers()))
 			fmt.Println()
 			fmt.Println("  Run `palm mcp search <query>` to find servers")
 			fmt.Println("  Run `palm mcp install <name>` to install one")
@@ -46,6 +47,8 @@ func mcpCmd() *cobra.Command {
 		mcpRemoveCmd(),
 		mcpSyncCmd(),
 		mcpInfoCmd(),
+		mcpDoctorCmd(),
+		mcpRefreshCmd(),
 	)
 
 	return cmd
@@ -67,7 +70,7 @@ func mcpListCmd() *cobra.Command {
 			}
 
 			var rows [][]string
-			for _, s := range mcp.Registry {
+			for _, s := range mcp.Servers() {
 				if category != "" && !strings.EqualFold(s.Category, category) {
 					continue
 				}
@@ -199,6 +202,49 @@ func mcpInfoCmd() *cobra.Command {
 			fmt.Printf("  %s  %s\n", ui.Brand.Sprint("Command"), s.Command+" "+strings.Join(s.Args, " "))
 			fmt.Printf("  %s  %s\n", ui.Brand.Sprint("Install"), s.Install)
 			fmt.Printf("  %s  %s\n", ui.Brand.Sprint("Backend"), s.Backend)
+			fmt.Printf("  %s  %s\n", ui.Brand.Sprint("Source"), s.Source)
 		},
 	}
 }
+
+func mcpRefreshCmd() *cobra.Command {
+	var url string
+
+	cmd := &cobra.Command{
+		Use:   "refresh",
+		Short: "Update the MCP server registry from its published source",
+		Long: `Fetch the latest MCP server registry, so new servers and corrected package
+names don't need a palm release. The source is [mcp] registry_url in config,
+or the registry published with palm.
+
+To add your own servers or correct an entry, put them in
+~/.config/palm/mcp-servers.toml; entries there replace same-named ones:
+
+  [[servers]]
+  name = "linear"
+  description = "Linear issues"
+  command = "npx"
+  args = ["-y", "mcp-remote", "https://mcp.linear.app/sse"]
+  category = "Dev"`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if url == "" {
+				url = config.Load().MCP.RegistryURL
+			}
+			if url == "" {
+				url = mcp.DefaultRegistryURL
+			}
+
+			before := len(mcp.Servers())
+			n, err := mcp.Refresh(url)
+			if err != nil {
+				ui.Bad.Printf("  Refresh failed: %v\n", err)
+				os.Exit(1)
+			}
+			ui.Good.Printf("  %s Fetched %d servers from %s\n", ui.StatusIcon(true), n, url)
+			fmt.Printf("  Registry now has %d servers (was %d)\n", len(mcp.Servers()), before)
+		},
+	}
+
+	cmd.Flags().StringVar(&url, "url", "", "Registry TOML to fetch (default: [mcp] registry_url)")
+	return cmd
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/msalah0e/palm/internal/mcp"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+)
+
+// mcpCheck is one configured server and how it answered the handshake.
+type mcpCheck struct {
+	mcp.ProbeResult
+	Name    string   `json:"name"`
+	Command string   `json:"command"`
+	UsedBy  []string `json:"used_by"`
+	spec    mcp.ServerSpec
+}
+
+func mcpDoctorCmd() *cobra.Command {
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "doctor [server...]",
+		Short: "Start each configured MCP server and check it answers",
+		Long: `Start every MCP server configured in Claude Code, Cursor and VS Code, run
+the MCP initialize handshake over stdio, and report the server's version,
+its tools and resources, and how long it took to start. Servers that fail
+to start or answer are flagged with the error they printed.
+
+  palm mcp doctor
+  palm mcp doctor github filesystem --timeout 60s`,
+		Run: func(cmd *cobra.Command, args []string) {
+			checks := configuredMCPServers(args)
+			if len(checks) == 0 {
+				if jsonOutput {
+					printJSON([]mcpCheck{})
+					return
+				}
+				fmt.Println("  No MCP servers configured")
+				return
+			}
+			if !jsonOutput {
+				ui.Banner("MCP doctor")
+				fmt.Printf("  Starting %d server(s)...\n\n", len(checks))
+			}
+
+			var g errgroup.Group
+			g.SetLimit(4)
+			for i := range checks {
+				g.Go(func() error {
+					checks[i].ProbeResult = mcp.Probe(checks[i].spec, timeout)
+					return nil
+				})
+			}
+			_ = g.Wait()
+
+			failed := 0
+			for _, c := range checks {
+				if !c.OK {
+					failed++
+				}
+			}
+			render(checks, func() { printMCPDoctor(checks, failed) })
+			if failed > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "How long to wait for each server (first runs of npx/uvx download packages)")
+	return cmd
+}
+
+// configuredMCPServers collects the servers configured across AI tools,
+// once per distinct launch command, optionally only the named ones.
+func configuredMCPServers(only []string) []*mcpCheck {
+	var checks []*mcpCheck
+	byKey := make(map[string]*mcpCheck)
+	for _, tc := range mcp.ToolConfigs() {
+		specs, err := mcp.ConfiguredSpecs(tc)
+		if err != nil {
+			continue
+		}
+		for _, s := range specs {
+			if len(only) > 0 && !containsString(only, s.Name) {
+				continue
+			}
+			command := strings.TrimSpace(s.Command + " " + strings.Join(s.Args, " "))
+			if command == "" {
+				command = s.URL
+			}
+			key := s.Name + "\x00" + command
+			if c, ok := byKey[key]; ok {
+				c.UsedBy = append(c.UsedBy, tc.Name)
+				continue
+			}
+			c := &mcpCheck{Name: s.Name, Command: command, UsedBy: []string{tc.Name}, spec: s}
+			byKey[key] = c
+			checks = append(checks, c)
+		}
+	}
+	return checks
+}
+
+func printMCPDoctor(checks []*mcpCheck, failed int) {
+	var rows [][]string
+	for _, c := range checks {
+		version, startup := "-", "-"
+		if c.OK {
+			version = strings.TrimSpace(c.Server + " " + c.Version)
+			startup = fmt.Sprintf("%.1fs", c.Startup.Seconds())
+			if c.Startup < time.Second {
+				startup = fmt.Sprintf("%dms", c.Startup.Milliseconds())
+			}
+		}
+		rows = append(rows, []string{
+			ui.StatusIcon(c.OK),
+			c.Name,
+			orDash(version),
+			fmt.Sprint(len(c.Tools)),
+			fmt.Sprint(len(c.Resources)),
+			startup,
+			strings.Join(c.UsedBy, ", "),
+		})
+	}
+	ui.Table([]string{"", "Server", "Version", "Tools", "Resources", "Startup", "Used by"}, rows)
+
+	if failed > 0 {
+		fmt.Println()
+		for _, c := range checks {
+			if c.OK {
+				continue
+			}
+			ui.Bad.Printf("  %s %s\n", ui.StatusIcon(false), c.Name)
+			fmt.Printf("    %s\n", ui.Subtle.Sprint(c.Command))
+			fmt.Printf("    %s\n", truncate(c.Error, 200))
+		}
+	}
+	fmt.Println()
+	fmt.Printf("  %d of %d servers healthy\n", len(checks)-failed, len(checks))
+}
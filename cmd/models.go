@@ -1,12 +1,16 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 
+	"github.com/msalah0e/palm/internal/gpu"
 	"github.com/msalah0e/palm/internal/models"
+	"github.com/msalah0e/palm/internal/proxy"
 	"github.com/msalah0e/palm/internal/ui"
 	"github.com/msalah0e/palm/internal/vault"
 	"github.com/spf13/cobra"
@@ -23,6 +27,9 @@ func modelsCmd() *cobra.Command {
 		modelsPullCmd(),
 		modelsInfoCmd(),
 		modelsProvidersCmd(),
+		modelsRefreshCmd(),
+		modelsDiffCmd(),
+		modelsRecommendCmd(),
 	)
 
 	return cmd
@@ -188,3 +195,200 @@ func getProviderKey(provider string) string {
 	}
 	return "API_KEY"
 }
+
+func modelsRefreshCmd() *cobra.Command {
+	var url, sum string
+
+	cmd := &cobra.Command{
+		Use:   "refresh",
+		Short: "Update model prices and context windows from a maintained catalog",
+		Long: `Download a maintained pricing and context-window catalog and use it in place
+of the prices built into palm, so cost estimates stay accurate.
+
+The catalog is cached in ~/.config/palm/models.json with its SHA-256; pass
+--sha256 to only accept a known download. See what changed with palm models diff.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			before := models.AllModels()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+			defer cancel()
+			c, changed, err := models.Refresh(ctx, nil, url, sum)
+			if err != nil {
+				ui.Bad.Printf("  Refresh failed: %v\n", err)
+				os.Exit(1)
+			}
+
+			ui.Banner("models refresh")
+			if !changed {
+				fmt.Printf("  %s Catalog unchanged since %s\n", ui.StatusIcon(true), c.FetchedAt.Format("2006-01-02 15:04"))
+				return
+			}
+			ui.Good.Printf("  %s Updated %d models from %s\n", ui.StatusIcon(true), len(c.Models), c.Source)
+			fmt.Printf("  %s\n", ui.Subtle.Sprint("sha256 "+c.Checksum))
+			fmt.Println()
+			printModelChanges(models.Diff(before, c.Models))
+		},
+	}
+
+	cmd.Flags().StringVar(&url, "url", models.DefaultCatalogURL, "Catalog to download")
+	cmd.Flags().StringVar(&sum, "sha256", "", "Expected SHA-256 of the download")
+	return cmd
+}
+
+func modelsDiffCmd() *cobra.Command {
+	var builtin bool
+
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Show price and context changes from the last refresh",
+		Long: `Show how the last palm models refresh changed model prices and context
+windows. With --builtin, compare against the values built into palm instead.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cur := models.LoadCatalog()
+			if cur == nil {
+				ui.Bad.Println("  No refreshed catalog yet — run: palm models refresh")
+				os.Exit(1)
+			}
+
+			old, against := models.BuiltinModels(), "built-in values"
+			if prev := models.LoadPreviousCatalog(); prev != nil && !builtin {
+				old, against = prev.Models, "refresh of "+prev.FetchedAt.Format("2006-01-02 15:04")
+			}
+			changes := models.Diff(old, cur.Models)
+
+			if jsonOutput {
+				if changes == nil {
+					changes = []models.Change{}
+				}
+				printJSON(changes)
+				return
+			}
+
+			ui.Banner("models diff")
+			fmt.Printf("  %s vs %s\n\n", ui.Brand.Sprint("Refresh of "+cur.FetchedAt.Format("2006-01-02 15:04")), against)
+			printModelChanges(changes)
+		},
+	}
+
+	cmd.Flags().BoolVar(&builtin, "builtin", false, "Compare against the built-in values")
+	return cmd
+}
+
+func printModelChanges(changes []models.Change) {
+	if len(changes) == 0 {
+		fmt.Println("  No price or context changes")
+		return
+	}
+	format := func(field string, v float64) string {
+		if field == "context" {
+			return models.FormatContext(int(v))
+		}
+		return fmt.Sprintf("$%.2f", v)
+	}
+	var rows [][]string
+	for _, c := range changes {
+		pct := "new"
+		if c.Old != 0 {
+			pct = fmt.Sprintf("%+.0f%%", (c.New-c.Old)/c.Old*100)
+		}
+		rows = append(rows, []string{c.Provider + "/" + c.ID, strings.ReplaceAll(c.Field, "_", " "), format(c.Field, c.Old), format(c.Field, c.New), pct})
+	}
+	ui.Table([]string{"Model", "Field", "Old", "New", "Change"}, rows)
+	fmt.Printf("\n  %d change(s) · costs are per 1M tokens\n", len(changes))
+}
+
+func modelsRecommendCmd() *cobra.Command {
+	var task, budget string
+	var localOK bool
+	var vramGB float64
+	var count int
+
+	cmd := &cobra.Command{
+		Use:   "recommend",
+		Short: "Suggest models for a task, budget and your hardware",
+		Long: `Suggest models for a task, weighing quality, price per token and, with
+--local-ok, what your GPU can run. Tasks: ` + strings.Join(models.Tasks, ", ") + `.
+Budgets: free (local only), low, medium, high.`,
+		Example: `  palm models recommend --task coding --budget low --local-ok
+  palm models recommend --task long-context --budget high
+  palm models recommend --task chat --budget free --vram 24`,
+		Run: func(cmd *cobra.Command, args []string) {
+			vram := int(vramGB * 1024)
+			if !cmd.Flags().Changed("vram") && localOK {
+				vram = gpu.MaxVRAMMB(gpu.Detect())
+			}
+			if budget == "free" {
+				localOK = true
+			}
+
+			v := vault.New()
+			recs, err := models.Recommend(models.Request{
+				Task:    task,
+				Budget:  budget,
+				LocalOK: localOK,
+				VRAMMB:  vram,
+				HasKey:  func(provider string) bool { return proxy.KeyAvailable(v, provider) },
+			}, count)
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+
+			if jsonOutput {
+				type rec struct {
+					Provider string   `json:"provider"`
+					Model    string   `json:"model"`
+					Local    bool     `json:"local"`
+					Blended  float64  `json:"blended_cost_per_1m"`
+					Reasons  []string `json:"reasons"`
+				}
+				out := make([]rec, 0, len(recs))
+				for _, r := range recs {
+					out = append(out, rec{r.Model.Provider, r.Model.ID, r.Local, r.Blended, r.Reasons})
+				}
+				printJSON(out)
+				return
+			}
+
+			ui.Banner("model recommendations")
+			hw := "no GPU detected"
+			if vram > 0 {
+				hw = fmt.Sprintf("%.0fGB GPU memory", float64(vram)/1024)
+			}
+			if !localOK {
+				hw = "cloud only"
+			}
+			fmt.Printf("  %s  %s · %s budget · %s\n\n", ui.Brand.Sprint("For"), task, budget, hw)
+
+			if len(recs) == 0 {
+				fmt.Println("  No model fits these constraints.")
+				if !localOK {
+					fmt.Println("  Try a higher --budget or allow local models with --local-ok")
+				}
+				return
+			}
+
+			for i, r := range recs {
+				kind := "cloud"
+				if r.Local {
+					kind = "local"
+				}
+				fmt.Printf("  %d. %s %s\n", i+1, ui.Brand.Sprint(r.Model.Provider+"/"+r.Model.ID), ui.Subtle.Sprintf("(%s, %s)", r.Model.Name, kind))
+				for _, reason := range r.Reasons {
+					fmt.Printf("     - %s\n", reason)
+				}
+				if r.Local {
+					fmt.Printf("     %s\n", ui.Subtle.Sprint("palm models pull "+r.Model.ID))
+				}
+				fmt.Println()
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&task, "task", "coding", "What the model is for ("+strings.Join(models.Tasks, ", ")+")")
+	cmd.Flags().StringVar(&budget, "budget", "medium", "Spending level: free, low, medium, high")
+	cmd.Flags().BoolVar(&localOK, "local-ok", false, "Consider local models that fit your GPU")
+	cmd.Flags().Float64Var(&vramGB, "vram", 0, "GPU memory in GB to plan for (default: detected)")
+	cmd.Flags().IntVarP(&count, "count", "n", 3, "Number of suggestions")
+	return cmd
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/msalah0e/palm/internal/outdated"
+	"github.com/msalah0e/palm/internal/registry"
+	"github.com/msalah0e/palm/internal/state"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+)
+
+// outdatedEntry compares one installed tool with its latest release.
+type outdatedEntry struct {
+	Tool      string `json:"tool"`
+	Installed string `json:"installed"`
+	Latest    string `json:"latest,omitempty"`
+	Backend   string `json:"backend"`
+	Source    string `json:"source,omitempty"`
+	NotesURL  string `json:"notes_url,omitempty"`
+	Outdated  bool   `json:"outdated"`
+	Error     string `json:"error,omitempty"`
+
+	detected registry.DetectedTool
+}
+
+func outdatedCmd() *cobra.Command {
+	var all, update bool
+
+	cmd := &cobra.Command{
+		Use:   "outdated",
+		Short: "Show installed tools with newer releases, and their release notes",
+		Long: `Check each installed tool against the latest release on the backend it was
+installed with: brew, npm, PyPI or crates.io, and GitHub releases for tools
+installed by script, binary or go install. Set GITHUB_TOKEN to avoid
+GitHub's rate limit.
+
+  palm outdated            # tools with updates
+  palm outdated --all      # every installed tool
+  palm outdated --update   # update just the outdated ones`,
+		Run: func(cmd *cobra.Command, args []string) {
+			reg := loadRegistry()
+			detected := registry.DetectInstalled(reg)
+			if !jsonOutput {
+				ui.Banner("outdated")
+				fmt.Printf("  Checking %d installed tools...\n\n", len(detected))
+			}
+			entries := checkOutdated(outdated.New(), detected, state.Load())
+
+			var stale []outdatedEntry
+			for _, e := range entries {
+				if e.Outdated {
+					stale = append(stale, e)
+				}
+			}
+
+			if jsonOutput {
+				if !all {
+					entries = stale
+				}
+				if entries == nil {
+					entries = []outdatedEntry{}
+				}
+				printJSON(entries)
+				return
+			}
+
+			printOutdated(entries, len(stale), all)
+
+			if update && len(stale) > 0 {
+				fmt.Println()
+				var tools []registry.DetectedTool
+				for _, e := range stale {
+					tools = append(tools, e.detected)
+				}
+				updateTools(tools)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "Include tools that are up to date or couldn't be checked")
+	cmd.Flags().BoolVar(&update, "update", false, "Update the outdated tools")
+	return cmd
+}
+
+// checkOutdated looks up the latest release of each detected tool, from the
+// backend palm recorded installing it with, or its preferred one.
+func checkOutdated(c *outdated.Checker, detected []registry.DetectedTool, st *state.State) []outdatedEntry {
+	entries := make([]outdatedEntry, len(detected))
+	var g errgroup.Group
+	g.SetLimit(8)
+	for i, dt := range detected {
+		backend, pkg := dt.Tool.InstallMethod()
+		if rec, ok := st.Installed[dt.Tool.Name]; ok && rec.Backend != "" && rec.Package != "" {
+			backend, pkg = rec.Backend, rec.Package
+		}
+		entries[i] = outdatedEntry{Tool: dt.Tool.Name, Installed: dt.Version, Backend: backend, detected: dt}
+
+		g.Go(func() error {
+			e := &entries[i]
+			rel, err := c.Latest(backend, pkg, dt.Tool.Repo)
+			if err != nil {
+				e.Error = err.Error()
+				return nil
+			}
+			e.Latest, e.Source, e.NotesURL = rel.Version, rel.Source, rel.NotesURL
+			e.Outdated = e.Installed != "" && registry.CompareVersions(e.Installed, e.Latest) < 0
+			return nil
+		})
+	}
+	_ = g.Wait()
+	return entries
+}
+
+func printOutdated(entries []outdatedEntry, stale int, all bool) {
+	var rows [][]string
+	failed := 0
+	for _, e := range entries {
+		latest := e.Latest
+		switch {
+		case e.Error != "":
+			failed++
+			latest = ui.Subtle.Sprint("unknown")
+		case e.Outdated:
+			latest = ui.Warn.Sprint(e.Latest)
+		default:
+			latest = ui.Good.Sprint(e.Latest)
+		}
+		if !e.Outdated && !all {
+			continue
+		}
+		rows = append(rows, []string{e.Tool, orDash(e.Installed), latest, orDash(e.Source), orDash(e.NotesURL)})
+	}
+
+	if len(rows) > 0 {
+		ui.Table([]string{"Tool", "Installed", "Latest", "From", "Release notes"}, rows)
+		fmt.Println()
+	}
+
+	switch {
+	case len(entries) == 0:
+		fmt.Println("  No tools installed.")
+	case failed == len(entries):
+		ui.Warn.Printf("  %s Couldn't reach any package index — check your network (palm doctor --network)\n", ui.WarnIcon())
+	case stale == 0:
+		ui.Good.Printf("  %s All checked tools are up to date\n", ui.StatusIcon(true))
+	default:
+		fmt.Printf("  %d outdated — run `palm outdated --update` or `palm update <tool>`\n", stale)
+	}
+	if failed > 0 && !all {
+		ui.Subtle.Printf("  %d couldn't be checked (palm outdated --all for details)\n", failed)
+	}
+	if all {
+		for _, e := range entries {
+			if e.Error != "" {
+				ui.Subtle.Printf("  %s: %s\n", e.Tool, e.Error)
+			}
+		}
+	}
+}
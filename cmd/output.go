@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// jsonOutput is set by the global --json flag. Informational commands then
+// print their data as JSON for scripts instead of tables for people.
+var jsonOutput bool
+
+// printJSON writes v to stdout as indented JSON.
+func printJSON(v any) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		fmt.Printf("{\"error\": %q}\n", err.Error())
+		return
+	}
+	fmt.Println(string(data))
+}
+
+// render prints v as JSON under --json, and otherwise calls human to print
+// it for people. Commands build v once and use it for both.
+func render(v any, human func()) {
+	if jsonOutput {
+		printJSON(v)
+		return
+	}
+	human()
+}
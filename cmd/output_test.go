@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestJSONFlagIsGlobal(t *testing.T) {
+	if rootCmd.PersistentFlags().Lookup("json") == nil {
+		t.Fatal("root command has no --json flag")
+	}
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		if c != rootCmd && c.LocalNonPersistentFlags().Lookup("json") != nil {
+			t.Errorf("%s defines its own --json, shadowing the global flag", c.CommandPath())
+		}
+		for _, sub := range c.Commands() {
+			walk(sub)
+		}
+	}
+	walk(rootCmd)
+}
+
+func TestRender(t *testing.T) {
+	capture := func(f func()) string {
+		r, w, _ := os.Pipe()
+		stdout := os.Stdout
+		os.Stdout = w
+		f()
+		w.Close()
+		os.Stdout = stdout
+		out, _ := io.ReadAll(r)
+		return string(out)
+	}
+	defer func() { jsonOutput = false }()
+
+	data := map[string]int{"tools": 3}
+	human := func() { os.Stdout.WriteString("3 tools\n") }
+
+	if out := capture(func() { render(data, human) }); out != "3 tools\n" {
+		t.Errorf("human output = %q", out)
+	}
+	jsonOutput = true
+	if out := capture(func() { render(data, human) }); !strings.Contains(out, `"tools": 3`) {
+		t.Errorf("JSON output = %q", out)
+	}
+}
@@ -14,7 +14,7 @@ import (
 )
 
 func pipeCmd() *cobra.Command {
-	var verbose bool
+	var verbose, allKeys bool
 
 	cmd := &cobra.Command{
 		Use:   "pipe <tool1> | <tool2> [| tool3...]",
@@ -26,8 +26,9 @@ func pipeCmd() *cobra.Command {
     palm pipe "echo 'explain quicksort'" "|" "ollama run llama3.3"
     palm pipe "cat README.md" "|" "ollama run llama3.3 'review this code'"
 
-  Each segment between | runs as a separate command with vault keys injected.
-  The stdout of each command becomes the stdin of the next.`,
+  Each segment between | runs as a separate command with the vault keys its
+  tool needs injected (--all-keys injects every key). The stdout of each
+  command becomes the stdin of the next.`,
 		Args:               cobra.MinimumNArgs(1),
 		DisableFlagParsing: false,
 		Run: func(cmd *cobra.Command, args []string) {
@@ -43,16 +44,13 @@ func pipeCmd() *cobra.Command {
 			v := vault.New()
 			reg := loadRegistry()
 
-			// Build environment with all vault keys
-			env := os.Environ()
-			keys, _ := v.List()
-			for _, key := range keys {
-				if val, err := v.Get(key); err == nil {
-					if os.Getenv(key) == "" {
-						env = append(env, fmt.Sprintf("%s=%s", key, val))
-					}
+			var tools []string
+			for _, segment := range segments {
+				if len(segment) > 0 {
+					tools = append(tools, segment[0])
 				}
 			}
+			env := buildVaultEnv(v, "pipe", reg, tools, allKeys)
 
 			var lastOutput bytes.Buffer
 			totalStart := time.Now()
@@ -116,6 +114,7 @@ func pipeCmd() *cobra.Command {
 	}
 
 	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show each pipeline step")
+	cmd.Flags().BoolVar(&allKeys, "all-keys", false, "Inject every vault key, not just the ones the tools need")
 	return cmd
 }
 
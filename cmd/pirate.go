@@ -110,6 +110,29 @@ func pirateStatusCmd() *cobra.Command {
 		Use:   "status",
 		Short: "Show available AI providers and their status",
 		Run: func(cmd *cobra.Command, args []string) {
+			if jsonOutput {
+				type providerStatus struct {
+					Name      string `json:"name"`
+					Local     bool   `json:"local"`
+					Available bool   `json:"available"`
+					Key       string `json:"key,omitempty"`
+					KeySet    bool   `json:"key_set"`
+					Priority  int    `json:"priority"`
+				}
+				out := []providerStatus{}
+				for _, p := range pirateProviders {
+					out = append(out, providerStatus{
+						Name:      p.Name,
+						Local:     p.EnvKey == "",
+						Available: isProviderAvailable(p),
+						Key:       p.EnvKey,
+						KeySet:    p.EnvKey != "" && os.Getenv(p.EnvKey) != "",
+						Priority:  p.Priority,
+					})
+				}
+				printJSON(out)
+				return
+			}
 			showPirateStatus(false)
 		},
 	}
@@ -222,6 +245,8 @@ func runPirate(prompt string, preferLocal bool, maxRetries int) {
 		available = append(local, cloud...)
 	}
 
+	var segments []pirateSegment
+	current := prompt
 	tried := 0
 	for _, p := range available {
 		if tried >= maxRetries {
@@ -231,39 +256,49 @@ func runPirate(prompt string, preferLocal bool, maxRetries int) {
 
 		fmt.Printf("  Trying %s (attempt %d/%d)...\n", ui.Brand.Sprint(p.Name), tried, maxRetries)
 
-		success, output := tryProvider(p, prompt)
-		if success {
+		attempt := tryProvider(p, current)
+		partial, isQuota := quotaFailure(p, attempt)
+		if attempt.OK && !isQuota {
+			segments = append(segments, pirateSegment{Provider: p.Name, Text: attempt.Output})
 			fmt.Println()
-			fmt.Println(output)
+			fmt.Println(stitchSegments(segments))
 			return
 		}
 
-		// Check if it's a quota error
-		isQuota := false
-		for _, qe := range p.QuotaErr {
-			if strings.Contains(strings.ToLower(output), strings.ToLower(qe)) {
-				isQuota = true
-				break
-			}
-		}
-
 		if isQuota {
-			ui.Warn.Printf("  %s %s hit quota limit — switching...\n", ui.WarnIcon(), p.Name)
+			if partial != "" {
+				// Carry the partial answer over so the next provider continues it
+				segments = append(segments, pirateSegment{Provider: p.Name, Text: partial})
+				current = continuationPrompt(prompt, joinSegments(segments))
+				ui.Warn.Printf("  %s %s\n", ui.WarnIcon(), pirateHandoffNote(p, partial))
+			} else {
+				ui.Warn.Printf("  %s %s hit quota limit — switching...\n", ui.WarnIcon(), p.Name)
+			}
 			time.Sleep(500 * time.Millisecond)
 			continue
 		}
 
 		// Non-quota error — still show output
-		if output != "" {
+		if len(segments) > 0 {
+			fmt.Println()
+			fmt.Println(stitchSegments(segments))
+		}
+		if output := strings.TrimSpace(attempt.Output + "\n" + attempt.Stderr); output != "" {
 			fmt.Println(output)
 		}
 		return
 	}
 
+	if len(segments) > 0 {
+		fmt.Println()
+		fmt.Println(stitchSegments(segments))
+		fmt.Println()
+		ui.Warn.Printf("  %s The response above is incomplete.\n", ui.WarnIcon())
+	}
 	ui.Bad.Println("  All providers exhausted. Try again later or install local models.")
 }
 
-func tryProvider(p pirateProvider, prompt string) (bool, string) {
+func tryProvider(p pirateProvider, prompt string) pirateAttempt {
 	var cmdArgs []string
 
 	switch p.Name {
@@ -282,13 +317,14 @@ func tryProvider(p pirateProvider, prompt string) (bool, string) {
 	}
 
 	c := exec.Command(cmdArgs[0], cmdArgs[1:]...)
-	out, err := c.CombinedOutput()
-	output := strings.TrimSpace(string(out))
-
-	if err != nil {
-		return false, output
+	var stderr strings.Builder
+	c.Stderr = &stderr
+	out, err := c.Output()
+	return pirateAttempt{
+		OK:     err == nil,
+		Output: strings.TrimRight(string(out), " \t\n"), // leading space may continue a word
+		Stderr: strings.TrimSpace(stderr.String()),
 	}
-	return true, output
 }
 
 func isProviderAvailable(p pirateProvider) bool {
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/msalah0e/palm/internal/ui"
+)
+
+// pirateAttempt is one provider's run: what it answered on stdout and what
+// it complained about on stderr.
+type pirateAttempt struct {
+	OK     bool
+	Output string
+	Stderr string
+}
+
+// pirateSegment is the part of a stitched response one provider wrote.
+type pirateSegment struct {
+	Provider string
+	Text     string
+}
+
+func isQuotaError(p pirateProvider, text string) bool {
+	lower := strings.ToLower(text)
+	for _, qe := range p.QuotaErr {
+		if strings.Contains(lower, strings.ToLower(qe)) {
+			return true
+		}
+	}
+	return false
+}
+
+// quotaFailure reports whether an attempt ran out of quota, and the partial
+// response written before it did. CLIs that die mid-stream often print the
+// error as the last line of stdout, so that line is checked and dropped;
+// earlier lines are the response and may legitimately mention "429". A run
+// that exited cleanly only counts when that line also reads as an error.
+func quotaFailure(p pirateProvider, a pirateAttempt) (partial string, quota bool) {
+	out := strings.TrimRight(a.Output, " \t\n")
+	lastLine := out
+	rest := ""
+	if i := strings.LastIndex(out, "\n"); i >= 0 {
+		rest, lastLine = out[:i], out[i+1:]
+	}
+	if isQuotaError(p, lastLine) && (!a.OK || strings.Contains(strings.ToLower(lastLine), "error")) {
+		return strings.TrimRight(rest, " \t\n"), true
+	}
+	if !a.OK && isQuotaError(p, a.Stderr) {
+		return out, true
+	}
+	return out, false
+}
+
+// continuationPrompt asks the next provider to pick up where the last one
+// stopped, without repeating what was already written.
+func continuationPrompt(prompt, partial string) string {
+	return prompt + "\n\n" +
+		"A previous assistant started answering this and was cut off. Its response so far is below, " +
+		"between the markers. Continue exactly where it stops: do not repeat or summarize it, " +
+		"and do not comment on the handover.\n\n" +
+		"<<<PARTIAL RESPONSE>>>\n" + partial + "\n<<<END PARTIAL RESPONSE>>>"
+}
+
+// joinSegments concatenates segment texts into one response. Providers
+// may stop mid-word, so nothing is inserted between them.
+func joinSegments(segments []pirateSegment) string {
+	var b strings.Builder
+	for _, s := range segments {
+		b.WriteString(s.Text)
+	}
+	return b.String()
+}
+
+// stitchSegments renders the response with a marker where each provider
+// took over from the previous one.
+func stitchSegments(segments []pirateSegment) string {
+	var parts []string
+	for i, s := range segments {
+		if i > 0 {
+			parts = append(parts, ui.Subtle.Sprintf("── %s ran out of quota; %s continues ──", segments[i-1].Provider, s.Provider))
+		}
+		parts = append(parts, strings.Trim(s.Text, "\n"))
+	}
+	return strings.Join(parts, "\n")
+}
+
+func pirateHandoffNote(p pirateProvider, partial string) string {
+	return fmt.Sprintf("%s hit quota limit after %d characters — continuing on the next provider...", p.Name, len(partial))
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQuotaFailure(t *testing.T) {
+	claude := pirateProviders[2]
+
+	tests := []struct {
+		name        string
+		attempt     pirateAttempt
+		wantQuota   bool
+		wantPartial string
+	}{
+		{"clean success", pirateAttempt{OK: true, Output: "step 1\nstep 2"}, false, "step 1\nstep 2"},
+		{"answer mentions 429", pirateAttempt{OK: true, Output: "Retry on HTTP 429\nwith backoff"}, false, "Retry on HTTP 429\nwith backoff"},
+		{"died mid-stream, error on stdout", pirateAttempt{OK: false, Output: "step 1\nstep 2\nAPI Error: 429 rate_limit_error"}, true, "step 1\nstep 2"},
+		{"exit 0 but error line last", pirateAttempt{OK: true, Output: "step 1\nError: quota exceeded"}, true, "step 1"},
+		{"quota on stderr", pirateAttempt{OK: false, Output: "step 1", Stderr: "overloaded_error"}, true, "step 1"},
+		{"other failure", pirateAttempt{OK: false, Output: "step 1", Stderr: "segfault"}, false, "step 1"},
+	}
+	for _, tt := range tests {
+		partial, quota := quotaFailure(claude, tt.attempt)
+		if quota != tt.wantQuota || partial != tt.wantPartial {
+			t.Errorf("%s: got (%q, %v), want (%q, %v)", tt.name, partial, quota, tt.wantPartial, tt.wantQuota)
+		}
+	}
+}
+
+func TestContinuationAndStitching(t *testing.T) {
+	segments := []pirateSegment{
+		{Provider: "claude-code", Text: "1. Install\n2. Conf"},
+		{Provider: "aider", Text: "igure\n3. Run"},
+	}
+
+	prompt := continuationPrompt("How do I deploy?", joinSegments(segments[:1]))
+	if !strings.HasPrefix(prompt, "How do I deploy?") || !strings.Contains(prompt, "1. Install\n2. Conf\n<<<END") {
+		t.Errorf("continuation prompt = %q", prompt)
+	}
+
+	if got := joinSegments(segments); got != "1. Install\n2. Configure\n3. Run" {
+		t.Errorf("joinSegments = %q", got)
+	}
+
+	out := stitchSegments(segments)
+	if !strings.Contains(out, "claude-code ran out of quota; aider continues") {
+		t.Errorf("stitched output lacks the switch marker: %q", out)
+	}
+	if strings.Index(out, "2. Conf") > strings.Index(out, "aider continues") || strings.Index(out, "3. Run") < strings.Index(out, "aider continues") {
+		t.Errorf("marker is not at the switch point: %q", out)
+	}
+}
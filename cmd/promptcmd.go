@@ -3,11 +3,16 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/msalah0e/palm/internal/prompt"
 	"github.com/msalah0e/palm/internal/ui"
+	"github.com/msalah0e/palm/internal/vault"
 	"github.com/spf13/cobra"
 )
 
@@ -33,9 +38,9 @@ func promptCmd() *cobra.Command {
 				if len(vars) > 0 {
 					varStr = strings.Join(vars, ", ")
 				}
-				rows = append(rows, []string{p.Name, varStr, truncatePrompt(p.Content, 50)})
+				rows = append(rows, []string{p.Name, fmt.Sprintf("v%d", p.Version), varStr, truncatePrompt(p.Content, 50)})
 			}
-			ui.Table([]string{"Name", "Variables", "Preview"}, rows)
+			ui.Table([]string{"Name", "Version", "Variables", "Preview"}, rows)
 			fmt.Printf("\n  %d prompts\n", len(prompts))
 		},
 	}
@@ -47,50 +52,96 @@ func promptCmd() *cobra.Command {
 		promptDeleteCmd(),
 		promptListCmd(),
 		promptExportCmd(),
+		promptImportCmd(),
+		promptHistoryCmd(),
+		promptRollbackCmd(),
 	)
 
 	return cmd
 }
 
 func promptAddCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "add <name> <content>",
-		Short: "Save a new prompt",
-		Args:  cobra.ExactArgs(2),
+	var file, note string
+
+	cmd := &cobra.Command{
+		Use:   "add <name> [content]",
+		Short: "Save a prompt, or a new version of an existing one",
+		Long: `Save a prompt template. Use {{name}} for variables filled in by palm prompt run.
+Saving a prompt that already exists adds a new version; earlier versions stay
+in its history.`,
+		Example: `  palm prompt add review --file review.md
+  palm prompt add summarize "Summarize this in 3 bullets: {{text}}"
+  git diff | palm prompt add review -f - -m "ask for test gaps"`,
+		Args: cobra.RangeArgs(1, 2),
 		Run: func(cmd *cobra.Command, args []string) {
-			name, content := args[0], args[1]
+			name := args[0]
+			var content string
+			switch {
+			case file == "-":
+				data, err := io.ReadAll(os.Stdin)
+				if err != nil {
+					ui.Bad.Printf("  Failed to read stdin: %v\n", err)
+					os.Exit(1)
+				}
+				content = string(data)
+			case file != "":
+				data, err := os.ReadFile(file)
+				if err != nil {
+					ui.Bad.Printf("  Failed to read %s: %v\n", file, err)
+					os.Exit(1)
+				}
+				content = string(data)
+			case len(args) == 2:
+				content = args[1]
+			default:
+				ui.Bad.Println("  Give the prompt as an argument or with --file")
+				os.Exit(1)
+			}
+			if strings.TrimSpace(content) == "" {
+				ui.Bad.Println("  Prompt is empty")
+				os.Exit(1)
+			}
 
-			if err := prompt.Save(name, content); err != nil {
+			version, changed, err := prompt.Add(name, content, note)
+			if err != nil {
 				ui.Bad.Printf("  Failed to save: %v\n", err)
 				os.Exit(1)
 			}
+			if !changed {
+				fmt.Printf("  %s v%d is unchanged\n", name, version)
+				return
+			}
 
-			ui.Good.Printf("  %s Saved prompt %q\n", ui.StatusIcon(true), name)
-			// Reload to get parsed variables
-			if saved, err := prompt.Load(name); err == nil && len(saved.Variables) > 0 {
-				fmt.Printf("  Variables: %s\n", strings.Join(saved.Variables, ", "))
+			ui.Good.Printf("  %s Saved prompt %q (v%d)\n", ui.StatusIcon(true), name, version)
+			if vars := prompt.Missing(content, nil); len(vars) > 0 {
+				fmt.Printf("  Variables: %s\n", strings.Join(vars, ", "))
 			}
 		},
 	}
+
+	cmd.Flags().StringVarP(&file, "file", "f", "", "Read the prompt from a file (- for stdin)")
+	cmd.Flags().StringVarP(&note, "note", "m", "", "Note describing this version")
+	return cmd
 }
 
 func promptShowCmd() *cobra.Command {
-	var jsonOutput bool
+	var version int
 
 	cmd := &cobra.Command{
 		Use:   "show <name>",
 		Short: "Display a prompt",
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			p, err := prompt.Load(args[0])
+			p, err := loadPromptVersion(args[0], version)
 			if err != nil {
-				ui.Bad.Printf("  Prompt %q not found\n", args[0])
+				ui.Bad.Printf("  %v\n", err)
 				os.Exit(1)
 			}
 
 			if jsonOutput {
 				data, _ := json.MarshalIndent(map[string]interface{}{
 					"name":      p.Name,
+					"version":   p.Version,
 					"content":   p.Content,
 					"variables": p.Variables,
 				}, "", "  ")
@@ -98,40 +149,99 @@ func promptShowCmd() *cobra.Command {
 				return
 			}
 
-			fmt.Printf("  %s\n\n", ui.Brand.Sprint(p.Name))
+			fmt.Printf("  %s %s\n\n", ui.Brand.Sprint(p.Name), ui.Subtle.Sprintf("v%d", p.Version))
 			fmt.Println(p.Content)
 		},
 	}
 
-	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON")
+	cmd.Flags().IntVar(&version, "version", 0, "Show an earlier version")
 	return cmd
 }
 
 func promptRunCmd() *cobra.Command {
-	return &cobra.Command{
+	var vars []string
+	var tool string
+	var version int
+	var allKeys bool
+
+	cmd := &cobra.Command{
 		Use:   "run <name> [var=value ...]",
-		Short: "Render a prompt with variable substitution",
-		Args:  cobra.MinimumNArgs(1),
+		Short: "Render a prompt with its variables, or send it to a tool",
+		Long: `Fill in a prompt's {{variables}} and print it, or send it to a tool with --tool.
+Every variable needs a value, given with --var or as a trailing var=value.`,
+		Example: `  palm prompt run review --var diff="$(git diff)"
+  palm prompt run review --tool ollama --var diff="$(git diff)"
+  palm prompt run summarize text="..." --version 2`,
+		Args: cobra.MinimumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			name := args[0]
-			p, err := prompt.Load(name)
+			p, err := loadPromptVersion(name, version)
 			if err != nil {
-				ui.Bad.Printf("  Prompt %q not found\n", name)
+				ui.Bad.Printf("  %v\n", err)
 				os.Exit(1)
 			}
 
-			vars := make(map[string]string)
-			for _, kv := range args[1:] {
-				parts := strings.SplitN(kv, "=", 2)
-				if len(parts) == 2 {
-					vars[parts[0]] = parts[1]
+			values := make(map[string]string)
+			for _, kv := range append(args[1:], vars...) {
+				k, v, ok := strings.Cut(kv, "=")
+				if !ok {
+					ui.Bad.Printf("  Expected var=value, got %q\n", kv)
+					os.Exit(1)
 				}
+				values[k] = v
+			}
+			if missing := prompt.Missing(p.Content, values); len(missing) > 0 {
+				ui.Bad.Printf("  Missing variables: %s\n", strings.Join(missing, ", "))
+				fmt.Printf("  Pass them with --var %s=...\n", missing[0])
+				os.Exit(1)
 			}
 
-			output := prompt.Render(p.Content, vars)
-			fmt.Println(output)
+			output := prompt.Render(p.Content, values)
+			if tool == "" {
+				fmt.Println(output)
+				return
+			}
+			os.Exit(runPromptTool(tool, output, allKeys))
 		},
 	}
+
+	cmd.Flags().StringArrayVar(&vars, "var", nil, "Variable value as name=value (repeatable)")
+	cmd.Flags().StringVarP(&tool, "tool", "t", "", "Send the rendered prompt to this tool")
+	cmd.Flags().IntVar(&version, "version", 0, "Run an earlier version")
+	cmd.Flags().BoolVar(&allKeys, "all-keys", false, "With --tool, inject every vault key, not just the ones the tool needs")
+	return cmd
+}
+
+// runPromptTool sends a rendered prompt to tool and returns its exit code.
+func runPromptTool(tool, text string, allKeys bool) int {
+	reg := loadRegistry()
+	args := promptArgs(reg, tool, text)
+	if _, err := exec.LookPath(args[0]); err != nil {
+		ui.Bad.Printf("  %s not found on PATH\n", args[0])
+		return 1
+	}
+
+	c := exec.Command(args[0], args[1:]...)
+	c.Stdin = strings.NewReader(text)
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	c.Env = buildVaultEnv(vault.New(), tool, reg, []string{tool}, allKeys)
+	if err := c.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode()
+		}
+		ui.Bad.Printf("  %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// loadPromptVersion loads the latest version of name, or version when set.
+func loadPromptVersion(name string, version int) (*prompt.Prompt, error) {
+	if version > 0 {
+		return prompt.LoadVersion(name, version)
+	}
+	return prompt.Load(name)
 }
 
 func promptDeleteCmd() *cobra.Command {
@@ -151,9 +261,7 @@ func promptDeleteCmd() *cobra.Command {
 }
 
 func promptListCmd() *cobra.Command {
-	var jsonOutput bool
-
-	cmd := &cobra.Command{
+	return &cobra.Command{
 		Use:     "list",
 		Aliases: []string{"ls"},
 		Short:   "List all saved prompts",
@@ -165,8 +273,7 @@ func promptListCmd() *cobra.Command {
 			}
 
 			if jsonOutput {
-				data, _ := json.MarshalIndent(prompts, "", "  ")
-				fmt.Println(string(data))
+				printJSON(prompts)
 				return
 			}
 
@@ -176,23 +283,158 @@ func promptListCmd() *cobra.Command {
 			fmt.Printf("\n  %d prompts\n", len(prompts))
 		},
 	}
-
-	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON")
-	return cmd
 }
 
 func promptExportCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "export",
-		Short: "Export all prompts as JSON",
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "export [name...]",
+		Short: "Export prompts as JSON for sharing",
+		Example: `  palm prompt export > prompts.json
+  palm prompt export review summarize -o team-prompts.json`,
 		Run: func(cmd *cobra.Command, args []string) {
 			prompts, err := prompt.List()
 			if err != nil {
 				ui.Bad.Printf("  %v\n", err)
 				os.Exit(1)
 			}
+			if len(args) > 0 {
+				var picked []prompt.Prompt
+				for _, name := range args {
+					found := false
+					for _, p := range prompts {
+						if p.Name == name {
+							picked = append(picked, p)
+							found = true
+						}
+					}
+					if !found {
+						ui.Bad.Printf("  Prompt %q not found\n", name)
+						os.Exit(1)
+					}
+				}
+				prompts = picked
+			}
+
 			data, _ := json.MarshalIndent(prompts, "", "  ")
-			fmt.Println(string(data))
+			if output == "" {
+				fmt.Println(string(data))
+				return
+			}
+			if err := os.WriteFile(output, append(data, '\n'), 0o644); err != nil {
+				ui.Bad.Printf("  Failed to write %s: %v\n", output, err)
+				os.Exit(1)
+			}
+			ui.Good.Printf("  %s Exported %d prompts to %s\n", ui.StatusIcon(true), len(prompts), output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write to a file instead of stdout")
+	return cmd
+}
+
+func promptImportCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import prompts exported with palm prompt export",
+		Long: `Import prompts from a palm prompt export file (- for stdin). A prompt that
+already exists with different content gets the import as a new version.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var data []byte
+			var err error
+			if args[0] == "-" {
+				data, err = io.ReadAll(os.Stdin)
+			} else {
+				data, err = os.ReadFile(args[0])
+			}
+			if err != nil {
+				ui.Bad.Printf("  Failed to read %s: %v\n", args[0], err)
+				os.Exit(1)
+			}
+
+			var prompts []prompt.Prompt
+			if err := json.Unmarshal(data, &prompts); err != nil {
+				ui.Bad.Printf("  %s is not a prompt export: %v\n", args[0], err)
+				os.Exit(1)
+			}
+
+			note := "imported from " + filepath.Base(args[0])
+			added, skipped := 0, 0
+			for _, p := range prompts {
+				version, changed, err := prompt.Add(p.Name, p.Content, note)
+				switch {
+				case err != nil:
+					ui.Bad.Printf("  %s %s: %v\n", ui.StatusIcon(false), p.Name, err)
+					skipped++
+				case changed:
+					fmt.Printf("  %s %s v%d\n", ui.StatusIcon(true), p.Name, version)
+					added++
+				default:
+					fmt.Printf("  %s %s\n", ui.Subtle.Sprint("="), ui.Subtle.Sprintf("%s unchanged", p.Name))
+				}
+			}
+			fmt.Printf("\n  Imported %d prompts", added)
+			if skipped > 0 {
+				fmt.Printf(" · %d failed", skipped)
+			}
+			fmt.Println()
+		},
+	}
+}
+
+func promptHistoryCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "history <name>",
+		Short: "List a prompt's versions",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			history, err := prompt.History(args[0])
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+			if len(history) == 0 {
+				ui.Bad.Printf("  Prompt %q not found\n", args[0])
+				os.Exit(1)
+			}
+
+			ui.Banner("prompt history")
+			var rows [][]string
+			for i := len(history) - 1; i >= 0; i-- {
+				v := history[i]
+				rows = append(rows, []string{
+					fmt.Sprintf("v%d", v.Version),
+					v.CreatedAt.Format("2006-01-02 15:04"),
+					orDash(v.Note),
+					truncatePrompt(v.Content, 40),
+				})
+			}
+			ui.Table([]string{"Version", "Saved", "Note", "Preview"}, rows)
+			fmt.Println()
+			ui.Subtle.Printf("  palm prompt show %s --version N · palm prompt rollback %s N\n", args[0], args[0])
+		},
+	}
+}
+
+func promptRollbackCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rollback <name> <version>",
+		Short: "Make an earlier version of a prompt the latest again",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			n, err := strconv.Atoi(strings.TrimPrefix(args[1], "v"))
+			if err != nil {
+				ui.Bad.Printf("  Invalid version %q\n", args[1])
+				os.Exit(1)
+			}
+			version, err := prompt.Rollback(args[0], n)
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+			ui.Good.Printf("  %s %s is now v%d (content of v%d)\n", ui.StatusIcon(true), args[0], version, n)
 		},
 	}
 }
@@ -4,10 +4,16 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/msalah0e/palm/internal/activity"
 	"github.com/msalah0e/palm/internal/proxy"
 	"github.com/msalah0e/palm/internal/ui"
+	"github.com/msalah0e/palm/internal/vault"
 	"github.com/spf13/cobra"
 )
 
@@ -21,7 +27,11 @@ func proxyCmd() *cobra.Command {
 		proxyStartCmd(),
 		proxyStopCmd(),
 		proxyStatusCmd(),
+		proxyResumeCmd(),
 		proxyLogsCmd(),
+		proxyRoutesCmd(),
+		proxyTokenCmd(),
+		proxyReplayCmd(),
 	)
 
 	return cmd
@@ -31,10 +41,27 @@ func proxyStartCmd() *cobra.Command {
 	var port int
 	var verbose bool
 	var background bool
+	var listen, tlsCert, tlsKey string
+	var useTLS bool
+	var logBodies bool
 
 	cmd := &cobra.Command{
 		Use:   "start",
 		Short: "Start the proxy server",
+		Long: `Start the proxy server. It listens on 127.0.0.1 unless --listen says
+otherwise. To share it with other machines, create an access token for each
+client and listen on the LAN, preferably over TLS:
+
+  palm proxy token add laptop
+  palm proxy start --listen 0.0.0.0 --tls
+
+Clients send their token where they would send an API key; requests from this
+machine need none. Without --tls-cert and --tls-key, --tls generates a
+self-signed certificate.
+
+Streamed answers (server-sent events) are forwarded event by event, and
+realtime WebSocket sessions are passed through with keys injected; usage the
+stream reports is counted toward cost. --verbose also logs each event.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			// Check if already running
 			if running, pid := proxy.IsRunning(); running {
@@ -45,10 +72,22 @@ func proxyStartCmd() *cobra.Command {
 			if background {
 				// Launch in background
 				exe, _ := os.Executable()
-				child := exec.Command(exe, "proxy", "start", "--port", strconv.Itoa(port))
+				child := exec.Command(exe, "proxy", "start", "--port", strconv.Itoa(port), "--listen", listen)
 				if verbose {
 					child.Args = append(child.Args, "--verbose")
 				}
+				if useTLS {
+					child.Args = append(child.Args, "--tls")
+				}
+				if tlsCert != "" {
+					child.Args = append(child.Args, "--tls-cert", tlsCert, "--tls-key", tlsKey)
+				}
+				if logBodies {
+					child.Args = append(child.Args, "--log-bodies")
+				}
+				if p := vault.Profile(); p != "" {
+					child.Args = append(child.Args, "--profile", p)
+				}
 				child.Stdout = nil
 				child.Stderr = nil
 				setDetached(child)
@@ -61,21 +100,38 @@ func proxyStartCmd() *cobra.Command {
 				// Write PID
 				_ = os.WriteFile(proxy.PidFile(), []byte(strconv.Itoa(child.Process.Pid)), 0o644)
 
-				ui.Good.Printf("  %s Proxy started on port %d (PID %d)\n", ui.StatusIcon(true), port, child.Process.Pid)
+				scheme := "http"
+				if useTLS || tlsCert != "" {
+					scheme = "https"
+				}
+				ui.Good.Printf("  %s Proxy started on %s port %d (PID %d)\n", ui.StatusIcon(true), listen, port, child.Process.Pid)
+				if p := vault.Profile(); p != "" {
+					fmt.Printf("  Using keys from profile %s\n", ui.Brand.Sprint(p))
+				}
 				fmt.Println()
 				fmt.Printf("  Set base URLs to route through proxy:\n")
-				fmt.Printf("    export OPENAI_BASE_URL=http://localhost:%d/openai/v1\n", port)
-				fmt.Printf("    export ANTHROPIC_BASE_URL=http://localhost:%d/anthropic/v1\n", port)
+				fmt.Printf("    export OPENAI_BASE_URL=%s://localhost:%d/openai/v1\n", scheme, port)
+				fmt.Printf("    export ANTHROPIC_BASE_URL=%s://localhost:%d/anthropic/v1\n", scheme, port)
 				return
 			}
 
 			// Foreground mode
 			ui.Banner("proxy server")
 			_ = proxy.WritePid()
+			logProxyEvent("start", fmt.Sprintf("%s port %d, PID %d", listen, port, os.Getpid()))
+			if p := vault.Profile(); p != "" {
+				fmt.Printf("  Using keys from profile %s\n", ui.Brand.Sprint(p))
+			}
 
 			srv := proxy.New(proxy.Config{
 				Port:    port,
+				Listen:  listen,
 				Verbose: verbose,
+				TLS:     useTLS,
+				TLSCert: tlsCert,
+				TLSKey:  tlsKey,
+
+				LogBodies: logBodies,
 			})
 
 			if err := srv.Start(); err != nil {
@@ -87,8 +143,13 @@ func proxyStartCmd() *cobra.Command {
 	}
 
 	cmd.Flags().IntVarP(&port, "port", "p", 4778, "Port to listen on")
-	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Log all requests to stdout")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Log all requests, and streamed events, to stdout")
 	cmd.Flags().BoolVarP(&background, "bg", "b", false, "Run in background")
+	cmd.Flags().StringVar(&listen, "listen", "127.0.0.1", "Address to listen on (0.0.0.0 for all interfaces; needs an access token)")
+	cmd.Flags().BoolVar(&useTLS, "tls", false, "Serve HTTPS, with a self-signed certificate unless --tls-cert is given")
+	cmd.Flags().StringVar(&tlsCert, "tls-cert", "", "TLS certificate file (PEM)")
+	cmd.Flags().StringVar(&tlsKey, "tls-key", "", "TLS private key file (PEM)")
+	cmd.Flags().BoolVar(&logBodies, "log-bodies", false, "Also log request and response bodies, for palm proxy replay")
 	return cmd
 }
 
@@ -115,6 +176,7 @@ func proxyStopCmd() *cobra.Command {
 			}
 
 			_ = os.Remove(proxy.PidFile())
+			logProxyEvent("stop", fmt.Sprintf("PID %d", pid))
 			ui.Good.Printf("  %s Proxy stopped (PID %d)\n", ui.StatusIcon(true), pid)
 		},
 	}
@@ -128,17 +190,83 @@ func proxyStatusCmd() *cobra.Command {
 			running, pid := proxy.IsRunning()
 			if running {
 				ui.Good.Printf("  %s Proxy running (PID %d)\n", ui.StatusIcon(true), pid)
-				fmt.Println("  Routes: /openai/, /anthropic/, /google/, /groq/, /mistral/, /ollama/")
+				fmt.Println("  Routes: /openai/, /anthropic/, /google/, /groq/, /mistral/, /ollama/,")
+				fmt.Println("          /llama-cpp/, /vllm/ (palm serve)")
+				fmt.Println("          /v1/ (by model, see palm proxy routes)")
 			} else {
 				fmt.Println("  Proxy is not running")
 				fmt.Println("  Start: palm proxy start")
 			}
+
+			paused, err := proxy.LoadPaused()
+			if err != nil {
+				ui.Warn.Printf("  %s %v\n", ui.WarnIcon(), err)
+				return
+			}
+			if len(paused) > 0 {
+				fmt.Println()
+				ui.Warn.Printf("  %s Paused after a usage anomaly:\n", ui.WarnIcon())
+				for _, p := range pausedProviders(paused) {
+					fmt.Printf("    %-10s since %s — %s\n", p, paused[p].At.Format("Jan 2 15:04"), paused[p].Reason)
+				}
+				fmt.Println("  Resume: palm proxy resume <provider>")
+			}
+		},
+	}
+}
+
+func proxyResumeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "resume [provider]",
+		Short: "Resume routing to providers paused after a usage anomaly",
+		Long: `Resume routing to a provider the proxy paused because its request rate
+or spend ran far above its usual level (see [anomaly] in palm proxy routes).
+Without a provider, every paused provider is resumed. The proxy picks the
+change up on its next request; no restart is needed.
+
+  palm proxy resume openai
+  palm proxy resume`,
+		Args: cobra.MaximumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			paused, _ := proxy.LoadPaused()
+			var out []string
+			for _, p := range pausedProviders(paused) {
+				out = append(out, p+"\t"+paused[p].Reason)
+			}
+			return out, cobra.ShellCompDirectiveNoFileComp
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			provider := ""
+			if len(args) == 1 {
+				provider = args[0]
+			}
+			resumed, err := proxy.ResumeProvider(provider)
+			if err != nil {
+				ui.Bad.Printf("  Failed to resume: %v\n", err)
+				os.Exit(1)
+			}
+			if len(resumed) == 0 {
+				if provider != "" {
+					fmt.Printf("  %s is not paused\n", provider)
+				} else {
+					fmt.Println("  No providers are paused")
+				}
+				return
+			}
+			for _, p := range resumed {
+				logProxyEvent("resume", p)
+				ui.Good.Printf("  %s Routing to %s resumed\n", ui.StatusIcon(true), ui.Brand.Sprint(p))
+			}
 		},
 	}
 }
 
 func proxyLogsCmd() *cobra.Command {
 	var count int
+	var client string
 
 	cmd := &cobra.Command{
 		Use:   "logs",
@@ -146,11 +274,33 @@ func proxyLogsCmd() *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			ui.Banner("proxy logs")
 
-			logs, err := proxy.ReadLogs(count)
+			n := count
+			if client != "" {
+				n = 0 // filter first, then keep the last count
+			}
+			logs, err := proxy.ReadLogs(n)
 			if err != nil {
 				ui.Bad.Printf("  Failed to read logs: %v\n", err)
 				os.Exit(1)
 			}
+			showClient := client != ""
+			if client != "" {
+				var matched []proxy.RequestLog
+				for _, entry := range logs {
+					if entry.Client == client {
+						matched = append(matched, entry)
+					}
+				}
+				if count > 0 && len(matched) > count {
+					matched = matched[len(matched)-count:]
+				}
+				logs = matched
+			}
+			showTool := false
+			for _, entry := range logs {
+				showClient = showClient || entry.Client != ""
+				showTool = showTool || entry.Tool != ""
+			}
 
 			if len(logs) == 0 {
 				fmt.Println("  No proxy logs yet.")
@@ -158,18 +308,31 @@ func proxyLogsCmd() *cobra.Command {
 			}
 
 			headers := []string{"Time", "Provider", "Method", "Path", "Status", "Duration"}
+			if showClient {
+				headers = append(headers, "Client")
+			}
+			if showTool {
+				headers = append(headers, "Tool")
+			}
 			var rows [][]string
 
 			for _, entry := range logs {
 				statusIcon := ui.StatusIcon(entry.Status < 400)
-				rows = append(rows, []string{
+				row := []string{
 					entry.Timestamp.Format("15:04:05"),
 					entry.Provider,
 					entry.Method,
 					truncate(entry.Path, 30),
 					fmt.Sprintf("%s %d", statusIcon, entry.Status),
 					fmt.Sprintf("%.0fms", entry.Duration),
-				})
+				}
+				if showClient {
+					row = append(row, orDash(entry.Client))
+				}
+				if showTool {
+					row = append(row, orDash(entry.Tool))
+				}
+				rows = append(rows, row)
 			}
 
 			ui.Table(headers, rows)
@@ -178,9 +341,268 @@ func proxyLogsCmd() *cobra.Command {
 	}
 
 	cmd.Flags().IntVarP(&count, "count", "n", 50, "Number of log entries to show")
+	cmd.Flags().StringVar(&client, "client", "", "Only show requests made with this access token")
+	return cmd
+}
+
+func proxyRoutesCmd() *cobra.Command {
+	var (
+		initFile bool
+		resolve  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "routes",
+		Short: "Show model routing rules and aliases",
+		Long: `Show the routing rules in ~/.config/palm/routes.toml.
+
+Requests sent to http://localhost:4778/v1/ are routed by the "model" field:
+a matching rule picks the provider and upstream model (aliases like "fast"
+or "cheap"), optionally overriding temperature and max_tokens. Models without
+a rule go to the provider palm knows them from, or to the llama-cpp or vllm
+server started by palm serve that serves them.
+
+A [downgrade] table maps models to cheaper ones served instead once spending
+reaches a share of the budget. Past the limit requests are refused with 402
+unless past_limit is set. Downgraded responses carry an X-Palm-Downgraded
+header.
+
+A [limits.<provider>] table caps the requests in flight to a provider. Bursts
+wait in a local queue instead of tripping its rate limits, and get a 429 only
+when the queue is full or the wait times out. /palm/stats reports each
+provider's queue depth and wait times.
+
+Requests longer than their model's context window in the catalog get a 400
+naming the sizes before any upstream call. Under [context], policy =
+"truncate" drops the oldest turns until the request fits instead, and
+"off" disables the check.
+
+Each provider's usual requests and spend per window (5 minutes unless
+[anomaly] says otherwise) are learned from the proxy log. A window running
+factor times above that (10 by default), such as an agent stuck in a loop,
+is logged and shown in /palm/stats, with notify or webhook alerts if set.
+From pause_at times, routing to the provider stops with 503 until
+palm proxy resume.
+
+A [transform] table rewrites chat requests before they leave the machine:
+system_prompt goes ahead of each request's own instructions, pii = "redact"
+replaces email addresses and phone numbers with [email] and [phone], and
+[transform.metadata] is sent as X-Palm-Meta-<name> headers. Providers set
+to false under [transform.providers] are left alone. Responses carry an
+X-Palm-Transformed header, and the proxy log records what changed.
+
+Examples:
+  palm proxy routes --init          # write an example routes.toml
+  palm proxy routes --resolve cheap # show which backend serves "cheap" now`,
+		Run: func(cmd *cobra.Command, args []string) {
+			path := proxy.RoutesPath()
+			if initFile {
+				if _, err := os.Stat(path); err == nil {
+					ui.Warn.Printf("  %s already exists\n", path)
+					os.Exit(1)
+				}
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					ui.Bad.Printf("  %v\n", err)
+					os.Exit(1)
+				}
+				if err := os.WriteFile(path, []byte(proxy.ExampleRoutes), 0o644); err != nil {
+					ui.Bad.Printf("  %v\n", err)
+					os.Exit(1)
+				}
+				ui.Good.Printf("  %s Wrote %s\n", ui.StatusIcon(true), path)
+				return
+			}
+
+			routes, err := proxy.LoadRoutes()
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+
+			if resolve != "" {
+				route := routes.Match(resolve)
+				if route == nil {
+					if provider := proxy.InferProvider(resolve); provider != "" {
+						fmt.Printf("  %s → %s/%s (no rule; known model)\n", resolve, provider, resolve)
+						return
+					}
+					ui.Warn.Printf("  No route for %q\n", resolve)
+					os.Exit(1)
+				}
+				v := vault.New()
+				usable := func(provider string) bool { return proxy.KeyAvailable(v, provider) }
+				b, err := route.Resolve(resolve, usable, nil)
+				if err != nil {
+					ui.Bad.Printf("  %v\n", err)
+					os.Exit(1)
+				}
+				fmt.Printf("  %s → %s\n", resolve, ui.Brand.Sprint(b.String()))
+				return
+			}
+
+			ui.Banner("proxy routes")
+			if len(routes.Routes) == 0 && len(routes.Failover.Fallbacks) == 0 && !routes.Downgrade.Enabled() && len(routes.Limits) == 0 && !routes.Transform.Enabled() {
+				fmt.Printf("  No routing rules in %s\n", path)
+				fmt.Println("  Create an example: palm proxy routes --init")
+				return
+			}
+			if len(routes.Routes) > 0 {
+				var rows [][]string
+				for _, rt := range routes.Routes {
+					fallback := "-"
+					if len(rt.Fallback) > 0 {
+						fallback = strings.Join(rt.Fallback, ", ")
+					}
+					rows = append(rows, []string{rt.Model, rt.Describe(), routeStrategy(rt), routeOverrides(rt), fallback})
+				}
+				ui.Table([]string{"Model", "Backend", "Strategy", "Overrides", "Fallback"}, rows)
+			}
+
+			if len(routes.Failover.Fallbacks) > 0 {
+				fmt.Println()
+				fmt.Printf("  Failover on 429/5xx (%d retries per backend):\n", routes.Failover.Retries)
+				providers := make([]string, 0, len(routes.Failover.Fallbacks))
+				for p := range routes.Failover.Fallbacks {
+					providers = append(providers, p)
+				}
+				sort.Strings(providers)
+				for _, p := range providers {
+					fmt.Printf("    %-10s → %s\n", p, strings.Join(routes.Failover.Fallbacks[p], " → "))
+				}
+			}
+			if d := routes.Downgrade; d.Enabled() {
+				fmt.Println()
+				at := "the budget's alert_at"
+				if d.At > 0 {
+					at = fmt.Sprintf("%g%% of the budget", d.At)
+				}
+				due, used := d.Due()
+				state := ui.Subtle.Sprintf("(%.0f%% used)", used)
+				if due {
+					state = ui.Warn.Sprintf("(%.0f%% used — downgrading now)", used)
+				}
+				if d.PastLimit {
+					at += ", past the limit too"
+				}
+				fmt.Printf("  Downgrade from %s %s:\n", at, state)
+				froms := make([]string, 0, len(d.Models))
+				for from := range d.Models {
+					froms = append(froms, from)
+				}
+				sort.Strings(froms)
+				for _, from := range froms {
+					fmt.Printf("    %-24s → %s\n", from, d.Models[from])
+				}
+			}
+			if len(routes.Limits) > 0 {
+				fmt.Println()
+				fmt.Println("  Concurrency limits:")
+				for _, p := range routes.LimitedProviders() {
+					l := routes.Limits[p]
+					queue := "unbounded queue"
+					if l.Queue > 0 {
+						queue = fmt.Sprintf("queue %d", l.Queue)
+					}
+					wait := "60s"
+					if l.QueueTimeoutMS > 0 {
+						wait = (time.Duration(l.QueueTimeoutMS) * time.Millisecond).String()
+					}
+					fmt.Printf("    %-10s %d in flight, %s, wait up to %s\n", p, l.MaxInFlight, queue, wait)
+				}
+			}
+			if routes.Context.Policy != "" && routes.Context.Policy != "reject" {
+				fmt.Printf("\n  Context window: %s\n", routes.Context.Policy)
+			}
+			if t := routes.Transform; t.Enabled() {
+				fmt.Println()
+				fmt.Println("  Request transforms:")
+				for _, d := range t.Describe() {
+					fmt.Printf("    %s\n", d)
+				}
+				var off []string
+				for p, on := range t.Providers {
+					if !on {
+						off = append(off, p)
+					}
+				}
+				if len(off) > 0 {
+					sort.Strings(off)
+					fmt.Printf("    %s\n", ui.Subtle.Sprintf("not for %s", strings.Join(off, ", ")))
+				}
+			}
+			if a := routes.Anomaly; !a.Off {
+				printAnomalyBaselines(a)
+			}
+			fmt.Printf("\n  %s\n", ui.Subtle.Sprint(path))
+		},
+	}
+
+	cmd.Flags().BoolVar(&initFile, "init", false, "Write an example routes.toml")
+	cmd.Flags().StringVar(&resolve, "resolve", "", "Show which backend a model or alias resolves to")
 	return cmd
 }
 
+func pausedProviders(paused map[string]proxy.Pause) []string {
+	providers := make([]string, 0, len(paused))
+	for p := range paused {
+		providers = append(providers, p)
+	}
+	sort.Strings(providers)
+	return providers
+}
+
+// printAnomalyBaselines shows what each provider's usage is compared with.
+func printAnomalyBaselines(a proxy.AnomalyConfig) {
+	logs, err := proxy.ReadLogs(50000)
+	if err != nil {
+		return
+	}
+	baselines := proxy.LearnBaselines(logs, a.Window())
+	if len(baselines) == 0 {
+		return
+	}
+	providers := make([]string, 0, len(baselines))
+	for p := range baselines {
+		providers = append(providers, p)
+	}
+	sort.Strings(providers)
+	fmt.Println()
+	fmt.Printf("  Usual use per %d-minute window (from the proxy log):\n", int(a.Window().Minutes()))
+	for _, p := range providers {
+		b := baselines[p]
+		fmt.Printf("    %-10s %.0f requests, $%.4f %s\n", p, b.Requests, b.Cost, ui.Subtle.Sprintf("(%d windows)", b.Windows))
+	}
+}
+
+// logProxyEvent records a proxy start or stop in the activity log.
+func logProxyEvent(op, details string) {
+	_ = activity.Append(activity.Entry{Action: "proxy." + op, Tool: "proxy", Details: details, Status: "ok"})
+}
+
+func routeStrategy(r proxy.Route) string {
+	if len(r.Candidates) == 0 {
+		return "-"
+	}
+	if r.Strategy == "" {
+		return "first"
+	}
+	return r.Strategy
+}
+
+func routeOverrides(r proxy.Route) string {
+	var parts []string
+	if r.Temperature != nil {
+		parts = append(parts, fmt.Sprintf("temperature=%g", *r.Temperature))
+	}
+	if r.MaxTokens != nil {
+		parts = append(parts, fmt.Sprintf("max_tokens=%d", *r.MaxTokens))
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, " ")
+}
+
 func truncate(s string, max int) string {
 	if len(s) <= max {
 		return s
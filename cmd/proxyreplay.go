@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/msalah0e/palm/internal/budget"
+	"github.com/msalah0e/palm/internal/proxy"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/msalah0e/palm/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+// replayReport is the outcome of a replay run.
+type replayReport struct {
+	Against  string               `json:"against"`
+	Requests int                  `json:"requests"` // logged requests replayed
+	Skipped  int                  `json:"skipped"`  // matching requests without logged bodies
+	Repeat   int                  `json:"repeat"`
+	Results  []proxy.ReplayResult `json:"results"`
+	Summary  replaySummary        `json:"summary"`
+}
+
+type replaySummary struct {
+	Sent         int     `json:"sent"`
+	Failed       int     `json:"failed"`
+	OriginalCost float64 `json:"original_cost"`
+	ReplayCost   float64 `json:"replay_cost"`
+	Unpriced     int     `json:"unpriced"` // results left out of the costs: a model without a known price
+	Similarity   float64 `json:"similarity"`
+	OriginalP50  float64 `json:"original_p50_ms"`
+	OriginalP95  float64 `json:"original_p95_ms"`
+	ReplayP50    float64 `json:"replay_p50_ms"`
+	ReplayP95    float64 `json:"replay_p95_ms"`
+	WallSeconds  float64 `json:"wall_seconds"`
+	PerSecond    float64 `json:"per_second"`
+}
+
+func proxyReplayCmd() *cobra.Command {
+	var (
+		last        int
+		against     string
+		provider    string
+		model       string
+		repeat      int
+		concurrency int
+		timeout     int
+		show        bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "replay --against provider/model",
+		Short: "Re-send logged requests to another model and compare answers and costs",
+		Long: `Re-send recent chat requests from the proxy log to another provider and
+model, and compare each answer, its latency and its cost with the original —
+to see whether a workload could move to a cheaper or local model.
+
+Replay needs the requests' bodies, which the proxy only logs when started
+with --log-bodies. Replays go straight to the target, without streaming,
+and are not added to the proxy log. Anthropic requests are translated to
+the OpenAI format.
+
+With --repeat and --concurrency the logged requests become a load test:
+each is sent repeat times by concurrency parallel workers.
+
+Examples:
+  palm proxy start --bg --log-bodies
+  palm proxy replay --last 20 --against ollama/llama3.3
+  palm proxy replay --model gpt-4o --against groq/llama-3.3-70b-versatile --show
+  palm proxy replay --last 5 --against ollama/llama3.3 --repeat 10 --concurrency 4`,
+		Run: func(cmd *cobra.Command, args []string) {
+			target, err := proxy.ParseBackend(against)
+			if err != nil {
+				ui.Bad.Printf("  Invalid --against: %v\n", err)
+				os.Exit(1)
+			}
+			if err := budget.CheckBudget(target.Provider); err != nil {
+				ui.Bad.Printf("  Budget exceeded for %s: %v\n", target.Provider, err)
+				os.Exit(1)
+			}
+			v := vault.New()
+			if !proxy.KeyAvailable(v, target.Provider) {
+				ui.Bad.Printf("  No API key for %s — add it with `palm keys add`\n", target.Provider)
+				os.Exit(1)
+			}
+
+			cases, skipped, err := proxy.LoadReplayCases(last, func(l proxy.RequestLog) bool {
+				return (provider == "" || l.Provider == provider) && (model == "" || l.Model == model || l.Alias == model)
+			})
+			if err != nil {
+				ui.Bad.Printf("  Failed to read proxy logs: %v\n", err)
+				os.Exit(1)
+			}
+			if len(cases) == 0 {
+				ui.Warn.Printf("  %s No logged chat requests with bodies to replay\n", ui.WarnIcon())
+				if skipped > 0 {
+					fmt.Printf("  %d matching request(s) were logged without bodies\n", skipped)
+				}
+				fmt.Println("  Log bodies with: palm proxy start --log-bodies")
+				os.Exit(1)
+			}
+			repeat, concurrency = max(repeat, 1), max(concurrency, 1)
+
+			if !jsonOutput {
+				ui.Banner("proxy replay")
+				fmt.Printf("  Replaying %d request(s) against %s", len(cases), ui.Brand.Sprint(target.String()))
+				if repeat > 1 || concurrency > 1 {
+					fmt.Printf(" · %d× each · %d worker(s)", repeat, concurrency)
+				}
+				fmt.Println()
+				if skipped > 0 {
+					fmt.Printf("  %s\n", ui.Subtle.Sprintf("%d matching request(s) skipped: no logged body", skipped))
+				}
+				fmt.Println()
+			}
+
+			started := time.Now()
+			results := runReplays(cases, target, v, repeat, concurrency, time.Duration(timeout)*time.Second)
+			report := replayReport{
+				Against:  target.String(),
+				Requests: len(cases),
+				Skipped:  skipped,
+				Repeat:   repeat,
+				Results:  results,
+				Summary:  summarizeReplays(results, time.Since(started)),
+			}
+			logProxyEvent("replay", fmt.Sprintf("%d requests against %s, %d failed", report.Summary.Sent, target, report.Summary.Failed))
+
+			render(report, func() { printReplayReport(report, show) })
+		},
+	}
+
+	cmd.Flags().IntVar(&last, "last", 20, "Number of recent logged requests to replay")
+	cmd.Flags().StringVar(&against, "against", "", "Target as provider/model, e.g. ollama/llama3.3 (required)")
+	cmd.Flags().StringVar(&provider, "provider", "", "Only replay requests originally sent to this provider")
+	cmd.Flags().StringVar(&model, "model", "", "Only replay requests for this model or alias")
+	cmd.Flags().IntVar(&repeat, "repeat", 1, "Send each request this many times (load testing)")
+	cmd.Flags().IntVar(&concurrency, "concurrency", 1, "Requests in flight at once")
+	cmd.Flags().IntVar(&timeout, "timeout", 120, "Timeout per request in seconds")
+	cmd.Flags().BoolVar(&show, "show", false, "Print the original and replayed answers")
+	_ = cmd.MarkFlagRequired("against")
+	return cmd
+}
+
+// runReplays sends every case repeat times with concurrency workers. The
+// results keep the order of cases.
+func runReplays(cases []proxy.ReplayCase, target proxy.Backend, v vault.Vault, repeat, concurrency int, timeout time.Duration) []proxy.ReplayResult {
+	results := make([]proxy.ReplayResult, len(cases)*repeat)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				results[i] = proxy.Replay(ctx, cases[i/repeat], target, v)
+				cancel()
+			}
+		}()
+	}
+	for i := range results {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+func summarizeReplays(results []proxy.ReplayResult, wall time.Duration) replaySummary {
+	s := replaySummary{Sent: len(results), WallSeconds: wall.Seconds()}
+	var origMS, replayMS []float64
+	compared := 0
+	for _, r := range results {
+		origMS = append(origMS, r.Original.DurationMS)
+		if !r.Replay.OK() {
+			s.Failed++
+			continue
+		}
+		replayMS = append(replayMS, r.Replay.DurationMS)
+		s.Similarity += r.Similarity
+		compared++
+		if r.Original.Priced && r.Replay.Priced {
+			s.OriginalCost += r.Original.Cost
+			s.ReplayCost += r.Replay.Cost
+		} else {
+			s.Unpriced++
+		}
+	}
+	if compared > 0 {
+		s.Similarity /= float64(compared)
+	}
+	s.OriginalP50, s.OriginalP95 = percentile(origMS, 50), percentile(origMS, 95)
+	s.ReplayP50, s.ReplayP95 = percentile(replayMS, 50), percentile(replayMS, 95)
+	if s.WallSeconds > 0 {
+		s.PerSecond = float64(s.Sent) / s.WallSeconds
+	}
+	return s
+}
+
+// percentile returns the p-th percentile of values by nearest rank.
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := int(p/100*float64(len(sorted))+0.5) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
+
+func replayCost(e proxy.Exchange) string {
+	if !e.Priced {
+		return "?"
+	}
+	return fmt.Sprintf("$%.4f", e.Cost)
+}
+
+func printReplayReport(r replayReport, show bool) {
+	if r.Repeat == 1 {
+		var rows [][]string
+		for i, res := range r.Results {
+			replay := ui.StatusIcon(false) + " " + truncate(res.Replay.Error, 40)
+			similarity := "-"
+			if res.Replay.OK() {
+				replay = fmt.Sprintf("%.0fms %s", res.Replay.DurationMS, replayCost(res.Replay))
+				similarity = fmt.Sprintf("%.0f%%", res.Similarity)
+			}
+			rows = append(rows, []string{
+				fmt.Sprintf("%d", i+1),
+				res.Time.Format("Jan 02 15:04"),
+				res.Original.Backend,
+				fmt.Sprintf("%.0fms %s", res.Original.DurationMS, replayCost(res.Original)),
+				replay,
+				similarity,
+			})
+		}
+		ui.Table([]string{"#", "Logged", "Original", "Time / cost", "Replay", "Similar"}, rows)
+		fmt.Println()
+	}
+
+	if show {
+		for i, res := range r.Results {
+			fmt.Printf("  %s %s\n", ui.Brand.Sprintf("#%d", i+1), ui.Subtle.Sprint(res.Original.Backend))
+			printTruncatedOutput(res.Original.Text, 600)
+			fmt.Printf("  %s %s\n", ui.Brand.Sprintf("#%d", i+1), ui.Subtle.Sprint(res.Replay.Backend))
+			if res.Replay.OK() {
+				printTruncatedOutput(res.Replay.Text, 600)
+			} else {
+				ui.Bad.Printf("  %s\n", res.Replay.Error)
+			}
+			fmt.Println()
+		}
+	}
+
+	s := r.Summary
+	fmt.Printf("  Sent:        %d (%d failed) in %.1fs, %.1f req/s\n", s.Sent, s.Failed, s.WallSeconds, s.PerSecond)
+	fmt.Printf("  Latency:     original p50 %.0fms p95 %.0fms · replay p50 %.0fms p95 %.0fms\n", s.OriginalP50, s.OriginalP95, s.ReplayP50, s.ReplayP95)
+	if s.Sent > s.Failed {
+		fmt.Printf("  Similarity:  %.0f%% average word overlap\n", s.Similarity)
+		fmt.Printf("  Cost:        $%.4f originally → $%.4f replayed", s.OriginalCost, s.ReplayCost)
+		if s.OriginalCost > 0 {
+			fmt.Printf(" (%+.0f%%)", (s.ReplayCost-s.OriginalCost)/s.OriginalCost*100)
+		}
+		fmt.Println()
+		if s.Unpriced > 0 {
+			fmt.Printf("  %s\n", ui.Subtle.Sprintf("%d result(s) left out of the costs: model price unknown", s.Unpriced))
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/msalah0e/palm/internal/proxy"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+func proxyTokenCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "token",
+		Short: "Manage access tokens for clients on other machines",
+		Long: `Access tokens let other machines use a proxy started with --listen.
+A client sends its token where it would send the provider's API key; the
+proxy swaps it for the real key from your vault, so keys never leave this
+machine. Requests are attributed to the token's name in logs and stats.
+
+  palm proxy token add laptop
+  palm proxy token list
+  palm proxy logs --client laptop
+  palm proxy token revoke laptop`,
+		Aliases: []string{"tokens"},
+	}
+
+	cmd.AddCommand(proxyTokenAddCmd(), proxyTokenListCmd(), proxyTokenRevokeCmd())
+	return cmd
+}
+
+func proxyTokenAddCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "add <name>",
+		Short: "Create an access token",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			secret, err := proxy.AddToken(args[0])
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+			logProxyEvent("token.add", args[0])
+
+			ui.Good.Printf("  %s Created token %s\n", ui.StatusIcon(true), ui.Brand.Sprint(args[0]))
+			fmt.Println()
+			fmt.Printf("    %s\n", secret)
+			fmt.Println()
+			ui.Warn.Println("  Copy it now — palm only keeps a hash and can't show it again.")
+			fmt.Println("  On the client, use it as the API key with this machine as base URL:")
+			fmt.Printf("    export OPENAI_API_KEY=%s\n", secret)
+			fmt.Println("    export OPENAI_BASE_URL=https://<this-host>:4778/openai/v1")
+		},
+	}
+}
+
+func proxyTokenListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Short:   "List access tokens and their usage",
+		Aliases: []string{"ls"},
+		Run: func(cmd *cobra.Command, args []string) {
+			tokens, err := proxy.LoadTokens()
+			if err != nil {
+				ui.Bad.Printf("  Failed to load tokens: %v\n", err)
+				os.Exit(1)
+			}
+
+			logs, _ := proxy.ReadLogs(0)
+			requests := make(map[string]int)
+			lastUsed := make(map[string]time.Time)
+			for _, l := range logs {
+				if l.Client != "" {
+					requests[l.Client]++
+					lastUsed[l.Client] = l.Timestamp
+				}
+			}
+
+			if jsonOutput {
+				type tokenUsage struct {
+					Name      string     `json:"name"`
+					Hint      string     `json:"hint"`
+					CreatedAt time.Time  `json:"created_at"`
+					Requests  int        `json:"requests"`
+					LastUsed  *time.Time `json:"last_used,omitempty"`
+				}
+				out := []tokenUsage{}
+				for _, t := range tokens {
+					u := tokenUsage{Name: t.Name, Hint: t.Hint, CreatedAt: t.CreatedAt, Requests: requests[t.Name]}
+					if ts, ok := lastUsed[t.Name]; ok {
+						u.LastUsed = &ts
+					}
+					out = append(out, u)
+				}
+				printJSON(out)
+				return
+			}
+
+			ui.Banner("proxy tokens")
+			if len(tokens) == 0 {
+				fmt.Println("  No access tokens. Create one for each client:")
+				fmt.Println()
+				ui.Info.Println("  palm proxy token add <name>")
+				return
+			}
+
+			var rows [][]string
+			for _, t := range tokens {
+				last := "never"
+				if ts, ok := lastUsed[t.Name]; ok {
+					last = formatDuration(time.Since(ts)) + " ago"
+				}
+				rows = append(rows, []string{
+					t.Name,
+					"…" + t.Hint,
+					t.CreatedAt.Format("2006-01-02"),
+					fmt.Sprint(requests[t.Name]),
+					last,
+				})
+			}
+			ui.Table([]string{"Name", "Token", "Created", "Requests", "Last used"}, rows)
+		},
+	}
+}
+
+func proxyTokenRevokeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "revoke <name>",
+		Short:   "Revoke an access token",
+		Aliases: []string{"rm"},
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := proxy.RevokeToken(args[0]); err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+			logProxyEvent("token.revoke", args[0])
+			ui.Good.Printf("  %s Revoked token %s (takes effect immediately)\n", ui.StatusIcon(true), args[0])
+		},
+	}
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/msalah0e/palm/internal/registry"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+func refreshCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "refresh",
+		Short: "Re-detect installed tools, discarding cached results",
+		Long: `palm caches which tools are installed, and their versions, in
+~/.cache/palm/detect.json so commands like palm list don't run every
+tool's --version each time. Results are reused for 10 minutes, or for
+[install] detect_ttl in the config ("0" turns the cache off).
+
+Installs, updates and removals through palm refresh their tool on their
+own. Run palm refresh after installing or upgrading a tool some other way.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := registry.ForgetDetection(); err != nil {
+				ui.Bad.Printf("  Failed to clear the detection cache: %v\n", err)
+				os.Exit(1)
+			}
+
+			start := time.Now()
+			detected := registry.Detect(loadRegistry())
+			installed := 0
+			for _, dt := range detected {
+				if dt.Installed {
+					installed++
+				}
+			}
+
+			elapsed := time.Since(start).Seconds()
+			out := map[string]any{"tools": len(detected), "installed": installed, "seconds": elapsed}
+			render(out, func() {
+				ui.Good.Printf("  %s Re-detected %d tools in %.1fs · %d installed\n",
+					ui.StatusIcon(true), len(detected), elapsed, installed)
+				fmt.Printf("  %s\n", ui.Subtle.Sprint(registry.DetectCachePath()))
+			})
+		},
+	}
+}
+
+// detectTools detects the named registry tools concurrently, keyed by name.
+// Names not in the registry are left out.
+func detectTools(reg *registry.Registry, names []string) map[string]registry.DetectedTool {
+	var tools []registry.Tool
+	for _, name := range names {
+		if t := reg.Get(name); t != nil {
+			tools = append(tools, *t)
+		}
+	}
+	detected := make(map[string]registry.DetectedTool, len(tools))
+	for _, dt := range registry.DetectMany(tools) {
+		detected[dt.Tool.Name] = dt
+	}
+	return detected
+}
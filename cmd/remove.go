@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/msalah0e/palm/internal/installer"
 	"github.com/msalah0e/palm/internal/state"
@@ -11,10 +12,17 @@ import (
 )
 
 func removeCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:               "remove <tool>",
-		Aliases:           []string{"uninstall", "rm"},
-		Short:             "Remove an AI tool",
+	var dryRun, purge, yes bool
+
+	cmd := &cobra.Command{
+		Use:     "remove <tool>",
+		Aliases: []string{"uninstall", "rm"},
+		Short:   "Remove an AI tool",
+		Long: `Remove an AI tool.
+
+With --purge, palm also offers to clean up what the tool leaves behind: its
+rules and context files in the current project, the MCP servers in its
+config, and vault keys that no other installed tool uses.`,
 		Args:              cobra.ExactArgs(1),
 		ValidArgsFunction: installedToolCompletionFunc,
 		Run: func(cmd *cobra.Command, args []string) {
@@ -27,9 +35,20 @@ func removeCmd() *cobra.Command {
 				os.Exit(1)
 			}
 
+			if dryRun {
+				printPlans(installer.ActionUninstall, []installer.Plan{installer.PlanFor(installer.ActionUninstall, *tool)})
+				if purge {
+					cleanArtifacts(tool, findArtifacts(reg, tool), yes, true)
+				}
+				return
+			}
+
 			ui.Banner("removing")
 
-			if err := installer.Uninstall(*tool); err != nil {
+			start := time.Now()
+			err := installer.Uninstall(*tool)
+			logToolChange("remove", tool, start, err)
+			if err != nil {
 				ui.Bad.Printf("\n  Remove failed: %v\n", err)
 				os.Exit(1)
 			}
@@ -38,6 +57,15 @@ func removeCmd() *cobra.Command {
 
 			fmt.Println()
 			ui.Good.Printf("  %s %s removed\n", ui.StatusIcon(true), tool.DisplayName)
+
+			if purge {
+				cleanArtifacts(tool, findArtifacts(reg, tool), yes, false)
+			}
 		},
 	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show the command that would run without executing it")
+	cmd.Flags().BoolVar(&purge, "purge", false, "Also remove the tool's rules/context files, MCP entries and unshared keys")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Don't ask before each removal")
+	return cmd
 }
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/msalah0e/palm/internal/mcp"
+	"github.com/msalah0e/palm/internal/registry"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/msalah0e/palm/internal/vault"
+)
+
+// toolArtifacts are the files and settings a tool leaves behind once its
+// binary is gone.
+type toolArtifacts struct {
+	Files      []string // rules and context files in the current project
+	MCP        *mcp.ToolConfig
+	MCPServers []string
+	Keys       []string // vault keys no other tool needs
+}
+
+func (a toolArtifacts) empty() bool {
+	return len(a.Files) == 0 && len(a.MCPServers) == 0 && len(a.Keys) == 0
+}
+
+// findArtifacts collects what removing tool could clean up.
+func findArtifacts(reg *registry.Registry, tool *registry.Tool) toolArtifacts {
+	var a toolArtifacts
+
+	seen := make(map[string]bool)
+	for _, file := range []string{ruleFiles[tool.Name], contextFiles[tool.Name]} {
+		if file == "" || seen[file] {
+			continue
+		}
+		seen[file] = true
+		if _, err := os.Stat(file); err == nil {
+			a.Files = append(a.Files, file)
+		}
+	}
+
+	if tc, ok := mcp.ConfigFor(tool.Name); ok {
+		if servers, err := mcp.ConfiguredServers(tc); err == nil && len(servers) > 0 {
+			a.MCP = &tc
+			a.MCPServers = servers
+		}
+	}
+
+	var others []registry.Tool
+	for _, dt := range registry.DetectInstalled(reg) {
+		if dt.Tool.Name != tool.Name {
+			others = append(others, dt.Tool)
+		}
+	}
+	stored, _ := vault.New().List()
+	meta, _ := vault.LoadMeta()
+	a.Keys = orphanedKeys(*tool, others, stored, meta)
+	return a
+}
+
+// orphanedKeys returns the stored keys tool uses that no other installed
+// tool declares and nothing else (the proxy, other tools) has been given.
+func orphanedKeys(tool registry.Tool, others []registry.Tool, stored []string, meta map[string]*vault.KeyMeta) []string {
+	inVault := make(map[string]bool, len(stored))
+	for _, k := range stored {
+		inVault[k] = true
+	}
+	needed := make(map[string]bool)
+	for _, o := range others {
+		for _, k := range append(o.Keys.Required, o.Keys.Optional...) {
+			needed[k] = true
+		}
+	}
+
+	var orphans []string
+	for _, k := range append(tool.Keys.Required, tool.Keys.Optional...) {
+		if !inVault[k] || needed[k] || containsString(orphans, k) {
+			continue
+		}
+		if m, ok := meta[k]; ok && usedByOthers(m, tool.Name) {
+			continue
+		}
+		orphans = append(orphans, k)
+	}
+	sort.Strings(orphans)
+	return orphans
+}
+
+func usedByOthers(m *vault.KeyMeta, tool string) bool {
+	for consumer := range m.UsedBy {
+		if consumer != tool {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanArtifacts removes what a confirms, asking first unless yes is set,
+// and prints a summary. In dry-run mode it only lists what would go.
+func cleanArtifacts(tool *registry.Tool, a toolArtifacts, yes, dryRun bool) {
+	fmt.Println()
+	if a.empty() {
+		ui.Subtle.Println("  No leftover config files, MCP entries or keys")
+		return
+	}
+
+	if dryRun {
+		ui.Info.Println("  Would also clean up:")
+		for _, f := range a.Files {
+			fmt.Printf("    file  %s\n", f)
+		}
+		if a.MCP != nil {
+			fmt.Printf("    mcp   %v in %s\n", a.MCPServers, a.MCP.Path)
+		}
+		for _, k := range a.Keys {
+			fmt.Printf("    key   %s\n", k)
+		}
+		return
+	}
+
+	ask := func(q string) bool { return yes || confirmNo(q) }
+	var removed, kept []string
+
+	for _, f := range a.Files {
+		if !ask(fmt.Sprintf("  Delete %s?", f)) {
+			kept = append(kept, f)
+			continue
+		}
+		if err := os.Remove(f); err != nil {
+			ui.Bad.Printf("  %s %s: %v\n", ui.StatusIcon(false), f, err)
+			continue
+		}
+		removed = append(removed, "file "+f)
+	}
+
+	if a.MCP != nil {
+		if ask(fmt.Sprintf("  Remove %d MCP server(s) from %s?", len(a.MCPServers), a.MCP.Path)) {
+			names, err := mcp.RemoveServers(*a.MCP)
+			if err != nil {
+				ui.Bad.Printf("  %s MCP config: %v\n", ui.StatusIcon(false), err)
+			}
+			for _, n := range names {
+				removed = append(removed, "mcp  "+n)
+			}
+		} else {
+			kept = append(kept, a.MCP.Path)
+		}
+	}
+
+	if len(a.Keys) > 0 {
+		v := vault.New()
+		for _, k := range a.Keys {
+			if !ask(fmt.Sprintf("  %s was only used by %s. Delete it from the vault?", k, tool.DisplayName)) {
+				kept = append(kept, k)
+				continue
+			}
+			if err := v.Delete(k); err != nil {
+				ui.Bad.Printf("  %s %s: %v\n", ui.StatusIcon(false), k, err)
+				continue
+			}
+			_ = vault.RecordDelete(k)
+			removed = append(removed, "key  "+k)
+		}
+	}
+
+	fmt.Println()
+	if len(removed) > 0 {
+		ui.Good.Printf("  %s Cleaned up %d item(s):\n", ui.StatusIcon(true), len(removed))
+		for _, r := range removed {
+			fmt.Printf("    %s\n", r)
+		}
+	}
+	if len(kept) > 0 {
+		ui.Subtle.Printf("  Kept: %v\n", kept)
+	}
+}
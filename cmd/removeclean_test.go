@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/msalah0e/palm/internal/registry"
+	"github.com/msalah0e/palm/internal/vault"
+)
+
+func TestOrphanedKeys(t *testing.T) {
+	tool := registry.Tool{Name: "aider", Keys: registry.Keys{
+		Required: []string{"OPENAI_API_KEY"},
+		Optional: []string{"ANTHROPIC_API_KEY", "DEEPSEEK_API_KEY", "GROQ_API_KEY"},
+	}}
+	others := []registry.Tool{
+		{Name: "claude-code", Keys: registry.Keys{Required: []string{"ANTHROPIC_API_KEY"}}},
+	}
+	stored := []string{"OPENAI_API_KEY", "ANTHROPIC_API_KEY", "DEEPSEEK_API_KEY"}
+	meta := map[string]*vault.KeyMeta{
+		"DEEPSEEK_API_KEY": {UsedBy: map[string]time.Time{"proxy:deepseek": time.Now()}},
+		"OPENAI_API_KEY":   {UsedBy: map[string]time.Time{"aider": time.Now()}},
+	}
+
+	got := orphanedKeys(tool, others, stored, meta)
+	// ANTHROPIC is needed by claude-code, DEEPSEEK by the proxy, GROQ isn't stored
+	if want := []string{"OPENAI_API_KEY"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("orphanedKeys = %v, want %v", got, want)
+	}
+}
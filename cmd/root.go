@@ -17,6 +17,7 @@ var version = "1.5.1"
 var (
 	reg         *registry.Registry
 	registryFS  embed.FS
+	workflowFS  embed.FS
 	offlineMode bool
 )
 
@@ -25,6 +26,11 @@ func SetRegistryFS(fs embed.FS) {
 	registryFS = fs
 }
 
+// SetWorkflowFS sets the embedded filesystem containing built-in compose workflows.
+func SetWorkflowFS(fs embed.FS) {
+	workflowFS = fs
+}
+
 func loadRegistry() *registry.Registry {
 	if reg != nil {
 		return reg
@@ -55,8 +61,12 @@ var rootCmd = &cobra.Command{
 			showQuickMenu(r)
 		}
 	},
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		applyTheme()
+		applyKeyProfile()
+	},
 	PersistentPostRun: func(cmd *cobra.Command, args []string) {
-		if !offlineMode {
+		if !offlineMode && !jsonOutput {
 			update.CheckForUpdate(version)
 		}
 	},
@@ -97,11 +107,16 @@ func showQuickMenu(r *registry.Registry) {
 func init() {
 	rootCmd.SetVersionTemplate("palm {{ .Version }}\n")
 	rootCmd.PersistentFlags().BoolVar(&offlineMode, "offline", false, "Run without network access")
+	rootCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "Print machine-readable JSON instead of tables")
+	rootCmd.PersistentFlags().StringVar(&keyProfile, "profile", "", "Vault key profile to use (e.g. work, personal)")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Print without colors (also NO_COLOR=1)")
 
 	rootCmd.AddCommand(
 		installCmd(),
+		stacksCmd(),
 		removeCmd(),
 		updateCmd(),
+		outdatedCmd(),
 		listCmd(),
 		searchCmd(),
 		infoCmd(),
@@ -144,6 +159,13 @@ func init() {
 		pirateCmd(),
 		setupCmd(),
 		topCmd(),
+		dashCmd(),
+		chatCmd(),
+		sessionsCmd(),
+		devCmd(),
+		runsCmd(),
+		themeCmd(),
+		refreshCmd(),
 	)
 }
 
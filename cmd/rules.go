@@ -62,6 +62,7 @@ func rulesCmd() *cobra.Command {
 		rulesSyncCmd(),
 		rulesAddCmd(),
 		rulesCheckCmd(),
+		rulesLintCmd(),
 	)
 
 	return cmd
@@ -128,10 +129,25 @@ func rulesInitCmd() *cobra.Command {
 
 func rulesSyncCmd() *cobra.Command {
 	var tools []string
+	var force, noFit bool
 
 	cmd := &cobra.Command{
 		Use:   "sync",
 		Short: "Sync rules to all AI tool config files",
+		Long: `Write the rules source to each tool's rules file.
+
+Some tools truncate or ignore long instruction files, so each file is fitted
+to a token budget: the tool's documented limit, or [rules] budgets in the
+config (0 writes the rules whole). Over budget, the longest sections are
+condensed by a local ollama model when one is running ([rules]
+summarize_model, default llama3.2, "off" to disable), then sections marked
+<!-- palm:priority low --> are dropped, then unmarked ones from the end.
+Sections marked <!-- palm:priority high --> are kept. Trimmed files say so
+at the end, and sync lists what was cut.
+
+  [rules.budgets]
+  copilot = 800
+  claude-code = 0`,
 		Run: func(cmd *cobra.Command, args []string) {
 			ui.Banner("rules sync")
 
@@ -148,37 +164,43 @@ func rulesSyncCmd() *cobra.Command {
 				os.Exit(1)
 			}
 
-			targetTools := ruleFiles
-			if len(tools) > 0 {
-				targetTools = make(map[string]string)
-				for _, t := range tools {
-					if f, ok := ruleFiles[t]; ok {
-						targetTools[t] = f
-					}
-				}
-			}
-
-			synced := 0
-			for tool, file := range targetTools {
-				dir := filepath.Dir(file)
-				if dir != "." {
-					os.MkdirAll(dir, 0o755)
-				}
+			targetTools := selectRuleTargets(tools)
 
-				content := wrapRulesForTool(tool, string(baseContent))
-				if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
-					ui.Bad.Printf("  %s %s: %v\n", ui.StatusIcon(false), file, err)
+			// Lint before writing: a file the tool can't read is worse than none
+			var skipped []string
+			for _, r := range lintRuleTargets(source, string(baseContent), targetTools) {
+				if len(r.Issues) == 0 {
 					continue
 				}
-				ui.Good.Printf("  %s synced → %s (%s)\n", ui.StatusIcon(true), file, tool)
-				synced++
+				printRuleIssues(r)
+				if r.hasErrors() && !force {
+					skipped = append(skipped, r.Tool)
+				}
+			}
+			if len(skipped) > 0 {
+				kept := make(map[string]string)
+				for tool, file := range targetTools {
+					if !containsStr(skipped, tool) {
+						kept[tool] = file
+					}
+				}
+				targetTools = kept
+				ui.Subtle.Printf("  Skipping %s (lint errors; --force writes them anyway)\n", strings.Join(skipped, ", "))
 			}
+			fmt.Println()
 
+			var fitter *ruleFitter
+			if !noFit {
+				fitter = newRuleFitter(source)
+			}
+			synced := writeRuleFiles(string(baseContent), targetTools, fitter)
 			fmt.Printf("\n  %d files synced from %s\n", synced, source)
 		},
 	}
 
 	cmd.Flags().StringSliceVar(&tools, "tools", nil, "Specific tools to sync (default: all)")
+	cmd.Flags().BoolVar(&force, "force", false, "Write files even when lint finds errors")
+	cmd.Flags().BoolVar(&noFit, "no-fit", false, "Write the rules whole, ignoring each tool's token budget")
 	return cmd
 }
 
@@ -239,6 +261,32 @@ func rulesCheckCmd() *cobra.Command {
 	}
 }
 
+// writeRuleFiles writes content to each tool's rules file, fitted to the
+// tool's token budget unless fitter is nil, and returns how many were
+// written.
+func writeRuleFiles(content string, targets map[string]string, fitter *ruleFitter) int {
+	synced := 0
+	for tool, file := range targets {
+		dir := filepath.Dir(file)
+		if dir != "." {
+			os.MkdirAll(dir, 0o755)
+		}
+
+		fitted, fit := content, ruleFit{}
+		if fitter != nil {
+			fitted, fit = fitter.fit(tool, content, func(c string) string { return wrapRulesForTool(tool, c) })
+		}
+		if err := os.WriteFile(file, []byte(wrapRulesForTool(tool, fitted)), 0o644); err != nil {
+			ui.Bad.Printf("  %s %s: %v\n", ui.StatusIcon(false), file, err)
+			continue
+		}
+		ui.Good.Printf("  %s synced → %s (%s)\n", ui.StatusIcon(true), file, tool)
+		printRuleFit(fit)
+		synced++
+	}
+	return synced
+}
+
 func findRulesSource() string {
 	for _, name := range []string{".palm-rules.md", ".palm-context.md"} {
 		if _, err := os.Stat(name); err == nil {
@@ -250,6 +298,10 @@ func findRulesSource() string {
 
 func wrapRulesForTool(tool, content string) string {
 	header := fmt.Sprintf("# %s Rules\n# Generated by palm rules — edit .palm-rules.md and run `palm rules sync`\n# Do not edit this file directly.\n\n", titleCase(tool))
+	if tool == "cursor" {
+		// .mdc rules need frontmatter, or Cursor never applies them
+		return "---\ndescription: Project rules synced by palm\nglobs:\nalwaysApply: true\n---\n\n" + header + content
+	}
 	return header + content
 }
 
@@ -0,0 +1,288 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/msalah0e/palm/internal/config"
+	"github.com/msalah0e/palm/internal/tokens"
+	"github.com/msalah0e/palm/internal/ui"
+)
+
+// defaultSummarizeModel condenses sections when [rules] summarize_model
+// isn't set.
+const defaultSummarizeModel = "llama3.2"
+
+// ruleBudget returns a tool's token budget: [rules] budgets, or its
+// documented size limit at about four characters per token. 0 means none.
+func ruleBudget(cfg config.RulesConfig, tool string) int {
+	if b, ok := cfg.Budgets[tool]; ok {
+		return b
+	}
+	if lim, ok := ruleLimits[tool]; ok && lim.chars > 0 {
+		return lim.chars / 4
+	}
+	return 0
+}
+
+// ruleSection is a level-2 section of the rules, or the preamble before the
+// first one.
+type ruleSection struct {
+	head     string // heading line, "" for the preamble
+	body     string
+	title    string
+	priority string // "high", "low" or ""
+	dropped  bool
+}
+
+var (
+	sectionHeading = regexp.MustCompile(`^##\s+(.+?)\s*#*\s*$`)
+	sectionMarker  = regexp.MustCompile(`<!--\s*palm:priority\s+(high|low)\s*-->`)
+)
+
+// splitRuleSections cuts content at level-2 headings outside code fences.
+// Joining the sections gives content back.
+func splitRuleSections(content string) []ruleSection {
+	secs := []ruleSection{{}}
+	fenced := false
+	for _, line := range strings.SplitAfter(content, "\n") {
+		t := strings.TrimSpace(line)
+		if strings.HasPrefix(t, "```") || strings.HasPrefix(t, "~~~") {
+			fenced = !fenced
+		}
+		if m := sectionHeading.FindStringSubmatch(strings.TrimRight(line, "\r\n")); m != nil && !fenced {
+			secs = append(secs, ruleSection{head: line, title: m[1]})
+			continue
+		}
+		secs[len(secs)-1].body += line
+	}
+	for i := range secs {
+		if m := sectionMarker.FindStringSubmatch(secs[i].body); m != nil {
+			secs[i].priority = m[1]
+		}
+	}
+	return secs
+}
+
+func joinRuleSections(secs []ruleSection) string {
+	var b strings.Builder
+	for _, s := range secs {
+		if !s.dropped {
+			b.WriteString(s.head)
+			b.WriteString(s.body)
+		}
+	}
+	return b.String()
+}
+
+// ruleFit reports what fitting did to one tool's rules.
+type ruleFit struct {
+	Budget       int      `json:"budget"`
+	Before       int      `json:"before"`
+	After        int      `json:"after"`
+	Condensed    []string `json:"condensed,omitempty"`
+	Dropped      []string `json:"dropped,omitempty"`
+	Truncated    bool     `json:"truncated,omitempty"`
+	SummarizeErr string   `json:"summarize_error,omitempty"`
+}
+
+func (f ruleFit) trimmed() bool {
+	return len(f.Condensed)+len(f.Dropped) > 0 || f.Truncated
+}
+
+// summarizer condenses a section body to about target tokens.
+type summarizer func(body string, target int) (string, error)
+
+// fitRuleSections makes content fit budget tokens. It condenses the longest
+// sections with summarize when given, then drops sections marked
+// <!-- palm:priority low -->, then unmarked ones from the end, and as a last
+// resort cuts the end off. The preamble and sections marked high are never
+// dropped. Trimmed content ends with a note pointing at source.
+func fitRuleSections(content string, budget int, count func(string) int, summarize summarizer, source string) (string, ruleFit) {
+	fit := ruleFit{Budget: budget, Before: count(content)}
+	if budget <= 0 || fit.Before <= budget {
+		fit.After = fit.Before
+		return content, fit
+	}
+
+	note := fmt.Sprintf("\n<!-- palm trimmed this file to fit the tool's token budget; the full rules are in %s -->\n", source)
+	limit := budget - count(note)
+	secs := splitRuleSections(content)
+	over := func() int { return count(joinRuleSections(secs)) - limit }
+
+	if summarize != nil {
+		order := make([]int, 0, len(secs))
+		for i, s := range secs {
+			if s.head != "" {
+				order = append(order, i)
+			}
+		}
+		sizes := make(map[int]int, len(order))
+		for _, i := range order {
+			sizes[i] = count(secs[i].body)
+		}
+		sort.SliceStable(order, func(a, b int) bool { return sizes[order[a]] > sizes[order[b]] })
+		for _, i := range order {
+			excess := over()
+			if excess <= 0 {
+				break
+			}
+			target := max(sizes[i]-excess, sizes[i]/4)
+			out, err := summarize(secs[i].body, target)
+			if err != nil {
+				fit.SummarizeErr = err.Error()
+				break
+			}
+			out = "\n" + strings.TrimSpace(out) + "\n\n"
+			if count(out) >= sizes[i] {
+				continue
+			}
+			secs[i].body = out
+			fit.Condensed = append(fit.Condensed, secs[i].title)
+		}
+	}
+
+	for _, priority := range []string{"low", ""} {
+		for i := len(secs) - 1; i >= 0 && over() > 0; i-- {
+			if secs[i].head == "" || secs[i].dropped || secs[i].priority != priority {
+				continue
+			}
+			secs[i].dropped = true
+			fit.Dropped = append(fit.Dropped, secs[i].title)
+		}
+	}
+
+	out := joinRuleSections(secs)
+	if count(out) > limit {
+		// Keep the most whole lines that fit
+		lines := strings.SplitAfter(out, "\n")
+		lo, hi := 0, len(lines)
+		for lo < hi {
+			mid := (lo + hi + 1) / 2
+			if count(strings.Join(lines[:mid], "")) <= limit {
+				lo = mid
+			} else {
+				hi = mid - 1
+			}
+		}
+		out = strings.Join(lines[:lo], "")
+		fit.Truncated = true
+	}
+
+	out = strings.TrimRight(out, "\n") + "\n" + note
+	fit.After = count(out)
+	return out, fit
+}
+
+// ruleFitter fits rules to each tool's budget for one sync.
+type ruleFitter struct {
+	cfg       config.RulesConfig
+	source    string
+	count     func(string) int
+	summarize summarizer
+	probed    bool
+}
+
+func newRuleFitter(source string) *ruleFitter {
+	t := tokens.DefaultTokenizer()
+	return &ruleFitter{cfg: config.Load().Rules, source: source, count: t.Count}
+}
+
+// fit fits content to tool's budget once wrap has added the tool's header.
+func (f *ruleFitter) fit(tool, content string, wrap func(string) string) (string, ruleFit) {
+	budget := ruleBudget(f.cfg, tool)
+	overhead := f.count(wrap(""))
+	if budget <= 0 || f.count(content)+overhead <= budget {
+		n := f.count(content) + overhead
+		return content, ruleFit{Budget: budget, Before: n, After: n}
+	}
+	if !f.probed {
+		f.probed = true
+		f.summarize = ollamaSummarizer(f.cfg.SummarizeModel)
+	}
+	out, fit := fitRuleSections(content, budget-overhead, f.count, f.summarize, f.source)
+	fit.Budget, fit.Before, fit.After = budget, fit.Before+overhead, fit.After+overhead
+	return out, fit
+}
+
+// ollamaSummarizer condenses sections with a local ollama model, or returns
+// nil when ollama isn't running or summarizing is off.
+func ollamaSummarizer(model string) summarizer {
+	if model == "off" {
+		return nil
+	}
+	if model == "" {
+		model = defaultSummarizeModel
+	}
+	root := ollamaRoot()
+	probe := &http.Client{Timeout: time.Second}
+	resp, err := probe.Get(root + "/api/tags")
+	if err != nil {
+		return nil
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+
+	client := &http.Client{Timeout: 2 * time.Minute}
+	return func(body string, target int) (string, error) {
+		prompt := fmt.Sprintf(`Condense this section of a project's instructions for AI coding assistants to at most %d tokens.
+Keep every concrete rule, command, path and name; drop examples, explanations and repetition.
+Reply with the condensed Markdown only, without a heading.
+
+%s`, target, body)
+		return ollamaGenerate(client, root, model, prompt)
+	}
+}
+
+// ollamaGenerate sends prompt to model on the ollama server at root and
+// returns its reply.
+func ollamaGenerate(client *http.Client, root, model, prompt string) (string, error) {
+	payload, _ := json.Marshal(map[string]any{"model": model, "prompt": prompt, "stream": false})
+	resp, err := client.Post(root+"/api/generate", "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("ollama: %s (is %s pulled?)", resp.Status, model)
+	}
+	var out struct {
+		Response string `json:"response"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(out.Response) == "" {
+		return "", fmt.Errorf("ollama: %s returned nothing", model)
+	}
+	return out.Response, nil
+}
+
+// printRuleFit warns under a synced file when its rules were trimmed.
+func printRuleFit(fit ruleFit) {
+	if !fit.trimmed() {
+		return
+	}
+	ui.Warn.Printf("      %s trimmed %s → %s tokens to fit the %s-token budget\n",
+		ui.WarnIcon(), tokens.FormatTokens(fit.Before), tokens.FormatTokens(fit.After), tokens.FormatTokens(fit.Budget))
+	if len(fit.Condensed) > 0 {
+		ui.Subtle.Printf("        condensed: %s\n", strings.Join(fit.Condensed, ", "))
+	}
+	if len(fit.Dropped) > 0 {
+		ui.Subtle.Printf("        dropped: %s\n", strings.Join(fit.Dropped, ", "))
+	}
+	if fit.Truncated {
+		ui.Subtle.Println("        cut off the end")
+	}
+	if fit.SummarizeErr != "" {
+		ui.Subtle.Printf("        couldn't condense with a local model: %s\n", fit.SummarizeErr)
+	}
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/msalah0e/palm/internal/config"
+)
+
+// wordCount stands in for a tokenizer in tests.
+func wordCount(s string) int { return len(strings.Fields(s)) }
+
+func rulesWithSections(words int, names ...string) string {
+	var b strings.Builder
+	b.WriteString("# Project Rules\n\nLanguage: Go\n")
+	for _, n := range names {
+		marker := ""
+		switch n {
+		case "Testing":
+			marker = "<!-- palm:priority high -->\n"
+		case "Style":
+			marker = "<!-- palm:priority low -->\n"
+		}
+		fmt.Fprintf(&b, "\n## %s\n%s%s\n", n, marker, strings.TrimSpace(strings.Repeat("word ", words)))
+	}
+	return b.String()
+}
+
+func TestSplitRuleSections(t *testing.T) {
+	content := rulesWithSections(3, "Guidelines", "Style") + "\n```md\n## not a heading\n```\n"
+	secs := splitRuleSections(content)
+	if len(secs) != 3 {
+		t.Fatalf("got %d sections, want preamble + 2", len(secs))
+	}
+	if secs[2].title != "Style" || secs[2].priority != "low" {
+		t.Errorf("section = %+v", secs[2])
+	}
+	if joinRuleSections(secs) != content {
+		t.Error("joining the sections should give the content back")
+	}
+}
+
+func TestFitRuleSections(t *testing.T) {
+	content := rulesWithSections(20, "Guidelines", "Testing", "Style", "History")
+
+	out, fit := fitRuleSections(content, 1000, wordCount, nil, ".palm-rules.md")
+	if out != content || fit.trimmed() {
+		t.Error("content under budget should be left alone")
+	}
+
+	// Low priority goes first, then unmarked sections from the end; high stays
+	out, fit = fitRuleSections(content, 72, wordCount, nil, ".palm-rules.md")
+	if wordCount(out) > 72 {
+		t.Errorf("%d words, over the budget", wordCount(out))
+	}
+	if strings.Join(fit.Dropped, ",") != "Style,History" {
+		t.Errorf("dropped %v", fit.Dropped)
+	}
+	if !strings.Contains(out, "## Testing") || !strings.Contains(out, "Language: Go") {
+		t.Error("high priority sections and the preamble must be kept")
+	}
+	if !strings.Contains(out, "full rules are in .palm-rules.md") {
+		t.Error("trimmed output should say so")
+	}
+
+	// A summarizer is tried before anything is dropped
+	short := func(body string, target int) (string, error) { return "condensed", nil }
+	out, fit = fitRuleSections(content, 72, wordCount, short, ".palm-rules.md")
+	if len(fit.Dropped) != 0 || len(fit.Condensed) == 0 || !strings.Contains(out, "condensed") {
+		t.Errorf("summarizing should have been enough: %+v", fit)
+	}
+
+	failing := func(string, int) (string, error) { return "", fmt.Errorf("no model") }
+	_, fit = fitRuleSections(content, 72, wordCount, failing, ".palm-rules.md")
+	if fit.SummarizeErr == "" || len(fit.Dropped) == 0 {
+		t.Errorf("a failing summarizer should fall back to dropping: %+v", fit)
+	}
+
+	// Nothing left to drop: the end is cut off
+	out, fit = fitRuleSections(rulesWithSections(100, "Testing"), 40, wordCount, nil, ".palm-rules.md")
+	if !fit.Truncated || wordCount(out) > 40 {
+		t.Errorf("expected truncation to 40 words, got %d: %+v", wordCount(out), fit)
+	}
+}
+
+func TestRuleBudget(t *testing.T) {
+	cfg := config.RulesConfig{Budgets: map[string]int{"copilot": 800, "claude-code": 0}}
+	if got := ruleBudget(cfg, "copilot"); got != 800 {
+		t.Errorf("configured budget = %d", got)
+	}
+	if got := ruleBudget(cfg, "claude-code"); got != 0 {
+		t.Errorf("0 should turn fitting off, got %d", got)
+	}
+	if got := ruleBudget(cfg, "windsurf"); got != 1500 {
+		t.Errorf("default from the documented limit = %d, want 1500", got)
+	}
+	if got := ruleBudget(cfg, "aider"); got != 0 {
+		t.Errorf("tools without a limit have no budget, got %d", got)
+	}
+}
@@ -0,0 +1,337 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// ruleIssue is one problem with a tool's generated rules file. Errors mean
+// the tool can't use the file; warnings mean it will only partly.
+type ruleIssue struct {
+	Severity string `json:"severity"` // "error" or "warning"
+	Message  string `json:"message"`
+}
+
+// ruleLimits are the documented size limits of each tool's rules file.
+// Content past them is truncated or ignored by the tool.
+var ruleLimits = map[string]struct {
+	chars int
+	lines int
+	why   string
+}{
+	"claude-code": {chars: 40000, why: "Claude Code warns about large CLAUDE.md files and loads them into every session"},
+	"cursor":      {lines: 500, why: "Cursor recommends keeping each rule under 500 lines"},
+	"copilot":     {chars: 4000, why: "Copilot code review reads only the first 4,000 characters"},
+	"codex":       {chars: 32 * 1024, why: "Codex truncates AGENTS.md past 32 KiB"},
+	"windsurf":    {chars: 6000, why: "Windsurf ignores rules past 6,000 characters"},
+	"gemini":      {chars: 40000, why: "GEMINI.md is loaded into every session"},
+	"trae":        {chars: 40000, why: "project rules are loaded into every session"},
+}
+
+// importTools expand @path imports in their rules files.
+var importTools = map[string]bool{"claude-code": true, "gemini": true, "cursor": true}
+
+// mdcKeys are the frontmatter keys Cursor understands in .mdc rules.
+var mdcKeys = map[string]bool{"description": true, "globs": true, "alwaysApply": true}
+
+var (
+	importLine  = regexp.MustCompile(`^@(\S+)\s*$`)
+	placeholder = regexp.MustCompile(`<!-- (Describe|List) [^>]*here -->|<!-- List important files and their purpose -->`)
+)
+
+// lintRulesSource checks the rules source itself, for problems every
+// generated file would share.
+func lintRulesSource(content string) []ruleIssue {
+	var issues []ruleIssue
+	add := func(format string, args ...interface{}) {
+		issues = append(issues, ruleIssue{Severity: "warning", Message: fmt.Sprintf(format, args...)})
+	}
+
+	if strings.TrimSpace(stripRulesHeader(content)) == "" {
+		add("no rules yet")
+	}
+	if placeholder.MatchString(content) {
+		add("template placeholders left in; fill them in or remove them")
+	}
+
+	// Broken Markdown
+	fences := 0
+	for _, line := range strings.Split(content, "\n") {
+		if t := strings.TrimSpace(line); strings.HasPrefix(t, "```") || strings.HasPrefix(t, "~~~") {
+			fences++
+		}
+	}
+	if fences%2 != 0 {
+		add("unclosed code fence; everything after it renders as code")
+	}
+	if strings.Count(content, "<!--") > strings.Count(content, "-->") {
+		add("unclosed HTML comment; everything after it is hidden")
+	}
+
+	for _, path := range ruleImports(content) {
+		p := path
+		if strings.HasPrefix(p, "~/") {
+			home, _ := os.UserHomeDir()
+			p = filepath.Join(home, p[2:])
+		}
+		if _, err := os.Stat(p); err != nil {
+			add("@%s: imported file not found", path)
+		}
+	}
+	return issues
+}
+
+// ruleImports returns the paths of @path import lines.
+func ruleImports(content string) []string {
+	var paths []string
+	for _, line := range strings.Split(content, "\n") {
+		if m := importLine.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			paths = append(paths, m[1])
+		}
+	}
+	return paths
+}
+
+// lintRules checks content as it would be written to a tool's rules file,
+// against that tool's format and size constraints.
+func lintRules(tool, content string) []ruleIssue {
+	var issues []ruleIssue
+	add := func(severity, format string, args ...interface{}) {
+		issues = append(issues, ruleIssue{Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if tool == "aider" {
+		// aider's file holds YAML settings, so Markdown rules break it
+		add("error", "%s holds YAML settings, not Markdown rules; point aider at the rules with `read: .palm-rules.md` in it instead", ruleFiles[tool])
+		return issues
+	}
+
+	if tool == "cursor" {
+		if !lintMDCFrontmatter(content, add) {
+			return issues
+		}
+	} else if strings.HasPrefix(strings.TrimSpace(stripRulesHeader(content)), "---\n") {
+		add("warning", "YAML frontmatter is only understood by Cursor .mdc rules; %s shows it as text", tool)
+	}
+
+	if lim, ok := ruleLimits[tool]; ok {
+		if n := utf8.RuneCountInString(content); lim.chars > 0 && n > lim.chars {
+			add("warning", "%d characters, over the %d limit: %s", n, lim.chars, lim.why)
+		}
+		if n := strings.Count(content, "\n"); lim.lines > 0 && n > lim.lines {
+			add("warning", "%d lines, over the %d limit: %s", n, lim.lines, lim.why)
+		}
+	}
+
+	if !importTools[tool] {
+		for _, path := range ruleImports(content) {
+			add("warning", "@%s: %s doesn't expand @imports, so the file isn't included", path, tool)
+		}
+	}
+	return issues
+}
+
+// lintMDCFrontmatter checks the frontmatter Cursor needs at the top of an
+// .mdc rule. It reports false when there is none to check.
+func lintMDCFrontmatter(content string, add func(severity, format string, args ...interface{})) bool {
+	rest, ok := strings.CutPrefix(content, "---\n")
+	if !ok {
+		add("error", "missing frontmatter; Cursor .mdc rules start with --- description, globs and alwaysApply ---")
+		return false
+	}
+	front, _, ok := strings.Cut(rest, "\n---\n")
+	if !ok {
+		add("error", "frontmatter is not closed with ---")
+		return false
+	}
+
+	seen := make(map[string]string)
+	for _, line := range strings.Split(front, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(line, ":")
+		if !ok {
+			add("error", "frontmatter line %q is not key: value", line)
+			continue
+		}
+		key, val = strings.TrimSpace(key), strings.TrimSpace(val)
+		if !mdcKeys[key] {
+			add("warning", "unsupported frontmatter key %q (Cursor reads description, globs and alwaysApply)", key)
+		}
+		seen[key] = val
+	}
+	if v, ok := seen["alwaysApply"]; ok && v != "true" && v != "false" {
+		add("error", "alwaysApply must be true or false, not %q", v)
+	}
+	if seen["alwaysApply"] != "true" && seen["globs"] == "" && seen["description"] == "" {
+		add("warning", "the rule never applies: set alwaysApply: true, globs, or a description")
+	}
+	return true
+}
+
+// stripRulesHeader drops the comment header palm adds to generated files.
+func stripRulesHeader(content string) string {
+	lines := strings.Split(content, "\n")
+	i := 0
+	for i < len(lines) && (strings.HasPrefix(lines[i], "# ") && (strings.HasSuffix(lines[i], " Rules") ||
+		strings.Contains(lines[i], "palm rules") || strings.Contains(lines[i], "Do not edit"))) {
+		i++
+	}
+	return strings.Join(lines[i:], "\n")
+}
+
+// ruleLintResult is the lint verdict for one tool's rules file, or with no
+// tool, for the rules source.
+type ruleLintResult struct {
+	Tool   string      `json:"tool,omitempty"`
+	File   string      `json:"file"`
+	Issues []ruleIssue `json:"issues"`
+}
+
+func (r ruleLintResult) hasErrors() bool {
+	for _, i := range r.Issues {
+		if i.Severity == "error" {
+			return true
+		}
+	}
+	return false
+}
+
+// lintRuleTargets lints the source, then the file sync would write for each
+// target tool.
+func lintRuleTargets(source, content string, targets map[string]string) []ruleLintResult {
+	tools := make([]string, 0, len(targets))
+	for tool := range targets {
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+
+	results := make([]ruleLintResult, 0, len(tools)+1)
+	sourceIssues := lintRulesSource(content)
+	if sourceIssues == nil {
+		sourceIssues = []ruleIssue{}
+	}
+	results = append(results, ruleLintResult{File: source, Issues: sourceIssues})
+	for _, tool := range tools {
+		issues := lintRules(tool, wrapRulesForTool(tool, content))
+		if issues == nil {
+			issues = []ruleIssue{}
+		}
+		results = append(results, ruleLintResult{Tool: tool, File: targets[tool], Issues: issues})
+	}
+	return results
+}
+
+// printRuleIssues prints one tool's lint issues under its name.
+func printRuleIssues(r ruleLintResult) {
+	name := r.Tool
+	if name == "" {
+		name = "source"
+	}
+	switch {
+	case r.hasErrors():
+		ui.Bad.Printf("  %s %-14s %s\n", ui.StatusIcon(false), name, r.File)
+	case len(r.Issues) > 0:
+		ui.Warn.Printf("  %s %-14s %s\n", ui.WarnIcon(), name, r.File)
+	default:
+		fmt.Printf("  %s %-14s %s\n", ui.StatusIcon(true), name, r.File)
+	}
+	for _, i := range r.Issues {
+		if i.Severity == "error" {
+			ui.Bad.Printf("      error: %s\n", i.Message)
+		} else {
+			ui.Warn.Printf("      warning: %s\n", i.Message)
+		}
+	}
+}
+
+// selectRuleTargets narrows ruleFiles to tools, or returns all of them.
+func selectRuleTargets(tools []string) map[string]string {
+	if len(tools) == 0 {
+		return ruleFiles
+	}
+	targets := make(map[string]string)
+	for _, t := range tools {
+		if f, ok := ruleFiles[t]; ok {
+			targets[t] = f
+		}
+	}
+	return targets
+}
+
+func rulesLintCmd() *cobra.Command {
+	var tools []string
+	var strict bool
+
+	cmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Validate the rules files sync would write against each tool's constraints",
+		Long: `Check the file palm rules sync would write for each tool, before it is
+written:
+
+  errors     the tool can't use the file: Markdown in aider's YAML config,
+             missing or invalid .mdc frontmatter for Cursor
+  warnings   the tool will only partly use it: content over its size limit,
+             unclosed code fences or comments, leftover template
+             placeholders, @imports the tool doesn't expand or can't find
+
+Exits non-zero when there are errors, or any issue with --strict, so it can
+run in CI. palm rules sync skips files with errors unless --force is given.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			source := findRulesSource()
+			if source == "" {
+				ui.Warn.Println("  No .palm-rules.md or .palm-context.md found")
+				fmt.Println("  Run `palm rules init` first")
+				os.Exit(1)
+			}
+			content, err := os.ReadFile(source)
+			if err != nil {
+				ui.Bad.Printf("  Failed to read %s: %v\n", source, err)
+				os.Exit(1)
+			}
+
+			results := lintRuleTargets(source, string(content), selectRuleTargets(tools))
+			errors, warnings := 0, 0
+			for _, r := range results {
+				for _, i := range r.Issues {
+					if i.Severity == "error" {
+						errors++
+					} else {
+						warnings++
+					}
+				}
+			}
+
+			render(results, func() {
+				ui.Banner("rules lint")
+				fmt.Printf("  Source: %s\n\n", ui.Brand.Sprint(source))
+				for _, r := range results {
+					printRuleIssues(r)
+				}
+				fmt.Println()
+				if errors+warnings == 0 {
+					ui.Good.Printf("  %s %s and all %d rules files are valid\n", ui.StatusIcon(true), source, len(results)-1)
+				} else {
+					fmt.Printf("  Errors: %d, warnings: %d\n", errors, warnings)
+				}
+			})
+
+			if errors > 0 || (strict && warnings > 0) {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&tools, "tools", nil, "Specific tools to lint (default: all)")
+	cmd.Flags().BoolVar(&strict, "strict", false, "Fail on warnings too")
+	return cmd
+}
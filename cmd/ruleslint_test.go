@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func issueText(issues []ruleIssue) string {
+	var parts []string
+	for _, i := range issues {
+		parts = append(parts, i.Severity+": "+i.Message)
+	}
+	return strings.Join(parts, "\n")
+}
+
+func TestLintRules(t *testing.T) {
+	good := "# Rules\n\n- Keep changes small\n"
+
+	for tool := range ruleFiles {
+		issues := lintRules(tool, wrapRulesForTool(tool, good))
+		if tool == "aider" {
+			if len(issues) != 1 || issues[0].Severity != "error" {
+				t.Errorf("aider should reject Markdown rules, got %s", issueText(issues))
+			}
+			continue
+		}
+		if len(issues) != 0 {
+			t.Errorf("%s: generated file should lint clean, got\n%s", tool, issueText(issues))
+		}
+	}
+
+	if got := issueText(lintRules("cursor", good)); !strings.Contains(got, "error: missing frontmatter") {
+		t.Errorf("cursor without frontmatter: %s", got)
+	}
+	mdc := "---\ndescription:\nglobs:\nalwaysApply: yes\nauto: true\n---\n" + good
+	got := issueText(lintRules("cursor", mdc))
+	for _, want := range []string{`unsupported frontmatter key "auto"`, "alwaysApply must be true or false", "never applies"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("cursor frontmatter: missing %q in\n%s", want, got)
+		}
+	}
+
+	long := wrapRulesForTool("copilot", good+strings.Repeat("- rule\n", 700))
+	if got := issueText(lintRules("copilot", long)); !strings.Contains(got, "over the 4000 limit") {
+		t.Errorf("copilot size: %s", got)
+	}
+
+	imports := good + "@docs/style.md\n"
+	if got := issueText(lintRules("codex", imports)); !strings.Contains(got, "doesn't expand @imports") {
+		t.Errorf("codex imports: %s", got)
+	}
+	if got := lintRules("claude-code", imports); len(got) != 0 {
+		t.Errorf("claude-code expands imports: %s", issueText(got))
+	}
+}
+
+func TestLintRulesSource(t *testing.T) {
+	src := "# Project Rules\n\n<!-- Describe your project structure here -->\n\n```go\nx\n<!-- open\n@nope/missing.md\n"
+	got := issueText(lintRulesSource(src))
+	for _, want := range []string{"placeholders", "unclosed code fence", "unclosed HTML comment", "@nope/missing.md: imported file not found"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in\n%s", want, got)
+		}
+	}
+	if got := lintRulesSource("# Project Rules\n\n- Be terse\n"); len(got) != 0 {
+		t.Errorf("clean source: %s", issueText(got))
+	}
+	if got := issueText(lintRulesSource("# Project Rules\n")); !strings.Contains(got, "no rules yet") {
+		t.Errorf("empty source: %s", got)
+	}
+}
@@ -1,13 +1,20 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"os/exec"
 	"runtime"
 	"strings"
 	"syscall"
+	"time"
 
+	"github.com/msalah0e/palm/internal/activity"
+	"github.com/msalah0e/palm/internal/proxy"
+	"github.com/msalah0e/palm/internal/session"
 	"github.com/msalah0e/palm/internal/ui"
 	"github.com/msalah0e/palm/internal/vault"
 	"github.com/spf13/cobra"
@@ -15,11 +22,33 @@ import (
 
 func runCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:                "run <tool> [args...]",
-		Short:              "Run an AI tool with vault keys auto-injected",
+		Use:   "run <tool> [--record] [--sandbox [--allow <dir>]] [args...]",
+		Short: "Run an AI tool with vault keys auto-injected",
+		Long: `Run an AI tool with its API keys injected from the vault.
+
+With --record (right after the tool name) the invocation, redacted
+environment, piped stdin, output and duration are saved so the run can be
+inspected and replayed later with ` + "`palm sessions`" + `. Recorded tools see
+pipes rather than a terminal for their output.
+
+With --sandbox the tool may only write inside the project (the enclosing git
+repository, or the working directory) and temp space, enforced with
+bubblewrap on Linux and sandbox-exec on macOS. Tools that keep state in
+your home directory need it allowed with --allow. Without either sandbox,
+palm runs the tool normally and lists the files it changed outside the
+project afterwards.`,
+		Example: `  palm run aider --model gpt-4o
+  echo "explain main.go" | palm run llm --record
+  palm run claude-code --sandbox --allow ~/.claude`,
 		Args:               cobra.MinimumNArgs(1),
 		DisableFlagParsing: true,
 		Run: func(cmd *cobra.Command, args []string) {
+			opts, args := splitRunArgs(args)
+			if len(args) == 0 {
+				ui.Bad.Println("palm: run needs a tool name")
+				os.Exit(1)
+			}
+
 			reg := loadRegistry()
 			toolName := args[0]
 			toolArgs := args[1:]
@@ -53,26 +82,61 @@ func runCmd() *cobra.Command {
 			// Build environment with vault keys injected
 			env := os.Environ()
 			v := vault.New()
+			var injected []string
+			proj, projPath := loadProject()
 
 			if tool != nil {
 				allKeys := append(tool.Keys.Required, tool.Keys.Optional...)
-				injected := 0
 				for _, key := range allKeys {
 					// Don't override keys already set in environment
 					if os.Getenv(key) != "" {
 						continue
 					}
+					if !proj.allowsKey(key) {
+						if containsStr(tool.Keys.Required, key) {
+							ui.Warn.Fprintf(os.Stderr, "palm: %s is required by %s but not listed in %s keys\n", key, toolName, projPath)
+						}
+						continue
+					}
 					val, err := v.Get(key)
-					if err == nil {
-						env = append(env, fmt.Sprintf("%s=%s", key, val))
-						injected++
+					if err != nil {
+						warnUnresolved(v, err, key)
+						continue
 					}
+					env = append(env, fmt.Sprintf("%s=%s", key, val))
+					injected = append(injected, key)
 				}
-				if injected > 0 {
-					ui.Subtle.Fprintf(os.Stderr, "palm: injected %d key(s) from vault\n", injected)
+				if len(injected) > 0 {
+					if p := vault.Profile(); p != "" {
+						ui.Subtle.Fprintf(os.Stderr, "palm: injected %d key(s) from vault profile %s\n", len(injected), p)
+					} else {
+						ui.Subtle.Fprintf(os.Stderr, "palm: injected %d key(s) from vault\n", len(injected))
+					}
+					_ = vault.RecordUse(toolName, injected...)
 				}
 			}
 
+			env = proj.applyEnv(env)
+			env = tagProxyEnv(env, toolName)
+
+			if opts.sandbox {
+				argv, mode, project := sandboxArgv(binPath, toolArgs, opts.allow)
+				_ = activity.Append(activity.Entry{Action: "run", Tool: toolName, Details: "sandbox: " + string(mode)})
+				if opts.record {
+					start := auditStart()
+					code := runRecorded(toolName, argv[0], argv[1:], env, injected)
+					reportSandboxAudit(project, start, mode)
+					os.Exit(code)
+				}
+				os.Exit(runSandboxed(argv, env, mode, project))
+			}
+
+			_ = activity.Append(activity.Entry{Action: "run", Tool: toolName})
+
+			if opts.record {
+				os.Exit(runRecorded(toolName, binPath, toolArgs, env, injected))
+			}
+
 			// On Unix, replace this process with the tool.
 			// On Windows, use exec.Command (syscall.Exec not supported).
 			if runtime.GOOS == "windows" {
@@ -99,3 +163,102 @@ func runCmd() *cobra.Command {
 		},
 	}
 }
+
+// runRecorded runs a tool as a child process, teeing its output, and saves
+// the invocation as a replayable recording. It returns the tool's exit code.
+func runRecorded(toolName, binPath string, args, env, injected []string) int {
+	rec := &session.Recording{
+		ID:        session.NewID(time.Now()),
+		Tool:      toolName,
+		Bin:       binPath,
+		Args:      args,
+		Env:       session.RedactEnv(env, injected...),
+		Injected:  injected,
+		StartedAt: time.Now(),
+	}
+	rec.Dir, _ = os.Getwd()
+
+	var stdin io.Reader = os.Stdin
+	if stdinPiped() {
+		data, _ := io.ReadAll(os.Stdin)
+		rec.Stdin = string(data)
+		stdin = bytes.NewReader(data)
+	}
+
+	stdout, stderr, code := runCaptured(binPath, args, env, rec.Dir, stdin)
+	rec.Duration = time.Since(rec.StartedAt).Seconds()
+	rec.ExitCode = code
+	rec.Stdout = session.ScrubSecrets(stdout.String(), env, injected...)
+	rec.Stderr = session.ScrubSecrets(stderr.String(), env, injected...)
+	rec.Stdin = session.ScrubSecrets(rec.Stdin, env, injected...)
+	rec.Truncated = stdout.Truncated || stderr.Truncated
+
+	if err := session.SaveRecording(rec); err != nil {
+		ui.Bad.Fprintf(os.Stderr, "palm: failed to save recording: %v\n", err)
+		return code
+	}
+	_ = session.Record(toolName, time.Duration(rec.Duration*float64(time.Second)), code, 0, 0, "")
+	ui.Subtle.Fprintf(os.Stderr, "palm: recorded session %s (palm sessions show %s)\n", rec.ID, rec.ID)
+	return code
+}
+
+// runCaptured runs bin, copying its output to the terminal and capturing it.
+// A tool that can't be started exits 127, like a shell.
+func runCaptured(bin string, args, env []string, dir string, stdin io.Reader) (stdout, stderr *session.Capture, code int) {
+	stdout, stderr = &session.Capture{}, &session.Capture{}
+	c := exec.Command(bin, args...)
+	c.Env = env
+	c.Dir = dir
+	c.Stdin = stdin
+	c.Stdout = io.MultiWriter(os.Stdout, stdout)
+	c.Stderr = io.MultiWriter(os.Stderr, stderr)
+	if err := c.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return stdout, stderr, exitErr.ExitCode()
+		}
+		ui.Bad.Fprintf(os.Stderr, "palm: failed to run %s: %v\n", bin, err)
+		return stdout, stderr, 127
+	}
+	return stdout, stderr, 0
+}
+
+// tagProxyEnv tags base URLs in env that point at a running palm proxy with
+// the tool name, so the proxy can attribute the tool's spending to it.
+func tagProxyEnv(env []string, toolName string) []string {
+	isProxy := make(map[string]bool)
+	tagged := 0
+	for i, kv := range env {
+		key, val, ok := strings.Cut(kv, "=")
+		if !ok || val == "" || !(strings.HasSuffix(key, "_BASE_URL") || strings.HasSuffix(key, "_API_BASE")) {
+			continue
+		}
+		origin := val
+		if u, err := url.Parse(val); err == nil {
+			origin = u.Scheme + "://" + u.Host
+		}
+		proxied, seen := isProxy[origin]
+		if !seen {
+			proxied = proxy.IsProxyURL(val)
+			isProxy[origin] = proxied
+		}
+		if !proxied {
+			continue
+		}
+		tagURL, err := proxy.ToolURL(val, toolName)
+		if err != nil || tagURL == val {
+			continue
+		}
+		env[i] = key + "=" + tagURL
+		tagged++
+	}
+	if tagged > 0 {
+		ui.Subtle.Fprintf(os.Stderr, "palm: tagged %d proxy base URL(s) for cost attribution\n", tagged)
+	}
+	return env
+}
+
+// stdinPiped reports whether stdin is a pipe or file rather than a terminal.
+func stdinPiped() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice == 0
+}
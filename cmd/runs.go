@@ -0,0 +1,405 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/msalah0e/palm/internal/gpu"
+	"github.com/msalah0e/palm/internal/registry"
+	"github.com/msalah0e/palm/internal/runs"
+	"github.com/msalah0e/palm/internal/top"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func runsCmd() *cobra.Command {
+	var kind string
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "runs",
+		Short: "Recorded eval, speedtest and squad runs — show, compare, rerun",
+		Long: `Every eval, eval suite, speedtest and squad run records a manifest in
+~/.config/palm/runs/: the prompts, flags and seed, each tool's version and
+model, a fingerprint of the machine, and the results. Manifests make
+numbers reproducible and comparable across machines and over time.
+
+  palm runs                            # recent runs
+  palm runs show 20260301-0930         # any unique ID prefix works
+  palm runs compare <id-a> <id-b>      # setup differences and metric changes
+
+The seed is exported to tools as PALM_SEED. Rerun with the same --seed to
+repeat a run as closely as the tools allow.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			all, err := runs.List()
+			if err != nil {
+				ui.Bad.Printf("  Failed to read runs: %v\n", err)
+				os.Exit(1)
+			}
+			var list []runs.Manifest
+			for _, m := range all {
+				if kind == "" || m.Kind == kind {
+					list = append(list, m)
+				}
+			}
+			if limit > 0 && len(list) > limit {
+				list = list[:limit]
+			}
+
+			render(list, func() {
+				ui.Banner("runs")
+				if len(list) == 0 {
+					fmt.Println("  No runs recorded yet")
+					fmt.Println("  Runs of palm eval, speedtest and squad are recorded automatically")
+					return
+				}
+				var rows [][]string
+				for _, m := range list {
+					var tools []string
+					for _, t := range m.Tools {
+						tools = append(tools, t.Name)
+					}
+					rows = append(rows, []string{m.ID, m.Kind, truncate(strings.Join(tools, ", "), 30), strconv.FormatInt(m.Seed, 10), m.Env.Fingerprint()})
+				}
+				ui.Table([]string{"ID", "Kind", "Tools", "Seed", "Env"}, rows)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&kind, "kind", "", "Only list runs of this kind (eval, eval-suite, speedtest, squad)")
+	cmd.Flags().IntVar(&limit, "limit", 20, "Most runs to list (0 = all)")
+	cmd.AddCommand(runsShowCmd(), runsCompareCmd())
+	return cmd
+}
+
+func runsShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <id>",
+		Short: "Show a run's manifest",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			m := loadRun(args[0])
+
+			render(m, func() {
+				ui.Banner("run " + m.ID)
+				fmt.Printf("  Kind:  %s\n", ui.Brand.Sprint(m.Kind))
+				fmt.Printf("  Time:  %s\n", m.Time.Format("2006-01-02 15:04:05"))
+				fmt.Printf("  Seed:  %d\n", m.Seed)
+				fmt.Printf("  Env:   %s %s\n", m.Env.Fingerprint(), ui.Subtle.Sprint(describeRunEnv(m.Env)))
+				for i, p := range m.Prompts {
+					if i == 5 {
+						fmt.Printf("         %s\n", ui.Subtle.Sprintf("… %d more", len(m.Prompts)-5))
+						break
+					}
+					label := "       "
+					if i == 0 {
+						label = "Prompt:"
+					}
+					fmt.Printf("  %s %s\n", label, truncatePrompt(p, 80))
+				}
+				fmt.Println()
+
+				var rows [][]string
+				for _, t := range m.Tools {
+					rows = append(rows, []string{t.Name, orDash(t.Version), orDash(t.Model)})
+				}
+				ui.Table([]string{"Tool", "Version", "Model"}, rows)
+				fmt.Println()
+
+				headers, rows := runResultRows(m.Results)
+				ui.Table(headers, rows)
+				fmt.Println()
+				fmt.Printf("  Rerun: %s\n", ui.Info.Sprint(rerunCommand(m)))
+			})
+		},
+	}
+}
+
+func runsCompareCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "compare <id-a> <id-b>",
+		Short: "Compare two runs' setups and results",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			a, b := loadRun(args[0]), loadRun(args[1])
+			report := struct {
+				A           string       `json:"a"`
+				B           string       `json:"b"`
+				Differences []string     `json:"differences"`
+				Deltas      []runs.Delta `json:"deltas"`
+			}{a.ID, b.ID, runs.Differences(a, b), runs.Compare(a, b)}
+
+			render(report, func() {
+				ui.Banner("runs compare")
+				fmt.Printf("  A: %s\n  B: %s\n\n", ui.Brand.Sprint(a.ID), ui.Brand.Sprint(b.ID))
+				if len(report.Differences) == 0 {
+					fmt.Printf("  %s Same setup: seed, prompts, tool versions and environment match\n", ui.StatusIcon(true))
+				} else {
+					for _, d := range report.Differences {
+						fmt.Printf("  %s %s\n", ui.WarnIcon(), d)
+					}
+				}
+				fmt.Println()
+
+				if len(report.Deltas) == 0 {
+					fmt.Println("  Neither run has results to compare")
+					return
+				}
+				var rows [][]string
+				for _, d := range report.Deltas {
+					rows = append(rows, []string{d.Tool, d.Metric, formatRunMetric(d.A, d.HasA), formatRunMetric(d.B, d.HasB), formatRunChange(d)})
+				}
+				ui.Table([]string{"Tool", "Metric", "A", "B", "Change"}, rows)
+			})
+		},
+	}
+}
+
+func loadRun(id string) *runs.Manifest {
+	m, err := runs.Load(id)
+	if err != nil {
+		ui.Bad.Printf("  %v\n", err)
+		fmt.Println("  List runs with `palm runs`")
+		os.Exit(1)
+	}
+	return m
+}
+
+// runResultRows tables results with a column per metric.
+func runResultRows(results []runs.Result) ([]string, [][]string) {
+	var metrics []string
+	seen := make(map[string]bool)
+	for _, r := range results {
+		for name := range r.Metrics {
+			if !seen[name] {
+				seen[name] = true
+				metrics = append(metrics, name)
+			}
+		}
+	}
+	sort.Strings(metrics)
+
+	headers := append(append([]string{"Tool"}, metrics...), "Status")
+	var rows [][]string
+	for _, r := range results {
+		row := []string{r.Tool}
+		for _, name := range metrics {
+			v, ok := r.Metrics[name]
+			row = append(row, formatRunMetric(v, ok))
+		}
+		status := ui.StatusIcon(true) + " ok"
+		if r.Error != "" {
+			status = ui.StatusIcon(false) + " " + truncate(r.Error, 30)
+		}
+		rows = append(rows, append(row, status))
+	}
+	return headers, rows
+}
+
+func formatRunMetric(v float64, ok bool) string {
+	if !ok {
+		return "-"
+	}
+	return strconv.FormatFloat(math.Round(v*1000)/1000, 'f', -1, 64)
+}
+
+func formatRunChange(d runs.Delta) string {
+	if !d.HasA || !d.HasB {
+		return "-"
+	}
+	change := formatRunMetric(d.Change(), true)
+	if d.Change() >= 0 {
+		change = "+" + change
+	}
+	if d.A != 0 {
+		change += fmt.Sprintf(" (%+.0f%%)", d.Change()/d.A*100)
+	}
+	return change
+}
+
+func describeRunEnv(e runs.Env) string {
+	parts := []string{"palm " + e.Palm, e.OS + "/" + e.Arch, fmt.Sprintf("%d CPUs", e.CPUs)}
+	if e.MemoryMB > 0 {
+		parts = append(parts, gpu.FormatMB(e.MemoryMB))
+	}
+	return strings.Join(append(parts, e.GPUs...), ", ")
+}
+
+// rerunCommand is the palm command that repeats m with its seed.
+func rerunCommand(m *runs.Manifest) string {
+	args := []string{"palm"}
+	for _, a := range m.Command {
+		args = append(args, shellQuote(a))
+	}
+	if _, ok := m.Flags["seed"]; !ok {
+		args = append(args, "--seed", strconv.FormatInt(m.Seed, 10))
+	}
+	return strings.Join(args, " ")
+}
+
+// evalRunResults pairs each tool's run with its scores for the run manifest.
+func evalRunResults(toolNames []string, results []SquadResult, scores []evalScore) []runs.Result {
+	var out []runs.Result
+	for i, s := range scores {
+		r := runs.Result{Tool: toolNames[i], Output: results[i].Output, Error: results[i].Error}
+		if r.Error == "" {
+			r.Metrics = map[string]float64{
+				"overall":       float64(s.Overall),
+				"accuracy":      float64(s.Accuracy),
+				"hallucination": float64(s.Hallucination),
+				"completeness":  float64(s.Completeness),
+				"clarity":       float64(s.Clarity),
+				"seconds":       results[i].Duration.Seconds(),
+			}
+			if s.Grounded >= 0 {
+				r.Metrics["grounded"] = float64(s.Grounded)
+			}
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// speedRunResults converts speedtest results for the run manifest.
+func speedRunResults(results []SpeedResult) []runs.Result {
+	var out []runs.Result
+	for _, r := range results {
+		res := runs.Result{Tool: r.Provider, Error: r.Error}
+		if r.Error == "" {
+			res.Metrics = map[string]float64{
+				"tps":             r.TPS,
+				"seconds":         r.TotalTime.Seconds(),
+				"latency_seconds": r.Latency.Seconds(),
+				"tokens":          float64(r.TokensEst),
+			}
+			addQualityMetrics(res.Metrics, r.TPS, r.Score)
+		}
+		out = append(out, res)
+	}
+	return out
+}
+
+// benchRunResults converts benchmark mode results for the run manifest.
+func benchRunResults(results []BenchResult) []runs.Result {
+	var out []runs.Result
+	for _, r := range results {
+		res := runs.Result{Tool: r.Tool, Error: r.Error, Output: r.Output}
+		if r.Error == "" {
+			res.Metrics = map[string]float64{"seconds": r.Duration.Seconds(), "output_chars": float64(len(r.Output))}
+			addQualityMetrics(res.Metrics, r.TPS(), r.Score)
+		}
+		out = append(out, res)
+	}
+	return out
+}
+
+// addQualityMetrics records the judge's score and useful throughput when a
+// speedtest was judged.
+func addQualityMetrics(m map[string]float64, tps float64, s *evalScore) {
+	if s == nil {
+		return
+	}
+	m["quality"] = float64(s.Overall)
+	m["useful_tps"] = usefulTPS(tps, s)
+}
+
+// squadRunResults converts squad results and their costs for the run
+// manifest, under the tool names asked for.
+func squadRunResults(toolNames []string, results []SquadResult, costs []squadCost) []runs.Result {
+	var out []runs.Result
+	for i, r := range results {
+		res := runs.Result{Tool: toolNames[i], Error: r.Error, Output: r.Output}
+		if r.Error == "" {
+			res.Metrics = map[string]float64{
+				"seconds":        r.Duration.Seconds(),
+				"output_chars":   float64(len(r.Output)),
+				"cost_estimated": costs[i].Estimated,
+				"cost":           costs[i].Actual,
+			}
+		}
+		out = append(out, res)
+	}
+	return out
+}
+
+// addSeedFlag adds --seed to a command that records runs.
+func addSeedFlag(cmd *cobra.Command, seed *int64) {
+	cmd.Flags().Int64Var(seed, "seed", 0, "Seed exported to tools as PALM_SEED and recorded with the run (default: random)")
+}
+
+// applySeed picks a seed when none was given and exports it to the tools
+// the run starts.
+func applySeed(seed *int64) {
+	if *seed == 0 {
+		*seed = rand.Int63n(1<<31-1) + 1
+	}
+	os.Setenv("PALM_SEED", strconv.FormatInt(*seed, 10))
+}
+
+// recordRun saves a manifest for a finished run and says where it went.
+// Recording is best effort: a run never fails because of it.
+func recordRun(cmd *cobra.Command, kind string, seed int64, prompts, toolNames []string, results []runs.Result) {
+	m := &runs.Manifest{
+		Kind:    kind,
+		Command: os.Args[1:],
+		Seed:    seed,
+		Prompts: prompts,
+		Flags:   make(map[string]string),
+		Tools:   runTools(loadRegistry(), toolNames),
+		Env:     runEnv(),
+		Results: results,
+	}
+	cmd.Flags().Visit(func(f *pflag.Flag) { m.Flags[f.Name] = f.Value.String() })
+	if err := runs.Save(m); err != nil {
+		ui.Warn.Fprintf(os.Stderr, "  %s Run not recorded: %v\n", ui.WarnIcon(), err)
+		return
+	}
+	if !jsonOutput {
+		ui.Subtle.Printf("\n  Run %s recorded · palm runs show %s\n", m.ID, m.ID)
+	}
+}
+
+// runTools looks up the version and default model of each tool.
+func runTools(reg *registry.Registry, names []string) []runs.Tool {
+	var tools []runs.Tool
+	seen := make(map[string]bool)
+	detected := detectTools(reg, names)
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		t := runs.Tool{Name: name}
+		t.Version = detected[name].Version
+		if m := toolModel(reg, name); m != nil {
+			t.Model = m.ID
+		}
+		tools = append(tools, t)
+	}
+	return tools
+}
+
+func runEnv() runs.Env {
+	gpus := gpu.Detect()
+	e := runs.Env{
+		Palm:     version,
+		OS:       runtime.GOOS,
+		Arch:     runtime.GOARCH,
+		CPUs:     runtime.NumCPU(),
+		MemoryMB: int(top.Stats(nil).MemTotal),
+	}
+	for _, g := range gpus {
+		e.GPUs = append(e.GPUs, strings.TrimSpace(g.Vendor+" "+g.Model))
+	}
+	if host, err := os.Hostname(); err == nil {
+		e.Host = runs.HashHost(host)
+	}
+	return e
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/msalah0e/palm/internal/runs"
+)
+
+func TestRerunCommand(t *testing.T) {
+	m := &runs.Manifest{Seed: 42, Command: []string{"eval", "What is 2+2?", "--tools", "ollama,mods"}, Flags: map[string]string{"tools": "ollama,mods"}}
+	if got := rerunCommand(m); got != "palm eval 'What is 2+2?' --tools ollama,mods --seed 42" {
+		t.Errorf("rerun = %s", got)
+	}
+	m.Flags["seed"] = "42"
+	m.Command = append(m.Command, "--seed", "42")
+	if got := rerunCommand(m); strings.Count(got, "--seed") != 1 {
+		t.Errorf("a run given --seed should not repeat it: %s", got)
+	}
+
+	seed := int64(0)
+	t.Setenv("PALM_SEED", "")
+	applySeed(&seed)
+	if seed <= 0 || os.Getenv("PALM_SEED") != fmt.Sprint(seed) {
+		t.Errorf("applySeed should pick and export a seed: %d, %q", seed, os.Getenv("PALM_SEED"))
+	}
+	if formatRunChange(runs.Delta{A: 40, B: 50, HasA: true, HasB: true}) != "+10 (+25%)" || formatRunMetric(0.00064, true) != "0.001" {
+		t.Error("unexpected metric formatting")
+	}
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/msalah0e/palm/internal/sandbox"
+	"github.com/msalah0e/palm/internal/ui"
+)
+
+// runOptions are the palm flags of `palm run`, given before the tool's own
+// arguments.
+type runOptions struct {
+	record  bool
+	sandbox bool
+	allow   []string // extra writable directories in the sandbox
+}
+
+// splitRunArgs separates palm's flags from the tool name and the tool's
+// arguments. Palm flags go right before or right after the tool name.
+func splitRunArgs(args []string) (runOptions, []string) {
+	var opts runOptions
+	take := func(args []string) []string {
+		for len(args) > 0 {
+			switch a := args[0]; {
+			case a == "--record":
+				opts.record = true
+			case a == "--sandbox":
+				opts.sandbox = true
+			case a == "--allow" && len(args) > 1:
+				opts.allow = append(opts.allow, args[1])
+				args = args[1:]
+			case strings.HasPrefix(a, "--allow="):
+				opts.allow = append(opts.allow, strings.TrimPrefix(a, "--allow="))
+			default:
+				return args
+			}
+			args = args[1:]
+		}
+		return args
+	}
+
+	args = take(args)
+	if len(args) == 0 {
+		return opts, args
+	}
+	return opts, append([]string{args[0]}, take(args[1:])...)
+}
+
+// projectRoot is the directory a sandboxed tool may write to: the git
+// repository around the working directory, or the working directory itself.
+func projectRoot() string {
+	if out, err := exec.Command("git", "rev-parse", "--show-toplevel").Output(); err == nil {
+		if root := strings.TrimSpace(string(out)); root != "" {
+			return root
+		}
+	}
+	wd, _ := os.Getwd()
+	return wd
+}
+
+// sandboxArgv wraps binPath in the strongest sandbox available and says
+// which it picked.
+func sandboxArgv(binPath string, toolArgs []string, allow []string) (argv []string, mode sandbox.Mode, project string) {
+	project = projectRoot()
+	mode = sandbox.Detect()
+	argv = sandbox.Command(mode, binPath, toolArgs, project, sandbox.Writable(allow))
+
+	switch mode {
+	case sandbox.AuditOnly:
+		ui.Warn.Fprintf(os.Stderr, "palm: no sandbox available (install bubblewrap); writes outside %s will be reported, not blocked\n", project)
+	default:
+		ui.Subtle.Fprintf(os.Stderr, "palm: sandboxed with %s — writable: %s\n", mode, project)
+	}
+	return argv, mode, project
+}
+
+// reportSandboxAudit lists files changed during the run, in the project and
+// in the home directory outside it.
+func reportSandboxAudit(project string, since time.Time, mode sandbox.Mode) {
+	inside := sandbox.ModifiedSince(project, since, nil, 32, 0)
+	home, _ := os.UserHomeDir()
+	var outside []string
+	if home != "" {
+		outside = sandbox.ModifiedSince(home, since, []string{project, palmConfigDir()}, 6, 20)
+	}
+
+	fmt.Fprintln(os.Stderr)
+	ui.Subtle.Fprintf(os.Stderr, "palm: %d file(s) modified in %s\n", len(inside), project)
+	if len(outside) == 0 {
+		return
+	}
+	verb := "modified"
+	if mode != sandbox.AuditOnly {
+		// Blocked writes can't show up here; these were allowed with --allow
+		verb = "modified in allowed directories"
+	}
+	ui.Warn.Fprintf(os.Stderr, "palm: %d file(s) %s outside the project:\n", len(outside), verb)
+	for _, path := range outside {
+		if rel, err := filepath.Rel(home, path); err == nil {
+			path = filepath.Join("~", rel)
+		}
+		fmt.Fprintf(os.Stderr, "    %s\n", path)
+	}
+}
+
+// auditStart is the time from which changes count as the tool's. File
+// systems keep coarse modification times, so it starts a little early.
+func auditStart() time.Time {
+	return time.Now().Add(-50 * time.Millisecond)
+}
+
+// runSandboxed runs argv as a child process and audits what it changed.
+// It returns the tool's exit code.
+func runSandboxed(argv, env []string, mode sandbox.Mode, project string) int {
+	start := auditStart()
+	c := exec.Command(argv[0], argv[1:]...)
+	c.Env = env
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	code := 0
+	if err := c.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			code = exitErr.ExitCode()
+		} else {
+			ui.Bad.Fprintf(os.Stderr, "palm: failed to run %s: %v\n", argv[0], err)
+			return 127
+		}
+	}
+	reportSandboxAudit(project, start, mode)
+	return code
+}
@@ -1,12 +1,20 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/msalah0e/palm/internal/activity"
 	"github.com/msalah0e/palm/internal/gpu"
+	"github.com/msalah0e/palm/internal/proxy"
 	"github.com/msalah0e/palm/internal/serve"
 	"github.com/msalah0e/palm/internal/ui"
 	"github.com/spf13/cobra"
@@ -21,18 +29,28 @@ automatic GPU detection and model management.
 
   palm serve start             # Start default model
   palm serve start --model codellama  # Start specific model
+  palm serve start --daemon    # Run detached, logging to a file
   palm serve stop              # Stop running server
+  palm serve restart           # Restart a daemon
   palm serve status            # Show status
+  palm serve logs -f           # Follow a daemon's log
   palm serve models            # List downloadable models
-  palm serve pull llama3.3     # Download a model`,
+  palm serve pull llama3.3     # Download a model
+  palm serve du                # Disk used by downloaded models
+  palm serve prune --unused 30d  # Delete models unused for 30 days`,
 	}
 
 	cmd.AddCommand(
 		serveStartCmd(),
 		serveStopCmd(),
+		serveRestartCmd(),
 		serveStatusCmd(),
+		serveLogsCmd(),
+		serveSuperviseCmd(),
 		serveModelsCmd(),
 		servePullCmd(),
+		serveDuCmd(),
+		servePruneCmd(),
 	)
 
 	return cmd
@@ -40,49 +58,95 @@ automatic GPU detection and model management.
 
 func serveStartCmd() *cobra.Command {
 	var (
-		model  string
-		useGPU bool
+		model   string
+		runtime string
+		port    int
+		ctxSize int
+		useGPU  bool
+		daemon  bool
+		timeout time.Duration
 	)
 
 	cmd := &cobra.Command{
 		Use:   "start",
 		Short: "Start a local LLM server",
+		Long: `Start a local LLM runtime. ollama opens an interactive session; llama.cpp
+(llama-server) and vLLM run as OpenAI-compatible servers that palm tracks,
+health-checks on /v1/models and registers with palm proxy.
+
+With --daemon the server (ollama included) runs detached under a palm
+supervisor that writes its PID and a rotating log to ~/.config/palm/serve/.
+Follow it with palm serve logs -f and manage it with palm serve restart/stop.
+
+GPU offload is configured from palm gpu: llama.cpp offloads all layers and
+splits them across GPUs by memory, vLLM uses tensor parallelism.
+
+  palm serve start --runtime llama-cpp --model ~/models/qwen2.5-7b-q4_k_m.gguf
+  palm serve start -r llama-cpp -m bartowski/Llama-3.2-3B-Instruct-GGUF --daemon
+  palm serve start -r vllm -m Qwen/Qwen2.5-7B-Instruct --port 8001
+  palm serve start -r ollama --daemon`,
 		Run: func(cmd *cobra.Command, args []string) {
-			rt := serve.DetectRuntime()
+			var rt *serve.Runtime
+			if runtime != "" {
+				rt = serve.FindRuntime(runtime)
+				if rt == nil {
+					if _, ok := serve.DefaultPorts[runtime]; !ok {
+						ui.Bad.Printf("  Unknown runtime %q (use %s)\n", runtime, strings.Join(serve.Runtimes, ", "))
+					} else {
+						ui.Bad.Printf("  %s is not installed\n", runtime)
+					}
+					os.Exit(1)
+				}
+			} else {
+				rt = serve.DetectRuntime()
+			}
 			if rt == nil {
 				ui.Bad.Println("  No LLM runtime found")
 				fmt.Println()
 				fmt.Println("  Install one:")
 				fmt.Println("    palm install ollama     (recommended)")
 				fmt.Println("    brew install llama.cpp")
+				fmt.Println("    pip install vllm")
 				os.Exit(1)
 			}
 
-			ui.Banner("serve start")
-			fmt.Printf("  Runtime:  %s\n", ui.Brand.Sprint(rt.String()))
-
 			if model == "" {
+				if rt.Serves() {
+					ui.Bad.Printf("  %s needs --model (a model file or Hugging Face repo)\n", rt.Name)
+					os.Exit(1)
+				}
 				model = "llama3.3"
 			}
 
 			// Auto-detect GPU if not explicitly set
+			gpus := gpu.Detect()
 			if !cmd.Flags().Changed("gpu") {
-				useGPU = gpu.HasGPU()
+				useGPU = len(gpus) > 0
 			}
 
-			gpuStr := "CPU only"
+			opts := serve.StartOptions{Model: model, GPU: useGPU, ContextSize: ctxSize}
 			if useGPU {
-				gpuStr = "GPU accelerated"
+				opts.GPUs = gpus
+			}
+
+			if rt.Serves() || daemon {
+				opts.Server = true
+				startServer(rt, opts, port, cmd.Flags().Changed("port"), daemon, timeout)
+				return
 			}
+
+			ui.Banner("serve start")
+			fmt.Printf("  Runtime:  %s\n", ui.Brand.Sprint(rt.String()))
 			fmt.Printf("  Model:    %s\n", ui.Brand.Sprint(model))
-			fmt.Printf("  GPU:      %s\n", gpuStr)
+			fmt.Printf("  GPU:      %s\n", gpuSummary(opts))
 			fmt.Println()
 
-			c := rt.Start(model, useGPU)
+			c := rt.Command(opts)
 			if c == nil {
 				ui.Bad.Println("  Runtime does not support starting")
 				os.Exit(1)
 			}
+			_ = serve.RecordUse(rt.Name, model)
 
 			c.Stdin = os.Stdin
 			c.Stdout = os.Stdout
@@ -100,77 +164,494 @@ func serveStartCmd() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVarP(&model, "model", "m", "", "Model to serve (default: llama3.3)")
+	cmd.Flags().StringVarP(&model, "model", "m", "", "Model to serve (default: llama3.3 for ollama)")
+	cmd.Flags().StringVarP(&runtime, "runtime", "r", "", "Runtime to use: ollama, llama-cpp, vllm (default: first installed)")
+	cmd.Flags().IntVarP(&port, "port", "p", 0, "Port for llama-cpp and vllm (default: the runtime's, or the next free one)")
+	cmd.Flags().IntVar(&ctxSize, "ctx-size", 0, "Context window in tokens (default: the runtime's)")
 	cmd.Flags().BoolVar(&useGPU, "gpu", false, "Force GPU acceleration")
+	cmd.Flags().BoolVarP(&daemon, "daemon", "d", false, "Run detached with a PID and rotating log file")
+	cmd.Flags().DurationVar(&timeout, "timeout", 10*time.Minute, "How long to wait for the model to load")
 	return cmd
 }
 
+// startServer prepares a server launch for rt and runs it.
+func startServer(rt *serve.Runtime, opts serve.StartOptions, port int, portSet, daemon bool, timeout time.Duration) {
+	if inst, ok := serve.FindInstance(rt.Name); ok {
+		if _, err := serve.Health(inst.Root()); err == nil {
+			ui.Warn.Printf("  %s %s is already serving %s on %s\n", ui.WarnIcon(), rt.Name, inst.Model, inst.Root())
+			fmt.Printf("  Restart it: palm serve restart --runtime %s\n", rt.Name)
+			os.Exit(1)
+		}
+	}
+
+	if port == 0 {
+		port = serve.DefaultPorts[rt.Name]
+	}
+	free, err := serve.FreePort(opts.Host, port)
+	if err != nil {
+		ui.Bad.Printf("  %v\n", err)
+		os.Exit(1)
+	}
+	if free != port && portSet {
+		ui.Bad.Printf("  Port %d is in use\n", port)
+		os.Exit(1)
+	}
+	opts.Port = free
+
+	ui.Banner("serve start")
+	fmt.Printf("  Runtime:  %s\n", ui.Brand.Sprint(rt.String()))
+	fmt.Printf("  Model:    %s\n", ui.Brand.Sprint(opts.Model))
+	fmt.Printf("  GPU:      %s\n", gpuSummary(opts))
+	if free != port {
+		fmt.Printf("  Port:     %d %s\n", free, ui.Subtle.Sprintf("(%d is in use)", port))
+	} else {
+		fmt.Printf("  Port:     %d\n", free)
+	}
+	if rt.Name == "vllm" && !opts.GPU {
+		ui.Warn.Printf("  %s No GPU detected; vLLM only runs on CPU when built for it\n", ui.WarnIcon())
+	}
+	fmt.Println()
+
+	c := rt.Command(opts)
+	fmt.Printf("  %s\n\n", ui.Subtle.Sprint(strings.Join(c.Args, " ")))
+
+	_ = serve.RecordUse(rt.Name, opts.Model)
+	model := serve.ServedName(rt.Name, opts.Model)
+	runServer(serve.Instance{
+		Runtime: rt.Name,
+		Model:   model,
+		Source:  opts.Model,
+		Host:    "127.0.0.1",
+		Port:    opts.Port,
+		Daemon:  daemon,
+		Command: c.Args,
+		Env:     rt.Env(opts),
+	}, timeout)
+}
+
+// runServer launches inst's command, waits until it answers on /v1/models
+// and records it for palm serve status and palm proxy. Daemons run under
+// palm serve supervise and return once ready; otherwise the server runs in
+// the foreground until it exits or Ctrl-C.
+func runServer(inst serve.Instance, timeout time.Duration) {
+	var c *exec.Cmd
+	if inst.Daemon {
+		exe, _ := os.Executable()
+		c = exec.Command(exe, append([]string{"serve", "supervise", "--runtime", inst.Runtime, "--"}, inst.Command...)...)
+		setDetached(c)
+		inst.LogFile = serve.LogPath(inst.Runtime)
+	} else {
+		c = exec.Command(inst.Command[0], inst.Command[1:]...)
+		c.Stdout, c.Stderr = os.Stdout, os.Stderr
+	}
+	if len(inst.Env) > 0 {
+		c.Env = append(os.Environ(), inst.Env...)
+	}
+
+	if err := c.Start(); err != nil {
+		ui.Bad.Printf("  Failed to start: %v\n", err)
+		os.Exit(1)
+	}
+	inst.PID = c.Process.Pid
+	inst.StartedAt = time.Now()
+	if err := serve.SaveInstance(inst); err != nil {
+		ui.Warn.Printf("  %s Could not record server: %v\n", ui.WarnIcon(), err)
+	}
+
+	exited := make(chan error, 1)
+	go func() { exited <- c.Wait() }()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	readyCtx, cancel := context.WithTimeout(ctx, timeout)
+	ready := make(chan error, 1)
+	go func() { ready <- serve.WaitReady(readyCtx, inst.Root(), time.Second) }()
+
+	select {
+	case err := <-exited:
+		cancel()
+		_ = serve.RemoveInstance(inst.Runtime)
+		ui.Bad.Printf("  %s exited before it was ready: %v\n", inst.Runtime, err)
+		printLogTail(inst.LogFile, 10)
+		os.Exit(1)
+	case err := <-ready:
+		cancel()
+		if err != nil {
+			_, _ = stopServer(inst)
+			ui.Bad.Printf("  %v\n", err)
+			printLogTail(inst.LogFile, 10)
+			os.Exit(1)
+		}
+	}
+
+	ui.Good.Printf("  %s Serving %s at %s/v1\n", ui.StatusIcon(true), inst.Model, inst.Root())
+	fmt.Printf("  Proxy:    http://localhost:4778/%s/v1 (or model %q on /v1/)\n", inst.Runtime, inst.Model)
+	if running, _ := proxy.IsRunning(); running {
+		fmt.Printf("  %s\n", ui.Subtle.Sprint("Restart palm proxy to route to this server"))
+	}
+	logServeEvent("start", inst.Runtime, fmt.Sprintf("%s on port %d", inst.Model, inst.Port))
+
+	if inst.Daemon {
+		fmt.Printf("  Logs:     palm serve logs -f %s\n", inst.Runtime)
+		fmt.Printf("  Stop:     palm serve stop --runtime %s\n", inst.Runtime)
+		return
+	}
+
+	fmt.Println()
+	select {
+	case <-exited:
+	case <-ctx.Done():
+		_ = stopProcess(c.Process)
+		<-exited
+	}
+	_ = serve.RemoveInstance(inst.Runtime)
+}
+
+// stopServer stops inst and forgets it, returning the PID it signalled. A
+// daemon's runtime is stopped directly; its supervisor exits with it.
+func stopServer(inst serve.Instance) (int, error) {
+	pid := inst.PID
+	if inst.Daemon {
+		if p, ok := serve.ReadPid(inst.Runtime); ok {
+			pid = p
+		}
+	}
+	_ = serve.RemoveInstance(inst.Runtime)
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return pid, err
+	}
+	return pid, stopProcess(proc)
+}
+
+func printLogTail(path string, n int) {
+	if path == "" {
+		return
+	}
+	lines, err := serve.Tail(path, n)
+	if err != nil || len(lines) == 0 {
+		return
+	}
+	fmt.Println()
+	for _, l := range lines {
+		fmt.Printf("  %s\n", ui.Subtle.Sprint(l))
+	}
+	fmt.Printf("\n  Full log: %s\n", path)
+}
+
+func logServeEvent(op, runtime, details string) {
+	_ = activity.Append(activity.Entry{Action: "serve." + op, Tool: runtime, Details: details, Status: "ok"})
+}
+
+// gpuSummary describes how opts uses the GPUs.
+func gpuSummary(opts serve.StartOptions) string {
+	switch {
+	case !opts.GPU:
+		return "CPU only"
+	case len(opts.GPUs) > 1:
+		return fmt.Sprintf("GPU accelerated (%d GPUs)", len(opts.GPUs))
+	}
+	return "GPU accelerated"
+}
+
 func serveStopCmd() *cobra.Command {
-	return &cobra.Command{
+	var runtime string
+
+	cmd := &cobra.Command{
 		Use:   "stop",
 		Short: "Stop the running LLM server",
 		Run: func(cmd *cobra.Command, args []string) {
-			rt := serve.DetectRuntime()
+			insts, _ := serve.Instances()
+			stopped := 0
+			for _, inst := range insts {
+				if runtime != "" && inst.Runtime != runtime {
+					continue
+				}
+				pid, _ := stopServer(inst)
+				logServeEvent("stop", inst.Runtime, inst.Model)
+				ui.Good.Printf("  %s Stopped %s (%s, PID %d)\n", ui.StatusIcon(true), inst.Runtime, inst.Model, pid)
+				stopped++
+			}
+			if stopped > 0 || (runtime != "" && runtime != "ollama") {
+				if stopped == 0 {
+					fmt.Printf("  No %s server running\n", runtime)
+				}
+				return
+			}
+
+			rt := serve.FindRuntime("ollama")
 			if rt == nil {
 				fmt.Println("  No runtime detected")
 				return
 			}
 
-			if rt.Name == "ollama" {
-				c := exec.Command(rt.Path, "stop")
-				c.Stdout = os.Stdout
-				c.Stderr = os.Stderr
-				if err := c.Run(); err != nil {
-					// ollama might not have a stop command; try killing the process
-					fmt.Println("  Stopping ollama serve...")
-					_ = exec.Command("pkill", "-f", "ollama serve").Run()
-				}
+			c := exec.Command(rt.Path, "stop")
+			c.Stdout = os.Stdout
+			c.Stderr = os.Stderr
+			if err := c.Run(); err != nil {
+				// ollama might not have a stop command; try killing the process
+				fmt.Println("  Stopping ollama serve...")
+				_ = exec.Command("pkill", "-f", "ollama serve").Run()
 			}
 
 			ui.Good.Printf("  %s Server stopped\n", ui.StatusIcon(true))
 		},
 	}
+
+	cmd.Flags().StringVarP(&runtime, "runtime", "r", "", "Only stop this runtime")
+	return cmd
 }
 
-func serveStatusCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "status",
-		Short: "Show running models and GPU info",
+func serveRestartCmd() *cobra.Command {
+	var (
+		runtime string
+		timeout time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "restart",
+		Short: "Restart a server started with --daemon",
 		Run: func(cmd *cobra.Command, args []string) {
-			ui.Banner("serve status")
+			inst, err := pickInstance(runtime)
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+			if !inst.Daemon {
+				ui.Bad.Printf("  %s runs in the foreground; restart it from its terminal\n", inst.Runtime)
+				os.Exit(1)
+			}
 
-			rt := serve.DetectRuntime()
-			if rt == nil {
-				fmt.Println("  No LLM runtime installed")
-				fmt.Println("  Install: palm install ollama")
-				return
+			ui.Banner("serve restart")
+			fmt.Printf("  Stopping %s (%s)...\n", ui.Brand.Sprint(inst.Runtime), inst.Model)
+			if _, err := stopServer(inst); err != nil {
+				ui.Warn.Printf("  %s %v\n", ui.WarnIcon(), err)
 			}
+			if err := waitPortFree(inst.Host, inst.Port, 30*time.Second); err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("  Starting %s\n\n", ui.Subtle.Sprint(strings.Join(inst.Command, " ")))
+			runServer(inst, timeout)
+		},
+	}
 
-			fmt.Printf("  Runtime: %s\n", ui.Brand.Sprint(rt.String()))
+	cmd.Flags().StringVarP(&runtime, "runtime", "r", "", "Runtime to restart (default: the only one running)")
+	cmd.Flags().DurationVar(&timeout, "timeout", 10*time.Minute, "How long to wait for the model to load")
+	return cmd
+}
+
+// pickInstance returns the recorded server for runtime, or the only one
+// when runtime is empty.
+func pickInstance(runtime string) (serve.Instance, error) {
+	if runtime != "" {
+		if inst, ok := serve.FindInstance(runtime); ok {
+			return inst, nil
+		}
+		return serve.Instance{}, fmt.Errorf("no %s server started by palm", runtime)
+	}
+	insts, err := serve.Instances()
+	if err != nil {
+		return serve.Instance{}, err
+	}
+	switch len(insts) {
+	case 0:
+		return serve.Instance{}, fmt.Errorf("no server started by palm (palm serve start --daemon)")
+	case 1:
+		return insts[0], nil
+	}
+	var names []string
+	for _, i := range insts {
+		names = append(names, i.Runtime)
+	}
+	return serve.Instance{}, fmt.Errorf("several servers running (%s); choose one with --runtime", strings.Join(names, ", "))
+}
+
+// waitPortFree waits for a stopped server to release its port.
+func waitPortFree(host string, port int, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		if free, err := serve.FreePort(host, port); err == nil && free == port {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("port %d is still in use after %s", port, timeout)
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+}
+
+func serveLogsCmd() *cobra.Command {
+	var (
+		follow bool
+		lines  int
+	)
 
-			if rt.IsRunning() {
-				fmt.Printf("  Status:  %s running\n", ui.StatusIcon(true))
+	cmd := &cobra.Command{
+		Use:   "logs [runtime]",
+		Short: "Show the log of a server started with --daemon",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runtime := ""
+			if len(args) == 1 {
+				runtime = args[0]
+			} else if inst, err := pickInstance(""); err == nil {
+				runtime = inst.Runtime
 			} else {
-				fmt.Printf("  Status:  %s not running\n", ui.Subtle.Sprint("-"))
+				// Fall back to the only log left by a stopped daemon
+				matches, _ := filepath.Glob(filepath.Join(serve.Dir(), "*.log"))
+				if len(matches) != 1 {
+					ui.Bad.Printf("  %v\n", err)
+					os.Exit(1)
+				}
+				runtime = strings.TrimSuffix(filepath.Base(matches[0]), ".log")
+			}
+
+			path := serve.LogPath(runtime)
+			tail, err := serve.Tail(path, lines)
+			if err != nil {
+				if os.IsNotExist(err) {
+					ui.Bad.Printf("  No log for %s (only daemons write one)\n", runtime)
+				} else {
+					ui.Bad.Printf("  %v\n", err)
+				}
+				os.Exit(1)
+			}
+			for _, l := range tail {
+				fmt.Println(l)
 			}
+			if !follow {
+				return
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			if err := serve.Follow(ctx, path, os.Stdout, 500*time.Millisecond); err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "Keep printing new lines until Ctrl-C")
+	cmd.Flags().IntVarP(&lines, "lines", "n", 50, "Number of lines to show")
+	return cmd
+}
+
+// serveSuperviseCmd runs a daemon's runtime, writing its PID and rotating
+// its output. palm serve start --daemon launches it detached.
+func serveSuperviseCmd() *cobra.Command {
+	var runtime string
+
+	cmd := &cobra.Command{
+		Use:    "supervise --runtime <name> -- <command...>",
+		Hidden: true,
+		Args:   cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			logw, err := serve.OpenLog(serve.LogPath(runtime), serve.MaxLogSize, serve.KeepLogs)
+			if err != nil {
+				os.Exit(1)
+			}
+			defer logw.Close()
+
+			c := exec.Command(args[0], args[1:]...)
+			c.Stdout, c.Stderr = logw, logw
+			fmt.Fprintf(logw, "palm: %s starting %s\n", time.Now().Format(time.RFC3339), strings.Join(args, " "))
+			if err := c.Start(); err != nil {
+				fmt.Fprintf(logw, "palm: %v\n", err)
+				os.Exit(1)
+			}
+			_ = os.WriteFile(serve.PidPath(runtime), []byte(strconv.Itoa(c.Process.Pid)), 0o644)
+
+			sigs := make(chan os.Signal, 1)
+			signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+			go func() {
+				for range sigs {
+					_ = stopProcess(c.Process)
+				}
+			}()
+
+			err = c.Wait()
+			fmt.Fprintf(logw, "palm: %s %s exited: %v\n", time.Now().Format(time.RFC3339), runtime, err)
+			if pid, ok := serve.ReadPid(runtime); ok && pid == c.Process.Pid {
+				_ = os.Remove(serve.PidPath(runtime))
+			}
+			// Forget the server unless a restart already replaced it
+			if inst, ok := serve.FindInstance(runtime); ok && inst.PID == os.Getpid() {
+				_ = serve.RemoveInstance(runtime)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&runtime, "runtime", "", "Runtime being supervised")
+	_ = cmd.MarkFlagRequired("runtime")
+	return cmd
+}
+
+func serveStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show running models and GPU info",
+		Run: func(cmd *cobra.Command, args []string) {
+			ui.Banner("serve status")
 
 			// Show GPU info
 			gpus := gpu.Detect()
 			if len(gpus) > 0 {
-				fmt.Printf("  GPU:     %s %s\n", gpus[0].Vendor, gpus[0].Model)
+				for _, g := range gpus {
+					fmt.Printf("  GPU %d:   %s %s %s\n", g.Index, g.Vendor, g.Model, ui.Subtle.Sprint(g.VRAM()))
+				}
 			} else {
 				fmt.Printf("  GPU:     none detected (CPU only)\n")
 			}
 
-			// Show running models
-			if rt.IsRunning() {
+			if insts, _ := serve.Instances(); len(insts) > 0 {
 				fmt.Println()
-				c := rt.ListModels()
-				if c != nil {
-					c.Stdout = os.Stdout
-					c.Stderr = os.Stderr
-					_ = c.Run()
+				var rows [][]string
+				for _, inst := range insts {
+					status := ui.Good.Sprint("ready")
+					served, err := serve.Health(inst.Root())
+					if err != nil {
+						status = ui.Bad.Sprint("not responding")
+					} else if len(served) > 0 {
+						inst.Model = strings.Join(served, ", ")
+					}
+					pid, mode := inst.PID, "foreground"
+					if inst.Daemon {
+						mode = "daemon"
+						if p, ok := serve.ReadPid(inst.Runtime); ok {
+							pid = p
+						}
+					}
+					rows = append(rows, []string{inst.Runtime, inst.Model, inst.Root() + "/v1", fmt.Sprint(pid), mode, formatDuration(time.Since(inst.StartedAt)), status})
+				}
+				ui.Table([]string{"Runtime", "Model", "Endpoint", "PID", "Mode", "Up", "Status"}, rows)
+			}
+
+			rt := serve.FindRuntime("ollama")
+			if rt == nil {
+				if serve.DetectRuntime() == nil {
+					fmt.Println()
+					fmt.Println("  No LLM runtime installed")
+					fmt.Println("  Install: palm install ollama")
 				}
+				return
+			}
+
+			fmt.Println()
+			fmt.Printf("  Runtime: %s\n", ui.Brand.Sprint(rt.String()))
+			if !rt.IsRunning() {
+				fmt.Printf("  Status:  %s not running\n", ui.Subtle.Sprint("-"))
+				return
+			}
+			fmt.Printf("  Status:  %s running\n", ui.StatusIcon(true))
+
+			// Show running models
+			fmt.Println()
+			if c := rt.ListModels(); c != nil {
+				c.Stdout = os.Stdout
+				c.Stderr = os.Stderr
+				_ = c.Run()
 			}
 		},
 	}
@@ -257,3 +738,137 @@ func servePullCmd() *cobra.Command {
 		},
 	}
 }
+
+func serveDuCmd() *cobra.Command {
+	var (
+		dirs []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "du",
+		Short: "Show disk space used by downloaded models",
+		Long: `List models downloaded by ollama, llama.cpp (.gguf files in its cache, in
+--dir folders and next to models palm has served) and vLLM (the Hugging Face
+cache), with their size and when they were last used.
+
+Last used is the later of when palm last served the model and when its
+files were last read.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			found, err := serve.ScanModels(dirs)
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+
+			if jsonOutput {
+				type modelJSON struct {
+					Runtime  string    `json:"runtime"`
+					Name     string    `json:"name"`
+					Path     string    `json:"path"`
+					Size     int64     `json:"size_bytes"`
+					LastUsed time.Time `json:"last_used"`
+				}
+				out := make([]modelJSON, 0, len(found))
+				for _, m := range found {
+					out = append(out, modelJSON{m.Runtime, m.Name, m.Path, m.Size, m.LastUsed})
+				}
+				printJSON(out)
+				return
+			}
+
+			ui.Banner("model disk usage")
+			if len(found) == 0 {
+				fmt.Println("  No downloaded models found")
+				fmt.Printf("  %s\n", ui.Subtle.Sprintf("Looked in %s, %s and %s", serve.OllamaModelsDir(), serve.LlamaCacheDir(), serve.HFHubDir()))
+				return
+			}
+			printLocalModels(found)
+			fmt.Println()
+			fmt.Printf("  Total: %s across %d models\n", ui.Brand.Sprint(formatBytes(int(serve.TotalSize(found)))), len(found))
+			fmt.Println("  Free space: palm serve prune --unused 30d")
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&dirs, "dir", nil, "Extra folder to search for .gguf files (repeatable)")
+	return cmd
+}
+
+func printLocalModels(list []serve.LocalModel) {
+	var rows [][]string
+	for _, m := range list {
+		rows = append(rows, []string{m.Runtime, truncate(m.Name, 48), formatBytes(int(m.Size)), m.LastUsed.Format("2006-01-02"), truncate(m.Path, 60)})
+	}
+	ui.Table([]string{"Runtime", "Model", "Size", "Last used", "Path"}, rows)
+}
+
+func servePruneCmd() *cobra.Command {
+	var (
+		unused string
+		dirs   []string
+		yes    bool
+		dryRun bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete downloaded models that haven't been used recently",
+		Long: `Delete models last used longer ago than --unused, after confirmation.
+Models a running palm serve server is using are kept, as are ollama layers
+shared with a kept model.
+
+  palm serve prune --unused 30d
+  palm serve prune --unused 2w --dry-run`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cutoff, err := parseSince(unused)
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+			found, err := serve.ScanModels(dirs)
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+
+			insts, _ := serve.Instances()
+			var stale []serve.LocalModel
+			for _, m := range found {
+				if m.LastUsed.Before(cutoff) && !serve.Serving(m, insts) {
+					stale = append(stale, m)
+				}
+			}
+
+			ui.Banner("serve prune")
+			if len(stale) == 0 {
+				fmt.Printf("  No models unused for %s\n", unused)
+				return
+			}
+			printLocalModels(stale)
+			fmt.Println()
+			freed := formatBytes(int(serve.Reclaimable(stale, found)))
+			fmt.Printf("  %d models unused for %s, freeing %s\n", len(stale), unused, ui.Brand.Sprint(freed))
+			if dryRun {
+				return
+			}
+			if !yes && !confirmNo("  Delete them?") {
+				fmt.Println("  Nothing deleted")
+				return
+			}
+
+			if err := serve.RemoveModels(stale, found); err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+			for _, m := range stale {
+				logServeEvent("prune", m.Runtime, m.Name)
+			}
+			ui.Good.Printf("  %s Deleted %d models, freed %s\n", ui.StatusIcon(true), len(stale), freed)
+		},
+	}
+
+	cmd.Flags().StringVar(&unused, "unused", "30d", "Delete models not used within this window (e.g. 30d, 2w)")
+	cmd.Flags().StringArrayVar(&dirs, "dir", nil, "Extra folder to search for .gguf files (repeatable)")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Delete without asking")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only list what would be deleted")
+	return cmd
+}
@@ -0,0 +1,278 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/msalah0e/palm/internal/session"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/msalah0e/palm/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+func sessionsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sessions",
+		Short: "Inspect and replay recorded tool runs",
+		Long: `Inspect and replay runs captured with ` + "`palm run <tool> --record`" + `.
+
+Recordings are kept in ~/.config/palm/sessions/. Secret environment values
+are redacted when recorded and filled in again from your environment or the
+vault on replay.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			sessionsListCmd().Run(cmd, args)
+		},
+	}
+	cmd.AddCommand(sessionsListCmd(), sessionsShowCmd(), sessionsReplayCmd())
+	return cmd
+}
+
+func sessionsListCmd() *cobra.Command {
+	var count int
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List recorded sessions",
+		Run: func(cmd *cobra.Command, args []string) {
+			recs, err := session.ListRecordings()
+			if err != nil {
+				ui.Bad.Printf("  Failed to read recordings: %v\n", err)
+				os.Exit(1)
+			}
+			if count > 0 && len(recs) > count {
+				recs = recs[:count]
+			}
+
+			if jsonOutput {
+				type summary struct {
+					ID        string    `json:"id"`
+					Tool      string    `json:"tool"`
+					Command   string    `json:"command"`
+					Dir       string    `json:"dir"`
+					ExitCode  int       `json:"exit_code"`
+					StartedAt time.Time `json:"started_at"`
+					Duration  float64   `json:"duration_secs"`
+				}
+				out := make([]summary, 0, len(recs))
+				for _, r := range recs {
+					out = append(out, summary{r.ID, r.Tool, r.Command(), r.Dir, r.ExitCode, r.StartedAt, r.Duration})
+				}
+				printJSON(out)
+				return
+			}
+
+			ui.Banner("recorded sessions")
+			if len(recs) == 0 {
+				fmt.Println("  No recorded sessions yet.")
+				fmt.Println("  Record one with: palm run <tool> --record [args...]")
+				return
+			}
+
+			var rows [][]string
+			for _, r := range recs {
+				rows = append(rows, []string{
+					r.ID,
+					r.StartedAt.Format("Jan 02 15:04"),
+					truncate(r.Command(), 40),
+					formatDuration(time.Duration(r.Duration * float64(time.Second))),
+					fmt.Sprintf("%s %d", ui.StatusIcon(r.ExitCode == 0), r.ExitCode),
+				})
+			}
+			ui.Table([]string{"ID", "Time", "Command", "Duration", "Exit"}, rows)
+			fmt.Println()
+			fmt.Printf("  %s\n", ui.Subtle.Sprint("palm sessions show <id> · palm sessions replay <id>"))
+		},
+	}
+
+	cmd.Flags().IntVarP(&count, "count", "n", 20, "Number of sessions to show (0 for all)")
+	return cmd
+}
+
+func sessionsShowCmd() *cobra.Command {
+	var showEnv bool
+
+	cmd := &cobra.Command{
+		Use:   "show <id>",
+		Short: "Show a recorded session's invocation and output",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			rec, err := session.LoadRecording(args[0])
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+
+			if jsonOutput {
+				printJSON(rec)
+				return
+			}
+
+			ui.Banner("session " + rec.ID)
+			field := func(name, value string) {
+				fmt.Printf("  %s  %s\n", ui.Brand.Sprintf("%-10s", name), value)
+			}
+			field("Command", rec.Command())
+			field("Binary", rec.Bin)
+			field("Directory", rec.Dir)
+			field("Started", rec.StartedAt.Format("2006-01-02 15:04:05"))
+			field("Duration", formatDuration(time.Duration(rec.Duration*float64(time.Second))))
+			field("Exit", fmt.Sprintf("%s %d", ui.StatusIcon(rec.ExitCode == 0), rec.ExitCode))
+			if len(rec.Injected) > 0 {
+				field("Vault keys", strings.Join(rec.Injected, ", "))
+			}
+			field("Env", fmt.Sprintf("%d variables", len(rec.Env)))
+			if showEnv {
+				for _, kv := range rec.Env {
+					fmt.Printf("    %s\n", ui.Subtle.Sprint(kv))
+				}
+			}
+
+			section := func(title, body string) {
+				if body == "" {
+					return
+				}
+				fmt.Println()
+				ui.Info.Printf("  ── %s ──\n", title)
+				for _, line := range strings.Split(strings.TrimRight(body, "\n"), "\n") {
+					fmt.Printf("  %s\n", line)
+				}
+			}
+			section("stdin", rec.Stdin)
+			section("stdout", rec.Stdout)
+			section("stderr", rec.Stderr)
+			if rec.Truncated {
+				fmt.Println()
+				ui.Warn.Println("  Output was truncated at 1 MB per stream")
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&showEnv, "env", false, "List the recorded (redacted) environment")
+	return cmd
+}
+
+func sessionsReplayCmd() *cobra.Command {
+	var dryRun, record bool
+
+	cmd := &cobra.Command{
+		Use:   "replay <id>",
+		Short: "Re-run a recorded session with the same command, environment and input",
+		Long: `Re-run a recorded session exactly: same binary, arguments, working
+directory, environment and piped stdin. Redacted secrets are filled in from
+your current environment or the vault. Afterwards palm reports whether the
+exit code and output match the original run.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			rec, err := session.LoadRecording(args[0])
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+
+			bin := rec.Bin
+			if _, err := os.Stat(bin); err != nil {
+				// The binary moved (upgrade, new venv); fall back to PATH
+				if bin, err = exec.LookPath(filepath.Base(rec.Bin)); err != nil {
+					ui.Bad.Printf("  %s no longer exists and %s is not in PATH\n", rec.Bin, filepath.Base(rec.Bin))
+					os.Exit(1)
+				}
+			}
+
+			v := vault.New()
+			env, missing := session.RestoreEnv(rec.Env, func(name string) (string, bool) {
+				if val, ok := os.LookupEnv(name); ok {
+					return val, true
+				}
+				val, err := v.Get(name)
+				return val, err == nil
+			})
+
+			dir := rec.Dir
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+				ui.Warn.Fprintf(os.Stderr, "palm: %s is gone, replaying in the current directory\n", dir)
+				dir = ""
+			}
+
+			if dryRun {
+				ui.Banner("replay " + rec.ID)
+				fmt.Printf("  %s  %s\n", ui.Brand.Sprintf("%-10s", "Command"), rec.Command())
+				fmt.Printf("  %s  %s\n", ui.Brand.Sprintf("%-10s", "Binary"), bin)
+				fmt.Printf("  %s  %s\n", ui.Brand.Sprintf("%-10s", "Directory"), orDash(dir))
+				fmt.Printf("  %s  %d variables\n", ui.Brand.Sprintf("%-10s", "Env"), len(env))
+				if rec.Stdin != "" {
+					fmt.Printf("  %s  %d bytes\n", ui.Brand.Sprintf("%-10s", "Stdin"), len(rec.Stdin))
+				}
+				for _, name := range missing {
+					ui.Warn.Printf("  %s %s is not available and will be unset\n", ui.WarnIcon(), name)
+				}
+				return
+			}
+
+			for _, name := range missing {
+				ui.Warn.Fprintf(os.Stderr, "palm: %s is not available and will be unset\n", name)
+			}
+			ui.Subtle.Fprintf(os.Stderr, "palm: replaying %s: %s\n", rec.ID, rec.Command())
+
+			start := time.Now()
+			stdout, stderr, code := runCaptured(bin, rec.Args, env, dir, strings.NewReader(rec.Stdin))
+
+			fmt.Fprintln(os.Stderr)
+			compare := func(what string, same bool, detail string) {
+				if same {
+					ui.Good.Fprintf(os.Stderr, "  %s %s\n", ui.StatusIcon(true), what)
+				} else {
+					ui.Warn.Fprintf(os.Stderr, "  %s %s\n", ui.WarnIcon(), detail)
+				}
+			}
+			compare(fmt.Sprintf("same exit code (%d)", code), code == rec.ExitCode,
+				fmt.Sprintf("exit code %d, was %d", code, rec.ExitCode))
+			newOut := session.ScrubSecrets(stdout.String(), env, rec.Injected...)
+			compare("stdout matches the recording", newOut == rec.Stdout,
+				fmt.Sprintf("stdout differs (%d bytes, was %d)", len(newOut), len(rec.Stdout)))
+
+			if record {
+				again := &session.Recording{
+					ID:        session.NewID(start),
+					Tool:      rec.Tool,
+					Bin:       bin,
+					Args:      rec.Args,
+					Dir:       dir,
+					Env:       rec.Env,
+					Injected:  rec.Injected,
+					Stdin:     rec.Stdin,
+					Stdout:    newOut,
+					Stderr:    session.ScrubSecrets(stderr.String(), env, rec.Injected...),
+					Truncated: stdout.Truncated || stderr.Truncated,
+					ExitCode:  code,
+					StartedAt: start,
+					Duration:  time.Since(start).Seconds(),
+				}
+				if again.Dir == "" {
+					again.Dir, _ = os.Getwd()
+				}
+				if err := session.SaveRecording(again); err != nil {
+					ui.Bad.Fprintf(os.Stderr, "palm: failed to save recording: %v\n", err)
+				} else {
+					ui.Subtle.Fprintf(os.Stderr, "palm: recorded replay as %s\n", again.ID)
+				}
+			}
+			os.Exit(code)
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would run without running it")
+	cmd.Flags().BoolVar(&record, "record", false, "Record the replay as a new session")
+	return cmd
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -14,12 +14,27 @@ import (
 
 func setupCmd() *cobra.Command {
 	var presetFlag string
-	var skip bool
+	var skip, assumeYes bool
 
 	cmd := &cobra.Command{
-		Use:   "setup",
+		Use:   "setup [tool]",
 		Short: "Interactive setup wizard — pick a preset and install tools",
+		Long: `Pick a preset of tools to install, or with a tool name, run that tool's
+post-install steps again — starting its service, shell completions, PATH
+additions. Steps that are already done are skipped.
+
+Examples:
+  palm setup
+  palm setup --preset coding
+  palm setup ollama`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: toolCompletionFunc,
 		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 1 {
+				runToolSetup(args[0], assumeYes)
+				return
+			}
+
 			presets := loadPresets()
 			if len(presets) == 0 {
 				ui.Bad.Println("palm: no presets available")
@@ -43,6 +58,7 @@ func setupCmd() *cobra.Command {
 
 	cmd.Flags().StringVar(&presetFlag, "preset", "", "Install a preset directly (non-interactive)")
 	cmd.Flags().BoolVar(&skip, "skip", false, "Mark setup complete without installing")
+	cmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Run a tool's setup steps without asking")
 
 	cmd.AddCommand(setupListCmd())
 
@@ -107,7 +123,7 @@ func runInteractiveSetup(presets []registry.Preset) {
 
 	reg := loadRegistry()
 	cfg := config.Load()
-	installParallel(reg, selected.Tools, cfg.Parallel.Concurrency)
+	installParallel(reg, selected.Tools, cfg.Parallel.Concurrency, false)
 
 	markSetupComplete(selected.Name)
 	fmt.Printf("\n  %s Setup complete!\n", ui.StatusIcon(true))
@@ -132,12 +148,36 @@ func runPreset(presets []registry.Preset, name string) {
 
 	reg := loadRegistry()
 	cfg := config.Load()
-	installParallel(reg, selected.Tools, cfg.Parallel.Concurrency)
+	installParallel(reg, selected.Tools, cfg.Parallel.Concurrency, false)
 
 	markSetupComplete(selected.Name)
 	fmt.Printf("\n  %s Setup complete!\n", ui.StatusIcon(true))
 }
 
+// runToolSetup runs an installed tool's post-install steps.
+func runToolSetup(name string, assumeYes bool) {
+	tool := loadRegistry().Get(name)
+	if tool == nil {
+		ui.Bad.Printf("palm: unknown tool %q\n", name)
+		os.Exit(1)
+	}
+
+	ui.Banner("setup")
+	if len(tool.SetupSteps()) == 0 {
+		fmt.Printf("  %s has no setup steps\n", tool.DisplayName)
+		return
+	}
+	if !registry.DetectOne(*tool).Installed {
+		ui.Warn.Printf("  %s %s is not installed — run `palm install %s` first\n", ui.WarnIcon(), tool.DisplayName, tool.Name)
+		os.Exit(1)
+	}
+	if runSetupSteps(tool, assumeYes) > 0 {
+		os.Exit(1)
+	}
+	fmt.Println()
+	ui.Good.Printf("  %s %s is set up\n", ui.StatusIcon(true), tool.DisplayName)
+}
+
 func markSetupComplete(preset string) {
 	cfg := config.Load()
 	cfg.Setup.Complete = true
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/msalah0e/palm/internal/installer"
+	"github.com/msalah0e/palm/internal/registry"
+	"github.com/msalah0e/palm/internal/ui"
+)
+
+// runSetupSteps offers each of a tool's post-install steps that isn't done
+// yet, asking first unless assumeYes. It returns how many are left undone.
+func runSetupSteps(tool *registry.Tool, assumeYes bool) int {
+	steps := tool.SetupSteps()
+	if len(steps) == 0 {
+		return 0
+	}
+
+	fmt.Printf("\n  %s\n", ui.Brand.Sprintf("%s setup", tool.DisplayName))
+	pending := 0
+	for _, s := range steps {
+		if installer.StepDone(s) {
+			fmt.Printf("  %s %s %s\n", ui.StatusIcon(true), s.Description, ui.Subtle.Sprint("(done)"))
+			continue
+		}
+
+		fmt.Printf("  %s %s\n", ui.Info.Sprint("→"), s.Description)
+		if s.Run != "" {
+			fmt.Printf("    %s\n", ui.Subtle.Sprint("$ "+s.Run))
+		}
+		if s.Shell != "" {
+			shell := installer.ShellName()
+			fmt.Printf("    %s\n", ui.Subtle.Sprintf("%s ← %s", orDash(installer.RCFile(shell)), installer.ShellLine(s, shell)))
+		}
+		if !assumeYes && !confirm("    Run it?") {
+			ui.Subtle.Println("    Skipped")
+			pending++
+			continue
+		}
+		if err := installer.RunStep(tool.Name, s); err != nil {
+			ui.Bad.Printf("    %s %v\n", ui.StatusIcon(false), err)
+			pending++
+			continue
+		}
+		ui.Good.Printf("    %s Done\n", ui.StatusIcon(true))
+	}
+	if pending > 0 {
+		fmt.Printf("  %s\n", ui.Subtle.Sprintf("Finish later with: palm setup %s", tool.Name))
+	}
+	return pending
+}
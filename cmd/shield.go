@@ -53,6 +53,7 @@ func shieldCmd() *cobra.Command {
 		shieldPreCmd(),
 		shieldPostCmd(),
 		shieldScanCmd(),
+		shieldDiffCmd(),
 	)
 
 	return cmd
@@ -99,6 +100,14 @@ func shieldPreCmd() *cobra.Command {
 				issues++
 			}
 
+			if isGitRepo() {
+				if _, err := saveShieldSnapshot(); err != nil {
+					ui.Warn.Printf("  %s Couldn't snapshot the working tree: %v\n", ui.WarnIcon(), err)
+				} else {
+					fmt.Printf("  %s Snapshot saved — run `palm shield diff` after the session\n", ui.StatusIcon(true))
+				}
+			}
+
 			fmt.Println()
 			if issues == 0 {
 				ui.Good.Printf("  %s Ready for AI session\n", ui.StatusIcon(true))
@@ -133,7 +142,7 @@ func shieldPostCmd() *cobra.Command {
 			}
 
 			// Check for common AI code smells in recently modified files
-			fmt.Printf("  %s Run `palm audit` for detailed code quality check\n", ui.StatusIcon(true))
+			fmt.Printf("  %s Run `palm audit` to score the session's changes for AI code smells\n", ui.StatusIcon(true))
 
 			fmt.Println()
 			if issues == 0 {
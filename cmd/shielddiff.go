@@ -0,0 +1,392 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// shieldSnapshot is the working tree as `palm shield pre` found it, stored as
+// a git tree object so later diffs include uncommitted and untracked files.
+type shieldSnapshot struct {
+	Tree    string    `json:"tree"`
+	Head    string    `json:"head,omitempty"`
+	TakenAt time.Time `json:"taken_at"`
+}
+
+// shieldFile is one changed file in a shield diff.
+type shieldFile struct {
+	Path    string `json:"path"`
+	Status  string `json:"status"` // added, modified, deleted, renamed
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+}
+
+// shieldFinding is a line in the diff worth a second look.
+type shieldFinding struct {
+	File string `json:"file"`
+	Line string `json:"line"`
+}
+
+// shieldReport summarizes what changed since the snapshot.
+type shieldReport struct {
+	Since        time.Time       `json:"since"`
+	Files        []shieldFile    `json:"files"`
+	LinesAdded   int             `json:"lines_added"`
+	LinesRemoved int             `json:"lines_removed"`
+	TodosAdded   int             `json:"todos_added"`
+	TodosRemoved int             `json:"todos_removed"`
+	TodoDensity  float64         `json:"todos_per_1000_lines"` // of the added lines
+	Dependencies []shieldFinding `json:"new_dependencies"`
+	Licenses     []shieldFinding `json:"license_headers"`
+}
+
+func shieldDiffCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "diff",
+		Short: "Audit what changed since `palm shield pre`",
+		Long: `Compare the working tree with the snapshot taken by ` + "`palm shield pre`" + `
+and summarize what the AI session changed: files added, modified and
+deleted, lines of code, TODO/FIXME markers introduced, dependencies added
+to manifests, and license headers that may have been copied in.
+
+Without a snapshot, the working tree is compared with HEAD.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			root, err := gitOutput("rev-parse", "--show-toplevel")
+			if err != nil {
+				ui.Bad.Println("  palm shield diff needs a git repository")
+				os.Exit(1)
+			}
+
+			snap, err := loadShieldSnapshot()
+			if err != nil {
+				head, herr := gitOutput("rev-parse", "HEAD^{tree}")
+				if herr != nil {
+					ui.Bad.Println("  No snapshot and no commits — run `palm shield pre` before the session")
+					os.Exit(1)
+				}
+				snap = &shieldSnapshot{Tree: head}
+				if !jsonOutput {
+					ui.Subtle.Println("  No snapshot from `palm shield pre` — comparing with HEAD")
+				}
+			}
+
+			current, err := snapshotWorkTree(root)
+			if err != nil {
+				ui.Bad.Printf("  Failed to snapshot the working tree: %v\n", err)
+				os.Exit(1)
+			}
+			report, err := diffShieldTrees(snap.Tree, current)
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+			report.Since = snap.TakenAt
+
+			render(report, func() { printShieldReport(report) })
+		},
+	}
+}
+
+func printShieldReport(r *shieldReport) {
+	ui.Banner("shield diff")
+	if !r.Since.IsZero() {
+		fmt.Printf("  Since snapshot %s (%s ago)\n\n", r.Since.Format("2006-01-02 15:04"), formatDuration(time.Since(r.Since)))
+	}
+	if len(r.Files) == 0 {
+		ui.Good.Printf("  %s No changes since the snapshot\n", ui.StatusIcon(true))
+		return
+	}
+
+	counts := make(map[string]int)
+	var rows [][]string
+	for _, f := range r.Files {
+		counts[f.Status]++
+		rows = append(rows, []string{f.Status, f.Path, ui.Good.Sprintf("+%d", f.Added), ui.Bad.Sprintf("-%d", f.Removed)})
+	}
+	ui.Table([]string{"Status", "File", "Added", "Removed"}, rows)
+
+	fmt.Println()
+	fmt.Printf("  Files:  %d added · %d modified · %d deleted", counts["added"], counts["modified"], counts["deleted"])
+	if counts["renamed"] > 0 {
+		fmt.Printf(" · %d renamed", counts["renamed"])
+	}
+	fmt.Println()
+	fmt.Printf("  Lines:  %s %s\n", ui.Good.Sprintf("+%d", r.LinesAdded), ui.Bad.Sprintf("-%d", r.LinesRemoved))
+
+	todo := fmt.Sprintf("  TODOs:  %d added, %d removed (%.1f per 1000 new lines)", r.TodosAdded, r.TodosRemoved, r.TodoDensity)
+	if r.TodoDensity > 10 {
+		ui.Warn.Println(todo)
+	} else {
+		fmt.Println(todo)
+	}
+
+	issues := 0
+	if len(r.Dependencies) > 0 {
+		fmt.Println()
+		ui.Warn.Printf("  %s %d new dependencies — check they exist and are the ones you meant:\n", ui.WarnIcon(), len(r.Dependencies))
+		for _, d := range r.Dependencies {
+			fmt.Printf("    %s  %s\n", ui.Subtle.Sprint(d.File), d.Line)
+		}
+		issues++
+	}
+	if len(r.Licenses) > 0 {
+		fmt.Println()
+		ui.Warn.Printf("  %s %d license header(s) added — code may have been copied from elsewhere:\n", ui.WarnIcon(), len(r.Licenses))
+		for _, l := range r.Licenses {
+			fmt.Printf("    %s  %s\n", ui.Subtle.Sprint(l.File), truncate(l.Line, 70))
+		}
+		issues++
+	}
+
+	fmt.Println()
+	if issues == 0 {
+		ui.Good.Printf("  %s Nothing unusual — review the changes with git diff as usual\n", ui.StatusIcon(true))
+	}
+}
+
+// shieldSnapshotPath keeps the snapshot inside the repository's git dir, so
+// it is per repository and never committed.
+func shieldSnapshotPath() (string, error) {
+	gitDir, err := gitOutput("rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(gitDir, "palm", "shield-snapshot.json"), nil
+}
+
+// saveShieldSnapshot records the working tree for a later `shield diff`.
+func saveShieldSnapshot() (*shieldSnapshot, error) {
+	root, err := gitOutput("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	tree, err := snapshotWorkTree(root)
+	if err != nil {
+		return nil, err
+	}
+	snap := &shieldSnapshot{Tree: tree, TakenAt: time.Now()}
+	snap.Head, _ = gitOutput("rev-parse", "HEAD")
+
+	path, err := shieldSnapshotPath()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return snap, os.WriteFile(path, data, 0644)
+}
+
+func loadShieldSnapshot() (*shieldSnapshot, error) {
+	path, err := shieldSnapshotPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var snap shieldSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, err
+	}
+	return &snap, nil
+}
+
+// snapshotWorkTree writes the working tree, untracked files included and
+// ignored files excluded, as a git tree object and returns its id. It uses
+// a scratch index so the real index and stash are left alone.
+func snapshotWorkTree(root string) (string, error) {
+	tmp, err := os.MkdirTemp("", "palm-shield-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+	index := filepath.Join(tmp, "index")
+
+	// Starting from the real index lets git skip files whose stat is unchanged
+	if gitDir, err := gitOutput("-C", root, "rev-parse", "--absolute-git-dir"); err == nil {
+		if data, err := os.ReadFile(filepath.Join(gitDir, "index")); err == nil {
+			_ = os.WriteFile(index, data, 0644)
+		}
+	}
+
+	env := append(os.Environ(), "GIT_INDEX_FILE="+index)
+	add := exec.Command("git", "-C", root, "add", "-A")
+	add.Env = env
+	if out, err := add.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("git add: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	write := exec.Command("git", "-C", root, "write-tree")
+	write.Env = env
+	out, err := write.Output()
+	if err != nil {
+		return "", fmt.Errorf("git write-tree: %v", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// diffShieldTrees builds the report for the changes from tree from to to.
+func diffShieldTrees(from, to string) (*shieldReport, error) {
+	status, err := gitOutput("diff", "--name-status", "-M", from, to)
+	if err != nil {
+		return nil, fmt.Errorf("git diff: %v", err)
+	}
+	patch, err := gitOutput("diff", "--no-color", "--no-ext-diff", "-M", "-U0", from, to)
+	if err != nil {
+		return nil, fmt.Errorf("git diff: %v", err)
+	}
+	return analyzeShieldDiff(status, patch), nil
+}
+
+var (
+	todoPattern    = regexp.MustCompile(`\b(TODO|FIXME|XXX|HACK)\b`)
+	licensePattern = regexp.MustCompile(`(?i)(SPDX-License-Identifier|Copyright \(c\)|Copyright ©|Copyright \d{4}|Licensed under the|GNU (Lesser )?General Public License|Permission is hereby granted, free of charge)`)
+)
+
+// analyzeShieldDiff summarizes `git diff --name-status` output and a
+// zero-context patch of the same change.
+func analyzeShieldDiff(nameStatus, patch string) *shieldReport {
+	r := &shieldReport{Files: []shieldFile{}, Dependencies: []shieldFinding{}, Licenses: []shieldFinding{}}
+	index := make(map[string]int)
+	for _, line := range strings.Split(strings.TrimSpace(nameStatus), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 {
+			continue
+		}
+		f := shieldFile{Path: fields[len(fields)-1]}
+		switch fields[0][0] {
+		case 'A':
+			f.Status = "added"
+		case 'D':
+			f.Status = "deleted"
+		case 'R':
+			f.Status = "renamed"
+		default:
+			f.Status = "modified"
+		}
+		index[f.Path] = len(r.Files)
+		r.Files = append(r.Files, f)
+	}
+
+	var file string
+	scanner := bufio.NewScanner(strings.NewReader(patch))
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			file = ""
+		case strings.HasPrefix(line, "+++ "):
+			if p := strings.TrimPrefix(line, "+++ "); p != "/dev/null" {
+				file = strings.TrimPrefix(p, "b/")
+			}
+		case strings.HasPrefix(line, "--- "):
+			if p := strings.TrimPrefix(line, "--- "); p != "/dev/null" && file == "" {
+				file = strings.TrimPrefix(p, "a/")
+			}
+		case strings.HasPrefix(line, "+"):
+			text := line[1:]
+			r.LinesAdded++
+			if i, ok := index[file]; ok {
+				r.Files[i].Added++
+			}
+			if todoPattern.MatchString(text) {
+				r.TodosAdded++
+			}
+			if dep := manifestDependency(file, text); dep != "" {
+				r.Dependencies = append(r.Dependencies, shieldFinding{File: file, Line: dep})
+			}
+			if licensePattern.MatchString(text) && !isLicenseFile(file) {
+				r.Licenses = append(r.Licenses, shieldFinding{File: file, Line: strings.TrimSpace(text)})
+			}
+		case strings.HasPrefix(line, "-"):
+			r.LinesRemoved++
+			if i, ok := index[file]; ok {
+				r.Files[i].Removed++
+			}
+			if todoPattern.MatchString(line[1:]) {
+				r.TodosRemoved++
+			}
+		}
+	}
+	if r.LinesAdded > 0 {
+		r.TodoDensity = float64(r.TodosAdded) * 1000 / float64(r.LinesAdded)
+	}
+	sort.SliceStable(r.Files, func(i, j int) bool { return r.Files[i].Path < r.Files[j].Path })
+	return r
+}
+
+var (
+	goRequire     = regexp.MustCompile(`^\s*(?:require\s+)?([\w.\-]+\.[\w.\-/]+)\s+v\d`)
+	jsonDep       = regexp.MustCompile(`^\s*"([@\w.\-/]+)"\s*:\s*"([\^~<>=*]|\d|latest|git|github:|file:|npm:)`)
+	tomlDep       = regexp.MustCompile(`^\s*([\w.\-]+)\s*=\s*(["{]|\[)`)
+	pyprojectDep  = regexp.MustCompile(`^\s*"([\w.\-\[\]]+)\s*([<>=!~]=?.*)?",?\s*$`)
+	requirementRe = regexp.MustCompile(`^\s*([A-Za-z0-9][\w.\-\[\]]*)`)
+	gemDep        = regexp.MustCompile(`^\s*gem\s+["']([\w.\-]+)["']`)
+)
+
+// manifestFields are package.json keys and Cargo.toml keys that look like
+// dependencies but aren't.
+var manifestFields = map[string]bool{
+	"name": true, "version": true, "description": true, "main": true, "license": true,
+	"edition": true, "authors": true, "type": true, "module": true, "types": true,
+	"readme": true, "repository": true, "homepage": true, "keywords": true, "categories": true,
+	"documentation": true, "build": true, "requires-python": true, "python": true,
+}
+
+// manifestDependency returns the dependency an added manifest line declares,
+// or "" when the file isn't a manifest or the line isn't a dependency.
+func manifestDependency(file, line string) string {
+	var m []string
+	switch filepath.Base(file) {
+	case "go.mod":
+		m = goRequire.FindStringSubmatch(line)
+	case "package.json":
+		m = jsonDep.FindStringSubmatch(line)
+	case "Cargo.toml":
+		m = tomlDep.FindStringSubmatch(line)
+	case "pyproject.toml":
+		if m = pyprojectDep.FindStringSubmatch(line); m == nil {
+			m = tomlDep.FindStringSubmatch(line)
+		}
+	case "Gemfile":
+		m = gemDep.FindStringSubmatch(line)
+	default:
+		if strings.HasPrefix(filepath.Base(file), "requirements") && strings.HasSuffix(file, ".txt") {
+			if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "#") && !strings.HasPrefix(trimmed, "-") {
+				m = requirementRe.FindStringSubmatch(line)
+			}
+		}
+	}
+	if m == nil || manifestFields[m[1]] {
+		return ""
+	}
+	return strings.TrimSpace(line)
+}
+
+func isLicenseFile(file string) bool {
+	base := strings.ToUpper(filepath.Base(file))
+	return strings.HasPrefix(base, "LICENSE") || strings.HasPrefix(base, "LICENCE") ||
+		strings.HasPrefix(base, "COPYING") || strings.HasPrefix(base, "NOTICE")
+}
+
+func gitOutput(args ...string) (string, error) {
+	out, err := exec.Command("git", args...).Output()
+	return strings.TrimSpace(string(out)), err
+}
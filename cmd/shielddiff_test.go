@@ -0,0 +1,75 @@
+package cmd
+
+import "testing"
+
+func TestAnalyzeShieldDiff(t *testing.T) {
+	nameStatus := "M\tgo.mod\nA\tpkg/new.go\nD\tnotes.txt\n"
+	patch := `diff --git a/go.mod b/go.mod
+--- a/go.mod
++++ b/go.mod
+@@ -3,0 +4 @@ go 1.22
++require github.com/foo/bar v1.2.3
+diff --git a/pkg/new.go b/pkg/new.go
+new file mode 100644
+--- /dev/null
++++ b/pkg/new.go
+@@ -0,0 +1,4 @@
++// Copyright (c) 2019 Someone Else
++package pkg
++
++// TODO: handle errors
+diff --git a/notes.txt b/notes.txt
+deleted file mode 100644
+--- a/notes.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-FIXME later
+`
+	r := analyzeShieldDiff(nameStatus, patch)
+
+	if len(r.Files) != 3 || r.Files[1].Path != "notes.txt" || r.Files[1].Status != "deleted" || r.Files[1].Removed != 1 {
+		t.Fatalf("unexpected files %+v", r.Files)
+	}
+	if r.Files[2].Status != "added" || r.Files[2].Added != 4 {
+		t.Errorf("pkg/new.go: %+v", r.Files[2])
+	}
+	if r.LinesAdded != 5 || r.LinesRemoved != 1 || r.TodosAdded != 1 || r.TodosRemoved != 1 {
+		t.Errorf("counts: %+v", r)
+	}
+	if r.TodoDensity != 200 {
+		t.Errorf("TodoDensity = %v, want 200", r.TodoDensity)
+	}
+	if len(r.Dependencies) != 1 || r.Dependencies[0].File != "go.mod" {
+		t.Errorf("dependencies: %+v", r.Dependencies)
+	}
+	if len(r.Licenses) != 1 || r.Licenses[0].File != "pkg/new.go" {
+		t.Errorf("licenses: %+v", r.Licenses)
+	}
+}
+
+func TestManifestDependency(t *testing.T) {
+	tests := []struct {
+		file, line string
+		dep        bool
+	}{
+		{"package.json", `    "left-pad": "^1.3.0",`, true},
+		{"package.json", `  "version": "1.0.0",`, false},
+		{"web/package.json", `    "react": "18.2.0"`, true},
+		{"requirements.txt", "requests>=2.31", true},
+		{"requirements-dev.txt", "# pinned for CI", false},
+		{"requirements.txt", "-r base.txt", false},
+		{"Cargo.toml", `serde = { version = "1", features = ["derive"] }`, true},
+		{"Cargo.toml", `edition = "2021"`, false},
+		{"pyproject.toml", `    "httpx>=0.27",`, true},
+		{"pyproject.toml", `requires-python = ">=3.10"`, false},
+		{"Gemfile", `gem "rails", "~> 7.1"`, true},
+		{"go.mod", "\tgolang.org/x/sync v0.7.0", true},
+		{"go.mod", "go 1.22", false},
+		{"main.go", `"fmt": "1"`, false},
+	}
+	for _, tt := range tests {
+		if got := manifestDependency(tt.file, tt.line) != ""; got != tt.dep {
+			t.Errorf("manifestDependency(%q, %q) = %v, want %v", tt.file, tt.line, got, tt.dep)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/msalah0e/palm/internal/ui"
+)
+
+// qualityRow is one provider's throughput weighed by how good its answer was.
+type qualityRow struct {
+	Name  string
+	TPS   float64
+	Score *evalScore // nil when the provider failed
+}
+
+// usefulTPS scales raw throughput by the judge's overall score, so a fast
+// provider that answers badly does not outrank a slower one that answers well.
+func usefulTPS(tps float64, s *evalScore) float64 {
+	if s == nil {
+		return 0
+	}
+	return tps * float64(s.Overall) / 100
+}
+
+// judgeOutputs scores each response to prompt with the eval judges and
+// returns the scores in input order. Failed responses get nil.
+func judgeOutputs(squad []SquadResult, prompt string, judges, env []string, timeout int) []*evalScore {
+	fmt.Printf("  %s Judging responses with %s...\n", ui.Info.Sprint("🔍"), formatJudges(judges))
+	fmt.Println()
+
+	scores := judgeResults(squad, prompt, "", nil, judges, env, timeout)
+	out := make([]*evalScore, len(squad))
+	for i := range scores {
+		if squad[i].Error == "" {
+			out[i] = &scores[i]
+		}
+	}
+	return out
+}
+
+func formatJudges(judges []string) string {
+	if len(judges) == 1 {
+		return ui.Info.Sprint(judges[0])
+	}
+	return ui.Info.Sprintf("%d judges", len(judges))
+}
+
+// printQualityScorecard ranks providers by useful throughput and grades the
+// stack on it, on the same scale as the raw tok/s grade.
+func printQualityScorecard(rows []qualityRow) {
+	var ranked []qualityRow
+	for _, r := range rows {
+		if r.Score != nil {
+			ranked = append(ranked, r)
+		}
+	}
+	if len(ranked) == 0 {
+		return
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return usefulTPS(ranked[i].TPS, ranked[i].Score) > usefulTPS(ranked[j].TPS, ranked[j].Score)
+	})
+
+	fmt.Println(ui.Brand.Sprint("  Quality-adjusted"))
+	var table [][]string
+	var total float64
+	for _, r := range ranked {
+		useful := usefulTPS(r.TPS, r.Score)
+		total += useful
+		table = append(table, []string{
+			r.Name,
+			fmt.Sprintf("%.1f", r.TPS),
+			formatScoreNum(r.Score.Overall),
+			fmt.Sprintf("%.1f", useful),
+			gradeFromScore(r.Score.Overall),
+		})
+	}
+	ui.Table([]string{"Provider", "tok/s", "Quality", "Useful tok/s", "Grade"}, table)
+
+	best := ranked[0]
+	fmt.Println()
+	fmt.Printf("  %s Most useful: %s at %.1f useful tok/s (quality %d)\n",
+		ui.Brand.Sprint("🏆"),
+		ui.Brand.Sprint(best.Name),
+		usefulTPS(best.TPS, best.Score),
+		best.Score.Overall)
+	if fastest := fastestRow(ranked); fastest.Name != best.Name {
+		fmt.Printf("  %s %s is faster but scores %d on quality\n",
+			ui.WarnIcon(), fastest.Name, fastest.Score.Overall)
+	}
+
+	avg := total / float64(len(ranked))
+	fmt.Printf("  Speed × Quality Grade: %s  (avg %.1f useful tok/s across %d providers)\n",
+		formatGrade(speedGrade(avg)), avg, len(ranked))
+	fmt.Println()
+}
+
+func fastestRow(rows []qualityRow) qualityRow {
+	best := rows[0]
+	for _, r := range rows[1:] {
+		if r.TPS > best.TPS {
+			best = r
+		}
+	}
+	return best
+}
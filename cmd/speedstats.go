@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/msalah0e/palm/internal/ui"
+)
+
+// sampleStats summarizes repeated measurements of one provider.
+type sampleStats struct {
+	N      int
+	Mean   float64
+	Median float64
+	StdDev float64 // sample standard deviation
+	Min    float64
+	Max    float64
+}
+
+// noisyCV is the coefficient of variation above which a provider's timings
+// are too spread out to rank it with confidence.
+const noisyCV = 0.2
+
+func summarizeSamples(samples []float64) sampleStats {
+	s := sampleStats{N: len(samples)}
+	if s.N == 0 {
+		return s
+	}
+	sorted := append([]float64(nil), samples...)
+	sort.Float64s(sorted)
+	s.Min, s.Max = sorted[0], sorted[s.N-1]
+	if s.N%2 == 1 {
+		s.Median = sorted[s.N/2]
+	} else {
+		s.Median = (sorted[s.N/2-1] + sorted[s.N/2]) / 2
+	}
+	for _, v := range samples {
+		s.Mean += v
+	}
+	s.Mean /= float64(s.N)
+	if s.N > 1 {
+		var sq float64
+		for _, v := range samples {
+			sq += (v - s.Mean) * (v - s.Mean)
+		}
+		s.StdDev = math.Sqrt(sq / float64(s.N-1))
+	}
+	return s
+}
+
+// CV is the coefficient of variation: spread relative to the mean.
+func (s sampleStats) CV() float64 {
+	if s.Mean == 0 {
+		return 0
+	}
+	return s.StdDev / s.Mean
+}
+
+// Noisy reports whether the spread makes the mean unreliable.
+func (s sampleStats) Noisy() bool {
+	return s.N > 1 && s.CV() > noisyCV
+}
+
+// rankedStats is a provider's statistics, for ranking.
+type rankedStats struct {
+	Name  string
+	Stats sampleStats
+}
+
+// tiedRankings returns the pairs of neighbours, in ranked order, whose
+// means differ by less than their combined standard deviation, so which
+// one is faster is within the noise.
+func tiedRankings(ranked []rankedStats) [][2]string {
+	var ties [][2]string
+	for i := 1; i < len(ranked); i++ {
+		a, b := ranked[i-1].Stats, ranked[i].Stats
+		if a.N < 2 || b.N < 2 {
+			continue
+		}
+		if math.Abs(a.Mean-b.Mean) < a.StdDev+b.StdDev {
+			ties = append(ties, [2]string{ranked[i-1].Name, ranked[i].Name})
+		}
+	}
+	return ties
+}
+
+// repeatRuns runs warmup untimed iterations, then runs timed ones, stopping
+// at the first failure. run reports whether it succeeded.
+func repeatRuns(warmup, runs int, run func(timed bool) bool) {
+	for i := 0; i < warmup; i++ {
+		if !run(false) {
+			return
+		}
+	}
+	for i := 0; i < max(runs, 1); i++ {
+		if !run(true) {
+			return
+		}
+	}
+}
+
+// printRepeatStats prints mean/median/stddev per provider and flags noisy
+// results and rankings that the spread can't support. unit formats a value.
+func printRepeatStats(title string, ranked []rankedStats, unit func(float64) string) {
+	if len(ranked) == 0 || ranked[0].Stats.N < 2 {
+		return
+	}
+	fmt.Printf("  %s (%d runs)\n\n", ui.Brand.Sprint(title), ranked[0].Stats.N)
+
+	var rows [][]string
+	for _, r := range ranked {
+		s := r.Stats
+		spread := fmt.Sprintf("%.0f%%", s.CV()*100)
+		if s.Noisy() {
+			spread = ui.Warn.Sprint(spread + " noisy")
+		}
+		rows = append(rows, []string{r.Name, unit(s.Mean), unit(s.Median), "±" + unit(s.StdDev), unit(s.Min) + "–" + unit(s.Max), spread})
+	}
+	ui.Table([]string{"Provider", "Mean", "Median", "Stddev", "Range", "Spread"}, rows)
+
+	ties := tiedRankings(ranked)
+	if len(ties) > 0 {
+		fmt.Println()
+	}
+	for _, t := range ties {
+		ui.Warn.Printf("  %s %s vs %s: the difference is within the noise — ranking unreliable, try more --runs\n", ui.WarnIcon(), t[0], t[1])
+	}
+	fmt.Println()
+}
+
+func formatSeconds(v float64) string {
+	return fmt.Sprintf("%.2fs", v)
+}
+
+func formatTPS(v float64) string {
+	return fmt.Sprintf("%.1f tok/s", v)
+}
@@ -6,10 +6,12 @@ import (
 	"math"
 	"os"
 	"os/exec"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/msalah0e/palm/internal/activity"
 	"github.com/msalah0e/palm/internal/registry"
 	"github.com/msalah0e/palm/internal/ui"
 	"github.com/msalah0e/palm/internal/vault"
@@ -27,6 +29,10 @@ type SpeedResult struct {
 	TPS       float64
 	ExitCode  int
 	Error     string
+	Output    string
+
+	TPSStats sampleStats // over the timed runs, with --runs
+	Score    *evalScore  // answer quality, with --judge
 }
 
 func speedtestCmd() *cobra.Command {
@@ -36,6 +42,11 @@ func speedtestCmd() *cobra.Command {
 		tools      string
 		timeout    int
 		showOutput bool
+		allKeys    bool
+		runs       int
+		warmup     int
+		judge      string
+		seed       int64
 	)
 
 	cmd := &cobra.Command{
@@ -47,12 +58,23 @@ Tests latency, throughput, and quality — displayed with progress bars and a sc
 
 When --tools is provided, runs a direct comparison between specific tools (benchmark mode).
 
+Single runs are noisy — loading a local model can dominate the first one.
+--warmup runs untimed iterations first, and --runs repeats the timed ones
+and reports mean, median and standard deviation per provider, flagging
+results too spread out to rank.
+
+Tok/s alone rewards models that babble. --judge has one or more tools score
+each answer the way palm eval does, and adds a speed × quality scorecard
+ranking providers by useful tok/s (throughput scaled by the quality score).
+
 Examples:
   palm speedtest                                      # Test all configured providers
   palm speedtest --prompt "explain recursion"          # Custom prompt
   palm speedtest --quick                               # Faster test (shorter prompt)
   palm speedtest "explain quicksort" --tools ollama,mods  # Compare specific tools
-  palm speedtest "fix the bug" --tools aider,codex --output  # Show tool output`,
+  palm speedtest "fix the bug" --tools aider,codex --output  # Show tool output
+  palm speedtest --runs 5 --warmup 1                   # Repeat for stable numbers
+  palm speedtest --judge ollama                        # Weigh speed by answer quality`,
 		Args: cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			// If positional arg provided, use as prompt
@@ -60,16 +82,24 @@ Examples:
 				prompt = args[0]
 			}
 
+			applySeed(&seed)
+			judges := parseJudges(judge)
+
 			// Benchmark mode: compare specific tools
 			if tools != "" {
-				runBenchmarkMode(prompt, tools, timeout, showOutput)
+				if prompt == "" {
+					prompt = benchmarkPrompt
+				}
+				results := runBenchmarkMode(prompt, tools, timeout, showOutput, warmup, runs, judges, allKeys)
+				var names []string
+				for _, r := range results {
+					names = append(names, r.Tool)
+				}
+				recordRun(cmd, "speedtest", seed, []string{prompt}, names, benchRunResults(results))
 				return
 			}
 
 			// Speedtest mode: auto-detect and test all providers
-			v := vault.New()
-			env := buildVaultEnv(v)
-
 			type testTarget struct {
 				Provider string
 				Model    string
@@ -119,6 +149,12 @@ Examples:
 				return
 			}
 
+			var names []string
+			for _, t := range targets {
+				names = append(names, t.Cmd[0])
+			}
+			env := buildVaultEnv(vault.New(), "speedtest", loadRegistry(), append(append([]string(nil), names...), judges...), allKeys)
+
 			if prompt == "" {
 				if quick {
 					prompt = "Say hello in 3 words"
@@ -131,6 +167,12 @@ Examples:
 			fmt.Println()
 			fmt.Printf("  Prompt:   %s\n", ui.Subtle.Sprint(prompt))
 			fmt.Printf("  Targets:  %d providers\n", len(targets))
+			if runs > 1 || warmup > 0 {
+				fmt.Printf("  Runs:     %d timed, %d warm-up\n", max(runs, 1), warmup)
+			}
+			if len(judges) > 0 {
+				fmt.Printf("  Judge:    %s\n", ui.Info.Sprint(strings.Join(judges, ", ")))
+			}
 			fmt.Println()
 
 			var mu sync.Mutex
@@ -147,7 +189,25 @@ Examples:
 						ui.Brand.Sprint(target.Provider),
 						target.Model)
 
-					result := runSpeedTest(target.Provider, target.Model, target.Cmd, prompt, env)
+					var result SpeedResult
+					var tps, secs []float64
+					repeatRuns(warmup, runs, func(timed bool) bool {
+						r := runSpeedTest(target.Provider, target.Model, target.Cmd, prompt, env)
+						if r.Error != "" || timed {
+							result = r
+						}
+						if timed && r.Error == "" {
+							tps = append(tps, r.TPS)
+							secs = append(secs, r.TotalTime.Seconds())
+						}
+						return r.Error == ""
+					})
+					if result.Error == "" && len(tps) > 1 {
+						// Report the typical run rather than the last one
+						result.TPSStats = summarizeSamples(tps)
+						result.TPS = result.TPSStats.Median
+						result.TotalTime = time.Duration(summarizeSamples(secs).Median * float64(time.Second))
+					}
 
 					mu.Lock()
 					results[idx] = result
@@ -169,21 +229,65 @@ Examples:
 			}
 
 			wg.Wait()
+			for _, r := range results {
+				_ = activity.Append(activity.Entry{
+					Action:   "speedtest",
+					Tool:     r.Provider,
+					Details:  r.Model,
+					Duration: r.TotalTime.Seconds(),
+					Status:   activity.StatusOf(r.Error == ""),
+					Value:    r.TPS,
+				})
+			}
 			fmt.Println()
+			if len(judges) > 0 {
+				squad := make([]SquadResult, len(results))
+				for i, r := range results {
+					squad[i] = SquadResult{Tool: r.Provider, Output: r.Output, Error: r.Error}
+				}
+				for i, s := range judgeOutputs(squad, prompt, judges, env, timeout) {
+					results[i].Score = s
+				}
+			}
 			printSpeedtestResults(results)
+			if len(judges) > 0 {
+				rows := make([]qualityRow, len(results))
+				for i, r := range results {
+					rows[i] = qualityRow{Name: r.Provider, TPS: r.TPS, Score: r.Score}
+				}
+				printQualityScorecard(rows)
+			}
+
+			var ranked []rankedStats
+			for _, r := range results {
+				if r.Error == "" {
+					ranked = append(ranked, rankedStats{Name: r.Provider, Stats: r.TPSStats})
+				}
+			}
+			sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Stats.Mean > ranked[j].Stats.Mean })
+			printRepeatStats("Throughput", ranked, formatTPS)
+			recordRun(cmd, "speedtest", seed, []string{prompt}, names, speedRunResults(results))
 		},
 	}
 
 	cmd.Flags().StringVar(&prompt, "prompt", "", "Custom test prompt")
 	cmd.Flags().BoolVar(&quick, "quick", false, "Quick test with shorter prompt")
 	cmd.Flags().StringVar(&tools, "tools", "", "Compare specific tools (e.g., ollama,mods)")
-	cmd.Flags().IntVar(&timeout, "timeout", 30, "Timeout per tool in seconds (benchmark mode)")
+	cmd.Flags().IntVar(&timeout, "timeout", 30, "Timeout per tool in seconds (benchmark mode and judges)")
 	cmd.Flags().BoolVar(&showOutput, "output", false, "Show tool output (benchmark mode)")
+	cmd.Flags().BoolVar(&allKeys, "all-keys", false, "Inject every vault key, not just the ones the tools need")
+	cmd.Flags().IntVar(&runs, "runs", 1, "Timed runs per provider; more than 1 reports mean, median and stddev")
+	cmd.Flags().IntVar(&warmup, "warmup", 0, "Untimed warm-up runs per provider before timing")
+	cmd.Flags().StringVar(&judge, "judge", "", "Tool(s) that score answer quality, comma-separated; adds a speed × quality grade")
+	addSeedFlag(cmd, &seed)
 	return cmd
 }
 
+// benchmarkPrompt is what benchmark mode asks when no prompt is given.
+const benchmarkPrompt = "Explain the difference between a stack and a queue in 100 words"
+
 // runBenchmarkMode compares specific tools on the same prompt.
-func runBenchmarkMode(prompt, tools string, timeout int, showOutput bool) {
+func runBenchmarkMode(prompt, tools string, timeout int, showOutput bool, warmup, runs int, judges []string, allKeys bool) []BenchResult {
 	reg := loadRegistry()
 	v := vault.New()
 
@@ -194,13 +298,20 @@ func runBenchmarkMode(prompt, tools string, timeout int, showOutput bool) {
 	}
 
 	if prompt == "" {
-		prompt = "Explain the difference between a stack and a queue in 100 words"
+		prompt = benchmarkPrompt
 	}
 
 	ui.Banner("benchmark")
 	fmt.Printf("  Prompt: %s\n", ui.Brand.Sprint(prompt))
 	fmt.Printf("  Tools:  %s\n", strings.Join(toolNames, ", "))
-	fmt.Printf("  Timeout: %ds\n\n", timeout)
+	fmt.Printf("  Timeout: %ds\n", timeout)
+	if runs > 1 || warmup > 0 {
+		fmt.Printf("  Runs:    %d timed, %d warm-up\n", max(runs, 1), warmup)
+	}
+	if len(judges) > 0 {
+		fmt.Printf("  Judge:   %s\n", ui.Info.Sprint(strings.Join(judges, ", ")))
+	}
+	fmt.Println()
 
 	var results []BenchResult
 
@@ -209,11 +320,8 @@ func runBenchmarkMode(prompt, tools string, timeout int, showOutput bool) {
 		tool := reg.Get(name)
 
 		bin := name
-		if tool != nil && tool.Install.Verify.Command != "" {
-			parts := strings.Fields(tool.Install.Verify.Command)
-			if len(parts) > 0 {
-				bin = parts[0]
-			}
+		if tool != nil {
+			bin = tool.Binary()
 		}
 
 		if _, err := exec.LookPath(bin); err != nil {
@@ -227,7 +335,22 @@ func runBenchmarkMode(prompt, tools string, timeout int, showOutput bool) {
 
 		fmt.Printf("  Running %s... ", ui.Brand.Sprint(name))
 
-		result := runBenchmark(name, bin, prompt, tool, v, timeout)
+		var result BenchResult
+		var secs []float64
+		repeatRuns(warmup, runs, func(timed bool) bool {
+			r := runBenchmark(name, bin, prompt, tool, v, timeout)
+			if r.Error != "" || timed {
+				result = r
+			}
+			if timed && r.Error == "" {
+				secs = append(secs, r.Duration.Seconds())
+			}
+			return r.Error == ""
+		})
+		if result.Error == "" && len(secs) > 1 {
+			result.Stats = summarizeSamples(secs)
+			result.Duration = time.Duration(result.Stats.Median * float64(time.Second))
+		}
 		results = append(results, result)
 
 		if result.Error != "" {
@@ -257,6 +380,33 @@ func runBenchmarkMode(prompt, tools string, timeout int, showOutput bool) {
 
 	ui.Table(headers, rows)
 
+	if len(judges) > 0 {
+		fmt.Println()
+		squad := make([]SquadResult, len(results))
+		for i, r := range results {
+			squad[i] = SquadResult{Tool: r.Tool, Output: r.Output, Error: r.Error}
+		}
+		env := buildVaultEnv(v, "speedtest", reg, judges, allKeys)
+		qrows := make([]qualityRow, len(results))
+		for i, s := range judgeOutputs(squad, prompt, judges, env, timeout) {
+			results[i].Score = s
+			qrows[i] = qualityRow{Name: results[i].Tool, TPS: results[i].TPS(), Score: s}
+		}
+		printQualityScorecard(qrows)
+	}
+
+	var ranked []rankedStats
+	for _, r := range results {
+		if r.Error == "" {
+			ranked = append(ranked, rankedStats{Name: r.Tool, Stats: r.Stats})
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Stats.Mean < ranked[j].Stats.Mean })
+	if len(ranked) > 0 && ranked[0].Stats.N > 1 {
+		fmt.Println()
+		printRepeatStats("Latency", ranked, formatSeconds)
+	}
+
 	if showOutput {
 		fmt.Println()
 		for _, r := range results {
@@ -271,6 +421,7 @@ func runBenchmarkMode(prompt, tools string, timeout int, showOutput bool) {
 			}
 		}
 	}
+	return results
 }
 
 // BenchResult holds the result of benchmarking a single tool.
@@ -280,27 +431,38 @@ type BenchResult struct {
 	Output   string
 	ExitCode int
 	Error    string
+
+	Stats sampleStats // over the timed runs, with --runs
+	Score *evalScore  // answer quality, with --judge
+}
+
+// TPS estimates throughput from the output length (chars / 4) and duration.
+func (r BenchResult) TPS() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(len(r.Output)/4) / r.Duration.Seconds()
 }
 
 func runBenchmark(name, bin, prompt string, tool *registry.Tool, v vault.Vault, timeout int) BenchResult {
 	env := os.Environ()
 	if tool != nil {
 		allKeys := append(tool.Keys.Required, tool.Keys.Optional...)
+		var injected []string
 		for _, key := range allKeys {
 			if os.Getenv(key) == "" {
 				if val, err := v.Get(key); err == nil {
 					env = append(env, fmt.Sprintf("%s=%s", key, val))
+					injected = append(injected, key)
 				}
 			}
 		}
+		_ = vault.RecordUse(name, injected...)
 	}
 
-	var cmdArgs []string
-	switch name {
-	case "ollama":
-		cmdArgs = []string{bin, "run", "llama3.3", prompt}
-	default:
-		cmdArgs = []string{bin, prompt}
+	cmdArgs := []string{bin, prompt}
+	if tool != nil {
+		cmdArgs = tool.PromptArgs(prompt)
 	}
 
 	var stdout, stderr bytes.Buffer
@@ -354,10 +516,10 @@ func runBenchmark(name, bin, prompt string, tool *registry.Tool, v vault.Vault,
 
 func printSpeedtestHeader() {
 	fmt.Println()
-	fmt.Println(ui.Brand.Sprint("  ╔═══════════════════════════════════════════════╗"))
-	fmt.Println(ui.Brand.Sprint("  ║") + "   🌴  " + ui.Brand.Sprint("palm speedtest") + "                          " + ui.Brand.Sprint("║"))
-	fmt.Println(ui.Brand.Sprint("  ║") + "   " + ui.Subtle.Sprint("AI performance benchmark for your stack") + "   " + ui.Brand.Sprint("║"))
-	fmt.Println(ui.Brand.Sprint("  ╚═══════════════════════════════════════════════╝"))
+	ui.Box(ui.BoxDouble, "", []string{
+		"🌴  " + ui.Brand.Sprint("palm speedtest"),
+		ui.Subtle.Sprint("AI performance benchmark for your stack"),
+	})
 }
 
 func runSpeedTest(provider, model string, cmdArgs []string, prompt string, env []string) SpeedResult {
@@ -412,6 +574,7 @@ func runSpeedTest(provider, model string, cmdArgs []string, prompt string, env [
 			TokensEst: tokensEst,
 			TPS:       tps,
 			ExitCode:  0,
+			Output:    output,
 		}
 
 	case <-time.After(90 * time.Second):
@@ -427,10 +590,6 @@ func runSpeedTest(provider, model string, cmdArgs []string, prompt string, env [
 }
 
 func printSpeedtestResults(results []SpeedResult) {
-	fmt.Println(ui.Brand.Sprint("  ┌─────────────────────────────────────────────────────────┐"))
-	fmt.Println(ui.Brand.Sprint("  │") + "  " + ui.Brand.Sprint("RESULTS") + "                                                " + ui.Brand.Sprint("│"))
-	fmt.Println(ui.Brand.Sprint("  ├─────────────────────────────────────────────────────────┤"))
-
 	var maxTPS float64
 	for _, r := range results {
 		if r.TPS > maxTPS {
@@ -441,20 +600,16 @@ func printSpeedtestResults(results []SpeedResult) {
 		maxTPS = 1
 	}
 
+	var lines []string
 	for _, r := range results {
-		fmt.Println(ui.Brand.Sprint("  │") + "                                                         " + ui.Brand.Sprint("│"))
+		lines = append(lines, "")
 
 		if r.Error != "" {
-			fmt.Printf(ui.Brand.Sprint("  │")+"  %-12s  %s%s\n",
-				r.Provider,
-				ui.Bad.Sprint("FAILED: "+r.Error),
-				strings.Repeat(" ", max(0, 40-len("FAILED: "+r.Error)))+ui.Brand.Sprint("│"))
+			lines = append(lines, fmt.Sprintf("%-12s  %s", r.Provider, ui.Bad.Sprint("FAILED: "+r.Error)))
 			continue
 		}
 
-		provLine := fmt.Sprintf("  %-12s  %s", r.Provider, ui.Subtle.Sprint(r.Model))
-		pad1 := max(0, 55-len(r.Provider)-2-len(r.Model)-2)
-		fmt.Println(ui.Brand.Sprint("  │") + provLine + strings.Repeat(" ", pad1) + ui.Brand.Sprint("│"))
+		lines = append(lines, fmt.Sprintf("%-12s  %s", r.Provider, ui.Subtle.Sprint(r.Model)))
 
 		barWidth := 30
 		filled := int(math.Round((r.TPS / maxTPS) * float64(barWidth)))
@@ -463,24 +618,15 @@ func printSpeedtestResults(results []SpeedResult) {
 		}
 
 		bar := ui.Brand.Sprint(strings.Repeat("█", filled)) + ui.Subtle.Sprint(strings.Repeat("░", barWidth-filled))
-		tpsStr := fmt.Sprintf("%.1f tok/s", r.TPS)
-		pad2 := max(0, 19-len(tpsStr))
-		fmt.Printf(ui.Brand.Sprint("  │")+"  %s  %s%s"+ui.Brand.Sprint("│")+"\n",
-			bar, tpsStr, strings.Repeat(" ", pad2))
+		lines = append(lines, fmt.Sprintf("%s  %.1f tok/s", bar, r.TPS))
 
 		timeStr := fmt.Sprintf("%.2fs", r.TotalTime.Seconds())
 		outStr := formatBytes(r.OutputLen)
 		tokStr := fmt.Sprintf("~%d tokens", r.TokensEst)
-		statsLine := fmt.Sprintf("  %s  %s  %s",
-			ui.Subtle.Sprint(timeStr),
-			ui.Subtle.Sprint(outStr),
-			ui.Subtle.Sprint(tokStr))
-		pad3 := max(0, 55-len(timeStr)-len(outStr)-len(tokStr)-6)
-		fmt.Println(ui.Brand.Sprint("  │") + statsLine + strings.Repeat(" ", pad3) + ui.Brand.Sprint("│"))
+		lines = append(lines, ui.Subtle.Sprintf("%s  %s  %s", timeStr, outStr, tokStr))
 	}
-
-	fmt.Println(ui.Brand.Sprint("  │") + "                                                         " + ui.Brand.Sprint("│"))
-	fmt.Println(ui.Brand.Sprint("  └─────────────────────────────────────────────────────────┘"))
+	lines = append(lines, "")
+	ui.Box(ui.BoxSingle, ui.Brand.Sprint("RESULTS"), lines)
 
 	var winner *SpeedResult
 	for i := range results {
@@ -518,25 +664,26 @@ func printSpeedGrade(results []SpeedResult) {
 	}
 
 	avgTPS := totalTPS / float64(count)
+	fmt.Printf("  Your AI Stack Grade: %s  (avg %.1f tok/s across %d providers)\n",
+		formatGrade(speedGrade(avgTPS)), avgTPS, count)
+}
 
-	var grade string
+// speedGrade grades an average throughput in tok/s.
+func speedGrade(tps float64) string {
 	switch {
-	case avgTPS >= 100:
-		grade = "A+"
-	case avgTPS >= 50:
-		grade = "A"
-	case avgTPS >= 25:
-		grade = "B"
-	case avgTPS >= 10:
-		grade = "C"
-	case avgTPS >= 5:
-		grade = "D"
+	case tps >= 100:
+		return "A+"
+	case tps >= 50:
+		return "A"
+	case tps >= 25:
+		return "B"
+	case tps >= 10:
+		return "C"
+	case tps >= 5:
+		return "D"
 	default:
-		grade = "F"
+		return "F"
 	}
-
-	fmt.Printf("  Your AI Stack Grade: %s  (avg %.1f tok/s across %d providers)\n",
-		formatGrade(grade), avgTPS, count)
 }
 
 func formatGrade(grade string) string {
@@ -553,10 +700,16 @@ func formatGrade(grade string) string {
 }
 
 func formatBytes(n int) string {
-	if n < 1024 {
+	switch {
+	case n < 1024:
 		return fmt.Sprintf("%dB", n)
+	case n < 1024*1024:
+		return fmt.Sprintf("%.1fKB", float64(n)/1024)
+	case n < 1024*1024*1024:
+		return fmt.Sprintf("%.1fMB", float64(n)/(1024*1024))
+	default:
+		return fmt.Sprintf("%.1fGB", float64(n)/(1024*1024*1024))
 	}
-	return fmt.Sprintf("%.1fKB", float64(n)/1024)
 }
 
 func max(a, b int) int {
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"math"
 	"testing"
 )
 
@@ -157,3 +158,82 @@ func TestPrintSpeedGrade_GradeThresholds(t *testing.T) {
 		printSpeedGrade(results)
 	}
 }
+
+func TestSummarizeSamples(t *testing.T) {
+	s := summarizeSamples([]float64{2, 4, 4, 4, 5, 5, 7, 9})
+	if s.N != 8 || s.Mean != 5 || s.Median != 4.5 || s.Min != 2 || s.Max != 9 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+	// Sample standard deviation: sqrt(32/7)
+	if math.Abs(s.StdDev-2.138) > 0.001 {
+		t.Errorf("StdDev = %.4f, want 2.138", s.StdDev)
+	}
+
+	one := summarizeSamples([]float64{3})
+	if one.StdDev != 0 || one.Median != 3 || one.Noisy() {
+		t.Errorf("a single sample has no spread: %+v", one)
+	}
+}
+
+func TestTiedRankings(t *testing.T) {
+	ranked := []rankedStats{
+		{"ollama", summarizeSamples([]float64{35, 65, 50})}, // 50 ± 15
+		{"mods", summarizeSamples([]float64{42, 46, 44})},   // 44 ± 2, within the noise
+		{"llm", summarizeSamples([]float64{10, 11, 12})},    // 11 ± 1, clearly slower
+	}
+	ties := tiedRankings(ranked)
+	if len(ties) != 1 || ties[0] != [2]string{"ollama", "mods"} {
+		t.Errorf("tiedRankings = %v", ties)
+	}
+	if !ranked[0].Stats.Noisy() || ranked[2].Stats.Noisy() {
+		t.Error("noise detection is off")
+	}
+}
+
+func TestRepeatRuns(t *testing.T) {
+	var warm, timed int
+	repeatRuns(2, 3, func(isTimed bool) bool {
+		if isTimed {
+			timed++
+		} else {
+			warm++
+		}
+		return true
+	})
+	if warm != 2 || timed != 3 {
+		t.Errorf("got %d warm-up and %d timed runs, want 2 and 3", warm, timed)
+	}
+
+	calls := 0
+	repeatRuns(1, 5, func(bool) bool { calls++; return false })
+	if calls != 1 {
+		t.Errorf("a failing warm-up should stop the runs, got %d calls", calls)
+	}
+}
+
+func TestUsefulTPS(t *testing.T) {
+	babbler := &evalScore{Tool: "fast", Overall: 20}
+	careful := &evalScore{Tool: "slow", Overall: 90}
+	if got := usefulTPS(100, babbler); got != 20 {
+		t.Errorf("usefulTPS(100, 20) = %v, want 20", got)
+	}
+	if usefulTPS(40, careful) <= usefulTPS(100, babbler) {
+		t.Error("a slower, better answer should be more useful than a fast, poor one")
+	}
+	if usefulTPS(100, nil) != 0 {
+		t.Error("unjudged results have no useful throughput")
+	}
+	if g := speedGrade(usefulTPS(40, careful)); g != "B" {
+		t.Errorf("speedGrade(36) = %s, want B", g)
+	}
+
+	m := map[string]float64{}
+	addQualityMetrics(m, 40, careful)
+	if m["quality"] != 90 || m["useful_tps"] != 36 {
+		t.Errorf("quality metrics = %v", m)
+	}
+
+	// Should not panic with failed or unjudged providers
+	printQualityScorecard([]qualityRow{{Name: "down"}})
+	printQualityScorecard([]qualityRow{{Name: "fast", TPS: 100, Score: babbler}, {Name: "slow", TPS: 40, Score: careful}, {Name: "down"}})
+}
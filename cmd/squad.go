@@ -9,7 +9,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/msalah0e/palm/internal/activity"
 	"github.com/msalah0e/palm/internal/registry"
+	"github.com/msalah0e/palm/internal/session"
 	"github.com/msalah0e/palm/internal/ui"
 	"github.com/msalah0e/palm/internal/vault"
 	"github.com/spf13/cobra"
@@ -22,6 +24,10 @@ type SquadResult struct {
 	Duration time.Duration
 	ExitCode int
 	Error    string
+	// Patch holds the changes the tool made under --isolate, as a git
+	// patch, and Changes their git diff --shortstat.
+	Patch   string
+	Changes string
 }
 
 func squadCmd() *cobra.Command {
@@ -31,28 +37,69 @@ func squadCmd() *cobra.Command {
 		timeout int
 		mode    string
 		showAll bool
+		noTUI   bool
+		allKeys bool
+		maxCost float64
+		file    string
+		attach  string
+		seed    int64
+		isolate bool
+		apply   bool
 	)
 
 	cmd := &cobra.Command{
-		Use:   `squad "<task>" --tools tool1,tool2 [--judge ollama]`,
+		Use:   `squad ["<task>" | -] --tools tool1,tool2 [--judge ollama]`,
 		Short: "Run multiple AI tools on the same task, pick the best result",
 		Long: `Squad runs the same task through multiple AI tools in parallel,
 then optionally uses a "judge" AI to evaluate and pick the best result.
 
 Modes:
   race    First tool to finish wins (default)
-  vote    All tools run, judge picks the best
+  vote    All tools run, judge picks the best (several judges vote by majority)
   merge   All tools run, judge merges/synthesizes results
-  all     Show all outputs side by side
+  all     Show all outputs side by side (in a terminal, a scrollable view
+          where you can mark a winner, copy an output, or save it)
 
 Examples:
   palm squad "explain quicksort" --tools ollama,aider --mode race
   palm squad "fix the bug in main.py" --tools aider,codex --judge ollama --mode vote
+  palm squad "fix the bug in main.py" --tools aider,codex,goose --judge ollama,mods,llm --mode vote
   palm squad "write unit tests" --tools claude-code,aider,codex --mode merge --judge ollama
-  palm squad "review this code" --tools ollama,aider --mode all`,
-		Args: cobra.ExactArgs(1),
+  palm squad "review this code" --tools ollama,aider --mode all
+  palm squad "summarize README.md" --tools claude-code,codex,ollama --max-cost 0.05
+  palm squad --task-file prompt.md --tools aider,codex --mode all
+  git diff | palm squad - --tools ollama,mods --mode all
+  palm squad "find the race" --attach worker.go,pool.go --tools aider,ollama
+  palm squad "fix the flaky test" --tools aider,codex --judge ollama --mode vote --isolate
+
+Long tasks can come from --task-file, or from stdin with - as the task.
+--attach adds files to the task: tools that take files by path (aider) get
+the paths, and the rest get the contents inlined after the task.
+
+Before dispatching, squad estimates what each cloud tool will cost from the
+models catalog, and after the run it prints estimated against actual cost.
+--max-cost skips tools whose estimate is over the cap.
+
+Coding agents editing the same repository at once trample each other's
+changes. --isolate runs each tool in its own temporary git worktree holding
+the current state of the repository, uncommitted and untracked files
+included. Afterwards the changes each tool made are saved as candidate
+patches under ~/.config/palm/squad-patches; judges see them in vote and
+merge modes, and you're asked which one to apply. --apply applies the
+winner's patch without asking: the judges' pick in vote mode, or the first
+finisher in race mode.`,
+		Args: cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			task := args[0]
+			task, err := readSquadTask(args, file, os.Stdin)
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+			files, err := loadSquadAttachments(attach)
+			if err != nil {
+				ui.Bad.Printf("  Failed to attach: %v\n", err)
+				os.Exit(1)
+			}
 			toolNames := strings.Split(tools, ",")
 
 			if len(toolNames) < 2 {
@@ -71,6 +118,10 @@ Examples:
 				ui.Bad.Printf("  Unknown mode: %s (use race, vote, merge, or all)\n", mode)
 				os.Exit(1)
 			}
+			if apply && !isolate {
+				ui.Warn.Println("  --apply needs --isolate")
+				os.Exit(1)
+			}
 
 			// Judge required for vote and merge modes
 			if (mode == "vote" || mode == "merge") && judge == "" {
@@ -78,69 +129,215 @@ Examples:
 				fmt.Println("  Example: --judge ollama")
 				os.Exit(1)
 			}
+			var judges []string
+			if judge != "" {
+				judges = judgeList(judge)
+			}
+
+			reg := loadRegistry()
+			v := vault.New()
 
 			ui.Banner("squad")
-			fmt.Printf("  Task:  %s\n", ui.Brand.Sprint(task))
+			fmt.Printf("  Task:  %s\n", ui.Brand.Sprint(truncatePrompt(task, 100)))
+			if len(files) > 0 {
+				var paths []string
+				for _, f := range files {
+					paths = append(paths, f.Path)
+				}
+				fmt.Printf("  Files: %s\n", strings.Join(paths, ", "))
+			}
+
+			// Price the task per tool, dropping tools over the cap
+			var costs []squadCost
+			var kept []string
+			var estimate float64
+			// Tools given files by path read them too, so price them inlined
+			priced := inlineAttachments(task, files)
+			for _, name := range toolNames {
+				c := estimateSquadCost(reg, name, priced)
+				if maxCost > 0 && c.Estimated > maxCost {
+					ui.Warn.Printf("  %s Skipping %s: estimated $%.4f is over --max-cost $%.4f\n", ui.WarnIcon(), name, c.Estimated, maxCost)
+					continue
+				}
+				costs = append(costs, c)
+				kept = append(kept, name)
+				estimate += c.Estimated
+			}
+			if len(kept) == 0 {
+				ui.Bad.Printf("  Every tool is estimated over --max-cost $%.4f\n", maxCost)
+				os.Exit(1)
+			}
+			toolNames = kept
+
 			fmt.Printf("  Tools: %s\n", strings.Join(toolNames, ", "))
 			fmt.Printf("  Mode:  %s\n", ui.Info.Sprint(mode))
 			if judge != "" {
 				fmt.Printf("  Judge: %s\n", ui.Info.Sprint(judge))
 			}
+			fmt.Printf("  Est.:  $%.4f\n", estimate)
 			fmt.Println()
 
-			reg := loadRegistry()
-			v := vault.New()
-
-			// Build environment with all vault keys
-			env := buildVaultEnv(v)
+			// Inject only the keys the tools and judges need
+			applySeed(&seed)
+			env := buildVaultEnv(v, "squad", reg, append(toolNames, judges...), allKeys)
 
-			// Run all tools in parallel
-			results := runSquad(toolNames, task, reg, env, timeout)
+			// Run all tools in parallel, each in its own worktree with --isolate
+			var iso *squadIsolation
+			var dirs []string
+			if isolate {
+				iso, err = isolateSquad(toolNames)
+				if err != nil {
+					ui.Bad.Printf("  Failed to isolate tools: %v\n", err)
+					os.Exit(1)
+				}
+				defer iso.cleanup()
+				dirs = iso.dirs()
+			}
+			results := runSquadIn(toolNames, task, files, reg, env, timeout, dirs)
+			if iso != nil {
+				iso.collect(results)
+			}
+			for i, r := range results {
+				c := &costs[i]
+				ran := r.Error != "not installed"
+				if ran {
+					c.settle(r.Output)
+				}
+				_ = activity.Append(activity.Entry{
+					Action:   "squad",
+					Tool:     r.Tool,
+					Details:  mode,
+					Duration: r.Duration.Seconds(),
+					Status:   activity.StatusOf(r.Error == ""),
+					Cost:     c.Actual,
+				})
+				// Count cloud spend toward the budget
+				if ran && c.Known() && !c.Local() {
+					_ = session.Record(toolNames[i], r.Duration, r.ExitCode, c.Actual, int64(c.InputTokens+c.OutputTokens), c.Model.Provider)
+				}
+			}
 
 			// Display results based on mode
+			winner := -1
 			switch mode {
 			case "race":
-				handleRaceMode(results)
+				winner = handleRaceMode(results)
 			case "all":
-				handleAllMode(results, showAll)
+				if !noTUI && squadTUIAvailable() {
+					winner = handleAllModeTUI(results)
+				} else {
+					handleAllMode(results, showAll)
+				}
 			case "vote":
-				handleVoteMode(results, judge, task, env, timeout)
+				winner = handleVoteMode(results, judges, task, env, timeout)
 			case "merge":
-				handleMergeMode(results, judge, task, env, timeout)
+				handleMergeMode(results, judges, task, env, timeout)
+			}
+			if iso != nil {
+				handleSquadPatches(iso, results, winner, apply)
 			}
+
+			printSquadCosts(costs, results)
+			recordRun(cmd, "squad", seed, []string{task}, append(toolNames, judges...), squadRunResults(toolNames, results, costs))
 		},
 	}
 
 	cmd.Flags().StringVar(&tools, "tools", "", "Comma-separated list of tools (required)")
-	cmd.Flags().StringVar(&judge, "judge", "", "Tool to judge/merge results (e.g., ollama)")
+	cmd.Flags().StringVar(&judge, "judge", "", "Tool(s) to judge/merge results, comma-separated for a judge ensemble (e.g., ollama,mods,llm)")
 	cmd.Flags().IntVar(&timeout, "timeout", 60, "Timeout per tool in seconds")
 	cmd.Flags().StringVar(&mode, "mode", "race", "Squad mode: race, vote, merge, all")
 	cmd.Flags().BoolVar(&showAll, "verbose", false, "Show full output from each tool")
+	cmd.Flags().BoolVar(&noTUI, "no-tui", false, "In all mode, print the outputs instead of opening the comparison view")
+	cmd.Flags().BoolVar(&allKeys, "all-keys", false, "Inject every vault key, not just the ones the tools need")
+	cmd.Flags().Float64Var(&maxCost, "max-cost", 0, "Skip tools whose estimated cost in USD is over this (0 = no cap)")
+	cmd.Flags().StringVar(&file, "task-file", "", "Read the task from a file (- for stdin)")
+	cmd.Flags().StringVar(&attach, "attach", "", "Comma-separated files to attach to the task")
+	cmd.Flags().BoolVar(&isolate, "isolate", false, "Run each tool in its own temporary git worktree and collect its changes as a patch")
+	cmd.Flags().BoolVar(&apply, "apply", false, "With --isolate, apply the winning tool's patch without asking")
+	addSeedFlag(cmd, &seed)
 	_ = cmd.MarkFlagRequired("tools")
 	return cmd
 }
 
-func buildVaultEnv(v vault.Vault) []string {
+// buildVaultEnv returns the environment for tools run on behalf of consumer.
+// Only the keys the registry lists for tools are injected unless allKeys is
+// set; a workspace key list narrows either choice. Injected key names are
+// printed so exposure can be audited.
+func buildVaultEnv(v vault.Vault, consumer string, reg *registry.Registry, tools []string, allKeys bool) []string {
 	env := os.Environ()
-	keys, _ := v.List()
+	proj, _ := loadProject()
+	keys := toolKeys(reg, tools)
+	if allKeys {
+		keys, _ = v.List()
+	}
+	var injected []string
 	for _, key := range keys {
-		if val, err := v.Get(key); err == nil {
-			if os.Getenv(key) == "" {
-				env = append(env, fmt.Sprintf("%s=%s", key, val))
+		if !proj.allowsKey(key) || os.Getenv(key) != "" {
+			continue
+		}
+		val, err := v.Get(key)
+		if err != nil {
+			warnUnresolved(v, err, key)
+			continue
+		}
+		env = append(env, fmt.Sprintf("%s=%s", key, val))
+		injected = append(injected, key)
+	}
+	if len(injected) > 0 {
+		_ = vault.RecordUse(consumer, injected...)
+		ui.Subtle.Fprintf(os.Stderr, "  palm: injected %s\n", strings.Join(injected, ", "))
+	}
+	return proj.applyEnv(env)
+}
+
+// toolKeys lists the required and optional keys of the named registry tools,
+// without duplicates. Names the registry doesn't know contribute nothing.
+func toolKeys(reg *registry.Registry, names []string) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		tool := reg.Get(name)
+		if tool == nil {
+			continue
+		}
+		for _, key := range append(append([]string{}, tool.Keys.Required...), tool.Keys.Optional...) {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
 			}
 		}
 	}
-	return env
+	return keys
 }
 
-func runSquad(toolNames []string, task string, reg *registry.Registry, env []string, timeout int) []SquadResult {
+// promptArgs returns the command that sends prompt to the named tool, from
+// its registry invoke template. Tools the registry doesn't know get the
+// prompt as their only argument.
+func promptArgs(reg *registry.Registry, name, prompt string) []string {
+	if reg != nil {
+		if tool := reg.Get(name); tool != nil {
+			return tool.PromptArgs(prompt)
+		}
+	}
+	return []string{name, prompt}
+}
+
+func runSquad(toolNames []string, task string, files []squadAttachment, reg *registry.Registry, env []string, timeout int) []SquadResult {
+	return runSquadIn(toolNames, task, files, reg, env, timeout, nil)
+}
+
+// runSquadIn runs the tools concurrently, each in the matching directory of
+// dirs, or in the current one when dirs is nil.
+func runSquadIn(toolNames []string, task string, files []squadAttachment, reg *registry.Registry, env []string, timeout int, dirs []string) []SquadResult {
 	var (
 		mu      sync.Mutex
 		wg      sync.WaitGroup
 		results = make([]SquadResult, len(toolNames))
 	)
 
-	fmt.Printf("  %s Dispatching to %d tools...\n\n", ui.Info.Sprint("⚡"), len(toolNames))
+	if !jsonOutput {
+		fmt.Printf("  %s Dispatching to %d tools...\n\n", ui.Info.Sprint("⚡"), len(toolNames))
+	}
 
 	for i, name := range toolNames {
 		wg.Add(1)
@@ -148,13 +345,8 @@ func runSquad(toolNames []string, task string, reg *registry.Registry, env []str
 			defer wg.Done()
 
 			tool := reg.Get(toolName)
-			bin := toolName
-			if tool != nil && tool.Install.Verify.Command != "" {
-				parts := strings.Fields(tool.Install.Verify.Command)
-				if len(parts) > 0 {
-					bin = parts[0]
-				}
-			}
+			cmdArgs, prompt := squadToolArgs(reg, toolName, task, files)
+			bin := cmdArgs[0]
 
 			displayName := toolName
 			if tool != nil {
@@ -173,21 +365,15 @@ func runSquad(toolNames []string, task string, reg *registry.Registry, env []str
 				return
 			}
 
-			// Build command
-			var cmdArgs []string
-			switch toolName {
-			case "ollama":
-				cmdArgs = []string{bin, "run", "llama3.3", task}
-			default:
-				cmdArgs = []string{bin, task}
-			}
-
 			var stdout, stderr bytes.Buffer
 			c := exec.Command(cmdArgs[0], cmdArgs[1:]...)
 			c.Stdout = &stdout
 			c.Stderr = &stderr
 			c.Env = env
-			c.Stdin = strings.NewReader(task)
+			c.Stdin = strings.NewReader(prompt)
+			if dirs != nil {
+				c.Dir = dirs[idx]
+			}
 
 			start := time.Now()
 			if err := c.Start(); err != nil {
@@ -240,15 +426,18 @@ func runSquad(toolNames []string, task string, reg *registry.Registry, env []str
 	return results
 }
 
-func handleRaceMode(results []SquadResult) {
+// handleRaceMode reports the fastest successful tool and returns its index,
+// or -1 when every tool failed.
+func handleRaceMode(results []SquadResult) int {
 	fmt.Printf("  %s %s mode — first successful result wins\n\n", ui.Info.Sprint("🏎️"), ui.Brand.Sprint("Race"))
 
 	// Find first successful result (lowest duration)
 	var winner *SquadResult
+	index := -1
 	for i := range results {
 		r := &results[i]
 		if r.Error == "" && (winner == nil || r.Duration < winner.Duration) {
-			winner = r
+			winner, index = r, i
 		}
 	}
 
@@ -265,6 +454,7 @@ func handleRaceMode(results []SquadResult) {
 	} else {
 		ui.Bad.Println("\n  All tools failed")
 	}
+	return index
 }
 
 func handleAllMode(results []SquadResult, verbose bool) {
@@ -293,27 +483,53 @@ func handleAllMode(results []SquadResult, verbose bool) {
 	}
 }
 
-func handleVoteMode(results []SquadResult, judge, task string, env []string, timeout int) {
+// handleAllModeTUI opens the side-by-side view, then prints the summary and
+// the winner so they stay in the scrollback. It returns the index of the
+// winner marked, or -1.
+func handleAllModeTUI(results []SquadResult) int {
+	p, err := runSquadPicker(results)
+	if err != nil {
+		handleAllMode(results, false)
+		return -1
+	}
+
+	fmt.Printf("  %s %s mode — compared side by side\n\n", ui.Info.Sprint("📋"), ui.Brand.Sprint("All"))
+	printSquadSummary(results)
+	if p.winner >= 0 {
+		w := results[p.winner]
+		fmt.Printf("\n  %s Winner: %s\n", ui.Brand.Sprint("🏆"), ui.Brand.Sprint(w.Tool))
+		if w.Output != "" {
+			fmt.Println()
+			fmt.Println("  " + strings.Repeat("─", 60))
+			printTruncatedOutput(w.Output, 2000)
+		}
+	}
+	if p.saved != "" {
+		fmt.Printf("\n  %s Saved to %s\n", ui.StatusIcon(true), p.saved)
+	}
+	return p.winner
+}
+
+// handleVoteMode has the judges pick the best result and returns its index,
+// or -1 when they didn't settle on one.
+func handleVoteMode(results []SquadResult, judges []string, task string, env []string, timeout int) int {
 	fmt.Printf("  %s %s mode — judge picks the best\n\n", ui.Info.Sprint("🗳️"), ui.Brand.Sprint("Vote"))
 
 	printSquadSummary(results)
 
 	// Collect successful outputs
 	var candidates []string
+	valid := make(map[int]bool)
 	for i, r := range results {
-		if r.Error == "" && r.Output != "" {
-			// Truncate long outputs for the judge
-			output := r.Output
-			if len(output) > 1000 {
-				output = output[:1000] + "..."
-			}
-			candidates = append(candidates, fmt.Sprintf("=== Candidate %d (%s, %.1fs) ===\n%s", i+1, r.Tool, r.Duration.Seconds(), output))
+		if r.Error == "" && (r.Output != "" || r.Patch != "") {
+			candidates = append(candidates, fmt.Sprintf("=== Candidate %d (%s, %.1fs) ===\n%s", i+1, r.Tool, r.Duration.Seconds(), candidateText(r)))
+			valid[i+1] = true
 		}
 	}
 
 	if len(candidates) < 2 {
 		ui.Warn.Println("\n  Need at least 2 successful results for voting")
-		return
+		return -1
 	}
 
 	// Build judge prompt
@@ -328,6 +544,11 @@ Pick the BEST response. Reply with ONLY:
 2. A brief reason why (1 sentence)
 3. Then paste the winning output`, task, strings.Join(candidates, "\n\n"))
 
+	if len(judges) > 1 {
+		return handleJudgeEnsemble(results, judges, judgePrompt, valid, env, timeout)
+	}
+	judge := judges[0]
+
 	fmt.Printf("\n  %s Sending to judge (%s)...\n", ui.Info.Sprint("⚖️"), ui.Brand.Sprint(judge))
 
 	judgeOutput := runJudge(judge, judgePrompt, env, timeout)
@@ -339,9 +560,53 @@ Pick the BEST response. Reply with ONLY:
 	} else {
 		ui.Bad.Println("  Judge failed to produce output")
 	}
+	pick, _ := parseVoteChoice(judgeOutput, valid)
+	return pick - 1
+}
+
+// handleJudgeEnsemble lets several judges vote independently and reports the
+// majority pick along with how strongly the judges agree. It returns the
+// index of the majority pick, or -1.
+func handleJudgeEnsemble(results []SquadResult, judges []string, prompt string, valid map[int]bool, env []string, timeout int) int {
+	fmt.Printf("\n  %s Sending to %d judges (%s)...\n", ui.Info.Sprint("⚖️"), len(judges), ui.Brand.Sprint(strings.Join(judges, ", ")))
+
+	outputs := runJudges(judges, prompt, env, timeout)
+	var ballots []judgeBallot
+	var rows [][]string
+	for i, out := range outputs {
+		pick, reason := parseVoteChoice(out, valid)
+		ballots = append(ballots, judgeBallot{Judge: judges[i], Pick: pick, Reason: reason})
+
+		choice := ui.Subtle.Sprint("no usable answer")
+		if pick > 0 {
+			choice = fmt.Sprintf("Candidate %d (%s)", pick, results[pick-1].Tool)
+		}
+		rows = append(rows, []string{judges[i], choice, reason})
+	}
+	fmt.Println()
+	ui.Table([]string{"Judge", "Pick", "Reason"}, rows)
+
+	winner, votes, tie := tallyVotes(ballots)
+	fmt.Println()
+	switch {
+	case winner == 0:
+		ui.Bad.Println("  No judge produced a usable vote")
+		return -1
+	case tie:
+		ui.Warn.Printf("  %s No majority — the lead is tied at %d vote(s) each\n", ui.WarnIcon(), votes)
+		return -1
+	}
+
+	fmt.Printf("  %s Winner: %s — %d/%d judges agree (%.0f%% agreement)\n",
+		ui.Brand.Sprint("🏆"), ui.Brand.Sprint(results[winner-1].Tool), votes, len(judges), float64(votes)/float64(len(judges))*100)
+	fmt.Println("  " + strings.Repeat("─", 60))
+	printTruncatedOutput(results[winner-1].Output, 3000)
+	return winner - 1
 }
 
-func handleMergeMode(results []SquadResult, judge, task string, env []string, timeout int) {
+func handleMergeMode(results []SquadResult, judges []string, task string, env []string, timeout int) {
+	// Synthesis produces one answer, so only the first judge merges
+	judge := judges[0]
 	fmt.Printf("  %s %s mode — judge synthesizes all results\n\n", ui.Info.Sprint("🔀"), ui.Brand.Sprint("Merge"))
 
 	printSquadSummary(results)
@@ -349,12 +614,8 @@ func handleMergeMode(results []SquadResult, judge, task string, env []string, ti
 	// Collect successful outputs
 	var contributions []string
 	for i, r := range results {
-		if r.Error == "" && r.Output != "" {
-			output := r.Output
-			if len(output) > 1000 {
-				output = output[:1000] + "..."
-			}
-			contributions = append(contributions, fmt.Sprintf("=== From %s (tool %d, %.1fs) ===\n%s", r.Tool, i+1, r.Duration.Seconds(), output))
+		if r.Error == "" && (r.Output != "" || r.Patch != "") {
+			contributions = append(contributions, fmt.Sprintf("=== From %s (tool %d, %.1fs) ===\n%s", r.Tool, i+1, r.Duration.Seconds(), candidateText(r)))
 		}
 	}
 
@@ -388,13 +649,7 @@ Do not mention the tools or that this is a merge — just produce the best answe
 }
 
 func runJudge(judge, prompt string, env []string, timeout int) string {
-	var cmdArgs []string
-	switch judge {
-	case "ollama":
-		cmdArgs = []string{"ollama", "run", "llama3.3", prompt}
-	default:
-		cmdArgs = []string{judge, prompt}
-	}
+	cmdArgs := promptArgs(loadRegistry(), judge, prompt)
 
 	var stdout bytes.Buffer
 	c := exec.Command(cmdArgs[0], cmdArgs[1:]...)
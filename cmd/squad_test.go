@@ -1,7 +1,13 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/msalah0e/palm/internal/registry"
 )
 
 func TestPrintTruncatedOutput_Short(t *testing.T) {
@@ -81,7 +87,7 @@ func TestHandleVoteMode_InsufficientCandidates(t *testing.T) {
 	}
 
 	// Should warn about needing 2+ results, not panic
-	handleVoteMode(results, "fake-judge", "task", nil, 1)
+	handleVoteMode(results, []string{"fake-judge"}, "task", nil, 1)
 }
 
 func TestHandleMergeMode_NoCandidates(t *testing.T) {
@@ -90,5 +96,283 @@ func TestHandleMergeMode_NoCandidates(t *testing.T) {
 	}
 
 	// Should warn about no results, not panic
-	handleMergeMode(results, "fake-judge", "task", nil, 1)
+	handleMergeMode(results, []string{"fake-judge"}, "task", nil, 1)
+}
+
+// memVault is an in-memory vault for tests.
+type memVault map[string]string
+
+func (m memVault) Set(k, v string) error { m[k] = v; return nil }
+func (m memVault) Delete(k string) error { delete(m, k); return nil }
+func (m memVault) Get(k string) (string, error) {
+	if v, ok := m[k]; ok {
+		return v, nil
+	}
+	return "", fmt.Errorf("not found: %s", k)
+}
+func (m memVault) List() ([]string, error) {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+func TestBuildVaultEnvWorkspaceScoping(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("PALM_TEST_ANTHROPIC_KEY", "")
+	t.Setenv("PALM_TEST_OPENAI_KEY", "")
+	t.Setenv("PALM_TEST_MODE", "")
+	dir := t.TempDir()
+	toml := `[workspace]
+name = "demo"
+keys = ["PALM_TEST_ANTHROPIC_KEY"]
+
+[env]
+PALM_TEST_MODE = "review-${PALM_TEST_BASE}"
+`
+	if err := os.WriteFile(filepath.Join(dir, ".palm.toml"), []byte(toml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PALM_TEST_BASE", "strict")
+	wd, _ := os.Getwd()
+	_ = os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	v := memVault{"PALM_TEST_ANTHROPIC_KEY": "a-secret", "PALM_TEST_OPENAI_KEY": "o-secret"}
+	env := strings.Join(buildVaultEnv(v, "test", nil, nil, true), "\n")
+	if !strings.Contains(env, "PALM_TEST_ANTHROPIC_KEY=a-secret") {
+		t.Error("listed key should be injected")
+	}
+	if strings.Contains(env, "o-secret") {
+		t.Error("unlisted key should be withheld in the workspace")
+	}
+	if !strings.Contains(env, "PALM_TEST_MODE=review-strict") {
+		t.Errorf("workspace env should be applied and expanded")
+	}
+}
+
+func TestBuildVaultEnvToolKeysOnly(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("PALM_TEST_ANTHROPIC_KEY", "")
+	t.Setenv("PALM_TEST_OPENAI_KEY", "")
+	t.Setenv("PALM_TEST_GROQ_KEY", "")
+	wd, _ := os.Getwd()
+	_ = os.Chdir(t.TempDir())
+	defer os.Chdir(wd)
+
+	tool := registry.Tool{Name: "demo"}
+	tool.Keys.Required = []string{"PALM_TEST_ANTHROPIC_KEY"}
+	tool.Keys.Optional = []string{"PALM_TEST_GROQ_KEY"}
+	reg := registry.New([]registry.Tool{tool})
+	v := memVault{"PALM_TEST_ANTHROPIC_KEY": "a-secret", "PALM_TEST_OPENAI_KEY": "o-secret"}
+
+	env := strings.Join(buildVaultEnv(v, "test", reg, []string{"demo", "unknown"}, false), "\n")
+	if !strings.Contains(env, "PALM_TEST_ANTHROPIC_KEY=a-secret") {
+		t.Error("required key should be injected")
+	}
+	if strings.Contains(env, "o-secret") {
+		t.Error("key no tool needs should be withheld")
+	}
+
+	env = strings.Join(buildVaultEnv(v, "test", reg, []string{"demo"}, true), "\n")
+	if !strings.Contains(env, "PALM_TEST_OPENAI_KEY=o-secret") {
+		t.Error("--all-keys should inject every vault key")
+	}
+}
+
+func TestWorkspaceShellEnvWins(t *testing.T) {
+	t.Setenv("PALM_TEST_MODE", "from-shell")
+	proj := &palmProject{Env: map[string]string{"PALM_TEST_MODE": "from-toml"}}
+	env := proj.applyEnv(nil)
+	if len(env) != 0 {
+		t.Errorf("shell value should win, got %v", env)
+	}
+
+	var none *palmProject
+	if !none.allowsKey("ANY_KEY") {
+		t.Error("outside a workspace every key is allowed")
+	}
+}
+
+func TestEstimateSquadCost(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	reg := registry.New([]registry.Tool{
+		{Name: "claude-code", Keys: registry.Keys{Required: []string{"ANTHROPIC_API_KEY"}},
+			Invoke: registry.Invoke{Model: "claude-sonnet-4-5-20250929"}},
+		{Name: "aider", Keys: registry.Keys{Required: []string{"OPENAI_API_KEY"}}},
+		{Name: "ollama", Invoke: registry.Invoke{Model: "llama3.3"}},
+		{Name: "mystery"},
+	})
+	task := strings.Repeat("word ", 200)
+
+	claude := estimateSquadCost(reg, "claude-code", task)
+	if !claude.Known() || claude.Model.ID != "claude-sonnet-4-5-20250929" {
+		t.Fatalf("claude-code model = %+v", claude.Model)
+	}
+	want := (float64(claude.InputTokens)*3 + squadAnswerTokens*15) / 1e6
+	if diff := claude.Estimated - want; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("estimate = %f, want %f", claude.Estimated, want)
+	}
+	claude.settle("short answer")
+	if claude.Actual <= 0 || claude.Actual >= claude.Estimated {
+		t.Errorf("actual %f should be positive and below the estimate %f for a short answer", claude.Actual, claude.Estimated)
+	}
+
+	// The required key picks the provider when the registry names no model
+	if aider := estimateSquadCost(reg, "aider", task); !aider.Known() || aider.Model.Provider != "openai" {
+		t.Errorf("aider model = %+v", aider.Model)
+	}
+	if local := estimateSquadCost(reg, "ollama", task); !local.Local() || local.Estimated != 0 {
+		t.Errorf("ollama should be free, got %+v", local)
+	}
+	if unknown := estimateSquadCost(reg, "mystery", task); unknown.Known() || formatSquadCost(unknown, 0) != "?" {
+		t.Errorf("mystery tool should have an unknown cost, got %+v", unknown)
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	got := wrapText("\x1b[1mhello\x1b[0m world foo\n\tindented\nabcdefghij", 11)
+	want := []string{"hello world", "foo", "    indente", "d", "abcdefghij"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("wrapText = %q, want %q", got, want)
+	}
+}
+
+func TestDecodeKeys(t *testing.T) {
+	got := decodeKeys([]byte("\x1b[Aj\x1b[6~\x1b[Z\r\x7fé\x1b"))
+	want := []string{"up", "j", "pgdn", "shift-tab", "enter", "backspace", "é", "esc"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("decodeKeys = %q, want %q", got, want)
+	}
+}
+
+func TestSquadPicker(t *testing.T) {
+	var long []string
+	for i := 1; i <= 50; i++ {
+		long = append(long, fmt.Sprintf("line %d", i))
+	}
+	p := newSquadPicker([]SquadResult{
+		{Tool: "aider", Output: strings.Join(long, "\n")},
+		{Tool: "codex", Output: "short answer"},
+		{Tool: "goose", Error: "timeout"},
+	})
+
+	// 70 columns fit two 30-column panes; the third scrolls into view
+	frame := p.render(70, 15)
+	if len(frame) != 15 || !strings.Contains(frame[0], "panes 1–2 of 3") {
+		t.Fatalf("frame = %q", frame)
+	}
+	if !strings.Contains(frame[3], "line 1 ") || !strings.Contains(frame[3], "short answer") {
+		t.Errorf("first body row = %q", frame[3])
+	}
+
+	p.handleKey("pgdn")
+	if p.scroll[0] != 9 {
+		t.Errorf("page down scrolled to %d, want 9", p.scroll[0])
+	}
+	p.handleKey("G")
+	if p.scroll[0] != 40 {
+		t.Errorf("end scrolled to %d, want 40", p.scroll[0])
+	}
+
+	p.handleKey("right")
+	p.handleKey("right")
+	frame = p.render(70, 15)
+	if p.first != 1 || !strings.Contains(frame[1], "goose ✗ timeout") || !strings.Contains(frame[3], "(timeout)") {
+		t.Errorf("after moving right: first=%d, frame %q", p.first, frame[:4])
+	}
+
+	p.handleKey("w")
+	if p.winner != -1 {
+		t.Error("a failed tool shouldn't be marked the winner")
+	}
+	p.handleKey("2")
+	p.handleKey("w")
+	if p.winner != 1 || !strings.Contains(p.render(70, 15)[0], "winner: codex") {
+		t.Errorf("winner = %d", p.winner)
+	}
+
+	// Save writes the winner, wherever the cursor is
+	p.handleKey("1")
+	p.handleKey("s")
+	if p.prompt == nil || *p.prompt != "squad-codex.txt" {
+		t.Fatalf("save prompt = %v", p.prompt)
+	}
+	path := filepath.Join(t.TempDir(), "pick.txt")
+	*p.prompt = ""
+	for _, r := range path {
+		p.handleKey(string(r))
+	}
+	p.handleKey("x")
+	p.handleKey("backspace")
+	p.handleKey("enter")
+	if data, err := os.ReadFile(path); err != nil || string(data) != "short answer" || p.saved != path {
+		t.Errorf("saved %q to %q: %v", data, p.saved, err)
+	}
+
+	if !p.handleKey("q") {
+		t.Error("q should quit")
+	}
+}
+
+func TestReadSquadTask(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "prompt.md")
+	os.WriteFile(path, []byte("Review this design.\n\nFocus on failure modes.\n"), 0o644)
+
+	if task, err := readSquadTask([]string{"fix it"}, "", nil); err != nil || task != "fix it" {
+		t.Errorf("argument task = %q, %v", task, err)
+	}
+	if task, err := readSquadTask(nil, path, nil); err != nil || task != "Review this design.\n\nFocus on failure modes." {
+		t.Errorf("file task = %q, %v", task, err)
+	}
+	if task, err := readSquadTask([]string{"-"}, "", strings.NewReader("from a pipe\n")); err != nil || task != "from a pipe" {
+		t.Errorf("stdin task = %q, %v", task, err)
+	}
+	if _, err := readSquadTask([]string{"fix it"}, path, nil); err == nil {
+		t.Error("an argument and --task-file together should be rejected")
+	}
+	if _, err := readSquadTask([]string{"-"}, "", strings.NewReader("  \n")); err == nil {
+		t.Error("an empty task should be rejected")
+	}
+	if _, err := readSquadTask(nil, "", nil); err == nil {
+		t.Error("a missing task should be rejected")
+	}
+}
+
+func TestSquadAttachments(t *testing.T) {
+	dir := t.TempDir()
+	code := filepath.Join(dir, "main.go")
+	os.WriteFile(code, []byte("package main\n"), 0o644)
+	binary := filepath.Join(dir, "app.bin")
+	os.WriteFile(binary, []byte{0x7f, 'E', 'L', 'F', 0}, 0o644)
+
+	files, err := loadSquadAttachments(code + ", ")
+	if err != nil || len(files) != 1 {
+		t.Fatalf("attachments = %+v, %v", files, err)
+	}
+	if _, err := loadSquadAttachments(binary); err == nil {
+		t.Error("a binary file should not be attached")
+	}
+	if _, err := loadSquadAttachments(filepath.Join(dir, "missing.go")); err == nil {
+		t.Error("a missing file should be an error")
+	}
+
+	reg := registry.New([]registry.Tool{
+		{Name: "aider", Invoke: registry.Invoke{Prompt: "aider --message {prompt}", Attach: "--read {file}"}},
+		{Name: "mods", Invoke: registry.Invoke{Prompt: "mods {prompt}"}},
+	})
+	args, prompt := squadToolArgs(reg, "aider", "review", files)
+	if strings.Join(args, "|") != "aider|--message|review|--read|"+code || prompt != "review" {
+		t.Errorf("aider should get the file by path: %q", args)
+	}
+	args, prompt = squadToolArgs(reg, "mods", "review", files)
+	want := "review\n\nAttached files:\n\n--- " + code + " ---\npackage main\n--- end of " + code + " ---\n"
+	if len(args) != 2 || args[1] != want || prompt != want {
+		t.Errorf("mods should get the file inlined: %q", args)
+	}
+	if args, _ := squadToolArgs(reg, "mods", "review", nil); args[1] != "review" {
+		t.Errorf("without attachments the task passes unchanged: %q", args)
+	}
 }
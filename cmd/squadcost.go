@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/msalah0e/palm/internal/models"
+	"github.com/msalah0e/palm/internal/registry"
+	"github.com/msalah0e/palm/internal/tokens"
+	"github.com/msalah0e/palm/internal/ui"
+)
+
+// squadAnswerTokens is the answer length squad cost estimates assume.
+const squadAnswerTokens = 1000
+
+// squadCost is what sending a squad task to one tool costs.
+type squadCost struct {
+	Tool  string
+	Model *models.Model // nil when palm can't tell which model the tool uses
+
+	InputTokens int
+	Estimated   float64 // before the run, assuming a squadAnswerTokens answer
+
+	OutputTokens int
+	Actual       float64 // after the run, from the measured answer
+}
+
+// Known reports whether the tool's model, and so its price, is known.
+func (c squadCost) Known() bool { return c.Model != nil }
+
+// Local reports whether the tool runs a free local model.
+func (c squadCost) Local() bool { return c.Model != nil && c.Model.Provider == "ollama" }
+
+// toolModel returns the model a registry tool uses by default: its invoke
+// model, else the flagship chat model of the provider its required key is
+// for. Nil means unknown.
+func toolModel(reg *registry.Registry, name string) *models.Model {
+	tool := reg.Get(name)
+	if tool == nil {
+		return nil
+	}
+	if tool.Invoke.Model != "" {
+		if m := models.FindModel(tool.Invoke.Model); m != nil {
+			return m
+		}
+	}
+	for _, key := range tool.Keys.Required {
+		if p := models.ProviderForKey(key); p != nil {
+			for _, m := range p.Models {
+				if m.Type == "chat" {
+					return &m
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// estimateSquadCost prices sending task to the named tool.
+func estimateSquadCost(reg *registry.Registry, name, task string) squadCost {
+	c := squadCost{Tool: name, Model: toolModel(reg, name)}
+	c.InputTokens = squadTokenizer(c.Model).Count(task)
+	c.Estimated = c.price(c.InputTokens, squadAnswerTokens)
+	return c
+}
+
+// settle records the actual cost once the tool has answered.
+func (c *squadCost) settle(output string) {
+	c.OutputTokens = squadTokenizer(c.Model).Count(output)
+	c.Actual = c.price(c.InputTokens, c.OutputTokens)
+}
+
+func (c squadCost) price(in, out int) float64 {
+	if c.Model == nil {
+		return 0
+	}
+	return (float64(in)*c.Model.InputCost + float64(out)*c.Model.OutputCost) / 1e6
+}
+
+func squadTokenizer(m *models.Model) tokens.Tokenizer {
+	if m == nil {
+		return tokens.DefaultTokenizer()
+	}
+	enc, _ := tokens.EncodingForModel(m.Provider, m.ID)
+	return tokens.LoadTokenizer(enc)
+}
+
+// formatSquadCost renders a cost for the squad tables.
+func formatSquadCost(c squadCost, usd float64) string {
+	switch {
+	case !c.Known():
+		return "?"
+	case c.Local():
+		return "free"
+	}
+	return fmt.Sprintf("$%.4f", usd)
+}
+
+// printSquadCosts compares estimated and actual cost per tool.
+func printSquadCosts(costs []squadCost, results []SquadResult) {
+	var rows [][]string
+	var estimated, actual float64
+	unknown := false
+	for i, c := range costs {
+		model := "unknown"
+		if c.Known() {
+			model = c.Model.ID
+		} else {
+			unknown = true
+		}
+		act := formatSquadCost(c, c.Actual)
+		if results[i].Error == "not installed" {
+			act = "-"
+		}
+		rows = append(rows, []string{results[i].Tool, model, formatSquadCost(c, c.Estimated), act})
+		estimated += c.Estimated
+		actual += c.Actual
+	}
+
+	fmt.Printf("\n  %s\n\n", ui.Brand.Sprint("Cost"))
+	ui.Table([]string{"Tool", "Model", "Estimated", "Actual"}, rows)
+	fmt.Printf("\n  Estimated $%.4f · actual $%.4f\n", estimated, actual)
+	note := fmt.Sprintf("Estimates assume a %d-token answer; actual counts the answer each tool returned", squadAnswerTokens)
+	if unknown {
+		note += "; ? means palm doesn't know the tool's model"
+	}
+	fmt.Printf("  %s\n", ui.Subtle.Sprint(note))
+}
@@ -0,0 +1,261 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/msalah0e/palm/internal/ui"
+)
+
+// squadSandbox is the throwaway worktree one tool runs in under --isolate.
+type squadSandbox struct {
+	root string // worktree root
+	dir  string // where the tool runs: the caller's directory within root
+}
+
+// squadIsolation holds one sandbox per tool, all starting from the same
+// snapshot of the repository.
+type squadIsolation struct {
+	repo  string // top level of the caller's repository
+	base  string // tree object of the snapshot
+	tmp   string
+	boxes []squadSandbox
+}
+
+// gitIn runs git in dir and returns its trimmed output; the error carries
+// git's own message.
+func gitIn(dir string, env []string, args ...string) (string, error) {
+	out, err := gitRawIn(dir, env, args...)
+	return strings.TrimSpace(out), err
+}
+
+// gitRawIn is gitIn without trimming, for output such as patches where
+// leading and trailing whitespace matters.
+func gitRawIn(dir string, env []string, args ...string) (string, error) {
+	c := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	if env != nil {
+		c.Env = append(os.Environ(), env...)
+	}
+	out, err := c.Output()
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok && len(ee.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(ee.Stderr)))
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return string(out), nil
+}
+
+// snapshotTree writes the working tree of repo, uncommitted and untracked
+// files included, as a tree object. It uses a scratch index, so the
+// caller's staging area is left alone.
+func snapshotTree(repo string) (string, error) {
+	scratch, err := os.CreateTemp("", "palm-squad-index-*")
+	if err != nil {
+		return "", err
+	}
+	scratch.Close()
+	defer os.Remove(scratch.Name())
+
+	// Start from the real index so unchanged files aren't hashed again
+	if indexPath, err := gitIn(repo, nil, "rev-parse", "--path-format=absolute", "--git-path", "index"); err == nil {
+		if data, err := os.ReadFile(indexPath); err == nil {
+			_ = os.WriteFile(scratch.Name(), data, 0o600)
+		}
+	}
+	env := []string{"GIT_INDEX_FILE=" + scratch.Name()}
+	if _, err := gitIn(repo, env, "add", "-A"); err != nil {
+		return "", err
+	}
+	return gitIn(repo, env, "write-tree")
+}
+
+// isolateSquad gives each tool a detached worktree holding the current
+// state of the caller's repository.
+func isolateSquad(toolNames []string) (*squadIsolation, error) {
+	repo, err := gitIn(".", nil, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("--isolate needs a git repository")
+	}
+	if _, err := gitIn(repo, nil, "rev-parse", "--verify", "-q", "HEAD"); err != nil {
+		return nil, fmt.Errorf("--isolate needs a repository with at least one commit")
+	}
+	prefix, _ := gitIn(".", nil, "rev-parse", "--show-prefix")
+
+	base, err := snapshotTree(repo)
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := os.MkdirTemp("", "palm-squad-")
+	if err != nil {
+		return nil, err
+	}
+
+	iso := &squadIsolation{repo: repo, base: base, tmp: tmp}
+	for i, name := range toolNames {
+		root := filepath.Join(tmp, fmt.Sprintf("%d-%s", i+1, filepath.Base(name)))
+		if _, err := gitIn(repo, nil, "worktree", "add", "-q", "--detach", root, "HEAD"); err != nil {
+			iso.cleanup()
+			return nil, err
+		}
+		iso.boxes = append(iso.boxes, squadSandbox{root: root, dir: filepath.Join(root, prefix)})
+		if _, err := gitIn(root, nil, "read-tree", "-u", "--reset", base); err != nil {
+			iso.cleanup()
+			return nil, err
+		}
+		// git doesn't track empty directories, and the caller may be in one
+		if err := os.MkdirAll(filepath.Join(root, prefix), 0o755); err != nil {
+			iso.cleanup()
+			return nil, err
+		}
+	}
+	return iso, nil
+}
+
+// dirs returns the directory each tool runs in.
+func (iso *squadIsolation) dirs() []string {
+	var dirs []string
+	for _, b := range iso.boxes {
+		dirs = append(dirs, b.dir)
+	}
+	return dirs
+}
+
+// collect stores the changes each tool made, relative to the snapshot, as a
+// patch on its result.
+func (iso *squadIsolation) collect(results []SquadResult) {
+	for i, b := range iso.boxes {
+		if _, err := gitIn(b.root, nil, "add", "-A"); err != nil {
+			continue
+		}
+		tree, err := gitIn(b.root, nil, "write-tree")
+		if err != nil || tree == iso.base {
+			continue
+		}
+		patch, err := gitRawIn(b.root, nil, "diff", "--binary", iso.base, tree)
+		if err == nil && patch != "" {
+			results[i].Patch = patch
+			results[i].Changes, _ = gitIn(b.root, nil, "diff", "--shortstat", iso.base, tree)
+		}
+	}
+}
+
+// cleanup removes the worktrees and their parent directory.
+func (iso *squadIsolation) cleanup() {
+	for _, b := range iso.boxes {
+		_, _ = gitIn(iso.repo, nil, "worktree", "remove", "--force", b.root)
+	}
+	os.RemoveAll(iso.tmp)
+	_, _ = gitIn(iso.repo, nil, "worktree", "prune")
+}
+
+// squadPatchDir returns the directory a run's patches are saved in.
+func squadPatchDir(at time.Time) string {
+	return filepath.Join(palmConfigDir(), "squad-patches", at.Format("20060102-150405"))
+}
+
+// handleSquadPatches saves each tool's patch, lists them, and applies the
+// one chosen: the winner with --apply, or the user's pick when asked.
+// winner is the index of the winning result, or -1.
+func handleSquadPatches(iso *squadIsolation, results []SquadResult, winner int, apply bool) {
+	dir := squadPatchDir(time.Now())
+	paths := make([]string, len(results))
+	var rows [][]string
+	for i, r := range results {
+		if r.Patch == "" {
+			continue
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			ui.Bad.Printf("  Failed to save patches: %v\n", err)
+			return
+		}
+		paths[i] = filepath.Join(dir, fmt.Sprintf("%d-%s.patch", i+1, strings.ReplaceAll(strings.ToLower(filepath.Base(r.Tool)), " ", "-")))
+		if err := os.WriteFile(paths[i], []byte(r.Patch), 0o644); err != nil {
+			ui.Bad.Printf("  Failed to save patch: %v\n", err)
+			return
+		}
+		pick := ""
+		if i == winner {
+			pick = "🏆"
+		}
+		rows = append(rows, []string{strconv.Itoa(i + 1), r.Tool, r.Changes, pick})
+	}
+
+	fmt.Println()
+	fmt.Printf("  %s %s — each tool worked in its own copy of the repository\n\n", ui.Info.Sprint("🧪"), ui.Brand.Sprint("Candidate patches"))
+	if len(rows) == 0 {
+		ui.Subtle.Println("  No tool changed any files")
+		return
+	}
+	ui.Table([]string{"#", "Tool", "Changes", "Pick"}, rows)
+	fmt.Printf("  Saved to %s\n", ui.Subtle.Sprint(dir))
+
+	choice := -1
+	switch {
+	case apply && winner >= 0 && paths[winner] != "":
+		choice = winner
+	case apply:
+		ui.Warn.Printf("  %s No winning patch to apply — pick one with git apply\n", ui.WarnIcon())
+		return
+	default:
+		choice = askSquadPatch(paths, winner)
+	}
+	if choice < 0 {
+		ui.Subtle.Printf("  Apply one with: git apply %s\n", filepath.Join(dir, "<patch>"))
+		return
+	}
+
+	if _, err := gitIn(iso.repo, nil, "apply", "--binary", paths[choice]); err != nil {
+		// Returning, not exiting, so the worktrees are still cleaned up
+		ui.Bad.Printf("  Failed to apply %s's patch: %v\n", results[choice].Tool, err)
+		ui.Subtle.Printf("  It is saved at %s\n", paths[choice])
+		return
+	}
+	ui.Good.Printf("  %s Applied %s's patch (%s)\n", ui.StatusIcon(true), results[choice].Tool, results[choice].Changes)
+}
+
+// askSquadPatch asks which patch to apply and returns its index, or -1 to
+// apply none. Non-interactive input applies none.
+func askSquadPatch(paths []string, winner int) int {
+	if fi, err := os.Stdin.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return -1
+	}
+	hint := "Enter to skip"
+	if winner >= 0 && paths[winner] != "" {
+		hint = fmt.Sprintf("winner is %d, Enter to skip", winner+1)
+	}
+	fmt.Printf("\n  Apply a patch? [#, %s] ", hint)
+	scanner := bufio.NewScanner(os.Stdin)
+	if !scanner.Scan() {
+		fmt.Println()
+		return -1
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+	if err != nil || n < 1 || n > len(paths) || paths[n-1] == "" {
+		return -1
+	}
+	return n - 1
+}
+
+// candidateText is what judges see of a result: its output and, under
+// --isolate, the changes it made.
+func candidateText(r SquadResult) string {
+	output := r.Output
+	if len(output) > 1000 {
+		output = output[:1000] + "..."
+	}
+	if r.Patch == "" {
+		return output
+	}
+	patch := r.Patch
+	if len(patch) > 3000 {
+		patch = patch[:3000] + "..."
+	}
+	return output + "\n--- changes ---\n" + patch
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSquadIsolation(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		if out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(dir, name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run("init", "-q")
+	run("config", "user.email", "palm@example.com")
+	run("config", "user.name", "palm")
+	write(repo, "main.go", "package main\n")
+	run("add", ".")
+	run("commit", "-qm", "init")
+
+	// Uncommitted and untracked files are part of the snapshot
+	write(repo, "main.go", "package main\n\nfunc main() {}\n")
+	write(repo, "notes.txt", "draft\n")
+	write(repo, "trailing.txt", "a\nb\nc\n \n") // ends in a blank context line
+	t.Chdir(repo)
+
+	iso, err := isolateSquad([]string{"one", "two"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iso.cleanup()
+
+	dirs := iso.dirs()
+	data, err := os.ReadFile(filepath.Join(dirs[0], "notes.txt"))
+	if err != nil || string(data) != "draft\n" {
+		t.Fatalf("sandbox missing untracked file: %q, %v", data, err)
+	}
+	write(dirs[0], "main.go", "package main\n\nfunc main() { println(1) }\n")
+	write(dirs[0], "trailing.txt", "A\nb\nc\n \n")
+
+	results := make([]SquadResult, 2)
+	iso.collect(results)
+	if !strings.Contains(results[0].Patch, "println(1)") || results[0].Changes == "" {
+		t.Errorf("tool one's patch = %q", results[0].Patch)
+	}
+	patch := filepath.Join(t.TempDir(), "one.patch")
+	write(filepath.Dir(patch), "one.patch", results[0].Patch)
+	if out, err := exec.Command("git", "-C", repo, "apply", "--check", patch).CombinedOutput(); err != nil {
+		t.Errorf("tool one's patch doesn't apply: %v\n%s", err, out)
+	}
+	if results[1].Patch != "" {
+		t.Errorf("tool two changed nothing, got patch %q", results[1].Patch)
+	}
+
+	// The caller's tree and index are untouched
+	data, _ = os.ReadFile(filepath.Join(repo, "main.go"))
+	if strings.Contains(string(data), "println") {
+		t.Error("sandbox edit leaked into the repository")
+	}
+	if out, _ := exec.Command("git", "-C", repo, "diff", "--cached", "--name-only").Output(); len(out) != 0 {
+		t.Errorf("index changed: %s", out)
+	}
+
+	iso.cleanup()
+	if _, err := os.Stat(iso.tmp); !os.IsNotExist(err) {
+		t.Error("sandboxes left behind")
+	}
+}
+
+func TestCandidateText(t *testing.T) {
+	r := SquadResult{Output: "done"}
+	if got := candidateText(r); got != "done" {
+		t.Errorf("got %q", got)
+	}
+	r.Patch = "diff --git a/x b/x\n"
+	if got := candidateText(r); !strings.Contains(got, "--- changes ---\ndiff --git") {
+		t.Errorf("patch missing: %q", got)
+	}
+	r.Output = strings.Repeat("x", 2000)
+	if got := candidateText(r); !strings.HasPrefix(got, strings.Repeat("x", 1000)+"...") {
+		t.Error("long output not truncated")
+	}
+}
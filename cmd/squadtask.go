@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/msalah0e/palm/internal/registry"
+)
+
+// maxSquadAttachment is the largest file --attach accepts.
+const maxSquadAttachment = 256 << 10
+
+// squadAttachment is a file attached to a squad task.
+type squadAttachment struct {
+	Path    string
+	Content string
+}
+
+// readSquadTask returns the task from the argument, or from --task-file.
+// Either one set to "-" reads the task from stdin.
+func readSquadTask(args []string, taskFile string, stdin io.Reader) (string, error) {
+	var source string
+	switch {
+	case taskFile != "" && len(args) > 0:
+		return "", fmt.Errorf("give the task as an argument or with --task-file, not both")
+	case taskFile != "":
+		source = taskFile
+	case len(args) > 0:
+		if args[0] != "-" {
+			return args[0], nil
+		}
+		source = "-"
+	default:
+		return "", fmt.Errorf("give the task as an argument, with --task-file, or - for stdin")
+	}
+
+	var data []byte
+	var err error
+	if source == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(source)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read the task: %w", err)
+	}
+	task := strings.TrimSpace(string(data))
+	if task == "" {
+		return "", fmt.Errorf("the task is empty")
+	}
+	return task, nil
+}
+
+// loadSquadAttachments reads the files in a comma-separated --attach list.
+// Binary and oversized files are rejected, since they can't be inlined.
+func loadSquadAttachments(list string) ([]squadAttachment, error) {
+	var files []squadAttachment
+	for _, path := range strings.Split(list, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > maxSquadAttachment {
+			return nil, fmt.Errorf("%s is over the %d KB attachment limit", path, maxSquadAttachment>>10)
+		}
+		if bytes.IndexByte(data, 0) >= 0 {
+			return nil, fmt.Errorf("%s looks like a binary file", path)
+		}
+		files = append(files, squadAttachment{Path: path, Content: string(data)})
+	}
+	return files, nil
+}
+
+// inlineAttachments appends each file's contents to the task, fenced and
+// labelled with its path.
+func inlineAttachments(task string, files []squadAttachment) string {
+	if len(files) == 0 {
+		return task
+	}
+	var b strings.Builder
+	b.WriteString(task)
+	b.WriteString("\n\nAttached files:\n")
+	for _, f := range files {
+		fmt.Fprintf(&b, "\n--- %s ---\n%s", f.Path, f.Content)
+		if !strings.HasSuffix(f.Content, "\n") {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "--- end of %s ---\n", f.Path)
+	}
+	return b.String()
+}
+
+// squadToolArgs returns the command that sends the task and its attachments
+// to the named tool, and the prompt it is given. Tools with an attach
+// template get the files by path; the rest get them inlined.
+func squadToolArgs(reg *registry.Registry, name, task string, files []squadAttachment) ([]string, string) {
+	if len(files) > 0 && reg != nil {
+		if tool := reg.Get(name); tool != nil && tool.Invoke.Attach != "" {
+			var paths []string
+			for _, f := range files {
+				paths = append(paths, f.Path)
+			}
+			return append(tool.PromptArgs(task), tool.AttachArgs(paths)...), task
+		}
+	}
+	prompt := inlineAttachments(task, files)
+	return promptArgs(reg, name, prompt), prompt
+}
@@ -0,0 +1,412 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/msalah0e/palm/internal/ui"
+)
+
+// squadPaneMin is the narrowest a pane gets before panes scroll sideways.
+const squadPaneMin = 30
+
+// squadPicker is the state of the side-by-side comparison view of
+// 'palm squad --mode all'.
+type squadPicker struct {
+	results []SquadResult
+	focus   int   // pane with the cursor
+	first   int   // leftmost visible pane
+	scroll  []int // first visible line per pane
+	winner  int   // -1 until one is marked
+	saved   string
+	status  string  // one-off footer message
+	prompt  *string // file name being typed for save, nil otherwise
+
+	wrapWidth int
+	lines     [][]string // outputs wrapped to wrapWidth
+	page      int        // body rows in the last frame
+}
+
+func newSquadPicker(results []SquadResult) *squadPicker {
+	return &squadPicker{results: results, scroll: make([]int, len(results)), winner: -1}
+}
+
+// squadTUIAvailable reports whether the comparison view can run: both ends
+// are terminals and stty can switch to raw mode.
+func squadTUIAvailable() bool {
+	if runtime.GOOS == "windows" {
+		return false
+	}
+	for _, f := range []*os.File{os.Stdin, os.Stdout} {
+		if fi, err := f.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+			return false
+		}
+	}
+	_, err := exec.LookPath("stty")
+	return err == nil
+}
+
+// runSquadPicker shows the results side by side until the user quits and
+// returns the picker for its winner and saved file.
+func runSquadPicker(results []SquadResult) (*squadPicker, error) {
+	state, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		return nil, err
+	}
+	defer stty(strings.TrimSpace(state))
+
+	fmt.Print("\033[?1049h\033[?25l")
+	defer fmt.Print("\033[?25h\033[?1049l")
+
+	p := newSquadPicker(results)
+	buf := make([]byte, 64)
+	for {
+		width, height := terminalSize()
+		fmt.Print("\033[H" + strings.Join(p.render(width, height), "\033[K\r\n") + "\033[K\033[J")
+
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return p, nil
+		}
+		for _, key := range decodeKeys(buf[:n]) {
+			if p.handleKey(key) {
+				return p, nil
+			}
+		}
+	}
+}
+
+// handleKey applies one key press and reports whether to quit.
+func (p *squadPicker) handleKey(key string) bool {
+	p.status = ""
+	if p.prompt != nil {
+		switch key {
+		case "enter":
+			path := strings.TrimSpace(*p.prompt)
+			p.prompt = nil
+			if path != "" {
+				p.save(path)
+			}
+		case "esc", "ctrl-c":
+			p.prompt = nil
+		case "backspace":
+			if r := []rune(*p.prompt); len(r) > 0 {
+				*p.prompt = string(r[:len(r)-1])
+			}
+		default:
+			if utf8.RuneCountInString(key) == 1 {
+				*p.prompt += key
+			}
+		}
+		return false
+	}
+
+	last := len(p.results) - 1
+	switch key {
+	case "q", "esc", "ctrl-c":
+		return true
+	case "left", "h", "shift-tab":
+		p.focus = max(p.focus-1, 0)
+	case "right", "l", "tab":
+		p.focus = min(p.focus+1, last)
+	case "up", "k":
+		p.scrollBy(-1)
+	case "down", "j":
+		p.scrollBy(1)
+	case "pgup", "b":
+		p.scrollBy(-max(p.page-1, 1))
+	case "pgdn", " ", "f":
+		p.scrollBy(max(p.page-1, 1))
+	case "home", "g":
+		p.scroll[p.focus] = 0
+	case "end", "G":
+		p.scrollBy(len(p.lines[p.focus]))
+	case "w", "enter":
+		if r := p.results[p.focus]; r.Error != "" {
+			p.status = r.Tool + " failed, so it can't win"
+		} else if p.winner == p.focus {
+			p.winner = -1
+			p.status = "Winner cleared"
+		} else {
+			p.winner = p.focus
+			p.status = "Marked " + p.results[p.focus].Tool + " as the winner"
+		}
+	case "c":
+		r := p.results[p.focus]
+		p.status = fmt.Sprintf("Copied %s's output (%s)", r.Tool, copyToClipboard(r.Output))
+	case "s":
+		name := "squad-" + p.results[p.chosen()].Tool + ".txt"
+		p.prompt = &name
+	default:
+		if n, err := strconv.Atoi(key); err == nil && n >= 1 && n <= len(p.results) {
+			p.focus = n - 1
+		}
+	}
+	return false
+}
+
+// chosen is the pane a save writes: the winner, or the focused pane.
+func (p *squadPicker) chosen() int {
+	if p.winner >= 0 {
+		return p.winner
+	}
+	return p.focus
+}
+
+func (p *squadPicker) save(path string) {
+	r := p.results[p.chosen()]
+	if err := os.WriteFile(path, []byte(r.Output), 0o644); err != nil {
+		p.status = "Save failed: " + err.Error()
+		return
+	}
+	p.saved = path
+	p.status = fmt.Sprintf("Saved %s's output to %s", r.Tool, path)
+}
+
+func (p *squadPicker) scrollBy(n int) {
+	if p.lines == nil {
+		return
+	}
+	maxScroll := max(len(p.lines[p.focus])-p.page, 0)
+	p.scroll[p.focus] = min(max(p.scroll[p.focus]+n, 0), maxScroll)
+}
+
+// render lays the panes out for a width x height terminal.
+func (p *squadPicker) render(width, height int) []string {
+	width, height = max(width, 20), max(height, 6)
+	cols := min(len(p.results), max((width+1)/(squadPaneMin+1), 1))
+	paneWidth := (width - (cols - 1)) / cols
+	if p.focus < p.first {
+		p.first = p.focus
+	}
+	if p.focus >= p.first+cols {
+		p.first = p.focus - cols + 1
+	}
+
+	if p.wrapWidth != paneWidth-2 {
+		p.wrapWidth = paneWidth - 2
+		p.lines = make([][]string, len(p.results))
+		for i, r := range p.results {
+			text := r.Output
+			if r.Error != "" && strings.TrimSpace(text) == "" {
+				text = "(" + r.Error + ")"
+			}
+			p.lines[i] = wrapText(text, p.wrapWidth)
+		}
+	}
+	p.page = height - 5
+	for i := range p.scroll {
+		p.scroll[i] = min(p.scroll[i], max(len(p.lines[i])-p.page, 0))
+	}
+
+	title := "🌴 palm squad — compare outputs"
+	if p.winner >= 0 {
+		title += " · winner: " + p.results[p.winner].Tool
+	}
+	if len(p.results) > cols {
+		title += fmt.Sprintf(" · panes %d–%d of %d", p.first+1, p.first+cols, len(p.results))
+	}
+	out := []string{ui.Brand.Sprint(title)}
+
+	var header, rule []string
+	for i := p.first; i < p.first+cols; i++ {
+		r := p.results[i]
+		label := fmt.Sprintf("%d %s", i+1, r.Tool)
+		if r.Error != "" {
+			label += " ✗ " + r.Error
+		} else {
+			label += fmt.Sprintf(" %.1fs", r.Duration.Seconds())
+		}
+		if i == p.winner {
+			label += " ★"
+		}
+		cell := padRunes(" "+label, paneWidth)
+		switch {
+		case i == p.focus:
+			cell = ui.Brand.Sprint(cell)
+		case r.Error != "":
+			cell = ui.Bad.Sprint(cell)
+		}
+		header = append(header, cell)
+		dash := "─"
+		if i == p.focus {
+			dash = "━"
+		}
+		rule = append(rule, strings.Repeat(dash, paneWidth))
+	}
+	out = append(out, strings.Join(header, ui.Subtle.Sprint("│")), ui.Subtle.Sprint(strings.Join(rule, "┼")))
+
+	for row := 0; row < p.page; row++ {
+		var cells []string
+		for i := p.first; i < p.first+cols; i++ {
+			line := ""
+			if n := p.scroll[i] + row; n < len(p.lines[i]) {
+				line = p.lines[i][n]
+			}
+			cells = append(cells, padRunes(" "+line, paneWidth))
+		}
+		out = append(out, strings.Join(cells, ui.Subtle.Sprint("│")))
+	}
+
+	total := len(p.lines[p.focus])
+	pos := fmt.Sprintf("%s: lines %d–%d of %d", p.results[p.focus].Tool,
+		min(p.scroll[p.focus]+1, total), min(p.scroll[p.focus]+p.page, total), total)
+	out = append(out, ui.Subtle.Sprint(padRunes(pos, width)))
+
+	switch {
+	case p.prompt != nil:
+		out = append(out, "Save "+p.results[p.chosen()].Tool+"'s output to: "+*p.prompt+"█  "+ui.Subtle.Sprint("enter save · esc cancel"))
+	case p.status != "":
+		out = append(out, ui.Info.Sprint(p.status))
+	default:
+		out = append(out, ui.Subtle.Sprint("←/→ pane  ↑/↓ PgUp/PgDn scroll  w winner  c copy  s save  q quit"))
+	}
+	return out
+}
+
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]|\x1b\][^\a]*\a`)
+
+// wrapText strips terminal escapes from text and word-wraps it to width.
+func wrapText(text string, width int) []string {
+	width = max(width, 1)
+	text = ansiEscape.ReplaceAllString(text, "")
+	text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\t", "    ")
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		line = strings.TrimRight(line, " \r")
+		for utf8.RuneCountInString(line) > width {
+			r := []rune(line)
+			head := string(r[:width+1])
+			// Break at the last space past the indentation, else mid-word
+			cut := width
+			indent := len(head) - len(strings.TrimLeft(head, " "))
+			if i := strings.LastIndex(head, " "); i > indent {
+				cut = utf8.RuneCountInString(head[:i])
+			}
+			lines = append(lines, strings.TrimRight(string(r[:cut]), " "))
+			line = strings.TrimLeft(string(r[cut:]), " ")
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// padRunes cuts or pads s with spaces to exactly width terminal columns, so
+// wide characters in tool output don't push the pane borders out of line.
+func padRunes(s string, width int) string {
+	s = ui.Clip(s, width)
+	return s + strings.Repeat(" ", max(0, width-ui.Width(s)))
+}
+
+// decodeKeys names the keys in one read from a raw terminal.
+func decodeKeys(b []byte) []string {
+	sequences := []struct{ seq, key string }{
+		{"\x1b[A", "up"}, {"\x1b[B", "down"}, {"\x1b[C", "right"}, {"\x1b[D", "left"},
+		{"\x1bOA", "up"}, {"\x1bOB", "down"}, {"\x1bOC", "right"}, {"\x1bOD", "left"},
+		{"\x1b[5~", "pgup"}, {"\x1b[6~", "pgdn"}, {"\x1b[H", "home"}, {"\x1b[F", "end"},
+		{"\x1b[1~", "home"}, {"\x1b[4~", "end"}, {"\x1b[Z", "shift-tab"},
+	}
+	var keys []string
+	s := string(b)
+outer:
+	for len(s) > 0 {
+		for _, sq := range sequences {
+			if strings.HasPrefix(s, sq.seq) {
+				keys = append(keys, sq.key)
+				s = s[len(sq.seq):]
+				continue outer
+			}
+		}
+		switch s[0] {
+		case 0x1b:
+			// A lone escape, or a sequence we don't handle
+			if len(s) > 1 && (s[1] == '[' || s[1] == 'O') {
+				return keys
+			}
+			keys = append(keys, "esc")
+		case '\r', '\n':
+			keys = append(keys, "enter")
+		case '\t':
+			keys = append(keys, "tab")
+		case 0x7f, 0x08:
+			keys = append(keys, "backspace")
+		case 0x03:
+			keys = append(keys, "ctrl-c")
+		default:
+			r, size := utf8.DecodeRuneInString(s)
+			if r >= ' ' {
+				keys = append(keys, string(r))
+			}
+			s = s[size:]
+			continue
+		}
+		s = s[1:]
+	}
+	return keys
+}
+
+// copyToClipboard copies text with the platform's clipboard command, or
+// through the terminal (OSC 52) when there is none. It returns how.
+func copyToClipboard(text string) string {
+	var candidates [][]string
+	switch runtime.GOOS {
+	case "darwin":
+		candidates = [][]string{{"pbcopy"}}
+	case "windows":
+		candidates = [][]string{{"clip"}}
+	default:
+		if os.Getenv("WAYLAND_DISPLAY") != "" {
+			candidates = append(candidates, []string{"wl-copy"})
+		}
+		candidates = append(candidates, []string{"xclip", "-selection", "clipboard"}, []string{"xsel", "--clipboard", "--input"})
+	}
+	for _, c := range candidates {
+		if _, err := exec.LookPath(c[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(c[0], c[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err == nil {
+			return c[0]
+		}
+	}
+	fmt.Print("\033]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a")
+	return "via the terminal"
+}
+
+// terminalSize returns the terminal's columns and rows, from stty, then
+// $COLUMNS and $LINES, then 80x24.
+func terminalSize() (int, int) {
+	if out, err := stty("size"); err == nil {
+		var rows, cols int
+		if _, err := fmt.Sscan(out, &rows, &cols); err == nil && rows > 0 && cols > 0 {
+			return cols, rows
+		}
+	}
+	cols, _ := strconv.Atoi(os.Getenv("COLUMNS"))
+	rows, _ := strconv.Atoi(os.Getenv("LINES"))
+	if cols <= 0 {
+		cols = 80
+	}
+	if rows <= 0 {
+		rows = 24
+	}
+	return cols, rows
+}
+
+func stty(args ...string) (string, error) {
+	c := exec.Command("stty", args...)
+	c.Stdin = os.Stdin
+	out, err := c.Output()
+	return string(out), err
+}
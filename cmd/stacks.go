@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/msalah0e/palm/internal/mcp"
+	"github.com/msalah0e/palm/internal/registry"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+func stacksCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "stacks [stack]",
+		Short: "Show curated tool stacks to install in one command",
+		Long: `Show the curated stacks in the registry: the tools, and MCP servers, for
+one way of working. Install one with:
+
+  palm install --stack local-first`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: stackCompletionFunc,
+		Run: func(cmd *cobra.Command, args []string) {
+			stacks := loadStacks()
+			if len(args) > 0 {
+				s := findStack(stacks, args[0])
+				if s == nil {
+					unknownStack(stacks, args[0])
+				}
+				stacks = []registry.Stack{*s}
+			}
+
+			render(stacks, func() {
+				ui.Banner("stacks")
+				if len(stacks) == 0 {
+					fmt.Println("  No stacks available.")
+					return
+				}
+				var all []string
+				for _, s := range stacks {
+					all = append(all, s.Tools...)
+				}
+				detected := detectTools(loadRegistry(), all)
+				for _, s := range stacks {
+					fmt.Printf("  %s %s\n", ui.Brand.Sprint(s.Name), ui.Subtle.Sprint("— "+s.DisplayName))
+					fmt.Printf("    %s\n", s.Description)
+					var tools []string
+					for _, name := range s.Tools {
+						if detected[name].Installed {
+							name = ui.Good.Sprint(name + " ✓")
+						}
+						tools = append(tools, name)
+					}
+					fmt.Printf("    Tools: %s\n", strings.Join(tools, ", "))
+					if len(s.MCP) > 0 {
+						fmt.Printf("    MCP:   %s\n", strings.Join(s.MCP, ", "))
+					}
+					fmt.Println()
+				}
+				fmt.Println("  Install one with: palm install --stack <name>")
+			})
+		},
+	}
+}
+
+func loadStacks() []registry.Stack {
+	stacks, err := registry.LoadStacksFromFS(registryFS, "registry")
+	if err != nil {
+		return nil
+	}
+	return stacks
+}
+
+func findStack(stacks []registry.Stack, name string) *registry.Stack {
+	for i := range stacks {
+		if stacks[i].Name == name {
+			return &stacks[i]
+		}
+	}
+	return nil
+}
+
+func unknownStack(stacks []registry.Stack, name string) {
+	var names []string
+	for _, s := range stacks {
+		names = append(names, s.Name)
+	}
+	ui.Bad.Printf("  Unknown stack %q — available: %s\n", name, strings.Join(names, ", "))
+	os.Exit(1)
+}
+
+// stackInstallList adds the stacks' tools that aren't installed to names,
+// and returns the MCP servers the stacks want.
+func stackInstallList(reg *registry.Registry, names, stackNames []string) (tools, servers []string) {
+	stacks := loadStacks()
+	tools = names
+	for _, sn := range stackNames {
+		s := findStack(stacks, sn)
+		if s == nil {
+			unknownStack(stacks, sn)
+		}
+		detected := detectTools(reg, s.Tools)
+		for _, name := range s.Tools {
+			t := reg.Get(name)
+			switch {
+			case t == nil:
+				ui.Warn.Printf("  %s %s — not in registry\n", ui.WarnIcon(), name)
+			case containsStr(tools, name):
+			case detected[name].Installed:
+				ui.Subtle.Printf("  %s is already installed\n", t.DisplayName)
+			default:
+				tools = append(tools, name)
+			}
+		}
+		for _, server := range s.MCP {
+			servers = appendUnique(servers, server)
+		}
+	}
+	return tools, servers
+}
+
+// installStackServers installs the MCP servers a stack wants.
+func installStackServers(servers []string, dryRun bool) {
+	if len(servers) == 0 {
+		return
+	}
+	fmt.Println()
+	installed := 0
+	for _, name := range servers {
+		s := mcp.GetServer(name)
+		if s == nil {
+			ui.Warn.Printf("  %s MCP server %s — not in the MCP registry\n", ui.WarnIcon(), name)
+			continue
+		}
+		if dryRun {
+			fmt.Printf("  %s %s\n", ui.Subtle.Sprint("mcp "+name+":"), s.Install)
+			continue
+		}
+		fmt.Printf("  Installing MCP server %s (%s)...\n", ui.Brand.Sprint(s.Display), s.Backend)
+		if err := mcp.Install(s); err != nil {
+			ui.Bad.Printf("  %s %s: %v\n", ui.StatusIcon(false), s.Display, err)
+			continue
+		}
+		installed++
+	}
+	if installed > 0 {
+		ui.Good.Printf("  %s %d MCP server(s) installed — run `palm mcp sync` to configure them in your AI tools\n", ui.StatusIcon(true), installed)
+	}
+}
+
+func stackCompletionFunc(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var completions []string
+	for _, s := range loadStacks() {
+		completions = append(completions, s.Name+"\t"+s.Description)
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
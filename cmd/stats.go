@@ -3,6 +3,8 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/msalah0e/palm/internal/session"
@@ -12,11 +14,44 @@ import (
 )
 
 func statsCmd() *cobra.Command {
+	var (
+		since string
+	)
+
 	cmd := &cobra.Command{
 		Use:     "stats",
 		Aliases: []string{"history"},
 		Short:   "Show usage statistics and session history",
+		Long: `Show unified usage analytics across palm subsystems:
+proxy traffic (tokens and cost per provider per day), compose runs and
+failure rates, speedtest throughput trends, eval scores, and tool launches.
+
+Examples:
+  palm stats                  # Everything recorded
+  palm stats --since 30d      # Last 30 days
+  palm stats --since 24h --json`,
 		Run: func(cmd *cobra.Command, args []string) {
+			var from time.Time
+			if since != "" {
+				t, err := parseSince(since)
+				if err != nil {
+					ui.Bad.Printf("  %v\n", err)
+					os.Exit(1)
+				}
+				from = t
+			}
+
+			report, err := stats.BuildReport(from)
+			if err != nil {
+				ui.Bad.Printf("  Failed to build report: %v\n", err)
+				os.Exit(1)
+			}
+
+			if jsonOutput {
+				printJSON(report)
+				return
+			}
+
 			ui.Banner("usage statistics")
 
 			summary, err := stats.Summarize()
@@ -25,30 +60,148 @@ func statsCmd() *cobra.Command {
 				os.Exit(1)
 			}
 
-			if summary.TotalCommands == 0 {
+			if summary.TotalCommands == 0 && report.Totals.Requests == 0 &&
+				report.Totals.ComposeRuns == 0 && report.Totals.Launches == 0 &&
+				len(report.Speed) == 0 && len(report.Evals) == 0 {
 				fmt.Println("  No usage data recorded yet.")
 				fmt.Println("  Enable stats in ~/.config/palm/config.toml")
 				return
 			}
 
-			fmt.Printf("  Total commands:     %d\n", summary.TotalCommands)
-			fmt.Printf("  Tools installed:    %d\n", summary.ToolsInstalled)
-
+			if since != "" {
+				fmt.Printf("  Window:             since %s\n", from.Format("Jan 02 15:04"))
+			}
+			if summary.TotalCommands > 0 {
+				fmt.Printf("  Total commands:     %d\n", summary.TotalCommands)
+				fmt.Printf("  Tools installed:    %d\n", summary.ToolsInstalled)
+			}
 			if !summary.LastUsed.IsZero() {
 				ago := time.Since(summary.LastUsed).Round(time.Second)
 				fmt.Printf("  Last used:          %s ago\n", ago)
 			}
+			if report.Totals.Requests > 0 {
+				fmt.Printf("  API requests:       %d\n", report.Totals.Requests)
+				fmt.Printf("  Tokens:             %d in / %d out\n", report.Totals.InputTokens, report.Totals.OutputTokens)
+				fmt.Printf("  Estimated cost:     $%.4f\n", report.Totals.Cost)
+			}
+
+			if len(report.Providers) > 0 {
+				fmt.Println()
+				ui.Brand.Println("  Provider usage")
+				var rows [][]string
+				for _, p := range report.Providers {
+					rows = append(rows, []string{
+						p.Day, p.Provider,
+						fmt.Sprintf("%d", p.Requests),
+						fmt.Sprintf("%d", p.Errors),
+						fmt.Sprintf("%d", p.InputTokens+p.OutputTokens),
+						fmt.Sprintf("$%.4f", p.Cost),
+					})
+				}
+				ui.Table([]string{"Day", "Provider", "Requests", "Errors", "Tokens", "Cost"}, rows)
+			}
+
+			if len(report.Launches) > 0 {
+				fmt.Println()
+				ui.Brand.Println("  Tool launches")
+				var rows [][]string
+				for _, l := range report.Launches {
+					rows = append(rows, []string{l.Tool, fmt.Sprintf("%d", l.Launches), l.LastUsed.Format("Jan 02 15:04")})
+				}
+				ui.Table([]string{"Tool", "Launches", "Last used"}, rows)
+			}
+
+			if len(report.Compose) > 0 {
+				fmt.Println()
+				ui.Brand.Println("  Compose workflows")
+				var rows [][]string
+				for _, w := range report.Compose {
+					rows = append(rows, []string{
+						w.Workflow,
+						fmt.Sprintf("%d", w.Runs),
+						fmt.Sprintf("%d (%.0f%%)", w.Failures, w.FailureRate*100),
+						fmt.Sprintf("%.1fs", w.AvgDuration),
+						w.LastRun.Format("Jan 02 15:04"),
+					})
+				}
+				ui.Table([]string{"Workflow", "Runs", "Failures", "Avg time", "Last run"}, rows)
+			}
+
+			if len(report.Speed) > 0 {
+				fmt.Println()
+				ui.Brand.Println("  Speedtest history")
+				var rows [][]string
+				for _, sp := range report.Speed {
+					rows = append(rows, []string{
+						sp.Provider,
+						fmt.Sprintf("%d", sp.Runs),
+						fmt.Sprintf("%.1f", sp.AvgTPS),
+						fmt.Sprintf("%.1f", sp.BestTPS),
+						fmt.Sprintf("%.1f", sp.LastTPS),
+						formatTrend(sp.Trend),
+					})
+				}
+				ui.Table([]string{"Provider", "Runs", "Avg tok/s", "Best", "Last", "Trend"}, rows)
+			}
+
+			if len(report.Evals) > 0 {
+				fmt.Println()
+				ui.Brand.Println("  Eval scores")
+				var rows [][]string
+				for _, e := range report.Evals {
+					rows = append(rows, []string{e.Tool, fmt.Sprintf("%d", e.Runs), fmt.Sprintf("%.0f", e.AvgScore), fmt.Sprintf("%.0f", e.Best)})
+				}
+				ui.Table([]string{"Tool", "Runs", "Avg score", "Best"}, rows)
+			}
 		},
 	}
 
 	cmd.AddCommand(
 		statsSessionsCmd(),
 		statsSessionsCostCmd(),
+		statsDigestCmd(),
 	)
 
+	cmd.Flags().StringVar(&since, "since", "", "Only include data from this window (e.g. 24h, 7d, 2w)")
 	return cmd
 }
 
+// parseSince converts a relative window like "30d", "2w", or "90m" into the
+// absolute start time. Plain Go durations ("1h30m") are accepted too.
+func parseSince(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if len(s) > 1 {
+		unit := s[len(s)-1]
+		if unit == 'd' || unit == 'w' {
+			n, err := strconv.Atoi(s[:len(s)-1])
+			if err != nil || n < 0 {
+				return time.Time{}, fmt.Errorf("invalid window %q (use e.g. 24h, 7d, 2w)", s)
+			}
+			days := n
+			if unit == 'w' {
+				days = n * 7
+			}
+			return time.Now().AddDate(0, 0, -days), nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("invalid window %q (use e.g. 24h, 7d, 2w)", s)
+	}
+	return time.Now().Add(-d), nil
+}
+
+func formatTrend(pct float64) string {
+	switch {
+	case pct > 5:
+		return ui.Good.Sprintf("↑ %.0f%%", pct)
+	case pct < -5:
+		return ui.Bad.Sprintf("↓ %.0f%%", -pct)
+	default:
+		return ui.Subtle.Sprint("→ steady")
+	}
+}
+
 func statsSessionsCmd() *cobra.Command {
 	var count int
 
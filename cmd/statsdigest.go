@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/msalah0e/palm/internal/notify"
+	"github.com/msalah0e/palm/internal/stats"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// digestEvent is the webhook payload for a digest.
+type digestEvent struct {
+	Text   string        `json:"text"` // Slack incoming webhooks display this field
+	Digest *stats.Digest `json:"digest"`
+}
+
+func statsDigestCmd() *cobra.Command {
+	var (
+		week      bool
+		since     string
+		out       string
+		webhook   string
+		summarize bool
+		model     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "digest [--week]",
+		Short: "Write a Markdown digest of usage, cost, tools, evals and notable events",
+		Long: `Assemble a Markdown report of a period's usage: API requests, tokens and
+cost against the period before, cost by provider, the most launched tools,
+compose workflows, eval score trends, speedtest results, and notable events
+such as failures, installs and tools used for the first time.
+
+The period is the last 7 days (--week) unless --since sets another. With
+--summarize, a local ollama model opens the report with a short summary.
+The digest is printed, or written to a file with --output, and can be
+posted to a webhook as {"text": ..., "digest": ...}.
+
+Examples:
+  palm stats digest --week
+  palm stats digest --week --summarize -o digest.md
+  palm stats digest --since 30d --webhook https://hooks.slack.com/...`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			to := time.Now()
+			from := to.AddDate(0, 0, -7)
+			if since != "" {
+				if week {
+					ui.Bad.Println("  --week and --since can't be used together")
+					os.Exit(1)
+				}
+				t, err := parseSince(since)
+				if err != nil {
+					ui.Bad.Printf("  %v\n", err)
+					os.Exit(1)
+				}
+				from = t
+			}
+
+			d, err := stats.BuildDigest(from, to)
+			if err != nil {
+				ui.Bad.Printf("  Failed to build digest: %v\n", err)
+				os.Exit(1)
+			}
+
+			if summarize && !d.Empty() {
+				summary, err := summarizeDigest(d, model)
+				if err != nil {
+					ui.Warn.Fprintf(os.Stderr, "  %s Couldn't summarize with a local model: %v\n", ui.WarnIcon(), err)
+				}
+				d.Summary = summary
+			}
+			md := d.Markdown()
+
+			if webhook != "" {
+				if offlineMode {
+					ui.Warn.Fprintf(os.Stderr, "  %s --offline set, not posting to the webhook\n", ui.WarnIcon())
+				} else if err := notify.Webhook(webhook, digestEvent{Text: md, Digest: d}); err != nil {
+					ui.Bad.Fprintf(os.Stderr, "  Failed to post digest: %v\n", err)
+					os.Exit(1)
+				} else {
+					ui.Good.Fprintf(os.Stderr, "  %s Posted digest to webhook\n", ui.StatusIcon(true))
+				}
+			}
+
+			if out != "" {
+				if err := os.WriteFile(out, []byte(md), 0o644); err != nil {
+					ui.Bad.Printf("  Failed to write %s: %v\n", out, err)
+					os.Exit(1)
+				}
+				ui.Good.Fprintf(os.Stderr, "  %s Wrote digest to %s\n", ui.StatusIcon(true), out)
+			}
+			if jsonOutput {
+				printJSON(d)
+				return
+			}
+			if out == "" && webhook == "" {
+				fmt.Print(md)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&week, "week", false, "Cover the last 7 days (the default)")
+	cmd.Flags().StringVar(&since, "since", "", "Cover this window instead (e.g. 24h, 30d, 2w)")
+	cmd.Flags().StringVarP(&out, "output", "o", "", "Write the Markdown digest to a file")
+	cmd.Flags().StringVar(&webhook, "webhook", "", "Post the digest to a webhook URL")
+	cmd.Flags().BoolVar(&summarize, "summarize", false, "Open the digest with a summary from a local ollama model")
+	cmd.Flags().StringVar(&model, "model", defaultSummarizeModel, "Ollama model for --summarize")
+	_ = cmd.MarkFlagFilename("output", "md")
+	return cmd
+}
+
+// summarizeDigest asks a local ollama model for a short summary of d.
+func summarizeDigest(d *stats.Digest, model string) (string, error) {
+	prompt := fmt.Sprintf(`Here is a report of one developer's AI tool usage for a period.
+Summarize it in three to five plain sentences for a weekly review: what changed,
+what cost the most, and anything that needs attention. Reply with the summary only.
+
+%s`, d.Markdown())
+	client := &http.Client{Timeout: 2 * time.Minute}
+	return ollamaGenerate(client, ollamaRoot(), model, prompt)
+}
@@ -7,7 +7,6 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/msalah0e/palm/internal/registry"
 	"github.com/msalah0e/palm/internal/ui"
 	"github.com/spf13/cobra"
 )
@@ -18,6 +17,10 @@ type teamConfig struct {
 	Tools   []string          `json:"tools"`
 	Rules   []string          `json:"rules"`
 	Prompts map[string]string `json:"prompts,omitempty"`
+	MCP     []string          `json:"mcp,omitempty"` // MCP servers from the registry
+
+	Source string `json:"-"` // where the config was loaded from
+	Dir    string `json:"-"` // clone directory for joined teams
 }
 
 func teamCmd() *cobra.Command {
@@ -31,15 +34,20 @@ func teamCmd() *cobra.Command {
 			if err != nil {
 				fmt.Println("  No team config found.")
 				fmt.Println("  Run `palm team init` to create .palm-team.json")
+				fmt.Println("  or `palm team join <git-url>` to use a shared one")
 				return
 			}
 
 			fmt.Printf("  %s  %s\n", ui.Brand.Sprint("Team"), tc.Name)
+			fmt.Printf("  %s  %s\n", ui.Brand.Sprint("Source"), tc.Source)
 			fmt.Printf("  %s  %s\n", ui.Brand.Sprint("Tools"), strings.Join(tc.Tools, ", "))
 			fmt.Printf("  %s  %d\n", ui.Brand.Sprint("Rules"), len(tc.Rules))
 			if len(tc.Prompts) > 0 {
 				fmt.Printf("  %s  %d\n", ui.Brand.Sprint("Prompts"), len(tc.Prompts))
 			}
+			if len(tc.MCP) > 0 {
+				fmt.Printf("  %s  %s\n", ui.Brand.Sprint("MCP"), strings.Join(tc.MCP, ", "))
+			}
 		},
 	}
 
@@ -49,6 +57,9 @@ func teamCmd() *cobra.Command {
 		teamAddRuleCmd(),
 		teamExportCmd(),
 		teamValidateCmd(),
+		teamJoinCmd(),
+		teamUpdateCmd(),
+		teamPromptsCmd(),
 	)
 
 	return cmd
@@ -108,6 +119,11 @@ func teamAddToolCmd() *cobra.Command {
 				os.Exit(1)
 			}
 
+			if tc.Dir != "" {
+				ui.Bad.Printf("  Team config comes from %s — change it in the team repo\n", tc.Source)
+				os.Exit(1)
+			}
+
 			tool := args[0]
 			for _, t := range tc.Tools {
 				if t == tool {
@@ -135,6 +151,11 @@ func teamAddRuleCmd() *cobra.Command {
 				os.Exit(1)
 			}
 
+			if tc.Dir != "" {
+				ui.Bad.Printf("  Team config comes from %s — change it in the team repo\n", tc.Source)
+				os.Exit(1)
+			}
+
 			tc.Rules = append(tc.Rules, args[0])
 			saveTeamConfig(tc)
 			ui.Good.Printf("  %s Added rule to team config\n", ui.StatusIcon(true))
@@ -176,15 +197,14 @@ func teamValidateCmd() *cobra.Command {
 			reg := loadRegistry()
 			issues := 0
 
+			detected := detectTools(reg, tc.Tools)
 			for _, tool := range tc.Tools {
-				t := reg.Get(tool)
-				if t == nil {
+				if reg.Get(tool) == nil {
 					ui.Warn.Printf("  %s %s — not in registry\n", ui.WarnIcon(), tool)
 					issues++
 					continue
 				}
-				dt := registry.DetectOne(*t)
-				if dt.Installed {
+				if detected[tool].Installed {
 					fmt.Printf("  %s %s installed\n", ui.StatusIcon(true), tool)
 				} else {
 					ui.Warn.Printf("  %s %s — not installed\n", ui.WarnIcon(), tool)
@@ -204,12 +224,14 @@ func teamValidateCmd() *cobra.Command {
 			if issues == 0 {
 				ui.Good.Printf("  %s Setup matches team config\n", ui.StatusIcon(true))
 			} else {
-				fmt.Printf("  %d issues — run `palm install` for missing tools\n", issues)
+				fmt.Printf("  %d issues — run `palm install --team` for missing tools\n", issues)
 			}
 		},
 	}
 }
 
+// loadTeamConfig finds .palm-team.json in the current directory or a parent,
+// falling back to the active joined team.
 func loadTeamConfig() (*teamConfig, error) {
 	// Look in current dir and parent dirs
 	dir, _ := os.Getwd()
@@ -221,6 +243,7 @@ func loadTeamConfig() (*teamConfig, error) {
 			if err := json.Unmarshal(data, &tc); err != nil {
 				return nil, err
 			}
+			tc.Source = path
 			return &tc, nil
 		}
 		parent := filepath.Dir(dir)
@@ -229,6 +252,9 @@ func loadTeamConfig() (*teamConfig, error) {
 		}
 		dir = parent
 	}
+	if name := activeTeam(); name != "" {
+		return loadRemoteTeamConfig(name)
+	}
 	return nil, fmt.Errorf("no .palm-team.json found")
 }
 
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/msalah0e/palm/internal/prompt"
+)
+
+func TestTeamNameFromURL(t *testing.T) {
+	tests := map[string]string{
+		"git@github.com:acme/ai-config.git":  "ai-config",
+		"https://github.com/acme/ai-config":  "ai-config",
+		"https://github.com/acme/ai-config/": "ai-config",
+		"/srv/git/team.git":                  "team",
+	}
+	for url, want := range tests {
+		if got := teamNameFromURL(url); got != want {
+			t.Errorf("teamNameFromURL(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
+
+func TestLoadTeamConfigFallsBackToActiveTeam(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	wd, _ := os.Getwd()
+	_ = os.Chdir(t.TempDir())
+	defer os.Chdir(wd)
+
+	if _, err := loadTeamConfig(); err == nil {
+		t.Fatal("expected no team config")
+	}
+
+	dir := filepath.Join(teamsDir(), "acme")
+	os.MkdirAll(dir, 0o755)
+	os.WriteFile(filepath.Join(dir, ".palm-team.json"), []byte(`{"name":"Acme","tools":["aider"]}`), 0o644)
+	os.WriteFile(activeTeamPath(), []byte("acme\n"), 0o644)
+
+	tc, err := loadTeamConfig()
+	if err != nil {
+		t.Fatalf("active team should be used: %v", err)
+	}
+	if tc.Name != "Acme" || tc.Dir != dir || len(tc.Tools) != 1 {
+		t.Errorf("unexpected config: %+v", tc)
+	}
+
+	os.WriteFile(".palm-team.json", []byte(`{"name":"local"}`), 0o644)
+	if tc, _ := loadTeamConfig(); tc == nil || tc.Name != "local" || tc.Dir != "" {
+		t.Errorf("a project .palm-team.json should win: %+v", tc)
+	}
+}
+
+func TestTeamPromptsSync(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	root := t.TempDir()
+	commands := filepath.Join(root, ".claude", "commands")
+	continuerc := filepath.Join(root, ".continuerc.json")
+	os.MkdirAll(commands, 0o755)
+	os.WriteFile(filepath.Join(commands, "mine.md"), []byte("my own command"), 0o644)
+	os.WriteFile(continuerc, []byte(`{"models":[],"customCommands":[{"name":"mine","prompt":"hi"}]}`), 0o644)
+
+	prompts := map[string]string{
+		"review":  "Review this diff for bugs:\n\n{{diff}}",
+		"compare": "Compare {{a}} with {{b}}",
+		"old":     "Dropped later",
+	}
+	if _, err := syncClaudeCommands(commands, prompts, false); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filepath.Join(commands, "compare.md"))
+	if !strings.Contains(string(data), "Compare $1 with $2") || !strings.Contains(string(data), `argument-hint: "<a> <b>"`) {
+		t.Errorf("compare.md:\n%s", data)
+	}
+	data, _ = os.ReadFile(filepath.Join(commands, "review.md"))
+	if !strings.Contains(string(data), "$ARGUMENTS") || !strings.Contains(string(data), teamPromptTag) {
+		t.Errorf("review.md:\n%s", data)
+	}
+
+	res, err := syncContinueCommands(continuerc, prompts, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Written) != 2 || len(res.Skipped) != 1 {
+		t.Errorf("continue sync = %+v", res)
+	}
+	data, _ = os.ReadFile(continuerc)
+	if !strings.Contains(string(data), "Review this diff for bugs:\\n\\n{{{ input }}}") {
+		t.Errorf(".continuerc.json:\n%s", data)
+	}
+
+	if res, err := syncPromptStore("Acme", prompts, false, false); err != nil || len(res.Written) != 3 {
+		t.Fatalf("prompt store sync = %+v, %v", res, err)
+	}
+	if p, err := prompt.Load("review"); err != nil || p.Content != prompts["review"] {
+		t.Errorf("palm prompt run review would get %+v, %v", p, err)
+	}
+
+	// Dropping a prompt removes what palm synced and nothing of the user's
+	delete(prompts, "old")
+	res, _ = syncClaudeCommands(commands, prompts, false)
+	if len(res.Removed) != 1 || res.Removed[0] != "old" || len(res.Unchanged) != 2 {
+		t.Errorf("claude resync = %+v", res)
+	}
+	if _, err := os.Stat(filepath.Join(commands, "mine.md")); err != nil {
+		t.Error("the user's own command was removed")
+	}
+	res, _ = syncContinueCommands(continuerc, prompts, false)
+	data, _ = os.ReadFile(continuerc)
+	if len(res.Removed) != 1 || strings.Contains(string(data), "Dropped later") || !strings.Contains(string(data), `"mine"`) {
+		t.Errorf("continue resync = %+v\n%s", res, data)
+	}
+
+	// A personal prompt of the same name is kept unless forced
+	prompt.Add("review", "my review prompt", "")
+	prompts["review"] = "Review again: {{diff}}"
+	if res, _ := syncPromptStore("Acme", prompts, false, false); len(res.Skipped) != 1 {
+		t.Errorf("personal prompt should be skipped: %+v", res)
+	}
+	if res, _ := syncPromptStore("Acme", prompts, true, false); len(res.Written) != 1 {
+		t.Errorf("--force should replace it: %+v", res)
+	}
+}
@@ -0,0 +1,424 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/msalah0e/palm/internal/prompt"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// teamPromptTag marks prompts palm synced into a tool, so a later sync can
+// replace or remove them without touching the user's own.
+const teamPromptTag = "[palm team prompt]"
+
+// teamPromptTools are the tools with a native prompt mechanism palm can
+// install team prompts into.
+var teamPromptTools = []string{"claude-code", "continue"}
+
+func teamPromptsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prompts",
+		Short: "List the team's shared prompts",
+		Run: func(cmd *cobra.Command, args []string) {
+			tc, err := loadTeamConfig()
+			if err != nil {
+				ui.Bad.Println("  No team config found")
+				os.Exit(1)
+			}
+
+			names := promptNames(tc.Prompts)
+			render(tc.Prompts, func() {
+				ui.Banner("team prompts")
+				if len(names) == 0 {
+					fmt.Printf("  %s has no shared prompts\n", tc.Name)
+					return
+				}
+				var rows [][]string
+				for _, name := range names {
+					content := tc.Prompts[name]
+					rows = append(rows, []string{name, orDash(strings.Join(prompt.Variables(content), ", ")), truncate(firstLine(content), 60)})
+				}
+				ui.Table([]string{"Prompt", "Variables", "Text"}, rows)
+				fmt.Println()
+				fmt.Println("  Install them into your tools: palm team prompts sync")
+			})
+		},
+	}
+	cmd.AddCommand(teamPromptsSyncCmd())
+	return cmd
+}
+
+func teamPromptsSyncCmd() *cobra.Command {
+	var tools []string
+	var global, force, dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Install team prompts into each tool's own prompt mechanism",
+		Long: `Install the team's prompts where each tool looks for them:
+
+  claude-code   .claude/commands/<name>.md, run as /<name>
+  continue      customCommands in .continuerc.json, run as /<name>
+  palm          the palm prompt store, for palm prompt run <name> and
+                every other tool through --tool
+
+Claude Code and Continue are synced when they are team tools or already
+configured in the project, or when named with --tool. {{variables}} become
+the tool's arguments: $ARGUMENTS (or $1, $2, ...) for Claude Code and the
+slash command input for Continue, which takes one variable at most.
+
+Prompts the team has dropped are removed from the tools on the next sync.
+A personal prompt with the same name as a team prompt is left alone unless
+--force is given; its earlier versions stay in palm prompt history.`,
+		Example: `  palm team prompts sync
+  palm team prompts sync --tool claude-code --global
+  palm team prompts sync --dry-run`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			tc, err := loadTeamConfig()
+			if err != nil {
+				ui.Bad.Println("  No team config found")
+				os.Exit(1)
+			}
+			for _, t := range tools {
+				if !containsStr(teamPromptTools, t) {
+					ui.Bad.Printf("  palm can't sync prompts into %s — supported: %s\n", t, strings.Join(teamPromptTools, ", "))
+					os.Exit(1)
+				}
+			}
+
+			prompts := make(map[string]string)
+			for _, name := range promptNames(tc.Prompts) {
+				if err := prompt.ValidName(name); err != nil {
+					ui.Warn.Printf("  %s Skipping %v\n", ui.WarnIcon(), err)
+					continue
+				}
+				prompts[name] = tc.Prompts[name]
+			}
+
+			ui.Banner("team prompts sync")
+			fmt.Printf("  Team: %s  %s\n\n", ui.Brand.Sprint(tc.Name), ui.Subtle.Sprintf("(%d prompts)", len(prompts)))
+
+			root := "."
+			if global {
+				root, _ = os.UserHomeDir()
+			}
+			if len(tools) == 0 {
+				tools = teamPromptTargets(tc, root)
+			}
+
+			failed := false
+			for _, tool := range tools {
+				var res promptSyncResult
+				switch tool {
+				case "claude-code":
+					res, err = syncClaudeCommands(filepath.Join(root, ".claude", "commands"), prompts, dryRun)
+				case "continue":
+					res, err = syncContinueCommands(filepath.Join(root, ".continuerc.json"), prompts, dryRun)
+				}
+				if err != nil {
+					ui.Bad.Printf("  %s %-12s %v\n", ui.StatusIcon(false), tool, err)
+					failed = true
+					continue
+				}
+				printPromptSync(tool, res)
+			}
+
+			res, err := syncPromptStore(tc.Name, prompts, force, dryRun)
+			if err != nil {
+				ui.Bad.Printf("  %s %-12s %v\n", ui.StatusIcon(false), "palm", err)
+				failed = true
+			} else {
+				printPromptSync("palm", res)
+			}
+
+			fmt.Println()
+			if dryRun {
+				ui.Subtle.Println("  Dry run — nothing written")
+			}
+			if failed {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&tools, "tool", nil, "Only sync into these tools: claude-code, continue (repeatable)")
+	cmd.Flags().BoolVar(&global, "global", false, "Install into the tools' user-wide config in your home directory instead of the project")
+	cmd.Flags().BoolVar(&force, "force", false, "Replace personal palm prompts that share a team prompt's name")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would change without writing anything")
+	return cmd
+}
+
+// promptSyncResult is what a sync into one tool did.
+type promptSyncResult struct {
+	Where     string
+	Written   []string
+	Unchanged []string
+	Removed   []string
+	Skipped   []string // name: reason
+}
+
+func printPromptSync(tool string, r promptSyncResult) {
+	var parts []string
+	if len(r.Written) > 0 {
+		parts = append(parts, fmt.Sprintf("%d written", len(r.Written)))
+	}
+	if len(r.Unchanged) > 0 {
+		parts = append(parts, fmt.Sprintf("%d unchanged", len(r.Unchanged)))
+	}
+	if len(r.Removed) > 0 {
+		parts = append(parts, fmt.Sprintf("%d removed (%s)", len(r.Removed), strings.Join(r.Removed, ", ")))
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "nothing to do")
+	}
+	fmt.Printf("  %s %-12s %s  %s\n", ui.StatusIcon(true), tool, strings.Join(parts, ", "), ui.Subtle.Sprint(r.Where))
+	for _, s := range r.Skipped {
+		ui.Warn.Printf("    %s skipped %s\n", ui.WarnIcon(), s)
+	}
+}
+
+// teamPromptTargets picks the tools to sync into: team tools with a prompt
+// mechanism, and tools already configured under root.
+func teamPromptTargets(tc *teamConfig, root string) []string {
+	var targets []string
+	for _, tool := range teamPromptTools {
+		configured := false
+		switch tool {
+		case "claude-code":
+			_, err := os.Stat(filepath.Join(root, ".claude"))
+			configured = err == nil
+		case "continue":
+			_, err := os.Stat(filepath.Join(root, ".continuerc.json"))
+			configured = err == nil
+		}
+		if configured || containsStr(tc.Tools, tool) {
+			targets = append(targets, tool)
+		}
+	}
+	return targets
+}
+
+// teamPromptSummary is a one-line description of a team prompt, tagged so
+// palm recognizes it later.
+func teamPromptSummary(content string) string {
+	return truncate(firstLine(content), 60) + " " + teamPromptTag
+}
+
+// claudeCommand renders a prompt as a Claude Code slash command: one
+// variable becomes $ARGUMENTS, several become $1, $2, ...
+func claudeCommand(content string) string {
+	vars := prompt.Variables(content)
+	values := make(map[string]string, len(vars))
+	var hint []string
+	for i, v := range vars {
+		values[v] = fmt.Sprintf("$%d", i+1)
+		hint = append(hint, "<"+v+">")
+	}
+	if len(vars) == 1 {
+		values[vars[0]] = "$ARGUMENTS"
+	}
+
+	var b strings.Builder
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "description: %s\n", yamlQuote(teamPromptSummary(content)))
+	if len(hint) > 0 {
+		fmt.Fprintf(&b, "argument-hint: %s\n", yamlQuote(strings.Join(hint, " ")))
+	}
+	b.WriteString("---\n\n")
+	b.WriteString(strings.TrimRight(prompt.Render(content, values), "\n"))
+	b.WriteString("\n")
+	return b.String()
+}
+
+// syncClaudeCommands writes each prompt to dir as <name>.md and removes
+// commands palm synced earlier that the team no longer has.
+func syncClaudeCommands(dir string, prompts map[string]string, dryRun bool) (promptSyncResult, error) {
+	res := promptSyncResult{Where: dir}
+	for _, name := range promptNames(prompts) {
+		path := filepath.Join(dir, name+".md")
+		body := claudeCommand(prompts[name])
+		existing, err := os.ReadFile(path)
+		switch {
+		case err == nil && string(existing) == body:
+			res.Unchanged = append(res.Unchanged, name)
+			continue
+		case err == nil && !strings.Contains(string(existing), teamPromptTag):
+			res.Skipped = append(res.Skipped, name+": "+path+" is your own command")
+			continue
+		}
+		res.Written = append(res.Written, name)
+		if dryRun {
+			continue
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return res, err
+		}
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			return res, err
+		}
+	}
+
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".md")
+		if !ok || e.IsDir() {
+			continue
+		}
+		if _, keep := prompts[name]; keep {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		if data, err := os.ReadFile(path); err != nil || !strings.Contains(string(data), teamPromptTag) {
+			continue
+		}
+		res.Removed = append(res.Removed, name)
+		if !dryRun {
+			if err := os.Remove(path); err != nil {
+				return res, err
+			}
+		}
+	}
+	return res, nil
+}
+
+// syncContinueCommands replaces the team prompts among the customCommands
+// of Continue's config at path, keeping everything else in the file.
+func syncContinueCommands(path string, prompts map[string]string, dryRun bool) (promptSyncResult, error) {
+	res := promptSyncResult{Where: path}
+	cfg := map[string]any{}
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return res, fmt.Errorf("%s: %w", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return res, err
+	}
+
+	existing := make(map[string]map[string]any)
+	var kept []any
+	list, _ := cfg["customCommands"].([]any)
+	for _, item := range list {
+		c, ok := item.(map[string]any)
+		desc, _ := c["description"].(string)
+		if !ok || !strings.HasSuffix(desc, teamPromptTag) {
+			kept = append(kept, item)
+			continue
+		}
+		name, _ := c["name"].(string)
+		existing[name] = c
+	}
+	own := make(map[string]bool)
+	for _, item := range kept {
+		if c, ok := item.(map[string]any); ok {
+			if name, ok := c["name"].(string); ok {
+				own[name] = true
+			}
+		}
+	}
+
+	for _, name := range promptNames(prompts) {
+		content := prompts[name]
+		vars := prompt.Variables(content)
+		switch {
+		case len(vars) > 1:
+			res.Skipped = append(res.Skipped, fmt.Sprintf("%s: needs %d variables, Continue passes one — use palm prompt run", name, len(vars)))
+			continue
+		case own[name]:
+			res.Skipped = append(res.Skipped, name+": a command of yours has that name")
+			continue
+		}
+		values := map[string]string{}
+		if len(vars) == 1 {
+			values[vars[0]] = "{{{ input }}}"
+		}
+		c := map[string]any{
+			"name":        name,
+			"description": teamPromptSummary(content),
+			"prompt":      prompt.Render(content, values),
+		}
+		if prev, ok := existing[name]; ok && prev["prompt"] == c["prompt"] && prev["description"] == c["description"] {
+			res.Unchanged = append(res.Unchanged, name)
+		} else {
+			res.Written = append(res.Written, name)
+		}
+		delete(existing, name)
+		kept = append(kept, c)
+	}
+	for name := range existing {
+		res.Removed = append(res.Removed, name)
+	}
+	sort.Strings(res.Removed)
+
+	if dryRun || (len(res.Written) == 0 && len(res.Removed) == 0) {
+		return res, nil
+	}
+	if kept == nil {
+		kept = []any{}
+	}
+	cfg["customCommands"] = kept
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return res, err
+	}
+	return res, os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// syncPromptStore saves each prompt to the palm prompt store, noting the
+// team in its history. A prompt whose latest version didn't come from a
+// team sync is personal and kept unless force is set.
+func syncPromptStore(team string, prompts map[string]string, force, dryRun bool) (promptSyncResult, error) {
+	res := promptSyncResult{Where: "palm prompt run <name>"}
+	note := "team " + team
+	for _, name := range promptNames(prompts) {
+		history, err := prompt.History(name)
+		if err != nil {
+			return res, err
+		}
+		if n := len(history); n > 0 {
+			latest := history[n-1]
+			if latest.Content == prompts[name] {
+				res.Unchanged = append(res.Unchanged, name)
+				continue
+			}
+			if !strings.HasPrefix(latest.Note, "team ") && !force {
+				res.Skipped = append(res.Skipped, name+": you have a prompt with that name (--force replaces it)")
+				continue
+			}
+		}
+		res.Written = append(res.Written, name)
+		if dryRun {
+			continue
+		}
+		if _, _, err := prompt.Add(name, prompts[name], note); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// promptNames returns the names of prompts, sorted.
+func promptNames(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(line)
+}
+
+// yamlQuote double-quotes s for a YAML frontmatter value.
+func yamlQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
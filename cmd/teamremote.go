@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/msalah0e/palm/internal/config"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// A shared team repo holds .palm-team.json (tools, rules, prompts) at its
+// root and optionally a workflows/ directory of compose files. Joined repos
+// are cloned under ~/.config/palm/teams/<name>; the one joined last is the
+// active team.
+
+// teamsDir returns the directory holding cloned team repos.
+func teamsDir() string {
+	return filepath.Join(config.ConfigDir(), "teams")
+}
+
+func activeTeamPath() string {
+	return filepath.Join(teamsDir(), ".active")
+}
+
+// activeTeam returns the name of the active joined team, or "".
+func activeTeam() string {
+	data, err := os.ReadFile(activeTeamPath())
+	if err != nil {
+		return ""
+	}
+	name := strings.TrimSpace(string(data))
+	if _, err := os.Stat(filepath.Join(teamsDir(), name)); err != nil {
+		return ""
+	}
+	return name
+}
+
+// joinedTeams lists the names of cloned team repos.
+func joinedTeams() []string {
+	entries, err := os.ReadDir(teamsDir())
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+			names = append(names, e.Name())
+		}
+	}
+	return names
+}
+
+// teamNameFromURL derives a directory name from a git URL:
+// git@github.com:acme/ai-config.git and https://host/acme/ai-config both
+// become "ai-config".
+func teamNameFromURL(url string) string {
+	url = strings.TrimRight(url, "/")
+	url = strings.TrimSuffix(url, ".git")
+	if i := strings.LastIndexAny(url, "/:"); i >= 0 {
+		url = url[i+1:]
+	}
+	return url
+}
+
+// loadRemoteTeamConfig reads the team config of the joined team name.
+func loadRemoteTeamConfig(name string) (*teamConfig, error) {
+	dir := filepath.Join(teamsDir(), name)
+	data, err := os.ReadFile(filepath.Join(dir, ".palm-team.json"))
+	if err != nil {
+		return nil, fmt.Errorf("team %s has no .palm-team.json", name)
+	}
+	var tc teamConfig
+	if err := json.Unmarshal(data, &tc); err != nil {
+		return nil, fmt.Errorf("team %s: %w", name, err)
+	}
+	if tc.Name == "" {
+		tc.Name = name
+	}
+	tc.Source = "team repo " + name
+	tc.Dir = dir
+	return &tc, nil
+}
+
+// teamWorkflowDir returns the workflows directory of the active team, or "".
+func teamWorkflowDir() string {
+	if name := activeTeam(); name != "" {
+		return filepath.Join(teamsDir(), name, "workflows")
+	}
+	return ""
+}
+
+// runGit runs git with output going to the terminal.
+func runGit(args ...string) error {
+	c := exec.Command("git", args...)
+	c.Stdout = os.Stderr
+	c.Stderr = os.Stderr
+	return c.Run()
+}
+
+func teamJoinCmd() *cobra.Command {
+	var name, branch string
+
+	cmd := &cobra.Command{
+		Use:   "join <git-url>",
+		Short: "Clone a shared team config repo and make it the active team",
+		Long: `Clone a git repo holding a shared team config into ~/.config/palm/teams/<name>
+and make it the active team. The repo needs a .palm-team.json at its root and
+may carry a workflows/ directory, offered by palm compose catalog.
+
+Outside a directory with its own .palm-team.json, palm team, validate and
+install --team use the active team's config. Joining a repo already cloned
+pulls it instead.`,
+		Example: `  palm team join git@github.com:acme/ai-config.git
+  palm team join https://github.com/acme/ai-config --name acme`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			url := args[0]
+			// git would read a URL starting with "-" as an option
+			if strings.HasPrefix(url, "-") {
+				ui.Bad.Printf("  Invalid git URL %q\n", url)
+				os.Exit(1)
+			}
+			if name == "" {
+				name = teamNameFromURL(url)
+			}
+			if name == "" || strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\`) {
+				ui.Bad.Printf("  Invalid team name %q — use --name\n", name)
+				os.Exit(1)
+			}
+			if _, err := exec.LookPath("git"); err != nil {
+				ui.Bad.Println("  git is required to join a team")
+				os.Exit(1)
+			}
+
+			dir := filepath.Join(teamsDir(), name)
+			cloned := false
+			if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+				fmt.Printf("  %s already joined — pulling\n", name)
+				if err := runGit("-C", dir, "pull", "--ff-only"); err != nil {
+					ui.Bad.Printf("  Failed to update %s: %v\n", name, err)
+					os.Exit(1)
+				}
+			} else {
+				if err := os.MkdirAll(teamsDir(), 0o755); err != nil {
+					ui.Bad.Printf("  %v\n", err)
+					os.Exit(1)
+				}
+				gitArgs := []string{"clone", "--depth", "1"}
+				if branch != "" {
+					gitArgs = append(gitArgs, "--branch", branch)
+				}
+				if err := runGit(append(gitArgs, "--", url, dir)...); err != nil {
+					ui.Bad.Printf("  Failed to clone %s: %v\n", url, err)
+					os.Exit(1)
+				}
+				cloned = true
+			}
+
+			tc, err := loadRemoteTeamConfig(name)
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				if cloned {
+					_ = os.RemoveAll(dir)
+				}
+				os.Exit(1)
+			}
+			if err := os.WriteFile(activeTeamPath(), []byte(name+"\n"), 0o644); err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+
+			ui.Good.Printf("  %s Joined %s\n", ui.StatusIcon(true), tc.Name)
+			fmt.Printf("  %d tools, %d rules, %d prompts\n", len(tc.Tools), len(tc.Rules), len(tc.Prompts))
+			fmt.Println("  Check your setup with: palm team validate")
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", "", "Local name for the team (default: repo name)")
+	cmd.Flags().StringVar(&branch, "branch", "", "Branch to track")
+	return cmd
+}
+
+func teamUpdateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "update [name]",
+		Short: "Pull the latest config for joined teams",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			names := joinedTeams()
+			if len(args) > 0 {
+				names = args
+			}
+			if len(names) == 0 {
+				fmt.Println("  No teams joined. Join one with: palm team join <git-url>")
+				return
+			}
+
+			failed := 0
+			for _, name := range names {
+				dir := filepath.Join(teamsDir(), name)
+				if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+					ui.Bad.Printf("  %s %s — not a joined team\n", ui.StatusIcon(false), name)
+					failed++
+					continue
+				}
+				fmt.Printf("  %s\n", ui.Brand.Sprint(name))
+				if err := runGit("-C", dir, "pull", "--ff-only"); err != nil {
+					ui.Bad.Printf("  %s %s: %v\n", ui.StatusIcon(false), name, err)
+					failed++
+					continue
+				}
+				if _, err := loadRemoteTeamConfig(name); err != nil {
+					ui.Warn.Printf("  %s %v\n", ui.WarnIcon(), err)
+					failed++
+					continue
+				}
+				ui.Good.Printf("  %s %s up to date\n", ui.StatusIcon(true), name)
+			}
+			if failed > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+}
@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/msalah0e/palm/internal/config"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// noColor is the --no-color flag.
+var noColor bool
+
+// themesDir holds custom theme files.
+func themesDir() string {
+	return filepath.Join(config.ConfigDir(), "themes")
+}
+
+// resolveTheme picks the active theme: PALM_THEME, then ui.theme in the
+// config.
+func resolveTheme(cfg *config.Config) string {
+	if env := os.Getenv("PALM_THEME"); env != "" {
+		return env
+	}
+	return cfg.UI.Theme
+}
+
+// applyTheme sets the colors every command prints with. NO_COLOR is
+// honored by the color package itself; --no-color and ui.color = false
+// turn colors off the same way.
+func applyTheme() {
+	cfg := config.Load()
+	if noColor || !cfg.UI.Color {
+		color.NoColor = true
+	}
+	p, err := ui.LoadTheme(themesDir(), resolveTheme(cfg))
+	if err == nil {
+		err = p.Apply()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "palm: %v — using the default theme\n", err)
+	}
+}
+
+type themeInfo struct {
+	Name    string      `json:"name"`
+	Builtin bool        `json:"builtin"`
+	Current bool        `json:"current"`
+	Palette *ui.Palette `json:"palette,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+func themeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "theme",
+		Short: "List color themes and pick one",
+		Long: `List the color themes, each shown in its own colors.
+
+Built-in themes are default (for dark terminals), light and high-contrast.
+Custom themes are TOML palettes in ~/.config/palm/themes/; start one with
+palm theme new. The active theme comes from PALM_THEME, then ui.theme in
+the config. NO_COLOR=1, --no-color or ui.color = false print without colors.
+
+  palm theme
+  palm theme use light
+  palm theme new solarized --from light`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			current := resolveTheme(config.Load())
+			if current == "" {
+				current = ui.DefaultTheme
+			}
+			var themes []themeInfo
+			for _, name := range ui.ThemeNames(themesDir()) {
+				_, builtin := ui.Themes[name]
+				t := themeInfo{Name: name, Builtin: builtin, Current: name == current}
+				if p, err := ui.LoadTheme(themesDir(), name); err != nil {
+					t.Error = err.Error()
+				} else {
+					t.Palette = &p
+				}
+				themes = append(themes, t)
+			}
+
+			render(themes, func() {
+				ui.Banner("themes")
+				var rows [][]string
+				for _, t := range themes {
+					name, kind := t.Name, "custom"
+					if t.Current {
+						name += " " + ui.Good.Sprint("*")
+					}
+					if t.Builtin {
+						kind = "built-in"
+					}
+					colors := ui.Bad.Sprint(t.Error)
+					if t.Palette != nil {
+						colors = t.Palette.Swatch()
+					}
+					rows = append(rows, []string{name, kind, colors})
+				}
+				ui.Table([]string{"THEME", "KIND", "COLORS"}, rows)
+				fmt.Println()
+				ui.Subtle.Println("  palm theme use <name> · palm theme new <name>")
+			})
+		},
+	}
+
+	cmd.AddCommand(themeUseCmd(), themeNewCmd())
+	return cmd
+}
+
+func themeUseCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "use <name>",
+		Short: "Make a theme the default for every command",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			name := args[0]
+			p, err := ui.LoadTheme(themesDir(), name)
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+			cfg := config.Load()
+			cfg.UI.Theme = name
+			if err := config.Save(cfg); err != nil {
+				ui.Bad.Printf("  Failed to save config: %v\n", err)
+				os.Exit(1)
+			}
+			_ = p.Apply()
+			ui.Good.Printf("  %s Theme set to %s\n", ui.StatusIcon(true), name)
+			if env := os.Getenv("PALM_THEME"); env != "" && env != name {
+				ui.Warn.Printf("  %s PALM_THEME=%s overrides it in this shell\n", ui.WarnIcon(), env)
+			}
+		},
+	}
+}
+
+func themeNewCmd() *cobra.Command {
+	var from string
+
+	cmd := &cobra.Command{
+		Use:   "new <name>",
+		Short: "Write a custom theme file to edit",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			name := args[0]
+			if _, ok := ui.Themes[name]; ok || strings.ContainsAny(name, `/\`) {
+				ui.Bad.Printf("  %q can't be a custom theme name\n", name)
+				os.Exit(1)
+			}
+			base, ok := ui.Themes[from]
+			if !ok {
+				ui.Bad.Printf("  Unknown built-in theme %q\n", from)
+				os.Exit(1)
+			}
+			path := ui.ThemeFile(themesDir(), name)
+			if _, err := os.Stat(path); err == nil {
+				ui.Warn.Printf("  %s already exists\n", path)
+				os.Exit(1)
+			}
+
+			var sb strings.Builder
+			sb.WriteString("# palm color theme. A color is a name (black, red, green, yellow, blue,\n")
+			sb.WriteString("# magenta, cyan, white, or hi- and one of those) or #rrggbb, plus any of\n")
+			sb.WriteString("# bold, dim, italic and underline. \"\" keeps the terminal's own color.\n")
+			sb.WriteString("# Roles left out come from the base theme.\n")
+			fmt.Fprintf(&sb, "base = %q\n\n", from)
+			for _, role := range []struct{ key, spec, use string }{
+				{"brand", base.Brand, "names, headings and borders"},
+				{"subtle", base.Subtle, "hints and secondary details"},
+				{"warn", base.Warn, "warnings"},
+				{"info", base.Info, "highlights"},
+				{"good", base.Good, "success"},
+				{"bad", base.Bad, "errors"},
+			} {
+				fmt.Fprintf(&sb, "%-6s = %-24q # %s\n", role.key, role.spec, role.use)
+			}
+
+			if err := os.MkdirAll(themesDir(), 0o755); err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+			if err := os.WriteFile(path, []byte(sb.String()), 0o644); err != nil {
+				ui.Bad.Printf("  Failed to write %s: %v\n", path, err)
+				os.Exit(1)
+			}
+			ui.Good.Printf("  %s Wrote %s\n", ui.StatusIcon(true), path)
+			fmt.Printf("  Edit it, then: %s\n", ui.Brand.Sprintf("palm theme use %s", name))
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", ui.DefaultTheme, "Built-in theme to start from")
+	return cmd
+}
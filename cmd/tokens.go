@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
+	"github.com/msalah0e/palm/internal/models"
 	"github.com/msalah0e/palm/internal/tokens"
 	"github.com/msalah0e/palm/internal/ui"
 	"github.com/spf13/cobra"
@@ -22,84 +25,185 @@ func tokensCmd() *cobra.Command {
 		tokensCountCmd(),
 		tokensBudgetCmd(),
 		tokensTopCmd(),
+		tokensFetchCmd(),
 	)
 
 	return cmd
 }
 
 func tokensCountCmd() *cobra.Command {
-	var jsonOutput bool
+	var (
+		tokenizer string
+	)
 
 	cmd := &cobra.Command{
-		Use:   "count <file|dir>",
-		Short: "Count tokens in files or directories",
-		Args:  cobra.ExactArgs(1),
+		Use:   "count <file|dir|glob>...",
+		Short: "Count tokens and check which models the selection fits",
+		Long: `Count tokens in files, directories or glob patterns ("**" matches any depth)
+and report whether the selection fits each known model's context window.
+
+Counts use tiktoken-compatible BPE (cl100k_base, o200k_base) once the
+vocabularies are downloaded with 'palm tokens fetch'; until then they are estimated.
+
+Examples:
+  palm tokens count .
+  palm tokens count 'cmd/**/*.go' README.md
+  palm tokens count src --tokenizer o200k_base --json`,
+		Args: cobra.MinimumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			target := args[0]
-			info, err := os.Stat(target)
-			if err != nil {
-				ui.Bad.Printf("  %v\n", err)
+			if _, ok := tokens.Encodings[tokenizer]; !ok && tokenizer != "estimate" {
+				ui.Bad.Printf("  Unknown tokenizer %q (use cl100k_base, o200k_base or estimate)\n", tokenizer)
 				os.Exit(1)
 			}
 
-			if !info.IsDir() {
-				fr, err := tokens.CountFile(target)
+			// One scan per encoding: the file breakdown uses --tokenizer, and
+			// each model is measured with its own encoding when available.
+			scans := make(map[string]*tokens.ScanResult)
+			scan := func(name string) *tokens.ScanResult {
+				if r, ok := scans[name]; ok {
+					return r
+				}
+				t := tokens.Estimator
+				if name != "estimate" {
+					t = tokens.LoadTokenizer(name)
+				}
+				r, err := tokens.Scan(args, t)
 				if err != nil {
 					ui.Bad.Printf("  %v\n", err)
 					os.Exit(1)
 				}
-				if jsonOutput {
-					data, _ := json.MarshalIndent(fr, "", "  ")
-					fmt.Println(string(data))
-					return
-				}
-				fmt.Printf("  %s  %s tokens (%d lines, %d bytes)\n",
-					ui.Brand.Sprint(target), tokens.FormatTokens(fr.Tokens), fr.Lines, fr.Bytes)
-				return
+				scans[name] = r
+				return r
 			}
 
-			result, err := tokens.ScanDir(target)
-			if err != nil {
-				ui.Bad.Printf("  Scan failed: %v\n", err)
+			result := scan(tokenizer)
+			if len(result.Files) == 0 {
+				ui.Warn.Println("  No text files matched")
 				os.Exit(1)
 			}
+			fits := modelFits(scan)
 
 			if jsonOutput {
-				data, _ := json.MarshalIndent(result, "", "  ")
+				data, _ := json.MarshalIndent(struct {
+					*tokens.ScanResult
+					Models []tokens.ModelFit `json:"models"`
+				}{result, fits}, "", "  ")
 				fmt.Println(string(data))
 				return
 			}
 
 			ui.Banner("token count")
-			fmt.Printf("  %s  %s\n", ui.Brand.Sprintf("%-12s", "Directory"), target)
+			fmt.Printf("  %s  %s\n", ui.Brand.Sprintf("%-12s", "Selection"), strings.Join(args, " "))
 			fmt.Printf("  %s  %d\n", ui.Brand.Sprintf("%-12s", "Files"), len(result.Files))
-			fmt.Printf("  %s  %s\n", ui.Brand.Sprintf("%-12s", "Tokens"), tokens.FormatTokens(result.Total))
+			fmt.Printf("  %s  %s (%s)\n", ui.Brand.Sprintf("%-12s", "Tokens"), tokens.FormatTokens(result.Total), result.Tokenizer)
 			fmt.Printf("  %s  %d\n", ui.Brand.Sprintf("%-12s", "Lines"), result.TotalLines)
 			fmt.Printf("  %s  %.1f KB\n", ui.Brand.Sprintf("%-12s", "Size"), float64(result.TotalBytes)/1024)
 
-			// Top 10 files
+			if len(result.Files) > 1 {
+				// Top 10 files
+				fmt.Println()
+				fmt.Println("  Top files by token count:")
+				limit := min(10, len(result.Files))
+				for i := 0; i < limit; i++ {
+					f := result.Files[i]
+					pct := float64(f.Tokens) / float64(max(result.Total, 1)) * 100
+					fmt.Printf("  %s  %-6s  %5.1f%%  %s\n",
+						ui.Subtle.Sprintf("%2d.", i+1),
+						tokens.FormatTokens(f.Tokens),
+						pct,
+						f.Path)
+				}
+			}
+
 			fmt.Println()
-			fmt.Println("  Top files by token count:")
-			limit := 10
-			if len(result.Files) < limit {
-				limit = len(result.Files)
+			var rows [][]string
+			estimated, approximated := result.Tokenizer == "estimate" && tokenizer != "estimate", false
+			for _, f := range fits {
+				counted := f.Tokenizer
+				switch {
+				case f.Tokenizer == "estimate":
+					estimated = true
+				case !f.Exact:
+					counted += " ≈"
+					approximated = true
+				}
+				rows = append(rows, []string{
+					ui.StatusIcon(f.Fits), f.Model, f.Provider, tokens.FormatTokens(f.Context),
+					tokens.FormatTokens(f.Tokens), fmt.Sprintf("%.1f%%", f.Percent), counted,
+				})
 			}
-			for i := 0; i < limit; i++ {
-				f := result.Files[i]
-				pct := float64(f.Tokens) / float64(result.Total) * 100
-				fmt.Printf("  %s  %-6s  %5.1f%%  %s\n",
-					ui.Subtle.Sprintf("%2d.", i+1),
-					tokens.FormatTokens(f.Tokens),
-					pct,
-					f.Path)
+			ui.Table([]string{"", "Model", "Provider", "Context", "Tokens", "Used", "Tokenizer"}, rows)
+
+			if estimated {
+				fmt.Printf("\n  %s\n", ui.Subtle.Sprint("Estimated counts — run 'palm tokens fetch' for exact BPE counts"))
+			}
+			if approximated {
+				fmt.Printf("\n  %s\n", ui.Subtle.Sprint("≈ model has no public tokenizer; measured with cl100k_base"))
 			}
 		},
 	}
 
-	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON")
+	cmd.Flags().StringVar(&tokenizer, "tokenizer", "cl100k_base", "Tokenizer for the file breakdown: cl100k_base, o200k_base or estimate")
 	return cmd
 }
 
+// modelFits measures the selection against every chat model's context
+// window, using each model's encoding.
+func modelFits(scan func(encoding string) *tokens.ScanResult) []tokens.ModelFit {
+	var fits []tokens.ModelFit
+	for _, m := range models.AllModels() {
+		if m.Type != "chat" || m.Context == 0 {
+			continue
+		}
+		enc, exact := tokens.EncodingForModel(m.Provider, m.ID)
+		r := scan(enc)
+		fits = append(fits, tokens.ModelFit{
+			Model:     m.ID,
+			Provider:  m.Provider,
+			Context:   m.Context,
+			Tokens:    r.Total,
+			Percent:   float64(r.Total) / float64(m.Context) * 100,
+			Fits:      r.Total <= m.Context,
+			Tokenizer: r.Tokenizer,
+			Exact:     exact && r.Tokenizer == enc,
+		})
+	}
+	sort.SliceStable(fits, func(i, j int) bool {
+		if fits[i].Context != fits[j].Context {
+			return fits[i].Context < fits[j].Context
+		}
+		return fits[i].Model < fits[j].Model
+	})
+	return fits
+}
+
+func tokensFetchCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "fetch [encoding...]",
+		Short: "Download BPE vocabularies for exact token counts",
+		Long: `Download tiktoken vocabularies (cl100k_base, o200k_base) into the palm cache
+so token counts are exact instead of estimated. Fetches both when none are named.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				args = []string{"cl100k_base", "o200k_base"}
+			}
+			failed := false
+			for _, name := range args {
+				fmt.Printf("  Fetching %s... ", name)
+				if err := tokens.FetchEncoding(name); err != nil {
+					ui.Bad.Printf("failed: %v\n", err)
+					failed = true
+					continue
+				}
+				ui.Good.Println("done")
+			}
+			if failed {
+				os.Exit(1)
+			}
+		},
+	}
+}
+
 func tokensBudgetCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "budget [dir]",
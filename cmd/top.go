@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
@@ -12,19 +13,63 @@ import (
 
 func topCmd() *cobra.Command {
 	var interval int
+	var once bool
+	var serveAddr string
+	var record bool
+	var historySize int
 
 	cmd := &cobra.Command{
 		Use:     "top",
 		Aliases: []string{"monitor", "htop"},
 		Short:   "Live monitor for running AI tool processes",
-		Long:    ui.Brand.Sprint(ui.Palm+" palm top") + " \u2014 htop-like dashboard for AI tools",
+		Long: ui.Brand.Sprint(ui.Palm+" palm top") + ` \u2014 htop-like dashboard for AI tools
+
+For scripts and monitoring, --once scans a single time and prints the
+result (as JSON with --json), and --serve exposes the same data over HTTP:
+JSON at / and Prometheus metrics at /metrics.
+
+  palm top --once --json
+  palm top --serve :9912            # 127.0.0.1:9912
+  palm top --serve 0.0.0.0:9912     # every interface
+
+--record keeps every refresh (or the --once scan) in a history file, and
+palm top report summarizes it per tool:
+
+  palm top --record --interval 5
+  palm top report --since 2h`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			reg := loadRegistry()
 			known := buildKnownBinaries(reg)
 
+			if serveAddr != "" {
+				if record {
+					return fmt.Errorf("--record works with the live view or --once, not --serve")
+				}
+				return serveTop(serveAddr, known, time.Duration(interval)*time.Second)
+			}
+
+			var rec *top.Recorder
+			if record {
+				var err error
+				if rec, err = top.NewRecorder(top.HistoryPath(), historySize); err != nil {
+					return fmt.Errorf("open top history: %w", err)
+				}
+			}
+			if once || jsonOutput {
+				s := top.Collect(known)
+				if rec != nil {
+					if err := rec.Add(top.SampleOf(s)); err != nil {
+						return fmt.Errorf("record sample: %w", err)
+					}
+				}
+				printTopSnapshot(s)
+				return nil
+			}
+
 			cfg := top.Config{
 				RefreshInterval: time.Duration(interval) * time.Second,
 				KnownBinaries:  known,
+				Recorder:       rec,
 			}
 
 			return top.Run(cfg)
@@ -32,6 +77,12 @@ func topCmd() *cobra.Command {
 	}
 
 	cmd.Flags().IntVar(&interval, "interval", 1, "Refresh interval in seconds")
+	cmd.Flags().BoolVar(&once, "once", false, "Scan once, print and exit")
+	cmd.Flags().StringVar(&serveAddr, "serve", "", "Serve JSON and Prometheus metrics over HTTP on this address (e.g. :9912)")
+	cmd.Flags().BoolVar(&record, "record", false, "Append each scan to the history for palm top report")
+	cmd.Flags().IntVar(&historySize, "history-size", top.DefaultHistorySize, "Samples the history keeps before dropping the oldest")
+
+	cmd.AddCommand(topReportCmd())
 
 	return cmd
 }
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/msalah0e/palm/internal/gpu"
+	"github.com/msalah0e/palm/internal/top"
+	"github.com/msalah0e/palm/internal/ui"
+)
+
+// printTopSnapshot prints a single scan: JSON under --json, a table otherwise.
+func printTopSnapshot(s top.Snapshot) {
+	render(s, func() {
+		ui.Banner("top")
+		sys := s.System
+		fmt.Printf("  CPU %.1f%% of %d cores · Memory %s / %s (%.1f%%)\n",
+			sys.CPUPercent, sys.CPUCores, gpu.FormatMB(int(sys.MemUsedMB)), gpu.FormatMB(int(sys.MemTotalMB)), sys.MemPercent)
+		for _, g := range sys.GPUs {
+			line := fmt.Sprintf("  GPU %d %s %s", g.Index, g.Vendor, g.Model)
+			if g.VRAMUsedMB != nil {
+				line += fmt.Sprintf(" · %d%% · %d / %d MB", *g.UtilizationPct, *g.VRAMUsedMB, g.VRAMTotalMB)
+			}
+			fmt.Println(line)
+		}
+		fmt.Println()
+
+		if len(s.Processes) == 0 {
+			fmt.Println("  No AI tool processes running")
+			return
+		}
+		var rows [][]string
+		for _, p := range s.Processes {
+			rows = append(rows, []string{
+				strconv.Itoa(p.PID),
+				p.Name,
+				fmt.Sprintf("%.1f", p.CPU),
+				fmt.Sprintf("%.0f MB", p.MemMB),
+				p.Cmd,
+			})
+		}
+		ui.Table([]string{"PID", "Tool", "CPU%", "Memory", "Command"}, rows)
+	})
+}
+
+// serveTop serves top's data over HTTP until interrupted. An address
+// without a host, like ":9912", listens on 127.0.0.1 only: process command
+// lines can hold secrets, so other machines need an explicit host.
+func serveTop(addr string, known map[string]string, maxAge time.Duration) error {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid --serve address %q: %w", addr, err)
+	}
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	addr = net.JoinHostPort(host, port)
+
+	ui.Banner("top")
+	fmt.Printf("  Serving on http://%s\n", addr)
+	fmt.Printf("    %s  JSON snapshot\n", ui.Info.Sprint("/        "))
+	fmt.Printf("    %s  Prometheus metrics\n", ui.Info.Sprint("/metrics "))
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		ui.Warn.Printf("  %s Reachable from other machines, and command lines may include secrets\n", ui.WarnIcon())
+	}
+	fmt.Println()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return top.Serve(ctx, addr, known, maxAge)
+}
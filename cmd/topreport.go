@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/msalah0e/palm/internal/gpu"
+	"github.com/msalah0e/palm/internal/top"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+func topReportCmd() *cobra.Command {
+	var since string
+
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Summarize recorded CPU, memory and GPU use per AI tool",
+		Long: `Summarize what palm top --record captured over a window: system CPU,
+memory and GPU load, and each AI tool's average and peak CPU, memory and GPU
+memory, busiest first. Averages cover the samples a tool was running in.
+
+  palm top --record --interval 5    # in another terminal, during the run
+  palm top report --since 2h
+
+GPU memory per tool is only known for NVIDIA cards.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			from, err := parseSince(since)
+			if err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+			samples, err := top.LoadHistory(top.HistoryPath(), from)
+			if err != nil {
+				ui.Bad.Printf("  Failed to read top history: %v\n", err)
+				os.Exit(1)
+			}
+			r := top.Summarize(samples)
+
+			render(r, func() {
+				ui.Banner("top report")
+				if r.Samples == 0 {
+					fmt.Printf("  No samples in the last %s\n", since)
+					fmt.Println("  Record some with `palm top --record`")
+					return
+				}
+				fmt.Printf("  %s – %s · %d samples\n\n", r.From.Format("Jan 2 15:04"), r.To.Format("Jan 2 15:04"), r.Samples)
+				fmt.Printf("  CPU     avg %5.1f%%   max %5.1f%%\n", r.CPU.Avg, r.CPU.Max)
+				fmt.Printf("  Memory  avg %s   max %s\n", gpu.FormatMB(int(r.MemMB.Avg)), gpu.FormatMB(int(r.MemMB.Max)))
+				for _, g := range r.GPUs {
+					fmt.Printf("  GPU %d   avg %5.1f%%   max %5.1f%% · VRAM max %s\n",
+						g.Index, g.Utilization.Avg, g.Utilization.Max, gpu.FormatMB(int(g.VRAMMB.Max)))
+				}
+				fmt.Println()
+
+				if len(r.Tools) == 0 {
+					fmt.Println("  No AI tool processes were running")
+					return
+				}
+				var rows [][]string
+				for _, t := range r.Tools {
+					vram := "-"
+					if t.VRAMMB.Max > 0 {
+						vram = gpu.FormatMB(int(t.VRAMMB.Max))
+					}
+					rows = append(rows, []string{
+						t.Tool,
+						fmt.Sprintf("%.0f%%", t.ActivePct),
+						fmt.Sprintf("%d", t.MaxProcs),
+						fmt.Sprintf("%.1f", t.CPU.Avg),
+						fmt.Sprintf("%.1f", t.CPU.Max),
+						gpu.FormatMB(int(t.RSSMB.Avg)),
+						gpu.FormatMB(int(t.RSSMB.Max)),
+						vram,
+						t.PeakAt.Format("15:04:05"),
+					})
+				}
+				ui.Table([]string{"Tool", "Active", "Procs", "CPU% avg", "CPU% max", "Mem avg", "Mem max", "VRAM max", "Peak at"}, rows)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "1h", "Window to summarize (e.g. 30m, 2h, 1d)")
+	return cmd
+}
@@ -7,7 +7,6 @@ import (
 	"strings"
 
 	"github.com/BurntSushi/toml"
-	"github.com/msalah0e/palm/internal/registry"
 	"github.com/msalah0e/palm/internal/ui"
 	"github.com/msalah0e/palm/internal/vault"
 	"github.com/spf13/cobra"
@@ -69,12 +68,12 @@ with bubbletea will be added in a future release.
 				var installedTools []string
 				var missingTools []string
 
+				detected := detectTools(reg, p.Tools)
 				for _, toolName := range p.Tools {
-					tool := reg.Get(toolName)
-					if tool == nil {
+					dt, ok := detected[toolName]
+					if !ok {
 						continue
 					}
-					dt := registry.DetectOne(*tool)
 					if dt.Installed {
 						installedTools = append(installedTools, toolName)
 					} else {
@@ -120,9 +119,7 @@ with bubbletea will be added in a future release.
 
 func printUIHeader() {
 	fmt.Println()
-	fmt.Println(ui.Brand.Sprint("  ┌─────────────────────────────────────────────────┐"))
-	fmt.Println(ui.Brand.Sprint("  │") + "  🌴 " + ui.Brand.Sprint("palm ui") + " — project navigator              " + ui.Brand.Sprint("│"))
-	fmt.Println(ui.Brand.Sprint("  └─────────────────────────────────────────────────┘"))
+	ui.Box(ui.BoxSingle, "🌴 "+ui.Brand.Sprint("palm ui")+" — project navigator", nil)
 	fmt.Println()
 }
 
@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/msalah0e/palm/internal/hooks"
 	"github.com/msalah0e/palm/internal/installer"
@@ -13,7 +14,7 @@ import (
 )
 
 func updateCmd() *cobra.Command {
-	var all bool
+	var all, dryRun bool
 
 	cmd := &cobra.Command{
 		Use:     "update [tool]",
@@ -23,6 +24,18 @@ func updateCmd() *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			reg := loadRegistry()
 
+			if dryRun && (len(args) == 1 || all) {
+				names := args
+				if all {
+					names = nil
+					for _, dt := range registry.DetectInstalled(reg) {
+						names = append(names, dt.Tool.Name)
+					}
+				}
+				printPlans(installer.ActionUpdate, planTools(reg, names, installer.ActionUpdate))
+				return
+			}
+
 			if len(args) == 1 {
 				updateOne(reg, args[0])
 				return
@@ -38,6 +51,7 @@ func updateCmd() *cobra.Command {
 	}
 
 	cmd.Flags().BoolVar(&all, "all", false, "Update all installed tools")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show the commands that would run without executing them")
 	return cmd
 }
 
@@ -54,7 +68,10 @@ func updateOne(reg *registry.Registry, name string) {
 
 	_ = hooks.Run("pre_update", tool.Name, tool.Category)
 
-	if err := installer.Update(*tool); err != nil {
+	start := time.Now()
+	err := installer.Update(*tool)
+	logToolChange("update", tool, start, err)
+	if err != nil {
 		ui.Bad.Printf("\n  Update failed: %v\n", err)
 		os.Exit(1)
 	}
@@ -71,9 +88,12 @@ func updateOne(reg *registry.Registry, name string) {
 }
 
 func updateAll(reg *registry.Registry) {
-	detected := registry.DetectInstalled(reg)
-
 	ui.Banner("updating all tools")
+	updateTools(registry.DetectInstalled(reg))
+}
+
+// updateTools updates each tool in turn, carrying on past failures.
+func updateTools(detected []registry.DetectedTool) {
 
 	if len(detected) == 0 {
 		fmt.Println("  No tools installed to update.")
@@ -88,7 +108,10 @@ func updateAll(reg *registry.Registry) {
 
 		_ = hooks.Run("pre_update", dt.Tool.Name, dt.Tool.Category)
 
-		if err := installer.Update(dt.Tool); err != nil {
+		start := time.Now()
+		err := installer.Update(dt.Tool)
+		logToolChange("update", &dt.Tool, start, err)
+		if err != nil {
 			ui.Bad.Printf("failed: %v\n", err)
 			failed++
 		} else {
@@ -4,10 +4,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/BurntSushi/toml"
-	"github.com/msalah0e/palm/internal/registry"
 	"github.com/msalah0e/palm/internal/ui"
 	"github.com/spf13/cobra"
 )
@@ -16,11 +16,41 @@ import (
 type WorkspaceConfig struct {
 	Name  string   `toml:"name"`
 	Tools []string `toml:"tools"`
-	Keys  []string `toml:"keys"`
+	Keys  []string `toml:"keys"` // when set, the only vault keys tools here receive
+	// Profile is the vault key profile tools here use, unless --profile or
+	// PALM_PROFILE picks another.
+	Profile string `toml:"profile"`
 }
 
 type palmProject struct {
-	Workspace WorkspaceConfig `toml:"workspace"`
+	Workspace WorkspaceConfig   `toml:"workspace"`
+	Env       map[string]string `toml:"env"` // static vars for tools run in the workspace
+}
+
+// allowsKey reports whether a vault key may be injected into tools run in
+// this workspace. Outside a workspace, or with no keys listed, all may.
+func (p *palmProject) allowsKey(key string) bool {
+	return p == nil || len(p.Workspace.Keys) == 0 || containsStr(p.Workspace.Keys, key)
+}
+
+// applyEnv adds the workspace's [env] vars to env. Values may reference
+// other variables as $VAR or ${VAR}; variables already set in the shell win.
+func (p *palmProject) applyEnv(env []string) []string {
+	if p == nil || len(p.Env) == 0 {
+		return env
+	}
+	names := make([]string, 0, len(p.Env))
+	for name := range p.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if os.Getenv(name) != "" {
+			continue
+		}
+		env = append(env, name+"="+os.ExpandEnv(p.Env[name]))
+	}
+	return env
 }
 
 func workspaceCmd() *cobra.Command {
@@ -36,6 +66,7 @@ func workspaceCmd() *cobra.Command {
 		workspaceStatusCmd(),
 		workspaceAddCmd(),
 		workspaceRemoveCmd(),
+		workspaceBootstrapCmd(),
 	)
 
 	return cmd
@@ -62,8 +93,13 @@ func workspaceInitCmd() *cobra.Command {
 [workspace]
 name = %q
 tools = []
+# Only these vault keys are injected into tools run here (all when empty)
 keys = []
 
+# Extra environment for tools run here; $VAR references are expanded
+[env]
+# OLLAMA_HOST = "http://localhost:11434"
+
 [parallel]
 concurrency = 4
 `, name)
@@ -100,6 +136,7 @@ func workspaceInstallCmd() *cobra.Command {
 			ui.Banner(fmt.Sprintf("workspace install — %s", ws.Name))
 
 			success, failed := 0, 0
+			detected := detectTools(reg, ws.Tools)
 			for _, name := range ws.Tools {
 				tool := reg.Get(name)
 				if tool == nil {
@@ -109,7 +146,7 @@ func workspaceInstallCmd() *cobra.Command {
 				}
 
 				// Check if already installed
-				dt := registry.DetectOne(*tool)
+				dt := detected[name]
 				if dt.Installed {
 					ui.Good.Printf("  %s %s already installed (%s)\n", ui.StatusIcon(true), tool.DisplayName, dt.Version)
 					success++
@@ -156,14 +193,14 @@ func workspaceStatusCmd() *cobra.Command {
 			headers := []string{"Tool", "Status", "Version"}
 			var rows [][]string
 
+			detected := detectTools(reg, ws.Tools)
 			for _, name := range ws.Tools {
-				tool := reg.Get(name)
-				if tool == nil {
+				if reg.Get(name) == nil {
 					rows = append(rows, []string{name, ui.StatusIcon(false) + " unknown", "-"})
 					continue
 				}
 
-				dt := registry.DetectOne(*tool)
+				dt := detected[name]
 				if dt.Installed {
 					ver := dt.Version
 					if ver == "" {
@@ -178,7 +215,15 @@ func workspaceStatusCmd() *cobra.Command {
 			ui.Table(headers, rows)
 
 			if len(ws.Keys) > 0 {
-				fmt.Printf("\n  Required keys: %s\n", strings.Join(ws.Keys, ", "))
+				fmt.Printf("\n  Keys injected: %s %s\n", strings.Join(ws.Keys, ", "), ui.Subtle.Sprint("(other vault keys are withheld)"))
+			}
+			if proj, _ := loadProject(); proj != nil && len(proj.Env) > 0 {
+				names := make([]string, 0, len(proj.Env))
+				for name := range proj.Env {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				fmt.Printf("  Environment:   %s\n", strings.Join(names, ", "))
 			}
 		},
 	}
@@ -274,6 +319,16 @@ func loadWorkspace() *WorkspaceConfig {
 }
 
 func loadWorkspaceWithPath() (*WorkspaceConfig, string) {
+	proj, path := loadProject()
+	if proj == nil {
+		return nil, ""
+	}
+	return &proj.Workspace, path
+}
+
+// loadProject finds the nearest .palm.toml walking up from the current
+// directory. It returns nil outside a workspace.
+func loadProject() (*palmProject, string) {
 	dir, err := os.Getwd()
 	if err != nil {
 		return nil, ""
@@ -289,7 +344,7 @@ func loadWorkspaceWithPath() (*WorkspaceConfig, string) {
 			if err := toml.Unmarshal(data, &proj); err != nil {
 				return nil, ""
 			}
-			return &proj.Workspace, path
+			return &proj, path
 		}
 		parent := filepath.Dir(dir)
 		if parent == dir {
@@ -0,0 +1,406 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/msalah0e/palm/internal/mcp"
+	"github.com/msalah0e/palm/internal/registry"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/msalah0e/palm/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+// readinessCheck is one line of the bootstrap's final report.
+type readinessCheck struct {
+	name   string
+	ok     bool
+	detail string
+	fix    string // what to run when it isn't ok
+}
+
+func workspaceBootstrapCmd() *cobra.Command {
+	var assumeYes, noPrompt bool
+
+	cmd := &cobra.Command{
+		Use:   "bootstrap",
+		Short: "Set up this project in one go: tools, keys, rules and MCP servers",
+		Long: `Onboard onto a project from its .palm.toml and .palm-team.json:
+
+  1. install the pinned workspace and team tools that are missing
+  2. ask for the required API keys that aren't in the vault or environment
+  3. generate the rules files of those tools from .palm-rules.md plus the
+     team's rules (.palm-rules.md is created when the project has none)
+  4. add the team's MCP servers to the tools' MCP configs
+  5. print a readiness report
+
+It runs in the directory holding .palm.toml (or .palm-team.json), is safe
+to re-run, and exits with status 1 while anything is left to do.
+
+Examples:
+  palm workspace bootstrap
+  palm workspace bootstrap --yes --no-prompt   # CI: never ask`,
+		Run: func(cmd *cobra.Command, args []string) {
+			proj, projPath := loadProject()
+			tc, _ := loadTeamConfig()
+			if proj == nil && tc == nil {
+				ui.Bad.Println("  No .palm.toml or .palm-team.json found")
+				fmt.Println("  Run `palm workspace init` or `palm team init` first")
+				os.Exit(1)
+			}
+
+			root, name := ".", ""
+			if tc != nil {
+				name = tc.Name
+				if tc.Dir == "" {
+					root = filepath.Dir(tc.Source)
+				}
+			}
+			if proj != nil {
+				root = filepath.Dir(projPath)
+				if proj.Workspace.Name != "" {
+					name = proj.Workspace.Name
+				}
+			}
+			if err := os.Chdir(root); err != nil {
+				ui.Bad.Printf("  %v\n", err)
+				os.Exit(1)
+			}
+
+			reg := loadRegistry()
+			tools := bootstrapTools(proj, tc)
+			ui.Banner(fmt.Sprintf("workspace bootstrap — %s", name))
+
+			var checks []readinessCheck
+			bootstrapStep(1, "Tools")
+			checks = append(checks, bootstrapInstall(reg, tools, assumeYes))
+			bootstrapStep(2, "API keys")
+			checks = append(checks, bootstrapKeys(reg, tools, proj, !noPrompt)...)
+			bootstrapStep(3, "Rules")
+			checks = append(checks, bootstrapRules(tools, tc))
+			if tc != nil && len(tc.MCP) > 0 {
+				bootstrapStep(4, "MCP servers")
+				checks = append(checks, bootstrapMCP(tools, tc.MCP))
+			}
+
+			fmt.Println()
+			var rows [][]string
+			ready := true
+			for _, c := range checks {
+				status := ui.StatusIcon(true) + " ready"
+				if !c.ok {
+					status = ui.WarnIcon() + " todo"
+					ready = false
+				}
+				rows = append(rows, []string{c.name, status, c.detail})
+			}
+			ui.Table([]string{"Check", "Status", "Detail"}, rows)
+			fmt.Println()
+
+			if ready {
+				ui.Good.Printf("  %s %s is ready\n", ui.StatusIcon(true), name)
+				if len(tools) > 0 {
+					fmt.Printf("  Start with: palm run %s\n", tools[0])
+				}
+				return
+			}
+			for _, c := range checks {
+				if !c.ok && c.fix != "" {
+					fmt.Printf("  %s %s\n", ui.Subtle.Sprintf("%-10s", c.name), c.fix)
+				}
+			}
+			os.Exit(1)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Install missing prerequisites and run setup steps without asking")
+	cmd.Flags().BoolVar(&noPrompt, "no-prompt", false, "Don't ask for missing keys, only report them")
+	return cmd
+}
+
+func bootstrapStep(n int, title string) {
+	if n > 1 {
+		fmt.Println()
+	}
+	fmt.Printf("  %s\n", ui.Brand.Sprintf("%d. %s", n, title))
+}
+
+// bootstrapTools returns the workspace's pinned tools followed by the
+// team's, without duplicates.
+func bootstrapTools(proj *palmProject, tc *teamConfig) []string {
+	var tools []string
+	if proj != nil {
+		tools = append(tools, proj.Workspace.Tools...)
+	}
+	if tc != nil {
+		tools = append(tools, tc.Tools...)
+	}
+	var unique []string
+	for _, t := range tools {
+		if !containsStr(unique, t) {
+			unique = append(unique, t)
+		}
+	}
+	return unique
+}
+
+func bootstrapInstall(reg *registry.Registry, names []string, assumeYes bool) readinessCheck {
+	check := readinessCheck{name: "Tools"}
+	if len(names) == 0 {
+		fmt.Println("  No tools pinned")
+		check.ok, check.detail = true, "none pinned"
+		return check
+	}
+
+	var missing []*registry.Tool
+	var unknown []string
+	detected := detectTools(reg, names)
+	for _, name := range names {
+		tool := reg.Get(name)
+		if tool == nil {
+			ui.Warn.Printf("  %s unknown tool %q\n", ui.WarnIcon(), name)
+			unknown = append(unknown, name)
+			continue
+		}
+		if dt := detected[name]; dt.Installed {
+			fmt.Printf("  %s %s %s\n", ui.StatusIcon(true), tool.DisplayName, ui.Subtle.Sprint(dt.Version))
+			continue
+		}
+		missing = append(missing, tool)
+	}
+
+	var failed []string
+	blocked, done := ensurePrerequisites(reg, missing, assumeYes)
+	for _, tool := range missing {
+		if done[tool.Name] {
+			continue
+		}
+		if reason, ok := blocked[tool.Name]; ok {
+			ui.Bad.Printf("  %s %s: %s\n", ui.StatusIcon(false), tool.DisplayName, reason)
+			failed = append(failed, tool.Name)
+			continue
+		}
+		if err := doInstall(tool); err != nil {
+			ui.Bad.Printf("  %s %s: %v\n", ui.StatusIcon(false), tool.DisplayName, err)
+			failed = append(failed, tool.Name)
+			continue
+		}
+		ui.Good.Printf("  %s %s installed\n", ui.StatusIcon(true), tool.DisplayName)
+		runSetupSteps(tool, assumeYes)
+	}
+
+	ready := len(names) - len(unknown) - len(failed)
+	check.ok = len(unknown)+len(failed) == 0
+	check.detail = fmt.Sprintf("%d/%d installed", ready, len(names))
+	if len(failed) > 0 {
+		check.detail += " · failed: " + strings.Join(failed, ", ")
+		check.fix = "palm install " + strings.Join(failed, " ")
+	}
+	if len(unknown) > 0 {
+		check.detail += " · unknown: " + strings.Join(unknown, ", ")
+		if len(failed) == 0 {
+			check.fix = "remove unknown tools with `palm workspace remove <tool>`"
+		}
+	}
+	return check
+}
+
+// bootstrapKeys asks for the tools' required keys that are neither in the
+// vault nor the environment. Keys the workspace withholds from its tools
+// are reported too.
+func bootstrapKeys(reg *registry.Registry, tools []string, proj *palmProject, prompt bool) []readinessCheck {
+	var required []string
+	for _, name := range tools {
+		if t := reg.Get(name); t != nil {
+			for _, k := range t.Keys.Required {
+				if !containsStr(required, k) {
+					required = append(required, k)
+				}
+			}
+		}
+	}
+	sort.Strings(required)
+	check := readinessCheck{name: "API keys", ok: true, detail: "none required"}
+	if len(required) == 0 {
+		fmt.Println("  No API keys required")
+		return []readinessCheck{check}
+	}
+
+	v := vault.New()
+	var missing, withheld []string
+	for _, k := range required {
+		if !proj.allowsKey(k) {
+			withheld = append(withheld, k)
+		}
+		if os.Getenv(k) != "" {
+			fmt.Printf("  %s %s %s\n", ui.StatusIcon(true), k, ui.Subtle.Sprint("(environment)"))
+			continue
+		}
+		if val, err := v.Get(k); err == nil && val != "" {
+			fmt.Printf("  %s %s %s\n", ui.StatusIcon(true), k, ui.Subtle.Sprint(vault.Mask(val)))
+			continue
+		}
+		if !prompt {
+			ui.Warn.Printf("  %s %s missing\n", ui.WarnIcon(), k)
+			missing = append(missing, k)
+			continue
+		}
+
+		fmt.Printf("  %s\n", ui.Brand.Sprint(k))
+		value := promptKey(k, false)
+		if value == "" {
+			ui.Warn.Printf("    %s Skipped\n", ui.WarnIcon())
+			missing = append(missing, k)
+			continue
+		}
+		if err := v.Set(k, value); err != nil {
+			ui.Bad.Printf("    Failed to store key: %v\n", err)
+			missing = append(missing, k)
+			continue
+		}
+		_ = vault.RecordSet(k)
+		ui.Good.Printf("    %s Stored in vault\n", ui.StatusIcon(true))
+	}
+
+	check.ok = len(missing) == 0
+	check.detail = fmt.Sprintf("%d/%d available", len(required)-len(missing), len(required))
+	if !check.ok {
+		check.detail += " · missing: " + strings.Join(missing, ", ")
+		check.fix = "palm keys add " + missing[0]
+	}
+	checks := []readinessCheck{check}
+	if len(withheld) > 0 {
+		checks = append(checks, readinessCheck{
+			name:   "Key scope",
+			detail: "not in .palm.toml keys: " + strings.Join(withheld, ", "),
+			fix:    "add them to keys in .palm.toml, or empty the list",
+		})
+	}
+	return checks
+}
+
+// bootstrapRules writes the rules files of the tools that have one, from
+// .palm-rules.md plus any team rules it doesn't already state.
+func bootstrapRules(tools []string, tc *teamConfig) readinessCheck {
+	check := readinessCheck{name: "Rules", fix: "palm rules init"}
+	var teamRules []string
+	if tc != nil {
+		teamRules = tc.Rules
+	}
+
+	source := findRulesSource()
+	var content string
+	if source == "" {
+		if len(teamRules) == 0 {
+			ui.Warn.Printf("  %s No .palm-rules.md and no team rules\n", ui.WarnIcon())
+			check.detail = "no rules source"
+			return check
+		}
+		source = ".palm-rules.md"
+		content = "# Project Rules\n\n" +
+			"<!-- palm rules — edit this file and run `palm rules sync` -->\n" +
+			"<!-- This is your single source of truth for all AI tool instructions -->\n"
+		if err := os.WriteFile(source, []byte(bootstrapRulesContent(content, teamRules)), 0o644); err != nil {
+			ui.Bad.Printf("  %s %s: %v\n", ui.StatusIcon(false), source, err)
+			check.detail = err.Error()
+			return check
+		}
+		ui.Good.Printf("  %s Created %s from the team rules\n", ui.StatusIcon(true), source)
+	}
+	data, err := os.ReadFile(source)
+	if err != nil {
+		ui.Bad.Printf("  Failed to read %s: %v\n", source, err)
+		check.detail = err.Error()
+		return check
+	}
+	content = bootstrapRulesContent(string(data), teamRules)
+
+	targets := make(map[string]string)
+	for _, t := range tools {
+		if f, ok := ruleFiles[t]; ok {
+			targets[t] = f
+		}
+	}
+	if len(targets) == 0 {
+		fmt.Println("  None of the tools has a rules file")
+		check.ok, check.detail = true, "nothing to generate"
+		return check
+	}
+
+	synced := writeRuleFiles(content, targets, newRuleFitter(source))
+	check.ok = synced == len(targets)
+	check.detail = fmt.Sprintf("%d/%d files from %s", synced, len(targets), source)
+	check.fix = "palm rules sync"
+	return check
+}
+
+// bootstrapRulesContent appends the team rules that content doesn't
+// already mention.
+func bootstrapRulesContent(content string, teamRules []string) string {
+	var missing []string
+	for _, r := range teamRules {
+		if !strings.Contains(content, r) {
+			missing = append(missing, r)
+		}
+	}
+	if len(missing) == 0 {
+		return content
+	}
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(content, "\n"))
+	b.WriteString("\n\n## Team Rules\n\n")
+	for _, r := range missing {
+		b.WriteString("- " + r + "\n")
+	}
+	return b.String()
+}
+
+// bootstrapMCP adds the team's MCP servers to the MCP config of each tool
+// that has one.
+func bootstrapMCP(tools, names []string) readinessCheck {
+	check := readinessCheck{name: "MCP", fix: "check the server names with `palm mcp list`"}
+
+	var servers []mcp.Server
+	var unknown []string
+	for _, name := range names {
+		if s := mcp.GetServer(name); s != nil {
+			servers = append(servers, *s)
+		} else {
+			ui.Warn.Printf("  %s unknown MCP server %q\n", ui.WarnIcon(), name)
+			unknown = append(unknown, name)
+		}
+	}
+
+	configured, failed := 0, 0
+	for _, t := range tools {
+		tc, ok := mcp.ConfigFor(t)
+		if !ok {
+			continue
+		}
+		added, err := mcp.AddServers(tc, servers)
+		if err != nil {
+			ui.Bad.Printf("  %s %s: %v\n", ui.StatusIcon(false), t, err)
+			failed++
+			continue
+		}
+		detail := "already configured"
+		if len(added) > 0 {
+			detail = "added " + strings.Join(added, ", ")
+		}
+		ui.Good.Printf("  %s %s → %s %s\n", ui.StatusIcon(true), t, tc.Path, ui.Subtle.Sprint(detail))
+		configured++
+	}
+	if configured+failed == 0 {
+		fmt.Println("  None of the tools has an MCP config palm manages")
+	}
+
+	check.ok = len(unknown) == 0 && failed == 0
+	check.detail = fmt.Sprintf("%d server(s) in %d tool config(s)", len(servers), configured)
+	if len(unknown) > 0 {
+		check.detail += " · unknown: " + strings.Join(unknown, ", ")
+	}
+	return check
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestBootstrapTools(t *testing.T) {
+	proj := &palmProject{Workspace: WorkspaceConfig{Tools: []string{"aider", "claude-code"}}}
+	tc := &teamConfig{Tools: []string{"claude-code", "cursor"}}
+
+	got := strings.Join(bootstrapTools(proj, tc), ",")
+	if got != "aider,claude-code,cursor" {
+		t.Errorf("bootstrapTools = %s", got)
+	}
+	if got := bootstrapTools(nil, tc); len(got) != 2 {
+		t.Errorf("team only = %v", got)
+	}
+}
+
+func TestBootstrapRulesContent(t *testing.T) {
+	base := "# Project Rules\n\n- Write tests for new functionality\n"
+	got := bootstrapRulesContent(base, []string{"Write tests for new functionality", "No force pushes"})
+	if !strings.HasPrefix(got, base[:len(base)-1]) || strings.Count(got, "Write tests") != 1 {
+		t.Errorf("rules already stated should not repeat:\n%s", got)
+	}
+	if !strings.HasSuffix(got, "## Team Rules\n\n- No force pushes\n") {
+		t.Errorf("missing team rule section:\n%s", got)
+	}
+	if got := bootstrapRulesContent(base, nil); got != base {
+		t.Errorf("content without team rules changed:\n%s", got)
+	}
+}
+
+func TestBootstrapRules(t *testing.T) {
+	wd, _ := os.Getwd()
+	_ = os.Chdir(t.TempDir())
+	defer os.Chdir(wd)
+
+	if c := bootstrapRules([]string{"claude-code"}, nil); c.ok {
+		t.Errorf("no rules source should need work: %+v", c)
+	}
+
+	tc := &teamConfig{Rules: []string{"Keep changes small"}}
+	c := bootstrapRules([]string{"claude-code", "aider-unknown"}, tc)
+	if !c.ok {
+		t.Fatalf("bootstrapRules: %+v", c)
+	}
+	for _, file := range []string{".palm-rules.md", "CLAUDE.md"} {
+		data, err := os.ReadFile(file)
+		if err != nil || !strings.Contains(string(data), "- Keep changes small") {
+			t.Errorf("%s: %v\n%s", file, err, data)
+		}
+	}
+}
@@ -24,6 +24,7 @@ branch without disturbing your current work.
 Examples:
   palm worktree add feature-auth             # Create worktree for branch
   palm worktree list                         # List active worktrees
+  palm worktree status                       # Ahead/behind, changes and AI tools per worktree
   palm worktree run feature-auth aider       # Run tool in worktree
   palm worktree remove feature-auth          # Clean up worktree`,
 	}
@@ -31,6 +32,7 @@ Examples:
 	cmd.AddCommand(
 		worktreeAddCmd(),
 		worktreeListCmd(),
+		worktreeStatusCmd(),
 		worktreeRemoveCmd(),
 		worktreeRunCmd(),
 	)
@@ -38,6 +40,72 @@ Examples:
 	return cmd
 }
 
+// gitWorktree is one entry of git worktree list --porcelain.
+type gitWorktree struct {
+	path   string
+	branch string // empty when detached or bare
+	bare   bool
+}
+
+// label names the worktree by its branch.
+func (t gitWorktree) label() string {
+	switch {
+	case t.branch != "":
+		return t.branch
+	case t.bare:
+		return "(bare)"
+	}
+	return "(detached)"
+}
+
+// listWorktrees returns the worktrees of the current repository, the main
+// one first.
+func listWorktrees() ([]gitWorktree, error) {
+	out, err := exec.Command("git", "worktree", "list", "--porcelain").Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseWorktrees(string(out)), nil
+}
+
+func parseWorktrees(porcelain string) []gitWorktree {
+	var trees []gitWorktree
+	var current gitWorktree
+
+	for _, line := range strings.Split(porcelain, "\n") {
+		if strings.HasPrefix(line, "worktree ") {
+			if current.path != "" {
+				trees = append(trees, current)
+			}
+			current = gitWorktree{path: strings.TrimPrefix(line, "worktree ")}
+		} else if strings.HasPrefix(line, "branch ") {
+			ref := strings.TrimPrefix(line, "branch ")
+			current.branch = strings.TrimPrefix(ref, "refs/heads/")
+		} else if line == "bare" {
+			current.bare = true
+		}
+	}
+	if current.path != "" {
+		trees = append(trees, current)
+	}
+	return trees
+}
+
+// worktreePath returns the path of the worktree checked out on branch, or
+// "" when there is none.
+func worktreePath(branch string) (string, error) {
+	trees, err := listWorktrees()
+	if err != nil {
+		return "", err
+	}
+	for _, t := range trees {
+		if t.branch == branch {
+			return t.path, nil
+		}
+	}
+	return "", nil
+}
+
 func worktreeAddCmd() *cobra.Command {
 	var path string
 
@@ -97,39 +165,12 @@ func worktreeListCmd() *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			ui.Banner("worktrees")
 
-			out, err := exec.Command("git", "worktree", "list", "--porcelain").Output()
+			trees, err := listWorktrees()
 			if err != nil {
 				ui.Bad.Printf("  Failed to list worktrees: %v\n", err)
 				os.Exit(1)
 			}
 
-			// Parse porcelain output
-			type worktree struct {
-				path   string
-				branch string
-				bare   bool
-			}
-
-			var trees []worktree
-			var current worktree
-
-			for _, line := range strings.Split(string(out), "\n") {
-				if strings.HasPrefix(line, "worktree ") {
-					if current.path != "" {
-						trees = append(trees, current)
-					}
-					current = worktree{path: strings.TrimPrefix(line, "worktree ")}
-				} else if strings.HasPrefix(line, "branch ") {
-					ref := strings.TrimPrefix(line, "branch ")
-					current.branch = strings.TrimPrefix(ref, "refs/heads/")
-				} else if line == "bare" {
-					current.bare = true
-				}
-			}
-			if current.path != "" {
-				trees = append(trees, current)
-			}
-
 			if len(trees) == 0 {
 				fmt.Println("  No worktrees found")
 				return
@@ -139,11 +180,7 @@ func worktreeListCmd() *cobra.Command {
 			var rows [][]string
 
 			for _, t := range trees {
-				branch := t.branch
-				if branch == "" && t.bare {
-					branch = "(bare)"
-				}
-				rows = append(rows, []string{branch, t.path})
+				rows = append(rows, []string{t.label(), t.path})
 			}
 
 			ui.Table(headers, rows)
@@ -164,23 +201,11 @@ func worktreeRemoveCmd() *cobra.Command {
 			branch := args[0]
 
 			// Find the worktree path for this branch
-			out, err := exec.Command("git", "worktree", "list", "--porcelain").Output()
+			targetPath, err := worktreePath(branch)
 			if err != nil {
 				ui.Bad.Printf("  Failed to list worktrees: %v\n", err)
 				os.Exit(1)
 			}
-
-			var targetPath string
-			var currentPath string
-
-			for _, line := range strings.Split(string(out), "\n") {
-				if strings.HasPrefix(line, "worktree ") {
-					currentPath = strings.TrimPrefix(line, "worktree ")
-				} else if strings.HasPrefix(line, "branch refs/heads/"+branch) {
-					targetPath = currentPath
-				}
-			}
-
 			if targetPath == "" {
 				ui.Bad.Printf("  No worktree found for branch %q\n", branch)
 				os.Exit(1)
@@ -211,38 +236,36 @@ func worktreeRemoveCmd() *cobra.Command {
 
 func worktreeRunCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:   "run <branch> <tool> [args...]",
+		Use:   "run [--all-keys] <branch> <tool> [args...]",
 		Short: "Run an AI tool inside a worktree",
 		Long: `Run an AI tool in the context of a specific worktree.
-Vault keys are automatically injected.
+The vault keys the tool needs are automatically injected; --all-keys,
+given before the branch, injects every key.
 
   palm worktree run feature-auth aider "add login form"
   palm worktree run fix-bug claude-code`,
 		Args:               cobra.MinimumNArgs(2),
 		DisableFlagParsing: true,
 		Run: func(cmd *cobra.Command, args []string) {
+			allKeys := false
+			for len(args) > 0 && args[0] == "--all-keys" {
+				allKeys = true
+				args = args[1:]
+			}
+			if len(args) < 2 {
+				ui.Bad.Println("  Usage: palm worktree run [--all-keys] <branch> <tool> [args...]")
+				os.Exit(1)
+			}
 			branch := args[0]
 			toolName := args[1]
 			toolArgs := args[2:]
 
 			// Find the worktree path
-			out, err := exec.Command("git", "worktree", "list", "--porcelain").Output()
+			targetPath, err := worktreePath(branch)
 			if err != nil {
 				ui.Bad.Printf("  Failed to list worktrees: %v\n", err)
 				os.Exit(1)
 			}
-
-			var targetPath string
-			var currentPath string
-
-			for _, line := range strings.Split(string(out), "\n") {
-				if strings.HasPrefix(line, "worktree ") {
-					currentPath = strings.TrimPrefix(line, "worktree ")
-				} else if strings.HasPrefix(line, "branch refs/heads/"+branch) {
-					targetPath = currentPath
-				}
-			}
-
 			if targetPath == "" {
 				ui.Bad.Printf("  No worktree found for branch %q\n", branch)
 				fmt.Printf("  Create one first: palm worktree add %s\n", branch)
@@ -267,19 +290,7 @@ Vault keys are automatically injected.
 				os.Exit(1)
 			}
 
-			// Build env with vault keys
-			env := os.Environ()
-			v := vault.New()
-			if tool != nil {
-				allKeys := append(tool.Keys.Required, tool.Keys.Optional...)
-				for _, key := range allKeys {
-					if os.Getenv(key) == "" {
-						if val, err := v.Get(key); err == nil {
-							env = append(env, fmt.Sprintf("%s=%s", key, val))
-						}
-					}
-				}
-			}
+			env := buildVaultEnv(vault.New(), toolName, reg, []string{toolName}, allKeys)
 
 			fmt.Printf("  Running %s in worktree %s (%s)\n\n",
 				ui.Brand.Sprint(toolName),
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/msalah0e/palm/internal/top"
+)
+
+func TestParseWorktrees(t *testing.T) {
+	trees := parseWorktrees(`worktree /src/palm
+HEAD 1111111111111111111111111111111111111111
+branch refs/heads/main
+
+worktree /src/palm-agent
+HEAD 2222222222222222222222222222222222222222
+detached
+
+worktree /src/palm.git
+bare
+`)
+	if len(trees) != 3 || trees[0].label() != "main" || trees[1].label() != "(detached)" || trees[2].label() != "(bare)" {
+		t.Errorf("unexpected worktrees %+v", trees)
+	}
+}
+
+func TestWorktreeStatus(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	for k, v := range map[string]string{"GIT_AUTHOR_NAME": "t", "GIT_AUTHOR_EMAIL": "t@example.com", "GIT_COMMITTER_NAME": "t", "GIT_COMMITTER_EMAIL": "t@example.com"} {
+		t.Setenv(k, v)
+	}
+	root := t.TempDir()
+	repo, agent := filepath.Join(root, "repo"), filepath.Join(root, "repo-agent")
+	run := func(dir string, args ...string) {
+		t.Helper()
+		c := exec.Command("git", args...)
+		c.Dir = dir
+		if out, err := c.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(path, content string) {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_ = os.Mkdir(repo, 0o755)
+	run(repo, "init", "-q", "-b", "main")
+	write(filepath.Join(repo, "a.txt"), "one\n")
+	run(repo, "add", ".")
+	run(repo, "commit", "-qm", "first")
+	run(repo, "worktree", "add", "-q", "-b", "agent", agent)
+
+	// The agent commits once and leaves an uncommitted edit; main moves on
+	write(filepath.Join(agent, "b.txt"), "new\nfile\n")
+	run(agent, "add", ".")
+	run(agent, "commit", "-qm", "agent work")
+	write(filepath.Join(agent, "a.txt"), "changed\n")
+	write(filepath.Join(repo, "c.txt"), "main\n")
+	run(repo, "add", ".")
+	run(repo, "commit", "-qm", "main work")
+
+	c := exec.Command("git", "worktree", "list", "--porcelain")
+	c.Dir = repo
+	out, err := c.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	trees := parseWorktrees(string(out))
+	if len(trees) != 2 {
+		t.Fatalf("expected 2 worktrees, got %+v", trees)
+	}
+	mainStatus, agentStatus := inspectWorktree(trees[0], "main"), inspectWorktree(trees[1], "main")
+
+	if mainStatus.Base != "" || mainStatus.Dirty != 0 || mainStatus.LastCommit.IsZero() {
+		t.Errorf("unexpected main status %+v", mainStatus)
+	}
+	if agentStatus.Base != "main" || agentStatus.Ahead != 1 || agentStatus.Behind != 1 || agentStatus.Dirty != 1 {
+		t.Errorf("unexpected agent sync %+v", agentStatus)
+	}
+	if agentStatus.Files != 2 || agentStatus.Insertions != 3 || agentStatus.Deletions != 1 {
+		t.Errorf("diffstat should count committed and uncommitted work since main: %+v", agentStatus)
+	}
+
+	statuses := []worktreeStatus{mainStatus, agentStatus}
+	cwds := map[int]string{1: filepath.Join(trees[1].path, "src"), 2: "/elsewhere"}
+	attachWorktreeProcesses(statuses, []top.ProcessInfo{{PID: 1, Name: "Aider"}, {PID: 2, Name: "Goose"}}, func(pid int) string { return cwds[pid] })
+	if len(statuses[0].Processes) != 0 || len(statuses[1].Processes) != 1 || statuses[1].Processes[0].Tool != "Aider" {
+		t.Errorf("processes should go to the worktree they run in: %+v", statuses)
+	}
+	if got := strings.Join([]string{shortAge(90e9), shortAge(5 * 3600e9), shortAge(72 * 3600e9)}, ","); got != "1m,5h,3d" {
+		t.Errorf("shortAge = %s", got)
+	}
+}
@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/msalah0e/palm/internal/top"
+	"github.com/msalah0e/palm/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// worktreeProcess is an AI tool process running inside a worktree.
+type worktreeProcess struct {
+	PID  int    `json:"pid"`
+	Tool string `json:"tool"`
+}
+
+// worktreeStatus is one row of palm worktree status.
+type worktreeStatus struct {
+	Branch     string            `json:"branch"`
+	Path       string            `json:"path"`
+	Base       string            `json:"base,omitempty"` // what ahead and behind count against
+	Ahead      int               `json:"ahead"`
+	Behind     int               `json:"behind"`
+	Dirty      int               `json:"dirty_files"`
+	LastCommit time.Time         `json:"last_commit,omitzero"`
+	Files      int               `json:"files_changed"` // since the base, uncommitted work included
+	Insertions int               `json:"insertions"`
+	Deletions  int               `json:"deletions"`
+	Processes  []worktreeProcess `json:"ai_processes,omitempty"`
+}
+
+func worktreeStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show each worktree's progress, changes and running AI tools",
+		Long: `Show every worktree of the repository at a glance: commits ahead of and
+behind its base, files with uncommitted changes, the age of its last
+commit, the AI tools running inside it (found the way palm top finds them)
+and a diffstat of everything changed since the base.
+
+The base is the branch's upstream, or else the main worktree's branch, so
+agent branches made with palm worktree add compare against where they
+started.
+
+  palm worktree status
+  palm worktree status --json`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			trees, err := listWorktrees()
+			if err != nil {
+				ui.Bad.Printf("  Failed to list worktrees: %v\n", err)
+				os.Exit(1)
+			}
+
+			mainBranch := ""
+			if len(trees) > 0 {
+				mainBranch = trees[0].branch
+			}
+			var statuses []worktreeStatus
+			for _, t := range trees {
+				if t.bare {
+					continue
+				}
+				statuses = append(statuses, inspectWorktree(t, mainBranch))
+			}
+			attachWorktreeProcesses(statuses, top.Scan(buildKnownBinaries(loadRegistry())), top.ProcessCwd)
+
+			render(statuses, func() {
+				ui.Banner("worktree status")
+				if len(statuses) == 0 {
+					fmt.Println("  No worktrees found")
+					return
+				}
+				printWorktreeStatuses(statuses)
+			})
+		},
+	}
+}
+
+// inspectWorktree gathers the git state of t. Queries that fail, such as
+// ahead/behind on a branch without a base, leave their fields zero.
+func inspectWorktree(t gitWorktree, mainBranch string) worktreeStatus {
+	s := worktreeStatus{Branch: t.label(), Path: t.path}
+	git := func(args ...string) (string, error) {
+		return gitOutput(append([]string{"-C", t.path}, args...)...)
+	}
+
+	if up, err := git("rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}"); err == nil && up != "" {
+		s.Base = up
+	} else if mainBranch != "" && t.branch != mainBranch {
+		s.Base = mainBranch
+	}
+
+	if s.Base != "" {
+		if out, err := git("rev-list", "--left-right", "--count", s.Base+"...HEAD"); err == nil {
+			if f := strings.Fields(out); len(f) == 2 {
+				s.Behind, _ = strconv.Atoi(f[0])
+				s.Ahead, _ = strconv.Atoi(f[1])
+			}
+		}
+	}
+
+	if out, err := git("status", "--porcelain"); err == nil && out != "" {
+		s.Dirty = len(strings.Split(out, "\n"))
+	}
+	if out, err := git("log", "-1", "--format=%ct"); err == nil {
+		if sec, err := strconv.ParseInt(out, 10, 64); err == nil {
+			s.LastCommit = time.Unix(sec, 0)
+		}
+	}
+
+	// Diff the working tree against where the branch left its base, so
+	// committed and uncommitted work both count
+	since := "HEAD"
+	if s.Base != "" {
+		if fork, err := git("merge-base", s.Base, "HEAD"); err == nil && fork != "" {
+			since = fork
+		}
+	}
+	if out, err := git("diff", "--shortstat", since); err == nil {
+		s.Files, s.Insertions, s.Deletions = parseShortstat(out)
+	}
+	return s
+}
+
+var shortstatRe = regexp.MustCompile(`(\d+) (file|insertion|deletion)`)
+
+// parseShortstat reads git diff --shortstat, e.g. "3 files changed,
+// 20 insertions(+), 4 deletions(-)".
+func parseShortstat(out string) (files, insertions, deletions int) {
+	for _, m := range shortstatRe.FindAllStringSubmatch(out, -1) {
+		n, _ := strconv.Atoi(m[1])
+		switch m[2] {
+		case "file":
+			files = n
+		case "insertion":
+			insertions = n
+		case "deletion":
+			deletions = n
+		}
+	}
+	return files, insertions, deletions
+}
+
+// attachWorktreeProcesses assigns each process to the worktree its working
+// directory is in. Worktrees can nest, so the deepest match wins.
+func attachWorktreeProcesses(statuses []worktreeStatus, procs []top.ProcessInfo, cwd func(int) string) {
+	for _, p := range procs {
+		dir := cwd(p.PID)
+		if dir == "" {
+			continue
+		}
+		best := -1
+		for i, s := range statuses {
+			if dir == s.Path || strings.HasPrefix(dir, s.Path+string(filepath.Separator)) {
+				if best < 0 || len(s.Path) > len(statuses[best].Path) {
+					best = i
+				}
+			}
+		}
+		if best >= 0 {
+			statuses[best].Processes = append(statuses[best].Processes, worktreeProcess{PID: p.PID, Tool: p.Name})
+		}
+	}
+}
+
+func printWorktreeStatuses(statuses []worktreeStatus) {
+	home, _ := os.UserHomeDir()
+	var rows [][]string
+	running, dirty := 0, 0
+	for _, s := range statuses {
+		sync := "-"
+		if s.Base != "" {
+			sync = fmt.Sprintf("↑%d ↓%d", s.Ahead, s.Behind)
+			if s.Behind > 0 {
+				sync = ui.Warn.Sprint(sync)
+			}
+			sync += " " + ui.Subtle.Sprint(s.Base)
+		}
+
+		changed := "-"
+		if s.Dirty > 0 {
+			changed = ui.Warn.Sprint(strconv.Itoa(s.Dirty))
+			dirty++
+		}
+
+		age := "-"
+		if !s.LastCommit.IsZero() {
+			age = shortAge(time.Since(s.LastCommit)) + " ago"
+		}
+
+		ai := ui.Subtle.Sprint("-")
+		if len(s.Processes) > 0 {
+			var tools []string
+			for _, p := range s.Processes {
+				tools = appendUnique(tools, p.Tool)
+			}
+			ai = ui.Good.Sprint("● " + strings.Join(tools, ", "))
+			running++
+		}
+
+		diff := "-"
+		if s.Files > 0 {
+			diff = fmt.Sprintf("%d files %s %s", s.Files, ui.Good.Sprintf("+%d", s.Insertions), ui.Bad.Sprintf("−%d", s.Deletions))
+		}
+
+		path := s.Path
+		if home != "" {
+			if rest, ok := strings.CutPrefix(path, home); ok {
+				path = "~" + rest
+			}
+		}
+		rows = append(rows, []string{ui.Brand.Sprint(s.Branch), sync, changed, age, ai, diff, ui.Subtle.Sprint(path)})
+	}
+
+	ui.Table([]string{"BRANCH", "AHEAD/BEHIND", "DIRTY", "LAST COMMIT", "AI", "CHANGES", "PATH"}, rows)
+	fmt.Printf("\n  %d worktrees · %d with AI tools running · %d with uncommitted changes\n", len(statuses), running, dirty)
+}
+
+// shortAge formats d as whole minutes, hours or days.
+func shortAge(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
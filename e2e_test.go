@@ -8,6 +8,9 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/msalah0e/palm/internal/mocktools"
 )
 
 var palmBin string
@@ -31,6 +34,13 @@ func TestMain(m *testing.M) {
 
 // runPalm executes the palm binary with an isolated HOME directory.
 func runPalm(t *testing.T, args ...string) (stdout, stderr string, exitCode int) {
+	t.Helper()
+	return runPalmEnv(t, nil, args...)
+}
+
+// runPalmEnv is runPalm with extra environment variables, which win over
+// the inherited ones.
+func runPalmEnv(t *testing.T, env []string, args ...string) (stdout, stderr string, exitCode int) {
 	t.Helper()
 	cmd := exec.Command(palmBin, args...)
 	home := t.TempDir()
@@ -39,6 +49,7 @@ func runPalm(t *testing.T, args ...string) (stdout, stderr string, exitCode int)
 		"XDG_CONFIG_HOME="+filepath.Join(home, ".config"),
 		"NO_COLOR=1",
 	)
+	cmd.Env = append(cmd.Env, env...)
 
 	var outBuf, errBuf strings.Builder
 	cmd.Stdout = &outBuf
@@ -329,3 +340,138 @@ func TestE2E_Actlog(t *testing.T) {
 		t.Fatalf("expected exit 0, got %d", code)
 	}
 }
+
+// --- Workflows against mock tools ---
+
+// withMockTools installs mock tools and returns the environment that puts
+// them first on PATH, and their directory for mocktools.Calls.
+func withMockTools(t *testing.T, specs ...mocktools.Spec) (env []string, dir string) {
+	t.Helper()
+	dir = t.TempDir()
+	if err := mocktools.Install(dir, specs); err != nil {
+		t.Skipf("mock tools unavailable: %v", err)
+	}
+	return []string{"PATH=" + mocktools.PathWith(dir, os.Getenv("PATH"))}, dir
+}
+
+func mockCalls(t *testing.T, dir string) []mocktools.Call {
+	t.Helper()
+	calls, err := mocktools.Calls(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return calls
+}
+
+func TestE2E_SquadRaceMockTools(t *testing.T) {
+	slow := mocktools.Default("aider")
+	slow.Latency = 500 * time.Millisecond
+	env, dir := withMockTools(t, mocktools.Default("ollama"), slow)
+
+	out, _, code := runPalmEnv(t, env, "squad", "fix the bug", "--tools", "ollama,aider", "--mode", "race", "--offline")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, out)
+	}
+	if !strings.Contains(out, "Winner: Ollama") || !strings.Contains(out, "ollama: fix the bug") {
+		t.Errorf("expected ollama to win with its answer, got %q", out)
+	}
+	if calls := mockCalls(t, dir); len(calls) != 2 {
+		t.Errorf("expected both tools to be called, got %+v", calls)
+	}
+}
+
+func TestE2E_SquadRaceSkipsFailedTool(t *testing.T) {
+	broken := mocktools.Default("ollama")
+	broken.ExitCode = 1
+	env, _ := withMockTools(t, broken, mocktools.Default("aider"))
+
+	out, _, _ := runPalmEnv(t, env, "squad", "fix the bug", "--tools", "ollama,aider", "--mode", "race", "--offline")
+	if !strings.Contains(out, "Winner: Aider") {
+		t.Errorf("a failing tool must not win the race, got %q", out)
+	}
+}
+
+func TestE2E_SpeedtestMockTools(t *testing.T) {
+	env, dir := withMockTools(t, mocktools.Default("ollama"), mocktools.Default("aider"))
+
+	out, _, code := runPalmEnv(t, env, "speedtest", "hello", "--tools", "ollama,aider", "--offline")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, out)
+	}
+	if strings.Count(out, "ok") < 2 {
+		t.Errorf("expected both tools to succeed, got %q", out)
+	}
+	calls := mockCalls(t, dir)
+	if len(calls) != 2 || calls[0].Args != "run llama3.3 hello" || calls[1].Args != "--message hello" {
+		t.Errorf("unexpected calls %+v", calls)
+	}
+}
+
+func TestE2E_EvalMockTools(t *testing.T) {
+	judge := mocktools.Default("ollama")
+	judge.Output = "ACCURACY: 90\nHALLUCINATION: 0\nCOMPLETENESS: 80\nCLARITY: 70\nVERDICT: mock verdict"
+	env, dir := withMockTools(t, judge, mocktools.Default("aider"))
+
+	out, _, code := runPalmEnv(t, env, "eval", "What is 2+2?", "--tools", "ollama,aider", "--judge", "ollama", "--offline")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, out)
+	}
+	if !strings.Contains(out, "mock verdict") {
+		t.Errorf("expected the judge's verdict, got %q", out)
+	}
+	// Two answers, then one judgement per answer
+	if calls := mockCalls(t, dir); len(calls) != 4 {
+		t.Errorf("expected 4 calls, got %+v", calls)
+	}
+}
+
+func TestE2E_ComposeMockTools(t *testing.T) {
+	env, dir := withMockTools(t, mocktools.Default("ollama"), mocktools.Default("aider"))
+	workflow := filepath.Join(t.TempDir(), "flow.toml")
+	err := os.WriteFile(workflow, []byte(`[workflow]
+name = "mock"
+
+[[steps]]
+name = "draft"
+tool = "ollama"
+prompt = "draft a plan"
+
+[[steps]]
+name = "apply"
+tool = "aider"
+prompt = "apply the plan:"
+input = "step:draft"
+depends_on = ["draft"]
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, _, code := runPalmEnv(t, env, "compose", "--file", workflow, "--yes", "--offline")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, out)
+	}
+	calls := mockCalls(t, dir)
+	if len(calls) != 2 || calls[0].Tool != "ollama" || calls[1].Tool != "aider" {
+		t.Fatalf("expected ollama then aider, got %+v", calls)
+	}
+	if !strings.Contains(calls[1].Input, "ollama: draft a plan") {
+		t.Errorf("the apply step should get the draft's output, got %q", calls[1].Input)
+	}
+}
+
+func TestE2E_DevMockTools(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mocks")
+	out, _, code := runPalm(t, "dev", "mock-tools", "--dir", dir, "--exit", "aider=2", "--offline")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, out)
+	}
+	for _, name := range []string{"ollama", "aider"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected a %s mock: %v", name, err)
+		}
+	}
+	if err := exec.Command(filepath.Join(dir, "aider"), "hi").Run(); err == nil {
+		t.Error("expected the aider mock to exit 2")
+	}
+}
@@ -1,11 +1,16 @@
 package activity
 
 import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 )
 
@@ -17,6 +22,14 @@ type Entry struct {
 	Details   string    `json:"details,omitempty"`
 	Cost      float64   `json:"cost,omitempty"`
 	Duration  float64   `json:"duration,omitempty"`
+	Status    string    `json:"status,omitempty"` // "ok" or "failed"
+	Value     float64   `json:"value,omitempty"`  // action-specific metric (tok/s, eval score)
+
+	// Tamper evidence: each entry hashes its own fields together with the
+	// previous entry's hash, so edits, insertions and removals break the chain.
+	Seq  int64  `json:"seq,omitempty"`
+	Prev string `json:"prev,omitempty"`
+	Hash string `json:"hash,omitempty"`
 }
 
 func logPath() string {
@@ -52,21 +65,211 @@ func LogWithCost(action, tool, details string, cost, duration float64) error {
 	return append_entry(entry)
 }
 
+// Append writes a fully populated entry, stamping the time if unset.
+func Append(entry Entry) error {
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+	return append_entry(entry)
+}
+
+// StatusOf maps a success flag to the Status field value.
+func StatusOf(ok bool) string {
+	if ok {
+		return "ok"
+	}
+	return "failed"
+}
+
 func append_entry(entry Entry) error {
 	path := logPath()
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return err
 	}
-	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	unlock := lock(path)
+	defer unlock()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0o644)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
+
+	if last, ok := lastEntry(f); ok {
+		entry.Seq = last.Seq + 1
+		entry.Prev = last.Hash
+	} else {
+		entry.Seq = 1
+	}
+	entry.Hash = hashEntry(entry)
+
 	data, _ := json.Marshal(entry)
 	_, err = fmt.Fprintf(f, "%s\n", data)
 	return err
 }
 
+// hashEntry is the SHA-256 of the entry's JSON with Hash left empty. Prev is
+// part of the JSON, which is what links the chain.
+func hashEntry(e Entry) string {
+	e.Hash = ""
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// lastEntry decodes the final line of the log.
+func lastEntry(f *os.File) (Entry, bool) {
+	info, err := f.Stat()
+	if err != nil || info.Size() == 0 {
+		return Entry{}, false
+	}
+	// Entries are small; the tail of the file holds the last one
+	size := min(info.Size(), 64*1024)
+	buf := make([]byte, size)
+	if _, err := f.ReadAt(buf, info.Size()-size); err != nil && err != io.EOF {
+		return Entry{}, false
+	}
+	lines := strings.Split(strings.TrimRight(string(buf), "\n"), "\n")
+	var e Entry
+	if json.Unmarshal([]byte(lines[len(lines)-1]), &e) != nil {
+		return Entry{}, false
+	}
+	return e, true
+}
+
+// lock serializes appends across palm processes so the chain stays linear.
+// A lock older than ten seconds is assumed abandoned; if the lock can't be
+// taken the entry is appended anyway rather than lost.
+func lock(path string) (unlock func()) {
+	lockPath := path + ".lock"
+	for i := 0; i < 50; i++ {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lockPath) }
+		}
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > 10*time.Second {
+			os.Remove(lockPath)
+			continue
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return func() {}
+}
+
+// VerifyResult describes the integrity of the log.
+type VerifyResult struct {
+	Entries int    // chained entries checked
+	Legacy  int    // entries written before hashing was added
+	Line    int    // first bad line, 0 when intact
+	Problem string // what is wrong at Line
+}
+
+// OK reports whether the chain is intact.
+func (r *VerifyResult) OK() bool {
+	return r.Line == 0
+}
+
+// Verify walks the log in file order and checks every entry's hash and link.
+// Unhashed entries are accepted only before the chain starts. Removing
+// entries from the end of the log cannot be detected.
+func Verify() (*VerifyResult, error) {
+	res := &VerifyResult{}
+	f, err := os.Open(logPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return res, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	prev := ""
+	chained := false
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		fail := func(problem string) (*VerifyResult, error) {
+			res.Line, res.Problem = n, problem
+			return res, nil
+		}
+		var e Entry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			return fail("entry is not valid JSON")
+		}
+		switch {
+		case e.Hash == "" && !chained:
+			res.Legacy++
+			continue
+		case e.Hash == "":
+			return fail("unhashed entry inside the chain (inserted)")
+		case hashEntry(e) != e.Hash:
+			return fail(fmt.Sprintf("entry %d was modified", e.Seq))
+		case e.Prev != prev:
+			if !chained {
+				return fail("earlier entries were removed")
+			}
+			return fail(fmt.Sprintf("chain broken before entry %d (removed or reordered)", e.Seq))
+		}
+		chained = true
+		prev = e.Hash
+		res.Entries++
+	}
+	return res, sc.Err()
+}
+
+// Filter selects entries for Query. Zero fields match everything.
+type Filter struct {
+	Since  time.Time
+	Types  []string // actions; "graph" also matches "graph.add" etc.
+	Tool   string   // case-insensitive substring
+	Status string
+}
+
+// Match reports whether e passes the filter.
+func (f Filter) Match(e Entry) bool {
+	if !f.Since.IsZero() && e.Timestamp.Before(f.Since) {
+		return false
+	}
+	if f.Tool != "" && !contains(e.Tool, f.Tool) {
+		return false
+	}
+	if f.Status != "" && !strings.EqualFold(e.Status, f.Status) {
+		return false
+	}
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, t := range f.Types {
+		if e.Action == t || strings.HasPrefix(e.Action, t+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// Query returns up to count matching entries, newest first.
+func Query(f Filter, count int) ([]Entry, error) {
+	all, err := Read(0)
+	if err != nil {
+		return nil, err
+	}
+	var out []Entry
+	for _, e := range all {
+		if f.Match(e) {
+			out = append(out, e)
+			if count > 0 && len(out) >= count {
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
 // Read returns the last N entries from the log.
 func Read(count int) ([]Entry, error) {
 	data, err := os.ReadFile(logPath())
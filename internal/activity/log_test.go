@@ -0,0 +1,108 @@
+package activity
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppendChainsEntries(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	for _, a := range []string{"install", "run", "graph.add"} {
+		if err := Append(Entry{Action: a, Tool: "aider"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := Read(0)
+	if err != nil || len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d (%v)", len(entries), err)
+	}
+	// Newest first: seq 3 links to seq 2
+	byseq := map[int64]Entry{}
+	for _, e := range entries {
+		byseq[e.Seq] = e
+	}
+	if byseq[1].Prev != "" || byseq[2].Prev != byseq[1].Hash || byseq[3].Prev != byseq[2].Hash {
+		t.Errorf("entries are not chained: %+v", entries)
+	}
+
+	res, err := Verify()
+	if err != nil || !res.OK() || res.Entries != 3 {
+		t.Errorf("expected intact chain of 3, got %+v (%v)", res, err)
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	tamper := func(t *testing.T, edit func(lines []string) []string) *VerifyResult {
+		t.Helper()
+		t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+		for _, a := range []string{"a", "b", "c"} {
+			_ = Append(Entry{Action: a})
+		}
+		data, _ := os.ReadFile(logPath())
+		lines := edit(strings.Split(strings.TrimSpace(string(data)), "\n"))
+		_ = os.WriteFile(logPath(), []byte(strings.Join(lines, "\n")+"\n"), 0o644)
+		res, err := Verify()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	edited := tamper(t, func(l []string) []string {
+		l[1] = strings.Replace(l[1], `"action":"b"`, `"action":"x"`, 1)
+		return l
+	})
+	if edited.OK() || edited.Line != 2 || !strings.Contains(edited.Problem, "modified") {
+		t.Errorf("edit not detected: %+v", edited)
+	}
+
+	removed := tamper(t, func(l []string) []string { return []string{l[0], l[2]} })
+	if removed.OK() || removed.Line != 2 {
+		t.Errorf("removal not detected: %+v", removed)
+	}
+
+	head := tamper(t, func(l []string) []string { return l[1:] })
+	if head.OK() || !strings.Contains(head.Problem, "earlier entries") {
+		t.Errorf("head removal not detected: %+v", head)
+	}
+}
+
+func TestVerifyAcceptsLegacyPrefix(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	_ = os.MkdirAll(strings.TrimSuffix(logPath(), "/activity.jsonl"), 0o755)
+	_ = os.WriteFile(logPath(), []byte(`{"timestamp":"2025-01-01T00:00:00Z","action":"run","tool":"old"}`+"\n"), 0o644)
+	_ = Append(Entry{Action: "run"})
+
+	res, err := Verify()
+	if err != nil || !res.OK() || res.Legacy != 1 || res.Entries != 1 {
+		t.Errorf("expected 1 legacy + 1 chained entry, got %+v (%v)", res, err)
+	}
+}
+
+func TestQueryFilters(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	now := time.Now()
+	_ = Append(Entry{Timestamp: now.Add(-48 * time.Hour), Action: "install", Tool: "aider", Status: "ok"})
+	_ = Append(Entry{Timestamp: now.Add(-time.Hour), Action: "install", Tool: "llm", Status: "failed"})
+	_ = Append(Entry{Timestamp: now.Add(-time.Minute), Action: "graph.add", Tool: "graph", Status: "ok"})
+
+	recent, _ := Query(Filter{Since: now.Add(-24 * time.Hour), Types: []string{"install"}}, 0)
+	if len(recent) != 1 || recent[0].Tool != "llm" {
+		t.Errorf("since+type filter: %+v", recent)
+	}
+	graphOnly, _ := Query(Filter{Types: []string{"graph"}}, 0)
+	if len(graphOnly) != 1 || graphOnly[0].Action != "graph.add" {
+		t.Errorf("type prefix filter: %+v", graphOnly)
+	}
+	failed, _ := Query(Filter{Status: "failed"}, 0)
+	if len(failed) != 1 {
+		t.Errorf("status filter: %+v", failed)
+	}
+	limited, _ := Query(Filter{}, 2)
+	if len(limited) != 2 || limited[0].Action != "graph.add" {
+		t.Errorf("count should keep the newest: %+v", limited)
+	}
+}
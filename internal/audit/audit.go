@@ -0,0 +1,254 @@
+// Package audit looks for the code smells AI assistants tend to leave
+// behind — dead code, duplicated blocks, swallowed errors, calls to APIs
+// that don't exist, naming that doesn't match the code around it — and
+// scores the result.
+package audit
+
+import (
+	"bufio"
+	"go/types"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Checks, in the order reports list them.
+const (
+	CheckUnknownAPI     = "unknown-api"
+	CheckSwallowedError = "swallowed-error"
+	CheckDeadCode       = "dead-code"
+	CheckDuplicate      = "duplicate"
+	CheckNaming         = "naming"
+	CheckSecret         = "secret"
+	CheckPlaceholder    = "placeholder"
+	CheckDebug          = "debug"
+	CheckStyle          = "style"
+)
+
+// Checks lists every check in report order.
+var Checks = []string{
+	CheckUnknownAPI, CheckSwallowedError, CheckDeadCode, CheckDuplicate,
+	CheckNaming, CheckSecret, CheckPlaceholder, CheckDebug, CheckStyle,
+}
+
+// Severities and what each costs the score.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+var penalty = map[string]int{SeverityError: 10, SeverityWarning: 3, SeverityInfo: 1}
+
+// Finding is one smell at a file and line.
+type Finding struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Check    string `json:"check"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// Report is the outcome of an audit.
+type Report struct {
+	Base     string         `json:"base,omitempty"` // what a diff audit compared against
+	Files    int            `json:"files"`
+	Lines    int            `json:"lines"` // lines audited: changed lines, or every line
+	Score    int            `json:"score"` // 0-100
+	Grade    string         `json:"grade"`
+	Counts   map[string]int `json:"by_check"`
+	Findings []Finding      `json:"findings"`
+}
+
+// Count returns how many findings have severity.
+func (r *Report) Count(severity string) int {
+	n := 0
+	for _, f := range r.Findings {
+		if f.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// Source is a file to audit. Changed holds the line numbers to report on;
+// nil means every line.
+type Source struct {
+	Path    string // relative to Options.Root, as reported
+	Changed map[int]bool
+}
+
+func (s Source) changed(line int) bool {
+	return s.Changed == nil || s.Changed[line]
+}
+
+// Options tune an audit.
+type Options struct {
+	Root string // directory Source paths are relative to
+	// Importer loads imported Go packages to check that the names a file
+	// uses from them exist. Nil uses the Go toolchain.
+	Importer types.ImporterFrom
+}
+
+// Extensions lists the file types audited.
+var Extensions = map[string]bool{".go": true, ".py": true, ".js": true, ".jsx": true, ".ts": true, ".tsx": true}
+
+// maxFileSize skips files too large to be hand-written.
+const maxFileSize = 512 * 1024
+
+// Analyze audits sources and scores the result.
+func Analyze(sources []Source, opts Options) *Report {
+	if opts.Importer == nil {
+		opts.Importer = newImporter()
+	}
+	r := &Report{Counts: make(map[string]int), Findings: []Finding{}}
+	var files []*file
+	for _, src := range sources {
+		if !Extensions[strings.ToLower(filepath.Ext(src.Path))] {
+			continue
+		}
+		path := filepath.Join(opts.Root, src.Path)
+		if fi, err := os.Stat(path); err != nil || fi.Size() > maxFileSize {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		f := &file{Source: src, abs: path, data: data, lines: strings.Split(string(data), "\n")}
+		files = append(files, f)
+
+		r.Files++
+		for i := range f.lines {
+			if src.changed(i + 1) {
+				r.Lines++
+			}
+		}
+	}
+
+	g := newGoChecker(opts.Importer)
+	for _, f := range files {
+		r.Findings = append(r.Findings, checkLines(f)...)
+		if f.ext() == ".go" {
+			r.Findings = append(r.Findings, g.check(f)...)
+		}
+	}
+	r.Findings = append(r.Findings, checkDuplicates(files)...)
+
+	order := make(map[string]int)
+	for i, c := range Checks {
+		order[c] = i
+	}
+	sort.SliceStable(r.Findings, func(i, j int) bool {
+		a, b := r.Findings[i], r.Findings[j]
+		if order[a.Check] != order[b.Check] {
+			return order[a.Check] < order[b.Check]
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	for _, f := range r.Findings {
+		r.Counts[f.Check]++
+	}
+	r.Score = Score(r.Findings, r.Lines)
+	r.Grade = Grade(r.Score)
+	return r
+}
+
+// Score rates findings over lines audited from 100 (nothing found) down to
+// 0. Each error costs 10 points, each warning 3 and each info 1, per 100
+// lines, so a large change isn't punished for its size alone.
+func Score(findings []Finding, lines int) int {
+	cost := 0
+	for _, f := range findings {
+		cost += penalty[f.Severity]
+	}
+	if lines < 100 {
+		lines = 100
+	}
+	score := 100 - cost*100/lines
+	if score < 0 {
+		return 0
+	}
+	return score
+}
+
+// Grade turns a score into a letter.
+func Grade(score int) string {
+	switch {
+	case score >= 90:
+		return "A"
+	case score >= 80:
+		return "B"
+	case score >= 70:
+		return "C"
+	case score >= 60:
+		return "D"
+	}
+	return "F"
+}
+
+// file is a source being audited.
+type file struct {
+	Source
+	abs   string
+	data  []byte
+	lines []string
+}
+
+func (f *file) ext() string { return strings.ToLower(filepath.Ext(f.Path)) }
+
+func (f *file) finding(line int, check, severity, msg string) Finding {
+	return Finding{File: f.Path, Line: line, Check: check, Severity: severity, Message: msg}
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// ChangedLines reads a zero-context patch (git diff -U0) and returns, for
+// each file it adds lines to, the line numbers added.
+func ChangedLines(patch string) map[string]map[int]bool {
+	changed := make(map[string]map[int]bool)
+	var current map[int]bool
+	scanner := bufio.NewScanner(strings.NewReader(patch))
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			current = nil
+		case strings.HasPrefix(line, "+++ "):
+			current = nil
+			if p := strings.TrimPrefix(line, "+++ "); p != "/dev/null" {
+				p = strings.TrimPrefix(p, "b/")
+				if changed[p] == nil {
+					changed[p] = make(map[int]bool)
+				}
+				current = changed[p]
+			}
+		case current != nil && strings.HasPrefix(line, "@@"):
+			m := hunkHeader.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			start, _ := strconv.Atoi(m[1])
+			count := 1
+			if m[2] != "" {
+				count, _ = strconv.Atoi(m[2])
+			}
+			for i := 0; i < count; i++ {
+				current[start+i] = true
+			}
+		}
+	}
+	for p, lines := range changed {
+		if len(lines) == 0 {
+			delete(changed, p)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,222 @@
+package audit
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeImporter serves packages holding just the named functions.
+type fakeImporter map[string][]string
+
+var testPackages = fakeImporter{"os": {"ReadFile", "Remove"}, "strings": {"ToUpper"}}
+
+func (f fakeImporter) Import(path string) (*types.Package, error) { return f.ImportFrom(path, "", 0) }
+
+func (f fakeImporter) ImportFrom(path, dir string, mode types.ImportMode) (*types.Package, error) {
+	funcs, ok := f[path]
+	if !ok {
+		return nil, fmt.Errorf("no package %s", path)
+	}
+	pkg := types.NewPackage(path, filepath.Base(path))
+	sig := types.NewSignatureType(nil, nil, nil, nil, nil, false)
+	for _, name := range funcs {
+		pkg.Scope().Insert(types.NewFunc(token.NoPos, pkg, name, sig))
+	}
+	return pkg, nil
+}
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// found lists findings as check:file:line.
+func found(r *Report) []string {
+	var out []string
+	for _, f := range r.Findings {
+		out = append(out, fmt.Sprintf("%s:%s:%d", f.Check, f.File, f.Line))
+	}
+	return out
+}
+
+func TestAnalyzeGo(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"load.go": `package x
+
+import (
+	"os"
+	"strings"
+)
+
+func Load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	user_name := strings.ReverseString(string(data))
+	if err := os.Remove(path); err != nil {
+	}
+	_ = os.Remove(user_name)
+	return nil
+	println("done")
+}
+
+func helper() {}
+
+func used() string { return strings.ToUpper("x") }
+
+var _ = used
+`})
+
+	r := Analyze([]Source{{Path: "load.go"}}, Options{Root: dir, Importer: testPackages})
+	want := []string{
+		"unknown-api:load.go:13",
+		"swallowed-error:load.go:10",
+		"swallowed-error:load.go:14",
+		"swallowed-error:load.go:16",
+		"dead-code:load.go:18",
+		"dead-code:load.go:21",
+		"naming:load.go:13",
+	}
+	if got := found(r); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("findings:\n got %v\nwant %v", got, want)
+	}
+	if r.Files != 1 || r.Lines != 26 || r.Score != Score(r.Findings, 26) || r.Grade != Grade(r.Score) {
+		t.Errorf("unexpected totals %+v", r)
+	}
+
+	// Only changed lines are reported
+	r = Analyze([]Source{{Path: "load.go", Changed: map[int]bool{13: true, 21: true}}}, Options{Root: dir, Importer: testPackages})
+	want = []string{"unknown-api:load.go:13", "dead-code:load.go:21", "naming:load.go:13"}
+	if got := found(r); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("changed-line findings:\n got %v\nwant %v", got, want)
+	}
+	if r.Lines != 2 {
+		t.Errorf("Lines = %d, want 2", r.Lines)
+	}
+}
+
+func TestAnalyzeScripts(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"app.py": `def load_config(path):
+    try:
+        return open(path).read()
+    except OSError:
+        pass
+
+def loadUser(user_id):
+    return user_id
+    print("unreachable")
+`,
+		"app.js": `function loadUser(userId) {
+  try {
+    fetchUser(userId);
+  } catch (e) {}
+  const user_name = "x";
+  return userId;
+  console.log("never");
+}
+`,
+	})
+	r := Analyze([]Source{{Path: "app.py"}, {Path: "app.js"}}, Options{Root: dir, Importer: testPackages})
+	want := []string{
+		"swallowed-error:app.js:4",
+		"swallowed-error:app.py:4",
+		"dead-code:app.js:7",
+		"dead-code:app.py:9",
+		"naming:app.js:5",
+		"naming:app.py:7",
+		"debug:app.js:7",
+	}
+	if got := found(r); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("findings:\n got %v\nwant %v", got, want)
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	block := `	total := 0
+	for _, item := range order.Items {
+		total += item.Price * item.Quantity
+	}
+	if order.Coupon != "" {
+		total -= discountFor(order.Coupon, total)
+	}
+	tax := total * taxRate / 100
+	return total + tax
+`
+	dir := writeFiles(t, map[string]string{
+		"a.go": "package x\n\nfunc invoiceTotal(order Order) int {\n" + block + "}\n",
+		"b.go": "package x\n\nfunc cartTotal(order Order) int {\n" + block + "}\n",
+	})
+	// Only b.go's copy is new
+	changed := make(map[int]bool)
+	for i := 1; i <= 14; i++ {
+		changed[i] = true
+	}
+	r := Analyze([]Source{{Path: "a.go", Changed: map[int]bool{}}, {Path: "b.go", Changed: changed}}, Options{Root: dir, Importer: testPackages})
+	var dups []Finding
+	for _, f := range r.Findings {
+		if f.Check == CheckDuplicate {
+			dups = append(dups, f)
+		}
+	}
+	if len(dups) != 1 || dups[0].File != "b.go" || dups[0].Line != 4 || !strings.Contains(dups[0].Message, "a.go:4-12") {
+		t.Errorf("duplicates = %+v", dups)
+	}
+}
+
+func TestChangedLines(t *testing.T) {
+	patch := `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -3 +3,2 @@ func a() {
+-	old()
++	new()
++	more()
+@@ -10,2 +11,0 @@
+-	gone()
+-	gone()
+diff --git a/new.py b/new.py
+new file mode 100644
+--- /dev/null
++++ b/new.py
+@@ -0,0 +1 @@
++print("hi")
+diff --git a/old.go b/old.go
+deleted file mode 100644
+--- a/old.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package x
+`
+	changed := ChangedLines(patch)
+	if len(changed) != 2 || len(changed["a.go"]) != 2 || !changed["a.go"][4] || !changed["new.py"][1] {
+		t.Errorf("ChangedLines = %v", changed)
+	}
+}
+
+func TestScore(t *testing.T) {
+	findings := []Finding{{Severity: SeverityError}, {Severity: SeverityWarning}, {Severity: SeverityInfo}}
+	if s := Score(findings, 10); s != 86 || Grade(s) != "B" {
+		t.Errorf("Score over a small change = %d (%s)", s, Grade(s))
+	}
+	if s := Score(findings, 1500); s != 100 {
+		t.Errorf("Score over a large change = %d", s)
+	}
+	if s := Score(make([]Finding, 20), 100); s != 100 {
+		t.Errorf("findings without a severity should cost nothing, got %d", s)
+	}
+	if Grade(0) != "F" || Grade(95) != "A" {
+		t.Error("unexpected grades")
+	}
+}
@@ -0,0 +1,116 @@
+package audit
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A duplicate is at least dupLines significant lines, and dupChars
+// characters of them, repeated verbatim apart from indentation.
+const (
+	dupLines = 6
+	dupChars = 150
+)
+
+// significant is a line worth comparing, with its line number.
+type significant struct {
+	line int
+	text string
+}
+
+// significantLines drops blank lines, comments and lone brackets, which
+// every block shares.
+func significantLines(f *file) []significant {
+	var out []significant
+	for i, line := range f.lines {
+		t := strings.TrimSpace(line)
+		if t == "" || strings.HasPrefix(t, "//") || strings.HasPrefix(t, "#") || strings.HasPrefix(t, "*") || strings.HasPrefix(t, "/*") {
+			continue
+		}
+		if strings.Trim(t, "{}()[];,") == "" || t == "} else {" {
+			continue
+		}
+		out = append(out, significant{i + 1, t})
+	}
+	return out
+}
+
+// checkDuplicates flags blocks of changed lines that repeat a block
+// elsewhere in the audited files. When both copies are new, the later one
+// is flagged.
+func checkDuplicates(files []*file) []Finding {
+	type location struct {
+		file, index int
+	}
+	sig := make([][]significant, len(files))
+	seen := make(map[string][]location)
+	for fi, f := range files {
+		sig[fi] = significantLines(f)
+		for i := 0; i+dupLines <= len(sig[fi]); i++ {
+			if key := windowKey(sig[fi][i : i+dupLines]); len(key) >= dupChars {
+				seen[key] = append(seen[key], location{fi, i})
+			}
+		}
+	}
+
+	var out []Finding
+	for fi, f := range files {
+		lines := sig[fi]
+		for i := 0; i+dupLines <= len(lines); i++ {
+			if !anyChanged(f, lines[i:i+dupLines]) {
+				continue
+			}
+			self := location{fi, i}
+			var orig location
+			found := false
+			for _, loc := range seen[windowKey(lines[i:i+dupLines])] {
+				if loc == self {
+					continue
+				}
+				later := loc.file > fi || loc.file == fi && loc.index > i
+				if later && anyChanged(files[loc.file], sig[loc.file][loc.index:loc.index+dupLines]) {
+					continue
+				}
+				orig, found = loc, true
+				break
+			}
+			if !found {
+				continue
+			}
+			// Extend the match as far as both copies agree
+			n := dupLines
+			other := sig[orig.file]
+			for i+n < len(lines) && orig.index+n < len(other) && lines[i+n].text == other[orig.index+n].text &&
+				(orig.file != fi || orig.index+n < i || orig.index > i+n) {
+				n++
+			}
+			from, to := other[orig.index].line, other[orig.index+n-1].line
+			where := fmt.Sprintf("lines %d-%d", from, to)
+			if orig.file != fi {
+				where = fmt.Sprintf("%s:%d-%d", files[orig.file].Path, from, to)
+			}
+			out = append(out, f.finding(lines[i].line, CheckDuplicate, SeverityWarning,
+				fmt.Sprintf("%d lines duplicate %s", lines[i+n-1].line-lines[i].line+1, where)))
+			i += n - 1
+		}
+	}
+	return out
+}
+
+func windowKey(lines []significant) string {
+	var b strings.Builder
+	for _, l := range lines {
+		b.WriteString(l.text)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func anyChanged(f *file, lines []significant) bool {
+	for _, l := range lines {
+		if f.changed(l.line) {
+			return true
+		}
+	}
+	return false
+}
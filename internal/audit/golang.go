@@ -0,0 +1,381 @@
+package audit
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// goChecker runs the Go checks, caching what it learns about packages
+// across the files of one audit.
+type goChecker struct {
+	importer types.ImporterFrom
+	imported map[string]*types.Package // by import path; nil when it won't load
+	idents   map[string]map[string]int // identifier uses, by package directory
+}
+
+func newGoChecker(imp types.ImporterFrom) *goChecker {
+	return &goChecker{importer: imp, imported: make(map[string]*types.Package), idents: make(map[string]map[string]int)}
+}
+
+// newImporter reads export data for the standard library and falls back
+// to type-checking source for everything else, resolving modules from
+// the working directory.
+func newImporter() types.ImporterFrom {
+	fset := token.NewFileSet()
+	return &fallbackImporter{
+		gc:     importer.ForCompiler(fset, "gc", nil).(types.ImporterFrom),
+		source: importer.ForCompiler(fset, "source", nil).(types.ImporterFrom),
+	}
+}
+
+type fallbackImporter struct {
+	gc, source types.ImporterFrom
+}
+
+func (f *fallbackImporter) Import(path string) (*types.Package, error) {
+	return f.ImportFrom(path, ".", 0)
+}
+
+func (f *fallbackImporter) ImportFrom(path, dir string, mode types.ImportMode) (*types.Package, error) {
+	if pkg, err := f.gc.ImportFrom(path, dir, mode); err == nil {
+		return pkg, nil
+	}
+	return f.source.ImportFrom(path, dir, mode)
+}
+
+func (g *goChecker) check(f *file) []Finding {
+	fset := token.NewFileSet()
+	parsed, err := parser.ParseFile(fset, f.abs, f.data, 0)
+	if err != nil {
+		line := 1
+		if list, ok := err.(scanner.ErrorList); ok && len(list) > 0 {
+			line = list[0].Pos.Line
+		}
+		return []Finding{f.finding(line, CheckStyle, SeverityError, "File does not parse: "+err.Error())}
+	}
+
+	var out []Finding
+	report := func(pos token.Pos, check, severity, msg string) {
+		if line := fset.Position(pos).Line; f.changed(line) {
+			out = append(out, f.finding(line, check, severity, msg))
+		}
+	}
+
+	g.checkImports(f, parsed, report)
+	for _, decl := range parsed.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil {
+			checkGoFunc(fn.Type, fn.Body, report)
+		}
+	}
+	checkGoDeadCode(parsed, report)
+	g.checkUnused(f, parsed, report)
+	g.checkGoNaming(f, parsed, report)
+	return out
+}
+
+type reportFunc func(pos token.Pos, check, severity, msg string)
+
+// checkImports flags selectors on imported packages that name nothing the
+// package exports.
+func (g *goChecker) checkImports(f *file, file *ast.File, report reportFunc) {
+	pkgs := make(map[string]*types.Package)
+	for _, spec := range file.Imports {
+		path := strings.Trim(spec.Path.Value, `"`)
+		if spec.Name != nil && (spec.Name.Name == "_" || spec.Name.Name == ".") {
+			continue
+		}
+		pkg, ok := g.imported[path]
+		if !ok {
+			pkg, _ = g.importer.ImportFrom(path, filepath.Dir(f.abs), 0)
+			g.imported[path] = pkg
+		}
+		if pkg == nil {
+			continue
+		}
+		name := pkg.Name()
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		pkgs[name] = pkg
+	}
+	if len(pkgs) == 0 {
+		return
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		x, ok := sel.X.(*ast.Ident)
+		if !ok || x.Obj != nil {
+			return true
+		}
+		pkg := pkgs[x.Name]
+		if pkg == nil {
+			return true
+		}
+		switch obj := pkg.Scope().Lookup(sel.Sel.Name); {
+		case !ast.IsExported(sel.Sel.Name):
+			report(sel.Pos(), CheckUnknownAPI, SeverityError, fmt.Sprintf("%s.%s is not exported by %s", x.Name, sel.Sel.Name, pkg.Path()))
+		case obj == nil:
+			report(sel.Pos(), CheckUnknownAPI, SeverityError, fmt.Sprintf("%s.%s does not exist in %s", x.Name, sel.Sel.Name, pkg.Path()))
+		}
+		return true
+	})
+}
+
+// isErrIdent reports whether e names an error variable: err, or a name
+// ending in Err or err.
+func isErrIdent(e ast.Expr) bool {
+	id, ok := e.(*ast.Ident)
+	return ok && (id.Name == "err" || strings.HasSuffix(id.Name, "Err") || strings.HasSuffix(id.Name, "err"))
+}
+
+func isNil(e ast.Expr) bool {
+	id, ok := e.(*ast.Ident)
+	return ok && id.Name == "nil"
+}
+
+// errNotNil reports whether cond is err != nil.
+func errNotNil(cond ast.Expr) bool {
+	b, ok := cond.(*ast.BinaryExpr)
+	if !ok || b.Op != token.NEQ {
+		return false
+	}
+	return isErrIdent(b.X) && isNil(b.Y) || isNil(b.X) && isErrIdent(b.Y)
+}
+
+func returnsError(ft *ast.FuncType) bool {
+	if ft.Results == nil || len(ft.Results.List) == 0 {
+		return false
+	}
+	last, ok := ft.Results.List[len(ft.Results.List)-1].Type.(*ast.Ident)
+	return ok && last.Name == "error"
+}
+
+// checkGoFunc looks for errors that are checked and then dropped, in body
+// and the function literals inside it.
+func checkGoFunc(ft *ast.FuncType, body *ast.BlockStmt, report reportFunc) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			checkGoFunc(n.Type, n.Body, report)
+			return false
+		case *ast.IfStmt:
+			if !errNotNil(n.Cond) {
+				return true
+			}
+			if len(n.Body.List) == 0 {
+				report(n.Pos(), CheckSwallowedError, SeverityWarning, "Empty if err != nil block — the error is ignored")
+				return true
+			}
+			if ret, ok := n.Body.List[0].(*ast.ReturnStmt); ok && len(n.Body.List) == 1 && returnsError(ft) && len(ret.Results) > 0 && isNil(ret.Results[len(ret.Results)-1]) {
+				report(n.Pos(), CheckSwallowedError, SeverityWarning, "The error is checked, then dropped: this returns a nil error")
+			}
+		case *ast.AssignStmt:
+			if len(n.Lhs) == 1 && len(n.Rhs) == 1 && n.Tok == token.ASSIGN {
+				if id, ok := n.Lhs[0].(*ast.Ident); ok && id.Name == "_" {
+					if call, ok := n.Rhs[0].(*ast.CallExpr); ok {
+						report(n.Pos(), CheckSwallowedError, SeverityInfo, fmt.Sprintf("Result of %s discarded with _ — make sure it isn't an error", exprString(call.Fun)))
+					}
+				}
+			}
+		}
+		return true
+	})
+}
+
+// terminates reports whether s always leaves the block it is in.
+func terminates(s ast.Stmt) bool {
+	switch s := s.(type) {
+	case *ast.ReturnStmt:
+		return true
+	case *ast.BranchStmt:
+		return s.Tok != token.FALLTHROUGH
+	case *ast.ExprStmt:
+		call, ok := s.X.(*ast.CallExpr)
+		if !ok {
+			return false
+		}
+		switch exprString(call.Fun) {
+		case "panic", "os.Exit", "log.Fatal", "log.Fatalf", "log.Fatalln", "log.Panic", "log.Panicf", "log.Panicln":
+			return true
+		}
+	}
+	return false
+}
+
+func exprString(e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.SelectorExpr:
+		return exprString(e.X) + "." + e.Sel.Name
+	}
+	return "call"
+}
+
+// checkGoDeadCode flags statements after one that always leaves the
+// block, and if false blocks.
+func checkGoDeadCode(file *ast.File, report reportFunc) {
+	unreachable := func(list []ast.Stmt) {
+		for i, s := range list[:max(len(list)-1, 0)] {
+			next := list[i+1]
+			if _, label := next.(*ast.LabeledStmt); terminates(s) && !label {
+				report(next.Pos(), CheckDeadCode, SeverityWarning, "Unreachable code after "+describeExit(s))
+				return
+			}
+		}
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.BlockStmt:
+			unreachable(n.List)
+		case *ast.CaseClause:
+			unreachable(n.Body)
+		case *ast.CommClause:
+			unreachable(n.Body)
+		case *ast.IfStmt:
+			if id, ok := n.Cond.(*ast.Ident); ok && id.Name == "false" {
+				report(n.Pos(), CheckDeadCode, SeverityWarning, "if false block never runs")
+			}
+		}
+		return true
+	})
+}
+
+func describeExit(s ast.Stmt) string {
+	switch s := s.(type) {
+	case *ast.ReturnStmt:
+		return "return"
+	case *ast.BranchStmt:
+		return s.Tok.String()
+	case *ast.ExprStmt:
+		return exprString(s.X.(*ast.CallExpr).Fun)
+	}
+	return "exit"
+}
+
+// packageIdents counts the identifiers used across the package in dir,
+// tests included, so a function only tests call still counts as used.
+func (g *goChecker) packageIdents(dir, pkgName string) map[string]int {
+	if idents, ok := g.idents[dir]; ok {
+		return idents
+	}
+	idents := make(map[string]int)
+	entries, _ := os.ReadDir(dir)
+	fset := token.NewFileSet()
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".go" {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(dir, e.Name()), nil, 0)
+		if err != nil || strings.TrimSuffix(file.Name.Name, "_test") != pkgName {
+			continue
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok {
+				idents[id.Name]++
+			}
+			return true
+		})
+	}
+	g.idents[dir] = idents
+	return idents
+}
+
+// checkUnused flags unexported functions nothing in the package calls.
+func (g *goChecker) checkUnused(f *file, file *ast.File, report reportFunc) {
+	idents := g.packageIdents(filepath.Dir(f.abs), file.Name.Name)
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil {
+			continue
+		}
+		name := fn.Name.Name
+		if ast.IsExported(name) || name == "init" || name == "main" || name == "_" {
+			continue
+		}
+		if idents[name] <= 1 { // the declaration itself
+			report(fn.Pos(), CheckDeadCode, SeverityWarning, fmt.Sprintf("func %s is never used", name))
+		}
+	}
+}
+
+var goInitialism = regexp.MustCompile(`(Id|Url|Uri|Http|Json|Api|Html|Sql|Xml|Tls|Ssh)([A-Z0-9]|$)`)
+
+// checkGoNaming flags names declared on changed lines that don't follow
+// Go's mixedCaps, or that spell an initialism differently from the rest
+// of the package.
+func (g *goChecker) checkGoNaming(f *file, file *ast.File, report reportFunc) {
+	idents := g.packageIdents(filepath.Dir(f.abs), file.Name.Name)
+	check := func(id *ast.Ident) {
+		name := id.Name
+		if name == "_" || strings.ToUpper(name) == name {
+			return
+		}
+		for _, prefix := range []string{"Test", "Benchmark", "Example", "Fuzz"} {
+			if strings.HasPrefix(name, prefix) {
+				return
+			}
+		}
+		if strings.Contains(strings.Trim(name, "_"), "_") {
+			report(id.Pos(), CheckNaming, SeverityInfo, fmt.Sprintf("%s is snake_case; Go names are mixedCaps", name))
+			return
+		}
+		if m := goInitialism.FindStringSubmatch(name); m != nil && packageSpells(idents, strings.ToUpper(m[1])) {
+			report(id.Pos(), CheckNaming, SeverityInfo, fmt.Sprintf("%s spells %s as %s; the package uses %s", name, strings.ToUpper(m[1]), m[1], strings.ToUpper(m[1])))
+		}
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			check(n.Name)
+		case *ast.Field:
+			for _, id := range n.Names {
+				check(id)
+			}
+		case *ast.ValueSpec:
+			for _, id := range n.Names {
+				check(id)
+			}
+		case *ast.TypeSpec:
+			check(n.Name)
+		case *ast.AssignStmt:
+			if n.Tok == token.DEFINE {
+				for _, e := range n.Lhs {
+					if id, ok := e.(*ast.Ident); ok {
+						check(id)
+					}
+				}
+			}
+		}
+		return true
+	})
+}
+
+// packageSpells reports whether any identifier in the package spells the
+// initialism upper in capitals, as in userID.
+func packageSpells(idents map[string]int, upper string) bool {
+	for name := range idents {
+		i := strings.Index(name, upper)
+		if i < 0 {
+			continue
+		}
+		if end := i + len(upper); end == len(name) || name[end] < 'a' || name[end] > 'z' {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,266 @@
+package audit
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	pyExceptPass  = regexp.MustCompile(`^except\b[^:]*:\s*(pass|\.\.\.)?\s*(#.*)?$`)
+	jsEmptyCatch  = regexp.MustCompile(`catch\s*(\(\s*[\w$]*\s*\))?\s*\{\s*\}|\.catch\(\s*\(?\s*[\w$]*\s*\)?\s*=>\s*(\{\s*\}|null|undefined)\s*\)`)
+	pyDecl        = regexp.MustCompile(`^\s*(?:def\s+([A-Za-z_]\w*)\s*\(|([A-Za-z_]\w*)\s*=[^=])`)
+	jsDecl        = regexp.MustCompile(`\b(?:function\s+([A-Za-z_$][\w$]*)|(?:const|let|var)\s+([A-Za-z_$][\w$]*)\s*=)`)
+	jsUnreachable = regexp.MustCompile(`^(return\b.*;|throw\b.*;|break;|continue;)$`)
+)
+
+// checkLines runs the checks that need no parser: placeholders, debug
+// output, secrets and long lines everywhere, and swallowed errors, dead
+// code and naming for Python and JavaScript.
+func checkLines(f *file) []Finding {
+	var out []Finding
+	ext := f.ext()
+	py := ext == ".py"
+	js := ext == ".js" || ext == ".jsx" || ext == ".ts" || ext == ".tsx"
+
+	for i, line := range f.lines {
+		n := i + 1
+		if !f.changed(n) {
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		comment := strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "*")
+
+		if strings.Contains(trimmed, "TODO: implement") || strings.Contains(trimmed, "TODO: add") {
+			out = append(out, f.finding(n, CheckPlaceholder, SeverityWarning, "Placeholder TODO — likely unimplemented AI suggestion"))
+		}
+		if strings.Contains(trimmed, "// This function") && strings.Contains(trimmed, "...") {
+			out = append(out, f.finding(n, CheckPlaceholder, SeverityWarning, "Truncated AI comment"))
+		}
+		if py && trimmed == "pass" && !afterExcept(f.lines, i) {
+			out = append(out, f.finding(n, CheckPlaceholder, SeverityInfo, "Empty pass statement — may be AI placeholder"))
+		}
+
+		if js && strings.Contains(trimmed, "console.log(") {
+			out = append(out, f.finding(n, CheckDebug, SeverityInfo, "Debug console.log left in code"))
+		}
+		if strings.Contains(trimmed, "fmt.Println(\"debug") || strings.Contains(trimmed, "fmt.Println(\"DEBUG") {
+			out = append(out, f.finding(n, CheckDebug, SeverityInfo, "Debug print statement"))
+		}
+
+		if strings.Contains(line, "password") && strings.Contains(line, "=") && strings.Contains(line, "\"") && !comment {
+			out = append(out, f.finding(n, CheckSecret, SeverityError, "Possible hardcoded password"))
+		}
+		if strings.Contains(line, "api_key") && strings.Contains(line, "\"sk-") {
+			out = append(out, f.finding(n, CheckSecret, SeverityError, "Possible hardcoded API key"))
+		}
+		if strings.Contains(line, "secret") && strings.Contains(line, "=") && len(line) > 50 &&
+			!comment && !strings.HasPrefix(trimmed, "os.") && !strings.HasPrefix(trimmed, "env") {
+			out = append(out, f.finding(n, CheckSecret, SeverityWarning, "Possible hardcoded secret"))
+		}
+
+		if len(line) > 200 {
+			out = append(out, f.finding(n, CheckStyle, SeverityInfo, fmt.Sprintf("Very long line (%d chars) — consider breaking up", len(line))))
+		}
+
+		switch {
+		case py && swallowedPython(f.lines, i):
+			out = append(out, f.finding(n, CheckSwallowedError, SeverityWarning, "Exception caught and ignored"))
+		case js && jsEmptyCatch.MatchString(trimmed):
+			out = append(out, f.finding(n, CheckSwallowedError, SeverityWarning, "Error caught and ignored"))
+		}
+
+		if py && unreachablePython(f.lines, i) {
+			out = append(out, f.finding(n, CheckDeadCode, SeverityWarning, "Unreachable code after return or raise"))
+		}
+		if js && !comment {
+			if after := unreachableJS(f.lines, i); after != "" {
+				out = append(out, f.finding(n, CheckDeadCode, SeverityWarning, "Unreachable code after "+after))
+			}
+		}
+	}
+
+	switch {
+	case py:
+		out = append(out, checkNaming(f, pyDecl, styleSnake)...)
+	case js:
+		out = append(out, checkNaming(f, jsDecl, styleCamel)...)
+	}
+	return out
+}
+
+// nextLine returns the next non-blank line after i, trimmed.
+func nextLine(lines []string, i int) string {
+	for j := i + 1; j < len(lines); j++ {
+		if t := strings.TrimSpace(lines[j]); t != "" {
+			return t
+		}
+	}
+	return ""
+}
+
+// prevLine returns the index of the last non-blank line before i, or i.
+func prevLine(lines []string, i int) int {
+	for j := i - 1; j >= 0; j-- {
+		if strings.TrimSpace(lines[j]) != "" {
+			return j
+		}
+	}
+	return i
+}
+
+// swallowedPython reports whether line i is an except clause whose whole
+// body is pass or an ellipsis.
+func swallowedPython(lines []string, i int) bool {
+	trimmed := strings.TrimSpace(lines[i])
+	m := pyExceptPass.FindStringSubmatch(trimmed)
+	if m == nil {
+		return false
+	}
+	if m[1] != "" {
+		return true
+	}
+	next := nextLine(lines, i)
+	return (next == "pass" || next == "...") && singleStatementBlock(lines, i)
+}
+
+// singleStatementBlock reports whether the block opened at line i holds a single
+// statement.
+func singleStatementBlock(lines []string, i int) bool {
+	var body []int
+	for j := i + 1; j < len(lines); j++ {
+		t := strings.TrimSpace(lines[j])
+		if t == "" || strings.HasPrefix(t, "#") {
+			continue
+		}
+		if indent(lines[j]) <= indent(lines[i]) {
+			break
+		}
+		body = append(body, j)
+	}
+	return len(body) == 1
+}
+
+func afterExcept(lines []string, i int) bool {
+	p := prevLine(lines, i)
+	return p != i && pyExceptPass.MatchString(strings.TrimSpace(lines[p]))
+}
+
+func indent(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+func sameIndent(lines []string, a, b int) bool {
+	return indent(lines[a]) == indent(lines[b])
+}
+
+// unreachablePython reports whether line i follows a complete return or
+// raise at the same indentation.
+func unreachablePython(lines []string, i int) bool {
+	trimmed := strings.TrimSpace(lines[i])
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return false
+	}
+	p := prevLine(lines, i)
+	if p == i || !sameIndent(lines, p, i) {
+		return false
+	}
+	prev := strings.TrimSpace(lines[p])
+	if prev != "return" && !strings.HasPrefix(prev, "return ") && !strings.HasPrefix(prev, "raise") {
+		return false
+	}
+	return balanced(prev) && !strings.HasSuffix(prev, "\\")
+}
+
+// unreachableJS returns the statement line i follows at the same
+// indentation when that statement always leaves the block, or "".
+func unreachableJS(lines []string, i int) string {
+	trimmed := strings.TrimSpace(lines[i])
+	if trimmed == "" || strings.HasPrefix(trimmed, "}") || strings.HasPrefix(trimmed, "case ") || strings.HasPrefix(trimmed, "default:") {
+		return ""
+	}
+	p := prevLine(lines, i)
+	if p == i || !sameIndent(lines, p, i) {
+		return ""
+	}
+	prev := strings.TrimSpace(lines[p])
+	if !jsUnreachable.MatchString(prev) {
+		return ""
+	}
+	return strings.TrimSuffix(strings.Fields(prev)[0], ";")
+}
+
+func balanced(s string) bool {
+	depth := 0
+	for _, r := range s {
+		switch r {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		}
+	}
+	return depth == 0
+}
+
+const (
+	styleSnake = "snake_case"
+	styleCamel = "camelCase"
+)
+
+// nameStyle classifies a name as snake_case or camelCase; constants,
+// type-like and one-word names are neither.
+func nameStyle(name string) string {
+	name = strings.Trim(name, "_$")
+	if name == "" || strings.ToUpper(name) == name || name[0] < 'a' || name[0] > 'z' {
+		return ""
+	}
+	if strings.Contains(name, "_") {
+		return styleSnake
+	}
+	if strings.ToLower(name) != name {
+		return styleCamel
+	}
+	return ""
+}
+
+// checkNaming flags names declared on changed lines in a style the rest of
+// the file doesn't use. Without a clear majority, the language's own
+// convention wins.
+func checkNaming(f *file, decl *regexp.Regexp, convention string) []Finding {
+	type declared struct {
+		name  string
+		line  int
+		style string
+	}
+	var decls []declared
+	count := make(map[string]int)
+	for i, line := range f.lines {
+		for _, m := range decl.FindAllStringSubmatch(line, -1) {
+			name := m[1]
+			if name == "" {
+				name = m[2]
+			}
+			if style := nameStyle(name); style != "" {
+				decls = append(decls, declared{name, i + 1, style})
+				count[style]++
+			}
+		}
+	}
+
+	expected := convention
+	if count[styleSnake] > count[styleCamel] {
+		expected = styleSnake
+	} else if count[styleCamel] > count[styleSnake] {
+		expected = styleCamel
+	}
+
+	var out []Finding
+	for _, d := range decls {
+		if d.style != expected && f.changed(d.line) {
+			out = append(out, f.finding(d.line, CheckNaming, SeverityInfo,
+				fmt.Sprintf("%s is %s; this file uses %s", d.name, d.style, expected)))
+		}
+	}
+	return out
+}
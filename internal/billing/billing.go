@@ -0,0 +1,281 @@
+// Package billing imports actual spend from provider usage APIs so it can be
+// reconciled against the estimates palm logs for proxied requests.
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DailyCost is a provider's actual spend for one UTC day.
+type DailyCost struct {
+	Provider string  `json:"provider"`
+	Day      string  `json:"day"` // YYYY-MM-DD
+	Amount   float64 `json:"amount_usd"`
+}
+
+// API roots, variables so tests can point them at a fake server.
+var (
+	OpenAIBase    = "https://api.openai.com/v1"
+	AnthropicBase = "https://api.anthropic.com/v1"
+)
+
+// AdminKeys names the vault key each provider's cost API needs. Both APIs
+// reject ordinary API keys.
+var AdminKeys = map[string]string{
+	"openai":    "OPENAI_ADMIN_KEY",
+	"anthropic": "ANTHROPIC_ADMIN_KEY",
+}
+
+// Providers lists the providers with an importer.
+var Providers = []string{"anthropic", "openai"}
+
+// Fetch pulls daily costs for provider between since and until.
+func Fetch(ctx context.Context, client *http.Client, provider, key string, since, until time.Time) ([]DailyCost, error) {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	switch provider {
+	case "openai":
+		return fetchOpenAI(ctx, client, key, since, until)
+	case "anthropic":
+		return fetchAnthropic(ctx, client, key, since, until)
+	}
+	return nil, fmt.Errorf("no billing importer for %s (use %s)", provider, strings.Join(Providers, ", "))
+}
+
+// fetchOpenAI reads the organization costs API, which reports dollars.
+func fetchOpenAI(ctx context.Context, client *http.Client, key string, since, until time.Time) ([]DailyCost, error) {
+	totals := make(map[string]float64)
+	page := ""
+	for {
+		q := url.Values{}
+		q.Set("start_time", strconv.FormatInt(since.Unix(), 10))
+		q.Set("end_time", strconv.FormatInt(until.Unix(), 10))
+		q.Set("bucket_width", "1d")
+		q.Set("limit", "180")
+		if page != "" {
+			q.Set("page", page)
+		}
+		h := http.Header{"Authorization": {"Bearer " + key}}
+
+		var resp struct {
+			Data []struct {
+				StartTime int64 `json:"start_time"`
+				Results   []struct {
+					Amount struct {
+						Value float64 `json:"value"`
+					} `json:"amount"`
+				} `json:"results"`
+			} `json:"data"`
+			HasMore  bool   `json:"has_more"`
+			NextPage string `json:"next_page"`
+		}
+		if err := getJSON(ctx, client, OpenAIBase+"/organization/costs?"+q.Encode(), h, &resp); err != nil {
+			return nil, err
+		}
+		for _, b := range resp.Data {
+			day := time.Unix(b.StartTime, 0).UTC().Format("2006-01-02")
+			for _, r := range b.Results {
+				totals[day] += r.Amount.Value
+			}
+		}
+		if !resp.HasMore || resp.NextPage == "" {
+			break
+		}
+		page = resp.NextPage
+	}
+	return collect("openai", totals), nil
+}
+
+// fetchAnthropic reads the Admin API cost report, which reports amounts as
+// decimal strings in cents.
+func fetchAnthropic(ctx context.Context, client *http.Client, key string, since, until time.Time) ([]DailyCost, error) {
+	totals := make(map[string]float64)
+	page := ""
+	for {
+		q := url.Values{}
+		q.Set("starting_at", since.UTC().Format(time.RFC3339))
+		q.Set("ending_at", until.UTC().Format(time.RFC3339))
+		q.Set("bucket_width", "1d")
+		if page != "" {
+			q.Set("page", page)
+		}
+		h := http.Header{"X-Api-Key": {key}, "Anthropic-Version": {"2023-06-01"}}
+
+		var resp struct {
+			Data []struct {
+				StartingAt time.Time `json:"starting_at"`
+				Results    []struct {
+					Amount string `json:"amount"`
+				} `json:"results"`
+			} `json:"data"`
+			HasMore  bool   `json:"has_more"`
+			NextPage string `json:"next_page"`
+		}
+		if err := getJSON(ctx, client, AnthropicBase+"/organizations/cost_report?"+q.Encode(), h, &resp); err != nil {
+			return nil, err
+		}
+		for _, b := range resp.Data {
+			day := b.StartingAt.UTC().Format("2006-01-02")
+			for _, r := range b.Results {
+				cents, err := strconv.ParseFloat(r.Amount, 64)
+				if err != nil {
+					return nil, fmt.Errorf("anthropic: bad amount %q", r.Amount)
+				}
+				totals[day] += cents / 100
+			}
+		}
+		if !resp.HasMore || resp.NextPage == "" {
+			break
+		}
+		page = resp.NextPage
+	}
+	return collect("anthropic", totals), nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, u string, h http.Header, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	for k, vs := range h {
+		req.Header[k] = vs
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+		msg := strings.TrimSpace(string(data))
+		var e struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(data, &e) == nil && e.Error.Message != "" {
+			msg = e.Error.Message
+		}
+		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+			msg += " (cost APIs need an organization admin key)"
+		}
+		return fmt.Errorf("%s: %s", resp.Status, msg)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func collect(provider string, totals map[string]float64) []DailyCost {
+	costs := make([]DailyCost, 0, len(totals))
+	for day, amount := range totals {
+		costs = append(costs, DailyCost{Provider: provider, Day: day, Amount: amount})
+	}
+	sortCosts(costs)
+	return costs
+}
+
+func sortCosts(costs []DailyCost) {
+	sort.Slice(costs, func(i, j int) bool {
+		if costs[i].Day != costs[j].Day {
+			return costs[i].Day < costs[j].Day
+		}
+		return costs[i].Provider < costs[j].Provider
+	})
+}
+
+func billingPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "palm", "billing.json")
+}
+
+// Load returns previously imported costs.
+func Load() ([]DailyCost, error) {
+	data, err := os.ReadFile(billingPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var costs []DailyCost
+	if err := json.Unmarshal(data, &costs); err != nil {
+		return nil, fmt.Errorf("billing.json: %w", err)
+	}
+	return costs, nil
+}
+
+// Merge stores imported costs, replacing any earlier import of the same
+// provider and day.
+func Merge(imported []DailyCost) error {
+	existing, err := Load()
+	if err != nil {
+		return err
+	}
+	byKey := make(map[string]DailyCost, len(existing)+len(imported))
+	for _, c := range append(existing, imported...) {
+		byKey[c.Provider+"/"+c.Day] = c
+	}
+	merged := make([]DailyCost, 0, len(byKey))
+	for _, c := range byKey {
+		merged = append(merged, c)
+	}
+	sortCosts(merged)
+
+	path := billingPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, _ := json.MarshalIndent(merged, "", "  ")
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Row compares palm's estimate with the billed amount for a provider-day.
+type Row struct {
+	Provider  string  `json:"provider"`
+	Day       string  `json:"day"`
+	Estimated float64 `json:"estimated_usd"`
+	Actual    float64 `json:"actual_usd"`
+	DiffPct   float64 `json:"diff_pct"` // (actual-estimated)/actual
+	Flagged   bool    `json:"flagged"`
+}
+
+// minDelta ignores differences too small to matter, whatever the percentage.
+const minDelta = 0.01
+
+// Reconcile pairs actual costs with estimates keyed by "provider/day" and
+// flags days where they differ by more than threshold percent. Days with an
+// estimate but no imported actual are left out, since there is nothing to
+// compare against.
+func Reconcile(actual []DailyCost, estimated map[string]float64, threshold float64) []Row {
+	var rows []Row
+	for _, c := range actual {
+		est := estimated[c.Provider+"/"+c.Day]
+		r := Row{Provider: c.Provider, Day: c.Day, Estimated: est, Actual: c.Amount}
+		delta := c.Amount - est
+		switch {
+		case c.Amount > 0:
+			r.DiffPct = delta / c.Amount * 100
+		case est > 0:
+			r.DiffPct = -100
+		}
+		r.Flagged = math.Abs(delta) >= minDelta && math.Abs(r.DiffPct) > threshold
+		rows = append(rows, r)
+	}
+	return rows
+}
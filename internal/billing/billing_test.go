@@ -0,0 +1,102 @@
+package billing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestFetchOpenAI(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/organization/costs" || r.Header.Get("Authorization") != "Bearer admin" {
+			http.Error(w, `{"error":{"message":"bad key"}}`, http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("page") == "" {
+			w.Write([]byte(`{"data":[{"start_time":` + unix(day) + `,"results":[{"amount":{"value":1.5}},{"amount":{"value":0.25}}]}],"has_more":true,"next_page":"p2"}`))
+			return
+		}
+		w.Write([]byte(`{"data":[{"start_time":` + unix(day.AddDate(0, 0, 1)) + `,"results":[{"amount":{"value":2}}]}],"has_more":false}`))
+	}))
+	defer srv.Close()
+	OpenAIBase = srv.URL
+	defer func() { OpenAIBase = "https://api.openai.com/v1" }()
+
+	costs, err := Fetch(context.Background(), srv.Client(), "openai", "admin", day, day.AddDate(0, 0, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(costs) != 2 || costs[0].Day != "2026-03-01" || costs[0].Amount != 1.75 || costs[1].Amount != 2 {
+		t.Errorf("unexpected costs: %+v", costs)
+	}
+
+	if _, err := Fetch(context.Background(), srv.Client(), "openai", "user-key", day, day); err == nil {
+		t.Error("expected an error for a rejected key")
+	}
+}
+
+func TestFetchAnthropicCents(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "admin" || r.Header.Get("Anthropic-Version") == "" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"data":[{"starting_at":"2026-03-01T00:00:00Z","results":[{"amount":"123.5"},{"amount":"76.5"}]}],"has_more":false}`))
+	}))
+	defer srv.Close()
+	AnthropicBase = srv.URL
+	defer func() { AnthropicBase = "https://api.anthropic.com/v1" }()
+
+	costs, err := Fetch(context.Background(), srv.Client(), "anthropic", "admin", time.Now().AddDate(0, 0, -1), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(costs) != 1 || costs[0].Amount != 2 {
+		t.Errorf("200 cents should be $2: %+v", costs)
+	}
+}
+
+func TestMergeReplacesDays(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	Merge([]DailyCost{{"openai", "2026-03-01", 1}, {"openai", "2026-03-02", 2}})
+	Merge([]DailyCost{{"openai", "2026-03-02", 3}, {"anthropic", "2026-03-02", 4}})
+
+	costs, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(costs) != 3 || costs[2].Provider != "openai" || costs[2].Amount != 3 {
+		t.Errorf("unexpected merge: %+v", costs)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	actual := []DailyCost{
+		{"openai", "2026-03-01", 10},
+		{"openai", "2026-03-02", 10},
+		{"anthropic", "2026-03-01", 0.004},
+	}
+	est := map[string]float64{
+		"openai/2026-03-01":    9.5,
+		"openai/2026-03-02":    6,
+		"anthropic/2026-03-01": 0,
+	}
+	rows := Reconcile(actual, est, 10)
+	if rows[0].Flagged {
+		t.Errorf("5%% off should pass: %+v", rows[0])
+	}
+	if !rows[1].Flagged || rows[1].DiffPct != 40 {
+		t.Errorf("40%% off should be flagged: %+v", rows[1])
+	}
+	if rows[2].Flagged {
+		t.Errorf("sub-cent differences should not be flagged: %+v", rows[2])
+	}
+}
+
+func unix(t time.Time) string {
+	return strconv.FormatInt(t.Unix(), 10)
+}
@@ -0,0 +1,273 @@
+package budget
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/msalah0e/palm/internal/fileutil"
+	"github.com/msalah0e/palm/internal/notify"
+)
+
+// Alert is an action fired once per budget period when spending crosses At percent.
+type Alert struct {
+	At      float64 `toml:"at"` // percentage of the limit (80 = 80%)
+	Notify  bool    `toml:"notify,omitempty"`
+	Webhook string  `toml:"webhook,omitempty"`
+	Command string  `toml:"command,omitempty"`
+}
+
+// Describe returns a short human-readable summary of the alert's actions.
+func (a Alert) Describe() string {
+	var parts []string
+	if a.Notify {
+		parts = append(parts, "desktop notification")
+	}
+	if a.Webhook != "" {
+		parts = append(parts, "webhook "+a.Webhook)
+	}
+	if a.Command != "" {
+		parts = append(parts, "command `"+a.Command+"`")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// AlertEvent describes a threshold crossing. It is also the webhook JSON payload.
+type AlertEvent struct {
+	Scope     string    `json:"scope"` // "monthly" or "daily"
+	Threshold float64   `json:"threshold"`
+	Percent   float64   `json:"percent"`
+	Spend     float64   `json:"spend"`
+	Limit     float64   `json:"limit"`
+	Text      string    `json:"text"` // rendered message; Slack incoming webhooks display this field
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// alertState remembers which thresholds already fired so each fires once per period.
+type alertState struct {
+	Fired map[string]time.Time `json:"fired"`
+}
+
+func alertStatePath() string {
+	return filepath.Join(filepath.Dir(budgetPath()), "budget_alerts.json")
+}
+
+func loadAlertState() *alertState {
+	st := &alertState{Fired: make(map[string]time.Time)}
+	data, err := os.ReadFile(alertStatePath())
+	if err != nil {
+		return st
+	}
+	_ = json.Unmarshal(data, st)
+	if st.Fired == nil {
+		st.Fired = make(map[string]time.Time)
+	}
+	return st
+}
+
+// alertMu and the lock file next to the state serialize claiming alerts
+// within and across palm processes, so each crossing fires once.
+var alertMu sync.Mutex
+
+func saveAlertState(st *alertState) error {
+	path := alertStatePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// ParsePercent parses a threshold such as "80%", "80" or "0.8" into a
+// percentage. Values up to 1 are fractions unless they carry a "%".
+func ParsePercent(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	num, percent := strings.CutSuffix(s, "%")
+	v, err := strconv.ParseFloat(strings.TrimSpace(num), 64)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("invalid threshold %q", s)
+	}
+	if !percent && v <= 1 {
+		v *= 100
+	}
+	return v, nil
+}
+
+// pendingAlerts returns the events for thresholds crossed in status that have
+// not fired yet in the current period, marking them as fired in st.
+func pendingAlerts(b *Budget, status *Status, st *alertState, now time.Time) []AlertEvent {
+	type scope struct {
+		name, period string
+		spend, limit float64
+	}
+	scopes := []scope{
+		{"monthly", now.Format("2006-01"), status.MonthlySpend, status.MonthlyLimit},
+		{"daily", now.Format("2006-01-02"), status.DailySpend, status.DailyLimit},
+	}
+
+	// Forget crossings from past periods
+	for key := range st.Fired {
+		current := false
+		for _, sc := range scopes {
+			if strings.HasPrefix(key, sc.name+":"+sc.period+":") {
+				current = true
+			}
+		}
+		if !current {
+			delete(st.Fired, key)
+		}
+	}
+
+	var events []AlertEvent
+	for _, sc := range scopes {
+		if sc.limit <= 0 {
+			continue
+		}
+		pct := sc.spend / sc.limit * 100
+		seen := make(map[float64]bool)
+		for _, a := range b.Alerts {
+			if pct < a.At || seen[a.At] {
+				continue
+			}
+			seen[a.At] = true
+			key := fmt.Sprintf("%s:%s:%g", sc.name, sc.period, a.At)
+			if _, fired := st.Fired[key]; fired {
+				continue
+			}
+			st.Fired[key] = now
+			events = append(events, AlertEvent{
+				Scope:     sc.name,
+				Threshold: a.At,
+				Percent:   pct,
+				Spend:     sc.spend,
+				Limit:     sc.limit,
+				Text:      fmt.Sprintf("palm: %s AI budget at %.0f%% ($%.2f / $%.2f)", sc.name, pct, sc.spend, sc.limit),
+				Timestamp: now,
+			})
+		}
+	}
+	return events
+}
+
+// CheckAlerts fires every configured alert whose threshold has been crossed
+// since it last fired. Delivery failures are returned but never block usage.
+func CheckAlerts() ([]AlertEvent, error) {
+	b := Load()
+	if len(b.Alerts) == 0 {
+		return nil, nil
+	}
+	status, err := GetStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := claimAlerts(b, status)
+	if len(events) == 0 || err != nil {
+		return events, err
+	}
+
+	var errs []string
+	for _, ev := range events {
+		for _, a := range b.Alerts {
+			if a.At != ev.Threshold {
+				continue
+			}
+			if err := Fire(a, ev); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return events, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return events, nil
+}
+
+// claimAlerts marks the crossings in status that haven't fired yet as fired
+// and returns them. The state is read and written under lock, so concurrent
+// checks never claim the same crossing twice.
+func claimAlerts(b *Budget, status *Status) ([]AlertEvent, error) {
+	alertMu.Lock()
+	defer alertMu.Unlock()
+	unlock, err := fileutil.Lock(alertStatePath())
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	st := loadAlertState()
+	events := pendingAlerts(b, status, st, time.Now())
+	if len(events) == 0 {
+		return nil, nil
+	}
+	return events, saveAlertState(st)
+}
+
+// alertsRunning is set while a background alert check is in flight.
+var alertsRunning atomic.Bool
+
+// checkAlertsAsync runs CheckAlerts in the background so webhooks and
+// commands never hold up a request. Checks arriving while one is running
+// are dropped, since a later request checks again.
+func checkAlertsAsync() {
+	if !alertsRunning.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer alertsRunning.Store(false)
+		_, _ = CheckAlerts()
+	}()
+}
+
+// Fire runs every action configured on a for the given event.
+func Fire(a Alert, ev AlertEvent) error {
+	var errs []string
+	if a.Notify {
+		if err := notify.Desktop("palm budget", ev.Text); err != nil {
+			errs = append(errs, "notify: "+err.Error())
+		}
+	}
+	if a.Webhook != "" {
+		if err := notify.Webhook(a.Webhook, ev); err != nil {
+			errs = append(errs, "webhook: "+err.Error())
+		}
+	}
+	if a.Command != "" {
+		if err := runAlertCommand(a.Command, ev); err != nil {
+			errs = append(errs, "command: "+err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func runAlertCommand(script string, ev AlertEvent) error {
+	cmd := exec.Command("sh", "-c", script)
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", script)
+	}
+	cmd.Env = append(os.Environ(),
+		"PALM_BUDGET_SCOPE="+ev.Scope,
+		fmt.Sprintf("PALM_BUDGET_THRESHOLD=%g", ev.Threshold),
+		fmt.Sprintf("PALM_BUDGET_PERCENT=%.1f", ev.Percent),
+		fmt.Sprintf("PALM_BUDGET_SPEND=%.2f", ev.Spend),
+		fmt.Sprintf("PALM_BUDGET_LIMIT=%.2f", ev.Limit),
+		"PALM_BUDGET_MESSAGE="+ev.Text,
+	)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
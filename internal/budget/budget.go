@@ -16,6 +16,7 @@ type Budget struct {
 	DailyLimit   float64            `toml:"daily_limit"`
 	AlertAt      float64            `toml:"alert_at"` // percentage (0.8 = 80%)
 	PerTool      map[string]float64 `toml:"per_tool"` // per-tool monthly limits
+	Alerts       []Alert            `toml:"alerts"`   // actions fired on threshold crossings
 }
 
 // Status represents current budget status.
@@ -117,6 +118,26 @@ func GetStatus() (*Status, error) {
 	return s, nil
 }
 
+// Used returns how much of the budget is spent as a percentage — of the
+// monthly or the daily limit, whichever is further along — and alert_at as
+// a percentage. Without limits nothing counts as used.
+func Used() (percent, alertAt float64) {
+	b := Load()
+	alertAt = b.AlertAt * 100
+	if b.MonthlyLimit == 0 && b.DailyLimit == 0 {
+		return 0, alertAt
+	}
+	status, err := GetStatus()
+	if err != nil {
+		return 0, alertAt
+	}
+	percent = status.PercentUsed
+	if b.DailyLimit > 0 {
+		percent = max(percent, status.DailySpend/b.DailyLimit*100)
+	}
+	return percent, alertAt
+}
+
 // CheckBudget returns an error if the budget would be exceeded.
 func CheckBudget(tool string) error {
 	b := Load()
@@ -129,6 +150,9 @@ func CheckBudget(tool string) error {
 		return nil // don't block on error
 	}
 
+	// Alert delivery is best-effort and must never block a request
+	checkAlertsAsync()
+
 	if status.IsOverBudget {
 		return fmt.Errorf("monthly budget exceeded ($%.2f / $%.2f)", status.MonthlySpend, status.MonthlyLimit)
 	}
@@ -2,9 +2,11 @@ package budget
 
 import (
 	"os"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/msalah0e/palm/internal/billing"
 	"github.com/msalah0e/palm/internal/session"
 )
 
@@ -112,3 +114,192 @@ func TestCheckBudget(t *testing.T) {
 		t.Error("expected budget exceeded error")
 	}
 }
+
+func TestParsePercent(t *testing.T) {
+	cases := map[string]float64{"80%": 80, "100": 100, "0.9": 90, " 75 % ": 75, "1%": 1, "0.5%": 0.5, "1": 100}
+	for in, want := range cases {
+		got, err := ParsePercent(in)
+		if err != nil || got != want {
+			t.Errorf("ParsePercent(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "abc", "-5%"} {
+		if _, err := ParsePercent(bad); err == nil {
+			t.Errorf("ParsePercent(%q) should fail", bad)
+		}
+	}
+}
+
+func TestPendingAlertsFireOncePerPeriod(t *testing.T) {
+	b := &Budget{Alerts: []Alert{{At: 80, Notify: true}, {At: 100, Command: "true"}}}
+	st := &alertState{Fired: make(map[string]time.Time)}
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	status := &Status{MonthlyLimit: 100, MonthlySpend: 85}
+	events := pendingAlerts(b, status, st, now)
+	if len(events) != 1 || events[0].Threshold != 80 || events[0].Scope != "monthly" {
+		t.Fatalf("expected one 80%% monthly event, got %+v", events)
+	}
+
+	if events := pendingAlerts(b, status, st, now); len(events) != 0 {
+		t.Errorf("80%% alert should not refire in the same month, got %+v", events)
+	}
+
+	status.MonthlySpend = 120
+	events = pendingAlerts(b, status, st, now)
+	if len(events) != 1 || events[0].Threshold != 100 {
+		t.Fatalf("expected 100%% event, got %+v", events)
+	}
+
+	// A new month clears previous crossings
+	if events := pendingAlerts(b, status, st, now.AddDate(0, 1, 0)); len(events) != 2 {
+		t.Errorf("expected both alerts to fire in a new month, got %d", len(events))
+	}
+}
+
+func TestCheckAlertsRunsCommand(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	out := dir + "/fired"
+	b := &Budget{
+		MonthlyLimit: 10.0,
+		AlertAt:      0.8,
+		PerTool:      make(map[string]float64),
+		Alerts:       []Alert{{At: 50, Command: "echo $PALM_BUDGET_THRESHOLD > " + out}},
+	}
+	if err := Save(b); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	_ = session.Record("aider", 5*time.Second, 0, 6.0, 1000, "openai")
+
+	events, err := CheckAlerts()
+	if err != nil {
+		t.Fatalf("CheckAlerts failed: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	data, err := os.ReadFile(out)
+	if err != nil || string(data) != "50\n" {
+		t.Errorf("command did not run as expected: %q, %v", data, err)
+	}
+
+	if events, _ := CheckAlerts(); len(events) != 0 {
+		t.Errorf("alert should fire only once, got %d events", len(events))
+	}
+}
+
+func TestCheckAlertsFiresOnceConcurrently(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	out := dir + "/fired"
+	b := &Budget{MonthlyLimit: 10.0, Alerts: []Alert{{At: 50, Command: "echo x >> " + out}}}
+	if err := Save(b); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	_ = session.Record("aider", 5*time.Second, 0, 6.0, 1000, "openai")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = CheckAlerts()
+		}()
+	}
+	wg.Wait()
+	if data, _ := os.ReadFile(out); string(data) != "x\n" {
+		t.Errorf("alert should fire exactly once, got %q", data)
+	}
+}
+
+func TestCheckBudgetDoesNotWaitForAlerts(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	out := dir + "/fired"
+	b := &Budget{MonthlyLimit: 10.0, Alerts: []Alert{{At: 50, Command: "sleep 1; echo done > " + out}}}
+	if err := Save(b); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	_ = session.Record("aider", 5*time.Second, 0, 6.0, 1000, "openai")
+
+	start := time.Now()
+	if err := CheckBudget("aider"); err != nil {
+		t.Fatalf("under the limit, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("CheckBudget waited %v for the alert command", elapsed)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for alertsRunning.Load() && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if data, _ := os.ReadFile(out); string(data) != "done\n" {
+		t.Errorf("alert should still fire in the background, got %q", data)
+	}
+}
+
+func TestProject(t *testing.T) {
+	// Noon on the 10th of a 30-day month
+	now := time.Date(2026, 6, 10, 12, 0, 0, 0, time.UTC)
+	estimated := map[string]float64{
+		"openai/2026-06-01":    5,
+		"openai/2026-06-09":    2,
+		"openai/2026-06-10":    1,
+		"anthropic/2026-05-31": 4,  // last month: burn rate only
+		"anthropic/2026-06-05": 99, // replaced by the billed amount
+		"openai/2026-06-11":    50, // the future is ignored
+	}
+	billed := []billing.DailyCost{{Provider: "anthropic", Day: "2026-06-05", Amount: 6}}
+
+	f := Project(estimated, billed, 30, now)
+	if f.Month != "2026-06" || len(f.Daily) != 10 {
+		t.Fatalf("month %s with %d days", f.Month, len(f.Daily))
+	}
+	if f.Spend != 14 || f.Daily[0] != 5 || f.Daily[4] != 6 {
+		t.Errorf("spend = %.2f, daily = %v", f.Spend, f.Daily)
+	}
+	// The window runs from the 4th to noon on the 10th: 6.5 days
+	if want := 9 / 6.5; !near(f.BurnRate, want) {
+		t.Errorf("burn rate = %.4f, want %.4f", f.BurnRate, want)
+	}
+	if want := 14 + 9/6.5*20.5; !near(f.Projected, want) {
+		t.Errorf("projected = %.2f, want %.2f", f.Projected, want)
+	}
+	// $16 left at ~$1.38/day runs out 11.6 days from now
+	if f.OverageDate != "2026-06-22" {
+		t.Errorf("overage date = %q", f.OverageDate)
+	}
+	if len(f.Providers) != 2 || f.Providers[0].Provider != "anthropic" || !f.Providers[0].Billed || f.Providers[1].Billed {
+		t.Errorf("providers = %+v", f.Providers)
+	}
+
+	// Already over the limit: the day it was crossed
+	if f := Project(estimated, billed, 10, now); f.OverageDate != "2026-06-05" {
+		t.Errorf("crossed overage date = %q", f.OverageDate)
+	}
+	// A limit the month won't reach
+	if f := Project(estimated, billed, 1000, now); f.OverageDate != "" {
+		t.Errorf("unexpected overage date %q", f.OverageDate)
+	}
+}
+
+func TestProjectNewData(t *testing.T) {
+	// Data only since yesterday: the rate isn't diluted over a whole week
+	now := time.Date(2026, 6, 20, 0, 0, 0, 0, time.UTC)
+	f := Project(map[string]float64{"openai/2026-06-19": 3}, nil, 0, now)
+	if !near(f.BurnRate, 3) {
+		t.Errorf("burn rate = %.2f, want 3", f.BurnRate)
+	}
+	if f := Project(nil, nil, 50, now); f.Spend != 0 || f.BurnRate != 0 || f.OverageDate != "" || f.Providers == nil {
+		t.Errorf("empty forecast = %+v", f)
+	}
+}
+
+func near(a, b float64) bool {
+	return a-b < 1e-9 && b-a < 1e-9
+}
@@ -0,0 +1,131 @@
+package budget
+
+import (
+	"sort"
+	"time"
+
+	"github.com/msalah0e/palm/internal/billing"
+)
+
+// burnWindow is how many recent days the burn rate averages over.
+const burnWindow = 7
+
+// Forecast projects the current month's spend from daily costs. Days are
+// UTC days, like billing data.
+type Forecast struct {
+	Month       string             `json:"month"` // YYYY-MM
+	Spend       float64            `json:"spend"` // month to date
+	BurnRate    float64            `json:"burn_rate"`
+	Projected   float64            `json:"projected"`
+	Limit       float64            `json:"limit,omitempty"`
+	OverageDate string             `json:"overage_date,omitempty"` // day the limit is, or was, crossed
+	Daily       []float64          `json:"daily"`                  // spend per day from the 1st to today
+	Providers   []ProviderForecast `json:"providers"`
+}
+
+// ProviderForecast is one provider's share of a Forecast.
+type ProviderForecast struct {
+	Provider  string    `json:"provider"`
+	Spend     float64   `json:"spend"`
+	BurnRate  float64   `json:"burn_rate"`
+	Projected float64   `json:"projected"`
+	Billed    bool      `json:"billed"` // some days use imported billing instead of estimates
+	Daily     []float64 `json:"daily"`
+}
+
+// Project forecasts the month of now. estimated holds proxy cost estimates
+// keyed "provider/YYYY-MM-DD"; billed days replace the estimate for the same
+// provider and day. The burn rate is the average daily spend over the last
+// week, or since the first day with data when that is more recent.
+func Project(estimated map[string]float64, billed []billing.DailyCost, limit float64, now time.Time) Forecast {
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := today.AddDate(0, 0, 1-now.Day())
+	monthEnd := monthStart.AddDate(0, 1, 0)
+
+	type key struct{ provider, day string }
+	costs := make(map[key]float64)
+	isBilled := make(map[key]bool)
+	for k, amount := range estimated {
+		for i := len(k) - 1; i >= 0; i-- {
+			if k[i] == '/' {
+				costs[key{k[:i], k[i+1:]}] = amount
+				break
+			}
+		}
+	}
+	for _, c := range billed {
+		k := key{c.Provider, c.Day}
+		costs[k], isBilled[k] = c.Amount, true
+	}
+
+	windowStart := today.AddDate(0, 0, 1-burnWindow)
+	first := today
+	for k := range costs {
+		if d, err := time.Parse("2006-01-02", k.day); err == nil && d.Before(first) {
+			first = d
+		}
+	}
+	if first.After(windowStart) {
+		windowStart = first
+	}
+	// Today counts for the part of it that has passed
+	sinceMidnight := now.Sub(today).Hours() / 24
+	windowDays := max(today.Sub(windowStart).Hours()/24+sinceMidnight, 1)
+	remaining := monthEnd.Sub(now).Hours() / 24
+
+	f := Forecast{Month: monthStart.Format("2006-01"), Limit: limit, Daily: make([]float64, now.Day())}
+	byProvider := make(map[string]*ProviderForecast)
+	for k, amount := range costs {
+		d, err := time.Parse("2006-01-02", k.day)
+		if err != nil || d.After(today) || amount == 0 {
+			continue
+		}
+		p := byProvider[k.provider]
+		if p == nil {
+			p = &ProviderForecast{Provider: k.provider, Daily: make([]float64, now.Day())}
+			byProvider[k.provider] = p
+		}
+		if !d.Before(windowStart) {
+			p.BurnRate += amount / windowDays
+		}
+		if !d.Before(monthStart) {
+			p.Spend += amount
+			p.Daily[d.Day()-1] += amount
+			f.Daily[d.Day()-1] += amount
+			p.Billed = p.Billed || isBilled[k]
+		}
+	}
+
+	f.Providers = []ProviderForecast{}
+	for _, p := range byProvider {
+		p.Projected = p.Spend + p.BurnRate*remaining
+		f.Spend += p.Spend
+		f.BurnRate += p.BurnRate
+		f.Providers = append(f.Providers, *p)
+	}
+	sort.Slice(f.Providers, func(i, j int) bool {
+		if f.Providers[i].Projected != f.Providers[j].Projected {
+			return f.Providers[i].Projected > f.Providers[j].Projected
+		}
+		return f.Providers[i].Provider < f.Providers[j].Provider
+	})
+	f.Projected = f.Spend + f.BurnRate*remaining
+
+	if limit > 0 {
+		var total float64
+		for i, amount := range f.Daily {
+			if total += amount; total >= limit {
+				f.OverageDate = monthStart.AddDate(0, 0, i).Format("2006-01-02")
+				return f
+			}
+		}
+		if f.BurnRate > 0 {
+			at := now.Add(time.Duration((limit - f.Spend) / f.BurnRate * float64(24*time.Hour)))
+			if at.Before(monthEnd) {
+				f.OverageDate = at.Format("2006-01-02")
+			}
+		}
+	}
+	return f
+}
@@ -0,0 +1,186 @@
+package chat
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Message is one turn of a conversation.
+type Message struct {
+	Role    string `json:"role"` // system, user or assistant
+	Content string `json:"content"`
+}
+
+// Client talks to an OpenAI-compatible chat completions endpoint.
+type Client struct {
+	BaseURL string      // API root, e.g. https://api.openai.com/v1
+	Header  http.Header // auth headers; empty when the palm proxy adds them
+	HTTP    *http.Client
+}
+
+// defaultModels is the model picked when switching to a provider without
+// naming one.
+var defaultModels = map[string]string{
+	"openai":    "gpt-4o",
+	"anthropic": "claude-sonnet-4-5-20250929",
+	"google":    "gemini-2.5-flash",
+	"groq":      "llama-3.3-70b-versatile",
+	"mistral":   "mistral-large-latest",
+	"ollama":    "llama3.3",
+}
+
+// Providers lists the providers chat can talk to, in the order a default
+// is picked.
+var Providers = []string{"anthropic", "openai", "google", "groq", "mistral", "ollama"}
+
+// DefaultModel returns the model used for provider when none is given.
+func DefaultModel(provider string) string {
+	return defaultModels[provider]
+}
+
+// Stream sends the conversation and calls onDelta with each piece of the
+// reply as it arrives. It returns the full reply.
+func (c *Client) Stream(ctx context.Context, model string, msgs []Message, onDelta func(string)) (string, error) {
+	body, _ := json.Marshal(map[string]any{
+		"model":    model,
+		"messages": msgs,
+		"stream":   true,
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(c.BaseURL, "/")+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	for k, vs := range c.Header {
+		req.Header[k] = vs
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := c.HTTP
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Minute}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+		return "", fmt.Errorf("%s: %s", resp.Status, apiError(data))
+	}
+
+	// Some OpenAI-compatible servers ignore "stream"; accept a plain reply too
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		var out struct {
+			Choices []struct {
+				Message Message `json:"message"`
+			} `json:"choices"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			return "", err
+		}
+		if len(out.Choices) == 0 {
+			return "", fmt.Errorf("no choices in response")
+		}
+		reply := out.Choices[0].Message.Content
+		if onDelta != nil {
+			onDelta(reply)
+		}
+		return reply, nil
+	}
+
+	var reply strings.Builder
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		data, ok := strings.CutPrefix(sc.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+		}
+		if json.Unmarshal([]byte(data), &chunk) != nil || len(chunk.Choices) == 0 {
+			continue
+		}
+		if d := chunk.Choices[0].Delta.Content; d != "" {
+			reply.WriteString(d)
+			if onDelta != nil {
+				onDelta(d)
+			}
+		}
+	}
+	return reply.String(), sc.Err()
+}
+
+// apiError pulls the message out of a provider error body.
+func apiError(data []byte) string {
+	var e struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(data, &e) == nil && e.Error.Message != "" {
+		return e.Error.Message
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// Session is the state of an interactive chat.
+type Session struct {
+	Provider string
+	Model    string
+	System   string // system prompt, including recalled memory
+	Messages []Message
+	Started  time.Time
+}
+
+// Conversation returns the messages to send: the system prompt, if any,
+// followed by the turns so far.
+func (s *Session) Conversation() []Message {
+	var msgs []Message
+	if s.System != "" {
+		msgs = append(msgs, Message{Role: "system", Content: s.System})
+	}
+	return append(msgs, s.Messages...)
+}
+
+// Transcript renders the conversation as markdown for /save.
+func (s *Session) Transcript() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# palm chat — %s\n\n", s.Started.Format("2006-01-02 15:04"))
+	fmt.Fprintf(&b, "Model: %s/%s\n\n", s.Provider, s.Model)
+	for _, m := range s.Messages {
+		who := "You"
+		if m.Role == "assistant" {
+			who = "Assistant"
+		}
+		fmt.Fprintf(&b, "## %s\n\n%s\n\n", who, strings.TrimSpace(m.Content))
+	}
+	return b.String()
+}
+
+// ParseCommand splits a "/name args" line. ok is false for ordinary input.
+func ParseCommand(line string) (name, arg string, ok bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "/") || strings.HasPrefix(line, "//") {
+		return "", "", false
+	}
+	name, arg, _ = strings.Cut(line[1:], " ")
+	return strings.ToLower(name), strings.TrimSpace(arg), true
+}
@@ -0,0 +1,122 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/msalah0e/palm/internal/graph"
+)
+
+func TestStreamReadsDeltas(t *testing.T) {
+	var got struct {
+		Model    string    `json:"model"`
+		Messages []Message `json:"messages"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer k" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, d := range []string{"Hel", "lo"} {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", d)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	c := &Client{BaseURL: srv.URL + "/v1", Header: http.Header{"Authorization": {"Bearer k"}}}
+	var deltas []string
+	reply, err := c.Stream(context.Background(), "m1", []Message{{Role: "user", Content: "hi"}}, func(d string) {
+		deltas = append(deltas, d)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply != "Hello" || len(deltas) != 2 {
+		t.Errorf("reply = %q, deltas = %v", reply, deltas)
+	}
+	if got.Model != "m1" || len(got.Messages) != 1 {
+		t.Errorf("unexpected request %+v", got)
+	}
+}
+
+func TestStreamReportsAPIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error":{"message":"invalid api key"}}`)
+	}))
+	defer srv.Close()
+
+	_, err := (&Client{BaseURL: srv.URL}).Stream(context.Background(), "m", nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "invalid api key") {
+		t.Errorf("expected API error message, got %v", err)
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	name, arg, ok := ParseCommand("/Model  gpt-4o ")
+	if !ok || name != "model" || arg != "gpt-4o" {
+		t.Errorf("got %q %q %v", name, arg, ok)
+	}
+	if _, _, ok := ParseCommand("what does /model do?"); ok {
+		t.Error("plain text should not be a command")
+	}
+	if _, _, ok := ParseCommand("//not a command"); ok {
+		t.Error("// should escape commands")
+	}
+}
+
+func TestSessionConversationAndTranscript(t *testing.T) {
+	s := &Session{Provider: "openai", Model: "gpt-4o", System: "be brief"}
+	s.Messages = []Message{{Role: "user", Content: "hi"}, {Role: "assistant", Content: "hello"}}
+	if msgs := s.Conversation(); len(msgs) != 3 || msgs[0].Role != "system" {
+		t.Errorf("unexpected conversation %+v", msgs)
+	}
+	out := s.Transcript()
+	if !strings.Contains(out, "Model: openai/gpt-4o") || !strings.Contains(out, "## Assistant\n\nhello") {
+		t.Errorf("unexpected transcript:\n%s", out)
+	}
+}
+
+func TestRememberAndRecall(t *testing.T) {
+	g := graph.New()
+	if added, err := Remember(g, Fact{Entity: "palm", Text: "uses cobra for commands"}, "chat"); err != nil || !added {
+		t.Fatalf("Remember: %v %v", added, err)
+	}
+	if added, _ := Remember(g, Fact{Entity: "Palm", Text: "Uses cobra for commands"}, "chat"); added {
+		t.Error("duplicate fact should not be stored again")
+	}
+	_, _ = Remember(g, Fact{Entity: "palm", Text: "config lives in ~/.config/palm"}, "chat")
+	_, _ = Remember(g, Fact{Entity: "billing", Text: "invoices are monthly"}, "chat")
+
+	facts := Recall(g, []string{"palm"}, 10)
+	if len(facts) != 2 || facts[0].Text != "config lives in ~/.config/palm" {
+		t.Errorf("expected newest palm fact first, got %+v", facts)
+	}
+	if p := MemoryPrompt(facts); !strings.Contains(p, "- palm: uses cobra for commands") {
+		t.Errorf("unexpected memory prompt:\n%s", p)
+	}
+	if MemoryPrompt(nil) != "" {
+		t.Error("no facts should give no prompt")
+	}
+}
+
+func TestParseFacts(t *testing.T) {
+	facts := ParseFacts("- api: rate limit is 100/min\n* the team prefers tabs\nNONE\n", "proj")
+	if len(facts) != 2 {
+		t.Fatalf("expected 2 facts, got %+v", facts)
+	}
+	if facts[0] != (Fact{Entity: "api", Text: "rate limit is 100/min"}) {
+		t.Errorf("unexpected first fact %+v", facts[0])
+	}
+	if facts[1] != (Fact{Entity: "proj", Text: "the team prefers tabs"}) {
+		t.Errorf("unexpected second fact %+v", facts[1])
+	}
+}
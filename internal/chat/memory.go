@@ -0,0 +1,121 @@
+package chat
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/msalah0e/palm/internal/graph"
+)
+
+// Fact is something worth remembering about an entity.
+type Fact struct {
+	Entity string
+	Text   string
+}
+
+// Recall gathers up to limit facts from the graph about entities matching
+// any of the queries, best matches first.
+func Recall(g *graph.Graph, queries []string, limit int) []Fact {
+	scores := make(map[*graph.Entity]int)
+	for _, q := range queries {
+		if q = strings.TrimSpace(q); q == "" {
+			continue
+		}
+		for _, r := range g.Search(q) {
+			scores[r.Entity] += r.Score
+		}
+	}
+	entities := make([]*graph.Entity, 0, len(scores))
+	for e := range scores {
+		entities = append(entities, e)
+	}
+	sort.Slice(entities, func(i, j int) bool {
+		if scores[entities[i]] != scores[entities[j]] {
+			return scores[entities[i]] > scores[entities[j]]
+		}
+		return entities[i].Name < entities[j].Name
+	})
+
+	var facts []Fact
+	for _, e := range entities {
+		// Newest observations are the most likely to still be true
+		for i := len(e.Observations) - 1; i >= 0 && len(facts) < limit; i-- {
+			facts = append(facts, Fact{Entity: e.Name, Text: e.Observations[i]})
+		}
+	}
+	return facts
+}
+
+// MemoryPrompt turns recalled facts into a system prompt section.
+func MemoryPrompt(facts []Fact) string {
+	if len(facts) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Things you know from earlier sessions (from the user's knowledge graph):\n")
+	for _, f := range facts {
+		fmt.Fprintf(&b, "- %s: %s\n", f.Entity, f.Text)
+	}
+	return b.String()
+}
+
+// Remember stores a fact as an observation, creating the entity with
+// entityType when it is new. A fact already recorded is not duplicated;
+// added reports whether anything changed.
+func Remember(g *graph.Graph, f Fact, entityType string) (added bool, err error) {
+	e, err := g.GetEntity(f.Entity)
+	if err != nil {
+		if err := g.AddEntity(f.Entity, entityType); err != nil {
+			return false, err
+		}
+		e, _ = g.GetEntity(f.Entity)
+	}
+	if err := g.AddObservation(e.Name, f.Text); err != nil {
+		if errors.Is(err, graph.ErrDuplicateObservation) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// ParseFact reads "/remember" input: "entity: fact" names the entity,
+// anything else is a fact about def.
+func ParseFact(arg, def string) Fact {
+	if entity, text, ok := strings.Cut(arg, ":"); ok && !strings.Contains(entity, " ") && strings.TrimSpace(text) != "" {
+		return Fact{Entity: strings.TrimSpace(entity), Text: strings.TrimSpace(text)}
+	}
+	return Fact{Entity: def, Text: strings.TrimSpace(arg)}
+}
+
+// ExtractPrompt asks the model for the durable facts in a conversation.
+func ExtractPrompt(msgs []Message) string {
+	var b strings.Builder
+	b.WriteString("From the conversation below, list durable facts worth remembering in later sessions: ")
+	b.WriteString("decisions, preferences, project details, names. Skip small talk and anything temporary.\n")
+	b.WriteString("Reply with one fact per line as `Entity: fact`, where Entity is a single word or hyphenated name. ")
+	b.WriteString("Reply NONE if there is nothing worth keeping.\n\n")
+	for _, m := range msgs {
+		fmt.Fprintf(&b, "%s: %s\n\n", m.Role, m.Content)
+	}
+	return b.String()
+}
+
+// ParseFacts reads the model's reply to ExtractPrompt.
+func ParseFacts(reply, def string) []Fact {
+	var facts []Fact
+	for _, line := range strings.Split(reply, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "-*•"))
+		line = strings.Trim(line, "`")
+		if line == "" || strings.EqualFold(line, "none") {
+			continue
+		}
+		f := ParseFact(line, def)
+		if f.Text != "" {
+			facts = append(facts, f)
+		}
+	}
+	return facts
+}
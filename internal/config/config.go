@@ -17,6 +17,9 @@ type Config struct {
 	Parallel ParallelConfig `toml:"parallel"`
 	Hooks    HooksConfig    `toml:"hooks"`
 	Setup    SetupConfig    `toml:"setup"`
+	Graph    GraphConfig    `toml:"graph"`
+	MCP      MCPConfig      `toml:"mcp"`
+	Rules    RulesConfig    `toml:"rules"`
 }
 
 // SetupConfig tracks setup wizard state.
@@ -27,8 +30,9 @@ type SetupConfig struct {
 
 // UIConfig controls display options.
 type UIConfig struct {
-	Emoji bool `toml:"emoji"`
-	Color bool `toml:"color"`
+	Emoji bool   `toml:"emoji"`
+	Color bool   `toml:"color"`
+	Theme string `toml:"theme"` // default, light, high-contrast or a file in themes/
 }
 
 // StatsConfig controls usage tracking.
@@ -40,6 +44,9 @@ type StatsConfig struct {
 type InstallConfig struct {
 	PreferUV     bool `toml:"prefer_uv"`
 	CleanupAfter bool `toml:"cleanup_after"`
+	// DetectTTL is how long tool detection results are reused, e.g. "10m";
+	// "0" probes every time. Empty means the default.
+	DetectTTL string `toml:"detect_ttl"`
 }
 
 // KeysConfig controls API key behavior.
@@ -49,7 +56,28 @@ type KeysConfig struct {
 
 // VaultConfig controls vault backend selection.
 type VaultConfig struct {
-	Backend string `toml:"backend"` // "auto", "keychain", "file"
+	Backend      string `toml:"backend"`       // "auto", "keychain", "file"
+	StaleDays    int    `toml:"stale_days"`    // flag keys unused this long in `palm keys audit`
+	RotationDays int    `toml:"rotation_days"` // required key rotation interval; 0 disables
+}
+
+// GraphConfig controls knowledge graph backups and writes.
+type GraphConfig struct {
+	BackupKeep    int  `toml:"backup_keep"`     // snapshots kept; older ones are pruned
+	BackupMaxDays int  `toml:"backup_max_days"` // prune snapshots older than this; 0 keeps them
+	ReadOnly      bool `toml:"readonly"`        // reject every change to the graph
+}
+
+// MCPConfig controls the MCP server registry.
+type MCPConfig struct {
+	RegistryURL string `toml:"registry_url"` // source for `palm mcp refresh`; empty uses the default
+}
+
+// RulesConfig controls how palm rules sync and palm context sync fit the
+// rules to each tool.
+type RulesConfig struct {
+	Budgets        map[string]int `toml:"budgets"`         // token budget per tool; 0 writes the rules whole
+	SummarizeModel string         `toml:"summarize_model"` // ollama model that condenses sections; "off" disables
 }
 
 // ParallelConfig controls concurrent execution.
@@ -75,8 +103,9 @@ func Default() *Config {
 		Stats:    StatsConfig{Enabled: false},
 		Install:  InstallConfig{PreferUV: true, CleanupAfter: false},
 		Keys:     KeysConfig{AutoExport: false},
-		Vault:    VaultConfig{Backend: "auto"},
+		Vault:    VaultConfig{Backend: "auto", StaleDays: 90},
 		Parallel: ParallelConfig{Enabled: true, Concurrency: 4},
+		Graph:    GraphConfig{BackupKeep: 20},
 	}
 }
 
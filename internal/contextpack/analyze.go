@@ -0,0 +1,417 @@
+package contextpack
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/msalah0e/palm/internal/tokens"
+)
+
+// Analysis is a structural summary of a project, for context files that
+// tell an AI tool where things are and how to build and test them.
+type Analysis struct {
+	Module      string    `json:"module,omitempty"` // go.mod module, package.json name, ...
+	Languages   []string  `json:"languages,omitempty"`
+	Dirs        []Dir     `json:"dirs"`
+	EntryPoints []string  `json:"entry_points,omitempty"`
+	Tests       []string  `json:"tests,omitempty"` // one line per test layout found
+	Commands    []Command `json:"commands,omitempty"`
+}
+
+// Dir is a top-level directory with a guess at its purpose.
+type Dir struct {
+	Path    string `json:"path"`
+	Purpose string `json:"purpose,omitempty"`
+	Files   int    `json:"files"`
+}
+
+// Command is a build, test or run command found in the project's manifests.
+type Command struct {
+	Run    string `json:"run"`
+	Source string `json:"source"` // the file it came from
+}
+
+// dirPurposes guesses what a top-level directory holds from its name.
+var dirPurposes = map[string]string{
+	"cmd": "command entry points", "internal": "private packages", "pkg": "library packages",
+	"src": "source code", "lib": "library code", "app": "application code",
+	"test": "tests", "tests": "tests", "spec": "tests", "__tests__": "tests", "e2e": "end-to-end tests",
+	"testdata": "test fixtures", "fixtures": "test fixtures",
+	"docs": "documentation", "doc": "documentation",
+	"scripts": "scripts", "script": "scripts", "bin": "scripts and binaries", "tools": "developer tooling",
+	"api": "API definitions", "proto": "protobuf definitions",
+	"web": "frontend", "frontend": "frontend", "ui": "user interface", "client": "client code",
+	"server": "server code", "backend": "backend",
+	"components": "UI components", "pages": "page routes", "routes": "routes", "hooks": "hooks",
+	"migrations": "database migrations", "db": "database code", "models": "data models",
+	"config": "configuration", "configs": "configuration",
+	"examples": "examples", "example": "examples", "samples": "examples",
+	"assets": "static assets", "static": "static assets", "public": "static assets",
+	"deploy": "deployment", "deployments": "deployment", "infra": "infrastructure",
+	"k8s": "Kubernetes manifests", "helm": "Helm charts", "terraform": "Terraform",
+	"benchmarks": "benchmarks", "bench": "benchmarks",
+	"registry": "registry data", "plugins": "plugins", "templates": "templates",
+}
+
+// entryPoints are files that usually start a program.
+var entryPoints = []string{
+	"main.go", "cmd/*/main.go", "src/main.rs", "src/lib.rs", "src/bin/*.rs",
+	"main.py", "app.py", "manage.py", "*/__main__.py", "src/*/__main__.py",
+	"index.js", "index.ts", "src/index.js", "src/index.ts", "src/index.tsx",
+	"src/main.ts", "src/main.tsx", "src/App.tsx", "server.js", "app.js",
+	"app/page.tsx", "pages/index.tsx", "Program.cs", "src/main/java/**/Application.java",
+}
+
+// extLanguages names the language of a file extension.
+var extLanguages = map[string]string{
+	".go": "Go", ".rs": "Rust", ".py": "Python", ".js": "JavaScript", ".jsx": "JavaScript",
+	".mjs": "JavaScript", ".ts": "TypeScript", ".tsx": "TypeScript", ".rb": "Ruby",
+	".java": "Java", ".kt": "Kotlin", ".swift": "Swift", ".c": "C", ".h": "C",
+	".cpp": "C++", ".cc": "C++", ".hpp": "C++", ".cs": "C#", ".php": "PHP",
+	".ex": "Elixir", ".exs": "Elixir", ".scala": "Scala", ".zig": "Zig",
+	".lua": "Lua", ".dart": "Dart", ".vue": "Vue", ".svelte": "Svelte",
+}
+
+// maxMakeTargets caps the Makefile targets listed, so a generated Makefile
+// doesn't flood the context file.
+const maxMakeTargets = 12
+
+// Analyze summarizes the project under root: its top-level layout, entry
+// points, test layout and build commands.
+func Analyze(root string) (*Analysis, error) {
+	if root == "" {
+		root = "."
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := tokens.Expand([]string{root})
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(paths))
+	for _, p := range paths {
+		rel, err := filepath.Rel(root, p)
+		if err == nil {
+			files = append(files, filepath.ToSlash(rel))
+		}
+	}
+
+	a := &Analysis{}
+	langCount := make(map[string]int)
+	dirFiles := make(map[string]int)
+	for _, f := range files {
+		if lang := extLanguages[strings.ToLower(filepath.Ext(f))]; lang != "" {
+			langCount[lang]++
+		}
+		if top, _, ok := strings.Cut(f, "/"); ok {
+			dirFiles[top]++
+		}
+	}
+	a.Languages = topLanguages(langCount, 3)
+
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() || strings.HasPrefix(name, ".") || dirFiles[name] == 0 {
+			continue
+		}
+		a.Dirs = append(a.Dirs, Dir{Path: name + "/", Purpose: dirPurposes[strings.ToLower(name)], Files: dirFiles[name]})
+	}
+
+	a.EntryPoints = findEntryPoints(files)
+	a.Tests = testLayout(files)
+	a.Module, a.Commands = buildCommands(root, files)
+	return a, nil
+}
+
+// topLanguages returns up to n languages by file count.
+func topLanguages(count map[string]int, n int) []string {
+	langs := make([]string, 0, len(count))
+	for l := range count {
+		langs = append(langs, l)
+	}
+	sort.Slice(langs, func(i, j int) bool {
+		if count[langs[i]] != count[langs[j]] {
+			return count[langs[i]] > count[langs[j]]
+		}
+		return langs[i] < langs[j]
+	})
+	if len(langs) > n {
+		langs = langs[:n]
+	}
+	return langs
+}
+
+func findEntryPoints(files []string) []string {
+	var found []string
+	seen := make(map[string]bool)
+	for _, pattern := range entryPoints {
+		for _, f := range files {
+			if !seen[f] && matchPath(pattern, f) {
+				seen[f] = true
+				found = append(found, f)
+			}
+		}
+	}
+	return found
+}
+
+// matchPath matches a slash-separated path against a pattern where * spans
+// one path element and ** any number of them.
+func matchPath(pattern, path string) bool {
+	if before, after, ok := strings.Cut(pattern, "**/"); ok {
+		if !strings.HasPrefix(path, before) {
+			return false
+		}
+		rest := strings.TrimPrefix(path, before)
+		for {
+			if ok, _ := filepath.Match(after, rest); ok {
+				return true
+			}
+			_, next, found := strings.Cut(rest, "/")
+			if !found {
+				return false
+			}
+			rest = next
+		}
+	}
+	ok, _ := filepath.Match(pattern, path)
+	return ok
+}
+
+// testLayout describes where tests live, one line per convention found.
+func testLayout(files []string) []string {
+	type layout struct {
+		desc  string
+		match func(f string) bool
+	}
+	inDir := func(f string, dirs ...string) bool {
+		for _, d := range dirs {
+			if strings.HasPrefix(f, d+"/") || strings.Contains(f, "/"+d+"/") {
+				return true
+			}
+		}
+		return false
+	}
+	layouts := []layout{
+		{"Go tests next to the code they test (*_test.go)", func(f string) bool { return strings.HasSuffix(f, "_test.go") }},
+		{"Python tests (test_*.py / *_test.py)", func(f string) bool {
+			base := filepath.Base(f)
+			return strings.HasSuffix(base, ".py") && (strings.HasPrefix(base, "test_") || strings.HasSuffix(base, "_test.py"))
+		}},
+		{"JavaScript/TypeScript tests (*.test.* / *.spec.*)", func(f string) bool {
+			base := filepath.Base(f)
+			return strings.Contains(base, ".test.") || strings.Contains(base, ".spec.")
+		}},
+		{"Rust integration tests in tests/", func(f string) bool { return strings.HasPrefix(f, "tests/") && strings.HasSuffix(f, ".rs") }},
+		{"Java tests in src/test/", func(f string) bool { return strings.HasPrefix(f, "src/test/") }},
+		{"Ruby specs in spec/", func(f string) bool { return strings.HasPrefix(f, "spec/") && strings.HasSuffix(f, "_spec.rb") }},
+	}
+
+	var out []string
+	claimed := make(map[string]bool)
+	for _, l := range layouts {
+		n := 0
+		for _, f := range files {
+			if !claimed[f] && l.match(f) {
+				claimed[f] = true
+				n++
+			}
+		}
+		if n > 0 {
+			out = append(out, fmt.Sprintf("%s: %d %s", l.desc, n, plural(n, "file")))
+		}
+	}
+	// Anything else under a test directory
+	n := 0
+	for _, f := range files {
+		if !claimed[f] && inDir(f, "test", "tests", "__tests__") {
+			n++
+		}
+	}
+	if n > 0 {
+		out = append(out, fmt.Sprintf("Other files in test directories: %d %s", n, plural(n, "file")))
+	}
+	return out
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+var makeTarget = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9_.-]*)\s*:([^=]|$)`)
+
+// buildCommands reads build and test commands from the project's
+// manifests, along with the module or package name.
+func buildCommands(root string, files []string) (module string, cmds []Command) {
+	has := func(name string) bool {
+		_, err := os.Stat(filepath.Join(root, name))
+		return err == nil
+	}
+
+	if data, err := os.ReadFile(filepath.Join(root, "Makefile")); err == nil {
+		sc := bufio.NewScanner(strings.NewReader(string(data)))
+		n := 0
+		for sc.Scan() && n < maxMakeTargets {
+			m := makeTarget.FindStringSubmatch(sc.Text())
+			if m == nil || strings.HasPrefix(m[1], ".") {
+				continue
+			}
+			cmds = append(cmds, Command{Run: "make " + m[1], Source: "Makefile"})
+			n++
+		}
+	}
+
+	if data, err := os.ReadFile(filepath.Join(root, "package.json")); err == nil {
+		var pkg struct {
+			Name    string            `json:"name"`
+			Scripts map[string]string `json:"scripts"`
+		}
+		if json.Unmarshal(data, &pkg) == nil {
+			module = pkg.Name
+			runner := "npm run"
+			switch {
+			case has("pnpm-lock.yaml"):
+				runner = "pnpm"
+			case has("yarn.lock"):
+				runner = "yarn"
+			case has("bun.lockb"), has("bun.lock"):
+				runner = "bun run"
+			}
+			for _, name := range scriptOrder(pkg.Scripts) {
+				cmds = append(cmds, Command{Run: runner + " " + name, Source: "package.json"})
+			}
+		}
+	}
+
+	if data, err := os.ReadFile(filepath.Join(root, "go.mod")); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+				module = strings.TrimSpace(rest)
+				break
+			}
+		}
+		cmds = append(cmds,
+			Command{Run: "go build ./...", Source: "go.mod"},
+			Command{Run: "go vet ./...", Source: "go.mod"},
+			Command{Run: "go test ./...", Source: "go.mod"},
+		)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(root, "Cargo.toml")); err == nil {
+		if m := regexp.MustCompile(`(?m)^name\s*=\s*"([^"]+)"`).FindSubmatch(data); m != nil && module == "" {
+			module = string(m[1])
+		}
+		cmds = append(cmds,
+			Command{Run: "cargo build", Source: "Cargo.toml"},
+			Command{Run: "cargo test", Source: "Cargo.toml"},
+			Command{Run: "cargo clippy", Source: "Cargo.toml"},
+		)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(root, "pyproject.toml")); err == nil {
+		if m := regexp.MustCompile(`(?m)^name\s*=\s*"([^"]+)"`).FindSubmatch(data); m != nil && module == "" {
+			module = string(m[1])
+		}
+		if strings.Contains(string(data), "pytest") || len(testLayout(filterSuffix(files, ".py"))) > 0 {
+			cmds = append(cmds, Command{Run: "pytest", Source: "pyproject.toml"})
+		}
+		if strings.Contains(string(data), "[tool.ruff") {
+			cmds = append(cmds, Command{Run: "ruff check .", Source: "pyproject.toml"})
+		}
+	}
+	return module, cmds
+}
+
+// scriptOrder lists package.json scripts with the common ones first.
+func scriptOrder(scripts map[string]string) []string {
+	rank := map[string]int{"dev": 1, "build": 2, "start": 3, "test": 4, "lint": 5, "typecheck": 6, "format": 7}
+	names := make([]string, 0, len(scripts))
+	for name := range scripts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		ri, rj := rank[names[i]], rank[names[j]]
+		if ri == 0 {
+			ri = len(rank) + 1
+		}
+		if rj == 0 {
+			rj = len(rank) + 1
+		}
+		if ri != rj {
+			return ri < rj
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+func filterSuffix(files []string, suffix string) []string {
+	var out []string
+	for _, f := range files {
+		if strings.HasSuffix(f, suffix) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// Markdown renders the analysis as context file sections.
+func (a *Analysis) Markdown() string {
+	var b strings.Builder
+	b.WriteString("## Project Structure\n\n")
+	if a.Module != "" {
+		fmt.Fprintf(&b, "Module: `%s`\n", a.Module)
+	}
+	if len(a.Languages) > 0 {
+		fmt.Fprintf(&b, "Languages: %s\n", strings.Join(a.Languages, ", "))
+	}
+	if a.Module != "" || len(a.Languages) > 0 {
+		b.WriteString("\n")
+	}
+	if len(a.Dirs) == 0 {
+		b.WriteString("All code is in the project root.\n")
+	}
+	for _, d := range a.Dirs {
+		fmt.Fprintf(&b, "- `%s`", d.Path)
+		if d.Purpose != "" {
+			fmt.Fprintf(&b, " — %s", d.Purpose)
+		}
+		fmt.Fprintf(&b, " (%d %s)\n", d.Files, plural(d.Files, "file"))
+	}
+
+	if len(a.EntryPoints) > 0 {
+		b.WriteString("\n## Entry Points\n\n")
+		for _, e := range a.EntryPoints {
+			fmt.Fprintf(&b, "- `%s`\n", e)
+		}
+	}
+
+	b.WriteString("\n## Tests\n\n")
+	if len(a.Tests) == 0 {
+		b.WriteString("No tests found.\n")
+	}
+	for _, t := range a.Tests {
+		fmt.Fprintf(&b, "- %s\n", t)
+	}
+
+	if len(a.Commands) > 0 {
+		b.WriteString("\n## Build & Test Commands\n\n```sh\n")
+		for _, c := range a.Commands {
+			b.WriteString(c.Run + "\n")
+		}
+		b.WriteString("```\n")
+	}
+	return b.String()
+}
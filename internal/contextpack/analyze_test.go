@@ -0,0 +1,86 @@
+package contextpack
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"go.mod":                     "module example.com/shop\n\ngo 1.24\n",
+		"Makefile":                   ".PHONY: build\nVERSION := 1.0\nbuild:\n\tgo build\nlint: vet\n\tgolangci-lint run\n",
+		"main.go":                    "package main\n",
+		"cmd/shopd/main.go":          "package main\n",
+		"internal/cart/cart.go":      "package cart\n",
+		"internal/cart/cart_test.go": "package cart\n",
+		"web/src/index.ts":           "export {}\n",
+		"web/src/cart.test.ts":       "test('x', () => {})\n",
+		"node_modules/x/index.js":    "ignored\n",
+		"assets/logo.png":            "\x89PNG",
+	})
+
+	a, err := Analyze(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Module != "example.com/shop" {
+		t.Errorf("module = %q", a.Module)
+	}
+	if len(a.Languages) != 2 || a.Languages[0] != "Go" {
+		t.Errorf("languages = %v", a.Languages)
+	}
+
+	var dirs []string
+	for _, d := range a.Dirs {
+		dirs = append(dirs, d.Path+":"+d.Purpose)
+	}
+	if got := strings.Join(dirs, ","); got != "cmd/:command entry points,internal/:private packages,web/:frontend" {
+		t.Errorf("dirs = %s", got)
+	}
+
+	if got := strings.Join(a.EntryPoints, ","); got != "main.go,cmd/shopd/main.go" {
+		t.Errorf("entry points = %s", got)
+	}
+	if len(a.Tests) != 2 || !strings.Contains(a.Tests[0], "*_test.go): 1 file") || !strings.Contains(a.Tests[1], ".spec.") {
+		t.Errorf("tests = %v", a.Tests)
+	}
+
+	var runs []string
+	for _, c := range a.Commands {
+		runs = append(runs, c.Run)
+	}
+	if got := strings.Join(runs, ","); got != "make build,make lint,go build ./...,go vet ./...,go test ./..." {
+		t.Errorf("commands = %s", got)
+	}
+
+	md := a.Markdown()
+	for _, want := range []string{"## Project Structure", "- `internal/` — private packages (2 files)", "## Entry Points", "```sh\nmake build\n"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"cmd/*/main.go", "cmd/palm/main.go", true},
+		{"cmd/*/main.go", "cmd/a/b/main.go", false},
+		{"src/main/java/**/Application.java", "src/main/java/com/acme/Application.java", true},
+		{"src/main/java/**/Application.java", "src/test/java/com/acme/Application.java", false},
+	}
+	for _, tt := range tests {
+		if got := matchPath(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("matchPath(%q, %q) = %v", tt.pattern, tt.path, got)
+		}
+	}
+}
+
+func TestScriptOrder(t *testing.T) {
+	got := scriptOrder(map[string]string{"release": "", "test": "", "dev": "", "build": "", "ci": ""})
+	if strings.Join(got, ",") != "dev,build,test,ci,release" {
+		t.Errorf("scriptOrder = %v", got)
+	}
+}
@@ -0,0 +1,81 @@
+package contextpack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+)
+
+// Embedder turns texts into vectors for semantic ranking.
+type Embedder func(texts []string) ([][]float64, error)
+
+// embedChars is how much of each file is embedded; the head of a file
+// (package doc, imports, type names) is usually the most telling part.
+const embedChars = 2000
+
+// OllamaEmbedder embeds with a local Ollama model such as nomic-embed-text.
+func OllamaEmbedder(endpoint, model string) Embedder {
+	client := &http.Client{Timeout: 2 * time.Minute}
+	return func(texts []string) ([][]float64, error) {
+		body, _ := json.Marshal(map[string]any{"model": model, "input": texts})
+		resp, err := client.Post(endpoint+"/api/embed", "application/json", bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("ollama: %s (is %s pulled?)", resp.Status, model)
+		}
+		var out struct {
+			Embeddings [][]float64 `json:"embeddings"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			return nil, err
+		}
+		if len(out.Embeddings) != len(texts) {
+			return nil, fmt.Errorf("ollama returned %d embeddings for %d inputs", len(out.Embeddings), len(texts))
+		}
+		return out.Embeddings, nil
+	}
+}
+
+// similarities returns the cosine similarity of each file to the query.
+func similarities(embed Embedder, query string, files []File) ([]float64, error) {
+	texts := make([]string, 0, len(files)+1)
+	texts = append(texts, query)
+	for _, f := range files {
+		head := f.content
+		if len(head) > embedChars {
+			head = head[:embedChars]
+		}
+		texts = append(texts, f.Path+"\n"+head)
+	}
+	vecs, err := embed(texts)
+	if err != nil {
+		return nil, err
+	}
+	sims := make([]float64, len(files))
+	for i := range files {
+		sims[i] = cosine(vecs[0], vecs[i+1])
+	}
+	return sims, nil
+}
+
+func cosine(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,333 @@
+package contextpack
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/msalah0e/palm/internal/tokens"
+)
+
+// Options controls how files are ranked and packed.
+type Options struct {
+	Root      string // project directory; defaults to "."
+	Query     string
+	Budget    int // token budget for the whole bundle
+	Tokenizer tokens.Tokenizer
+	NoChurn   bool     // skip git history
+	Embedder  Embedder // optional semantic similarity
+	Include   []string // extra files always packed first
+}
+
+// File is a ranked candidate file.
+type File struct {
+	Path    string   `json:"path"`
+	Tokens  int      `json:"tokens"`
+	Score   float64  `json:"score"`
+	Reasons []string `json:"reasons,omitempty"`
+	content string
+}
+
+// Pack is a context bundle that fits a token budget.
+type Pack struct {
+	Query   string `json:"query"`
+	Budget  int    `json:"budget"`
+	Tokens  int    `json:"tokens"`
+	Files   []File `json:"files"`
+	Skipped []File `json:"skipped,omitempty"` // relevant but over budget
+}
+
+// Ranking weights. Path and content matches dominate; churn only breaks ties
+// between otherwise relevant files.
+const (
+	pathWeight    = 0.35
+	contentWeight = 0.40
+	churnWeight   = 0.15
+	embedWeight   = 0.30
+)
+
+// Rank scores every code file under opts.Root against the query, most
+// relevant first. Files with no path, content or semantic match are dropped.
+func Rank(opts Options) ([]File, error) {
+	root := opts.Root
+	if root == "" {
+		root = "."
+	}
+	if opts.Tokenizer == nil {
+		opts.Tokenizer = tokens.DefaultTokenizer()
+	}
+	query := terms(opts.Query)
+	if len(query) == 0 && opts.Embedder == nil {
+		return nil, fmt.Errorf("query has no searchable words")
+	}
+
+	paths, err := tokens.Expand([]string{root})
+	if err != nil {
+		return nil, err
+	}
+
+	var files []File
+	tf := make([]map[string]int, 0, len(paths))
+	df := make(map[string]int)
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil || info.Size() > 256*1024 {
+			continue
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		rel, _ := filepath.Rel(root, p)
+		f := File{Path: filepath.ToSlash(rel), content: string(data)}
+		counts := make(map[string]int)
+		for _, t := range terms(f.content) {
+			counts[t]++
+		}
+		for _, q := range query {
+			if counts[q] > 0 {
+				df[q]++
+			}
+		}
+		files = append(files, f)
+		tf = append(tf, counts)
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	churn := map[string]int{}
+	if !opts.NoChurn {
+		churn = gitChurn(root)
+	}
+	maxChurn := 0
+	for _, c := range churn {
+		maxChurn = max(maxChurn, c)
+	}
+
+	var sims []float64
+	if opts.Embedder != nil {
+		sims, err = similarities(opts.Embedder, opts.Query, files)
+		if err != nil {
+			return nil, fmt.Errorf("embeddings: %w", err)
+		}
+	}
+
+	pathScores := make([]float64, len(files))
+	contentScores := make([]float64, len(files))
+	var maxPath, maxContent float64
+	n := float64(len(files))
+	for i, f := range files {
+		pathScores[i] = pathScore(f.Path, query)
+		for _, q := range query {
+			if c := tf[i][q]; c > 0 {
+				idf := math.Log(1 + n/float64(df[q]))
+				contentScores[i] += idf * math.Log(1+float64(c))
+			}
+		}
+		maxPath = math.Max(maxPath, pathScores[i])
+		maxContent = math.Max(maxContent, contentScores[i])
+	}
+
+	var ranked []File
+	for i, f := range files {
+		var reasons []string
+		score := 0.0
+		if pathScores[i] > 0 {
+			score += pathWeight * pathScores[i] / maxPath
+			reasons = append(reasons, "path")
+		}
+		if contentScores[i] > 0 {
+			score += contentWeight * contentScores[i] / maxContent
+			reasons = append(reasons, "content")
+		}
+		if sims != nil && sims[i] > 0.3 {
+			score += embedWeight * sims[i]
+			reasons = append(reasons, fmt.Sprintf("semantic %.2f", sims[i]))
+		}
+		if score == 0 {
+			continue
+		}
+		if c := churn[f.Path]; c > 0 && maxChurn > 0 {
+			score += churnWeight * math.Log(1+float64(c)) / math.Log(1+float64(maxChurn))
+			if c == 1 {
+				reasons = append(reasons, "1 commit")
+			} else {
+				reasons = append(reasons, fmt.Sprintf("%d commits", c))
+			}
+		}
+		f.Score = score
+		f.Reasons = reasons
+		ranked = append(ranked, f)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Score > ranked[j].Score })
+	for i := range ranked {
+		ranked[i].Tokens = opts.Tokenizer.Count(ranked[i].section())
+	}
+	return ranked, nil
+}
+
+// Build ranks files and greedily packs the most relevant ones that fit the
+// budget. Files listed in opts.Include are packed first regardless of rank.
+func Build(opts Options) (*Pack, error) {
+	if opts.Budget <= 0 {
+		return nil, fmt.Errorf("budget must be positive")
+	}
+	if opts.Tokenizer == nil {
+		opts.Tokenizer = tokens.DefaultTokenizer()
+	}
+	ranked, err := Rank(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Pack{Query: opts.Query, Budget: opts.Budget}
+	p.Tokens = opts.Tokenizer.Count(p.header())
+	seen := make(map[string]bool)
+
+	for _, inc := range opts.Include {
+		data, err := os.ReadFile(inc)
+		if err != nil {
+			return nil, err
+		}
+		f := File{Path: filepath.ToSlash(inc), Reasons: []string{"included"}, content: string(data)}
+		f.Tokens = opts.Tokenizer.Count(f.section())
+		if p.Tokens+f.Tokens > p.Budget {
+			return nil, fmt.Errorf("%s alone needs %s tokens, over the %s budget",
+				inc, tokens.FormatTokens(f.Tokens), tokens.FormatTokens(p.Budget))
+		}
+		p.add(f)
+		seen[f.Path] = true
+	}
+
+	for _, f := range ranked {
+		if seen[f.Path] {
+			continue
+		}
+		if p.Tokens+f.Tokens > p.Budget {
+			p.Skipped = append(p.Skipped, f)
+			continue
+		}
+		p.add(f)
+	}
+	return p, nil
+}
+
+func (p *Pack) add(f File) {
+	p.Files = append(p.Files, f)
+	p.Tokens += f.Tokens
+}
+
+func (p *Pack) header() string {
+	return fmt.Sprintf("# Context for: %s\n\n", p.Query)
+}
+
+// Render returns the bundle: a header followed by each file in a fenced block.
+func (p *Pack) Render() string {
+	var b strings.Builder
+	b.WriteString(p.header())
+	for _, f := range p.Files {
+		b.WriteString(f.section())
+	}
+	return b.String()
+}
+
+func (f File) section() string {
+	lang := strings.TrimPrefix(filepath.Ext(f.Path), ".")
+	body := f.content
+	if !strings.HasSuffix(body, "\n") {
+		body += "\n"
+	}
+	return fmt.Sprintf("## %s\n\n```%s\n%s```\n\n", f.Path, lang, body)
+}
+
+// pathScore rewards query words that appear in the path, most of all in the
+// file name itself.
+func pathScore(path string, query []string) float64 {
+	dir, base := filepath.Split(path)
+	stem := strings.TrimSuffix(base, filepath.Ext(base))
+	nameTerms := make(map[string]bool)
+	for _, t := range terms(splitIdent(stem)) {
+		nameTerms[t] = true
+	}
+	dirTerms := make(map[string]bool)
+	for _, t := range terms(splitIdent(dir)) {
+		dirTerms[t] = true
+	}
+
+	score := 0.0
+	for _, q := range query {
+		switch {
+		case nameTerms[q]:
+			score += 3
+		case dirTerms[q]:
+			score += 2
+		case strings.Contains(strings.ToLower(stem), q):
+			score += 1
+		}
+	}
+	return score
+}
+
+// splitIdent breaks camelCase so "authMiddleware" also matches "auth".
+func splitIdent(s string) string {
+	var b strings.Builder
+	prev := rune(0)
+	for _, r := range s {
+		if unicode.IsUpper(r) && unicode.IsLower(prev) {
+			b.WriteRune(' ')
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+	return b.String()
+}
+
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"bug": true, "by": true, "do": true, "fix": true, "for": true, "from": true, "how": true,
+	"in": true, "is": true, "it": true, "of": true, "on": true, "or": true, "the": true,
+	"this": true, "to": true, "with": true, "add": true, "make": true, "update": true,
+}
+
+// terms lowercases text into searchable words. Task verbs like "fix" and
+// "add" are dropped since they say nothing about which files matter.
+func terms(text string) []string {
+	var out []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(w) > 1 && !stopwords[w] {
+			// Crude plural folding so "tokens" matches "token"
+			if len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") {
+				w = w[:len(w)-1]
+			}
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// gitChurn counts commits touching each file in the last 90 days, keyed by
+// path relative to root. It returns an empty map outside a git repository.
+func gitChurn(root string) map[string]int {
+	churn := make(map[string]int)
+	c := exec.Command("git", "log", "--since=90.days", "--name-only", "--format=", "--relative", "--", ".")
+	c.Dir = root
+	out, err := c.Output()
+	if err != nil {
+		return churn
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			churn[line]++
+		}
+	}
+	return churn
+}
@@ -0,0 +1,123 @@
+package contextpack
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/msalah0e/palm/internal/tokens"
+)
+
+func writeProject(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		_ = os.MkdirAll(filepath.Dir(p), 0o755)
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestRankPrefersPathAndContentMatches(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"auth/login.go":   "package auth\n\nfunc Login() { checkToken() }\n",
+		"server/auth.go":  "package server\n\n// session token validation\n",
+		"util/strings.go": "package util\n\nfunc Reverse(s string) string { return s }\n",
+	})
+
+	ranked, err := Rank(Options{Root: dir, Query: "fix the auth token bug", Tokenizer: tokens.Estimator, NoChurn: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ranked) != 2 {
+		t.Fatalf("expected 2 relevant files, got %d", len(ranked))
+	}
+	if ranked[0].Path != "server/auth.go" {
+		t.Errorf("expected server/auth.go first, got %s", ranked[0].Path)
+	}
+	for _, f := range ranked {
+		if f.Path == "util/strings.go" {
+			t.Error("unrelated file should not be ranked")
+		}
+	}
+}
+
+func TestBuildRespectsBudget(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"billing.go":     "package x\n// billing invoice\n",
+		"billing_big.go": "package x\n// billing\n" + strings.Repeat("var billingValue = 1\n", 400),
+	})
+
+	p, err := Build(Options{Root: dir, Query: "billing", Budget: 200, Tokenizer: tokens.Estimator, NoChurn: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Tokens > p.Budget {
+		t.Errorf("pack uses %d tokens, over budget %d", p.Tokens, p.Budget)
+	}
+	if len(p.Files) != 1 || p.Files[0].Path != "billing.go" {
+		t.Errorf("expected only billing.go packed, got %+v", p.Files)
+	}
+	if len(p.Skipped) != 1 {
+		t.Errorf("expected the large file to be skipped, got %+v", p.Skipped)
+	}
+
+	out := p.Render()
+	if !strings.Contains(out, "# Context for: billing") || !strings.Contains(out, "## billing.go\n\n```go\n") {
+		t.Errorf("unexpected bundle:\n%s", out)
+	}
+}
+
+func TestBuildIncludeComesFirst(t *testing.T) {
+	dir := writeProject(t, map[string]string{"api.go": "package x // api handler\n"})
+	notes := filepath.Join(dir, "NOTES.txt")
+	_ = os.WriteFile(notes, []byte("remember the api"), 0o644)
+
+	p, err := Build(Options{Root: dir, Query: "api", Budget: 1000, Tokenizer: tokens.Estimator, NoChurn: true, Include: []string{notes}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Files) < 1 || p.Files[0].Reasons[0] != "included" {
+		t.Errorf("expected included file first, got %+v", p.Files)
+	}
+}
+
+func TestRankWithEmbedder(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"a.go": "package a // alpha",
+		"b.go": "package b // beta",
+	})
+	// Fake embedder: the query and b.go point the same way, a.go is orthogonal
+	embed := func(texts []string) ([][]float64, error) {
+		vecs := make([][]float64, len(texts))
+		for i, t := range texts {
+			if i == 0 || strings.HasPrefix(t, "b.go") {
+				vecs[i] = []float64{1, 0}
+			} else {
+				vecs[i] = []float64{0, 1}
+			}
+		}
+		return vecs, nil
+	}
+
+	ranked, err := Rank(Options{Root: dir, Query: "greek letters", Tokenizer: tokens.Estimator, NoChurn: true, Embedder: embed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ranked) != 1 || ranked[0].Path != "b.go" {
+		t.Errorf("expected only b.go by semantic match, got %+v", ranked)
+	}
+}
+
+func TestPathScoreSplitsIdentifiers(t *testing.T) {
+	if pathScore("internal/authMiddleware.go", []string{"auth"}) != 3 {
+		t.Error("expected camelCase file name to match auth")
+	}
+	if pathScore("auth/handler.go", []string{"auth"}) != 2 {
+		t.Error("expected directory match")
+	}
+}
@@ -0,0 +1,312 @@
+// Package dash draws palm's live dashboard: running AI processes, the proxy
+// request feed, budget burn-down and recent graph and compose activity in
+// one screen that redraws in place.
+package dash
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/msalah0e/palm/internal/activity"
+	"github.com/msalah0e/palm/internal/budget"
+	"github.com/msalah0e/palm/internal/gpu"
+	"github.com/msalah0e/palm/internal/graph"
+	"github.com/msalah0e/palm/internal/proxy"
+	"github.com/msalah0e/palm/internal/session"
+	"github.com/msalah0e/palm/internal/top"
+	"github.com/msalah0e/palm/internal/ui"
+)
+
+// Config configures the dashboard.
+type Config struct {
+	RefreshInterval time.Duration
+	KnownBinaries   map[string]string // binary name → display name, as for top
+	ProxyPort       int
+	Rows            int // entries shown in the process, request and activity panels
+}
+
+// Snapshot is everything one frame shows.
+type Snapshot struct {
+	Time   time.Time
+	System top.SystemStats
+	Procs  []top.ProcessInfo
+
+	ProxyRunning bool
+	ProxyPID     int
+	ProxyStats   *proxy.ProxyStats // nil when the proxy didn't answer
+	Requests     []proxy.RequestLog
+
+	Budget *budget.Status
+	Daily  []float64 // spend per day this month, from the 1st to today
+
+	Entities, Relations int
+	Activity            []activity.Entry // newest first
+}
+
+// The dashboard draws in the theme's colors
+var (
+	brand  = ui.Brand
+	subtle = ui.Subtle
+	good   = ui.Good
+	warn   = ui.Warn
+	bad    = ui.Bad
+	cyan   = ui.Info
+)
+
+// Run redraws the dashboard every cfg.RefreshInterval until interrupted.
+func Run(cfg Config) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Print("\033[?25l")
+	defer fmt.Print("\033[?25h\n")
+
+	gpus := gpu.Detect()
+	ticker := time.NewTicker(cfg.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		var frame strings.Builder
+		Render(&frame, Collect(cfg, gpus), cfg, Width())
+		// One write per frame, so the screen doesn't flicker
+		fmt.Print("\033[H\033[J" + frame.String())
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Collect gathers a snapshot. Sources that fail are left empty.
+func Collect(cfg Config, gpus []gpu.Info) Snapshot {
+	s := Snapshot{Time: time.Now(), System: top.Stats(gpus), Procs: top.Scan(cfg.KnownBinaries)}
+
+	s.ProxyRunning, s.ProxyPID = proxy.IsRunning()
+	if s.ProxyRunning {
+		s.ProxyStats = fetchProxyStats(cfg.ProxyPort)
+	}
+	s.Requests, _ = proxy.ReadLogs(cfg.Rows)
+
+	s.Budget, _ = budget.GetStatus()
+	if sessions, err := session.List(0); err == nil {
+		s.Daily = dailySpend(sessions, s.Time)
+	}
+
+	if g, err := graph.Load(); err == nil {
+		s.Entities, s.Relations = len(g.Entities), len(g.Relations)
+	}
+	s.Activity, _ = activity.Query(activity.Filter{Types: []string{"graph", "compose"}}, cfg.Rows)
+	return s
+}
+
+// fetchProxyStats asks the running proxy for its live counters. The proxy
+// may serve HTTPS with a self-signed certificate; it is on this machine, so
+// the certificate isn't checked.
+func fetchProxyStats(port int) *proxy.ProxyStats {
+	client := &http.Client{
+		Timeout:   500 * time.Millisecond,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+	}
+	for _, scheme := range []string{"http", "https"} {
+		resp, err := client.Get(fmt.Sprintf("%s://127.0.0.1:%d/palm/stats", scheme, port))
+		if err != nil {
+			continue
+		}
+		var stats proxy.ProxyStats
+		err = json.NewDecoder(resp.Body).Decode(&stats)
+		resp.Body.Close()
+		if err == nil && resp.StatusCode == http.StatusOK {
+			return &stats
+		}
+	}
+	return nil
+}
+
+// dailySpend sums session cost per day of now's month, up to today.
+func dailySpend(sessions []session.Session, now time.Time) []float64 {
+	days := make([]float64, now.Day())
+	for _, sess := range sessions {
+		t := sess.StartedAt.In(now.Location())
+		if t.Year() == now.Year() && t.Month() == now.Month() && t.Day() <= now.Day() {
+			days[t.Day()-1] += sess.Cost
+		}
+	}
+	return days
+}
+
+// Width returns the terminal width from $COLUMNS, or 80.
+func Width() int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n >= 60 {
+		return n
+	}
+	return 80
+}
+
+// Render draws s as one frame, width columns wide.
+func Render(w io.Writer, s Snapshot, cfg Config, width int) {
+	rule := subtle.Sprint("  " + strings.Repeat("─", width-4))
+	title := brand.Sprint("  🌴 palm dash")
+	fmt.Fprintf(w, "%s%*s\n", title, width-16, s.Time.Format("15:04:05"))
+
+	// Processes
+	section(w, rule, "Processes", fmt.Sprintf("CPU %.0f%% · MEM %.0f%% (%.1f / %.1f GB)",
+		s.System.CPUPercent, s.System.MemPercent, float64(s.System.MemUsed)/1024, float64(s.System.MemTotal)/1024))
+	if len(s.Procs) == 0 {
+		subtle.Fprintln(w, "  No AI processes running")
+	}
+	for i, p := range s.Procs {
+		if i == cfg.Rows {
+			subtle.Fprintf(w, "  … %d more (palm top)\n", len(s.Procs)-i)
+			break
+		}
+		fmt.Fprintf(w, "  %-7d %-18s %5.1f%% %7.0f MB  %s\n", p.PID, brand.Sprint(clip(p.Name, 18)), p.CPU, p.MemMB, subtle.Sprint(clip(p.Cmd, width-48)))
+	}
+
+	// Proxy
+	switch {
+	case !s.ProxyRunning:
+		section(w, rule, "Proxy", subtle.Sprint("not running (palm proxy start --bg)"))
+	case s.ProxyStats == nil:
+		section(w, rule, "Proxy", fmt.Sprintf("PID %d on port %d · not answering", s.ProxyPID, cfg.ProxyPort))
+	default:
+		st := s.ProxyStats
+		summary := fmt.Sprintf("PID %d · %d requests · up %s", s.ProxyPID, st.TotalRequests, time.Since(st.StartedAt).Round(time.Minute))
+		if st.Failovers > 0 {
+			summary += fmt.Sprintf(" · %d failovers", st.Failovers)
+		}
+		if st.Downgrades > 0 {
+			summary += fmt.Sprintf(" · %d downgraded", st.Downgrades)
+		}
+		waiting := 0
+		for _, q := range st.Queues {
+			waiting += q.Waiting
+		}
+		if waiting > 0 {
+			summary += fmt.Sprintf(" · %d queued", waiting)
+		}
+		section(w, rule, "Proxy", summary)
+	}
+	if len(s.Requests) == 0 {
+		subtle.Fprintln(w, "  No requests logged yet")
+	}
+	for i := len(s.Requests) - 1; i >= 0; i-- {
+		r := s.Requests[i]
+		status := good.Sprint(r.Status)
+		if r.Status >= 400 {
+			status = bad.Sprint(r.Status)
+		}
+		what := r.Model
+		if what == "" {
+			what = r.Path
+		}
+		client := ""
+		if r.Client != "" {
+			client = subtle.Sprint(" · " + r.Client)
+		}
+		fmt.Fprintf(w, "  %s  %-10s %s %6.0fms  %s%s\n", subtle.Sprint(r.Timestamp.Format("15:04:05")), clip(r.Provider, 10), status, r.Duration, clip(what, width-48), client)
+	}
+
+	// Budget
+	renderBudget(w, rule, s, width)
+
+	// Graph and compose activity
+	section(w, rule, "Activity", fmt.Sprintf("graph: %d entities · %d relations", s.Entities, s.Relations))
+	if len(s.Activity) == 0 {
+		subtle.Fprintln(w, "  No graph or compose activity yet")
+	}
+	for _, e := range s.Activity {
+		icon := good.Sprint("✓")
+		if e.Status == "failed" {
+			icon = bad.Sprint("✗")
+		}
+		fmt.Fprintf(w, "  %s %s %-16s %s\n", subtle.Sprint(e.Timestamp.Format("Jan 02 15:04")), icon, cyan.Sprint(clip(e.Action, 16)), clip(e.Details, width-38))
+	}
+
+	fmt.Fprintln(w, rule)
+	subtle.Fprintf(w, "  Refresh: %s · Ctrl+C to exit\n", cfg.RefreshInterval)
+}
+
+func renderBudget(w io.Writer, rule string, s Snapshot, width int) {
+	b := s.Budget
+	if b == nil {
+		section(w, rule, "Budget", subtle.Sprint("unavailable"))
+		return
+	}
+	if b.MonthlyLimit == 0 {
+		section(w, rule, "Budget", fmt.Sprintf("$%.2f this month · no limit set (palm budget set --monthly 50)", b.MonthlySpend))
+	} else {
+		state := good
+		if b.IsOverBudget {
+			state = bad
+		} else if b.IsNearBudget {
+			state = warn
+		}
+		section(w, rule, "Budget", state.Sprintf("$%.2f of $%.2f (%.0f%%)", b.MonthlySpend, b.MonthlyLimit, b.PercentUsed)+" "+b.CurrentMonth)
+		fmt.Fprintf(w, "  %s\n", bar(b.PercentUsed, width-8))
+	}
+
+	if len(s.Daily) > 0 {
+		fmt.Fprintf(w, "  %s %s\n", subtle.Sprint("daily"), sparkline(s.Daily))
+	}
+	if line := burnDown(b, s.Time); line != "" {
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+}
+
+// burnDown projects month-end spend at the pace so far, and when a monthly
+// limit runs out at that pace.
+func burnDown(b *budget.Status, now time.Time) string {
+	if b.MonthlySpend <= 0 {
+		return ""
+	}
+	daysIn := time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, now.Location()).Day()
+	perDay := b.MonthlySpend / float64(now.Day())
+	line := fmt.Sprintf("$%.2f/day · on pace for $%.2f by month end", perDay, perDay*float64(daysIn))
+	if b.MonthlyLimit > 0 && !b.IsOverBudget {
+		left := (b.MonthlyLimit - b.MonthlySpend) / perDay
+		if out := now.AddDate(0, 0, int(left)); out.Month() == now.Month() {
+			line += " · " + warn.Sprintf("limit reached ~%s", out.Format("Jan 2"))
+		} else {
+			line += " · " + good.Sprint("within limit")
+		}
+	}
+	return line
+}
+
+func section(w io.Writer, rule, name, summary string) {
+	fmt.Fprintln(w, rule)
+	fmt.Fprintf(w, "  %s  %s\n", brand.Sprint(name), summary)
+}
+
+func bar(pct float64, width int) string {
+	filled := int(pct / 100 * float64(width))
+	filled = max(0, min(filled, width))
+	return "[" + brand.Sprint(strings.Repeat("█", filled)) + subtle.Sprint(strings.Repeat("░", width-filled)) + "]"
+}
+
+// sparkline draws values as a row of block heights.
+func sparkline(values []float64) string {
+	return "[" + ui.Sparkline(values) + "]"
+}
+
+func clip(s string, n int) string {
+	if n < 4 {
+		n = 4
+	}
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
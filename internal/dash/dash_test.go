@@ -0,0 +1,75 @@
+package dash
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/msalah0e/palm/internal/activity"
+	"github.com/msalah0e/palm/internal/budget"
+	"github.com/msalah0e/palm/internal/proxy"
+	"github.com/msalah0e/palm/internal/session"
+	"github.com/msalah0e/palm/internal/top"
+)
+
+func TestRender(t *testing.T) {
+	color.NoColor = true
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	s := Snapshot{
+		Time:         now,
+		Procs:        []top.ProcessInfo{{PID: 42, Name: "Claude Code", CPU: 12.5, MemMB: 300, Cmd: "claude"}},
+		ProxyRunning: true,
+		ProxyPID:     7,
+		ProxyStats:   &proxy.ProxyStats{TotalRequests: 3, StartedAt: now.Add(-time.Hour)},
+		Requests:     []proxy.RequestLog{{Timestamp: now, Provider: "openai", Model: "gpt-4.1", Status: 200, Client: "laptop"}},
+		Budget:       &budget.Status{MonthlyLimit: 100, MonthlySpend: 50, PercentUsed: 50, CurrentMonth: "March 2026"},
+		Daily:        []float64{0, 5, 10},
+		Entities:     4,
+		Relations:    2,
+		Activity:     []activity.Entry{{Timestamp: now, Action: "graph.add", Details: "Alice", Status: "ok"}},
+	}
+
+	var b strings.Builder
+	Render(&b, s, Config{RefreshInterval: time.Second, Rows: 5, ProxyPort: 4778}, 80)
+	out := b.String()
+	for _, want := range []string{"Claude Code", "3 requests", "gpt-4.1", "laptop", "$50.00 of $100.00", "4 entities", "graph.add"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("frame lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestBurnDown(t *testing.T) {
+	now := time.Date(2026, 4, 10, 12, 0, 0, 0, time.UTC)
+	color.NoColor = true
+
+	// $5/day against $200: 30 days left, past the end of April
+	line := burnDown(&budget.Status{MonthlySpend: 50, MonthlyLimit: 200}, now)
+	if !strings.Contains(line, "$5.00/day") || !strings.Contains(line, "$150.00") || !strings.Contains(line, "within limit") {
+		t.Errorf("unexpected burn-down %q", line)
+	}
+	// $5/day against $70: runs out in 4 days
+	if line := burnDown(&budget.Status{MonthlySpend: 50, MonthlyLimit: 70}, now); !strings.Contains(line, "Apr 14") {
+		t.Errorf("expected limit date, got %q", line)
+	}
+	if burnDown(&budget.Status{}, now) != "" {
+		t.Error("no spend should give no burn-down")
+	}
+}
+
+func TestDailySpend(t *testing.T) {
+	now := time.Date(2026, 4, 3, 12, 0, 0, 0, time.UTC)
+	days := dailySpend([]session.Session{
+		{StartedAt: time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC), Cost: 1},
+		{StartedAt: time.Date(2026, 4, 3, 9, 0, 0, 0, time.UTC), Cost: 2},
+		{StartedAt: time.Date(2026, 4, 3, 10, 0, 0, 0, time.UTC), Cost: 0.5},
+		{StartedAt: time.Date(2026, 3, 31, 9, 0, 0, 0, time.UTC), Cost: 9},
+	}, now)
+	if len(days) != 3 || days[0] != 1 || days[1] != 0 || days[2] != 2.5 {
+		t.Errorf("daily spend = %v", days)
+	}
+	if got := sparkline([]float64{0, 1, 2}); got != "[ ▅█]" {
+		t.Errorf("sparkline = %q", got)
+	}
+}
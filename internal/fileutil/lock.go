@@ -0,0 +1,45 @@
+// Package fileutil holds file helpers shared by palm's state files.
+package fileutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockStale is the age after which a lock file is assumed abandoned by a
+// process that died holding it.
+const lockStale = 10 * time.Second
+
+// lockWait is how long Lock waits for another process to let go.
+const lockWait = 2 * time.Second
+
+// Lock takes an exclusive lock on path, held across palm processes through
+// a path+".lock" file, and returns the function that releases it. It fails
+// when the lock is still held by someone else after a short wait.
+func Lock(path string) (unlock func(), err error) {
+	lockPath := path + ".lock"
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0o700); err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(lockWait)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > lockStale {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s is locked by another palm process (remove %s if none is running)", filepath.Base(path), lockPath)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,36 @@
+package fileutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "data.json")
+
+	unlock, err := Lock(path)
+	if err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+	if _, err := Lock(path); err == nil {
+		t.Fatal("a held lock should not be taken twice")
+	}
+	unlock()
+	unlock, err = Lock(path)
+	if err != nil {
+		t.Fatalf("Lock after release: %v", err)
+	}
+	unlock()
+
+	// A lock left behind by a dead process is taken over
+	old := time.Now().Add(-time.Minute)
+	os.WriteFile(path+".lock", nil, 0o600)
+	os.Chtimes(path+".lock", old, old)
+	unlock, err = Lock(path)
+	if err != nil {
+		t.Fatalf("a stale lock should be taken over: %v", err)
+	}
+	unlock()
+}
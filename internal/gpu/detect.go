@@ -1,232 +1,365 @@
 package gpu
 
 import (
+	"encoding/json"
+	"fmt"
 	"os/exec"
 	"regexp"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 )
 
 // Info holds detected GPU information.
 type Info struct {
+	Index   int    // position among the system's GPUs
 	Vendor  string // NVIDIA, AMD, Apple, Intel
 	Model   string // e.g., "RTX 4090", "M3 Max"
-	VRAM    string // e.g., "24GB", "unified 36GB"
 	Driver  string // driver version
-	Compute string // CUDA 12.3, Metal 3, ROCm 6.0
+	Compute string // CUDA 8.9, Metal 3, ROCm
+
+	VRAMTotalMB int  // 0 when unknown
+	Unified     bool // memory is shared with the CPU (Apple Silicon)
+
+	// Sampled reports whether the usage fields below were read; most
+	// platforms only report them for NVIDIA and AMD cards.
+	Sampled        bool
+	VRAMUsedMB     int
+	UtilizationPct int
+}
+
+// VRAM returns the memory size for display, e.g. "24.0 GB".
+func (i Info) VRAM() string {
+	if i.VRAMTotalMB == 0 {
+		return ""
+	}
+	s := FormatMB(i.VRAMTotalMB)
+	if i.Unified {
+		s += " (unified)"
+	}
+	return s
+}
+
+// VRAMFreeMB returns unused memory when sampled, else the total.
+func (i Info) VRAMFreeMB() int {
+	if i.Sampled {
+		return max(i.VRAMTotalMB-i.VRAMUsedMB, 0)
+	}
+	return i.VRAMTotalMB
+}
+
+// FormatMB renders a size in MB as GB with one decimal, or MB below 1 GB.
+func FormatMB(mb int) string {
+	if mb >= 1024 {
+		return fmt.Sprintf("%.1f GB", float64(mb)/1024)
+	}
+	return fmt.Sprintf("%d MB", mb)
 }
 
 // Detect returns GPU information for the current system.
 func Detect() []Info {
+	var gpus []Info
 	switch runtime.GOOS {
 	case "darwin":
-		return detectMacOS()
+		gpus = detectMacOS()
 	case "linux":
-		return detectLinux()
+		gpus = detectLinux()
 	case "windows":
-		return detectWindows()
+		gpus = detectWindows()
+	}
+	for i := range gpus {
+		gpus[i].Index = i
 	}
-	return nil
+	return gpus
 }
 
-func detectMacOS() []Info {
-	// Check for Apple Silicon / Metal
-	out, err := exec.Command("system_profiler", "SPDisplaysDataType").Output()
+// MaxVRAMMB returns the largest VRAM among gpus in MB, or 0.
+func MaxVRAMMB(gpus []Info) int {
+	best := 0
+	for _, g := range gpus {
+		best = max(best, g.VRAMTotalMB)
+	}
+	return best
+}
+
+// HasGPU returns true if any GPU was detected.
+func HasGPU() bool {
+	return len(Detect()) > 0
+}
+
+// nvidiaQuery asks nvidia-smi for one CSV line per GPU.
+var nvidiaQuery = []string{
+	"--query-gpu=name,memory.total,memory.used,utilization.gpu,driver_version,compute_cap",
+	"--format=csv,noheader,nounits",
+}
+
+func detectNVIDIA() []Info {
+	out, err := exec.Command("nvidia-smi", nvidiaQuery...).Output()
 	if err != nil {
 		return nil
 	}
+	return parseNvidiaSMI(string(out))
+}
 
-	output := string(out)
-	var gpus []Info
+// ProcessVRAM returns the GPU memory each process uses, in MB by PID. Only
+// NVIDIA reports it; elsewhere the map is empty.
+func ProcessVRAM() map[int]int {
+	out, err := exec.Command("nvidia-smi", "--query-compute-apps=pid,used_memory", "--format=csv,noheader,nounits").Output()
+	if err != nil {
+		return map[int]int{}
+	}
+	return parseComputeApps(string(out))
+}
 
-	// Parse chipset/model
-	model := extractField(output, `Chipset Model:\s*(.+)`)
-	if model == "" {
-		model = extractField(output, `Chip:\s*(.+)`)
-	}
-
-	vram := extractField(output, `VRAM.*?:\s*(.+)`)
-	if vram == "" {
-		// Apple Silicon uses unified memory
-		memOut, err := exec.Command("sysctl", "-n", "hw.memsize").Output()
-		if err == nil {
-			memStr := strings.TrimSpace(string(memOut))
-			// Convert bytes to GB
-			var memBytes int64
-			if _, err := parseMemBytes(memStr, &memBytes); err == nil {
-				vram = formatGB(memBytes) + " (unified)"
-			}
+// parseComputeApps reads ProcessVRAM's query, summing a process's use
+// across GPUs.
+func parseComputeApps(out string) map[int]int {
+	byPID := make(map[int]int)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		pidStr, memStr, ok := strings.Cut(line, ",")
+		if !ok {
+			continue
 		}
+		pid, err1 := strconv.Atoi(strings.TrimSpace(pidStr))
+		mem, err2 := strconv.Atoi(strings.TrimSpace(memStr))
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		byPID[pid] += mem
 	}
+	return byPID
+}
 
-	metal := extractField(output, `Metal.*?:\s*(.+)`)
-
-	if model != "" {
-		gpu := Info{
-			Model:   model,
-			VRAM:    vram,
-			Compute: metal,
+// parseNvidiaSMI reads nvidiaQuery output. Fields a card doesn't support
+// come back as "[N/A]".
+func parseNvidiaSMI(out string) []Info {
+	var gpus []Info
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		parts := strings.Split(line, ",")
+		if len(parts) < 6 {
+			continue
 		}
-		if strings.Contains(strings.ToLower(model), "apple") || strings.Contains(strings.ToLower(model), "m1") || strings.Contains(strings.ToLower(model), "m2") || strings.Contains(strings.ToLower(model), "m3") || strings.Contains(strings.ToLower(model), "m4") {
-			gpu.Vendor = "Apple"
-			if gpu.Compute == "" {
-				gpu.Compute = "Metal"
-			}
-		} else if strings.Contains(strings.ToLower(model), "amd") || strings.Contains(strings.ToLower(model), "radeon") {
-			gpu.Vendor = "AMD"
-		} else if strings.Contains(strings.ToLower(model), "intel") {
-			gpu.Vendor = "Intel"
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		g := Info{Vendor: "NVIDIA", Model: parts[0], Driver: parts[4]}
+		g.VRAMTotalMB, _ = strconv.Atoi(parts[1])
+		used, errUsed := strconv.Atoi(parts[2])
+		util, errUtil := strconv.Atoi(parts[3])
+		if errUsed == nil && errUtil == nil {
+			g.Sampled, g.VRAMUsedMB, g.UtilizationPct = true, used, util
+		}
+		g.Compute = "CUDA"
+		if _, err := strconv.ParseFloat(parts[5], 64); err == nil {
+			g.Compute += " " + parts[5]
 		}
-		gpus = append(gpus, gpu)
+		gpus = append(gpus, g)
 	}
-
 	return gpus
 }
 
-func detectLinux() []Info {
-	var gpus []Info
+func detectMacOS() []Info {
+	out, err := exec.Command("system_profiler", "-json", "SPDisplaysDataType").Output()
+	if err != nil {
+		return nil
+	}
+	var memBytes int64
+	if memOut, err := exec.Command("sysctl", "-n", "hw.memsize").Output(); err == nil {
+		memBytes, _ = strconv.ParseInt(strings.TrimSpace(string(memOut)), 10, 64)
+	}
+	return parseSystemProfiler(out, memBytes)
+}
 
-	// Check NVIDIA
-	if out, err := exec.Command("nvidia-smi", "--query-gpu=name,memory.total,driver_version", "--format=csv,noheader,nounits").Output(); err == nil {
-		for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
-			parts := strings.Split(line, ", ")
-			if len(parts) >= 3 {
-				gpus = append(gpus, Info{
-					Vendor: "NVIDIA",
-					Model:  strings.TrimSpace(parts[0]),
-					VRAM:   strings.TrimSpace(parts[1]) + " MiB",
-					Driver: strings.TrimSpace(parts[2]),
-				})
-			}
+// parseSystemProfiler reads `system_profiler -json SPDisplaysDataType`.
+// GPUs without dedicated VRAM use memBytes of unified memory.
+func parseSystemProfiler(data []byte, memBytes int64) []Info {
+	var sp struct {
+		Displays []struct {
+			Model  string `json:"sppci_model"`
+			VRAM   string `json:"spdisplays_vram"`
+			Shared string `json:"spdisplays_vram_shared"`
+			Metal  string `json:"spdisplays_mtlgpufamilysupport"`
+			Vendor string `json:"spdisplays_vendor"`
+		} `json:"SPDisplaysDataType"`
+	}
+	if json.Unmarshal(data, &sp) != nil {
+		return nil
+	}
+
+	var gpus []Info
+	for _, d := range sp.Displays {
+		if d.Model == "" {
+			continue
 		}
-		// Detect CUDA version
-		if cudaOut, err := exec.Command("nvidia-smi", "--query-gpu=compute_cap", "--format=csv,noheader").Output(); err == nil {
-			cuda := strings.TrimSpace(string(cudaOut))
-			for i := range gpus {
-				gpus[i].Compute = "CUDA " + cuda
-			}
+		g := Info{Model: d.Model, Vendor: vendorOf(d.Model + " " + d.Vendor)}
+		if metal := strings.TrimPrefix(d.Metal, "spdisplays_"); metal != "" {
+			g.Compute = strings.ToUpper(metal[:1]) + metal[1:]
 		}
-		return gpus
+		vram := d.VRAM
+		if vram == "" {
+			vram = d.Shared
+		}
+		if g.VRAMTotalMB = parseSizeMB(vram); g.VRAMTotalMB == 0 && memBytes > 0 {
+			g.VRAMTotalMB = int(memBytes >> 20)
+			g.Unified = true
+		}
+		if g.Vendor == "Apple" && g.Compute == "" {
+			g.Compute = "Metal"
+		}
+		gpus = append(gpus, g)
 	}
+	return gpus
+}
 
-	// Check AMD ROCm
-	if out, err := exec.Command("rocm-smi", "--showproductname", "--showmeminfo", "vram").Output(); err == nil {
-		output := string(out)
-		model := extractField(output, `Card.*?:\s*(.+)`)
-		gpus = append(gpus, Info{
-			Vendor:  "AMD",
-			Model:   model,
-			Compute: "ROCm",
-		})
+func detectLinux() []Info {
+	if gpus := detectNVIDIA(); len(gpus) > 0 {
 		return gpus
 	}
 
-	// Check lspci as fallback
+	if out, err := exec.Command("rocm-smi", "--showproductname", "--showmeminfo", "vram", "--showuse", "--json").Output(); err == nil {
+		if gpus := parseROCmSMI(out); len(gpus) > 0 {
+			return gpus
+		}
+	}
+
+	// lspci as fallback: model names only
+	var gpus []Info
 	if out, err := exec.Command("lspci").Output(); err == nil {
 		for _, line := range strings.Split(string(out), "\n") {
 			lower := strings.ToLower(line)
-			if strings.Contains(lower, "vga") || strings.Contains(lower, "3d") {
-				gpus = append(gpus, Info{
-					Model: strings.TrimSpace(line),
-				})
+			if !strings.Contains(lower, "vga") && !strings.Contains(lower, "3d controller") {
+				continue
+			}
+			model := line
+			if _, desc, ok := strings.Cut(line, ": "); ok {
+				model = desc
 			}
+			gpus = append(gpus, Info{Vendor: vendorOf(model), Model: strings.TrimSpace(model)})
 		}
 	}
-
 	return gpus
 }
 
-func detectWindows() []Info {
-	var gpus []Info
-
-	// Check NVIDIA
-	if out, err := exec.Command("nvidia-smi", "--query-gpu=name,memory.total,driver_version", "--format=csv,noheader,nounits").Output(); err == nil {
-		for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
-			parts := strings.Split(line, ", ")
-			if len(parts) >= 3 {
-				gpus = append(gpus, Info{
-					Vendor:  "NVIDIA",
-					Model:   strings.TrimSpace(parts[0]),
-					VRAM:    strings.TrimSpace(parts[1]) + " MiB",
-					Driver:  strings.TrimSpace(parts[2]),
-					Compute: "CUDA",
-				})
-			}
+// parseROCmSMI reads `rocm-smi --json` output, one object per card.
+func parseROCmSMI(data []byte) []Info {
+	var cards map[string]map[string]string
+	if json.Unmarshal(data, &cards) != nil {
+		return nil
+	}
+	names := make([]string, 0, len(cards))
+	for name := range cards {
+		if strings.HasPrefix(name, "card") {
+			names = append(names, name)
 		}
-		return gpus
 	}
+	sort.Slice(names, func(i, j int) bool {
+		a, _ := strconv.Atoi(strings.TrimPrefix(names[i], "card"))
+		b, _ := strconv.Atoi(strings.TrimPrefix(names[j], "card"))
+		return a < b
+	})
 
-	// Fallback: use wmic
-	if out, err := exec.Command("wmic", "path", "win32_VideoController", "get", "name,adapterram").Output(); err == nil {
-		for _, line := range strings.Split(string(out), "\n") {
-			line = strings.TrimSpace(line)
-			if line != "" && !strings.HasPrefix(line, "AdapterRAM") {
-				gpus = append(gpus, Info{
-					Model:   line,
-					Compute: "DirectML",
-				})
-			}
+	var gpus []Info
+	for _, name := range names {
+		c := cards[name]
+		g := Info{Vendor: "AMD", Compute: "ROCm", Model: c["Card series"]}
+		if g.Model == "" {
+			g.Model = c["Card model"]
+		}
+		total, _ := strconv.ParseInt(c["VRAM Total Memory (B)"], 10, 64)
+		g.VRAMTotalMB = int(total >> 20)
+		used, errUsed := strconv.ParseInt(c["VRAM Total Used Memory (B)"], 10, 64)
+		util, errUtil := strconv.Atoi(c["GPU use (%)"])
+		if errUsed == nil && errUtil == nil {
+			g.Sampled, g.VRAMUsedMB, g.UtilizationPct = true, int(used>>20), util
 		}
+		gpus = append(gpus, g)
 	}
-
 	return gpus
 }
 
-func extractField(text, pattern string) string {
-	re := regexp.MustCompile(pattern)
-	matches := re.FindStringSubmatch(text)
-	if len(matches) >= 2 {
-		return strings.TrimSpace(matches[1])
+func detectWindows() []Info {
+	if gpus := detectNVIDIA(); len(gpus) > 0 {
+		return gpus
 	}
-	return ""
-}
-
-func parseMemBytes(s string, out *int64) (int, error) {
-	var n int
-	n, err := strings.NewReader(s).Read(make([]byte, len(s)))
+	out, err := exec.Command("wmic", "path", "win32_VideoController", "get", "Name,AdapterRAM,DriverVersion", "/format:csv").Output()
 	if err != nil {
-		return 0, err
+		return nil
 	}
-	// Simple parse: atoi
-	var val int64
-	for _, c := range s {
-		if c >= '0' && c <= '9' {
-			val = val*10 + int64(c-'0')
+	return parseWMIC(string(out))
+}
+
+// parseWMIC reads wmic CSV output (Node,AdapterRAM,DriverVersion,Name).
+// AdapterRAM is a 32-bit field, so cards over 4 GB report at most 4 GB.
+func parseWMIC(out string) []Info {
+	var gpus []Info
+	var header []string
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
 		}
+		fields := strings.Split(line, ",")
+		if header == nil {
+			header = fields
+			continue
+		}
+		row := make(map[string]string, len(header))
+		for i, h := range header {
+			if i < len(fields) {
+				row[h] = strings.TrimSpace(fields[i])
+			}
+		}
+		if row["Name"] == "" {
+			continue
+		}
+		ram, _ := strconv.ParseInt(row["AdapterRAM"], 10, 64)
+		gpus = append(gpus, Info{
+			Vendor:      vendorOf(row["Name"]),
+			Model:       row["Name"],
+			Driver:      row["DriverVersion"],
+			Compute:     "DirectML",
+			VRAMTotalMB: int(ram >> 20),
+		})
 	}
-	*out = val
-	return n, nil
+	return gpus
 }
 
-func formatGB(bytes int64) string {
-	gb := bytes / (1024 * 1024 * 1024)
-	if gb > 0 {
-		return strings.TrimRight(strings.TrimRight(strings.Replace(
-			strings.Replace(string(rune(gb/10+'0'))+string(rune(gb%10+'0')), "00", "0", 1),
-			"0", "", -1), ""), ".") + "GB"
+var appleChip = regexp.MustCompile(`\bm[1-9]\b`)
+
+// vendorOf guesses the vendor from a model or vendor string.
+func vendorOf(s string) string {
+	lower := strings.ToLower(s)
+	switch {
+	case strings.Contains(lower, "nvidia") || strings.Contains(lower, "geforce") || strings.Contains(lower, "quadro"):
+		return "NVIDIA"
+	case strings.Contains(lower, "amd") || strings.Contains(lower, "radeon") || strings.Contains(lower, "ati "):
+		return "AMD"
+	case strings.Contains(lower, "intel"):
+		return "Intel"
+	case strings.Contains(lower, "apple") || appleChip.MatchString(lower):
+		return "Apple"
 	}
-	return "unknown"
+	return ""
 }
 
-// HasGPU returns true if any GPU was detected.
-func HasGPU() bool {
-	return len(Detect()) > 0
-}
+var sizePattern = regexp.MustCompile(`(?i)([\d.]+)\s*(TB|TiB|GB|GiB|MB|MiB)\b`)
 
-// RecommendModel suggests a model based on available VRAM.
-func RecommendModel(vramMB int) string {
-	switch {
-	case vramMB >= 48000:
-		return "llama3.3:70b"
-	case vramMB >= 24000:
-		return "llama3.3:70b-q4"
-	case vramMB >= 16000:
-		return "llama3.3"
-	case vramMB >= 8000:
-		return "llama3.2"
-	case vramMB >= 4000:
-		return "phi3:mini"
-	default:
-		return "tinyllama"
+// parseSizeMB reads a size such as "8 GB" or "1536 MB" into MB.
+func parseSizeMB(s string) int {
+	m := sizePattern.FindStringSubmatch(s)
+	if m == nil {
+		return 0
+	}
+	n, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0
+	}
+	switch strings.ToUpper(m[2]) {
+	case "GB", "GIB":
+		return int(n * 1024)
+	case "TB", "TIB":
+		return int(n * 1024 * 1024)
 	}
+	return int(n)
 }
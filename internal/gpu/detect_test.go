@@ -0,0 +1,79 @@
+package gpu
+
+import "testing"
+
+func TestParseNvidiaSMI(t *testing.T) {
+	out := "NVIDIA GeForce RTX 4090, 24564, 1203, 37, 550.54.14, 8.9\n" +
+		"Tesla T4, 15360, [N/A], [N/A], 535.104.05, 7.5\n"
+	gpus := parseNvidiaSMI(out)
+	if len(gpus) != 2 {
+		t.Fatalf("expected 2 GPUs, got %d", len(gpus))
+	}
+	g := gpus[0]
+	if g.Model != "NVIDIA GeForce RTX 4090" || g.VRAMTotalMB != 24564 || !g.Sampled || g.VRAMUsedMB != 1203 || g.UtilizationPct != 37 || g.Compute != "CUDA 8.9" {
+		t.Errorf("unexpected first GPU: %+v", g)
+	}
+	if gpus[1].Sampled || gpus[1].VRAMTotalMB != 15360 {
+		t.Errorf("[N/A] usage should leave the GPU unsampled: %+v", gpus[1])
+	}
+}
+
+func TestParseComputeApps(t *testing.T) {
+	out := "4242, 5120\n4242, 1024\n77, [N/A]\n99, 300\n"
+	got := parseComputeApps(out)
+	if len(got) != 2 || got[4242] != 6144 || got[99] != 300 {
+		t.Errorf("unexpected per-process VRAM: %v", got)
+	}
+}
+
+func TestParseROCmSMI(t *testing.T) {
+	out := []byte(`{
+		"card1": {"Card series": "Radeon RX 7900 XTX", "VRAM Total Memory (B)": "25753026560", "VRAM Total Used Memory (B)": "1073741824", "GPU use (%)": "12"},
+		"card0": {"Card series": "Radeon Pro W7800", "VRAM Total Memory (B)": "34359738368"},
+		"system": {"Driver version": "6.7.0"}
+	}`)
+	gpus := parseROCmSMI(out)
+	if len(gpus) != 2 || gpus[0].Model != "Radeon Pro W7800" {
+		t.Fatalf("cards should be ordered by index: %+v", gpus)
+	}
+	if gpus[0].VRAMTotalMB != 32768 || gpus[0].Sampled {
+		t.Errorf("unexpected card0: %+v", gpus[0])
+	}
+	if !gpus[1].Sampled || gpus[1].VRAMUsedMB != 1024 || gpus[1].UtilizationPct != 12 {
+		t.Errorf("unexpected card1: %+v", gpus[1])
+	}
+}
+
+func TestParseSystemProfiler(t *testing.T) {
+	data := []byte(`{"SPDisplaysDataType": [{"sppci_model": "Apple M3 Max", "spdisplays_mtlgpufamilysupport": "spdisplays_metal3"}]}`)
+	gpus := parseSystemProfiler(data, 36<<30)
+	if len(gpus) != 1 {
+		t.Fatalf("expected 1 GPU, got %d", len(gpus))
+	}
+	g := gpus[0]
+	if g.Vendor != "Apple" || g.VRAMTotalMB != 36864 || !g.Unified || g.Compute != "Metal3" || g.VRAM() != "36.0 GB (unified)" {
+		t.Errorf("unexpected GPU: %+v (%s)", g, g.VRAM())
+	}
+
+	data = []byte(`{"SPDisplaysDataType": [{"sppci_model": "AMD Radeon Pro 5500M", "spdisplays_vram": "8 GB"}]}`)
+	if g := parseSystemProfiler(data, 16<<30)[0]; g.Vendor != "AMD" || g.VRAMTotalMB != 8192 || g.Unified {
+		t.Errorf("dedicated VRAM should be used: %+v", g)
+	}
+}
+
+func TestParseWMIC(t *testing.T) {
+	out := "\r\nNode,AdapterRAM,DriverVersion,Name\r\nDESKTOP,4293918720,31.0.101.4502,Intel(R) Arc(TM) A770 Graphics\r\n"
+	gpus := parseWMIC(out)
+	if len(gpus) != 1 || gpus[0].Vendor != "Intel" || gpus[0].VRAMTotalMB != 4095 || gpus[0].Driver != "31.0.101.4502" {
+		t.Errorf("unexpected GPUs: %+v", gpus)
+	}
+}
+
+func TestMaxVRAMMB(t *testing.T) {
+	if got := MaxVRAMMB([]Info{{VRAMTotalMB: 8192}, {VRAMTotalMB: 24576}}); got != 24576 {
+		t.Errorf("MaxVRAMMB = %d", got)
+	}
+	if got := MaxVRAMMB(nil); got != 0 {
+		t.Errorf("MaxVRAMMB(nil) = %d", got)
+	}
+}
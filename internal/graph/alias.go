@@ -0,0 +1,133 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// resolve returns the key of the entity name refers to: the entity with
+// that name, else the one with that alias, else the only entity whose name
+// or an alias matches ignoring case, spaces and punctuation ("Node JS" for
+// "Node.js").
+func (g *Graph) resolve(name string) (string, bool) {
+	if key, ok := g.exactKey(name); ok {
+		return key, true
+	}
+	fold := duplicateKey(name)
+	if fold == "" {
+		return normalize(name), false
+	}
+	match := ""
+	for key, e := range g.Entities {
+		if duplicateKey(e.Name) != fold && !containsFoldKey(e.Aliases, fold) {
+			continue
+		}
+		if match != "" && match != key {
+			return normalize(name), false // ambiguous
+		}
+		match = key
+	}
+	if match == "" {
+		return normalize(name), false
+	}
+	return match, true
+}
+
+// exactKey returns the key of the entity named name or having it as an
+// alias, compared case-insensitively.
+func (g *Graph) exactKey(name string) (string, bool) {
+	key := normalize(name)
+	if _, ok := g.Entities[key]; ok {
+		return key, true
+	}
+	for k, e := range g.Entities {
+		for _, a := range e.Aliases {
+			if normalize(a) == key {
+				return k, true
+			}
+		}
+	}
+	return key, false
+}
+
+func containsFoldKey(names []string, fold string) bool {
+	for _, n := range names {
+		if duplicateKey(n) == fold {
+			return true
+		}
+	}
+	return false
+}
+
+// AddAlias gives the entity name an alternate name that lookups, relations
+// and imports resolve to it.
+func (g *Graph) AddAlias(name, alias string) (*Entity, error) {
+	key, ok := g.resolve(name)
+	if !ok {
+		return nil, fmt.Errorf("entity not found: %s", name)
+	}
+	alias = strings.TrimSpace(alias)
+	if alias == "" {
+		return nil, fmt.Errorf("alias cannot be empty")
+	}
+	e := g.Entities[key]
+	if owner, taken := g.exactKey(alias); taken {
+		if owner == key {
+			return nil, fmt.Errorf("%s already refers to %s", alias, e.Name)
+		}
+		return nil, fmt.Errorf("%s already refers to %s", alias, g.Entities[owner].Name)
+	}
+	e.Aliases = append(e.Aliases, alias)
+	e.UpdatedAt = time.Now()
+	return e, nil
+}
+
+// RemoveAlias drops alias from the entity it belongs to, and returns that
+// entity.
+func (g *Graph) RemoveAlias(alias string) (*Entity, error) {
+	key := normalize(alias)
+	for _, e := range g.Entities {
+		for i, a := range e.Aliases {
+			if normalize(a) == key {
+				e.Aliases = append(e.Aliases[:i], e.Aliases[i+1:]...)
+				e.UpdatedAt = time.Now()
+				return e, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("alias not found: %s", alias)
+}
+
+// Aliased returns the entities that have aliases, by name.
+func (g *Graph) Aliased() []*Entity {
+	var out []*Entity
+	for _, e := range g.Entities {
+		if len(e.Aliases) > 0 {
+			out = append(out, e)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return normalize(out[i].Name) < normalize(out[j].Name) })
+	return out
+}
+
+// addAliases adds names to the entity at key as aliases, skipping its own
+// name and names already taken by it or another entity. It returns how many
+// were added.
+func (g *Graph) addAliases(key string, names []string) int {
+	e := g.Entities[key]
+	added := 0
+	for _, n := range names {
+		n = strings.TrimSpace(n)
+		if n == "" {
+			continue
+		}
+		if _, taken := g.exactKey(n); taken {
+			continue
+		}
+		e.Aliases = append(e.Aliases, n)
+		added++
+	}
+	return added
+}
@@ -0,0 +1,220 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/msalah0e/palm/internal/config"
+)
+
+// ─── Backups ───
+
+// Backup is a snapshot of the encrypted graph file. Snapshots are copies of
+// graph.enc, so they stay encrypted with the same key.
+type Backup struct {
+	Name   string    `json:"name"`
+	Time   time.Time `json:"time"`
+	Reason string    `json:"reason"` // manual, daily, or the operation it preceded
+	Size   int64     `json:"size"`
+}
+
+// backupInterval is how often Save takes a scheduled snapshot.
+const backupInterval = 24 * time.Hour
+
+const backupTimeFormat = "20060102-150405.000000"
+
+// BackupDir is where graph snapshots are kept.
+func BackupDir() string {
+	return filepath.Join(filepath.Dir(graphPath()), "backups")
+}
+
+// CreateBackup snapshots the graph as it is on disk, then prunes old
+// snapshots per the [graph] retention settings. It returns nil when there
+// is no graph to back up yet.
+func CreateBackup(reason string) (*Backup, error) {
+	data, err := os.ReadFile(graphPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := os.MkdirAll(BackupDir(), 0o700); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	b := &Backup{Time: now, Reason: sanitizeReason(reason), Size: int64(len(data))}
+	b.Name = "graph-" + now.Format(backupTimeFormat) + "-" + b.Reason + ".enc"
+	if err := os.WriteFile(filepath.Join(BackupDir(), b.Name), data, 0o600); err != nil {
+		return nil, err
+	}
+
+	cfg := config.Load().Graph
+	_, err = PruneBackups(cfg.BackupKeep, time.Duration(cfg.BackupMaxDays)*24*time.Hour)
+	return b, err
+}
+
+func sanitizeReason(reason string) string {
+	reason = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(reason))
+	if reason == "" {
+		return "manual"
+	}
+	return reason
+}
+
+// ListBackups returns the snapshots, newest first.
+func ListBackups() ([]Backup, error) {
+	entries, err := os.ReadDir(BackupDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var backups []Backup
+	for _, e := range entries {
+		b, ok := parseBackupName(e.Name())
+		if !ok {
+			continue
+		}
+		if info, err := e.Info(); err == nil {
+			b.Size = info.Size()
+		}
+		backups = append(backups, b)
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Time.After(backups[j].Time) })
+	return backups, nil
+}
+
+func parseBackupName(name string) (Backup, bool) {
+	if !strings.HasPrefix(name, "graph-") || !strings.HasSuffix(name, ".enc") {
+		return Backup{}, false
+	}
+	rest := strings.TrimSuffix(strings.TrimPrefix(name, "graph-"), ".enc")
+	if len(rest) < len(backupTimeFormat)+2 {
+		return Backup{}, false
+	}
+	t, err := time.Parse(backupTimeFormat, rest[:len(backupTimeFormat)])
+	if err != nil {
+		return Backup{}, false
+	}
+	return Backup{Name: name, Time: t, Reason: rest[len(backupTimeFormat)+1:]}, true
+}
+
+// FindBackup resolves a snapshot by name, by a unique name prefix, or by its
+// position in ListBackups (1 is the newest).
+func FindBackup(ref string) (*Backup, error) {
+	backups, err := ListBackups()
+	if err != nil {
+		return nil, err
+	}
+	if n, err := strconv.Atoi(ref); err == nil {
+		if n < 1 || n > len(backups) {
+			return nil, fmt.Errorf("no backup #%d (%d available)", n, len(backups))
+		}
+		return &backups[n-1], nil
+	}
+	var match *Backup
+	for i := range backups {
+		if backups[i].Name == ref {
+			return &backups[i], nil
+		}
+		if strings.HasPrefix(backups[i].Name, ref) || strings.HasPrefix(backups[i].Name, "graph-"+ref) {
+			if match != nil {
+				return nil, fmt.Errorf("%q matches more than one backup", ref)
+			}
+			match = &backups[i]
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("backup %q not found", ref)
+	}
+	return match, nil
+}
+
+// LoadBackup decrypts and parses a snapshot without touching the live graph.
+func LoadBackup(b *Backup) (*Graph, error) {
+	g, _, err := readBackup(b)
+	return g, err
+}
+
+// readBackup returns a snapshot's graph along with its encrypted bytes.
+func readBackup(b *Backup) (*Graph, []byte, error) {
+	data, err := os.ReadFile(filepath.Join(BackupDir(), b.Name))
+	if err != nil {
+		return nil, nil, err
+	}
+	plaintext, err := decrypt(deriveKey(), data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("backup decrypt: %w", err)
+	}
+	g := New()
+	if err := json.Unmarshal(plaintext, g); err != nil {
+		return nil, nil, fmt.Errorf("backup parse: %w", err)
+	}
+	return g, data, nil
+}
+
+// RestoreBackup replaces the live graph with a snapshot, after checking the
+// snapshot decrypts and backing up the current graph as "pre-restore".
+func RestoreBackup(b *Backup) (*Graph, error) {
+	if ReadOnly() {
+		return nil, ErrReadOnly
+	}
+	// Read before backing up: the pre-restore snapshot can prune b
+	g, data, err := readBackup(b)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := CreateBackup("pre-restore"); err != nil {
+		return nil, fmt.Errorf("backing up the current graph: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(graphPath()), 0o755); err != nil {
+		return nil, err
+	}
+	return g, os.WriteFile(graphPath(), data, 0o600)
+}
+
+// PruneBackups keeps the newest keep snapshots and deletes the rest, and any
+// older than maxAge. Zero disables either limit. It returns what it removed.
+func PruneBackups(keep int, maxAge time.Duration) ([]string, error) {
+	backups, err := ListBackups()
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for i, b := range backups {
+		tooMany := keep > 0 && i >= keep
+		tooOld := maxAge > 0 && time.Since(b.Time) > maxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+		if err := os.Remove(filepath.Join(BackupDir(), b.Name)); err != nil {
+			return removed, err
+		}
+		removed = append(removed, b.Name)
+	}
+	return removed, nil
+}
+
+// backupIfDue takes the scheduled snapshot when the newest one is older
+// than backupInterval.
+func backupIfDue() {
+	backups, err := ListBackups()
+	if err != nil || (len(backups) > 0 && time.Since(backups[0].Time) < backupInterval) {
+		return
+	}
+	_, _ = CreateBackup("daily")
+}
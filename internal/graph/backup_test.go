@@ -0,0 +1,151 @@
+package graph
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	setupTestEnv(t)
+
+	if b, err := CreateBackup("manual"); b != nil || err != nil {
+		t.Fatalf("backing up a missing graph should be a no-op, got %v %v", b, err)
+	}
+
+	g := New()
+	g.AddEntity("Kubernetes", "tool")
+	if err := Save(g); err != nil {
+		t.Fatal(err)
+	}
+	b, err := CreateBackup("before import")
+	if err != nil || b == nil {
+		t.Fatalf("CreateBackup: %v", err)
+	}
+	if b.Reason != "before-import" {
+		t.Errorf("reason = %q, want it made file-safe", b.Reason)
+	}
+
+	// A bad import wipes the graph
+	if err := Save(New()); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := FindBackup(b.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := RestoreBackup(found)
+	if err != nil {
+		t.Fatalf("RestoreBackup: %v", err)
+	}
+	if _, err := restored.GetEntity("kubernetes"); err != nil {
+		t.Error("restored graph is missing its entity")
+	}
+	live, _ := Load()
+	if _, err := live.GetEntity("Kubernetes"); err != nil {
+		t.Error("the live graph wasn't replaced")
+	}
+
+	backups, _ := ListBackups()
+	if len(backups) < 2 || backups[0].Reason != "pre-restore" {
+		t.Errorf("restore should back up the graph it replaces first: %+v", backups)
+	}
+	if newest, err := FindBackup("1"); err != nil || newest.Name != backups[0].Name {
+		t.Errorf("FindBackup(1) = %v, %v", newest, err)
+	}
+}
+
+func TestRestoreRejectsCorruptBackup(t *testing.T) {
+	setupTestEnv(t)
+	g := New()
+	g.AddEntity("Go", "language")
+	Save(g)
+
+	os.MkdirAll(BackupDir(), 0o700)
+	name := "graph-" + time.Now().UTC().Format(backupTimeFormat) + "-manual.enc"
+	os.WriteFile(filepath.Join(BackupDir(), name), []byte("not encrypted"), 0o600)
+
+	b, err := FindBackup(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RestoreBackup(b); err == nil {
+		t.Fatal("expected a corrupt backup to be refused")
+	}
+	live, _ := Load()
+	if _, err := live.GetEntity("Go"); err != nil {
+		t.Error("a refused restore must leave the graph alone")
+	}
+}
+
+func TestRestoreOldestBackupAtRetentionLimit(t *testing.T) {
+	setupTestEnv(t)
+
+	old := New()
+	old.AddEntity("Mainframe", "tool")
+	if err := Save(old); err != nil {
+		t.Fatal(err)
+	}
+	first, err := CreateBackup("manual")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Fill the default retention of 20 with newer snapshots
+	data, _ := os.ReadFile(filepath.Join(BackupDir(), first.Name))
+	for i := 1; i < 20; i++ {
+		name := "graph-" + first.Time.Add(time.Duration(i)*time.Second).Format(backupTimeFormat) + "-daily.enc"
+		os.WriteFile(filepath.Join(BackupDir(), name), data, 0o600)
+	}
+	if err := Save(New()); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := FindBackup("20")
+	if err != nil || b.Name != first.Name {
+		t.Fatalf("FindBackup(20) = %v, %v", b, err)
+	}
+	if _, err := RestoreBackup(b); err != nil {
+		t.Fatalf("restoring the oldest snapshot: %v", err)
+	}
+	live, _ := Load()
+	if _, err := live.GetEntity("Mainframe"); err != nil {
+		t.Error("the live graph wasn't replaced")
+	}
+}
+
+func TestPruneBackups(t *testing.T) {
+	setupTestEnv(t)
+	os.MkdirAll(BackupDir(), 0o700)
+	now := time.Now().UTC()
+	for _, age := range []time.Duration{0, time.Hour, 48 * time.Hour, 10 * 24 * time.Hour} {
+		name := "graph-" + now.Add(-age).Format(backupTimeFormat) + "-daily.enc"
+		os.WriteFile(filepath.Join(BackupDir(), name), []byte("x"), 0o600)
+	}
+
+	removed, err := PruneBackups(3, 7*24*time.Hour)
+	if err != nil || len(removed) != 1 {
+		t.Fatalf("expected the 10-day-old backup pruned, got %v %v", removed, err)
+	}
+	removed, _ = PruneBackups(1, 0)
+	if left, _ := ListBackups(); len(removed) != 2 || len(left) != 1 || now.Sub(left[0].Time) > time.Minute {
+		t.Errorf("expected only the newest kept, removed %v, left %+v", removed, left)
+	}
+}
+
+func TestScheduledBackup(t *testing.T) {
+	setupTestEnv(t)
+	g := New()
+	g.AddEntity("A", "")
+	Save(g) // nothing on disk yet to back up
+	g.AddEntity("B", "")
+	Save(g)
+	g.AddEntity("C", "")
+	Save(g)
+
+	backups, _ := ListBackups()
+	if len(backups) != 1 || backups[0].Reason != "daily" {
+		t.Errorf("expected one daily backup, got %+v", backups)
+	}
+}
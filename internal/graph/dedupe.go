@@ -0,0 +1,232 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ─── Observation deduplication ───
+
+// ErrDuplicateObservation is returned by AddObservation when the entity
+// already has the observation, ignoring case and spacing.
+var ErrDuplicateObservation = errors.New("observation already recorded")
+
+// DefaultSimilarity is the similarity above which two observations are
+// treated as near-duplicates.
+const DefaultSimilarity = 0.8
+
+// ObservationCluster is a set of near-identical observations on one entity.
+type ObservationCluster struct {
+	Entity       string   `json:"entity"`
+	Indices      []int    `json:"indices"` // positions in the entity's observations
+	Observations []string `json:"observations"`
+	Keep         int      `json:"keep"` // suggested observation to keep, an index into Observations
+}
+
+// observationKey folds an observation for exact-duplicate checks: case and
+// runs of whitespace don't make a new fact.
+func observationKey(o string) string {
+	return strings.ToLower(strings.Join(strings.Fields(o), " "))
+}
+
+// hasObservation reports whether e already records o.
+func (e *Entity) hasObservation(o string) bool {
+	key := observationKey(o)
+	for _, existing := range e.Observations {
+		if observationKey(existing) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Similarity scores how alike two observations are, from 0 to 1: the
+// better of their normalized edit distance and the overlap of their words.
+// Edit distance catches typos and small rewordings, word overlap catches
+// reordering.
+func Similarity(a, b string) float64 {
+	a, b = similarityText(a), similarityText(b)
+	if a == b {
+		return 1
+	}
+	return max(levenshteinSimilarity(a, b), jaccard(strings.Fields(a), strings.Fields(b)))
+}
+
+// similarityText lowercases o and reduces punctuation to spaces, so "uses
+// Postgres 15." and "uses postgres 15" compare equal.
+func similarityText(o string) string {
+	mapped := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, o)
+	return strings.Join(strings.Fields(mapped), " ")
+}
+
+func levenshteinSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// levenshtein is the edit distance between a and b, keeping one row.
+func levenshtein(a, b []rune) int {
+	row := make([]int, len(b)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		prev := row[0]
+		row[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur := min(row[j]+1, row[j-1]+1, prev+cost)
+			prev, row[j] = row[j], cur
+		}
+	}
+	return row[len(b)]
+}
+
+func jaccard(a, b []string) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	set := make(map[string]bool, len(a))
+	for _, w := range a {
+		set[w] = true
+	}
+	inB := make(map[string]bool, len(b))
+	shared := 0
+	for _, w := range b {
+		if set[w] && !inB[w] {
+			shared++
+		}
+		inB[w] = true
+	}
+	return float64(shared) / float64(len(set)+len(inB)-shared)
+}
+
+// NearDuplicates clusters the observations of each entity that are at least
+// threshold similar, directly or through a chain of similar observations.
+// With a name only that entity is checked. Clusters come in entity order.
+func (g *Graph) NearDuplicates(name string, threshold float64) ([]ObservationCluster, error) {
+	keys := g.sortedKeys()
+	if name != "" {
+		key, ok := g.resolve(name)
+		if !ok {
+			return nil, fmt.Errorf("entity not found: %s", name)
+		}
+		keys = []string{key}
+	}
+
+	var clusters []ObservationCluster
+	for _, key := range keys {
+		clusters = append(clusters, clusterObservations(g.Entities[key], threshold)...)
+	}
+	return clusters, nil
+}
+
+func clusterObservations(e *Entity, threshold float64) []ObservationCluster {
+	n := len(e.Observations)
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if find(i) != find(j) && Similarity(e.Observations[i], e.Observations[j]) >= threshold {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	groups := make(map[int][]int)
+	for i := 0; i < n; i++ {
+		root := find(i)
+		groups[root] = append(groups[root], i)
+	}
+	var clusters []ObservationCluster
+	for _, idx := range groups {
+		if len(idx) < 2 {
+			continue
+		}
+		c := ObservationCluster{Entity: e.Name, Indices: idx}
+		for k, i := range idx {
+			c.Observations = append(c.Observations, e.Observations[i])
+			// The longest wording usually carries the most detail
+			if len(e.Observations[i]) > len(c.Observations[c.Keep]) {
+				c.Keep = k
+			}
+		}
+		clusters = append(clusters, c)
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].Indices[0] < clusters[j].Indices[0] })
+	return clusters
+}
+
+// MergeClusters collapses each cluster into its Keep observation, which
+// takes the place of the cluster's first observation. All clusters must come
+// from the same NearDuplicates call, before any other change to the graph.
+func (g *Graph) MergeClusters(clusters []ObservationCluster) (removed int, err error) {
+	type merge struct {
+		drop    map[int]bool
+		replace map[int]string
+	}
+	byEntity := make(map[string]*merge)
+	for _, c := range clusters {
+		e, err := g.GetEntity(c.Entity)
+		if err != nil {
+			return 0, err
+		}
+		if c.Keep < 0 || c.Keep >= len(c.Observations) || len(c.Indices) != len(c.Observations) {
+			return 0, fmt.Errorf("invalid cluster for %s", c.Entity)
+		}
+		m, ok := byEntity[e.Name]
+		if !ok {
+			m = &merge{drop: make(map[int]bool), replace: make(map[int]string)}
+			byEntity[e.Name] = m
+		}
+		for k, i := range c.Indices {
+			if i < 0 || i >= len(e.Observations) || e.Observations[i] != c.Observations[k] {
+				return 0, fmt.Errorf("observations of %s changed since they were clustered", e.Name)
+			}
+			m.drop[i] = true
+		}
+		m.replace[slices.Min(c.Indices)] = c.Observations[c.Keep]
+	}
+
+	for name, m := range byEntity {
+		e, _ := g.GetEntity(name)
+		kept := make([]string, 0, len(e.Observations))
+		for i, o := range e.Observations {
+			if keep, ok := m.replace[i]; ok {
+				kept = append(kept, keep)
+			} else if !m.drop[i] {
+				kept = append(kept, o)
+			}
+		}
+		removed += len(e.Observations) - len(kept)
+		e.Observations = kept
+		e.UpdatedAt = time.Now()
+	}
+	return removed, nil
+}
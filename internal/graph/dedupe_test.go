@@ -0,0 +1,89 @@
+package graph
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAddObservationRejectsDuplicates(t *testing.T) {
+	g := New()
+	g.AddEntity("Postgres", "service")
+	if err := g.AddObservation("Postgres", "Runs on port 5432"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AddObservation("postgres", "  runs on PORT   5432 "); !errors.Is(err, ErrDuplicateObservation) {
+		t.Errorf("expected ErrDuplicateObservation, got %v", err)
+	}
+	if err := g.AddObservation("Postgres", "Runs on port 5433"); err != nil {
+		t.Errorf("a different observation was rejected: %v", err)
+	}
+	if e, _ := g.GetEntity("Postgres"); len(e.Observations) != 2 {
+		t.Errorf("observations = %q", e.Observations)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		near bool
+	}{
+		{"Uses Postgres 15 for storage.", "uses postgres 15 for storage", true},
+		{"Deployed to us-east-1 on Fridays", "Deployed to us-east-1 on Friday", true},
+		{"owner: payments team, on call weekly", "on call weekly, owner: payments team", true},
+		{"Uses Postgres 15 for storage", "Written in Go", false},
+	}
+	for _, tt := range tests {
+		if got := Similarity(tt.a, tt.b) >= DefaultSimilarity; got != tt.near {
+			t.Errorf("Similarity(%q, %q) = %.2f", tt.a, tt.b, Similarity(tt.a, tt.b))
+		}
+	}
+}
+
+func TestNearDuplicatesAndMerge(t *testing.T) {
+	g := New()
+	g.AddEntity("API", "service")
+	g.Entities["api"].Observations = []string{
+		"Rate limit is 100 req/s",            // 0
+		"Deployed with Helm charts",          // 1
+		"rate limit: 100 req/s",              // 2
+		"Deployed with helm chart",           // 3
+		"Rate limit is 100 requests/s.",      // 4
+		"Owned by the platform team",         // 5
+		"Rate limit is 100 req/s per client", // 6: adds a detail, not a duplicate
+	}
+	g.AddEntity("Docs", "")
+	g.AddObservation("Docs", "Hosted on Pages")
+
+	clusters, err := g.NearDuplicates("", DefaultSimilarity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters) != 2 {
+		t.Fatalf("clusters = %+v", clusters)
+	}
+	if !reflect.DeepEqual(clusters[0].Indices, []int{0, 2, 4}) || clusters[0].Observations[clusters[0].Keep] != "Rate limit is 100 requests/s." {
+		t.Errorf("rate limit cluster = %+v", clusters[0])
+	}
+	if !reflect.DeepEqual(clusters[1].Indices, []int{1, 3}) {
+		t.Errorf("language cluster = %+v", clusters[1])
+	}
+	if _, err := g.NearDuplicates("missing", DefaultSimilarity); err == nil {
+		t.Error("expected an error for an unknown entity")
+	}
+
+	clusters[1].Keep = 0 // keep "Deployed with Helm charts"
+	removed, err := g.MergeClusters(clusters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Rate limit is 100 requests/s.", "Deployed with Helm charts", "Owned by the platform team", "Rate limit is 100 req/s per client"}
+	if e, _ := g.GetEntity("API"); removed != 3 || !reflect.DeepEqual(e.Observations, want) {
+		t.Errorf("after merge removed %d: %q", removed, e.Observations)
+	}
+
+	// Clusters are stale once the observations they point at have moved
+	if _, err := g.MergeClusters(clusters); err == nil {
+		t.Error("expected an error merging stale clusters")
+	}
+}
@@ -0,0 +1,86 @@
+package graph
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ─── Garbage collection ───
+
+// GCReport lists entities that are candidates for cleanup.
+type GCReport struct {
+	Orphans    []*Entity        `json:"orphans"`    // no relations and no observations
+	Stale      []*Entity        `json:"stale"`      // not updated since the cutoff; orphans aren't repeated
+	Duplicates []DuplicateGroup `json:"duplicates"` // names that differ only in case or punctuation
+}
+
+// DuplicateGroup is a set of duplicate-looking entities and the one the
+// others should be merged into.
+type DuplicateGroup struct {
+	Target  *Entity   `json:"target"`
+	Sources []*Entity `json:"sources"`
+}
+
+// Removable returns the orphan and stale entities.
+func (r GCReport) Removable() []*Entity {
+	return append(append([]*Entity{}, r.Orphans...), r.Stale...)
+}
+
+// GC reports orphan entities, entities not updated since staleBefore, and
+// duplicate-looking names. A zero staleBefore skips the stale check.
+func (g *Graph) GC(staleBefore time.Time) GCReport {
+	degree := make(map[string]int)
+	for _, r := range g.Relations {
+		degree[normalize(r.From)]++
+		degree[normalize(r.To)]++
+	}
+
+	report := GCReport{Orphans: []*Entity{}, Stale: []*Entity{}, Duplicates: []DuplicateGroup{}}
+	groups := make(map[string][]*Entity)
+	for _, key := range g.sortedKeys() {
+		e := g.Entities[key]
+		switch {
+		case degree[key] == 0 && len(e.Observations) == 0:
+			report.Orphans = append(report.Orphans, e)
+		case !staleBefore.IsZero() && e.UpdatedAt.Before(staleBefore):
+			report.Stale = append(report.Stale, e)
+		}
+		if k := duplicateKey(e.Name); k != "" {
+			groups[k] = append(groups[k], e)
+		}
+	}
+
+	// The best-connected entity of a group is the merge target; ties go to
+	// the oldest, which is likely the original.
+	weight := func(e *Entity) int { return degree[normalize(e.Name)] + len(e.Observations) }
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+		sort.SliceStable(group, func(i, j int) bool {
+			if wi, wj := weight(group[i]), weight(group[j]); wi != wj {
+				return wi > wj
+			}
+			return group[i].CreatedAt.Before(group[j].CreatedAt)
+		})
+		report.Duplicates = append(report.Duplicates, DuplicateGroup{Target: group[0], Sources: group[1:]})
+	}
+	sort.Slice(report.Duplicates, func(i, j int) bool {
+		return normalize(report.Duplicates[i].Target.Name) < normalize(report.Duplicates[j].Target.Name)
+	})
+	return report
+}
+
+// duplicateKey folds a name to its lowercase letters and digits, so
+// "Node.js", "nodejs" and "Node JS" compare equal.
+func duplicateKey(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,58 @@
+package graph
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGC(t *testing.T) {
+	g := New()
+	for _, name := range []string{"Node.js", "nodejs", "Node JS", "api", "lonely", "old", "postgres"} {
+		if err := g.AddEntity(name, "tool"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	g.AddRelation("api", "uses", "nodejs")
+	g.AddRelation("api", "uses", "postgres")
+	g.AddObservation("Node JS", "Runtime")
+	g.AddObservation("old", "Deprecated service")
+
+	cutoff := time.Now().AddDate(0, 0, -30)
+	for _, name := range []string{"old", "postgres"} {
+		e, _ := g.GetEntity(name)
+		e.UpdatedAt = cutoff.AddDate(0, 0, -1)
+	}
+
+	r := g.GC(cutoff)
+	if len(r.Orphans) != 2 || r.Orphans[0].Name != "lonely" || r.Orphans[1].Name != "Node.js" {
+		t.Errorf("orphans = %v", entityNames(r.Orphans))
+	}
+	if len(r.Stale) != 2 || r.Stale[0].Name != "old" || r.Stale[1].Name != "postgres" {
+		t.Errorf("stale = %v", entityNames(r.Stale))
+	}
+	if len(r.Removable()) != 4 {
+		t.Errorf("removable = %v", entityNames(r.Removable()))
+	}
+
+	// nodejs has a relation, so it's the target over the orphan and the one
+	// with an observation only
+	if len(r.Duplicates) != 1 {
+		t.Fatalf("duplicates = %+v", r.Duplicates)
+	}
+	d := r.Duplicates[0]
+	if d.Target.Name != "nodejs" || len(d.Sources) != 2 {
+		t.Errorf("target %s, sources %v", d.Target.Name, entityNames(d.Sources))
+	}
+
+	if r := g.GC(time.Time{}); len(r.Stale) != 0 {
+		t.Errorf("zero cutoff should skip the stale check, got %v", entityNames(r.Stale))
+	}
+}
+
+func entityNames(entities []*Entity) []string {
+	var out []string
+	for _, e := range entities {
+		out = append(out, e.Name)
+	}
+	return out
+}
@@ -5,7 +5,10 @@ import (
 	"crypto/cipher"
 	"crypto/rand"
 	"crypto/sha256"
+	"encoding/csv"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -13,12 +16,15 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/msalah0e/palm/internal/config"
 )
 
 // Entity represents a node in the knowledge graph.
 type Entity struct {
 	Name         string    `json:"name"`
 	Type         string    `json:"type"`
+	Aliases      []string  `json:"aliases,omitempty"` // alternate names that resolve to the entity
 	Observations []string  `json:"observations"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
@@ -35,6 +41,7 @@ type Relation struct {
 type Graph struct {
 	Entities  map[string]*Entity `json:"entities"`
 	Relations []*Relation        `json:"relations"`
+	Schema    *Schema            `json:"schema,omitempty"`
 }
 
 // Stats holds summary counts.
@@ -159,8 +166,21 @@ func Load() (*Graph, error) {
 	return g, nil
 }
 
-// Save encrypts and writes the graph to disk.
+// ErrReadOnly is returned by writes to a graph configured as read-only.
+var ErrReadOnly = errors.New("the graph is read-only (readonly = true under [graph] in config.toml or .palm.toml)")
+
+// ReadOnly reports whether the config, global or project, makes the graph
+// read-only.
+func ReadOnly() bool {
+	return config.Load().Graph.ReadOnly
+}
+
+// Save encrypts and writes the graph to disk. It fails with ErrReadOnly when
+// the graph is read-only.
 func Save(g *Graph) error {
+	if ReadOnly() {
+		return ErrReadOnly
+	}
 	plaintext, err := json.Marshal(g)
 	if err != nil {
 		return err
@@ -172,6 +192,8 @@ func Save(g *Graph) error {
 		return err
 	}
 
+	backupIfDue()
+
 	path := graphPath()
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return err
@@ -191,7 +213,10 @@ func (g *Graph) AddEntity(name, entityType string) error {
 	if key == "" {
 		return fmt.Errorf("entity name cannot be empty")
 	}
-	if _, exists := g.Entities[key]; exists {
+	if existing, exists := g.exactKey(name); exists {
+		if existing != key {
+			return fmt.Errorf("%s is an alias of %s", name, g.Entities[existing].Name)
+		}
 		return fmt.Errorf("entity already exists: %s", name)
 	}
 	now := time.Now()
@@ -205,19 +230,20 @@ func (g *Graph) AddEntity(name, entityType string) error {
 	return nil
 }
 
-// GetEntity returns an entity by name (case-insensitive).
+// GetEntity returns an entity by name or alias, ignoring case, and also
+// punctuation when that leaves a single match.
 func (g *Graph) GetEntity(name string) (*Entity, error) {
-	e, ok := g.Entities[normalize(name)]
+	key, ok := g.resolve(name)
 	if !ok {
 		return nil, fmt.Errorf("entity not found: %s", name)
 	}
-	return e, nil
+	return g.Entities[key], nil
 }
 
 // RemoveEntity deletes an entity and all its relations.
 func (g *Graph) RemoveEntity(name string) error {
-	key := normalize(name)
-	if _, ok := g.Entities[key]; !ok {
+	key, ok := g.resolve(name)
+	if !ok {
 		return fmt.Errorf("entity not found: %s", name)
 	}
 	delete(g.Entities, key)
@@ -233,12 +259,140 @@ func (g *Graph) RemoveEntity(name string) error {
 	return nil
 }
 
-// AddObservation appends an observation to an entity.
+// RenameEntity gives an entity a new name, keeping its observations and
+// relations. Changing only the case of a name is allowed; renaming onto
+// another entity is not (use MergeEntity). Renaming to one of the entity's
+// aliases swaps the two.
+func (g *Graph) RenameEntity(oldName, newName string) error {
+	oldKey, ok := g.resolve(oldName)
+	if !ok {
+		return fmt.Errorf("entity not found: %s", oldName)
+	}
+	e, newKey := g.Entities[oldKey], normalize(newName)
+	if newKey == "" {
+		return fmt.Errorf("entity name cannot be empty")
+	}
+	if owner, exists := g.exactKey(newName); exists && owner != oldKey {
+		return fmt.Errorf("entity already exists: %s (merge instead)", newName)
+	}
+
+	for i, a := range e.Aliases {
+		if normalize(a) == newKey {
+			e.Aliases[i] = e.Name
+		}
+	}
+	e.Name = newName
+	e.UpdatedAt = time.Now()
+	delete(g.Entities, oldKey)
+	g.Entities[newKey] = e
+	g.rewriteRelations(oldKey, e.Name)
+	return nil
+}
+
+// MergeEntity folds source into target: unseen observations move over,
+// relations to or from source are pointed at target, and source is removed.
+// Relations between the two are dropped rather than becoming self-loops.
+// The source's name and aliases become aliases of target. It returns how
+// many observations and relations moved.
+func (g *Graph) MergeEntity(source, target string) (observations, relations int, err error) {
+	srcKey, ok := g.resolve(source)
+	if !ok {
+		return 0, 0, fmt.Errorf("entity not found: %s", source)
+	}
+	dstKey, ok := g.resolve(target)
+	if !ok {
+		return 0, 0, fmt.Errorf("entity not found: %s", target)
+	}
+	src, dst := g.Entities[srcKey], g.Entities[dstKey]
+	if srcKey == dstKey {
+		return 0, 0, fmt.Errorf("cannot merge %s into itself", source)
+	}
+
+	seen := make(map[string]bool, len(dst.Observations))
+	for _, o := range dst.Observations {
+		seen[o] = true
+	}
+	for _, o := range src.Observations {
+		if !seen[o] {
+			seen[o] = true
+			dst.Observations = append(dst.Observations, o)
+			observations++
+		}
+	}
+	if (dst.Type == "" || dst.Type == "default") && src.Type != "" {
+		dst.Type = src.Type
+	}
+	if src.CreatedAt.Before(dst.CreatedAt) {
+		dst.CreatedAt = src.CreatedAt
+	}
+	dst.UpdatedAt = time.Now()
+
+	kept := make([]*Relation, 0, len(g.Relations))
+	for _, r := range g.Relations {
+		from, to := normalize(r.From), normalize(r.To)
+		if (from == srcKey && to == dstKey) || (from == dstKey && to == srcKey) {
+			continue
+		}
+		kept = append(kept, r)
+	}
+	g.Relations = kept
+	delete(g.Entities, srcKey)
+	g.addAliases(dstKey, append([]string{src.Name}, src.Aliases...))
+	relations = g.rewriteRelations(srcKey, dst.Name)
+	return observations, relations, nil
+}
+
+// rewriteRelations points every relation end at key to name. Rewritten
+// relations that duplicate an existing one are dropped. It returns how many
+// rewritten relations were kept.
+func (g *Graph) rewriteRelations(key, name string) int {
+	relID := func(r *Relation) string {
+		return normalize(r.From) + "\x00" + r.Type + "\x00" + normalize(r.To)
+	}
+	touches := func(r *Relation) bool {
+		return normalize(r.From) == key || normalize(r.To) == key
+	}
+
+	seen := make(map[string]bool, len(g.Relations))
+	for _, r := range g.Relations {
+		if !touches(r) {
+			seen[relID(r)] = true
+		}
+	}
+
+	rewritten := 0
+	kept := make([]*Relation, 0, len(g.Relations))
+	for _, r := range g.Relations {
+		if touches(r) {
+			if normalize(r.From) == key {
+				r.From = name
+			}
+			if normalize(r.To) == key {
+				r.To = name
+			}
+			if seen[relID(r)] {
+				continue
+			}
+			seen[relID(r)] = true
+			rewritten++
+		}
+		kept = append(kept, r)
+	}
+	g.Relations = kept
+	return rewritten
+}
+
+// AddObservation appends an observation to an entity. It returns
+// ErrDuplicateObservation, and changes nothing, when the entity already has
+// the observation.
 func (g *Graph) AddObservation(name, observation string) error {
 	e, err := g.GetEntity(name)
 	if err != nil {
 		return err
 	}
+	if e.hasObservation(observation) {
+		return ErrDuplicateObservation
+	}
 	e.Observations = append(e.Observations, observation)
 	e.UpdatedAt = time.Now()
 	return nil
@@ -258,15 +412,15 @@ func (g *Graph) RemoveObservation(name string, index int) error {
 	return nil
 }
 
-// AddRelation creates a directed relation. Both entities must exist.
+// AddRelation creates a directed relation. Both entities must exist, and
+// are recorded by their names even when given by alias.
 func (g *Graph) AddRelation(from, relType, to string) error {
-	fromKey := normalize(from)
-	toKey := normalize(to)
-
-	if _, ok := g.Entities[fromKey]; !ok {
+	fromKey, ok := g.resolve(from)
+	if !ok {
 		return fmt.Errorf("entity not found: %s", from)
 	}
-	if _, ok := g.Entities[toKey]; !ok {
+	toKey, ok := g.resolve(to)
+	if !ok {
 		return fmt.Errorf("entity not found: %s", to)
 	}
 
@@ -287,8 +441,8 @@ func (g *Graph) AddRelation(from, relType, to string) error {
 
 // RemoveRelation removes a specific relation.
 func (g *Graph) RemoveRelation(from, relType, to string) error {
-	fromKey := normalize(from)
-	toKey := normalize(to)
+	fromKey, _ := g.resolve(from)
+	toKey, _ := g.resolve(to)
 
 	for i, r := range g.Relations {
 		if normalize(r.From) == fromKey && r.Type == relType && normalize(r.To) == toKey {
@@ -303,7 +457,7 @@ func (g *Graph) RemoveRelation(from, relType, to string) error {
 
 // RelationsOf returns outgoing and incoming relations for an entity.
 func (g *Graph) RelationsOf(name string) ([]*Relation, []*Relation) {
-	key := normalize(name)
+	key, _ := g.resolve(name)
 	var outgoing, incoming []*Relation
 	for _, r := range g.Relations {
 		if normalize(r.From) == key {
@@ -316,20 +470,24 @@ func (g *Graph) RelationsOf(name string) ([]*Relation, []*Relation) {
 	return outgoing, incoming
 }
 
-// Search finds entities matching a query string. Scored: name(100) > type(20) > observation(10).
+// Search finds entities matching a query string. Scored: name or alias(100)
+// > type(20) > observation(10).
 func (g *Graph) Search(query string) []SearchResult {
 	q := strings.ToLower(query)
 	var results []SearchResult
 
 	for _, e := range g.Entities {
 		score := 0
-		nameLower := strings.ToLower(e.Name)
 		typeLower := strings.ToLower(e.Type)
 
-		if nameLower == q {
-			score += 100
-		} else if strings.Contains(nameLower, q) {
-			score += 50
+		for _, name := range append([]string{e.Name}, e.Aliases...) {
+			nameLower := strings.ToLower(name)
+			if nameLower == q {
+				score = 100
+				break
+			} else if strings.Contains(nameLower, q) {
+				score = 50
+			}
 		}
 
 		if typeLower == q {
@@ -444,6 +602,91 @@ func (g *Graph) ExportDOT() string {
 	return b.String()
 }
 
+// ExportGraphML returns the graph in GraphML format (readable by Gephi and yEd).
+func (g *Graph) ExportGraphML() string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">` + "\n")
+	b.WriteString(`  <key id="name" for="node" attr.name="name" attr.type="string"/>` + "\n")
+	b.WriteString(`  <key id="type" for="node" attr.name="type" attr.type="string"/>` + "\n")
+	b.WriteString(`  <key id="aliases" for="node" attr.name="aliases" attr.type="string"/>` + "\n")
+	b.WriteString(`  <key id="observations" for="node" attr.name="observations" attr.type="string"/>` + "\n")
+	b.WriteString(`  <key id="relation" for="edge" attr.name="relation" attr.type="string"/>` + "\n")
+	b.WriteString(`  <graph id="palm_graph" edgedefault="directed">` + "\n")
+
+	for _, k := range g.sortedKeys() {
+		e := g.Entities[k]
+		b.WriteString(fmt.Sprintf("    <node id=\"%s\">\n", xmlEscape(k)))
+		b.WriteString(fmt.Sprintf("      <data key=\"name\">%s</data>\n", xmlEscape(e.Name)))
+		b.WriteString(fmt.Sprintf("      <data key=\"type\">%s</data>\n", xmlEscape(e.Type)))
+		if len(e.Aliases) > 0 {
+			b.WriteString(fmt.Sprintf("      <data key=\"aliases\">%s</data>\n", xmlEscape(strings.Join(e.Aliases, "\n"))))
+		}
+		b.WriteString(fmt.Sprintf("      <data key=\"observations\">%s</data>\n", xmlEscape(strings.Join(e.Observations, "\n"))))
+		b.WriteString("    </node>\n")
+	}
+
+	for i, r := range g.Relations {
+		b.WriteString(fmt.Sprintf("    <edge id=\"e%d\" source=\"%s\" target=\"%s\">\n",
+			i, xmlEscape(normalize(r.From)), xmlEscape(normalize(r.To))))
+		b.WriteString(fmt.Sprintf("      <data key=\"relation\">%s</data>\n", xmlEscape(r.Type)))
+		b.WriteString("    </edge>\n")
+	}
+
+	b.WriteString("  </graph>\n")
+	b.WriteString("</graphml>\n")
+	return b.String()
+}
+
+// ExportNodesCSV returns entities as CSV rows (id, name, type, observations, created_at, updated_at, aliases).
+// Observations and aliases are joined with " | " so each entity fits in a single row.
+func (g *Graph) ExportNodesCSV() (string, error) {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Write([]string{"id", "name", "type", "observations", "created_at", "updated_at", "aliases"})
+	for _, k := range g.sortedKeys() {
+		e := g.Entities[k]
+		w.Write([]string{
+			k,
+			e.Name,
+			e.Type,
+			strings.Join(e.Observations, " | "),
+			e.CreatedAt.Format(time.RFC3339),
+			e.UpdatedAt.Format(time.RFC3339),
+			strings.Join(e.Aliases, " | "),
+		})
+	}
+	w.Flush()
+	return b.String(), w.Error()
+}
+
+// ExportEdgesCSV returns relations as CSV rows (source, target, type).
+func (g *Graph) ExportEdgesCSV() (string, error) {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Write([]string{"source", "target", "type"})
+	for _, r := range g.Relations {
+		w.Write([]string{normalize(r.From), normalize(r.To), r.Type})
+	}
+	w.Flush()
+	return b.String(), w.Error()
+}
+
+func (g *Graph) sortedKeys() []string {
+	keys := make([]string, 0, len(g.Entities))
+	for k := range g.Entities {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
 // ImportJSON merges entities and relations from JSON data into this graph.
 // New entities are added; existing entities get observations appended.
 func (g *Graph) ImportJSON(data []byte) (added, merged, relAdded int, err error) {
@@ -451,10 +694,34 @@ func (g *Graph) ImportJSON(data []byte) (added, merged, relAdded int, err error)
 	if err = json.Unmarshal(data, &incoming); err != nil {
 		return 0, 0, 0, fmt.Errorf("import parse: %w", err)
 	}
+	added, merged, relAdded = g.merge(&incoming)
+	g.mergeSchema(incoming.Schema)
+	return added, merged, relAdded, nil
+}
 
-	for key, ie := range incoming.Entities {
-		existing, exists := g.Entities[normalize(key)]
-		if exists {
+// merge folds another graph into g. New entities are added, existing ones get
+// unseen observations and aliases appended, and relations are deduplicated.
+// Entities and relation ends named by an alias resolve to its entity.
+func (g *Graph) merge(incoming *Graph) (added, merged, relAdded int) {
+	// Entities named by another incoming entity's alias come last, so they
+	// fold into it
+	aliases := make(map[string]bool)
+	for _, ie := range incoming.Entities {
+		for _, a := range ie.Aliases {
+			aliases[normalize(a)] = true
+		}
+	}
+	keys := incoming.sortedKeys()
+	sort.SliceStable(keys, func(i, j int) bool { return !aliases[keys[i]] && aliases[keys[j]] })
+
+	for _, key := range keys {
+		ie := incoming.Entities[key]
+		name := ie.Name
+		if name == "" {
+			name = key
+		}
+		if existingKey, exists := g.exactKey(name); exists {
+			existing := g.Entities[existingKey]
 			// Merge: append new observations
 			obsSet := make(map[string]bool)
 			for _, o := range existing.Observations {
@@ -465,6 +732,7 @@ func (g *Graph) ImportJSON(data []byte) (added, merged, relAdded int, err error)
 					existing.Observations = append(existing.Observations, o)
 				}
 			}
+			g.addAliases(existingKey, ie.Aliases)
 			existing.UpdatedAt = time.Now()
 			merged++
 		} else {
@@ -478,21 +746,21 @@ func (g *Graph) ImportJSON(data []byte) (added, merged, relAdded int, err error)
 			if ie.UpdatedAt.IsZero() {
 				ie.UpdatedAt = time.Now()
 			}
+			newAliases := ie.Aliases
+			ie.Aliases = nil
 			g.Entities[normalize(key)] = ie
+			g.addAliases(normalize(key), newAliases)
 			added++
 		}
 	}
 
 	// Import relations (deduplicate)
 	for _, ir := range incoming.Relations {
-		fromKey := normalize(ir.From)
-		toKey := normalize(ir.To)
+		fromKey, fromOK := g.exactKey(ir.From)
+		toKey, toOK := g.exactKey(ir.To)
 
 		// Skip if either entity doesn't exist in final graph
-		if _, ok := g.Entities[fromKey]; !ok {
-			continue
-		}
-		if _, ok := g.Entities[toKey]; !ok {
+		if !fromOK || !toOK {
 			continue
 		}
 
@@ -504,12 +772,12 @@ func (g *Graph) ImportJSON(data []byte) (added, merged, relAdded int, err error)
 			}
 		}
 		if !dup {
-			g.Relations = append(g.Relations, ir)
+			g.Relations = append(g.Relations, &Relation{From: g.Entities[fromKey].Name, To: g.Entities[toKey].Name, Type: ir.Type})
 			relAdded++
 		}
 	}
 
-	return added, merged, relAdded, nil
+	return added, merged, relAdded
 }
 
 // ─── Visualization ───
@@ -547,6 +815,9 @@ func RenderShow(g *Graph, name string, brandFn, subtleFn, infoFn func(string) st
 	if result.Entity.Type != "" {
 		b.WriteString(fmt.Sprintf("  \u2502  %s\n", subtleFn(result.Entity.Type)))
 	}
+	if len(result.Entity.Aliases) > 0 {
+		b.WriteString(fmt.Sprintf("  \u2502  %s\n", subtleFn("aka "+strings.Join(result.Entity.Aliases, ", "))))
+	}
 	for _, obs := range result.Entity.Observations {
 		b.WriteString(fmt.Sprintf("  \u2502  %s\n", infoFn("\""+obs+"\"")))
 	}
@@ -581,15 +852,87 @@ func RenderShow(g *Graph, name string, brandFn, subtleFn, infoFn func(string) st
 
 // ─── HTML Visualization (Obsidian-like graph view) ───
 
+// nodeColors maps every entity type in the graph to its display color.
+// Declared types keep their own color; the rest share the remaining palette
+// in name order.
+func (g *Graph) nodeColors() map[string]string {
+	colors := make(map[string]string)
+	taken := make(map[string]bool)
+	for _, e := range g.Entities {
+		t := e.Type
+		if t == "" {
+			t = "default"
+		}
+		if d, ok := g.lookupType(t); ok {
+			colors[t] = d.Color
+			taken[strings.ToUpper(d.Color)] = true
+		} else {
+			colors[t] = ""
+		}
+	}
+
+	var free []string
+	for _, c := range typePalette {
+		if !taken[c] {
+			free = append(free, c)
+		}
+	}
+	if len(free) == 0 {
+		free = typePalette
+	}
+	var undeclared []string
+	for t, c := range colors {
+		if c == "" {
+			undeclared = append(undeclared, t)
+		}
+	}
+	sort.Strings(undeclared)
+	for i, t := range undeclared {
+		colors[t] = free[i%len(free)]
+	}
+	return colors
+}
+
 // ExportHTML returns a self-contained HTML file with a force-directed graph visualization.
 // All data is embedded as JSON constants — no external dependencies.
 func (g *Graph) ExportHTML() string {
+	return g.renderHTML(false, "")
+}
+
+// ExportFocusHTML renders only the entities within depth relations of the
+// named one, which starts highlighted. It keeps large graphs readable.
+func (g *Graph) ExportFocusHTML(name string, depth int) (string, error) {
+	sub, err := g.Neighborhood(name, depth)
+	if err != nil {
+		return "", err
+	}
+	key, _ := g.resolve(name)
+	return sub.renderHTML(false, key), nil
+}
+
+// LiveHTML returns the visualization with an editing side panel wired to the
+// JSON API served by Handler. Clicking a node loads its details, and new
+// observations and relations are posted back to the server.
+func (g *Graph) LiveHTML() string {
+	return g.renderHTML(true, "")
+}
+
+// renderHTML builds the visualization. focus is the key of an entity to
+// start highlighted and to keep visible through filters, or "".
+func (g *Graph) renderHTML(live bool, focus string) string {
+	liveStyle, livePanel, liveScript := "", "", ""
+	if live {
+		liveStyle, livePanel, liveScript = liveHTMLStyle, liveHTMLPanel, liveHTMLScript
+	}
+
 	// Build nodes and edges arrays as JSON for the JS
 	type jsNode struct {
-		ID   string   `json:"id"`
-		Name string   `json:"name"`
-		Type string   `json:"type"`
-		Obs  []string `json:"obs"`
+		ID        string   `json:"id"`
+		Name      string   `json:"name"`
+		Type      string   `json:"type"`
+		Aliases   []string `json:"aliases,omitempty"`
+		Obs       []string `json:"obs"`
+		Community int      `json:"community"`
 	}
 	type jsEdge struct {
 		Source string `json:"source"`
@@ -603,9 +946,10 @@ func (g *Graph) ExportHTML() string {
 		keys = append(keys, k)
 	}
 	sort.Strings(keys)
+	clusters := g.communities()
 	for _, k := range keys {
 		e := g.Entities[k]
-		nodes = append(nodes, jsNode{ID: k, Name: e.Name, Type: e.Type, Obs: e.Observations})
+		nodes = append(nodes, jsNode{ID: k, Name: e.Name, Type: e.Type, Aliases: e.Aliases, Obs: e.Observations, Community: clusters[k]})
 	}
 
 	edges := make([]jsEdge, 0, len(g.Relations))
@@ -615,6 +959,8 @@ func (g *Graph) ExportHTML() string {
 
 	nodesJSON, _ := json.Marshal(nodes)
 	edgesJSON, _ := json.Marshal(edges)
+	colorsJSON, _ := json.Marshal(g.nodeColors())
+	focusJSON, _ := json.Marshal(focus)
 
 	return fmt.Sprintf(`<!DOCTYPE html>
 <html lang="en">
@@ -638,8 +984,17 @@ canvas{display:block}
 #search-box::placeholder{color:#555}
 #search-box:focus{border-color:#2DB682}
 #legend{position:fixed;bottom:16px;left:16px;z-index:10;background:rgba(10,14,23,0.9);border:1px solid rgba(255,255,255,0.06);border-radius:10px;padding:12px 16px;font-size:11px;color:#666}
-.leg-row{margin:3px 0;display:flex;align-items:center;gap:8px}
+.leg-row{margin:3px 0;display:flex;align-items:center;gap:8px;cursor:pointer;user-select:none}
+.leg-row.off{opacity:0.35;text-decoration:line-through}
+#controls{margin-top:10px;padding-top:10px;border-top:1px solid rgba(255,255,255,0.06);font-size:11px;color:#888}
+#controls label{display:block;margin:6px 0 2px}
+#controls label b{color:#ccc}
+#controls input[type=range]{width:100%%;accent-color:#2DB682}
+#controls select{background:#0a0e17;color:#ccc;border:1px solid rgba(255,255,255,0.12);border-radius:6px;padding:2px 6px;font-size:11px;font-family:inherit}
+.btn{background:rgba(45,182,130,0.12);border:1px solid rgba(45,182,130,0.4);color:#2DB682;border-radius:6px;padding:3px 8px;font-size:11px;cursor:pointer;margin:8px 4px 0 0;font-family:inherit}
+.btn:hover{background:rgba(45,182,130,0.25)}
 .dot{width:10px;height:10px;border-radius:50%%;display:inline-block}
+%s
 </style>
 </head>
 <body>
@@ -647,26 +1002,41 @@ canvas{display:block}
   <h2 id="title"></h2>
   <div class="stat"><b id="n-nodes">0</b> entities</div>
   <div class="stat"><b id="n-edges">0</b> relations</div>
+  <div class="stat"><b id="n-shown">0</b> shown</div>
   <div class="stat" id="hint" style="margin-top:8px;color:#555;font-size:11px;"></div>
+  <div id="controls">
+    <label>Min. connections: <b id="deg-val">0</b></label>
+    <input id="deg" type="range" min="0" max="0" value="0">
+    <label>Color by</label>
+    <select id="color-by"><option value="type">type</option><option value="community">cluster</option></select>
+    <div><button class="btn" id="png">PNG</button><button class="btn" id="svg">SVG</button><button class="btn" id="unpin">Unpin all</button></div>
+  </div>
 </div>
 <input id="search-box" type="text" placeholder="Search entities...">
 <div id="tooltip"></div>
+%s
 <div id="legend"></div>
 <canvas id="canvas"></canvas>
 <script>
 "use strict";
 const NODES=%s;
 const EDGES=%s;
+const FOCUS=%s;
 
 document.getElementById('title').textContent='palm graph';
 document.getElementById('n-nodes').textContent=NODES.length;
 document.getElementById('n-edges').textContent=EDGES.length;
-document.getElementById('hint').textContent='drag nodes / scroll to zoom / search to filter';
+document.getElementById('hint').textContent='drag to pin / double-click to unpin / scroll to zoom / search to highlight';
 
-const PALETTE=['#2DB682','#0171E3','#E07C3A','#9B59B6','#E74C3C','#1ABC9C','#F1C40F','#3498DB','#E91E63','#00BCD4'];
-const TYPE_COLORS={};
+const TYPE_COLORS=%s;
 const types=[...new Set(NODES.map(n=>n.type||'default'))].sort();
-types.forEach((t,i)=>{TYPE_COLORS[t]=PALETTE[i%%PALETTE.length]});
+const CLUSTER_COLORS=['#2DB682','#4F8EF7','#F5A623','#E5566C','#9B6CF2','#3CC8D8','#F2D04B','#F07C3E','#7ED957','#D86BC8'];
+const hiddenTypes=new Set();
+let minDegree=0,colorBy='type';
+function nodeColor(n){
+  if(colorBy==='community')return CLUSTER_COLORS[n.community%%CLUSTER_COLORS.length];
+  return TYPE_COLORS[n.type||'default']||'#2DB682';
+}
 
 const legend=document.getElementById('legend');
 types.forEach(t=>{
@@ -678,6 +1048,12 @@ types.forEach(t=>{
   row.appendChild(dot);
   const lbl=document.createTextNode(' '+(t||'default'));
   row.appendChild(lbl);
+  row.title='click to show or hide';
+  row.onclick=()=>{
+    if(hiddenTypes.has(t))hiddenTypes.delete(t);else hiddenTypes.add(t);
+    row.classList.toggle('off',hiddenTypes.has(t));
+    applyFilters();
+  };
   legend.appendChild(row);
 });
 
@@ -689,11 +1065,36 @@ resize();
 window.addEventListener('resize',resize);
 
 const sim={
-  nodes:NODES.map(n=>({...n,x:W/2+(Math.random()-0.5)*300,y:H/2+(Math.random()-0.5)*300,vx:0,vy:0,r:6+Math.min(n.obs.length,10)*1.5,highlight:false,hidden:false})),
+  nodes:NODES.map(n=>({...n,x:W/2+(Math.random()-0.5)*300,y:H/2+(Math.random()-0.5)*300,vx:0,vy:0,r:6+Math.min(n.obs.length,10)*1.5,highlight:n.id===FOCUS,hidden:false,pinned:false,degree:0})),
   edges:EDGES.map(e=>({...e,si:NODES.findIndex(n=>n.id===e.source),ti:NODES.findIndex(n=>n.id===e.target)})).filter(e=>e.si>=0&&e.ti>=0)
 };
 
-let camera={x:0,y:0,zoom:1},drag=null,hovered=null;
+let camera={x:0,y:0,zoom:1},drag=null,dragMoved=false,hovered=null;
+
+// applyFilters hides nodes of switched-off types and nodes with fewer
+// relations than the slider asks for. The focus node always stays.
+function applyFilters(){
+  const deg=new Map();
+  for(const e of sim.edges){deg.set(e.si,(deg.get(e.si)||0)+1);deg.set(e.ti,(deg.get(e.ti)||0)+1)}
+  let shown=0;
+  sim.nodes.forEach((n,i)=>{
+    n.degree=deg.get(i)||0;
+    n.hidden=n.id!==FOCUS&&(hiddenTypes.has(n.type||'default')||n.degree<minDegree);
+    if(!n.hidden)shown++;
+  });
+  document.getElementById('n-shown').textContent=shown;
+}
+applyFilters();
+
+const degSlider=document.getElementById('deg');
+degSlider.max=Math.max(0,...sim.nodes.map(n=>n.degree));
+degSlider.addEventListener('input',()=>{
+  minDegree=+degSlider.value;
+  document.getElementById('deg-val').textContent=minDegree;
+  applyFilters();
+});
+document.getElementById('color-by').addEventListener('change',function(){colorBy=this.value});
+document.getElementById('unpin').addEventListener('click',()=>{for(const n of sim.nodes)n.pinned=false});
 
 function tick(){
   const nodes=sim.nodes,edges=sim.edges;
@@ -715,7 +1116,7 @@ function tick(){
     a.vx+=fx;a.vy+=fy;b.vx-=fx;b.vy-=fy;
   }
   for(const n of nodes){
-    if(n===drag)continue;
+    if(n===drag||n.pinned){n.vx=0;n.vy=0;continue}
     n.vx*=damp;n.vy*=damp;n.x+=n.vx;n.y+=n.vy;
   }
 }
@@ -750,7 +1151,7 @@ function draw(){
     if(n.hidden)continue;
     const[sx,sy]=toScreen(n.x,n.y);
     const r=n.r*camera.zoom;
-    const col=TYPE_COLORS[n.type||'default']||'#2DB682';
+    const col=nodeColor(n);
     const isHl=n===hovered||n.highlight;
     if(isHl){
       ctx.beginPath();ctx.arc(sx,sy,r+6,0,Math.PI*2);
@@ -761,6 +1162,10 @@ function draw(){
     ctx.beginPath();ctx.arc(sx,sy,r,0,Math.PI*2);
     ctx.fillStyle=isHl?col:col+'99';ctx.fill();
     ctx.strokeStyle=col;ctx.lineWidth=isHl?2:1;ctx.stroke();
+    if(n.pinned){
+      ctx.beginPath();ctx.arc(sx,sy,r+3,0,Math.PI*2);
+      ctx.setLineDash([3,3]);ctx.strokeStyle='rgba(255,255,255,0.6)';ctx.lineWidth=1;ctx.stroke();ctx.setLineDash([]);
+    }
     ctx.font=(isHl?'bold ':'')+Math.max(11,12*camera.zoom)+'px -apple-system,sans-serif';
     ctx.fillStyle=isHl?'#fff':'#bbb';ctx.textAlign='center';
     ctx.fillText(n.name,sx,sy+r+14*camera.zoom);
@@ -779,6 +1184,7 @@ function findNode(sx,sy){
 
 canvas.addEventListener('mousedown',e=>{
   const n=findNode(e.clientX,e.clientY);
+  dragMoved=false;
   if(n){drag=n;drag.vx=0;drag.vy=0}
   else{drag={pan:true,sx:e.clientX,sy:e.clientY,cx:camera.x,cy:camera.y}}
 });
@@ -787,7 +1193,7 @@ canvas.addEventListener('mousemove',e=>{
     camera.x=drag.cx-(e.clientX-drag.sx)/camera.zoom;
     camera.y=drag.cy-(e.clientY-drag.sy)/camera.zoom;
   }else if(drag){
-    const[wx,wy]=toWorld(e.clientX,e.clientY);drag.x=wx;drag.y=wy;
+    const[wx,wy]=toWorld(e.clientX,e.clientY);drag.x=wx;drag.y=wy;dragMoved=true;
   }
   const n=findNode(e.clientX,e.clientY);
   hovered=n;
@@ -798,6 +1204,7 @@ canvas.addEventListener('mousemove',e=>{
     tt.textContent='';
     const nameEl=document.createElement('div');nameEl.className='tt-name';nameEl.textContent=n.name;tt.appendChild(nameEl);
     if(n.type){const typeEl=document.createElement('div');typeEl.className='tt-type';typeEl.textContent=n.type;tt.appendChild(typeEl)}
+    if(n.aliases){const akaEl=document.createElement('div');akaEl.className='tt-type';akaEl.textContent='aka '+n.aliases.join(', ');tt.appendChild(akaEl)}
     if(n.obs&&n.obs.length>0){
       n.obs.forEach(o=>{const obsEl=document.createElement('div');obsEl.className='tt-obs';obsEl.textContent=o;tt.appendChild(obsEl)});
     }
@@ -806,7 +1213,15 @@ canvas.addEventListener('mousemove',e=>{
     canvas.style.cursor=drag?'grabbing':'default';tt.style.display='none';
   }
 });
-canvas.addEventListener('mouseup',()=>{drag=null});
+canvas.addEventListener('mouseup',()=>{
+  // A node dropped after dragging stays where it was put
+  if(drag&&!drag.pan&&dragMoved)drag.pinned=true;
+  drag=null;
+});
+canvas.addEventListener('dblclick',e=>{
+  const n=findNode(e.clientX,e.clientY);
+  if(n)n.pinned=!n.pinned;
+});
 canvas.addEventListener('wheel',e=>{
   e.preventDefault();
   const factor=e.deltaY>0?0.9:1.1;
@@ -816,13 +1231,46 @@ canvas.addEventListener('wheel',e=>{
 document.getElementById('search-box').addEventListener('input',function(){
   const q=this.value.toLowerCase();
   for(const n of sim.nodes){
-    n.highlight=q&&(n.name.toLowerCase().includes(q)||(n.type||'').toLowerCase().includes(q));
-    n.hidden=false;
+    n.highlight=q&&(n.name.toLowerCase().includes(q)||(n.type||'').toLowerCase().includes(q)||(n.aliases||[]).some(a=>a.toLowerCase().includes(q)));
+  }
+});
+
+function download(name,url){
+  const a=document.createElement('a');
+  a.href=url;a.download=name;
+  document.body.appendChild(a);a.click();a.remove();
+}
+document.getElementById('png').addEventListener('click',()=>{
+  const out=document.createElement('canvas');
+  out.width=W;out.height=H;
+  const o=out.getContext('2d');
+  o.fillStyle='#0a0e17';o.fillRect(0,0,W,H);o.drawImage(canvas,0,0);
+  download('palm-graph.png',out.toDataURL('image/png'));
+});
+function esc(s){return String(s).replace(/[&<>"]/g,c=>({'&':'&amp;','<':'&lt;','>':'&gt;','"':'&quot;'}[c]))}
+document.getElementById('svg').addEventListener('click',()=>{
+  const f=v=>v.toFixed(1);
+  const parts=['<svg xmlns="http://www.w3.org/2000/svg" width="'+W+'" height="'+H+'" viewBox="0 0 '+W+' '+H+'" font-family="sans-serif">',
+    '<rect width="100%%" height="100%%" fill="#0a0e17"/>'];
+  for(const e of sim.edges){
+    const a=sim.nodes[e.si],b=sim.nodes[e.ti];
+    if(a.hidden||b.hidden)continue;
+    const[ax,ay]=toScreen(a.x,a.y),[bx,by]=toScreen(b.x,b.y);
+    parts.push('<line x1="'+f(ax)+'" y1="'+f(ay)+'" x2="'+f(bx)+'" y2="'+f(by)+'" stroke="#ffffff" stroke-opacity="0.15"><title>'+esc(e.type)+'</title></line>');
+  }
+  for(const n of sim.nodes){
+    if(n.hidden)continue;
+    const[sx,sy]=toScreen(n.x,n.y),r=n.r*camera.zoom,col=nodeColor(n);
+    parts.push('<circle cx="'+f(sx)+'" cy="'+f(sy)+'" r="'+f(r)+'" fill="'+col+'" fill-opacity="0.6" stroke="'+col+'"/>');
+    parts.push('<text x="'+f(sx)+'" y="'+f(sy+r+14*camera.zoom)+'" fill="#bbbbbb" font-size="'+Math.max(11,12*camera.zoom).toFixed(0)+'" text-anchor="middle">'+esc(n.name)+'</text>');
   }
+  parts.push('</svg>');
+  download('palm-graph.svg',URL.createObjectURL(new Blob([parts.join('\n')],{type:'image/svg+xml'})));
 });
 
+%s
 (function loop(){tick();draw();requestAnimationFrame(loop)})();
 </script>
 </body>
-</html>`, string(nodesJSON), string(edgesJSON))
+</html>`, liveStyle, livePanel, string(nodesJSON), string(edgesJSON), string(focusJSON), string(colorsJSON), liveScript)
 }
@@ -2,8 +2,13 @@ package graph
 
 import (
 	"encoding/json"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -108,6 +113,88 @@ func TestRemoveEntityNotFound(t *testing.T) {
 	}
 }
 
+func TestRenameEntity(t *testing.T) {
+	g := New()
+	g.AddEntity("K8s", "tool")
+	g.AddEntity("palm", "project")
+	g.AddRelation("palm", "deploys-to", "K8s")
+	g.AddRelation("K8s", "runs", "palm")
+
+	if err := g.RenameEntity("k8s", "Kubernetes"); err != nil {
+		t.Fatalf("RenameEntity failed: %v", err)
+	}
+	if _, err := g.GetEntity("K8s"); err == nil {
+		t.Error("old name should no longer resolve")
+	}
+	e, err := g.GetEntity("kubernetes")
+	if err != nil || e.Name != "Kubernetes" || e.Type != "tool" {
+		t.Fatalf("renamed entity = %+v, %v", e, err)
+	}
+	out, in := g.RelationsOf("Kubernetes")
+	if len(out) != 1 || len(in) != 1 || in[0].To != "Kubernetes" {
+		t.Errorf("relations not preserved: out=%v in=%v", out, in)
+	}
+
+	if err := g.RenameEntity("Kubernetes", "palm"); err == nil {
+		t.Error("expected error renaming onto an existing entity")
+	}
+	if err := g.RenameEntity("kubernetes", "KUBERNETES"); err != nil {
+		t.Errorf("case-only rename failed: %v", err)
+	}
+}
+
+func TestMergeEntity(t *testing.T) {
+	g := New()
+	g.AddEntity("K8s", "default")
+	g.AddEntity("Kubernetes", "tool")
+	g.AddEntity("palm", "project")
+	g.AddEntity("Helm", "tool")
+	g.AddObservation("K8s", "container orchestrator")
+	g.AddObservation("K8s", "CNCF project")
+	g.AddObservation("Kubernetes", "CNCF project")
+	g.AddRelation("palm", "deploys-to", "K8s")
+	g.AddRelation("palm", "deploys-to", "Kubernetes")
+	g.AddRelation("K8s", "uses", "Helm")
+	g.AddRelation("K8s", "alias-of", "Kubernetes")
+
+	obs, rels, err := g.MergeEntity("K8s", "Kubernetes")
+	if err != nil {
+		t.Fatalf("MergeEntity failed: %v", err)
+	}
+	if obs != 1 {
+		t.Errorf("expected 1 observation moved, got %d", obs)
+	}
+	if rels != 1 {
+		t.Errorf("expected 1 relation rewritten, got %d", rels)
+	}
+	if _, ok := g.Entities["k8s"]; ok {
+		t.Error("source should be removed")
+	}
+	if e, err := g.GetEntity("K8s"); err != nil || e.Name != "Kubernetes" {
+		t.Errorf("the source's name should become an alias of the target, got %v %v", e, err)
+	}
+	k, _ := g.GetEntity("Kubernetes")
+	if len(k.Observations) != 2 || k.Type != "tool" {
+		t.Errorf("target = %+v", k)
+	}
+	// palm->K8s collapses into the existing palm->Kubernetes edge, and the
+	// edge between the two duplicates is dropped
+	if len(g.Relations) != 2 {
+		t.Errorf("expected 2 relations, got %d: %v", len(g.Relations), g.Relations)
+	}
+	out, _ := g.RelationsOf("Kubernetes")
+	if len(out) != 1 || out[0].To != "Helm" || out[0].From != "Kubernetes" {
+		t.Errorf("outgoing relations = %v", out)
+	}
+
+	if _, _, err := g.MergeEntity("Kubernetes", "kubernetes"); err == nil {
+		t.Error("expected error merging an entity into itself")
+	}
+	if _, _, err := g.MergeEntity("missing", "Kubernetes"); err == nil {
+		t.Error("expected error for missing source")
+	}
+}
+
 func TestAddObservation(t *testing.T) {
 	g := New()
 	g.AddEntity("Alice", "person")
@@ -516,6 +603,87 @@ func TestExportHTML(t *testing.T) {
 	}
 }
 
+func TestNeighborhood(t *testing.T) {
+	g := New()
+	for _, n := range []string{"A", "B", "C", "D", "E"} {
+		g.AddEntity(n, "thing")
+	}
+	g.AddRelation("A", "uses", "B")
+	g.AddRelation("C", "uses", "B")
+	g.AddRelation("C", "uses", "D")
+	g.AddRelation("D", "uses", "E")
+
+	sub, err := g.Neighborhood("b", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sub.Entities) != 3 || sub.Entities["d"] != nil {
+		t.Errorf("depth 1 around B: got %d entities, want A, B, C", len(sub.Entities))
+	}
+	if len(sub.Relations) != 2 {
+		t.Errorf("got %d relations, want 2", len(sub.Relations))
+	}
+
+	sub, _ = g.Neighborhood("B", 2)
+	if len(sub.Entities) != 4 || sub.Entities["e"] != nil {
+		t.Errorf("depth 2 around B: got %d entities, want A-D", len(sub.Entities))
+	}
+	if _, err := g.Neighborhood("missing", 2); err == nil {
+		t.Error("expected an error for a missing entity")
+	}
+}
+
+func TestCommunities(t *testing.T) {
+	g := New()
+	for _, n := range []string{"a1", "a2", "a3", "b1", "b2", "b3", "lone"} {
+		g.AddEntity(n, "thing")
+	}
+	for _, r := range [][2]string{{"a1", "a2"}, {"a2", "a3"}, {"a3", "a1"}, {"b1", "b2"}, {"b2", "b3"}, {"b3", "b1"}, {"a1", "b1"}} {
+		g.AddRelation(r[0], "links", r[1])
+	}
+
+	c := g.communities()
+	if c["a1"] != c["a2"] || c["a2"] != c["a3"] {
+		t.Errorf("a-triangle split: %v", c)
+	}
+	if c["b1"] != c["b2"] || c["b2"] != c["b3"] {
+		t.Errorf("b-triangle split: %v", c)
+	}
+	if c["a1"] == c["b1"] || c["lone"] == c["a1"] || c["lone"] == c["b1"] {
+		t.Errorf("clusters merged: %v", c)
+	}
+	for i := 0; i < 5; i++ {
+		if again := g.communities(); !maps.Equal(again, c) {
+			t.Fatalf("communities not stable: %v then %v", c, again)
+		}
+	}
+}
+
+func TestExportFocusHTML(t *testing.T) {
+	g := New()
+	g.AddEntity("Near", "tool")
+	g.AddEntity("Center", "tool")
+	g.AddEntity("Far", "tool")
+	g.AddRelation("Center", "uses", "Near")
+	g.AddRelation("Near", "uses", "Far")
+
+	html, err := g.ExportFocusHTML("center", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contains(html, `"Far"`) || !contains(html, `"Near"`) {
+		t.Error("focus view should hold only the neighborhood")
+	}
+	for _, want := range []string{`const FOCUS="center"`, `id="deg"`, `id="color-by"`, `id="png"`, `id="svg"`, `"community":`} {
+		if !contains(html, want) {
+			t.Errorf("HTML missing %s", want)
+		}
+	}
+	if contains(html, "%!") {
+		t.Error("HTML has a formatting error")
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsStr(s, substr))
 }
@@ -528,3 +696,436 @@ func containsStr(s, sub string) bool {
 	}
 	return false
 }
+
+func TestSchemaValidation(t *testing.T) {
+	g := New()
+	if _, err := g.DefineType("person", []string{"email,company"}, ""); err != nil {
+		t.Fatalf("DefineType failed: %v", err)
+	}
+	g.DefineType("project", nil, "#0171E3")
+	if _, err := g.DefineRelation("works-on", []string{"person"}, []string{"project"}); err != nil {
+		t.Fatalf("DefineRelation failed: %v", err)
+	}
+	if _, err := g.DefineRelation("owns", []string{"team"}, nil); err == nil {
+		t.Error("expected error for relation over an undeclared type")
+	}
+	if _, err := g.DefineType("bad", nil, "green"); err == nil {
+		t.Error("expected error for non-hex color")
+	}
+
+	if err := g.CheckEntity("Person", []string{"email"}); err != nil {
+		t.Errorf("declared type rejected: %v", err)
+	}
+	if err := g.CheckEntity("person", []string{"phone"}); err == nil {
+		t.Error("expected error for undeclared field")
+	}
+	if err := g.CheckEntity("robot", nil); err == nil {
+		t.Error("expected error for undeclared type")
+	}
+
+	g.AddEntity("Alice", "person")
+	g.AddEntity("palm", "project")
+	if err := g.CheckRelation("Alice", "works-on", "palm"); err != nil {
+		t.Errorf("valid relation rejected: %v", err)
+	}
+	if err := g.CheckRelation("palm", "works-on", "Alice"); err == nil {
+		t.Error("expected error for relation in the wrong direction")
+	}
+	if err := g.CheckRelation("Alice", "likes", "palm"); err == nil {
+		t.Error("expected error for undeclared relation")
+	}
+
+	if err := g.Undefine("works-on"); err != nil {
+		t.Errorf("Undefine failed: %v", err)
+	}
+	if len(g.DeclaredRelations()) != 0 || len(g.DeclaredTypes()) != 2 {
+		t.Errorf("unexpected schema after Undefine: %v %v", g.DeclaredTypes(), g.DeclaredRelations())
+	}
+}
+
+func TestNodeColorsStable(t *testing.T) {
+	g := New()
+	g.DefineType("zebra", nil, "")
+	g.AddEntity("Z", "zebra")
+	g.AddEntity("A", "aardvark")
+	want := g.TypeDef("zebra").Color
+
+	// Adding types that sort first must not shift a declared type's color
+	g.AddEntity("B", "badger")
+	colors := g.nodeColors()
+	if colors["zebra"] != want {
+		t.Errorf("zebra color = %s, want %s", colors["zebra"], want)
+	}
+	if colors["aardvark"] == want || colors["badger"] == want {
+		t.Errorf("undeclared types reuse the declared color: %v", colors)
+	}
+	if !contains(g.ExportHTML(), want) {
+		t.Error("HTML output missing declared type color")
+	}
+}
+
+func TestExportGraphML(t *testing.T) {
+	g := New()
+	g.AddEntity("A & B", "node")
+	g.AddEntity("C", "node")
+	g.AddRelation("A & B", "connects", "C")
+
+	out := g.ExportGraphML()
+	if !contains(out, `<graphml xmlns="http://graphml.graphdrawing.org/xmlns">`) {
+		t.Error("GraphML output missing graphml root")
+	}
+	if !contains(out, "A &amp; B") {
+		t.Error("GraphML output should escape entity names")
+	}
+	if !contains(out, `source="a &amp; b" target="c"`) {
+		t.Errorf("GraphML output missing edge: %s", out)
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	g := New()
+	g.AddEntity("Alice", "person")
+	g.AddObservation("Alice", "likes tea, not coffee")
+	g.AddEntity("Bob", "person")
+	g.AddRelation("Alice", "knows", "Bob")
+
+	nodes, err := g.ExportNodesCSV()
+	if err != nil {
+		t.Fatalf("ExportNodesCSV failed: %v", err)
+	}
+	if !contains(nodes, "id,name,type,observations") {
+		t.Error("nodes CSV missing header")
+	}
+	if !contains(nodes, `alice,Alice,person,"likes tea, not coffee"`) {
+		t.Errorf("nodes CSV missing quoted row: %s", nodes)
+	}
+
+	edges, err := g.ExportEdgesCSV()
+	if err != nil {
+		t.Fatalf("ExportEdgesCSV failed: %v", err)
+	}
+	if edges != "source,target,type\nalice,bob,knows\n" {
+		t.Errorf("unexpected edges CSV: %q", edges)
+	}
+}
+
+func TestImportObsidian(t *testing.T) {
+	vault := t.TempDir()
+	os.MkdirAll(filepath.Join(vault, ".obsidian"), 0o755)
+	os.MkdirAll(filepath.Join(vault, "projects"), 0o755)
+	os.WriteFile(filepath.Join(vault, ".obsidian", "hidden.md"), []byte("[[Ignored]]"), 0o644)
+	os.WriteFile(filepath.Join(vault, "Alice.md"), []byte(`---
+type: person
+---
+# Alice
+- Works on [[projects/Palm|the palm project]]
+- Knows [[Bob#intro]]
+`), 0o644)
+	os.WriteFile(filepath.Join(vault, "projects", "Palm.md"), []byte("AI tool manager\n"), 0o644)
+
+	g := New()
+	g.AddEntity("Bob", "person")
+
+	added, merged, relAdded, err := g.ImportObsidian(vault)
+	if err != nil {
+		t.Fatalf("ImportObsidian failed: %v", err)
+	}
+	if added != 2 || merged != 1 || relAdded != 2 {
+		t.Errorf("expected 2 added, 1 merged, 2 relations; got %d, %d, %d", added, merged, relAdded)
+	}
+
+	alice, err := g.GetEntity("Alice")
+	if err != nil {
+		t.Fatalf("Alice not imported: %v", err)
+	}
+	if alice.Type != "person" {
+		t.Errorf("expected frontmatter type 'person', got %q", alice.Type)
+	}
+	if len(alice.Observations) != 2 || alice.Observations[0] != "Works on the palm project" {
+		t.Errorf("unexpected observations: %v", alice.Observations)
+	}
+	if _, err := g.GetEntity("Ignored"); err == nil {
+		t.Error("notes inside dot-directories should be skipped")
+	}
+	out, _ := g.RelationsOf("Alice")
+	if len(out) != 2 || out[0].Type != ObsidianRelation {
+		t.Errorf("expected 2 links_to relations, got %v", out)
+	}
+}
+
+func TestImportObsidianLongLine(t *testing.T) {
+	vault := t.TempDir()
+	os.WriteFile(filepath.Join(vault, "Big.md"), []byte("start\n"+strings.Repeat("x", 2*1024*1024)+"\nend\n"), 0o644)
+
+	g := New()
+	_, _, _, err := g.ImportObsidian(vault)
+	if err == nil || !strings.Contains(err.Error(), "Big.md") {
+		t.Fatalf("a line over the limit should fail the note, got %v", err)
+	}
+	if len(g.Entities) != 0 {
+		t.Error("a failed import should leave the graph untouched")
+	}
+}
+
+func TestHandlerPersistsEdits(t *testing.T) {
+	setupTestEnv(t)
+
+	g := New()
+	g.AddEntity("Alice", "person")
+	g.AddEntity("Bob", "person")
+	if err := Save(g); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	srv := httptest.NewServer(handler("secret"))
+	defer srv.Close()
+
+	resp, err := apiRequest(t, http.MethodPost, srv.URL+"/api/observe", `{"name":"alice","observation":"likes tea"}`)
+	if err != nil {
+		t.Fatalf("observe request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 from observe, got %d", resp.StatusCode)
+	}
+
+	resp, err = apiRequest(t, http.MethodPost, srv.URL+"/api/relate", `{"from":"Alice","type":"knows","to":"Bob"}`)
+	if err != nil {
+		t.Fatalf("relate request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 from relate, got %d", resp.StatusCode)
+	}
+
+	resp, err = apiRequest(t, http.MethodPost, srv.URL+"/api/relate", `{"from":"Alice","type":"knows","to":"Nobody"}`)
+	if err != nil {
+		t.Fatalf("relate request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown target, got %d", resp.StatusCode)
+	}
+
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	e, _ := loaded.GetEntity("Alice")
+	if len(e.Observations) != 1 || e.Observations[0] != "likes tea" {
+		t.Errorf("observation not persisted: %v", e.Observations)
+	}
+	if len(loaded.Relations) != 1 {
+		t.Errorf("expected 1 persisted relation, got %d", len(loaded.Relations))
+	}
+
+	resp, err = apiRequest(t, http.MethodGet, srv.URL+"/api/entity?name=Alice", "")
+	if err != nil {
+		t.Fatalf("entity request failed: %v", err)
+	}
+	var show ShowResult
+	json.NewDecoder(resp.Body).Decode(&show)
+	resp.Body.Close()
+	if len(show.Outgoing) != 1 || show.Outgoing[0].Target.Name != "Bob" {
+		t.Errorf("unexpected entity response: %+v", show)
+	}
+}
+
+// apiRequest sends a request to the live view API with the test session
+// token, posting body as JSON when it isn't empty.
+func apiRequest(t *testing.T, method, url, body string) (*http.Response, error) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Palm-Token", "secret")
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return http.DefaultClient.Do(req)
+}
+
+func TestHandlerRejectsForeignRequests(t *testing.T) {
+	setupTestEnv(t)
+
+	g := New()
+	g.AddEntity("Alice", "person")
+	if err := Save(g); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	srv := httptest.NewServer(handler("secret"))
+	defer srv.Close()
+
+	observe := `{"name":"Alice","observation":"likes tea"}`
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		ctype   string
+		body    string
+		headers []string
+		want    int
+	}{
+		{"no token", http.MethodGet, "/api/graph", "", "", []string{"X-Palm-Token", ""}, http.StatusForbidden},
+		{"wrong token", http.MethodGet, "/api/graph", "", "", []string{"X-Palm-Token", "guess"}, http.StatusForbidden},
+		{"form post", http.MethodPost, "/api/observe", "text/plain", observe, nil, http.StatusUnsupportedMediaType},
+		{"foreign origin", http.MethodPost, "/api/observe", "application/json", observe, []string{"Origin", "https://evil.example"}, http.StatusForbidden},
+		{"rebound host", http.MethodGet, "/api/graph", "", "", []string{"Host", "evil.example:7777"}, http.StatusForbidden},
+		{"rebound page", http.MethodGet, "/", "", "", []string{"Host", "evil.example"}, http.StatusForbidden},
+		{"local origin", http.MethodGet, "/api/graph", "", "", []string{"Origin", "http://localhost:7777"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(tt.method, srv.URL+tt.path, strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("X-Palm-Token", "secret")
+		if tt.ctype != "" {
+			req.Header.Set("Content-Type", tt.ctype)
+		}
+		for i := 0; i+1 < len(tt.headers); i += 2 {
+			if tt.headers[i] == "Host" {
+				req.Host = tt.headers[i+1]
+			} else {
+				req.Header.Set(tt.headers[i], tt.headers[i+1])
+			}
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.want, resp.StatusCode)
+		}
+	}
+
+	loaded, _ := Load()
+	if e, _ := loaded.GetEntity("Alice"); len(e.Observations) != 0 {
+		t.Errorf("rejected requests should not edit the graph: %v", e.Observations)
+	}
+
+	resp, err := http.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	page, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(page), `<meta name="palm-token" content="secret">`) {
+		t.Error("the live page should carry the session token")
+	}
+}
+
+func TestAliases(t *testing.T) {
+	g := New()
+	g.AddEntity("Kubernetes", "tool")
+	g.AddEntity("palm", "project")
+	g.AddEntity("Node.js", "runtime")
+
+	if _, err := g.AddAlias("kubernetes", "k8s"); err != nil {
+		t.Fatalf("AddAlias failed: %v", err)
+	}
+	if _, err := g.AddAlias("palm", "K8S"); err == nil {
+		t.Error("an alias taken by another entity should be rejected")
+	}
+	if err := g.AddEntity("k8s", "tool"); err == nil || !contains(err.Error(), "alias of Kubernetes") {
+		t.Errorf("adding an entity named like an alias should fail, got %v", err)
+	}
+
+	for _, name := range []string{"k8s", "K8S ", "kubernetes", "node js", "NodeJS"} {
+		if _, err := g.GetEntity(name); err != nil {
+			t.Errorf("GetEntity(%q): %v", name, err)
+		}
+	}
+	g.AddEntity("NodeJS", "library")
+	if e, _ := g.GetEntity("nodejs"); e == nil || e.Name != "NodeJS" {
+		t.Error("an exact name should win over a punctuation-insensitive match")
+	}
+	if _, err := g.GetEntity("node-js"); err == nil {
+		t.Error("an ambiguous punctuation-insensitive match should not resolve")
+	}
+
+	if err := g.AddRelation("palm", "deploys-to", "K8s"); err != nil {
+		t.Fatalf("AddRelation via alias failed: %v", err)
+	}
+	if g.Relations[0].To != "Kubernetes" {
+		t.Errorf("relations should record the entity name, got %+v", g.Relations[0])
+	}
+	if _, in := g.RelationsOf("k8s"); len(in) != 1 {
+		t.Errorf("RelationsOf via alias = %v", in)
+	}
+	if res := g.Search("k8s"); len(res) != 1 || res[0].Score != 100 {
+		t.Errorf("search should match aliases like names, got %+v", res)
+	}
+
+	// Renaming to an alias swaps the two
+	if err := g.RenameEntity("Kubernetes", "K8s"); err != nil {
+		t.Fatalf("RenameEntity to an alias failed: %v", err)
+	}
+	if e, _ := g.GetEntity("kubernetes"); e == nil || e.Name != "K8s" || e.Aliases[0] != "Kubernetes" {
+		t.Errorf("rename to alias = %+v", e)
+	}
+
+	nodes, _ := g.ExportNodesCSV()
+	if !contains(nodes, ",Kubernetes\n") {
+		t.Errorf("nodes CSV missing aliases: %s", nodes)
+	}
+	if !contains(g.ExportGraphML(), `<data key="aliases">Kubernetes</data>`) {
+		t.Error("GraphML missing aliases")
+	}
+
+	if e, err := g.RemoveAlias("KUBERNETES"); err != nil || e.Name != "K8s" || len(e.Aliases) != 0 {
+		t.Errorf("RemoveAlias = %+v, %v", e, err)
+	}
+	if _, err := g.RemoveAlias("kubernetes"); err == nil {
+		t.Error("removing a missing alias should fail")
+	}
+}
+
+func TestImportResolvesAliases(t *testing.T) {
+	g := New()
+	g.AddEntity("Kubernetes", "tool")
+	g.AddAlias("Kubernetes", "k8s")
+	g.AddEntity("palm", "project")
+
+	data := `{"entities": {
+		"k8s": {"name": "k8s", "observations": ["container orchestrator"]},
+		"helm": {"name": "Helm", "aliases": ["helm-cli", "K8S"]}
+	}, "relations": [
+		{"from": "palm", "to": "k8s", "type": "deploys-to"},
+		{"from": "helm-cli", "to": "K8s", "type": "packages"}
+	]}`
+	added, merged, rels, err := g.ImportJSON([]byte(data))
+	if err != nil {
+		t.Fatalf("ImportJSON failed: %v", err)
+	}
+	if added != 1 || merged != 1 || rels != 2 {
+		t.Errorf("added %d, merged %d, relations %d", added, merged, rels)
+	}
+	k, _ := g.GetEntity("Kubernetes")
+	if len(k.Observations) != 1 || len(g.Entities) != 3 {
+		t.Errorf("k8s should merge into Kubernetes: %+v", k)
+	}
+	if h, _ := g.GetEntity("helm-cli"); h == nil || len(h.Aliases) != 1 {
+		t.Errorf("imported aliases taken elsewhere should be dropped: %+v", h)
+	}
+	if g.Relations[1].From != "Helm" || g.Relations[1].To != "Kubernetes" {
+		t.Errorf("relations should resolve aliases, got %+v", g.Relations[1])
+	}
+
+	// Obsidian notes declare aliases in their frontmatter
+	vault := t.TempDir()
+	os.WriteFile(filepath.Join(vault, "Docker.md"), []byte("---\naliases:\n  - docker-engine\n  - \"moby\"\n---\nContainers\n"), 0o644)
+	os.WriteFile(filepath.Join(vault, "palm.md"), []byte("Ships with [[moby]]\n"), 0o644)
+	if _, _, _, err := g.ImportObsidian(vault); err != nil {
+		t.Fatal(err)
+	}
+	d, err := g.GetEntity("moby")
+	if err != nil || d.Name != "Docker" || len(d.Aliases) != 2 {
+		t.Fatalf("Docker = %+v, %v", d, err)
+	}
+	if out, _ := g.RelationsOf("palm"); len(out) != 2 || out[1].To != "Docker" {
+		t.Errorf("links to an alias should point at its note: %+v", out)
+	}
+}
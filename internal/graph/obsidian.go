@@ -0,0 +1,200 @@
+package graph
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ObsidianRelation is the relation type created for [[wikilinks]] between notes.
+const ObsidianRelation = "links_to"
+
+var wikilinkRe = regexp.MustCompile(`\[\[([^\]]+)\]\]`)
+
+// ImportObsidian walks an Obsidian vault directory and merges it into the graph.
+// Each note becomes an entity (type from the frontmatter `type:` field, else "note"),
+// its body lines become observations, and every [[wikilink]] becomes a links_to relation.
+// Links to notes that do not exist in the vault still create (empty) entities.
+func (g *Graph) ImportObsidian(dir string) (added, merged, relAdded int, err error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if !info.IsDir() {
+		return 0, 0, 0, fmt.Errorf("not a directory: %s", dir)
+	}
+
+	incoming := New()
+	now := time.Now()
+	ensure := func(name, entityType string) *Entity {
+		key := normalize(name)
+		if e, ok := incoming.Entities[key]; ok {
+			if entityType != "" {
+				e.Type = entityType
+			}
+			return e
+		}
+		if entityType == "" {
+			entityType = "note"
+		}
+		e := &Entity{Name: name, Type: entityType, Observations: make([]string, 0), CreatedAt: now, UpdatedAt: now}
+		incoming.Entities[key] = e
+		return e
+	}
+
+	err = filepath.Walk(dir, func(path string, fi os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if fi.IsDir() {
+			if path != dir && strings.HasPrefix(fi.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.EqualFold(filepath.Ext(path), ".md") {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(fi.Name(), filepath.Ext(fi.Name()))
+		note, err := parseObsidianNote(data)
+		if err != nil {
+			rel, _ := filepath.Rel(dir, path)
+			return fmt.Errorf("%s: %w", rel, err)
+		}
+
+		e := ensure(name, note.Type)
+		e.Aliases = append(e.Aliases, note.Aliases...)
+		e.Observations = append(e.Observations, note.Observations...)
+		e.CreatedAt = fi.ModTime()
+		e.UpdatedAt = fi.ModTime()
+
+		for _, target := range note.Links {
+			ensure(target, "")
+			incoming.Relations = append(incoming.Relations, &Relation{From: name, To: target, Type: ObsidianRelation})
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("obsidian import: %w", err)
+	}
+
+	added, merged, relAdded = g.merge(incoming)
+	return added, merged, relAdded, nil
+}
+
+type obsidianNote struct {
+	Type         string
+	Aliases      []string
+	Observations []string
+	Links        []string
+}
+
+// parseObsidianNote extracts the frontmatter type and aliases, observation
+// lines, and wikilink targets from a Markdown note. A line over 1MB is an
+// error rather than the end of the note.
+func parseObsidianNote(data []byte) (obsidianNote, error) {
+	var note obsidianNote
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	inFrontmatter := false
+	inAliases := false // in a YAML list under aliases:
+	inCode := false
+	first := true
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if first {
+			first = false
+			if line == "---" {
+				inFrontmatter = true
+				continue
+			}
+		}
+		if inFrontmatter {
+			if line == "---" {
+				inFrontmatter = false
+				continue
+			}
+			if inAliases && strings.HasPrefix(line, "-") {
+				note.Aliases = appendFrontmatterValues(note.Aliases, strings.TrimPrefix(line, "-"))
+				continue
+			}
+			inAliases = false
+			k, v, ok := strings.Cut(line, ":")
+			switch k = strings.TrimSpace(k); {
+			case !ok:
+			case k == "type":
+				note.Type = strings.Trim(strings.TrimSpace(v), `"'`)
+			case k == "aliases" || k == "alias":
+				// aliases: [k8s, kube], aliases: k8s, or a list below
+				note.Aliases = appendFrontmatterValues(note.Aliases, strings.Trim(strings.TrimSpace(v), "[]"))
+				inAliases = strings.TrimSpace(v) == ""
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode || line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		for _, m := range wikilinkRe.FindAllStringSubmatch(line, -1) {
+			target := wikilinkTarget(m[1])
+			if target != "" && !seen[normalize(target)] {
+				seen[normalize(target)] = true
+				note.Links = append(note.Links, target)
+			}
+		}
+
+		obs := strings.TrimSpace(strings.TrimLeft(line, "-*+>"))
+		obs = wikilinkRe.ReplaceAllStringFunc(obs, func(link string) string {
+			inner := link[2 : len(link)-2]
+			if _, alias, ok := strings.Cut(inner, "|"); ok {
+				return alias
+			}
+			return wikilinkTarget(inner)
+		})
+		if obs != "" {
+			note.Observations = append(note.Observations, obs)
+		}
+	}
+	return note, scanner.Err()
+}
+
+// appendFrontmatterValues appends the comma-separated, optionally quoted
+// values of a frontmatter field.
+func appendFrontmatterValues(list []string, values string) []string {
+	for _, v := range strings.Split(values, ",") {
+		if v = strings.Trim(strings.TrimSpace(v), `"'`); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// wikilinkTarget strips aliases (|), headings (#), block refs (^), and folder
+// prefixes from a link, leaving the note name.
+func wikilinkTarget(inner string) string {
+	if i := strings.IndexAny(inner, "|#^"); i >= 0 {
+		inner = inner[:i]
+	}
+	if i := strings.LastIndex(inner, "/"); i >= 0 {
+		inner = inner[i+1:]
+	}
+	return strings.TrimSuffix(strings.TrimSpace(inner), ".md")
+}
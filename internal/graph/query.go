@@ -0,0 +1,122 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SortOrders are the accepted values of Query.Sort.
+var SortOrders = []string{"updated", "created", "name", "degree"}
+
+// Query filters, sorts and pages the entities of list and search.
+type Query struct {
+	Type         string    // entity type, case-insensitive
+	HasRelation  string    // relation type the entity takes part in, either direction
+	UpdatedSince time.Time // keep entities updated at or after this time
+	Sort         string    // one of SortOrders; "" keeps the default order
+	Offset       int
+	Limit        int // 0 means no limit
+}
+
+// Page is one page of query results and the number of matches overall.
+type Page struct {
+	Results []SearchResult
+	Total   int
+}
+
+// Entities returns the entities of the page.
+func (p Page) Entities() []*Entity {
+	entities := make([]*Entity, len(p.Results))
+	for i, r := range p.Results {
+		entities[i] = r.Entity
+	}
+	return entities
+}
+
+// List returns all entities matching q, by name unless q sorts otherwise.
+func (g *Graph) List(q Query) (Page, error) {
+	results := make([]SearchResult, 0, len(g.Entities))
+	for _, e := range g.Entities {
+		results = append(results, SearchResult{Entity: e})
+	}
+	return g.apply(results, q)
+}
+
+// Query searches entities like Search and narrows the hits with q. Hits are
+// ranked by score unless q sorts otherwise.
+func (g *Graph) Query(query string, q Query) (Page, error) {
+	return g.apply(g.Search(query), q)
+}
+
+func (g *Graph) apply(results []SearchResult, q Query) (Page, error) {
+	if q.Sort != "" && !containsFold(SortOrders, q.Sort) {
+		return Page{}, fmt.Errorf("unknown sort %q (use %s)", q.Sort, strings.Join(SortOrders, ", "))
+	}
+	if q.Offset < 0 || q.Limit < 0 {
+		return Page{}, fmt.Errorf("offset and limit must not be negative")
+	}
+
+	// Degrees and relation types per entity, in one pass over the relations
+	degree := make(map[string]int)
+	relTypes := make(map[string]map[string]bool)
+	for _, r := range g.Relations {
+		for _, end := range []string{normalize(r.From), normalize(r.To)} {
+			degree[end]++
+			if relTypes[end] == nil {
+				relTypes[end] = make(map[string]bool)
+			}
+			relTypes[end][strings.ToLower(r.Type)] = true
+		}
+	}
+
+	kept := results[:0]
+	for _, r := range results {
+		e := r.Entity
+		if q.Type != "" && !strings.EqualFold(e.Type, q.Type) {
+			continue
+		}
+		if q.HasRelation != "" && !relTypes[normalize(e.Name)][strings.ToLower(q.HasRelation)] {
+			continue
+		}
+		if !q.UpdatedSince.IsZero() && e.UpdatedAt.Before(q.UpdatedSince) {
+			continue
+		}
+		kept = append(kept, r)
+	}
+
+	byName := func(a, b *Entity) bool { return strings.ToLower(a.Name) < strings.ToLower(b.Name) }
+	sort.SliceStable(kept, func(i, j int) bool {
+		a, b := kept[i].Entity, kept[j].Entity
+		switch strings.ToLower(q.Sort) {
+		case "updated":
+			if !a.UpdatedAt.Equal(b.UpdatedAt) {
+				return a.UpdatedAt.After(b.UpdatedAt)
+			}
+		case "created":
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.After(b.CreatedAt)
+			}
+		case "degree":
+			if da, db := degree[normalize(a.Name)], degree[normalize(b.Name)]; da != db {
+				return da > db
+			}
+		case "name":
+		default:
+			if kept[i].Score != kept[j].Score {
+				return kept[i].Score > kept[j].Score
+			}
+		}
+		return byName(a, b)
+	})
+
+	page := Page{Total: len(kept)}
+	start := min(q.Offset, len(kept))
+	end := len(kept)
+	if q.Limit > 0 {
+		end = min(start+q.Limit, len(kept))
+	}
+	page.Results = append([]SearchResult{}, kept[start:end]...)
+	return page, nil
+}
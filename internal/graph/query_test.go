@@ -0,0 +1,106 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func queryTestGraph(t *testing.T) *Graph {
+	t.Helper()
+	g := New()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	entities := []struct{ name, typ string }{
+		{"api", "project"},
+		{"web", "project"},
+		{"docs", "project"},
+		{"alice", "person"},
+		{"postgres", "tool"},
+	}
+	for _, e := range entities {
+		if err := g.AddEntity(e.name, e.typ); err != nil {
+			t.Fatal(err)
+		}
+	}
+	g.AddRelation("api", "depends_on", "postgres")
+	g.AddRelation("web", "depends_on", "api")
+	g.AddRelation("alice", "owns", "api")
+	g.AddObservation("docs", "Explains the api")
+
+	for i, e := range entities {
+		ent, _ := g.GetEntity(e.name)
+		ent.CreatedAt = base.AddDate(0, 0, i)
+		ent.UpdatedAt = base.AddDate(0, 0, 10-i)
+	}
+	return g
+}
+
+func names(results []SearchResult) string {
+	var out []string
+	for _, r := range results {
+		out = append(out, r.Entity.Name)
+	}
+	return strings.Join(out, ",")
+}
+
+func TestList(t *testing.T) {
+	g := queryTestGraph(t)
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		q     Query
+		want  string
+		total int
+	}{
+		{"default by name", Query{}, "alice,api,docs,postgres,web", 5},
+		{"type", Query{Type: "PROJECT"}, "api,docs,web", 3},
+		{"has relation either direction", Query{HasRelation: "depends_on"}, "api,postgres,web", 3},
+		{"combined", Query{Type: "project", HasRelation: "depends_on"}, "api,web", 2},
+		{"updated since", Query{UpdatedSince: base.AddDate(0, 0, 8)}, "api,docs,web", 3},
+		{"sort updated", Query{Sort: "updated"}, "api,web,docs,alice,postgres", 5},
+		{"sort created", Query{Sort: "created"}, "postgres,alice,docs,web,api", 5},
+		{"sort degree", Query{Sort: "degree"}, "api,alice,postgres,web,docs", 5},
+		{"page", Query{Sort: "name", Offset: 1, Limit: 2}, "api,docs", 5},
+		{"offset past end", Query{Offset: 9}, "", 5},
+	}
+	for _, tt := range tests {
+		page, err := g.List(tt.q)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := names(page.Results); got != tt.want || page.Total != tt.total {
+			t.Errorf("%s: got %s (%d total), want %s (%d total)", tt.name, got, page.Total, tt.want, tt.total)
+		}
+	}
+
+	if _, err := g.List(Query{Sort: "size"}); err == nil {
+		t.Error("expected an error for an unknown sort")
+	}
+	if _, err := g.List(Query{Limit: -1}); err == nil {
+		t.Error("expected an error for a negative limit")
+	}
+}
+
+func TestQuery(t *testing.T) {
+	g := queryTestGraph(t)
+
+	// "api" matches the api entity by name and docs by observation
+	page, err := g.Query("api", Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(page.Results); got != "api,docs" {
+		t.Errorf("by score: got %s", got)
+	}
+
+	page, _ = g.Query("api", Query{HasRelation: "depends_on"})
+	if got := names(page.Results); got != "api" || page.Total != 1 {
+		t.Errorf("filtered: got %s (%d total)", got, page.Total)
+	}
+
+	page, _ = g.Query("api", Query{Sort: "name", Limit: 1})
+	if got := names(page.Results); got != "api" || page.Total != 2 {
+		t.Errorf("paged: got %s (%d total)", got, page.Total)
+	}
+}
@@ -0,0 +1,80 @@
+package graph
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RedactReport lists what Redact left out.
+type RedactReport struct {
+	Private      []string `json:"private"`      // entities dropped for being private
+	Observations int      `json:"observations"` // observations dropped for matching a secret
+	Relations    int      `json:"relations"`    // relations dropped with private entities
+}
+
+// Private reports whether e is tagged private, with a "tags: private"
+// observation (palm graph add --field tags=private). Tags are comma
+// separated.
+func (e *Entity) Private() bool {
+	for _, o := range e.Observations {
+		key, value, ok := strings.Cut(o, ":")
+		if !ok || !strings.EqualFold(strings.TrimSpace(key), "tags") {
+			continue
+		}
+		for _, tag := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(tag), "private") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Redact returns a copy of g fit to share: private entities and their
+// relations are left out, and so are observations matching any of secrets.
+// g itself is not changed.
+func (g *Graph) Redact(secrets []*regexp.Regexp) (*Graph, RedactReport) {
+	var report RedactReport
+	out := New()
+	out.Schema = g.Schema
+
+	for key, e := range g.Entities {
+		if e.Private() {
+			report.Private = append(report.Private, e.Name)
+			continue
+		}
+		c := *e
+		c.Observations = make([]string, 0, len(e.Observations))
+		for _, o := range e.Observations {
+			if matchesAny(secrets, o) {
+				report.Observations++
+				continue
+			}
+			c.Observations = append(c.Observations, o)
+		}
+		out.Entities[key] = &c
+	}
+	sort.Strings(report.Private)
+
+	for _, r := range g.Relations {
+		_, from := out.Entities[normalize(r.From)]
+		_, to := out.Entities[normalize(r.To)]
+		if !from || !to {
+			report.Relations++
+			continue
+		}
+		c := *r
+		out.Relations = append(out.Relations, &c)
+	}
+	return out, report
+}
+
+func matchesAny(patterns []*regexp.Regexp, s string) bool {
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package graph
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	g := New()
+	g.AddEntity("Billing API", "service")
+	g.AddObservation("Billing API", "owner: payments team")
+	g.AddObservation("Billing API", "staging key sk-proj-abcdefghijklmnopqrstuvwx")
+	g.AddEntity("Acquisition", "project")
+	g.AddObservation("Acquisition", "tags: roadmap, Private")
+	g.AddEntity("Payments", "team")
+	g.AddRelation("Payments", "owns", "Billing API")
+	g.AddRelation("Acquisition", "depends_on", "Billing API")
+
+	secrets := []*regexp.Regexp{regexp.MustCompile(`sk-(?:proj-)?[A-Za-z0-9]{20,}`)}
+	out, report := g.Redact(secrets)
+
+	if len(report.Private) != 1 || report.Private[0] != "Acquisition" || report.Observations != 1 || report.Relations != 1 {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if _, err := out.GetEntity("Acquisition"); err == nil {
+		t.Error("a private entity should be left out")
+	}
+	api, _ := out.GetEntity("Billing API")
+	if len(api.Observations) != 1 || api.Observations[0] != "owner: payments team" {
+		t.Errorf("secret observations should be dropped: %q", api.Observations)
+	}
+	if len(out.Relations) != 1 || out.Relations[0].From != "Payments" {
+		t.Errorf("only relations between shared entities should stay: %+v", out.Relations)
+	}
+
+	// The original graph is untouched
+	if orig, _ := g.GetEntity("Billing API"); len(orig.Observations) != 2 || len(g.Entities) != 3 || len(g.Relations) != 2 {
+		t.Error("Redact should not change the graph it copies")
+	}
+}
+
+func TestReadOnlyGraph(t *testing.T) {
+	setupTestEnv(t)
+	g := New()
+	g.AddEntity("Alice", "person")
+	if err := Save(g); err != nil {
+		t.Fatal(err)
+	}
+	b, err := CreateBackup("manual")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.WriteFile(filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "palm", "config.toml"), []byte("[graph]\nreadonly = true\n"), 0o644)
+	g.AddEntity("Bob", "person")
+	if err := Save(g); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("saving a read-only graph should fail, got %v", err)
+	}
+	if _, err := RestoreBackup(b); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("restoring over a read-only graph should fail, got %v", err)
+	}
+	if loaded, _ := Load(); len(loaded.Entities) != 1 {
+		t.Errorf("the read-only graph changed: %d entities", len(loaded.Entities))
+	}
+}
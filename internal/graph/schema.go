@@ -0,0 +1,265 @@
+package graph
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Schema declares the entity and relation types a graph expects. It is
+// optional: add and relate only check against it when asked to be strict.
+type Schema struct {
+	Types     map[string]*TypeDef     `json:"types,omitempty"`
+	Relations map[string]*RelationDef `json:"relations,omitempty"`
+}
+
+// TypeDef declares an entity type.
+type TypeDef struct {
+	Name string `json:"name"`
+	// Fields are the "key: value" observation keys entities of this type
+	// may carry. Empty allows any.
+	Fields []string `json:"fields,omitempty"`
+	Color  string   `json:"color"` // fixed color in the HTML view
+}
+
+// RelationDef declares a relation type and, optionally, which entity types
+// it may connect.
+type RelationDef struct {
+	Name string   `json:"name"`
+	From []string `json:"from,omitempty"` // allowed source types; empty allows any
+	To   []string `json:"to,omitempty"`   // allowed target types; empty allows any
+}
+
+// typePalette colors entity types in the HTML view. Declared types take the
+// next unused color and keep it however many types the graph holds.
+var typePalette = []string{"#2DB682", "#0171E3", "#E07C3A", "#9B59B6", "#E74C3C", "#1ABC9C", "#F1C40F", "#3498DB", "#E91E63", "#00BCD4"}
+
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+func (g *Graph) schema() *Schema {
+	if g.Schema == nil {
+		g.Schema = &Schema{}
+	}
+	if g.Schema.Types == nil {
+		g.Schema.Types = make(map[string]*TypeDef)
+	}
+	if g.Schema.Relations == nil {
+		g.Schema.Relations = make(map[string]*RelationDef)
+	}
+	return g.Schema
+}
+
+// DefineType declares an entity type, replacing any earlier declaration.
+// An empty color keeps the type's existing color or picks an unused one.
+func (g *Graph) DefineType(name string, fields []string, color string) (*TypeDef, error) {
+	key := normalize(name)
+	if key == "" {
+		return nil, fmt.Errorf("type name cannot be empty")
+	}
+	if color != "" && !hexColor.MatchString(color) {
+		return nil, fmt.Errorf("color must be a hex value like #2DB682, got %q", color)
+	}
+	s := g.schema()
+	if color == "" {
+		if old, ok := s.Types[key]; ok {
+			color = old.Color
+		} else {
+			color = s.nextColor()
+		}
+	}
+	def := &TypeDef{Name: strings.TrimSpace(name), Fields: cleanList(fields), Color: color}
+	s.Types[key] = def
+	return def, nil
+}
+
+// DefineRelation declares a relation type, replacing any earlier
+// declaration. from and to must be declared entity types.
+func (g *Graph) DefineRelation(name string, from, to []string) (*RelationDef, error) {
+	key := strings.TrimSpace(name)
+	if key == "" {
+		return nil, fmt.Errorf("relation name cannot be empty")
+	}
+	s := g.schema()
+	from, to = cleanList(from), cleanList(to)
+	for _, t := range append(append([]string{}, from...), to...) {
+		if _, ok := s.Types[normalize(t)]; !ok {
+			return nil, fmt.Errorf("unknown type %q (define it with 'palm graph types define %s')", t, t)
+		}
+	}
+	def := &RelationDef{Name: key, From: from, To: to}
+	s.Relations[key] = def
+	return def, nil
+}
+
+// Undefine removes a type or relation declaration. Entities and relations
+// using it are left alone.
+func (g *Graph) Undefine(name string) error {
+	if g.Schema != nil {
+		if _, ok := g.Schema.Types[normalize(name)]; ok {
+			delete(g.Schema.Types, normalize(name))
+			return nil
+		}
+		if _, ok := g.Schema.Relations[strings.TrimSpace(name)]; ok {
+			delete(g.Schema.Relations, strings.TrimSpace(name))
+			return nil
+		}
+	}
+	return fmt.Errorf("no type or relation declared as %q", name)
+}
+
+// DeclaredTypes returns the declared entity types sorted by name.
+func (g *Graph) DeclaredTypes() []*TypeDef {
+	var defs []*TypeDef
+	if g.Schema != nil {
+		for _, d := range g.Schema.Types {
+			defs = append(defs, d)
+		}
+	}
+	sort.Slice(defs, func(i, j int) bool { return normalize(defs[i].Name) < normalize(defs[j].Name) })
+	return defs
+}
+
+// DeclaredRelations returns the declared relation types sorted by name.
+func (g *Graph) DeclaredRelations() []*RelationDef {
+	var defs []*RelationDef
+	if g.Schema != nil {
+		for _, d := range g.Schema.Relations {
+			defs = append(defs, d)
+		}
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs
+}
+
+// CheckEntity reports whether an entity of entityType with the given field
+// keys fits the schema.
+func (g *Graph) CheckEntity(entityType string, fields []string) error {
+	def, ok := g.lookupType(entityType)
+	if !ok {
+		return fmt.Errorf("type %q is not declared (declared: %s)", entityType, g.typeList())
+	}
+	if len(def.Fields) == 0 {
+		return nil
+	}
+	for _, f := range fields {
+		if !containsFold(def.Fields, f) {
+			return fmt.Errorf("type %s has no field %q (fields: %s)", def.Name, f, strings.Join(def.Fields, ", "))
+		}
+	}
+	return nil
+}
+
+// CheckRelation reports whether from --relType--> to fits the schema. Both
+// entities must exist.
+func (g *Graph) CheckRelation(from, relType, to string) error {
+	src, err := g.GetEntity(from)
+	if err != nil {
+		return err
+	}
+	dst, err := g.GetEntity(to)
+	if err != nil {
+		return err
+	}
+	var def *RelationDef
+	if g.Schema != nil {
+		def = g.Schema.Relations[strings.TrimSpace(relType)]
+	}
+	if def == nil {
+		var names []string
+		for _, d := range g.DeclaredRelations() {
+			names = append(names, d.Name)
+		}
+		return fmt.Errorf("relation %q is not declared (declared: %s)", relType, orNone(names))
+	}
+	if len(def.From) > 0 && !containsFold(def.From, src.Type) {
+		return fmt.Errorf("%s must start at a %s, not %s (a %s)", def.Name, strings.Join(def.From, " or "), src.Name, src.Type)
+	}
+	if len(def.To) > 0 && !containsFold(def.To, dst.Type) {
+		return fmt.Errorf("%s must point to a %s, not %s (a %s)", def.Name, strings.Join(def.To, " or "), dst.Name, dst.Type)
+	}
+	return nil
+}
+
+// mergeSchema adds declarations from other that g lacks.
+func (g *Graph) mergeSchema(other *Schema) {
+	if other == nil {
+		return
+	}
+	s := g.schema()
+	for k, d := range other.Types {
+		if _, ok := s.Types[normalize(k)]; !ok {
+			s.Types[normalize(k)] = d
+		}
+	}
+	for k, d := range other.Relations {
+		if _, ok := s.Relations[k]; !ok {
+			s.Relations[k] = d
+		}
+	}
+}
+
+// TypeDef returns the declaration of an entity type, or nil.
+func (g *Graph) TypeDef(name string) *TypeDef {
+	d, _ := g.lookupType(name)
+	return d
+}
+
+func (g *Graph) lookupType(name string) (*TypeDef, bool) {
+	if g.Schema == nil {
+		return nil, false
+	}
+	d, ok := g.Schema.Types[normalize(name)]
+	return d, ok
+}
+
+func (g *Graph) typeList() string {
+	var names []string
+	for _, d := range g.DeclaredTypes() {
+		names = append(names, d.Name)
+	}
+	return orNone(names)
+}
+
+func (s *Schema) nextColor() string {
+	used := make(map[string]bool, len(s.Types))
+	for _, d := range s.Types {
+		used[strings.ToUpper(d.Color)] = true
+	}
+	for _, c := range typePalette {
+		if !used[c] {
+			return c
+		}
+	}
+	return typePalette[len(s.Types)%len(typePalette)]
+}
+
+// cleanList trims entries and drops empty ones, accepting comma-separated
+// values inside entries.
+func cleanList(items []string) []string {
+	var out []string
+	for _, item := range items {
+		for _, part := range strings.Split(item, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func orNone(names []string) string {
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,348 @@
+package graph
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"html"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/msalah0e/palm/internal/activity"
+)
+
+// server backs `palm graph view --serve`. Every request reloads graph.enc so
+// edits made from the CLI while the server is running are picked up, and every
+// mutation is saved immediately.
+//
+// The graph is decrypted for anyone who can reach the API, so every /api
+// request must come from a local Host and Origin and carry the session
+// token embedded in the page, and edits must be JSON posts, which a
+// cross-site form can't send.
+type server struct {
+	mu    sync.Mutex
+	token string
+}
+
+type observeRequest struct {
+	Name        string `json:"name"`
+	Observation string `json:"observation"`
+}
+
+type relateRequest struct {
+	From string `json:"from"`
+	Type string `json:"type"`
+	To   string `json:"to"`
+}
+
+// Handler returns the HTTP handler for the live graph view:
+//
+//	GET  /                   visualization with editing panel
+//	GET  /api/graph          full graph as JSON
+//	GET  /api/entity?name=X  entity with incoming/outgoing edges
+//	POST /api/observe        {"name", "observation"}
+//	POST /api/relate         {"from", "type", "to"}
+//
+// API requests need the X-Palm-Token header set to a token generated for
+// this handler, which the page served at / carries.
+func Handler() http.Handler {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return handler(hex.EncodeToString(b))
+}
+
+func handler(token string) http.Handler {
+	s := &server{token: token}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.local(s.handleIndex))
+	mux.HandleFunc("/api/graph", s.api(s.handleGraph))
+	mux.HandleFunc("/api/entity", s.api(s.handleEntity))
+	mux.HandleFunc("/api/observe", s.api(s.handleObserve))
+	mux.HandleFunc("/api/relate", s.api(s.handleRelate))
+	return mux
+}
+
+// local rejects requests whose Host or Origin isn't a loopback address,
+// which stops DNS rebinding and cross-site pages from reaching the server.
+func (s *server) local(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !isLocalHost(r.Host) {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "host not allowed"})
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" {
+			u, err := url.Parse(origin)
+			if err != nil || !isLocalHost(u.Host) {
+				writeJSON(w, http.StatusForbidden, map[string]string{"error": "origin not allowed"})
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// api guards an API endpoint: local requests with the session token, and
+// JSON bodies for posts.
+func (s *server) api(next http.HandlerFunc) http.HandlerFunc {
+	return s.local(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Palm-Token")), []byte(s.token)) != 1 {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "missing or invalid session token"})
+			return
+		}
+		if r.Method == http.MethodPost {
+			if ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); ct != "application/json" {
+				writeJSON(w, http.StatusUnsupportedMediaType, map[string]string{"error": "Content-Type must be application/json"})
+				return
+			}
+		}
+		next(w, r)
+	})
+}
+
+// isLocalHost reports whether a Host header value names this machine.
+func isLocalHost(hostport string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (s *server) load() (*Graph, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Load()
+}
+
+// mutate applies fn to the on-disk graph and saves the result.
+func (s *server) mutate(fn func(g *Graph) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, err := Load()
+	if err != nil {
+		return err
+	}
+	if err := fn(g); err != nil {
+		return err
+	}
+	return Save(g)
+}
+
+func (s *server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	g, err := s.load()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	page := strings.Replace(g.LiveHTML(), "</head>",
+		`<meta name="palm-token" content="`+html.EscapeString(s.token)+`">`+"\n</head>", 1)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(page))
+}
+
+func (s *server) handleGraph(w http.ResponseWriter, r *http.Request) {
+	g, err := s.load()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, g)
+}
+
+func (s *server) handleEntity(w http.ResponseWriter, r *http.Request) {
+	g, err := s.load()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	result, err := g.ShowEntity(r.URL.Query().Get("name"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *server) handleObserve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req observeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	req.Observation = strings.TrimSpace(req.Observation)
+	if req.Observation == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "observation cannot be empty"})
+		return
+	}
+
+	var entity *Entity
+	err := s.mutate(func(g *Graph) error {
+		err := g.AddObservation(req.Name, req.Observation)
+		entity, _ = g.GetEntity(req.Name)
+		return err
+	})
+	if errors.Is(err, ErrDuplicateObservation) {
+		// Already known, which is not the writer's problem
+		writeJSON(w, http.StatusOK, entity)
+		return
+	}
+	if err != nil {
+		writeError(w, mutationStatus(err), err)
+		return
+	}
+	_ = activity.Append(activity.Entry{Action: "graph.observe", Tool: "graph", Details: entity.Name + ": " + req.Observation, Status: "ok"})
+	writeJSON(w, http.StatusOK, entity)
+}
+
+func (s *server) handleRelate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req relateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	req.Type = strings.TrimSpace(req.Type)
+	if req.Type == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "relation type cannot be empty"})
+		return
+	}
+
+	err := s.mutate(func(g *Graph) error {
+		return g.AddRelation(req.From, req.Type, req.To)
+	})
+	if err != nil {
+		writeError(w, mutationStatus(err), err)
+		return
+	}
+	_ = activity.Append(activity.Entry{Action: "graph.relate", Tool: "graph", Details: req.From + " --" + req.Type + "--> " + req.To, Status: "ok"})
+	writeJSON(w, http.StatusOK, Relation{From: req.From, To: req.To, Type: req.Type})
+}
+
+// mutationStatus is the HTTP status for a failed edit.
+func mutationStatus(err error) int {
+	if errors.Is(err, ErrReadOnly) {
+		return http.StatusForbidden
+	}
+	return http.StatusBadRequest
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// ─── Live view assets ───
+
+const liveHTMLStyle = `#panel{position:fixed;top:16px;right:16px;bottom:16px;width:320px;z-index:15;display:none;overflow-y:auto;background:rgba(10,14,23,0.95);border:1px solid rgba(45,182,130,0.3);border-radius:12px;padding:16px 20px;backdrop-filter:blur(10px);font-size:13px}
+#panel h3{color:#2DB682;font-size:16px;margin-bottom:2px}
+#panel .p-type{color:#888;font-style:italic;margin-bottom:10px}
+#panel .p-sec{color:#666;font-size:11px;text-transform:uppercase;letter-spacing:1px;margin:14px 0 6px}
+#panel .p-item{color:#bbb;margin:3px 0}
+#panel input{width:100%;margin:4px 0;background:#111726;border:1px solid rgba(255,255,255,0.1);border-radius:6px;padding:6px 10px;color:#e0e0e0;font-family:inherit;font-size:12px;outline:none}
+#panel input:focus{border-color:#2DB682}
+#panel button{margin-top:4px;background:#2DB682;border:none;border-radius:6px;padding:6px 12px;color:#0a0e17;font-weight:700;cursor:pointer}
+#panel .p-err{color:#E74C3C;font-size:12px;margin-top:6px}
+#panel .p-close{position:absolute;top:10px;right:14px;color:#666;cursor:pointer}
+body.live #search-box{right:352px}`
+
+const liveHTMLPanel = `<div id="panel"></div>
+<datalist id="entity-names"></datalist>`
+
+const liveHTMLScript = `
+document.body.classList.add('live');
+document.getElementById('hint').textContent='click a node to view and edit / drag to pin / scroll to zoom';
+const panel=document.getElementById('panel');
+const names=document.getElementById('entity-names');
+function refreshNames(){names.textContent='';sim.nodes.forEach(n=>{const o=document.createElement('option');o.value=n.name;names.appendChild(o)})}
+refreshNames();
+
+function el(tag,cls,text){const e=document.createElement(tag);if(cls)e.className=cls;if(text!==undefined)e.textContent=text;return e}
+
+const token=document.querySelector('meta[name="palm-token"]').content;
+async function api(path,body){
+  const opts=body?{method:'POST',headers:{'Content-Type':'application/json','X-Palm-Token':token},body:JSON.stringify(body)}:{headers:{'X-Palm-Token':token}};
+  const res=await fetch(path,opts);
+  const data=await res.json();
+  if(!res.ok)throw new Error(data.error||res.statusText);
+  return data;
+}
+
+async function openPanel(node){
+  let info;
+  try{info=await api('/api/entity?name='+encodeURIComponent(node.name))}catch(err){return}
+  panel.textContent='';
+  const close=el('span','p-close','✕');close.onclick=()=>{panel.style.display='none'};panel.appendChild(close);
+  panel.appendChild(el('h3','',info.entity.name));
+  panel.appendChild(el('div','p-type',info.entity.type||'default'));
+
+  panel.appendChild(el('div','p-sec','Observations'));
+  (info.entity.observations||[]).forEach(o=>panel.appendChild(el('div','p-item','• '+o)));
+  const obsIn=el('input');obsIn.placeholder='New observation';panel.appendChild(obsIn);
+  const obsBtn=el('button','','Add observation');panel.appendChild(obsBtn);
+
+  panel.appendChild(el('div','p-sec','Relations'));
+  (info.outgoing||[]).forEach(e=>panel.appendChild(el('div','p-item','→ '+e.type+' '+(e.target?e.target.name:''))));
+  (info.incoming||[]).forEach(e=>panel.appendChild(el('div','p-item','← '+e.type+' '+(e.source?e.source.name:''))));
+  const relType=el('input');relType.placeholder='Relation type (e.g. depends_on)';panel.appendChild(relType);
+  const relTo=el('input');relTo.placeholder='Target entity';relTo.setAttribute('list','entity-names');panel.appendChild(relTo);
+  const relBtn=el('button','','Add relation');panel.appendChild(relBtn);
+  const errEl=el('div','p-err');panel.appendChild(errEl);
+
+  obsBtn.onclick=async()=>{
+    try{
+      const ent=await api('/api/observe',{name:node.name,observation:obsIn.value});
+      node.obs=ent.observations;node.r=6+Math.min(node.obs.length,10)*1.5;
+      openPanel(node);
+    }catch(err){errEl.textContent=err.message}
+  };
+  relBtn.onclick=async()=>{
+    try{
+      await api('/api/relate',{from:node.name,type:relType.value,to:relTo.value});
+      const ti=sim.nodes.findIndex(n=>n.name.toLowerCase()===relTo.value.trim().toLowerCase());
+      const si=sim.nodes.indexOf(node);
+      if(ti>=0)sim.edges.push({source:node.id,target:sim.nodes[ti].id,type:relType.value,si,ti});
+      document.getElementById('n-edges').textContent=sim.edges.length;
+      applyFilters();
+      openPanel(node);
+    }catch(err){errEl.textContent=err.message}
+  };
+  panel.style.display='block';
+}
+
+let downAt=null;
+canvas.addEventListener('mousedown',e=>{downAt=[e.clientX,e.clientY]});
+canvas.addEventListener('click',e=>{
+  if(downAt&&Math.abs(e.clientX-downAt[0])+Math.abs(e.clientY-downAt[1])>4)return;
+  const n=findNode(e.clientX,e.clientY);
+  if(n)openPanel(n);
+});
+`
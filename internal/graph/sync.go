@@ -0,0 +1,324 @@
+package graph
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ─── Diff & sync ───
+
+// EntityChange describes how an entity differs between two graphs.
+type EntityChange struct {
+	Name                string   `json:"name"`
+	TypeFrom            string   `json:"type_from,omitempty"`
+	TypeTo              string   `json:"type_to,omitempty"`
+	ObservationsAdded   []string `json:"observations_added,omitempty"`
+	ObservationsRemoved []string `json:"observations_removed,omitempty"`
+}
+
+// Diff lists what another graph has that this one doesn't, and vice versa.
+// "Added" means present in the other graph only.
+type Diff struct {
+	Added            []*Entity      `json:"added"`
+	Removed          []*Entity      `json:"removed"`
+	Changed          []EntityChange `json:"changed"`
+	RelationsAdded   []*Relation    `json:"relations_added"`
+	RelationsRemoved []*Relation    `json:"relations_removed"`
+}
+
+// Empty reports whether the graphs are the same.
+func (d Diff) Empty() bool {
+	return len(d.Added)+len(d.Removed)+len(d.Changed)+len(d.RelationsAdded)+len(d.RelationsRemoved) == 0
+}
+
+// ParseJSON reads a graph from a JSON export.
+func ParseJSON(data []byte) (*Graph, error) {
+	var in Graph
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, fmt.Errorf("graph parse: %w", err)
+	}
+	g := New()
+	g.Schema = in.Schema
+	for key, e := range in.Entities {
+		if e == nil {
+			continue
+		}
+		if e.Name == "" {
+			e.Name = key
+		}
+		if e.Observations == nil {
+			e.Observations = make([]string, 0)
+		}
+		g.Entities[normalize(e.Name)] = e
+	}
+	for _, r := range in.Relations {
+		if r != nil {
+			g.Relations = append(g.Relations, r)
+		}
+	}
+	return g, nil
+}
+
+// Compare diffs other against g.
+func Compare(g, other *Graph) Diff {
+	var d Diff
+	for _, key := range other.sortedKeys() {
+		oe := other.Entities[key]
+		e, ok := g.Entities[key]
+		if !ok {
+			d.Added = append(d.Added, oe)
+			continue
+		}
+		if c, changed := compareEntity(e, oe); changed {
+			d.Changed = append(d.Changed, c)
+		}
+	}
+	for _, key := range g.sortedKeys() {
+		if _, ok := other.Entities[key]; !ok {
+			d.Removed = append(d.Removed, g.Entities[key])
+		}
+	}
+	mine, theirs := relationSet(g), relationSet(other)
+	for _, r := range other.Relations {
+		if !mine[relationKey(r)] {
+			d.RelationsAdded = append(d.RelationsAdded, r)
+		}
+	}
+	for _, r := range g.Relations {
+		if !theirs[relationKey(r)] {
+			d.RelationsRemoved = append(d.RelationsRemoved, r)
+		}
+	}
+	return d
+}
+
+func compareEntity(from, to *Entity) (EntityChange, bool) {
+	c := EntityChange{Name: from.Name}
+	if from.Type != to.Type {
+		c.TypeFrom, c.TypeTo = from.Type, to.Type
+	}
+	c.ObservationsAdded = missingFrom(to.Observations, from.Observations)
+	c.ObservationsRemoved = missingFrom(from.Observations, to.Observations)
+	changed := c.TypeFrom != c.TypeTo || len(c.ObservationsAdded) > 0 || len(c.ObservationsRemoved) > 0
+	return c, changed
+}
+
+// missingFrom returns the items of a that b lacks.
+func missingFrom(a, b []string) []string {
+	have := make(map[string]bool, len(b))
+	for _, s := range b {
+		have[s] = true
+	}
+	var out []string
+	for _, s := range a {
+		if !have[s] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func relationKey(r *Relation) string {
+	return normalize(r.From) + "\x00" + r.Type + "\x00" + normalize(r.To)
+}
+
+func relationSet(g *Graph) map[string]bool {
+	set := make(map[string]bool)
+	for _, r := range g.Relations {
+		set[relationKey(r)] = true
+	}
+	return set
+}
+
+// Conflict is a change both sides made differently; pull keeps the local side.
+type Conflict struct {
+	Entity string `json:"entity"`
+	Reason string `json:"reason"`
+}
+
+// PullResult summarizes a pull.
+type PullResult struct {
+	Added            int        `json:"added"`
+	Updated          int        `json:"updated"`
+	Removed          int        `json:"removed"`
+	RelationsAdded   int        `json:"relations_added"`
+	RelationsRemoved int        `json:"relations_removed"`
+	Conflicts        []Conflict `json:"conflicts"`
+}
+
+// Pull merges theirs into g. base is theirs as of the last pull from the
+// same source: changes they made since then are applied, including
+// deletions, while local edits win over conflicting remote ones. Without a
+// base nothing is deleted and the graphs are unioned.
+func (g *Graph) Pull(theirs, base *Graph) PullResult {
+	var res PullResult
+	if base == nil {
+		base = New()
+	}
+
+	for _, key := range theirs.sortedKeys() {
+		te := theirs.Entities[key]
+		be, inBase := base.Entities[key]
+		e, ok := g.Entities[key]
+		switch {
+		case !ok && !inBase:
+			cp := *te
+			cp.Observations = append([]string{}, te.Observations...)
+			cp.Aliases = nil
+			if cp.CreatedAt.IsZero() {
+				cp.CreatedAt = time.Now()
+			}
+			if cp.UpdatedAt.IsZero() {
+				cp.UpdatedAt = time.Now()
+			}
+			g.Entities[key] = &cp
+			g.addAliases(key, te.Aliases)
+			res.Added++
+		case !ok:
+			// Deleted here since the last pull
+			if _, changed := compareEntity(be, te); changed {
+				res.Conflicts = append(res.Conflicts, Conflict{te.Name, "deleted locally but changed remotely; kept deleted"})
+			}
+		default:
+			// Aliases only accumulate: a pull never drops a local one
+			aliased := g.addAliases(key, te.Aliases) > 0
+			if pullEntity(e, te, be, inBase, &res) || aliased {
+				e.UpdatedAt = time.Now()
+				res.Updated++
+			}
+		}
+	}
+
+	// Deleted remotely since the last pull
+	for _, key := range base.sortedKeys() {
+		if _, ok := theirs.Entities[key]; ok {
+			continue
+		}
+		e, ok := g.Entities[key]
+		if !ok {
+			continue
+		}
+		if _, changed := compareEntity(base.Entities[key], e); changed {
+			res.Conflicts = append(res.Conflicts, Conflict{e.Name, "deleted remotely but changed locally; kept"})
+			continue
+		}
+		if err := g.RemoveEntity(e.Name); err == nil {
+			res.Removed++
+		}
+	}
+
+	baseRels, theirRels, mine := relationSet(base), relationSet(theirs), relationSet(g)
+	for _, r := range theirs.Relations {
+		k := relationKey(r)
+		if mine[k] || baseRels[k] {
+			continue
+		}
+		if _, ok := g.Entities[normalize(r.From)]; !ok {
+			continue
+		}
+		if _, ok := g.Entities[normalize(r.To)]; !ok {
+			continue
+		}
+		g.Relations = append(g.Relations, &Relation{From: r.From, To: r.To, Type: r.Type})
+		mine[k] = true
+		res.RelationsAdded++
+	}
+	kept := g.Relations[:0]
+	for _, r := range g.Relations {
+		k := relationKey(r)
+		if baseRels[k] && !theirRels[k] {
+			res.RelationsRemoved++
+			continue
+		}
+		kept = append(kept, r)
+	}
+	g.Relations = kept
+
+	g.mergeSchema(theirs.Schema)
+	sort.Slice(res.Conflicts, func(i, j int) bool { return res.Conflicts[i].Entity < res.Conflicts[j].Entity })
+	return res
+}
+
+// pullEntity applies remote changes to one entity and reports whether it
+// changed.
+func pullEntity(e, te, be *Entity, inBase bool, res *PullResult) bool {
+	changed := false
+	switch {
+	case e.Type == te.Type:
+	case inBase && e.Type == be.Type:
+		e.Type = te.Type
+		changed = true
+	case !inBase || te.Type != be.Type:
+		res.Conflicts = append(res.Conflicts, Conflict{e.Name, fmt.Sprintf("type is %q here but %q remotely; kept %q", e.Type, te.Type, e.Type)})
+	}
+
+	var baseObs []string
+	if inBase {
+		baseObs = be.Observations
+	}
+	for _, o := range missingFrom(te.Observations, e.Observations) {
+		// Skip observations deleted here since the last pull
+		if len(missingFrom([]string{o}, baseObs)) == 0 {
+			continue
+		}
+		e.Observations = append(e.Observations, o)
+		changed = true
+	}
+	if removed := missingFrom(baseObs, te.Observations); len(removed) > 0 {
+		if kept := missingFrom(e.Observations, removed); len(kept) != len(e.Observations) {
+			e.Observations = kept
+			changed = true
+		}
+	}
+	if e.Observations == nil {
+		e.Observations = make([]string, 0)
+	}
+	return changed
+}
+
+// ─── Sync bases ───
+
+func syncBasePath(source string) string {
+	sum := sha256.Sum256([]byte(source))
+	return filepath.Join(filepath.Dir(graphPath()), "sync", hex.EncodeToString(sum[:8])+".enc")
+}
+
+// LoadSyncBase returns the graph as last pulled from source, or nil if it
+// was never pulled.
+func LoadSyncBase(source string) (*Graph, error) {
+	data, err := os.ReadFile(syncBasePath(source))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	plaintext, err := decrypt(deriveKey(), data)
+	if err != nil {
+		return nil, fmt.Errorf("sync base decrypt: %w", err)
+	}
+	return ParseJSON(plaintext)
+}
+
+// SaveSyncBase records theirs as the base for the next pull from source.
+func SaveSyncBase(source string, theirs *Graph) error {
+	plaintext, err := json.Marshal(theirs)
+	if err != nil {
+		return err
+	}
+	ciphertext, err := encrypt(deriveKey(), plaintext)
+	if err != nil {
+		return err
+	}
+	path := syncBasePath(source)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, ciphertext, 0o600)
+}
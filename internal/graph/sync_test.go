@@ -0,0 +1,164 @@
+package graph
+
+import (
+	"testing"
+)
+
+func syncFixture() *Graph {
+	g := New()
+	g.AddEntity("Kubernetes", "tool")
+	g.AddObservation("Kubernetes", "runs containers")
+	g.AddEntity("Docker", "tool")
+	g.AddEntity("Alice", "person")
+	g.AddRelation("Alice", "uses", "Kubernetes")
+	return g
+}
+
+func clone(t *testing.T, g *Graph) *Graph {
+	t.Helper()
+	data, err := g.ExportJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := ParseJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCompare(t *testing.T) {
+	mine := syncFixture()
+	other := clone(t, mine)
+	if d := Compare(mine, other); !d.Empty() {
+		t.Fatalf("a copy should not differ: %+v", d)
+	}
+
+	other.AddEntity("Helm", "tool")
+	other.RemoveEntity("Docker")
+	other.AddObservation("Kubernetes", "made by Google")
+	other.Entities["alice"].Type = "engineer"
+	other.AddRelation("Alice", "uses", "Helm")
+
+	d := Compare(mine, other)
+	if len(d.Added) != 1 || d.Added[0].Name != "Helm" {
+		t.Errorf("added = %v", d.Added)
+	}
+	if len(d.Removed) != 1 || d.Removed[0].Name != "Docker" {
+		t.Errorf("removed = %v", d.Removed)
+	}
+	if len(d.Changed) != 2 {
+		t.Fatalf("changed = %+v", d.Changed)
+	}
+	if d.Changed[0].TypeFrom != "person" || d.Changed[0].TypeTo != "engineer" {
+		t.Errorf("alice change = %+v", d.Changed[0])
+	}
+	if got := d.Changed[1].ObservationsAdded; len(got) != 1 || got[0] != "made by Google" {
+		t.Errorf("kubernetes observations added = %v", got)
+	}
+	if len(d.RelationsAdded) != 1 || len(d.RelationsRemoved) != 0 {
+		t.Errorf("relations +%v -%v", d.RelationsAdded, d.RelationsRemoved)
+	}
+}
+
+func TestPullFirstTimeOnlyAdds(t *testing.T) {
+	mine := syncFixture()
+	theirs := New()
+	theirs.AddEntity("Kubernetes", "tool")
+	theirs.AddObservation("Kubernetes", "made by Google")
+	theirs.AddEntity("Helm", "tool")
+
+	res := mine.Pull(theirs, nil)
+	if res.Added != 1 || res.Updated != 1 || res.Removed != 0 {
+		t.Fatalf("result = %+v", res)
+	}
+	if _, err := mine.GetEntity("Docker"); err != nil {
+		t.Error("without a base, pull must not delete")
+	}
+	if e, _ := mine.GetEntity("Kubernetes"); len(e.Observations) != 2 {
+		t.Errorf("observations = %v", e.Observations)
+	}
+}
+
+func TestPullThreeWay(t *testing.T) {
+	base := syncFixture()
+	mine := clone(t, base)
+	theirs := clone(t, base)
+
+	// Remote: deletes Docker, drops an observation, retypes Alice, adds Helm
+	theirs.RemoveEntity("Docker")
+	theirs.RemoveObservation("Kubernetes", 0)
+	theirs.Entities["alice"].Type = "engineer"
+	theirs.AddEntity("Helm", "tool")
+	theirs.AddRelation("Alice", "uses", "Helm")
+	theirs.RemoveRelation("Alice", "uses", "Kubernetes")
+
+	// Local: adds an observation, which remote doesn't touch
+	mine.AddObservation("Kubernetes", "v1.30 on prod")
+
+	res := mine.Pull(theirs, base)
+	if len(res.Conflicts) != 0 {
+		t.Fatalf("unexpected conflicts: %v", res.Conflicts)
+	}
+	if _, err := mine.GetEntity("Docker"); err == nil {
+		t.Error("Docker was deleted remotely and unchanged here; it should be gone")
+	}
+	k, _ := mine.GetEntity("Kubernetes")
+	if len(k.Observations) != 1 || k.Observations[0] != "v1.30 on prod" {
+		t.Errorf("kubernetes observations = %v", k.Observations)
+	}
+	if a, _ := mine.GetEntity("Alice"); a.Type != "engineer" {
+		t.Errorf("alice type = %q", a.Type)
+	}
+	if d := Compare(mine, theirs); len(d.RelationsAdded)+len(d.RelationsRemoved) != 0 {
+		t.Errorf("relations should match remote: +%v -%v", d.RelationsAdded, d.RelationsRemoved)
+	}
+	if res.Added != 1 || res.Removed != 1 || res.RelationsAdded != 1 || res.RelationsRemoved != 1 {
+		t.Errorf("result = %+v", res)
+	}
+}
+
+func TestPullConflictsKeepLocal(t *testing.T) {
+	base := syncFixture()
+	mine := clone(t, base)
+	theirs := clone(t, base)
+
+	theirs.Entities["alice"].Type = "engineer"
+	mine.Entities["alice"].Type = "manager"
+	theirs.RemoveEntity("Docker")
+	mine.AddObservation("Docker", "used for local dev")
+	theirs.AddObservation("Kubernetes", "made by Google")
+	mine.RemoveEntity("Kubernetes")
+
+	res := mine.Pull(theirs, base)
+	if len(res.Conflicts) != 3 {
+		t.Fatalf("conflicts = %v", res.Conflicts)
+	}
+	if a, _ := mine.GetEntity("Alice"); a.Type != "manager" {
+		t.Errorf("alice type = %q, local side should win", a.Type)
+	}
+	if _, err := mine.GetEntity("Docker"); err != nil {
+		t.Error("Docker changed locally; a remote delete must not drop it")
+	}
+	if _, err := mine.GetEntity("Kubernetes"); err == nil {
+		t.Error("Kubernetes was deleted locally; pull must not resurrect it")
+	}
+}
+
+func TestSyncBaseRoundTrip(t *testing.T) {
+	setupTestEnv(t)
+
+	if b, err := LoadSyncBase("/tmp/desktop.json"); b != nil || err != nil {
+		t.Fatalf("no base yet, got %v %v", b, err)
+	}
+	if err := SaveSyncBase("/tmp/desktop.json", syncFixture()); err != nil {
+		t.Fatal(err)
+	}
+	b, err := LoadSyncBase("/tmp/desktop.json")
+	if err != nil || b == nil {
+		t.Fatalf("LoadSyncBase: %v", err)
+	}
+	if d := Compare(syncFixture(), b); !d.Empty() {
+		t.Errorf("base changed in the round trip: %+v", d)
+	}
+}
@@ -0,0 +1,147 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Neighborhood returns the part of the graph within depth relations of the
+// named entity, following relations in either direction. The schema is kept
+// so types keep their colors.
+func (g *Graph) Neighborhood(name string, depth int) (*Graph, error) {
+	root, ok := g.resolve(name)
+	if !ok {
+		return nil, fmt.Errorf("entity not found: %s", name)
+	}
+	if depth < 0 {
+		return nil, fmt.Errorf("depth can't be negative")
+	}
+
+	adj := g.adjacency()
+	seen := map[string]bool{root: true}
+	frontier := []string{root}
+	for d := 0; d < depth && len(frontier) > 0; d++ {
+		var next []string
+		for _, k := range frontier {
+			for _, n := range adj[k] {
+				if !seen[n] {
+					seen[n] = true
+					next = append(next, n)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	sub := &Graph{Entities: make(map[string]*Entity, len(seen)), Schema: g.Schema}
+	for k := range seen {
+		sub.Entities[k] = g.Entities[k]
+	}
+	for _, r := range g.Relations {
+		if seen[normalize(r.From)] && seen[normalize(r.To)] {
+			sub.Relations = append(sub.Relations, r)
+		}
+	}
+	return sub, nil
+}
+
+// adjacency maps each entity key to the keys it is related to, in either
+// direction. Relations to missing entities are left out.
+func (g *Graph) adjacency() map[string][]string {
+	adj := make(map[string][]string, len(g.Entities))
+	for _, r := range g.Relations {
+		from, to := normalize(r.From), normalize(r.To)
+		if g.Entities[from] == nil || g.Entities[to] == nil || from == to {
+			continue
+		}
+		adj[from] = append(adj[from], to)
+		adj[to] = append(adj[to], from)
+	}
+	return adj
+}
+
+// communities groups entities into clusters by label propagation: each
+// entity repeatedly takes the label with the most weight among its
+// neighbors. A relation weighs one plus the neighbors its two ends share,
+// so relations inside a dense cluster outweigh a bridge between two. Keys
+// are visited in sorted order and ties go to the smallest label, so the
+// result is stable between runs. Clusters are numbered largest first.
+func (g *Graph) communities() map[string]int {
+	keys := make([]string, 0, len(g.Entities))
+	for k := range g.Entities {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	adj := g.adjacency()
+	neighbors := make(map[string]map[string]bool, len(adj))
+	for k, ns := range adj {
+		neighbors[k] = map[string]bool{}
+		for _, n := range ns {
+			neighbors[k][n] = true
+		}
+	}
+	weight := func(a, b string) int {
+		w := 1
+		for n := range neighbors[a] {
+			if neighbors[b][n] {
+				w++
+			}
+		}
+		return w
+	}
+
+	label := make(map[string]string, len(keys))
+	for _, k := range keys {
+		label[k] = k
+	}
+	for iter := 0; iter < 20; iter++ {
+		changed := false
+		for _, k := range keys {
+			if len(adj[k]) == 0 {
+				continue
+			}
+			counts := map[string]int{}
+			for n := range neighbors[k] {
+				counts[label[n]] += weight(k, n)
+			}
+			best, bestN := "", 0
+			for l, n := range counts {
+				if n > bestN || (n == bestN && l < best) {
+					best, bestN = l, n
+				}
+			}
+			if best != label[k] {
+				label[k] = best
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	size := map[string]int{}
+	for _, l := range label {
+		size[l]++
+	}
+	labels := make([]string, 0, len(size))
+	for l := range size {
+		labels = append(labels, l)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if size[labels[i]] != size[labels[j]] {
+			return size[labels[i]] > size[labels[j]]
+		}
+		return labels[i] < labels[j]
+	})
+	index := make(map[string]int, len(labels))
+	for i, l := range labels {
+		index[l] = i
+	}
+	out := make(map[string]int, len(keys))
+	for _, k := range keys {
+		out[k] = index[label[k]]
+	}
+	return out
+}
@@ -0,0 +1,209 @@
+package grounding
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Chunk is a passage of a source document.
+type Chunk struct {
+	Source string // path relative to the grounding root
+	Line   int    // first line of the passage, 1-based
+	Text   string
+}
+
+// Ref returns "path:line" for citing the chunk.
+func (c Chunk) Ref() string {
+	return fmt.Sprintf("%s:%d", c.Source, c.Line)
+}
+
+const (
+	// chunkWords is the target passage size; paragraphs are merged up to it.
+	chunkWords = 180
+	// maxFileSize skips large files, which are rarely useful as sources.
+	maxFileSize = 1 << 20
+)
+
+var skipDirs = map[string]bool{
+	"node_modules": true, "vendor": true, "dist": true, "build": true,
+	"target": true, "__pycache__": true, "venv": true, ".venv": true,
+}
+
+// Load reads every text file under root (or root itself when it is a file)
+// and splits it into passages.
+func Load(root string) ([]Chunk, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		data, err := os.ReadFile(root)
+		if err != nil {
+			return nil, err
+		}
+		return Split(filepath.Base(root), string(data)), nil
+	}
+
+	var chunks []Chunk
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(name, ".") || skipDirs[name]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(name, ".") {
+			return nil
+		}
+		if fi, err := d.Info(); err != nil || fi.Size() > maxFileSize {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || !isText(data) {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		chunks = append(chunks, Split(filepath.ToSlash(rel), string(data))...)
+		return nil
+	})
+	return chunks, err
+}
+
+// isText rejects files with NUL bytes near the start, the usual binary tell.
+func isText(data []byte) bool {
+	head := data
+	if len(head) > 512 {
+		head = head[:512]
+	}
+	return !bytes.Contains(head, []byte{0})
+}
+
+// Split breaks a document into passages of about chunkWords words, keeping
+// paragraphs intact where possible.
+func Split(source, text string) []Chunk {
+	var (
+		chunks []Chunk
+		buf    []string
+		words  int
+		start  int
+	)
+	flush := func() {
+		if words > 0 {
+			chunks = append(chunks, Chunk{Source: source, Line: start, Text: strings.TrimSpace(strings.Join(buf, "\n"))})
+		}
+		buf, words, start = nil, 0, 0
+	}
+
+	for i, line := range strings.Split(text, "\n") {
+		n := len(strings.Fields(line))
+		if n == 0 {
+			// Paragraph break: close the passage once it is big enough.
+			if words >= chunkWords/2 {
+				flush()
+			} else if words > 0 {
+				buf = append(buf, "")
+			}
+			continue
+		}
+		if words > 0 && words+n > chunkWords {
+			flush()
+		}
+		if start == 0 {
+			start = i + 1
+		}
+		buf = append(buf, line)
+		words += n
+	}
+	flush()
+	return chunks
+}
+
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"by": true, "do": true, "does": true, "for": true, "from": true, "how": true, "in": true,
+	"is": true, "it": true, "of": true, "on": true, "or": true, "that": true, "the": true,
+	"this": true, "to": true, "was": true, "what": true, "when": true, "where": true,
+	"which": true, "who": true, "why": true, "with": true, "you": true,
+}
+
+// terms lowercases text into searchable words, dropping stopwords.
+func terms(text string) []string {
+	var out []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		if len(w) > 1 && !stopwords[w] {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// Retrieve returns the k passages most relevant to query, ranked by BM25.
+// Passages sharing no terms with the query are never returned.
+func Retrieve(chunks []Chunk, query string, k int) []Chunk {
+	q := terms(query)
+	if len(q) == 0 || len(chunks) == 0 || k <= 0 {
+		return nil
+	}
+
+	const k1, b = 1.2, 0.75
+	tf := make([]map[string]int, len(chunks))
+	lengths := make([]int, len(chunks))
+	df := make(map[string]int)
+	total := 0
+	for i, c := range chunks {
+		tf[i] = make(map[string]int)
+		for _, t := range terms(c.Text) {
+			tf[i][t]++
+			lengths[i]++
+		}
+		for t := range tf[i] {
+			df[t]++
+		}
+		total += lengths[i]
+	}
+	avgLen := float64(total) / float64(len(chunks))
+	if avgLen == 0 {
+		return nil
+	}
+
+	type scored struct {
+		idx   int
+		score float64
+	}
+	var ranked []scored
+	n := float64(len(chunks))
+	for i := range chunks {
+		score := 0.0
+		for _, t := range q {
+			f := float64(tf[i][t])
+			if f == 0 {
+				continue
+			}
+			idf := math.Log(1 + (n-float64(df[t])+0.5)/(float64(df[t])+0.5))
+			score += idf * f * (k1 + 1) / (f + k1*(1-b+b*float64(lengths[i])/avgLen))
+		}
+		if score > 0 {
+			ranked = append(ranked, scored{i, score})
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+
+	var out []Chunk
+	for _, r := range ranked[:min(k, len(ranked))] {
+		out = append(out, chunks[r.idx])
+	}
+	return out
+}
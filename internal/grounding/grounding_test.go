@@ -0,0 +1,53 @@
+package grounding
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitKeepsLineNumbers(t *testing.T) {
+	text := "# Title\n\nFirst paragraph here.\n\n" + strings.Repeat("word ", chunkWords) + "\nlast line"
+	chunks := Split("doc.md", text)
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+	if chunks[0].Line != 1 || chunks[0].Source != "doc.md" {
+		t.Errorf("unexpected first chunk %+v", chunks[0])
+	}
+	if chunks[1].Ref() != "doc.md:5" || chunks[2].Ref() != "doc.md:6" {
+		t.Errorf("expected oversized passage split at lines 5 and 6, got %s and %s", chunks[1].Ref(), chunks[2].Ref())
+	}
+}
+
+func TestLoadSkipsBinaryAndHidden(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "guide.md"), []byte("Palm installs AI tools."), 0o644)
+	_ = os.WriteFile(filepath.Join(dir, "logo.png"), []byte{0x89, 'P', 'N', 'G', 0, 0}, 0o644)
+	_ = os.MkdirAll(filepath.Join(dir, ".git"), 0o755)
+	_ = os.WriteFile(filepath.Join(dir, ".git", "HEAD"), []byte("ref: refs/heads/main"), 0o644)
+
+	chunks, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 1 || chunks[0].Source != "guide.md" {
+		t.Errorf("expected only guide.md, got %+v", chunks)
+	}
+}
+
+func TestRetrieveRanksRelevantPassages(t *testing.T) {
+	chunks := []Chunk{
+		{Source: "a.md", Line: 1, Text: "The budget command tracks monthly spending limits."},
+		{Source: "b.md", Line: 1, Text: "The vault stores API keys in the system keychain."},
+		{Source: "c.md", Line: 1, Text: "Installing tools uses brew, npm or pip."},
+	}
+	got := Retrieve(chunks, "Where are API keys stored?", 2)
+	if len(got) != 1 || got[0].Source != "b.md" {
+		t.Errorf("expected b.md only, got %+v", got)
+	}
+	if got := Retrieve(chunks, "what is it", 3); got != nil {
+		t.Errorf("stopword-only query should match nothing, got %+v", got)
+	}
+}
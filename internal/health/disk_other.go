@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package health
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// FreeBytes is not implemented here; disk checks report unknown.
+func FreeBytes(path string) (uint64, error) {
+	return 0, fmt.Errorf("free space not supported on %s", runtime.GOOS)
+}
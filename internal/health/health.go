@@ -0,0 +1,186 @@
+// Package health tracks the results of palm's background health checks and
+// alerts when a check starts failing or recovers.
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/msalah0e/palm/internal/config"
+	"github.com/msalah0e/palm/internal/notify"
+)
+
+// Check statuses. A check is unknown when it could not be run, e.g. a key
+// check while offline; unknown results never raise or clear an alert.
+const (
+	StatusOK      = "ok"
+	StatusFail    = "fail"
+	StatusUnknown = "unknown"
+)
+
+// Check is the result of one health check.
+type Check struct {
+	Name   string `json:"name"` // unique, e.g. "tool:aider" or "key:openai"
+	Kind   string `json:"kind"` // tool, runtime, proxy, key or disk
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Failed reports whether the check ran and failed.
+func (c Check) Failed() bool { return c.Status == StatusFail }
+
+// Change is a check whose status flipped since the previous round.
+type Change struct {
+	Check
+	Recovered bool `json:"recovered"`
+}
+
+// Tracker remembers the last known status of every check, so alerts fire
+// once when something breaks and once when it recovers, not every round.
+type Tracker struct {
+	last map[string]Check
+}
+
+// NewTracker returns a Tracker with no history. A check failing in the
+// first round counts as a change.
+func NewTracker() *Tracker {
+	return &Tracker{last: make(map[string]Check)}
+}
+
+// Update records a round of results and returns what changed.
+func (t *Tracker) Update(checks []Check) []Change {
+	var changes []Change
+	for _, c := range checks {
+		if c.Status == StatusUnknown {
+			continue
+		}
+		prev, seen := t.last[c.Name]
+		switch {
+		case c.Failed() && (!seen || !prev.Failed()):
+			changes = append(changes, Change{Check: c})
+		case !c.Failed() && seen && prev.Failed():
+			changes = append(changes, Change{Check: c, Recovered: true})
+		}
+		t.last[c.Name] = c
+	}
+	return changes
+}
+
+// Summary describes changes in one line, broken checks first.
+func Summary(changes []Change) string {
+	var broken, recovered []string
+	for _, c := range changes {
+		if c.Recovered {
+			recovered = append(recovered, c.Name)
+		} else if c.Detail != "" {
+			broken = append(broken, c.Name+" ("+c.Detail+")")
+		} else {
+			broken = append(broken, c.Name)
+		}
+	}
+	var parts []string
+	if len(broken) > 0 {
+		parts = append(parts, "broken: "+strings.Join(broken, ", "))
+	}
+	if len(recovered) > 0 {
+		parts = append(parts, "recovered: "+strings.Join(recovered, ", "))
+	}
+	return "palm health: " + strings.Join(parts, "; ")
+}
+
+// event is the webhook payload for a round with changes.
+type event struct {
+	Text    string   `json:"text"` // Slack incoming webhooks display this field
+	Changes []Change `json:"changes"`
+}
+
+// Notify sends changes as a desktop notification and/or to a webhook.
+func Notify(changes []Change, desktop bool, webhook string) error {
+	text := Summary(changes)
+	var errs []string
+	if desktop {
+		if err := notify.Desktop("palm health", text); err != nil {
+			errs = append(errs, "notify: "+err.Error())
+		}
+	}
+	if webhook != "" {
+		if err := notify.Webhook(webhook, event{Text: text, Changes: changes}); err != nil {
+			errs = append(errs, "webhook: "+err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Report is the latest round of checks, saved for palm health watch status.
+type Report struct {
+	At     time.Time `json:"at"`
+	Checks []Check   `json:"checks"`
+}
+
+// ReportPath returns where the watchdog saves its latest round.
+func ReportPath() string {
+	return filepath.Join(config.ConfigDir(), "health-watch.json")
+}
+
+// SaveReport writes r to ReportPath.
+func SaveReport(r Report) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(ReportPath()), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(ReportPath(), data, 0o644)
+}
+
+// LoadReport reads the latest saved round.
+func LoadReport() (Report, error) {
+	var r Report
+	data, err := os.ReadFile(ReportPath())
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(data, &r)
+	return r, err
+}
+
+// PidFile returns the path to the watchdog's PID file.
+func PidFile() string {
+	return filepath.Join(config.ConfigDir(), "health-watch.pid")
+}
+
+// LogPath returns where the watchdog writes its output.
+func LogPath() string {
+	return filepath.Join(config.ConfigDir(), "health-watch.log")
+}
+
+// IsRunning reports whether the watchdog is running, and its PID.
+func IsRunning() (bool, int) {
+	data, err := os.ReadFile(PidFile())
+	if err != nil {
+		return false, 0
+	}
+	var pid int
+	if _, err := fmt.Sscanf(string(data), "%d", &pid); err != nil {
+		return false, 0
+	}
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false, 0
+	}
+	// Signal 0 checks the process exists without disturbing it
+	if err := proc.Signal(syscall.Signal(0)); err == nil {
+		return true, pid
+	}
+	_ = os.Remove(PidFile())
+	return false, 0
+}
@@ -0,0 +1,49 @@
+package health
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTrackerAlertsOnTransitions(t *testing.T) {
+	tr := NewTracker()
+	ok := Check{Name: "runtime:ollama", Status: StatusOK}
+	down := Check{Name: "runtime:ollama", Status: StatusFail, Detail: "unreachable"}
+	key := Check{Name: "key:openai", Status: StatusFail, Detail: "key rejected"}
+	offline := Check{Name: "key:openai", Status: StatusUnknown}
+
+	rounds := []struct {
+		checks []Check
+		want   []string // changed checks, "+" for recovered
+	}{
+		{[]Check{ok, key}, []string{"key:openai"}},
+		{[]Check{down, key}, []string{"runtime:ollama"}},
+		{[]Check{down, offline}, nil}, // still broken, key unknown: nothing new
+		{[]Check{ok, key}, []string{"+runtime:ollama"}},
+		{[]Check{ok, {Name: "key:openai", Status: StatusOK}}, []string{"+key:openai"}},
+	}
+	for i, r := range rounds {
+		var got []string
+		for _, c := range tr.Update(r.checks) {
+			if c.Recovered {
+				got = append(got, "+"+c.Name)
+			} else {
+				got = append(got, c.Name)
+			}
+		}
+		if strings.Join(got, ",") != strings.Join(r.want, ",") {
+			t.Errorf("round %d: changes = %v, want %v", i+1, got, r.want)
+		}
+	}
+}
+
+func TestSummary(t *testing.T) {
+	got := Summary([]Change{
+		{Check: Check{Name: "disk:/models", Status: StatusFail, Detail: "3.2 GB free"}},
+		{Check: Check{Name: "proxy", Status: StatusOK}, Recovered: true},
+	})
+	want := "palm health: broken: disk:/models (3.2 GB free); recovered: proxy"
+	if got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+}
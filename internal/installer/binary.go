@@ -0,0 +1,190 @@
+package installer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/msalah0e/palm/internal/registry"
+	"github.com/msalah0e/palm/internal/ui"
+)
+
+// downloadClient fetches release binaries; the timeout covers the whole
+// download, so it is generous.
+var downloadClient = &http.Client{Timeout: 10 * time.Minute}
+
+// installBinary downloads tool's release asset for this platform, verifies
+// its SHA256, unpacks the executable and installs it into registry.BinDir.
+// Progress goes to w.
+func installBinary(tool registry.Tool, w io.Writer) error {
+	asset, ok := tool.Install.Binary.Asset()
+	if !ok {
+		return fmt.Errorf("no release binary for %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
+	if asset.SHA256 == "" {
+		return fmt.Errorf("registry has no sha256 for %s — refusing to install an unverified binary", asset.URL)
+	}
+
+	fmt.Fprintf(w, "  Downloading %s\n", asset.URL)
+	data, err := download(asset.URL)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, asset.SHA256) {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", path.Base(asset.URL), strings.ToLower(asset.SHA256), got)
+	}
+	fmt.Fprintf(w, "  %s SHA256 verified\n", ui.StatusIcon(true))
+
+	name := tool.BinaryName()
+	exe, err := extractExecutable(asset.URL, data, name)
+	if err != nil {
+		return err
+	}
+
+	dir := registry.BinDir()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	dest := filepath.Join(dir, name)
+	// Write beside the destination and rename, so a running copy is
+	// replaced atomically rather than truncated
+	tmp := dest + ".palm-tmp"
+	if err := os.WriteFile(tmp, exe, 0o755); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	fmt.Fprintf(w, "  Installed %s\n", dest)
+
+	if !onPath(dir) {
+		fmt.Fprintf(w, "  %s %s is not on your PATH — add it to your shell rc:\n", ui.WarnIcon(), dir)
+		fmt.Fprintf(w, "    export PATH=\"%s:$PATH\"\n", dir)
+	}
+	if want := tool.Install.Binary.Version; want != "" {
+		if got := registry.Probe(tool).Version; got != "" && registry.CompareVersions(got, want) != 0 {
+			fmt.Fprintf(w, "  %s %s reports version %s, registry expects %s\n", ui.WarnIcon(), name, got, want)
+		}
+	}
+	return nil
+}
+
+// uninstallBinary removes an executable installed by installBinary.
+func uninstallBinary(tool registry.Tool, w io.Writer) error {
+	dest := filepath.Join(registry.BinDir(), tool.BinaryName())
+	if err := os.Remove(dest); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%s was not installed by palm", dest)
+		}
+		return err
+	}
+	fmt.Fprintf(w, "  Removed %s\n", dest)
+	return nil
+}
+
+func download(u string) ([]byte, error) {
+	resp, err := downloadClient.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", u, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// extractExecutable returns the executable called name from a downloaded
+// asset: the matching entry of a .tar.gz/.tgz or .zip archive, or the
+// download itself when it is not an archive.
+func extractExecutable(u string, data []byte, name string) ([]byte, error) {
+	base := strings.ToLower(path.Base(u))
+	switch {
+	case strings.HasSuffix(base, ".tar.gz"), strings.HasSuffix(base, ".tgz"):
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		tr := tar.NewReader(gz)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if hdr.Typeflag == tar.TypeReg && path.Base(hdr.Name) == name {
+				return io.ReadAll(tr)
+			}
+		}
+	case strings.HasSuffix(base, ".zip"):
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range zr.File {
+			if f.FileInfo().Mode().IsRegular() && path.Base(f.Name) == name {
+				rc, err := f.Open()
+				if err != nil {
+					return nil, err
+				}
+				defer rc.Close()
+				return io.ReadAll(rc)
+			}
+		}
+	default:
+		return data, nil
+	}
+	return nil, fmt.Errorf("%s not found in %s", name, path.Base(u))
+}
+
+// onPath reports whether dir is one of the directories on PATH.
+func onPath(dir string) bool {
+	for _, p := range filepath.SplitList(os.Getenv("PATH")) {
+		if filepath.Clean(p) == filepath.Clean(dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// binaryNotes describes what a binary install would do, for dry-run plans.
+func binaryNotes(action string, tool registry.Tool) []string {
+	dest := filepath.Join(registry.BinDir(), tool.BinaryName())
+	if action == ActionUninstall {
+		return []string{"removes " + dest}
+	}
+	asset, _ := tool.Install.Binary.Asset()
+	notes := []string{"downloads the release asset and installs it as " + dest}
+	if asset.SHA256 == "" {
+		notes = append(notes, "no sha256 in the registry — palm will refuse to install it")
+	} else {
+		notes = append(notes, "verifies SHA256 "+shortSum(asset.SHA256))
+	}
+	if !onPath(registry.BinDir()) {
+		notes = append(notes, registry.BinDir()+" is not on PATH")
+	}
+	return notes
+}
+
+func shortSum(sum string) string {
+	if len(sum) > 12 {
+		return sum[:12] + "…"
+	}
+	return sum
+}
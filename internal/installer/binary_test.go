@@ -0,0 +1,163 @@
+package installer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/msalah0e/palm/internal/registry"
+)
+
+const fakeExe = "#!/bin/sh\necho fake-tool 1.4.0\n"
+
+func tarGz(t *testing.T, name, body string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, f := range []struct{ name, body string }{{"README.md", "docs"}, {"fake-tool_v1/" + name, body}} {
+		if err := tw.WriteHeader(&tar.Header{Name: f.name, Mode: 0o755, Size: int64(len(f.body)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(tw, f.body)
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func zipped(t *testing.T, name, body string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("bin/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(w, body)
+	zw.Close()
+	return buf.Bytes()
+}
+
+func sum(b []byte) string {
+	s := sha256.Sum256(b)
+	return hex.EncodeToString(s[:])
+}
+
+func binaryTool(url, sha string) registry.Tool {
+	return registry.Tool{
+		Name: "fake-tool", DisplayName: "Fake Tool",
+		Install: registry.Install{
+			Binary: registry.Binary{
+				Version: "1.4.0",
+				Assets:  []registry.BinaryAsset{{OS: runtime.GOOS, Arch: runtime.GOARCH, URL: url, SHA256: sha}},
+			},
+			Verify: registry.Verify{Command: "fake-tool --version"},
+		},
+	}
+}
+
+func TestInstallBinary(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake executable is a shell script")
+	}
+	assets := map[string][]byte{
+		"/v1.4.0/fake-tool.tar.gz": tarGz(t, "fake-tool", fakeExe),
+		"/v1.4.0/fake-tool.zip":    zipped(t, "fake-tool", fakeExe),
+		"/v1.4.0/fake-tool":        []byte(fakeExe),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if b, ok := assets[r.URL.Path]; ok {
+			w.Write(b)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	for p, data := range assets {
+		t.Run(filepath.Base(p), func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			t.Setenv("PATH", "/usr/bin:/bin")
+
+			tool := binaryTool(srv.URL+strings.Replace(p, "1.4.0", "{version}", 1), sum(data))
+			if backend, pkg := tool.InstallMethod(); backend != "binary" || pkg != srv.URL+p {
+				t.Fatalf("InstallMethod() = %s %s", backend, pkg)
+			}
+			out, err := InstallQuiet(tool)
+			if err != nil {
+				t.Fatalf("install failed: %v\n%s", err, out)
+			}
+			dest := filepath.Join(home, ".local", "bin", "fake-tool")
+			if got, _ := os.ReadFile(dest); string(got) != fakeExe {
+				t.Errorf("installed %q", got)
+			}
+			if !strings.Contains(out, "SHA256 verified") || !strings.Contains(out, "not on your PATH") {
+				t.Errorf("missing verification or PATH hint:\n%s", out)
+			}
+			if dt := registry.DetectOne(tool); !dt.Installed || dt.Version != "1.4.0" || dt.Path != dest {
+				t.Errorf("detection off the PATH: %+v", dt)
+			}
+
+			if err := uninstallBinary(tool, io.Discard); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(dest); !os.IsNotExist(err) {
+				t.Error("uninstall left the binary behind")
+			}
+		})
+	}
+}
+
+func TestInstallBinaryRejectsBadChecksum(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, fakeExe)
+	}))
+	defer srv.Close()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	_, err := InstallQuiet(binaryTool(srv.URL+"/fake-tool", strings.Repeat("0", 64)))
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected checksum mismatch, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".local", "bin", "fake-tool")); !os.IsNotExist(err) {
+		t.Error("a binary failing verification must not be installed")
+	}
+
+	if _, err := InstallQuiet(binaryTool(srv.URL+"/fake-tool", "")); err == nil || !strings.Contains(err.Error(), "unverified") {
+		t.Errorf("assets without a checksum should be refused, got %v", err)
+	}
+}
+
+func TestPlanForBinary(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tool := binaryTool("https://example.com/v{version}/fake-tool_{os}_{arch}.tar.gz", strings.Repeat("ab", 32))
+	p := PlanFor(ActionInstall, tool)
+	want := "https://example.com/v1.4.0/fake-tool_" + runtime.GOOS + "_" + runtime.GOARCH + ".tar.gz"
+	if p.Backend != "binary" || p.Package != want || p.Error != "" || len(p.Command) != 0 {
+		t.Fatalf("unexpected plan: %+v", p)
+	}
+	if !strings.Contains(strings.Join(p.Notes, "\n"), "verifies SHA256 abababababab") {
+		t.Errorf("plan should mention the checksum: %v", p.Notes)
+	}
+
+	// No asset for this platform falls back to the next backend
+	tool.Install.Binary.Assets[0].OS = "plan9"
+	tool.Install.Pip = "fake-tool"
+	if backend, _ := tool.InstallMethod(); backend != "pip" {
+		t.Errorf("expected pip fallback, got %s", backend)
+	}
+}
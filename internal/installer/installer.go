@@ -1,6 +1,7 @@
 package installer
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"os/exec"
@@ -10,199 +11,191 @@ import (
 	"github.com/msalah0e/palm/internal/ui"
 )
 
+// Installer operations, used to resolve backend commands and plans.
+const (
+	ActionInstall   = "install"
+	ActionUpdate    = "update"
+	ActionUninstall = "uninstall"
+)
+
 // Install installs a tool using the best available backend.
 func Install(tool registry.Tool) error {
+	defer registry.ForgetDetection(tool.Name)
 	backend, pkg := tool.InstallMethod()
-	if backend == "manual" {
-		return fmt.Errorf("no automated install method — visit %s", pkg)
+	if backend == "binary" {
+		fmt.Printf("  Installing %s via binary (%s)...\n", ui.Brand.Sprint(tool.DisplayName), tool.Install.Binary.Version)
+		return installBinary(tool, os.Stdout)
+	}
+	args, err := Command(ActionInstall, backend, pkg)
+	if err != nil {
+		return err
 	}
 
 	fmt.Printf("  Installing %s via %s (%s)...\n", ui.Brand.Sprint(tool.DisplayName), backend, pkg)
-
-	switch backend {
-	case "linux":
-		return linuxInstall(pkg)
-	case "brew":
-		return brewInstall(pkg)
-	case "pip":
-		return pipInstall(pkg)
-	case "npm":
-		return npmInstall(pkg)
-	case "cargo":
-		return cargoInstall(pkg)
-	case "go":
-		return goInstall(pkg)
-	case "docker":
-		return dockerPull(pkg)
-	case "script":
-		return scriptInstall(pkg)
-	case "binary":
-		return fmt.Errorf("binary install not yet supported — download from %s", pkg)
-	default:
-		return fmt.Errorf("unknown backend: %s", backend)
-	}
+	return runCmd(args[0], args[1:]...)
 }
 
 // InstallQuiet installs a tool, capturing all output instead of printing it.
 // Returns the captured output (useful for showing on failure) and any error.
 // Used during parallel installs to prevent interleaved terminal output.
 func InstallQuiet(tool registry.Tool) (string, error) {
+	defer registry.ForgetDetection(tool.Name)
 	backend, pkg := tool.InstallMethod()
-	if backend == "manual" {
-		return "", fmt.Errorf("no automated install method — visit %s", pkg)
+	if backend == "binary" {
+		var out bytes.Buffer
+		err := installBinary(tool, &out)
+		return out.String(), err
 	}
-
-	switch backend {
-	case "linux":
-		return linuxInstallQuiet(pkg)
-	case "brew":
-		return runCmdQuiet("brew", "install", pkg)
-	case "pip":
-		return pipInstallQuiet(pkg)
-	case "npm":
-		return npmInstallQuiet(pkg)
-	case "cargo":
-		return cargoInstallQuiet(pkg)
-	case "go":
-		return goInstallQuiet(pkg)
-	case "docker":
-		return runCmdQuiet("docker", "pull", pkg)
-	case "script":
-		return scriptInstallQuiet(pkg)
-	case "binary":
-		return "", fmt.Errorf("binary install not yet supported — download from %s", pkg)
-	default:
-		return "", fmt.Errorf("unknown backend: %s", backend)
+	args, err := Command(ActionInstall, backend, pkg)
+	if err != nil {
+		return "", err
 	}
+	return runCmdQuiet(args[0], args[1:]...)
 }
 
 // Update updates a tool by re-running its install with upgrade flags.
 func Update(tool registry.Tool) error {
+	defer registry.ForgetDetection(tool.Name)
 	backend, pkg := tool.InstallMethod()
-
-	switch backend {
-	case "linux":
-		return linuxUpdate(pkg)
-	case "brew":
-		return runCmd("brew", "upgrade", pkg)
-	case "pip":
-		return pipUpdate(pkg)
-	case "npm":
-		return runCmd("npm", "update", "-g", pkg)
-	case "cargo":
-		return cargoInstall(pkg)
-	case "go":
-		return goInstall(pkg)
-	case "docker":
-		return dockerPull(pkg)
-	case "script":
-		return scriptInstall(pkg)
-	default:
-		return fmt.Errorf("cannot auto-update %s tools", backend)
+	if backend == "binary" {
+		// The registry pins the version, so updating is reinstalling it
+		return installBinary(tool, os.Stdout)
+	}
+	args, err := Command(ActionUpdate, backend, pkg)
+	if err != nil {
+		return err
 	}
+	return runCmd(args[0], args[1:]...)
 }
 
 // Uninstall removes a tool using its install backend.
 func Uninstall(tool registry.Tool) error {
+	defer registry.ForgetDetection(tool.Name)
 	backend, pkg := tool.InstallMethod()
+	if backend == "binary" {
+		fmt.Printf("  Removing %s...\n", ui.Brand.Sprint(tool.DisplayName))
+		return uninstallBinary(tool, os.Stdout)
+	}
+	args, err := Command(ActionUninstall, backend, pkg)
+	if err != nil {
+		return err
+	}
 
 	fmt.Printf("  Removing %s via %s...\n", ui.Brand.Sprint(tool.DisplayName), backend)
+	return runCmd(args[0], args[1:]...)
+}
 
+// Command resolves the exact command line the given backend runs for an action
+// on this machine. It is shared by real runs and dry-run plans so the plan
+// always matches what would execute. The binary backend runs no command;
+// palm downloads and installs release binaries itself.
+func Command(action, backend, pkg string) ([]string, error) {
 	switch backend {
+	case "manual":
+		return nil, fmt.Errorf("no automated install method — visit %s", pkg)
+	case "binary":
+		return nil, fmt.Errorf("binary releases are installed by palm, not a command")
 	case "linux":
-		return linuxUninstall(pkg)
+		return linuxCommand(action, pkg)
 	case "brew":
-		return runCmd("brew", "uninstall", pkg)
+		switch action {
+		case ActionInstall:
+			return []string{"brew", "install", pkg}, nil
+		case ActionUpdate:
+			return []string{"brew", "upgrade", pkg}, nil
+		case ActionUninstall:
+			return []string{"brew", "uninstall", pkg}, nil
+		}
 	case "pip":
-		return pipUninstall(pkg)
+		return pipCommand(action, pkg)
 	case "npm":
-		return runCmd("npm", "uninstall", "-g", pkg)
+		if action != ActionUninstall && !hasCommand("npm") {
+			return nil, fmt.Errorf("npm not found — install Node.js first")
+		}
+		switch action {
+		case ActionInstall:
+			return []string{"npm", "install", "-g", pkg}, nil
+		case ActionUpdate:
+			return []string{"npm", "update", "-g", pkg}, nil
+		case ActionUninstall:
+			return []string{"npm", "uninstall", "-g", pkg}, nil
+		}
+	case "cargo":
+		if action == ActionUninstall {
+			return nil, fmt.Errorf("cannot auto-uninstall %s tools", backend)
+		}
+		if !hasCommand("cargo") {
+			return nil, fmt.Errorf("cargo not found — install Rust first")
+		}
+		return []string{"cargo", "install", pkg}, nil
+	case "go":
+		if action == ActionUninstall {
+			return nil, fmt.Errorf("cannot auto-uninstall %s tools", backend)
+		}
+		if !hasCommand("go") {
+			return nil, fmt.Errorf("go not found — install Go first")
+		}
+		return []string{"go", "install", pkg}, nil
 	case "docker":
-		return runCmd("docker", "rmi", pkg)
+		if action == ActionUninstall {
+			return []string{"docker", "rmi", pkg}, nil
+		}
+		if !hasCommand("docker") {
+			return nil, fmt.Errorf("docker not found — install Docker first")
+		}
+		return []string{"docker", "pull", pkg}, nil
+	case "script":
+		if action == ActionUninstall {
+			return nil, fmt.Errorf("cannot auto-uninstall %s tools", backend)
+		}
+		if isRemoteScript(pkg) {
+			if !hasCommand("curl") {
+				return nil, fmt.Errorf("curl not found")
+			}
+			return []string{"sh", "-c", fmt.Sprintf("curl -fsSL %s | sh", pkg)}, nil
+		}
+		return []string{"sh", "-c", pkg}, nil
 	default:
-		return fmt.Errorf("cannot auto-uninstall %s tools", backend)
+		return nil, fmt.Errorf("unknown backend: %s", backend)
 	}
+	return nil, fmt.Errorf("unknown action: %s", action)
 }
 
-func brewInstall(pkg string) error {
-	return runCmd("brew", "install", pkg)
-}
-
-func pipInstall(pkg string) error {
-	if hasCommand("uv") {
-		return runCmd("uv", "tool", "install", pkg)
-	}
-	if hasCommand("pipx") {
-		return runCmd("pipx", "install", pkg)
-	}
-	if hasCommand("pip3") {
-		return runCmd("pip3", "install", pkg)
-	}
-	if hasCommand("pip") {
-		return runCmd("pip", "install", pkg)
-	}
-	return fmt.Errorf("no pip/uv/pipx found — install Python first")
-}
-
-func pipUpdate(pkg string) error {
-	if hasCommand("uv") {
-		return runCmd("uv", "tool", "upgrade", pkg)
-	}
-	if hasCommand("pipx") {
-		return runCmd("pipx", "upgrade", pkg)
-	}
-	if hasCommand("pip3") {
-		return runCmd("pip3", "install", "--upgrade", pkg)
-	}
-	return fmt.Errorf("no pip/uv/pipx found — install Python first")
-}
-
-func pipUninstall(pkg string) error {
-	if hasCommand("uv") {
-		return runCmd("uv", "tool", "uninstall", pkg)
-	}
-	if hasCommand("pipx") {
-		return runCmd("pipx", "uninstall", pkg)
-	}
-	return runCmd("pip3", "uninstall", "-y", pkg)
-}
-
-func npmInstall(pkg string) error {
-	if !hasCommand("npm") {
-		return fmt.Errorf("npm not found — install Node.js first")
-	}
-	return runCmd("npm", "install", "-g", pkg)
-}
-
-func cargoInstall(pkg string) error {
-	if !hasCommand("cargo") {
-		return fmt.Errorf("cargo not found — install Rust first")
-	}
-	return runCmd("cargo", "install", pkg)
-}
-
-func goInstall(pkg string) error {
-	if !hasCommand("go") {
-		return fmt.Errorf("go not found — install Go first")
-	}
-	return runCmd("go", "install", pkg)
-}
-
-func dockerPull(image string) error {
-	if !hasCommand("docker") {
-		return fmt.Errorf("docker not found — install Docker first")
-	}
-	return runCmd("docker", "pull", image)
-}
-
-func scriptInstall(script string) error {
-	if strings.HasPrefix(script, "http://") || strings.HasPrefix(script, "https://") {
-		if !hasCommand("curl") {
-			return fmt.Errorf("curl not found")
+func pipCommand(action, pkg string) ([]string, error) {
+	switch {
+	case hasCommand("uv"):
+		switch action {
+		case ActionInstall:
+			return []string{"uv", "tool", "install", pkg}, nil
+		case ActionUpdate:
+			return []string{"uv", "tool", "upgrade", pkg}, nil
+		case ActionUninstall:
+			return []string{"uv", "tool", "uninstall", pkg}, nil
 		}
-		return runCmd("sh", "-c", fmt.Sprintf("curl -fsSL %s | sh", script))
+	case hasCommand("pipx"):
+		switch action {
+		case ActionInstall:
+			return []string{"pipx", "install", pkg}, nil
+		case ActionUpdate:
+			return []string{"pipx", "upgrade", pkg}, nil
+		case ActionUninstall:
+			return []string{"pipx", "uninstall", pkg}, nil
+		}
+	case hasCommand("pip3"):
+		switch action {
+		case ActionInstall:
+			return []string{"pip3", "install", pkg}, nil
+		case ActionUpdate:
+			return []string{"pip3", "install", "--upgrade", pkg}, nil
+		case ActionUninstall:
+			return []string{"pip3", "uninstall", "-y", pkg}, nil
+		}
+	case hasCommand("pip") && action == ActionInstall:
+		return []string{"pip", "install", pkg}, nil
+	case action == ActionUninstall:
+		return []string{"pip3", "uninstall", "-y", pkg}, nil
 	}
-	return runCmd("sh", "-c", script)
+	return nil, fmt.Errorf("no pip/uv/pipx found — install Python first")
 }
 
 func detectLinuxPM() (string, error) {
@@ -218,52 +211,30 @@ func detectLinuxPM() (string, error) {
 	return "", fmt.Errorf("no supported package manager found (need apt-get, dnf, or pacman)")
 }
 
-func linuxInstall(pkg string) error {
+func linuxCommand(action, pkg string) ([]string, error) {
 	pm, err := detectLinuxPM()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	switch pm {
-	case "apt-get":
-		return runCmd("sudo", "apt-get", "install", "-y", pkg)
-	case "dnf":
-		return runCmd("sudo", "dnf", "install", "-y", pkg)
+	case "apt-get", "dnf":
+		verb := map[string]string{ActionInstall: "install", ActionUpdate: "upgrade", ActionUninstall: "remove"}[action]
+		return []string{"sudo", pm, verb, "-y", pkg}, nil
 	case "pacman":
-		return runCmd("sudo", "pacman", "-S", "--noconfirm", pkg)
+		switch action {
+		case ActionInstall:
+			return []string{"sudo", "pacman", "-S", "--noconfirm", pkg}, nil
+		case ActionUpdate:
+			return []string{"sudo", "pacman", "-Syu", "--noconfirm"}, nil
+		case ActionUninstall:
+			return []string{"sudo", "pacman", "-R", "--noconfirm", pkg}, nil
+		}
 	}
-	return fmt.Errorf("unsupported package manager: %s", pm)
+	return nil, fmt.Errorf("unsupported package manager: %s", pm)
 }
 
-func linuxUpdate(pkg string) error {
-	pm, err := detectLinuxPM()
-	if err != nil {
-		return err
-	}
-	switch pm {
-	case "apt-get":
-		return runCmd("sudo", "apt-get", "upgrade", "-y", pkg)
-	case "dnf":
-		return runCmd("sudo", "dnf", "upgrade", "-y", pkg)
-	case "pacman":
-		return runCmd("sudo", "pacman", "-Syu", "--noconfirm")
-	}
-	return fmt.Errorf("unsupported package manager: %s", pm)
-}
-
-func linuxUninstall(pkg string) error {
-	pm, err := detectLinuxPM()
-	if err != nil {
-		return err
-	}
-	switch pm {
-	case "apt-get":
-		return runCmd("sudo", "apt-get", "remove", "-y", pkg)
-	case "dnf":
-		return runCmd("sudo", "dnf", "remove", "-y", pkg)
-	case "pacman":
-		return runCmd("sudo", "pacman", "-R", "--noconfirm", pkg)
-	}
-	return fmt.Errorf("unsupported package manager: %s", pm)
+func isRemoteScript(script string) bool {
+	return strings.HasPrefix(script, "http://") || strings.HasPrefix(script, "https://")
 }
 
 func hasCommand(name string) bool {
@@ -285,66 +256,3 @@ func runCmdQuiet(name string, args ...string) (string, error) {
 	out, err := cmd.CombinedOutput()
 	return string(out), err
 }
-
-func pipInstallQuiet(pkg string) (string, error) {
-	if hasCommand("uv") {
-		return runCmdQuiet("uv", "tool", "install", pkg)
-	}
-	if hasCommand("pipx") {
-		return runCmdQuiet("pipx", "install", pkg)
-	}
-	if hasCommand("pip3") {
-		return runCmdQuiet("pip3", "install", pkg)
-	}
-	if hasCommand("pip") {
-		return runCmdQuiet("pip", "install", pkg)
-	}
-	return "", fmt.Errorf("no pip/uv/pipx found — install Python first")
-}
-
-func npmInstallQuiet(pkg string) (string, error) {
-	if !hasCommand("npm") {
-		return "", fmt.Errorf("npm not found — install Node.js first")
-	}
-	return runCmdQuiet("npm", "install", "-g", pkg)
-}
-
-func cargoInstallQuiet(pkg string) (string, error) {
-	if !hasCommand("cargo") {
-		return "", fmt.Errorf("cargo not found — install Rust first")
-	}
-	return runCmdQuiet("cargo", "install", pkg)
-}
-
-func goInstallQuiet(pkg string) (string, error) {
-	if !hasCommand("go") {
-		return "", fmt.Errorf("go not found — install Go first")
-	}
-	return runCmdQuiet("go", "install", pkg)
-}
-
-func scriptInstallQuiet(script string) (string, error) {
-	if strings.HasPrefix(script, "http://") || strings.HasPrefix(script, "https://") {
-		if !hasCommand("curl") {
-			return "", fmt.Errorf("curl not found")
-		}
-		return runCmdQuiet("sh", "-c", fmt.Sprintf("curl -fsSL %s | sh", script))
-	}
-	return runCmdQuiet("sh", "-c", script)
-}
-
-func linuxInstallQuiet(pkg string) (string, error) {
-	pm, err := detectLinuxPM()
-	if err != nil {
-		return "", err
-	}
-	switch pm {
-	case "apt-get":
-		return runCmdQuiet("sudo", "apt-get", "install", "-y", pkg)
-	case "dnf":
-		return runCmdQuiet("sudo", "dnf", "install", "-y", pkg)
-	case "pacman":
-		return runCmdQuiet("sudo", "pacman", "-S", "--noconfirm", pkg)
-	}
-	return "", fmt.Errorf("unsupported package manager: %s", pm)
-}
@@ -0,0 +1,157 @@
+package installer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/msalah0e/palm/internal/registry"
+)
+
+// Plan describes what an install, update or uninstall would do, without doing it.
+type Plan struct {
+	Tool     string   `json:"tool"`
+	Action   string   `json:"action"`
+	Backend  string   `json:"backend"`
+	Package  string   `json:"package"`
+	Command  []string `json:"command,omitempty"`
+	Sudo     bool     `json:"sudo"`
+	Download int64    `json:"download_bytes,omitempty"` // 0 when unknown
+	Notes    []string `json:"notes,omitempty"`
+	Error    string   `json:"error,omitempty"` // why the action cannot run
+}
+
+// CommandLine renders the plan's command as it would be typed in a shell.
+func (p Plan) CommandLine() string {
+	parts := make([]string, len(p.Command))
+	for i, a := range p.Command {
+		if strings.ContainsAny(a, " |'\"$&;") {
+			a = "'" + strings.ReplaceAll(a, "'", `'\''`) + "'"
+		}
+		parts[i] = a
+	}
+	return strings.Join(parts, " ")
+}
+
+// PlanFor resolves the backend command for action on tool without running it.
+// Download sizes are not looked up; see EstimateDownload.
+func PlanFor(action string, tool registry.Tool) Plan {
+	backend, pkg := tool.InstallMethod()
+	p := Plan{Tool: tool.Name, Action: action, Backend: backend, Package: pkg}
+
+	if backend == "binary" {
+		p.Notes = append(binaryNotes(action, tool), setupNotes(action, tool)...)
+		return p
+	}
+	args, err := Command(action, backend, pkg)
+	if err != nil {
+		p.Error = err.Error()
+		return p
+	}
+	p.Command = args
+	p.Sudo = args[0] == "sudo"
+
+	switch backend {
+	case "script":
+		if isRemoteScript(pkg) {
+			p.Notes = append(p.Notes, "downloads and runs a remote script — review it before running")
+		}
+	case "brew":
+		if action != ActionUninstall {
+			p.Notes = append(p.Notes, "Homebrew may also install or upgrade dependencies")
+		}
+	case "pip":
+		if args[0] == "pip3" || args[0] == "pip" {
+			if action != ActionUninstall {
+				p.Notes = append(p.Notes, "installs into the active Python environment (no uv/pipx found)")
+			}
+		}
+	case "linux":
+		if strings.Contains(strings.Join(args, " "), "-Syu") {
+			p.Notes = append(p.Notes, "pacman upgrades the whole system, not just this package")
+		}
+	}
+	p.Notes = append(p.Notes, setupNotes(action, tool)...)
+	return p
+}
+
+func setupNotes(action string, tool registry.Tool) []string {
+	if steps := tool.SetupSteps(); action == ActionInstall && len(steps) > 0 {
+		return []string{fmt.Sprintf("then offers %d setup step(s); rerun them with `palm setup %s`", len(steps), tool.Name)}
+	}
+	return nil
+}
+
+var pkgNameRe = regexp.MustCompile(`^(@?[A-Za-z0-9._/\-]+)`)
+
+// EstimateDownload looks up the published package size for pip and npm
+// packages, and the asset size for release binaries. Dependencies are not
+// included. Returns 0 when unknown.
+func EstimateDownload(backend, pkg string) int64 {
+	if backend == "binary" {
+		resp, err := (&http.Client{Timeout: 5 * time.Second}).Head(pkg)
+		if err != nil {
+			return 0
+		}
+		resp.Body.Close()
+		return max(resp.ContentLength, 0)
+	}
+
+	name := pkgNameRe.FindString(pkg)
+	if name == "" {
+		return 0
+	}
+	// npm scoped packages keep their leading @; strip a trailing @version
+	if i := strings.LastIndex(name, "@"); i > 0 {
+		name = name[:i]
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	switch backend {
+	case "pip":
+		var meta struct {
+			URLs []struct {
+				PackageType string `json:"packagetype"`
+				Size        int64  `json:"size"`
+			} `json:"urls"`
+		}
+		if err := getJSON(client, "https://pypi.org/pypi/"+url.PathEscape(name)+"/json", &meta); err != nil {
+			return 0
+		}
+		var size int64
+		for _, u := range meta.URLs {
+			if u.PackageType == "bdist_wheel" {
+				return u.Size
+			}
+			size = u.Size
+		}
+		return size
+	case "npm":
+		var meta struct {
+			Dist struct {
+				UnpackedSize int64 `json:"unpackedSize"`
+			} `json:"dist"`
+		}
+		if err := getJSON(client, "https://registry.npmjs.org/"+name+"/latest", &meta); err != nil {
+			return 0
+		}
+		return meta.Dist.UnpackedSize
+	}
+	return 0
+}
+
+func getJSON(client *http.Client, u string, v interface{}) error {
+	resp, err := client.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", u, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,74 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/msalah0e/palm/internal/registry"
+)
+
+func TestPlanForManualTool(t *testing.T) {
+	tool := registry.Tool{Name: "web-only", Homepage: "https://example.com"}
+	p := PlanFor(ActionInstall, tool)
+	if p.Backend != "manual" || p.Error == "" || len(p.Command) != 0 {
+		t.Errorf("manual tool should produce an error plan, got %+v", p)
+	}
+}
+
+func TestPlanForBrew(t *testing.T) {
+	tool := registry.Tool{Name: "llm", Install: registry.Install{Brew: "llm"}}
+	p := PlanFor(ActionUninstall, tool)
+	if p.CommandLine() != "brew uninstall llm" || p.Sudo {
+		t.Errorf("unexpected plan: %+v", p)
+	}
+}
+
+func TestCommandLineQuoting(t *testing.T) {
+	p := Plan{Command: []string{"sh", "-c", "curl -fsSL https://x.sh | sh"}}
+	if got, want := p.CommandLine(), "sh -c 'curl -fsSL https://x.sh | sh'"; got != want {
+		t.Errorf("CommandLine() = %q, want %q", got, want)
+	}
+}
+
+func TestMissingPrerequisite(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	npmTool := registry.Tool{Name: "x", Install: registry.Install{Npm: "x-cli"}}
+	p := MissingPrerequisite(npmTool)
+	if p == nil || p.Name != "node" {
+		t.Fatalf("expected node prerequisite, got %+v", p)
+	}
+	if p.CanInstall() {
+		t.Error("prerequisite should not be installable with an empty PATH")
+	}
+
+	scriptTool := registry.Tool{Name: "y", Install: registry.Install{Script: "echo hi"}}
+	if p := MissingPrerequisite(scriptTool); p != nil {
+		t.Errorf("script backend has no prerequisite, got %+v", p)
+	}
+}
+
+func TestCheckRequirements(t *testing.T) {
+	bin := t.TempDir()
+	script := "#!/bin/sh\necho fakebin 1.4.2\n"
+	if err := os.WriteFile(filepath.Join(bin, "fakebin"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+
+	tool := registry.Tool{Name: "x", Requires: []string{"fakebin>=1.2", "fakebin>=2", "nothere"}}
+	unmet, err := CheckRequirements(tool, registry.New(nil))
+	if err != nil {
+		t.Fatalf("CheckRequirements failed: %v", err)
+	}
+	if len(unmet) != 2 {
+		t.Fatalf("expected 2 unmet requirements, got %+v", unmet)
+	}
+	if unmet[0].Missing || unmet[0].Found != "1.4.2" {
+		t.Errorf("expected fakebin found at 1.4.2, got %+v", unmet[0])
+	}
+	if !unmet[1].Missing {
+		t.Errorf("expected nothere to be missing, got %+v", unmet[1])
+	}
+}
@@ -0,0 +1,193 @@
+package installer
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/msalah0e/palm/internal/registry"
+)
+
+// Prerequisite is a toolchain an install backend needs before it can run.
+type Prerequisite struct {
+	Name     string        // short id, e.g. "node"
+	Label    string        // human name, e.g. "Node.js"
+	Commands []string      // any one of these on PATH satisfies the backend
+	Version  []string      // command printing the toolchain version
+	Aliases  []string      // other names accepted in requires, e.g. "nodejs"
+	Tool     registry.Tool // how palm installs it; manual when not automatable
+}
+
+// prerequisites maps install backends to the toolchain they need.
+var prerequisites = map[string]Prerequisite{
+	"npm": {
+		Name: "node", Label: "Node.js", Commands: []string{"npm"},
+		Version: []string{"node", "--version"}, Aliases: []string{"nodejs", "npm"},
+		Tool: registry.Tool{Name: "node", DisplayName: "Node.js", Homepage: "https://nodejs.org",
+			Install: registry.Install{Brew: "node", Linux: "npm"}},
+	},
+	"pip": {
+		Name: "python", Label: "Python", Commands: []string{"uv", "pipx", "pip3", "pip"},
+		Version: []string{"python3", "--version"}, Aliases: []string{"python3"},
+		Tool: registry.Tool{Name: "python", DisplayName: "Python", Homepage: "https://www.python.org/downloads/",
+			Install: registry.Install{Brew: "pipx", Linux: "python3-pip"}},
+	},
+	"cargo": {
+		Name: "rust", Label: "Rust", Commands: []string{"cargo"},
+		Version: []string{"cargo", "--version"}, Aliases: []string{"cargo"},
+		Tool: registry.Tool{Name: "rust", DisplayName: "Rust", Homepage: "https://rustup.rs",
+			Install: registry.Install{Brew: "rust", Linux: "cargo"}},
+	},
+	"go": {
+		Name: "go", Label: "Go", Commands: []string{"go"},
+		Version: []string{"go", "version"}, Aliases: []string{"golang"},
+		Tool: registry.Tool{Name: "go", DisplayName: "Go", Homepage: "https://go.dev/dl/",
+			Install: registry.Install{Brew: "go", Linux: "golang"}},
+	},
+	"docker": {
+		Name: "docker", Label: "Docker", Commands: []string{"docker"},
+		Version: []string{"docker", "--version"},
+		Tool:    registry.Tool{Name: "docker", DisplayName: "Docker", Homepage: "https://docs.docker.com/get-docker/"},
+	},
+}
+
+// MissingPrerequisite returns the toolchain tool's backend needs but cannot
+// find on PATH, or nil when the backend is ready to run.
+func MissingPrerequisite(tool registry.Tool) *Prerequisite {
+	backend, _ := tool.InstallMethod()
+	p, ok := prerequisites[backend]
+	if !ok {
+		return nil
+	}
+	for _, c := range p.Commands {
+		if hasCommand(c) {
+			return nil
+		}
+	}
+	return &p
+}
+
+// LookupPrerequisite finds a toolchain by the name used in a requires entry.
+func LookupPrerequisite(name string) *Prerequisite {
+	for _, p := range prerequisites {
+		if p.Name == name {
+			return &p
+		}
+		for _, a := range p.Aliases {
+			if a == name {
+				return &p
+			}
+		}
+	}
+	return nil
+}
+
+// CanInstall reports whether palm can install the prerequisite itself.
+func (p Prerequisite) CanInstall() bool {
+	backend, pkg := p.Tool.InstallMethod()
+	args, err := Command(ActionInstall, backend, pkg)
+	return err == nil && hasCommand(args[0])
+}
+
+// InstalledVersion returns the toolchain's version and whether it is on
+// PATH. The version is "" when it is installed but does not report one.
+func (p Prerequisite) InstalledVersion() (string, bool) {
+	return commandVersion(p.Version...)
+}
+
+// Unmet is a requires entry the current machine does not satisfy.
+type Unmet struct {
+	Requirement registry.Requirement
+	Found       string         // installed version; empty when missing
+	Missing     bool           // nothing satisfying the name is installed
+	Prereq      *Prerequisite  // toolchain that provides it, if known
+	Tool        *registry.Tool // registry tool that provides it, if known
+}
+
+func (u Unmet) String() string {
+	if u.Missing {
+		return fmt.Sprintf("requires %s (not installed)", u.Requirement)
+	}
+	return fmt.Sprintf("requires %s (found %s)", u.Requirement, u.Found)
+}
+
+// CheckRequirements evaluates tool's requires list. Names resolve to a known
+// toolchain, then a registry tool, then any command on PATH.
+func CheckRequirements(tool registry.Tool, reg *registry.Registry) ([]Unmet, error) {
+	var unmet []Unmet
+	for _, raw := range tool.Requires {
+		req, err := registry.ParseRequirement(raw)
+		if err != nil {
+			return unmet, fmt.Errorf("%s: %w", tool.Name, err)
+		}
+
+		u := Unmet{Requirement: req}
+		var version string
+		var installed bool
+		switch {
+		case LookupPrerequisite(req.Name) != nil:
+			u.Prereq = LookupPrerequisite(req.Name)
+			version, installed = u.Prereq.InstalledVersion()
+		case reg != nil && reg.Get(req.Name) != nil:
+			u.Tool = reg.Get(req.Name)
+			dt := registry.DetectOne(*u.Tool)
+			version, installed = dt.Version, dt.Installed
+		default:
+			version, installed = commandVersion(req.Name, "--version")
+		}
+
+		if !installed {
+			u.Missing = true
+			unmet = append(unmet, u)
+			continue
+		}
+		if !req.SatisfiedBy(version) {
+			u.Found = version
+			if u.Found == "" {
+				u.Found = "unknown version"
+			}
+			unmet = append(unmet, u)
+		}
+	}
+	return unmet, nil
+}
+
+// InstalledConflicts returns installed registry tools that conflict with tool,
+// whether declared by tool itself or by the other tool.
+func InstalledConflicts(tool registry.Tool, reg *registry.Registry) []string {
+	candidates := make(map[string]bool)
+	for _, name := range tool.Conflicts {
+		candidates[name] = true
+	}
+	for _, other := range reg.All() {
+		for _, name := range other.Conflicts {
+			if name == tool.Name {
+				candidates[other.Name] = true
+			}
+		}
+	}
+
+	var found []string
+	for name := range candidates {
+		if name == tool.Name {
+			continue
+		}
+		if other := reg.Get(name); other != nil && registry.DetectOne(*other).Installed {
+			found = append(found, name)
+		}
+	}
+	return found
+}
+
+// commandVersion runs a version command, returning the extracted version and
+// whether the binary exists at all.
+func commandVersion(args ...string) (string, bool) {
+	if len(args) == 0 || !hasCommand(args[0]) {
+		return "", false
+	}
+	out, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		return "", true
+	}
+	return registry.ExtractVersion(strings.TrimSpace(string(out))), true
+}
@@ -0,0 +1,97 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/msalah0e/palm/internal/registry"
+)
+
+// ShellName returns the user's login shell, "bash", "zsh" or "fish", or ""
+// when it is another shell or unknown.
+func ShellName() string {
+	switch name := filepath.Base(os.Getenv("SHELL")); name {
+	case "bash", "zsh", "fish":
+		return name
+	}
+	return ""
+}
+
+// RCFile returns the startup file of shell, or "" for unknown shells.
+func RCFile(shell string) string {
+	home, _ := os.UserHomeDir()
+	switch shell {
+	case "bash":
+		return filepath.Join(home, ".bashrc")
+	case "zsh":
+		return filepath.Join(home, ".zshrc")
+	case "fish":
+		return filepath.Join(home, ".config", "fish", "config.fish")
+	}
+	return ""
+}
+
+// ShellLine returns a step's rc file line for shell.
+func ShellLine(s registry.Setup, shell string) string {
+	return strings.ReplaceAll(s.Shell, "{shell}", shell)
+}
+
+// StepDone reports whether a setup step needs no work: its check command
+// succeeds, or its shell line is already in the rc file.
+func StepDone(s registry.Setup) bool {
+	if s.Check != "" {
+		return shellCommand(s.Check).Run() == nil
+	}
+	if s.Shell != "" {
+		shell := ShellName()
+		data, err := os.ReadFile(RCFile(shell))
+		return err == nil && strings.Contains(string(data), ShellLine(s, shell))
+	}
+	return false
+}
+
+// RunStep runs a tool's setup step: its command, then its shell line,
+// which is appended to the rc file of the user's shell.
+func RunStep(tool string, s registry.Setup) error {
+	if s.Run != "" {
+		c := shellCommand(s.Run)
+		c.Stdout, c.Stderr, c.Stdin = os.Stdout, os.Stderr, os.Stdin
+		if err := c.Run(); err != nil {
+			return err
+		}
+	}
+	if s.Shell == "" {
+		return nil
+	}
+
+	shell := ShellName()
+	if shell == "" {
+		return fmt.Errorf("unsupported shell %q — add this line to your shell's startup file: %s", os.Getenv("SHELL"), ShellLine(s, "bash"))
+	}
+	rc := RCFile(shell)
+	line := ShellLine(s, shell)
+	if data, err := os.ReadFile(rc); err == nil && strings.Contains(string(data), line) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(rc), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(rc, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "\n# added by palm setup %s\n%s\n", tool, line)
+	return err
+}
+
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
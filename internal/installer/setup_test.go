@@ -0,0 +1,70 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/msalah0e/palm/internal/registry"
+)
+
+func TestStepDoneCheck(t *testing.T) {
+	if !StepDone(registry.Setup{Check: "true"}) {
+		t.Error("a passing check means the step is done")
+	}
+	if StepDone(registry.Setup{Check: "false"}) {
+		t.Error("a failing check means the step is not done")
+	}
+	if StepDone(registry.Setup{Run: "true"}) {
+		t.Error("a step without a check is never done")
+	}
+}
+
+func TestRunStepShellLine(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("SHELL", "/bin/zsh")
+
+	step := registry.Setup{Description: "completions", Shell: `eval "$(tool completion {shell})"`}
+	if StepDone(step) {
+		t.Fatal("step should not be done before it runs")
+	}
+	for i := 0; i < 2; i++ {
+		if err := RunStep("tool", step); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(home, ".zshrc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), `eval "$(tool completion zsh)"`); n != 1 {
+		t.Errorf("line added %d times:\n%s", n, data)
+	}
+	if !strings.Contains(string(data), "# added by palm setup tool") {
+		t.Errorf("missing marker comment:\n%s", data)
+	}
+	if !StepDone(step) {
+		t.Error("step should be done once its line is in the rc file")
+	}
+
+	t.Setenv("SHELL", "/bin/tcsh")
+	if err := RunStep("tool", step); err == nil || !strings.Contains(err.Error(), "tool completion bash") {
+		t.Errorf("unsupported shell should explain the line to add, got %v", err)
+	}
+}
+
+func TestRunStepCommand(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "ran")
+	if err := RunStep("tool", registry.Setup{Run: "echo ok > " + out}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(out); strings.TrimSpace(string(data)) != "ok" {
+		t.Errorf("command did not run: %q", data)
+	}
+	if err := RunStep("tool", registry.Setup{Run: "exit 3"}); err == nil {
+		t.Error("a failing command should return an error")
+	}
+}
@@ -13,15 +13,16 @@ import (
 
 // Server represents an MCP server in the registry.
 type Server struct {
-	Name        string `json:"name"`
-	Display     string `json:"display"`
-	Description string `json:"description"`
-	Command     string `json:"command"`
-	Args        []string `json:"args"`
-	Install     string `json:"install"`
-	Backend     string `json:"backend"`
-	URL         string `json:"url"`
-	Category    string `json:"category"`
+	Name        string   `json:"name" toml:"name"`
+	Display     string   `json:"display" toml:"display"`
+	Description string   `json:"description" toml:"description"`
+	Command     string   `json:"command" toml:"command"`
+	Args        []string `json:"args" toml:"args"`
+	Install     string   `json:"install" toml:"install"`
+	Backend     string   `json:"backend" toml:"backend"`
+	URL         string   `json:"url" toml:"url"`
+	Category    string   `json:"category" toml:"category"`
+	Source      string   `json:"source" toml:"-"` // built-in, remote or custom
 }
 
 // ToolConfig represents how a specific AI tool stores MCP configuration.
@@ -31,35 +32,12 @@ type ToolConfig struct {
 	Format   string // "json-servers", "json-mcp"
 }
 
-// Registry is the built-in list of popular MCP servers.
-var Registry = []Server{
-	{Name: "filesystem", Display: "Filesystem", Description: "Read/write local files", Command: "npx", Args: []string{"-y", "@modelcontextprotocol/server-filesystem"}, Install: "npm install -g @modelcontextprotocol/server-filesystem", Backend: "npm", Category: "Core"},
-	{Name: "postgres", Display: "PostgreSQL", Description: "Query PostgreSQL databases", Command: "npx", Args: []string{"-y", "@modelcontextprotocol/server-postgres"}, Install: "npm install -g @modelcontextprotocol/server-postgres", Backend: "npm", Category: "Database"},
-	{Name: "sqlite", Display: "SQLite", Description: "Query SQLite databases", Command: "npx", Args: []string{"-y", "@modelcontextprotocol/server-sqlite"}, Install: "npm install -g @modelcontextprotocol/server-sqlite", Backend: "npm", Category: "Database"},
-	{Name: "github", Display: "GitHub", Description: "GitHub repos, issues, PRs", Command: "npx", Args: []string{"-y", "@modelcontextprotocol/server-github"}, Install: "npm install -g @modelcontextprotocol/server-github", Backend: "npm", Category: "Dev"},
-	{Name: "gitlab", Display: "GitLab", Description: "GitLab repos and pipelines", Command: "npx", Args: []string{"-y", "@modelcontextprotocol/server-gitlab"}, Install: "npm install -g @modelcontextprotocol/server-gitlab", Backend: "npm", Category: "Dev"},
-	{Name: "slack", Display: "Slack", Description: "Read/send Slack messages", Command: "npx", Args: []string{"-y", "@modelcontextprotocol/server-slack"}, Install: "npm install -g @modelcontextprotocol/server-slack", Backend: "npm", Category: "Communication"},
-	{Name: "memory", Display: "Memory", Description: "Persistent knowledge graph", Command: "npx", Args: []string{"-y", "@modelcontextprotocol/server-memory"}, Install: "npm install -g @modelcontextprotocol/server-memory", Backend: "npm", Category: "Core"},
-	{Name: "brave-search", Display: "Brave Search", Description: "Web search via Brave", Command: "npx", Args: []string{"-y", "@modelcontextprotocol/server-brave-search"}, Install: "npm install -g @modelcontextprotocol/server-brave-search", Backend: "npm", Category: "Search"},
-	{Name: "puppeteer", Display: "Puppeteer", Description: "Browser automation", Command: "npx", Args: []string{"-y", "@modelcontextprotocol/server-puppeteer"}, Install: "npm install -g @modelcontextprotocol/server-puppeteer", Backend: "npm", Category: "Browser"},
-	{Name: "playwright", Display: "Playwright", Description: "Browser automation", Command: "npx", Args: []string{"-y", "@anthropic/mcp-server-playwright"}, Install: "npm install -g @anthropic/mcp-server-playwright", Backend: "npm", Category: "Browser"},
-	{Name: "fetch", Display: "Fetch", Description: "HTTP fetching", Command: "npx", Args: []string{"-y", "@modelcontextprotocol/server-fetch"}, Install: "npm install -g @modelcontextprotocol/server-fetch", Backend: "npm", Category: "Core"},
-	{Name: "sentry", Display: "Sentry", Description: "Error tracking and monitoring", Command: "npx", Args: []string{"-y", "@modelcontextprotocol/server-sentry"}, Install: "npm install -g @modelcontextprotocol/server-sentry", Backend: "npm", Category: "Monitoring"},
-	{Name: "sequential-thinking", Display: "Sequential Thinking", Description: "Step-by-step reasoning", Command: "npx", Args: []string{"-y", "@modelcontextprotocol/server-sequential-thinking"}, Install: "npm install -g @modelcontextprotocol/server-sequential-thinking", Backend: "npm", Category: "Reasoning"},
-	{Name: "context7", Display: "Context7", Description: "Up-to-date library documentation", Command: "npx", Args: []string{"-y", "@upstash/context7-mcp"}, Install: "npm install -g @upstash/context7-mcp", Backend: "npm", Category: "Docs"},
-	{Name: "redis", Display: "Redis", Description: "Redis database operations", Command: "npx", Args: []string{"-y", "@modelcontextprotocol/server-redis"}, Install: "npm install -g @modelcontextprotocol/server-redis", Backend: "npm", Category: "Database"},
-	{Name: "docker", Display: "Docker", Description: "Docker container management", Command: "npx", Args: []string{"-y", "@modelcontextprotocol/server-docker"}, Install: "npm install -g @modelcontextprotocol/server-docker", Backend: "npm", Category: "Infra"},
-	{Name: "kubernetes", Display: "Kubernetes", Description: "K8s cluster management", Command: "npx", Args: []string{"-y", "@modelcontextprotocol/server-kubernetes"}, Install: "npm install -g @modelcontextprotocol/server-kubernetes", Backend: "npm", Category: "Infra"},
-	{Name: "google-maps", Display: "Google Maps", Description: "Maps and geocoding", Command: "npx", Args: []string{"-y", "@modelcontextprotocol/server-google-maps"}, Install: "npm install -g @modelcontextprotocol/server-google-maps", Backend: "npm", Category: "API"},
-	{Name: "stripe", Display: "Stripe", Description: "Stripe payments API", Command: "npx", Args: []string{"-y", "@stripe/mcp"}, Install: "npm install -g @stripe/mcp", Backend: "npm", Category: "API"},
-	{Name: "firebase", Display: "Firebase", Description: "Firebase services", Command: "npx", Args: []string{"-y", "firebase-mcp"}, Install: "npm install -g firebase-mcp", Backend: "npm", Category: "Cloud"},
-}
-
 // GetServer returns a server by name.
 func GetServer(name string) *Server {
-	for i := range Registry {
-		if Registry[i].Name == name {
-			return &Registry[i]
+	servers := Servers()
+	for i := range servers {
+		if servers[i].Name == name {
+			return &servers[i]
 		}
 	}
 	return nil
@@ -69,7 +47,7 @@ func GetServer(name string) *Server {
 func Search(query string) []Server {
 	q := strings.ToLower(query)
 	var results []Server
-	for _, s := range Registry {
+	for _, s := range Servers() {
 		if strings.Contains(strings.ToLower(s.Name), q) ||
 			strings.Contains(strings.ToLower(s.Description), q) ||
 			strings.Contains(strings.ToLower(s.Category), q) {
@@ -82,7 +60,7 @@ func Search(query string) []Server {
 // Categories returns sorted unique categories.
 func Categories() []string {
 	seen := make(map[string]bool)
-	for _, s := range Registry {
+	for _, s := range Servers() {
 		seen[s.Category] = true
 	}
 	cats := make([]string, 0, len(seen))
@@ -167,3 +145,138 @@ func ListInstalled() []string {
 	sort.Strings(names)
 	return names
 }
+
+// ConfigFor returns the MCP config location of the named tool, if it has one.
+func ConfigFor(tool string) (ToolConfig, bool) {
+	for _, tc := range ToolConfigs() {
+		if tc.Name == tool {
+			return tc, true
+		}
+	}
+	return ToolConfig{}, false
+}
+
+// ConfiguredServers lists the MCP servers configured in tc, sorted.
+func ConfiguredServers(tc ToolConfig) ([]string, error) {
+	config, err := readConfig(tc.Path)
+	if err != nil {
+		return nil, err
+	}
+	servers := serverMap(config, tc.Format)
+	names := make([]string, 0, len(servers))
+	for name := range servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// RemoveServers deletes every MCP server entry from tc, leaving the rest of
+// the file as it was. It returns the names it removed.
+func RemoveServers(tc ToolConfig) ([]string, error) {
+	names, err := ConfiguredServers(tc)
+	if err != nil || len(names) == 0 {
+		return nil, err
+	}
+	config, err := readConfig(tc.Path)
+	if err != nil {
+		return nil, err
+	}
+	if tc.Format == "json-mcp" {
+		if mcpSection, ok := config["mcp"].(map[string]interface{}); ok {
+			delete(mcpSection, "servers")
+		}
+	} else {
+		delete(config, "mcpServers")
+	}
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(tc.Path, append(data, '\n'), 0644); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// AddServers configures servers in tc, keeping entries already there and
+// the rest of the file as it was. It returns the names it added.
+func AddServers(tc ToolConfig, servers []Server) ([]string, error) {
+	config, err := readConfig(tc.Path)
+	if err != nil {
+		return nil, err
+	}
+	section := serverMap(config, tc.Format)
+	if section == nil {
+		section = make(map[string]interface{})
+	}
+	var added []string
+	for _, s := range servers {
+		if _, ok := section[s.Name]; ok {
+			continue
+		}
+		entry := map[string]interface{}{}
+		if s.URL != "" && s.Command == "" {
+			entry["url"] = s.URL
+		} else {
+			entry["command"] = s.Command
+			if len(s.Args) > 0 {
+				entry["args"] = s.Args
+			}
+		}
+		section[s.Name] = entry
+		added = append(added, s.Name)
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+
+	if tc.Format == "json-mcp" {
+		mcpSection, _ := config["mcp"].(map[string]interface{})
+		if mcpSection == nil {
+			mcpSection = make(map[string]interface{})
+			config["mcp"] = mcpSection
+		}
+		mcpSection["servers"] = section
+	} else {
+		config["mcpServers"] = section
+	}
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(tc.Path), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(tc.Path, append(data, '\n'), 0644); err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+func readConfig(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return make(map[string]interface{}), nil
+		}
+		return nil, err
+	}
+	config := make(map[string]interface{})
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+// serverMap returns the server section of a config: "mcpServers" at the top
+// level, or "mcp.servers" in VS Code settings.
+func serverMap(config map[string]interface{}, format string) map[string]interface{} {
+	if format == "json-mcp" {
+		section, _ := config["mcp"].(map[string]interface{})
+		servers, _ := section["servers"].(map[string]interface{})
+		return servers
+	}
+	servers, _ := config["mcpServers"].(map[string]interface{})
+	return servers
+}
@@ -0,0 +1,239 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ServerSpec is how an AI tool's config launches an MCP server.
+type ServerSpec struct {
+	Name    string            `json:"name"`
+	Command string            `json:"command,omitempty"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	URL     string            `json:"url,omitempty"` // remote (HTTP/SSE) servers
+}
+
+// ConfiguredSpecs returns the launch settings of every server in tc's config.
+func ConfiguredSpecs(tc ToolConfig) ([]ServerSpec, error) {
+	config, err := readConfig(tc.Path)
+	if err != nil {
+		return nil, err
+	}
+	var specs []ServerSpec
+	for name, raw := range serverMap(config, tc.Format) {
+		data, _ := json.Marshal(raw)
+		spec := ServerSpec{Name: name}
+		if err := json.Unmarshal(data, &spec); err != nil {
+			continue
+		}
+		spec.Name = name
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Name < specs[j].Name })
+	return specs, nil
+}
+
+// ProbeResult is what an MCP server reported during the handshake.
+type ProbeResult struct {
+	OK        bool          `json:"ok"`
+	Server    string        `json:"server,omitempty"` // serverInfo.name
+	Version   string        `json:"version,omitempty"`
+	Protocol  string        `json:"protocol,omitempty"`
+	Tools     []string      `json:"tools"`
+	Resources []string      `json:"resources"`
+	Startup   time.Duration `json:"startup_ns"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// protocolVersion is the MCP revision palm speaks in the handshake.
+const protocolVersion = "2024-11-05"
+
+// Probe starts a stdio server, performs the MCP initialize handshake, lists
+// its tools and resources, and stops it. Startup is the time to the
+// initialize response.
+func Probe(spec ServerSpec, timeout time.Duration) ProbeResult {
+	var res ProbeResult
+	if spec.Command == "" {
+		res.Error = "no command (remote servers are not probed)"
+		return res
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, spec.Command, spec.Args...)
+	// npx and uvx run the server as a child that can outlive a kill
+	cmd.WaitDelay = 2 * time.Second
+	cmd.Env = os.Environ()
+	for k, v := range spec.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	defer func() {
+		stdin.Close()
+		cancel()
+		_ = cmd.Wait()
+	}()
+
+	rpc := &rpcConn{w: stdin, r: bufio.NewReader(stdout)}
+	fail := func(err error) ProbeResult {
+		res.Error = err.Error()
+		if ctx.Err() != nil {
+			res.Error = fmt.Sprintf("no response within %s", timeout)
+		}
+		// Stop the server so its stderr is complete
+		cancel()
+		_ = cmd.Wait()
+		if msg := lastLine(stderr.String()); msg != "" {
+			res.Error += ": " + msg
+		}
+		return res
+	}
+
+	var init struct {
+		ProtocolVersion string `json:"protocolVersion"`
+		Capabilities    struct {
+			Tools     json.RawMessage `json:"tools"`
+			Resources json.RawMessage `json:"resources"`
+		} `json:"capabilities"`
+		ServerInfo struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"serverInfo"`
+	}
+	err = rpc.call("initialize", map[string]interface{}{
+		"protocolVersion": protocolVersion,
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      map[string]string{"name": "palm", "version": "doctor"},
+	}, &init)
+	if err != nil {
+		return fail(err)
+	}
+	res.Startup = time.Since(start)
+	res.Server, res.Version, res.Protocol = init.ServerInfo.Name, init.ServerInfo.Version, init.ProtocolVersion
+	if err := rpc.notify("notifications/initialized"); err != nil {
+		return fail(err)
+	}
+
+	res.Tools, res.Resources = []string{}, []string{}
+	if len(init.Capabilities.Tools) > 0 {
+		var list struct {
+			Tools []struct {
+				Name string `json:"name"`
+			} `json:"tools"`
+		}
+		if err := rpc.call("tools/list", map[string]interface{}{}, &list); err != nil {
+			return fail(fmt.Errorf("tools/list: %w", err))
+		}
+		for _, t := range list.Tools {
+			res.Tools = append(res.Tools, t.Name)
+		}
+	}
+	if len(init.Capabilities.Resources) > 0 {
+		var list struct {
+			Resources []struct {
+				Name string `json:"name"`
+				URI  string `json:"uri"`
+			} `json:"resources"`
+		}
+		// Resources are optional to list; a failure here isn't fatal
+		if err := rpc.call("resources/list", map[string]interface{}{}, &list); err == nil {
+			for _, r := range list.Resources {
+				res.Resources = append(res.Resources, orString(r.Name, r.URI))
+			}
+		}
+	}
+	res.OK = true
+	return res
+}
+
+// rpcConn speaks newline-delimited JSON-RPC, the MCP stdio transport.
+type rpcConn struct {
+	w      io.Writer
+	r      *bufio.Reader
+	nextID int
+}
+
+func (c *rpcConn) call(method string, params interface{}, result interface{}) error {
+	c.nextID++
+	id := c.nextID
+	if err := c.send(map[string]interface{}{"jsonrpc": "2.0", "id": id, "method": method, "params": params}); err != nil {
+		return err
+	}
+	for {
+		line, err := c.r.ReadBytes('\n')
+		if err != nil {
+			if err == io.EOF {
+				return fmt.Errorf("server exited before answering %s", method)
+			}
+			return err
+		}
+		var msg struct {
+			ID     *int            `json:"id"`
+			Method string          `json:"method"`
+			Result json.RawMessage `json:"result"`
+			Error  *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(bytes.TrimSpace(line), &msg) != nil || msg.ID == nil || *msg.ID != id || msg.Method != "" {
+			// Log lines and server notifications or requests
+			continue
+		}
+		if msg.Error != nil {
+			return fmt.Errorf("%s: %s", method, msg.Error.Message)
+		}
+		return json.Unmarshal(msg.Result, result)
+	}
+}
+
+func (c *rpcConn) notify(method string) error {
+	return c.send(map[string]interface{}{"jsonrpc": "2.0", "method": method})
+}
+
+func (c *rpcConn) send(msg interface{}) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = c.w.Write(append(data, '\n'))
+	return err
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+func orString(a, b string) string {
+	if a != "" {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,160 @@
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestFakeServer is not a real test: Probe runs the test binary with
+// PALM_FAKE_MCP set, and it acts as an MCP server over stdio.
+func TestFakeServer(t *testing.T) {
+	mode := os.Getenv("PALM_FAKE_MCP")
+	if mode == "" {
+		return
+	}
+	switch mode {
+	case "crash":
+		fmt.Fprintln(os.Stderr, "Error: GITHUB_TOKEN is not set")
+		os.Exit(1)
+	case "hang":
+		time.Sleep(time.Minute)
+		os.Exit(0)
+	}
+
+	fmt.Println("starting fake server") // stray log lines must be ignored
+	in := bufio.NewScanner(os.Stdin)
+	for in.Scan() {
+		var req struct {
+			ID     *int   `json:"id"`
+			Method string `json:"method"`
+		}
+		json.Unmarshal(in.Bytes(), &req)
+		if req.ID == nil {
+			continue
+		}
+		var result interface{}
+		switch req.Method {
+		case "initialize":
+			result = map[string]interface{}{
+				"protocolVersion": "2024-11-05",
+				"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}, "resources": map[string]interface{}{}},
+				"serverInfo":      map[string]string{"name": "fake", "version": "1.2.3"},
+			}
+		case "tools/list":
+			result = map[string]interface{}{"tools": []map[string]string{{"name": "search"}, {"name": "fetch"}}}
+		case "resources/list":
+			result = map[string]interface{}{"resources": []map[string]string{{"uri": "file:///readme"}}}
+		}
+		out, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": *req.ID, "result": result})
+		fmt.Println(string(out))
+	}
+	os.Exit(0)
+}
+
+func fakeSpec(mode string) ServerSpec {
+	return ServerSpec{
+		Name:    "fake",
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^TestFakeServer$"},
+		Env:     map[string]string{"PALM_FAKE_MCP": mode},
+	}
+}
+
+func TestProbe(t *testing.T) {
+	res := Probe(fakeSpec("ok"), 10*time.Second)
+	if !res.OK {
+		t.Fatalf("probe failed: %s", res.Error)
+	}
+	if res.Server != "fake" || res.Version != "1.2.3" || res.Protocol != "2024-11-05" {
+		t.Errorf("server info = %+v", res)
+	}
+	if strings.Join(res.Tools, ",") != "search,fetch" {
+		t.Errorf("tools = %v", res.Tools)
+	}
+	if len(res.Resources) != 1 || res.Resources[0] != "file:///readme" {
+		t.Errorf("resources = %v", res.Resources)
+	}
+	if res.Startup <= 0 {
+		t.Error("startup time not recorded")
+	}
+}
+
+func TestProbeFailures(t *testing.T) {
+	res := Probe(fakeSpec("crash"), 10*time.Second)
+	if res.OK || !strings.Contains(res.Error, "GITHUB_TOKEN is not set") {
+		t.Errorf("crash: %+v, want the server's stderr in the error", res)
+	}
+
+	res = Probe(fakeSpec("hang"), 500*time.Millisecond)
+	if res.OK || !strings.Contains(res.Error, "no response within") {
+		t.Errorf("hang: %+v", res)
+	}
+
+	res = Probe(ServerSpec{Name: "missing", Command: "palm-no-such-mcp-server"}, time.Second)
+	if res.OK || res.Error == "" {
+		t.Errorf("missing command: %+v", res)
+	}
+}
+
+func TestConfiguredSpecs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	config := `{"mcp": {"servers": {
+		"github": {"command": "npx", "args": ["-y", "@modelcontextprotocol/server-github"], "env": {"GITHUB_TOKEN": "x"}},
+		"remote": {"url": "https://mcp.example.com/sse"}
+	}}}`
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	specs, err := ConfiguredSpecs(ToolConfig{Name: "vscode", Path: path, Format: "json-mcp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(specs) != 2 || specs[0].Name != "github" || specs[0].Env["GITHUB_TOKEN"] != "x" || specs[1].URL == "" {
+		t.Errorf("specs = %+v", specs)
+	}
+}
+
+func TestAddServers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "claude", "settings.json")
+	tc := ToolConfig{Name: "claude-code", Path: path, Format: "json-servers"}
+	servers := []Server{
+		{Name: "github", Command: "npx", Args: []string{"-y", "@modelcontextprotocol/server-github"}},
+		{Name: "remote", URL: "https://mcp.example.com/sse"},
+	}
+
+	added, err := AddServers(tc, servers)
+	if err != nil || len(added) != 2 {
+		t.Fatalf("AddServers = %v, %v", added, err)
+	}
+	specs, _ := ConfiguredSpecs(tc)
+	if len(specs) != 2 || specs[0].Command != "npx" || specs[1].URL == "" {
+		t.Errorf("specs = %+v", specs)
+	}
+
+	// Existing entries, including edited ones, are left alone
+	if err := os.WriteFile(path, []byte(`{"theme": "dark", "mcpServers": {"github": {"command": "gh-mcp"}}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if added, _ := AddServers(tc, servers); len(added) != 1 || added[0] != "remote" {
+		t.Errorf("added = %v, want [remote]", added)
+	}
+	config, _ := readConfig(path)
+	specs, _ = ConfiguredSpecs(tc)
+	if config["theme"] != "dark" || specs[0].Command != "gh-mcp" {
+		t.Errorf("config rewritten: %v", config)
+	}
+
+	vsc := ToolConfig{Name: "vscode", Path: filepath.Join(t.TempDir(), "settings.json"), Format: "json-mcp"}
+	if _, err := AddServers(vsc, servers[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if names, _ := ConfiguredServers(vsc); len(names) != 1 || names[0] != "github" {
+		t.Errorf("vscode servers = %v", names)
+	}
+}
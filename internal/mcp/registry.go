@@ -0,0 +1,136 @@
+package mcp
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/msalah0e/palm/internal/config"
+)
+
+//go:embed servers.toml
+var builtinServers []byte
+
+// DefaultRegistryURL is where `palm mcp refresh` fetches the registry from.
+const DefaultRegistryURL = "https://raw.githubusercontent.com/msalah0e/palm/main/internal/mcp/servers.toml"
+
+type serverFile struct {
+	Servers []Server `toml:"servers"`
+}
+
+var (
+	loadOnce sync.Once
+	servers  []Server
+)
+
+// Servers returns the MCP server registry: the built-in list, updated by the
+// last `palm mcp refresh`, with custom entries from mcp-servers.toml on top.
+// Later layers replace earlier entries of the same name.
+func Servers() []Server {
+	loadOnce.Do(func() {
+		servers = loadServers()
+	})
+	return servers
+}
+
+// CachePath is where the refreshed registry is stored.
+func CachePath() string {
+	return filepath.Join(config.ConfigDir(), "cache", "mcp-servers.toml")
+}
+
+// CustomPath is the user's file of extra or corrected servers.
+func CustomPath() string {
+	return filepath.Join(config.ConfigDir(), "mcp-servers.toml")
+}
+
+func loadServers() []Server {
+	list, _ := parseServers(builtinServers, "built-in")
+	for _, layer := range []struct{ path, source string }{{CachePath(), "remote"}, {CustomPath(), "custom"}} {
+		data, err := os.ReadFile(layer.path)
+		if err != nil {
+			continue
+		}
+		extra, err := parseServers(data, layer.source)
+		if err != nil {
+			continue
+		}
+		list = overlayServers(list, extra)
+	}
+	return list
+}
+
+func parseServers(data []byte, source string) ([]Server, error) {
+	var f serverFile
+	if err := toml.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	for i := range f.Servers {
+		s := &f.Servers[i]
+		if s.Name == "" || (s.Command == "" && s.URL == "") {
+			return nil, fmt.Errorf("server #%d needs a name and a command or url", i+1)
+		}
+		if s.Display == "" {
+			s.Display = s.Name
+		}
+		s.Source = source
+	}
+	return f.Servers, nil
+}
+
+// overlayServers replaces entries of base with same-named ones from top, in
+// place, and appends the rest.
+func overlayServers(base, top []Server) []Server {
+	index := make(map[string]int, len(base))
+	for i, s := range base {
+		index[s.Name] = i
+	}
+	out := append([]Server(nil), base...)
+	for _, s := range top {
+		if i, ok := index[s.Name]; ok {
+			out[i] = s
+			continue
+		}
+		index[s.Name] = len(out)
+		out = append(out, s)
+	}
+	return out
+}
+
+// Refresh downloads the registry from url, checks it parses, and caches it
+// for later runs. It returns the number of servers fetched.
+func Refresh(url string) (int, error) {
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("%s returned %d", resp.Request.URL.Host, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return 0, err
+	}
+	list, err := parseServers(data, "remote")
+	if err != nil {
+		return 0, fmt.Errorf("invalid registry: %w", err)
+	}
+	if len(list) == 0 {
+		return 0, fmt.Errorf("invalid registry: no servers")
+	}
+	if err := os.MkdirAll(filepath.Dir(CachePath()), 0o755); err != nil {
+		return 0, err
+	}
+	if err := os.WriteFile(CachePath(), data, 0o644); err != nil {
+		return 0, err
+	}
+	servers = loadServers()
+	return len(list), nil
+}
@@ -0,0 +1,102 @@
+package mcp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuiltinServersParse(t *testing.T) {
+	list, err := parseServers(builtinServers, "built-in")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) == 0 {
+		t.Fatal("no built-in servers")
+	}
+	seen := map[string]bool{}
+	for _, s := range list {
+		if seen[s.Name] {
+			t.Errorf("duplicate server %q", s.Name)
+		}
+		seen[s.Name] = true
+	}
+}
+
+func TestCustomServersOverrideBuiltin(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if err := os.MkdirAll(filepath.Dir(CustomPath()), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	custom := `
+[[servers]]
+name = "github"
+description = "GitHub (official server)"
+command = "docker"
+args = ["run", "-i", "ghcr.io/github/github-mcp-server"]
+
+[[servers]]
+name = "linear"
+command = "npx"
+args = ["-y", "mcp-remote", "https://mcp.linear.app/sse"]
+`
+	if err := os.WriteFile(CustomPath(), []byte(custom), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	builtin, _ := parseServers(builtinServers, "built-in")
+	list := loadServers()
+	if len(list) != len(builtin)+1 {
+		t.Fatalf("got %d servers, want %d", len(list), len(builtin)+1)
+	}
+	byName := map[string]Server{}
+	for _, s := range list {
+		byName[s.Name] = s
+	}
+	if gh := byName["github"]; gh.Command != "docker" || gh.Source != "custom" {
+		t.Errorf("github = %+v, want the custom entry", gh)
+	}
+	if l := byName["linear"]; l.Display != "linear" || l.Source != "custom" {
+		t.Errorf("linear = %+v", l)
+	}
+	if fs := byName["filesystem"]; fs.Source != "built-in" {
+		t.Errorf("filesystem source = %q", fs.Source)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/good.toml":
+			w.Write([]byte("[[servers]]\nname = \"playwright\"\ncommand = \"npx\"\nargs = [\"-y\", \"@playwright/mcp@next\"]\n"))
+		case "/bad.toml":
+			w.Write([]byte("[[servers]]\ndescription = \"no name\"\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	if _, err := Refresh(srv.URL + "/bad.toml"); err == nil {
+		t.Error("a registry with invalid entries should be rejected")
+	}
+	if _, err := Refresh(srv.URL + "/missing.toml"); err == nil {
+		t.Error("a 404 should fail")
+	}
+	if _, err := os.Stat(CachePath()); !os.IsNotExist(err) {
+		t.Fatal("failed refreshes must not write the cache")
+	}
+
+	n, err := Refresh(srv.URL + "/good.toml")
+	if err != nil || n != 1 {
+		t.Fatalf("Refresh = %d, %v", n, err)
+	}
+	for _, s := range loadServers() {
+		if s.Name == "playwright" && (s.Source != "remote" || s.Args[1] != "@playwright/mcp@next") {
+			t.Errorf("playwright = %+v, want the refreshed entry", s)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Package mocktools installs fake AI tool executables for hermetic tests.
+//
+// Each mock is a small shell script that records how it was called, waits a
+// fixed latency, prints a canned answer and exits with a chosen code. With
+// the mocks' directory first on PATH, palm squad, speedtest, eval and compose
+// run end to end without models, network or API keys.
+package mocktools
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// PromptPlaceholder in an output is replaced with the prompt the mock got:
+// its last argument, or stdin when it has no arguments.
+const PromptPlaceholder = "{prompt}"
+
+// CallsFile is the log every mock in a directory appends its calls to.
+const CallsFile = "calls.log"
+
+// Spec describes one mock tool.
+type Spec struct {
+	Name     string        `json:"name"`
+	Output   string        `json:"output"`
+	Stderr   string        `json:"stderr,omitempty"`
+	Latency  time.Duration `json:"latency"`
+	ExitCode int           `json:"exit_code"`
+}
+
+// DefaultNames are the tools mocked when none are named: the ones squad,
+// speedtest, eval and the sample compose workflow use.
+var DefaultNames = []string{"ollama", "aider"}
+
+// Default returns a spec for name that answers "<name>: <prompt>" at once
+// and succeeds.
+func Default(name string) Spec {
+	return Spec{Name: name, Output: name + ": " + PromptPlaceholder}
+}
+
+// Install writes an executable mock for each spec into dir, creating it if
+// needed, and starts a fresh call log.
+func Install(dir string, specs []Spec) error {
+	if runtime.GOOS == "windows" {
+		return fmt.Errorf("mock tools are shell scripts and need a Unix shell")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	for _, s := range specs {
+		if s.Name == "" || strings.ContainsAny(s.Name, `/\`) {
+			return fmt.Errorf("invalid mock tool name %q", s.Name)
+		}
+		if s.ExitCode < 0 || s.ExitCode > 255 {
+			return fmt.Errorf("%s: exit code must be 0-255", s.Name)
+		}
+		if err := os.WriteFile(filepath.Join(abs, s.Name), []byte(script(s, abs)), 0o755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(filepath.Join(abs, CallsFile), nil, 0o644)
+}
+
+// PathWith returns a PATH value with dir ahead of path.
+func PathWith(dir, path string) string {
+	if path == "" {
+		return dir
+	}
+	return dir + string(os.PathListSeparator) + path
+}
+
+// Call is one recorded invocation of a mock.
+type Call struct {
+	Tool  string `json:"tool"`
+	Args  string `json:"args"`
+	Input string `json:"input,omitempty"` // what it read on stdin
+}
+
+// Calls returns the invocations recorded in dir, oldest first.
+func Calls(dir string) ([]Call, error) {
+	f, err := os.Open(filepath.Join(dir, CallsFile))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var calls []Call
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		fields := strings.SplitN(sc.Text(), "\t", 3)
+		for len(fields) < 3 {
+			fields = append(fields, "")
+		}
+		calls = append(calls, Call{Tool: fields[0], Args: fields[1], Input: fields[2]})
+	}
+	return calls, sc.Err()
+}
+
+// script renders the shell script for s. Calls are logged one per line as
+// tool, arguments and stdin, with tabs and newlines flattened to spaces.
+func script(s Spec, dir string) string {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	fmt.Fprintf(&b, "# palm mock tool: %s (palm dev mock-tools)\n", s.Name)
+	b.WriteString("input=\nif [ ! -t 0 ]; then input=$(cat); fi\n")
+	b.WriteString("prompt=$input\nfor a in \"$@\"; do prompt=$a; done\n")
+	b.WriteString("flat() { printf '%s' \"$1\" | tr '\\t\\n' '  '; }\n")
+	fmt.Fprintf(&b, "printf '%%s\\t%%s\\t%%s\\n' %s \"$(flat \"$*\")\" \"$(flat \"$input\")\" >> %s\n",
+		shellQuote(s.Name), shellQuote(filepath.Join(dir, CallsFile)))
+	if s.Latency > 0 {
+		fmt.Fprintf(&b, "sleep %s\n", seconds(s.Latency))
+	}
+	if s.Stderr != "" {
+		fmt.Fprintf(&b, "printf '%%s\\n' %s >&2\n", shellQuote(s.Stderr))
+	}
+	if s.Output != "" {
+		parts := strings.Split(s.Output, PromptPlaceholder)
+		for i, p := range parts {
+			if i > 0 {
+				b.WriteString("printf '%s' \"$prompt\"\n")
+			}
+			if p != "" {
+				fmt.Fprintf(&b, "printf '%%s' %s\n", shellQuote(p))
+			}
+		}
+		b.WriteString("echo\n")
+	}
+	fmt.Fprintf(&b, "exit %d\n", s.ExitCode)
+	return b.String()
+}
+
+// seconds formats d for sleep(1), which takes fractions on Linux and macOS.
+func seconds(d time.Duration) string {
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.3f", d.Seconds()), "0"), ".")
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
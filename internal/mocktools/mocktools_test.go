@@ -0,0 +1,80 @@
+package mocktools
+
+import (
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInstall(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("mock tools need a Unix shell")
+	}
+	dir := t.TempDir()
+	specs := []Spec{
+		Default("ollama"),
+		{Name: "aider", Output: "it's done\nACCURACY: 90", Stderr: "warming up", Latency: 50 * time.Millisecond, ExitCode: 3},
+	}
+	if err := Install(dir, specs); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := exec.Command(filepath.Join(dir, "ollama"), "run", "llama3.3", "why is\nthe sky blue?").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "ollama: why is\nthe sky blue?\n" {
+		t.Errorf("ollama output = %q", out)
+	}
+
+	c := exec.Command(filepath.Join(dir, "aider"))
+	c.Stdin = strings.NewReader("from stdin")
+	var stdout, stderr strings.Builder
+	c.Stdout, c.Stderr = &stdout, &stderr
+	start := time.Now()
+	err = c.Run()
+	if exit, ok := err.(*exec.ExitError); !ok || exit.ExitCode() != 3 {
+		t.Errorf("aider exit = %v", err)
+	}
+	if time.Since(start) < 50*time.Millisecond {
+		t.Error("aider should wait its latency")
+	}
+	if stdout.String() != "it's done\nACCURACY: 90\n" || stderr.String() != "warming up\n" {
+		t.Errorf("aider stdout %q, stderr %q", stdout.String(), stderr.String())
+	}
+
+	calls, err := Calls(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 2 || calls[0].Tool != "ollama" || calls[0].Args != "run llama3.3 why is the sky blue?" || calls[1].Tool != "aider" || calls[1].Args != "" || calls[1].Input != "from stdin" {
+		t.Errorf("calls = %+v", calls)
+	}
+
+	// Reinstalling starts a fresh log
+	if err := Install(dir, specs[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if calls, _ := Calls(dir); len(calls) != 0 {
+		t.Errorf("calls after reinstall = %+v", calls)
+	}
+
+	if err := Install(dir, []Spec{{Name: "../x"}}); err == nil {
+		t.Error("names with slashes should be rejected")
+	}
+}
+
+func TestSeconds(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		2 * time.Second:         "2",
+		250 * time.Millisecond:  "0.25",
+		1500 * time.Millisecond: "1.5",
+	} {
+		if got := seconds(d); got != want {
+			t.Errorf("seconds(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package models
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCatalogURL is the maintained pricing and context-window list
+// `palm models refresh` reads. Entries are keyed by model ID, with costs per
+// token.
+const DefaultCatalogURL = "https://raw.githubusercontent.com/BerriAI/litellm/main/model_prices_and_context_window.json"
+
+// maxCatalogSize bounds the download; the upstream file is a few MB.
+const maxCatalogSize = 32 << 20
+
+// Catalog is a refreshed snapshot of prices and context windows for the
+// built-in models.
+type Catalog struct {
+	Source    string    `json:"source"`
+	FetchedAt time.Time `json:"fetched_at"`
+	Checksum  string    `json:"sha256"`
+	Models    []Model   `json:"models"`
+}
+
+// catalogProviders maps upstream provider names to palm's.
+var catalogProviders = map[string]string{
+	"openai":    "openai",
+	"anthropic": "anthropic",
+	"gemini":    "google",
+	"groq":      "groq",
+	"mistral":   "mistral",
+}
+
+func catalogDir() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "palm")
+}
+
+func catalogPath() string     { return filepath.Join(catalogDir(), "models.json") }
+func prevCatalogPath() string { return filepath.Join(catalogDir(), "models.prev.json") }
+
+var (
+	catalogOnce   sync.Once
+	cachedCatalog *Catalog
+)
+
+// LoadCatalog returns the cached catalog, or nil before the first refresh.
+func LoadCatalog() *Catalog {
+	return readCatalog(catalogPath())
+}
+
+// LoadPreviousCatalog returns the catalog the last refresh replaced, or nil.
+func LoadPreviousCatalog() *Catalog {
+	return readCatalog(prevCatalogPath())
+}
+
+func readCatalog(path string) *Catalog {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var c Catalog
+	if json.Unmarshal(data, &c) != nil {
+		return nil
+	}
+	return &c
+}
+
+// applyCatalog overlays cached prices and context windows on providers.
+func applyCatalog(providers []Provider) []Provider {
+	catalogOnce.Do(func() { cachedCatalog = LoadCatalog() })
+	if cachedCatalog == nil {
+		return providers
+	}
+	byID := make(map[string]Model, len(cachedCatalog.Models))
+	for _, m := range cachedCatalog.Models {
+		byID[m.Provider+"/"+m.ID] = m
+	}
+	for i := range providers {
+		for j := range providers[i].Models {
+			m := &providers[i].Models[j]
+			if c, ok := byID[m.Provider+"/"+m.ID]; ok {
+				if c.InputCost > 0 || c.OutputCost > 0 {
+					m.InputCost, m.OutputCost = c.InputCost, c.OutputCost
+				}
+				if c.Context > 0 {
+					m.Context = c.Context
+				}
+			}
+		}
+	}
+	return providers
+}
+
+// BuiltinModels returns the models as shipped, ignoring any refresh.
+func BuiltinModels() []Model {
+	var all []Model
+	for _, p := range builtinProviders() {
+		all = append(all, p.Models...)
+	}
+	return all
+}
+
+// ParseCatalog reads the upstream catalog format and keeps the entries for
+// built-in models.
+func ParseCatalog(data []byte) ([]Model, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("catalog is not valid JSON: %w", err)
+	}
+
+	known := make(map[string]bool)
+	for _, m := range BuiltinModels() {
+		known[m.Provider+"/"+m.ID] = true
+	}
+
+	var out []Model
+	for key, msg := range raw {
+		var e struct {
+			Provider       string  `json:"litellm_provider"`
+			MaxInputTokens int     `json:"max_input_tokens"`
+			MaxTokens      int     `json:"max_tokens"`
+			InputPerToken  float64 `json:"input_cost_per_token"`
+			OutputPerToken float64 `json:"output_cost_per_token"`
+		}
+		if json.Unmarshal(msg, &e) != nil {
+			continue
+		}
+		provider, ok := catalogProviders[e.Provider]
+		if !ok {
+			continue
+		}
+		// Keys may carry a provider prefix, e.g. "gemini/gemini-2.5-flash"
+		id := key
+		if i := strings.LastIndex(id, "/"); i >= 0 {
+			id = id[i+1:]
+		}
+		if !known[provider+"/"+id] {
+			continue
+		}
+		ctx := e.MaxInputTokens
+		if ctx == 0 {
+			ctx = e.MaxTokens
+		}
+		out = append(out, Model{
+			ID:         id,
+			Provider:   provider,
+			Context:    ctx,
+			InputCost:  e.InputPerToken * 1e6,
+			OutputCost: e.OutputPerToken * 1e6,
+		})
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("catalog has no entries for palm's models")
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Provider != out[j].Provider {
+			return out[i].Provider < out[j].Provider
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+// Refresh downloads the catalog from url and caches it, keeping the catalog
+// it replaces for Diff. When wantSum is set the download must have that
+// SHA-256. changed is false when the download matches the cached checksum.
+func Refresh(ctx context.Context, client *http.Client, url, wantSum string) (c *Catalog, changed bool, err error) {
+	if client == nil {
+		client = &http.Client{Timeout: time.Minute}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCatalogSize+1))
+	if err != nil {
+		return nil, false, err
+	}
+	if len(data) > maxCatalogSize {
+		return nil, false, fmt.Errorf("catalog is larger than %d MB", maxCatalogSize>>20)
+	}
+
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+	if wantSum != "" && !strings.EqualFold(wantSum, checksum) {
+		return nil, false, fmt.Errorf("checksum mismatch: got %s, want %s", checksum, wantSum)
+	}
+
+	old := LoadCatalog()
+	if old != nil && old.Checksum == checksum {
+		return old, false, nil
+	}
+
+	parsed, err := ParseCatalog(data)
+	if err != nil {
+		return nil, false, err
+	}
+	c = &Catalog{Source: url, FetchedAt: time.Now(), Checksum: checksum, Models: parsed}
+
+	if err := os.MkdirAll(catalogDir(), 0o755); err != nil {
+		return nil, false, err
+	}
+	if old != nil {
+		_ = os.Rename(catalogPath(), prevCatalogPath())
+	}
+	out, _ := json.MarshalIndent(c, "", "  ")
+	if err := os.WriteFile(catalogPath(), out, 0o644); err != nil {
+		return nil, false, err
+	}
+	return c, true, nil
+}
+
+// Change is one difference between two catalog snapshots.
+type Change struct {
+	Provider string  `json:"provider"`
+	ID       string  `json:"id"`
+	Field    string  `json:"field"` // input_cost, output_cost or context
+	Old      float64 `json:"old"`
+	New      float64 `json:"new"`
+}
+
+// Diff lists price and context changes for models present in both old and
+// new.
+func Diff(old, new []Model) []Change {
+	before := make(map[string]Model, len(old))
+	for _, m := range old {
+		before[m.Provider+"/"+m.ID] = m
+	}
+	var changes []Change
+	for _, m := range new {
+		o, ok := before[m.Provider+"/"+m.ID]
+		if !ok {
+			continue
+		}
+		add := func(field string, a, b float64) {
+			// Ignore float noise from per-token conversion
+			if b != 0 && (a-b > 1e-9 || b-a > 1e-9) {
+				changes = append(changes, Change{Provider: m.Provider, ID: m.ID, Field: field, Old: a, New: b})
+			}
+		}
+		add("input_cost", o.InputCost, m.InputCost)
+		add("output_cost", o.OutputCost, m.OutputCost)
+		add("context", float64(o.Context), float64(m.Context))
+	}
+	return changes
+}
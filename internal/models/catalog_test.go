@@ -0,0 +1,77 @@
+package models
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+const sampleCatalog = `{
+  "sample_spec": {"litellm_provider": "openai or cohere"},
+  "gpt-4o": {"litellm_provider": "openai", "max_input_tokens": 128000, "input_cost_per_token": 2e-06, "output_cost_per_token": 8e-06},
+  "gemini/gemini-2.5-flash": {"litellm_provider": "gemini", "max_input_tokens": 1048576, "input_cost_per_token": 3e-07, "output_cost_per_token": 2.5e-06},
+  "gpt-unknown": {"litellm_provider": "openai", "input_cost_per_token": 1e-06},
+  "command-r": {"litellm_provider": "cohere_chat", "input_cost_per_token": 1e-06}
+}`
+
+func TestParseCatalog(t *testing.T) {
+	got, err := ParseCatalog([]byte(sampleCatalog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected only built-in models, got %+v", got)
+	}
+	if got[0].Provider != "google" || got[0].ID != "gemini-2.5-flash" {
+		t.Errorf("provider prefix should be stripped and mapped: %+v", got[0])
+	}
+	if got[1].ID != "gpt-4o" || got[1].Context != 128000 || got[1].InputCost < 1.999 || got[1].InputCost > 2.001 {
+		t.Errorf("per-token costs should become per-1M: %+v", got[1])
+	}
+
+	if _, err := ParseCatalog([]byte(`{}`)); err == nil {
+		t.Error("expected error for a catalog without palm's models")
+	}
+}
+
+func TestRefreshAndApply(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	body := sampleCatalog
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	if _, _, err := Refresh(ctx, srv.Client(), srv.URL, "deadbeef"); err == nil {
+		t.Fatal("expected checksum mismatch")
+	}
+	c, changed, err := Refresh(ctx, srv.Client(), srv.URL, "")
+	if err != nil || !changed {
+		t.Fatalf("refresh = %v, %v", changed, err)
+	}
+	if _, changed, _ := Refresh(ctx, srv.Client(), srv.URL, c.Checksum); changed {
+		t.Error("same download should be reported unchanged")
+	}
+
+	catalogOnce = sync.Once{}
+	defer func() { catalogOnce = sync.Once{} }()
+	if m := FindModel("gpt-4o"); m == nil || m.OutputCost < 7.999 || m.OutputCost > 8.001 {
+		t.Errorf("refreshed price should apply: %+v", m)
+	}
+
+	body = `{"gpt-4o": {"litellm_provider": "openai", "max_input_tokens": 256000, "input_cost_per_token": 2e-06, "output_cost_per_token": 8e-06}}`
+	if _, changed, err := Refresh(ctx, srv.Client(), srv.URL, ""); err != nil || !changed {
+		t.Fatalf("second refresh = %v, %v", changed, err)
+	}
+	prev := LoadPreviousCatalog()
+	if prev == nil || prev.Checksum != c.Checksum {
+		t.Fatal("previous catalog should be kept")
+	}
+	changes := Diff(prev.Models, LoadCatalog().Models)
+	if len(changes) != 1 || changes[0].Field != "context" || changes[0].New != 256000 {
+		t.Errorf("unexpected diff: %+v", changes)
+	}
+}
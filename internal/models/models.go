@@ -23,8 +23,14 @@ type Model struct {
 	Description string  `toml:"description"`
 }
 
-// BuiltinProviders returns the known LLM providers.
+// BuiltinProviders returns the known LLM providers, with prices and context
+// windows from the last `palm models refresh` where available.
 func BuiltinProviders() []Provider {
+	return applyCatalog(builtinProviders())
+}
+
+// builtinProviders returns the providers as shipped in this build.
+func builtinProviders() []Provider {
 	return []Provider{
 		{
 			Name:     "OpenAI",
@@ -71,6 +77,8 @@ func BuiltinProviders() []Provider {
 				{ID: "deepseek-r1", Name: "DeepSeek R1", Provider: "ollama", Context: 131072, Type: "chat"},
 				{ID: "mistral", Name: "Mistral 7B", Provider: "ollama", Context: 32768, Type: "chat"},
 				{ID: "codellama", Name: "Code Llama", Provider: "ollama", Context: 16384, Type: "chat"},
+				{ID: "qwen2.5-coder", Name: "Qwen 2.5 Coder 7B", Provider: "ollama", Context: 32768, Type: "chat"},
+				{ID: "llama3.2", Name: "Llama 3.2 3B", Provider: "ollama", Context: 131072, Type: "chat"},
 				{ID: "nomic-embed-text", Name: "Nomic Embed", Provider: "ollama", Context: 8192, Type: "embedding"},
 			},
 		},
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -109,3 +111,31 @@ func TestProviderEnvKeys(t *testing.T) {
 		}
 	}
 }
+
+func TestVerifyKey(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" || r.Header.Get("Authorization") != "Bearer good-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	defer srv.Close()
+
+	p := &Provider{Name: "Test", Endpoint: srv.URL + "/v1", EnvKey: "OPENAI_API_KEY"}
+	if err := VerifyKey(p, "good-key"); err != nil {
+		t.Errorf("expected good key to verify: %v", err)
+	}
+	if err := VerifyKey(p, "bad-key"); err == nil {
+		t.Error("expected bad key to be rejected")
+	}
+}
+
+func TestProviderForKey(t *testing.T) {
+	if p := ProviderForKey("GEMINI_API_KEY"); p == nil || p.Name != "Google" {
+		t.Errorf("GEMINI_API_KEY should map to Google, got %+v", p)
+	}
+	if p := ProviderForKey("UNKNOWN_KEY"); p != nil {
+		t.Errorf("expected no provider, got %s", p.Name)
+	}
+}
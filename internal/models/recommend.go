@@ -0,0 +1,230 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Tasks lists the tasks Recommend knows how to rank models for.
+var Tasks = []string{"coding", "reasoning", "chat", "summarize", "long-context", "embedding"}
+
+// Budgets maps a budget level to the most a cloud model may cost, as a
+// blended price per 1M tokens (3 parts input to 1 part output).
+var Budgets = map[string]float64{
+	"free":   0,
+	"low":    1.5,
+	"medium": 6,
+	"high":   1e9,
+}
+
+// strengths rates built-in chat models per task from 1 (weak) to 5, by hand
+// from public benchmarks. Unrated models count as 2.
+var strengths = map[string]map[string]int{
+	"gpt-4o":                        {"coding": 4, "reasoning": 3, "chat": 5, "summarize": 4},
+	"gpt-4o-mini":                   {"coding": 3, "reasoning": 2, "chat": 4, "summarize": 4},
+	"gpt-4.1":                       {"coding": 5, "reasoning": 4, "chat": 4, "summarize": 4},
+	"gpt-4.1-mini":                  {"coding": 4, "reasoning": 3, "chat": 4, "summarize": 4},
+	"gpt-4.1-nano":                  {"coding": 2, "reasoning": 2, "chat": 3, "summarize": 4},
+	"o3":                            {"coding": 5, "reasoning": 5, "chat": 3, "summarize": 3},
+	"o4-mini":                       {"coding": 4, "reasoning": 5, "chat": 3, "summarize": 3},
+	"claude-opus-4-6":               {"coding": 5, "reasoning": 5, "chat": 5, "summarize": 4},
+	"claude-sonnet-4-5-20250929":    {"coding": 5, "reasoning": 4, "chat": 5, "summarize": 5},
+	"claude-haiku-4-5-20251001":     {"coding": 4, "reasoning": 3, "chat": 4, "summarize": 5},
+	"gemini-2.5-pro":                {"coding": 5, "reasoning": 5, "chat": 4, "summarize": 4},
+	"gemini-2.5-flash":              {"coding": 4, "reasoning": 4, "chat": 4, "summarize": 5},
+	"gemini-2.0-flash":              {"coding": 3, "reasoning": 3, "chat": 4, "summarize": 4},
+	"llama-3.3-70b-versatile":       {"coding": 3, "reasoning": 3, "chat": 4, "summarize": 4},
+	"deepseek-r1-distill-llama-70b": {"coding": 3, "reasoning": 4, "chat": 3, "summarize": 3},
+	"mistral-large-latest":          {"coding": 4, "reasoning": 3, "chat": 4, "summarize": 4},
+	"codestral-latest":              {"coding": 4, "reasoning": 2, "chat": 2, "summarize": 2},
+	"llama3.3":                      {"coding": 3, "reasoning": 3, "chat": 4, "summarize": 4},
+	"qwen3":                         {"coding": 3, "reasoning": 3, "chat": 3, "summarize": 3},
+	"deepseek-r1":                   {"coding": 3, "reasoning": 4, "chat": 2, "summarize": 2},
+	"mistral":                       {"coding": 2, "reasoning": 2, "chat": 3, "summarize": 3},
+	"codellama":                     {"coding": 3, "reasoning": 1, "chat": 1, "summarize": 1},
+	"qwen2.5-coder":                 {"coding": 4, "reasoning": 2, "chat": 2, "summarize": 2},
+	"llama3.2":                      {"coding": 2, "reasoning": 2, "chat": 3, "summarize": 3},
+}
+
+// localVRAM is the memory in MB each Ollama model needs at its default
+// (4-bit) quantization.
+var localVRAM = map[string]int{
+	"llama3.3":         43000,
+	"qwen3":            5200,
+	"deepseek-r1":      5000,
+	"mistral":          4400,
+	"codellama":        3800,
+	"qwen2.5-coder":    4700,
+	"llama3.2":         2000,
+	"nomic-embed-text": 300,
+}
+
+// cpuVRAM is how large a local model may be to run tolerably without a GPU.
+const cpuVRAM = 5500
+
+// longContext is the context window the long-context task asks for.
+const longContext = 200000
+
+// Request describes what a recommendation is for.
+type Request struct {
+	Task    string
+	Budget  string // key of Budgets
+	LocalOK bool   // consider local models
+	VRAMMB  int    // GPU memory available to local models; 0 for CPU only
+	// HasKey reports whether a cloud provider can be used right away.
+	// Nil treats every provider as available.
+	HasKey func(provider string) bool
+}
+
+// Recommendation is a suggested model and why.
+type Recommendation struct {
+	Model   Model
+	Local   bool
+	Blended float64 // price per 1M tokens, 3:1 input to output
+	Score   float64
+	Reasons []string
+}
+
+// Recommend suggests up to n models for req, best first. When both local
+// and cloud models qualify, the suggestions include at least one of each.
+func Recommend(req Request, n int) ([]Recommendation, error) {
+	task := strings.ToLower(req.Task)
+	if !containsString(Tasks, task) {
+		return nil, fmt.Errorf("unknown task %q (use %s)", req.Task, strings.Join(Tasks, ", "))
+	}
+	budget := strings.ToLower(req.Budget)
+	maxCost, ok := Budgets[budget]
+	if !ok {
+		return nil, fmt.Errorf("unknown budget %q (use free, low, medium, high)", req.Budget)
+	}
+
+	var cands []Recommendation
+	for _, m := range AllModels() {
+		r, ok := rate(m, task, budget, maxCost, req)
+		if ok {
+			cands = append(cands, r)
+		}
+	}
+	sort.SliceStable(cands, func(i, j int) bool { return cands[i].Score > cands[j].Score })
+
+	var picked []Recommendation
+	used := make(map[int]bool)
+	pick := func(match func(Recommendation) bool) {
+		for i, c := range cands {
+			if !used[i] && match(c) && len(picked) < n {
+				used[i] = true
+				picked = append(picked, c)
+				return
+			}
+		}
+	}
+	pick(func(Recommendation) bool { return true })
+	if len(picked) > 0 {
+		first := picked[0].Local
+		pick(func(c Recommendation) bool { return c.Local != first })
+	}
+	for len(picked) < n && len(used) < len(cands) {
+		pick(func(Recommendation) bool { return true })
+	}
+	sort.SliceStable(picked, func(i, j int) bool { return picked[i].Score > picked[j].Score })
+	return picked, nil
+}
+
+// rate scores m for the request, or reports that it doesn't qualify.
+func rate(m Model, task, budget string, maxCost float64, req Request) (Recommendation, bool) {
+	r := Recommendation{Model: m, Local: m.Provider == "ollama"}
+
+	if task == "embedding" {
+		if m.Type != "embedding" {
+			return r, false
+		}
+	} else if m.Type != "chat" {
+		return r, false
+	}
+
+	quality := 2
+	if s, ok := strengths[m.ID]; ok {
+		if q, ok := s[task]; ok {
+			quality = q
+		}
+	}
+	if task == "long-context" {
+		if m.Context < longContext {
+			return r, false
+		}
+		quality = 3
+		if s, ok := strengths[m.ID]; ok {
+			quality = (s["summarize"] + s["reasoning"] + 1) / 2
+		}
+		if m.Context >= 1000000 {
+			quality++
+		}
+		r.Reasons = append(r.Reasons, FormatContext(m.Context)+" context window")
+	}
+	if task == "embedding" {
+		quality = 3
+	}
+	r.Score = float64(quality) * 10
+
+	if r.Local {
+		if !req.LocalOK {
+			return r, false
+		}
+		need := localVRAM[m.ID]
+		switch {
+		case need == 0:
+			return r, false
+		case req.VRAMMB >= need:
+			r.Reasons = append(r.Reasons, fmt.Sprintf("fits your %s GPU memory (needs ~%s)", formatMB(req.VRAMMB), formatMB(need)))
+		case req.VRAMMB == 0 && need <= cpuVRAM:
+			r.Reasons = append(r.Reasons, "small enough to run on CPU, though slowly")
+			r.Score -= 8
+		default:
+			return r, false
+		}
+		r.Reasons = append(r.Reasons, "runs locally: free and private")
+		// Local models shine when money matters most
+		r.Score += map[string]float64{"free": 10, "low": 6, "medium": 2}[budget]
+	} else {
+		r.Blended = (3*m.InputCost + m.OutputCost) / 4
+		if r.Blended > maxCost {
+			return r, false
+		}
+		r.Reasons = append(r.Reasons, fmt.Sprintf("$%.2f per 1M tokens blended", r.Blended))
+		// Cheaper is better, more so on a tight budget
+		r.Score -= r.Blended * map[string]float64{"low": 3, "medium": 1, "high": 0.2}[budget]
+		if req.HasKey != nil {
+			if req.HasKey(m.Provider) {
+				r.Score += 2
+				r.Reasons = append(r.Reasons, "API key already configured")
+			} else {
+				r.Reasons = append(r.Reasons, "needs a "+m.Provider+" API key")
+			}
+		}
+	}
+
+	switch {
+	case quality >= 5:
+		r.Reasons = append([]string{"top tier for " + task}, r.Reasons...)
+	case quality == 4:
+		r.Reasons = append([]string{"strong at " + task}, r.Reasons...)
+	}
+	return r, true
+}
+
+func formatMB(mb int) string {
+	if mb >= 1024 {
+		return fmt.Sprintf("%.0fGB", float64(mb)/1024)
+	}
+	return fmt.Sprintf("%dMB", mb)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
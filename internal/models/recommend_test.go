@@ -0,0 +1,61 @@
+package models
+
+import "testing"
+
+func TestRecommendMixesLocalAndCloud(t *testing.T) {
+	recs, err := Recommend(Request{Task: "coding", Budget: "low", LocalOK: true, VRAMMB: 8192}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 3 {
+		t.Fatalf("expected 3 suggestions, got %d", len(recs))
+	}
+	local, cloud := 0, 0
+	for _, r := range recs {
+		if r.Local {
+			local++
+			if localVRAM[r.Model.ID] > 8192 {
+				t.Errorf("%s does not fit in 8GB", r.Model.ID)
+			}
+		} else {
+			cloud++
+			if r.Blended > Budgets["low"] {
+				t.Errorf("%s is over the low budget: $%.2f", r.Model.ID, r.Blended)
+			}
+		}
+		if len(r.Reasons) == 0 {
+			t.Errorf("%s has no reasoning", r.Model.ID)
+		}
+	}
+	if local == 0 || cloud == 0 {
+		t.Errorf("expected both local and cloud suggestions, got %d local, %d cloud", local, cloud)
+	}
+}
+
+func TestRecommendConstraints(t *testing.T) {
+	recs, _ := Recommend(Request{Task: "chat", Budget: "high"}, 5)
+	for _, r := range recs {
+		if r.Local {
+			t.Errorf("local model %s suggested without LocalOK", r.Model.ID)
+		}
+	}
+
+	recs, _ = Recommend(Request{Task: "long-context", Budget: "high"}, 5)
+	for _, r := range recs {
+		if r.Model.Context < longContext {
+			t.Errorf("%s has too small a context for long-context", r.Model.ID)
+		}
+	}
+
+	recs, _ = Recommend(Request{Task: "embedding", Budget: "free", LocalOK: true}, 3)
+	if len(recs) != 1 || recs[0].Model.ID != "nomic-embed-text" {
+		t.Errorf("expected the local embedding model, got %+v", recs)
+	}
+
+	if _, err := Recommend(Request{Task: "juggling", Budget: "low"}, 3); err == nil {
+		t.Error("expected error for unknown task")
+	}
+	if _, err := Recommend(Request{Task: "chat", Budget: "lavish"}, 3); err == nil {
+		t.Error("expected error for unknown budget")
+	}
+}
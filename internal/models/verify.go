@@ -0,0 +1,73 @@
+package models
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ProviderForKey returns the provider authenticated by the given env key.
+func ProviderForKey(envKey string) *Provider {
+	if envKey == "GEMINI_API_KEY" {
+		envKey = "GOOGLE_API_KEY"
+	}
+	for _, p := range BuiltinProviders() {
+		if p.EnvKey != "" && p.EnvKey == envKey {
+			return &p
+		}
+	}
+	return nil
+}
+
+// KeyRejectedError is returned by VerifyKey when the provider was reached
+// and refused the key, typically because it was revoked or has expired.
+type KeyRejectedError struct {
+	Provider string
+	Status   string
+}
+
+func (e *KeyRejectedError) Error() string {
+	return fmt.Sprintf("%s rejected the key (%s)", e.Provider, e.Status)
+}
+
+// VerifyKey checks a key against the provider's model listing endpoint,
+// which is free and consumes no tokens.
+func VerifyKey(p *Provider, key string) error {
+	endpoint := strings.TrimRight(p.Endpoint, "/") + "/models"
+	if p.EnvKey == "GOOGLE_API_KEY" {
+		endpoint += "?key=" + url.QueryEscape(key)
+	}
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	switch p.EnvKey {
+	case "ANTHROPIC_API_KEY":
+		req.Header.Set("x-api-key", key)
+		req.Header.Set("anthropic-version", "2023-06-01")
+	case "GOOGLE_API_KEY":
+	default:
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not reach %s: %w", p.Name, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return nil
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden,
+		resp.StatusCode == http.StatusBadRequest && p.EnvKey == "GOOGLE_API_KEY": // Google reports bad keys as 400
+		return &KeyRejectedError{Provider: p.Name, Status: resp.Status}
+	default:
+		return fmt.Errorf("%s returned %s", p.Name, resp.Status)
+	}
+}
@@ -0,0 +1,296 @@
+// Package netcheck diagnoses why an API endpoint can't be reached: it walks
+// DNS, TCP and TLS in turn, going through the environment's HTTP proxy when
+// one is set, and measures the round trip once a connection works.
+package netcheck
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Target is an endpoint to diagnose.
+type Target struct {
+	Name string
+	URL  string // API root, e.g. https://api.openai.com/v1
+}
+
+// Step is the outcome of one stage of reaching an endpoint.
+type Step struct {
+	Took    time.Duration
+	Err     error
+	Skipped bool // an earlier stage failed, or the stage doesn't apply
+}
+
+// Result is what Check found out about a target.
+type Result struct {
+	Target
+	Host  string
+	Proxy *url.URL // proxy the target is reached through, if any
+	Addrs []string
+
+	DNS, TCP, TLS, HTTP Step
+
+	// Issuer names who issued the certificate the endpoint presented.
+	Issuer string
+	// Intercepted is set when the certificate was re-signed by a proxy or
+	// security product instead of coming from the provider.
+	Intercepted bool
+
+	// RTT is the fastest of a few HTTP round trips over a warm connection.
+	RTT time.Duration
+}
+
+// OK reports whether every stage succeeded.
+func (r Result) OK() bool {
+	return r.DNS.Err == nil && r.TCP.Err == nil && r.TLS.Err == nil && r.HTTP.Err == nil
+}
+
+// Failure returns the first stage that failed and why.
+func (r Result) Failure() (string, error) {
+	for _, s := range []struct {
+		name string
+		step Step
+	}{{"dns", r.DNS}, {"tcp", r.TCP}, {"tls", r.TLS}, {"http", r.HTTP}} {
+		if s.step.Err != nil {
+			return s.name, s.step.Err
+		}
+	}
+	return "", nil
+}
+
+// Options tune a check.
+type Options struct {
+	Timeout time.Duration // per stage; 5s when zero
+	Samples int           // HTTP round trips to take the fastest of; 3 when zero
+	// Roots verifies certificates; nil uses the system pool.
+	Roots *x509.CertPool
+	// Proxy picks the proxy for a request; nil uses the environment.
+	Proxy func(*http.Request) (*url.URL, error)
+}
+
+// interceptors are issuers used by products that inspect TLS traffic.
+var interceptors = []string{
+	"zscaler", "netskope", "palo alto", "fortinet", "fortigate", "blue coat",
+	"bluecoat", "symantec web", "cisco umbrella", "forcepoint", "websense",
+	"sophos", "mcafee", "skyhigh", "checkpoint", "check point", "kaspersky",
+	"avast", "eset ssl filter", "bitdefender", "charles proxy", "mitmproxy",
+	"fiddler", "burp",
+}
+
+// Check diagnoses reaching t.
+func Check(t Target, opts Options) Result {
+	if opts.Timeout == 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	if opts.Samples == 0 {
+		opts.Samples = 3
+	}
+	if opts.Proxy == nil {
+		opts.Proxy = http.ProxyFromEnvironment
+	}
+
+	r := Result{Target: t}
+	u, err := url.Parse(t.URL)
+	if err != nil || u.Host == "" {
+		r.DNS.Err = fmt.Errorf("invalid URL %q", t.URL)
+		return r
+	}
+	r.Host = u.Hostname()
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+	}
+	target := net.JoinHostPort(r.Host, port)
+	if req, err := http.NewRequest(http.MethodGet, t.URL, nil); err == nil {
+		r.Proxy, _ = opts.Proxy(req)
+	}
+
+	// DNS. Behind a proxy the proxy resolves the name, so a local failure
+	// is only informational.
+	start := time.Now()
+	resolver := &net.Resolver{}
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	r.Addrs, err = resolver.LookupHost(ctx, r.Host)
+	cancel()
+	r.DNS.Took = time.Since(start)
+	if err != nil && r.Proxy == nil {
+		r.DNS.Err = err
+		r.TCP.Skipped, r.TLS.Skipped, r.HTTP.Skipped = true, true, true
+		return r
+	}
+
+	// TCP, to the proxy when there is one, then a tunnel through it
+	dialAddr := target
+	if r.Proxy != nil {
+		dialAddr = proxyAddr(r.Proxy)
+	}
+	start = time.Now()
+	conn, err := net.DialTimeout("tcp", dialAddr, opts.Timeout)
+	if err == nil && r.Proxy != nil {
+		err = connectTunnel(conn, r.Proxy, target, opts.Timeout)
+		if err != nil {
+			conn.Close()
+		}
+	}
+	r.TCP.Took = time.Since(start)
+	if err != nil {
+		r.TCP.Err = err
+		r.TLS.Skipped, r.HTTP.Skipped = true, true
+		return r
+	}
+
+	if u.Scheme == "https" {
+		start = time.Now()
+		r.TLS.Err = r.handshake(conn, opts)
+		r.TLS.Took = time.Since(start)
+		if r.TLS.Err != nil {
+			r.HTTP.Skipped = true
+			return r
+		}
+	} else {
+		conn.Close()
+		r.TLS.Skipped = true
+	}
+
+	r.RTT, r.HTTP.Err = roundTrips(t.URL, opts)
+	r.HTTP.Took = r.RTT
+	return r
+}
+
+// handshake completes a TLS handshake on conn and verifies the certificate,
+// recording who issued it. conn is closed.
+func (r *Result) handshake(conn net.Conn, opts Options) error {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(opts.Timeout))
+	tc := tls.Client(conn, &tls.Config{ServerName: r.Host, InsecureSkipVerify: true})
+	if err := tc.Handshake(); err != nil {
+		return err
+	}
+	certs := tc.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return errors.New("no certificate presented")
+	}
+	leaf := certs[0]
+	r.Issuer = issuerName(leaf)
+	if isInterceptor(r.Issuer) {
+		r.Intercepted = true
+	}
+
+	inter := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		inter.AddCert(c)
+	}
+	_, err := leaf.Verify(x509.VerifyOptions{DNSName: r.Host, Roots: opts.Roots, Intermediates: inter})
+	var unknown x509.UnknownAuthorityError
+	if errors.As(err, &unknown) {
+		r.Intercepted = true
+		return fmt.Errorf("certificate from %q is not trusted", r.Issuer)
+	}
+	return err
+}
+
+// roundTrips times opts.Samples requests to rawURL over one connection and
+// returns the fastest. Any HTTP response counts: the status doesn't matter,
+// only that the endpoint answers.
+func roundTrips(rawURL string, opts Options) (time.Duration, error) {
+	tr := &http.Transport{
+		Proxy:           opts.Proxy,
+		TLSClientConfig: &tls.Config{RootCAs: opts.Roots},
+	}
+	defer tr.CloseIdleConnections()
+	client := &http.Client{Transport: tr, Timeout: opts.Timeout}
+
+	var best time.Duration
+	for i := 0; i < opts.Samples; i++ {
+		start := time.Now()
+		resp, err := client.Get(rawURL)
+		if err != nil {
+			return 0, err
+		}
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+		if took := time.Since(start); i == 0 || took < best {
+			best = took
+		}
+	}
+	return best, nil
+}
+
+// connectTunnel asks an HTTP proxy on conn to open a tunnel to target.
+func connectTunnel(conn net.Conn, proxy *url.URL, target string, timeout time.Duration) error {
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+	defer conn.SetDeadline(time.Time{})
+
+	req := "CONNECT " + target + " HTTP/1.1\r\nHost: " + target + "\r\n"
+	if proxy.User != nil {
+		pass, _ := proxy.User.Password()
+		auth := base64.StdEncoding.EncodeToString([]byte(proxy.User.Username() + ":" + pass))
+		req += "Proxy-Authorization: Basic " + auth + "\r\n"
+	}
+	if _, err := conn.Write([]byte(req + "\r\n")); err != nil {
+		return err
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), &http.Request{Method: http.MethodConnect})
+	if err != nil {
+		return fmt.Errorf("proxy %s: %w", proxy.Host, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("proxy %s refused the tunnel: %s", proxy.Host, resp.Status)
+	}
+	return nil
+}
+
+func proxyAddr(u *url.URL) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	if u.Scheme == "https" {
+		return net.JoinHostPort(u.Hostname(), "443")
+	}
+	return net.JoinHostPort(u.Hostname(), "80")
+}
+
+func issuerName(c *x509.Certificate) string {
+	if len(c.Issuer.Organization) > 0 {
+		return c.Issuer.Organization[0]
+	}
+	return c.Issuer.CommonName
+}
+
+func isInterceptor(issuer string) bool {
+	issuer = strings.ToLower(issuer)
+	for _, name := range interceptors {
+		if strings.Contains(issuer, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTimeout reports whether err is a stage giving up rather than being
+// refused, which usually means a firewall drops the traffic silently.
+func IsTimeout(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// IsRefused reports whether err is a connection being actively refused,
+// e.g. because nothing listens on a local port.
+func IsRefused(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "connection refused")
+}
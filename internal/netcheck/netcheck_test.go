@@ -0,0 +1,92 @@
+package netcheck
+
+import (
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func noProxy(*http.Request) (*url.URL, error) { return nil, nil }
+
+func TestCheckTrusted(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	r := Check(Target{Name: "test", URL: srv.URL + "/v1"}, Options{Roots: roots, Proxy: noProxy, Timeout: 2 * time.Second})
+	if !r.OK() {
+		stage, err := r.Failure()
+		t.Fatalf("expected OK, %s failed: %v", stage, err)
+	}
+	if r.Intercepted || r.RTT <= 0 || r.Issuer == "" {
+		t.Errorf("unexpected result %+v", r)
+	}
+}
+
+func TestCheckUntrustedCertificate(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	r := Check(Target{URL: srv.URL}, Options{Roots: x509.NewCertPool(), Proxy: noProxy, Timeout: 2 * time.Second})
+	if stage, err := r.Failure(); stage != "tls" || err == nil {
+		t.Fatalf("expected tls failure, got %s %v", stage, err)
+	}
+	if !r.Intercepted || !r.HTTP.Skipped {
+		t.Errorf("untrusted certificate should be flagged as interception: %+v", r)
+	}
+}
+
+func TestCheckRefused(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	r := Check(Target{URL: "http://" + addr}, Options{Proxy: noProxy, Timeout: time.Second})
+	if stage, err := r.Failure(); stage != "tcp" || !IsRefused(err) {
+		t.Errorf("expected refused tcp, got %s %v", stage, err)
+	}
+}
+
+func TestCheckThroughProxy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer upstream.Close()
+
+	tunnels := 0
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodConnect {
+			tunnels++
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		http.Error(w, "no", http.StatusBadGateway)
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	r := Check(Target{URL: "http://unresolvable.invalid"}, Options{
+		Proxy:   func(*http.Request) (*url.URL, error) { return proxyURL, nil },
+		Timeout: time.Second,
+	})
+	if r.DNS.Err != nil {
+		t.Errorf("local DNS failures shouldn't count behind a proxy: %v", r.DNS.Err)
+	}
+	if stage, _ := r.Failure(); stage != "tcp" || tunnels != 1 {
+		t.Errorf("expected the proxy to refuse the tunnel, got %s (%d tunnels)", stage, tunnels)
+	}
+}
+
+func TestIsInterceptor(t *testing.T) {
+	if !isInterceptor("Zscaler Inc.") || isInterceptor("Google Trust Services") {
+		t.Error("interceptor matching is off")
+	}
+}
@@ -0,0 +1,235 @@
+// Package outdated looks up the newest published version of installed tools
+// from the backend they were installed with, and where to read what changed.
+package outdated
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Release is the newest published version of a package.
+type Release struct {
+	Version  string `json:"version"`
+	Source   string `json:"source"`              // brew, npm, pypi, crates.io, github
+	NotesURL string `json:"notes_url,omitempty"` // changelog or release notes
+}
+
+// Checker queries package indexes. The zero value is not usable; use New.
+type Checker struct {
+	Client *http.Client
+
+	NPMBase    string
+	PyPIBase   string
+	CratesBase string
+	GitHubAPI  string
+
+	// Brew runs `brew info --json=v2 <pkg>`; nil when brew isn't installed.
+	Brew func(pkg string) ([]byte, error)
+}
+
+// New returns a Checker for the public package indexes.
+func New() *Checker {
+	c := &Checker{
+		Client:     &http.Client{Timeout: 10 * time.Second},
+		NPMBase:    "https://registry.npmjs.org",
+		PyPIBase:   "https://pypi.org",
+		CratesBase: "https://crates.io",
+		GitHubAPI:  "https://api.github.com",
+	}
+	if _, err := exec.LookPath("brew"); err == nil {
+		c.Brew = func(pkg string) ([]byte, error) {
+			return exec.Command("brew", "info", "--json=v2", pkg).Output()
+		}
+	}
+	return c
+}
+
+// Latest returns the newest release of pkg as published by backend. Tools
+// installed by script, binary or go install are looked up in their GitHub
+// repository's releases.
+func (c *Checker) Latest(backend, pkg, repo string) (Release, error) {
+	var (
+		r   Release
+		err error
+	)
+	switch backend {
+	case "brew":
+		if r, err = c.brew(pkg); err != nil && repo != "" {
+			// Without brew on this machine, GitHub releases are the next best source
+			if gr, gerr := c.github(repo); gerr == nil {
+				r, err = gr, nil
+			}
+		}
+	case "npm":
+		r, err = c.npm(pkg)
+	case "pip":
+		r, err = c.pypi(pkg)
+	case "cargo":
+		r, err = c.crates(pkg)
+	default:
+		if repo == "" && backend == "go" {
+			// "github.com/owner/tool/cmd/x@latest" names its repository
+			repo = strings.SplitN(pkg, "@", 2)[0]
+		}
+		r, err = c.github(repo)
+	}
+	if err != nil {
+		return r, err
+	}
+	if r.NotesURL == "" {
+		r.NotesURL = githubReleasesURL(repo)
+	}
+	if r.NotesURL == "" && backend == "npm" {
+		r.NotesURL = "https://www.npmjs.com/package/" + pkg + "?activeTab=versions"
+	}
+	return r, nil
+}
+
+func (c *Checker) brew(pkg string) (Release, error) {
+	if c.Brew == nil {
+		return Release{}, fmt.Errorf("brew not installed")
+	}
+	out, err := c.Brew(pkg)
+	if err != nil {
+		return Release{}, fmt.Errorf("brew info %s: %w", pkg, err)
+	}
+	var info struct {
+		Formulae []struct {
+			Versions struct {
+				Stable string `json:"stable"`
+			} `json:"versions"`
+		} `json:"formulae"`
+		Casks []struct {
+			Version string `json:"version"`
+		} `json:"casks"`
+	}
+	if err := json.Unmarshal(out, &info); err != nil {
+		return Release{}, err
+	}
+	switch {
+	case len(info.Formulae) > 0 && info.Formulae[0].Versions.Stable != "":
+		return Release{Version: info.Formulae[0].Versions.Stable, Source: "brew"}, nil
+	case len(info.Casks) > 0 && info.Casks[0].Version != "":
+		// Casks append a build id: "1.2.3,abcdef"
+		return Release{Version: strings.SplitN(info.Casks[0].Version, ",", 2)[0], Source: "brew"}, nil
+	}
+	return Release{}, fmt.Errorf("brew: no version for %s", pkg)
+}
+
+func (c *Checker) npm(pkg string) (Release, error) {
+	var body struct {
+		Version string `json:"version"`
+	}
+	// Scoped names keep their @ but escape the slash
+	if err := c.getJSON(c.NPMBase+"/"+strings.Replace(pkg, "/", "%2F", 1)+"/latest", &body); err != nil {
+		return Release{}, err
+	}
+	return Release{Version: body.Version, Source: "npm"}, nil
+}
+
+func (c *Checker) pypi(pkg string) (Release, error) {
+	// Strip extras and pins: "aider-chat[browser]==0.50" → "aider-chat"
+	fields := strings.FieldsFunc(pkg, func(r rune) bool { return strings.ContainsRune("[=<>~! ", r) })
+	if len(fields) == 0 {
+		return Release{}, fmt.Errorf("pypi: no package name")
+	}
+	name := fields[0]
+	var body struct {
+		Info struct {
+			Version     string            `json:"version"`
+			ProjectURLs map[string]string `json:"project_urls"`
+		} `json:"info"`
+	}
+	if err := c.getJSON(c.PyPIBase+"/pypi/"+url.PathEscape(name)+"/json", &body); err != nil {
+		return Release{}, err
+	}
+	r := Release{Version: body.Info.Version, Source: "pypi"}
+	for label, link := range body.Info.ProjectURLs {
+		l := strings.ToLower(label)
+		if strings.Contains(l, "changelog") || strings.Contains(l, "release") || strings.Contains(l, "changes") || strings.Contains(l, "history") {
+			r.NotesURL = link
+			break
+		}
+	}
+	return r, nil
+}
+
+func (c *Checker) crates(pkg string) (Release, error) {
+	var body struct {
+		Crate struct {
+			MaxStable string `json:"max_stable_version"`
+			Newest    string `json:"newest_version"`
+		} `json:"crate"`
+	}
+	if err := c.getJSON(c.CratesBase+"/api/v1/crates/"+url.PathEscape(pkg), &body); err != nil {
+		return Release{}, err
+	}
+	v := body.Crate.MaxStable
+	if v == "" {
+		v = body.Crate.Newest
+	}
+	return Release{Version: v, Source: "crates.io"}, nil
+}
+
+func (c *Checker) github(repo string) (Release, error) {
+	slug := GitHubSlug(repo)
+	if slug == "" {
+		return Release{}, fmt.Errorf("no GitHub repository to check")
+	}
+	var body struct {
+		TagName string `json:"tag_name"`
+		HTMLURL string `json:"html_url"`
+	}
+	if err := c.getJSON(c.GitHubAPI+"/repos/"+slug+"/releases/latest", &body); err != nil {
+		return Release{}, err
+	}
+	return Release{Version: body.TagName, Source: "github", NotesURL: body.HTMLURL}, nil
+}
+
+func (c *Checker) getJSON(u string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "palm (https://github.com/msalah0e/palm)")
+	req.Header.Set("Accept", "application/json")
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" && strings.HasPrefix(u, c.GitHubAPI) {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %d", req.URL.Host, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// GitHubSlug returns "owner/repo" for a GitHub URL, or "".
+func GitHubSlug(repo string) string {
+	repo = strings.TrimSuffix(strings.TrimSuffix(repo, "/"), ".git")
+	i := strings.Index(repo, "github.com/")
+	if i < 0 {
+		return ""
+	}
+	parts := strings.Split(repo[i+len("github.com/"):], "/")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return ""
+	}
+	return parts[0] + "/" + parts[1]
+}
+
+func githubReleasesURL(repo string) string {
+	if slug := GitHubSlug(repo); slug != "" {
+		return "https://github.com/" + slug + "/releases"
+	}
+	return ""
+}
@@ -0,0 +1,110 @@
+package outdated
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func testChecker(t *testing.T) *Checker {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/@openai%2Fcodex/latest", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name":"@openai/codex","version":"0.9.1"}`))
+	})
+	mux.HandleFunc("/pypi/aider-chat/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"info":{"version":"0.60.0","project_urls":{"Homepage":"https://aider.chat","Release notes":"https://aider.chat/HISTORY.html"}}}`))
+	})
+	mux.HandleFunc("/api/v1/crates/aichat", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") == "" {
+			http.Error(w, "crates.io needs a user agent", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"crate":{"max_stable_version":"0.25.0","newest_version":"0.26.0-beta"}}`))
+	})
+	mux.HandleFunc("/repos/charmbracelet/mods/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"tag_name":"v1.7.0","html_url":"https://github.com/charmbracelet/mods/releases/tag/v1.7.0"}`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return &Checker{
+		Client:     srv.Client(),
+		NPMBase:    srv.URL,
+		PyPIBase:   srv.URL,
+		CratesBase: srv.URL,
+		GitHubAPI:  srv.URL,
+	}
+}
+
+func TestLatest(t *testing.T) {
+	c := testChecker(t)
+	tests := []struct {
+		backend, pkg, repo string
+		want               Release
+	}{
+		{"npm", "@openai/codex", "https://github.com/openai/codex", Release{"0.9.1", "npm", "https://github.com/openai/codex/releases"}},
+		{"npm", "@openai/codex", "", Release{"0.9.1", "npm", "https://www.npmjs.com/package/@openai/codex?activeTab=versions"}},
+		{"pip", "aider-chat[browser]", "", Release{"0.60.0", "pypi", "https://aider.chat/HISTORY.html"}},
+		{"cargo", "aichat", "", Release{"0.25.0", "crates.io", ""}},
+		{"go", "github.com/charmbracelet/mods@latest", "", Release{"v1.7.0", "github", "https://github.com/charmbracelet/mods/releases/tag/v1.7.0"}},
+		// No brew on this machine: GitHub releases instead
+		{"brew", "mods", "https://github.com/charmbracelet/mods", Release{"v1.7.0", "github", "https://github.com/charmbracelet/mods/releases/tag/v1.7.0"}},
+	}
+	for _, tt := range tests {
+		got, err := c.Latest(tt.backend, tt.pkg, tt.repo)
+		if err != nil {
+			t.Errorf("Latest(%s, %s): %v", tt.backend, tt.pkg, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Latest(%s, %s) = %+v, want %+v", tt.backend, tt.pkg, got, tt.want)
+		}
+	}
+
+	if _, err := c.Latest("pip", "no-such-package", ""); err == nil {
+		t.Error("expected an error for an unknown package")
+	}
+	if _, err := c.Latest("script", "https://example.com/install.sh", ""); err == nil {
+		t.Error("expected an error for a script install without a repository")
+	}
+}
+
+func TestLatestBrew(t *testing.T) {
+	c := testChecker(t)
+	c.Brew = func(pkg string) ([]byte, error) {
+		switch pkg {
+		case "goose":
+			return []byte(`{"formulae":[{"versions":{"stable":"1.0.3"}}],"casks":[]}`), nil
+		case "claude":
+			return []byte(`{"formulae":[],"casks":[{"version":"0.7.1,a1b2c3"}]}`), nil
+		}
+		return nil, errors.New("exit status 1")
+	}
+	if r, err := c.Latest("brew", "goose", ""); err != nil || r.Version != "1.0.3" {
+		t.Errorf("formula: %+v %v", r, err)
+	}
+	if r, err := c.Latest("brew", "claude", ""); err != nil || r.Version != "0.7.1" {
+		t.Errorf("cask: %+v %v", r, err)
+	}
+	if _, err := c.Latest("brew", "missing", ""); err == nil {
+		t.Error("expected an error for an unknown formula")
+	}
+}
+
+func TestGitHubSlug(t *testing.T) {
+	tests := map[string]string{
+		"https://github.com/danielmiessler/fabric": "danielmiessler/fabric",
+		"https://github.com/owner/repo.git":        "owner/repo",
+		"github.com/aandrew-me/tgpt/v2":            "aandrew-me/tgpt",
+		"https://github.com/owner/":                "",
+		"https://gitlab.com/owner/repo":            "",
+		"":                                         "",
+	}
+	for in, want := range tests {
+		if got := GitHubSlug(in); got != want {
+			t.Errorf("GitHubSlug(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -27,14 +27,19 @@ type Task struct {
 }
 
 // Run executes tasks in parallel with the given concurrency limit.
-// Returns results in the order tasks were submitted.
+// Returns results in the order tasks were submitted. On a terminal progress
+// is drawn as a live table; otherwise one line is printed per state change.
 func Run(tasks []Task, concurrency int) []Result {
 	if concurrency < 1 {
 		concurrency = 4
 	}
 
+	var r reporter = &lineReporter{}
+	if ui.IsTerminal() {
+		r = newLiveReporter(tasks)
+	}
+
 	results := make([]Result, len(tasks))
-	var mu sync.Mutex
 
 	g, _ := errgroup.WithContext(context.Background())
 	g.SetLimit(concurrency)
@@ -43,38 +48,163 @@ func Run(tasks []Task, concurrency int) []Result {
 		i, task := i, task
 		g.Go(func() error {
 			start := time.Now()
-
-			mu.Lock()
-			fmt.Printf("  %s %s...\n", ui.Subtle.Sprint("⟳"), task.Name)
-			mu.Unlock()
+			r.started(i, task.Name)
 
 			output, err := task.Fn()
-			elapsed := time.Since(start)
-
-			mu.Lock()
-			if err != nil {
-				results[i] = Result{Name: task.Name, OK: false, Err: err, Output: output, Elapsed: elapsed}
-				fmt.Printf("  %s %s %s\n", ui.StatusIcon(false), task.Name, ui.Bad.Sprintf("(%v)", err))
-				// Show truncated output to help diagnose failures
-				if output = strings.TrimSpace(output); output != "" {
-					for _, line := range truncateLines(output, 5) {
-						fmt.Printf("      %s\n", ui.Subtle.Sprint(line))
-					}
-				}
-			} else {
-				results[i] = Result{Name: task.Name, OK: true, Output: output, Elapsed: elapsed}
-				fmt.Printf("  %s %s %s\n", ui.StatusIcon(true), task.Name, ui.Subtle.Sprintf("%.1fs", elapsed.Seconds()))
-			}
-			mu.Unlock()
+			results[i] = Result{Name: task.Name, OK: err == nil, Err: err, Output: output, Elapsed: time.Since(start)}
+			r.finished(i, results[i])
 
 			return nil // never fail the group — collect results instead
 		})
 	}
 
 	_ = g.Wait()
+	r.done()
 	return results
 }
 
+// reporter renders task progress.
+type reporter interface {
+	started(i int, name string)
+	finished(i int, res Result)
+	done()
+}
+
+// lineReporter prints one line per state change, for pipes and CI logs.
+type lineReporter struct {
+	mu sync.Mutex
+}
+
+func (l *lineReporter) started(_ int, name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Printf("  %s %s...\n", ui.Subtle.Sprint("⟳"), name)
+}
+
+func (l *lineReporter) finished(_ int, res Result) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if res.Err != nil {
+		fmt.Printf("  %s %s %s\n", ui.StatusIcon(false), res.Name, ui.Bad.Sprintf("(%v)", res.Err))
+		// Show truncated output to help diagnose failures
+		if output := strings.TrimSpace(res.Output); output != "" {
+			for _, line := range truncateLines(output, 5) {
+				fmt.Printf("      %s\n", ui.Subtle.Sprint(line))
+			}
+		}
+		return
+	}
+	fmt.Printf("  %s %s %s\n", ui.StatusIcon(true), res.Name, ui.Subtle.Sprintf("%.1fs", res.Elapsed.Seconds()))
+}
+
+func (l *lineReporter) done() {}
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// liveReporter redraws a per-task status table in place.
+type liveReporter struct {
+	mu      sync.Mutex
+	names   []string
+	state   []int // 0 queued, 1 running, 2 done
+	began   []time.Time
+	results []Result
+	width   int
+	frame   int
+	drawn   bool
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+func newLiveReporter(tasks []Task) *liveReporter {
+	l := &liveReporter{
+		names:   make([]string, len(tasks)),
+		state:   make([]int, len(tasks)),
+		began:   make([]time.Time, len(tasks)),
+		results: make([]Result, len(tasks)),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	for i, t := range tasks {
+		l.names[i] = t.Name
+		if len(t.Name) > l.width {
+			l.width = len(t.Name)
+		}
+	}
+	go func() {
+		defer close(l.stopped)
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-l.stop:
+				return
+			case <-ticker.C:
+				l.mu.Lock()
+				l.frame++
+				l.draw()
+				l.mu.Unlock()
+			}
+		}
+	}()
+	return l
+}
+
+func (l *liveReporter) started(i int, _ string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.state[i] = 1
+	l.began[i] = time.Now()
+}
+
+func (l *liveReporter) finished(i int, res Result) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.state[i] = 2
+	l.results[i] = res
+}
+
+func (l *liveReporter) done() {
+	close(l.stop)
+	<-l.stopped
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.draw()
+}
+
+// draw must be called with l.mu held.
+func (l *liveReporter) draw() {
+	if l.drawn {
+		fmt.Printf("\033[%dA", len(l.names)) // move cursor back to the first row
+	}
+	l.drawn = true
+	for i, name := range l.names {
+		var icon, status string
+		switch l.state[i] {
+		case 0:
+			icon, status = ui.Subtle.Sprint("·"), ui.Subtle.Sprint("queued")
+		case 1:
+			icon = ui.Info.Sprint(spinnerFrames[l.frame%len(spinnerFrames)])
+			status = ui.Subtle.Sprintf("running %.0fs", time.Since(l.began[i]).Seconds())
+		case 2:
+			res := l.results[i]
+			icon = ui.StatusIcon(res.OK)
+			if res.OK {
+				status = ui.Subtle.Sprintf("%.1fs", res.Elapsed.Seconds())
+			} else {
+				status = ui.Bad.Sprint(firstLine(res.Err.Error()))
+			}
+		}
+		fmt.Printf("\033[2K  %s %-*s  %s\n", icon, l.width, name, status)
+	}
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
 // truncateLines splits text into lines and returns at most n lines.
 func truncateLines(s string, n int) []string {
 	lines := strings.Split(s, "\n")
@@ -1,9 +1,11 @@
 package prompt
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 	"time"
@@ -14,9 +16,21 @@ type Prompt struct {
 	Name      string
 	Content   string
 	Variables []string
+	Version   int
 	CreatedAt time.Time
 }
 
+// Version is one saved revision of a prompt.
+type Version struct {
+	Version   int       `json:"version"`
+	Content   string    `json:"content"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// varPattern matches {{name}} and {{ name }}.
+var varPattern = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.\-]+)\s*\}\}`)
+
 // promptDir returns the prompts directory path.
 func promptDir() string {
 	dir := os.Getenv("XDG_CONFIG_HOME")
@@ -27,14 +41,81 @@ func promptDir() string {
 	return filepath.Join(dir, "palm", "prompts")
 }
 
-// Save stores a prompt to disk.
+// historyPath returns where a prompt's versions are kept. History lives
+// beside the prompts directory so that directory stays plain markdown.
+func historyPath(name string) string {
+	return filepath.Join(filepath.Dir(promptDir()), "prompt-history", name+".json")
+}
+
+// ValidName reports whether name can be used as a prompt name.
+func ValidName(name string) error {
+	if name == "" || strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid prompt name %q", name)
+	}
+	return nil
+}
+
+// Save stores a prompt to disk as a new version.
 func Save(name, content string) error {
+	_, _, err := Add(name, content, "")
+	return err
+}
+
+// Add stores content as the latest version of name. Saving content identical
+// to the latest version changes nothing; changed reports whether a version
+// was added.
+func Add(name, content, note string) (version int, changed bool, err error) {
+	if err := ValidName(name); err != nil {
+		return 0, false, err
+	}
+	history, err := History(name)
+	if err != nil {
+		return 0, false, err
+	}
+	if n := len(history); n > 0 && history[n-1].Content == content {
+		return history[n-1].Version, false, nil
+	}
+
+	version = len(history) + 1
+	history = append(history, Version{Version: version, Content: content, Note: note, CreatedAt: time.Now()})
+
 	dir := promptDir()
 	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return err
+		return 0, false, err
+	}
+	if err := os.MkdirAll(filepath.Dir(historyPath(name)), 0o755); err != nil {
+		return 0, false, err
+	}
+	data, _ := json.MarshalIndent(history, "", "  ")
+	if err := os.WriteFile(historyPath(name), data, 0o644); err != nil {
+		return 0, false, err
 	}
 	path := filepath.Join(dir, name+".md")
-	return os.WriteFile(path, []byte(content), 0o644)
+	return version, true, os.WriteFile(path, []byte(content), 0o644)
+}
+
+// History returns every saved version of name, oldest first. A prompt saved
+// before versioning existed has its current content as version 1.
+func History(name string) ([]Version, error) {
+	data, err := os.ReadFile(historyPath(name))
+	if err == nil {
+		var history []Version
+		if err := json.Unmarshal(data, &history); err != nil {
+			return nil, fmt.Errorf("prompt %s history: %w", name, err)
+		}
+		return history, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	path := filepath.Join(promptDir(), name+".md")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil
+	}
+	info, _ := os.Stat(path)
+	return []Version{{Version: 1, Content: string(content), CreatedAt: info.ModTime()}}, nil
 }
 
 // Load reads a prompt from disk.
@@ -46,20 +127,60 @@ func Load(name string) (*Prompt, error) {
 	}
 	info, _ := os.Stat(path)
 	content := string(data)
-	return &Prompt{
+	p := &Prompt{
 		Name:      name,
 		Content:   content,
 		Variables: extractVariables(content),
+		Version:   1,
 		CreatedAt: info.ModTime(),
-	}, nil
+	}
+	if history, _ := History(name); len(history) > 0 {
+		p.Version = history[len(history)-1].Version
+	}
+	return p, nil
+}
+
+// LoadVersion reads a specific version of a prompt.
+func LoadVersion(name string, version int) (*Prompt, error) {
+	history, err := History(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(history) == 0 {
+		return nil, fmt.Errorf("prompt not found: %s", name)
+	}
+	for _, v := range history {
+		if v.Version == version {
+			return &Prompt{
+				Name:      name,
+				Content:   v.Content,
+				Variables: extractVariables(v.Content),
+				Version:   v.Version,
+				CreatedAt: v.CreatedAt,
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("prompt %s has no version %d (latest is %d)", name, version, history[len(history)-1].Version)
 }
 
-// Delete removes a prompt.
+// Rollback makes an earlier version the latest one again. History is kept:
+// the old content is saved as a new version.
+func Rollback(name string, version int) (int, error) {
+	p, err := LoadVersion(name, version)
+	if err != nil {
+		return 0, err
+	}
+	v, _, err := Add(name, p.Content, fmt.Sprintf("rollback to v%d", version))
+	return v, err
+}
+
+// Delete removes a prompt and its history.
 func Delete(name string) error {
 	path := filepath.Join(promptDir(), name+".md")
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		return fmt.Errorf("prompt not found: %s", name)
 	}
+	_ = os.Remove(historyPath(name))
 	return os.Remove(path)
 }
 
@@ -92,35 +213,42 @@ func List() ([]Prompt, error) {
 	return prompts, nil
 }
 
-// Render substitutes variables in a prompt.
+// Render substitutes variables in a prompt. Variables without a value are
+// left as they are.
 func Render(content string, vars map[string]string) string {
-	result := content
-	for k, v := range vars {
-		result = strings.ReplaceAll(result, "{{"+k+"}}", v)
+	return varPattern.ReplaceAllStringFunc(content, func(m string) string {
+		if v, ok := vars[varPattern.FindStringSubmatch(m)[1]]; ok {
+			return v
+		}
+		return m
+	})
+}
+
+// Missing returns the variables of content that vars gives no value for.
+func Missing(content string, vars map[string]string) []string {
+	var missing []string
+	for _, name := range extractVariables(content) {
+		if _, ok := vars[name]; !ok {
+			missing = append(missing, name)
+		}
 	}
-	return result
+	return missing
+}
+
+// Variables returns the {{variables}} of content in order of first use.
+func Variables(content string) []string {
+	return extractVariables(content)
 }
 
 // extractVariables finds all {{var}} patterns in content.
 func extractVariables(content string) []string {
 	seen := make(map[string]bool)
 	var vars []string
-	for {
-		start := strings.Index(content, "{{")
-		if start == -1 {
-			break
-		}
-		end := strings.Index(content[start:], "}}")
-		if end == -1 {
-			break
-		}
-		name := content[start+2 : start+end]
-		name = strings.TrimSpace(name)
-		if name != "" && !seen[name] {
+	for _, m := range varPattern.FindAllStringSubmatch(content, -1) {
+		if name := m[1]; !seen[name] {
 			vars = append(vars, name)
 			seen[name] = true
 		}
-		content = content[start+end+2:]
 	}
 	return vars
 }
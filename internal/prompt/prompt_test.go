@@ -0,0 +1,89 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAddVersions(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	if v, changed, err := Add("review", "Review {{diff}}", ""); err != nil || v != 1 || !changed {
+		t.Fatalf("first save = v%d %v %v", v, changed, err)
+	}
+	if v, changed, _ := Add("review", "Review {{diff}}", ""); v != 1 || changed {
+		t.Errorf("identical save should not add a version, got v%d", v)
+	}
+	if v, _, _ := Add("review", "Review {{ diff }} for {{lang}}", "add lang"); v != 2 {
+		t.Errorf("expected v2, got v%d", v)
+	}
+
+	p, err := Load("review")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Version != 2 || !reflect.DeepEqual(p.Variables, []string{"diff", "lang"}) {
+		t.Errorf("unexpected latest: %+v", p)
+	}
+	old, err := LoadVersion("review", 1)
+	if err != nil || old.Content != "Review {{diff}}" {
+		t.Errorf("v1 = %+v, %v", old, err)
+	}
+	if _, err := LoadVersion("review", 9); err == nil {
+		t.Error("expected error for unknown version")
+	}
+
+	if v, err := Rollback("review", 1); err != nil || v != 3 {
+		t.Fatalf("rollback = v%d, %v", v, err)
+	}
+	history, _ := History("review")
+	if len(history) != 3 || history[2].Note != "rollback to v1" || history[2].Content != "Review {{diff}}" {
+		t.Errorf("unexpected history: %+v", history)
+	}
+
+	if err := Delete("review"); err != nil {
+		t.Fatal(err)
+	}
+	if history, _ := History("review"); len(history) != 0 {
+		t.Error("delete should remove history")
+	}
+}
+
+func TestHistoryOfUnversionedPrompt(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	os.MkdirAll(promptDir(), 0o755)
+	os.WriteFile(filepath.Join(promptDir(), "legacy.md"), []byte("old prompt"), 0o644)
+
+	history, err := History("legacy")
+	if err != nil || len(history) != 1 || history[0].Content != "old prompt" {
+		t.Fatalf("legacy prompt should be v1: %+v %v", history, err)
+	}
+	if v, _, _ := Add("legacy", "new prompt", ""); v != 2 {
+		t.Errorf("expected v2 after legacy v1, got v%d", v)
+	}
+}
+
+func TestRenderAndMissing(t *testing.T) {
+	content := "Review {{diff}} in {{ lang }}; keep {{unset}}"
+	vars := map[string]string{"diff": "+x", "lang": "Go"}
+
+	if got := Render(content, vars); got != "Review +x in Go; keep {{unset}}" {
+		t.Errorf("Render = %q", got)
+	}
+	if got := Missing(content, vars); !reflect.DeepEqual(got, []string{"unset"}) {
+		t.Errorf("Missing = %v", got)
+	}
+}
+
+func TestValidName(t *testing.T) {
+	for _, name := range []string{"", ".hidden", "a/b", `a\b`} {
+		if ValidName(name) == nil {
+			t.Errorf("%q should be invalid", name)
+		}
+	}
+	if err := ValidName("code-review"); err != nil {
+		t.Error(err)
+	}
+}
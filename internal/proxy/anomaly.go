@@ -0,0 +1,361 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/msalah0e/palm/internal/notify"
+)
+
+// AnomalyConfig flags providers whose request rate or spend jumps far above
+// their usual level, such as an agent stuck in a loop. The usual level is
+// learned from the proxy log.
+type AnomalyConfig struct {
+	Off bool `toml:"off"`
+	// Factor is how many times the baseline counts as an anomaly; 0 means 10.
+	Factor float64 `toml:"factor"`
+	// WindowMinutes is the length of the windows compared; 0 means 5.
+	WindowMinutes int `toml:"window_minutes"`
+	// MinRequests keeps quiet providers from being flagged over a handful
+	// of requests; 0 means 20.
+	MinRequests int `toml:"min_requests"`
+	// PauseAt pauses routing to the provider once it runs this many times
+	// above the baseline, until palm proxy resume. 0 never pauses.
+	PauseAt float64 `toml:"pause_at"`
+	Notify  bool    `toml:"notify"`
+	Webhook string  `toml:"webhook"`
+}
+
+func (c AnomalyConfig) validate() error {
+	switch {
+	case c.Factor < 0 || (c.Factor > 0 && c.Factor <= 1):
+		return fmt.Errorf("anomaly: factor must be above 1")
+	case c.WindowMinutes < 0 || c.MinRequests < 0:
+		return fmt.Errorf("anomaly: window_minutes and min_requests can't be negative")
+	case c.PauseAt < 0 || (c.PauseAt > 0 && c.PauseAt < c.factor()):
+		return fmt.Errorf("anomaly: pause_at must be at least factor (%g)", c.factor())
+	}
+	return nil
+}
+
+func (c AnomalyConfig) factor() float64 {
+	if c.Factor == 0 {
+		return 10
+	}
+	return c.Factor
+}
+
+// Window returns the length of the windows compared.
+func (c AnomalyConfig) Window() time.Duration {
+	if c.WindowMinutes == 0 {
+		return 5 * time.Minute
+	}
+	return time.Duration(c.WindowMinutes) * time.Minute
+}
+
+func (c AnomalyConfig) minRequests() int {
+	if c.MinRequests == 0 {
+		return 20
+	}
+	return c.MinRequests
+}
+
+// minBaselineWindows is how many windows of use a provider needs in the log
+// before it has a baseline; until then it is never flagged.
+const minBaselineWindows = 12
+
+// Baseline is a provider's usual activity in a window it is used in.
+type Baseline struct {
+	Requests float64 `json:"requests"`
+	Cost     float64 `json:"cost"`
+	Windows  int     `json:"windows"` // windows of use it was learned from
+}
+
+// LearnBaselines takes the median requests and cost per window over the
+// windows each provider was used in. Providers with too little history are
+// left out.
+func LearnBaselines(logs []RequestLog, window time.Duration) map[string]Baseline {
+	type bucket struct {
+		provider string
+		slot     int64
+	}
+	requests := map[bucket]float64{}
+	costs := map[bucket]float64{}
+	for _, l := range logs {
+		if l.Provider == "" {
+			continue
+		}
+		b := bucket{l.Provider, l.Timestamp.UnixNano() / int64(window)}
+		requests[b]++
+		costs[b] += l.Cost
+	}
+
+	counts := map[string][]float64{}
+	spend := map[string][]float64{}
+	for b, n := range requests {
+		counts[b.provider] = append(counts[b.provider], n)
+		spend[b.provider] = append(spend[b.provider], costs[b])
+	}
+	baselines := map[string]Baseline{}
+	for p, c := range counts {
+		if len(c) < minBaselineWindows {
+			continue
+		}
+		baselines[p] = Baseline{Requests: median(c), Cost: median(spend[p]), Windows: len(c)}
+	}
+	return baselines
+}
+
+func median(v []float64) float64 {
+	sort.Float64s(v)
+	mid := len(v) / 2
+	if len(v)%2 == 0 {
+		return (v[mid-1] + v[mid]) / 2
+	}
+	return v[mid]
+}
+
+// Anomaly is a window in which a provider ran far above its baseline. It is
+// also the webhook payload.
+type Anomaly struct {
+	Provider string    `json:"provider"`
+	At       time.Time `json:"at"`
+	Metric   string    `json:"metric"` // "requests" or "cost"
+	Requests int       `json:"requests"`
+	Cost     float64   `json:"cost"`
+	Times    float64   `json:"times"` // how many times the baseline
+	Baseline Baseline  `json:"baseline"`
+	Paused   bool      `json:"paused"`
+	Text     string    `json:"text"`
+}
+
+// baselineTTL is how long learned baselines are used before the log is read
+// again.
+const baselineTTL = time.Hour
+
+// anomalyDetector keeps each provider's recent requests and compares them
+// with its baseline.
+type anomalyDetector struct {
+	cfg       AnomalyConfig
+	mu        sync.Mutex
+	baselines map[string]Baseline
+	learnedAt time.Time
+	recent    map[string][]sample
+	flagged   map[string]Anomaly // last anomaly per provider, to flag once per window
+	learning  bool               // a relearn is running
+	learn     func() map[string]Baseline
+}
+
+type sample struct {
+	at   time.Time
+	cost float64
+}
+
+func newAnomalyDetector(cfg AnomalyConfig) *anomalyDetector {
+	d := &anomalyDetector{cfg: cfg, recent: map[string][]sample{}, flagged: map[string]Anomaly{}}
+	d.learn = func() map[string]Baseline {
+		logs, _ := ReadLogs(50000)
+		return LearnBaselines(logs, cfg.Window())
+	}
+	return d
+}
+
+// record adds a request and returns the anomaly it completes, if any.
+func (d *anomalyDetector) record(provider string, cost float64, at time.Time) *Anomaly {
+	if d == nil || d.cfg.Off || provider == "" {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Reading the log is slow, so baselines are learned in the background
+	// and requests are compared with the previous ones meanwhile
+	if !d.learning && (d.baselines == nil || at.Sub(d.learnedAt) > baselineTTL) {
+		d.learning = true
+		go d.relearn(at)
+	}
+
+	window := d.cfg.Window()
+	kept := d.recent[provider][:0]
+	for _, s := range d.recent[provider] {
+		if at.Sub(s.at) < window {
+			kept = append(kept, s)
+		}
+	}
+	kept = append(kept, sample{at, cost})
+	d.recent[provider] = kept
+
+	base, ok := d.baselines[provider]
+	if !ok || len(kept) < d.cfg.minRequests() {
+		return nil
+	}
+	spent := 0.0
+	for _, s := range kept {
+		spent += s.cost
+	}
+
+	a := &Anomaly{Provider: provider, At: at, Requests: len(kept), Cost: spent, Baseline: base}
+	if base.Requests > 0 {
+		a.Metric, a.Times = "requests", float64(len(kept))/base.Requests
+	}
+	if base.Cost > 0 && spent/base.Cost > a.Times {
+		a.Metric, a.Times = "cost", spent/base.Cost
+	}
+	if a.Times < d.cfg.factor() {
+		return nil
+	}
+	a.Paused = d.cfg.PauseAt > 0 && a.Times >= d.cfg.PauseAt
+
+	// Flag once per window, unless the provider has since become due a pause
+	if last, seen := d.flagged[provider]; seen && at.Sub(last.At) < window && (last.Paused || !a.Paused) {
+		return nil
+	}
+	d.flagged[provider] = *a
+
+	minutes := int(window.Minutes())
+	if a.Metric == "cost" {
+		a.Text = fmt.Sprintf("%s: $%.2f spent in %d min, %.0fx the usual $%.2f", provider, spent, minutes, a.Times, base.Cost)
+	} else {
+		a.Text = fmt.Sprintf("%s: %d requests in %d min, %.0fx the usual %.0f", provider, len(kept), minutes, a.Times, base.Requests)
+	}
+	return a
+}
+
+// relearn learns baselines from the log without holding the lock and swaps
+// them in.
+func (d *anomalyDetector) relearn(at time.Time) {
+	baselines := d.learn()
+	d.mu.Lock()
+	d.baselines, d.learnedAt, d.learning = baselines, at, false
+	d.mu.Unlock()
+}
+
+// raise acts on an anomaly: it pauses the provider when due and logs it,
+// then sends the configured alerts in the background.
+func (d *anomalyDetector) raise(a *Anomaly) {
+	if a.Paused {
+		if err := PauseProvider(a.Provider, a.Text); err != nil {
+			log.Printf("anomaly: pausing %s: %v", a.Provider, err)
+			a.Paused = false
+		} else {
+			a.Text += " — routing paused, resume with: palm proxy resume " + a.Provider
+		}
+	}
+	log.Printf("anomaly: %s", a.Text)
+	alert := *a
+	go func() {
+		if d.cfg.Notify {
+			if err := notify.Desktop("palm proxy", alert.Text); err != nil {
+				log.Printf("anomaly: notify: %v", err)
+			}
+		}
+		if d.cfg.Webhook != "" {
+			if err := notify.Webhook(d.cfg.Webhook, alert); err != nil {
+				log.Printf("anomaly: webhook: %v", err)
+			}
+		}
+	}()
+}
+
+// Pause is a provider the proxy has stopped routing to.
+type Pause struct {
+	At     time.Time `json:"at"`
+	Reason string    `json:"reason"`
+}
+
+// PausedPath returns the file listing paused providers. It outlives the
+// proxy, so a restart doesn't resume a runaway provider.
+func PausedPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "palm", "proxy-paused.json")
+}
+
+// LoadPaused returns the paused providers.
+func LoadPaused() (map[string]Pause, error) {
+	data, err := os.ReadFile(PausedPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]Pause{}, nil
+		}
+		return nil, err
+	}
+	paused := map[string]Pause{}
+	if err := json.Unmarshal(data, &paused); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(PausedPath()), err)
+	}
+	return paused, nil
+}
+
+func savePaused(paused map[string]Pause) error {
+	path := PausedPath()
+	if len(paused) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(paused, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// PauseProvider stops the proxy from routing to provider until it is resumed.
+func PauseProvider(provider, reason string) error {
+	paused, err := LoadPaused()
+	if err != nil {
+		return err
+	}
+	paused[provider] = Pause{At: time.Now(), Reason: reason}
+	return savePaused(paused)
+}
+
+// ResumeProvider lifts the pause on provider, or on every provider when
+// provider is empty, and returns the providers resumed.
+func ResumeProvider(provider string) ([]string, error) {
+	paused, err := LoadPaused()
+	if err != nil {
+		return nil, err
+	}
+	var resumed []string
+	for p := range paused {
+		if provider == "" || p == provider {
+			resumed = append(resumed, p)
+			delete(paused, p)
+		}
+	}
+	sort.Strings(resumed)
+	if len(resumed) == 0 {
+		return nil, nil
+	}
+	return resumed, savePaused(paused)
+}
+
+// pausedError returns the message for requests to a paused provider, or ""
+// when provider isn't paused.
+func pausedError(provider string) string {
+	paused, err := LoadPaused()
+	if err != nil {
+		return ""
+	}
+	p, ok := paused[provider]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("palm proxy: routing to %s is paused since %s after a usage anomaly (%s) — resume with: palm proxy resume %s",
+		provider, p.At.Format("15:04"), p.Reason, provider)
+}
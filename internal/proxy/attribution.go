@@ -0,0 +1,130 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ToolHeader names the tool a request comes from, so its cost can be
+// attributed per tool.
+const ToolHeader = "X-Palm-Tool"
+
+// toolPrefix is the path form of ToolHeader for tools that can't send
+// custom headers: /tool/<name>/openai/v1/... is /openai/v1/... from <name>.
+// palm run adds it to base URLs that point at the proxy.
+const toolPrefix = "/tool/"
+
+var toolNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// requestTool returns the tool a request is tagged with, by header or path
+// prefix, and strips the tag so it doesn't reach the upstream.
+func requestTool(r *http.Request) string {
+	tool := r.Header.Get(ToolHeader)
+	r.Header.Del(ToolHeader)
+	if rest, ok := strings.CutPrefix(r.URL.Path, toolPrefix); ok {
+		name, path, _ := strings.Cut(rest, "/")
+		r.URL.Path = "/" + path
+		r.URL.RawPath = ""
+		if tool == "" {
+			tool = name
+		}
+	}
+	tool = toolNameChars.ReplaceAllString(tool, "-")
+	if len(tool) > 64 {
+		tool = tool[:64]
+	}
+	return tool
+}
+
+// ToolURL returns base, a URL that points at the proxy, tagged with tool.
+func ToolURL(base, tool string) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(u.Path, toolPrefix) {
+		return base, nil
+	}
+	u.Path = toolPrefix + toolNameChars.ReplaceAllString(tool, "-") + "/" + strings.TrimPrefix(u.Path, "/")
+	return u.String(), nil
+}
+
+// IsProxyURL reports whether rawURL points at a palm proxy on this machine.
+// It asks the server behind the URL for its palm status; the proxy may serve
+// HTTPS with a self-signed certificate, so the certificate isn't checked.
+func IsProxyURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	if host := u.Hostname(); host != "localhost" {
+		if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+			return false
+		}
+	}
+
+	client := &http.Client{
+		Timeout:   300 * time.Millisecond,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+	}
+	resp, err := client.Get(u.Scheme + "://" + u.Host + "/palm/status")
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	var status struct {
+		Status string `json:"status"`
+	}
+	return resp.StatusCode == http.StatusOK && json.NewDecoder(resp.Body).Decode(&status) == nil && status.Status == "running"
+}
+
+// ToolCost is one tool's share of logged proxy traffic.
+type ToolCost struct {
+	Tool         string  `json:"tool"` // "" for untagged requests
+	Requests     int     `json:"requests"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	Cost         float64 `json:"cost"`
+}
+
+// CostByTool totals logged requests since a time per calling tool, most
+// expensive first.
+func CostByTool(logs []RequestLog, since time.Time) []ToolCost {
+	byTool := make(map[string]*ToolCost)
+	for _, l := range logs {
+		if l.Timestamp.Before(since) {
+			continue
+		}
+		t := byTool[l.Tool]
+		if t == nil {
+			t = &ToolCost{Tool: l.Tool}
+			byTool[l.Tool] = t
+		}
+		t.Requests++
+		t.InputTokens += l.InputTokens
+		t.OutputTokens += l.OutputTokens
+		t.Cost += l.Cost
+	}
+
+	out := make([]ToolCost, 0, len(byTool))
+	for _, t := range byTool {
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Cost != out[j].Cost {
+			return out[i].Cost > out[j].Cost
+		}
+		if out[i].Requests != out[j].Requests {
+			return out[i].Requests > out[j].Requests
+		}
+		return out[i].Tool < out[j].Tool
+	})
+	return out
+}
@@ -0,0 +1,134 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestTool(t *testing.T) {
+	tests := []struct {
+		path, header   string
+		tool, wantPath string
+	}{
+		{"/v1/chat/completions", "", "", "/v1/chat/completions"},
+		{"/v1/chat/completions", "aider", "aider", "/v1/chat/completions"},
+		{"/tool/aider/openai/v1/chat/completions", "", "aider", "/openai/v1/chat/completions"},
+		{"/tool/aider/v1/messages", "claude", "claude", "/v1/messages"},
+		{"/v1/messages", "bad name;rm", "bad-name-rm", "/v1/messages"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("POST", tt.path, nil)
+		if tt.header != "" {
+			r.Header.Set(ToolHeader, tt.header)
+		}
+		if got := requestTool(r); got != tt.tool {
+			t.Errorf("%s %q: tool = %q, want %q", tt.path, tt.header, got, tt.tool)
+		}
+		if r.URL.Path != tt.wantPath {
+			t.Errorf("%s: path = %q, want %q", tt.path, r.URL.Path, tt.wantPath)
+		}
+		if r.Header.Get(ToolHeader) != "" {
+			t.Errorf("%s: %s header not stripped", tt.path, ToolHeader)
+		}
+	}
+}
+
+func TestToolURL(t *testing.T) {
+	tests := []struct{ base, want string }{
+		{"http://localhost:4778", "http://localhost:4778/tool/aider/"},
+		{"http://localhost:4778/openai/v1", "http://localhost:4778/tool/aider/openai/v1"},
+		{"http://localhost:4778/tool/other/v1", "http://localhost:4778/tool/other/v1"},
+	}
+	for _, tt := range tests {
+		if got, err := ToolURL(tt.base, "aider"); err != nil || got != tt.want {
+			t.Errorf("ToolURL(%q) = %q, %v; want %q", tt.base, got, err, tt.want)
+		}
+	}
+}
+
+func TestIsProxyURL(t *testing.T) {
+	srv := New(Config{})
+	ts := httptest.NewServer(http.HandlerFunc(srv.handleStatus))
+	defer ts.Close()
+
+	if !IsProxyURL(ts.URL + "/v1") {
+		t.Errorf("%s should be a proxy", ts.URL)
+	}
+	if IsProxyURL("https://api.openai.com/v1") {
+		t.Error("remote URLs should never be probed")
+	}
+
+	other := httptest.NewServer(http.NotFoundHandler())
+	defer other.Close()
+	if IsProxyURL(other.URL) {
+		t.Errorf("%s isn't a proxy", other.URL)
+	}
+}
+
+func TestCostByTool(t *testing.T) {
+	now := time.Now()
+	logs := []RequestLog{
+		{Timestamp: now, Tool: "aider", InputTokens: 100, OutputTokens: 50, Cost: 0.02},
+		{Timestamp: now, Tool: "aider", InputTokens: 10, OutputTokens: 5, Cost: 0.01},
+		{Timestamp: now, Tool: "claude", Cost: 0.05},
+		{Timestamp: now, Cost: 0.01},
+		{Timestamp: now.AddDate(0, 0, -10), Tool: "old", Cost: 1},
+	}
+
+	got := CostByTool(logs, now.AddDate(0, 0, -1))
+	if len(got) != 3 {
+		t.Fatalf("CostByTool = %+v", got)
+	}
+	if got[0].Tool != "claude" || got[1].Tool != "aider" || got[2].Tool != "" {
+		t.Errorf("order = %+v", got)
+	}
+	a := got[1]
+	if a.Requests != 2 || a.InputTokens != 110 || a.OutputTokens != 55 || a.Cost < 0.0299 || a.Cost > 0.0301 {
+		t.Errorf("aider = %+v", a)
+	}
+}
+
+func TestHandleRequestAttributesTool(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var upstreamPath string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamPath = r.URL.Path
+		if r.Header.Get(ToolHeader) != "" {
+			t.Errorf("%s header reached the upstream", ToolHeader)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"ok"}}],"usage":{"prompt_tokens":12,"completion_tokens":3}}`))
+	}))
+	defer upstream.Close()
+
+	orig := compatBases["ollama"]
+	compatBases["ollama"] = upstream.URL + "/v1"
+	defer func() { compatBases["ollama"] = orig }()
+
+	srv := New(Config{})
+	srv.SetRoutes(&RouteConfig{Routes: []Route{{Model: "fast", Provider: "ollama", Target: "llama3.3"}}})
+
+	body := `{"model":"fast","messages":[{"role":"user","content":"hi"}]}`
+	req := httptest.NewRequest("POST", "/tool/aider/v1/chat/completions", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	srv.handleRequest(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(ToolHeader, "aider")
+	srv.handleRequest(httptest.NewRecorder(), req)
+
+	if upstreamPath != "/v1/chat/completions" {
+		t.Errorf("upstream path = %q", upstreamPath)
+	}
+	if srv.stats.ByTool["aider"] != 2 {
+		t.Errorf("ByTool = %v", srv.stats.ByTool)
+	}
+	if srv.stats.TotalTokens != 30 {
+		t.Errorf("TotalTokens = %d, want 30", srv.stats.TotalTokens)
+	}
+}
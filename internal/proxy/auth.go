@@ -0,0 +1,253 @@
+package proxy
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// tokenPrefix marks palm proxy access tokens so they are easy to tell apart
+// from provider keys.
+const tokenPrefix = "palm_"
+
+// AccessToken lets a client on another machine use the proxy. Only a hash
+// of the token is stored; the token itself is shown once, when created.
+type AccessToken struct {
+	Name      string    `json:"name"`
+	Hash      string    `json:"hash"` // hex SHA-256 of the token
+	Hint      string    `json:"hint"` // last characters of the token
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TokensPath returns the path of the access token store.
+func TokensPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "palm", "proxy-tokens.json")
+}
+
+// LoadTokens returns the access tokens sorted by name.
+func LoadTokens() ([]AccessToken, error) {
+	data, err := os.ReadFile(TokensPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var tokens []AccessToken
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(TokensPath()), err)
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].Name < tokens[j].Name })
+	return tokens, nil
+}
+
+func saveTokens(tokens []AccessToken) error {
+	path := TokensPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// AddToken creates an access token for the named client and returns it.
+func AddToken(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("token name cannot be empty")
+	}
+	tokens, err := LoadTokens()
+	if err != nil {
+		return "", err
+	}
+	for _, t := range tokens {
+		if t.Name == name {
+			return "", fmt.Errorf("a token named %q already exists (revoke it first)", name)
+		}
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	secret := tokenPrefix + hex.EncodeToString(buf)
+	tokens = append(tokens, AccessToken{
+		Name:      name,
+		Hash:      hashToken(secret),
+		Hint:      secret[len(secret)-4:],
+		CreatedAt: time.Now(),
+	})
+	if err := saveTokens(tokens); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// RevokeToken deletes the named access token.
+func RevokeToken(name string) error {
+	tokens, err := LoadTokens()
+	if err != nil {
+		return err
+	}
+	for i, t := range tokens {
+		if t.Name == name {
+			return saveTokens(append(tokens[:i], tokens[i+1:]...))
+		}
+	}
+	return fmt.Errorf("no token named %q", name)
+}
+
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// matchToken returns the name of the token secret belongs to.
+func matchToken(tokens []AccessToken, secret string) (string, bool) {
+	if secret == "" {
+		return "", false
+	}
+	hash := []byte(hashToken(secret))
+	for _, t := range tokens {
+		if subtle.ConstantTimeCompare(hash, []byte(t.Hash)) == 1 {
+			return t.Name, true
+		}
+	}
+	return "", false
+}
+
+// presentedSecrets returns the credentials a client sent, in the places
+// OpenAI, Anthropic and Google clients put their API key.
+func presentedSecrets(r *http.Request) []string {
+	var secrets []string
+	if v, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		secrets = append(secrets, strings.TrimSpace(v))
+	}
+	for _, h := range []string{"x-api-key", "x-goog-api-key"} {
+		if v := r.Header.Get(h); v != "" {
+			secrets = append(secrets, v)
+		}
+	}
+	if v := r.URL.Query().Get("key"); v != "" {
+		secrets = append(secrets, v)
+	}
+	return secrets
+}
+
+// stripToken removes a matched access token from the request so it never
+// reaches the upstream provider.
+func stripToken(r *http.Request) {
+	r.Header.Del("Authorization")
+	r.Header.Del("x-api-key")
+	r.Header.Del("x-goog-api-key")
+	if q := r.URL.Query(); q.Has("key") {
+		q.Del("key")
+		r.URL.RawQuery = q.Encode()
+	}
+}
+
+type clientKey struct{}
+
+// clientName returns the access token name the request was authenticated
+// with, or "" for requests from this machine that sent none.
+func clientName(r *http.Request) string {
+	name, _ := r.Context().Value(clientKey{}).(string)
+	return name
+}
+
+// authenticate admits requests carrying a known access token, recording the
+// token's name for the logs, and requests from this machine. Everything else
+// is turned away. Tokens are re-read on every request so revoking one takes
+// effect without a restart.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens, err := LoadTokens()
+		if err != nil {
+			http.Error(w, "palm proxy: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, secret := range presentedSecrets(r) {
+			if name, ok := matchToken(tokens, secret); ok {
+				stripToken(r)
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientKey{}, name)))
+				return
+			}
+		}
+		if isLoopbackAddr(r.RemoteAddr) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		provider, _, _ := s.resolveProvider(r.URL.Path)
+		s.writeLog(RequestLog{
+			Timestamp: time.Now(),
+			Method:    r.Method,
+			Path:      r.URL.Path,
+			Provider:  provider,
+			Status:    http.StatusUnauthorized,
+		})
+		if s.cfg.Verbose {
+			log.Printf("rejected %s %s from %s: missing or unknown token", r.Method, r.URL.Path, r.RemoteAddr)
+		}
+		w.Header().Set("WWW-Authenticate", `Bearer realm="palm proxy"`)
+		http.Error(w, "palm proxy: missing or unknown access token — create one with 'palm proxy token add <name>'", http.StatusUnauthorized)
+	})
+}
+
+// isLoopbackAddr reports whether a remote "host:port" address is on this
+// machine.
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// IsLoopbackHost reports whether listening on host keeps the proxy private
+// to this machine.
+func IsLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// LANAddrs returns this machine's non-loopback IP addresses.
+func LANAddrs() []string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, a := range addrs {
+		ipnet, ok := a.(*net.IPNet)
+		if !ok || ipnet.IP.IsLoopback() || ipnet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		out = append(out, ipnet.IP.String())
+	}
+	return out
+}
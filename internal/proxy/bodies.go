@@ -0,0 +1,83 @@
+package proxy
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// maxLoggedBody caps each logged request and response body.
+const maxLoggedBody = 1 << 20
+
+// BodyLog is the request and response body of a proxied call, logged when
+// the proxy runs with body logging on. RequestLog.ID links the two.
+type BodyLog struct {
+	ID        string `json:"id"`
+	Request   string `json:"request"`
+	Response  string `json:"response"` // as sent to the client; may be an SSE stream
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// BodiesPath returns the body log. It holds prompts and completions, so it
+// is only written with --log-bodies and only readable by its owner.
+func BodiesPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "palm", "proxy-bodies.jsonl")
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (s *Server) writeBodies(entry BodyLog) {
+	if s.bodyFile == nil {
+		return
+	}
+	if len(entry.Request) > maxLoggedBody {
+		entry.Request, entry.Truncated = entry.Request[:maxLoggedBody], true
+	}
+	if len(entry.Response) > maxLoggedBody {
+		entry.Response, entry.Truncated = entry.Response[:maxLoggedBody], true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = json.NewEncoder(s.bodyFile).Encode(entry)
+}
+
+// ReadBodies returns the logged bodies with the given IDs.
+func ReadBodies(ids []string) (map[string]BodyLog, error) {
+	want := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		want[id] = true
+	}
+
+	f, err := os.Open(BodiesPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]BodyLog{}, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	found := make(map[string]BodyLog)
+	dec := json.NewDecoder(f)
+	for dec.More() {
+		var b BodyLog
+		if err := dec.Decode(&b); err != nil {
+			break
+		}
+		if want[b.ID] {
+			found[b.ID] = b
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,247 @@
+package proxy
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/msalah0e/palm/internal/models"
+	"github.com/msalah0e/palm/internal/tokens"
+)
+
+// ContextConfig decides what happens to requests too long for their model's
+// context window, which palm knows from the model catalog.
+type ContextConfig struct {
+	// Policy is "reject" (default) to answer 400 without calling the
+	// provider, "truncate" to drop the oldest conversation turns until the
+	// request fits, or "off".
+	Policy string `toml:"policy"`
+}
+
+func (c ContextConfig) validate() error {
+	switch c.Policy {
+	case "", "reject", "truncate", "off":
+		return nil
+	}
+	return fmt.Errorf("context: unknown policy %q (use reject, truncate or off)", c.Policy)
+}
+
+// contextPolicy returns the configured policy, "reject" when unset.
+func (c *RouteConfig) contextPolicy() string {
+	if c == nil || c.Context.Policy == "" {
+		return "reject"
+	}
+	return c.Context.Policy
+}
+
+// contextWindow returns the context window of b from the model catalog, or
+// 0 when it isn't known.
+func contextWindow(b Backend) int {
+	for _, m := range models.AllModels() {
+		if m.Provider == b.Provider && m.ID == b.Model {
+			return m.Context
+		}
+	}
+	return 0
+}
+
+var (
+	tokenizerMu sync.Mutex
+	tokenizers  = map[string]tokens.Tokenizer{}
+)
+
+// tokenizerFor returns the tokenizer to measure b's prompts with, and
+// whether its counts are exact rather than an estimate.
+func tokenizerFor(b Backend) (tokens.Tokenizer, bool) {
+	name, real := tokens.EncodingForModel(b.Provider, b.Model)
+	tokenizerMu.Lock()
+	defer tokenizerMu.Unlock()
+	t, ok := tokenizers[name]
+	if !ok {
+		t = tokens.LoadTokenizer(name)
+		tokenizers[name] = t
+	}
+	return t, real && t != tokens.Estimator
+}
+
+// messageOverhead is the tokens each chat message costs beyond its text,
+// for the role and separators.
+const messageOverhead = 4
+
+// contextUsage is a request's size against its model's window.
+type contextUsage struct {
+	Prompt int  // estimated prompt tokens
+	Output int  // max_tokens reserved for the reply
+	Window int  // the model's context window
+	Exact  bool // Prompt was counted with the model's tokenizer
+}
+
+// limit is the size a request may reach. Estimates can run 10-20% high, so
+// they get that much slack rather than rejecting requests that would fit.
+func (u contextUsage) limit() int {
+	if u.Exact {
+		return u.Window
+	}
+	return u.Window + u.Window/10
+}
+
+func (u contextUsage) over() bool {
+	return u.Window > 0 && u.Prompt+u.Output > u.limit()
+}
+
+// measureContext estimates the tokens of a chat request body in the OpenAI
+// or Anthropic format.
+func measureContext(body map[string]any, b Backend) contextUsage {
+	t, exact := tokenizerFor(b)
+	u := contextUsage{Window: contextWindow(b), Exact: exact}
+	u.Prompt = countText(t, body["system"])
+	if msgs, ok := body["messages"].([]any); ok {
+		for _, m := range msgs {
+			u.Prompt += messageTokens(t, m)
+		}
+	}
+	u.Prompt += countText(t, body["tools"])
+	for _, key := range []string{"max_tokens", "max_completion_tokens"} {
+		if n, ok := body[key].(float64); ok && n > 0 {
+			u.Output = int(n)
+			break
+		}
+	}
+	return u
+}
+
+func messageTokens(t tokens.Tokenizer, m any) int {
+	msg, ok := m.(map[string]any)
+	if !ok {
+		return 0
+	}
+	return messageOverhead + countText(t, msg["content"]) + countText(t, msg["tool_calls"])
+}
+
+// countText counts the text in a message field: a string, a list of content
+// parts, or any other JSON, which is counted as its text values.
+func countText(t tokens.Tokenizer, v any) int {
+	switch v := v.(type) {
+	case nil:
+		return 0
+	case string:
+		return t.Count(v)
+	case []any:
+		n := 0
+		for _, item := range v {
+			n += countText(t, item)
+		}
+		return n
+	case map[string]any:
+		n := 0
+		for k, item := range v {
+			// Images and other binary parts aren't text the window holds
+			// as tokens in any way palm can estimate
+			if k == "image_url" || k == "source" || k == "data" {
+				continue
+			}
+			n += countText(t, item)
+		}
+		return n
+	}
+	return 0
+}
+
+// truncateContext drops the oldest messages, keeping system messages and
+// the latest one, until the request fits. It returns how many messages were
+// dropped and whether the request fits now.
+func truncateContext(body map[string]any, b Backend, format apiFormat) (int, bool) {
+	msgs, ok := body["messages"].([]any)
+	if !ok {
+		return 0, false
+	}
+	u := measureContext(body, b)
+	if !u.over() {
+		return 0, true
+	}
+
+	// Each message is measured once and its size taken off as it's dropped
+	t, _ := tokenizerFor(b)
+	if format == formatAnthropic {
+		return truncateTurns(body, msgs, t, u)
+	}
+	gone := make([]bool, len(msgs))
+	drop := func(i int) {
+		u.Prompt -= messageTokens(t, msgs[i])
+		gone[i] = true
+	}
+	dropped, last := 0, len(msgs)-1
+	for i := 0; i < last && u.over(); i++ {
+		if role := messageRole(msgs[i]); role == "system" || role == "developer" {
+			continue
+		}
+		drop(i)
+		dropped++
+		// A tool result can't outlive the call it answers
+		for i+1 < last && messageRole(msgs[i+1]) == "tool" {
+			i++
+			drop(i)
+			dropped++
+		}
+	}
+	if dropped > 0 {
+		kept := make([]any, 0, len(msgs)-dropped)
+		for i, m := range msgs {
+			if !gone[i] {
+				kept = append(kept, m)
+			}
+		}
+		body["messages"] = kept
+	}
+	return dropped, !u.over()
+}
+
+// truncateTurns truncates an Anthropic conversation, which must open with a
+// user turn and can't hold a tool_result without the tool_use it answers.
+// It cuts only in front of a user message without tool results, so whole
+// turns go together with their tool calls.
+func truncateTurns(body map[string]any, msgs []any, t tokens.Tokenizer, u contextUsage) (int, bool) {
+	cut, rest := 0, u
+	for j := 1; j < len(msgs) && u.over(); j++ {
+		rest.Prompt -= messageTokens(t, msgs[j-1])
+		if messageRole(msgs[j]) == "user" && !hasToolResult(msgs[j]) {
+			cut, u = j, rest
+		}
+	}
+	if cut > 0 {
+		body["messages"] = append([]any(nil), msgs[cut:]...)
+	}
+	return cut, !u.over()
+}
+
+// hasToolResult reports whether an Anthropic message carries tool_result
+// blocks.
+func hasToolResult(m any) bool {
+	msg, _ := m.(map[string]any)
+	blocks, _ := msg["content"].([]any)
+	for _, block := range blocks {
+		if b, ok := block.(map[string]any); ok && b["type"] == "tool_result" {
+			return true
+		}
+	}
+	return false
+}
+
+func messageRole(m any) string {
+	msg, _ := m.(map[string]any)
+	role, _ := msg["role"].(string)
+	return role
+}
+
+// contextError describes a request too long for its model.
+func contextError(b Backend, u contextUsage) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "palm proxy: request needs about %d tokens", u.Prompt+u.Output)
+	if u.Output > 0 {
+		fmt.Fprintf(&sb, " (%d prompt + %d max_tokens)", u.Prompt, u.Output)
+	}
+	fmt.Fprintf(&sb, " but %s has a %d-token context window", b, u.Window)
+	sb.WriteString(" — shorten the conversation, lower max_tokens, pick a longer-context model,")
+	fmt.Fprintf(&sb, " or set policy = \"truncate\" under [context] in %s", RoutesPath())
+	return sb.String()
+}
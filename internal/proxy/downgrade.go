@@ -0,0 +1,83 @@
+package proxy
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/msalah0e/palm/internal/budget"
+)
+
+// DowngradeConfig swaps requests to cheaper models once spending nears the
+// budget, so tools keep working on a smaller model for longer. Past the
+// limit requests are refused with 402 unless PastLimit is set.
+type DowngradeConfig struct {
+	// At is the share of the budget, in percent, from which requests are
+	// downgraded; 0 uses the budget's alert_at.
+	At float64 `toml:"at"`
+	// PastLimit keeps serving downgraded models once the budget is
+	// exceeded instead of refusing them, so spending can go over the limit.
+	PastLimit bool `toml:"past_limit"`
+	// Models maps requested models ("*" globs allowed) to the cheaper model
+	// of the same provider that serves them instead.
+	Models map[string]string `toml:"models"`
+}
+
+// Enabled reports whether any model has a cheaper replacement.
+func (d DowngradeConfig) Enabled() bool {
+	return len(d.Models) > 0
+}
+
+// Cheaper returns the model to serve instead of model. An exact entry wins
+// over patterns, and longer patterns over shorter ones.
+func (d DowngradeConfig) Cheaper(model string) (string, bool) {
+	if model == "" || !d.Enabled() {
+		return "", false
+	}
+	if to, ok := d.Models[model]; ok {
+		return to, to != model
+	}
+	patterns := make([]string, 0, len(d.Models))
+	for p := range d.Models {
+		patterns = append(patterns, p)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) > len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, model); ok {
+			to := d.Models[p]
+			return to, to != model
+		}
+	}
+	return "", false
+}
+
+// Due reports whether spending has reached the downgrade threshold, and how
+// much of the budget is used.
+func (d DowngradeConfig) Due() (bool, float64) {
+	used, alertAt := budget.Used()
+	at := d.At
+	if at == 0 {
+		at = alertAt
+	}
+	return used > 0 && used >= at, used
+}
+
+func (d DowngradeConfig) validate() error {
+	if d.At < 0 || d.At > 100 {
+		return fmt.Errorf("downgrade: at must be a percentage between 0 and 100")
+	}
+	for from, to := range d.Models {
+		if _, err := path.Match(from, ""); err != nil {
+			return fmt.Errorf("downgrade.models: bad pattern %q: %w", from, err)
+		}
+		if to == "" {
+			return fmt.Errorf("downgrade.models: %q needs a model to downgrade to", from)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,353 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/msalah0e/palm/internal/budget"
+)
+
+// FailoverConfig is the [failover] section of routes.toml.
+type FailoverConfig struct {
+	Retries      int                 `toml:"retries"`        // extra attempts per backend on 429/5xx
+	BackoffMS    int                 `toml:"backoff_ms"`     // first retry delay, doubled each time
+	MaxBackoffMS int                 `toml:"max_backoff_ms"` // cap, also applied to Retry-After
+	Fallbacks    map[string][]string `toml:"fallbacks"`      // provider → "provider/model" backends
+}
+
+const (
+	defaultBackoff    = 500 * time.Millisecond
+	defaultMaxBackoff = 8 * time.Second
+)
+
+func (f FailoverConfig) backoff(attempt int, retryAfter string) time.Duration {
+	maxWait := defaultMaxBackoff
+	if f.MaxBackoffMS > 0 {
+		maxWait = time.Duration(f.MaxBackoffMS) * time.Millisecond
+	}
+	if secs, err := strconv.Atoi(retryAfter); err == nil && secs >= 0 {
+		return min(time.Duration(secs)*time.Second, maxWait)
+	}
+	wait := defaultBackoff
+	if f.BackoffMS > 0 {
+		wait = time.Duration(f.BackoffMS) * time.Millisecond
+	}
+	return min(wait<<attempt, maxWait)
+}
+
+// shouldFailover reports whether a status is worth retrying elsewhere.
+func shouldFailover(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// apiFormat is the wire format of a chat request.
+type apiFormat int
+
+const (
+	formatOther     apiFormat = iota
+	formatOpenAI              // POST .../chat/completions
+	formatAnthropic           // POST .../v1/messages
+)
+
+func detectFormat(method, path string) apiFormat {
+	if method != http.MethodPost {
+		return formatOther
+	}
+	switch {
+	case strings.HasSuffix(path, "/chat/completions"):
+		return formatOpenAI
+	case strings.HasSuffix(path, "/v1/messages"):
+		return formatAnthropic
+	}
+	return formatOther
+}
+
+// upstreamCall is one attempt at serving a request.
+type upstreamCall struct {
+	backend   Backend
+	url       string
+	body      []byte
+	header    http.Header
+	translate bool // response is OpenAI format but the client speaks Anthropic
+}
+
+// fallbackCall builds the attempt against a fallback backend, translating
+// Anthropic requests to the OpenAI-compatible format when needed. It reports
+// false when the backend can't serve the request: streaming through
+// translation, budget exhausted or no key.
+func (s *Server) fallbackCall(b Backend, format apiFormat, body map[string]any, orig http.Header) (upstreamCall, bool) {
+	stream, _ := body["stream"].(bool)
+	if format == formatAnthropic && b.Provider != "anthropic" && stream {
+		return upstreamCall{}, false
+	}
+	if !s.usable(b.Provider) || budget.CheckBudget(b.Provider) != nil {
+		return upstreamCall{}, false
+	}
+	payload := cloneBody(body)
+	c := upstreamCall{backend: b, header: make(http.Header)}
+	c.header.Set("Content-Type", "application/json")
+
+	switch {
+	case format == formatAnthropic && b.Provider == "anthropic":
+		c.url = "https://api.anthropic.com/v1/messages"
+		c.header.Set("anthropic-version", orDefault(orig.Get("anthropic-version"), "2023-06-01"))
+	case format == formatAnthropic:
+		payload = anthropicToOpenAI(payload)
+		c.url = compatBases[b.Provider] + "/chat/completions"
+		c.translate = true
+	default:
+		c.url = compatBases[b.Provider] + "/chat/completions"
+	}
+	payload["model"] = b.Model
+	c.body, _ = json.Marshal(payload)
+	s.setAuth(c.header, b.Provider)
+	return c, true
+}
+
+// failoverState carries the last failed response across attempts.
+type failoverState struct {
+	attempts   int
+	lastStatus int
+	lastBody   []byte
+	lastHeader http.Header
+}
+
+// failover sends primary, then each fallback in order, retrying each with
+// backoff on 429/5xx, and streams the first acceptable response to w. A
+// fallback is only prepared, and its provider's queue slot taken, once the
+// attempts before it have failed. When every attempt fails the last upstream
+// error response is passed through.
+func (s *Server) failover(w http.ResponseWriter, r *http.Request, primary upstreamCall, fallbacks []Backend, format apiFormat, body map[string]any, cfg FailoverConfig) (served Backend, attempts int) {
+	st := &failoverState{}
+	last := primary.backend
+	for i := 0; i <= len(fallbacks); i++ {
+		c := primary
+		if i > 0 {
+			var ok bool
+			if c, ok = s.fallbackCall(fallbacks[i-1], format, body, r.Header); !ok {
+				continue
+			}
+		}
+		last = c.backend
+		done, canceled := s.attempt(w, r, c, i > 0 && c.backend.Provider != primary.backend.Provider, cfg, st)
+		if done {
+			if i > 0 {
+				s.mu.Lock()
+				s.stats.Failovers++
+				s.mu.Unlock()
+			}
+			return c.backend, st.attempts
+		}
+		if canceled {
+			return c.backend, st.attempts
+		}
+	}
+
+	for k, vs := range st.lastHeader {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+	w.Header().Del("Content-Length")
+	if st.lastStatus == 0 {
+		st.lastStatus = http.StatusBadGateway
+	}
+	w.WriteHeader(st.lastStatus)
+	_, _ = w.Write(st.lastBody)
+	return last, st.attempts
+}
+
+// attempt tries one backend with retries and writes its response to w when
+// it succeeds. With limit set it first takes a slot from the provider's
+// [limits] queue; the primary's slot is already held by the caller.
+func (s *Server) attempt(w http.ResponseWriter, r *http.Request, c upstreamCall, limit bool, cfg FailoverConfig, st *failoverState) (done, canceled bool) {
+	if l := s.limiters[c.backend.Provider]; limit && l != nil {
+		if _, err := l.acquire(r.Context()); err != nil {
+			st.lastStatus, st.lastBody = http.StatusTooManyRequests, []byte(fmt.Sprintf("palm proxy: %s %v", c.backend.Provider, err))
+			st.lastHeader = http.Header{"Retry-After": {"1"}}
+			return false, r.Context().Err() != nil
+		}
+		defer l.release()
+	}
+
+	for try := 0; try <= max(cfg.Retries, 0); try++ {
+		// Back off between retries of the same backend; a new backend
+		// is tried straight away.
+		if try > 0 {
+			select {
+			case <-time.After(cfg.backoff(try-1, st.lastHeader.Get("Retry-After"))):
+			case <-r.Context().Done():
+				return false, true
+			}
+		}
+		st.attempts++
+
+		req, err := http.NewRequestWithContext(r.Context(), r.Method, c.url, bytes.NewReader(c.body))
+		if err != nil {
+			return false, false
+		}
+		req.Header = c.header.Clone()
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			st.lastStatus, st.lastBody, st.lastHeader = http.StatusBadGateway, []byte(err.Error()), http.Header{}
+			continue
+		}
+		if shouldFailover(resp.StatusCode) {
+			st.lastStatus, st.lastHeader = resp.StatusCode, resp.Header
+			st.lastBody, _ = io.ReadAll(resp.Body)
+			resp.Body.Close()
+			continue
+		}
+		writeUpstream(w, resp, c)
+		return true, false
+	}
+	return false, false
+}
+
+// writeUpstream copies a successful upstream response to the client,
+// flushing as it goes so streamed responses stay streamed.
+func writeUpstream(w http.ResponseWriter, resp *http.Response, c upstreamCall) {
+	defer resp.Body.Close()
+	if c.translate {
+		data, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode < 300 {
+			if out, err := openAIToAnthropic(data); err == nil {
+				data = out
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(resp.StatusCode)
+		_, _ = w.Write(data)
+		return
+	}
+
+	for k, vs := range resp.Header {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	flusher, _ := w.(http.Flusher)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := resp.Body.Read(buf)
+		if n > 0 {
+			_, _ = w.Write(buf[:n])
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func cloneBody(body map[string]any) map[string]any {
+	out := make(map[string]any, len(body))
+	for k, v := range body {
+		out[k] = v
+	}
+	return out
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+// anthropicToOpenAI converts a Messages API request into a chat completion
+// request. Only text content is carried over.
+func anthropicToOpenAI(in map[string]any) map[string]any {
+	out := map[string]any{}
+	var messages []map[string]any
+	if sys := contentText(in["system"]); sys != "" {
+		messages = append(messages, map[string]any{"role": "system", "content": sys})
+	}
+	if msgs, ok := in["messages"].([]any); ok {
+		for _, m := range msgs {
+			msg, _ := m.(map[string]any)
+			role, _ := msg["role"].(string)
+			messages = append(messages, map[string]any{"role": role, "content": contentText(msg["content"])})
+		}
+	}
+	out["messages"] = messages
+	for _, k := range []string{"max_tokens", "temperature", "top_p"} {
+		if v, ok := in[k]; ok {
+			out[k] = v
+		}
+	}
+	if stop, ok := in["stop_sequences"]; ok {
+		out["stop"] = stop
+	}
+	return out
+}
+
+// contentText flattens a string or a list of content blocks into text.
+func contentText(v any) string {
+	switch c := v.(type) {
+	case string:
+		return c
+	case []any:
+		var parts []string
+		for _, block := range c {
+			if b, ok := block.(map[string]any); ok {
+				if text, ok := b["text"].(string); ok {
+					parts = append(parts, text)
+				}
+			}
+		}
+		return strings.Join(parts, "\n")
+	}
+	return ""
+}
+
+// openAIToAnthropic converts a chat completion response into a Messages API
+// response.
+func openAIToAnthropic(data []byte) ([]byte, error) {
+	var in struct {
+		ID      string `json:"id"`
+		Model   string `json:"model"`
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, err
+	}
+	if len(in.Choices) == 0 {
+		return nil, fmt.Errorf("no choices in response")
+	}
+
+	stop := "end_turn"
+	switch in.Choices[0].FinishReason {
+	case "length":
+		stop = "max_tokens"
+	case "stop_sequence":
+		stop = "stop_sequence"
+	}
+	return json.Marshal(map[string]any{
+		"id":          in.ID,
+		"type":        "message",
+		"role":        "assistant",
+		"model":       in.Model,
+		"content":     []map[string]any{{"type": "text", "text": in.Choices[0].Message.Content}},
+		"stop_reason": stop,
+		"usage":       map[string]int{"input_tokens": in.Usage.PromptTokens, "output_tokens": in.Usage.CompletionTokens},
+	})
+}
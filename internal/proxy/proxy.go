@@ -1,51 +1,85 @@
 package proxy
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/msalah0e/palm/internal/budget"
+	"github.com/msalah0e/palm/internal/serve"
 	"github.com/msalah0e/palm/internal/vault"
 )
 
 // Config holds proxy configuration.
 type Config struct {
 	Port    int
+	Listen  string // host to listen on; empty means 127.0.0.1
 	LogFile string
 	Verbose bool
+
+	// LogBodies also logs request and response bodies, for palm proxy replay.
+	LogBodies bool
+
+	// TLS serves HTTPS, with TLSCert and TLSKey if set or else a generated
+	// self-signed certificate.
+	TLS     bool
+	TLSCert string
+	TLSKey  string
 }
 
 // RequestLog represents a logged API request.
 type RequestLog struct {
-	Timestamp    time.Time `json:"ts"`
-	Method       string    `json:"method"`
-	Path         string    `json:"path"`
-	Provider     string    `json:"provider"`
-	Model        string    `json:"model,omitempty"`
-	Status       int       `json:"status"`
-	Duration     float64   `json:"duration_ms"`
-	InputTokens  int64     `json:"input_tokens,omitempty"`
-	OutputTokens int64     `json:"output_tokens,omitempty"`
-	Cost         float64   `json:"cost,omitempty"`
+	ID             string    `json:"id,omitempty"` // links to the body log
+	Timestamp      time.Time `json:"ts"`
+	Method         string    `json:"method"`
+	Path           string    `json:"path"`
+	Provider       string    `json:"provider"`
+	Model          string    `json:"model,omitempty"`
+	Alias          string    `json:"alias,omitempty"`           // model the client asked for, when routed
+	FailoverFrom   string    `json:"failover_from,omitempty"`   // backend that failed before this one served
+	DowngradedFrom string    `json:"downgraded_from,omitempty"` // model swapped for a cheaper one near the budget
+	QueuedMS       float64   `json:"queued_ms,omitempty"`       // wait for a free slot under [limits]; not in duration_ms
+	Transforms     []string  `json:"transforms,omitempty"`      // [transform] changes, e.g. "pii:2", "system-prompt"
+	Attempts       int       `json:"attempts,omitempty"`
+	Status         int       `json:"status"`
+	Duration       float64   `json:"duration_ms"`
+	InputTokens    int64     `json:"input_tokens,omitempty"`
+	OutputTokens   int64     `json:"output_tokens,omitempty"`
+	Cost           float64   `json:"cost,omitempty"`
+	Client         string    `json:"client,omitempty"` // access token name, for requests from other machines
+	Tool           string    `json:"tool,omitempty"`   // calling tool, from the X-Palm-Tool header or palm run
+	Stream         string    `json:"stream,omitempty"` // "sse" or "websocket" for streamed responses
+	Events         int       `json:"events,omitempty"` // events streamed either way
 }
 
 // Server is the palm proxy server.
 type Server struct {
-	cfg     Config
-	v       vault.Vault
-	logFile *os.File
-	mu      sync.Mutex
-	stats   ProxyStats
+	cfg      Config
+	v        vault.Vault
+	logFile  *os.File
+	bodyFile *os.File // nil unless bodies are logged
+	mu       sync.Mutex
+	stats    ProxyStats
+	routes   *RouteConfig
+	latency  map[string]float64 // backend → mean response time in ms
+	limiters map[string]*limiter
+	hooks    []EventHook
+
+	anomalies *anomalyDetector
 }
 
 // ProxyStats tracks real-time proxy statistics.
@@ -55,8 +89,21 @@ type ProxyStats struct {
 	TotalCost     float64
 	StartedAt     time.Time
 	ByProvider    map[string]int64
+	Routed        int64
+	ByModel       map[string]int64
+	Failovers     int64
+	Downgrades    int64
+	Transformed   int64
+	ByClient      map[string]int64
+	ByTool        map[string]int64
+	CostByTool    map[string]float64
+	Queues        map[string]QueueStats // providers with [limits], filled in per request
+	Anomalies     []Anomaly             // the most recent, oldest first
 }
 
+// maxAnomalies is how many anomalies /palm/stats keeps.
+const maxAnomalies = 20
+
 // providerRoutes maps path prefixes to upstream targets.
 var providerRoutes = map[string]string{
 	"/openai/":    "https://api.openai.com",
@@ -65,6 +112,8 @@ var providerRoutes = map[string]string{
 	"/groq/":      "https://api.groq.com",
 	"/mistral/":   "https://api.mistral.ai",
 	"/ollama/":    "http://localhost:11434",
+	"/llama-cpp/": "http://127.0.0.1:8080",
+	"/vllm/":      "http://127.0.0.1:8000",
 }
 
 // providerKeys maps provider name to the vault key for auth.
@@ -84,8 +133,44 @@ func New(cfg Config) *Server {
 		stats: ProxyStats{
 			StartedAt:  time.Now(),
 			ByProvider: make(map[string]int64),
+			ByModel:    make(map[string]int64),
+			ByClient:   make(map[string]int64),
+			ByTool:     make(map[string]int64),
+			CostByTool: make(map[string]float64),
 		},
+		routes:   &RouteConfig{},
+		latency:  make(map[string]float64),
+		limiters: make(map[string]*limiter),
+	}
+}
+
+// SetRoutes installs a routing config.
+func (s *Server) SetRoutes(cfg *RouteConfig) {
+	s.routes = cfg
+	s.limiters = newLimiters(cfg.Limits)
+	s.anomalies = newAnomalyDetector(cfg.Anomaly)
+}
+
+// latencyWeight is the EWMA weight of the newest sample.
+const latencyWeight = 0.3
+
+// seedLatency primes the latency table from past proxy logs so the fastest
+// strategy has data right after a restart.
+func (s *Server) seedLatency(logs []RequestLog) {
+	for _, l := range logs {
+		if l.Model != "" && l.Status < 400 {
+			s.observe(Backend{Provider: l.Provider, Model: l.Model}, l.Duration)
+		}
+	}
+}
+
+func (s *Server) observe(b Backend, ms float64) {
+	key := b.String()
+	if prev, ok := s.latency[key]; ok {
+		s.latency[key] = prev*(1-latencyWeight) + ms*latencyWeight
+		return
 	}
+	s.latency[key] = ms
 }
 
 // Start begins serving the proxy.
@@ -107,38 +192,231 @@ func (s *Server) Start() error {
 	if err != nil {
 		return fmt.Errorf("failed to open log file: %w", err)
 	}
+	if s.cfg.LogBodies {
+		s.bodyFile, err = os.OpenFile(BodiesPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return fmt.Errorf("failed to open body log: %w", err)
+		}
+	}
+
+	// Servers started by palm serve, which may not be on default ports
+	if insts, err := serve.Instances(); err == nil {
+		for _, inst := range insts {
+			_ = RegisterLocal(inst.Runtime, inst.Root(), inst.Model)
+		}
+	}
+
+	routes, err := LoadRoutes()
+	if err != nil {
+		return fmt.Errorf("routes: %w", err)
+	}
+	s.SetRoutes(routes)
+	if logs, err := ReadLogs(1000); err == nil {
+		s.seedLatency(logs)
+	}
+
+	host := s.cfg.Listen
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	s.cfg.Listen = host
+	if !IsLoopbackHost(host) {
+		tokens, err := LoadTokens()
+		if err != nil {
+			return err
+		}
+		if len(tokens) == 0 {
+			return fmt.Errorf("listening on %s would expose your API keys to the network; create an access token first: palm proxy token add <name>", host)
+		}
+	}
+
+	certFile, keyFile := s.cfg.TLSCert, s.cfg.TLSKey
+	if (certFile == "") != (keyFile == "") {
+		return fmt.Errorf("--tls-cert and --tls-key must be given together")
+	}
+	if s.cfg.TLS && certFile == "" {
+		certFile, keyFile, err = SelfSignedCert(CertHosts(host))
+		if err != nil {
+			return fmt.Errorf("self-signed certificate: %w", err)
+		}
+	}
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", s.handleRequest)
 	mux.HandleFunc("/palm/status", s.handleStatus)
 	mux.HandleFunc("/palm/stats", s.handleStats)
 
-	addr := fmt.Sprintf(":%d", s.cfg.Port)
-	log.Printf("palm proxy listening on http://localhost%s\n", addr)
+	scheme := "http"
+	if certFile != "" {
+		scheme = "https"
+	}
+	base := fmt.Sprintf("%s://localhost:%d", scheme, s.cfg.Port)
+	addr := net.JoinHostPort(host, strconv.Itoa(s.cfg.Port))
+	log.Printf("palm proxy listening on %s://%s\n", scheme, addr)
 	log.Printf("Routes:")
 	for prefix, target := range providerRoutes {
-		log.Printf("  http://localhost%s%s → %s", addr, prefix, target)
+		log.Printf("  %s%s → %s", base, prefix, target)
+	}
+	log.Printf("  %s/v1/ → by model (%d routing rules)", base, len(s.routes.Routes))
+	log.Printf("\nSet OPENAI_BASE_URL=%s/openai/v1 to route through proxy", base)
+	log.Printf("Set OPENAI_BASE_URL=%s/v1 to route by model and alias", base)
+	if !IsLoopbackHost(host) {
+		log.Printf("\nOther machines need an access token as their API key (palm proxy token add <name>)")
+		if certFile != "" {
+			if fp, err := CertFingerprint(certFile); err == nil {
+				log.Printf("Certificate %s\n  SHA-256 %s", certFile, fp)
+			}
+		}
 	}
-	log.Printf("\nSet OPENAI_BASE_URL=http://localhost%s/openai/v1 to route through proxy", addr)
 
-	return http.ListenAndServe(addr, mux)
+	handler := s.authenticate(mux)
+	if certFile != "" {
+		return http.ListenAndServeTLS(addr, certFile, keyFile, handler)
+	}
+	return http.ListenAndServe(addr, handler)
 }
 
 func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
+	tool := requestTool(r)
 
 	// Determine provider from path
 	provider, target, trimmedPath := s.resolveProvider(r.URL.Path)
-	if provider == "" {
-		http.Error(w, "unknown provider — use /openai/, /anthropic/, /google/, etc.", http.StatusBadGateway)
+
+	// Read the requested model so routing rules can apply
+	var body map[string]any
+	var raw []byte
+	var requested, model string
+	if r.Body != nil && strings.Contains(r.Header.Get("Content-Type"), "json") {
+		raw, _ = io.ReadAll(r.Body)
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(raw))
+		if json.Unmarshal(raw, &body) == nil {
+			requested, _ = body["model"].(string)
+			model = requested
+		}
+	}
+	websocket := isWebSocket(r)
+	if websocket {
+		// Realtime sessions name their model in the query
+		requested = r.URL.Query().Get("model")
+		model = requested
+	}
+
+	var route *Route
+	if requested != "" && body != nil {
+		route = s.routes.Match(requested)
+	}
+	switch {
+	case provider == "" && strings.HasPrefix(r.URL.Path, "/v1/"):
+		// Model-routed endpoint: the rule or the model decides the provider
+		backend := Backend{Provider: InferProvider(requested), Model: requested}
+		if route != nil {
+			var err error
+			backend, err = route.Resolve(requested, s.usable, s.latencySnapshot())
+			if err != nil {
+				http.Error(w, "palm proxy: "+err.Error(), http.StatusBadGateway)
+				return
+			}
+		}
+		if backend.Provider == "" {
+			http.Error(w, fmt.Sprintf("palm proxy: no route for model %q — add one to %s", requested, RoutesPath()), http.StatusBadGateway)
+			return
+		}
+		provider, target, trimmedPath = backend.Provider, compatBases[backend.Provider], strings.TrimPrefix(r.URL.Path, "/v1")
+		model = backend.Model
+		if route != nil {
+			route.Apply(body, backend)
+		}
+	case provider == "":
+		http.Error(w, "unknown provider — use /v1/ (routed by model), /openai/, /anthropic/, /google/, etc.", http.StatusBadGateway)
+		return
+	case route != nil && route.Provider == provider:
+		// Provider-prefixed paths keep their provider; only overrides apply
+		backend := Backend{Provider: provider, Model: route.backends(requested)[0].Model}
+		model = backend.Model
+		route.Apply(body, backend)
+	default:
+		route = nil
+	}
+
+	// Providers paused after a usage anomaly stay paused until palm proxy resume
+	if msg := pausedError(provider); msg != "" {
+		http.Error(w, msg, http.StatusServiceUnavailable)
 		return
 	}
 
-	// Budget check
-	if err := budget.CheckBudget(provider); err != nil {
-		http.Error(w, fmt.Sprintf("palm proxy: budget exceeded — %v", err), http.StatusPaymentRequired)
+	// Budget check. Models with a downgrade switch to the cheaper one from
+	// the downgrade threshold on. Past the limit they are refused like the
+	// rest unless the downgrade is configured to keep serving them.
+	budgetErr := budget.CheckBudget(provider)
+	var downgradedFrom string
+	if s.routes != nil && body != nil {
+		if cheaper, ok := s.routes.Downgrade.Cheaper(model); ok {
+			due, used := false, 0.0
+			if budgetErr == nil {
+				due, used = s.routes.Downgrade.Due()
+			} else if s.routes.Downgrade.PastLimit {
+				due, budgetErr = true, nil
+			}
+			if due {
+				downgradedFrom, model = model, cheaper
+				body["model"] = cheaper
+				if used > 0 {
+					log.Printf("budget at %.0f%%: downgraded %s → %s", used, downgradedFrom, cheaper)
+				} else {
+					log.Printf("budget exceeded: downgraded %s → %s", downgradedFrom, cheaper)
+				}
+			}
+		}
+	}
+	if budgetErr != nil {
+		http.Error(w, fmt.Sprintf("palm proxy: budget exceeded — %v", budgetErr), http.StatusPaymentRequired)
 		return
 	}
+	if downgradedFrom != "" {
+		w.Header().Set("X-Palm-Downgraded", downgradedFrom+" -> "+model)
+	}
+
+	// Org-wide transforms go first, so the context guard sees the prompt
+	// that is actually sent
+	var transforms []string
+	format := detectFormat(r.Method, trimmedPath)
+	if s.routes != nil && format != formatOther && body != nil && s.routes.Transform.For(provider) {
+		transforms = s.routes.Transform.applyTransforms(body, format, r.Header)
+		if len(transforms) > 0 {
+			w.Header().Set("X-Palm-Transformed", strings.Join(transforms, ", "))
+			if s.cfg.Verbose {
+				log.Printf("[%s] transformed request: %s", provider, strings.Join(transforms, ", "))
+			}
+		}
+	}
+
+	// Context-window guard: a request the model can't hold would only come
+	// back as an opaque provider error after a wasted call
+	truncated := 0
+	if policy := s.routes.contextPolicy(); policy != "off" && format != formatOther && body != nil {
+		backend := Backend{Provider: provider, Model: model}
+		if usage := measureContext(body, backend); usage.over() {
+			fits := false
+			if policy == "truncate" {
+				truncated, fits = truncateContext(body, backend, format)
+			}
+			if !fits {
+				http.Error(w, contextError(backend, usage), http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("X-Palm-Truncated", strconv.Itoa(truncated))
+			log.Printf("%s: dropped %d oldest messages to fit the context window", backend, truncated)
+		}
+	}
+
+	if route != nil || downgradedFrom != "" || truncated > 0 || len(transforms) > 0 {
+		raw, _ = json.Marshal(body)
+		r.Body = io.NopCloser(bytes.NewReader(raw))
+		r.ContentLength = int64(len(raw))
+		r.Header.Set("Content-Length", strconv.Itoa(len(raw)))
+	}
 
 	// Parse upstream URL
 	upstream, err := url.Parse(target)
@@ -147,38 +425,63 @@ func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create reverse proxy
-	proxy := httputil.NewSingleHostReverseProxy(upstream)
-
-	// Inject API key from vault
-	if keyName, ok := providerKeys[provider]; ok {
-		key := os.Getenv(keyName)
-		if key == "" {
-			if val, err := s.v.Get(keyName); err == nil {
-				key = val
-			}
+	// Wait for a free slot when the provider's in-flight requests are capped
+	var queued time.Duration
+	if l := s.limiters[provider]; l != nil {
+		queued, err = l.acquire(r.Context())
+		if err != nil {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, fmt.Sprintf("palm proxy: %s %v (%d in flight)", provider, err, l.cfg.MaxInFlight), http.StatusTooManyRequests)
+			return
 		}
-		if key != "" {
-			switch provider {
-			case "anthropic":
-				r.Header.Set("x-api-key", key)
-			default:
-				r.Header.Set("Authorization", "Bearer "+key)
-			}
+		defer l.release()
+		if queued > 0 && s.cfg.Verbose {
+			log.Printf("[%s] queued %.0fms for a free slot", provider, float64(queued.Milliseconds()))
 		}
 	}
 
-	// Update request path to strip the provider prefix
-	r.URL.Path = trimmedPath
-	r.URL.Host = upstream.Host
-	r.URL.Scheme = upstream.Scheme
-	r.Host = upstream.Host
+	// Inject API key from vault
+	s.setAuth(r.Header, provider)
 
-	// Capture response
-	rec := &responseRecorder{ResponseWriter: w}
-	proxy.ServeHTTP(rec, r)
+	// Capture response, following any stream event by event
+	hooks := s.hooks
+	if s.cfg.Verbose {
+		hooks = append(append([]EventHook(nil), s.hooks...), logEvent)
+	}
+	tap := &streamTap{provider: provider, path: trimmedPath, hooks: hooks}
+	rec := &responseRecorder{ResponseWriter: w, tap: tap, websocket: websocket}
+	if websocket {
+		// Without compression the messages can be read for accounting
+		r.Header.Del("Sec-WebSocket-Extensions")
+	}
+	primary := Backend{Provider: provider, Model: model}
+	served, attempts := primary, 1
+
+	fallbacks := s.routes.Fallbacks(primary, route)
+	if format != formatOther && body != nil && len(fallbacks) > 0 {
+		// Failover needs to see the status before anything reaches the
+		// client, so the upstream is called directly instead of proxied.
+		call := upstreamCall{backend: primary, url: target + trimmedPath, body: raw, header: r.Header.Clone()}
+		if r.URL.RawQuery != "" {
+			call.url += "?" + r.URL.RawQuery
+		}
+		for _, h := range []string{"Connection", "Keep-Alive", "Proxy-Connection", "Te", "Trailer", "Transfer-Encoding", "Upgrade", "Content-Length"} {
+			call.header.Del(h)
+		}
+		served, attempts = s.failover(rec, r, call, fallbacks, format, body, s.routes.Failover)
+		r.URL.Path = trimmedPath
+	} else {
+		// Update request path to strip the provider prefix
+		r.URL.Path = trimmedPath
+		r.URL.Host = upstream.Host
+		r.URL.Scheme = upstream.Scheme
+		r.Host = upstream.Host
+
+		httputil.NewSingleHostReverseProxy(upstream).ServeHTTP(rec, r)
+	}
 
-	elapsed := time.Since(start)
+	elapsed := time.Since(start) - queued
+	provider, model = served.Provider, served.Model
 
 	// Log the request
 	entry := RequestLog{
@@ -186,20 +489,205 @@ func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 		Method:    r.Method,
 		Path:      r.URL.Path,
 		Provider:  provider,
+		Model:     model,
 		Status:    rec.statusCode,
 		Duration:  float64(elapsed.Milliseconds()),
+		Client:    clientName(r),
+		Tool:      tool,
+		QueuedMS:  float64(queued.Milliseconds()),
+	}
+	kind, events, in, out := tap.usage()
+	entry.Stream, entry.Events = kind, events
+	if rec.statusCode < 400 {
+		if events > 0 {
+			entry.InputTokens, entry.OutputTokens = in, out
+		} else {
+			_, entry.InputTokens, entry.OutputTokens = parseResponse(rec.body)
+		}
+		entry.Cost, _ = priceTokens(served, entry.InputTokens, entry.OutputTokens)
+	}
+	if route != nil && requested != model {
+		entry.Alias = requested
+	}
+	entry.DowngradedFrom = downgradedFrom
+	entry.Transforms = transforms
+	if served != primary {
+		entry.FailoverFrom = primary.String()
+	}
+	if attempts > 1 {
+		entry.Attempts = attempts
+	}
+	if s.bodyFile != nil && raw != nil {
+		entry.ID = newRequestID()
+		s.writeBodies(BodyLog{ID: entry.ID, Request: string(raw), Response: string(rec.body)})
 	}
 
 	s.mu.Lock()
 	s.stats.TotalRequests++
+	s.stats.TotalTokens += entry.InputTokens + entry.OutputTokens
+	s.stats.TotalCost += entry.Cost
 	s.stats.ByProvider[provider]++
+	if model != "" {
+		s.stats.ByModel[model]++
+		if rec.statusCode < 400 {
+			s.observe(Backend{Provider: provider, Model: model}, entry.Duration)
+		}
+	}
+	if route != nil {
+		s.stats.Routed++
+	}
+	if downgradedFrom != "" {
+		s.stats.Downgrades++
+	}
+	if len(transforms) > 0 {
+		s.stats.Transformed++
+	}
+	if entry.Client != "" {
+		s.stats.ByClient[entry.Client]++
+	}
+	if entry.Tool != "" {
+		s.stats.ByTool[entry.Tool]++
+		s.stats.CostByTool[entry.Tool] += entry.Cost
+	}
 	s.mu.Unlock()
 
 	s.writeLog(entry)
 
+	if a := s.anomalies.record(provider, entry.Cost, start); a != nil {
+		s.anomalies.raise(a)
+		s.mu.Lock()
+		s.stats.Anomalies = append(s.stats.Anomalies, *a)
+		if n := len(s.stats.Anomalies); n > maxAnomalies {
+			s.stats.Anomalies = s.stats.Anomalies[n-maxAnomalies:]
+		}
+		s.mu.Unlock()
+	}
+
 	if s.cfg.Verbose {
 		log.Printf("[%s] %s %s → %d (%.0fms)", provider, r.Method, r.URL.Path, rec.statusCode, entry.Duration)
+		if entry.FailoverFrom != "" {
+			log.Printf("  failed over from %s after %d attempts", entry.FailoverFrom, attempts)
+		}
+	}
+}
+
+// setAuth adds the provider's API key from the environment or the vault.
+func (s *Server) setAuth(h http.Header, provider string) {
+	Authorize(h, s.v, provider, "proxy:"+provider)
+}
+
+// Authorize adds provider's API key to h, taken from the environment or
+// else the vault, where the use is recorded against consumer.
+func Authorize(h http.Header, v vault.Vault, provider, consumer string) {
+	keyName, ok := providerKeys[provider]
+	if !ok {
+		return
+	}
+	key := os.Getenv(keyName)
+	if key == "" {
+		if val, err := v.Get(keyName); err == nil {
+			key = val
+			recordUse(consumer, keyName)
+		}
+	}
+	if key == "" {
+		return
+	}
+	switch provider {
+	case "anthropic":
+		h.Set("x-api-key", key)
+	default:
+		h.Set("Authorization", "Bearer "+key)
+	}
+}
+
+// recorded holds when each consumer's use of a key was last recorded in
+// this process, keyed by consumer and key name.
+var recorded sync.Map
+
+// recordUse records a key use unless one was recorded within
+// vault.UseGranularity, so busy proxies don't go to disk on every request.
+func recordUse(consumer, keyName string) {
+	id := consumer + "\x00" + keyName
+	now := time.Now()
+	if last, ok := recorded.Load(id); ok && now.Sub(last.(time.Time)) < vault.UseGranularity {
+		return
+	}
+	recorded.Store(id, now)
+	_ = vault.RecordUse(consumer, keyName)
+}
+
+func (s *Server) usable(provider string) bool {
+	return KeyAvailable(s.v, provider) && pausedError(provider) == ""
+}
+
+// KeyAvailable reports whether requests to provider can be authenticated,
+// from the environment or the vault. Local providers need no key.
+func KeyAvailable(v vault.Vault, provider string) bool {
+	keyName, ok := providerKeys[provider]
+	if !ok {
+		return true
+	}
+	if os.Getenv(keyName) != "" {
+		return true
+	}
+	_, err := v.Get(keyName)
+	return err == nil
+}
+
+// KeyedProviders lists the providers that need an API key, sorted.
+func KeyedProviders() []string {
+	providers := make([]string, 0, len(providerKeys))
+	for p := range providerKeys {
+		providers = append(providers, p)
+	}
+	sort.Strings(providers)
+	return providers
+}
+
+// ErrKeyRejected is returned by CheckKey when the provider refuses the key.
+var ErrKeyRejected = errors.New("key rejected")
+
+var keyCheckClient = &http.Client{Timeout: 10 * time.Second}
+
+// CheckKey lists provider's models with its key to confirm the key still
+// works. It returns ErrKeyRejected when the provider answers 401 or 403;
+// other errors mean the provider could not be asked.
+func CheckKey(v vault.Vault, provider string) error {
+	base, ok := compatBases[provider]
+	if !ok {
+		return fmt.Errorf("unknown provider %q", provider)
+	}
+	req, err := http.NewRequest("GET", base+"/models", nil)
+	if err != nil {
+		return err
+	}
+	Authorize(req.Header, v, provider, "health:"+provider)
+	if provider == "anthropic" {
+		req.Header.Set("anthropic-version", "2023-06-01")
+	}
+	resp, err := keyCheckClient.Do(req)
+	if err != nil {
+		return err
 	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("%w: %s", ErrKeyRejected, resp.Status)
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("/models: %s", resp.Status)
+	}
+	return nil
+}
+
+func (s *Server) latencySnapshot() map[string]float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap := make(map[string]float64, len(s.latency))
+	for k, v := range s.latency {
+		snap[k] = v
+	}
+	return snap
 }
 
 func (s *Server) resolveProvider(path string) (provider, target, trimmed string) {
@@ -218,13 +706,18 @@ func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 		"status":  "running",
 		"version": "1.0.0",
 		"port":    s.cfg.Port,
+		"listen":  s.cfg.Listen,
+		"tls":     s.cfg.TLS || s.cfg.TLSCert != "",
 		"uptime":  time.Since(s.stats.StartedAt).String(),
+		"profile": vault.Profile(),
 	})
 }
 
 func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	queues := s.queueSnapshot()
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.stats.Queues = queues
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(s.stats)
@@ -274,11 +767,15 @@ func ReadLogs(n int) ([]RequestLog, error) {
 	return all, nil
 }
 
-// responseRecorder captures the HTTP status code.
+// responseRecorder captures the HTTP status code and body, and passes
+// streamed events to its tap.
 type responseRecorder struct {
 	http.ResponseWriter
 	statusCode int
 	body       []byte
+	tap        *streamTap
+	websocket  bool
+	sse        *sseParser
 }
 
 func (r *responseRecorder) WriteHeader(code int) {
@@ -290,6 +787,12 @@ func (r *responseRecorder) Write(b []byte) (int, error) {
 	if r.statusCode == 0 {
 		r.statusCode = 200
 	}
+	if r.sse == nil && r.tap != nil && strings.HasPrefix(r.Header().Get("Content-Type"), "text/event-stream") {
+		r.sse = &sseParser{emit: func(event string, data []byte) { r.tap.emit("sse", false, event, data) }}
+	}
+	if r.sse != nil {
+		r.sse.feed(b)
+	}
 	r.body = append(r.body, b...)
 	return r.ResponseWriter.Write(b)
 }
@@ -1,10 +1,22 @@
 package proxy
 
 import (
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/msalah0e/palm/internal/budget"
+	"github.com/msalah0e/palm/internal/session"
+	"github.com/msalah0e/palm/internal/tokens"
+	"github.com/msalah0e/palm/internal/vault"
 )
 
 func TestResolveProvider(t *testing.T) {
@@ -130,3 +142,957 @@ func TestProviderKeys(t *testing.T) {
 		}
 	}
 }
+
+func TestRouteConfigValidate(t *testing.T) {
+	bad := []Route{
+		{Model: ""},
+		{Model: "fast"},
+		{Model: "fast", Provider: "nope"},
+		{Model: "fast", Provider: "groq", Strategy: "random"},
+		{Model: "cheap", Candidates: []string{"groq"}},
+		{Model: "both", Provider: "groq", Candidates: []string{"openai/gpt-4o"}},
+	}
+	for _, r := range bad {
+		cfg := &RouteConfig{Routes: []Route{r}}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", r)
+		}
+	}
+
+	good := &RouteConfig{Routes: []Route{
+		{Model: "gpt-4*", Provider: "openai"},
+		{Model: "cheap", Strategy: "cheapest", Candidates: []string{"groq/llama-3.3-70b-versatile", "openai/gpt-4o-mini"}},
+	}}
+	if err := good.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if r := good.Match("gpt-4o-mini"); r == nil || r.Provider != "openai" {
+		t.Errorf("expected glob route to match, got %+v", r)
+	}
+	if good.Match("claude-opus-4-6") != nil {
+		t.Error("unexpected match")
+	}
+}
+
+func TestRouteResolveStrategies(t *testing.T) {
+	r := Route{Model: "cheap", Strategy: "cheapest", Candidates: []string{"openai/gpt-4o", "groq/llama-3.3-70b-versatile", "openai/gpt-4o-mini"}}
+	b, err := r.Resolve("cheap", nil, nil)
+	if err != nil || b.String() != "openai/gpt-4o-mini" {
+		t.Errorf("cheapest = %s, %v", b, err)
+	}
+
+	// Providers without a key are skipped
+	onlyOpenAI := func(p string) bool { return p == "openai" }
+	r.Candidates = []string{"groq/llama-3.3-70b-versatile", "openai/gpt-4o"}
+	if b, _ := r.Resolve("cheap", onlyOpenAI, nil); b.String() != "openai/gpt-4o" {
+		t.Errorf("expected unusable groq to be skipped, got %s", b)
+	}
+	if _, err := r.Resolve("cheap", func(string) bool { return false }, nil); err == nil {
+		t.Error("expected error when no backend is usable")
+	}
+
+	fast := Route{Model: "fast", Strategy: "fastest", Candidates: []string{"openai/gpt-4o", "groq/llama-3.3-70b-versatile", "mistral/mistral-large-latest"}}
+	latency := map[string]float64{"openai/gpt-4o": 900, "groq/llama-3.3-70b-versatile": 250}
+	if b, _ := fast.Resolve("fast", nil, latency); b.String() != "groq/llama-3.3-70b-versatile" {
+		t.Errorf("fastest = %s", b)
+	}
+
+	fixed := Route{Model: "gpt-4*", Provider: "openai"}
+	if b, _ := fixed.Resolve("gpt-4.1", nil, nil); b.String() != "openai/gpt-4.1" {
+		t.Errorf("fixed route should keep the requested model, got %s", b)
+	}
+}
+
+func TestRoutedRequest(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var got map[string]any
+	var gotPath string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	orig := compatBases["ollama"]
+	compatBases["ollama"] = upstream.URL + "/v1"
+	defer func() { compatBases["ollama"] = orig }()
+
+	temp := 0.1
+	srv := New(Config{})
+	srv.SetRoutes(&RouteConfig{Routes: []Route{{Model: "fast", Provider: "ollama", Target: "llama3.3", Temperature: &temp}}})
+
+	req := httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(`{"model":"fast","temperature":0.9,"messages":[]}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	srv.handleRequest(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	if gotPath != "/v1/chat/completions" {
+		t.Errorf("upstream path = %s", gotPath)
+	}
+	if got["model"] != "llama3.3" || got["temperature"] != 0.1 {
+		t.Errorf("body not rewritten: %v", got)
+	}
+	if srv.stats.Routed != 1 || srv.stats.ByModel["llama3.3"] != 1 {
+		t.Errorf("unexpected stats %+v", srv.stats)
+	}
+
+	// Unknown models on /v1/ are rejected with a hint
+	req = httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(`{"model":"mystery"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	srv.handleRequest(rec, req)
+	if rec.Code != http.StatusBadGateway || !strings.Contains(rec.Body.String(), "routes.toml") {
+		t.Errorf("expected 502 with routes hint, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestFailoverToFallbackProvider(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+
+	var primaryHits int
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryHits++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+
+	var fallbackBody map[string]any
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&fallbackBody)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"x","model":"llama3.3","choices":[{"message":{"content":"hi"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":1}}`))
+	}))
+	defer fallback.Close()
+
+	origOpenAI, origOllama := compatBases["openai"], compatBases["ollama"]
+	compatBases["openai"], compatBases["ollama"] = primary.URL+"/v1", fallback.URL+"/v1"
+	defer func() { compatBases["openai"], compatBases["ollama"] = origOpenAI, origOllama }()
+
+	srv := New(Config{})
+	srv.SetRoutes(&RouteConfig{Failover: FailoverConfig{
+		Retries:   1,
+		BackoffMS: 1,
+		Fallbacks: map[string][]string{"openai": {"ollama/llama3.3"}},
+	}})
+
+	req := httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	srv.handleRequest(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	if primaryHits != 2 {
+		t.Errorf("expected primary to be retried once, got %d hits", primaryHits)
+	}
+	if fallbackBody["model"] != "llama3.3" {
+		t.Errorf("fallback got model %v", fallbackBody["model"])
+	}
+	if srv.stats.Failovers != 1 || srv.stats.ByProvider["ollama"] != 1 {
+		t.Errorf("unexpected stats %+v", srv.stats)
+	}
+}
+
+func TestFailoverPreparesFallbacksLazily(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+	t.Setenv("GROQ_API_KEY", "")
+	if err := vault.NewFileVault().Set("GROQ_API_KEY", "gsk-test"); err != nil {
+		t.Fatal(err)
+	}
+
+	status := http.StatusOK
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer primary.Close()
+	fallbackHits := 0
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fallbackHits++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer fallback.Close()
+	origOpenAI, origGroq := compatBases["openai"], compatBases["groq"]
+	compatBases["openai"], compatBases["groq"] = primary.URL+"/v1", fallback.URL+"/v1"
+	defer func() { compatBases["openai"], compatBases["groq"] = origOpenAI, origGroq }()
+
+	srv := New(Config{})
+	srv.SetRoutes(&RouteConfig{
+		Failover: FailoverConfig{BackoffMS: 1, Fallbacks: map[string][]string{"openai": {"groq/llama-3.3-70b-versatile"}}},
+		Limits:   map[string]LimitConfig{"groq": {MaxInFlight: 1, QueueTimeoutMS: 20}},
+	})
+	send := func() int {
+		req := httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		srv.handleRequest(rec, req)
+		return rec.Code
+	}
+
+	// A healthy primary never touches the fallback's key
+	if code := send(); code != http.StatusOK {
+		t.Fatalf("status = %d", code)
+	}
+	if meta, _ := vault.LoadMeta(); meta["GROQ_API_KEY"] != nil && len(meta["GROQ_API_KEY"].UsedBy) > 0 {
+		t.Errorf("an unused fallback shouldn't record key use: %+v", meta["GROQ_API_KEY"].UsedBy)
+	}
+
+	// A failing primary falls back through groq's queue: busy, then free
+	status = http.StatusServiceUnavailable
+	l := srv.limiters["groq"]
+	if _, err := l.acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if code := send(); code != http.StatusTooManyRequests || fallbackHits != 0 {
+		t.Errorf("a full fallback queue should be respected, got %d after %d fallback hits", code, fallbackHits)
+	}
+	l.release()
+	if code := send(); code != http.StatusOK || fallbackHits != 1 {
+		t.Errorf("expected the fallback to serve, got %d after %d fallback hits", code, fallbackHits)
+	}
+	if l.stats.InFlight != 0 {
+		t.Errorf("the fallback's slot should be released, %d in flight", l.stats.InFlight)
+	}
+}
+
+func TestFailoverTranslatesAnthropicRequests(t *testing.T) {
+	in := map[string]any{
+		"model":      "claude-sonnet-4-5",
+		"system":     "be brief",
+		"max_tokens": float64(100),
+		"messages": []any{
+			map[string]any{"role": "user", "content": []any{map[string]any{"type": "text", "text": "hello"}}},
+		},
+	}
+	out := anthropicToOpenAI(in)
+	msgs := out["messages"].([]map[string]any)
+	if len(msgs) != 2 || msgs[0]["role"] != "system" || msgs[1]["content"] != "hello" {
+		t.Errorf("unexpected messages %v", msgs)
+	}
+	if out["max_tokens"] != float64(100) {
+		t.Errorf("max_tokens not carried over: %v", out)
+	}
+
+	resp, err := openAIToAnthropic([]byte(`{"id":"c1","model":"gpt-4o","choices":[{"message":{"content":"hi there"},"finish_reason":"length"}],"usage":{"prompt_tokens":5,"completion_tokens":2}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg struct {
+		Type       string `json:"type"`
+		StopReason string `json:"stop_reason"`
+		Content    []struct {
+			Text string `json:"text"`
+		} `json:"content"`
+		Usage map[string]int `json:"usage"`
+	}
+	_ = json.Unmarshal(resp, &msg)
+	if msg.Type != "message" || msg.StopReason != "max_tokens" || msg.Content[0].Text != "hi there" || msg.Usage["output_tokens"] != 2 {
+		t.Errorf("unexpected translated response %s", resp)
+	}
+}
+
+func TestFailoverBackoff(t *testing.T) {
+	f := FailoverConfig{BackoffMS: 100, MaxBackoffMS: 1000}
+	if got := f.backoff(0, ""); got.Milliseconds() != 100 {
+		t.Errorf("first backoff = %v", got)
+	}
+	if got := f.backoff(5, ""); got.Milliseconds() != 1000 {
+		t.Errorf("backoff should be capped, got %v", got)
+	}
+	if got := f.backoff(0, "60"); got.Milliseconds() != 1000 {
+		t.Errorf("Retry-After should be capped, got %v", got)
+	}
+	if !shouldFailover(429) || !shouldFailover(502) || shouldFailover(400) {
+		t.Error("unexpected shouldFailover result")
+	}
+}
+
+func TestCompatBase(t *testing.T) {
+	if base, _ := CompatBase("openai", 0); base != "https://api.openai.com/v1" {
+		t.Errorf("direct base = %q", base)
+	}
+	// Through the proxy the provider prefix must resolve back to the same API
+	base, ok := CompatBase("google", 4778)
+	if !ok || base != "http://localhost:4778/google/v1beta/openai" {
+		t.Errorf("proxied base = %q", base)
+	}
+	srv := New(Config{Port: 4778})
+	_, target, trimmed := srv.resolveProvider(strings.TrimPrefix(base, "http://localhost:4778") + "/chat/completions")
+	if target+trimmed != "https://generativelanguage.googleapis.com/v1beta/openai/chat/completions" {
+		t.Errorf("proxied request goes to %s%s", target, trimmed)
+	}
+	if _, ok := CompatBase("nope", 0); ok {
+		t.Error("unknown provider should not resolve")
+	}
+}
+
+func TestRegisterLocal(t *testing.T) {
+	route, base := providerRoutes["/vllm/"], compatBases["vllm"]
+	defer func() {
+		providerRoutes["/vllm/"], compatBases["vllm"] = route, base
+		delete(localModels, "Qwen/Qwen2.5-7B-Instruct")
+	}()
+
+	if err := RegisterLocal("openai", "http://127.0.0.1:1", ""); err == nil {
+		t.Error("RegisterLocal should refuse cloud providers")
+	}
+	if err := RegisterLocal("vllm", "http://127.0.0.1:8001", "Qwen/Qwen2.5-7B-Instruct"); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := New(Config{})
+	if provider, target, _ := srv.resolveProvider("/vllm/v1/models"); provider != "vllm" || target != "http://127.0.0.1:8001" {
+		t.Errorf("resolveProvider = %s %s", provider, target)
+	}
+	if base, _ := CompatBase("vllm", 0); base != "http://127.0.0.1:8001/v1" {
+		t.Errorf("CompatBase(vllm) = %s", base)
+	}
+	if p := InferProvider("Qwen/Qwen2.5-7B-Instruct"); p != "vllm" {
+		t.Errorf("InferProvider = %q, want vllm", p)
+	}
+	if !KeyAvailable(nil, "vllm") || backendCost(Backend{Provider: "vllm", Model: "x"}) != 0 {
+		t.Error("local runtimes need no key and cost nothing")
+	}
+}
+
+func TestAccessTokens(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	secret, err := AddToken("laptop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(secret, tokenPrefix) {
+		t.Errorf("token %q lacks prefix", secret)
+	}
+	if _, err := AddToken("laptop"); err == nil {
+		t.Error("duplicate token name should fail")
+	}
+	data, _ := os.ReadFile(TokensPath())
+	if strings.Contains(string(data), secret) {
+		t.Error("token stored in plain text")
+	}
+
+	tokens, _ := LoadTokens()
+	if name, ok := matchToken(tokens, secret); !ok || name != "laptop" {
+		t.Errorf("matchToken = %q, %v", name, ok)
+	}
+	if _, ok := matchToken(tokens, secret+"x"); ok {
+		t.Error("wrong token matched")
+	}
+
+	if err := RevokeToken("laptop"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RevokeToken("laptop"); err == nil {
+		t.Error("revoking a missing token should fail")
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	secret, _ := AddToken("laptop")
+
+	var client, auth, apiKey string
+	srv := New(Config{})
+	_ = os.MkdirAll(filepath.Dir(TokensPath()), 0o755)
+	srv.logFile, _ = os.Create(filepath.Join(filepath.Dir(TokensPath()), "proxy.jsonl"))
+	defer srv.logFile.Close()
+	handler := srv.authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client, auth, apiKey = clientName(r), r.Header.Get("Authorization"), r.Header.Get("x-api-key")
+	}))
+
+	tests := []struct {
+		name   string
+		remote string
+		header string
+		value  string
+		status int
+		client string
+	}{
+		{"remote bearer", "192.168.1.20:5000", "Authorization", "Bearer " + secret, http.StatusOK, "laptop"},
+		{"remote anthropic key", "192.168.1.20:5000", "x-api-key", secret, http.StatusOK, "laptop"},
+		{"remote no token", "192.168.1.20:5000", "", "", http.StatusUnauthorized, ""},
+		{"remote wrong token", "192.168.1.20:5000", "Authorization", "Bearer palm_nope", http.StatusUnauthorized, ""},
+		{"local no token", "127.0.0.1:5000", "", "", http.StatusOK, ""},
+		{"local with token", "[::1]:5000", "Authorization", "Bearer " + secret, http.StatusOK, "laptop"},
+	}
+	for _, tt := range tests {
+		client, auth, apiKey = "", "", ""
+		req := httptest.NewRequest("POST", "/openai/v1/chat/completions", nil)
+		req.RemoteAddr = tt.remote
+		if tt.header != "" {
+			req.Header.Set(tt.header, tt.value)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.status || client != tt.client {
+			t.Errorf("%s: status %d client %q, want %d %q", tt.name, rec.Code, client, tt.status, tt.client)
+		}
+		if tt.client != "" && (auth != "" || apiKey != "") {
+			t.Errorf("%s: token forwarded upstream", tt.name)
+		}
+	}
+
+	logs, _ := ReadLogs(0)
+	if len(logs) != 2 || logs[0].Status != http.StatusUnauthorized || logs[0].Provider != "openai" {
+		t.Errorf("rejections not logged: %+v", logs)
+	}
+}
+
+func TestStartRequiresTokenOffLoopback(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	err := New(Config{Port: 0, Listen: "0.0.0.0"}).Start()
+	if err == nil || !strings.Contains(err.Error(), "token add") {
+		t.Errorf("expected token error, got %v", err)
+	}
+}
+
+func TestSelfSignedCert(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	hosts := []string{"localhost", "127.0.0.1", "192.168.1.20"}
+	certFile, keyFile, err := SelfSignedCert(hosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+		t.Fatalf("unusable pair: %v", err)
+	}
+	fp, _ := CertFingerprint(certFile)
+
+	// Reused while it covers the hosts, regenerated when a new one appears
+	SelfSignedCert(hosts)
+	if again, _ := CertFingerprint(certFile); again != fp {
+		t.Error("certificate regenerated needlessly")
+	}
+	SelfSignedCert(append(hosts, "10.0.0.5"))
+	cert, _ := readCert(certFile)
+	if cert.VerifyHostname("10.0.0.5") != nil || cert.VerifyHostname("192.168.1.20") != nil {
+		t.Error("regenerated certificate should cover all hosts")
+	}
+}
+
+func TestBudgetDowngrade(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var got map[string]any
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+	orig := providerRoutes["/ollama/"]
+	providerRoutes["/ollama/"] = upstream.URL
+	defer func() { providerRoutes["/ollama/"] = orig }()
+
+	d := DowngradeConfig{At: 50, Models: map[string]string{"llama3.3": "llama3.2:1b", "qwen*": "qwen2.5:0.5b", "qwen2.5-coder*": "qwen2.5-coder:1.5b"}}
+	if to, ok := d.Cheaper("qwen2.5-coder:32b"); !ok || to != "qwen2.5-coder:1.5b" {
+		t.Errorf("the longest pattern should win, got %q", to)
+	}
+	if _, ok := d.Cheaper("mistral"); ok {
+		t.Error("unmapped models shouldn't be downgraded")
+	}
+	if err := (&RouteConfig{Downgrade: DowngradeConfig{At: 120}}).Validate(); err == nil {
+		t.Error("a threshold over 100% should be rejected")
+	}
+
+	srv := New(Config{})
+	srv.SetRoutes(&RouteConfig{Downgrade: d})
+	send := func(model string) *httptest.ResponseRecorder {
+		got = nil
+		req := httptest.NewRequest("POST", "/ollama/api/chat", strings.NewReader(`{"model":"`+model+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		srv.handleRequest(rec, req)
+		return rec
+	}
+
+	// Under the threshold nothing changes
+	if err := budget.Save(&budget.Budget{MonthlyLimit: 10, AlertAt: 0.8}); err != nil {
+		t.Fatal(err)
+	}
+	srv.logFile, _ = os.Create(filepath.Join(filepath.Dir(TokensPath()), "proxy.jsonl"))
+	defer srv.logFile.Close()
+	_ = session.Record("aider", time.Second, 0, 2, 100, "ollama")
+	if rec := send("llama3.3"); rec.Header().Get("X-Palm-Downgraded") != "" || got["model"] != "llama3.3" {
+		t.Errorf("downgraded under the threshold: %v", got)
+	}
+
+	// Past it mapped models are swapped
+	_ = session.Record("aider", time.Second, 0, 4, 100, "ollama")
+	rec := send("llama3.3")
+	if rec.Code != http.StatusOK || got["model"] != "llama3.2:1b" || rec.Header().Get("X-Palm-Downgraded") != "llama3.3 -> llama3.2:1b" {
+		t.Errorf("expected a downgrade, got %d %v %q", rec.Code, got, rec.Header().Get("X-Palm-Downgraded"))
+	}
+	if srv.stats.Downgrades != 1 || srv.stats.ByModel["llama3.2:1b"] != 1 {
+		t.Errorf("unexpected stats %+v", srv.stats)
+	}
+	logs, _ := ReadLogs(2)
+	if len(logs) != 2 || logs[0].DowngradedFrom != "" || logs[1].DowngradedFrom != "llama3.3" || logs[1].Model != "llama3.2:1b" {
+		t.Errorf("downgrade not logged: %+v", logs)
+	}
+
+	// Over the limit everything is refused, unless past_limit keeps the
+	// downgraded models going
+	_ = session.Record("aider", time.Second, 0, 5, 100, "ollama")
+	if rec := send("llama3.3"); rec.Code != http.StatusPaymentRequired || got != nil {
+		t.Errorf("downgraded models past the limit should get 402, got %d %v", rec.Code, got)
+	}
+	d.PastLimit = true
+	srv.SetRoutes(&RouteConfig{Downgrade: d})
+	if rec := send("llama3.3"); rec.Code != http.StatusOK || got["model"] != "llama3.2:1b" {
+		t.Errorf("expected a downgrade past the limit with past_limit, got %d %v", rec.Code, got)
+	}
+	if rec := send("mistral"); rec.Code != http.StatusPaymentRequired {
+		t.Errorf("unmapped models past the limit should get 402, got %d", rec.Code)
+	}
+}
+
+func TestProviderQueue(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	arrived := make(chan struct{}, 4)
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	orig := providerRoutes["/ollama/"]
+	providerRoutes["/ollama/"] = upstream.URL
+	defer func() { providerRoutes["/ollama/"] = orig }()
+
+	bad := &RouteConfig{Limits: map[string]LimitConfig{"ollama": {MaxInFlight: 0}}}
+	if err := bad.Validate(); err == nil {
+		t.Error("max_in_flight 0 should be invalid")
+	}
+	cfg := &RouteConfig{Limits: map[string]LimitConfig{"ollama": {MaxInFlight: 1, Queue: 1, QueueTimeoutMS: 5000}}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	srv := New(Config{})
+	srv.SetRoutes(cfg)
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/ollama/api/chat", strings.NewReader(`{"model":"llama3.3"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		srv.handleRequest(rec, req)
+		return rec
+	}
+	waiting := func() int { return srv.queueSnapshot()["ollama"].Waiting }
+
+	codes := make(chan int, 2)
+	go func() { codes <- send().Code }()
+	<-arrived // first request holds the only slot
+	go func() { codes <- send().Code }()
+	for deadline := time.Now().Add(2 * time.Second); waiting() != 1; {
+		if time.Now().After(deadline) {
+			t.Fatal("second request never queued")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// The queue holds one request, so a third is turned away
+	if rec := send(); rec.Code != http.StatusTooManyRequests || !strings.Contains(rec.Body.String(), "queue full") {
+		t.Errorf("expected 429 queue full, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		if code := <-codes; code != http.StatusOK {
+			t.Errorf("queued request got %d", code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	srv.handleStats(rec, httptest.NewRequest("GET", "/palm/stats", nil))
+	var stats ProxyStats
+	if err := json.NewDecoder(rec.Body).Decode(&stats); err != nil {
+		t.Fatal(err)
+	}
+	q := stats.Queues["ollama"]
+	if q.Queued != 1 || q.Rejected != 1 || q.MaxWaiting != 1 || q.InFlight != 0 || q.Waiting != 0 || q.MaxWaitMS <= 0 {
+		t.Errorf("unexpected queue stats %+v", q)
+	}
+
+	// A request that waits too long gives up
+	l := newLimiter(LimitConfig{MaxInFlight: 1, QueueTimeoutMS: 20})
+	if _, err := l.acquire(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.acquire(t.Context()); err != errQueueTimeout {
+		t.Errorf("expected queue timeout, got %v", err)
+	}
+	if l.snapshot().TimedOut != 1 {
+		t.Errorf("timeout not counted: %+v", l.snapshot())
+	}
+}
+
+func TestCheckKey(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" || r.Header.Get("Authorization") != "Bearer good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	defer upstream.Close()
+	orig := compatBases["openai"]
+	compatBases["openai"] = upstream.URL + "/v1"
+	defer func() { compatBases["openai"] = orig }()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	t.Setenv("OPENAI_API_KEY", "good")
+	if err := CheckKey(nil, "openai"); err != nil {
+		t.Errorf("valid key: %v", err)
+	}
+	t.Setenv("OPENAI_API_KEY", "revoked")
+	if err := CheckKey(nil, "openai"); !errors.Is(err, ErrKeyRejected) {
+		t.Errorf("revoked key: got %v, want ErrKeyRejected", err)
+	}
+	upstream.Close()
+	if err := CheckKey(nil, "openai"); err == nil || errors.Is(err, ErrKeyRejected) {
+		t.Errorf("unreachable provider should not count as rejected: %v", err)
+	}
+}
+
+func TestContextGuard(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var got map[string]any
+	calls := 0
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		got = nil
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+	orig := providerRoutes["/ollama/"]
+	providerRoutes["/ollama/"] = upstream.URL
+	defer func() { providerRoutes["/ollama/"] = orig }()
+
+	if err := (&RouteConfig{Context: ContextConfig{Policy: "shrink"}}).Validate(); err == nil {
+		t.Error("an unknown context policy should be rejected")
+	}
+
+	// codellama has a 16k window in the catalog; each turn is about 7k tokens
+	turn := strings.Repeat("lorem ipsum dolor sit amet ", 1400)
+	if n := tokens.Estimator.Count(turn); n < 6000 || n > 9000 {
+		t.Fatalf("test turn is %d tokens, want about 7000", n)
+	}
+	body := func(turns int) string {
+		msgs := []map[string]string{{"role": "system", "content": "be brief"}}
+		for i := 0; i < turns; i++ {
+			role := "user"
+			if i%2 == 1 {
+				role = "assistant"
+			}
+			msgs = append(msgs, map[string]string{"role": role, "content": turn})
+		}
+		data, _ := json.Marshal(map[string]any{"model": "codellama", "messages": msgs})
+		return string(data)
+	}
+
+	srv := New(Config{})
+	send := func(payload string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/ollama/v1/chat/completions", strings.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		srv.handleRequest(rec, req)
+		return rec
+	}
+
+	if rec := send(body(2)); rec.Code != http.StatusOK || calls != 1 {
+		t.Fatalf("a request that fits should pass, got %d", rec.Code)
+	}
+	rec := send(body(3))
+	if rec.Code != http.StatusBadRequest || calls != 1 || !strings.Contains(rec.Body.String(), "16384-token context window") {
+		t.Errorf("an oversized request should get 400 without an upstream call, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	srv.SetRoutes(&RouteConfig{Context: ContextConfig{Policy: "truncate"}})
+	rec = send(body(3))
+	msgs, _ := got["messages"].([]any)
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Palm-Truncated") != "1" || len(msgs) != 3 || messageRole(msgs[0]) != "system" {
+		t.Errorf("expected the oldest turn dropped, got %d %q %d messages", rec.Code, rec.Header().Get("X-Palm-Truncated"), len(msgs))
+	}
+
+	srv.SetRoutes(&RouteConfig{Context: ContextConfig{Policy: "off"}})
+	if rec := send(body(3)); rec.Code != http.StatusOK || calls != 3 {
+		t.Errorf("policy off should send everything, got %d", rec.Code)
+	}
+}
+
+func TestTruncateContextDropsToolResults(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	turn := strings.Repeat("lorem ipsum dolor sit amet ", 1400)
+	msg := func(role, content string) map[string]any {
+		return map[string]any{"role": role, "content": content}
+	}
+	body := map[string]any{"model": "codellama", "messages": []any{
+		msg("system", "be brief"),
+		msg("assistant", "calling a tool"),
+		msg("tool", turn),
+		msg("tool", turn),
+		msg("user", turn),
+		msg("user", "and now?"),
+	}}
+	b := Backend{Provider: "ollama", Model: "codellama"}
+
+	dropped, fits := truncateContext(body, b, formatOpenAI)
+	msgs := body["messages"].([]any)
+	if dropped != 3 || !fits || len(msgs) != 3 || messageRole(msgs[0]) != "system" || messageRole(msgs[1]) != "user" {
+		t.Fatalf("expected the call and its results dropped, got %d dropped, fits=%v, %d left", dropped, fits, len(msgs))
+	}
+	if measureContext(body, b).over() {
+		t.Error("the truncated request should fit when measured again")
+	}
+}
+
+func TestTruncateContextKeepsAnthropicTurns(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	turn := strings.Repeat("lorem ipsum dolor sit amet ", 1400)
+	msg := func(role string, content any) map[string]any {
+		return map[string]any{"role": role, "content": content}
+	}
+	body := map[string]any{"model": "codellama", "messages": []any{
+		msg("user", turn),
+		msg("assistant", []any{map[string]any{"type": "tool_use", "id": "t1", "name": "read", "input": map[string]any{}}}),
+		msg("user", []any{map[string]any{"type": "tool_result", "tool_use_id": "t1", "content": turn}}),
+		msg("assistant", "read it"),
+		msg("user", turn),
+		msg("assistant", "done"),
+		msg("user", "and now?"),
+	}}
+	b := Backend{Provider: "ollama", Model: "codellama"}
+
+	dropped, fits := truncateContext(body, b, formatAnthropic)
+	msgs := body["messages"].([]any)
+	if dropped != 4 || !fits || len(msgs) != 3 {
+		t.Fatalf("expected the first two turns dropped whole, got %d dropped, fits=%v, %d left", dropped, fits, len(msgs))
+	}
+	if messageRole(msgs[0]) != "user" || hasToolResult(msgs[0]) {
+		t.Errorf("the conversation should open with a plain user turn, got %v", msgs[0])
+	}
+}
+
+func TestUsageAnomaly(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	// Two requests costing a cent in each of 12 past windows, plus a
+	// provider seen too rarely to have a baseline
+	var logs []RequestLog
+	past := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 12; i++ {
+		at := past.Add(time.Duration(i) * time.Hour)
+		logs = append(logs, RequestLog{Timestamp: at, Provider: "ollama", Cost: 0.005}, RequestLog{Timestamp: at.Add(time.Minute), Provider: "ollama", Cost: 0.005})
+	}
+	logs = append(logs, RequestLog{Timestamp: past, Provider: "groq"})
+	baselines := LearnBaselines(logs, 5*time.Minute)
+	if b := baselines["ollama"]; b.Requests != 2 || b.Windows != 12 || b.Cost < 0.0099 || b.Cost > 0.0101 {
+		t.Errorf("unexpected baseline %+v", b)
+	}
+	if _, ok := baselines["groq"]; ok {
+		t.Error("a provider with little history shouldn't get a baseline")
+	}
+
+	if err := (&RouteConfig{Anomaly: AnomalyConfig{Factor: 10, PauseAt: 5}}).Validate(); err == nil {
+		t.Error("pause_at below factor should be rejected")
+	}
+
+	calls := 0
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+	orig := providerRoutes["/ollama/"]
+	providerRoutes["/ollama/"] = upstream.URL
+	defer func() { providerRoutes["/ollama/"] = orig }()
+
+	srv := New(Config{})
+	srv.SetRoutes(&RouteConfig{Anomaly: AnomalyConfig{Factor: 2, PauseAt: 3, MinRequests: 4}})
+	srv.anomalies.learn = func() map[string]Baseline { return baselines }
+	srv.anomalies.relearn(time.Now())
+	send := func() int {
+		req := httptest.NewRequest("GET", "/ollama/api/tags", nil)
+		rec := httptest.NewRecorder()
+		srv.handleRequest(rec, req)
+		return rec.Code
+	}
+
+	// 4 requests are twice the baseline: flagged once; 6 are three times it
+	for i := 0; i < 5; i++ {
+		send()
+	}
+	if len(srv.stats.Anomalies) != 1 || srv.stats.Anomalies[0].Paused || srv.stats.Anomalies[0].Metric != "requests" {
+		t.Fatalf("expected one anomaly without a pause, got %+v", srv.stats.Anomalies)
+	}
+	send()
+	if len(srv.stats.Anomalies) != 2 || !srv.stats.Anomalies[1].Paused {
+		t.Fatalf("expected the provider paused at three times the baseline, got %+v", srv.stats.Anomalies)
+	}
+	if code := send(); code != http.StatusServiceUnavailable || calls != 6 {
+		t.Errorf("a paused provider should get 503 without an upstream call, got %d after %d calls", code, calls)
+	}
+	if srv.usable("ollama") {
+		t.Error("routing should skip a paused provider")
+	}
+
+	resumed, err := ResumeProvider("")
+	if err != nil || len(resumed) != 1 || resumed[0] != "ollama" {
+		t.Fatalf("ResumeProvider = %v, %v", resumed, err)
+	}
+	if code := send(); code != http.StatusOK {
+		t.Errorf("a resumed provider should be routed again, got %d", code)
+	}
+	if _, err := os.Stat(PausedPath()); !os.IsNotExist(err) {
+		t.Error("resuming every provider should remove the pause file")
+	}
+}
+
+func TestAnomalyLearnsInBackground(t *testing.T) {
+	d := newAnomalyDetector(AnomalyConfig{MinRequests: 1})
+	release := make(chan struct{})
+	learned := make(chan struct{})
+	d.learn = func() map[string]Baseline {
+		<-release
+		defer close(learned)
+		return map[string]Baseline{"ollama": {Requests: 1, Windows: 12}}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		d.record("ollama", 0, time.Now())
+		d.record("ollama", 0, time.Now())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("record waited for the log to be read")
+	}
+
+	close(release)
+	<-learned
+	for i := 0; i < 100; i++ {
+		d.mu.Lock()
+		ready := d.baselines != nil && !d.learning
+		d.mu.Unlock()
+		if ready {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("learned baselines were never swapped in")
+}
+
+func TestRequestTransforms(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var got map[string]any
+	var gotHeader http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = nil
+		gotHeader = r.Header.Clone()
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+	for _, p := range []string{"/ollama/", "/anthropic/"} {
+		orig := providerRoutes[p]
+		providerRoutes[p] = upstream.URL
+		defer func(p string) { providerRoutes[p] = orig }(p)
+	}
+
+	for _, bad := range []TransformConfig{{PII: "mask"}, {Metadata: map[string]string{"a b": "x"}}, {Providers: map[string]bool{"nope": false}}} {
+		if err := (&RouteConfig{Transform: bad}).Validate(); err == nil {
+			t.Errorf("%+v should be rejected", bad)
+		}
+	}
+
+	srv := New(Config{})
+	srv.SetRoutes(&RouteConfig{Transform: TransformConfig{
+		SystemPrompt: "Follow policy.",
+		PII:          "redact",
+		Metadata:     map[string]string{"Team": "platform"},
+	}})
+	send := func(path, payload string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, strings.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		srv.handleRequest(rec, req)
+		return rec
+	}
+
+	rec := send("/ollama/v1/chat/completions", `{"model":"llama3.3","messages":[{"role":"user","content":"mail jane.doe@example.com or call +1 555-123-4567 about v1.2.3 on 2026-03-01"}]}`)
+	msgs, _ := got["messages"].([]any)
+	if rec.Code != http.StatusOK || len(msgs) != 2 || messageRole(msgs[0]) != "system" {
+		t.Fatalf("expected the system prompt first, got %d %v", rec.Code, got)
+	}
+	if content := msgs[1].(map[string]any)["content"]; content != "mail [email] or call [phone] about v1.2.3 on 2026-03-01" {
+		t.Errorf("content = %q", content)
+	}
+	if gotHeader.Get("X-Palm-Meta-Team") != "platform" || rec.Header().Get("X-Palm-Transformed") != "pii:2, system-prompt, metadata" {
+		t.Errorf("headers: meta %q, transformed %q", gotHeader.Get("X-Palm-Meta-Team"), rec.Header().Get("X-Palm-Transformed"))
+	}
+
+	// Anthropic keeps its own system prompt after the org-wide one, and
+	// content blocks are redacted too
+	send("/anthropic/v1/messages", `{"model":"claude-haiku-4-5-20251001","system":"Be terse.","messages":[{"role":"user","content":[{"type":"text","text":"I'm bob@corp.io"}]}]}`)
+	block := got["messages"].([]any)[0].(map[string]any)["content"].([]any)[0].(map[string]any)
+	if got["system"] != "Follow policy.\n\nBe terse." || block["text"] != "I'm [email]" {
+		t.Errorf("anthropic body = %v", got)
+	}
+	if srv.stats.Transformed != 2 {
+		t.Errorf("Transformed = %d, want 2", srv.stats.Transformed)
+	}
+
+	// Providers set to false are sent as they are
+	srv.routes.Transform.Providers = map[string]bool{"ollama": false}
+	rec = send("/ollama/v1/chat/completions", `{"model":"llama3.3","messages":[{"role":"user","content":"a@b.co"}]}`)
+	if msgs, _ := got["messages"].([]any); len(msgs) != 1 || rec.Header().Get("X-Palm-Transformed") != "" {
+		t.Errorf("ollama should be left alone, got %v", got)
+	}
+}
+
+func TestAuthorizeRecordsUseOncePerWindow(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("GROQ_API_KEY", "")
+	v := vault.NewFileVault()
+	if err := v.Set("GROQ_API_KEY", "gsk-test"); err != nil {
+		t.Fatal(err)
+	}
+
+	h := http.Header{}
+	Authorize(h, v, "groq", "proxy:test-once")
+	if h.Get("Authorization") != "Bearer gsk-test" {
+		t.Fatalf("expected the vault key, got %q", h.Get("Authorization"))
+	}
+	meta, _ := vault.LoadMeta()
+	if m := meta["GROQ_API_KEY"]; m == nil || m.UsedBy["proxy:test-once"].IsZero() {
+		t.Fatalf("the first use should be recorded, got %+v", m)
+	}
+
+	// Within the window the metadata file isn't touched again
+	path := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "palm", "keys_meta.json")
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	Authorize(http.Header{}, v, "groq", "proxy:test-once")
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("a repeated use within the window should not be written")
+	}
+}
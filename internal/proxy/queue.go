@@ -0,0 +1,176 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LimitConfig caps how many requests palm sends a provider at once. Requests
+// over the cap wait in a local queue instead of tripping the provider's rate
+// limits, and fail with 429 only when the queue is full or they wait too long.
+type LimitConfig struct {
+	MaxInFlight    int `toml:"max_in_flight"`    // concurrent upstream requests
+	Queue          int `toml:"queue"`            // requests allowed to wait; 0 = no limit
+	QueueTimeoutMS int `toml:"queue_timeout_ms"` // longest wait for a slot; 0 = 60s
+}
+
+const defaultQueueTimeout = 60 * time.Second
+
+func (l LimitConfig) timeout() time.Duration {
+	if l.QueueTimeoutMS > 0 {
+		return time.Duration(l.QueueTimeoutMS) * time.Millisecond
+	}
+	return defaultQueueTimeout
+}
+
+func validateLimits(limits map[string]LimitConfig) error {
+	for provider, l := range limits {
+		if _, ok := compatBases[provider]; !ok {
+			return fmt.Errorf("limits: unknown provider %q", provider)
+		}
+		if l.MaxInFlight < 1 {
+			return fmt.Errorf("limits.%s: max_in_flight must be at least 1", provider)
+		}
+		if l.Queue < 0 || l.QueueTimeoutMS < 0 {
+			return fmt.Errorf("limits.%s: queue and queue_timeout_ms cannot be negative", provider)
+		}
+	}
+	return nil
+}
+
+// QueueStats is a provider's queue as reported by /palm/stats.
+type QueueStats struct {
+	MaxInFlight int
+	QueueLimit  int // 0 = no limit
+	InFlight    int
+	Waiting     int
+	MaxWaiting  int   // deepest the queue has been
+	Queued      int64 // requests that had to wait
+	Rejected    int64 // turned away with the queue full
+	TimedOut    int64 // gave up waiting
+	AvgWaitMS   float64
+	MaxWaitMS   float64
+}
+
+var (
+	errQueueFull    = fmt.Errorf("queue full")
+	errQueueTimeout = fmt.Errorf("timed out waiting in the queue")
+)
+
+// limiter holds one provider's in-flight slots and waiting requests.
+type limiter struct {
+	cfg   LimitConfig
+	slots chan struct{}
+
+	mu        sync.Mutex
+	stats     QueueStats
+	totalWait time.Duration
+}
+
+func newLimiter(cfg LimitConfig) *limiter {
+	return &limiter{
+		cfg:   cfg,
+		slots: make(chan struct{}, cfg.MaxInFlight),
+		stats: QueueStats{MaxInFlight: cfg.MaxInFlight, QueueLimit: cfg.Queue},
+	}
+}
+
+// acquire takes an in-flight slot, waiting for one when all are busy. It
+// returns how long it waited; every successful acquire must be released.
+func (l *limiter) acquire(ctx context.Context) (time.Duration, error) {
+	select {
+	case l.slots <- struct{}{}:
+		l.mu.Lock()
+		l.stats.InFlight++
+		l.mu.Unlock()
+		return 0, nil
+	default:
+	}
+
+	l.mu.Lock()
+	if l.cfg.Queue > 0 && l.stats.Waiting >= l.cfg.Queue {
+		l.stats.Rejected++
+		l.mu.Unlock()
+		return 0, errQueueFull
+	}
+	l.stats.Waiting++
+	l.stats.Queued++
+	l.stats.MaxWaiting = max(l.stats.MaxWaiting, l.stats.Waiting)
+	l.mu.Unlock()
+
+	start := time.Now()
+	timer := time.NewTimer(l.cfg.timeout())
+	defer timer.Stop()
+
+	var err error
+	select {
+	case l.slots <- struct{}{}:
+	case <-timer.C:
+		err = errQueueTimeout
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	wait := time.Since(start)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stats.Waiting--
+	l.totalWait += wait
+	l.stats.MaxWaitMS = max(l.stats.MaxWaitMS, float64(wait.Milliseconds()))
+	if err != nil {
+		if err == errQueueTimeout {
+			l.stats.TimedOut++
+		}
+		return wait, err
+	}
+	l.stats.InFlight++
+	return wait, nil
+}
+
+func (l *limiter) release() {
+	<-l.slots
+	l.mu.Lock()
+	l.stats.InFlight--
+	l.mu.Unlock()
+}
+
+func (l *limiter) snapshot() QueueStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	st := l.stats
+	if st.Queued > 0 {
+		st.AvgWaitMS = float64(l.totalWait.Milliseconds()) / float64(st.Queued)
+	}
+	return st
+}
+
+// newLimiters builds a limiter for each provider with a [limits] entry.
+func newLimiters(limits map[string]LimitConfig) map[string]*limiter {
+	out := make(map[string]*limiter, len(limits))
+	for provider, cfg := range limits {
+		out[provider] = newLimiter(cfg)
+	}
+	return out
+}
+
+// queueSnapshot returns the queue stats of every limited provider.
+func (s *Server) queueSnapshot() map[string]QueueStats {
+	out := make(map[string]QueueStats, len(s.limiters))
+	for provider, l := range s.limiters {
+		out[provider] = l.snapshot()
+	}
+	return out
+}
+
+// LimitedProviders lists the providers with a [limits] entry, sorted.
+func (c *RouteConfig) LimitedProviders() []string {
+	providers := make([]string, 0, len(c.Limits))
+	for p := range c.Limits {
+		providers = append(providers, p)
+	}
+	sort.Strings(providers)
+	return providers
+}
@@ -0,0 +1,307 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/msalah0e/palm/internal/models"
+	"github.com/msalah0e/palm/internal/vault"
+)
+
+// ReplayCase is a logged chat request with its bodies, ready to replay.
+type ReplayCase struct {
+	Log    RequestLog
+	Bodies BodyLog
+}
+
+// LoadReplayCases returns the last n logged chat requests that match keep
+// and have bodies in the body log. It also reports how many matching
+// requests were skipped because their bodies weren't logged.
+func LoadReplayCases(n int, keep func(RequestLog) bool) ([]ReplayCase, int, error) {
+	logs, err := ReadLogs(0)
+	if err != nil {
+		return nil, 0, err
+	}
+	var picked []RequestLog
+	skipped := 0
+	for i := len(logs) - 1; i >= 0 && len(picked) < n; i-- {
+		l := logs[i]
+		if detectFormat(l.Method, l.Path) == formatOther || (keep != nil && !keep(l)) {
+			continue
+		}
+		if l.ID == "" {
+			skipped++
+			continue
+		}
+		picked = append(picked, l)
+	}
+
+	ids := make([]string, len(picked))
+	for i, l := range picked {
+		ids[i] = l.ID
+	}
+	bodies, err := ReadBodies(ids)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Oldest first, as they were sent
+	var cases []ReplayCase
+	for i := len(picked) - 1; i >= 0; i-- {
+		b, ok := bodies[picked[i].ID]
+		if !ok || b.Truncated {
+			skipped++
+			continue
+		}
+		cases = append(cases, ReplayCase{Log: picked[i], Bodies: b})
+	}
+	return cases, skipped, nil
+}
+
+// ParseBackend parses a "provider/model" target such as "ollama/llama3.3".
+func ParseBackend(s string) (Backend, error) {
+	if err := validateBackends([]string{s}); err != nil {
+		return Backend{}, err
+	}
+	return parseBackends([]string{s})[0], nil
+}
+
+// Exchange is one side of a replay: a response and what it cost.
+type Exchange struct {
+	Backend      string  `json:"backend"`
+	Status       int     `json:"status"`
+	DurationMS   float64 `json:"duration_ms"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	Cost         float64 `json:"cost"`
+	Priced       bool    `json:"priced"` // false when palm doesn't know the model's price
+	Text         string  `json:"text"`
+	Error        string  `json:"error,omitempty"`
+}
+
+// OK reports whether the exchange produced a response.
+func (e Exchange) OK() bool {
+	return e.Error == "" && e.Status > 0 && e.Status < 400
+}
+
+// ReplayResult compares a logged response with the replayed one.
+type ReplayResult struct {
+	Time       time.Time `json:"time"` // when the original was sent
+	Original   Exchange  `json:"original"`
+	Replay     Exchange  `json:"replay"`
+	Similarity float64   `json:"similarity"` // 0-100, word overlap of the two responses
+}
+
+// Original rebuilds the logged side of a case from its log entry and
+// response body.
+func (c ReplayCase) Original() Exchange {
+	b := Backend{Provider: c.Log.Provider, Model: c.Log.Model}
+	e := Exchange{Backend: b.String(), Status: c.Log.Status, DurationMS: c.Log.Duration}
+	e.Text, e.InputTokens, e.OutputTokens = parseResponse([]byte(c.Bodies.Response))
+	e.Cost, e.Priced = priceTokens(b, e.InputTokens, e.OutputTokens)
+	return e
+}
+
+// Replay sends a logged request to target through its OpenAI-compatible
+// API, without streaming, and compares the answer with the logged one.
+// Anthropic requests are translated first.
+func Replay(ctx context.Context, c ReplayCase, target Backend, v vault.Vault) ReplayResult {
+	res := ReplayResult{Time: c.Log.Timestamp, Original: c.Original()}
+	res.Replay = sendReplay(ctx, c, target, v)
+	if res.Replay.OK() {
+		res.Similarity = TextSimilarity(res.Original.Text, res.Replay.Text)
+	}
+	return res
+}
+
+func sendReplay(ctx context.Context, c ReplayCase, target Backend, v vault.Vault) Exchange {
+	e := Exchange{Backend: target.String()}
+
+	var body map[string]any
+	if err := json.Unmarshal([]byte(c.Bodies.Request), &body); err != nil {
+		e.Error = "logged request isn't JSON: " + err.Error()
+		return e
+	}
+	if detectFormat(c.Log.Method, c.Log.Path) == formatAnthropic {
+		body = anthropicToOpenAI(body)
+	}
+	body["model"] = target.Model
+	delete(body, "stream")
+	delete(body, "stream_options")
+	payload, _ := json.Marshal(body)
+
+	base, ok := compatBases[target.Provider]
+	if !ok {
+		e.Error = "unknown provider " + target.Provider
+		return e
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+"/chat/completions", bytes.NewReader(payload))
+	if err != nil {
+		e.Error = err.Error()
+		return e
+	}
+	req.Header.Set("Content-Type", "application/json")
+	Authorize(req.Header, v, target.Provider, "proxy:replay")
+
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		e.Error = err.Error()
+		return e
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxLoggedBody))
+	e.DurationMS = float64(time.Since(start).Milliseconds())
+	e.Status = resp.StatusCode
+	if err != nil {
+		e.Error = err.Error()
+		return e
+	}
+	if resp.StatusCode >= 400 {
+		e.Error = fmt.Sprintf("%s: %s", resp.Status, strings.TrimSpace(truncateBody(string(data), 200)))
+		return e
+	}
+	e.Text, e.InputTokens, e.OutputTokens = parseResponse(data)
+	e.Cost, e.Priced = priceTokens(target, e.InputTokens, e.OutputTokens)
+	return e
+}
+
+func truncateBody(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "…"
+}
+
+// priceTokens prices a call from palm's model catalog. Local models are
+// free.
+func priceTokens(b Backend, in, out int64) (float64, bool) {
+	if localProviders[b.Provider] {
+		return 0, true
+	}
+	for _, m := range models.AllModels() {
+		if m.Provider == b.Provider && m.ID == b.Model {
+			return (float64(in)*m.InputCost + float64(out)*m.OutputCost) / 1e6, true
+		}
+	}
+	return 0, false
+}
+
+// chatChunk covers the response fields replay reads, in both the OpenAI
+// and Anthropic formats, whole or as stream events.
+type chatChunk struct {
+	Type    string `json:"type"`
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Content []struct {
+		Text string `json:"text"`
+	} `json:"content"`
+	Delta struct {
+		Text string `json:"text"`
+	} `json:"delta"`
+	Message struct {
+		Usage chatUsage `json:"usage"`
+	} `json:"message"`
+	Usage chatUsage `json:"usage"`
+}
+
+type chatUsage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	InputTokens      int64 `json:"input_tokens"`
+	OutputTokens     int64 `json:"output_tokens"`
+}
+
+func (u chatUsage) tokens() (in, out int64) {
+	return u.PromptTokens + u.InputTokens, u.CompletionTokens + u.OutputTokens
+}
+
+// parseResponse extracts the answer text and token usage from a chat
+// response body, whole or streamed as server-sent events.
+func parseResponse(data []byte) (text string, in, out int64) {
+	var chunks []chatChunk
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		var c chatChunk
+		if json.Unmarshal(trimmed, &c) == nil {
+			chunks = append(chunks, c)
+		}
+	} else {
+		sc := bufio.NewScanner(bytes.NewReader(data))
+		sc.Buffer(make([]byte, 64*1024), maxLoggedBody)
+		for sc.Scan() {
+			line, ok := strings.CutPrefix(sc.Text(), "data:")
+			if !ok {
+				continue
+			}
+			var c chatChunk
+			if json.Unmarshal([]byte(strings.TrimSpace(line)), &c) == nil {
+				chunks = append(chunks, c)
+			}
+		}
+	}
+
+	var b strings.Builder
+	for _, c := range chunks {
+		for _, choice := range c.Choices {
+			b.WriteString(choice.Message.Content)
+			b.WriteString(choice.Delta.Content)
+		}
+		for _, block := range c.Content {
+			b.WriteString(block.Text)
+		}
+		b.WriteString(c.Delta.Text)
+
+		// Streams report usage in pieces: Anthropic's input tokens come
+		// with message_start and its output tokens with message_delta.
+		for _, u := range []chatUsage{c.Usage, c.Message.Usage} {
+			i, o := u.tokens()
+			in, out = max(in, i), max(out, o)
+		}
+	}
+	return b.String(), in, out
+}
+
+// TextSimilarity scores how alike two responses are, 0-100, by the words
+// they share (Sørensen-Dice over word counts). Identical texts score 100.
+func TextSimilarity(a, b string) float64 {
+	wa, wb := wordCounts(a), wordCounts(b)
+	total := 0
+	for _, n := range wa {
+		total += n
+	}
+	for _, n := range wb {
+		total += n
+	}
+	if total == 0 {
+		return 100
+	}
+	shared := 0
+	for w, n := range wa {
+		shared += min(n, wb[w])
+	}
+	return float64(2*shared) / float64(total) * 100
+}
+
+func wordCounts(s string) map[string]int {
+	counts := make(map[string]int)
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !(r == '\'' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r > 127)
+	}) {
+		counts[w]++
+	}
+	return counts
+}
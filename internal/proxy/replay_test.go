@@ -0,0 +1,239 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/msalah0e/palm/internal/vault"
+)
+
+func TestParseResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		text    string
+		in, out int64
+	}{
+		{
+			"openai json",
+			`{"choices":[{"message":{"content":"Hello there"}}],"usage":{"prompt_tokens":12,"completion_tokens":3}}`,
+			"Hello there", 12, 3,
+		},
+		{
+			"anthropic json",
+			`{"type":"message","content":[{"type":"text","text":"Hi"},{"type":"text","text":" you"}],"usage":{"input_tokens":9,"output_tokens":2}}`,
+			"Hi you", 9, 2,
+		},
+		{
+			"openai stream",
+			"data: {\"choices\":[{\"delta\":{\"content\":\"Hel\"}}]}\n\n" +
+				"data: {\"choices\":[{\"delta\":{\"content\":\"lo\"}}]}\n\n" +
+				"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":5,\"completion_tokens\":2}}\n\n" +
+				"data: [DONE]\n\n",
+			"Hello", 5, 2,
+		},
+		{
+			"anthropic stream",
+			"event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"usage\":{\"input_tokens\":7,\"output_tokens\":1}}}\n\n" +
+				"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"Yo\"}}\n\n" +
+				"event: message_delta\ndata: {\"type\":\"message_delta\",\"usage\":{\"output_tokens\":4}}\n\n",
+			"Yo", 7, 4,
+		},
+		{"not json", "upstream exploded", "", 0, 0},
+	}
+	for _, tt := range tests {
+		text, in, out := parseResponse([]byte(tt.body))
+		if text != tt.text || in != tt.in || out != tt.out {
+			t.Errorf("%s: got %q %d/%d, want %q %d/%d", tt.name, text, in, out, tt.text, tt.in, tt.out)
+		}
+	}
+}
+
+func TestTextSimilarity(t *testing.T) {
+	if got := TextSimilarity("The answer is 42.", "the ANSWER is 42"); got != 100 {
+		t.Errorf("same words = %.1f, want 100", got)
+	}
+	if got := TextSimilarity("red green", "blue yellow"); got != 0 {
+		t.Errorf("no shared words = %.1f, want 0", got)
+	}
+	if got := TextSimilarity("a b c d", "a b x y"); got != 50 {
+		t.Errorf("half shared = %.1f, want 50", got)
+	}
+	if got := TextSimilarity("", ""); got != 100 {
+		t.Errorf("both empty = %.1f, want 100", got)
+	}
+}
+
+// writeReplayLogs writes a proxy log and body log into a temp config dir.
+func writeReplayLogs(t *testing.T, logs []RequestLog, bodies []BodyLog) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	if err := os.MkdirAll(filepath.Join(dir, "palm"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name string, v any) {
+		f, err := os.OpenFile(filepath.Join(dir, "palm", name), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		_ = json.NewEncoder(f).Encode(v)
+	}
+	for _, l := range logs {
+		write("proxy.jsonl", l)
+	}
+	for _, b := range bodies {
+		write("proxy-bodies.jsonl", b)
+	}
+}
+
+func TestLoadReplayCases(t *testing.T) {
+	now := time.Now()
+	writeReplayLogs(t, []RequestLog{
+		{ID: "a1", Timestamp: now, Method: "POST", Path: "/openai/v1/chat/completions", Provider: "openai", Model: "gpt-4o", Status: 200},
+		{Timestamp: now, Method: "POST", Path: "/openai/v1/chat/completions", Provider: "openai", Model: "gpt-4o", Status: 200},
+		{ID: "m1", Timestamp: now, Method: "GET", Path: "/openai/v1/models", Provider: "openai", Status: 200},
+		{ID: "b2", Timestamp: now, Method: "POST", Path: "/anthropic/v1/messages", Provider: "anthropic", Model: "claude-sonnet-4", Status: 200},
+		{ID: "c3", Timestamp: now, Method: "POST", Path: "/openai/v1/chat/completions", Provider: "openai", Model: "gpt-4o-mini", Status: 200},
+	}, []BodyLog{
+		{ID: "a1", Request: `{}`, Response: `{}`},
+		{ID: "m1", Request: ``, Response: `{}`},
+		{ID: "b2", Request: `{}`, Response: `{}`},
+		{ID: "c3", Request: `{}`, Response: `{}`, Truncated: true},
+	})
+
+	cases, skipped, err := LoadReplayCases(10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The model listing isn't a chat request; the unlogged and truncated
+	// bodies are skipped.
+	if len(cases) != 2 || cases[0].Log.ID != "a1" || cases[1].Log.ID != "b2" || skipped != 2 {
+		t.Fatalf("got %d cases, %d skipped: %+v", len(cases), skipped, cases)
+	}
+
+	cases, _, _ = LoadReplayCases(10, func(l RequestLog) bool { return l.Provider == "anthropic" })
+	if len(cases) != 1 || cases[0].Log.ID != "b2" {
+		t.Errorf("provider filter: %+v", cases)
+	}
+	cases, _, _ = LoadReplayCases(1, nil)
+	if len(cases) != 0 {
+		t.Errorf("the most recent request has a truncated body, got %+v", cases)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var got map[string]any
+	var gotPath string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"Paris is the capital"}}],"usage":{"prompt_tokens":10,"completion_tokens":4}}`))
+	}))
+	defer upstream.Close()
+
+	orig := compatBases["ollama"]
+	compatBases["ollama"] = upstream.URL + "/v1"
+	defer func() { compatBases["ollama"] = orig }()
+
+	c := ReplayCase{
+		Log: RequestLog{ID: "x", Method: "POST", Path: "/anthropic/v1/messages", Provider: "anthropic", Model: "claude-sonnet-4", Status: 200, Duration: 900},
+		Bodies: BodyLog{
+			ID:       "x",
+			Request:  `{"model":"claude-sonnet-4","max_tokens":100,"stream":true,"system":"Be brief.","messages":[{"role":"user","content":"Capital of France?"}]}`,
+			Response: `{"content":[{"type":"text","text":"The capital is Paris"}],"usage":{"input_tokens":12,"output_tokens":5}}`,
+		},
+	}
+	res := Replay(context.Background(), c, Backend{Provider: "ollama", Model: "llama3.3"}, vault.NewFileVault())
+
+	if !res.Replay.OK() {
+		t.Fatalf("replay failed: %+v", res.Replay)
+	}
+	if gotPath != "/v1/chat/completions" || got["model"] != "llama3.3" {
+		t.Errorf("sent %s %v", gotPath, got)
+	}
+	if _, ok := got["stream"]; ok {
+		t.Error("replay should not stream")
+	}
+	if msgs, _ := got["messages"].([]any); len(msgs) != 2 {
+		t.Errorf("anthropic request not translated: %v", got["messages"])
+	}
+	if res.Original.Text != "The capital is Paris" || res.Original.InputTokens != 12 || res.Original.Backend != "anthropic/claude-sonnet-4" {
+		t.Errorf("original = %+v", res.Original)
+	}
+	if res.Replay.Text != "Paris is the capital" || res.Replay.OutputTokens != 4 || !res.Replay.Priced || res.Replay.Cost != 0 {
+		t.Errorf("replay = %+v", res.Replay)
+	}
+	if res.Similarity != 100 {
+		t.Errorf("similarity = %.1f, want 100", res.Similarity)
+	}
+
+	// Upstream errors are reported, not compared
+	compatBases["ollama"] = upstream.URL + "/missing"
+	upstream.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model not found", http.StatusNotFound)
+	})
+	res = Replay(context.Background(), c, Backend{Provider: "ollama", Model: "nope"}, vault.NewFileVault())
+	if res.Replay.OK() || res.Replay.Status != http.StatusNotFound || res.Similarity != 0 {
+		t.Errorf("expected a failed replay, got %+v", res)
+	}
+}
+
+func TestLogBodies(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"ok"}}]}`))
+	}))
+	defer upstream.Close()
+
+	orig := compatBases["ollama"]
+	compatBases["ollama"] = upstream.URL + "/v1"
+	defer func() { compatBases["ollama"] = orig }()
+
+	if err := os.MkdirAll(filepath.Dir(BodiesPath()), 0755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(BodiesPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	srv := New(Config{LogBodies: true})
+	srv.bodyFile = f
+	srv.SetRoutes(&RouteConfig{Routes: []Route{{Model: "fast", Provider: "ollama", Target: "llama3.3"}}})
+
+	body := `{"model":"fast","messages":[{"role":"user","content":"hi"}]}`
+	req := httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	srv.handleRequest(httptest.NewRecorder(), req)
+
+	bodies, err := ReadBodies(nil)
+	if err != nil || len(bodies) != 0 {
+		t.Fatalf("ReadBodies(nil) = %v, %v", bodies, err)
+	}
+	var b BodyLog
+	data, _ := os.ReadFile(BodiesPath())
+	if err := json.Unmarshal(data, &b); err != nil {
+		t.Fatalf("body log: %v (%s)", err, data)
+	}
+	// The request is logged as sent upstream, after routing
+	if b.ID == "" || !strings.Contains(b.Request, `"model":"llama3.3"`) || !strings.Contains(b.Response, `"ok"`) {
+		t.Errorf("logged %+v", b)
+	}
+	if got, _ := ReadBodies([]string{b.ID}); got[b.ID].Request != b.Request {
+		t.Errorf("ReadBodies by ID = %+v", got)
+	}
+}
@@ -0,0 +1,436 @@
+package proxy
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/msalah0e/palm/internal/models"
+)
+
+// Route maps a requested model (or alias) to the model that serves it.
+type Route struct {
+	Model       string   `toml:"model"`      // requested name; "*" globs allowed
+	Provider    string   `toml:"provider"`   // upstream provider for a fixed target
+	Target      string   `toml:"target"`     // upstream model; defaults to the requested one
+	Candidates  []string `toml:"candidates"` // "provider/model" options picked by strategy
+	Strategy    string   `toml:"strategy"`   // first (default), cheapest or fastest
+	Temperature *float64 `toml:"temperature"`
+	MaxTokens   *int     `toml:"max_tokens"`
+	Fallback    []string `toml:"fallback"` // "provider/model" backends tried on 429/5xx
+}
+
+// RouteConfig is the contents of routes.toml.
+type RouteConfig struct {
+	Routes    []Route                `toml:"route"`
+	Failover  FailoverConfig         `toml:"failover"`
+	Downgrade DowngradeConfig        `toml:"downgrade"`
+	Limits    map[string]LimitConfig `toml:"limits"` // by provider
+	Context   ContextConfig          `toml:"context"`
+	Anomaly   AnomalyConfig          `toml:"anomaly"`
+	Transform TransformConfig        `toml:"transform"`
+}
+
+// Backend is a concrete provider and model a request is sent to.
+type Backend struct {
+	Provider string
+	Model    string
+}
+
+func (b Backend) String() string {
+	return b.Provider + "/" + b.Model
+}
+
+// compatBases are the OpenAI-compatible API roots of each provider, used for
+// requests to the model-routed /v1/ endpoint.
+var compatBases = map[string]string{
+	"openai":    "https://api.openai.com/v1",
+	"anthropic": "https://api.anthropic.com/v1",
+	"google":    "https://generativelanguage.googleapis.com/v1beta/openai",
+	"groq":      "https://api.groq.com/openai/v1",
+	"mistral":   "https://api.mistral.ai/v1",
+	"ollama":    "http://localhost:11434/v1",
+	"llama-cpp": "http://127.0.0.1:8080/v1",
+	"vllm":      "http://127.0.0.1:8000/v1",
+}
+
+// localProviders serve models on this machine: they need no API key and
+// cost nothing.
+var localProviders = map[string]bool{"ollama": true, "llama-cpp": true, "vllm": true}
+
+// localModels maps models served by a registered local runtime to it.
+var localModels = map[string]string{}
+
+// RegisterLocal points a local provider at the server on root, e.g.
+// "http://127.0.0.1:8081", and routes requests for model to it. It must be
+// called before the proxy starts serving.
+func RegisterLocal(provider, root, model string) error {
+	if !localProviders[provider] {
+		return fmt.Errorf("%s is not a local runtime", provider)
+	}
+	providerRoutes["/"+provider+"/"] = root
+	compatBases[provider] = root + "/v1"
+	if model != "" {
+		localModels[model] = provider
+	}
+	return nil
+}
+
+// CompatBase returns the OpenAI-compatible API root of provider. With a
+// port it is the same root reached through a local palm proxy.
+func CompatBase(provider string, proxyPort int) (string, bool) {
+	base, ok := compatBases[provider]
+	if !ok || proxyPort == 0 {
+		return base, ok
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("http://localhost:%d/%s%s", proxyPort, provider, u.Path), true
+}
+
+// RoutesPath returns the path of the routing config.
+func RoutesPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "palm", "routes.toml")
+}
+
+// LoadRoutes reads and validates routes.toml. A missing file is an empty config.
+func LoadRoutes() (*RouteConfig, error) {
+	cfg := &RouteConfig{}
+	data, err := os.ReadFile(RoutesPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+	if err := toml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", RoutesPath(), err)
+	}
+	return cfg, cfg.Validate()
+}
+
+// Validate checks every route for unknown providers and strategies.
+func (c *RouteConfig) Validate() error {
+	for i, r := range c.Routes {
+		where := fmt.Sprintf("route %d (%s)", i+1, r.Model)
+		if r.Model == "" {
+			return fmt.Errorf("route %d: model is required", i+1)
+		}
+		if _, err := path.Match(r.Model, ""); err != nil {
+			return fmt.Errorf("%s: bad pattern: %w", where, err)
+		}
+		switch r.Strategy {
+		case "", "first", "cheapest", "fastest":
+		default:
+			return fmt.Errorf("%s: unknown strategy %q (use first, cheapest or fastest)", where, r.Strategy)
+		}
+		if r.Provider == "" && len(r.Candidates) == 0 {
+			return fmt.Errorf("%s: needs a provider or candidates", where)
+		}
+		if r.Provider != "" && len(r.Candidates) > 0 {
+			return fmt.Errorf("%s: use either provider or candidates, not both", where)
+		}
+		if _, ok := compatBases[r.Provider]; r.Provider != "" && !ok {
+			return fmt.Errorf("%s: unknown provider %q", where, r.Provider)
+		}
+		if err := validateBackends(r.Candidates); err != nil {
+			return fmt.Errorf("%s: %w", where, err)
+		}
+		if err := validateBackends(r.Fallback); err != nil {
+			return fmt.Errorf("%s: fallback: %w", where, err)
+		}
+	}
+	for provider, list := range c.Failover.Fallbacks {
+		if _, ok := compatBases[provider]; !ok {
+			return fmt.Errorf("failover: unknown provider %q", provider)
+		}
+		if err := validateBackends(list); err != nil {
+			return fmt.Errorf("failover.fallbacks.%s: %w", provider, err)
+		}
+	}
+	if c.Failover.Retries < 0 || c.Failover.Retries > 5 {
+		return fmt.Errorf("failover: retries must be between 0 and 5")
+	}
+	if err := validateLimits(c.Limits); err != nil {
+		return err
+	}
+	if err := c.Context.validate(); err != nil {
+		return err
+	}
+	if err := c.Anomaly.validate(); err != nil {
+		return err
+	}
+	if err := c.Transform.validate(); err != nil {
+		return err
+	}
+	return c.Downgrade.validate()
+}
+
+// validateBackends checks a list of "provider/model" entries.
+func validateBackends(list []string) error {
+	for _, entry := range list {
+		provider, model, _ := strings.Cut(entry, "/")
+		if _, ok := compatBases[provider]; !ok {
+			return fmt.Errorf("unknown provider in %q", entry)
+		}
+		if model == "" {
+			return fmt.Errorf("%q needs the form provider/model", entry)
+		}
+	}
+	return nil
+}
+
+// parseBackends turns "provider/model" entries into backends.
+func parseBackends(list []string) []Backend {
+	var out []Backend
+	for _, entry := range list {
+		provider, model, _ := strings.Cut(entry, "/")
+		out = append(out, Backend{Provider: provider, Model: model})
+	}
+	return out
+}
+
+// Fallbacks returns the backends to try when primary fails: the route's own
+// fallback list when set, otherwise the provider-wide list.
+func (c *RouteConfig) Fallbacks(primary Backend, route *Route) []Backend {
+	if c == nil {
+		return nil
+	}
+	list := c.Failover.Fallbacks[primary.Provider]
+	if route != nil && len(route.Fallback) > 0 {
+		list = route.Fallback
+	}
+	var out []Backend
+	for _, b := range parseBackends(list) {
+		if b != primary {
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+// Match returns the first route whose model pattern matches the requested
+// model, or nil.
+func (c *RouteConfig) Match(model string) *Route {
+	if c == nil {
+		return nil
+	}
+	for i, r := range c.Routes {
+		if ok, _ := path.Match(r.Model, model); ok {
+			return &c.Routes[i]
+		}
+	}
+	return nil
+}
+
+// backends lists the route's possible upstreams in configured order.
+func (r Route) backends(requested string) []Backend {
+	if len(r.Candidates) == 0 {
+		target := r.Target
+		if target == "" {
+			target = requested
+		}
+		return []Backend{{Provider: r.Provider, Model: target}}
+	}
+	return parseBackends(r.Candidates)
+}
+
+// Describe summarizes where the route sends requests.
+func (r Route) Describe() string {
+	if len(r.Candidates) > 0 {
+		return strings.Join(r.Candidates, ", ")
+	}
+	if r.Target == "" {
+		return r.Provider + " (same model)"
+	}
+	return r.Provider + "/" + r.Target
+}
+
+// Resolve picks the upstream for a request. Candidates whose provider is not
+// usable (no API key) are skipped; latency holds observed mean response times
+// in milliseconds keyed by Backend.String().
+func (r Route) Resolve(requested string, usable func(provider string) bool, latency map[string]float64) (Backend, error) {
+	var options []Backend
+	for _, b := range r.backends(requested) {
+		if usable == nil || usable(b.Provider) {
+			options = append(options, b)
+		}
+	}
+	if len(options) == 0 {
+		return Backend{}, fmt.Errorf("no usable backend for %q — add an API key for one of its providers", requested)
+	}
+
+	best := options[0]
+	switch r.Strategy {
+	case "cheapest":
+		bestCost := backendCost(best)
+		for _, b := range options[1:] {
+			if c := backendCost(b); c < bestCost {
+				best, bestCost = b, c
+			}
+		}
+	case "fastest":
+		// Untried backends rank after measured ones, in configured order
+		bestLat := latencyOf(latency, best)
+		for _, b := range options[1:] {
+			if l := latencyOf(latency, b); l < bestLat {
+				best, bestLat = b, l
+			}
+		}
+	}
+	return best, nil
+}
+
+// backendCost is the blended per-1M-token price; local models are free and
+// unknown models sort last.
+func backendCost(b Backend) float64 {
+	if localProviders[b.Provider] {
+		return 0
+	}
+	for _, m := range models.AllModels() {
+		if m.Provider == b.Provider && m.ID == b.Model {
+			return m.InputCost + m.OutputCost
+		}
+	}
+	return math.Inf(1)
+}
+
+func latencyOf(latency map[string]float64, b Backend) float64 {
+	if l, ok := latency[b.String()]; ok {
+		return l
+	}
+	return math.Inf(1)
+}
+
+// Apply rewrites a request body for the chosen backend and the route's
+// parameter overrides.
+func (r Route) Apply(body map[string]any, b Backend) {
+	body["model"] = b.Model
+	if r.Temperature != nil {
+		body["temperature"] = *r.Temperature
+	}
+	if r.MaxTokens != nil {
+		body["max_tokens"] = *r.MaxTokens
+	}
+}
+
+// InferProvider guesses the provider of a model palm knows about.
+func InferProvider(model string) string {
+	if provider, ok := localModels[model]; ok {
+		return provider
+	}
+	for _, m := range models.AllModels() {
+		if m.ID == model {
+			return m.Provider
+		}
+	}
+	switch {
+	case strings.HasPrefix(model, "gpt-"), strings.HasPrefix(model, "o1"), strings.HasPrefix(model, "o3"), strings.HasPrefix(model, "o4"):
+		return "openai"
+	case strings.HasPrefix(model, "claude-"):
+		return "anthropic"
+	case strings.HasPrefix(model, "gemini-"):
+		return "google"
+	}
+	return ""
+}
+
+// ExampleRoutes is written by `palm proxy routes --init`.
+const ExampleRoutes = `# palm proxy routing — requests to http://localhost:4778/v1/ are sent to the
+# provider of the requested model. Rules are matched top to bottom.
+
+# Pin a model to a provider
+[[route]]
+model = "gpt-4o"
+provider = "openai"
+
+# Alias: tools ask for "fast", palm serves a small Groq model
+[[route]]
+model = "fast"
+provider = "groq"
+target = "llama-3.1-8b-instant"
+temperature = 0.2
+
+# Alias resolved per request: the cheapest backend with an API key
+[[route]]
+model = "cheap"
+strategy = "cheapest"
+candidates = ["ollama/llama3.3", "groq/llama-3.3-70b-versatile", "openai/gpt-4o-mini"]
+max_tokens = 2048
+
+# When a provider returns 429 or 5xx, retry with backoff, then fall back.
+# Anthropic /v1/messages requests are translated for OpenAI-style backends.
+[failover]
+retries = 1
+backoff_ms = 500
+max_backoff_ms = 8000
+
+[failover.fallbacks]
+openai = ["anthropic/claude-sonnet-4-5-20250929", "groq/llama-3.3-70b-versatile"]
+anthropic = ["openai/gpt-4o"]
+
+# Once 80% of the budget is spent, serve cheaper models instead. Responses
+# carry an X-Palm-Downgraded header. Without "at" the budget's alert_at is
+# used. Past the limit requests get 402; past_limit = true keeps serving the
+# cheaper models, letting spending go over the budget.
+[downgrade]
+at = 80
+# past_limit = true
+
+[downgrade.models]
+"gpt-4o" = "gpt-4o-mini"
+"claude-opus-*" = "claude-haiku-4-5-20251001"
+
+# Send a provider at most max_in_flight requests at once. Bursts from agents
+# wait locally (up to queue requests, for queue_timeout_ms) instead of hitting
+# its rate limits; /palm/stats shows queue depth and wait times.
+[limits.anthropic]
+max_in_flight = 4
+queue = 64
+queue_timeout_ms = 60000
+
+# Requests longer than their model's context window (from the catalog) get a
+# 400 naming the sizes instead of an upstream call. "truncate" drops the
+# oldest turns until the request fits, with an X-Palm-Truncated header; "off"
+# sends everything.
+[context]
+policy = "reject"
+
+# Flag a provider whose requests or spend in a 5-minute window run 10x its
+# usual level, learned from the proxy log — e.g. an agent looping. From 30x,
+# routing to it pauses until: palm proxy resume <provider>
+[anomaly]
+factor = 10
+window_minutes = 5
+min_requests = 20
+pause_at = 30
+notify = true
+
+# Rewrite chat requests before they leave the machine: put an org-wide
+# system prompt first, replace email addresses and phone numbers with
+# [email] and [phone], and send metadata as X-Palm-Meta-<name> headers.
+# Responses carry an X-Palm-Transformed header and the proxy log records
+# what changed. Providers set to false are left alone.
+[transform]
+system_prompt = "Follow the ACME engineering guidelines. Never include customer data in code."
+pii = "redact"
+
+[transform.metadata]
+Team = "platform"
+Data-Classification = "internal"
+
+[transform.providers]
+ollama = false
+`
@@ -0,0 +1,328 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// StreamEvent is one server-sent event or WebSocket message passing
+// through the proxy.
+type StreamEvent struct {
+	Provider string
+	Path     string
+	Kind     string // "sse" or "websocket"
+	Up       bool   // sent by the client; otherwise by the provider
+	Type     string // the SSE event name, else the JSON "type" or "object"
+	Data     []byte
+}
+
+// EventHook observes stream events as they pass. Hooks run on the
+// goroutine forwarding the stream, so they should return quickly.
+type EventHook func(StreamEvent)
+
+// OnEvent adds a hook called for every streamed event. Add hooks before
+// Start.
+func (s *Server) OnEvent(h EventHook) {
+	s.hooks = append(s.hooks, h)
+}
+
+// logEvent is the hook --verbose adds, logging each event's type.
+func logEvent(ev StreamEvent) {
+	dir := "←"
+	if ev.Up {
+		dir = "→"
+	}
+	log.Printf("  [%s] %s %s %s (%d bytes)", ev.Provider, ev.Kind, dir, orUnnamed(ev.Type), len(ev.Data))
+}
+
+func orUnnamed(s string) string {
+	if s == "" {
+		return "message"
+	}
+	return s
+}
+
+// maxWSFrame is the largest WebSocket frame the proxy looks into; bigger
+// ones still pass through, but the rest of the stream goes unobserved.
+const maxWSFrame = 16 << 20
+
+// isWebSocket reports whether r asks to upgrade to a WebSocket.
+func isWebSocket(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
+
+// streamTap follows one request's stream, passing each event to the
+// hooks and adding up the token usage events report.
+type streamTap struct {
+	provider, path string
+	hooks          []EventHook
+
+	mu      sync.Mutex
+	kind    string
+	events  int
+	in, out int64
+}
+
+// eventBody holds the fields usage is read from. Chat streams repeat or
+// split their usage across chunks; a realtime session reports it once
+// per response.done, so those add up.
+type eventBody struct {
+	Type    string    `json:"type"`
+	Object  string    `json:"object"`
+	Usage   chatUsage `json:"usage"`
+	Message struct {
+		Usage chatUsage `json:"usage"`
+	} `json:"message"`
+	Response struct {
+		Usage chatUsage `json:"usage"`
+	} `json:"response"`
+}
+
+func (t *streamTap) emit(kind string, up bool, name string, data []byte) {
+	var body eventBody
+	parsed := json.Unmarshal(data, &body) == nil
+	if name == "" {
+		name = body.Type
+	}
+	if name == "" {
+		name = body.Object
+	}
+	if name == "" && string(bytes.TrimSpace(data)) == "[DONE]" {
+		name = "done"
+	}
+
+	t.mu.Lock()
+	t.kind = kind
+	t.events++
+	if parsed && !up {
+		if kind == "websocket" {
+			if body.Type == "response.done" {
+				i, o := body.Response.Usage.tokens()
+				t.in, t.out = t.in+i, t.out+o
+			}
+		} else {
+			for _, u := range []chatUsage{body.Usage, body.Message.Usage, body.Response.Usage} {
+				i, o := u.tokens()
+				t.in, t.out = max(t.in, i), max(t.out, o)
+			}
+		}
+	}
+	t.mu.Unlock()
+
+	ev := StreamEvent{Provider: t.provider, Path: t.path, Kind: kind, Up: up, Type: name, Data: data}
+	for _, h := range t.hooks {
+		h(ev)
+	}
+}
+
+// usage returns the events seen and the tokens they reported.
+func (t *streamTap) usage() (kind string, events int, in, out int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.kind, t.events, t.in, t.out
+}
+
+// sseParser splits a text/event-stream body into events.
+type sseParser struct {
+	buf   []byte
+	event string
+	data  []byte
+	emit  func(event string, data []byte)
+}
+
+func (p *sseParser) feed(b []byte) {
+	p.buf = append(p.buf, b...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			return
+		}
+		line := strings.TrimSuffix(string(p.buf[:i]), "\r")
+		p.buf = p.buf[i+1:]
+		p.line(line)
+	}
+}
+
+func (p *sseParser) line(line string) {
+	if line == "" {
+		// A blank line ends the event
+		if p.data != nil || p.event != "" {
+			p.emit(p.event, p.data)
+		}
+		p.event, p.data = "", nil
+		return
+	}
+	if strings.HasPrefix(line, ":") {
+		return // comment, often a keep-alive
+	}
+	field, value, _ := strings.Cut(line, ":")
+	value = strings.TrimPrefix(value, " ")
+	switch field {
+	case "event":
+		p.event = value
+	case "data":
+		if p.data != nil {
+			p.data = append(p.data, '\n')
+		}
+		p.data = append(p.data, value...)
+	}
+}
+
+// wsParser reassembles the WebSocket messages in one direction of a
+// connection. Only text messages are reported; binary and control frames
+// pass by unseen.
+type wsParser struct {
+	buf    []byte
+	msg    []byte
+	msgOp  byte
+	broken bool // a frame was too big to follow
+	emit   func(data []byte)
+}
+
+func (p *wsParser) feed(b []byte) {
+	if p.broken {
+		return
+	}
+	p.buf = append(p.buf, b...)
+	for {
+		frame, n, ok := p.frame()
+		if !ok {
+			return
+		}
+		p.buf = p.buf[n:]
+		p.handle(frame)
+	}
+}
+
+type wsFrame struct {
+	fin     bool
+	op      byte
+	payload []byte
+}
+
+// frame decodes the frame at the start of the buffer, reporting false when
+// it hasn't fully arrived.
+func (p *wsParser) frame() (wsFrame, int, bool) {
+	b := p.buf
+	if len(b) < 2 {
+		return wsFrame{}, 0, false
+	}
+	f := wsFrame{fin: b[0]&0x80 != 0, op: b[0] & 0x0f}
+	masked := b[1]&0x80 != 0
+	n := 2
+	length := uint64(b[1] & 0x7f)
+	switch length {
+	case 126:
+		if len(b) < n+2 {
+			return wsFrame{}, 0, false
+		}
+		length = uint64(binary.BigEndian.Uint16(b[n:]))
+		n += 2
+	case 127:
+		if len(b) < n+8 {
+			return wsFrame{}, 0, false
+		}
+		length = binary.BigEndian.Uint64(b[n:])
+		n += 8
+	}
+	if length > maxWSFrame {
+		p.broken, p.buf, p.msg = true, nil, nil
+		return wsFrame{}, 0, false
+	}
+	var mask []byte
+	if masked {
+		if len(b) < n+4 {
+			return wsFrame{}, 0, false
+		}
+		mask = b[n : n+4]
+		n += 4
+	}
+	if uint64(len(b)-n) < length {
+		return wsFrame{}, 0, false
+	}
+	f.payload = append([]byte(nil), b[n:n+int(length)]...)
+	for i := range f.payload {
+		if mask != nil {
+			f.payload[i] ^= mask[i%4]
+		}
+	}
+	return f, n + int(length), true
+}
+
+func (p *wsParser) handle(f wsFrame) {
+	switch {
+	case f.op >= 0x8:
+		return // close, ping and pong
+	case f.op != 0:
+		p.msgOp, p.msg = f.op, nil
+	}
+	p.msg = append(p.msg, f.payload...)
+	if len(p.msg) > maxWSFrame {
+		p.broken, p.buf, p.msg = true, nil, nil
+		return
+	}
+	if f.fin {
+		if p.msgOp == 0x1 {
+			p.emit(p.msg)
+		}
+		p.msg = nil
+	}
+}
+
+// tappedConn is a hijacked client connection whose WebSocket traffic is
+// reported to a tap in both directions.
+type tappedConn struct {
+	net.Conn
+	up, down *wsParser
+}
+
+func (c *tappedConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.up.feed(b[:n])
+	}
+	return n, err
+}
+
+func (c *tappedConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	if n > 0 {
+		c.down.feed(b[:n])
+	}
+	return n, err
+}
+
+// Flush sends buffered output on at once, so streamed events reach the
+// client as they arrive.
+func (r *responseRecorder) Flush() {
+	_ = http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+// Hijack hands the connection over for a protocol switch, tapping it when
+// it carries a WebSocket.
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := http.NewResponseController(r.ResponseWriter).Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	r.statusCode = http.StatusSwitchingProtocols
+	if !r.websocket || r.tap == nil {
+		return conn, brw, nil
+	}
+	emit := func(up bool) func([]byte) {
+		return func(data []byte) { r.tap.emit("websocket", up, "", data) }
+	}
+	return &tappedConn{Conn: conn, up: &wsParser{emit: emit(true)}, down: &wsParser{emit: emit(false)}}, brw, nil
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
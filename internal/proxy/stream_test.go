@@ -0,0 +1,283 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// wsFrameBytes encodes one WebSocket frame, masked as clients send them.
+func wsFrameBytes(op byte, fin bool, payload []byte, masked bool) []byte {
+	b0 := op
+	if fin {
+		b0 |= 0x80
+	}
+	frame := []byte{b0}
+	var maskBit byte
+	if masked {
+		maskBit = 0x80
+	}
+	switch {
+	case len(payload) < 126:
+		frame = append(frame, maskBit|byte(len(payload)))
+	case len(payload) <= 0xffff:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(len(payload)))
+	}
+	if !masked {
+		return append(frame, payload...)
+	}
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i, c := range payload {
+		frame = append(frame, c^mask[i%4])
+	}
+	return frame
+}
+
+func TestSSEParser(t *testing.T) {
+	var got []string
+	p := &sseParser{emit: func(event string, data []byte) { got = append(got, event+"|"+string(data)) }}
+	// Split mid-line, with a comment, CRLFs and a multi-line data field
+	p.feed([]byte(": keep-alive\n\nevent: message_start\r\ndata: {\"a\":"))
+	p.feed([]byte("1}\r\n\r\ndata: one\ndata: two\n\n"))
+	p.feed([]byte("data: [DONE]"))
+
+	want := []string{`message_start|{"a":1}`, "|one\ntwo"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+	p.feed([]byte("\n\n"))
+	if len(got) != 3 || got[2] != "|[DONE]" {
+		t.Errorf("the last event should arrive once complete: %q", got)
+	}
+}
+
+func TestWSParser(t *testing.T) {
+	var got []string
+	p := &wsParser{emit: func(data []byte) { got = append(got, string(data)) }}
+
+	long := strings.Repeat("x", 300)
+	stream := append(wsFrameBytes(0x1, true, []byte(`{"type":"session.created"}`), false),
+		wsFrameBytes(0x9, true, []byte("ping"), false)...)
+	stream = append(stream, wsFrameBytes(0x1, false, []byte("hel"), true)...)
+	stream = append(stream, wsFrameBytes(0x0, true, []byte("lo"), true)...)
+	stream = append(stream, wsFrameBytes(0x2, true, []byte{0, 1, 2}, false)...)
+	stream = append(stream, wsFrameBytes(0x1, true, []byte(long), false)...)
+	// Byte by byte, as a slow connection might deliver it
+	for i := range stream {
+		p.feed(stream[i : i+1])
+	}
+
+	if len(got) != 3 || got[0] != `{"type":"session.created"}` || got[1] != "hello" || got[2] != long {
+		t.Errorf("messages = %q", got)
+	}
+
+	big := &wsParser{emit: func([]byte) { t.Error("an oversized frame should not be reported") }}
+	header := []byte{0x81, 127}
+	big.feed(binary.BigEndian.AppendUint64(header, maxWSFrame+1))
+	if !big.broken {
+		t.Error("an oversized frame should stop the parser")
+	}
+}
+
+// withUpstream points the /ollama/ route at url for one test.
+func withUpstream(t *testing.T, url string) {
+	orig := providerRoutes["/ollama/"]
+	providerRoutes["/ollama/"] = url
+	t.Cleanup(func() { providerRoutes["/ollama/"] = orig })
+}
+
+// collectEvents records the events srv's hooks see.
+func collectEvents(srv *Server) func() []StreamEvent {
+	var mu sync.Mutex
+	var events []StreamEvent
+	srv.OnEvent(func(ev StreamEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, ev)
+	})
+	return func() []StreamEvent {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]StreamEvent(nil), events...)
+	}
+}
+
+func waitForRequests(t *testing.T, srv *Server, n int64) ProxyStats {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		srv.mu.Lock()
+		stats := srv.stats
+		srv.mu.Unlock()
+		if stats.TotalRequests >= n {
+			return stats
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("proxy handled %d requests, want %d", stats.TotalRequests, n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSSEPassthrough(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	release := make(chan struct{})
+	var once sync.Once
+	unblock := func() { once.Do(func() { close(release) }) }
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: {\"object\":\"chat.completion.chunk\",\"choices\":[{\"delta\":{\"content\":\"Hi\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		<-release
+		io.WriteString(w, "data: {\"object\":\"chat.completion.chunk\",\"usage\":{\"prompt_tokens\":12,\"completion_tokens\":3}}\n\ndata: [DONE]\n\n")
+	}))
+	defer upstream.Close()
+	withUpstream(t, upstream.URL)
+
+	srv := New(Config{})
+	events := collectEvents(srv)
+	proxy := httptest.NewServer(http.HandlerFunc(srv.handleRequest))
+	defer proxy.Close()
+	defer unblock()
+
+	// The first event must arrive while the upstream is still streaming
+	var br *bufio.Reader
+	first := make(chan error, 1)
+	go func() {
+		resp, err := http.Post(proxy.URL+"/ollama/v1/chat/completions", "application/json", strings.NewReader(`{"model":"llama3.3","stream":true}`))
+		if err != nil {
+			first <- err
+			return
+		}
+		br = bufio.NewReader(resp.Body)
+		line, err := br.ReadString('\n')
+		if err == nil && !strings.Contains(line, `"Hi"`) {
+			err = fmt.Errorf("first line = %q", line)
+		}
+		first <- err
+	}()
+	select {
+	case err := <-first:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("the first event was held back until the stream ended")
+	}
+	unblock()
+	rest, _ := io.ReadAll(br)
+	if !strings.Contains(string(rest), "[DONE]") {
+		t.Errorf("stream lost its end: %q", rest)
+	}
+
+	stats := waitForRequests(t, srv, 1)
+	if stats.TotalTokens != 15 {
+		t.Errorf("streamed usage not counted: %d tokens", stats.TotalTokens)
+	}
+	evs := events()
+	if len(evs) != 3 || evs[0].Kind != "sse" || evs[0].Type != "chat.completion.chunk" || evs[2].Type != "done" {
+		t.Errorf("unexpected events %+v", evs)
+	}
+}
+
+func TestWebSocketPassthrough(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	gotExtensions := make(chan string, 1)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isWebSocket(r) || r.URL.Query().Get("model") != "gpt-realtime" {
+			http.Error(w, "expected a realtime upgrade", http.StatusBadRequest)
+			return
+		}
+		gotExtensions <- r.Header.Get("Sec-WebSocket-Extensions")
+		conn, brw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: test\r\n\r\n")
+		brw.Flush()
+
+		// Wait for the client's message, then answer
+		msg := &wsParser{}
+		received := make(chan []byte, 1)
+		msg.emit = func(data []byte) { received <- data }
+		buf := make([]byte, 1024)
+		for len(received) == 0 {
+			n, err := brw.Read(buf)
+			if err != nil {
+				return
+			}
+			msg.feed(buf[:n])
+		}
+		<-received
+		conn.Write(wsFrameBytes(0x1, true, []byte(`{"type":"response.done","response":{"usage":{"input_tokens":40,"output_tokens":25}}}`), false))
+		conn.Write(wsFrameBytes(0x1, true, []byte(`{"type":"response.done","response":{"usage":{"input_tokens":10,"output_tokens":5}}}`), false))
+		conn.Write(wsFrameBytes(0x8, true, nil, false))
+	}))
+	defer upstream.Close()
+	withUpstream(t, upstream.URL)
+
+	srv := New(Config{})
+	events := collectEvents(srv)
+	proxy := httptest.NewServer(http.HandlerFunc(srv.handleRequest))
+	defer proxy.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(proxy.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	io.WriteString(conn, "GET /ollama/v1/realtime?model=gpt-realtime HTTP/1.1\r\nHost: localhost\r\n"+
+		"Upgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n"+
+		"Sec-WebSocket-Extensions: permessage-deflate\r\n\r\n")
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	if ext := <-gotExtensions; ext != "" {
+		t.Errorf("compression should not be negotiated through the proxy, upstream got %q", ext)
+	}
+
+	conn.Write(wsFrameBytes(0x1, true, []byte(`{"type":"response.create"}`), true))
+	var got []string
+	client := &wsParser{emit: func(data []byte) { got = append(got, string(data)) }}
+	buf := make([]byte, 1024)
+	for len(got) < 2 {
+		n, err := br.Read(buf)
+		if err != nil {
+			t.Fatalf("reading messages: %v (got %q)", err, got)
+		}
+		client.feed(buf[:n])
+	}
+	conn.Close()
+
+	stats := waitForRequests(t, srv, 1)
+	if stats.TotalTokens != 80 {
+		t.Errorf("realtime usage should add up across responses: %d tokens", stats.TotalTokens)
+	}
+	evs := events()
+	if len(evs) != 3 || !evs[0].Up || evs[0].Type != "response.create" || evs[1].Kind != "websocket" || evs[2].Type != "response.done" {
+		t.Errorf("unexpected events %+v", evs)
+	}
+}
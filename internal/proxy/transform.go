@@ -0,0 +1,168 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// TransformConfig rewrites outbound chat requests before they leave the
+// machine: an org-wide system prompt, PII redaction and compliance
+// metadata. It applies to OpenAI chat completions and Anthropic messages.
+type TransformConfig struct {
+	// SystemPrompt is put ahead of every request's own instructions.
+	SystemPrompt string `toml:"system_prompt"`
+	// PII is "redact" to replace email addresses and phone numbers in
+	// messages with [email] and [phone], or "off" (default).
+	PII string `toml:"pii"`
+	// Metadata is sent upstream as X-Palm-Meta-<name> headers.
+	Metadata map[string]string `toml:"metadata"`
+	// Providers turns the transforms off for providers set to false; the
+	// others get them.
+	Providers map[string]bool `toml:"providers"`
+}
+
+var metadataName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*$`)
+
+func (c TransformConfig) validate() error {
+	switch c.PII {
+	case "", "off", "redact":
+	default:
+		return fmt.Errorf("transform: unknown pii mode %q (use redact or off)", c.PII)
+	}
+	for name := range c.Metadata {
+		if !metadataName.MatchString(name) {
+			return fmt.Errorf("transform.metadata: %q can't be a header name (use letters, digits and -)", name)
+		}
+	}
+	for provider := range c.Providers {
+		if _, ok := compatBases[provider]; !ok {
+			return fmt.Errorf("transform.providers: unknown provider %q", provider)
+		}
+	}
+	return nil
+}
+
+// Enabled reports whether any transform is configured.
+func (c TransformConfig) Enabled() bool {
+	return c.SystemPrompt != "" || c.PII == "redact" || len(c.Metadata) > 0
+}
+
+// For reports whether requests to provider are transformed.
+func (c TransformConfig) For(provider string) bool {
+	on, listed := c.Providers[provider]
+	return c.Enabled() && (on || !listed)
+}
+
+// Describe lists the configured transforms, for palm proxy routes.
+func (c TransformConfig) Describe() []string {
+	var out []string
+	if c.SystemPrompt != "" {
+		out = append(out, fmt.Sprintf("system prompt (%d chars)", len(c.SystemPrompt)))
+	}
+	if c.PII == "redact" {
+		out = append(out, "redact emails and phone numbers")
+	}
+	if len(c.Metadata) > 0 {
+		names := make([]string, 0, len(c.Metadata))
+		for name := range c.Metadata {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		out = append(out, fmt.Sprintf("metadata headers %v", names))
+	}
+	return out
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+	phonePattern = regexp.MustCompile(`(?:\+\d{1,3}[ -]?)?(?:\(\d{2,4}\)[ -]?|\b\d{2,4}[ -])\d{3,4}[ -]\d{3,4}\b`)
+)
+
+// piiRedactor replaces PII and counts what it replaced.
+type piiRedactor struct {
+	n int
+}
+
+func (p *piiRedactor) redact(s string) string {
+	s = emailPattern.ReplaceAllStringFunc(s, func(string) string {
+		p.n++
+		return "[email]"
+	})
+	return phonePattern.ReplaceAllStringFunc(s, func(string) string {
+		p.n++
+		return "[phone]"
+	})
+}
+
+// redactValue redacts a message field: a string, or content parts whose
+// text (and nested content, for tool results) is redacted.
+func (p *piiRedactor) redactValue(v any) any {
+	switch v := v.(type) {
+	case string:
+		return p.redact(v)
+	case []any:
+		for i, item := range v {
+			v[i] = p.redactValue(item)
+		}
+	case map[string]any:
+		for _, key := range []string{"text", "content"} {
+			if item, ok := v[key]; ok {
+				v[key] = p.redactValue(item)
+			}
+		}
+	}
+	return v
+}
+
+// applyTransforms rewrites a chat request body in format for the
+// configured transforms and adds the metadata headers to h. It returns
+// what it did, e.g. ["pii:2", "system-prompt"], for the proxy log.
+func (c TransformConfig) applyTransforms(body map[string]any, format apiFormat, h http.Header) []string {
+	var applied []string
+
+	if c.PII == "redact" {
+		var p piiRedactor
+		if sys, ok := body["system"]; ok {
+			body["system"] = p.redactValue(sys)
+		}
+		if msgs, ok := body["messages"].([]any); ok {
+			for _, m := range msgs {
+				if msg, ok := m.(map[string]any); ok && msg["content"] != nil {
+					msg["content"] = p.redactValue(msg["content"])
+				}
+			}
+		}
+		if p.n > 0 {
+			applied = append(applied, "pii:"+strconv.Itoa(p.n))
+		}
+	}
+
+	if c.SystemPrompt != "" {
+		switch format {
+		case formatOpenAI:
+			msgs, _ := body["messages"].([]any)
+			body["messages"] = append([]any{map[string]any{"role": "system", "content": c.SystemPrompt}}, msgs...)
+		case formatAnthropic:
+			switch sys := body["system"].(type) {
+			case string:
+				body["system"] = c.SystemPrompt + "\n\n" + sys
+			case []any:
+				body["system"] = append([]any{map[string]any{"type": "text", "text": c.SystemPrompt}}, sys...)
+			default:
+				body["system"] = c.SystemPrompt
+			}
+		}
+		applied = append(applied, "system-prompt")
+	}
+
+	if len(c.Metadata) > 0 {
+		for name, value := range c.Metadata {
+			h.Set("X-Palm-Meta-"+name, value)
+		}
+		applied = append(applied, "metadata")
+	}
+	return applied
+}
@@ -0,0 +1,169 @@
+package registry
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/msalah0e/palm/internal/config"
+)
+
+// DefaultDetectTTL is how long detection results are reused when
+// [install] detect_ttl isn't set.
+const DefaultDetectTTL = 10 * time.Minute
+
+// detectWorkers caps the verify commands run at once.
+const detectWorkers = 8
+
+// detectEntry is a cached detection result. Keys are left out: they depend
+// on the environment of each run, and checking them is cheap.
+type detectEntry struct {
+	Verify    string    `json:"verify"` // verify command the result is for
+	Installed bool      `json:"installed"`
+	Version   string    `json:"version,omitempty"`
+	Path      string    `json:"path,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+var detectCache struct {
+	sync.Mutex
+	entries map[string]detectEntry
+	ttl     time.Duration
+	loaded  bool
+}
+
+// DetectCachePath returns the file detection results are cached in.
+func DetectCachePath() string {
+	dir := os.Getenv("XDG_CACHE_HOME")
+	if dir == "" {
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, ".cache")
+	}
+	return filepath.Join(dir, "palm", "detect.json")
+}
+
+// DetectTTL returns how long detection results are reused, from
+// [install] detect_ttl.
+func DetectTTL() time.Duration {
+	if v := config.Load().Install.DetectTTL; v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			return d
+		}
+	}
+	return DefaultDetectTTL
+}
+
+// loadDetectCache reads the cache file once per process. The caller holds
+// the lock.
+func loadDetectCache() {
+	if detectCache.loaded {
+		return
+	}
+	detectCache.loaded = true
+	detectCache.ttl = DetectTTL()
+	detectCache.entries = make(map[string]detectEntry)
+	if data, err := os.ReadFile(DetectCachePath()); err == nil {
+		_ = json.Unmarshal(data, &detectCache.entries)
+	}
+}
+
+// saveDetectCache writes the cache through a temporary file, so concurrent
+// palm processes never read a partial one. The caller holds the lock.
+func saveDetectCache() error {
+	path := DetectCachePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(detectCache.entries)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".detect-*.json")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	tmp.Close()
+	return os.Rename(tmp.Name(), path)
+}
+
+// DetectMany detects tools concurrently, in the order given. Results younger
+// than DetectTTL come from the cache; the rest are probed and cached.
+func DetectMany(tools []Tool) []DetectedTool {
+	results := make([]DetectedTool, len(tools))
+
+	detectCache.Lock()
+	loadDetectCache()
+	ttl := detectCache.ttl
+	var stale []int
+	for i, t := range tools {
+		e, ok := detectCache.entries[t.Name]
+		if ttl > 0 && ok && e.Verify == t.Install.Verify.Command && time.Since(e.CheckedAt) < ttl {
+			results[i] = DetectedTool{Tool: t, Installed: e.Installed, Version: e.Version, Path: e.Path}
+			checkKeys(&results[i])
+			continue
+		}
+		stale = append(stale, i)
+	}
+	detectCache.Unlock()
+	if len(stale) == 0 {
+		return results
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, detectWorkers)
+	for _, i := range stale {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = Probe(tools[i])
+		}(i)
+	}
+	wg.Wait()
+
+	if ttl == 0 {
+		return results
+	}
+	detectCache.Lock()
+	defer detectCache.Unlock()
+	now := time.Now()
+	for _, i := range stale {
+		dt := results[i]
+		detectCache.entries[dt.Tool.Name] = detectEntry{
+			Verify:    dt.Tool.Install.Verify.Command,
+			Installed: dt.Installed,
+			Version:   dt.Version,
+			Path:      dt.Path,
+			CheckedAt: now,
+		}
+	}
+	_ = saveDetectCache()
+	return results
+}
+
+// ForgetDetection drops cached results for the named tools, or for every
+// tool when none are named, so the next detection probes them again.
+func ForgetDetection(names ...string) error {
+	detectCache.Lock()
+	defer detectCache.Unlock()
+	loadDetectCache()
+	if len(names) == 0 {
+		detectCache.entries = make(map[string]detectEntry)
+		if err := os.Remove(DetectCachePath()); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	for _, n := range names {
+		delete(detectCache.entries, n)
+	}
+	return saveDetectCache()
+}
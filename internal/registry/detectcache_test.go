@@ -0,0 +1,69 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectCache(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	detectCache.loaded = false
+	t.Cleanup(func() { detectCache.loaded = false })
+
+	probes := filepath.Join(dir, "probes")
+	tool := func(name, version string) Tool {
+		var tl Tool
+		tl.Name = name
+		tl.Install.Verify.Command = "echo " + name + " >> " + probes + "; echo " + name + " " + version
+		return tl
+	}
+	count := func() int {
+		data, _ := os.ReadFile(probes)
+		return strings.Count(string(data), "\n")
+	}
+
+	tools := []Tool{tool("a", "1.0.0"), tool("b", "2.0.0"), tool("c", "3.0.0")}
+	got := DetectMany(tools)
+	if len(got) != 3 || got[0].Version != "1.0.0" || got[2].Tool.Name != "c" || !got[2].Installed || count() != 3 {
+		t.Fatalf("DetectMany should probe every tool and keep their order, got %+v", got)
+	}
+	if _, err := os.Stat(DetectCachePath()); err != nil {
+		t.Errorf("results should be cached: %v", err)
+	}
+
+	if dt := DetectOne(tools[1]); dt.Version != "2.0.0" || count() != 3 {
+		t.Errorf("a cached tool shouldn't be probed again, got %+v after %d probes", dt, count())
+	}
+	if dt := DetectOne(tool("b", "2.1.0")); dt.Version != "2.1.0" || count() != 4 {
+		t.Errorf("a changed verify command should be probed, got %+v", dt)
+	}
+
+	if err := ForgetDetection("a"); err != nil {
+		t.Fatal(err)
+	}
+	DetectMany(tools)
+	if count() != 6 {
+		t.Errorf("the forgotten tool and the one whose command changed should be probed again, got %d probes", count())
+	}
+
+	if err := ForgetDetection(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(DetectCachePath()); !os.IsNotExist(err) {
+		t.Error("forgetting everything should remove the cache file")
+	}
+
+	// detect_ttl = "0" turns the cache off
+	_ = os.MkdirAll(filepath.Join(dir, "palm"), 0o755)
+	_ = os.WriteFile(filepath.Join(dir, "palm", "config.toml"), []byte("[install]\ndetect_ttl = \"0\"\n"), 0o644)
+	detectCache.loaded = false
+	DetectOne(tools[0])
+	DetectOne(tools[0])
+	if count() != 8 {
+		t.Errorf("with a zero TTL every detection should probe, got %d probes", count())
+	}
+}
@@ -3,6 +3,7 @@ package registry
 import (
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 )
 
@@ -18,19 +19,13 @@ type DetectedTool struct {
 
 // Detect scans the system for installed AI tools from the registry.
 func Detect(reg *Registry) []DetectedTool {
-	var results []DetectedTool
-	for _, tool := range reg.All() {
-		dt := DetectOne(tool)
-		results = append(results, dt)
-	}
-	return results
+	return DetectMany(reg.All())
 }
 
 // DetectInstalled returns only tools that are installed.
 func DetectInstalled(reg *Registry) []DetectedTool {
 	var results []DetectedTool
-	for _, tool := range reg.All() {
-		dt := DetectOne(tool)
+	for _, dt := range DetectMany(reg.All()) {
 		if dt.Installed {
 			results = append(results, dt)
 		}
@@ -39,7 +34,14 @@ func DetectInstalled(reg *Registry) []DetectedTool {
 }
 
 // DetectOne checks if a single tool is installed and returns detection info.
+// Recent results come from the detection cache.
 func DetectOne(tool Tool) DetectedTool {
+	return DetectMany([]Tool{tool})[0]
+}
+
+// Probe runs tool's verify command to see whether it is installed, bypassing
+// the detection cache.
+func Probe(tool Tool) DetectedTool {
 	dt := DetectedTool{Tool: tool}
 
 	if tool.Install.Verify.Command == "" {
@@ -48,6 +50,12 @@ func DetectOne(tool Tool) DetectedTool {
 
 	// Run the full verify command via shell to handle pipes, subshells, etc.
 	cmd := exec.Command("sh", "-c", tool.Install.Verify.Command)
+	binPath := ""
+	if len(tool.Install.Binary.Assets) > 0 {
+		// Release binaries land in BinDir, which may not be on PATH yet
+		binPath = filepath.Join(BinDir(), tool.BinaryName())
+		cmd.Env = append(os.Environ(), "PATH="+BinDir()+string(os.PathListSeparator)+os.Getenv("PATH"))
+	}
 	out, err := cmd.Output()
 	if err != nil {
 		// Command failed → tool not installed
@@ -62,19 +70,30 @@ func DetectOne(tool Tool) DetectedTool {
 	if len(parts) > 0 {
 		if path, err := exec.LookPath(parts[0]); err == nil {
 			dt.Path = path
+		} else if binPath != "" {
+			if _, err := os.Stat(binPath); err == nil {
+				dt.Path = binPath
+			}
 		}
 	}
 
-	// Check API keys
-	for _, key := range tool.Keys.Required {
+	checkKeys(&dt)
+	return dt
+}
+
+// checkKeys sorts an installed tool's required API keys into set and missing.
+func checkKeys(dt *DetectedTool) {
+	dt.KeysSet, dt.KeysMissing = nil, nil
+	if !dt.Installed {
+		return
+	}
+	for _, key := range dt.Tool.Keys.Required {
 		if os.Getenv(key) != "" {
 			dt.KeysSet = append(dt.KeysSet, key)
 		} else {
 			dt.KeysMissing = append(dt.KeysMissing, key)
 		}
 	}
-
-	return dt
 }
 
 // ExtractVersion tries to pull a version number from command output.
@@ -15,6 +15,10 @@ type presetFile struct {
 	Presets []Preset `toml:"presets"`
 }
 
+type stackFile struct {
+	Stacks []Stack `toml:"stacks"`
+}
+
 // LoadFromFS loads all tools from an embed.FS containing TOML files in a "registry" directory.
 func LoadFromFS(fs embed.FS, dir string) (*Registry, error) {
 	entries, err := fs.ReadDir(dir)
@@ -24,7 +28,7 @@ func LoadFromFS(fs embed.FS, dir string) (*Registry, error) {
 
 	var allTools []Tool
 	for _, entry := range entries {
-		if entry.IsDir() || entry.Name() == "presets.toml" {
+		if entry.IsDir() || entry.Name() == "presets.toml" || entry.Name() == "stacks.toml" {
 			continue
 		}
 		data, err := fs.ReadFile(dir + "/" + entry.Name())
@@ -55,3 +59,17 @@ func LoadPresetsFromFS(fs embed.FS, dir string) ([]Preset, error) {
 	}
 	return pf.Presets, nil
 }
+
+// LoadStacksFromFS loads stack definitions from stacks.toml in the embedded FS.
+func LoadStacksFromFS(fs embed.FS, dir string) ([]Stack, error) {
+	data, err := fs.ReadFile(dir + "/stacks.toml")
+	if err != nil {
+		return nil, fmt.Errorf("reading stacks.toml: %w", err)
+	}
+
+	var sf stackFile
+	if err := toml.Unmarshal(data, &sf); err != nil {
+		return nil, fmt.Errorf("parsing stacks.toml: %w", err)
+	}
+	return sf.Stacks, nil
+}
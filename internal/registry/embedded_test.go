@@ -34,6 +34,10 @@ func TestLoadFromFS(t *testing.T) {
 		t.Errorf("expected pip 'test-tool-a', got %q", tool.Install.Pip)
 	}
 
+	if len(tool.PostInstall) != 2 || tool.PostInstall[0].Check != "test-tool-a status" || tool.PostInstall[1].Shell == "" {
+		t.Errorf("post_install not loaded: %+v", tool.PostInstall)
+	}
+
 	tool2 := reg.Get("test-tool-c")
 	if tool2 == nil {
 		t.Fatal("test-tool-c not found")
@@ -95,3 +99,13 @@ func TestLoadFromFS_InvalidDir(t *testing.T) {
 		t.Error("expected error for nonexistent directory")
 	}
 }
+
+func TestLoadStacksFromFS(t *testing.T) {
+	stacks, err := LoadStacksFromFS(testFS, "testdata")
+	if err != nil {
+		t.Fatalf("LoadStacksFromFS failed: %v", err)
+	}
+	if len(stacks) != 1 || stacks[0].Name != "test-stack" || len(stacks[0].Tools) != 2 || stacks[0].MCP[0] != "filesystem" {
+		t.Errorf("unexpected stacks %+v", stacks)
+	}
+}
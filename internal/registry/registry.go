@@ -1,6 +1,7 @@
 package registry
 
 import (
+	"sort"
 	"strings"
 )
 
@@ -17,11 +18,108 @@ func New(tools []Tool) *Registry {
 		byName: make(map[string]*Tool, len(tools)),
 	}
 	for i := range r.tools {
+		r.tools[i].Tags = normalizeTags(r.tools[i].Tags)
 		r.byName[r.tools[i].Name] = &r.tools[i]
 	}
 	return r
 }
 
+// tagAliases folds other spellings of a tag into the one the registry uses.
+var tagAliases = map[string]string{
+	"agent":   "agents",
+	"offline": "local",
+	"on-prem": "self-hosted",
+}
+
+// NormalizeTag lowercases tag and folds its aliases, so --tag agent finds
+// tools tagged agents.
+func NormalizeTag(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if alias, ok := tagAliases[tag]; ok {
+		return alias
+	}
+	return tag
+}
+
+func normalizeTags(tags []string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		if tag = NormalizeTag(tag); tag != "" && !seen[tag] {
+			seen[tag] = true
+			out = append(out, tag)
+		}
+	}
+	return out
+}
+
+// HasTag reports whether the tool carries tag.
+func (t Tool) HasTag(tag string) bool {
+	tag = NormalizeTag(tag)
+	for _, have := range t.Tags {
+		if have == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the tools in category ("" for any) that carry every one
+// of tags.
+func (r *Registry) Filter(category string, tags []string) []Tool {
+	var results []Tool
+	for _, t := range r.tools {
+		if category != "" && !strings.EqualFold(t.Category, category) {
+			continue
+		}
+		all := true
+		for _, tag := range tags {
+			if !t.HasTag(tag) {
+				all = false
+				break
+			}
+		}
+		if all {
+			results = append(results, t)
+		}
+	}
+	return results
+}
+
+// Facet is a category or tag and how many tools have it.
+type Facet struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Facets counts the categories and tags of tools, most common first, for
+// narrowing a listing down.
+func Facets(tools []Tool) (categories, tags []Facet) {
+	cats := make(map[string]int)
+	tagCounts := make(map[string]int)
+	for _, t := range tools {
+		cats[t.Category]++
+		for _, tag := range t.Tags {
+			tagCounts[tag]++
+		}
+	}
+	return sortFacets(cats), sortFacets(tagCounts)
+}
+
+func sortFacets(counts map[string]int) []Facet {
+	facets := make([]Facet, 0, len(counts))
+	for name, n := range counts {
+		facets = append(facets, Facet{name, n})
+	}
+	sort.Slice(facets, func(i, j int) bool {
+		if facets[i].Count != facets[j].Count {
+			return facets[i].Count > facets[j].Count
+		}
+		return facets[i].Name < facets[j].Name
+	})
+	return facets
+}
+
 // All returns all tools in the registry.
 func (r *Registry) All() []Tool {
 	return r.tools
@@ -81,10 +179,5 @@ func matches(t Tool, query string) bool {
 	if strings.ToLower(t.Category) == query {
 		return true
 	}
-	for _, tag := range t.Tags {
-		if strings.ToLower(tag) == query {
-			return true
-		}
-	}
-	return false
+	return t.HasTag(query)
 }
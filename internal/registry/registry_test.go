@@ -1,8 +1,13 @@
 package registry
 
 import (
+	"path/filepath"
+	"regexp"
 	"runtime"
+	"strings"
 	"testing"
+
+	"github.com/BurntSushi/toml"
 )
 
 func sampleTools() []Tool {
@@ -261,3 +266,187 @@ func TestExtractVersion(t *testing.T) {
 		}
 	}
 }
+
+func TestParseRequirement(t *testing.T) {
+	cases := []struct {
+		in   string
+		want Requirement
+	}{
+		{"node>=18", Requirement{Name: "node", Op: ">=", Version: "18"}},
+		{"Python >= 3.10", Requirement{Name: "python", Op: ">=", Version: "3.10"}},
+		{"ollama", Requirement{Name: "ollama"}},
+		{"go==v1.22", Requirement{Name: "go", Op: "=", Version: "1.22"}},
+	}
+	for _, c := range cases {
+		got, err := ParseRequirement(c.in)
+		if err != nil || got != c.want {
+			t.Errorf("ParseRequirement(%q) = %+v, %v; want %+v", c.in, got, err, c.want)
+		}
+	}
+	if _, err := ParseRequirement("node>>18"); err == nil {
+		t.Error("expected error for malformed requirement")
+	}
+}
+
+func TestRequirementSatisfiedBy(t *testing.T) {
+	cases := []struct {
+		req, version string
+		want         bool
+	}{
+		{"node>=18", "v20.11.1", true},
+		{"node>=18", "v16.20.0", false},
+		{"python>=3.10", "3.9.18", false},
+		{"python>=3.10", "Python 3.12.1", true},
+		{"go<1.22", "go1.21.5", true},
+		{"node=18", "18.19.0", true},
+		{"node=18", "20.0.0", false},
+		{"ollama", "", true},
+		{"node>=18", "", false},
+	}
+	for _, c := range cases {
+		r, _ := ParseRequirement(c.req)
+		if got := r.SatisfiedBy(c.version); got != c.want {
+			t.Errorf("%s satisfied by %q = %v, want %v", c.req, c.version, got, c.want)
+		}
+	}
+}
+
+func TestPromptArgs(t *testing.T) {
+	aider := Tool{
+		Name:    "aider",
+		Install: Install{Verify: Verify{Command: "aider --version"}},
+		Invoke:  Invoke{Prompt: "aider --message {prompt}"},
+	}
+	got := aider.PromptArgs("fix the bug in main.go")
+	want := []string{"aider", "--message", "fix the bug in main.go"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("PromptArgs = %q, want %q", got, want)
+	}
+
+	// Without a template the prompt is the binary's only argument
+	plain := Tool{Name: "mytool", Install: Install{Verify: Verify{Command: "mt --version"}}}
+	if got := plain.PromptArgs("hi"); strings.Join(got, "|") != "mt|hi" {
+		t.Errorf("PromptArgs without template = %q", got)
+	}
+}
+
+func TestAttachArgs(t *testing.T) {
+	aider := Tool{Name: "aider", Invoke: Invoke{Attach: "--read {file}"}}
+	got := aider.AttachArgs([]string{"main.go", "docs/spec.md"})
+	if strings.Join(got, "|") != "--read|main.go|--read|docs/spec.md" {
+		t.Errorf("AttachArgs = %q", got)
+	}
+	if got := (Tool{Name: "mods"}).AttachArgs([]string{"main.go"}); got != nil {
+		t.Errorf("a tool without an attach template should take no file args, got %q", got)
+	}
+}
+
+func TestBinary(t *testing.T) {
+	cases := []struct {
+		verify, want string
+	}{
+		{"claude --version", "claude"},
+		{"python3 -c 'import vllm'", "vllm"},
+		{"", "vllm"},
+	}
+	for _, c := range cases {
+		tool := Tool{Name: "vllm", Install: Install{Verify: Verify{Command: c.verify}}}
+		if got := tool.Binary(); got != c.want {
+			t.Errorf("Binary() with verify %q = %q, want %q", c.verify, got, c.want)
+		}
+	}
+}
+
+func TestSetupSteps(t *testing.T) {
+	tool := Tool{PostInstall: []Setup{
+		{Description: "everywhere"},
+		{Description: "here", OS: []string{runtime.GOOS}},
+		{Description: "elsewhere", OS: []string{"plan9"}},
+	}}
+	steps := tool.SetupSteps()
+	if len(steps) != 2 || steps[0].Description != "everywhere" || steps[1].Description != "here" {
+		t.Errorf("SetupSteps = %+v", steps)
+	}
+}
+
+func TestFilterAndFacets(t *testing.T) {
+	reg := New([]Tool{
+		{Name: "ollama", Category: "llm", Tags: []string{"LLM", "local", "offline"}},
+		{Name: "aider", Category: "coding", Tags: []string{"coding", "local"}},
+		{Name: "goose", Category: "agents", Tags: []string{"agent", "coding"}},
+	})
+
+	if tags := reg.Get("ollama").Tags; len(tags) != 2 || tags[0] != "llm" || tags[1] != "local" {
+		t.Errorf("tags should be lowercased with aliases folded: %q", tags)
+	}
+	if got := reg.Filter("coding", []string{"local"}); len(got) != 1 || got[0].Name != "aider" {
+		t.Errorf("Filter(coding, local) = %v", got)
+	}
+	if got := reg.Filter("", []string{"agents"}); len(got) != 1 || got[0].Name != "goose" {
+		t.Errorf("an aliased tag should match: %v", got)
+	}
+	if got := reg.Filter("", []string{"Agent", "coding"}); len(got) != 1 {
+		t.Errorf("every tag must match: %v", got)
+	}
+	if got := reg.Filter("", nil); len(got) != 3 {
+		t.Errorf("no facets should match everything, got %d", len(got))
+	}
+
+	cats, tags := Facets(reg.All())
+	if len(cats) != 3 || tags[0] != (Facet{"coding", 2}) || tags[1] != (Facet{"local", 2}) {
+		t.Errorf("Facets = %v, %v", cats, tags)
+	}
+}
+
+// TestBuiltinRegistry checks the shipped registry files: tags are clean,
+// and every stack names tools and MCP servers that exist.
+func TestBuiltinRegistry(t *testing.T) {
+	files, _ := filepath.Glob("../../registry/*.toml")
+	tools := make(map[string]bool)
+	tagName := regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	for _, path := range files {
+		var tf toolFile
+		if _, err := toml.DecodeFile(path, &tf); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		for _, tool := range tf.Tools {
+			tools[tool.Name] = true
+			for _, tag := range tool.Tags {
+				if !tagName.MatchString(tag) || NormalizeTag(tag) != tag || tag == "ai" {
+					t.Errorf("%s: tag %q should be a lowercase, canonical, specific name", tool.Name, tag)
+				}
+			}
+		}
+	}
+
+	var servers struct {
+		Servers []struct{ Name string } `toml:"servers"`
+	}
+	if _, err := toml.DecodeFile("../mcp/servers.toml", &servers); err != nil {
+		t.Fatal(err)
+	}
+	mcp := make(map[string]bool)
+	for _, s := range servers.Servers {
+		mcp[s.Name] = true
+	}
+
+	var sf stackFile
+	if _, err := toml.DecodeFile("../../registry/stacks.toml", &sf); err != nil {
+		t.Fatal(err)
+	}
+	if len(sf.Stacks) == 0 {
+		t.Fatal("no stacks")
+	}
+	for _, s := range sf.Stacks {
+		for _, name := range s.Tools {
+			if !tools[name] {
+				t.Errorf("stack %s: unknown tool %q", s.Name, name)
+			}
+		}
+		for _, name := range s.MCP {
+			if !mcp[name] {
+				t.Errorf("stack %s: unknown MCP server %q", s.Name, name)
+			}
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package registry
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Requirement is a parsed entry of a tool's requires list, e.g. "node>=18".
+type Requirement struct {
+	Name    string // toolchain ("node", "python", ...) or registry tool name
+	Op      string // one of >=, >, <=, <, = ; empty when any version will do
+	Version string
+}
+
+var requirementRe = regexp.MustCompile(`^\s*([A-Za-z0-9_.\-]+)\s*(?:(>=|<=|==|=|>|<)\s*v?([0-9][0-9A-Za-z.\-]*))?\s*$`)
+
+// ParseRequirement parses "name", "name>=1.2" and similar forms.
+func ParseRequirement(s string) (Requirement, error) {
+	m := requirementRe.FindStringSubmatch(s)
+	if m == nil {
+		return Requirement{}, fmt.Errorf("invalid requirement %q", s)
+	}
+	op := m[2]
+	if op == "==" {
+		op = "="
+	}
+	return Requirement{Name: strings.ToLower(m[1]), Op: op, Version: m[3]}, nil
+}
+
+func (r Requirement) String() string {
+	return r.Name + r.Op + r.Version
+}
+
+// SatisfiedBy reports whether an installed version meets the requirement.
+// An unknown installed version satisfies a requirement without a version.
+func (r Requirement) SatisfiedBy(version string) bool {
+	if r.Op == "" {
+		return true
+	}
+	if version == "" {
+		return false
+	}
+	c := CompareVersions(version, r.Version)
+	switch r.Op {
+	case ">=":
+		return c >= 0
+	case ">":
+		return c > 0
+	case "<=":
+		return c <= 0
+	case "<":
+		return c < 0
+	case "=":
+		// "=18" matches any 18.x.y
+		return c == 0 || strings.HasPrefix(normalizeVersion(version)+".", normalizeVersion(r.Version)+".")
+	}
+	return false
+}
+
+var versionNumRe = regexp.MustCompile(`\d+(?:\.\d+)*`)
+
+// normalizeVersion extracts the dotted numeric part of strings like
+// "v18.19.0", "go1.24.0" or "Python 3.12.1".
+func normalizeVersion(v string) string {
+	return versionNumRe.FindString(v)
+}
+
+// CompareVersions compares dotted numeric versions, returning -1, 0 or 1.
+// Missing components count as zero, so "18" == "18.0.0".
+func CompareVersions(a, b string) int {
+	pa := strings.Split(normalizeVersion(a), ".")
+	pb := strings.Split(normalizeVersion(b), ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x, _ = strconv.Atoi(pa[i])
+		}
+		if i < len(pb) {
+			y, _ = strconv.Atoi(pb[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
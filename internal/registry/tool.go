@@ -1,6 +1,11 @@
 package registry
 
-import "runtime"
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
 
 // Tool represents an AI CLI tool in the registry.
 type Tool struct {
@@ -13,6 +18,10 @@ type Tool struct {
 	Repo        string   `toml:"repo"`
 	Install     Install  `toml:"install"`
 	Keys        Keys     `toml:"keys"`
+	Invoke      Invoke   `toml:"invoke"`
+	Requires    []string `toml:"requires"`  // e.g. "node>=18", "python>=3.10", "ollama"
+	Conflicts   []string `toml:"conflicts"` // registry tools that cannot be installed alongside
+	PostInstall []Setup  `toml:"post_install"`
 }
 
 // Install defines how to install a tool via different backends.
@@ -23,17 +32,71 @@ type Install struct {
 	Cargo  string `toml:"cargo"`
 	Go     string `toml:"go"`
 	Docker string `toml:"docker"`
-	Binary string `toml:"binary"`
+	Binary Binary `toml:"binary"`
 	Script string `toml:"script"`
 	Linux  string `toml:"linux"`
 	Verify Verify `toml:"verify"`
 }
 
+// Binary describes a tool shipped as prebuilt release binaries, e.g. on
+// GitHub releases. palm downloads the asset for this OS and architecture,
+// checks its SHA256, and installs the executable into BinDir.
+type Binary struct {
+	Version string `toml:"version"`
+	// Name is the executable inside the archive and the name it is
+	// installed as; defaults to the tool's binary.
+	Name   string        `toml:"name"`
+	Assets []BinaryAsset `toml:"assets"`
+}
+
+// BinaryAsset is one platform's release download. URL may contain
+// {version}, {os} and {arch}; archives ending in .tar.gz, .tgz or .zip are
+// unpacked, anything else is taken as the executable itself.
+type BinaryAsset struct {
+	OS     string `toml:"os"`   // GOOS, e.g. "darwin"
+	Arch   string `toml:"arch"` // GOARCH, e.g. "arm64"
+	URL    string `toml:"url"`
+	SHA256 string `toml:"sha256"`
+}
+
+// Asset returns the release asset for this machine, with its URL expanded.
+func (b Binary) Asset() (BinaryAsset, bool) {
+	for _, a := range b.Assets {
+		if a.OS == runtime.GOOS && a.Arch == runtime.GOARCH {
+			a.URL = strings.NewReplacer("{version}", b.Version, "{os}", a.OS, "{arch}", a.Arch).Replace(a.URL)
+			return a, true
+		}
+	}
+	return BinaryAsset{}, false
+}
+
+// BinDir is where palm installs release binaries: ~/.local/bin.
+func BinDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".local", "bin")
+}
+
 // Verify defines how to check if a tool is installed.
 type Verify struct {
 	Command string `toml:"command"`
 }
 
+// Setup is a step that finishes a tool's installation, such as starting a
+// service or adding shell completions. Steps run after an install, with
+// confirmation, and again with `palm setup <tool>`.
+type Setup struct {
+	Description string `toml:"description"`
+	// Run is a shell command.
+	Run string `toml:"run"`
+	// Shell is a line added to the user's shell rc file, e.g. completions
+	// or a PATH export. {shell} is replaced by the shell's name.
+	Shell string `toml:"shell"`
+	// Check is a shell command that succeeds when the step is already done.
+	Check string `toml:"check"`
+	// OS limits the step to these GOOS values; empty means every OS.
+	OS []string `toml:"os"`
+}
+
 // Keys defines API key requirements for a tool.
 type Keys struct {
 	Required  []string `toml:"required"`
@@ -41,6 +104,19 @@ type Keys struct {
 	EnvPrefix string   `toml:"env_prefix"`
 }
 
+// Invoke defines how palm sends a tool a one-shot prompt.
+type Invoke struct {
+	// Prompt is the command split on spaces, e.g. "aider --message {prompt}".
+	// {prompt} is replaced by the prompt as a single argument.
+	Prompt string `toml:"prompt"`
+	// Model is the model the tool uses out of the box, for cost estimates.
+	Model string `toml:"model"`
+	// Attach passes the tool one file by path, e.g. "--read {file}". It is
+	// repeated for each attached file; tools without it get files inlined
+	// into the prompt.
+	Attach string `toml:"attach"`
+}
+
 // Preset defines a curated tool bundle for quick setup.
 type Preset struct {
 	Name        string   `toml:"name"`
@@ -49,6 +125,16 @@ type Preset struct {
 	Tools       []string `toml:"tools"`
 }
 
+// Stack is a curated setup for one way of working: the registry tools to
+// install and the MCP servers to add alongside them.
+type Stack struct {
+	Name        string   `toml:"name" json:"name"`
+	DisplayName string   `toml:"display_name" json:"display_name"`
+	Description string   `toml:"description" json:"description"`
+	Tools       []string `toml:"tools" json:"tools"`
+	MCP         []string `toml:"mcp" json:"mcp,omitempty"`
+}
+
 // InstallMethod returns the preferred install backend and package identifier.
 func (t Tool) InstallMethod() (backend, pkg string) {
 	switch {
@@ -58,8 +144,9 @@ func (t Tool) InstallMethod() (backend, pkg string) {
 		return "brew", t.Install.Brew
 	case t.Install.Script != "":
 		return "script", t.Install.Script
-	case t.Install.Binary != "":
-		return "binary", t.Install.Binary
+	case t.hasBinaryAsset():
+		a, _ := t.Install.Binary.Asset()
+		return "binary", a.URL
 	case t.Install.Pip != "":
 		return "pip", t.Install.Pip
 	case t.Install.Npm != "":
@@ -75,7 +162,82 @@ func (t Tool) InstallMethod() (backend, pkg string) {
 	}
 }
 
+func (t Tool) hasBinaryAsset() bool {
+	_, ok := t.Install.Binary.Asset()
+	return ok
+}
+
+// BinaryName is the executable a binary install puts in BinDir.
+func (t Tool) BinaryName() string {
+	name := t.Install.Binary.Name
+	if name == "" {
+		name = t.Binary()
+	}
+	if runtime.GOOS == "windows" && !strings.HasSuffix(name, ".exe") {
+		name += ".exe"
+	}
+	return name
+}
+
+// SetupSteps returns the post-install steps that apply to this OS.
+func (t Tool) SetupSteps() []Setup {
+	var steps []Setup
+	for _, s := range t.PostInstall {
+		if len(s.OS) == 0 || containsOS(s.OS, runtime.GOOS) {
+			steps = append(steps, s)
+		}
+	}
+	return steps
+}
+
+func containsOS(list []string, goos string) bool {
+	for _, os := range list {
+		if os == goos {
+			return true
+		}
+	}
+	return false
+}
+
 // NeedsAPIKey returns true if the tool requires at least one API key.
 func (t Tool) NeedsAPIKey() bool {
 	return len(t.Keys.Required) > 0
 }
+
+// Binary returns the executable the tool runs as, taken from its verify
+// command. Tools verified through an interpreter run under their own name.
+func (t Tool) Binary() string {
+	fields := strings.Fields(t.Install.Verify.Command)
+	if len(fields) == 0 || fields[0] == "python" || fields[0] == "python3" {
+		return t.Name
+	}
+	return fields[0]
+}
+
+// PromptArgs returns the command that sends prompt to the tool: its invoke
+// template, or else its binary with the prompt as the only argument.
+func (t Tool) PromptArgs(prompt string) []string {
+	if t.Invoke.Prompt == "" {
+		return []string{t.Binary(), prompt}
+	}
+	args := strings.Fields(t.Invoke.Prompt)
+	for i, a := range args {
+		args[i] = strings.ReplaceAll(a, "{prompt}", prompt)
+	}
+	return args
+}
+
+// AttachArgs returns the arguments that pass files to the tool by path, or
+// nil when the tool has no attach template.
+func (t Tool) AttachArgs(files []string) []string {
+	if t.Invoke.Attach == "" {
+		return nil
+	}
+	var args []string
+	for _, f := range files {
+		for _, a := range strings.Fields(t.Invoke.Attach) {
+			args = append(args, strings.ReplaceAll(a, "{file}", f))
+		}
+	}
+	return args
+}
@@ -0,0 +1,277 @@
+// Package runs records a manifest for each eval, speedtest and squad run,
+// so their numbers can be reproduced and compared across machines and time.
+package runs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxOutput is how much of each tool's output a manifest keeps.
+const maxOutput = 4000
+
+// Manifest describes one run: what was asked, of which tools, where, and
+// what came back.
+type Manifest struct {
+	ID      string            `json:"id"`
+	Kind    string            `json:"kind"` // eval, eval-suite, speedtest, benchmark or squad
+	Time    time.Time         `json:"time"`
+	Command []string          `json:"command"` // the palm arguments, for reruns
+	Seed    int64             `json:"seed"`
+	Prompts []string          `json:"prompts"`
+	Flags   map[string]string `json:"flags,omitempty"` // flags set on the command line
+	Tools   []Tool            `json:"tools"`
+	Env     Env               `json:"env"`
+	Results []Result          `json:"results"`
+}
+
+// Tool is a tool taking part in a run, as installed at the time.
+type Tool struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	Model   string `json:"model,omitempty"`
+}
+
+// Env fingerprints the machine a run happened on.
+type Env struct {
+	Palm     string   `json:"palm"`
+	OS       string   `json:"os"`
+	Arch     string   `json:"arch"`
+	CPUs     int      `json:"cpus"`
+	MemoryMB int      `json:"memory_mb,omitempty"`
+	GPUs     []string `json:"gpus,omitempty"`
+	Host     string   `json:"host"` // hashed, so manifests can be shared
+}
+
+// Fingerprint is a short hash of the environment; runs with the same
+// fingerprint ran on comparable machines.
+func (e Env) Fingerprint() string {
+	h := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%s|%d|%d|%s|%s",
+		e.Palm, e.OS, e.Arch, e.CPUs, e.MemoryMB, strings.Join(e.GPUs, ","), e.Host)))
+	return hex.EncodeToString(h[:])[:12]
+}
+
+// HashHost hashes a hostname for Env.Host.
+func HashHost(name string) string {
+	h := sha256.Sum256([]byte("palm-run:" + name))
+	return hex.EncodeToString(h[:])[:12]
+}
+
+// Result is one tool's outcome. Metrics hold the run kind's numbers, e.g.
+// an eval's scores or a speedtest's tokens per second.
+type Result struct {
+	Tool    string             `json:"tool"`
+	Metrics map[string]float64 `json:"metrics"`
+	Error   string             `json:"error,omitempty"`
+	Output  string             `json:"output,omitempty"`
+}
+
+// Dir returns where manifests are kept.
+func Dir() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "palm", "runs")
+}
+
+// Save writes m, giving it an ID from its kind and time when it has none.
+func Save(m *Manifest) error {
+	if m.Time.IsZero() {
+		m.Time = time.Now()
+	}
+	for i := range m.Results {
+		if len(m.Results[i].Output) > maxOutput {
+			m.Results[i].Output = m.Results[i].Output[:maxOutput]
+		}
+	}
+	dir := Dir()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if m.ID == "" {
+		base := m.Time.Format("20060102-150405") + "-" + m.Kind
+		m.ID = base
+		for i := 2; ; i++ {
+			if _, err := os.Stat(filepath.Join(dir, m.ID+".json")); os.IsNotExist(err) {
+				break
+			}
+			m.ID = fmt.Sprintf("%s-%d", base, i)
+		}
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, m.ID+".json"), data, 0o644)
+}
+
+// Load reads the manifest with the given ID, or the only one whose ID
+// starts with it.
+func Load(id string) (*Manifest, error) {
+	if m, err := read(filepath.Join(Dir(), id+".json")); err == nil {
+		return m, nil
+	}
+	all, err := List()
+	if err != nil {
+		return nil, err
+	}
+	var matches []Manifest
+	for _, m := range all {
+		if strings.HasPrefix(m.ID, id) {
+			matches = append(matches, m)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no run %q", id)
+	case 1:
+		return &matches[0], nil
+	}
+	return nil, fmt.Errorf("%q matches %d runs; give more of the ID", id, len(matches))
+}
+
+func read(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return &m, nil
+}
+
+// List returns every recorded run, newest first. Unreadable manifests are
+// skipped.
+func List() ([]Manifest, error) {
+	entries, err := os.ReadDir(Dir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var all []Manifest
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		if m, err := read(filepath.Join(Dir(), e.Name())); err == nil {
+			all = append(all, *m)
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Time.After(all[j].Time) })
+	return all, nil
+}
+
+// Delta is how one metric of one tool moved between two runs. A tool or
+// metric missing from one side has that side's Has flag unset.
+type Delta struct {
+	Tool   string  `json:"tool"`
+	Metric string  `json:"metric"`
+	A      float64 `json:"a"`
+	B      float64 `json:"b"`
+	HasA   bool    `json:"has_a"`
+	HasB   bool    `json:"has_b"`
+}
+
+// Change is B minus A.
+func (d Delta) Change() float64 { return d.B - d.A }
+
+// Compare lines up the results of two runs by tool and metric, in a's tool
+// order, then tools only b has.
+func Compare(a, b *Manifest) []Delta {
+	var tools []string
+	seen := make(map[string]bool)
+	for _, m := range []*Manifest{a, b} {
+		for _, r := range m.Results {
+			if !seen[r.Tool] {
+				seen[r.Tool] = true
+				tools = append(tools, r.Tool)
+			}
+		}
+	}
+
+	var deltas []Delta
+	for _, tool := range tools {
+		ra, rb := a.result(tool), b.result(tool)
+		var names []string
+		for name := range ra.Metrics {
+			names = append(names, name)
+		}
+		for name := range rb.Metrics {
+			if _, ok := ra.Metrics[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			d := Delta{Tool: tool, Metric: name}
+			d.A, d.HasA = ra.Metrics[name]
+			d.B, d.HasB = rb.Metrics[name]
+			deltas = append(deltas, d)
+		}
+	}
+	return deltas
+}
+
+func (m *Manifest) result(tool string) Result {
+	for _, r := range m.Results {
+		if r.Tool == tool {
+			return r
+		}
+	}
+	return Result{Tool: tool}
+}
+
+// Differences lists what differs between the setups of two runs: seed,
+// prompts, tool versions and models, and environment.
+func Differences(a, b *Manifest) []string {
+	var diffs []string
+	if a.Kind != b.Kind {
+		diffs = append(diffs, fmt.Sprintf("kind: %s → %s", a.Kind, b.Kind))
+	}
+	if a.Seed != b.Seed {
+		diffs = append(diffs, fmt.Sprintf("seed: %d → %d", a.Seed, b.Seed))
+	}
+	if strings.Join(a.Prompts, "\x00") != strings.Join(b.Prompts, "\x00") {
+		diffs = append(diffs, "prompts differ")
+	}
+	toolsA := make(map[string]Tool)
+	for _, t := range a.Tools {
+		toolsA[t.Name] = t
+	}
+	for _, t := range b.Tools {
+		old, ok := toolsA[t.Name]
+		if !ok {
+			continue
+		}
+		if old.Version != t.Version {
+			diffs = append(diffs, fmt.Sprintf("%s version: %s → %s", t.Name, orNone(old.Version), orNone(t.Version)))
+		}
+		if old.Model != t.Model {
+			diffs = append(diffs, fmt.Sprintf("%s model: %s → %s", t.Name, orNone(old.Model), orNone(t.Model)))
+		}
+	}
+	if a.Env.Fingerprint() != b.Env.Fingerprint() {
+		diffs = append(diffs, fmt.Sprintf("environment: %s → %s", a.Env.Fingerprint(), b.Env.Fingerprint()))
+	}
+	return diffs
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
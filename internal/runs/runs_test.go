@@ -0,0 +1,86 @@
+package runs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSaveLoadList(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	at := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	first := &Manifest{Kind: "eval", Time: at, Seed: 7, Results: []Result{{Tool: "ollama", Output: strings.Repeat("x", maxOutput+10)}}}
+	second := &Manifest{Kind: "eval", Time: at}
+	later := &Manifest{Kind: "speedtest", Time: at.Add(time.Hour)}
+	for _, m := range []*Manifest{first, second, later} {
+		if err := Save(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if first.ID != "20260301-093000-eval" || second.ID != "20260301-093000-eval-2" {
+		t.Errorf("IDs = %s, %s", first.ID, second.ID)
+	}
+
+	m, err := Load(first.ID)
+	if err != nil || m.Seed != 7 || len(m.Results[0].Output) != maxOutput {
+		t.Fatalf("Load = %+v, %v", m, err)
+	}
+	if m, err := Load("20260301-1030"); err != nil || m.Kind != "speedtest" {
+		t.Errorf("a unique prefix should load, got %v, %v", m, err)
+	}
+	if _, err := Load("20260301-0930"); err == nil {
+		t.Error("an ambiguous prefix should be an error")
+	}
+	if _, err := Load("nope"); err == nil {
+		t.Error("an unknown ID should be an error")
+	}
+
+	os.WriteFile(filepath.Join(Dir(), "broken.json"), []byte("{"), 0o644)
+	all, err := List()
+	if err != nil || len(all) != 3 || all[0].Kind != "speedtest" {
+		t.Errorf("List should skip broken manifests, newest first: %d runs, %v", len(all), err)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	a := &Manifest{
+		Kind: "speedtest", Seed: 1, Prompts: []string{"hi"},
+		Tools:   []Tool{{Name: "ollama", Version: "0.5.1", Model: "llama3.3"}},
+		Env:     Env{OS: "linux", CPUs: 8},
+		Results: []Result{{Tool: "ollama", Metrics: map[string]float64{"tps": 40, "seconds": 2}}},
+	}
+	b := &Manifest{
+		Kind: "speedtest", Seed: 1, Prompts: []string{"hi"},
+		Tools: []Tool{{Name: "ollama", Version: "0.6.0", Model: "llama3.3"}},
+		Env:   Env{OS: "linux", CPUs: 16},
+		Results: []Result{
+			{Tool: "ollama", Metrics: map[string]float64{"tps": 55}},
+			{Tool: "mods", Metrics: map[string]float64{"tps": 80}},
+		},
+	}
+
+	deltas := Compare(a, b)
+	if len(deltas) != 3 {
+		t.Fatalf("deltas = %+v", deltas)
+	}
+	if d := deltas[1]; d.Metric != "tps" || d.Change() != 15 || !d.HasA || !d.HasB {
+		t.Errorf("unexpected tps delta %+v", d)
+	}
+	if d := deltas[0]; d.Metric != "seconds" || d.HasB {
+		t.Errorf("a metric missing from b should say so: %+v", d)
+	}
+	if d := deltas[2]; d.Tool != "mods" || d.HasA {
+		t.Errorf("a tool only b ran should come last: %+v", d)
+	}
+
+	diffs := Differences(a, b)
+	if len(diffs) != 2 || diffs[0] != "ollama version: 0.5.1 → 0.6.0" || !strings.HasPrefix(diffs[1], "environment: ") {
+		t.Errorf("differences = %q", diffs)
+	}
+	if diffs := Differences(a, a); len(diffs) != 0 {
+		t.Errorf("a run should not differ from itself: %q", diffs)
+	}
+}
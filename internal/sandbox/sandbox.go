@@ -0,0 +1,151 @@
+// Package sandbox confines an AI tool's writes to the project it runs in.
+// It uses bubblewrap on Linux and sandbox-exec on macOS, and falls back to
+// auditing what changed outside the project once the tool exits.
+package sandbox
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Mode is how a tool is confined.
+type Mode string
+
+const (
+	Bubblewrap  Mode = "bwrap"
+	SandboxExec Mode = "sandbox-exec"
+	AuditOnly   Mode = "audit" // nothing is blocked; changes are reported afterwards
+)
+
+// Detect picks the strongest mode available on this machine.
+func Detect() Mode {
+	switch runtime.GOOS {
+	case "linux":
+		if _, err := exec.LookPath("bwrap"); err == nil {
+			return Bubblewrap
+		}
+	case "darwin":
+		if _, err := exec.LookPath("sandbox-exec"); err == nil {
+			return SandboxExec
+		}
+	}
+	return AuditOnly
+}
+
+// Writable lists the directories a confined tool may write to besides the
+// project: temp space and the caller's extras. Paths that don't exist are
+// dropped, since neither sandbox can bind them.
+func Writable(extra []string) []string {
+	dirs := []string{os.TempDir()}
+	dirs = append(dirs, extra...)
+	var out []string
+	seen := make(map[string]bool)
+	for _, d := range dirs {
+		abs, err := filepath.Abs(d)
+		if err != nil || seen[abs] {
+			continue
+		}
+		if _, err := os.Stat(abs); err != nil {
+			continue
+		}
+		seen[abs] = true
+		out = append(out, abs)
+	}
+	return out
+}
+
+// Command returns the argv that runs bin with args under mode, allowed to
+// write only inside project and writable.
+func Command(mode Mode, bin string, args []string, project string, writable []string) []string {
+	switch mode {
+	case Bubblewrap:
+		argv := []string{"bwrap", "--ro-bind", "/", "/", "--dev", "/dev", "--proc", "/proc"}
+		for _, dir := range append([]string{project}, writable...) {
+			argv = append(argv, "--bind", dir, dir)
+		}
+		argv = append(argv, "--chdir", mustGetwd(project), "--die-with-parent", "--", bin)
+		return append(argv, args...)
+	case SandboxExec:
+		argv := []string{"sandbox-exec", "-p", Profile(project, writable), bin}
+		return append(argv, args...)
+	}
+	return append([]string{bin}, args...)
+}
+
+// Profile is the sandbox-exec policy: everything is allowed except writing
+// files outside project, writable and /dev.
+func Profile(project string, writable []string) string {
+	var b strings.Builder
+	b.WriteString("(version 1)\n(allow default)\n(deny file-write*)\n(allow file-write*\n")
+	for _, dir := range append([]string{project}, writable...) {
+		// /tmp and /var are symlinks into /private on macOS
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			dir = resolved
+		}
+		fmt.Fprintf(&b, "  (subpath %q)\n", dir)
+	}
+	b.WriteString("  (subpath \"/dev\"))\n")
+	return b.String()
+}
+
+func mustGetwd(fallback string) string {
+	if wd, err := os.Getwd(); err == nil {
+		return wd
+	}
+	return fallback
+}
+
+// skipDirs are caches and package stores that churn on their own and would
+// bury real changes in an audit.
+var skipDirs = map[string]bool{
+	".cache": true, ".npm": true, ".cargo": true, ".rustup": true, "node_modules": true,
+	".git": true, "Caches": true, ".Trash": true, "go": true, ".local/share/Trash": true,
+}
+
+// maxAuditEntries bounds how much of a home directory an audit walks.
+const maxAuditEntries = 200000
+
+// ModifiedSince lists files under root changed at or after since, skipping
+// the excluded directories, caches and anything deeper than maxDepth.
+// The result is sorted and capped at limit entries.
+func ModifiedSince(root string, since time.Time, exclude []string, maxDepth, limit int) []string {
+	excluded := make(map[string]bool, len(exclude))
+	for _, e := range exclude {
+		excluded[filepath.Clean(e)] = true
+	}
+	var changed []string
+	visited := 0
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		visited++
+		if err != nil || visited > maxAuditEntries {
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			rel, _ := filepath.Rel(root, path)
+			depth := strings.Count(rel, string(filepath.Separator)) + 1
+			if path != root && (excluded[path] || skipDirs[d.Name()] || skipDirs[rel] || depth > maxDepth) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if info, err := d.Info(); err == nil && !info.ModTime().Before(since) {
+			changed = append(changed, path)
+		}
+		return nil
+	})
+	sort.Strings(changed)
+	if limit > 0 && len(changed) > limit {
+		changed = changed[:limit]
+	}
+	return changed
+}
@@ -0,0 +1,63 @@
+package sandbox
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommandBubblewrap(t *testing.T) {
+	argv := Command(Bubblewrap, "/usr/bin/aider", []string{"--yes"}, "/work/repo", []string{"/tmp"})
+	got := strings.Join(argv, " ")
+	for _, want := range []string{"--ro-bind / /", "--bind /work/repo /work/repo", "--bind /tmp /tmp", "-- /usr/bin/aider --yes"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("bwrap argv %q missing %q", got, want)
+		}
+	}
+}
+
+func TestCommandAuditOnly(t *testing.T) {
+	argv := Command(AuditOnly, "aider", []string{"a", "b"}, "/work", nil)
+	if strings.Join(argv, " ") != "aider a b" {
+		t.Errorf("audit mode should run the tool directly, got %v", argv)
+	}
+}
+
+func TestProfile(t *testing.T) {
+	p := Profile("/work/repo", []string{"/opt/cache"})
+	for _, want := range []string{"(deny file-write*)", `(subpath "/work/repo")`, `(subpath "/opt/cache")`} {
+		if !strings.Contains(p, want) {
+			t.Errorf("profile missing %q:\n%s", want, p)
+		}
+	}
+}
+
+func TestModifiedSince(t *testing.T) {
+	root := t.TempDir()
+	old := time.Now().Add(-time.Hour)
+	write := func(rel string, mtime time.Time) {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	since := time.Now().Add(-time.Minute)
+	write("untouched.txt", old)
+	write(".bashrc", time.Now())
+	write("project/main.go", time.Now())
+	write(".cache/blob", time.Now())
+	write("a/b/c/deep.txt", time.Now())
+
+	got := ModifiedSince(root, since, []string{filepath.Join(root, "project")}, 2, 0)
+	if len(got) != 1 || filepath.Base(got[0]) != ".bashrc" {
+		t.Errorf("ModifiedSince = %v, want only .bashrc", got)
+	}
+}
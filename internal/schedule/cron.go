@@ -0,0 +1,188 @@
+// Package schedule keeps recurring compose workflows: their cron
+// expressions, the schedule list and the artifacts of each run.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a parsed five-field cron expression:
+// minute hour day-of-month month day-of-week.
+type Cron struct {
+	expr   string
+	minute [60]bool
+	hour   [24]bool
+	dom    [32]bool
+	month  [13]bool
+	dow    [7]bool
+
+	// As in cron, when both day fields are restricted a day matching
+	// either one is enough.
+	domAny, dowAny bool
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	dayNames   = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// ParseCron parses a cron expression such as "0 2 * * *", "*/15 9-17 * * mon-fri"
+// or "@daily".
+func ParseCron(expr string) (*Cron, error) {
+	expr = strings.TrimSpace(expr)
+	spec := expr
+	if m, ok := cronMacros[strings.ToLower(expr)]; ok {
+		spec = m
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q needs 5 fields (minute hour day month weekday)", expr)
+	}
+
+	c := &Cron{expr: expr}
+	var err error
+	if err = parseField(fields[0], 0, 59, nil, c.minute[:]); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if err = parseField(fields[1], 0, 23, nil, c.hour[:]); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if err = parseField(fields[2], 1, 31, nil, c.dom[:]); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if err = parseField(fields[3], 1, 12, monthNames, c.month[:]); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	// Weekdays run 0-7 so that both 0 and 7 mean Sunday
+	var dow [8]bool
+	if err = parseField(fields[4], 0, 7, dayNames, dow[:]); err != nil {
+		return nil, fmt.Errorf("weekday: %w", err)
+	}
+	copy(c.dow[:], dow[:7])
+	c.dow[0] = c.dow[0] || dow[7]
+
+	c.domAny = strings.HasPrefix(fields[2], "*")
+	c.dowAny = strings.HasPrefix(fields[4], "*")
+	return c, nil
+}
+
+// parseField sets set[v] for every value the field matches. A field is a
+// comma-separated list of *, N, A-B, each optionally followed by /step.
+func parseField(field string, min, max int, names []string, set []bool) error {
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid step in %q", part)
+			}
+			rng, step = part[:i], n
+		}
+
+		lo, hi := min, max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			a, b, _ := strings.Cut(rng, "-")
+			var err error
+			if lo, err = fieldValue(a, min, max, names); err != nil {
+				return err
+			}
+			if hi, err = fieldValue(b, min, max, names); err != nil {
+				return err
+			}
+			if lo > hi {
+				return fmt.Errorf("range %q runs backwards", rng)
+			}
+		default:
+			v, err := fieldValue(rng, min, max, names)
+			if err != nil {
+				return err
+			}
+			lo = v
+			if step == 1 {
+				hi = v
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return nil
+}
+
+func fieldValue(s string, min, max int, names []string) (int, error) {
+	for i, name := range names {
+		if strings.EqualFold(s, name) {
+			// Month names start at 1, weekday names at 0
+			return i + min, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if v < min || v > max {
+		return 0, fmt.Errorf("%d is out of range %d-%d", v, min, max)
+	}
+	return v, nil
+}
+
+// String returns the expression as it was written.
+func (c *Cron) String() string { return c.expr }
+
+// Matches reports whether the expression fires in the minute holding t.
+func (c *Cron) Matches(t time.Time) bool {
+	return c.minute[t.Minute()] && c.hour[t.Hour()] && c.month[t.Month()] && c.dayMatches(t)
+}
+
+func (c *Cron) dayMatches(t time.Time) bool {
+	dom, dow := c.dom[t.Day()], c.dow[t.Weekday()]
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// Next returns the first minute after t the expression fires in, or the
+// zero time when it never does (e.g. "0 0 30 2 *").
+func (c *Cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		y, m, d := t.Date()
+		switch {
+		case !c.month[m]:
+			t = time.Date(y, m+1, 1, 0, 0, 0, 0, loc)
+		case !c.dayMatches(t):
+			t = time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+		case !c.hour[t.Hour()]:
+			t = time.Date(y, m, d, t.Hour()+1, 0, 0, 0, loc)
+		case !c.minute[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,47 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "x * * * *"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) should fail", expr)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	from := time.Date(2026, 10, 15, 14, 37, 20, 0, time.UTC) // a Thursday
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"0 2 * * *", time.Date(2026, 10, 16, 2, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 10, 15, 14, 45, 0, 0, time.UTC)},
+		{"30 9-17 * * mon-fri", time.Date(2026, 10, 15, 15, 30, 0, 0, time.UTC)},
+		{"0 9 * * sat,sun", time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either one matches
+		{"0 0 1 * fri", time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 feb *", time.Time{}},
+	}
+	for _, tt := range tests {
+		c, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Errorf("ParseCron(%q): %v", tt.expr, err)
+			continue
+		}
+		if got := c.Next(from); !got.Equal(tt.want) {
+			t.Errorf("%q: Next = %v, want %v", tt.expr, got, tt.want)
+		}
+		if !tt.want.IsZero() && !c.Matches(tt.want) {
+			t.Errorf("%q doesn't match its own next run %v", tt.expr, tt.want)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package schedule
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/msalah0e/palm/internal/config"
+	"github.com/msalah0e/palm/internal/notify"
+)
+
+// KeepRuns is how many runs' artifacts are kept per schedule.
+const KeepRuns = 30
+
+// Schedule runs a compose workflow whenever its cron expression fires.
+type Schedule struct {
+	Name    string    `toml:"name" json:"name"`
+	Cron    string    `toml:"cron" json:"cron"`
+	File    string    `toml:"file" json:"file"` // absolute path to the workflow
+	Dir     string    `toml:"dir" json:"dir"`   // working directory for its steps
+	Yes     bool      `toml:"yes,omitempty" json:"yes,omitempty"`
+	Notify  bool      `toml:"notify,omitempty" json:"notify,omitempty"`
+	Webhook string    `toml:"webhook,omitempty" json:"webhook,omitempty"`
+	Command string    `toml:"command,omitempty" json:"command,omitempty"`
+	Created time.Time `toml:"created" json:"created"`
+}
+
+// Alerts returns a short summary of what happens when a run fails.
+func (s Schedule) Alerts() string {
+	var parts []string
+	if s.Notify {
+		parts = append(parts, "desktop")
+	}
+	if s.Webhook != "" {
+		parts = append(parts, "webhook")
+	}
+	if s.Command != "" {
+		parts = append(parts, "command")
+	}
+	return strings.Join(parts, ", ")
+}
+
+type scheduleFile struct {
+	Schedules []Schedule `toml:"schedules"`
+}
+
+// Path returns the file holding the schedules.
+func Path() string {
+	return filepath.Join(config.ConfigDir(), "compose-schedules.toml")
+}
+
+// Load returns every schedule, sorted by name.
+func Load() ([]Schedule, error) {
+	var f scheduleFile
+	if _, err := toml.DecodeFile(Path(), &f); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	sort.Slice(f.Schedules, func(i, j int) bool { return f.Schedules[i].Name < f.Schedules[j].Name })
+	return f.Schedules, nil
+}
+
+func save(schedules []Schedule) error {
+	path := Path()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	return toml.NewEncoder(out).Encode(scheduleFile{Schedules: schedules})
+}
+
+// Get returns the schedule called name.
+func Get(name string) (Schedule, bool) {
+	schedules, _ := Load()
+	for _, s := range schedules {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Schedule{}, false
+}
+
+// validName keeps schedule names usable as directory names.
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Add saves s, replacing any schedule with the same name. It reports
+// whether one was replaced.
+func Add(s Schedule) (bool, error) {
+	if !validName.MatchString(s.Name) {
+		return false, fmt.Errorf("invalid schedule name %q (use letters, digits, '.', '-' and '_')", s.Name)
+	}
+	if _, err := ParseCron(s.Cron); err != nil {
+		return false, err
+	}
+	schedules, err := Load()
+	if err != nil {
+		return false, err
+	}
+	for i := range schedules {
+		if schedules[i].Name == s.Name {
+			schedules[i] = s
+			return true, save(schedules)
+		}
+	}
+	return false, save(append(schedules, s))
+}
+
+// Remove deletes the schedule called name. Its run artifacts are kept.
+func Remove(name string) error {
+	schedules, err := Load()
+	if err != nil {
+		return err
+	}
+	for i := range schedules {
+		if schedules[i].Name == name {
+			return save(append(schedules[:i], schedules[i+1:]...))
+		}
+	}
+	return fmt.Errorf("no schedule named %q", name)
+}
+
+// Step is the outcome of one workflow step in a run.
+type Step struct {
+	Name     string  `json:"name"`
+	Duration float64 `json:"duration"` // seconds
+	ExitCode int     `json:"exit_code"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// Run records one scheduled run of a workflow. Its artifacts (run.json,
+// the console output and each step's output) live in Dir.
+type Run struct {
+	Schedule string    `json:"schedule"`
+	Workflow string    `json:"workflow"`
+	Started  time.Time `json:"started"`
+	Duration float64   `json:"duration"` // seconds
+	OK       bool      `json:"ok"`
+	ExitCode int       `json:"exit_code"`
+	Error    string    `json:"error,omitempty"`
+	Steps    []Step    `json:"steps,omitempty"`
+	Dir      string    `json:"dir"`
+}
+
+// Failed returns the names of the steps that failed.
+func (r Run) Failed() []string {
+	var names []string
+	for _, s := range r.Steps {
+		if s.Error != "" {
+			names = append(names, s.Name)
+		}
+	}
+	return names
+}
+
+// runTimeFormat names run directories so they sort by start time.
+const runTimeFormat = "20060102-150405"
+
+// RunsDir returns the directory holding a schedule's runs.
+func RunsDir(name string) string {
+	return filepath.Join(config.ConfigDir(), "compose-runs", name)
+}
+
+// NewRunDir creates the artifact directory for a run starting at t.
+func NewRunDir(name string, t time.Time) (string, error) {
+	base := filepath.Join(RunsDir(name), t.Format(runTimeFormat))
+	dir := base
+	for i := 2; ; i++ {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			break
+		}
+		dir = fmt.Sprintf("%s-%d", base, i)
+	}
+	return dir, os.MkdirAll(dir, 0o700)
+}
+
+// SaveRun writes r to run.json in its directory and drops the oldest runs
+// beyond KeepRuns.
+func SaveRun(r Run) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(r.Dir, "run.json"), data, 0o600); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(RunsDir(r.Schedule))
+	if err != nil {
+		return nil
+	}
+	for i := 0; i < len(entries)-KeepRuns; i++ {
+		_ = os.RemoveAll(filepath.Join(RunsDir(r.Schedule), entries[i].Name()))
+	}
+	return nil
+}
+
+// Runs returns a schedule's recorded runs, newest first, or every
+// schedule's when name is empty.
+func Runs(name string) ([]Run, error) {
+	names := []string{name}
+	if name == "" {
+		entries, err := os.ReadDir(filepath.Join(config.ConfigDir(), "compose-runs"))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		names = names[:0]
+		for _, e := range entries {
+			if e.IsDir() {
+				names = append(names, e.Name())
+			}
+		}
+	}
+
+	var runs []Run
+	for _, n := range names {
+		entries, _ := os.ReadDir(RunsDir(n))
+		for _, e := range entries {
+			data, err := os.ReadFile(filepath.Join(RunsDir(n), e.Name(), "run.json"))
+			if err != nil {
+				continue
+			}
+			var r Run
+			if json.Unmarshal(data, &r) == nil {
+				runs = append(runs, r)
+			}
+		}
+	}
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].Started.After(runs[j].Started) })
+	return runs, nil
+}
+
+// LastRun returns a schedule's most recent run.
+func LastRun(name string) (Run, bool) {
+	runs, err := Runs(name)
+	if err != nil || len(runs) == 0 {
+		return Run{}, false
+	}
+	return runs[0], true
+}
+
+// failureEvent is the webhook payload for a failed run.
+type failureEvent struct {
+	Run
+	Text string `json:"text"` // Slack incoming webhooks display this field
+}
+
+// NotifyFailure fires the failure alerts configured on s for run r.
+func NotifyFailure(s Schedule, r Run) error {
+	text := fmt.Sprintf("palm: scheduled workflow %s failed", s.Name)
+	if failed := r.Failed(); len(failed) > 0 {
+		text += " at " + strings.Join(failed, ", ")
+	} else if r.Error != "" {
+		text += ": " + r.Error
+	}
+
+	var errs []string
+	if s.Notify {
+		if err := notify.Desktop("palm compose", text); err != nil {
+			errs = append(errs, "notify: "+err.Error())
+		}
+	}
+	if s.Webhook != "" {
+		if err := notify.Webhook(s.Webhook, failureEvent{Run: r, Text: text}); err != nil {
+			errs = append(errs, "webhook: "+err.Error())
+		}
+	}
+	if s.Command != "" {
+		if err := runFailureCommand(s.Command, r, text); err != nil {
+			errs = append(errs, "command: "+err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func runFailureCommand(script string, r Run, text string) error {
+	cmd := exec.Command("sh", "-c", script)
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", script)
+	}
+	cmd.Env = append(os.Environ(),
+		"PALM_SCHEDULE="+r.Schedule,
+		"PALM_SCHEDULE_WORKFLOW="+r.Workflow,
+		"PALM_SCHEDULE_FAILED="+strings.Join(r.Failed(), ","),
+		"PALM_SCHEDULE_ARTIFACTS="+r.Dir,
+		"PALM_SCHEDULE_MESSAGE="+text,
+	)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// PidFile returns the path to the scheduler daemon's PID file.
+func PidFile() string {
+	return filepath.Join(config.ConfigDir(), "compose-scheduler.pid")
+}
+
+// LogPath returns where the scheduler daemon writes its output.
+func LogPath() string {
+	return filepath.Join(config.ConfigDir(), "compose-scheduler.log")
+}
+
+// IsRunning reports whether the scheduler daemon is running, and its PID.
+func IsRunning() (bool, int) {
+	data, err := os.ReadFile(PidFile())
+	if err != nil {
+		return false, 0
+	}
+	var pid int
+	if _, err := fmt.Sscanf(string(data), "%d", &pid); err != nil {
+		return false, 0
+	}
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false, 0
+	}
+	// Signal 0 checks the process exists without disturbing it
+	if err := proc.Signal(syscall.Signal(0)); err == nil {
+		return true, pid
+	}
+	_ = os.Remove(PidFile())
+	return false, 0
+}
@@ -0,0 +1,86 @@
+package schedule
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestAddReplaceRemove(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	if _, err := Add(Schedule{Name: "../escape", Cron: "@daily"}); err == nil {
+		t.Error("a name with a path separator should be rejected")
+	}
+	if _, err := Add(Schedule{Name: "nightly", Cron: "0 2 * *"}); err == nil {
+		t.Error("an invalid cron expression should be rejected")
+	}
+
+	if replaced, err := Add(Schedule{Name: "nightly", Cron: "0 2 * * *", File: "/w/review.toml"}); err != nil || replaced {
+		t.Fatalf("Add = %v, %v", replaced, err)
+	}
+	if _, err := Add(Schedule{Name: "hourly", Cron: "@hourly"}); err != nil {
+		t.Fatal(err)
+	}
+	if replaced, err := Add(Schedule{Name: "nightly", Cron: "0 3 * * *", Notify: true}); err != nil || !replaced {
+		t.Fatalf("re-adding should replace: %v, %v", replaced, err)
+	}
+
+	schedules, _ := Load()
+	if len(schedules) != 2 || schedules[0].Name != "hourly" || schedules[1].Cron != "0 3 * * *" {
+		t.Fatalf("schedules = %+v", schedules)
+	}
+	if err := Remove("nightly"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Get("nightly"); ok {
+		t.Error("removed schedule still there")
+	}
+	if err := Remove("nightly"); err == nil {
+		t.Error("removing a missing schedule should fail")
+	}
+}
+
+func TestRunsRetention(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	start := time.Date(2026, 10, 1, 2, 0, 0, 0, time.UTC)
+	for i := 0; i < KeepRuns+2; i++ {
+		at := start.AddDate(0, 0, i)
+		dir, err := NewRunDir("nightly", at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		run := Run{Schedule: "nightly", Started: at, OK: i%2 == 0, Dir: dir}
+		if !run.OK {
+			run.Steps = []Step{{Name: "review"}, {Name: "test", Error: "exit status 1"}}
+		}
+		if err := SaveRun(run); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runs, err := Runs("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != KeepRuns {
+		t.Fatalf("kept %d runs, want %d", len(runs), KeepRuns)
+	}
+	if !runs[0].Started.Equal(start.AddDate(0, 0, KeepRuns+1)) {
+		t.Errorf("newest run = %v", runs[0].Started)
+	}
+	if last, _ := LastRun("nightly"); last.OK || len(last.Failed()) != 1 || last.Failed()[0] != "test" {
+		t.Errorf("last run = %+v", last)
+	}
+
+	// Two runs in the same second get separate directories
+	a, _ := NewRunDir("hourly", start)
+	b, _ := NewRunDir("hourly", start)
+	if a == b {
+		t.Error("run directories collide")
+	}
+	if _, err := os.Stat(b); err != nil {
+		t.Error(err)
+	}
+}
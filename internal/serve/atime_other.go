@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package serve
+
+import (
+	"os"
+	"time"
+)
+
+// accessTime returns the file's mtime; access times aren't read here.
+func accessTime(info os.FileInfo) time.Time {
+	return info.ModTime()
+}
@@ -0,0 +1,405 @@
+package serve
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LocalModel is a model downloaded to disk by one of the runtimes.
+type LocalModel struct {
+	Runtime  string // ollama, llama-cpp, vllm
+	Name     string
+	Path     string // ollama manifest, gguf file or Hugging Face cache dir
+	Size     int64
+	LastUsed time.Time
+
+	files map[string]int64 // everything the model occupies, with sizes
+}
+
+// OllamaModelsDir returns where ollama keeps manifests and blobs.
+func OllamaModelsDir() string {
+	if dir := os.Getenv("OLLAMA_MODELS"); dir != "" {
+		return dir
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".ollama", "models")
+}
+
+// LlamaCacheDir returns where llama.cpp stores models fetched with -hf.
+func LlamaCacheDir() string {
+	if dir := os.Getenv("LLAMA_CACHE"); dir != "" {
+		return dir
+	}
+	if runtime.GOOS == "darwin" {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, "Library", "Caches", "llama.cpp")
+	}
+	dir, _ := os.UserCacheDir()
+	return filepath.Join(dir, "llama.cpp")
+}
+
+// HFHubDir returns the Hugging Face cache vLLM downloads models into.
+func HFHubDir() string {
+	if dir := os.Getenv("HF_HUB_CACHE"); dir != "" {
+		return dir
+	}
+	if home := os.Getenv("HF_HOME"); home != "" {
+		return filepath.Join(home, "hub")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".cache", "huggingface", "hub")
+}
+
+// ScanModels lists the models downloaded by ollama, llama.cpp and vLLM,
+// plus .gguf files under ggufDirs and next to models palm has served.
+// Missing directories are skipped.
+func ScanModels(ggufDirs []string) ([]LocalModel, error) {
+	usage := loadUsage()
+	var all []LocalModel
+
+	ollama, err := scanOllama(OllamaModelsDir())
+	if err != nil {
+		return nil, err
+	}
+	all = append(all, ollama...)
+
+	dirs := append([]string{LlamaCacheDir()}, ggufDirs...)
+	for key := range usage {
+		if path, ok := strings.CutPrefix(key, "llama-cpp/"); ok && filepath.IsAbs(path) {
+			dirs = append(dirs, filepath.Dir(path))
+		}
+	}
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		found, err := scanGGUF(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range found {
+			if !seen[m.Path] {
+				seen[m.Path] = true
+				all = append(all, m)
+			}
+		}
+	}
+
+	hf, err := scanHFHub(HFHubDir())
+	if err != nil {
+		return nil, err
+	}
+	all = append(all, hf...)
+
+	for i := range all {
+		if t := usage.lastUse(all[i]); t.After(all[i].LastUsed) {
+			all[i].LastUsed = t
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Size > all[j].Size })
+	return all, nil
+}
+
+// scanOllama reads manifests/<registry>/<namespace>/<model>/<tag>; layers
+// live in blobs/ and may be shared between models.
+func scanOllama(dir string) ([]LocalModel, error) {
+	root := filepath.Join(dir, "manifests")
+	var out []LocalModel
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if len(parts) != 4 {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var manifest struct {
+			Config struct {
+				Digest string `json:"digest"`
+			} `json:"config"`
+			Layers []struct {
+				Digest string `json:"digest"`
+			} `json:"layers"`
+		}
+		if json.Unmarshal(data, &manifest) != nil {
+			return nil
+		}
+
+		m := LocalModel{Runtime: "ollama", Name: ollamaName(parts), Path: path, files: map[string]int64{}}
+		if info, err := d.Info(); err == nil {
+			m.add(path, info)
+			// Reading the manifest just now touched its access time
+			m.LastUsed = info.ModTime()
+		}
+		digests := []string{manifest.Config.Digest}
+		for _, l := range manifest.Layers {
+			digests = append(digests, l.Digest)
+		}
+		for _, digest := range digests {
+			if digest == "" {
+				continue
+			}
+			blob := filepath.Join(dir, "blobs", strings.Replace(digest, ":", "-", 1))
+			if info, err := os.Stat(blob); err == nil {
+				m.add(blob, info)
+			}
+		}
+		out = append(out, m)
+		return nil
+	})
+	return out, err
+}
+
+// ollamaName turns manifest path parts back into the name ollama shows,
+// e.g. "llama3.3:latest" or "hf.co/org/model:Q4_K_M".
+func ollamaName(parts []string) string {
+	host, namespace, model, tag := parts[0], parts[1], parts[2], parts[3]
+	switch {
+	case host == "registry.ollama.ai" && namespace == "library":
+		return model + ":" + tag
+	case host == "registry.ollama.ai":
+		return namespace + "/" + model + ":" + tag
+	}
+	return host + "/" + namespace + "/" + model + ":" + tag
+}
+
+// scanGGUF lists the .gguf files under dir.
+func scanGGUF(dir string) ([]LocalModel, error) {
+	var out []LocalModel
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		switch {
+		case err != nil && path == dir && os.IsNotExist(err):
+			return fs.SkipAll
+		case err != nil && path == dir:
+			return err
+		case err != nil:
+			// Unreadable subdirectories are not ours to report
+			return nil
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".gguf") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		abs, _ := filepath.Abs(path)
+		m := LocalModel{Runtime: "llama-cpp", Name: filepath.Base(path), Path: abs, files: map[string]int64{}}
+		m.add(abs, info)
+		out = append(out, m)
+		return nil
+	})
+	return out, err
+}
+
+// scanHFHub lists the models--<org>--<name> directories of a Hugging Face
+// cache.
+func scanHFHub(dir string) ([]LocalModel, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []LocalModel
+	for _, e := range entries {
+		rest, ok := strings.CutPrefix(e.Name(), "models--")
+		if !ok || !e.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		m := LocalModel{Runtime: "vllm", Name: strings.ReplaceAll(rest, "--", "/"), Path: path, files: map[string]int64{}}
+		err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			// Snapshots are symlinks into blobs/; count the blobs once
+			if d.Type().IsRegular() {
+				info, err := d.Info()
+				if err != nil {
+					return err
+				}
+				m.add(p, info)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+func (m *LocalModel) add(path string, info os.FileInfo) {
+	if info == nil {
+		return
+	}
+	m.files[path] = info.Size()
+	m.Size += info.Size()
+	if t := accessTime(info); t.After(m.LastUsed) {
+		m.LastUsed = t
+	}
+}
+
+// TotalSize sums the disk space of models, counting shared files once.
+func TotalSize(models []LocalModel) int64 {
+	seen := make(map[string]bool)
+	var total int64
+	for _, m := range models {
+		for path, size := range m.files {
+			if !seen[path] {
+				seen[path] = true
+				total += size
+			}
+		}
+	}
+	return total
+}
+
+// Reclaimable returns how much removing drop frees, given every model on
+// disk: ollama blobs still used by a kept model stay.
+func Reclaimable(drop, all []LocalModel) int64 {
+	kept := keptFiles(drop, all)
+	seen := make(map[string]bool)
+	var total int64
+	for _, m := range drop {
+		for path, size := range m.files {
+			if !kept[path] && !seen[path] {
+				seen[path] = true
+				total += size
+			}
+		}
+	}
+	return total
+}
+
+func keptFiles(drop, all []LocalModel) map[string]bool {
+	dropped := make(map[string]bool, len(drop))
+	for _, m := range drop {
+		dropped[m.Path] = true
+	}
+	kept := make(map[string]bool)
+	for _, m := range all {
+		if !dropped[m.Path] {
+			for path := range m.files {
+				kept[path] = true
+			}
+		}
+	}
+	return kept
+}
+
+// RemoveModels deletes drop from disk, keeping files other models in all
+// still use.
+func RemoveModels(drop, all []LocalModel) error {
+	kept := keptFiles(drop, all)
+	for _, m := range drop {
+		if m.Runtime == "vllm" {
+			if err := os.RemoveAll(m.Path); err != nil {
+				return fmt.Errorf("%s: %w", m.Name, err)
+			}
+			continue
+		}
+		for path := range m.files {
+			if kept[path] {
+				continue
+			}
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("%s: %w", m.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// usageRecord holds when palm last started each model, keyed
+// "runtime/model".
+type usageRecord map[string]time.Time
+
+func usagePath() string {
+	return filepath.Join(Dir(), "usage.json")
+}
+
+func loadUsage() usageRecord {
+	u := usageRecord{}
+	if data, err := os.ReadFile(usagePath()); err == nil {
+		_ = json.Unmarshal(data, &u)
+	}
+	return u
+}
+
+// RecordUse notes that palm served model with runtime now, so prune can
+// tell it is in use even where file access times aren't kept.
+func RecordUse(runtime, model string) error {
+	u := loadUsage()
+	u[usageKey(runtime, model)] = time.Now()
+	if err := os.MkdirAll(Dir(), 0o755); err != nil {
+		return err
+	}
+	data, _ := json.MarshalIndent(u, "", "  ")
+	return os.WriteFile(usagePath(), data, 0o644)
+}
+
+// usageKey identifies model as scans name it: local files by absolute path
+// and ollama models with their tag.
+func usageKey(runtime, model string) string {
+	if runtime == "llama-cpp" && !isHFRepo(model) {
+		if abs, err := filepath.Abs(model); err == nil {
+			model = abs
+		}
+	}
+	if runtime == "ollama" && !strings.Contains(model, ":") {
+		model += ":latest"
+	}
+	return runtime + "/" + model
+}
+
+// Serving reports whether one of insts is serving m.
+func Serving(m LocalModel, insts []Instance) bool {
+	u := usageRecord{}
+	for _, inst := range insts {
+		if inst.Source != "" {
+			u[usageKey(inst.Runtime, inst.Source)] = inst.StartedAt
+		}
+	}
+	return !u.lastUse(m).IsZero()
+}
+
+// lastUse returns when palm last served m. llama.cpp names files it
+// fetched with -hf after the repo, e.g. org_repo_file.gguf.
+func (u usageRecord) lastUse(m LocalModel) time.Time {
+	switch m.Runtime {
+	case "llama-cpp":
+		latest := u["llama-cpp/"+m.Path]
+		for key, t := range u {
+			repo, ok := strings.CutPrefix(key, "llama-cpp/")
+			if !ok || !isHFRepo(repo) {
+				continue
+			}
+			repo, _, _ = strings.Cut(repo, ":")
+			if strings.HasPrefix(m.Name, strings.ReplaceAll(repo, "/", "_")+"_") && t.After(latest) {
+				latest = t
+			}
+		}
+		return latest
+	}
+	return u[m.Runtime+"/"+m.Name]
+}
@@ -0,0 +1,149 @@
+package serve
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path string, size int) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Repeat("x", size)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// fakeModels lays out two ollama models sharing a layer, a gguf file in the
+// llama.cpp cache and a Hugging Face cache entry.
+func fakeModels(t *testing.T) string {
+	root := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "config"))
+	t.Setenv("OLLAMA_MODELS", filepath.Join(root, "ollama"))
+	t.Setenv("LLAMA_CACHE", filepath.Join(root, "llama"))
+	t.Setenv("HF_HUB_CACHE", filepath.Join(root, "hf"))
+
+	blobs := filepath.Join(root, "ollama", "blobs")
+	writeFile(t, filepath.Join(blobs, "sha256-aaa"), 1000) // weights of llama3.3
+	writeFile(t, filepath.Join(blobs, "sha256-bbb"), 800)  // weights of mistral
+	writeFile(t, filepath.Join(blobs, "sha256-lic"), 10)   // shared license layer
+	manifests := filepath.Join(root, "ollama", "manifests", "registry.ollama.ai", "library")
+	for name, weights := range map[string]string{"llama3.3/latest": "aaa", "mistral/7b": "bbb"} {
+		path := filepath.Join(manifests, filepath.FromSlash(name))
+		writeFile(t, path, 0)
+		os.WriteFile(path, []byte(manifestFor(weights)), 0o644)
+	}
+
+	writeFile(t, filepath.Join(root, "llama", "bartowski_Llama-3.2-3B-Instruct-GGUF_Llama-3.2-3B-Instruct-Q4_K_M.gguf"), 500)
+	writeFile(t, filepath.Join(root, "llama", "notes.txt"), 5)
+
+	repo := filepath.Join(root, "hf", "models--Qwen--Qwen2.5-7B-Instruct")
+	writeFile(t, filepath.Join(repo, "blobs", "abc"), 300)
+	os.MkdirAll(filepath.Join(repo, "snapshots", "main"), 0o755)
+	os.Symlink(filepath.Join(repo, "blobs", "abc"), filepath.Join(repo, "snapshots", "main", "model.safetensors"))
+	writeFile(t, filepath.Join(root, "hf", "datasets--squad", "x"), 50)
+	return root
+}
+
+func manifestFor(weights string) string {
+	return `{"config":{"digest":""},"layers":[{"digest":"sha256:` + weights + `"},{"digest":"sha256:lic"}]}`
+}
+
+// manifestSize is the size of each fake ollama manifest.
+var manifestSize = int64(len(manifestFor("aaa")))
+
+func byName(list []LocalModel) map[string]LocalModel {
+	out := make(map[string]LocalModel)
+	for _, m := range list {
+		out[m.Name] = m
+	}
+	return out
+}
+
+func TestScanModels(t *testing.T) {
+	fakeModels(t)
+	found, err := ScanModels(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := byName(found)
+	want := map[string]struct {
+		runtime string
+		size    int64
+	}{
+		"llama3.3:latest": {"ollama", 1010 + manifestSize},
+		"mistral:7b":      {"ollama", 810 + manifestSize},
+		"bartowski_Llama-3.2-3B-Instruct-GGUF_Llama-3.2-3B-Instruct-Q4_K_M.gguf": {"llama-cpp", 500},
+		"Qwen/Qwen2.5-7B-Instruct": {"vllm", 300},
+	}
+	if len(found) != len(want) {
+		t.Fatalf("found %d models, want %d: %v", len(found), len(want), found)
+	}
+	for name, w := range want {
+		m, ok := got[name]
+		if !ok || m.Runtime != w.runtime || m.Size != w.size {
+			t.Errorf("%s = %+v, want runtime %s size %d", name, m, w.runtime, w.size)
+		}
+	}
+	if found[0].Name != "llama3.3:latest" {
+		t.Errorf("models should be sorted largest first, got %s", found[0].Name)
+	}
+	// The shared license layer counts once
+	if total := TotalSize(found); total != 1000+800+10+500+300+2*manifestSize {
+		t.Errorf("TotalSize = %d", total)
+	}
+}
+
+func TestRemoveModelsKeepsSharedLayers(t *testing.T) {
+	root := fakeModels(t)
+	found, _ := ScanModels(nil)
+	all := byName(found)
+	drop := []LocalModel{all["llama3.3:latest"], all["Qwen/Qwen2.5-7B-Instruct"]}
+
+	if got := Reclaimable(drop, found); got != 1000+300+manifestSize {
+		t.Errorf("Reclaimable = %d, want %d", got, 1300+manifestSize)
+	}
+	if err := RemoveModels(drop, found); err != nil {
+		t.Fatal(err)
+	}
+
+	left, _ := ScanModels(nil)
+	if len(left) != 2 {
+		t.Errorf("%d models left, want 2: %v", len(left), left)
+	}
+	if _, err := os.Stat(filepath.Join(root, "ollama", "blobs", "sha256-lic")); err != nil {
+		t.Error("shared layer deleted while mistral still uses it")
+	}
+	if _, err := os.Stat(filepath.Join(root, "hf", "models--Qwen--Qwen2.5-7B-Instruct")); !os.IsNotExist(err) {
+		t.Error("Hugging Face cache dir not removed")
+	}
+}
+
+func TestRecordUseAndServing(t *testing.T) {
+	fakeModels(t)
+	if err := RecordUse("llama-cpp", "bartowski/Llama-3.2-3B-Instruct-GGUF:Q4_K_M"); err != nil {
+		t.Fatal(err)
+	}
+	RecordUse("ollama", "mistral")
+
+	found, _ := ScanModels(nil)
+	got := byName(found)
+	recent := time.Now().Add(-time.Minute)
+	gguf := got["bartowski_Llama-3.2-3B-Instruct-GGUF_Llama-3.2-3B-Instruct-Q4_K_M.gguf"]
+	if gguf.LastUsed.Before(recent) {
+		t.Errorf("-hf model not matched to its cached file: last used %s", gguf.LastUsed)
+	}
+	// "mistral" means mistral:latest, not the 7b tag
+	if u := loadUsage(); !u.lastUse(got["mistral:7b"]).IsZero() {
+		t.Error("mistral:7b matched a use of mistral:latest")
+	}
+
+	insts := []Instance{{Runtime: "vllm", Source: "Qwen/Qwen2.5-7B-Instruct", StartedAt: time.Now()}}
+	if !Serving(got["Qwen/Qwen2.5-7B-Instruct"], insts) || Serving(got["mistral:7b"], insts) {
+		t.Error("Serving matched the wrong models")
+	}
+}
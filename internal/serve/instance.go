@@ -0,0 +1,164 @@
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Instance is a runtime server palm started.
+type Instance struct {
+	Runtime   string    `json:"runtime"`
+	Model     string    `json:"model"`            // name the server answers to
+	Source    string    `json:"source,omitempty"` // model as given to start
+	Host      string    `json:"host"`
+	Port      int       `json:"port"`
+	PID       int       `json:"pid"` // the runtime, or its supervisor for daemons
+	Daemon    bool      `json:"daemon,omitempty"`
+	LogFile   string    `json:"log_file,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+
+	// Command and Env start the server again on restart.
+	Command []string `json:"command"`
+	Env     []string `json:"env,omitempty"`
+}
+
+// Root returns the server's URL without the API path.
+func (i Instance) Root() string {
+	return "http://" + i.Host + ":" + strconv.Itoa(i.Port)
+}
+
+// Dir returns the directory holding server state and logs.
+func Dir() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "palm", "serve")
+}
+
+func instancesPath() string {
+	return filepath.Join(Dir(), "instances.json")
+}
+
+// Instances returns the servers palm has started and not yet stopped.
+func Instances() ([]Instance, error) {
+	data, err := os.ReadFile(instancesPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var list []Instance
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("instances.json: %w", err)
+	}
+	return list, nil
+}
+
+// FindInstance returns the recorded server for runtime.
+func FindInstance(runtime string) (Instance, bool) {
+	list, _ := Instances()
+	for _, i := range list {
+		if i.Runtime == runtime {
+			return i, true
+		}
+	}
+	return Instance{}, false
+}
+
+// SaveInstance records inst, replacing any earlier server of its runtime.
+func SaveInstance(inst Instance) error {
+	list, err := Instances()
+	if err != nil {
+		return err
+	}
+	kept := []Instance{inst}
+	for _, i := range list {
+		if i.Runtime != inst.Runtime {
+			kept = append(kept, i)
+		}
+	}
+	return writeInstances(kept)
+}
+
+// RemoveInstance forgets the server for runtime.
+func RemoveInstance(runtime string) error {
+	list, err := Instances()
+	if err != nil {
+		return err
+	}
+	var kept []Instance
+	for _, i := range list {
+		if i.Runtime != runtime {
+			kept = append(kept, i)
+		}
+	}
+	return writeInstances(kept)
+}
+
+func writeInstances(list []Instance) error {
+	sort.Slice(list, func(a, b int) bool { return list[a].Runtime < list[b].Runtime })
+	if err := os.MkdirAll(Dir(), 0o755); err != nil {
+		return err
+	}
+	if list == nil {
+		list = []Instance{}
+	}
+	data, _ := json.MarshalIndent(list, "", "  ")
+	return os.WriteFile(instancesPath(), data, 0o644)
+}
+
+var healthClient = &http.Client{Timeout: 3 * time.Second}
+
+// Health asks the OpenAI-compatible server at root for /v1/models and
+// returns the model IDs it serves. Servers answer 503 while loading.
+func Health(root string) ([]string, error) {
+	resp, err := healthClient.Get(root + "/v1/models")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("/v1/models: %s", resp.Status)
+	}
+	var body struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("/v1/models: %w", err)
+	}
+	ids := make([]string, 0, len(body.Data))
+	for _, m := range body.Data {
+		ids = append(ids, m.ID)
+	}
+	return ids, nil
+}
+
+// WaitReady polls root until it serves /v1/models or ctx ends. Loading a
+// large model can take minutes.
+func WaitReady(ctx context.Context, root string, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		_, err := Health(root)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s not ready: %w", root, err)
+		case <-ticker.C:
+		}
+	}
+}
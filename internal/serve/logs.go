@@ -0,0 +1,168 @@
+package serve
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Log rotation limits for daemon output.
+const (
+	MaxLogSize = 10 << 20
+	KeepLogs   = 3
+)
+
+// LogPath returns where a daemon for runtime writes its output.
+func LogPath(runtime string) string {
+	return filepath.Join(Dir(), runtime+".log")
+}
+
+// PidPath returns the file holding a daemon's runtime process ID.
+func PidPath(runtime string) string {
+	return filepath.Join(Dir(), runtime+".pid")
+}
+
+// ReadPid returns the runtime process ID a daemon's supervisor recorded.
+func ReadPid(runtime string) (int, bool) {
+	data, err := os.ReadFile(PidPath(runtime))
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	return pid, err == nil
+}
+
+// RotatingWriter appends to a log file, moving it to path.1 (and older
+// files to path.2 and so on) once it would grow past max bytes.
+type RotatingWriter struct {
+	path string
+	max  int64
+	keep int
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// OpenLog opens path for appending with rotation at max bytes, keeping
+// keep old files.
+func OpenLog(path string, max int64, keep int) (*RotatingWriter, error) {
+	w := &RotatingWriter{path: path, max: max, keep: keep}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *RotatingWriter) open() error {
+	f, err := os.OpenFile(w.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.f, w.size = f, info.Size()
+	return nil
+}
+
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.size > 0 && w.size+int64(len(p)) > w.max {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.f.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *RotatingWriter) rotate() error {
+	w.f.Close()
+	_ = os.Remove(fmt.Sprintf("%s.%d", w.path, w.keep))
+	for i := w.keep - 1; i >= 1; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", w.path, i), fmt.Sprintf("%s.%d", w.path, i+1))
+	}
+	if w.keep > 0 {
+		_ = os.Rename(w.path, w.path+".1")
+	} else {
+		_ = os.Remove(w.path)
+	}
+	return w.open()
+}
+
+// Close closes the current log file.
+func (w *RotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.f.Close()
+}
+
+// Tail returns the last n lines of the file at path.
+func Tail(path string, n int) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var lines []string
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+		if len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+	return lines, sc.Err()
+}
+
+// Follow copies what is appended to path to w until ctx ends, switching to
+// the new file when the log is rotated.
+func Follow(ctx context.Context, path string, w io.Writer, interval time.Duration) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { f.Close() }()
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := io.Copy(w, f); err != nil {
+			return err
+		}
+		cur, _ := f.Stat()
+		if info, err := os.Stat(path); err == nil && cur != nil && !os.SameFile(cur, info) {
+			// Rotated: finish the old file, then read the new one from the start
+			_, _ = io.Copy(w, f)
+			if nf, err := os.Open(path); err == nil {
+				f.Close()
+				f = nf
+				continue
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
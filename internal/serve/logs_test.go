@@ -0,0 +1,104 @@
+package serve
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRotatingWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vllm.log")
+	w, err := OpenLog(path, 20, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"first line 1\n", "second line\n", "third line 3\n", "fourth line\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Close()
+
+	want := map[string]string{
+		path:        "fourth line\n",
+		path + ".1": "third line 3\n",
+		path + ".2": "second line\n",
+	}
+	for p, content := range want {
+		data, err := os.ReadFile(p)
+		if err != nil || string(data) != content {
+			t.Errorf("%s = %q, %v; want %q", filepath.Base(p), data, err, content)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("kept more than 2 rotated logs")
+	}
+}
+
+func TestTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	os.WriteFile(path, []byte("a\nb\nc\nd\n"), 0o644)
+
+	lines, err := Tail(path, 2)
+	if err != nil || strings.Join(lines, ",") != "c,d" {
+		t.Errorf("Tail = %v, %v; want [c d]", lines, err)
+	}
+	lines, _ = Tail(path, 10)
+	if len(lines) != 4 {
+		t.Errorf("Tail(10) returned %d lines, want 4", len(lines))
+	}
+}
+
+type syncBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.String()
+}
+
+func TestFollowAcrossRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "llama-cpp.log")
+	w, err := OpenLog(path, 16, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	w.Write([]byte("old output\n"))
+
+	var out syncBuffer
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- Follow(ctx, path, &out, 5*time.Millisecond) }()
+
+	time.Sleep(30 * time.Millisecond)
+	w.Write([]byte("new 1\n"))
+	time.Sleep(30 * time.Millisecond)
+	w.Write([]byte("rotated 2\n"))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(out.String(), "rotated 2") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "new 1\nrotated 2\n" {
+		t.Errorf("followed %q, want only lines written after Follow started", got)
+	}
+}
@@ -2,7 +2,14 @@ package serve
 
 import (
 	"fmt"
+	"net"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/msalah0e/palm/internal/gpu"
 )
 
 // Runtime represents a local LLM runtime.
@@ -12,49 +19,205 @@ type Runtime struct {
 	Version string
 }
 
+// Runtimes lists the supported runtimes in detection priority.
+var Runtimes = []string{"ollama", "llama-cpp", "vllm"}
+
+// DefaultPorts are the ports each runtime listens on out of the box.
+var DefaultPorts = map[string]int{
+	"ollama":    11434,
+	"llama-cpp": 8080,
+	"vllm":      8000,
+}
+
+// binaries lists the executable names of each runtime.
+var binaries = map[string][]string{
+	"ollama":    {"ollama"},
+	"llama-cpp": {"llama-server", "llama-cpp"},
+	"vllm":      {"vllm"},
+}
+
 // DetectRuntime finds the best available LLM runtime.
 func DetectRuntime() *Runtime {
-	// Priority: ollama > llama-server > vllm
-	if path, err := exec.LookPath("ollama"); err == nil {
-		ver := ""
-		if out, err := exec.Command(path, "--version").Output(); err == nil {
-			ver = extractVersion(string(out))
+	for _, name := range Runtimes {
+		if rt := FindRuntime(name); rt != nil {
+			return rt
 		}
-		return &Runtime{Name: "ollama", Path: path, Version: ver}
 	}
+	return nil
+}
 
-	if path, err := exec.LookPath("llama-server"); err == nil {
-		return &Runtime{Name: "llama-cpp", Path: path}
+// FindRuntime returns the named runtime if it is installed, or nil.
+func FindRuntime(name string) *Runtime {
+	for _, bin := range binaries[name] {
+		path, err := exec.LookPath(bin)
+		if err != nil {
+			continue
+		}
+		rt := &Runtime{Name: name, Path: path}
+		// vllm --version imports torch, which takes seconds
+		if name != "vllm" {
+			if out, err := exec.Command(path, "--version").CombinedOutput(); err == nil {
+				rt.Version = extractVersion(string(out))
+			}
+		}
+		return rt
 	}
+	return nil
+}
 
-	if path, err := exec.LookPath("llama-cpp"); err == nil {
-		return &Runtime{Name: "llama-cpp", Path: path}
-	}
+// Serves reports whether the runtime always runs as an HTTP server palm
+// manages. ollama runs an interactive session unless started as a daemon.
+func (r *Runtime) Serves() bool {
+	return r.Name == "llama-cpp" || r.Name == "vllm"
+}
 
-	if path, err := exec.LookPath("vllm"); err == nil {
-		return &Runtime{Name: "vllm", Path: path}
-	}
+// StartOptions configures a runtime launch.
+type StartOptions struct {
+	Model       string
+	Host        string // defaults to 127.0.0.1
+	Port        int    // defaults to the runtime's default port
+	GPU         bool   // offload to the GPU
+	GPUs        []gpu.Info
+	ContextSize int  // 0 keeps the runtime default
+	Server      bool // run ollama as an API server, not a chat session
+}
 
-	return nil
+func (o StartOptions) host() string {
+	if o.Host == "" {
+		return "127.0.0.1"
+	}
+	return o.Host
 }
 
-// Start launches the runtime with the given model.
-func (r *Runtime) Start(model string, gpu bool) *exec.Cmd {
+// Args returns the command-line arguments that start the runtime with opts.
+// On several GPUs llama.cpp splits layers in proportion to each card's
+// memory and vLLM shards the model with tensor parallelism.
+func (r *Runtime) Args(opts StartOptions) []string {
+	port := opts.Port
+	if port == 0 {
+		port = DefaultPorts[r.Name]
+	}
 	switch r.Name {
 	case "ollama":
-		return exec.Command(r.Path, "run", model)
+		if opts.Server {
+			return []string{"serve"}
+		}
+		return []string{"run", opts.Model}
 	case "llama-cpp":
-		args := []string{"--model", model}
-		if gpu {
+		args := []string{"--model", opts.Model}
+		if isHFRepo(opts.Model) {
+			args = []string{"-hf", opts.Model}
+		}
+		args = append(args, "--alias", ServedName(r.Name, opts.Model),
+			"--host", opts.host(), "--port", strconv.Itoa(port))
+		if opts.GPU {
 			args = append(args, "--n-gpu-layers", "999")
+			if split := tensorSplit(opts.GPUs); split != "" {
+				args = append(args, "--split-mode", "layer", "--tensor-split", split)
+			}
+		}
+		if opts.ContextSize > 0 {
+			args = append(args, "--ctx-size", strconv.Itoa(opts.ContextSize))
 		}
-		return exec.Command(r.Path, args...)
+		return args
 	case "vllm":
-		return exec.Command(r.Path, "serve", model)
+		args := []string{"serve", opts.Model, "--host", opts.host(), "--port", strconv.Itoa(port)}
+		if tp := tensorParallel(len(opts.GPUs)); opts.GPU && tp > 1 {
+			args = append(args, "--tensor-parallel-size", strconv.Itoa(tp))
+		}
+		if opts.ContextSize > 0 {
+			args = append(args, "--max-model-len", strconv.Itoa(opts.ContextSize))
+		}
+		return args
+	}
+	return nil
+}
+
+// Env returns environment variables the runtime needs on top of palm's.
+// ollama takes its listen address from OLLAMA_HOST rather than a flag.
+func (r *Runtime) Env(opts StartOptions) []string {
+	if r.Name == "ollama" && opts.Server && opts.Port != 0 {
+		return []string{"OLLAMA_HOST=" + net.JoinHostPort(opts.host(), strconv.Itoa(opts.Port))}
 	}
 	return nil
 }
 
+// Command builds the command that starts the runtime with opts.
+func (r *Runtime) Command(opts StartOptions) *exec.Cmd {
+	args := r.Args(opts)
+	if args == nil {
+		return nil
+	}
+	c := exec.Command(r.Path, args...)
+	if env := r.Env(opts); env != nil {
+		c.Env = append(os.Environ(), env...)
+	}
+	return c
+}
+
+// ServedName is the model name the runtime answers to on /v1/models.
+// llama.cpp serves model files under their base name.
+func ServedName(runtime, model string) string {
+	if runtime == "llama-cpp" && !isHFRepo(model) {
+		return strings.TrimSuffix(filepath.Base(model), ".gguf")
+	}
+	return model
+}
+
+// isHFRepo reports whether model names a Hugging Face repo
+// ("org/name[:quant]") rather than a local model file.
+func isHFRepo(model string) bool {
+	if strings.HasSuffix(strings.ToLower(model), ".gguf") {
+		return false
+	}
+	if _, err := os.Stat(model); err == nil {
+		return false
+	}
+	return strings.Count(model, "/") == 1 && !strings.HasPrefix(model, ".")
+}
+
+// tensorSplit weights llama.cpp's layer split by each GPU's memory, e.g.
+// "24,12". It is empty for a single GPU or when sizes are unknown.
+func tensorSplit(gpus []gpu.Info) string {
+	if len(gpus) < 2 {
+		return ""
+	}
+	parts := make([]string, len(gpus))
+	for i, g := range gpus {
+		if g.VRAMTotalMB == 0 {
+			return ""
+		}
+		parts[i] = strconv.Itoa(max(g.VRAMTotalMB/1024, 1))
+	}
+	return strings.Join(parts, ",")
+}
+
+// tensorParallel is the largest power of two not above n; vLLM needs the
+// model's attention heads to divide evenly across GPUs.
+func tensorParallel(n int) int {
+	tp := 1
+	for tp*2 <= n {
+		tp *= 2
+	}
+	return tp
+}
+
+// FreePort returns from, or the next port above it that nothing is
+// listening on.
+func FreePort(host string, from int) (int, error) {
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	for port := from; port < from+50; port++ {
+		ln, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+		if err == nil {
+			ln.Close()
+			return port, nil
+		}
+	}
+	return 0, fmt.Errorf("no free port between %d and %d", from, from+49)
+}
+
 // Pull downloads a model using the runtime.
 func (r *Runtime) Pull(model string) *exec.Cmd {
 	switch r.Name {
@@ -77,8 +240,11 @@ func (r *Runtime) ListModels() *exec.Cmd {
 
 // IsRunning checks if the runtime is currently serving.
 func (r *Runtime) IsRunning() bool {
-	switch r.Name {
-	case "ollama":
+	if inst, ok := FindInstance(r.Name); ok {
+		_, err := Health(inst.Root())
+		return err == nil
+	}
+	if r.Name == "ollama" {
 		cmd := exec.Command(r.Path, "list")
 		return cmd.Run() == nil
 	}
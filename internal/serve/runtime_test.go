@@ -0,0 +1,153 @@
+package serve
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/msalah0e/palm/internal/gpu"
+)
+
+func TestArgsLlamaCpp(t *testing.T) {
+	rt := &Runtime{Name: "llama-cpp"}
+	got := strings.Join(rt.Args(StartOptions{Model: "/models/qwen2.5-7b.gguf"}), " ")
+	want := "--model /models/qwen2.5-7b.gguf --alias qwen2.5-7b --host 127.0.0.1 --port 8080"
+	if got != want {
+		t.Errorf("CPU args = %q, want %q", got, want)
+	}
+
+	got = strings.Join(rt.Args(StartOptions{
+		Model:       "bartowski/Llama-3.2-3B-Instruct-GGUF",
+		Port:        9000,
+		GPU:         true,
+		GPUs:        []gpu.Info{{VRAMTotalMB: 24576}, {VRAMTotalMB: 12288}},
+		ContextSize: 8192,
+	}), " ")
+	want = "-hf bartowski/Llama-3.2-3B-Instruct-GGUF --alias bartowski/Llama-3.2-3B-Instruct-GGUF --host 127.0.0.1 --port 9000 " +
+		"--n-gpu-layers 999 --split-mode layer --tensor-split 24,12 --ctx-size 8192"
+	if got != want {
+		t.Errorf("GPU args = %q, want %q", got, want)
+	}
+}
+
+func TestArgsVLLM(t *testing.T) {
+	rt := &Runtime{Name: "vllm"}
+	three := make([]gpu.Info, 3)
+	got := rt.Args(StartOptions{Model: "Qwen/Qwen2.5-7B-Instruct", GPU: true, GPUs: three, ContextSize: 4096})
+	want := []string{"serve", "Qwen/Qwen2.5-7B-Instruct", "--host", "127.0.0.1", "--port", "8000",
+		"--tensor-parallel-size", "2", "--max-model-len", "4096"}
+	if !slices.Equal(got, want) {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+
+	got = rt.Args(StartOptions{Model: "m", GPUs: make([]gpu.Info, 1), GPU: true})
+	if slices.Contains(got, "--tensor-parallel-size") {
+		t.Errorf("single GPU should not set tensor parallelism: %q", got)
+	}
+}
+
+func TestOllamaServer(t *testing.T) {
+	rt := &Runtime{Name: "ollama"}
+	if got := rt.Args(StartOptions{Model: "llama3.3"}); !slices.Equal(got, []string{"run", "llama3.3"}) {
+		t.Errorf("interactive args = %q", got)
+	}
+	opts := StartOptions{Model: "llama3.3", Server: true, Port: 11435}
+	if got := rt.Args(opts); !slices.Equal(got, []string{"serve"}) {
+		t.Errorf("server args = %q", got)
+	}
+	if got := rt.Env(opts); !slices.Equal(got, []string{"OLLAMA_HOST=127.0.0.1:11435"}) {
+		t.Errorf("server env = %q", got)
+	}
+}
+
+func TestTensorParallel(t *testing.T) {
+	for n, want := range map[int]int{0: 1, 1: 1, 2: 2, 3: 2, 4: 4, 7: 4, 8: 8} {
+		if got := tensorParallel(n); got != want {
+			t.Errorf("tensorParallel(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
+
+func TestFreePortSkipsBusy(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	busy := ln.Addr().(*net.TCPAddr).Port
+
+	port, err := FreePort("127.0.0.1", busy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port == busy {
+		t.Errorf("FreePort returned busy port %d", busy)
+	}
+}
+
+func TestHealthAndWaitReady(t *testing.T) {
+	loaded := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			http.NotFound(w, r)
+			return
+		}
+		if !loaded {
+			loaded = true
+			http.Error(w, `{"error":"Loading model"}`, http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"object":"list","data":[{"id":"qwen2.5-7b","object":"model"}]}`))
+	}))
+	defer srv.Close()
+
+	if _, err := Health(srv.URL); err == nil {
+		t.Fatal("Health should fail while the model loads")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := WaitReady(ctx, srv.URL, 10*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	ids, err := Health(srv.URL)
+	if err != nil || len(ids) != 1 || ids[0] != "qwen2.5-7b" {
+		t.Errorf("Health = %v, %v", ids, err)
+	}
+}
+
+func TestInstances(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	if list, err := Instances(); err != nil || len(list) != 0 {
+		t.Fatalf("Instances() = %v, %v; want empty", list, err)
+	}
+	for i, rt := range []string{"vllm", "llama-cpp", "vllm"} {
+		if err := SaveInstance(Instance{Runtime: rt, Model: "m" + strconv.Itoa(i), Host: "127.0.0.1", Port: 8000 + i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list, _ := Instances()
+	if len(list) != 2 {
+		t.Fatalf("got %d instances, want 2 (one per runtime)", len(list))
+	}
+	inst, ok := FindInstance("vllm")
+	if !ok || inst.Model != "m2" || inst.Root() != "http://127.0.0.1:8002" {
+		t.Errorf("FindInstance(vllm) = %+v, %v", inst, ok)
+	}
+
+	if err := RemoveInstance("vllm"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := FindInstance("vllm"); ok {
+		t.Error("vllm still recorded after RemoveInstance")
+	}
+	if _, ok := FindInstance("llama-cpp"); !ok {
+		t.Error("llama-cpp lost by RemoveInstance(vllm)")
+	}
+}
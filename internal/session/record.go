@@ -0,0 +1,229 @@
+package session
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Redacted replaces secret environment values in recordings.
+const Redacted = "<redacted>"
+
+// maxCapture caps each recorded stream so a chatty tool can't fill the disk.
+const maxCapture = 1 << 20
+
+// Recording is a full capture of one `palm run --record` invocation, enough
+// to run it again later.
+type Recording struct {
+	ID        string    `json:"id"`
+	Tool      string    `json:"tool"`
+	Bin       string    `json:"bin"` // resolved binary path
+	Args      []string  `json:"args"`
+	Dir       string    `json:"dir"`
+	Env       []string  `json:"env"`      // KEY=value, secrets redacted
+	Injected  []string  `json:"injected"` // keys palm added from the vault
+	Stdin     string    `json:"stdin"`    // piped prompt; empty when interactive
+	Stdout    string    `json:"stdout"`
+	Stderr    string    `json:"stderr"`
+	Truncated bool      `json:"truncated,omitempty"`
+	ExitCode  int       `json:"exit_code"`
+	StartedAt time.Time `json:"started_at"`
+	Duration  float64   `json:"duration_secs"`
+}
+
+// RecordingsDir returns the directory holding recordings.
+func RecordingsDir() string {
+	return filepath.Join(filepath.Dir(sessionsPath()), "sessions")
+}
+
+// NewID returns a sortable, collision-resistant recording ID.
+func NewID(t time.Time) string {
+	b := make([]byte, 2)
+	_, _ = rand.Read(b)
+	return t.Format("20060102-150405") + "-" + hex.EncodeToString(b)
+}
+
+// Command returns the invocation as a shell-like line for display.
+func (r *Recording) Command() string {
+	parts := []string{r.Tool}
+	for _, a := range r.Args {
+		if a == "" || strings.ContainsAny(a, " \t\n\"'$") {
+			a = fmt.Sprintf("%q", a)
+		}
+		parts = append(parts, a)
+	}
+	return strings.Join(parts, " ")
+}
+
+// SaveRecording writes r to the recordings directory. Files are private to
+// the user since outputs may contain sensitive data.
+func SaveRecording(r *Recording) error {
+	dir := RecordingsDir()
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, r.ID+".json"), data, 0o600)
+}
+
+// LoadRecording reads a recording by ID or unique ID prefix.
+func LoadRecording(id string) (*Recording, error) {
+	entries, err := os.ReadDir(RecordingsDir())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	var matches []string
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), ".json")
+		if name == id {
+			matches = []string{name}
+			break
+		}
+		if strings.HasPrefix(name, id) {
+			matches = append(matches, name)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no recorded session %q", id)
+	case 1:
+	default:
+		return nil, fmt.Errorf("%q matches %d sessions — use more of the ID", id, len(matches))
+	}
+
+	data, err := os.ReadFile(filepath.Join(RecordingsDir(), matches[0]+".json"))
+	if err != nil {
+		return nil, err
+	}
+	var r Recording
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("%s: %w", matches[0], err)
+	}
+	return &r, nil
+}
+
+// ListRecordings returns all recordings, most recent first.
+func ListRecordings() ([]Recording, error) {
+	entries, err := os.ReadDir(RecordingsDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var all []Recording
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(RecordingsDir(), e.Name()))
+		if err != nil {
+			continue
+		}
+		var r Recording
+		if json.Unmarshal(data, &r) == nil {
+			all = append(all, r)
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].StartedAt.After(all[j].StartedAt) })
+	return all, nil
+}
+
+// secretMarkers flag environment variables whose values must not be stored.
+var secretMarkers = []string{"KEY", "TOKEN", "SECRET", "PASSWORD", "PASSWD", "CREDENTIAL", "AUTH", "COOKIE", "SESSION"}
+
+// IsSecretVar reports whether an environment variable name looks like it
+// holds a credential.
+func IsSecretVar(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, m := range secretMarkers {
+		if strings.Contains(upper, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactEnv copies env with secret values replaced. Names in extra are
+// always redacted, whatever they are called.
+func RedactEnv(env []string, extra ...string) []string {
+	always := make(map[string]bool, len(extra))
+	for _, k := range extra {
+		always[k] = true
+	}
+	out := make([]string, 0, len(env))
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		if always[name] || IsSecretVar(name) {
+			kv = name + "=" + Redacted
+		}
+		out = append(out, kv)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// RestoreEnv rebuilds an environment for replay: recorded values are kept,
+// and redacted ones are filled by lookup. Variables lookup can't provide are
+// dropped and returned as missing.
+func RestoreEnv(env []string, lookup func(name string) (string, bool)) (restored, missing []string) {
+	for _, kv := range env {
+		name, val, _ := strings.Cut(kv, "=")
+		if val == Redacted {
+			v, ok := lookup(name)
+			if !ok {
+				missing = append(missing, name)
+				continue
+			}
+			val = v
+		}
+		restored = append(restored, name+"="+val)
+	}
+	return restored, missing
+}
+
+// Capture is an io.Writer that keeps the first maxCapture bytes written.
+type Capture struct {
+	buf       bytes.Buffer
+	Truncated bool
+}
+
+func (c *Capture) Write(p []byte) (int, error) {
+	if room := maxCapture - c.buf.Len(); room < len(p) {
+		c.Truncated = true
+		c.buf.Write(p[:max(room, 0)])
+	} else {
+		c.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+func (c *Capture) String() string {
+	return c.buf.String()
+}
+
+// ScrubSecrets replaces the values of secret variables in env wherever they
+// appear in text, so keys a tool echoes don't end up in a recording.
+func ScrubSecrets(text string, env []string, extra ...string) string {
+	always := make(map[string]bool, len(extra))
+	for _, k := range extra {
+		always[k] = true
+	}
+	for _, kv := range env {
+		name, val, _ := strings.Cut(kv, "=")
+		if len(val) >= 8 && (always[name] || IsSecretVar(name)) {
+			text = strings.ReplaceAll(text, val, Redacted)
+		}
+	}
+	return text
+}
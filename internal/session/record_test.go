@@ -0,0 +1,81 @@
+package session
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordingRoundTrip(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	older := &Recording{ID: NewID(time.Now().Add(-time.Hour)), Tool: "aider", StartedAt: time.Now().Add(-time.Hour)}
+	newer := &Recording{ID: "20990101-000000-beef", Tool: "llm", Args: []string{"-m", "gpt 4"}, Stdout: "hi\n", StartedAt: time.Now()}
+	for _, r := range []*Recording{older, newer} {
+		if err := SaveRecording(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	info, err := os.Stat(RecordingsDir() + "/" + newer.ID + ".json")
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("recording should be private, got %v %v", info.Mode(), err)
+	}
+
+	all, err := ListRecordings()
+	if err != nil || len(all) != 2 || all[0].ID != newer.ID {
+		t.Fatalf("expected newest first, got %+v (%v)", all, err)
+	}
+
+	got, err := LoadRecording("2099")
+	if err != nil || got.Stdout != "hi\n" {
+		t.Errorf("prefix lookup: %+v %v", got, err)
+	}
+	if got.Command() != `llm -m "gpt 4"` {
+		t.Errorf("Command() = %s", got.Command())
+	}
+	if _, err := LoadRecording("nope"); err == nil {
+		t.Error("expected error for unknown ID")
+	}
+}
+
+func TestRedactAndRestoreEnv(t *testing.T) {
+	env := []string{"PATH=/bin", "OPENAI_API_KEY=sk-123456789", "GH_TOKEN=abc", "CUSTOM=vaultvalue"}
+	redacted := RedactEnv(env, "CUSTOM")
+	joined := strings.Join(redacted, " ")
+	if strings.Contains(joined, "sk-123456789") || strings.Contains(joined, "abc") || strings.Contains(joined, "vaultvalue") {
+		t.Errorf("secrets left in %v", redacted)
+	}
+	if !strings.Contains(joined, "PATH=/bin") {
+		t.Errorf("plain vars should be kept: %v", redacted)
+	}
+
+	restored, missing := RestoreEnv(redacted, func(name string) (string, bool) {
+		if name == "OPENAI_API_KEY" {
+			return "sk-new", true
+		}
+		return "", false
+	})
+	if !strings.Contains(strings.Join(restored, " "), "OPENAI_API_KEY=sk-new") {
+		t.Errorf("expected key restored, got %v", restored)
+	}
+	if len(missing) != 2 {
+		t.Errorf("expected CUSTOM and GH_TOKEN missing, got %v", missing)
+	}
+}
+
+func TestScrubSecretsAndCapture(t *testing.T) {
+	env := []string{"OPENAI_API_KEY=sk-123456789", "HOME=/home/me"}
+	out := ScrubSecrets("using sk-123456789 from /home/me", env)
+	if out != "using <redacted> from /home/me" {
+		t.Errorf("ScrubSecrets = %q", out)
+	}
+
+	var c Capture
+	_, _ = c.Write([]byte(strings.Repeat("x", maxCapture-2)))
+	n, _ := c.Write([]byte("abcd"))
+	if n != 4 || !c.Truncated || len(c.String()) != maxCapture {
+		t.Errorf("capture should keep %d bytes and flag truncation, got %d %v", maxCapture, len(c.String()), c.Truncated)
+	}
+}
@@ -0,0 +1,263 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/msalah0e/palm/internal/activity"
+	"github.com/msalah0e/palm/internal/proxy"
+	"github.com/msalah0e/palm/internal/session"
+)
+
+// maxDigestEvents caps the notable events a digest lists.
+const maxDigestEvents = 15
+
+// Digest is a usage report for one period, compared with the period before.
+type Digest struct {
+	From     time.Time    `json:"from"`
+	To       time.Time    `json:"to"`
+	Report   *Report      `json:"report"`
+	Previous ReportTotals `json:"previous"` // totals of the period before
+	Evals    []EvalTrend  `json:"eval_trends"`
+	Events   []Event      `json:"events"`
+	Summary  string       `json:"summary,omitempty"`
+}
+
+// EvalTrend is one tool's average eval score in the period and the one
+// before. Previous is 0 when the tool wasn't evaluated before.
+type EvalTrend struct {
+	Tool     string  `json:"tool"`
+	Runs     int     `json:"runs"`
+	Score    float64 `json:"score"`
+	Previous float64 `json:"previous,omitempty"`
+}
+
+// Event is something worth pointing out in a digest.
+type Event struct {
+	Time time.Time `json:"time"`
+	Kind string    `json:"kind"` // failure, install or first-use
+	Text string    `json:"text"`
+}
+
+// BuildDigest reports on usage from from up to to.
+func BuildDigest(from, to time.Time) (*Digest, error) {
+	logs, err := proxy.ReadLogs(0)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := activity.Read(0)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := session.List(0)
+	if err != nil {
+		return nil, err
+	}
+	return digest(from, to, logs, entries, sessions), nil
+}
+
+func digest(from, to time.Time, logs []proxy.RequestLog, entries []activity.Entry, sessions []session.Session) *Digest {
+	prevFrom := from.Add(-to.Sub(from))
+	cur := window(from, to, logs, entries, sessions)
+	prev := window(prevFrom, from, logs, entries, sessions)
+
+	d := &Digest{From: from, To: to, Report: cur, Previous: prev.Totals, Events: []Event{}}
+	d.Report.Since = from
+
+	before := make(map[string]float64)
+	for _, e := range prev.Evals {
+		before[e.Tool] = e.AvgScore
+	}
+	for _, e := range cur.Evals {
+		d.Evals = append(d.Evals, EvalTrend{Tool: e.Tool, Runs: e.Runs, Score: e.AvgScore, Previous: before[e.Tool]})
+	}
+
+	// Tools launched before the period, to spot first uses
+	used := make(map[string]bool)
+	for _, e := range entries {
+		if (e.Action == "run" || e.Action == "squad") && e.Timestamp.Before(from) {
+			used[e.Tool] = true
+		}
+	}
+	for _, s := range sessions {
+		if s.StartedAt.Before(from) {
+			used[s.Tool] = true
+		}
+	}
+
+	// Activity log is newest-first
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if e.Timestamp.Before(from) || !e.Timestamp.Before(to) {
+			continue
+		}
+		switch {
+		case e.Status == "failed" && e.Action != "compose.step": // the run's own entry covers its steps
+			text := strings.TrimSpace(e.Action + " " + e.Tool + " failed")
+			if e.Details != "" {
+				text += " (" + e.Details + ")"
+			}
+			d.Events = append(d.Events, Event{Time: e.Timestamp, Kind: "failure", Text: text})
+		case e.Action == "install" && e.Tool != "":
+			d.Events = append(d.Events, Event{Time: e.Timestamp, Kind: "install", Text: "installed " + e.Tool})
+		case (e.Action == "run" || e.Action == "squad") && e.Tool != "" && !used[e.Tool]:
+			used[e.Tool] = true
+			d.Events = append(d.Events, Event{Time: e.Timestamp, Kind: "first-use", Text: "first used " + e.Tool})
+		}
+	}
+	sort.SliceStable(d.Events, func(i, j int) bool { return d.Events[i].Time.Before(d.Events[j].Time) })
+	return d
+}
+
+// window aggregates what was recorded from from up to to.
+func window(from, to time.Time, logs []proxy.RequestLog, entries []activity.Entry, sessions []session.Session) *Report {
+	var l []proxy.RequestLog
+	for _, x := range logs {
+		if x.Timestamp.Before(to) {
+			l = append(l, x)
+		}
+	}
+	var e []activity.Entry
+	for _, x := range entries {
+		if x.Timestamp.Before(to) {
+			e = append(e, x)
+		}
+	}
+	var s []session.Session
+	for _, x := range sessions {
+		if x.StartedAt.Before(to) {
+			s = append(s, x)
+		}
+	}
+	return aggregate(from, l, e, s)
+}
+
+// ProviderTotals sums the report's per-day provider traffic per provider,
+// most expensive first.
+func (r *Report) ProviderTotals() []ProviderDay {
+	byProvider := make(map[string]*ProviderDay)
+	var out []*ProviderDay
+	for _, p := range r.Providers {
+		t, ok := byProvider[p.Provider]
+		if !ok {
+			t = &ProviderDay{Provider: p.Provider}
+			byProvider[p.Provider] = t
+			out = append(out, t)
+		}
+		t.Requests += p.Requests
+		t.Errors += p.Errors
+		t.InputTokens += p.InputTokens
+		t.OutputTokens += p.OutputTokens
+		t.Cost += p.Cost
+	}
+	totals := make([]ProviderDay, len(out))
+	for i, t := range out {
+		totals[i] = *t
+	}
+	sort.SliceStable(totals, func(i, j int) bool { return totals[i].Cost > totals[j].Cost })
+	return totals
+}
+
+// Empty reports whether nothing was recorded in the period.
+func (d *Digest) Empty() bool {
+	t := d.Report.Totals
+	return t.Requests == 0 && t.ComposeRuns == 0 && t.Launches == 0 &&
+		len(d.Report.Speed) == 0 && len(d.Evals) == 0 && len(d.Events) == 0
+}
+
+// Markdown renders the digest as a report for reading or sharing.
+func (d *Digest) Markdown() string {
+	var b strings.Builder
+	r, t := d.Report, d.Report.Totals
+	fmt.Fprintf(&b, "# palm digest: %s – %s\n\n", d.From.Format("Jan 2"), d.To.Add(-time.Second).Format("Jan 2, 2006"))
+
+	if d.Summary != "" {
+		b.WriteString("## Summary\n\n")
+		b.WriteString(strings.TrimSpace(d.Summary) + "\n\n")
+	}
+
+	b.WriteString("## Usage\n\n")
+	if d.Empty() {
+		b.WriteString("Nothing was recorded in this period.\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "- API requests: %d%s\n", t.Requests, change(float64(t.Requests), float64(d.Previous.Requests)))
+	fmt.Fprintf(&b, "- Tokens: %d in / %d out\n", t.InputTokens, t.OutputTokens)
+	fmt.Fprintf(&b, "- Estimated cost: $%.2f%s\n", t.Cost, change(t.Cost, d.Previous.Cost))
+	fmt.Fprintf(&b, "- Tool launches: %d%s\n", t.Launches, change(float64(t.Launches), float64(d.Previous.Launches)))
+	if t.ComposeRuns > 0 {
+		fmt.Fprintf(&b, "- Compose runs: %d (%d failed)\n", t.ComposeRuns, t.ComposeFails)
+	}
+
+	if providers := r.ProviderTotals(); len(providers) > 0 {
+		b.WriteString("\n## Cost by provider\n\n| Provider | Requests | Errors | Tokens | Cost |\n|---|---:|---:|---:|---:|\n")
+		for _, p := range providers {
+			fmt.Fprintf(&b, "| %s | %d | %d | %d | $%.2f |\n", p.Provider, p.Requests, p.Errors, p.InputTokens+p.OutputTokens, p.Cost)
+		}
+	}
+
+	if len(r.Launches) > 0 {
+		b.WriteString("\n## Top tools\n\n")
+		for i, l := range r.Launches {
+			if i == 5 {
+				break
+			}
+			fmt.Fprintf(&b, "%d. %s — %d %s\n", i+1, l.Tool, l.Launches, plural(l.Launches, "launch", "launches"))
+		}
+	}
+
+	if len(r.Compose) > 0 {
+		b.WriteString("\n## Workflows\n\n| Workflow | Runs | Failure rate | Avg time |\n|---|---:|---:|---:|\n")
+		for _, w := range r.Compose {
+			fmt.Fprintf(&b, "| %s | %d | %.0f%% | %.1fs |\n", w.Workflow, w.Runs, w.FailureRate*100, w.AvgDuration)
+		}
+	}
+
+	if len(d.Evals) > 0 {
+		b.WriteString("\n## Eval trends\n\n| Tool | Runs | Avg score | Before |\n|---|---:|---:|---:|\n")
+		for _, e := range d.Evals {
+			prev := "–"
+			if e.Previous > 0 {
+				prev = fmt.Sprintf("%.0f (%+.0f)", e.Previous, e.Score-e.Previous)
+			}
+			fmt.Fprintf(&b, "| %s | %d | %.0f | %s |\n", e.Tool, e.Runs, e.Score, prev)
+		}
+	}
+
+	if len(r.Speed) > 0 {
+		b.WriteString("\n## Speed\n\n")
+		for _, s := range r.Speed {
+			fmt.Fprintf(&b, "- %s: %.1f tok/s on average (best %.1f)\n", s.Provider, s.AvgTPS, s.BestTPS)
+		}
+	}
+
+	if len(d.Events) > 0 {
+		b.WriteString("\n## Notable events\n\n")
+		for i, e := range d.Events {
+			if i == maxDigestEvents {
+				fmt.Fprintf(&b, "- …and %d more\n", len(d.Events)-maxDigestEvents)
+				break
+			}
+			fmt.Fprintf(&b, "- %s — %s\n", e.Time.Format("Mon Jan 2 15:04"), e.Text)
+		}
+	}
+	return b.String()
+}
+
+// change describes now against before as " (+12% vs previous period)", or
+// "" when there is nothing to compare with.
+func change(now, before float64) string {
+	if before == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%+.0f%% vs previous period)", (now-before)/before*100)
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
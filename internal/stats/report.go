@@ -0,0 +1,266 @@
+package stats
+
+import (
+	"sort"
+	"time"
+
+	"github.com/msalah0e/palm/internal/activity"
+	"github.com/msalah0e/palm/internal/proxy"
+	"github.com/msalah0e/palm/internal/session"
+)
+
+// Report aggregates usage across proxy logs, sessions, and the activity log.
+type Report struct {
+	Since     time.Time       `json:"since,omitempty"`
+	Providers []ProviderDay   `json:"providers"`
+	Compose   []WorkflowStats `json:"compose"`
+	Speed     []SpeedStats    `json:"speedtest"`
+	Evals     []EvalStats     `json:"evals"`
+	Launches  []ToolLaunches  `json:"launches"`
+	Totals    ReportTotals    `json:"totals"`
+}
+
+// ReportTotals holds headline numbers for the report window.
+type ReportTotals struct {
+	Requests     int64   `json:"requests"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	Cost         float64 `json:"cost"`
+	ComposeRuns  int     `json:"compose_runs"`
+	ComposeFails int     `json:"compose_failures"`
+	Launches     int     `json:"launches"`
+}
+
+// ProviderDay is proxy traffic for one provider on one day.
+type ProviderDay struct {
+	Day          string  `json:"day"` // YYYY-MM-DD
+	Provider     string  `json:"provider"`
+	Requests     int64   `json:"requests"`
+	Errors       int64   `json:"errors"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	Cost         float64 `json:"cost"`
+}
+
+// WorkflowStats summarizes compose runs of one workflow.
+type WorkflowStats struct {
+	Workflow    string    `json:"workflow"`
+	Runs        int       `json:"runs"`
+	Failures    int       `json:"failures"`
+	FailureRate float64   `json:"failure_rate"`
+	AvgDuration float64   `json:"avg_duration_secs"`
+	LastRun     time.Time `json:"last_run"`
+}
+
+// SpeedStats summarizes speedtest history for one provider.
+type SpeedStats struct {
+	Provider string  `json:"provider"`
+	Runs     int     `json:"runs"`
+	AvgTPS   float64 `json:"avg_tps"`
+	BestTPS  float64 `json:"best_tps"`
+	LastTPS  float64 `json:"last_tps"`
+	Trend    float64 `json:"trend_pct"` // recent half vs older half, in percent
+}
+
+// EvalStats summarizes eval scores for one tool.
+type EvalStats struct {
+	Tool     string  `json:"tool"`
+	Runs     int     `json:"runs"`
+	AvgScore float64 `json:"avg_score"`
+	Best     float64 `json:"best_score"`
+}
+
+// ToolLaunches counts how often a tool was started through palm.
+type ToolLaunches struct {
+	Tool     string    `json:"tool"`
+	Launches int       `json:"launches"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+// BuildReport aggregates all usage sources recorded at or after since.
+// A zero since includes everything.
+func BuildReport(since time.Time) (*Report, error) {
+	logs, err := proxy.ReadLogs(0)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := activity.Read(0)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := session.List(0)
+	if err != nil {
+		return nil, err
+	}
+	return aggregate(since, logs, entries, sessions), nil
+}
+
+func aggregate(since time.Time, logs []proxy.RequestLog, entries []activity.Entry, sessions []session.Session) *Report {
+	r := &Report{Since: since}
+
+	// Proxy traffic per provider per day
+	days := make(map[[2]string]*ProviderDay)
+	for _, l := range logs {
+		if l.Timestamp.Before(since) {
+			continue
+		}
+		k := [2]string{l.Timestamp.Format("2006-01-02"), l.Provider}
+		pd, ok := days[k]
+		if !ok {
+			pd = &ProviderDay{Day: k[0], Provider: k[1]}
+			days[k] = pd
+		}
+		pd.Requests++
+		if l.Status >= 400 {
+			pd.Errors++
+		}
+		pd.InputTokens += l.InputTokens
+		pd.OutputTokens += l.OutputTokens
+		pd.Cost += l.Cost
+
+		r.Totals.Requests++
+		r.Totals.InputTokens += l.InputTokens
+		r.Totals.OutputTokens += l.OutputTokens
+		r.Totals.Cost += l.Cost
+	}
+	for _, pd := range days {
+		r.Providers = append(r.Providers, *pd)
+	}
+	sort.Slice(r.Providers, func(i, j int) bool {
+		if r.Providers[i].Day != r.Providers[j].Day {
+			return r.Providers[i].Day < r.Providers[j].Day
+		}
+		return r.Providers[i].Provider < r.Providers[j].Provider
+	})
+
+	// Activity log is newest-first; walk it oldest-first so trends read naturally
+	workflows := make(map[string]*WorkflowStats)
+	speeds := make(map[string][]float64)
+	evals := make(map[string]*EvalStats)
+	launches := make(map[string]*ToolLaunches)
+
+	launch := func(tool string, at time.Time) {
+		if tool == "" {
+			return
+		}
+		tl, ok := launches[tool]
+		if !ok {
+			tl = &ToolLaunches{Tool: tool}
+			launches[tool] = tl
+		}
+		tl.Launches++
+		if at.After(tl.LastUsed) {
+			tl.LastUsed = at
+		}
+		r.Totals.Launches++
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if e.Timestamp.Before(since) {
+			continue
+		}
+		switch e.Action {
+		case "compose":
+			ws, ok := workflows[e.Tool]
+			if !ok {
+				ws = &WorkflowStats{Workflow: e.Tool}
+				workflows[e.Tool] = ws
+			}
+			ws.Runs++
+			if e.Status == "failed" {
+				ws.Failures++
+				r.Totals.ComposeFails++
+			}
+			ws.AvgDuration += e.Duration
+			if e.Timestamp.After(ws.LastRun) {
+				ws.LastRun = e.Timestamp
+			}
+			r.Totals.ComposeRuns++
+		case "speedtest":
+			if e.Status != "failed" {
+				speeds[e.Tool] = append(speeds[e.Tool], e.Value)
+			}
+		case "eval":
+			es, ok := evals[e.Tool]
+			if !ok {
+				es = &EvalStats{Tool: e.Tool}
+				evals[e.Tool] = es
+			}
+			es.Runs++
+			es.AvgScore += e.Value
+			if e.Value > es.Best {
+				es.Best = e.Value
+			}
+		case "run", "squad":
+			launch(e.Tool, e.Timestamp)
+		}
+	}
+	for _, s := range sessions {
+		if s.StartedAt.Before(since) {
+			continue
+		}
+		launch(s.Tool, s.StartedAt)
+	}
+
+	for _, ws := range workflows {
+		ws.AvgDuration /= float64(ws.Runs)
+		ws.FailureRate = float64(ws.Failures) / float64(ws.Runs)
+		r.Compose = append(r.Compose, *ws)
+	}
+	sort.Slice(r.Compose, func(i, j int) bool { return r.Compose[i].Runs > r.Compose[j].Runs })
+
+	for provider, tps := range speeds {
+		ss := SpeedStats{Provider: provider, Runs: len(tps), LastTPS: tps[len(tps)-1]}
+		sum := 0.0
+		for _, v := range tps {
+			sum += v
+			if v > ss.BestTPS {
+				ss.BestTPS = v
+			}
+		}
+		ss.AvgTPS = sum / float64(len(tps))
+		ss.Trend = trend(tps)
+		r.Speed = append(r.Speed, ss)
+	}
+	sort.Slice(r.Speed, func(i, j int) bool { return r.Speed[i].AvgTPS > r.Speed[j].AvgTPS })
+
+	for _, es := range evals {
+		es.AvgScore /= float64(es.Runs)
+		r.Evals = append(r.Evals, *es)
+	}
+	sort.Slice(r.Evals, func(i, j int) bool { return r.Evals[i].AvgScore > r.Evals[j].AvgScore })
+
+	for _, tl := range launches {
+		r.Launches = append(r.Launches, *tl)
+	}
+	sort.Slice(r.Launches, func(i, j int) bool {
+		if r.Launches[i].Launches != r.Launches[j].Launches {
+			return r.Launches[i].Launches > r.Launches[j].Launches
+		}
+		return r.Launches[i].Tool < r.Launches[j].Tool
+	})
+
+	return r
+}
+
+// trend compares the mean of the newer half of a series against the older half,
+// returning the change in percent. Series shorter than two points have no trend.
+func trend(series []float64) float64 {
+	if len(series) < 2 {
+		return 0
+	}
+	mid := len(series) / 2
+	mean := func(xs []float64) float64 {
+		sum := 0.0
+		for _, x := range xs {
+			sum += x
+		}
+		return sum / float64(len(xs))
+	}
+	older, newer := mean(series[:mid]), mean(series[mid:])
+	if older == 0 {
+		return 0
+	}
+	return (newer - older) / older * 100
+}
@@ -2,7 +2,13 @@ package stats
 
 import (
 	"os"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/msalah0e/palm/internal/activity"
+	"github.com/msalah0e/palm/internal/proxy"
+	"github.com/msalah0e/palm/internal/session"
 )
 
 func TestStats(t *testing.T) {
@@ -48,3 +54,101 @@ func TestHistoryPath(t *testing.T) {
 		t.Errorf("unexpected path: %q", path)
 	}
 }
+
+func TestAggregateReport(t *testing.T) {
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	logs := []proxy.RequestLog{
+		{Timestamp: base, Provider: "openai", Status: 200, InputTokens: 100, OutputTokens: 50, Cost: 0.01},
+		{Timestamp: base.Add(time.Hour), Provider: "openai", Status: 429},
+		{Timestamp: base.Add(24 * time.Hour), Provider: "anthropic", Status: 200, InputTokens: 10, Cost: 0.02},
+		{Timestamp: base.Add(-48 * time.Hour), Provider: "openai", Status: 200, Cost: 5}, // outside window
+	}
+	// activity.Read returns newest first
+	entries := []activity.Entry{
+		{Timestamp: base.Add(5 * time.Hour), Action: "speedtest", Tool: "Ollama", Value: 30, Status: "ok"},
+		{Timestamp: base.Add(4 * time.Hour), Action: "compose", Tool: "review", Status: "failed", Duration: 4},
+		{Timestamp: base.Add(3 * time.Hour), Action: "compose", Tool: "review", Status: "ok", Duration: 2},
+		{Timestamp: base.Add(2 * time.Hour), Action: "speedtest", Tool: "Ollama", Value: 20, Status: "ok"},
+		{Timestamp: base.Add(time.Hour), Action: "run", Tool: "aider"},
+		{Timestamp: base.Add(time.Hour), Action: "eval", Tool: "mods", Value: 80},
+	}
+	sessions := []session.Session{{Tool: "aider", StartedAt: base}}
+
+	r := aggregate(base.Add(-time.Hour), logs, entries, sessions)
+
+	if r.Totals.Requests != 3 {
+		t.Errorf("expected 3 requests in window, got %d", r.Totals.Requests)
+	}
+	if len(r.Providers) != 2 || r.Providers[0].Provider != "openai" || r.Providers[0].Errors != 1 {
+		t.Errorf("unexpected provider days: %+v", r.Providers)
+	}
+	if len(r.Compose) != 1 || r.Compose[0].Runs != 2 || r.Compose[0].FailureRate != 0.5 || r.Compose[0].AvgDuration != 3 {
+		t.Errorf("unexpected compose stats: %+v", r.Compose)
+	}
+	if len(r.Speed) != 1 || r.Speed[0].LastTPS != 30 || r.Speed[0].Trend != 50 {
+		t.Errorf("unexpected speed stats: %+v", r.Speed)
+	}
+	if len(r.Launches) != 1 || r.Launches[0].Launches != 2 {
+		t.Errorf("expected 2 aider launches, got %+v", r.Launches)
+	}
+	if len(r.Evals) != 1 || r.Evals[0].AvgScore != 80 {
+		t.Errorf("unexpected eval stats: %+v", r.Evals)
+	}
+}
+
+func TestDigest(t *testing.T) {
+	to := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+	from := to.AddDate(0, 0, -7)
+	day := 24 * time.Hour
+
+	logs := []proxy.RequestLog{
+		{Timestamp: from.Add(day), Provider: "openai", Status: 200, Cost: 3},
+		{Timestamp: from.Add(2 * day), Provider: "anthropic", Status: 200, Cost: 1},
+		{Timestamp: from.Add(3 * day), Provider: "openai", Status: 500, Cost: 1},
+		{Timestamp: from.Add(-day), Provider: "openai", Status: 200, Cost: 2},    // previous week
+		{Timestamp: to.Add(time.Hour), Provider: "openai", Status: 200, Cost: 9}, // after the period
+	}
+	// activity.Read returns newest first
+	entries := []activity.Entry{
+		{Timestamp: from.Add(4 * day), Action: "run", Tool: "codex"},
+		{Timestamp: from.Add(3 * day), Action: "compose.step", Tool: "sh", Status: "failed"},
+		{Timestamp: from.Add(3 * day), Action: "compose", Tool: "review", Details: "1/2 steps ok", Status: "failed"},
+		{Timestamp: from.Add(2 * day), Action: "install", Tool: "llm", Status: "ok"},
+		{Timestamp: from.Add(day), Action: "eval", Tool: "mods", Value: 90},
+		{Timestamp: from.Add(day), Action: "run", Tool: "aider"},
+		{Timestamp: from.Add(-day), Action: "eval", Tool: "mods", Value: 70},
+		{Timestamp: from.Add(-2 * day), Action: "run", Tool: "aider"},
+	}
+
+	d := digest(from, to, logs, entries, nil)
+	if d.Report.Totals.Cost != 5 || d.Previous.Cost != 2 || d.Report.Totals.Launches != 2 {
+		t.Fatalf("unexpected totals %+v, previous %+v", d.Report.Totals, d.Previous)
+	}
+	if p := d.Report.ProviderTotals(); len(p) != 2 || p[0].Provider != "openai" || p[0].Cost != 4 || p[0].Errors != 1 {
+		t.Errorf("unexpected provider totals %+v", p)
+	}
+	if len(d.Evals) != 1 || d.Evals[0].Score != 90 || d.Evals[0].Previous != 70 {
+		t.Errorf("unexpected eval trends %+v", d.Evals)
+	}
+	var texts []string
+	for _, e := range d.Events {
+		texts = append(texts, e.Text)
+	}
+	want := []string{"installed llm", "compose review failed (1/2 steps ok)", "first used codex"}
+	if strings.Join(texts, "|") != strings.Join(want, "|") {
+		t.Errorf("events = %q, want %q", texts, want)
+	}
+
+	md := d.Markdown()
+	for _, s := range []string{"# palm digest: Mar 8 – Mar 14, 2026", "Estimated cost: $5.00 (+150% vs previous period)", "| openai | 2 | 1 |", "| mods | 1 | 90 | 70 (+20) |", "first used codex"} {
+		if !strings.Contains(md, s) {
+			t.Errorf("digest is missing %q:\n%s", s, md)
+		}
+	}
+
+	empty := digest(to, to.AddDate(0, 0, 7), nil, nil, nil)
+	if !empty.Empty() || !strings.Contains(empty.Markdown(), "Nothing was recorded") {
+		t.Errorf("an empty period should say so:\n%s", empty.Markdown())
+	}
+}
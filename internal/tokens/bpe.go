@@ -0,0 +1,274 @@
+package tokens
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/msalah0e/palm/internal/cache"
+)
+
+// Tokenizer counts tokens in text.
+type Tokenizer interface {
+	Name() string
+	Count(text string) int
+}
+
+// Encoding is a tiktoken-compatible byte-level BPE tokenizer.
+type Encoding struct {
+	name  string
+	ranks map[string]int
+	pat   *regexp.Regexp
+}
+
+// Pre-tokenizer patterns from tiktoken. Go's regexp has no lookahead, so the
+// trailing `\s+(?!\S)` alternative is emulated in splitPieces.
+var (
+	cl100kPattern = regexp.MustCompile(`(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+`)
+	o200kPattern  = regexp.MustCompile(`[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n/]*|\s*[\r\n]+|\s+`)
+)
+
+// Encodings lists the supported encodings and where their vocabularies live.
+var Encodings = map[string]string{
+	"cl100k_base": "https://openaipublic.blob.core.windows.net/encodings/cl100k_base.tiktoken",
+	"o200k_base":  "https://openaipublic.blob.core.windows.net/encodings/o200k_base.tiktoken",
+}
+
+// ErrEncodingMissing means the vocabulary has not been downloaded yet.
+var ErrEncodingMissing = errors.New("encoding not downloaded")
+
+// EncodingPath is where a downloaded vocabulary is cached.
+func EncodingPath(name string) string {
+	return filepath.Join(cache.Dir(), "tokenizers", name+".tiktoken")
+}
+
+// LoadEncoding reads a cached vocabulary. It returns ErrEncodingMissing when
+// the file has not been fetched.
+func LoadEncoding(name string) (*Encoding, error) {
+	if _, ok := Encodings[name]; !ok {
+		return nil, fmt.Errorf("unknown encoding %q", name)
+	}
+	f, err := os.Open(EncodingPath(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%s: %w", name, ErrEncodingMissing)
+		}
+		return nil, err
+	}
+	defer f.Close()
+	return ParseEncoding(name, f)
+}
+
+// ParseEncoding reads a .tiktoken vocabulary: one "base64-token rank" per line.
+func ParseEncoding(name string, r io.Reader) (*Encoding, error) {
+	pat := cl100kPattern
+	if name == "o200k_base" {
+		pat = o200kPattern
+	}
+	enc := &Encoding{name: name, ranks: make(map[string]int), pat: pat}
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		token, rank, ok := strings.Cut(strings.TrimSpace(sc.Text()), " ")
+		if !ok {
+			continue
+		}
+		b, err := base64.StdEncoding.DecodeString(token)
+		if err != nil {
+			return nil, fmt.Errorf("%s: bad token %q: %w", name, token, err)
+		}
+		n, err := strconv.Atoi(rank)
+		if err != nil {
+			return nil, fmt.Errorf("%s: bad rank %q: %w", name, rank, err)
+		}
+		enc.ranks[string(b)] = n
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(enc.ranks) == 0 {
+		return nil, fmt.Errorf("%s: empty vocabulary", name)
+	}
+	return enc, nil
+}
+
+// FetchEncoding downloads a vocabulary into the cache.
+func FetchEncoding(name string) error {
+	url, ok := Encodings[name]
+	if !ok {
+		return fmt.Errorf("unknown encoding %q", name)
+	}
+	client := &http.Client{Timeout: 2 * time.Minute}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download %s: %s", name, resp.Status)
+	}
+
+	path := EncodingPath(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	// Validate before replacing a working copy
+	if _, err := loadFile(name, tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func loadFile(name, path string) (*Encoding, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseEncoding(name, f)
+}
+
+// Name returns the encoding name, e.g. "cl100k_base".
+func (e *Encoding) Name() string { return e.name }
+
+// Count returns the exact number of tokens the encoding produces for text.
+func (e *Encoding) Count(text string) int {
+	n := 0
+	for _, piece := range splitPieces(e.pat, text) {
+		if _, ok := e.ranks[piece]; ok {
+			n++
+			continue
+		}
+		n += e.mergeCount(piece)
+	}
+	return n
+}
+
+// mergeCount runs byte-pair merges on piece, always merging the adjacent
+// pair with the lowest rank, and returns how many tokens remain.
+func (e *Encoding) mergeCount(piece string) int {
+	// bounds[i] is the start of the i-th part; the last entry is len(piece)
+	bounds := make([]int, len(piece)+1)
+	for i := range bounds {
+		bounds[i] = i
+	}
+	for len(bounds) > 2 {
+		best, bestRank := -1, int(^uint(0)>>1)
+		for i := 0; i+2 < len(bounds); i++ {
+			if r, ok := e.ranks[piece[bounds[i]:bounds[i+2]]]; ok && r < bestRank {
+				best, bestRank = i, r
+			}
+		}
+		if best < 0 {
+			break
+		}
+		bounds = append(bounds[:best+1], bounds[best+2:]...)
+	}
+	return len(bounds) - 1
+}
+
+// splitPieces applies the pre-tokenizer. A whitespace run followed by a
+// non-space character gives up its last character to the next piece, which
+// is what tiktoken's `\s+(?!\S)` lookahead does.
+func splitPieces(pat *regexp.Regexp, text string) []string {
+	var pieces []string
+	for pos := 0; pos < len(text); {
+		loc := pat.FindStringIndex(text[pos:])
+		if loc == nil {
+			pieces = append(pieces, text[pos:])
+			break
+		}
+		start, end := pos+loc[0], pos+loc[1]
+		if start > pos {
+			pieces = append(pieces, text[pos:start])
+		}
+		m := text[start:end]
+		if end < len(text) && isSpaceRun(m) && !strings.ContainsAny(m[len(m)-1:], "\r\n") {
+			next, _ := utf8.DecodeRuneInString(text[end:])
+			_, last := utf8.DecodeLastRuneInString(m)
+			if !unicode.IsSpace(next) && len(m) > last {
+				end -= last
+				m = text[start:end]
+			}
+		}
+		pieces = append(pieces, m)
+		pos = end
+	}
+	return pieces
+}
+
+func isSpaceRun(s string) bool {
+	for _, r := range s {
+		if !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// estimator approximates BPE without a vocabulary: it uses the cl100k
+// pre-tokenizer, counting short pieces as one token and longer ones at
+// about six bytes per token, which tracks cl100k on English and code.
+type estimator struct{}
+
+// Estimator is the fallback tokenizer when no vocabulary is available.
+var Estimator Tokenizer = estimator{}
+
+func (estimator) Name() string { return "estimate" }
+
+func (estimator) Count(text string) int {
+	n := 0
+	for _, piece := range splitPieces(cl100kPattern, text) {
+		n += max(1, (len(piece)+2)/6)
+	}
+	return n
+}
+
+// EncodingForModel returns the encoding a model uses and whether it is the
+// model's real tokenizer. Models without a public tokenizer are measured with
+// cl100k_base, which is usually within 10-20% for English text and code.
+func EncodingForModel(provider, id string) (string, bool) {
+	if provider == "openai" {
+		if strings.HasPrefix(id, "gpt-4o") || strings.HasPrefix(id, "gpt-4.1") || strings.HasPrefix(id, "o") {
+			return "o200k_base", true
+		}
+		return "cl100k_base", true
+	}
+	return "cl100k_base", false
+}
+
+// LoadTokenizer returns the named encoding, falling back to Estimator when
+// its vocabulary has not been downloaded.
+func LoadTokenizer(name string) Tokenizer {
+	enc, err := LoadEncoding(name)
+	if err != nil {
+		return Estimator
+	}
+	return enc
+}
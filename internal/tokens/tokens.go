@@ -1,8 +1,11 @@
 package tokens
 
 import (
+	"bytes"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -33,18 +36,19 @@ var ModelContextWindows = map[string]int{
 
 // FileResult holds token count info for a single file.
 type FileResult struct {
-	Path   string
-	Tokens int
-	Lines  int
-	Bytes  int
+	Path   string `json:"path"`
+	Tokens int    `json:"tokens"`
+	Lines  int    `json:"lines"`
+	Bytes  int    `json:"bytes"`
 }
 
 // ScanResult holds results for a directory scan.
 type ScanResult struct {
-	Files      []FileResult
-	Total      int
-	TotalBytes int
-	TotalLines int
+	Tokenizer  string       `json:"tokenizer"`
+	Files      []FileResult `json:"files"`
+	Total      int          `json:"total"`
+	TotalBytes int          `json:"total_bytes"`
+	TotalLines int          `json:"total_lines"`
 }
 
 // EstimateTokens estimates token count from byte length.
@@ -53,8 +57,13 @@ func EstimateTokens(content []byte) int {
 	return (len(content) + 3) / 4
 }
 
-// CountFile counts tokens for a single file.
+// CountFile counts tokens for a single file with the default tokenizer.
 func CountFile(path string) (FileResult, error) {
+	return CountFileWith(path, DefaultTokenizer())
+}
+
+// CountFileWith counts tokens for a single file with t.
+func CountFileWith(path string, t Tokenizer) (FileResult, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return FileResult{}, err
@@ -67,12 +76,17 @@ func CountFile(path string) (FileResult, error) {
 	}
 	return FileResult{
 		Path:   path,
-		Tokens: EstimateTokens(data),
+		Tokens: t.Count(string(data)),
 		Lines:  lines,
 		Bytes:  len(data),
 	}, nil
 }
 
+// DefaultTokenizer is cl100k_base when downloaded, otherwise the estimator.
+func DefaultTokenizer() Tokenizer {
+	return LoadTokenizer("cl100k_base")
+}
+
 // defaultIgnore lists directories to skip.
 var defaultIgnore = map[string]bool{
 	".git": true, "node_modules": true, "__pycache__": true,
@@ -94,16 +108,109 @@ var codeExtensions = map[string]bool{
 	".vue": true, ".svelte": true, ".astro": true,
 }
 
-// ScanDir counts tokens for all code files in a directory.
+// ScanDir counts tokens for all code files in a directory. Paths in the
+// result are relative to root.
 func ScanDir(root string) (*ScanResult, error) {
-	result := &ScanResult{}
+	result, err := Scan([]string{root}, DefaultTokenizer())
+	if err != nil {
+		return result, err
+	}
+	for i := range result.Files {
+		if rel, err := filepath.Rel(root, result.Files[i].Path); err == nil {
+			result.Files[i].Path = rel
+		}
+	}
+	return result, nil
+}
+
+// Scan counts tokens with t across files, directories and glob patterns
+// (including "**"). Directories contribute their code files; binary and
+// oversized files are skipped.
+func Scan(targets []string, t Tokenizer) (*ScanResult, error) {
+	result := &ScanResult{Tokenizer: t.Name()}
+	files, err := Expand(targets)
+	if err != nil {
+		return result, err
+	}
+
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil || info.Size() > 1024*1024 {
+			continue
+		}
+		fr, err := CountFileWith(f, t)
+		if err != nil || isBinary(f) {
+			continue
+		}
+		result.Files = append(result.Files, fr)
+		result.Total += fr.Tokens
+		result.TotalBytes += fr.Bytes
+		result.TotalLines += fr.Lines
+	}
 
-	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+	sort.Slice(result.Files, func(i, j int) bool {
+		return result.Files[i].Tokens > result.Files[j].Tokens
+	})
+	return result, nil
+}
+
+func isBinary(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return true
+	}
+	defer f.Close()
+	head := make([]byte, 512)
+	n, _ := f.Read(head)
+	return bytes.IndexByte(head[:n], 0) >= 0
+}
+
+// Expand resolves files, directories and glob patterns into a list of files.
+func Expand(targets []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var files []string
+	add := func(p string) {
+		if !seen[p] {
+			seen[p] = true
+			files = append(files, p)
+		}
+	}
+
+	for _, t := range targets {
+		paths := []string{t}
+		if hasMeta(t) {
+			matches, err := Glob(t)
+			if err != nil {
+				return nil, err
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("%s: no matching files", t)
+			}
+			paths = matches
+		}
+		for _, p := range paths {
+			info, err := os.Stat(p)
+			if err != nil {
+				return nil, err
+			}
+			if info.IsDir() {
+				walkCodeFiles(p, add)
+			} else {
+				add(p)
+			}
+		}
+	}
+	return files, nil
+}
+
+// walkCodeFiles calls add for every code file under root.
+func walkCodeFiles(root string, add func(string)) {
+	_ = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil // skip errors
 		}
 		if info.IsDir() {
-			if defaultIgnore[info.Name()] {
+			if path != root && defaultIgnore[info.Name()] {
 				return filepath.SkipDir
 			}
 			return nil
@@ -114,28 +221,81 @@ func ScanDir(root string) (*ScanResult, error) {
 		if !codeExtensions[ext] && base != "dockerfile" && base != "makefile" && base != "cmakelists.txt" {
 			return nil
 		}
-		// Skip large files (>1MB)
-		if info.Size() > 1024*1024 {
-			return nil
+		add(path)
+		return nil
+	})
+}
+
+func hasMeta(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+// Glob is filepath.Glob with support for "**", which matches any number of
+// directories. Ignored directories such as node_modules are not descended.
+func Glob(pattern string) ([]string, error) {
+	if !strings.Contains(pattern, "**") {
+		return filepath.Glob(pattern)
+	}
+	parts := strings.Split(filepath.ToSlash(pattern), "/")
+	i := 0
+	for i < len(parts)-1 && !hasMeta(parts[i]) {
+		i++
+	}
+	base := strings.Join(parts[:i], "/")
+	switch {
+	case i == 0:
+		base = "."
+	case base == "":
+		base = "/"
+	}
+	rest := parts[i:]
+	for _, p := range rest {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, err
 		}
+	}
 
-		fr, err := CountFile(path)
+	var matches []string
+	err := filepath.WalkDir(base, func(p string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return nil
 		}
-		fr.Path, _ = filepath.Rel(root, path)
-		result.Files = append(result.Files, fr)
-		result.Total += fr.Tokens
-		result.TotalBytes += fr.Bytes
-		result.TotalLines += fr.Lines
+		if d.IsDir() {
+			if p != base && defaultIgnore[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(base, p)
+		if err == nil && matchParts(rest, strings.Split(filepath.ToSlash(rel), "/")) {
+			matches = append(matches, p)
+		}
 		return nil
 	})
+	return matches, err
+}
 
-	sort.Slice(result.Files, func(i, j int) bool {
-		return result.Files[i].Tokens > result.Files[j].Tokens
-	})
-
-	return result, err
+// matchParts matches path segments against pattern segments, where "**"
+// matches zero or more segments.
+func matchParts(pat, name []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for k := 0; k <= len(name); k++ {
+				if matchParts(pat[1:], name[k:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], name[0]); !ok {
+			return false
+		}
+		pat, name = pat[1:], name[1:]
+	}
+	return len(name) == 0
 }
 
 // ContextBudget shows how a token count fits within model context windows.
@@ -166,6 +326,35 @@ func Budget(totalTokens int) []ContextBudget {
 	return budgets
 }
 
+// ModelFit is how a token count fits one model's context window.
+type ModelFit struct {
+	Model     string  `json:"model"`
+	Provider  string  `json:"provider"`
+	Context   int     `json:"context"`
+	Tokens    int     `json:"tokens"`
+	Percent   float64 `json:"percent"`
+	Fits      bool    `json:"fits"`
+	Tokenizer string  `json:"tokenizer"`
+	Exact     bool    `json:"exact"` // counted with the model's own tokenizer
+}
+
+// ParseCount parses token counts such as "32k", "1.5M" or "8000".
+func ParseCount(s string) (int, error) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	mult := 1.0
+	switch {
+	case strings.HasSuffix(s, "k"):
+		mult, s = 1000, strings.TrimSuffix(s, "k")
+	case strings.HasSuffix(s, "m"):
+		mult, s = 1000000, strings.TrimSuffix(s, "m")
+	}
+	var n float64
+	if _, err := fmt.Sscanf(s, "%g", &n); err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid token count %q", s)
+	}
+	return int(n * mult), nil
+}
+
 // FormatTokens returns a human-readable token count.
 func FormatTokens(n int) string {
 	if n >= 1000000 {
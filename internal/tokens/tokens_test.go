@@ -0,0 +1,138 @@
+package tokens
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitPiecesMatchesTiktoken(t *testing.T) {
+	got := splitPieces(cl100kPattern, "hello world  foo's 12345\n\nbar")
+	want := []string{"hello", " world", " ", " foo", "'s", " ", "123", "45", "\n\n", "bar"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("splitPieces = %q, want %q", got, want)
+	}
+}
+
+// testVocab builds a vocabulary with every byte plus the given merges, in order.
+func testVocab(merges ...string) string {
+	var b strings.Builder
+	rank := 0
+	for i := 0; i < 256; i++ {
+		fmt.Fprintf(&b, "%s %d\n", base64.StdEncoding.EncodeToString([]byte{byte(i)}), rank)
+		rank++
+	}
+	for _, m := range merges {
+		fmt.Fprintf(&b, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(m)), rank)
+		rank++
+	}
+	return b.String()
+}
+
+func TestEncodingCount(t *testing.T) {
+	enc, err := ParseEncoding("cl100k_base", strings.NewReader(testVocab("lo", "he", "hel", "hello", " w")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// "hello" is a whole token; " world" merges " w" and leaves o, r, l, d
+	if got := enc.Count("hello world"); got != 6 {
+		t.Errorf("Count = %d, want 6", got)
+	}
+	if got := enc.Count(""); got != 0 {
+		t.Errorf("Count of empty text = %d", got)
+	}
+}
+
+func TestParseEncodingRejectsGarbage(t *testing.T) {
+	if _, err := ParseEncoding("cl100k_base", strings.NewReader("!!! 1\n")); err == nil {
+		t.Error("expected error for invalid base64")
+	}
+	if _, err := ParseEncoding("cl100k_base", strings.NewReader("")); err == nil {
+		t.Error("expected error for empty vocabulary")
+	}
+}
+
+func TestLoadTokenizerFallsBackToEstimate(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	if got := LoadTokenizer("cl100k_base").Name(); got != "estimate" {
+		t.Errorf("expected estimate without a vocabulary, got %s", got)
+	}
+}
+
+func TestGlobDoublestar(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"a.go", "pkg/b.go", "pkg/deep/c.go", "pkg/d.txt", "node_modules/e.go"} {
+		p := filepath.Join(dir, f)
+		_ = os.MkdirAll(filepath.Dir(p), 0o755)
+		_ = os.WriteFile(p, []byte("package x\n"), 0o644)
+	}
+
+	matches, err := Glob(filepath.Join(dir, "**", "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rel []string
+	for _, m := range matches {
+		r, _ := filepath.Rel(dir, m)
+		rel = append(rel, filepath.ToSlash(r))
+	}
+	if got := strings.Join(rel, ","); got != "a.go,pkg/b.go,pkg/deep/c.go" {
+		t.Errorf("Glob = %s", got)
+	}
+}
+
+func TestScanMixedTargets(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644)
+	_ = os.WriteFile(filepath.Join(dir, "notes"), []byte("plain text without extension"), 0o644)
+	_ = os.WriteFile(filepath.Join(dir, "blob.bin"), []byte{1, 0, 2}, 0o644)
+
+	// The directory contributes main.go; explicit files are always counted
+	// unless binary; duplicates are counted once.
+	r, err := Scan([]string{dir, filepath.Join(dir, "notes"), filepath.Join(dir, "main.go"), filepath.Join(dir, "blob.bin")}, Estimator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Files) != 2 || r.Tokenizer != "estimate" || r.Total == 0 {
+		t.Errorf("unexpected scan result %+v", r)
+	}
+
+	if _, err := Scan([]string{filepath.Join(dir, "*.rs")}, Estimator); err == nil {
+		t.Error("expected error for a glob with no matches")
+	}
+}
+
+func TestEncodingForModel(t *testing.T) {
+	cases := []struct {
+		provider, id, enc string
+		exact             bool
+	}{
+		{"openai", "gpt-4o", "o200k_base", true},
+		{"openai", "o3", "o200k_base", true},
+		{"openai", "gpt-4", "cl100k_base", true},
+		{"anthropic", "claude-opus-4-6", "cl100k_base", false},
+	}
+	for _, c := range cases {
+		enc, exact := EncodingForModel(c.provider, c.id)
+		if enc != c.enc || exact != c.exact {
+			t.Errorf("EncodingForModel(%s, %s) = %s, %v", c.provider, c.id, enc, exact)
+		}
+	}
+}
+
+func TestParseCount(t *testing.T) {
+	cases := map[string]int{"32k": 32000, "1.5M": 1500000, "8000": 8000, " 4K ": 4000}
+	for in, want := range cases {
+		if got, err := ParseCount(in); err != nil || got != want {
+			t.Errorf("ParseCount(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "k", "-5", "lots"} {
+		if _, err := ParseCount(bad); err == nil {
+			t.Errorf("ParseCount(%q) should fail", bad)
+		}
+	}
+}
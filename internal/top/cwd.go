@@ -0,0 +1,34 @@
+package top
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// ProcessCwd returns the working directory of process pid, or "" when it
+// can't be read, e.g. for another user's process.
+func ProcessCwd(pid int) string {
+	if runtime.GOOS == "linux" {
+		dir, err := os.Readlink(fmt.Sprintf("/proc/%d/cwd", pid))
+		if err != nil {
+			return ""
+		}
+		return dir
+	}
+
+	// lsof -Fn prints the cwd as a line starting with "n"
+	out, err := exec.Command("lsof", "-a", "-p", strconv.Itoa(pid), "-d", "cwd", "-Fn").Output()
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if dir, ok := strings.CutPrefix(line, "n"); ok {
+			return dir
+		}
+	}
+	return ""
+}
@@ -0,0 +1,222 @@
+package top
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/msalah0e/palm/internal/gpu"
+)
+
+// Snapshot is one scan of AI tool processes and system usage, for scripts
+// and monitoring agents.
+type Snapshot struct {
+	Time      time.Time      `json:"time"`
+	Host      string         `json:"host"`
+	System    SystemSnapshot `json:"system"`
+	Processes []ProcessInfo  `json:"processes"` // busiest first
+}
+
+// SystemSnapshot is SystemStats as Snapshot reports it.
+type SystemSnapshot struct {
+	CPUPercent float64       `json:"cpu_percent"`
+	CPUCores   int           `json:"cpu_cores"`
+	MemTotalMB uint64        `json:"mem_total_mb"`
+	MemUsedMB  uint64        `json:"mem_used_mb"`
+	MemPercent float64       `json:"mem_percent"`
+	GPUs       []GPUSnapshot `json:"gpus"`
+}
+
+// GPUSnapshot is a GPU as Snapshot reports it. Usage is only present when
+// the platform reports it.
+type GPUSnapshot struct {
+	Index          int    `json:"index"`
+	Vendor         string `json:"vendor"`
+	Model          string `json:"model"`
+	VRAMTotalMB    int    `json:"vram_total_mb"`
+	VRAMUsedMB     *int   `json:"vram_used_mb,omitempty"`
+	UtilizationPct *int   `json:"utilization_pct,omitempty"`
+}
+
+// Collect scans once. GPUs are detected afresh so their usage is current.
+func Collect(known map[string]string) Snapshot {
+	procs := scanProcesses(known)
+	attachVRAM(procs, gpu.ProcessVRAM())
+	return snapshotOf(procs, getSystemStats(gpu.Detect()))
+}
+
+// attachVRAM sets each process's GPU memory from vram, by PID.
+func attachVRAM(procs []ProcessInfo, vram map[int]int) {
+	for i := range procs {
+		procs[i].VRAMMB = vram[procs[i].PID]
+	}
+}
+
+func snapshotOf(procs []ProcessInfo, stats SystemStats) Snapshot {
+	host, _ := os.Hostname()
+
+	s := Snapshot{
+		Time: time.Now(),
+		Host: host,
+		System: SystemSnapshot{
+			CPUPercent: stats.CPUPercent,
+			CPUCores:   stats.CPUCores,
+			MemTotalMB: stats.MemTotal,
+			MemUsedMB:  stats.MemUsed,
+			MemPercent: stats.MemPercent,
+			GPUs:       []GPUSnapshot{},
+		},
+		Processes: procs,
+	}
+	if s.Processes == nil {
+		s.Processes = []ProcessInfo{}
+	}
+	for _, g := range stats.GPUs {
+		gs := GPUSnapshot{Index: g.Index, Vendor: g.Vendor, Model: g.Model, VRAMTotalMB: g.VRAMTotalMB}
+		if g.Sampled {
+			gs.VRAMUsedMB, gs.UtilizationPct = &g.VRAMUsedMB, &g.UtilizationPct
+		}
+		s.System.GPUs = append(s.System.GPUs, gs)
+	}
+	return s
+}
+
+// WriteMetrics writes s in the Prometheus text exposition format. Process
+// figures are summed per tool, so PIDs coming and going don't create new
+// series.
+func WriteMetrics(w io.Writer, s Snapshot) {
+	gauge := func(name, help string) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	}
+
+	gauge("palm_system_cpu_percent", "System CPU use in percent.")
+	fmt.Fprintf(w, "palm_system_cpu_percent %g\n", s.System.CPUPercent)
+	gauge("palm_system_cpu_cores", "Logical CPU cores.")
+	fmt.Fprintf(w, "palm_system_cpu_cores %d\n", s.System.CPUCores)
+	gauge("palm_system_memory_total_bytes", "Total system memory.")
+	fmt.Fprintf(w, "palm_system_memory_total_bytes %d\n", s.System.MemTotalMB<<20)
+	gauge("palm_system_memory_used_bytes", "System memory in use.")
+	fmt.Fprintf(w, "palm_system_memory_used_bytes %d\n", s.System.MemUsedMB<<20)
+
+	if len(s.System.GPUs) > 0 {
+		gauge("palm_gpu_vram_total_bytes", "GPU memory.")
+		for _, g := range s.System.GPUs {
+			fmt.Fprintf(w, "palm_gpu_vram_total_bytes{%s} %d\n", gpuLabels(g), int64(g.VRAMTotalMB)<<20)
+		}
+		gauge("palm_gpu_vram_used_bytes", "GPU memory in use, where the platform reports it.")
+		for _, g := range s.System.GPUs {
+			if g.VRAMUsedMB != nil {
+				fmt.Fprintf(w, "palm_gpu_vram_used_bytes{%s} %d\n", gpuLabels(g), int64(*g.VRAMUsedMB)<<20)
+			}
+		}
+		gauge("palm_gpu_utilization_percent", "GPU utilization in percent, where the platform reports it.")
+		for _, g := range s.System.GPUs {
+			if g.UtilizationPct != nil {
+				fmt.Fprintf(w, "palm_gpu_utilization_percent{%s} %d\n", gpuLabels(g), *g.UtilizationPct)
+			}
+		}
+	}
+
+	type toolTotals struct {
+		procs      int
+		cpu, rssMB float64
+	}
+	byTool := make(map[string]*toolTotals)
+	for _, p := range s.Processes {
+		t := byTool[p.Name]
+		if t == nil {
+			t = &toolTotals{}
+			byTool[p.Name] = t
+		}
+		t.procs++
+		t.cpu += p.CPU
+		t.rssMB += p.MemMB
+	}
+	tools := make([]string, 0, len(byTool))
+	for name := range byTool {
+		tools = append(tools, name)
+	}
+	sort.Strings(tools)
+
+	gauge("palm_ai_processes", "Running AI tool processes.")
+	fmt.Fprintf(w, "palm_ai_processes %d\n", len(s.Processes))
+	gauge("palm_tool_processes", "Running processes per AI tool.")
+	for _, name := range tools {
+		fmt.Fprintf(w, "palm_tool_processes{tool=%q} %d\n", labelValue(name), byTool[name].procs)
+	}
+	gauge("palm_tool_cpu_percent", "CPU use per AI tool in percent of one core.")
+	for _, name := range tools {
+		fmt.Fprintf(w, "palm_tool_cpu_percent{tool=%q} %g\n", labelValue(name), byTool[name].cpu)
+	}
+	gauge("palm_tool_memory_rss_bytes", "Resident memory per AI tool.")
+	for _, name := range tools {
+		fmt.Fprintf(w, "palm_tool_memory_rss_bytes{tool=%q} %d\n", labelValue(name), int64(byTool[name].rssMB*(1<<20)))
+	}
+}
+
+func gpuLabels(g GPUSnapshot) string {
+	return fmt.Sprintf("gpu=\"%d\",vendor=%q,model=%q", g.Index, labelValue(g.Vendor), labelValue(g.Model))
+}
+
+// labelValue replaces control characters, leaving %q to escape backslashes
+// and quotes the way Prometheus label values expect.
+func labelValue(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// Serve exposes snapshots over HTTP on addr until ctx is done: JSON at /
+// and /json, Prometheus metrics at /metrics. A scan is reused for maxAge
+// so frequent scrapers don't run ps on every request.
+func Serve(ctx context.Context, addr string, known map[string]string, maxAge time.Duration) error {
+	var (
+		mu   sync.Mutex
+		last Snapshot
+	)
+	snapshot := func() Snapshot {
+		mu.Lock()
+		defer mu.Unlock()
+		if time.Since(last.Time) >= maxAge {
+			last = Collect(known)
+		}
+		return last
+	}
+
+	mux := http.NewServeMux()
+	serveJSON := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(snapshot())
+	}
+	mux.HandleFunc("GET /{$}", serveJSON)
+	mux.HandleFunc("GET /json", serveJSON)
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		WriteMetrics(w, snapshot())
+	})
+
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdown)
+	}()
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package top
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	used, util := 2048, 55
+	s := Snapshot{
+		System: SystemSnapshot{
+			CPUPercent: 12.5, CPUCores: 8, MemTotalMB: 16384, MemUsedMB: 4096,
+			GPUs: []GPUSnapshot{
+				{Index: 0, Vendor: "NVIDIA", Model: "RTX 4090", VRAMTotalMB: 24576, VRAMUsedMB: &used, UtilizationPct: &util},
+				{Index: 1, Vendor: "Intel", Model: `UHD "770"`, VRAMTotalMB: 0},
+			},
+		},
+		Processes: []ProcessInfo{
+			{PID: 10, Name: "Ollama", CPU: 150, MemMB: 4096},
+			{PID: 11, Name: "Ollama", CPU: 50, MemMB: 1024},
+			{PID: 12, Name: "Claude Code", CPU: 1.5, MemMB: 300},
+		},
+	}
+
+	var b strings.Builder
+	WriteMetrics(&b, s)
+	out := b.String()
+	for _, want := range []string{
+		"palm_system_memory_used_bytes 4294967296\n",
+		`palm_gpu_utilization_percent{gpu="0",vendor="NVIDIA",model="RTX 4090"} 55` + "\n",
+		`palm_gpu_vram_total_bytes{gpu="1",vendor="Intel",model="UHD \"770\""} 0` + "\n",
+		"palm_ai_processes 3\n",
+		`palm_tool_processes{tool="Ollama"} 2` + "\n",
+		`palm_tool_cpu_percent{tool="Ollama"} 200` + "\n",
+		`palm_tool_memory_rss_bytes{tool="Claude Code"} 314572800` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics lack %q", want)
+		}
+	}
+	// GPUs that don't report usage get no usage series
+	if strings.Contains(out, `palm_gpu_utilization_percent{gpu="1"`) {
+		t.Error("unsampled GPU has a utilization series")
+	}
+}
@@ -0,0 +1,210 @@
+package top
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// DefaultHistorySize is how many samples the history keeps: a day at a
+// five-second interval.
+const DefaultHistorySize = 17280
+
+// Sample is one recorded scan, trimmed to what reports need.
+type Sample struct {
+	Time       time.Time    `json:"time"`
+	CPUPercent float64      `json:"cpu_percent"`
+	MemUsedMB  uint64       `json:"mem_used_mb"`
+	MemPercent float64      `json:"mem_percent"`
+	GPUs       []GPUSample  `json:"gpus,omitempty"`
+	Tools      []ToolSample `json:"tools,omitempty"`
+}
+
+// GPUSample is a GPU's usage in a Sample. Only GPUs that report usage are
+// recorded.
+type GPUSample struct {
+	Index          int `json:"index"`
+	UtilizationPct int `json:"utilization_pct"`
+	VRAMUsedMB     int `json:"vram_used_mb"`
+}
+
+// ToolSample sums a tool's processes in a Sample.
+type ToolSample struct {
+	Tool   string  `json:"tool"`
+	Procs  int     `json:"procs"`
+	CPU    float64 `json:"cpu_percent"`
+	RSSMB  float64 `json:"rss_mb"`
+	VRAMMB int     `json:"vram_mb,omitempty"`
+}
+
+// SampleOf reduces a snapshot to a Sample, summing processes per tool.
+func SampleOf(s Snapshot) Sample {
+	sample := Sample{
+		Time:       s.Time,
+		CPUPercent: s.System.CPUPercent,
+		MemUsedMB:  s.System.MemUsedMB,
+		MemPercent: s.System.MemPercent,
+	}
+	for _, g := range s.System.GPUs {
+		if g.UtilizationPct != nil && g.VRAMUsedMB != nil {
+			sample.GPUs = append(sample.GPUs, GPUSample{Index: g.Index, UtilizationPct: *g.UtilizationPct, VRAMUsedMB: *g.VRAMUsedMB})
+		}
+	}
+
+	byTool := make(map[string]*ToolSample)
+	for _, p := range s.Processes {
+		t := byTool[p.Name]
+		if t == nil {
+			t = &ToolSample{Tool: p.Name}
+			byTool[p.Name] = t
+		}
+		t.Procs++
+		t.CPU += p.CPU
+		t.RSSMB += p.MemMB
+		t.VRAMMB += p.VRAMMB
+	}
+	for _, t := range byTool {
+		sample.Tools = append(sample.Tools, *t)
+	}
+	sort.Slice(sample.Tools, func(i, j int) bool { return sample.Tools[i].Tool < sample.Tools[j].Tool })
+	return sample
+}
+
+// HistoryPath returns where palm top --record keeps its samples.
+func HistoryPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "palm", "top-history.jsonl")
+}
+
+// Recorder appends samples to a history file that works as a ring buffer:
+// once it holds more than its size, the oldest samples are dropped.
+type Recorder struct {
+	path string
+	size int
+	n    int // samples in the file
+}
+
+// NewRecorder opens the history at path, keeping at most size samples.
+func NewRecorder(path string, size int) (*Recorder, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("history size must be at least 1")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	r := &Recorder{path: path, size: size}
+	f, err := os.Open(path)
+	if err == nil {
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			r.n++
+		}
+		f.Close()
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Add appends s. The file is trimmed back to size only after it overshoots
+// by a tenth, so it isn't rewritten on every sample.
+func (r *Recorder) Add(s Sample) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	r.n++
+	if r.n > r.size+r.size/10 {
+		return r.trim()
+	}
+	return nil
+}
+
+// trim rewrites the history with only its newest size samples.
+func (r *Recorder) trim() error {
+	f, err := os.Open(r.path)
+	if err != nil {
+		return err
+	}
+	var lines [][]byte
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		lines = append(lines, append([]byte(nil), sc.Bytes()...))
+	}
+	f.Close()
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	if len(lines) > r.size {
+		lines = lines[len(lines)-r.size:]
+	}
+
+	tmp := r.path + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(out)
+	for _, l := range lines {
+		w.Write(l)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, r.path); err != nil {
+		return err
+	}
+	r.n = len(lines)
+	return nil
+}
+
+// LoadHistory reads the samples at path taken at or after since, oldest
+// first. A missing history holds no samples.
+func LoadHistory(path string, since time.Time) ([]Sample, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var samples []Sample
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var s Sample
+		if json.Unmarshal(sc.Bytes(), &s) != nil || s.Time.Before(since) {
+			continue
+		}
+		samples = append(samples, s)
+	}
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].Time.Before(samples[j].Time) })
+	return samples, sc.Err()
+}
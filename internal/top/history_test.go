@@ -0,0 +1,115 @@
+package top
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSampleOf(t *testing.T) {
+	used, util := 2048, 55
+	s := SampleOf(Snapshot{
+		Time: time.Unix(1000, 0),
+		System: SystemSnapshot{
+			CPUPercent: 40, MemUsedMB: 8192, MemPercent: 50,
+			GPUs: []GPUSnapshot{
+				{Index: 0, VRAMUsedMB: &used, UtilizationPct: &util},
+				{Index: 1}, // reports no usage
+			},
+		},
+		Processes: []ProcessInfo{
+			{PID: 10, Name: "Ollama", CPU: 150, MemMB: 4096, VRAMMB: 1500},
+			{PID: 11, Name: "Ollama", CPU: 50, MemMB: 1024},
+			{PID: 12, Name: "Aider", CPU: 2, MemMB: 300},
+		},
+	})
+	if len(s.GPUs) != 1 || s.GPUs[0].UtilizationPct != 55 {
+		t.Errorf("only sampled GPUs should be recorded: %+v", s.GPUs)
+	}
+	if len(s.Tools) != 2 || s.Tools[0].Tool != "Aider" {
+		t.Fatalf("expected tools summed and sorted by name: %+v", s.Tools)
+	}
+	if o := s.Tools[1]; o.Procs != 2 || o.CPU != 200 || o.RSSMB != 5120 || o.VRAMMB != 1500 {
+		t.Errorf("unexpected Ollama totals: %+v", o)
+	}
+}
+
+func TestRecorderRingBuffer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "top-history.jsonl")
+	r, err := NewRecorder(path, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Unix(1000, 0)
+	for i := 0; i < 25; i++ {
+		if err := r.Add(Sample{Time: start.Add(time.Duration(i) * time.Second), CPUPercent: float64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, err := LoadHistory(path, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) > 11 || all[len(all)-1].CPUPercent != 24 {
+		t.Fatalf("history should keep about the newest 10 samples, got %d ending at %v", len(all), all[len(all)-1].CPUPercent)
+	}
+
+	// A reopened recorder picks up the count and keeps trimming
+	r, err = NewRecorder(path, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 25; i < 30; i++ {
+		if err := r.Add(Sample{Time: start.Add(time.Duration(i) * time.Second)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if all, _ = LoadHistory(path, time.Time{}); len(all) > 11 {
+		t.Errorf("reopened history grew past its size: %d samples", len(all))
+	}
+
+	recent, err := LoadHistory(path, start.Add(28*time.Second))
+	if err != nil || len(recent) != 2 {
+		t.Errorf("expected 2 samples in the window, got %d (%v)", len(recent), err)
+	}
+	if missing, err := LoadHistory(filepath.Join(t.TempDir(), "none.jsonl"), time.Time{}); err != nil || missing != nil {
+		t.Errorf("a missing history should be empty, got %v, %v", missing, err)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	start := time.Unix(1000, 0)
+	samples := []Sample{
+		{Time: start, CPUPercent: 20, MemUsedMB: 4000,
+			GPUs:  []GPUSample{{Index: 0, UtilizationPct: 10, VRAMUsedMB: 1000}},
+			Tools: []ToolSample{{Tool: "Aider", Procs: 1, CPU: 5, RSSMB: 200}}},
+		{Time: start.Add(time.Minute), CPUPercent: 80, MemUsedMB: 9000,
+			GPUs: []GPUSample{{Index: 0, UtilizationPct: 90, VRAMUsedMB: 7000}},
+			Tools: []ToolSample{
+				{Tool: "Aider", Procs: 1, CPU: 3, RSSMB: 250},
+				{Tool: "Ollama", Procs: 3, CPU: 300, RSSMB: 6000, VRAMMB: 6000},
+			}},
+	}
+	r := Summarize(samples)
+	if r.Samples != 2 || !r.From.Equal(start) || r.CPU.Avg != 50 || r.CPU.Max != 80 || r.MemMB.Max != 9000 {
+		t.Errorf("unexpected system summary: %+v", r)
+	}
+	if len(r.GPUs) != 1 || r.GPUs[0].Utilization.Avg != 50 || r.GPUs[0].VRAMMB.Max != 7000 {
+		t.Errorf("unexpected GPU summary: %+v", r.GPUs)
+	}
+	if len(r.Tools) != 2 || r.Tools[0].Tool != "Ollama" {
+		t.Fatalf("expected the busiest tool first: %+v", r.Tools)
+	}
+	o, a := r.Tools[0], r.Tools[1]
+	if o.ActivePct != 50 || o.MaxProcs != 3 || o.CPU.Avg != 300 || o.VRAMMB.Max != 6000 || !o.PeakAt.Equal(start.Add(time.Minute)) {
+		t.Errorf("unexpected Ollama report: %+v", o)
+	}
+	if a.ActivePct != 100 || a.CPU.Avg != 4 || a.CPU.Max != 5 || a.RSSMB.Max != 250 || !a.PeakAt.Equal(start) {
+		t.Errorf("unexpected Aider report: %+v", a)
+	}
+
+	if empty := Summarize(nil); empty.Samples != 0 || empty.Tools == nil {
+		t.Errorf("an empty report should have no samples and an empty tool list: %+v", empty)
+	}
+}
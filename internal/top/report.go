@@ -0,0 +1,140 @@
+package top
+
+import (
+	"sort"
+	"time"
+)
+
+// Stat is the average and peak of a figure over a report's window.
+type Stat struct {
+	Avg float64 `json:"avg"`
+	Max float64 `json:"max"`
+}
+
+// Report summarizes recorded samples: system load, GPU load and what each
+// AI tool used.
+type Report struct {
+	From    time.Time    `json:"from"`
+	To      time.Time    `json:"to"`
+	Samples int          `json:"samples"`
+	CPU     Stat         `json:"cpu_percent"`
+	MemMB   Stat         `json:"mem_used_mb"`
+	MemPct  Stat         `json:"mem_percent"`
+	GPUs    []GPUReport  `json:"gpus"`
+	Tools   []ToolReport `json:"tools"` // busiest first
+}
+
+// GPUReport is one GPU's load over the window.
+type GPUReport struct {
+	Index       int  `json:"index"`
+	Utilization Stat `json:"utilization_pct"`
+	VRAMMB      Stat `json:"vram_used_mb"`
+}
+
+// ToolReport is one tool's use over the window. Averages cover the samples
+// the tool was running in.
+type ToolReport struct {
+	Tool      string    `json:"tool"`
+	Samples   int       `json:"samples"`
+	ActivePct float64   `json:"active_pct"` // share of the window it ran
+	MaxProcs  int       `json:"max_procs"`
+	CPU       Stat      `json:"cpu_percent"`
+	RSSMB     Stat      `json:"rss_mb"`
+	VRAMMB    Stat      `json:"vram_mb"`
+	PeakAt    time.Time `json:"peak_at"` // when its CPU use peaked
+}
+
+// acc accumulates a Stat.
+type acc struct {
+	sum, max float64
+	n        int
+}
+
+func (a *acc) add(v float64) {
+	if a.n == 0 || v > a.max {
+		a.max = v
+	}
+	a.sum += v
+	a.n++
+}
+
+func (a acc) stat() Stat {
+	if a.n == 0 {
+		return Stat{}
+	}
+	return Stat{Avg: a.sum / float64(a.n), Max: a.max}
+}
+
+// Summarize builds a report from samples, oldest first.
+func Summarize(samples []Sample) Report {
+	r := Report{Samples: len(samples), GPUs: []GPUReport{}, Tools: []ToolReport{}}
+	if len(samples) == 0 {
+		return r
+	}
+	r.From, r.To = samples[0].Time, samples[len(samples)-1].Time
+
+	type gpuAcc struct{ util, vram acc }
+	type toolAcc struct {
+		cpu, rss, vram acc
+		maxProcs       int
+		peakAt         time.Time
+	}
+	var cpu, memMB, memPct acc
+	gpus := make(map[int]*gpuAcc)
+	tools := make(map[string]*toolAcc)
+
+	for _, s := range samples {
+		cpu.add(s.CPUPercent)
+		memMB.add(float64(s.MemUsedMB))
+		memPct.add(s.MemPercent)
+		for _, g := range s.GPUs {
+			a := gpus[g.Index]
+			if a == nil {
+				a = &gpuAcc{}
+				gpus[g.Index] = a
+			}
+			a.util.add(float64(g.UtilizationPct))
+			a.vram.add(float64(g.VRAMUsedMB))
+		}
+		for _, t := range s.Tools {
+			a := tools[t.Tool]
+			if a == nil {
+				a = &toolAcc{}
+				tools[t.Tool] = a
+			}
+			if a.cpu.n == 0 || t.CPU > a.cpu.max {
+				a.peakAt = s.Time
+			}
+			a.cpu.add(t.CPU)
+			a.rss.add(t.RSSMB)
+			a.vram.add(float64(t.VRAMMB))
+			a.maxProcs = max(a.maxProcs, t.Procs)
+		}
+	}
+
+	r.CPU, r.MemMB, r.MemPct = cpu.stat(), memMB.stat(), memPct.stat()
+	for idx, a := range gpus {
+		r.GPUs = append(r.GPUs, GPUReport{Index: idx, Utilization: a.util.stat(), VRAMMB: a.vram.stat()})
+	}
+	sort.Slice(r.GPUs, func(i, j int) bool { return r.GPUs[i].Index < r.GPUs[j].Index })
+
+	for name, a := range tools {
+		r.Tools = append(r.Tools, ToolReport{
+			Tool:      name,
+			Samples:   a.cpu.n,
+			ActivePct: float64(a.cpu.n) / float64(len(samples)) * 100,
+			MaxProcs:  a.maxProcs,
+			CPU:       a.cpu.stat(),
+			RSSMB:     a.rss.stat(),
+			VRAMMB:    a.vram.stat(),
+			PeakAt:    a.peakAt,
+		})
+	}
+	sort.Slice(r.Tools, func(i, j int) bool {
+		if r.Tools[i].CPU.Avg != r.Tools[j].CPU.Avg {
+			return r.Tools[i].CPU.Avg > r.Tools[j].CPU.Avg
+		}
+		return r.Tools[i].Tool < r.Tools[j].Tool
+	})
+	return r
+}
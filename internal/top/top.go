@@ -14,24 +14,26 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/msalah0e/palm/internal/gpu"
+	"github.com/msalah0e/palm/internal/ui"
 )
 
 // ProcessInfo holds information about a running AI tool process.
 type ProcessInfo struct {
-	PID    int
-	Name   string  // matched tool display name
-	Binary string  // actual binary name
-	CPU    float64 // CPU%
-	Mem    float64 // MEM%
-	MemMB  float64 // RSS in MB
-	Cmd    string  // truncated command line
+	PID    int     `json:"pid"`
+	Name   string  `json:"tool"`        // matched tool display name
+	Binary string  `json:"binary"`      // actual binary name
+	CPU    float64 `json:"cpu_percent"` // CPU%
+	Mem    float64 `json:"mem_percent"` // MEM%
+	MemMB  float64 `json:"rss_mb"`      // RSS in MB
+	VRAMMB int     `json:"vram_mb,omitempty"` // GPU memory, where reported
+	Cmd    string  `json:"command"`     // truncated command line
 }
 
 // SystemStats holds system resource usage.
 type SystemStats struct {
 	CPUPercent float64
-	MemTotal   uint64
-	MemUsed    uint64
+	MemTotal   uint64 // MB
+	MemUsed    uint64 // MB
 	MemPercent float64
 	CPUCores   int
 	GPUs       []gpu.Info
@@ -41,14 +43,17 @@ type SystemStats struct {
 type Config struct {
 	RefreshInterval time.Duration
 	KnownBinaries  map[string]string // binary name → display name
+	Recorder       *Recorder         // when set, every refresh is recorded
 }
 
+// The monitor draws in the theme's colors; plain text keeps the terminal's
+// own foreground so it stays readable on light backgrounds
 var (
-	brand  = color.New(color.FgHiGreen, color.Bold)
-	subtle = color.New(color.FgHiBlack)
-	dim    = color.New(color.FgWhite)
-	cyan   = color.New(color.FgCyan)
-	yellow = color.New(color.FgYellow)
+	brand  = ui.Brand
+	subtle = ui.Subtle
+	dim    = color.New()
+	cyan   = ui.Info
+	yellow = ui.Warn
 )
 
 // Run starts the live top monitor loop.
@@ -66,15 +71,31 @@ func Run(cfg Config) error {
 	ticker := time.NewTicker(cfg.RefreshInterval)
 	defer ticker.Stop()
 
+	refresh := func() {
+		procs := scanProcesses(cfg.KnownBinaries)
+		if cfg.Recorder != nil {
+			// The history needs current GPU usage, so detect afresh
+			gpus = gpu.Detect()
+			attachVRAM(procs, gpu.ProcessVRAM())
+		}
+		stats := getSystemStats(gpus)
+		render(procs, stats, cfg)
+		if cfg.Recorder != nil {
+			if err := cfg.Recorder.Add(SampleOf(snapshotOf(procs, stats))); err != nil {
+				yellow.Printf("  Recording failed: %v\n", err)
+			}
+		}
+	}
+
 	// Render immediately, then on each tick
-	render(scanProcesses(cfg.KnownBinaries), getSystemStats(gpus), cfg)
+	refresh()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
 		case <-ticker.C:
-			render(scanProcesses(cfg.KnownBinaries), getSystemStats(gpus), cfg)
+			refresh()
 		}
 	}
 }
@@ -339,8 +360,11 @@ func render(procs []ProcessInfo, stats SystemStats, cfg Config) {
 		if g.Compute != "" {
 			gpuLine += " \u00b7 " + g.Compute
 		}
-		if g.VRAM != "" {
-			gpuLine += " \u00b7 " + g.VRAM
+		if g.VRAM() != "" {
+			gpuLine += " \u00b7 " + g.VRAM()
+		}
+		if g.Sampled {
+			gpuLine += fmt.Sprintf(" \u00b7 %d%% busy \u00b7 %s used", g.UtilizationPct, gpu.FormatMB(g.VRAMUsedMB))
 		}
 		cyan.Println(gpuLine)
 	}
@@ -361,7 +385,7 @@ func render(procs []ProcessInfo, stats SystemStats, cfg Config) {
 		for _, p := range procs {
 			cpuColor := dim
 			if p.CPU > 50 {
-				cpuColor = color.New(color.FgRed)
+				cpuColor = ui.Bad
 			} else if p.CPU > 20 {
 				cpuColor = yellow
 			}
@@ -411,3 +435,13 @@ func truncate(s string, max int) string {
 	}
 	return s[:max-1] + "\u2026"
 }
+
+// Scan returns the running processes of known AI tools, busiest first.
+func Scan(known map[string]string) []ProcessInfo {
+	return scanProcesses(known)
+}
+
+// Stats samples system CPU and memory use; gpus come from gpu.Detect.
+func Stats(gpus []gpu.Info) SystemStats {
+	return getSystemStats(gpus)
+}
@@ -0,0 +1,183 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// BoxStyle picks the border of a Box.
+type BoxStyle int
+
+const (
+	BoxSingle BoxStyle = iota // ┌─┐ for results
+	BoxDouble                 // ╔═╗ for command headers
+)
+
+var boxBorders = map[BoxStyle][6]string{
+	// top-left, top-right, bottom-left, bottom-right, horizontal, vertical
+	BoxSingle: {"┌", "┐", "└", "┘", "─", "│"},
+	BoxDouble: {"╔", "╗", "╚", "╝", "═", "║"},
+}
+
+// Box prints lines inside a border, indented like the rest of palm's output.
+// A title gets its own row above a divider and an empty line is a blank row.
+// The border fits the widest line in terminal columns, so colors and emoji
+// don't push it out of line, and lines too wide for the terminal are cut.
+func Box(style BoxStyle, title string, lines []string) {
+	fmt.Print(RenderBox(style, title, lines, TermWidth()))
+}
+
+// RenderBox draws a Box at most width columns wide.
+func RenderBox(style BoxStyle, title string, lines []string, width int) string {
+	b := boxBorders[style]
+	// Each row is "  │  " + line + "  │"
+	inner := 0
+	for _, l := range append([]string{title}, lines...) {
+		inner = max(inner, Width(l))
+	}
+	inner = min(inner, max(width-8, 10))
+
+	edge := func(s string) string { return Brand.Sprint(s) }
+	row := func(s string) string {
+		s = Clip(s, inner)
+		return "  " + edge(b[5]) + "  " + s + strings.Repeat(" ", inner-Width(s)) + "  " + edge(b[5]) + "\n"
+	}
+	rule := strings.Repeat(b[4], inner+4)
+
+	var sb strings.Builder
+	sb.WriteString("  " + edge(b[0]+rule+b[1]) + "\n")
+	if title != "" {
+		sb.WriteString(row(title))
+		if len(lines) > 0 {
+			divider := "├" + rule + "┤"
+			if style == BoxDouble {
+				divider = "╠" + rule + "╣"
+			}
+			sb.WriteString("  " + edge(divider) + "\n")
+		}
+	}
+	for _, l := range lines {
+		sb.WriteString(row(l))
+	}
+	sb.WriteString("  " + edge(b[2]+rule+b[3]) + "\n")
+	return sb.String()
+}
+
+// TermWidth returns the terminal width from $COLUMNS, or 80.
+func TermWidth() int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	return 80
+}
+
+// Width is the number of terminal columns s takes: color codes take none,
+// and emoji and East Asian wide characters take two.
+func Width(s string) int {
+	w, prev := 0, 0
+	for _, r := range stripANSI(s) {
+		rw := runeWidth(r)
+		// An emoji selector widens the symbol before it
+		if r == 0xFE0F && prev == 1 {
+			rw = 1
+		}
+		w += rw
+		prev = rw
+	}
+	return w
+}
+
+// Clip cuts s to at most width columns, ending it with "…" when cut. Color
+// codes are kept, and reset after the cut.
+func Clip(s string, width int) string {
+	if Width(s) <= width {
+		return s
+	}
+	if width <= 0 {
+		return ""
+	}
+	var sb strings.Builder
+	w, colored := 0, false
+	for i := 0; i < len(s); {
+		if n := ansiLen(s[i:]); n > 0 {
+			sb.WriteString(s[i : i+n])
+			colored = true
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		rw := runeWidth(r)
+		if w+rw > width-1 {
+			break
+		}
+		sb.WriteString(s[i : i+size])
+		w += rw
+		i += size
+	}
+	sb.WriteString("…")
+	if colored {
+		sb.WriteString("\x1b[0m")
+	}
+	return sb.String()
+}
+
+// ansiLen is the length of the escape sequence s starts with, or 0.
+func ansiLen(s string) int {
+	if len(s) < 2 || s[0] != 0x1b || s[1] != '[' {
+		return 0
+	}
+	for i := 2; i < len(s); i++ {
+		if c := s[i]; c >= 0x40 && c <= 0x7e {
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+func stripANSI(s string) string {
+	if !strings.Contains(s, "\x1b[") {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); {
+		if n := ansiLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		sb.WriteByte(s[i])
+		i++
+	}
+	return sb.String()
+}
+
+func runeWidth(r rune) int {
+	switch {
+	case r == 0x200D, r >= 0xFE00 && r <= 0xFE0F, unicode.Is(unicode.Mn, r), unicode.IsControl(r):
+		return 0
+	case r >= 0x1100 && r <= 0x115F, r >= 0x2E80 && r <= 0xA4CF, r >= 0xAC00 && r <= 0xD7A3,
+		r >= 0xF900 && r <= 0xFAFF, r >= 0xFE30 && r <= 0xFE4F, r >= 0xFF00 && r <= 0xFF60,
+		r >= 0xFFE0 && r <= 0xFFE6, r >= 0x1F300 && r <= 0x1F64F, r >= 0x1F680 && r <= 0x1F6FF,
+		r >= 0x1F900 && r <= 0x1FAFF, r >= 0x20000 && r <= 0x3FFFD:
+		return 2
+	case wideSymbols[r]:
+		return 2
+	}
+	return 1
+}
+
+// wideSymbols are the characters below the emoji blocks that terminals
+// draw as emoji, two columns wide.
+var wideSymbols = map[rune]bool{
+	0x231A: true, 0x231B: true, 0x23E9: true, 0x23EA: true, 0x23EB: true, 0x23EC: true,
+	0x23F0: true, 0x23F3: true, 0x25FD: true, 0x25FE: true, 0x2614: true, 0x2615: true,
+	0x267F: true, 0x2693: true, 0x26A1: true, 0x26AA: true, 0x26AB: true, 0x26BD: true,
+	0x26BE: true, 0x26C4: true, 0x26C5: true, 0x26CE: true, 0x26D4: true, 0x26EA: true,
+	0x26F2: true, 0x26F3: true, 0x26F5: true, 0x26FA: true, 0x26FD: true, 0x2705: true,
+	0x270A: true, 0x270B: true, 0x2728: true, 0x274C: true, 0x274E: true, 0x2753: true,
+	0x2754: true, 0x2755: true, 0x2757: true, 0x2795: true, 0x2796: true, 0x2797: true,
+	0x27B0: true, 0x27BF: true, 0x2B1B: true, 0x2B1C: true, 0x2B50: true, 0x2B55: true,
+}
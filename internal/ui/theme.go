@@ -0,0 +1,225 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/fatih/color"
+)
+
+// Palette assigns a color spec to each of palm's color roles. A spec is a
+// space-separated list of a color name (red, hi-green, ...) or #rrggbb and
+// any of bold, dim, italic and underline, like "hi-green bold". An empty
+// spec prints in the terminal's own foreground.
+type Palette struct {
+	Brand  string `toml:"brand" json:"brand"`
+	Subtle string `toml:"subtle" json:"subtle"`
+	Warn   string `toml:"warn" json:"warn"`
+	Info   string `toml:"info" json:"info"`
+	Good   string `toml:"good" json:"good"`
+	Bad    string `toml:"bad" json:"bad"`
+}
+
+// DefaultTheme is the theme used when none is configured.
+const DefaultTheme = "default"
+
+// Themes are the built-in palettes.
+var Themes = map[string]Palette{
+	// Bright colors for dark terminals
+	"default": {Brand: "hi-green bold", Subtle: "hi-black", Warn: "yellow", Info: "cyan", Good: "green", Bad: "red"},
+	// Darker colors that stay readable on white backgrounds
+	"light": {Brand: "green bold", Subtle: "hi-black", Warn: "magenta", Info: "blue", Good: "green", Bad: "red"},
+	// No dim grays; everything colored is bold
+	"high-contrast": {Brand: "green bold underline", Subtle: "", Warn: "yellow bold", Info: "cyan bold", Good: "green bold", Bad: "red bold"},
+}
+
+// ThemeNames lists the built-in themes and the custom ones in dir, sorted.
+func ThemeNames(dir string) []string {
+	names := make([]string, 0, len(Themes))
+	for name := range Themes {
+		names = append(names, name)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.toml"))
+	for _, f := range files {
+		name := strings.TrimSuffix(filepath.Base(f), ".toml")
+		if _, builtin := Themes[name]; !builtin {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ThemeFile is where the custom theme name is read from in dir.
+func ThemeFile(dir, name string) string {
+	return filepath.Join(dir, name+".toml")
+}
+
+// customTheme is the contents of a theme file: a built-in base, default
+// unless set, with any roles replaced.
+type customTheme struct {
+	Base string `toml:"base"`
+	Palette
+}
+
+// LoadTheme returns the palette of a built-in theme or of the custom theme
+// file name.toml in dir.
+func LoadTheme(dir, name string) (Palette, error) {
+	if name == "" {
+		name = DefaultTheme
+	}
+	if p, ok := Themes[name]; ok {
+		return p, nil
+	}
+	data, err := os.ReadFile(ThemeFile(dir, name))
+	if os.IsNotExist(err) {
+		return Palette{}, fmt.Errorf("unknown theme %q", name)
+	}
+	if err != nil {
+		return Palette{}, err
+	}
+
+	var custom customTheme
+	md, err := toml.Decode(string(data), &custom)
+	if err != nil {
+		return Palette{}, fmt.Errorf("theme %s: %w", name, err)
+	}
+	if custom.Base == "" {
+		custom.Base = DefaultTheme
+	}
+	p, ok := Themes[custom.Base]
+	if !ok {
+		return Palette{}, fmt.Errorf("theme %s: unknown base %q", name, custom.Base)
+	}
+	// Roles the file sets replace the base's, even with an empty spec
+	for role, spec := range map[string]string{
+		"brand": custom.Brand, "subtle": custom.Subtle, "warn": custom.Warn,
+		"info": custom.Info, "good": custom.Good, "bad": custom.Bad,
+	} {
+		if md.IsDefined(role) {
+			p.set(role, spec)
+		}
+	}
+	if err := p.Validate(); err != nil {
+		return Palette{}, fmt.Errorf("theme %s: %w", name, err)
+	}
+	return p, nil
+}
+
+func (p *Palette) set(role, spec string) {
+	switch role {
+	case "brand":
+		p.Brand = spec
+	case "subtle":
+		p.Subtle = spec
+	case "warn":
+		p.Warn = spec
+	case "info":
+		p.Info = spec
+	case "good":
+		p.Good = spec
+	case "bad":
+		p.Bad = spec
+	}
+}
+
+// role pairs a palette spec with the color it sets.
+type role struct {
+	name  string
+	spec  string
+	color *color.Color
+}
+
+func (p Palette) roles() []role {
+	return []role{
+		{"brand", p.Brand, Brand}, {"subtle", p.Subtle, Subtle}, {"warn", p.Warn, Warn},
+		{"info", p.Info, Info}, {"good", p.Good, Good}, {"bad", p.Bad, Bad},
+	}
+}
+
+// Validate checks every spec in p.
+func (p Palette) Validate() error {
+	for _, r := range p.roles() {
+		if _, err := ParseColor(r.spec); err != nil {
+			return fmt.Errorf("%s: %w", r.name, err)
+		}
+	}
+	return nil
+}
+
+// Apply recolors Brand, Subtle, Warn, Info, Good and Bad. They change in
+// place, so colors other packages took from them follow the theme too.
+func (p Palette) Apply() error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	for _, r := range p.roles() {
+		c, _ := ParseColor(r.spec)
+		*r.color = *c
+	}
+	return nil
+}
+
+var colorNames = map[string]color.Attribute{
+	"black": color.FgBlack, "red": color.FgRed, "green": color.FgGreen, "yellow": color.FgYellow,
+	"blue": color.FgBlue, "magenta": color.FgMagenta, "cyan": color.FgCyan, "white": color.FgWhite,
+}
+
+var styleNames = map[string]color.Attribute{
+	"bold": color.Bold, "dim": color.Faint, "italic": color.Italic, "underline": color.Underline,
+}
+
+// ParseColor turns a spec like "hi-green bold" or "#ff8800 underline" into
+// a color.
+func ParseColor(spec string) (*color.Color, error) {
+	c := color.New()
+	colored := false
+	for _, word := range strings.Fields(strings.ToLower(spec)) {
+		if a, ok := styleNames[word]; ok {
+			c.Add(a)
+			continue
+		}
+		if colored {
+			return nil, fmt.Errorf("%q: more than one color", spec)
+		}
+		colored = true
+		if hex, ok := strings.CutPrefix(word, "#"); ok {
+			n, err := strconv.ParseUint(hex, 16, 32)
+			if err != nil || len(hex) != 6 {
+				return nil, fmt.Errorf("%q: colors in hex are #rrggbb", spec)
+			}
+			c.AddRGB(int(n>>16), int(n>>8&0xff), int(n&0xff))
+			continue
+		}
+		name, hi := strings.CutPrefix(word, "hi-")
+		a, ok := colorNames[name]
+		if !ok {
+			return nil, fmt.Errorf("%q: unknown color or style %q", spec, word)
+		}
+		if hi {
+			a += color.FgHiBlack - color.FgBlack
+		}
+		c.Add(a)
+	}
+	return c, nil
+}
+
+// Swatch shows the roles of p in their own colors, for theme listings.
+// It doesn't change the current colors.
+func (p Palette) Swatch() string {
+	var parts []string
+	for _, r := range p.roles() {
+		c, err := ParseColor(r.spec)
+		if err != nil {
+			parts = append(parts, Bad.Sprint(r.name+"?"))
+			continue
+		}
+		parts = append(parts, c.Sprint(r.name))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,105 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestParseColor(t *testing.T) {
+	for _, spec := range []string{"", "red", "hi-green bold", "#ff8800 underline", "Bold Cyan"} {
+		if _, err := ParseColor(spec); err != nil {
+			t.Errorf("ParseColor(%q): %v", spec, err)
+		}
+	}
+	for _, spec := range []string{"purple", "red blue", "#ff88", "#gg0000"} {
+		if _, err := ParseColor(spec); err == nil {
+			t.Errorf("ParseColor(%q) should fail", spec)
+		}
+	}
+	for name, p := range Themes {
+		if err := p.Validate(); err != nil {
+			t.Errorf("built-in theme %s: %v", name, err)
+		}
+	}
+}
+
+func TestLoadCustomTheme(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) {
+		if err := os.WriteFile(filepath.Join(dir, name+".toml"), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("mine", "base = \"light\"\nbrand = \"#268bd2 bold\"\nsubtle = \"\"\n")
+	write("broken", "warn = \"chartreuse\"\n")
+
+	p, err := LoadTheme(dir, "mine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	light := Themes["light"]
+	if p.Brand != "#268bd2 bold" || p.Subtle != "" || p.Warn != light.Warn {
+		t.Errorf("custom roles should override the base only where set: %+v", p)
+	}
+	if _, err := LoadTheme(dir, "broken"); err == nil || !strings.Contains(err.Error(), "warn") {
+		t.Errorf("a bad spec should name its role, got %v", err)
+	}
+	if _, err := LoadTheme(dir, "missing"); err == nil {
+		t.Error("an unknown theme should be an error")
+	}
+	if got := strings.Join(ThemeNames(dir), ","); got != "broken,default,high-contrast,light,mine" {
+		t.Errorf("ThemeNames = %s", got)
+	}
+}
+
+func TestWidthAndClip(t *testing.T) {
+	orig := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = orig }()
+
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"palm", 4},
+		{Good.Sprint("ok"), 2},
+		{"🌴 palm", 7},
+		{"⚠️ low", 6},
+		{"日本", 4},
+		{"✓ done", 6},
+	}
+	for _, tt := range tests {
+		if got := Width(tt.s); got != tt.want {
+			t.Errorf("Width(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+
+	if got := Clip("hello world", 6); got != "hello…" {
+		t.Errorf("Clip = %q", got)
+	}
+	clipped := Clip(Bad.Sprint("日本語テキスト"), 6)
+	if Width(clipped) > 6 || !strings.HasSuffix(clipped, "…\x1b[0m") {
+		t.Errorf("Clip should keep colors, reset them and fit: %q", clipped)
+	}
+}
+
+func TestRenderBoxAligns(t *testing.T) {
+	orig := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = orig }()
+
+	out := RenderBox(BoxSingle, "🌴 "+Brand.Sprint("palm"), []string{"", Good.Sprint("✓ fine"), strings.Repeat("x", 200)}, 60)
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != 7 {
+		t.Fatalf("expected 7 rows, got %d:\n%s", len(lines), out)
+	}
+	for _, l := range lines {
+		if w := Width(l); w != Width(lines[0]) || w > 60 {
+			t.Errorf("row %q is %d columns, the border is %d", l, w, Width(lines[0]))
+		}
+	}
+}
@@ -2,6 +2,7 @@ package ui
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/fatih/color"
@@ -30,15 +31,16 @@ func Table(headers []string, rows [][]string) {
 		return
 	}
 
-	// Calculate column widths
+	// Calculate column widths in terminal columns, so colored cells and
+	// emoji don't throw the columns off
 	widths := make([]int, len(headers))
 	for i, h := range headers {
-		widths[i] = len(h)
+		widths[i] = Width(h)
 	}
 	for _, row := range rows {
 		for i, cell := range row {
-			if i < len(widths) && len(cell) > widths[i] {
-				widths[i] = len(cell)
+			if i < len(widths) {
+				widths[i] = max(widths[i], Width(cell))
 			}
 		}
 	}
@@ -47,7 +49,7 @@ func Table(headers []string, rows [][]string) {
 	headerLine := "  "
 	sepLine := "  "
 	for i, h := range headers {
-		headerLine += fmt.Sprintf("%-*s  ", widths[i], h)
+		headerLine += pad(h, widths[i]) + "  "
 		sepLine += strings.Repeat("\u2500", widths[i]) + "  "
 	}
 	Subtle.Println(headerLine)
@@ -58,13 +60,18 @@ func Table(headers []string, rows [][]string) {
 		line := "  "
 		for i, cell := range row {
 			if i < len(widths) {
-				line += fmt.Sprintf("%-*s  ", widths[i], cell)
+				line += pad(cell, widths[i]) + "  "
 			}
 		}
 		fmt.Println(line)
 	}
 }
 
+// pad fills s with spaces to width terminal columns.
+func pad(s string, width int) string {
+	return s + strings.Repeat(" ", max(0, width-Width(s)))
+}
+
 // StatusIcon returns a status icon string.
 func StatusIcon(ok bool) string {
 	if ok {
@@ -77,3 +84,42 @@ func StatusIcon(ok bool) string {
 func WarnIcon() string {
 	return Warn.Sprint("\u26A0")
 }
+
+// IsTerminal reports whether stdout is an interactive terminal.
+func IsTerminal() bool {
+	fi, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// ToStderr sends everything printed to stdout from here on, colored output
+// included, to stderr, so stdout can carry machine-readable output alone.
+// It returns the real stdout.
+func ToStderr() *os.File {
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	color.Output = os.Stderr
+	return stdout
+}
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline draws values as a row of block heights scaled to the largest.
+// Zero values are blank.
+func Sparkline(values []float64) string {
+	var top float64
+	for _, v := range values {
+		top = max(top, v)
+	}
+	var b strings.Builder
+	for _, v := range values {
+		if top == 0 || v <= 0 {
+			b.WriteRune(' ')
+			continue
+		}
+		b.WriteRune(sparks[min(int(v/top*float64(len(sparks))), len(sparks)-1)])
+	}
+	return b.String()
+}
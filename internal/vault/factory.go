@@ -2,12 +2,14 @@ package vault
 
 import "runtime"
 
-// New returns the best available vault for the current platform.
-// On macOS, it uses the system Keychain. On other platforms, it falls
-// back to an AES-256-GCM encrypted file at ~/.config/palm/vault.enc.
+// New returns the best available vault for the current platform, showing
+// the keys of the active profile. On macOS, it uses the system Keychain. On
+// other platforms, it falls back to an AES-256-GCM encrypted file at
+// ~/.config/palm/vault.enc. Keys stored as references to an external
+// secret manager are resolved by Get.
 func New() Vault {
 	if runtime.GOOS == "darwin" {
-		return NewKeychain()
+		return Resolving(WithProfile(NewKeychain(), activeProfile))
 	}
-	return NewFileVault()
+	return Resolving(WithProfile(NewFileVault(), activeProfile))
 }
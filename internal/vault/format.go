@@ -0,0 +1,43 @@
+package vault
+
+import (
+	"fmt"
+	"strings"
+)
+
+// keyPrefixes lists the documented prefixes of well-known provider keys.
+var keyPrefixes = map[string][]string{
+	"OPENAI_API_KEY":      {"sk-"},
+	"ANTHROPIC_API_KEY":   {"sk-ant-"},
+	"GROQ_API_KEY":        {"gsk_"},
+	"GOOGLE_API_KEY":      {"AIza"},
+	"GEMINI_API_KEY":      {"AIza"},
+	"OPENROUTER_API_KEY":  {"sk-or-"},
+	"XAI_API_KEY":         {"xai-"},
+	"PERPLEXITY_API_KEY":  {"pplx-"},
+	"REPLICATE_API_TOKEN": {"r8_"},
+	"HF_TOKEN":            {"hf_"},
+	"HUGGINGFACE_TOKEN":   {"hf_"},
+	"GITHUB_TOKEN":        {"ghp_", "gho_", "ghu_", "ghs_", "github_pat_"},
+}
+
+// CheckFormat reports obvious mistakes in a key value before it is stored:
+// stray whitespace, implausibly short values, or the wrong provider prefix.
+func CheckFormat(name, value string) error {
+	if strings.ContainsAny(value, " \t\r\n") {
+		return fmt.Errorf("value contains whitespace")
+	}
+	if len(value) < 16 {
+		return fmt.Errorf("value is too short to be an API key")
+	}
+	prefixes, ok := keyPrefixes[name]
+	if !ok {
+		return nil
+	}
+	for _, p := range prefixes {
+		if strings.HasPrefix(value, p) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s keys start with %s", name, strings.Join(prefixes, " or "))
+}
@@ -0,0 +1,228 @@
+package vault
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/msalah0e/palm/internal/fileutil"
+)
+
+// KeyMeta tracks the lifecycle of a vault key. It never holds the secret.
+type KeyMeta struct {
+	Name     string               `json:"name"`
+	AddedAt  time.Time            `json:"added_at"`
+	LastUsed time.Time            `json:"last_used,omitempty"`
+	UsedBy   map[string]time.Time `json:"used_by,omitempty"` // consumer → last use
+}
+
+// UseGranularity limits how often repeated uses are written to disk, so the
+// proxy does not rewrite the metadata file on every request.
+const UseGranularity = time.Minute
+
+func metaPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "palm", "keys_meta.json")
+}
+
+// LoadMeta returns the metadata of every tracked key in the active profile,
+// keyed by name.
+func LoadMeta() (map[string]*KeyMeta, error) {
+	all, err := loadAllMeta()
+	if err != nil {
+		return nil, err
+	}
+	meta := make(map[string]*KeyMeta)
+	for stored, m := range all {
+		if name, profile := SplitProfile(stored); profile == activeProfile {
+			meta[name] = m
+		}
+	}
+	return meta, nil
+}
+
+// loadAllMeta returns the metadata of every profile, keyed by stored name.
+func loadAllMeta() (map[string]*KeyMeta, error) {
+	meta := make(map[string]*KeyMeta)
+	data, err := os.ReadFile(metaPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return meta, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// saveMeta writes meta through a temporary file, so readers never see a
+// half-written file.
+func saveMeta(meta map[string]*KeyMeta) error {
+	path := metaPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".keys_meta-*.json")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// metaMu and the lock file next to keys_meta.json serialize updates within
+// and across palm processes, so concurrent updates aren't lost.
+var metaMu sync.Mutex
+
+// updateMeta applies fn to the stored metadata under lock and saves it when
+// fn reports a change.
+func updateMeta(fn func(meta map[string]*KeyMeta) bool) error {
+	metaMu.Lock()
+	defer metaMu.Unlock()
+	unlock, err := fileutil.Lock(metaPath())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	meta, err := loadAllMeta()
+	if err != nil {
+		return err
+	}
+	if !fn(meta) {
+		return nil
+	}
+	return saveMeta(meta)
+}
+
+// RecordSet marks a key as added (or rotated) now.
+func RecordSet(name string) error {
+	return updateMeta(func(meta map[string]*KeyMeta) bool {
+		stored := ProfileKey(name, activeProfile)
+		m, ok := meta[stored]
+		if !ok {
+			m = &KeyMeta{Name: name}
+			meta[stored] = m
+		}
+		m.AddedAt = time.Now()
+		return true
+	})
+}
+
+// RecordDelete forgets a removed key.
+func RecordDelete(name string) error {
+	return updateMeta(func(meta map[string]*KeyMeta) bool {
+		stored := ProfileKey(name, activeProfile)
+		if _, ok := meta[stored]; !ok {
+			return false
+		}
+		delete(meta, stored)
+		return true
+	})
+}
+
+// RecordUse notes that consumer (a tool, "proxy:openai", ...) was given keys.
+func RecordUse(consumer string, names ...string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	return updateMeta(func(meta map[string]*KeyMeta) bool {
+		now := time.Now()
+		changed := false
+		for _, name := range names {
+			stored := ProfileKey(name, activeProfile)
+			m, ok := meta[stored]
+			if !ok {
+				m = &KeyMeta{Name: name}
+				meta[stored] = m
+			}
+			if m.UsedBy == nil {
+				m.UsedBy = make(map[string]time.Time)
+			}
+			if now.Sub(m.UsedBy[consumer]) < UseGranularity {
+				continue
+			}
+			m.UsedBy[consumer] = now
+			m.LastUsed = now
+			changed = true
+		}
+		return changed
+	})
+}
+
+// KeyAudit is the audit verdict for one vault key.
+type KeyAudit struct {
+	Name        string               `json:"name"`
+	AddedAt     *time.Time           `json:"added_at,omitempty"` // nil when added before tracking
+	AgeDays     int                  `json:"age_days,omitempty"`
+	LastUsed    *time.Time           `json:"last_used,omitempty"`
+	UsedBy      map[string]time.Time `json:"used_by,omitempty"`
+	Stale       bool                 `json:"stale"`
+	RotationDue bool                 `json:"rotation_due"`
+	RotateSoon  bool                 `json:"rotate_soon"`
+}
+
+// rotationWarning is how far ahead of the deadline a rotation is flagged.
+const rotationWarning = 14 * 24 * time.Hour
+
+// Audit evaluates each key against the stale and rotation windows. A zero
+// rotation window disables rotation checks.
+func Audit(keys []string, meta map[string]*KeyMeta, now time.Time, staleAfter, rotateEvery time.Duration) []KeyAudit {
+	var result []KeyAudit
+	for _, name := range keys {
+		a := KeyAudit{Name: name}
+		m := meta[name]
+		if m != nil {
+			if !m.AddedAt.IsZero() {
+				added := m.AddedAt
+				a.AddedAt = &added
+				a.AgeDays = int(now.Sub(added).Hours() / 24)
+			}
+			if !m.LastUsed.IsZero() {
+				last := m.LastUsed
+				a.LastUsed = &last
+			}
+			a.UsedBy = m.UsedBy
+		}
+
+		// Stale: not used within the window, and old enough to have been
+		if staleAfter > 0 {
+			switch {
+			case a.LastUsed != nil:
+				a.Stale = now.Sub(*a.LastUsed) > staleAfter
+			case a.AddedAt != nil:
+				a.Stale = now.Sub(*a.AddedAt) > staleAfter
+			}
+		}
+
+		if rotateEvery > 0 && a.AddedAt != nil {
+			age := now.Sub(*a.AddedAt)
+			a.RotationDue = age >= rotateEvery
+			a.RotateSoon = !a.RotationDue && age >= rotateEvery-rotationWarning
+		}
+		result = append(result, a)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
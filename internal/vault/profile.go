@@ -0,0 +1,126 @@
+package vault
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Keys in a named profile are stored as NAME@profile, so work and personal
+// credentials for the same provider live side by side. Keys without a
+// suffix make up the default profile.
+const profileSep = "@"
+
+var profileName = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// activeProfile is the profile New opens, set once at startup.
+var activeProfile string
+
+// SetProfile selects the profile New opens; "" is the default profile.
+func SetProfile(name string) error {
+	if err := ValidateProfile(name); err != nil {
+		return err
+	}
+	activeProfile = name
+	return nil
+}
+
+// Profile returns the active profile, "" for the default one.
+func Profile() string {
+	return activeProfile
+}
+
+// ValidateProfile checks that name can be used as a profile name.
+func ValidateProfile(name string) error {
+	if name != "" && !profileName.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q (use letters, digits, '.', '_' and '-')", name)
+	}
+	return nil
+}
+
+// ProfileKey returns the name a key is stored under in profile.
+func ProfileKey(name, profile string) string {
+	if profile == "" {
+		return name
+	}
+	return name + profileSep + profile
+}
+
+// SplitProfile splits a stored key name into the key and its profile.
+func SplitProfile(stored string) (name, profile string) {
+	name, profile, _ = strings.Cut(stored, profileSep)
+	return name, profile
+}
+
+// Profiles lists the named profiles that hold at least one key in v.
+func Profiles(v Vault) ([]string, error) {
+	if r, ok := v.(*resolvingVault); ok {
+		v = r.Vault
+	}
+	if p, ok := v.(*profileVault); ok {
+		v = p.base
+	}
+	stored, err := v.List()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var profiles []string
+	for _, s := range stored {
+		if _, profile := SplitProfile(s); profile != "" && !seen[profile] {
+			seen[profile] = true
+			profiles = append(profiles, profile)
+		}
+	}
+	sort.Strings(profiles)
+	return profiles, nil
+}
+
+// WithProfile returns a view of v that only sees the keys of profile. There
+// is no fallback to the default profile: a key missing from a work profile
+// is missing, rather than quietly taken from personal credentials.
+func WithProfile(v Vault, profile string) Vault {
+	if r, ok := v.(*resolvingVault); ok {
+		return Resolving(WithProfile(r.Vault, profile))
+	}
+	if p, ok := v.(*profileVault); ok {
+		v = p.base
+	}
+	return &profileVault{base: v, profile: profile}
+}
+
+type profileVault struct {
+	base    Vault
+	profile string
+}
+
+func (p *profileVault) Set(key, value string) error {
+	return p.base.Set(ProfileKey(key, p.profile), value)
+}
+
+func (p *profileVault) Get(key string) (string, error) {
+	val, err := p.base.Get(ProfileKey(key, p.profile))
+	if err != nil && p.profile != "" {
+		return "", fmt.Errorf("key not found in profile %s: %s", p.profile, key)
+	}
+	return val, err
+}
+
+func (p *profileVault) Delete(key string) error {
+	return p.base.Delete(ProfileKey(key, p.profile))
+}
+
+func (p *profileVault) List() ([]string, error) {
+	stored, err := p.base.List()
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, s := range stored {
+		if name, profile := SplitProfile(s); profile == p.profile {
+			keys = append(keys, name)
+		}
+	}
+	return keys, nil
+}
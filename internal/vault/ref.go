@@ -0,0 +1,225 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// A vault entry can hold a reference to a secret kept elsewhere instead of
+// the secret itself, for organisations that forbid copies of keys:
+//
+//	op://vault/item/field   1Password, read with `op read`
+//	pass:openai/key         pass, the first line of `pass show`
+//	env:NAME                an environment variable of the palm process
+//
+// Only the reference is stored. It is resolved when the key is read, which
+// is when it is injected into a tool.
+const (
+	opPrefix   = "op://"
+	passPrefix = "pass:"
+	envPrefix  = "env:"
+)
+
+// resolveTimeout bounds a secret manager CLI, which may be waiting on an
+// unlock prompt.
+const resolveTimeout = 60 * time.Second
+
+var envRefName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// IsRef reports whether value is a reference to an external secret.
+func IsRef(value string) bool {
+	return RefSource(value) != ""
+}
+
+// RefSource names the secret manager a reference points to, or "" when
+// value is a plain secret.
+func RefSource(value string) string {
+	switch {
+	case strings.HasPrefix(value, opPrefix):
+		return "1Password"
+	case strings.HasPrefix(value, passPrefix):
+		return "pass"
+	case strings.HasPrefix(value, envPrefix):
+		return "env"
+	}
+	return ""
+}
+
+// CheckRef reports a malformed reference before it is stored.
+func CheckRef(value string) error {
+	switch RefSource(value) {
+	case "1Password":
+		if parts := strings.Split(strings.TrimPrefix(value, opPrefix), "/"); len(parts) < 3 || containsEmpty(parts) {
+			return fmt.Errorf("1Password references look like op://vault/item/field")
+		}
+	case "pass":
+		if path := strings.TrimPrefix(value, passPrefix); path == "" || strings.HasPrefix(path, "/") {
+			return fmt.Errorf("pass references look like pass:path/to/entry")
+		}
+	case "env":
+		if !envRefName.MatchString(strings.TrimPrefix(value, envPrefix)) {
+			return fmt.Errorf("env references look like env:VARIABLE_NAME")
+		}
+	default:
+		return fmt.Errorf("not a reference: use op://, pass: or env:")
+	}
+	return nil
+}
+
+func containsEmpty(parts []string) bool {
+	for _, p := range parts {
+		if p == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// refCacheTTL is how long a looked-up reference is reused, so a command
+// injecting keys into several tools unlocks each manager once, while a
+// long-running proxy still picks up rotated secrets.
+const refCacheTTL = 5 * time.Minute
+
+// resolved caches looked-up references as cachedRef values.
+var resolved sync.Map
+
+// lookups runs one secret manager CLI per reference at a time, so callers
+// arriving together share a lookup instead of each spawning op or pass.
+var lookups singleflight.Group
+
+type cachedRef struct {
+	secret string
+	at     time.Time
+}
+
+// Resolve returns the secret value refers to, or value itself when it is
+// not a reference. An expired lookup is still served while it is refreshed
+// in the background.
+func Resolve(value string) (string, error) {
+	source := RefSource(value)
+	if source == "" {
+		return value, nil
+	}
+	if err := CheckRef(value); err != nil {
+		return "", err
+	}
+	if source == "env" {
+		name := strings.TrimPrefix(value, envPrefix)
+		v, ok := os.LookupEnv(name)
+		if !ok || v == "" {
+			return "", fmt.Errorf("%s: $%s is not set", value, name)
+		}
+		return v, nil
+	}
+
+	if cached, ok := resolved.Load(value); ok {
+		c := cached.(cachedRef)
+		if time.Since(c.at) >= refCacheTTL {
+			go func() {
+				// A failed refresh drops the entry, so the next caller
+				// looks it up again and sees the error.
+				if _, err, _ := lookups.Do(value, func() (any, error) { return lookupRef(value, source) }); err != nil {
+					resolved.CompareAndDelete(value, cached)
+				}
+			}()
+		}
+		return c.secret, nil
+	}
+	secret, err, _ := lookups.Do(value, func() (any, error) { return lookupRef(value, source) })
+	if err != nil {
+		return "", err
+	}
+	return secret.(string), nil
+}
+
+// lookupRef reads an op:// or pass: reference from its manager and caches
+// the result.
+func lookupRef(value, source string) (string, error) {
+	var secret string
+	switch source {
+	case "1Password":
+		out, err := runSecretCLI("op", "read", value)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", value, err)
+		}
+		secret = out
+	case "pass":
+		out, err := runSecretCLI("pass", "show", strings.TrimPrefix(value, passPrefix))
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", value, err)
+		}
+		secret, _, _ = strings.Cut(out, "\n")
+	}
+
+	secret = strings.TrimSpace(secret)
+	if secret == "" {
+		return "", fmt.Errorf("%s: the %s entry is empty", value, source)
+	}
+	resolved.Store(value, cachedRef{secret: secret, at: time.Now()})
+	return secret, nil
+}
+
+func runSecretCLI(name string, args ...string) (string, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return "", fmt.Errorf("%s is not installed", name)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+
+	var stderr strings.Builder
+	c := exec.CommandContext(ctx, name, args...)
+	c.Stderr = &stderr
+	out, err := c.Output()
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("%s timed out after %s", name, resolveTimeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %s", name, msg)
+		}
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return string(out), nil
+}
+
+// Resolving returns a view of v whose Get resolves references. Everything
+// else, including what is stored, is left to v.
+func Resolving(v Vault) Vault {
+	if _, ok := v.(*resolvingVault); ok {
+		return v
+	}
+	return &resolvingVault{v}
+}
+
+type resolvingVault struct {
+	Vault
+}
+
+func (r *resolvingVault) Get(key string) (string, error) {
+	val, err := r.Vault.Get(key)
+	if err != nil {
+		return "", err
+	}
+	secret, err := Resolve(val)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", key, err)
+	}
+	return secret, nil
+}
+
+// Stored returns what v holds for key without resolving it: the reference
+// for a referenced key, the secret for any other.
+func Stored(v Vault, key string) (string, error) {
+	if r, ok := v.(*resolvingVault); ok {
+		v = r.Vault
+	}
+	return v.Get(key)
+}
@@ -1,9 +1,13 @@
 package vault
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestFileVault(t *testing.T) {
@@ -165,3 +169,302 @@ func TestNewFileVault_DefaultPath(t *testing.T) {
 		t.Errorf("expected %q, got %q", expected, v.path)
 	}
 }
+
+func TestCheckFormat(t *testing.T) {
+	valid := map[string]string{
+		"OPENAI_API_KEY":    "sk-proj-abcdefghijklmnop",
+		"ANTHROPIC_API_KEY": "sk-ant-api03-abcdefghijkl",
+		"CUSTOM_TOKEN":      "anything-long-enough-1234",
+	}
+	for name, val := range valid {
+		if err := CheckFormat(name, val); err != nil {
+			t.Errorf("CheckFormat(%s) unexpected error: %v", name, err)
+		}
+	}
+
+	invalid := map[string]string{
+		"ANTHROPIC_API_KEY": "sk-proj-abcdefghijklmnop",
+		"GROQ_API_KEY":      "sk-abcdefghijklmnopqrst",
+		"OPENAI_API_KEY":    "sk-abc def ghi jkl mno",
+		"CUSTOM_TOKEN":      "short",
+	}
+	for name, val := range invalid {
+		if err := CheckFormat(name, val); err == nil {
+			t.Errorf("CheckFormat(%s, %q) should fail", name, val)
+		}
+	}
+}
+
+func TestKeyMetaRecording(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	if err := RecordSet("OPENAI_API_KEY"); err != nil {
+		t.Fatalf("RecordSet failed: %v", err)
+	}
+	if err := RecordUse("aider", "OPENAI_API_KEY"); err != nil {
+		t.Fatalf("RecordUse failed: %v", err)
+	}
+	if err := RecordUse("proxy:openai", "OPENAI_API_KEY"); err != nil {
+		t.Fatalf("RecordUse failed: %v", err)
+	}
+
+	meta, err := LoadMeta()
+	if err != nil {
+		t.Fatalf("LoadMeta failed: %v", err)
+	}
+	m := meta["OPENAI_API_KEY"]
+	if m == nil || m.AddedAt.IsZero() || m.LastUsed.IsZero() {
+		t.Fatalf("expected added and used timestamps, got %+v", m)
+	}
+	if len(m.UsedBy) != 2 {
+		t.Errorf("expected 2 consumers, got %v", m.UsedBy)
+	}
+
+	if err := RecordDelete("OPENAI_API_KEY"); err != nil {
+		t.Fatalf("RecordDelete failed: %v", err)
+	}
+	meta, _ = LoadMeta()
+	if _, ok := meta["OPENAI_API_KEY"]; ok {
+		t.Error("metadata should be removed with the key")
+	}
+}
+
+func TestKeyMetaConcurrentUpdates(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := RecordSet(fmt.Sprintf("KEY_%d", i)); err != nil {
+				t.Errorf("RecordSet failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	meta, err := LoadMeta()
+	if err != nil {
+		t.Fatalf("LoadMeta failed: %v", err)
+	}
+	if len(meta) != 20 {
+		t.Errorf("expected 20 keys, got %d", len(meta))
+	}
+}
+
+func TestRecordSetKeepsUnreadableMeta(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	path := metaPath()
+	os.MkdirAll(filepath.Dir(path), 0o700)
+	os.WriteFile(path, []byte("{not json"), 0o600)
+
+	if err := RecordSet("OPENAI_API_KEY"); err == nil {
+		t.Error("RecordSet should report unreadable metadata")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "{not json" {
+		t.Errorf("unreadable metadata should be left alone, got %q", data)
+	}
+}
+
+func TestAudit(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	meta := map[string]*KeyMeta{
+		"FRESH":    {AddedAt: now.Add(-10 * day), LastUsed: now.Add(-day)},
+		"UNUSED":   {AddedAt: now.Add(-120 * day)},
+		"OLD_USED": {AddedAt: now.Add(-200 * day), LastUsed: now.Add(-2 * day)},
+		"SOON":     {AddedAt: now.Add(-175 * day), LastUsed: now.Add(-day)},
+	}
+	keys := []string{"FRESH", "UNUSED", "OLD_USED", "SOON", "UNTRACKED"}
+
+	byName := make(map[string]KeyAudit)
+	for _, a := range Audit(keys, meta, now, 90*day, 180*day) {
+		byName[a.Name] = a
+	}
+
+	if a := byName["FRESH"]; a.Stale || a.RotationDue || a.RotateSoon {
+		t.Errorf("FRESH should be ok, got %+v", a)
+	}
+	if !byName["UNUSED"].Stale {
+		t.Error("UNUSED should be stale")
+	}
+	if a := byName["OLD_USED"]; a.Stale || !a.RotationDue {
+		t.Errorf("OLD_USED should be due for rotation but not stale, got %+v", a)
+	}
+	if !byName["SOON"].RotateSoon {
+		t.Error("SOON should be flagged for upcoming rotation")
+	}
+	if a := byName["UNTRACKED"]; a.AddedAt != nil || a.Stale || a.RotationDue {
+		t.Errorf("UNTRACKED has no metadata to judge, got %+v", a)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	base := &FileVault{path: filepath.Join(t.TempDir(), "vault.enc"), key: deriveKey()}
+	personal := WithProfile(base, "")
+	work := WithProfile(base, "work")
+
+	_ = personal.Set("OPENAI_API_KEY", "sk-personal")
+	_ = work.Set("OPENAI_API_KEY", "sk-work")
+	_ = work.Set("ANTHROPIC_API_KEY", "sk-ant-work")
+	_ = WithProfile(base, "client.b").Set("GROQ_API_KEY", "gsk-b")
+
+	if val, _ := personal.Get("OPENAI_API_KEY"); val != "sk-personal" {
+		t.Errorf("default profile got %q", val)
+	}
+	if val, _ := work.Get("OPENAI_API_KEY"); val != "sk-work" {
+		t.Errorf("work profile got %q", val)
+	}
+	// No fallback between profiles
+	if _, err := personal.Get("ANTHROPIC_API_KEY"); err == nil {
+		t.Error("default profile must not see work keys")
+	}
+	if _, err := work.Get("GROQ_API_KEY"); err == nil {
+		t.Error("work profile must not see other profiles' keys")
+	}
+
+	if keys, _ := personal.List(); len(keys) != 1 || keys[0] != "OPENAI_API_KEY" {
+		t.Errorf("default List = %v", keys)
+	}
+	if keys, _ := work.List(); len(keys) != 2 || keys[0] != "ANTHROPIC_API_KEY" {
+		t.Errorf("work List = %v", keys)
+	}
+	if profiles, _ := Profiles(work); len(profiles) != 2 || profiles[0] != "client.b" || profiles[1] != "work" {
+		t.Errorf("Profiles = %v", profiles)
+	}
+
+	if err := work.Delete("OPENAI_API_KEY"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := personal.Get("OPENAI_API_KEY"); err != nil {
+		t.Error("deleting a work key must leave the personal one")
+	}
+
+	for _, bad := range []string{"a b", "x@y", "../etc"} {
+		if err := ValidateProfile(bad); err == nil {
+			t.Errorf("ValidateProfile(%q) should fail", bad)
+		}
+	}
+}
+
+func TestKeyMetaProfiles(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer SetProfile("")
+
+	_ = RecordSet("OPENAI_API_KEY")
+	if err := SetProfile("work"); err != nil {
+		t.Fatal(err)
+	}
+	_ = RecordUse("aider", "OPENAI_API_KEY")
+
+	meta, _ := LoadMeta()
+	if m := meta["OPENAI_API_KEY"]; m == nil || !m.AddedAt.IsZero() || m.UsedBy["aider"].IsZero() {
+		t.Errorf("work metadata = %+v", m)
+	}
+
+	_ = SetProfile("")
+	meta, _ = LoadMeta()
+	if m := meta["OPENAI_API_KEY"]; m == nil || m.AddedAt.IsZero() || len(m.UsedBy) != 0 {
+		t.Errorf("default metadata = %+v", m)
+	}
+}
+
+func TestResolveSharesLookups(t *testing.T) {
+	bin := t.TempDir()
+	calls := filepath.Join(bin, "calls")
+	os.WriteFile(filepath.Join(bin, "op"), []byte("#!/bin/sh\necho x >> "+calls+"\nsleep 0.2\necho sk-shared\n"), 0o755)
+	t.Setenv("PATH", bin+":"+os.Getenv("PATH"))
+
+	const ref = "op://Work/Shared/credential"
+	resolved.Delete(ref)
+	defer resolved.Delete(ref)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got, err := Resolve(ref); err != nil || got != "sk-shared" {
+				t.Errorf("Resolve = %q, %v", got, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if data, _ := os.ReadFile(calls); strings.Count(string(data), "x") != 1 {
+		t.Errorf("concurrent callers should share one op read, got %d", strings.Count(string(data), "x"))
+	}
+}
+
+func TestSecretRefs(t *testing.T) {
+	bin := t.TempDir()
+	os.WriteFile(filepath.Join(bin, "op"), []byte("#!/bin/sh\n[ \"$2\" = op://Work/OpenAI/credential ] && echo sk-from-1password && exit 0\necho \"[ERROR] item not found\" >&2; exit 1\n"), 0o755)
+	os.WriteFile(filepath.Join(bin, "pass"), []byte("#!/bin/sh\nprintf 'sk-from-pass\\nuser: me\\n'\n"), 0o755)
+	t.Setenv("PATH", bin)
+	t.Setenv("PALM_TEST_SECRET", "sk-from-env")
+	resolved.Range(func(k, _ any) bool {
+		resolved.Delete(k)
+		return true
+	})
+
+	base := &FileVault{path: filepath.Join(t.TempDir(), "vault.enc"), key: deriveKey()}
+	v := Resolving(WithProfile(base, "work"))
+	_ = v.Set("OPENAI_API_KEY", "op://Work/OpenAI/credential")
+	_ = v.Set("ANTHROPIC_API_KEY", "pass:anthropic/key")
+	_ = v.Set("GROQ_API_KEY", "env:PALM_TEST_SECRET")
+	_ = v.Set("XAI_API_KEY", "op://Work/Missing/credential")
+	_ = v.Set("HF_TOKEN", "hf_plainvalue")
+
+	for key, want := range map[string]string{
+		"OPENAI_API_KEY":    "sk-from-1password",
+		"ANTHROPIC_API_KEY": "sk-from-pass",
+		"GROQ_API_KEY":      "sk-from-env",
+		"HF_TOKEN":          "hf_plainvalue",
+	} {
+		if got, err := v.Get(key); err != nil || got != want {
+			t.Errorf("Get(%s) = %q, %v; want %q", key, got, err, want)
+		}
+	}
+	if _, err := v.Get("XAI_API_KEY"); err == nil || !strings.Contains(err.Error(), "item not found") {
+		t.Errorf("a failed lookup should carry the manager's error, got %v", err)
+	}
+
+	// Only the reference is stored
+	if stored, _ := Stored(v, "OPENAI_API_KEY"); stored != "op://Work/OpenAI/credential" {
+		t.Errorf("Stored = %q", stored)
+	}
+	if raw, _ := base.Get("OPENAI_API_KEY@work"); raw != "op://Work/OpenAI/credential" {
+		t.Errorf("the file vault holds %q", raw)
+	}
+	if profiles, _ := Profiles(v); len(profiles) != 1 || profiles[0] != "work" {
+		t.Errorf("Profiles should see through Resolving: %v", profiles)
+	}
+	if got, _ := WithProfile(v, "work").Get("GROQ_API_KEY"); got != "sk-from-env" {
+		t.Errorf("switching profile should keep resolving, got %q", got)
+	}
+
+	// Rotated secrets are picked up once the cached lookup expires
+	os.WriteFile(filepath.Join(bin, "pass"), []byte("#!/bin/sh\nprintf 'sk-rotated\\n'\n"), 0o755)
+	if got, _ := v.Get("ANTHROPIC_API_KEY"); got != "sk-from-pass" {
+		t.Errorf("a fresh lookup should be reused, got %q", got)
+	}
+	cached, _ := resolved.Load("pass:anthropic/key")
+	resolved.Store("pass:anthropic/key", cachedRef{secret: cached.(cachedRef).secret, at: time.Now().Add(-refCacheTTL)})
+	if got, _ := v.Get("ANTHROPIC_API_KEY"); got != "sk-from-pass" {
+		t.Errorf("an expired lookup should be served while it refreshes, got %q", got)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if got, _ := v.Get("ANTHROPIC_API_KEY"); got == "sk-rotated" {
+			break
+		} else if time.Now().After(deadline) {
+			t.Fatalf("an expired lookup should be resolved again, got %q", got)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for _, bad := range []string{"op://Work/item", "op://Work//field", "pass:", "env:1BAD", "sk-plain"} {
+		if err := CheckRef(bad); err == nil {
+			t.Errorf("CheckRef(%q) should fail", bad)
+		}
+	}
+}
@@ -10,8 +10,12 @@ import (
 //go:embed registry/*.toml
 var registryFS embed.FS
 
+//go:embed workflows/*.toml
+var workflowFS embed.FS
+
 func main() {
 	cmd.SetRegistryFS(registryFS)
+	cmd.SetWorkflowFS(workflowFS)
 	if err := cmd.Execute(); err != nil {
 		os.Exit(1)
 	}